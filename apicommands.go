@@ -0,0 +1,895 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/capture"
+	"github.com/ffuf/ffuf/v2/pkg/api/executor"
+	"github.com/ffuf/ffuf/v2/pkg/api/jobfile"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/reporting"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+// apiCommand is a command of the api command group
+type apiCommand struct {
+	name        string
+	description string
+	run         func(args []string) int
+}
+
+// apiCommands are the commands of the api command group
+var apiCommands = []apiCommand{
+	{"discover", "List the endpoints and parameters of API specifications", runAPIDiscover},
+	{"params", "Discover the hidden parameters of endpoints by bruteforcing their names", runAPIParams},
+	{"testgen", "Generate test cases for the endpoints of API specifications", runAPITestgen},
+	{"scan", "Scan the endpoints of API specifications with the security testers", runAPIScan},
+	{"payloads", "List and export the payloads of the injection tester by category", runAPIPayloads},
+	{"coverage", "Measure the coverage of API specifications by recorded traffic", runAPICoverage},
+	{"report", "Convert a JSON vulnerability report and compare it with a baseline", runAPIReport},
+	{"run", "Run the scan job declared in a YAML job file", runAPIJob},
+}
+
+// apiUsage prints the usage of the api command group
+func apiUsage() {
+	fmt.Fprintf(os.Stderr, "Fuzz Faster U Fool - v%s\n\n", ffuf.Version())
+	fmt.Fprintf(os.Stderr, "Usage: ffuf api <command> [options]\n\n")
+	fmt.Fprintf(os.Stderr, "COMMANDS:\n")
+	for _, command := range apiCommands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", command.name, command.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun ffuf api <command> -h for the options of a command.\n\n")
+}
+
+// runAPI runs a command of the api command group and returns the exit code
+func runAPI(args []string) int {
+	if len(args) == 0 {
+		apiUsage()
+		return 2
+	}
+	for _, command := range apiCommands {
+		if command.name == args[0] {
+			return command.run(args[1:])
+		}
+	}
+	if args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		apiUsage()
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "Encountered error(s): unknown api command %s\n", args[0])
+	apiUsage()
+	return 2
+}
+
+// apiFlags are the flags shared by the commands of the api command group
+type apiFlags struct {
+	specs   multiStringFlag
+	target  string
+	headers multiStringFlag
+	vars    string
+	output  string
+	format  string
+
+	// variables are the -vars variables, resolved in the requests of the commands
+	variables *parser.Variables
+}
+
+// newAPIFlagSet creates the flag set of an api command, printing its usage and examples
+func newAPIFlagSet(name, usage, description string, examples ...string) *flag.FlagSet {
+	flags := flag.NewFlagSet("ffuf api "+name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Fuzz Faster U Fool - v%s\n\n", ffuf.Version())
+		fmt.Fprintf(os.Stderr, "Usage: ffuf api %s %s\n\n", name, usage)
+		fmt.Fprintf(os.Stderr, "%s\n\n", description)
+		flags.PrintDefaults()
+		if len(examples) > 0 {
+			fmt.Fprintf(os.Stderr, "\nEXAMPLE USAGE:\n")
+			for i := 0; i+1 < len(examples); i += 2 {
+				fmt.Fprintf(os.Stderr, "  %s\n    %s\n\n", examples[i], examples[i+1])
+			}
+		}
+	}
+	return flags
+}
+
+// addSpecFlags adds the -spec, -target and -vars flags
+func (a *apiFlags) addSpecFlags(flags *flag.FlagSet) {
+	flags.Var(&a.specs, "spec", "OpenAPI/Swagger specification (JSON file or URL), Postman collection, HAR file or Burp Suite/OWASP ZAP export. Multiple flags are accepted.")
+	flags.StringVar(&a.target, "target", "", "Base URL replacing the scheme and host of the server URLs of the specifications")
+	flags.StringVar(&a.vars, "vars", "", "YAML, JSON or .env file of the variables replacing {{name}} placeholders in -spec, -target and -H. {{env.NAME}} is read from the environment")
+}
+
+// addOutputFlags adds the -o and -format flags
+func (a *apiFlags) addOutputFlags(flags *flag.FlagSet, format, formats string) {
+	flags.StringVar(&a.output, "o", "", "Write the output to a file. Default: standard output")
+	flags.StringVar(&a.format, "format", format, "Format of the output: "+formats)
+}
+
+// resolve replaces the {{name}} placeholders of the specs, target and headers with the
+// variables of the -vars file and the environment
+func (a *apiFlags) resolve() error {
+	variables := parser.NewVariables()
+	if a.vars != "" {
+		loaded, err := parser.LoadVariables(a.vars)
+		if err != nil {
+			return err
+		}
+		variables = loaded
+	}
+	for i, spec := range a.specs {
+		a.specs[i] = variables.Resolve(spec)
+	}
+	a.target = variables.Resolve(a.target)
+	for i, header := range a.headers {
+		a.headers[i] = variables.Resolve(header)
+	}
+	a.variables = variables
+	return nil
+}
+
+// discover imports the endpoints of the specs, on the target if it is set
+func (a *apiFlags) discover(client *http.Client) ([]*parser.APIEndpointDiscovery, error) {
+	if len(a.specs) == 0 {
+		return nil, fmt.Errorf("-spec is required")
+	}
+	discoveries := make([]*parser.APIEndpointDiscovery, 0, len(a.specs))
+	for _, spec := range a.specs {
+		discovery, err := discoverEndpoints(spec, client)
+		if err != nil {
+			return nil, fmt.Errorf("could not import the spec %s: %s", spec, err)
+		}
+		if a.target != "" {
+			retarget(discovery, a.target)
+		}
+		discoveries = append(discoveries, discovery)
+	}
+	return discoveries, nil
+}
+
+// retarget moves the endpoints of a spec to a target, keeping the path of its server URL
+func retarget(discovery *parser.APIEndpointDiscovery, target string) {
+	base := strings.TrimSuffix(target, "/")
+	if u, err := url.Parse(discovery.BaseURL); err == nil {
+		base += strings.TrimSuffix(u.Path, "/")
+	}
+	discovery.BaseURL = base
+	for _, endpoint := range discovery.GetEndpoints() {
+		endpoint.URL = base + endpoint.Path
+	}
+}
+
+// requestConfig returns the configuration of the requests sent by an api command, with the -H
+// headers
+func (a *apiFlags) requestConfig(proxy string, timeout int) (*ffuf.Config, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Timeout = timeout
+	conf.ProxyURL = proxy
+	for _, header := range a.headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 {
+			cancel()
+			return nil, fmt.Errorf("invalid header %s, expected \"Name: Value\"", header)
+		}
+		conf.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return &conf, nil
+}
+
+// writeAPIOutput writes the output of an api command to the -o file or standard output
+func writeAPIOutput(filename string, write func(w io.Writer) error) error {
+	if filename == "" {
+		return write(os.Stdout)
+	}
+	return writeCaptureFile(filename, write)
+}
+
+// runAPIDiscover runs the api discover command and returns the exit code
+func runAPIDiscover(args []string) int {
+	opts := apiFlags{}
+	flags := newAPIFlagSet("discover", "-spec openapi.json [options]",
+		"List the endpoints of API specifications, Postman collections, HAR files and proxy exports, with\ntheir parameters.",
+		"List the endpoints of a specification on a staging server.",
+		"ffuf api discover -spec openapi.json -target https://staging.example.com",
+		"Write the inventory of the endpoints of two specifications as JSON.",
+		"ffuf api discover -spec users.json -spec orders.json -format json -o inventory.json",
+		"List the riskiest endpoints first, with their risk score.",
+		"ffuf api discover -spec openapi.json -prioritize",
+		"Add the endpoints of undocumented versions and hosts of the API to the inventory.",
+		"ffuf api discover -spec openapi.json -shadow -H \"Authorization: Bearer {{env.TOKEN}}\" -format json -o inventory.json")
+	opts.addSpecFlags(flags)
+	opts.addOutputFlags(flags, "text", "text (a METHOD URL line per endpoint), json (inventory of the endpoints and their parameters)")
+	prioritize := flags.Bool("prioritize", false, "List the riskiest endpoints first, with their risk score and its factors: authentication, write methods, sensitive names, object identifiers and endpoints missing from specifications")
+	shadow := flags.Bool("shadow", false, "Find shadow APIs, the undocumented versions (e.g. v1 when v2 is documented) and hosts (e.g. api-staging) still serving the endpoints, and add their endpoints to the inventory. Sends GET requests")
+	flags.Var(&opts.headers, "H", "Header \"Name: Value\" added to the requests of -shadow. Multiple flags are accepted.")
+	proxy := flags.String("x", "", "Proxy URL (SOCKS5 or HTTP) of the requests of -shadow and of the specs fetched from URLs")
+	timeout := flags.Int("timeout", 10, "HTTP request timeout of -shadow in seconds")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if opts.format != "text" && opts.format != "json" {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -format must be text or json\n")
+		return 2
+	}
+	if err := opts.resolve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	discoveries, err := opts.discover(runner.NewHTTPClient(&ffuf.Config{ProxyURL: *proxy}, 30*time.Second))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	if *shadow {
+		conf, err := opts.requestConfig(*proxy, *timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+		prober := parser.NewShadowAPIProber(runner.NewSimpleRunner(conf, false))
+		for _, discovery := range discoveries {
+			for _, api := range discovery.DiscoverShadowAPIs(prober) {
+				fmt.Fprintf(os.Stderr, "Found the shadow API %s (%s %s of %s) with %d endpoint(s)\n", api.BaseURL, api.Kind, api.Version+api.Host, api.Documented, len(api.Endpoints))
+			}
+		}
+	}
+
+	endpoints := make([]*parser.DiscoveredEndpoint, 0)
+	for _, discovery := range discoveries {
+		endpoints = append(endpoints, discovery.GetEndpoints()...)
+	}
+	var risks []*parser.EndpointRisk
+	if *prioritize {
+		risks = parser.PrioritizeEndpoints(endpoints)
+		for i, risk := range risks {
+			endpoints[i] = risk.Endpoint
+		}
+	}
+
+	inventory := make([]*capture.InventoryEndpoint, 0, len(endpoints))
+	for i, endpoint := range endpoints {
+		item := inventoryEndpoint(endpoint)
+		if risks != nil {
+			item.RiskScore = risks[i].Score
+			item.RiskFactors = risks[i].Factors
+		}
+		inventory = append(inventory, item)
+	}
+
+	err = writeAPIOutput(opts.output, func(w io.Writer) error {
+		if opts.format == "json" {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(map[string]interface{}{"endpoints": inventory})
+		}
+		for _, endpoint := range inventory {
+			line := fmt.Sprintf("%s %s", endpoint.Method, endpoint.URL)
+			if endpoint.Source == "Shadow" {
+				line += " [shadow]"
+			}
+			if *prioritize {
+				line = fmt.Sprintf("%3d %s", endpoint.RiskScore, line)
+				if len(endpoint.RiskFactors) > 0 {
+					line += " (" + strings.Join(endpoint.RiskFactors, "; ") + ")"
+				}
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Discovered %d endpoint(s)\n", len(inventory))
+	return 0
+}
+
+// inventoryEndpoint returns the inventory entry of an endpoint and its parameters
+func inventoryEndpoint(endpoint *parser.DiscoveredEndpoint) *capture.InventoryEndpoint {
+	item := &capture.InventoryEndpoint{
+		Method:       endpoint.Method,
+		Path:         endpoint.Path,
+		URL:          endpoint.URL,
+		Parameters:   make([]capture.InventoryParameter, 0, len(endpoint.Parameters)),
+		StatusCodes:  []int{},
+		RequiresAuth: endpoint.RequiresAuth,
+		Source:       endpoint.Source,
+		Description:  endpoint.Description,
+	}
+	for _, param := range endpoint.Parameters {
+		item.Parameters = append(item.Parameters, capture.InventoryParameter{
+			Name:     param.Name,
+			In:       param.In,
+			Type:     param.Type,
+			Required: param.Required,
+			Example:  param.Example,
+		})
+	}
+	return item
+}
+
+// runAPITestgen runs the api testgen command and returns the exit code
+func runAPITestgen(args []string) int {
+	opts := apiFlags{}
+	var templates string
+	flags := newAPIFlagSet("testgen", "-spec openapi.json [options]",
+		"Generate positive, negative and security test cases for the endpoints of API specifications,\nPostman collections, HAR files and proxy exports.",
+		"Generate test cases for a specification and write them as a Postman collection.",
+		"ffuf api testgen -spec openapi.json -target https://staging.example.com -format postman -o tests.json",
+		"Run the generated test cases on a staging server.",
+		"ffuf api testgen -spec openapi.json -target https://staging.example.com -H \"Authorization: Bearer {{env.TOKEN}}\" -run")
+	opts.addSpecFlags(flags)
+	opts.addOutputFlags(flags, "json", "json (ffuf test cases), postman (Postman collection), curl (curl commands)")
+	flags.StringVar(&templates, "templates", "", "Directory of YAML or JSON test case templates added to the default templates")
+	run := flags.Bool("run", false, "Run the generated test cases, dependencies first with the values extracted from their responses passed to their dependents, and report the test cases that did not pass. The test cases are written only with -o")
+	flags.Var(&opts.headers, "H", "Header \"Name: Value\" added to the requests of -run. Multiple flags are accepted.")
+	proxy := flags.String("x", "", "Proxy URL (SOCKS5 or HTTP) of the requests of -run")
+	timeout := flags.Int("timeout", 10, "HTTP request timeout of -run in seconds")
+	threads := flags.Int("t", 10, "Number of test cases of -run executed at once")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if opts.format != "json" && opts.format != "postman" && opts.format != "curl" {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -format must be json, postman or curl\n")
+		return 2
+	}
+	if *threads < 1 {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -t must be at least 1\n")
+		return 2
+	}
+	if err := opts.resolve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	var conf *ffuf.Config
+	if *run {
+		var err error
+		if conf, err = opts.requestConfig(*proxy, *timeout); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+		defer conf.Cancel()
+	}
+	discoveries, err := opts.discover(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+
+	// The test cases of all the specs are generated from a single discovery
+	discovery := discoveries[0]
+	for _, other := range discoveries[1:] {
+		discovery.Endpoints = append(discovery.Endpoints, other.GetEndpoints()...)
+	}
+	extractor := parser.NewAPIParameterExtractor(discovery)
+	if err := extractor.ExtractParameters(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	generator := parser.NewAPITestGenerator(discovery, extractor)
+	generator.Options.BaseURL = discovery.BaseURL
+	generator.AddDefaultTemplates()
+	if templates != "" {
+		if err := generator.LoadTemplatesFromDirectory(templates); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+	}
+	if err := generator.GenerateTestCases(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+
+	if !*run || opts.output != "" {
+		var data string
+		switch opts.format {
+		case "postman":
+			data, err = generator.ExportTestCasesToPostman("ffuf test cases")
+		case "curl":
+			data = strings.Join(generator.ExportTestCasesToCurl(), "\n") + "\n"
+		default:
+			data, err = generator.ExportTestCasesToJSON()
+		}
+		if err == nil {
+			err = writeAPIOutput(opts.output, func(w io.Writer) error {
+				_, err := io.WriteString(w, data)
+				return err
+			})
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 1
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Generated %d test case(s)\n", len(generator.GetTestCases()))
+	if *run {
+		return runTestCases(conf, opts.variables, *threads, generator.GetTestCases())
+	}
+	return 0
+}
+
+// runTestCases executes the test cases with the executor, prints the test cases that did not
+// pass and returns the exit code, 1 if any test case failed or could not be executed
+func runTestCases(conf *ffuf.Config, variables *parser.Variables, threads int, testCases []*parser.APITestCase) int {
+	options := executor.DefaultOptions()
+	options.Concurrency = threads
+	options.Headers = conf.Headers
+	options.Variables = variables
+	result, err := executor.NewExecutor(conf, options).Execute(conf.Context, testCases)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	for _, test := range result.Results {
+		method, url := test.TestCase.Method, test.TestCase.URL
+		if test.Request != nil {
+			method, url = test.Request.Method, test.Request.Url
+		}
+		switch test.Status {
+		case executor.StatusFailed:
+			fmt.Fprintf(os.Stderr, "FAIL %s %s %s: %s\n", method, url, test.TestCase.Name, strings.Join(test.Failures, "; "))
+		case executor.StatusError:
+			fmt.Fprintf(os.Stderr, "ERROR %s %s %s: %s\n", method, url, test.TestCase.Name, test.Error)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Passed: %d, failed: %d, errors: %d, skipped: %d\n", result.Passed, result.Failed, result.Errors, result.Skipped)
+	if result.Failed > 0 || result.Errors > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runAPIScan runs the api scan command and returns the exit code. The flags are converted to
+// a scan job, run like the job files of ffuf api run.
+func runAPIScan(args []string) int {
+	opts := apiFlags{}
+	security := &jobfile.Security{}
+	job := &jobfile.Job{Security: security}
+	var include, exclude, categories, tamper string
+	var options, budgets, testerHeaders, testerProxies, resolve multiStringFlag
+	var dryRun bool
+	var loginAttempts int
+	var logs runLogOptions
+	var notify notifyOptions
+	flags := newAPIFlagSet("scan", "-spec openapi.json [options]",
+		"Scan the endpoints of API specifications, Postman collections, HAR files and proxy exports with\nthe security testers, or the -target itself without -spec, and write the vulnerability report.",
+		"Scan a specification on a staging server and write a SARIF report.",
+		"ffuf api scan -spec openapi.json -target https://staging.example.com -H \"Authorization: Bearer {{env.TOKEN}}\" -o report.sarif",
+		"Scan with the injection testers, failing on findings missing from the baseline.",
+		"ffuf api scan -spec openapi.json -profile injection-only -baseline baseline.json -fail-on-new")
+	opts.addSpecFlags(flags)
+	opts.addOutputFlags(flags, "", "json, sarif, html, md, csv, xlsx, burp (Burp Suite issues XML), har (requests of the findings). Default: inferred from the extension of -o")
+	flags.Var(&opts.headers, "H", "Header \"Name: Value\" added to the requests of the scan. Multiple flags are accepted.")
+	flags.StringVar(&job.Name, "name", "", "Name of the scanned target in the report. Default: the target")
+	flags.StringVar(&job.Proxy, "x", "", "Proxy URL (SOCKS5 or HTTP) of the requests of the scan")
+	flags.StringVar(&job.ProxyList, "proxy-list", "", "File of proxy URLs (SOCKS5 or HTTP), one per line, each request of the scan going through the next one in turn")
+	flags.Var(&resolve, "resolve", "Connect to an address in place of a host, in the form host:port:address (e.g. api.example.com:443:10.0.0.5), as curl --resolve. Multiple flags are accepted.")
+	flags.IntVar(&job.Rate.Threads, "t", 10, "Number of concurrent requests of the scan")
+	flags.IntVar(&job.Rate.TargetsParallel, "parallel", 10, "Number of endpoints scanned at once, sharing the -t workers round-robin")
+	flags.IntVar(&job.Rate.Timeout, "timeout", 10, "HTTP request timeout of the scan in seconds")
+	flags.IntVar(&job.Rate.RequestsPerSecond, "rate", 0, "Rate of requests per second of the scan")
+	flags.StringVar(&security.MaxDuration, "max-duration", "", "Maximum running time of the scan, e.g. 30m. The riskiest endpoints are scanned first, and the report lists the endpoints not scanned")
+	flags.Var(&budgets, "budget", "Maximum running time of the security testers of a type against each endpoint, in the form type=duration (e.g. injection=2m), or of every tester without a budget with a bare duration. Multiple flags are accepted.")
+	flags.Var(&testerHeaders, "tester-header", "Header \"type=Name: Value\" added to the requests of the security testers of a type (e.g. injection=X-Debug: 1), or \"Name: Value\" to those of every tester. An empty value removes the header. Multiple flags are accepted.")
+	flags.Var((*multiStringFlag)(&security.Identities), "identity", "Identity \"name:id1,id2=Name: Value\" of the BOLA tester: a header authenticating a user of the API and the identifiers of the objects it owns (e.g. alice:101,102=Authorization: Bearer TOKEN). The objects of each of the two identities are requested as the other one. Multiple flags are accepted.")
+	flags.Var(&testerProxies, "tester-proxy", "Proxy URL of the requests of the security testers of a type, in the form type=URL (e.g. ssrf=http://127.0.0.1:8080), in place of -x. Multiple flags are accepted.")
+	flags.StringVar(&security.Profile, "profile", "quick", "Security scan profile: all, quick, owasp-top10, injection-only")
+	flags.StringVar(&include, "include", "", "Comma separated list of security testers or tags added to the profile")
+	flags.StringVar(&exclude, "exclude", "", "Comma separated list of security testers or tags removed from the profile")
+	flags.Var(&options, "option", "Option \"tester.Option=value\" of a security tester. Multiple flags are accepted.")
+	flags.Var((*multiStringFlag)(&security.Payloads), "payloads", "Payloads of the injection tester by category: a file, a directory of files named after the categories (e.g. sqli-error.txt), or a remote feed URL. Multiple flags are accepted.")
+	flags.StringVar(&categories, "payload-categories", "", "Comma separated list of the payload categories sent by the injection tester (e.g. sqli-error,xxe-oob). Default: all")
+	flags.BoolVar(&security.PayloadsReplace, "payloads-replace", false, "Replace the built-in payloads of the categories of -payloads instead of adding to them")
+	flags.StringVar(&tamper, "tamper", "", "Comma separated chain of tampers transforming the payloads of the injection tester in order: built-in tampers (e.g. randomcase,space2comment), executable scripts and Go plugins")
+	flags.BoolVar(&security.WAF, "waf", false, "Fingerprint the web application firewall in front of the targets before the scan, tamper with the payloads to evade it and mark the findings it blocked")
+	flags.BoolVar(&security.Fingerprint, "fingerprint", false, "Fingerprint the framework, language, server, gateway and database of the targets before the scan, report them and skip the payloads of other databases")
+	flags.IntVar(&loginAttempts, "login-attempts", 10, "Maximum number of login attempts of the security testers to each login endpoint, 0 for no limit. Attempts to an endpoint stop at the first lockout indicator")
+	flags.BoolVar(&security.AccountSafe, "account-safe", false, "Stop the credential testing of a host after its first lockout indicator (423, 403 after failed logins, or a locked account message)")
+	flags.StringVar(&security.OOBListen, "oob-listen", "", "Address of the HTTP callback listener receiving the out-of-band interactions of the injection and SSRF payloads (e.g. 0.0.0.0:8089)")
+	flags.StringVar(&security.OOBURL, "oob-url", "", "Public URL the targets reach the -oob-listen callback listener at (e.g. http://oob.example.com:8089)")
+	flags.StringVar(&security.OOBDNSListen, "oob-dns", "", "Address of the DNS callback listener receiving the out-of-band lookups of the payloads (e.g. 0.0.0.0:53)")
+	flags.StringVar(&security.OOBDomain, "oob-domain", "", "Domain delegated to the -oob-dns listener, under which the payloads look up unique subdomains")
+	flags.StringVar(&security.Scoring, "scoring", "", "File (YAML or JSON) of CVSS v3.1 vectors overriding the default vectors scoring the findings of the scan, by vulnerability type, finding name regex and CWE")
+	flags.StringVar(&job.Policy, "policy", "", "Scope policy file denying requests of the scan by host, path regex, method and parameter name")
+	flags.StringVar(&job.SafeMode, "safe-mode", "", "Consent level of the scan: read-only or non-destructive. Default: no restrictions")
+	flags.StringVar(&security.Baseline, "baseline", "", "Baseline file of the findings of previous scans. Findings are marked as new or known, and findings of the baseline no longer found as fixed")
+	flags.BoolVar(&security.UpdateBaseline, "update-baseline", false, "Write the findings of the scan to the -baseline file")
+	flags.BoolVar(&security.FailOnNew, "fail-on-new", false, "Exit with an error if the scan finds findings missing from the -baseline file")
+	flags.StringVar(&security.Evidence, "evidence", "", "Write an evidence bundle of every vulnerability found by the scan, with the raw request and response and replay scripts, to a directory referenced from the report")
+	flags.StringVar(&security.Mermaid, "report-mermaid", "", "Local Mermaid script embedded in the HTML report to render its API map offline")
+	flags.StringVar(&security.State, "state", "", "File recording the completed requests of the scan, to resume it with -resume if it is interrupted")
+	flags.BoolVar(&security.Resume, "resume", false, "Resume an interrupted scan, replaying the requests recorded in the -state file")
+	flags.BoolVar(&dryRun, "dry-run", false, "Print the endpoints of the scan without sending requests")
+	flags.StringVar(&logs.eventLog, "event-log", "", "Stream the requests, findings and finished security testers of the scan as NDJSON events to a file, or to stdout with -")
+	notify.addFlags(flags, "the scan")
+	logs.addHARFlags(flags, "har", "the scan")
+	logs.addRedactFlags(flags, "the report, evidence bundles, baseline, notifications and logs of the scan")
+	logs.addLogFlags(flags, "the scan")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if err := opts.resolve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	if _, err := logs.redactor(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	if err := logs.logging().Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	if job.Rate.TargetsParallel < 1 {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -parallel must be at least 1\n")
+		return 2
+	}
+
+	job.Specs = opts.specs
+	if opts.target != "" {
+		job.Targets = []string{opts.target}
+	}
+	job.Resolve = resolve
+	security.LoginAttempts = &loginAttempts
+	job.Headers = make(map[string]string, len(opts.headers))
+	for _, header := range opts.headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): invalid header %s, expected \"Name: Value\"\n", header)
+			return 2
+		}
+		job.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if include != "" {
+		security.Include = strings.Split(include, ",")
+	}
+	if exclude != "" {
+		security.Exclude = strings.Split(exclude, ",")
+	}
+	if categories != "" {
+		security.PayloadCategories = strings.Split(categories, ",")
+	}
+	if tamper != "" {
+		security.Tamper = strings.Split(tamper, ",")
+	}
+	security.Options = make(map[string]string, len(options))
+	for _, option := range options {
+		parts := strings.SplitN(option, "=", 2)
+		if len(parts) != 2 {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): invalid option %s, expected \"tester.Option=value\"\n", option)
+			return 2
+		}
+		security.Options[parts[0]] = parts[1]
+	}
+	security.Budgets = make(map[string]string, len(budgets))
+	for _, budget := range budgets {
+		name, duration := "default", budget
+		if parts := strings.SplitN(budget, "=", 2); len(parts) == 2 {
+			name, duration = parts[0], parts[1]
+		}
+		security.Budgets[name] = duration
+	}
+	security.Headers = make(map[string][]string)
+	for _, header := range testerHeaders {
+		name, value := "default", header
+		if eq, colon := strings.Index(header, "="), strings.Index(header, ":"); eq > 0 && (colon < 0 || eq < colon) {
+			name, value = header[:eq], header[eq+1:]
+		}
+		security.Headers[name] = append(security.Headers[name], value)
+	}
+	security.Proxies = make(map[string]string, len(testerProxies))
+	for _, proxy := range testerProxies {
+		parts := strings.SplitN(proxy, "=", 2)
+		if len(parts) != 2 {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): invalid tester proxy %s, expected \"type=URL\"\n", proxy)
+			return 2
+		}
+		security.Proxies[parts[0]] = parts[1]
+	}
+	if opts.output != "" {
+		job.Reports = []jobfile.Report{{File: opts.output, Format: opts.format}}
+	}
+	job.Normalize()
+	if err := job.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	return runJob(job, dryRun, logs, notify)
+}
+
+// runAPICoverage runs the api coverage command and returns the exit code
+func runAPICoverage(args []string) int {
+	opts := apiFlags{}
+	var traffic multiStringFlag
+	var minimum float64
+	flags := newAPIFlagSet("coverage", "-spec openapi.json -traffic capture.har [options]",
+		"Measure the endpoints and parameters of an API specification, Postman collection, HAR file or\nproxy export exercised by recorded traffic, and write the coverage report.",
+		"Write the coverage of a specification by the traffic of a test run as an HTML report.",
+		"ffuf api coverage -spec openapi.json -traffic e2e.har -o coverage.html -min 80")
+	opts.addSpecFlags(flags)
+	flags.StringVar(&opts.output, "o", "", "Write the coverage report to a file, in the format of its extension: .json, .html, .md, .txt, .csv or .xlsx")
+	flags.Var(&traffic, "traffic", "HAR capture, Burp Suite XML export or OWASP ZAP message export of the requests measured. Multiple flags are accepted.")
+	flags.Float64Var(&minimum, "min", 0, "Exit with an error if the percentage of requested endpoints is below this value")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if len(opts.specs) != 1 {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): a single -spec is required\n")
+		return 2
+	}
+	if len(traffic) == 0 {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -traffic is required\n")
+		return 2
+	}
+	if err := opts.resolve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+
+	conf := &ffuf.Config{
+		APICoverageSpec:   opts.specs[0],
+		APICoverageReport: opts.output,
+		APICoverageMin:    minimum,
+	}
+	analyzer, err := newCoverageAnalyzer(conf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	recorder := capture.NewRecorder()
+	recorder.Parser.IncludeStatic = true
+	for _, file := range traffic {
+		count, format, err := recorder.ImportFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): could not import %s: %s\n", file, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Imported %d request(s) from the %s file %s\n", count, format, file)
+	}
+	for _, entry := range recorder.Entries() {
+		req, resp := coverageExchange(entry)
+		analyzer.RecordRequest(req, resp)
+	}
+	if !reportCoverage(conf, analyzer) {
+		return 1
+	}
+	return 0
+}
+
+// coverageExchange converts a recorded entry to the request and response measured by the
+// coverage analyzer
+func coverageExchange(entry *capture.Entry) (*ffuf.Request, *ffuf.Response) {
+	req := &ffuf.Request{
+		Method:  entry.Request.Method,
+		Url:     entry.Request.URL,
+		Headers: make(map[string]string, len(entry.Request.Headers)),
+	}
+	for _, header := range entry.Request.Headers {
+		req.Headers[header.Name] = header.Value
+	}
+	if entry.Request.PostData != nil {
+		req.Data = []byte(entry.Request.PostData.Text)
+	}
+	resp := &ffuf.Response{
+		StatusCode:    int64(entry.Response.Status),
+		ContentLength: int64(entry.Response.Content.Size),
+		ContentType:   entry.Response.Content.MimeType,
+		Request:       req,
+	}
+	return req, resp
+}
+
+// runAPIReport runs the api report command and returns the exit code
+func runAPIReport(args []string) int {
+	opts := apiFlags{}
+	var input, baseline string
+	var updateBase, failOnNew bool
+	var redact runLogOptions
+	var export exportOptions
+	flags := newAPIFlagSet("report", "-i report.json [options]",
+		"Convert a JSON vulnerability report written by a scan to another format, and compare its findings\nwith a baseline of previous scans.",
+		"Convert a JSON report to SARIF for code scanning.",
+		"ffuf api report -i report.json -o report.sarif",
+		"Print the findings of a report missing from the baseline, and update the baseline.",
+		"ffuf api report -i report.json -baseline baseline.json -update-baseline -format md",
+		"Mask the tokens and personal data of a report before sharing it.",
+		"ffuf api report -i report.json -redact all -o report-redacted.html",
+		"Import the findings of a report into a DefectDojo engagement, with the key in DEFECTDOJO_API_KEY.",
+		"ffuf api report -i report.json -defectdojo https://defectdojo.example.com -defectdojo-engagement 7",
+		"Open Jira issues for the high and critical findings, with the token in JIRA_API_TOKEN.",
+		"ffuf api report -i report.json -jira https://example.atlassian.net -jira-project SEC -jira-user me@example.com -export-severity High")
+	flags.StringVar(&input, "i", "", "JSON vulnerability report written by ffuf api scan, ffuf api run or ffuf capture -scan")
+	opts.addOutputFlags(flags, "", "json, sarif, html, md, csv, xlsx, burp (Burp Suite issues XML), har (requests of the findings). Default: inferred from the extension of -o, json on standard output")
+	flags.StringVar(&baseline, "baseline", "", "Baseline file of the findings of previous scans. Findings are marked as new or known, and findings of the baseline no longer found as fixed")
+	flags.BoolVar(&updateBase, "update-baseline", false, "Write the findings of the report to the -baseline file")
+	flags.BoolVar(&failOnNew, "fail-on-new", false, "Exit with an error if the report has findings missing from the -baseline file")
+	redact.addRedactFlags(flags, "the converted report, the baseline and the exported findings")
+	export.addFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	redactor, err := redact.redactor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	exporters, err := export.exporters()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	if input == "" {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -i is required\n")
+		return 2
+	}
+	if (updateBase || failOnNew) && baseline == "" {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -update-baseline and -fail-on-new require -baseline\n")
+		return 2
+	}
+	format := reporting.FormatJSON
+	if opts.format != "" || opts.output != "" {
+		inferred, err := jobfile.Report{File: opts.output, Format: opts.format}.ReportFormat()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+		format = inferred
+	}
+
+	report, err := reporting.LoadVulnerabilityReport(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	report.Redact(redactor)
+	counts := report.SeverityCounts()
+	severities := make([]string, 0, len(counts))
+	for severity := range counts {
+		severities = append(severities, severity)
+	}
+	sort.Strings(severities)
+	for _, severity := range severities {
+		if counts[severity] > 0 {
+			fmt.Fprintf(os.Stderr, "%s: %d\n", severity, counts[severity])
+		}
+	}
+
+	var previous *reporting.Baseline
+	if baseline != "" {
+		if previous, err = reporting.LoadBaseline(baseline); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 1
+		}
+		report.CompareBaseline(previous)
+		baselineCounts := report.BaselineCounts()
+		fmt.Fprintf(os.Stderr, "Baseline: %d new, %d known, %d fixed\n", baselineCounts[reporting.FindingNew], baselineCounts[reporting.FindingKnown], baselineCounts[reporting.FindingFixed])
+	}
+
+	generated, err := report.Generate(format)
+	if err == nil {
+		err = writeAPIOutput(opts.output, func(w io.Writer) error {
+			_, err := io.WriteString(w, generated)
+			return err
+		})
+	}
+	if err == nil && updateBase {
+		err = report.NewBaseline(previous).Save(baseline)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	for _, exporter := range exporters {
+		result, err := exporter.Export(report)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s export failed: %s\n", exporter.Name(), err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d created, %d skipped\n", exporter.Name(), result.Created, result.Skipped)
+		for _, link := range result.Links {
+			fmt.Fprintf(os.Stderr, "  %s\n", link)
+		}
+	}
+	if count := report.BaselineCounts()[reporting.FindingNew]; failOnNew && count > 0 {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %d new finding(s) missing from the baseline %s\n", count, filepath.Clean(baseline))
+		return 1
+	}
+	return 0
+}
+
+// exportOptions are the vulnerability management systems the findings of a report are
+// exported to. The credentials are read from the environment to keep them out of the
+// command line.
+type exportOptions struct {
+	defectDojo     string
+	engagement     int
+	product        string
+	engagementName string
+	jira           string
+	jiraProject    string
+	jiraUser       string
+	severity       string
+}
+
+// addFlags adds the -defectdojo and -jira flags of api report
+func (o *exportOptions) addFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.defectDojo, "defectdojo", "", "Import the findings into the DefectDojo instance at this URL, with the API v2 key in the DEFECTDOJO_API_KEY environment variable")
+	flags.IntVar(&o.engagement, "defectdojo-engagement", 0, "ID of the DefectDojo engagement the findings are imported into")
+	flags.StringVar(&o.product, "defectdojo-product", "", "DefectDojo product the findings are imported into without -defectdojo-engagement, created if it does not exist")
+	flags.StringVar(&o.engagementName, "defectdojo-engagement-name", "ffuf", "Name of the DefectDojo engagement of -defectdojo-product, created if it does not exist")
+	flags.StringVar(&o.jira, "jira", "", "Open issues for the findings in the Jira instance at this URL, with the API token in the JIRA_API_TOKEN environment variable. Findings with an issue are skipped")
+	flags.StringVar(&o.jiraProject, "jira-project", "", "Key of the Jira project the issues are opened in")
+	flags.StringVar(&o.jiraUser, "jira-user", "", "Jira user of the API token. Without it, the token is sent as a personal access token")
+	flags.StringVar(&o.severity, "export-severity", "", "Minimum severity of the findings exported to -defectdojo and -jira: Critical, High, Medium, Low, Info. Default: Info for DefectDojo, Medium for Jira")
+}
+
+// exporters creates the exporters of the -defectdojo and -jira flags
+func (o *exportOptions) exporters() ([]reporting.Exporter, error) {
+	severity := ""
+	if o.severity != "" {
+		for _, name := range []string{"Critical", "High", "Medium", "Low", "Info"} {
+			if strings.EqualFold(name, o.severity) {
+				severity = name
+			}
+		}
+		if severity == "" {
+			return nil, fmt.Errorf("-export-severity must be one of Critical, High, Medium, Low, Info")
+		}
+	}
+
+	exporters := make([]reporting.Exporter, 0)
+	if o.defectDojo != "" {
+		key := os.Getenv("DEFECTDOJO_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("-defectdojo requires the DEFECTDOJO_API_KEY environment variable")
+		}
+		if o.engagement <= 0 && o.product == "" {
+			return nil, fmt.Errorf("-defectdojo requires -defectdojo-engagement or -defectdojo-product")
+		}
+		exporter := reporting.NewDefectDojoExporter(o.defectDojo, key)
+		exporter.EngagementID = o.engagement
+		exporter.ProductName = o.product
+		exporter.EngagementName = o.engagementName
+		exporter.AutoCreateContext = o.product != ""
+		if severity != "" {
+			exporter.MinimumSeverity = severity
+		}
+		exporters = append(exporters, exporter)
+	}
+	if o.jira != "" {
+		token := os.Getenv("JIRA_API_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("-jira requires the JIRA_API_TOKEN environment variable")
+		}
+		if o.jiraProject == "" {
+			return nil, fmt.Errorf("-jira requires -jira-project")
+		}
+		exporter := reporting.NewJiraExporter(o.jira, o.jiraProject)
+		if o.jiraUser != "" {
+			exporter.Username = o.jiraUser
+			exporter.APIToken = token
+		} else {
+			exporter.Token = token
+		}
+		if severity != "" {
+			exporter.MinimumSeverity = severity
+		}
+		exporters = append(exporters, exporter)
+	}
+	return exporters, nil
+}
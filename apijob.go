@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/capture"
+	"github.com/ffuf/ffuf/v2/pkg/api/jobfile"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/reporting"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+// apiRunUsage prints the usage of the api run command
+func apiRunUsage(flags *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Fuzz Faster U Fool - v%s\n\n", ffuf.Version())
+	fmt.Fprintf(os.Stderr, "Usage: ffuf api run [options] job.yaml\n\n")
+	fmt.Fprintf(os.Stderr, "Run the scan job declared in a YAML file: its targets, API specifications, authentication,\n")
+	fmt.Fprintf(os.Stderr, "rate limits, fuzzing stage, security testers and reports.\n\n")
+	flags.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\nEXAMPLE JOB FILE:\n")
+	fmt.Fprintf(os.Stderr, "    name: staging\n")
+	fmt.Fprintf(os.Stderr, "    targets: [https://staging.example.com]\n")
+	fmt.Fprintf(os.Stderr, "    specs: [openapi.json]\n")
+	fmt.Fprintf(os.Stderr, "    auth: {token: \"{{env.API_TOKEN}}\"}\n")
+	fmt.Fprintf(os.Stderr, "    rate: {threads: 10, requests_per_second: 50}\n")
+	fmt.Fprintf(os.Stderr, "    security: {profile: owasp-top10}\n")
+	fmt.Fprintf(os.Stderr, "    reports: [{file: report.html}, {file: report.sarif}]\n\n")
+}
+
+// runAPIJob runs the api run command and returns the exit code
+func runAPIJob(args []string) int {
+	flags := flag.NewFlagSet("ffuf api run", flag.ContinueOnError)
+	flags.Usage = func() { apiRunUsage(flags) }
+	dryRun := flags.Bool("dry-run", false, "Print the targets and endpoints of the job without sending requests")
+	resume := flags.Bool("resume", false, "Resume an interrupted job, replaying the requests recorded in the state file of its security section")
+	var notify notifyOptions
+	notify.addFlags(flags, "the security stage of the job")
+	var logs runLogOptions
+	flags.StringVar(&logs.eventLog, "event-log", "", "Stream the requests, results, findings and finished security testers of the job as NDJSON events to a file, or to stdout with -")
+	logs.addHARFlags(flags, "har", "the job")
+	logs.addRedactFlags(flags, "the reports, evidence bundles, baseline, notifications and logs of the job, in addition to the redact section of the job file")
+	logs.addLogFlags(flags, "the job, overriding the log section of the job file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): a single job file is required\n")
+		return 2
+	}
+	if _, err := logs.redactor(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	if err := logs.logging().Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	job, err := jobfile.Load(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	if *resume {
+		if job.Security == nil || job.Security.State == "" {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): -resume requires the state file of the security section of the job\n")
+			return 2
+		}
+		job.Security.Resume = true
+	}
+	return runJob(job, *dryRun, logs, notify)
+}
+
+// runJob runs the fuzzing and security stages of a job, or prints the URLs they would
+// request, and returns the exit code. The requests of the job are written to the event log
+// and the HAR capture if they are set, and the findings of its security stage sent to the
+// -notify webhooks.
+func runJob(job *jobfile.Job, dryRun bool, logOpts runLogOptions, notify notifyOptions) int {
+	opts := job.Options()
+	if err := insertPayloadFuzzPoint(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	if len(notify.webhooks) > 0 && job.Security == nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -notify requires a security stage\n")
+		return 2
+	}
+	notifier, err := notify.notifier(jobName(job))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	targets, endpoints, values, specs, err := jobEndpoints(job)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+
+	if dryRun {
+		if job.Fuzz != nil {
+			method := opts.HTTP.Method
+			if method == "" {
+				method = "GET"
+			}
+			for _, target := range targets {
+				fmt.Printf("fuzz %s %s\n", method, targetURL(target, opts.HTTP.URL))
+			}
+		}
+		if job.Security != nil {
+			for _, endpoint := range endpoints {
+				fmt.Printf("scan %s %s\n", endpoint.Method, endpoint.URL)
+			}
+		}
+		return 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// The redaction of the job file is added to the -redact options
+	logOpts.redact = strings.Join([]string{logOpts.redact, opts.API.Redact}, ",")
+	logOpts.redactPatterns = append(logOpts.redactPatterns, opts.API.RedactPatterns...)
+	// The -log options override the log section of the job file
+	if logOpts.logLevel == "" {
+		logOpts.logLevel = opts.API.LogLevel
+	}
+	if logOpts.logFormat == "" {
+		logOpts.logFormat = opts.API.LogFormat
+	}
+	if logOpts.logFile == "" {
+		logOpts.logFile = opts.API.LogFile
+	}
+	started := time.Now()
+	logs, err := openRunLogs(logOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	defer logs.close()
+	logs.start("scan", targets)
+	defer logs.finish("scan")
+
+	exitCode := 0
+	if job.Fuzz != nil {
+		exitCode = fuzzTargets(opts, targets, ctx, cancel, logs)
+	}
+	if job.Security != nil && ctx.Err() == nil {
+		// The max_time of the job is shared by the fuzzing and security stages, the
+		// max_duration of the security stage starts with it
+		timeCtx, timeCancel := withMaxTime(ctx, started, opts.General.MaxTime)
+		defer timeCancel()
+		scanCtx, scanCancel := withMaxDuration(timeCtx, job.Security.Duration())
+		defer scanCancel()
+		scanCtx = security.WithSpecifications(scanCtx, specs)
+		if err := scanJob(scanCtx, scanCancel, job, opts, endpoints, values, logs, notifier); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 1
+		}
+	}
+	return exitCode
+}
+
+// withMaxTime returns a context cancelled maxTime seconds after started, or only with its
+// parent if maxTime is 0
+func withMaxTime(parent context.Context, started time.Time, maxTime int) (context.Context, context.CancelFunc) {
+	if maxTime <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithDeadline(parent, started.Add(time.Duration(maxTime)*time.Second))
+}
+
+// withMaxDuration returns a context cancelled after maxDuration, or only with its parent if
+// maxDuration is 0
+func withMaxDuration(parent context.Context, maxDuration time.Duration) (context.Context, context.CancelFunc) {
+	if maxDuration <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, maxDuration)
+}
+
+// jobEndpoints returns the targets of a job, the base URLs of its specs if it declares none,
+// the endpoints scanned by its security stage: the endpoints of its specs on every target, or
+// the targets themselves, the riskiest first, and its OpenAPI specs
+func jobEndpoints(job *jobfile.Job) ([]string, []*capture.Target, *parser.ValueDictionary, []*parser.OpenAPIParser, error) {
+	proxies := []string{}
+	if job.ProxyList != "" {
+		var err error
+		if proxies, err = ffuf.ReadProxyList(job.ProxyList); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	client := runner.NewHTTPClient(&ffuf.Config{ProxyURL: job.Proxy, ProxyList: proxies, Resolve: job.Resolve}, 30*time.Second)
+	discoveries := make([]*parser.APIEndpointDiscovery, 0, len(job.Specs))
+	values := parser.NewValueDictionary()
+	specs := make([]*parser.OpenAPIParser, 0, len(job.Specs))
+	for _, spec := range job.Specs {
+		discovery, err := discoverEndpoints(spec, client)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("could not import the spec %s: %s", spec, err)
+		}
+		discoveries = append(discoveries, discovery)
+		values.AddEndpoints(discovery.GetEndpoints())
+		if openapi, ok := discovery.Parser.(*parser.OpenAPIParser); ok {
+			specs = append(specs, openapi)
+		}
+	}
+
+	targets := job.Targets
+	if len(targets) == 0 {
+		for i, discovery := range discoveries {
+			if discovery.BaseURL == "" {
+				return nil, nil, nil, nil, fmt.Errorf("the spec %s has no server URL, set the targets of the job", job.Specs[i])
+			}
+			targets = appendUnique(targets, strings.TrimSuffix(discovery.BaseURL, "/"))
+		}
+	}
+
+	endpoints := make([]*capture.Target, 0)
+	seen := make(map[string]bool)
+	add := func(method, u, path string, risk int) {
+		if key := method + " " + u; !seen[key] {
+			seen[key] = true
+			endpoints = append(endpoints, &capture.Target{Method: method, URL: u, Path: path, Risk: risk})
+		}
+	}
+	if len(discoveries) == 0 {
+		for _, target := range targets {
+			add("GET", job.URL(target, ""), "/", 0)
+		}
+	}
+	for _, discovery := range discoveries {
+		for _, target := range targets {
+			base := target
+			// The targets replace the scheme and host of the server URL of the spec
+			if u, err := url.Parse(discovery.BaseURL); err == nil && len(job.Targets) > 0 {
+				base += strings.TrimSuffix(u.Path, "/")
+			}
+			for _, endpoint := range discovery.GetEndpoints() {
+				path := endpointPath(endpoint, values)
+				add(endpoint.Method, job.URL(base, path), path, parser.ScoreEndpoint(endpoint).Score)
+			}
+		}
+	}
+	// The riskiest endpoints are scanned first, within the time budget of the job
+	capture.SortTargets(endpoints)
+	return targets, endpoints, values, specs, nil
+}
+
+// endpointPath returns the path of an endpoint with its path parameters replaced with their
+// example value, the first candidate value of a parameter with the same name, or 1
+func endpointPath(endpoint *parser.DiscoveredEndpoint, values *parser.ValueDictionary) string {
+	path := endpoint.Path
+	for _, param := range endpoint.Parameters {
+		if param.In != "path" {
+			continue
+		}
+		value := "1"
+		if param.Example != nil {
+			value = url.PathEscape(fmt.Sprint(param.Example))
+		} else if candidates := values.Values(param.Name); len(candidates) > 0 {
+			value = url.PathEscape(fmt.Sprint(candidates[0]))
+		}
+		path = strings.ReplaceAll(path, "{"+param.Name+"}", value)
+	}
+	return path
+}
+
+// appendUnique appends a value to a slice if it is not in it yet
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// jobName returns the name of the scanned target of a job in reports and notifications
+func jobName(job *jobfile.Job) string {
+	if job.Name != "" {
+		return job.Name
+	}
+	return strings.Join(job.Targets, ", ")
+}
+
+// scanJob runs the security stage of a job against its endpoints, sending its findings to the
+// notifier if it is not nil, and writes its reports
+func scanJob(ctx context.Context, cancel context.CancelFunc, job *jobfile.Job, opts *ffuf.ConfigOptions, endpoints []*capture.Target, values *parser.ValueDictionary, logs *runLogs, notifier *reporting.Notifier) error {
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Threads = opts.General.Threads
+	conf.APITargetsParallel = opts.API.TargetsParallel
+	conf.Timeout = opts.HTTP.Timeout
+	conf.Rate = int64(opts.General.Rate)
+	conf.ProxyURL = opts.HTTP.ProxyURL
+	conf.Resolve = opts.HTTP.Resolve
+	conf.Data = opts.HTTP.Data
+	for _, header := range opts.HTTP.Headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) == 2 {
+			conf.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	conf.APISecurityProfile = opts.API.SecurityProfile
+	conf.APISecurityInclude = job.Security.Include
+	conf.APISecurityExclude = job.Security.Exclude
+	conf.APISecurityOptions = opts.API.SecurityOptions
+	conf.APISecurityScoring = opts.API.SecurityScoring
+	conf.APISecurityBudgets = opts.API.SecurityBudgets
+	conf.APISecurityPayloads = opts.API.SecurityPayloads
+	conf.APISecurityPayloadCategories = job.Security.PayloadCategories
+	conf.APISecurityPayloadsReplace = opts.API.PayloadsReplace
+	conf.APISecurityTamper = job.Security.Tamper
+	conf.APISecurityWAF = opts.API.SecurityWAF
+	conf.APISecurityFingerprint = opts.API.Fingerprint
+	conf.APISecurityHeaders = opts.API.SecurityHeaders
+	conf.APISecurityProxies = opts.API.SecurityProxies
+	conf.APISecurityIdentities = opts.API.Identities
+	conf.APISecurityLoginAttempts = opts.API.LoginAttempts
+	conf.APISecurityAccountSafe = opts.API.AccountSafe
+	conf.APISecurityOOBURL = opts.API.OOBURL
+	conf.APISecurityOOBListen = opts.API.OOBListen
+	conf.APISecurityOOBDomain = opts.API.OOBDomain
+	conf.APISecurityOOBDNSListen = opts.API.OOBDNSListen
+	conf.APIStateFile = opts.API.StateFile
+	conf.APIResume = opts.API.Resume
+	conf.APISigningConfig = opts.API.SigningConfig
+	conf.APILoginURL = opts.API.LoginURL
+	conf.APILoginType = opts.API.LoginType
+	conf.APILoginData = opts.API.LoginData
+	conf.APILoginScope = opts.API.LoginScope
+	conf.APILoginTokenPath = opts.API.LoginTokenPath
+	conf.APILoginHeader = opts.API.LoginHeader
+	if opts.API.LoginClient != "" {
+		client := strings.SplitN(opts.API.LoginClient, ":", 2)
+		conf.APILoginClientID = client[0]
+		if len(client) == 2 {
+			conf.APILoginClientSecret = client[1]
+		}
+	}
+	if opts.API.Policy != "" {
+		policy, err := ffuf.LoadPolicy(opts.API.Policy)
+		if err != nil {
+			return err
+		}
+		conf.APIPolicy = opts.API.Policy
+		conf.Policy = policy
+	}
+	if opts.API.SafeMode != "" {
+		safeMode, err := ffuf.NewSafeMode(opts.API.SafeMode)
+		if err != nil {
+			return err
+		}
+		conf.APISafeMode = opts.API.SafeMode
+		conf.SafeMode = safeMode
+	}
+	if opts.HTTP.ProxyList != "" {
+		proxies, err := ffuf.ReadProxyList(opts.HTTP.ProxyList)
+		if err != nil {
+			return err
+		}
+		conf.ProxyList = proxies
+	}
+
+	if notifier != nil {
+		notifier.Redactor = logs.redactor
+		ctx = security.WithResultHandler(ctx, notifier.HandleResult)
+	}
+	results, skipped, err := scanTargets(ctx, &conf, endpoints, values, logs)
+	if err != nil {
+		return err
+	}
+	report := reporting.NewVulnerabilityReport(jobName(job), results)
+	report.SkippedEndpoints = skipped
+	outputs := scanOutputs{
+		mermaid:     job.Security.Mermaid,
+		evidenceDir: job.Security.Evidence,
+		baseline:    job.Security.Baseline,
+		updateBase:  job.Security.UpdateBaseline,
+		failOnNew:   job.Security.FailOnNew,
+		redactor:    logs.redactor,
+	}
+	for _, output := range job.Reports {
+		format, _ := output.ReportFormat()
+		outputs.reports = append(outputs.reports, scanReport{file: output.File, format: string(format)})
+	}
+	if err := finishScan(report, &conf, outputs, notifier); err != nil {
+		return err
+	}
+	return interrupted(ctx)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/capture"
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+// paramsEndpoint is an endpoint searched for hidden parameters, with the parameters found
+type paramsEndpoint struct {
+	endpoint  *parser.DiscoveredEndpoint
+	extractor *parser.APIParameterExtractor
+	request   *ffuf.Request
+	hidden    []*parser.HiddenParameter
+}
+
+// runAPIParams runs the api params command and returns the exit code
+func runAPIParams(args []string) int {
+	opts := apiFlags{}
+	var rawURL, method, data, wordlist, headerWordlist, locations, proxy string
+	var chunkSize, timeout int
+	var noHeuristics bool
+	flags := newAPIFlagSet("params", "-u URL | -spec openapi.json [options]",
+		"Discover the hidden parameters of an URL or of the endpoints of API specifications: candidate names\nare sent in batches in the query, the body and the headers, and the names changing the response are\nreported.",
+		"Discover the hidden query parameters and headers of an endpoint.",
+		"ffuf api params -u https://api.example.com/users -H \"Authorization: Bearer {{env.TOKEN}}\"",
+		"Discover the hidden JSON fields of a request body with a wordlist.",
+		"ffuf api params -u https://api.example.com/users -X POST -d '{\"name\": \"alice\"}' -in body -w params.txt",
+		"Add the hidden parameters to the inventory of the endpoints of a specification.",
+		"ffuf api params -spec openapi.json -target https://staging.example.com -format json -o inventory.json")
+	flags.StringVar(&rawURL, "u", "", "URL of the endpoint searched for hidden parameters")
+	opts.addSpecFlags(flags)
+	opts.addOutputFlags(flags, "text", "text (a METHOD URL location name line per hidden parameter), json (inventory of the endpoints and their parameters), wordlist (the names of the hidden parameters)")
+	flags.Var(&opts.headers, "H", "Header \"Name: Value\" added to the requests. Multiple flags are accepted.")
+	flags.StringVar(&method, "X", "GET", "HTTP method of the -u request")
+	flags.StringVar(&data, "d", "", "Body of the -u request. The hidden body parameters are added to its JSON object or form fields")
+	flags.StringVar(&wordlist, "w", "", "Wordlist of the candidate names of query and body parameters. Default: a built-in list of common names")
+	flags.StringVar(&headerWordlist, "wh", "", "Wordlist of the candidate names of headers. Default: a built-in list of common headers")
+	flags.StringVar(&locations, "in", "query,body,header", "Comma separated list of the locations the candidates are sent in: query, body, header")
+	flags.IntVar(&chunkSize, "chunk", 30, "Number of query and body parameters sent per request")
+	flags.BoolVar(&noHeuristics, "no-heuristics", false, "Do not add the names found in the response, such as its JSON fields and the inputs of its forms, to the candidates")
+	flags.StringVar(&proxy, "x", "", "Proxy URL (SOCKS5 or HTTP) of the requests")
+	flags.IntVar(&timeout, "timeout", 10, "HTTP request timeout in seconds")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if opts.format != "text" && opts.format != "json" && opts.format != "wordlist" {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -format must be text, json or wordlist\n")
+		return 2
+	}
+	if (rawURL == "") == (len(opts.specs) == 0) {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): either -u or -spec is required\n")
+		return 2
+	}
+	if chunkSize < 1 {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -chunk must be at least 1\n")
+		return 2
+	}
+	if err := opts.resolve(); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+
+	conf, err := opts.requestConfig(proxy, timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	defer conf.Cancel()
+
+	finder := parser.NewHiddenParameterFinder(runner.NewSimpleRunner(conf, false))
+	finder.ChunkSize = chunkSize
+	finder.Heuristics = !noHeuristics
+	finder.Locations = make([]string, 0)
+	for _, location := range strings.Split(locations, ",") {
+		location = strings.TrimSpace(location)
+		if location != parser.HiddenParamQuery && location != parser.HiddenParamBody && location != parser.HiddenParamHeader {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): unknown location %s, expected query, body or header\n", location)
+			return 2
+		}
+		finder.Locations = append(finder.Locations, location)
+	}
+	if wordlist != "" {
+		names, err := parser.LoadParameterNames(wordlist)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+		finder.Names = names
+	}
+	if headerWordlist != "" {
+		names, err := parser.LoadParameterNames(headerWordlist)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+		finder.HeaderNames = names
+	}
+
+	endpoints := make([]*paramsEndpoint, 0)
+	if rawURL != "" {
+		// The -u request is searched like the single endpoint of a spec
+		discovery := parser.NewAPIEndpointDiscovery(rawURL)
+		endpoint := &parser.DiscoveredEndpoint{Method: strings.ToUpper(method), URL: rawURL, Parameters: []*parser.DiscoveredParameter{}}
+		discovery.Endpoints = []*parser.DiscoveredEndpoint{endpoint}
+		endpoints = append(endpoints, &paramsEndpoint{
+			endpoint:  endpoint,
+			extractor: parser.NewAPIParameterExtractor(discovery),
+			request:   paramsRequest(conf, endpoint.Method, rawURL, []byte(data)),
+		})
+	} else {
+		discoveries, err := opts.discover(runner.NewHTTPClient(conf, 30*time.Second))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 1
+		}
+		for _, discovery := range discoveries {
+			extractor := parser.NewAPIParameterExtractor(discovery)
+			if err := extractor.ExtractParameters(); err != nil {
+				fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+				return 1
+			}
+			values := parser.NewValueDictionaryFromEndpoints(discovery.GetEndpoints())
+			for _, endpoint := range discovery.GetEndpoints() {
+				u := strings.TrimSuffix(endpoint.URL, endpoint.Path) + endpointPath(endpoint, values)
+				endpoints = append(endpoints, &paramsEndpoint{endpoint: endpoint, extractor: extractor, request: paramsRequest(conf, endpoint.Method, u, nil)})
+			}
+		}
+	}
+
+	found, requests := 0, 0
+	for i, item := range endpoints {
+		hidden, err := finder.Find(item.request)
+		requests += finder.Requests
+		if err != nil {
+			logging.For("params").Warn("Could not find the hidden parameters", "method", item.request.Method, logging.FieldEndpoint, item.request.Url, logging.FieldError, err)
+			continue
+		}
+		// Documented parameters of the specs are not hidden
+		for _, param := range hidden {
+			if !documentedParameter(item.endpoint, param) {
+				item.hidden = append(item.hidden, param)
+			}
+		}
+		item.extractor.AddHiddenParameters(item.endpoint, item.hidden)
+		found += len(item.hidden)
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s %s: %d hidden parameter(s)\n", i+1, len(endpoints), item.request.Method, item.request.Url, len(item.hidden))
+	}
+
+	err = writeAPIOutput(opts.output, func(w io.Writer) error {
+		switch opts.format {
+		case "json":
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			inventory := make([]*capture.InventoryEndpoint, 0, len(endpoints))
+			for _, item := range endpoints {
+				inventory = append(inventory, inventoryEndpoint(item.endpoint))
+			}
+			return encoder.Encode(map[string]interface{}{"endpoints": inventory})
+		case "wordlist":
+			seen := make(map[string]bool)
+			for _, item := range endpoints {
+				for _, param := range item.hidden {
+					if seen[param.Name] {
+						continue
+					}
+					seen[param.Name] = true
+					if _, err := fmt.Fprintln(w, param.Name); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+		for _, item := range endpoints {
+			for _, param := range item.hidden {
+				if _, err := fmt.Fprintf(w, "%s %s %s %s (%s)\n", item.request.Method, item.request.Url, param.In, param.Name, param.Reason); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Found %d hidden parameter(s) of %d endpoint(s) in %d requests\n", found, len(endpoints), requests)
+	return 0
+}
+
+// paramsRequest returns the request of an endpoint searched for hidden parameters, with the
+// -H headers
+func paramsRequest(conf *ffuf.Config, method, u string, data []byte) *ffuf.Request {
+	req := ffuf.NewRequest(conf)
+	req.Method = method
+	req.Url = u
+	req.Data = data
+	for name, value := range conf.Headers {
+		req.Headers[name] = value
+	}
+	if len(data) > 0 && req.Headers["Content-Type"] == "" {
+		if json.Valid(data) {
+			req.Headers["Content-Type"] = "application/json"
+		} else {
+			req.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+	}
+	return &req
+}
+
+// documentedParameter returns true if a hidden parameter is a parameter of the endpoint
+func documentedParameter(endpoint *parser.DiscoveredEndpoint, hidden *parser.HiddenParameter) bool {
+	for _, param := range endpoint.Parameters {
+		if param.Name == hidden.Name && param.In == hidden.In {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+)
+
+// runAPIPayloads runs the api payloads command and returns the exit code
+func runAPIPayloads(args []string) int {
+	opts := apiFlags{}
+	var sources multiStringFlag
+	var categories, tamper string
+	var replace bool
+	flags := newAPIFlagSet("payloads", "[options]",
+		"List the payload categories of the injection tester, or write their payloads in the format read by\n-payloads, including the payloads of files and remote feeds.",
+		"List the payload categories and their number of payloads.",
+		"ffuf api payloads",
+		"Export the built-in time-based SQL injection payloads to edit them.",
+		"ffuf api payloads -format payloads -category sqli-time -o sqli-time.txt",
+		"Check the payloads a scan would send with a payload directory.",
+		"ffuf api payloads -format payloads -payloads ./payloads -replace",
+		"Preview the SQL injection payloads transformed by a tamper chain.",
+		"ffuf api payloads -format payloads -category sqli-error -tamper randomcase,space2comment")
+	opts.addOutputFlags(flags, "text", "text (a line per category with its number of payloads), payloads (the payloads under [category] lines)")
+	flags.Var(&sources, "payloads", "Payloads added to the built-in payloads: a file, a directory of files named after the categories, or a remote feed URL. Multiple flags are accepted.")
+	flags.StringVar(&categories, "category", "", "Comma separated list of the payload categories. Default: all")
+	flags.BoolVar(&replace, "replace", false, "Replace the built-in payloads of the categories of -payloads instead of adding to them")
+	flags.StringVar(&tamper, "tamper", "", "Comma separated chain of tampers transforming the payloads in order: built-in tampers ("+strings.Join(payload.AvailableTampers(), ", ")+"), executable scripts and Go plugins")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if opts.format != "text" && opts.format != "payloads" {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -format must be text or payloads\n")
+		return 2
+	}
+
+	var selected []string
+	if categories != "" {
+		selected = strings.Split(categories, ",")
+	}
+	var provider security.PayloadProvider
+	if len(sources) > 0 {
+		loaded := security.NewPayloadSet()
+		for _, source := range sources {
+			set, err := security.LoadPayloads(source)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+				return 2
+			}
+			loaded.Merge(set)
+		}
+		provider = loaded
+	}
+	selection, err := security.NewPayloadSelection(provider, selected, replace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	chain, err := payload.NewTamperChain(strings.Split(tamper, ","))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+
+	// The payloads of each category as the injection tester selects them
+	builtin := security.BuiltinPayloads()
+	payloads := security.NewPayloadSet()
+	names := make([]string, 0, len(security.PayloadCategories))
+	for category := range security.PayloadCategories {
+		names = append(names, category)
+	}
+	sort.Strings(names)
+	for _, category := range names {
+		tampered, err := chain.Tamper(selection.Payloads(category, builtin.Payloads(category)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 1
+		}
+		payloads.Add(category, tampered...)
+	}
+
+	err = writeAPIOutput(opts.output, func(w io.Writer) error {
+		if opts.format == "payloads" {
+			return payloads.Write(w, payloads.Categories())
+		}
+		for _, category := range names {
+			if !selection.Selected(category) {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "%-18s %4d  %s\n", category, len(payloads.Payloads(category)), security.PayloadCategories[category]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	return 0
+}
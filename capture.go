@@ -0,0 +1,728 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/capture"
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/reporting"
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+	"github.com/ffuf/ffuf/v2/pkg/api/state"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// captureOptions are the options of the capture subcommand
+type captureOptions struct {
+	listen        string
+	target        string
+	hosts         string
+	importFile    string
+	includeStatic bool
+	inventoryFile string
+	harFile       string
+	caCert        string
+	caKey         string
+	tunnel        bool
+	testsFile     string
+	scan          bool
+	profile       string
+	scoring       string
+	policy        string
+	safeMode      string
+	reportFile    string
+	reportFormat  string
+	reportMermaid string
+	evidenceDir   string
+	baseline      string
+	updateBase    bool
+	failOnNew     bool
+	threads       int
+	parallel      int
+	timeout       int
+	headers       multiStringFlag
+	vars          string
+	notify        notifyOptions
+	logs          runLogOptions
+	maxDuration   time.Duration
+	budgets       multiStringFlag
+	stateFile     string
+	resume        bool
+}
+
+// captureUsage prints the usage of the capture subcommand
+func captureUsage(flags *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Fuzz Faster U Fool - v%s\n\n", ffuf.Version())
+	fmt.Fprintf(os.Stderr, "Usage: ffuf capture [options]\n\n")
+	fmt.Fprintf(os.Stderr, "Run a proxy recording the traffic between a client and an API until interrupted, build an\n")
+	fmt.Fprintf(os.Stderr, "inventory of the endpoints, parameters and schemas of the API, and optionally generate test\n")
+	fmt.Fprintf(os.Stderr, "cases for it and scan it with the security testers.\n\n")
+	flags.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\nEXAMPLE USAGE:\n")
+	fmt.Fprintf(os.Stderr, "  Record the traffic of a client using the proxy, then scan the recorded endpoints.\n")
+	fmt.Fprintf(os.Stderr, "    ffuf capture -listen 127.0.0.1:8080 -ca-cert ca.pem -ca-key ca.key -o inventory.json -scan\n\n")
+	fmt.Fprintf(os.Stderr, "  Record the traffic sent to a reverse proxy in front of an API, and save it as a HAR file.\n")
+	fmt.Fprintf(os.Stderr, "    ffuf capture -listen :8080 -target https://api.example.com -har capture.har -tests tests.json\n\n")
+	fmt.Fprintf(os.Stderr, "  Scan the requests of a Burp Suite export, and write the findings as Burp Suite issues.\n")
+	fmt.Fprintf(os.Stderr, "    ffuf capture -import items.xml -scan -report issues.xml -report-format burp\n\n")
+}
+
+// runCapture runs the capture subcommand and returns the exit code
+func runCapture(args []string) int {
+	opts := captureOptions{}
+	flags := flag.NewFlagSet("capture", flag.ContinueOnError)
+	flags.Usage = func() { captureUsage(flags) }
+	flags.StringVar(&opts.listen, "listen", "127.0.0.1:8080", "Address the proxy listens on")
+	flags.StringVar(&opts.target, "target", "", "Upstream URL of a reverse proxy. Default: run as a forward proxy")
+	flags.StringVar(&opts.hosts, "hosts", "", "Comma separated list of hosts to record. Default: all hosts")
+	flags.StringVar(&opts.importFile, "import", "", "Import the requests of a HAR capture, a Burp Suite XML export or an OWASP ZAP message export instead of running the proxy")
+	flags.BoolVar(&opts.includeStatic, "static", false, "Add requests for static assets (images, scripts, styles, fonts) to the inventory")
+	flags.StringVar(&opts.inventoryFile, "o", "", "Write the inventory of the API to a JSON file")
+	flags.StringVar(&opts.harFile, "har", "", "Write the recorded traffic to a HAR file")
+	flags.StringVar(&opts.caCert, "ca-cert", "", "Certificate of the CA issuing the certificates of intercepted HTTPS connections. Created with the key if it does not exist. Default: a temporary CA")
+	flags.StringVar(&opts.caKey, "ca-key", "", "Key of the CA issuing the certificates of intercepted HTTPS connections")
+	flags.BoolVar(&opts.tunnel, "tunnel", false, "Tunnel HTTPS connections of the forward proxy without intercepting and recording them")
+	flags.StringVar(&opts.testsFile, "tests", "", "Generate test cases for the recorded endpoints and write them to a JSON file")
+	flags.BoolVar(&opts.scan, "scan", false, "Scan the recorded endpoints with the security testers once the capture is stopped")
+	flags.StringVar(&opts.profile, "profile", "quick", "Security scan profile: all, quick, owasp-top10, injection-only")
+	flags.StringVar(&opts.scoring, "scoring", "", "File (YAML or JSON) of CVSS v3.1 vectors overriding the default vectors scoring the findings of the scan, by vulnerability type, finding name regex and CWE")
+	flags.StringVar(&opts.policy, "policy", "", "Scope policy file denying requests of the scan by host, path regex, method and parameter name")
+	flags.StringVar(&opts.safeMode, "safe-mode", "", "Consent level of the scan: read-only or non-destructive. Default: no restrictions")
+	flags.StringVar(&opts.reportFile, "report", "", "Write the vulnerability report of the scan to a file")
+	flags.StringVar(&opts.reportFormat, "report-format", "json", "Format of the vulnerability report: json, sarif, html, md, csv, xlsx, burp (Burp Suite issues XML), har (requests of the findings)")
+	flags.StringVar(&opts.reportMermaid, "report-mermaid", "", "Local Mermaid script embedded in the HTML report to render its API map offline")
+	flags.StringVar(&opts.evidenceDir, "evidence", "", "Write an evidence bundle of every vulnerability found by the scan, with the raw request and response and replay scripts, to a directory referenced from the report")
+	flags.StringVar(&opts.baseline, "baseline", "", "Baseline file of the findings of previous scans. Findings are marked as new or known, and findings of the baseline no longer found as fixed")
+	flags.BoolVar(&opts.updateBase, "update-baseline", false, "Write the findings of the scan to the -baseline file")
+	flags.BoolVar(&opts.failOnNew, "fail-on-new", false, "Exit with an error if the scan finds findings missing from the -baseline file")
+	flags.IntVar(&opts.threads, "t", 10, "Number of concurrent requests of the scan")
+	flags.IntVar(&opts.parallel, "parallel", 10, "Number of endpoints scanned at once, sharing the -t workers round-robin")
+	flags.IntVar(&opts.timeout, "timeout", 10, "HTTP request timeout of the scan in seconds")
+	flags.DurationVar(&opts.maxDuration, "max-duration", 0, "Maximum running time of the scan, e.g. 30m. The riskiest endpoints are scanned first, and the report lists the endpoints not scanned")
+	flags.Var(&opts.budgets, "budget", "Maximum running time of the security testers of a type against each endpoint, in the form type=duration (e.g. injection=2m), or of every tester without a budget with a bare duration. Multiple flags are accepted.")
+	flags.StringVar(&opts.stateFile, "state", "", "File recording the completed requests of the scan, to resume it with -resume if it is interrupted")
+	flags.BoolVar(&opts.resume, "resume", false, "Resume an interrupted scan, replaying the requests recorded in the -state file")
+	flags.Var(&opts.headers, "H", "Header \"Name: Value\" added to the requests of the scan. Multiple flags are accepted.")
+	flags.StringVar(&opts.vars, "vars", "", "YAML, JSON or .env file of the variables replacing {{name}} placeholders in -target and -H. {{env.NAME}} is read from the environment")
+	opts.notify.addFlags(flags, "the scan")
+	flags.StringVar(&opts.logs.eventLog, "event-log", "", "Stream the requests, findings and finished security testers of the scan as NDJSON events to a file, or to stdout with -")
+	opts.logs.addHARFlags(flags, "scan-har", "the scan")
+	opts.logs.addRedactFlags(flags, "the recorded traffic, the inventory, and the reports, evidence bundles, notifications and logs of the scan")
+	opts.logs.addLogFlags(flags, "the capture and the scan")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	redactor, err := opts.logs.redactor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	if opts.parallel < 1 {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -parallel must be at least 1\n")
+		return 2
+	}
+	// The log records of the proxy are written from the start of the capture
+	logOpts := opts.logs.logging()
+	if redactor != nil {
+		logOpts.Redact = redactor.String
+	}
+	if err := logging.Configure(logOpts); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	defer logging.Close()
+	if (opts.caCert == "") != (opts.caKey == "") {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -ca-cert and -ca-key must be set together\n")
+		return 2
+	}
+	if len(opts.notify.webhooks) > 0 && !opts.scan {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -notify requires -scan\n")
+		return 2
+	}
+	if opts.logs.eventLog != "" && !opts.scan {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -event-log requires -scan\n")
+		return 2
+	}
+	if opts.logs.har != "" && !opts.scan {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -scan-har requires -scan\n")
+		return 2
+	}
+	if _, err := security.ParseTesterBudgets(opts.budgets); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+	if opts.evidenceDir != "" && !opts.scan {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -evidence requires -scan\n")
+		return 2
+	}
+	if opts.baseline != "" && !opts.scan {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -baseline requires -scan\n")
+		return 2
+	}
+	if (opts.updateBase || opts.failOnNew) && opts.baseline == "" {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -update-baseline and -fail-on-new require -baseline\n")
+		return 2
+	}
+	if opts.baseline != "" {
+		// The baseline is loaded again once the scan is done, it is validated before the capture starts
+		if _, err := reporting.LoadBaseline(opts.baseline); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+	}
+	if opts.scoring != "" {
+		if !opts.scan {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): -scoring requires -scan\n")
+			return 2
+		}
+		if _, err := security.LoadScoring(opts.scoring); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+	}
+	if opts.policy != "" && !opts.scan {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -policy requires -scan\n")
+		return 2
+	}
+	if opts.stateFile != "" && !opts.scan {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -state requires -scan\n")
+		return 2
+	}
+	if opts.resume && opts.stateFile == "" {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -resume requires -state\n")
+		return 2
+	}
+	if opts.policy != "" {
+		// The policy is loaded again for the scan, it is validated before the capture starts
+		if _, err := ffuf.LoadPolicy(opts.policy); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+	}
+	if opts.safeMode != "" {
+		if !opts.scan {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): -safe-mode requires -scan\n")
+			return 2
+		}
+		if _, err := ffuf.NewSafeMode(opts.safeMode); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+	}
+	variables := parser.NewVariables()
+	if opts.vars != "" {
+		loaded, err := parser.LoadVariables(opts.vars)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 2
+		}
+		variables = loaded
+	}
+	opts.target = variables.Resolve(opts.target)
+	for i := range opts.headers {
+		opts.headers[i] = variables.Resolve(opts.headers[i])
+	}
+	notifier, err := opts.notify.notifier(captureTarget(opts))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 2
+	}
+
+	recorder := capture.NewRecorder()
+	recorder.Redactor = redactor
+	recorder.Parser.IncludeStatic = opts.includeStatic
+	for _, host := range strings.Split(opts.hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			recorder.Parser.Hosts = append(recorder.Parser.Hosts, host)
+		}
+	}
+	if opts.importFile != "" {
+		count, format, err := recorder.ImportFile(opts.importFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Imported %d request(s) from the %s file %s\n", count, format, opts.importFile)
+	} else if err := runCaptureProxy(recorder, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+
+	inventory := recorder.Inventory()
+	fmt.Fprintf(os.Stderr, "\nRecorded %d request(s) to %d endpoint(s)\n", len(recorder.Entries()), len(inventory))
+	if err := writeCaptureFile(opts.harFile, recorder.WriteHAR); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	if err := writeCaptureFile(opts.inventoryFile, recorder.WriteInventory); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	if opts.testsFile != "" {
+		if err := writeCapturedTests(recorder, opts.testsFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 1
+		}
+	}
+	if opts.scan {
+		if err := scanCaptured(recorder, opts, notifier); err != nil {
+			fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// runCaptureProxy records the requests sent through the proxy until interrupted
+func runCaptureProxy(recorder *capture.Recorder, opts captureOptions) error {
+	recorder.OnRecord = func(entry *capture.Entry) {
+		fmt.Fprintf(os.Stderr, "[%d] %s %s\n", entry.Response.Status, entry.Request.Method, entry.Request.URL)
+	}
+	proxy, err := capture.NewProxy(recorder, opts.target)
+	if err != nil {
+		return err
+	}
+	if opts.target == "" && !opts.tunnel {
+		if proxy.CA, err = captureCA(opts.caCert, opts.caKey); err != nil {
+			return err
+		}
+	}
+
+	listener, err := net.Listen("tcp", opts.listen)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: proxy}
+	go server.Serve(listener)
+	if opts.target != "" {
+		fmt.Fprintf(os.Stderr, "Recording requests to %s through %s, press Ctrl-C to stop\n", opts.target, listener.Addr())
+	} else {
+		fmt.Fprintf(os.Stderr, "Recording requests through the proxy %s, press Ctrl-C to stop\n", listener.Addr())
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	<-interrupt
+	signal.Stop(interrupt)
+	shutdown, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	return server.Shutdown(shutdown)
+}
+
+// captureCA loads the CA of intercepted HTTPS connections, or creates it if its files do not
+// exist. A temporary CA, whose certificate is written to the working directory, is created if
+// no files are set.
+func captureCA(certFile, keyFile string) (*capture.CertificateAuthority, error) {
+	if certFile != "" {
+		if _, err := os.Stat(certFile); err == nil {
+			return capture.LoadCertificateAuthority(certFile, keyFile)
+		}
+	}
+	ca, err := capture.NewCertificateAuthority()
+	if err != nil {
+		return nil, err
+	}
+	if certFile != "" {
+		if err := ca.Save(certFile, keyFile); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Created the CA certificate %s, trust it in the client to record HTTPS requests\n", certFile)
+		return ca, nil
+	}
+	if err := os.WriteFile("ffuf-capture-ca.pem", ca.CertificatePEM(), 0644); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Created the temporary CA certificate ffuf-capture-ca.pem, trust it in the client to record HTTPS requests\n")
+	return ca, nil
+}
+
+// writeCaptureFile writes a file with a write function, if the file name is set
+func writeCaptureFile(filename string, write func(w io.Writer) error) error {
+	if filename == "" {
+		return nil
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return write(f)
+}
+
+// writeCapturedTests generates test cases for the recorded endpoints and writes them to a file
+func writeCapturedTests(recorder *capture.Recorder, filename string) error {
+	discovery := recorder.Discovery()
+	extractor := parser.NewAPIParameterExtractor(discovery)
+	if err := extractor.ExtractParameters(); err != nil {
+		return err
+	}
+	generator := parser.NewAPITestGenerator(discovery, extractor)
+	generator.Values = recorder.Values()
+	generator.AddDefaultTemplates()
+	if err := generator.GenerateTestCases(); err != nil {
+		return err
+	}
+	data, err := generator.ExportTestCasesToJSON()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Generated %d test case(s)\n", len(generator.GetTestCases()))
+	return os.WriteFile(filename, []byte(data), 0644)
+}
+
+// scanCaptured scans the recorded endpoints with the security testers of the profile
+func scanCaptured(recorder *capture.Recorder, opts captureOptions, notifier *reporting.Notifier) error {
+	ctx, cancel := withMaxDuration(context.Background(), opts.maxDuration)
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Threads = opts.threads
+	conf.APITargetsParallel = opts.parallel
+	conf.Timeout = opts.timeout
+	conf.APISecurityProfile = opts.profile
+	conf.APISecurityScoring = opts.scoring
+	conf.APISecurityBudgets = opts.budgets
+	conf.APIStateFile = opts.stateFile
+	conf.APIResume = opts.resume
+	if opts.policy != "" {
+		policy, err := ffuf.LoadPolicy(opts.policy)
+		if err != nil {
+			return err
+		}
+		conf.APIPolicy = opts.policy
+		conf.Policy = policy
+	}
+	if opts.safeMode != "" {
+		safeMode, err := ffuf.NewSafeMode(opts.safeMode)
+		if err != nil {
+			return err
+		}
+		conf.APISafeMode = opts.safeMode
+		conf.SafeMode = safeMode
+	}
+	for _, header := range opts.headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) == 2 {
+			conf.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	logs, err := openRunLogs(opts.logs)
+	if err != nil {
+		return err
+	}
+	if notifier != nil {
+		notifier.Redactor = logs.redactor
+		ctx = security.WithResultHandler(ctx, notifier.HandleResult)
+	}
+	defer logs.close()
+	logs.start("capture", []string{captureTarget(opts)})
+	defer logs.finish("capture")
+	results, skipped, err := scanTargets(ctx, &conf, recorder.Targets(), recorder.Values(), logs)
+	if err != nil {
+		return err
+	}
+
+	report := reporting.NewVulnerabilityReport(captureTarget(opts), results)
+	report.SkippedEndpoints = skipped
+	outputs := scanOutputs{
+		mermaid:     opts.reportMermaid,
+		evidenceDir: opts.evidenceDir,
+		baseline:    opts.baseline,
+		updateBase:  opts.updateBase,
+		failOnNew:   opts.failOnNew,
+		redactor:    logs.redactor,
+	}
+	if opts.reportFile != "" {
+		outputs.reports = []scanReport{{file: opts.reportFile, format: opts.reportFormat}}
+	}
+	if err := finishScan(report, &conf, outputs, notifier); err != nil {
+		return err
+	}
+	return interrupted(ctx)
+}
+
+// interrupted returns an error if a scan was interrupted, once its partial results are
+// reported
+func interrupted(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return fmt.Errorf("the scan was interrupted, its reports only cover the requests sent before")
+	}
+	return nil
+}
+
+// scanOutputs are the reports, evidence bundles and baseline written at the end of a scan
+type scanOutputs struct {
+	reports     []scanReport
+	mermaid     string
+	evidenceDir string
+	baseline    string
+	updateBase  bool
+	failOnNew   bool
+	// redactor masks the tokens and personal data of the report, evidence bundles and baseline
+	redactor *secrets.Redactor
+}
+
+// scanReport is a report file of a scan and its format
+type scanReport struct {
+	file   string
+	format string
+}
+
+// scanTargets scans the targets with the security testers selected by the config, scanning
+// config.APITargetsParallel targets at once, until the context is done. The targets scanned
+// at once share the config.Threads workers, granted round-robin across their endpoints, so
+// that the partial results of a scan cover many endpoints early. A scan reaching the deadline
+// of the context, its maximum duration, or interrupted returns the results so far, the
+// testers it stopped being incomplete, and the endpoints it did not scan. The candidate
+// parameter values, completed with those of the responses of the scan, provide real object
+// identifiers to the testers. The requests, findings and testers of the scan are written to
+// the run logs.
+func scanTargets(ctx context.Context, conf *ffuf.Config, targets []*capture.Target, values *parser.ValueDictionary, logs *runLogs) ([]*security.TestResult, []string, error) {
+	ctx = logs.scanContext(ctx)
+	ctx = security.WithValueDictionary(ctx, values)
+	if conf.APISecurityWAF {
+		// The WAF of each host is fingerprinted once
+		ctx = security.WithWAFDetector(ctx, security.NewWAFDetector())
+	}
+	if conf.APISecurityFingerprint {
+		// The technologies of each host are fingerprinted once
+		ctx = security.WithTechnologyDetector(ctx, security.NewTechnologyDetector())
+	}
+	// The login attempts to each endpoint are capped across the targets
+	ctx = security.WithLoginGuard(ctx, security.NewLoginGuard(conf.APISecurityLoginAttempts, conf.APISecurityAccountSafe))
+	// The out-of-band payloads of every target are sent to a single callback listener
+	tracker, err := security.NewConfiguredOOBTracker(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tracker != nil {
+		defer tracker.Provider.Close()
+		ctx = security.WithOOBTracker(ctx, tracker)
+	}
+	pool := security.NewWorkerPool(conf)
+	defer pool.Close()
+	ctx = security.WithWorkerPool(ctx, pool)
+	// The completed requests of every target are recorded to a single state file, redacted
+	// like the reports
+	store, err := state.OpenConfigured(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if store != nil {
+		if logs.redactor != nil {
+			store.Redactor = logs.redactor
+		}
+		defer store.Close()
+		ctx = security.WithStateStore(ctx, store)
+	}
+
+	parallel := make(chan struct{}, conf.APITargetsParallel)
+	if conf.APITargetsParallel < 1 {
+		parallel = make(chan struct{}, 1)
+	}
+	targetResults := make([][]*security.TestResult, len(targets))
+	var skipped []string
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var scanErr error
+	for i, target := range targets {
+		select {
+		case parallel <- struct{}{}:
+		case <-ctx.Done():
+		}
+		mu.Lock()
+		failed := scanErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		if ctx.Err() != nil {
+			for _, target := range targets[i:] {
+				skipped = append(skipped, target.Method+" "+target.URL)
+			}
+			break
+		}
+		fmt.Fprintf(os.Stderr, "Scanning %s %s\n", target.Method, target.URL)
+		wg.Add(1)
+		go func(i int, target *capture.Target) {
+			defer wg.Done()
+			defer func() { <-parallel }()
+			targetConf := *conf
+			targetConf.Url = target.URL
+			targetConf.Method = target.Method
+			targetCtx := ctx
+			if logs.events != nil {
+				targetCtx = security.WithResultHandler(ctx, logs.events.ResultHandler(target.URL))
+			}
+			var err error
+			targetResults[i], err = security.RunConfiguredSecurityTests(targetCtx, &targetConf)
+			if err != nil && ctx.Err() == nil {
+				mu.Lock()
+				if scanErr == nil {
+					scanErr = err
+				}
+				mu.Unlock()
+			}
+		}(i, target)
+	}
+	wg.Wait()
+
+	// The results are ordered like the targets, regardless of the order they completed in
+	results := make([]*security.TestResult, 0)
+	for _, r := range targetResults {
+		results = append(results, r...)
+	}
+	if scanErr != nil {
+		return results, nil, scanErr
+	}
+	return results, skipped, nil
+}
+
+// finishScan prints the summary of a scan, compares its findings with the baseline and writes
+// its outputs. It returns an error if new findings fail the scan.
+func finishScan(report *reporting.VulnerabilityReport, conf *ffuf.Config, outputs scanOutputs, notifier *reporting.Notifier) error {
+	report.Redact(outputs.redactor)
+	if conf.Policy != nil {
+		report.PolicyViolations = conf.Policy.Violations()
+		reportPolicy(conf)
+	}
+	if conf.SafeMode != nil {
+		reportSafeMode(conf)
+	}
+	for severity, count := range report.SeverityCounts() {
+		if count > 0 {
+			fmt.Fprintf(os.Stderr, "%s: %d\n", severity, count)
+		}
+	}
+	if incomplete := report.IncompleteSummary(); incomplete != "" {
+		logging.For("scan").Warn(incomplete)
+	}
+	if waf := report.WAFSummary(); waf != "" {
+		logging.For("scan").Info(waf)
+	}
+	if technologies := report.TechnologySummary(); technologies != "" {
+		logging.For("scan").Info(technologies)
+	}
+	var baseline *reporting.Baseline
+	if outputs.baseline != "" {
+		loaded, err := reporting.LoadBaseline(outputs.baseline)
+		if err != nil {
+			return err
+		}
+		baseline = loaded
+		report.CompareBaseline(baseline)
+		counts := report.BaselineCounts()
+		fmt.Fprintf(os.Stderr, "Baseline: %d new, %d known, %d fixed\n", counts[reporting.FindingNew], counts[reporting.FindingKnown], counts[reporting.FindingFixed])
+	}
+	if outputs.evidenceDir != "" {
+		bundles, err := report.WriteEvidenceBundles(outputs.evidenceDir)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d evidence bundle(s) to %s\n", len(bundles), outputs.evidenceDir)
+	}
+	if notifier != nil {
+		notifier.NotifySummary(report)
+	}
+	report.MermaidScript = outputs.mermaid
+	for _, output := range outputs.reports {
+		generated, err := report.Generate(reporting.CoverageFormat(output.format))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(output.file, []byte(generated), 0644); err != nil {
+			return err
+		}
+	}
+	if baseline == nil {
+		return nil
+	}
+	if outputs.updateBase {
+		if err := report.NewBaseline(baseline).Save(outputs.baseline); err != nil {
+			return err
+		}
+	}
+	if count := report.BaselineCounts()[reporting.FindingNew]; outputs.failOnNew && count > 0 {
+		return fmt.Errorf("%d new finding(s) missing from the baseline %s", count, outputs.baseline)
+	}
+	return nil
+}
+
+// captureTarget returns the name of the scanned target in reports and notifications
+func captureTarget(opts captureOptions) string {
+	if opts.target == "" {
+		return "ffuf capture"
+	}
+	return opts.target
+}
+
+// notifyOptions are the webhooks the findings and the summary of a scan are sent to, the
+// minimum severity of the findings sent and the templates of the notifications
+type notifyOptions struct {
+	webhooks multiStringFlag
+	severity string
+	finding  string
+	summary  string
+}
+
+// addFlags adds the -notify flags of a subcommand
+func (o *notifyOptions) addFlags(flags *flag.FlagSet, subject string) {
+	flags.Var(&o.webhooks, "notify", "Send the findings of "+subject+" as they are found, and its summary, to a webhook URL. Use slack=URL, teams=URL or webhook=URL to set the kind of the webhook. Multiple flags are accepted.")
+	flags.StringVar(&o.severity, "notify-severity", "Info", "Minimum severity of the findings sent to -notify: Critical, High, Medium, Low, Info")
+	flags.StringVar(&o.finding, "notify-template", "", "Go text/template of finding notifications, e.g. \"{{.Finding.Severity}}: {{.Finding.Name}}\"")
+	flags.StringVar(&o.summary, "notify-summary-template", "", "Go text/template of the summary notification, e.g. \"{{.Findings}} findings on {{.Target}}\"")
+}
+
+// notifier creates the notifier of the -notify webhooks for a target, or nil if there are none
+func (o *notifyOptions) notifier(target string) (*reporting.Notifier, error) {
+	if len(o.webhooks) == 0 {
+		return nil, nil
+	}
+	notifier := reporting.NewNotifier(target)
+	for _, spec := range o.webhooks {
+		sink, err := reporting.NewNotificationSink(spec)
+		if err != nil {
+			return nil, err
+		}
+		notifier.Sinks = append(notifier.Sinks, sink)
+	}
+	for _, severity := range []string{"Critical", "High", "Medium", "Low", "Info"} {
+		if strings.EqualFold(severity, o.severity) {
+			notifier.MinimumSeverity = severity
+		}
+	}
+	if !strings.EqualFold(notifier.MinimumSeverity, o.severity) {
+		return nil, fmt.Errorf("-notify-severity must be one of Critical, High, Medium, Low, Info")
+	}
+	if err := notifier.SetTemplates(o.finding, o.summary); err != nil {
+		return nil, err
+	}
+	notifier.OnError = func(sink reporting.NotificationSink, err error) {
+		logging.For("notify").Error("Notification failed", "sink", sink.Name(), logging.FieldError, err)
+	}
+	return notifier, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/reporting"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+// newCoverageAnalyzer imports the endpoints of the -api-coverage specification, Postman
+// collection, HAR file or Burp Suite/OWASP ZAP export into a coverage analyzer
+func newCoverageAnalyzer(conf *ffuf.Config) (*reporting.CoverageAnalyzer, error) {
+	options := reporting.DefaultCoverageOptions()
+	options.OutputFile = conf.APICoverageReport
+	options.MermaidScript = conf.APIReportMermaid
+	if conf.APICoverageReport != "" {
+		switch format := reporting.CoverageFormat(strings.TrimPrefix(strings.ToLower(filepath.Ext(conf.APICoverageReport)), ".")); format {
+		case reporting.FormatJSON, reporting.FormatHTML, reporting.FormatMarkdown, reporting.FormatText, reporting.FormatCSV, reporting.FormatXLSX:
+			options.Format = format
+		default:
+			return nil, fmt.Errorf("-api-coverage-report must end with .json, .html, .md, .txt, .csv or .xlsx")
+		}
+	}
+
+	discovery, err := discoverEndpoints(conf.APICoverageSpec, runner.NewHTTPClient(conf, 30*time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("could not import -api-coverage: %s", err)
+	}
+
+	analyzer := reporting.NewCoverageAnalyzer(options)
+	analyzer.ImportFromDiscovery(discovery)
+	return analyzer, nil
+}
+
+// discoverEndpoints imports the endpoints of an OpenAPI/Swagger specification, Postman
+// collection, HAR file or Burp Suite/OWASP ZAP export. Specifications of URLs are fetched with
+// the client, or the default client of the parser if nil.
+func discoverEndpoints(spec string, client *http.Client) (*parser.APIEndpointDiscovery, error) {
+	discovery := parser.NewAPIEndpointDiscovery("")
+	discovery.Client = client
+	var err error
+	if strings.HasSuffix(strings.ToLower(spec), ".har") {
+		err = discovery.DiscoverFromHAR(spec)
+	} else if err = discovery.DiscoverFromOpenAPI(spec); err != nil && !strings.HasPrefix(spec, "http://") && !strings.HasPrefix(spec, "https://") {
+		// Not an OpenAPI/Swagger specification, try it as a Postman collection, then as a Burp
+		// Suite or OWASP ZAP export
+		discovery = parser.NewAPIEndpointDiscovery("")
+		if postmanErr := discovery.DiscoverFromPostman(spec); postmanErr == nil {
+			err = nil
+		} else {
+			discovery = parser.NewAPIEndpointDiscovery("")
+			if proxyErr := discovery.DiscoverFromProxyExport(spec); proxyErr == nil {
+				err = nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(discovery.GetEndpoints()) == 0 {
+		return nil, fmt.Errorf("no endpoints found in %s", spec)
+	}
+	return discovery, nil
+}
+
+// reportCoverage prints the coverage summary at the end of the run and writes the coverage
+// report. It returns false if the coverage is below -api-coverage-min.
+func reportCoverage(conf *ffuf.Config, analyzer *reporting.CoverageAnalyzer) bool {
+	stats := analyzer.GetCoverageStats()
+	exercised := stats["exercised_coverage"].(float64)
+	fmt.Fprintf(os.Stderr, "\n:: API coverage of %s\n", conf.APICoverageSpec)
+	fmt.Fprintf(os.Stderr, ":: Endpoints         : %d/%d requested (%.1f%%), %d fully tested, %d partially tested, %d with errors\n",
+		stats["exercised_endpoints"], stats["total_endpoints"], exercised,
+		stats["tested_endpoints"], stats["partial_endpoints"], stats["error_endpoints"])
+	fmt.Fprintf(os.Stderr, ":: Parameters        : %d/%d tested (%.1f%%)\n",
+		stats["tested_parameters"], stats["total_parameters"], stats["parameter_coverage"])
+	if unmatched := analyzer.UnmatchedRequests(); unmatched > 0 {
+		fmt.Fprintf(os.Stderr, ":: Unmatched requests: %d\n", unmatched)
+	}
+
+	if conf.APICoverageHistory != "" {
+		recordCoverageHistory(conf, analyzer)
+	}
+
+	if conf.APICoverageReport != "" {
+		report, err := analyzer.GenerateReport()
+		if err == nil {
+			err = os.WriteFile(conf.APICoverageReport, []byte(report), 0644)
+		}
+		if err != nil {
+			logging.For("coverage").Error("Could not write the coverage report", "file", conf.APICoverageReport, logging.FieldError, err)
+		}
+	}
+
+	if exercised < conf.APICoverageMin {
+		fmt.Fprintf(os.Stderr, "[ERR] API coverage %.1f%% is below the minimum of %.1f%%\n", exercised, conf.APICoverageMin)
+		return false
+	}
+	return true
+}
+
+// recordCoverageHistory appends the coverage of the run to the -api-coverage-history file,
+// prints the change since the previous run of the target and shows the trend in the report
+func recordCoverageHistory(conf *ffuf.Config, analyzer *reporting.CoverageAnalyzer) {
+	target := conf.Url
+	if u, err := url.Parse(conf.Url); err == nil && u.Host != "" {
+		target = u.Scheme + "://" + u.Host
+	}
+	history := reporting.NewCoverageHistory(conf.APICoverageHistory)
+	snapshot := analyzer.Snapshot(target, nil)
+	previous, err := history.Load(snapshot.Target, snapshot.SpecVersion)
+	if err == nil {
+		err = history.Append(snapshot)
+	}
+	if err != nil {
+		logging.For("coverage").Error("Could not update the coverage history", "file", conf.APICoverageHistory, logging.FieldError, err)
+		return
+	}
+	if len(previous) > 0 {
+		last := previous[len(previous)-1]
+		fmt.Fprintf(os.Stderr, ":: Trend             : %.1f%% -> %.1f%% (%+.1f) since %s\n", last.ExercisedCoverage,
+			snapshot.ExercisedCoverage, snapshot.ExercisedCoverage-last.ExercisedCoverage, last.Timestamp.Format("2006-01-02 15:04"))
+	}
+	analyzer.SetHistory(append(previous, snapshot))
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/distributed"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/output"
+)
+
+// runCoordinator shards the first wordlist of the scan, for every target of -api-targets,
+// and leases the shards to the workers started with "ffuf worker" until every shard is
+// completed. The results reported by the workers are printed as they arrive and written to
+// the output file at the end. It returns the exit code of the run.
+func runCoordinator(opts *ffuf.ConfigOptions, ctx context.Context, cancel context.CancelFunc) int {
+	c, conf, err := newCoordinator(opts, ctx, cancel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	defer c.Close()
+
+	ln, err := net.Listen("tcp", opts.API.Coordinator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): could not start the coordinator: %s\n", err)
+		return 1
+	}
+	server := &http.Server{Handler: c}
+	go server.Serve(ln)
+	defer server.Close()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	completed, total := c.Progress()
+	fmt.Fprintf(os.Stderr, ":: Coordinator listening on %s, %d/%d shard(s) of up to %d words completed\n", ln.Addr(), completed, total, opts.API.ShardSize)
+	if opts.API.DistToken == "" {
+		fmt.Fprintf(os.Stderr, ":: Start the workers with: ffuf worker -coordinator http://%s -token %s\n", ln.Addr(), c.Token)
+	}
+
+	progress := func() {
+		if !conf.Quiet {
+			completed, total := c.Progress()
+			fmt.Fprintf(os.Stderr, "%s:: Shards: [%d/%d] ::", output.TERMINAL_CLEAR_LINE, completed, total)
+		}
+	}
+	ticker := time.NewTicker(time.Duration(conf.ProgressFrequency) * time.Millisecond)
+	defer ticker.Stop()
+	interrupted := false
+	for running := true; running; {
+		select {
+		case <-c.Done():
+			progress()
+			// Idle workers polling for shards learn that the scan is completed
+			time.Sleep(3 * time.Second)
+			running = false
+		case <-interrupt:
+			interrupted = true
+			running = false
+		case <-ticker.C:
+			progress()
+		}
+	}
+	cancel()
+
+	out := output.NewOutputProviderByName("stdout", conf)
+	out.SetCurrentResults(c.Results())
+	if err := out.Finalize(); err != nil {
+		out.Error(err.Error())
+	}
+	if interrupted {
+		completed, total := c.Progress()
+		if conf.APIStateFile != "" {
+			fmt.Fprintf(os.Stderr, ":: Interrupted with %d/%d shard(s) completed, resume the scan with -api-resume\n", completed, total)
+		} else {
+			fmt.Fprintf(os.Stderr, ":: Interrupted with %d/%d shard(s) completed\n", completed, total)
+		}
+		return 1
+	}
+	if failures := c.Failures(); len(failures) > 0 {
+		fmt.Fprintf(os.Stderr, ":: %d shard(s) failed on every worker they were leased to\n", len(failures))
+		return 1
+	}
+	return 0
+}
+
+// newCoordinator validates the options of the scan and creates the coordinator of its shards,
+// along with the config of the merged results
+func newCoordinator(opts *ffuf.ConfigOptions, ctx context.Context, cancel context.CancelFunc) (*distributed.Coordinator, *ffuf.Config, error) {
+	if len(opts.Input.Wordlists) == 0 || len(opts.Input.Inputcommands) > 0 {
+		return nil, nil, fmt.Errorf("-api-coordinator shards the first wordlist of -w, and cannot be combined with -input-cmd")
+	}
+	if opts.API.CoverageSpec != "" {
+		return nil, nil, fmt.Errorf("-api-coverage is not supported with -api-coordinator")
+	}
+	var targets []string
+	if opts.API.Targets != "" {
+		var err error
+		if targets, err = readTargets(opts.API.Targets); err != nil {
+			return nil, nil, err
+		}
+		if len(targets) == 0 {
+			return nil, nil, fmt.Errorf("no targets found in %s", opts.API.Targets)
+		}
+	}
+	words, err := readWords(strings.SplitN(opts.Input.Wordlists[0], ":", 2)[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	job := distributed.Job{Options: workerOptions(opts), Flags: visitedFlags()}
+
+	// The config of the first target validates the options and formats the merged results
+	confOpts := copyOptions(job.Options)
+	confOpts.Output = opts.Output
+	confOpts.API.StateFile = opts.API.StateFile
+	confOpts.API.Resume = opts.API.Resume
+	confOpts.API.Coordinator = opts.API.Coordinator
+	confOpts.API.ShardSize = opts.API.ShardSize
+	if len(targets) > 0 {
+		confOpts.HTTP.URL = targetURL(targets[0], opts.HTTP.URL)
+		// Results of all targets are printed to the same terminal, so they are printed with their URL
+		confOpts.General.Verbose = !opts.General.Json
+	}
+	conf, err := ffuf.ConfigFromOptions(confOpts, ctx, cancel)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := SetupFilters(confOpts, conf); err != nil {
+		return nil, nil, err
+	}
+
+	c := distributed.NewCoordinator(job, distributed.SplitShards(targets, words, conf.APIShardSize))
+	c.Token = opts.API.DistToken
+	if c.Token == "" {
+		token := make([]byte, 16)
+		if _, err := rand.Read(token); err != nil {
+			return nil, nil, err
+		}
+		c.Token = hex.EncodeToString(token)
+	}
+	if conf.APIStateFile != "" {
+		if err := c.OpenState(conf.APIStateFile, conf.APIResume); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	out := output.NewOutputProviderByName("stdout", conf)
+	var mu sync.Mutex
+	c.OnReport = func(shard distributed.Shard, report distributed.Report) {
+		mu.Lock()
+		defer mu.Unlock()
+		if report.Error != "" {
+			out.Warning(fmt.Sprintf("Shard %d abandoned, failed on %s: %s", shard.ID, report.Worker, report.Error))
+			return
+		}
+		for _, result := range report.Results {
+			out.PrintResult(result)
+		}
+	}
+	return c, conf, nil
+}
+
+// workerOptions returns the options of the scan sent to the workers: a copy of the options
+// without the options of the coordinator and the output files, which are written by the
+// coordinator
+func workerOptions(opts *ffuf.ConfigOptions) *ffuf.ConfigOptions {
+	shared := *opts
+	// Cookies are not serialized, they are sent as a header
+	shared.HTTP.Headers = append([]string{}, opts.HTTP.Headers...)
+	if len(opts.HTTP.Cookies) > 0 {
+		shared.HTTP.Headers = append(shared.HTTP.Headers, "Cookie: "+strings.Join(opts.HTTP.Cookies, "; "))
+	}
+	shared.HTTP.Cookies = nil
+	shared.Output = ffuf.NewConfigOptions().Output
+	shared.General.ConfigFile = ""
+	shared.API.Coordinator = ""
+	shared.API.DistToken = ""
+	shared.API.Targets = ""
+	shared.API.StateFile = ""
+	shared.API.Resume = false
+	return copyOptions(&shared)
+}
+
+// copyOptions returns a deep copy of options, as sent to the workers
+func copyOptions(opts *ffuf.ConfigOptions) *ffuf.ConfigOptions {
+	data, _ := json.Marshal(opts)
+	copied := &ffuf.ConfigOptions{}
+	json.Unmarshal(data, copied)
+	return copied
+}
+
+// readWords reads the lines of a wordlist, from stdin if the path is -
+func readWords(path string) ([]string, error) {
+	file := os.Stdin
+	if path != "-" {
+		var err error
+		if file, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		defer file.Close()
+	}
+	words := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		words = append(words, scanner.Text())
+	}
+	return words, scanner.Err()
+}
+
+// workerUsage prints the usage of the worker subcommand
+func workerUsage(flags *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Fuzz Faster U Fool - v%s\n\n", ffuf.Version())
+	fmt.Fprintf(os.Stderr, "Usage: ffuf worker -coordinator URL -token TOKEN\n\n")
+	fmt.Fprintf(os.Stderr, "Fuzz the shards of a scan leased by a coordinator started with -api-coordinator, and\n")
+	fmt.Fprintf(os.Stderr, "report their results until the scan is completed. The options of the scan are set by the\n")
+	fmt.Fprintf(os.Stderr, "coordinator, files other than the first wordlist must exist at the same path on the worker.\n\n")
+	flags.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\nEXAMPLE USAGE:\n")
+	fmt.Fprintf(os.Stderr, "  Coordinate a scan of the targets of a file, and run a worker on every scanning host.\n")
+	fmt.Fprintf(os.Stderr, "    ffuf -api-coordinator :9400 -api-dist-token s3cret -api-targets targets.txt -u /FUZZ -w words.txt -o results.json\n")
+	fmt.Fprintf(os.Stderr, "    ffuf worker -coordinator http://coordinator.internal:9400 -token s3cret\n\n")
+}
+
+// runWorker runs the worker subcommand and returns the exit code
+func runWorker(args []string) int {
+	hostname, _ := os.Hostname()
+	var coordinator, token, name string
+	flags := flag.NewFlagSet("worker", flag.ContinueOnError)
+	flags.Usage = func() { workerUsage(flags) }
+	flags.StringVar(&coordinator, "coordinator", "", "URL of the coordinator")
+	flags.StringVar(&token, "token", "", "Token authenticating the worker, set with -api-dist-token on the coordinator")
+	flags.StringVar(&name, "name", fmt.Sprintf("%s-%d", hostname, os.Getpid()), "Name of the worker in the leases of the coordinator")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if coordinator == "" {
+		workerUsage(flags)
+		fmt.Fprintf(os.Stderr, "Encountered error(s): -coordinator is required\n")
+		return 2
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	worker := distributed.NewWorker(coordinator, token, name)
+	job, err := worker.FetchJob(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	executed, err := worker.Run(ctx, func(ctx context.Context, shard distributed.Shard) ([]ffuf.Result, error) {
+		return executeShard(ctx, job, shard)
+	})
+	fmt.Fprintf(os.Stderr, ":: Worker %s executed %d shard(s)\n", name, executed)
+	if err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// executeShard fuzzes the words of a shard with the options of the job, and returns the
+// matching results
+func executeShard(ctx context.Context, dist *distributed.Job, shard distributed.Shard) ([]ffuf.Result, error) {
+	opts := copyOptions(dist.Options)
+	if shard.Target != "" {
+		opts.HTTP.URL = targetURL(shard.Target, opts.HTTP.URL)
+	}
+	opts.General.Noninteractive = true
+	opts.General.Quiet = true
+	opts.API.OutputFormat = false
+
+	// The words of the shard replace the first wordlist
+	file, err := os.CreateTemp("", "ffuf-shard-*.txt")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(strings.Join(shard.Words, "\n") + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	wordlist := strings.SplitN(opts.Input.Wordlists[0], ":", 2)
+	wordlist[0] = file.Name()
+	opts.Input.Wordlists[0] = strings.Join(wordlist, ":")
+
+	shardCtx, shardCancel := context.WithCancel(ctx)
+	defer shardCancel()
+	conf, err := ffuf.ConfigFromOptions(opts, shardCtx, shardCancel)
+	if err != nil {
+		return nil, err
+	}
+	job, err := prepareJob(conf, nil)
+	if job.AuditLogger != nil {
+		defer job.AuditLogger.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := setupFilters(opts, conf, dist.Flags); err != nil {
+		return nil, err
+	}
+	job.Start()
+
+	out, ok := job.Output.(*output.Stdoutput)
+	if !ok {
+		return nil, fmt.Errorf("unsupported output provider")
+	}
+	return append(out.Results, out.CurrentResults...), nil
+}
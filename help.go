@@ -54,7 +54,7 @@ func Usage() {
 		Description:   "Options controlling the HTTP request and its parts.",
 		Flags:         make([]UsageFlag, 0),
 		Hidden:        false,
-		ExpectedFlags: []string{"cc", "ck", "H", "X", "b", "d", "r", "u", "raw", "recursion", "recursion-depth", "recursion-strategy", "replay-proxy", "timeout", "ignore-body", "x", "sni", "http2"},
+		ExpectedFlags: []string{"cc", "ck", "H", "X", "b", "d", "r", "u", "raw", "recursion", "recursion-depth", "recursion-strategy", "replay-proxy", "timeout", "ignore-body", "x", "proxy-list", "resolve", "sni", "http2", "http-version"},
 	}
 	u_general := UsageSection{
 		Name:          "GENERAL OPTIONS",
@@ -103,7 +103,7 @@ func Usage() {
 		Description:   "Options for API testing mode and specialized API functionality.",
 		Flags:         make([]UsageFlag, 0),
 		Hidden:        false,
-		ExpectedFlags: []string{"api-mode", "api-output", "api-wordlist", "api-wordlist-category", "api-auth-type", "api-auth-user", "api-auth-pass", "api-auth-token", "api-auth-key", "api-auth-key-name", "api-auth-key-loc", "api-payload-format", "api-payload-template", "api-payload-path", "api-parse-response", "api-extract-endpoints"},
+		ExpectedFlags: []string{"api-mode", "api-output", "api-wordlist", "api-wordlist-category", "api-auth-type", "api-auth-user", "api-auth-pass", "api-auth-token", "api-auth-key", "api-auth-key-name", "api-auth-key-loc", "api-payload-format", "api-payload-template", "api-payload-path", "api-request-fuzz", "api-request-name", "api-vars", "api-event-log", "api-har", "api-har-max-body", "api-har-max-size", "api-redact", "api-redact-pattern", "api-log-level", "api-log-format", "api-log-file", "api-parse-response", "api-extract-endpoints", "api-security-profile", "api-security-include", "api-security-exclude", "api-security-option", "api-security-scoring", "api-security-budget", "api-security-payloads", "api-security-payload-categories", "api-security-payloads-replace", "api-security-tamper", "api-security-waf", "api-security-fingerprint", "api-security-login-attempts", "api-security-account-safe", "api-security-oob-listen", "api-security-oob-url", "api-security-oob-dns", "api-security-oob-domain", "api-security-header", "api-security-identity", "api-security-proxy", "api-state", "api-resume", "api-grpc", "api-proto", "api-proto-message", "api-websocket", "api-stream-time", "api-stream-events", "api-adaptive-rate", "api-backoff-max", "api-retries", "api-retry-delay", "api-max-body", "api-targets", "api-targets-parallel", "api-coordinator", "api-dist-token", "api-shard-size", "api-policy", "api-safe-mode", "api-login-url", "api-login-type", "api-login-data", "api-login-client", "api-login-scope", "api-login-token", "api-login-header", "api-sign", "api-coverage", "api-coverage-min", "api-coverage-report", "api-coverage-history", "api-report-mermaid"},
 	}
 	sections := []UsageSection{u_http, u_general, u_compat, u_matcher, u_filter, u_input, u_output, u_api}
 
@@ -165,6 +165,12 @@ func Usage() {
 	fmt.Printf("    ffuf -w params.txt -u https://api.example.com/endpoint -X POST -H \"Content-Type: application/json\" \\\n")
 	fmt.Printf("      -d '{\"FUZZ\":\"value\"}' -api-parse-response\n\n")
 
+	fmt.Printf("  Record the traffic of a client through a proxy to build an API inventory, then scan it.\n")
+	fmt.Printf("    ffuf capture -listen 127.0.0.1:8080 -o inventory.json -scan -report report.sarif -report-format sarif\n\n")
+
+	fmt.Printf("  Scan the endpoints of an OpenAPI specification on a staging server, without fuzzing.\n")
+	fmt.Printf("    ffuf api scan -spec openapi.json -target https://staging.example.com -o report.sarif\n\n")
+
 	fmt.Printf("  More information and examples: https://github.com/ffuf/ffuf\n")
 	fmt.Printf("  API Testing Documentation: https://github.com/ffuf/ffuf/tree/master/docs/api\n\n")
 }
@@ -7,9 +7,18 @@ import (
 	"io"
 	"log"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ffuf/ffuf/v2/pkg/api/auth"
+	"github.com/ffuf/ffuf/v2/pkg/api/capture"
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+	"github.com/ffuf/ffuf/v2/pkg/api/reporting"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
 	"github.com/ffuf/ffuf/v2/pkg/filter"
 	"github.com/ffuf/ffuf/v2/pkg/input"
@@ -51,13 +60,21 @@ func (m *wordlistFlag) Set(value string) error {
 func ParseFlags(opts *ffuf.ConfigOptions) *ffuf.ConfigOptions {
 	var ignored bool
 
-	var cookies, autocalibrationstrings, autocalibrationstrategies, headers, inputcommands multiStringFlag
+	var cookies, autocalibrationstrings, autocalibrationstrategies, headers, inputcommands, securityoptions, securitybudgets, securitypayloads, securityheaders, securityproxies, identities, redactpatterns, resolve multiStringFlag
 	var wordlists, encoders wordlistFlag
 
 	cookies = opts.HTTP.Cookies
 	autocalibrationstrings = opts.General.AutoCalibrationStrings
 	headers = opts.HTTP.Headers
 	inputcommands = opts.Input.Inputcommands
+	securityoptions = opts.API.SecurityOptions
+	securitybudgets = opts.API.SecurityBudgets
+	securitypayloads = opts.API.SecurityPayloads
+	securityheaders = opts.API.SecurityHeaders
+	securityproxies = opts.API.SecurityProxies
+	identities = opts.API.Identities
+	resolve = opts.HTTP.Resolve
+	redactpatterns = opts.API.RedactPatterns
 	wordlists = opts.Input.Wordlists
 	encoders = opts.Input.Encoders
 
@@ -81,6 +98,7 @@ func ParseFlags(opts *ffuf.ConfigOptions) *ffuf.ConfigOptions {
 	flag.BoolVar(&opts.HTTP.Raw, "raw", opts.HTTP.Raw, "Do not encode URI")
 	flag.BoolVar(&opts.HTTP.Recursion, "recursion", opts.HTTP.Recursion, "Scan recursively. Only FUZZ keyword is supported, and URL (-u) has to end in it.")
 	flag.BoolVar(&opts.HTTP.Http2, "http2", opts.HTTP.Http2, "Use HTTP2 protocol")
+	flag.StringVar(&opts.HTTP.HTTPVersion, "http-version", opts.HTTP.HTTPVersion, "Force the HTTP version: 1.1, 2 multiplexing the requests over a connection per host (h2c with prior knowledge for http:// URLs), or 3 over QUIC for https:// URLs")
 	flag.BoolVar(&opts.Input.DirSearchCompat, "D", opts.Input.DirSearchCompat, "DirSearch wordlist compatibility mode. Used in conjunction with -e flag.")
 	flag.BoolVar(&opts.Input.IgnoreWordlistComments, "ic", opts.Input.IgnoreWordlistComments, "Ignore wordlist comments")
 	flag.IntVar(&opts.General.MaxTime, "maxtime", opts.General.MaxTime, "Maximum running time in seconds for entire process.")
@@ -111,6 +129,8 @@ func ParseFlags(opts *ffuf.ConfigOptions) *ffuf.ConfigOptions {
 	flag.StringVar(&opts.HTTP.Data, "data-binary", opts.HTTP.Data, "POST data (alias of -d)")
 	flag.StringVar(&opts.HTTP.Method, "X", opts.HTTP.Method, "HTTP method to use")
 	flag.StringVar(&opts.HTTP.ProxyURL, "x", opts.HTTP.ProxyURL, "Proxy URL (SOCKS5 or HTTP). For example: http://127.0.0.1:8080 or socks5://127.0.0.1:8080")
+	flag.StringVar(&opts.HTTP.ProxyList, "proxy-list", opts.HTTP.ProxyList, "File of proxy URLs (SOCKS5 or HTTP), one per line, each request going through the next one in turn")
+	flag.Var(&resolve, "resolve", "Connect to an address in place of a host, in the form host:port:address (e.g. api.example.com:443:10.0.0.5), as curl --resolve. The port may be * for every port, and the address may have a port. Multiple flags are accepted.")
 	flag.StringVar(&opts.HTTP.ReplayProxyURL, "replay-proxy", opts.HTTP.ReplayProxyURL, "Replay matched requests using this proxy.")
 	flag.StringVar(&opts.HTTP.RecursionStrategy, "recursion-strategy", opts.HTTP.RecursionStrategy, "Recursion strategy: \"default\" for a redirect based, and \"greedy\" to recurse on all matches")
 	flag.StringVar(&opts.HTTP.URL, "u", opts.HTTP.URL, "Target URL")
@@ -118,7 +138,7 @@ func ParseFlags(opts *ffuf.ConfigOptions) *ffuf.ConfigOptions {
 	flag.StringVar(&opts.Input.Extensions, "e", opts.Input.Extensions, "Comma separated list of extensions. Extends FUZZ keyword.")
 	flag.StringVar(&opts.Input.InputMode, "mode", opts.Input.InputMode, "Multi-wordlist operation mode. Available modes: clusterbomb, pitchfork, sniper")
 	flag.StringVar(&opts.Input.InputShell, "input-shell", opts.Input.InputShell, "Shell to be used for running command")
-	flag.StringVar(&opts.Input.Request, "request", opts.Input.Request, "File containing the raw http request")
+	flag.StringVar(&opts.Input.Request, "request", opts.Input.Request, "File containing the raw http request, curl commands or a .http file")
 	flag.StringVar(&opts.Input.RequestProto, "request-proto", opts.Input.RequestProto, "Protocol to use along with raw request")
 	flag.StringVar(&opts.Matcher.Mode, "mmode", opts.Matcher.Mode, "Matcher set operator. Either of: and, or")
 	flag.StringVar(&opts.Matcher.Lines, "ml", opts.Matcher.Lines, "Match amount of lines in response")
@@ -145,11 +165,77 @@ func ParseFlags(opts *ffuf.ConfigOptions) *ffuf.ConfigOptions {
 	flag.StringVar(&opts.API.AuthAPIKey, "api-auth-key", opts.API.AuthAPIKey, "API key for authentication")
 	flag.StringVar(&opts.API.AuthAPIKeyName, "api-auth-key-name", opts.API.AuthAPIKeyName, "Name of the API key header or parameter")
 	flag.StringVar(&opts.API.AuthAPIKeyLoc, "api-auth-key-loc", opts.API.AuthAPIKeyLoc, "Location of the API key (header, query, cookie)")
-	flag.StringVar(&opts.API.PayloadFormat, "api-payload-format", opts.API.PayloadFormat, "Format of API payload (json, xml, graphql, formdata)")
+	flag.StringVar(&opts.API.PayloadFormat, "api-payload-format", opts.API.PayloadFormat, "Format of API payload (json, xml, graphql, formdata, protobuf)")
 	flag.StringVar(&opts.API.PayloadTemplate, "api-payload-template", opts.API.PayloadTemplate, "Template for API payload")
 	flag.StringVar(&opts.API.PayloadPath, "api-payload-path", opts.API.PayloadPath, "Path in the payload where the FUZZ keyword should be inserted")
+	flag.StringVar(&opts.API.RequestFuzz, "api-request-fuzz", opts.API.RequestFuzz, "Comma separated parameters of a -request curl command or .http file replaced with FUZZ, or with a keyword given as name:KEYWORD")
+	flag.StringVar(&opts.API.RequestName, "api-request-name", opts.API.RequestName, "Name or 1-based index of the request of a -request file of several curl commands or .http requests. Default: the first request")
+	flag.StringVar(&opts.API.EventLog, "api-event-log", opts.API.EventLog, "Stream the requests, results, covered endpoints, findings and finished security testers of the run as NDJSON events to a file, or to stdout with -")
+	flag.StringVar(&opts.API.HARFile, "api-har", opts.API.HARFile, "Record the requests sent by the run and their responses to a HAR file")
+	flag.IntVar(&opts.API.HARMaxBody, "api-har-max-body", opts.API.HARMaxBody, "Maximum size in bytes of the request and response bodies recorded by -api-har, larger bodies are truncated. 0 for no limit")
+	flag.IntVar(&opts.API.HARMaxSize, "api-har-max-size", opts.API.HARMaxSize, "Maximum size in megabytes of the -api-har file, further requests are not recorded. 0 for no limit")
+	flag.StringVar(&opts.API.Redact, "api-redact", opts.API.Redact, "Comma separated list of the redaction rules masking tokens and personal data in -api-event-log and -api-har: authorization, cookies, secrets, emails, cards, or all")
+	flag.Var(&redactpatterns, "api-redact-pattern", "Regular expression of data masked in -api-event-log and -api-har, its first capture group if it has groups. Multiple flags are accepted.")
+	flag.StringVar(&opts.API.LogLevel, "api-log-level", opts.API.LogLevel, "Minimum level of the log records of the API modules written to stderr: debug, info, warn or error")
+	flag.StringVar(&opts.API.LogFormat, "api-log-format", opts.API.LogFormat, "Format of the log records of the API modules: text or json")
+	flag.StringVar(&opts.API.LogFile, "api-log-file", opts.API.LogFile, "Append the log records of the API modules of the run to a file, in addition to stderr")
+	flag.StringVar(&opts.API.Vars, "api-vars", opts.API.Vars, "YAML, JSON or .env file of the variables replacing {{name}} placeholders in the URL, headers, body, authentication and security options. {{env.NAME}} is read from the environment")
 	flag.BoolVar(&opts.API.ParseResponseBody, "api-parse-response", opts.API.ParseResponseBody, "Parse API response body")
 	flag.BoolVar(&opts.API.ExtractEndpoints, "api-extract-endpoints", opts.API.ExtractEndpoints, "Extract API endpoints from responses")
+	flag.StringVar(&opts.API.SecurityProfile, "api-security-profile", opts.API.SecurityProfile, "Security scan profile: all, quick, owasp-top10, injection-only")
+	flag.StringVar(&opts.API.SecurityInclude, "api-security-include", opts.API.SecurityInclude, "Comma separated list of vulnerability types to test for in addition to the profile (e.g. injection,ssrf)")
+	flag.StringVar(&opts.API.SecurityExclude, "api-security-exclude", opts.API.SecurityExclude, "Comma separated list of vulnerability types not to test for")
+	flag.StringVar(&opts.API.PayloadCategories, "api-security-payload-categories", opts.API.PayloadCategories, "Comma separated list of the payload categories sent by the injection tester (e.g. sqli-error,xxe-oob). Default: all")
+	flag.BoolVar(&opts.API.PayloadsReplace, "api-security-payloads-replace", opts.API.PayloadsReplace, "Replace the built-in payloads of the categories of -api-security-payloads instead of adding to them")
+	flag.StringVar(&opts.API.SecurityTamper, "api-security-tamper", opts.API.SecurityTamper, "Comma separated chain of tampers transforming the payloads of the injection tester in order: built-in tampers (e.g. randomcase,space2comment), executable scripts and Go plugins")
+	flag.BoolVar(&opts.API.SecurityWAF, "api-security-waf", opts.API.SecurityWAF, "Fingerprint the web application firewall in front of the target before the security tests, tamper with the payloads to evade it and mark the findings it blocked")
+	flag.BoolVar(&opts.API.Fingerprint, "api-security-fingerprint", opts.API.Fingerprint, "Fingerprint the framework, language, server, gateway and database of the target before the security tests, report them and skip the payloads of other databases")
+	flag.IntVar(&opts.API.LoginAttempts, "api-security-login-attempts", opts.API.LoginAttempts, "Maximum number of login attempts of the security testers to each login endpoint, 0 for no limit. Attempts to an endpoint stop at the first lockout indicator (423, 403 after failed logins, or a locked account message)")
+	flag.BoolVar(&opts.API.AccountSafe, "api-security-account-safe", opts.API.AccountSafe, "Account-safe mode: stop the credential testing of a host after its first lockout indicator")
+	flag.StringVar(&opts.API.OOBListen, "api-security-oob-listen", opts.API.OOBListen, "Address of the HTTP callback listener receiving the out-of-band interactions of the injection and SSRF payloads (e.g. 0.0.0.0:8089)")
+	flag.StringVar(&opts.API.OOBURL, "api-security-oob-url", opts.API.OOBURL, "Public URL the targets reach the -api-security-oob-listen callback listener at (e.g. http://oob.example.com:8089)")
+	flag.StringVar(&opts.API.OOBDNSListen, "api-security-oob-dns", opts.API.OOBDNSListen, "Address of the DNS callback listener receiving the out-of-band lookups of the payloads (e.g. 0.0.0.0:53)")
+	flag.StringVar(&opts.API.OOBDomain, "api-security-oob-domain", opts.API.OOBDomain, "Domain delegated to the -api-security-oob-dns listener, under which the payloads look up unique subdomains")
+	flag.StringVar(&opts.API.SecurityScoring, "api-security-scoring", opts.API.SecurityScoring, "File (YAML or JSON) of CVSS v3.1 vectors overriding the default vectors scoring the findings of security testers, by vulnerability type, finding name regex and CWE")
+	flag.StringVar(&opts.API.StateFile, "api-state", opts.API.StateFile, "File recording completed requests of security tests and generated test cases, or completed shards of -api-coordinator, to resume an interrupted scan")
+	flag.BoolVar(&opts.API.Resume, "api-resume", opts.API.Resume, "Resume an interrupted scan from the file set with -api-state")
+	flag.StringVar(&opts.API.GRPC, "api-grpc", opts.API.GRPC, "Send requests as gRPC calls over HTTP/2 (grpc) or as gRPC-web calls (grpc-web)")
+	flag.StringVar(&opts.API.ProtoFile, "api-proto", opts.API.ProtoFile, "Protobuf definitions (.proto file or descriptor set) used to encode the JSON request body of gRPC calls")
+	flag.StringVar(&opts.API.ProtoMessage, "api-proto-message", opts.API.ProtoMessage, "Full name of the protobuf request message. Default: the input of the gRPC method of the URL")
+	flag.IntVar(&opts.API.StreamTime, "api-stream-time", opts.API.StreamTime, "Seconds to read event streams, and responses of unknown length such as long polls, keeping the data read. 0 reads event streams until -timeout, and other responses as usual")
+	flag.IntVar(&opts.API.StreamEvents, "api-stream-events", opts.API.StreamEvents, "Maximum number of Server-Sent Events read, returned as NDJSON records. 0 for no limit")
+	flag.BoolVar(&opts.API.AdaptiveRate, "api-adaptive-rate", opts.API.AdaptiveRate, "Slow down requests to a host with an exponential backoff when it answers with 429, Retry-After or rising latency, for the fuzzing job, security testers and test executor alike")
+	flag.IntVar(&opts.API.BackoffMax, "api-backoff-max", opts.API.BackoffMax, "Maximum delay in seconds between requests to a host with -api-adaptive-rate, also capping Retry-After and the delay of -api-retries")
+	flag.IntVar(&opts.API.Retries, "api-retries", opts.API.Retries, "Retry requests failing with transient errors, such as timeouts and connection resets, up to this many times with an exponential backoff and jitter. 0 disables retries")
+	flag.IntVar(&opts.API.RetryDelay, "api-retry-delay", opts.API.RetryDelay, "Delay in milliseconds before the first retry of -api-retries, doubled for each further retry")
+	flag.IntVar(&opts.API.MaxBody, "api-max-body", opts.API.MaxBody, "Maximum size in bytes of the response bodies kept in memory, larger bodies are truncated while their length, words, lines and hash still cover the whole body. 0 for no limit")
+	flag.StringVar(&opts.API.Targets, "api-targets", opts.API.Targets, "File listing the base URLs of targets, one per line, or - to read them from stdin. -u is appended to every target, -t being the worker budget shared by all targets")
+	flag.IntVar(&opts.API.TargetsParallel, "api-targets-parallel", opts.API.TargetsParallel, "Number of targets of -api-targets scanned at once, each with its own connection pool, rate limit and coverage")
+	flag.StringVar(&opts.API.Coordinator, "api-coordinator", opts.API.Coordinator, "Listen address of a coordinator sharding the first wordlist, for every target of -api-targets, over workers started with \"ffuf worker\", and merging their results")
+	flag.StringVar(&opts.API.DistToken, "api-dist-token", opts.API.DistToken, "Token authenticating the workers of -api-coordinator. Default: a random token")
+	flag.IntVar(&opts.API.ShardSize, "api-shard-size", opts.API.ShardSize, "Number of words of a shard leased to a worker of -api-coordinator")
+	flag.StringVar(&opts.API.Policy, "api-policy", opts.API.Policy, "Scope policy file (YAML or JSON) allowing or denying requests by host, path regex, method and parameter name. Denied requests are never sent, and are counted in the summary")
+	flag.StringVar(&opts.API.SafeMode, "api-safe-mode", opts.API.SafeMode, "Consent level for scanning staging and production: read-only sends GET, HEAD and OPTIONS only, non-destructive any method but DELETE and PURGE. Other methods are replaced with GET requests, and destructive payloads (data deletion, file writes, sleeps) are blocked")
+	flag.BoolVar(&opts.API.WebSocket, "api-websocket", opts.API.WebSocket, "Send requests as WebSocket handshakes with the request body as message, returning the reply messages. Default for ws:// and wss:// URLs")
+	flag.StringVar(&opts.API.LoginURL, "api-login-url", opts.API.LoginURL, "Login URL, OAuth 2.0 token endpoint or OpenID Connect issuer. Requests are authenticated with the obtained token and cookies, logging in again when rejected with 401")
+	flag.StringVar(&opts.API.LoginType, "api-login-type", opts.API.LoginType, "Login type: form, json, oauth2-password, oauth2-client-credentials, oidc")
+	flag.StringVar(&opts.API.LoginData, "api-login-data", opts.API.LoginData, "Login request body, or additional token request parameters. Default: built from -api-auth-user and -api-auth-pass")
+	flag.StringVar(&opts.API.LoginClient, "api-login-client", opts.API.LoginClient, "OAuth 2.0 client credentials in the form id:secret")
+	flag.StringVar(&opts.API.LoginScope, "api-login-scope", opts.API.LoginScope, "OAuth 2.0 scope of the requested token")
+	flag.StringVar(&opts.API.LoginTokenPath, "api-login-token", opts.API.LoginTokenPath, "Dot path of the token in the login response (e.g. data.token). Default: common token fields")
+	flag.StringVar(&opts.API.LoginHeader, "api-login-header", opts.API.LoginHeader, "Header injecting the token, {token} being replaced with it. Default: \"Authorization: Bearer {token}\"")
+	flag.StringVar(&opts.API.SigningConfig, "api-sign", opts.API.SigningConfig, "JSON file configuring the signing of requests per target: AWS SigV4, HMAC, authentication plugins and mTLS client certificates")
+	flag.StringVar(&opts.API.CoverageSpec, "api-coverage", opts.API.CoverageSpec, "OpenAPI/Swagger specification, Postman collection, HAR file or Burp Suite/OWASP ZAP export the executed requests are recorded against, printing its coverage at the end of the run")
+	flag.Float64Var(&opts.API.CoverageMin, "api-coverage-min", opts.API.CoverageMin, "Minimum percentage of endpoints of -api-coverage that must receive requests, exiting with status 1 otherwise")
+	flag.StringVar(&opts.API.CoverageReport, "api-coverage-report", opts.API.CoverageReport, "Write the coverage report of -api-coverage to a file, in the format of its extension: json, html, md, txt, csv, xlsx")
+	flag.StringVar(&opts.API.CoverageHistory, "api-coverage-history", opts.API.CoverageHistory, "Append the coverage of -api-coverage to a JSON lines history file, and show the trend of the target in the HTML report")
+	flag.StringVar(&opts.API.ReportMermaid, "api-report-mermaid", opts.API.ReportMermaid, "Local Mermaid script embedded in the HTML report of -api-coverage to render its API map offline")
+	flag.Var(&securityoptions, "api-security-option", "Security tester option override in the form type.Field=value (e.g. injection.TestTimeBased=false). Multiple flags are accepted.")
+	flag.Var(&securityheaders, "api-security-header", "Header \"type=Name: Value\" added to the requests of the security testers of a type (e.g. injection=X-Debug: 1), or \"Name: Value\" to those of every tester, in addition to the -H headers and -b cookies. An empty value removes the header. Multiple flags are accepted.")
+	flag.Var(&identities, "api-security-identity", "Identity \"name:id1,id2=Name: Value\" of the BOLA tester: a header authenticating a user of the API and the identifiers of the objects it owns (e.g. alice:101,102=Authorization: Bearer TOKEN). The objects of each of the two identities are requested as the other one. Multiple flags are accepted.")
+	flag.Var(&securityproxies, "api-security-proxy", "Proxy URL of the requests of the security testers of a type, in the form type=URL (e.g. ssrf=http://127.0.0.1:8080), in place of -x. Multiple flags are accepted.")
+	flag.Var(&securitybudgets, "api-security-budget", "Maximum running time of the security testers of a type against each target, in the form type=duration (e.g. injection=2m), or of every tester without a budget with a bare duration. Multiple flags are accepted.")
+	flag.Var(&securitypayloads, "api-security-payloads", "Payloads of the injection tester by category: a file, a directory of files named after the categories (e.g. sqli-error.txt), or a remote feed URL. Multiple flags are accepted.")
 	flag.Var(&autocalibrationstrings, "acc", "Custom auto-calibration string. Can be used multiple times. Implies -ac")
 	flag.Var(&autocalibrationstrategies, "acs", "Custom auto-calibration strategies. Can be used multiple times. Implies -ac")
 	flag.Var(&cookies, "b", "Cookie data `\"NAME1=VALUE1; NAME2=VALUE2\"` for copy as curl functionality.")
@@ -171,6 +257,14 @@ func ParseFlags(opts *ffuf.ConfigOptions) *ffuf.ConfigOptions {
 	opts.HTTP.Cookies = cookies
 	opts.HTTP.Headers = headers
 	opts.Input.Inputcommands = inputcommands
+	opts.API.SecurityOptions = securityoptions
+	opts.API.SecurityBudgets = securitybudgets
+	opts.API.SecurityPayloads = securitypayloads
+	opts.API.SecurityHeaders = securityheaders
+	opts.API.SecurityProxies = securityproxies
+	opts.API.Identities = identities
+	opts.HTTP.Resolve = resolve
+	opts.API.RedactPatterns = redactpatterns
 	opts.Input.Wordlists = wordlists
 	opts.Input.Encoders = encoders
 	return opts
@@ -178,6 +272,19 @@ func ParseFlags(opts *ffuf.ConfigOptions) *ffuf.ConfigOptions {
 
 func main() {
 
+	// Run the capture subcommand and exit
+	if len(os.Args) > 1 && os.Args[1] == "capture" {
+		os.Exit(runCapture(os.Args[2:]))
+	}
+	// Run the api subcommand and exit
+	if len(os.Args) > 1 && os.Args[1] == "api" {
+		os.Exit(runAPI(os.Args[2:]))
+	}
+	// Run the worker subcommand and exit
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		os.Exit(runWorker(os.Args[2:]))
+	}
+
 	var err, optserr error
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -250,6 +357,35 @@ func main() {
 		opts = ParseFlags(opts)
 	}
 
+	if err := importRequest(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		Usage()
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		os.Exit(1)
+	}
+	if err := applyVariables(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		Usage()
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		os.Exit(1)
+	}
+	if err := insertPayloadFuzzPoint(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		Usage()
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		os.Exit(1)
+	}
+
+	// Coordinate the workers of a distributed scan and exit
+	if opts.API.Coordinator != "" {
+		os.Exit(runCoordinator(opts, ctx, cancel))
+	}
+
+	// Fuzz the targets of -api-targets in parallel and exit
+	if opts.API.Targets != "" {
+		os.Exit(runTargets(opts, ctx, cancel))
+	}
+
 	// Set up Config struct
 	conf, err := ffuf.ConfigFromOptions(opts, ctx, cancel)
 	if err != nil {
@@ -259,7 +395,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	job, err := prepareJob(conf)
+	logs, err := openRunLogs(apiRunLogOptions(opts))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		os.Exit(1)
+	}
+	defer logs.close()
+
+	job, err := prepareJob(conf, logs)
 
 	if job.AuditLogger != nil {
 		defer job.AuditLogger.Close()
@@ -276,6 +419,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
 		os.Exit(1)
 	}
+	logs.attach(job)
+	logs.start("fuzz", []string{conf.Url})
 
 	if !conf.Noninteractive {
 		go func() {
@@ -288,16 +433,131 @@ func main() {
 
 	// Job handles waiting for goroutines to complete itself
 	job.Start()
+	logs.finish("fuzz")
+
+	if conf.SafeMode != nil {
+		reportSafeMode(conf)
+	}
+	if conf.Policy != nil {
+		reportPolicy(conf)
+	}
+
+	// Fail the run if the coverage of the imported specification is below the threshold
+	if coverage, ok := job.Runner.(*reporting.CoverageRunner); ok && !reportCoverage(conf, coverage.Analyzer) {
+		if job.AuditLogger != nil {
+			job.AuditLogger.Close()
+		}
+		logs.close()
+		os.Exit(1)
+	}
+}
+
+// openEventLog opens the event log of -api-event-log, or returns nil if the path is empty.
+// The first error writing to it is logged.
+func openEventLog(path string) (*reporting.EventLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	events, err := reporting.OpenEventLog(path)
+	if err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	events.OnError = func(err error) {
+		once.Do(func() { logging.For("events").Error("Could not write the event log", logging.FieldError, err) })
+	}
+	return events, nil
+}
+
+// attachEventLog makes a job write its requests, results and covered endpoints to an event log
+func attachEventLog(job *ffuf.Job, events *reporting.EventLog) {
+	job.EventLogger = events
+	if coverage, ok := job.Runner.(*reporting.CoverageRunner); ok {
+		coverage.Analyzer.SetCoveredHandler(events.EndpointCovered)
+	}
+}
+
+// reportPolicy prints the number of requests denied by each rule of the scope policy
+func reportPolicy(conf *ffuf.Config) {
+	violations := conf.Policy.Violations()
+	rules := make([]string, 0, len(violations))
+	total := 0
+	for rule, count := range violations {
+		rules = append(rules, rule)
+		total += count
+	}
+	sort.Strings(rules)
+	fmt.Fprintf(os.Stderr, "\n:: Scope policy %s: %d requests denied\n", conf.APIPolicy, total)
+	for _, rule := range rules {
+		fmt.Fprintf(os.Stderr, "::   %-16s: %d\n", rule, violations[rule])
+	}
+}
+
+// reportSafeMode prints the number of requests substituted and blocked by the safe mode
+func reportSafeMode(conf *ffuf.Config) {
+	blocked, substituted := conf.SafeMode.Stats()
+	kinds := make([]string, 0, len(blocked))
+	total := 0
+	for kind, count := range blocked {
+		kinds = append(kinds, kind)
+		total += count
+	}
+	sort.Strings(kinds)
+	fmt.Fprintf(os.Stderr, "\n:: Safe mode %s: %d requests substituted with GET, %d blocked\n", conf.SafeMode.Level, substituted, total)
+	for _, kind := range kinds {
+		fmt.Fprintf(os.Stderr, "::   %-16s: %d\n", kind, blocked[kind])
+	}
 }
 
-func prepareJob(conf *ffuf.Config) (*ffuf.Job, error) {
+// prepareJob creates the job of a fuzzing run. Its requests are recorded to the HAR capture of
+// the run logs if they are not nil.
+func prepareJob(conf *ffuf.Config, logs *runLogs) (*ffuf.Job, error) {
 	var err error
 	job := ffuf.NewJob(conf)
 	var errs ffuf.Multierror
 	job.Input, errs = input.NewInputProvider(conf)
 	// TODO: implement error handling for runnerprovider and outputprovider
-	// We only have http runner right now
-	job.Runner = runner.NewRunnerByName("http", conf, false)
+	if conf.APIGRPC != "" {
+		job.Runner, err = newGRPCRunner(conf)
+		if err != nil {
+			errs.Add(err)
+		}
+	} else if conf.APIWebSocket {
+		job.Runner = runner.NewRunnerByName("websocket", conf, false)
+	} else {
+		job.Runner = runner.NewRunnerByName("http", conf, false)
+	}
+	if job.Runner != nil && logs != nil && logs.har != nil {
+		// The runner sending the requests is wrapped, so that they are recorded with the
+		// signatures and credentials added by the runners wrapping it
+		job.Runner = capture.NewHARRunner(logs.har, job.Runner)
+	}
+	if job.Runner != nil {
+		// Client certificates of signing targets are only supported by the HTTP runner
+		var newRunner func(conf *ffuf.Config) ffuf.RunnerProvider
+		if conf.APIGRPC == "" && !conf.APIWebSocket {
+			newRunner = func(conf *ffuf.Config) ffuf.RunnerProvider { return runner.NewRunnerByName("http", conf, false) }
+		}
+		if signed, err := auth.NewConfiguredSigningRunner(conf, job.Runner, newRunner); err != nil {
+			errs.Add(err)
+		} else {
+			job.Runner = signed
+		}
+		session, err := auth.NewConfiguredSession(conf, job.Runner)
+		if err != nil {
+			errs.Add(err)
+		} else if session != nil {
+			job.Runner = auth.NewRunner(session, job.Runner)
+		}
+		if conf.APICoverageSpec != "" {
+			analyzer, err := newCoverageAnalyzer(conf)
+			if err != nil {
+				errs.Add(err)
+			} else {
+				job.Runner = reporting.NewCoverageRunner(analyzer, job.Runner)
+			}
+		}
+	}
 	if len(conf.ReplayProxyURL) > 0 {
 		job.ReplayRunner = runner.NewRunnerByName("http", conf, true)
 	}
@@ -336,36 +596,219 @@ func prepareJob(conf *ffuf.Config) (*ffuf.Job, error) {
 	return job, errs.ErrorOrNil()
 }
 
+// newGRPCRunner creates the gRPC runner of the job. If protobuf definitions are set, the
+// request body is a JSON message template encoded as protobuf for every request.
+func newGRPCRunner(conf *ffuf.Config) (*runner.GRPCRunner, error) {
+	r := runner.NewGRPCRunner(conf, conf.APIGRPC == "grpc-web")
+	if conf.APIProtoFile == "" {
+		return r, nil
+	}
+
+	schema, err := payload.LoadProtoSchema(conf.APIProtoFile)
+	if err != nil {
+		return r, err
+	}
+	message := conf.APIProtoMessage
+	if message == "" {
+		if message, err = schema.MethodInput(conf.Url); err != nil {
+			return r, fmt.Errorf("could not find the request message, set it with -api-proto-message: %s", err)
+		}
+	}
+	encoder, err := payload.NewProtoEncoder(schema, message)
+	if err != nil {
+		return r, err
+	}
+	r.Encode = encoder.Encode
+	return r, nil
+}
+
+// insertPayloadFuzzPoint sets the request body to the payload template, or the request body,
+// with the FUZZ keyword inserted at -api-payload-path. This creates the fuzz points of
+// JSON and XML request bodies and WebSocket messages without editing the template.
+func insertPayloadFuzzPoint(opts *ffuf.ConfigOptions) error {
+	if opts.API.PayloadPath == "" {
+		return nil
+	}
+	template := opts.API.PayloadTemplate
+	if template == "" {
+		template = opts.HTTP.Data
+	}
+
+	var data string
+	var err error
+	switch opts.API.PayloadFormat {
+	case "", "json":
+		data, err = payload.NewPayloadGenerator(payload.FormatJSON).GenerateJSON(template, opts.API.PayloadPath)
+	case "xml":
+		data, err = payload.NewPayloadGenerator(payload.FormatXML).GenerateXML(template, opts.API.PayloadPath)
+	default:
+		return fmt.Errorf("-api-payload-path is only supported for json and xml payloads, got %s", opts.API.PayloadFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("could not insert the fuzz point at %s: %s", opts.API.PayloadPath, err)
+	}
+	opts.HTTP.Data = data
+	return nil
+}
+
+// importRequest replaces a -request file of curl commands, a .http file or, in API mode, a raw
+// HTTP request with the options of the selected request, the parameters of -api-request-fuzz
+// being replaced with keywords. Raw HTTP requests of the other modes are left to
+// ConfigFromOptions.
+func importRequest(opts *ffuf.ConfigOptions) error {
+	if opts.Input.Request == "" {
+		return nil
+	}
+	data, err := os.ReadFile(opts.Input.Request)
+	if err != nil {
+		return nil
+	}
+	format := parser.DetectRequestFormat(opts.Input.Request, data)
+	if format == "" || (format == parser.RequestFormatRaw && !opts.API.Enabled) {
+		return nil
+	}
+	requests, err := parser.ParseImportedRequests(format, data, opts.Input.RequestProto)
+	if err != nil {
+		return fmt.Errorf("could not import -request: %s", err)
+	}
+
+	request := requests[0]
+	if opts.API.RequestName != "" {
+		request = nil
+		index, _ := strconv.Atoi(opts.API.RequestName)
+		for i, candidate := range requests {
+			if candidate.Name == opts.API.RequestName || i+1 == index {
+				request = candidate
+				break
+			}
+		}
+		if request == nil {
+			return fmt.Errorf("no request %s in %s", opts.API.RequestName, opts.Input.Request)
+		}
+	}
+
+	points := make(map[string]string)
+	for _, point := range strings.Split(opts.API.RequestFuzz, ",") {
+		if point = strings.TrimSpace(point); point == "" {
+			continue
+		}
+		name, keyword := point, "FUZZ"
+		if i := strings.LastIndex(point, ":"); i > 0 {
+			name, keyword = point[:i], point[i+1:]
+		}
+		points[name] = keyword
+	}
+	req, err := request.Fuzz(points)
+	if err != nil {
+		return fmt.Errorf("could not insert the keywords of -api-request-fuzz: %s", err)
+	}
+	if len(points) == 0 && !strings.Contains(req.Url+string(req.Data)+fmt.Sprint(req.Headers), "FUZZ") {
+		fmt.Fprintf(os.Stderr, "Parameters of the imported request, select them with -api-request-fuzz: %s\n", request.ParameterList())
+	}
+
+	// The options set on the command line take precedence over the imported request
+	opts.Input.Request = ""
+	if opts.HTTP.Method == "" {
+		opts.HTTP.Method = req.Method
+	}
+	if opts.HTTP.URL == "" {
+		opts.HTTP.URL = req.Url
+	}
+	if opts.HTTP.Data == "" {
+		opts.HTTP.Data = string(req.Data)
+	}
+	names := make([]string, 0, len(req.Headers))
+	for name := range req.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := make([]string, 0, len(names)+len(opts.HTTP.Headers))
+	for _, name := range names {
+		headers = append(headers, name+": "+req.Headers[name])
+	}
+	opts.HTTP.Headers = append(headers, opts.HTTP.Headers...)
+	return nil
+}
+
+// applyVariables replaces the {{name}} placeholders of the request, authentication, login and
+// security tester options with the variables of -api-vars, and {{env.NAME}} placeholders with
+// environment variables
+func applyVariables(opts *ffuf.ConfigOptions) error {
+	variables := parser.NewVariables()
+	if opts.API.Vars != "" {
+		var err error
+		if variables, err = parser.LoadVariables(opts.API.Vars); err != nil {
+			return err
+		}
+	}
+	resolve := func(values ...*string) {
+		for _, value := range values {
+			*value = variables.Resolve(*value)
+		}
+	}
+	resolve(&opts.HTTP.URL, &opts.HTTP.Data, &opts.API.PayloadTemplate,
+		&opts.API.AuthUsername, &opts.API.AuthPassword, &opts.API.AuthToken, &opts.API.AuthAPIKey,
+		&opts.API.LoginURL, &opts.API.LoginData, &opts.API.LoginClient, &opts.API.LoginScope)
+	for _, values := range [][]string{opts.HTTP.Headers, opts.HTTP.Cookies, opts.API.SecurityOptions} {
+		for i := range values {
+			resolve(&values[i])
+		}
+	}
+
+	if opts.API.Vars != "" {
+		unresolved := variables.Unresolved(strings.Join(append([]string{opts.HTTP.URL, opts.HTTP.Data}, opts.HTTP.Headers...), "\n"))
+		if len(unresolved) > 0 {
+			fmt.Fprintf(os.Stderr, "Variables without a value in %s: %s\n", opts.API.Vars, strings.Join(unresolved, ", "))
+		}
+	}
+	return nil
+}
+
 func SetupFilters(parseOpts *ffuf.ConfigOptions, conf *ffuf.Config) error {
+	return setupFilters(parseOpts, conf, visitedFlags())
+}
+
+// visitedFlags returns the names of the flags set on the command line
+func visitedFlags() []string {
+	names := make([]string, 0)
+	flag.Visit(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	return names
+}
+
+// setupFilters sets up the matchers and filters of the config, the flags set on the command
+// line selecting the default matchers
+func setupFilters(parseOpts *ffuf.ConfigOptions, conf *ffuf.Config, flags []string) error {
 	errs := ffuf.NewMultierror()
 	conf.MatcherManager = filter.NewMatcherManager()
 	// If any other matcher is set, ignore -mc default value
 	matcherSet := false
 	statusSet := false
 	warningIgnoreBody := false
-	flag.Visit(func(f *flag.Flag) {
-		if f.Name == "mc" {
+	for _, name := range flags {
+		if name == "mc" {
 			statusSet = true
 		}
-		if f.Name == "ms" {
+		if name == "ms" {
 			matcherSet = true
 			warningIgnoreBody = true
 		}
-		if f.Name == "ml" {
+		if name == "ml" {
 			matcherSet = true
 			warningIgnoreBody = true
 		}
-		if f.Name == "mr" {
+		if name == "mr" {
 			matcherSet = true
 		}
-		if f.Name == "mt" {
+		if name == "mt" {
 			matcherSet = true
 		}
-		if f.Name == "mw" {
+		if name == "mw" {
 			matcherSet = true
 			warningIgnoreBody = true
 		}
-	})
+	}
 	// Only set default matchers if no
 	if statusSet || !matcherSet {
 		if err := conf.MatcherManager.AddMatcher("status", parseOpts.Matcher.Status); err != nil {
@@ -32,6 +32,8 @@ const (
 	AuthAPIKey
 	// AuthOAuth represents OAuth authentication
 	AuthOAuth
+	// AuthLogin represents a session obtained by a login request
+	AuthLogin
 )
 
 // AuthProvider is an interface for different authentication mechanisms
@@ -0,0 +1,410 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// LoginType is the kind of login request of a session
+type LoginType string
+
+const (
+	// LoginForm posts the credentials as a form
+	LoginForm LoginType = "form"
+	// LoginJSON posts the credentials as a JSON object
+	LoginJSON LoginType = "json"
+	// LoginOAuth2Password requests a token with the OAuth 2.0 password grant
+	LoginOAuth2Password LoginType = "oauth2-password"
+	// LoginOAuth2ClientCredentials requests a token with the OAuth 2.0 client credentials grant
+	LoginOAuth2ClientCredentials LoginType = "oauth2-client-credentials"
+	// LoginOIDC discovers the token endpoint of an OpenID Connect issuer, and requests a token
+	// with the password grant if a username is set, or the client credentials grant otherwise
+	LoginOIDC LoginType = "oidc"
+)
+
+// DefaultTokenHeader is the header template injecting the token of a session
+const DefaultTokenHeader = "Authorization: Bearer {token}"
+
+// tokenFields are the fields of login responses searched for a token if no token path is set
+var tokenFields = []string{"access_token", "accessToken", "token", "id_token", "jwt", "data.token", "data.access_token"}
+
+// LoginConfig configures the login request of a session
+type LoginConfig struct {
+	Type LoginType
+	// URL is the login URL, the token endpoint for OAuth 2.0, or the issuer for OpenID Connect
+	URL string
+	// Data is the body of form and JSON logins, or additional parameters of token requests.
+	// If empty, the body is built from Username and Password.
+	Data     string
+	Username string
+	Password string
+	// ClientID and ClientSecret authenticate OAuth 2.0 and OpenID Connect token requests
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	// Headers are added to the login request
+	Headers map[string]string
+	// TokenPath is the dot path of the token in the JSON login response, e.g. data.token.
+	// Common token fields are searched if empty.
+	TokenPath string
+	// TokenHeader is the header injecting the token, {token} being replaced with the token
+	TokenHeader string
+}
+
+// Session performs a login request, and injects the obtained token and cookies into
+// requests. Sessions are safe for concurrent use.
+type Session struct {
+	config LoginConfig
+	runner ffuf.RunnerProvider
+
+	mu        sync.RWMutex
+	token     string
+	cookies   map[string]string
+	expiresAt time.Time
+	// generation is incremented by every login, so that concurrent requests failing with
+	// the same expired session trigger a single login
+	generation int
+	// Logins is the number of successful logins
+	Logins int
+}
+
+// NewSession creates a session sending its login requests with a runner
+func NewSession(config LoginConfig, r ffuf.RunnerProvider) (*Session, error) {
+	switch config.Type {
+	case "":
+		config.Type = LoginJSON
+	case LoginForm, LoginJSON, LoginOAuth2Password, LoginOAuth2ClientCredentials, LoginOIDC:
+	default:
+		return nil, api.NewAPIError(fmt.Sprintf("Unsupported login type: %s", config.Type), 0)
+	}
+	if config.URL == "" {
+		return nil, api.NewAPIError("Login URL is empty", 0)
+	}
+	if config.TokenHeader == "" {
+		config.TokenHeader = DefaultTokenHeader
+	}
+	if !strings.Contains(config.TokenHeader, ":") {
+		return nil, api.NewAPIError("Token header must be in the form Name: value", 0)
+	}
+	return &Session{
+		config:  config,
+		runner:  r,
+		cookies: make(map[string]string),
+	}, nil
+}
+
+// NewConfiguredSession creates the login session of the config, or returns nil if no login
+// URL is set
+func NewConfiguredSession(config *ffuf.Config, r ffuf.RunnerProvider) (*Session, error) {
+	if config.APILoginURL == "" {
+		return nil, nil
+	}
+	return NewSession(LoginConfig{
+		Type:         LoginType(config.APILoginType),
+		URL:          config.APILoginURL,
+		Data:         config.APILoginData,
+		Username:     config.APIAuthUsername,
+		Password:     config.APIAuthPassword,
+		ClientID:     config.APILoginClientID,
+		ClientSecret: config.APILoginClientSecret,
+		Scope:        config.APILoginScope,
+		TokenPath:    config.APILoginTokenPath,
+		TokenHeader:  config.APILoginHeader,
+	}, r)
+}
+
+// Login performs the login request and stores the obtained token and cookies
+func (s *Session) Login() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.login()
+}
+
+// login performs the login request, with the lock held
+func (s *Session) login() error {
+	tokenURL := s.config.URL
+	if s.config.Type == LoginOIDC {
+		var err error
+		if tokenURL, err = s.discoverTokenEndpoint(); err != nil {
+			return err
+		}
+	}
+
+	req := &ffuf.Request{Method: "POST", Url: tokenURL, Headers: make(map[string]string)}
+	for name, value := range s.config.Headers {
+		req.Headers[name] = value
+	}
+	body, contentType, err := s.loginBody()
+	if err != nil {
+		return err
+	}
+	req.Data = []byte(body)
+	req.Headers["Content-Type"] = contentType
+	if s.isOAuth() {
+		req.Headers["Accept"] = "application/json"
+		if s.config.ClientID != "" && s.config.ClientSecret != "" {
+			credentials := url.QueryEscape(s.config.ClientID) + ":" + url.QueryEscape(s.config.ClientSecret)
+			req.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+		}
+	}
+
+	resp, err := s.runner.Execute(req)
+	if err != nil {
+		return api.NewAPIError("Login request failed: "+err.Error(), 0)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return api.NewAPIError(fmt.Sprintf("Login request failed with status %d: %s", resp.StatusCode, truncate(string(resp.Data), 200)), int(resp.StatusCode))
+	}
+
+	cookies := make(map[string]string)
+	header := http.Header{"Set-Cookie": resp.Headers["Set-Cookie"]}
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+
+	token, expiresIn := s.extractToken(resp.Data)
+	if token == "" && len(cookies) == 0 {
+		return api.NewAPIError("Login response contains neither a token nor cookies", 0)
+	}
+
+	s.token = token
+	s.cookies = cookies
+	s.expiresAt = time.Time{}
+	if expiresIn > 0 {
+		s.expiresAt = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	s.generation++
+	s.Logins++
+	return nil
+}
+
+// isOAuth checks if the login is an OAuth 2.0 token request
+func (s *Session) isOAuth() bool {
+	return s.config.Type == LoginOAuth2Password || s.config.Type == LoginOAuth2ClientCredentials || s.config.Type == LoginOIDC
+}
+
+// loginBody returns the body and the content type of the login request
+func (s *Session) loginBody() (string, string, error) {
+	switch s.config.Type {
+	case LoginForm:
+		if s.config.Data != "" {
+			return s.config.Data, "application/x-www-form-urlencoded", nil
+		}
+		values := url.Values{}
+		values.Set("username", s.config.Username)
+		values.Set("password", s.config.Password)
+		return values.Encode(), "application/x-www-form-urlencoded", nil
+	case LoginJSON:
+		if s.config.Data != "" {
+			return s.config.Data, "application/json", nil
+		}
+		data, _ := json.Marshal(map[string]string{"username": s.config.Username, "password": s.config.Password})
+		return string(data), "application/json", nil
+	}
+
+	values, err := url.ParseQuery(s.config.Data)
+	if err != nil {
+		return "", "", api.NewAPIError("Invalid token request parameters: "+err.Error(), 0)
+	}
+	grantType := GrantTypeClientCredentials
+	if s.config.Type == LoginOAuth2Password || (s.config.Type == LoginOIDC && s.config.Username != "") {
+		grantType = GrantTypePassword
+	}
+	values.Set("grant_type", string(grantType))
+	if grantType == GrantTypePassword {
+		if s.config.Username == "" {
+			return "", "", api.NewAPIError("Username and password are required for password grant", 0)
+		}
+		values.Set("username", s.config.Username)
+		values.Set("password", s.config.Password)
+	}
+	if s.config.Scope != "" {
+		values.Set("scope", s.config.Scope)
+	}
+	// Public clients send their client ID in the body
+	if s.config.ClientID != "" && s.config.ClientSecret == "" {
+		values.Set("client_id", s.config.ClientID)
+	}
+	return values.Encode(), "application/x-www-form-urlencoded", nil
+}
+
+// discoverTokenEndpoint returns the token endpoint of the OpenID Connect issuer
+func (s *Session) discoverTokenEndpoint() (string, error) {
+	discoveryURL := s.config.URL
+	if !strings.Contains(discoveryURL, "/.well-known/") {
+		discoveryURL = strings.TrimSuffix(discoveryURL, "/") + "/.well-known/openid-configuration"
+	}
+	resp, err := s.runner.Execute(&ffuf.Request{Method: "GET", Url: discoveryURL, Headers: map[string]string{"Accept": "application/json"}})
+	if err != nil {
+		return "", api.NewAPIError("OpenID Connect discovery failed: "+err.Error(), 0)
+	}
+	var configuration struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.Unmarshal(resp.Data, &configuration); err != nil || configuration.TokenEndpoint == "" {
+		return "", api.NewAPIError(fmt.Sprintf("OpenID Connect discovery document at %s has no token endpoint", discoveryURL), int(resp.StatusCode))
+	}
+	return configuration.TokenEndpoint, nil
+}
+
+// extractToken returns the token of a JSON login response and its lifetime in seconds
+func (s *Session) extractToken(body []byte) (string, int) {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", 0
+	}
+
+	expiresIn := 0
+	if object, ok := data.(map[string]interface{}); ok {
+		switch v := object["expires_in"].(type) {
+		case float64:
+			expiresIn = int(v)
+		case string:
+			expiresIn, _ = strconv.Atoi(v)
+		}
+	}
+
+	paths := tokenFields
+	if s.config.TokenPath != "" {
+		paths = []string{s.config.TokenPath}
+	}
+	for _, path := range paths {
+		if token, ok := lookupPath(data, path).(string); ok && token != "" {
+			return token, expiresIn
+		}
+	}
+	return "", expiresIn
+}
+
+// lookupPath returns the value at a dot path of a JSON value, or nil
+func lookupPath(data interface{}, path string) interface{} {
+	for _, part := range strings.Split(strings.TrimPrefix(path, "$."), ".") {
+		switch v := data.(type) {
+		case map[string]interface{}:
+			data = v[part]
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			data = v[index]
+		default:
+			return nil
+		}
+	}
+	return data
+}
+
+// expired checks if the session has no credentials or its token has expired, with the lock held
+func (s *Session) expired() bool {
+	if s.token == "" && len(s.cookies) == 0 {
+		return true
+	}
+	// Consider the token expired if it expires in less than 30 seconds
+	return !s.expiresAt.IsZero() && s.expiresAt.Before(time.Now().Add(30*time.Second))
+}
+
+// Apply logs in if needed, and injects the token and cookies of the session into the headers
+// of a request. It returns the generation of the session, to be passed to Reauthenticate.
+func (s *Session) Apply(req *ffuf.Request) (int, error) {
+	s.mu.RLock()
+	expired := s.expired()
+	s.mu.RUnlock()
+	if expired {
+		s.mu.Lock()
+		if s.expired() {
+			if err := s.login(); err != nil {
+				s.mu.Unlock()
+				return 0, err
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Copy the headers, which may be shared with other requests
+	headers := copyHeaders(req.Headers)
+	if s.token != "" {
+		parts := strings.SplitN(s.config.TokenHeader, ":", 2)
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(strings.ReplaceAll(parts[1], "{token}", s.token))
+	}
+	if len(s.cookies) > 0 {
+		cookies := make([]string, 0, len(s.cookies)+1)
+		for name, value := range headers {
+			if strings.EqualFold(name, "Cookie") {
+				cookies = append(cookies, value)
+				delete(headers, name)
+			}
+		}
+		for _, name := range sortedNames(s.cookies) {
+			cookies = append(cookies, name+"="+s.cookies[name])
+		}
+		headers["Cookie"] = strings.Join(cookies, "; ")
+	}
+	req.Headers = headers
+	return s.generation, nil
+}
+
+// Reauthenticate logs in again after a request of the given session generation was rejected,
+// unless another request already did since
+func (s *Session) Reauthenticate(generation int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generation != generation {
+		return nil
+	}
+	return s.login()
+}
+
+// AddAuth adds the token and cookies of the session to the given HTTP request
+func (s *Session) AddAuth(req *http.Request) error {
+	r := &ffuf.Request{Headers: make(map[string]string)}
+	if _, err := s.Apply(r); err != nil {
+		return err
+	}
+	for name, value := range r.Headers {
+		req.Header.Set(name, value)
+	}
+	return nil
+}
+
+// GetAuthType returns the type of authentication
+func (s *Session) GetAuthType() AuthType {
+	return AuthLogin
+}
+
+// GetDescription returns a human-readable description of the authentication
+func (s *Session) GetDescription() string {
+	return fmt.Sprintf("Login session (%s login at %s)", s.config.Type, s.config.URL)
+}
+
+// sortedNames returns the names of cookies in order
+func sortedNames(cookies map[string]string) []string {
+	names := make([]string, 0, len(cookies))
+	for name := range cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// truncate shortens a string to a maximum length
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length] + "..."
+}
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+func newTestRunner() ffuf.RunnerProvider {
+	config := &ffuf.Config{
+		Context: context.Background(),
+		Timeout: 10,
+	}
+	return runner.NewSimpleRunner(config, false)
+}
+
+func TestSessionJSONLogin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			var credentials map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil || credentials["username"] != "alice" || credentials["password"] != "secret" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"data": {"token": "abc"}}`))
+		case "/users":
+			if r.Header.Get("Authorization") != "Bearer abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	r := newTestRunner()
+	session, err := NewSession(LoginConfig{Type: LoginJSON, URL: server.URL + "/login", Username: "alice", Password: "secret"}, r)
+	if err != nil {
+		t.Fatalf("NewSession returned an error: %s", err)
+	}
+	authed := NewRunner(session, r)
+	req := &ffuf.Request{Method: "GET", Url: server.URL + "/users", Headers: map[string]string{"Accept": "application/json"}}
+	resp, err := authed.Execute(req)
+	if err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if _, ok := req.Headers["Authorization"]; ok {
+		t.Errorf("Execute modified the headers of the request")
+	}
+}
+
+func TestSessionFormLoginCookies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			if r.FormValue("user") != "alice" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+			w.WriteHeader(http.StatusFound)
+		case "/profile":
+			cookie, err := r.Cookie("session")
+			if err != nil || cookie.Value != "s1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if pref, err := r.Cookie("theme"); err != nil || pref.Value != "dark" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	r := newTestRunner()
+	session, err := NewSession(LoginConfig{Type: LoginForm, URL: server.URL + "/login", Data: "user=alice&pass=secret"}, r)
+	if err != nil {
+		t.Fatalf("NewSession returned an error: %s", err)
+	}
+	req := &ffuf.Request{Method: "GET", Url: server.URL + "/profile", Headers: map[string]string{"Cookie": "theme=dark"}}
+	resp, err := NewRunner(session, r).Execute(req)
+	if err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestSessionOAuth2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			w.Write([]byte(`{"issuer": "test", "token_endpoint": "http://` + r.Host + `/token"}`))
+		case "/token":
+			id, secret, ok := r.BasicAuth()
+			if !ok || id != "client" || secret != "s3cret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			r.ParseForm()
+			token := "cc-token"
+			if r.Form.Get("grant_type") == "password" {
+				if r.Form.Get("username") != "alice" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				token = "password-token"
+			}
+			w.Write([]byte(`{"access_token": "` + token + `", "token_type": "Bearer", "expires_in": 3600}`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		config   LoginConfig
+		expected string
+	}{
+		{"client credentials", LoginConfig{Type: LoginOAuth2ClientCredentials, URL: server.URL + "/token"}, "cc-token"},
+		{"password", LoginConfig{Type: LoginOAuth2Password, URL: server.URL + "/token", Username: "alice", Password: "pw"}, "password-token"},
+		{"oidc client credentials", LoginConfig{Type: LoginOIDC, URL: server.URL}, "cc-token"},
+		{"oidc password", LoginConfig{Type: LoginOIDC, URL: server.URL + "/", Username: "alice", Password: "pw"}, "password-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ClientID = "client"
+			tt.config.ClientSecret = "s3cret"
+			tt.config.TokenHeader = "X-Token: {token}"
+			session, err := NewSession(tt.config, newTestRunner())
+			if err != nil {
+				t.Fatalf("NewSession returned an error: %s", err)
+			}
+			req := &ffuf.Request{Headers: map[string]string{}}
+			if _, err := session.Apply(req); err != nil {
+				t.Fatalf("Apply returned an error: %s", err)
+			}
+			if req.Headers["X-Token"] != tt.expected {
+				t.Errorf("Expected token %s, got %s", tt.expected, req.Headers["X-Token"])
+			}
+		})
+	}
+}
+
+func TestSessionReauthenticate(t *testing.T) {
+	var mu sync.Mutex
+	logins := 0
+	valid := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/login":
+			logins++
+			valid = "token" + string(rune('0'+logins))
+			w.Write([]byte(`{"token": "` + valid + `"}`))
+		case "/expire":
+			valid = ""
+		default:
+			if r.Header.Get("Authorization") != "Bearer "+valid || valid == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	r := newTestRunner()
+	session, err := NewSession(LoginConfig{URL: server.URL + "/login", Data: `{"user": "alice"}`}, r)
+	if err != nil {
+		t.Fatalf("NewSession returned an error: %s", err)
+	}
+	authed := NewRunner(session, r)
+	get := func() int64 {
+		resp, err := authed.Execute(&ffuf.Request{Method: "GET", Url: server.URL + "/data", Headers: map[string]string{}})
+		if err != nil {
+			t.Fatalf("Execute returned an error: %s", err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := get(); status != 200 {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	r.Execute(&ffuf.Request{Method: "GET", Url: server.URL + "/expire", Headers: map[string]string{}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if status := get(); status != 200 {
+				t.Errorf("Expected status 200 after logging in again, got %d", status)
+			}
+		}()
+	}
+	wg.Wait()
+	if session.Logins != 2 {
+		t.Errorf("Expected 2 logins, got %d", session.Logins)
+	}
+}
+
+func TestSessionLoginFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	session, err := NewSession(LoginConfig{Type: LoginForm, URL: server.URL}, newTestRunner())
+	if err != nil {
+		t.Fatalf("NewSession returned an error: %s", err)
+	}
+	if err := session.Login(); err == nil {
+		t.Errorf("Expected an error for a rejected login")
+	}
+	if _, err := NewSession(LoginConfig{Type: "saml", URL: server.URL}, nil); err == nil {
+		t.Errorf("Expected an error for an unsupported login type")
+	}
+}
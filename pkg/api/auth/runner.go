@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// Runner wraps a runner to inject the token and cookies of a session into its requests. A
+// request rejected with 401 Unauthorized is sent again once after logging in again.
+type Runner struct {
+	session *Session
+	runner  ffuf.RunnerProvider
+}
+
+// NewRunner creates a runner authenticating its requests with a session
+func NewRunner(session *Session, r ffuf.RunnerProvider) *Runner {
+	return &Runner{
+		session: session,
+		runner:  r,
+	}
+}
+
+// Prepare prepares a request using the underlying runner
+func (r *Runner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return r.runner.Prepare(input, basereq)
+}
+
+// Dump dumps a request using the underlying runner
+func (r *Runner) Dump(req *ffuf.Request) ([]byte, error) {
+	return r.runner.Dump(req)
+}
+
+// Execute executes an authenticated copy of a request, logging in again if the session
+// has expired
+func (r *Runner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	authed := *req
+	generation, err := r.session.Apply(&authed)
+	if err != nil {
+		return ffuf.Response{}, err
+	}
+	resp, err := r.runner.Execute(&authed)
+	if err != nil || resp.StatusCode != 401 {
+		return resp, err
+	}
+
+	if err := r.session.Reauthenticate(generation); err != nil {
+		return resp, nil
+	}
+	authed = *req
+	if _, err := r.session.Apply(&authed); err != nil {
+		return resp, nil
+	}
+	return r.runner.Execute(&authed)
+}
@@ -0,0 +1,489 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// Signer signs a request right before it is sent
+type Signer interface {
+	// Sign adds the signature headers to a request
+	Sign(req *ffuf.Request) error
+	// GetDescription returns a human-readable description of the signer
+	GetDescription() string
+}
+
+// emptyPayloadHash is the SHA-256 hash of an empty body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// SigV4Signer signs requests with AWS Signature Version 4
+type SigV4Signer struct {
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token"`
+	Region       string `json:"region"`
+	// Service is the signing name of the service, execute-api for API Gateway
+	Service string `json:"service"`
+	// UnsignedPayload sends UNSIGNED-PAYLOAD instead of the hash of the body, as allowed by S3
+	UnsignedPayload bool `json:"unsigned_payload"`
+
+	now func() time.Time
+}
+
+// init fills the missing credentials of the signer from the standard AWS environment variables
+func (s *SigV4Signer) init() error {
+	if s.AccessKey == "" && s.SecretKey == "" {
+		s.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		s.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if s.SessionToken == "" {
+			s.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		}
+	}
+	if s.Region == "" {
+		s.Region = os.Getenv("AWS_REGION")
+	}
+	if s.Service == "" {
+		s.Service = "execute-api"
+	}
+	if s.AccessKey == "" || s.SecretKey == "" {
+		return api.NewAPIError("AWS access key or secret key is empty", 0)
+	}
+	if s.Region == "" {
+		return api.NewAPIError("AWS region is empty", 0)
+	}
+	return nil
+}
+
+// Sign adds the X-Amz-Date and Authorization headers of the signature to a request
+func (s *SigV4Signer) Sign(req *ffuf.Request) error {
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return api.NewAPIError("Cannot sign request with invalid URL: "+err.Error(), 0)
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	dateStamp := t.Format("20060102")
+
+	payloadHash := emptyPayloadHash
+	if s.UnsignedPayload {
+		payloadHash = "UNSIGNED-PAYLOAD"
+	} else if len(req.Data) > 0 {
+		payloadHash = hex.EncodeToString(sha256Hash(req.Data))
+	}
+
+	headers := copyHeaders(req.Headers)
+	for name := range headers {
+		if strings.EqualFold(name, "Authorization") || strings.EqualFold(name, "X-Amz-Date") {
+			delete(headers, name)
+		}
+	}
+	headers["X-Amz-Date"] = amzDate
+	if s.Service == "s3" {
+		headers["X-Amz-Content-Sha256"] = payloadHash
+	}
+	if s.SessionToken != "" {
+		headers["X-Amz-Security-Token"] = s.SessionToken
+	}
+
+	// Sign the host and the headers set on the request, which the runner sends unchanged
+	signed := map[string]string{"host": u.Host}
+	for name, value := range headers {
+		lower := strings.ToLower(name)
+		if lower == "user-agent" || lower == "content-length" {
+			continue
+		}
+		if lower == "host" {
+			signed["host"] = value
+			continue
+		}
+		signed[lower] = strings.Join(strings.Fields(value), " ")
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		strings.ToUpper(req.Method),
+		sigV4Path(u, s.Service != "s3"),
+		sigV4Query(u),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", dateStamp, s.Region, s.Service)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		hex.EncodeToString(sha256Hash([]byte(canonicalRequest))),
+	}, "\n")
+	signingKey := getAWSSignatureKey(s.SecretKey, dateStamp, s.Region, s.Service)
+	signature := hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign)))
+
+	headers["Authorization"] = fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, credentialScope, signedHeaders, signature)
+	req.Headers = headers
+	return nil
+}
+
+// GetDescription returns a human-readable description of the signer
+func (s *SigV4Signer) GetDescription() string {
+	return fmt.Sprintf("AWS SigV4 (%s, %s)", s.Service, s.Region)
+}
+
+// sigV4Path returns the canonical URI of a URL, with its segments encoded twice for services
+// other than S3
+func sigV4Path(u *url.URL, doubleEncode bool) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segment = unescaped
+		}
+		segment = uriEncode(segment)
+		if doubleEncode {
+			segment = uriEncode(segment)
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+// sigV4Query returns the canonical query string of a URL
+func sigV4Query(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+	var params []string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		key, _ := url.QueryUnescape(parts[0])
+		value := ""
+		if len(parts) == 2 {
+			value, _ = url.QueryUnescape(parts[1])
+		}
+		params = append(params, uriEncode(key)+"="+uriEncode(value))
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode percent-encodes the characters of a value other than the unreserved characters
+func uriEncode(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// HMACSigner signs requests with an HMAC of a canonical string built from parts of the request
+type HMACSigner struct {
+	KeyID  string `json:"key_id"`
+	Secret string `json:"secret"`
+	// SecretEncoding is the encoding of the secret: raw (default), hex or base64
+	SecretEncoding string `json:"secret_encoding"`
+	// Algorithm is the hash function: sha256 (default), sha1 or sha512
+	Algorithm string `json:"algorithm"`
+	// Encoding is the encoding of the signature: hex (default) or base64
+	Encoding string `json:"encoding"`
+	// Components are the parts of the canonical string: method, path, query, url, host, body,
+	// body-sha256, body-md5, timestamp, nonce, key-id, header:<name>, or a literal in quotes.
+	// Default: method, path, timestamp and body-sha256.
+	Components []string `json:"components"`
+	// Separator joins the components of the canonical string, a newline by default
+	Separator *string `json:"separator"`
+	// Header is the header carrying the signature. Its value may contain {signature}, {key-id},
+	// {timestamp}, {nonce} and {headers}, the lower case names of the signed headers.
+	// Default: X-Signature: {signature}
+	Header string `json:"header"`
+	// TimestampHeader and NonceHeader are set to the timestamp and the nonce of the signature
+	TimestampHeader string `json:"timestamp_header"`
+	NonceHeader     string `json:"nonce_header"`
+	// TimestampFormat is unix (default), unix-ms, rfc3339 or http
+	TimestampFormat string `json:"timestamp_format"`
+
+	key     []byte
+	newHash func() hash.Hash
+	now     func() time.Time
+}
+
+// init validates the settings of the signer
+func (s *HMACSigner) init() error {
+	var err error
+	switch s.SecretEncoding {
+	case "", "raw":
+		s.key = []byte(s.Secret)
+	case "hex":
+		s.key, err = hex.DecodeString(s.Secret)
+	case "base64":
+		s.key, err = base64.StdEncoding.DecodeString(s.Secret)
+	default:
+		return api.NewAPIError("Unsupported HMAC secret encoding: "+s.SecretEncoding, 0)
+	}
+	if err != nil {
+		return api.NewAPIError("Invalid HMAC secret: "+err.Error(), 0)
+	}
+	if len(s.key) == 0 {
+		return api.NewAPIError("HMAC secret is empty", 0)
+	}
+
+	switch s.Algorithm {
+	case "", "sha256":
+		s.newHash = sha256.New
+	case "sha1":
+		s.newHash = sha1.New
+	case "sha512":
+		s.newHash = sha512.New
+	default:
+		return api.NewAPIError("Unsupported HMAC algorithm: "+s.Algorithm, 0)
+	}
+	if s.Encoding != "" && s.Encoding != "hex" && s.Encoding != "base64" {
+		return api.NewAPIError("Unsupported HMAC signature encoding: "+s.Encoding, 0)
+	}
+	switch s.TimestampFormat {
+	case "", "unix", "unix-ms", "rfc3339", "http":
+	default:
+		return api.NewAPIError("Unsupported HMAC timestamp format: "+s.TimestampFormat, 0)
+	}
+	if len(s.Components) == 0 {
+		s.Components = []string{"method", "path", "timestamp", "body-sha256"}
+	}
+	for _, component := range s.Components {
+		if _, ok := hmacComponent(component, nil, nil, "", "", ""); !ok {
+			return api.NewAPIError("Unsupported HMAC component: "+component, 0)
+		}
+	}
+	if s.Header == "" {
+		s.Header = "X-Signature: {signature}"
+	}
+	if !strings.Contains(s.Header, ":") {
+		return api.NewAPIError("HMAC header must be in the form Name: value", 0)
+	}
+	return nil
+}
+
+// Sign adds the signature header, and the timestamp and nonce headers if set, to a request
+func (s *HMACSigner) Sign(req *ffuf.Request) error {
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return api.NewAPIError("Cannot sign request with invalid URL: "+err.Error(), 0)
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	var timestamp string
+	switch s.TimestampFormat {
+	case "unix-ms":
+		timestamp = strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case "rfc3339":
+		timestamp = t.Format(time.RFC3339)
+	case "http":
+		timestamp = t.Format(http.TimeFormat)
+	default:
+		timestamp = strconv.FormatInt(t.Unix(), 10)
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return api.NewAPIError("Failed to generate nonce: "+err.Error(), 0)
+	}
+	nonceHex := hex.EncodeToString(nonce)
+
+	headers := copyHeaders(req.Headers)
+	if s.TimestampHeader != "" {
+		headers[s.TimestampHeader] = timestamp
+	}
+	if s.NonceHeader != "" {
+		headers[s.NonceHeader] = nonceHex
+	}
+
+	parts := make([]string, len(s.Components))
+	var signedHeaders []string
+	for i, component := range s.Components {
+		parts[i], _ = hmacComponent(component, req, u, timestamp, nonceHex, s.KeyID)
+		if name := strings.TrimPrefix(component, "header:"); name != component {
+			parts[i] = headerValue(headers, name)
+			signedHeaders = append(signedHeaders, strings.ToLower(name))
+		}
+	}
+	separator := "\n"
+	if s.Separator != nil {
+		separator = *s.Separator
+	}
+
+	mac := hmac.New(s.newHash, s.key)
+	mac.Write([]byte(strings.Join(parts, separator)))
+	signature := hex.EncodeToString(mac.Sum(nil))
+	if s.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	header := strings.SplitN(s.Header, ":", 2)
+	value := strings.NewReplacer(
+		"{signature}", signature,
+		"{key-id}", s.KeyID,
+		"{timestamp}", timestamp,
+		"{nonce}", nonceHex,
+		"{headers}", strings.Join(signedHeaders, " "),
+	).Replace(strings.TrimSpace(header[1]))
+	headers[strings.TrimSpace(header[0])] = value
+	req.Headers = headers
+	return nil
+}
+
+// GetDescription returns a human-readable description of the signer
+func (s *HMACSigner) GetDescription() string {
+	algorithm := s.Algorithm
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	return fmt.Sprintf("HMAC-%s signature (%s)", strings.ToUpper(algorithm), strings.Join(s.Components, ", "))
+}
+
+// hmacComponent returns the value of a component of an HMAC canonical string, and whether the
+// component is supported. Header components are resolved by the caller.
+func hmacComponent(component string, req *ffuf.Request, u *url.URL, timestamp, nonce, keyID string) (string, bool) {
+	if strings.HasPrefix(component, "header:") {
+		return "", len(component) > len("header:")
+	}
+	if len(component) >= 2 && (component[0] == '\'' || component[0] == '"') && component[len(component)-1] == component[0] {
+		return component[1 : len(component)-1], true
+	}
+	switch component {
+	case "method", "path", "query", "url", "host", "body", "body-sha256", "body-md5", "timestamp", "nonce", "key-id":
+	default:
+		return "", false
+	}
+	if req == nil {
+		return "", true
+	}
+	switch component {
+	case "method":
+		return strings.ToUpper(req.Method), true
+	case "path":
+		if u.EscapedPath() == "" {
+			return "/", true
+		}
+		return u.EscapedPath(), true
+	case "query":
+		return u.RawQuery, true
+	case "url":
+		return req.Url, true
+	case "host":
+		if host := headerValue(req.Headers, "Host"); host != "" {
+			return host, true
+		}
+		return u.Host, true
+	case "body":
+		return string(req.Data), true
+	case "body-sha256":
+		return hex.EncodeToString(sha256Hash(req.Data)), true
+	case "body-md5":
+		sum := md5.Sum(req.Data)
+		return hex.EncodeToString(sum[:]), true
+	case "timestamp":
+		return timestamp, true
+	case "nonce":
+		return nonce, true
+	}
+	return keyID, true
+}
+
+// ProviderSigner signs requests with an authentication provider, such as a provider of the
+// plugin registry
+type ProviderSigner struct {
+	Provider AuthProvider
+}
+
+// Sign adds the headers set by the authentication provider to a request
+func (s *ProviderSigner) Sign(req *ffuf.Request) error {
+	httpreq, err := http.NewRequest(req.Method, req.Url, nil)
+	if err != nil {
+		return api.NewAPIError("Cannot sign request with invalid URL: "+err.Error(), 0)
+	}
+	rawQuery := httpreq.URL.RawQuery
+	for name, value := range req.Headers {
+		httpreq.Header.Set(name, value)
+	}
+	if err := s.Provider.AddAuth(httpreq); err != nil {
+		return err
+	}
+	headers := make(map[string]string, len(httpreq.Header))
+	for name := range httpreq.Header {
+		headers[name] = httpreq.Header.Get(name)
+	}
+	req.Headers = headers
+	// Keep the URL unchanged unless the provider added query parameters, as re-encoding it
+	// would alter fuzz values
+	if httpreq.URL.RawQuery != rawQuery {
+		req.Url = httpreq.URL.String()
+	}
+	return nil
+}
+
+// GetDescription returns a human-readable description of the signer
+func (s *ProviderSigner) GetDescription() string {
+	return s.Provider.GetDescription()
+}
+
+// copyHeaders returns a copy of the headers of a request
+func copyHeaders(headers map[string]string) map[string]string {
+	copied := make(map[string]string, len(headers)+3)
+	for name, value := range headers {
+		copied[name] = value
+	}
+	return copied
+}
+
+// headerValue returns the value of a header, ignoring the case of its name
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+func TestSigV4Signer(t *testing.T) {
+	// Test vectors of the AWS Signature Version 4 test suite
+	tests := []struct {
+		name      string
+		url       string
+		signature string
+	}{
+		{"get-vanilla", "https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-query-order-key-case", "https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := &SigV4Signer{
+				AccessKey: "AKIDEXAMPLE",
+				SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+				Region:    "us-east-1",
+				Service:   "service",
+				now:       func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+			}
+			if err := signer.init(); err != nil {
+				t.Fatalf("init returned an error: %s", err)
+			}
+			req := &ffuf.Request{Method: "GET", Url: tt.url, Headers: map[string]string{}}
+			if err := signer.Sign(req); err != nil {
+				t.Fatalf("Sign returned an error: %s", err)
+			}
+			expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + tt.signature
+			if req.Headers["Authorization"] != expected {
+				t.Errorf("Expected Authorization header\n%s\ngot\n%s", expected, req.Headers["Authorization"])
+			}
+			if req.Headers["X-Amz-Date"] != "20150830T123600Z" {
+				t.Errorf("Expected X-Amz-Date 20150830T123600Z, got %s", req.Headers["X-Amz-Date"])
+			}
+		})
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	separator := "|"
+	signer := &HMACSigner{
+		KeyID:           "key1",
+		Secret:          "secret",
+		Components:      []string{"method", "path", "query", "timestamp", "header:X-Tenant", "'v1'", "body"},
+		Separator:       &separator,
+		Header:          "Authorization: HMAC {key-id}:{signature}",
+		TimestampHeader: "X-Timestamp",
+		now:             func() time.Time { return time.Unix(1700000000, 0) },
+	}
+	if err := signer.init(); err != nil {
+		t.Fatalf("init returned an error: %s", err)
+	}
+	req := &ffuf.Request{Method: "post", Url: "https://api.example.com/orders?id=1", Headers: map[string]string{"x-tenant": "acme"}, Data: []byte(`{"a":1}`)}
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("Sign returned an error: %s", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`POST|/orders|id=1|1700000000|acme|v1|{"a":1}`))
+	expected := "HMAC key1:" + hex.EncodeToString(mac.Sum(nil))
+	if req.Headers["Authorization"] != expected {
+		t.Errorf("Expected Authorization header %s, got %s", expected, req.Headers["Authorization"])
+	}
+	if req.Headers["X-Timestamp"] != "1700000000" {
+		t.Errorf("Expected X-Timestamp 1700000000, got %s", req.Headers["X-Timestamp"])
+	}
+
+	invalid := &HMACSigner{Secret: "secret", Components: []string{"method", "cookie"}}
+	if err := invalid.init(); err == nil {
+		t.Errorf("Expected an error for an unsupported component")
+	}
+}
+
+func TestSigningRunnerTargets(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	config := &SigningConfig{Targets: []*SigningTarget{
+		{Host: "127.0.0.1", PathPrefix: "/signed", HMAC: &HMACSigner{Secret: "secret"}},
+		{Host: "*.example.com", HMAC: &HMACSigner{Secret: "other", Header: "X-Other: {signature}"}},
+	}}
+	signing, err := NewSigningRunner(config, newTestRunner(), nil, nil)
+	if err != nil {
+		t.Fatalf("NewSigningRunner returned an error: %s", err)
+	}
+
+	headers := map[string]string{}
+	if _, err := signing.Execute(&ffuf.Request{Method: "GET", Url: server.URL + "/signed/users", Headers: headers}); err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if received.Get("X-Signature") == "" {
+		t.Errorf("Expected a signature for a request to a target")
+	}
+	if len(headers) != 0 {
+		t.Errorf("Execute modified the headers of the request")
+	}
+
+	if _, err := signing.Execute(&ffuf.Request{Method: "GET", Url: server.URL + "/public", Headers: map[string]string{}}); err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if received.Get("X-Signature") != "" || received.Get("X-Other") != "" {
+		t.Errorf("Expected no signature for a request outside of the targets")
+	}
+
+	if _, err := NewSigningRunner(&SigningConfig{Targets: []*SigningTarget{{Host: "example.com"}}}, newTestRunner(), nil, nil); err == nil {
+		t.Errorf("Expected an error for a target without signer")
+	}
+}
+
+func TestSigningRunnerPlugin(t *testing.T) {
+	err := RegisterAuthProvider("test-signer", func(config map[string]string) (AuthProvider, error) {
+		return NewCustomAuth("test-signer", "", config, func(req *http.Request, config map[string]string) error {
+			req.Header.Set("X-Custom", config["value"])
+			return nil
+		}), nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterAuthProvider returned an error: %s", err)
+	}
+	defer UnregisterAuthProvider("test-signer")
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("X-Custom") + " " + r.URL.RawQuery
+	}))
+	defer server.Close()
+
+	config := &SigningConfig{Targets: []*SigningTarget{{Plugin: "test-signer", Options: map[string]string{"value": "signed"}}}}
+	signing, err := NewSigningRunner(config, newTestRunner(), nil, nil)
+	if err != nil {
+		t.Fatalf("NewSigningRunner returned an error: %s", err)
+	}
+	if _, err := signing.Execute(&ffuf.Request{Method: "GET", Url: server.URL + "/?q=%27", Headers: map[string]string{}}); err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if received != "signed q=%27" {
+		t.Errorf("Expected the plugin header and the unchanged query, got %s", received)
+	}
+}
+
+func TestSigningRunnerClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	conf := &ffuf.Config{Context: context.Background(), Timeout: 10}
+	newRunner := func(conf *ffuf.Config) ffuf.RunnerProvider { return runner.NewSimpleRunner(conf, false) }
+	config := &SigningConfig{Targets: []*SigningTarget{{Host: "127.0.0.1:*", ClientCert: certFile, ClientKey: keyFile}}}
+	signing, err := NewSigningRunner(config, newRunner(conf), conf, newRunner)
+	if err != nil {
+		t.Fatalf("NewSigningRunner returned an error: %s", err)
+	}
+	resp, err := signing.Execute(&ffuf.Request{Method: "GET", Url: server.URL, Headers: map[string]string{}})
+	if err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if resp.StatusCode != 200 || !strings.Contains(string(resp.Data), "ffuf-test") {
+		t.Errorf("Expected the client certificate to be sent, got status %d: %s", resp.StatusCode, resp.Data)
+	}
+}
+
+// writeTestCertificate writes a self-signed client certificate and its key to a directory
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	template.Subject.CommonName = "ffuf-test"
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %s", err)
+	}
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write certificate: %s", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %s", err)
+	}
+	return certFile, keyFile
+}
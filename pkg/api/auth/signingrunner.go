@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// SigningConfig configures the signing of requests per target
+type SigningConfig struct {
+	Targets []*SigningTarget `json:"targets"`
+}
+
+// SigningTarget configures the signing of the requests to a target. Exactly one signer, or
+// only a client certificate, is set per target.
+type SigningTarget struct {
+	// Host is a glob pattern matching the host of the target, e.g. *.example.com. An empty
+	// pattern matches every host.
+	Host string `json:"host"`
+	// PathPrefix restricts the target to the URLs whose path starts with it
+	PathPrefix string `json:"path_prefix"`
+
+	SigV4 *SigV4Signer `json:"sigv4"`
+	HMAC  *HMACSigner  `json:"hmac"`
+	// Plugin is the name of an authentication provider of the plugin registry, created with
+	// Options
+	Plugin  string            `json:"plugin"`
+	Options map[string]string `json:"options"`
+
+	// ClientCert and ClientKey are the client certificate and key of mutual TLS with the target
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+
+	signer Signer
+	runner ffuf.RunnerProvider
+}
+
+// LoadSigningConfig reads a JSON signing config file
+func LoadSigningConfig(filename string) (*SigningConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to read signing config: "+err.Error(), 0)
+	}
+	var config SigningConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, api.NewAPIError("Failed to parse signing config: "+err.Error(), 0)
+	}
+	return &config, nil
+}
+
+// init creates the signer of the target and validates its client certificate
+func (t *SigningTarget) init() error {
+	if _, err := path.Match(t.Host, ""); err != nil {
+		return api.NewAPIError("Invalid host pattern "+t.Host+": "+err.Error(), 0)
+	}
+	signers := 0
+	if t.SigV4 != nil {
+		if err := t.SigV4.init(); err != nil {
+			return err
+		}
+		t.signer = t.SigV4
+		signers++
+	}
+	if t.HMAC != nil {
+		if err := t.HMAC.init(); err != nil {
+			return err
+		}
+		t.signer = t.HMAC
+		signers++
+	}
+	if t.Plugin != "" {
+		provider, err := CreateAuthProvider(t.Plugin, t.Options)
+		if err != nil {
+			return api.NewAPIError(err.Error(), 0)
+		}
+		t.signer = &ProviderSigner{Provider: provider}
+		signers++
+	}
+	if signers > 1 {
+		return api.NewAPIError("Signing target "+t.Host+" has more than one signer", 0)
+	}
+	if (t.ClientCert == "") != (t.ClientKey == "") {
+		return api.NewAPIError("Signing target "+t.Host+" needs both a client certificate and a client key", 0)
+	}
+	if t.ClientCert != "" {
+		if _, err := tls.LoadX509KeyPair(t.ClientCert, t.ClientKey); err != nil {
+			return api.NewAPIError("Failed to load client certificate: "+err.Error(), 0)
+		}
+	}
+	if signers == 0 && t.ClientCert == "" {
+		return api.NewAPIError("Signing target "+t.Host+" has neither a signer nor a client certificate", 0)
+	}
+	return nil
+}
+
+// matches checks if a URL belongs to the target
+func (t *SigningTarget) matches(u *url.URL) bool {
+	if t.Host != "" {
+		if ok, _ := path.Match(strings.ToLower(t.Host), strings.ToLower(u.Hostname())); !ok {
+			if ok, _ := path.Match(strings.ToLower(t.Host), strings.ToLower(u.Host)); !ok {
+				return false
+			}
+		}
+	}
+	return strings.HasPrefix(u.Path, t.PathPrefix)
+}
+
+// SigningRunner wraps a runner to sign its requests with the signer of the first matching
+// target, and to send them with the client certificate of the target
+type SigningRunner struct {
+	targets []*SigningTarget
+	runner  ffuf.RunnerProvider
+}
+
+// NewSigningRunner creates a runner signing its requests. newRunner creates the runner sending
+// the requests of targets with a client certificate from a copy of the config having it set.
+func NewSigningRunner(config *SigningConfig, r ffuf.RunnerProvider, conf *ffuf.Config, newRunner func(conf *ffuf.Config) ffuf.RunnerProvider) (*SigningRunner, error) {
+	for _, target := range config.Targets {
+		if err := target.init(); err != nil {
+			return nil, err
+		}
+		if target.ClientCert != "" {
+			if newRunner == nil {
+				return nil, api.NewAPIError("Client certificates of signing targets are not supported by this runner", 0)
+			}
+			targetConf := *conf
+			targetConf.ClientCert = target.ClientCert
+			targetConf.ClientKey = target.ClientKey
+			target.runner = newRunner(&targetConf)
+		}
+	}
+	return &SigningRunner{
+		targets: config.Targets,
+		runner:  r,
+	}, nil
+}
+
+// NewConfiguredSigningRunner wraps a runner with the signing config file of the config, or
+// returns the runner if no signing config is set
+func NewConfiguredSigningRunner(conf *ffuf.Config, r ffuf.RunnerProvider, newRunner func(conf *ffuf.Config) ffuf.RunnerProvider) (ffuf.RunnerProvider, error) {
+	if conf.APISigningConfig == "" {
+		return r, nil
+	}
+	config, err := LoadSigningConfig(conf.APISigningConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigningRunner(config, r, conf, newRunner)
+}
+
+// Prepare prepares a request using the underlying runner
+func (r *SigningRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return r.runner.Prepare(input, basereq)
+}
+
+// Dump dumps a request using the underlying runner
+func (r *SigningRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return r.runner.Dump(req)
+}
+
+// Execute signs a copy of a request for its target, and executes it
+func (r *SigningRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return r.runner.Execute(req)
+	}
+	for _, target := range r.targets {
+		if !target.matches(u) {
+			continue
+		}
+		signed := *req
+		if target.signer != nil {
+			if err := target.signer.Sign(&signed); err != nil {
+				return ffuf.Response{}, err
+			}
+		}
+		if target.runner != nil {
+			return target.runner.Execute(&signed)
+		}
+		return r.runner.Execute(&signed)
+	}
+	return r.runner.Execute(req)
+}
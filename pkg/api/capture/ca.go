@@ -0,0 +1,164 @@
+package capture
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// CertificateAuthority issues the certificates presented to clients for intercepted HTTPS
+// connections. Clients have to trust its certificate.
+type CertificateAuthority struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	certPEM []byte
+	keyPEM  []byte
+
+	mu    sync.Mutex
+	cache map[string]*tls.Certificate
+}
+
+// NewCertificateAuthority generates a certificate authority valid for a year
+func NewCertificateAuthority() (*CertificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to generate CA key: "+err.Error(), 0)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "ffuf capture CA", Organization: []string{"ffuf"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to create CA certificate: "+err.Error(), 0)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to marshal CA key: "+err.Error(), 0)
+	}
+	return parseCertificateAuthority(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	)
+}
+
+// LoadCertificateAuthority reads a certificate authority from PEM encoded certificate and
+// key files
+func LoadCertificateAuthority(certFile, keyFile string) (*CertificateAuthority, error) {
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to read CA certificate: "+err.Error(), 0)
+	}
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to read CA key: "+err.Error(), 0)
+	}
+	return parseCertificateAuthority(certPEM, keyPEM)
+}
+
+// parseCertificateAuthority creates a certificate authority from its PEM encoded certificate
+// and key
+func parseCertificateAuthority(certPEM, keyPEM []byte) (*CertificateAuthority, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, api.NewAPIError("Invalid CA certificate or key: "+err.Error(), 0)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, api.NewAPIError("Invalid CA certificate: "+err.Error(), 0)
+	}
+	if !cert.IsCA {
+		return nil, api.NewAPIError("Certificate "+cert.Subject.CommonName+" is not a CA certificate", 0)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, api.NewAPIError("Unsupported CA key type", 0)
+	}
+	return &CertificateAuthority{
+		cert:    cert,
+		key:     key,
+		certPEM: certPEM,
+		keyPEM:  keyPEM,
+		cache:   make(map[string]*tls.Certificate),
+	}, nil
+}
+
+// Save writes the PEM encoded certificate and key of the certificate authority
+func (ca *CertificateAuthority) Save(certFile, keyFile string) error {
+	if err := ioutil.WriteFile(certFile, ca.certPEM, 0644); err != nil {
+		return api.NewAPIError("Failed to write CA certificate: "+err.Error(), 0)
+	}
+	if err := ioutil.WriteFile(keyFile, ca.keyPEM, 0600); err != nil {
+		return api.NewAPIError("Failed to write CA key: "+err.Error(), 0)
+	}
+	return nil
+}
+
+// CertificatePEM returns the PEM encoded certificate of the certificate authority
+func (ca *CertificateAuthority) CertificatePEM() []byte {
+	return ca.certPEM
+}
+
+// Certificate returns a certificate for a host name or IP address signed by the authority
+func (ca *CertificateAuthority) Certificate(host string) (*tls.Certificate, error) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if cert, ok := ca.cache[host]; ok {
+		return cert, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(0, 1, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, err
+	}
+	cert := &tls.Certificate{
+		Certificate: [][]byte{der, ca.cert.Raw},
+		PrivateKey:  key,
+	}
+	ca.cache[host] = cert
+	return cert, nil
+}
+
+// randomSerial returns a random certificate serial number
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
@@ -0,0 +1,569 @@
+// Package capture records the traffic between a client and an API through an intercepting
+// proxy, and builds an inventory of the endpoints, parameters and schemas of the API from it.
+package capture
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+// DefaultMaxBodySize is the maximum size of the recorded request and response bodies
+const DefaultMaxBodySize = 1024 * 1024
+
+// Entry is a request and its response in HAR 1.2 format
+type Entry struct {
+	StartedDateTime time.Time     `json:"startedDateTime"`
+	Time            float64       `json:"time"`
+	Request         EntryRequest  `json:"request"`
+	Response        EntryResponse `json:"response"`
+	Cache           struct{}      `json:"cache"`
+	Timings         EntryTimings  `json:"timings"`
+	Comment         string        `json:"comment,omitempty"`
+}
+
+// EntryRequest is a recorded request in HAR 1.2 format
+type EntryRequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	Cookies     []NameValue `json:"cookies"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *EntryBody  `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// EntryResponse is a recorded response in HAR 1.2 format
+type EntryResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []NameValue  `json:"headers"`
+	Cookies     []NameValue  `json:"cookies"`
+	Content     EntryContent `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+// EntryBody is a recorded request body in HAR 1.2 format
+type EntryBody struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// EntryContent is a recorded response body in HAR 1.2 format. Binary bodies are base64
+// encoded.
+type EntryContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// EntryTimings are the timings of a recorded request in HAR 1.2 format, in milliseconds
+type EntryTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NameValue is a name and value pair in HAR 1.2 format
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Recorder records requests and responses, and incrementally builds the inventory of the
+// API from them. Recorders are safe for concurrent use.
+type Recorder struct {
+	// Parser builds the inventory from the recorded requests. Its Hosts restrict the
+	// recorded hosts, and IncludeStatic records requests for static assets.
+	Parser *parser.HARParser
+	// MaxBodySize is the maximum size of the recorded bodies, larger bodies are truncated
+	MaxBodySize int
+	// OnRecord is called with each recorded entry
+	OnRecord func(entry *Entry)
+	// Redactor masks the tokens and personal data of the HAR capture and the examples of the
+	// inventory, nil to write them as is. The recorded entries are kept as is to be scanned.
+	Redactor *secrets.Redactor
+
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+// NewRecorder creates a new recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		Parser:      parser.NewHARParser(),
+		MaxBodySize: DefaultMaxBodySize,
+		entries:     make([]*Entry, 0),
+	}
+}
+
+// Record records a request and its response. The request URL is absolute.
+func (r *Recorder) Record(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, started time.Time, duration time.Duration) error {
+	entry := &Entry{
+		StartedDateTime: started,
+		Time:            float64(duration) / float64(time.Millisecond),
+		Request: EntryRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     headerValues(req.Header),
+			Cookies:     make([]NameValue, 0),
+			QueryString: make([]NameValue, 0),
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: EntryResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Headers:     headerValues(resp.Header),
+			Cookies:     make([]NameValue, 0),
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(respBody),
+		},
+		Timings: EntryTimings{Wait: float64(duration) / float64(time.Millisecond)},
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		entry.Request.Headers = append(entry.Request.Headers, NameValue{Name: "Host", Value: req.Host})
+	}
+	for _, cookie := range req.Cookies() {
+		entry.Request.Cookies = append(entry.Request.Cookies, NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	for _, cookie := range resp.Cookies() {
+		entry.Response.Cookies = append(entry.Response.Cookies, NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, NameValue{Name: name, Value: value})
+		}
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &EntryBody{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(r.truncate(reqBody)),
+		}
+	}
+
+	body := decodeBody(respBody, resp.Header.Get("Content-Encoding"), resp.Header.Get("Content-Type"))
+	entry.Response.Content = EntryContent{Size: len(body), MimeType: resp.Header.Get("Content-Type")}
+	if len(body) > 0 {
+		body = r.truncate(body)
+		if utf8.Valid(body) {
+			entry.Response.Content.Text = string(body)
+		} else {
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+			entry.Response.Content.Encoding = "base64"
+		}
+	}
+	return r.Add(entry)
+}
+
+// Add adds a recorded entry to the inventory. Entries for hosts other than the hosts of the
+// parser, if set, are ignored.
+func (r *Recorder) Add(entry *Entry) error {
+	if !r.acceptURL(entry.Request.URL) {
+		return nil
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"log": map[string]interface{}{"entries": []*Entry{entry}},
+	})
+	if err != nil {
+		return api.NewAPIError("Failed to encode entry: "+err.Error(), 0)
+	}
+
+	r.mu.Lock()
+	err = r.Parser.ParseJSON(data)
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if r.OnRecord != nil {
+		r.OnRecord(entry)
+	}
+	return nil
+}
+
+// Import records the messages exported by an intercepting proxy, such as Burp Suite or OWASP
+// ZAP. Messages without a response are recorded with an empty response.
+func (r *Recorder) Import(messages []*parser.ProxyMessage) error {
+	for _, message := range messages {
+		resp := message.Response
+		if resp == nil {
+			resp = &http.Response{Header: http.Header{}}
+		}
+		if err := r.Record(message.Request, message.RequestBody, resp, message.ResponseBody, message.Time, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportFile records the entries of a HAR capture, or the messages of a Burp Suite XML export
+// or an OWASP ZAP message export. It returns the number of imported requests and the format
+// of the file.
+func (r *Recorder) ImportFile(filePath string) (int, string, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return 0, "", api.NewAPIError("Failed to read import: "+err.Error(), 0)
+	}
+	var har struct {
+		Log struct {
+			Entries []*Entry `json:"entries"`
+		} `json:"log"`
+	}
+	if json.Unmarshal(data, &har) == nil && har.Log.Entries != nil {
+		for _, entry := range har.Log.Entries {
+			if err := r.Add(entry); err != nil {
+				return 0, "HAR", err
+			}
+		}
+		return len(har.Log.Entries), "HAR", nil
+	}
+
+	messages, format, err := parser.ParseProxyExport(data)
+	if err != nil {
+		return 0, format, err
+	}
+	return len(messages), format, r.Import(messages)
+}
+
+// acceptURL checks if the requests to a URL are recorded
+func (r *Recorder) acceptURL(rawURL string) bool {
+	if len(r.Parser.Hosts) == 0 {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, host := range r.Parser.Hosts {
+		if strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate shortens a body to the maximum body size
+func (r *Recorder) truncate(body []byte) []byte {
+	if r.MaxBodySize > 0 && len(body) > r.MaxBodySize {
+		return body[:r.MaxBodySize]
+	}
+	return body
+}
+
+// Entries returns the recorded entries
+func (r *Recorder) Entries() []*Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]*Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// WriteHAR writes the recorded entries as a HAR 1.2 capture, which can be imported again with
+// parser.APIEndpointDiscovery.DiscoverFromHAR
+func (r *Recorder) WriteHAR(w io.Writer) error {
+	har := map[string]interface{}{
+		"log": map[string]interface{}{
+			"version": "1.2",
+			"creator": map[string]string{"name": "ffuf", "version": ffuf.Version()},
+			"entries": r.redactedEntries(),
+		},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(har); err != nil {
+		return api.NewAPIError("Failed to write HAR capture: "+err.Error(), 0)
+	}
+	return nil
+}
+
+// redactedEntries returns the recorded entries masked by the redactor
+func (r *Recorder) redactedEntries() []*Entry {
+	entries := r.Entries()
+	for i, entry := range entries {
+		entries[i] = redactEntry(r.Redactor, entry)
+	}
+	return entries
+}
+
+// Discovery returns the endpoints recorded so far, to be passed to the test generator
+func (r *Recorder) Discovery() *parser.APIEndpointDiscovery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	discovery := parser.NewAPIEndpointDiscovery("")
+	discovery.DiscoverFromHARParser(r.Parser)
+	return discovery
+}
+
+// Values returns the candidate values of the parameters of the recorded endpoints: the values
+// of the query strings and JSON bodies of the requests and of the JSON responses recorded so
+// far, and the examples of the endpoints
+func (r *Recorder) Values() *parser.ValueDictionary {
+	values := parser.NewValueDictionaryFromEndpoints(r.Discovery().GetEndpoints())
+	for _, entry := range r.Entries() {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		for _, pair := range entry.Request.QueryString {
+			values.AddObservedValue(pair.Name, pair.Value)
+		}
+		if entry.Request.PostData != nil && strings.Contains(entry.Request.PostData.MimeType, "json") {
+			values.AddJSON(u.Path, []byte(entry.Request.PostData.Text))
+		}
+		content := entry.Response.Content
+		if entry.Response.Status >= 200 && entry.Response.Status < 300 && content.Encoding == "" && strings.Contains(content.MimeType, "json") {
+			values.AddJSON(u.Path, []byte(content.Text))
+		}
+	}
+	return values
+}
+
+// InventoryEndpoint is an endpoint of the inventory of an API
+type InventoryEndpoint struct {
+	Method         string                 `json:"method"`
+	Path           string                 `json:"path"`
+	URL            string                 `json:"url"`
+	Parameters     []InventoryParameter   `json:"parameters"`
+	RequestType    string                 `json:"request_type,omitempty"`
+	RequestSchema  map[string]interface{} `json:"request_schema,omitempty"`
+	ResponseSchema map[string]interface{} `json:"response_schema,omitempty"`
+	StatusCodes    []int                  `json:"status_codes"`
+	Count          int                    `json:"count"`
+	RequiresAuth   bool                   `json:"requires_auth"`
+	// RiskScore and RiskFactors are the risk of the endpoint, see parser.ScoreEndpoint
+	RiskScore   int      `json:"risk_score,omitempty"`
+	RiskFactors []string `json:"risk_factors,omitempty"`
+	// Source and Description are those of the endpoints of specifications, e.g. Shadow for the
+	// endpoints of shadow APIs
+	Source      string `json:"source,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// InventoryParameter is a parameter of an endpoint of the inventory of an API
+type InventoryParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Type     string      `json:"type"`
+	Required bool        `json:"required"`
+	Example  interface{} `json:"example,omitempty"`
+}
+
+// Inventory returns the endpoints recorded so far, with their parameters and the JSON
+// schemas of their request and response bodies
+func (r *Recorder) Inventory() []*InventoryEndpoint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inventory := make([]*InventoryEndpoint, 0, len(r.Parser.Endpoints))
+	for _, endpoint := range r.Parser.GetEndpoints() {
+		item := &InventoryEndpoint{
+			Method:         endpoint.Method,
+			Path:           endpoint.Path,
+			URL:            endpoint.Origin + endpoint.Path,
+			Parameters:     make([]InventoryParameter, 0, len(endpoint.Parameters)),
+			RequestType:    endpoint.RequestMimeType,
+			RequestSchema:  r.jsonSchema(endpoint.RequestSchema),
+			ResponseSchema: r.jsonSchema(endpoint.ResponseSchema),
+			StatusCodes:    endpoint.StatusCodes,
+			Count:          endpoint.Count,
+			RequiresAuth:   endpoint.RequiresAuth,
+		}
+		for _, param := range endpoint.Parameters {
+			item.Parameters = append(item.Parameters, InventoryParameter{
+				Name:     param.Name,
+				In:       param.In,
+				Type:     param.Type,
+				Required: param.Required,
+				Example:  r.redactExample(param.Name, param.In, param.Example),
+			})
+		}
+		inventory = append(inventory, item)
+	}
+	return inventory
+}
+
+// redactExample masks the example value of a parameter
+func (r *Recorder) redactExample(name, in string, example interface{}) interface{} {
+	value, ok := example.(string)
+	if !ok || r.Redactor == nil {
+		return example
+	}
+	switch in {
+	case "header":
+		return r.Redactor.Value(name, value)
+	case "cookie":
+		return r.Redactor.Cookie(value)
+	}
+	return r.Redactor.Param(name, value)
+}
+
+// jsonSchema converts an inferred schema to a JSON schema, with the lock held
+func (r *Recorder) jsonSchema(schema *parser.Schema) map[string]interface{} {
+	if schema == nil || r.Parser.SchemaDetector == nil {
+		return nil
+	}
+	data, err := r.Parser.SchemaDetector.ConvertToJSONSchema(schema)
+	if err != nil {
+		return nil
+	}
+	var converted map[string]interface{}
+	if err := json.Unmarshal(data, &converted); err != nil {
+		return nil
+	}
+	return converted
+}
+
+// WriteInventory writes the inventory as JSON
+func (r *Recorder) WriteInventory(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(map[string]interface{}{"endpoints": r.Inventory()}); err != nil {
+		return api.NewAPIError("Failed to write inventory: "+err.Error(), 0)
+	}
+	return nil
+}
+
+// Targets returns an observed URL of each recorded endpoint, with its method and the media
+// type of its request body, to be scanned by the security testers. The riskiest endpoints
+// come first.
+func (r *Recorder) Targets() []*Target {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	targets := make([]*Target, 0, len(r.Parser.Endpoints))
+	for _, endpoint := range r.Parser.GetEndpoints() {
+		risk := parser.ScoreEndpoint(&parser.DiscoveredEndpoint{
+			Method:       endpoint.Method,
+			Path:         endpoint.Path,
+			Parameters:   endpoint.Parameters,
+			RequiresAuth: endpoint.RequiresAuth,
+		})
+		targets = append(targets, &Target{
+			Method:      endpoint.Method,
+			URL:         endpoint.URL,
+			Path:        endpoint.Path,
+			ContentType: endpoint.RequestMimeType,
+			Risk:        risk.Score,
+		})
+	}
+	SortTargets(targets)
+	return targets
+}
+
+// SortTargets orders targets by risk, the riskiest first, so that they are scanned first
+func SortTargets(targets []*Target) {
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Risk > targets[j].Risk
+	})
+}
+
+// Target is an observed request to an endpoint of the inventory
+type Target struct {
+	Method      string
+	URL         string
+	Path        string
+	ContentType string
+	// Risk is the risk score of the endpoint, see parser.ScoreEndpoint
+	Risk int
+}
+
+// headerValues converts headers to HAR name and value pairs
+func headerValues(header http.Header) []NameValue {
+	values := make([]NameValue, 0, len(header))
+	for name, hv := range header {
+		for _, value := range hv {
+			values = append(values, NameValue{Name: name, Value: value})
+		}
+	}
+	return values
+}
+
+// redactEntry returns a copy of an entry with the URL, headers, cookies, query string and
+// bodies of its request and response masked by a redactor, or the entry if the redactor is nil
+func redactEntry(redactor *secrets.Redactor, entry *Entry) *Entry {
+	if redactor == nil {
+		return entry
+	}
+	redacted := *entry
+	redacted.Comment = redactor.String(entry.Comment)
+	redacted.Request.URL = redactor.String(entry.Request.URL)
+	redacted.Request.Headers = redactHeaders(redactor, entry.Request.Headers)
+	redacted.Request.Cookies = redactCookies(redactor, entry.Request.Cookies)
+	redacted.Request.QueryString = make([]NameValue, len(entry.Request.QueryString))
+	for i, param := range entry.Request.QueryString {
+		redacted.Request.QueryString[i] = NameValue{Name: param.Name, Value: redactor.Param(param.Name, param.Value)}
+	}
+	if entry.Request.PostData != nil {
+		redacted.Request.PostData = &EntryBody{MimeType: entry.Request.PostData.MimeType, Text: redactor.String(entry.Request.PostData.Text)}
+	}
+	redacted.Response.Headers = redactHeaders(redactor, entry.Response.Headers)
+	redacted.Response.Cookies = redactCookies(redactor, entry.Response.Cookies)
+	redacted.Response.RedirectURL = redactor.String(entry.Response.RedirectURL)
+	if entry.Response.Content.Encoding == "" {
+		redacted.Response.Content.Text = redactor.String(entry.Response.Content.Text)
+	}
+	return &redacted
+}
+
+// redactHeaders returns a copy of HAR headers with their values masked
+func redactHeaders(redactor *secrets.Redactor, headers []NameValue) []NameValue {
+	redacted := make([]NameValue, len(headers))
+	for i, header := range headers {
+		redacted[i] = NameValue{Name: header.Name, Value: redactor.Value(header.Name, header.Value)}
+	}
+	return redacted
+}
+
+// redactCookies returns a copy of HAR cookies with their values masked
+func redactCookies(redactor *secrets.Redactor, cookies []NameValue) []NameValue {
+	redacted := make([]NameValue, len(cookies))
+	for i, cookie := range cookies {
+		redacted[i] = NameValue{Name: cookie.Name, Value: redactor.Cookie(cookie.Value)}
+	}
+	return redacted
+}
+
+// decodeBody decompresses a body with the given content encoding and converts it to UTF-8
+// from the charset of its content type. It returns the body unchanged if it is not compressed
+// or cannot be decompressed.
+func decodeBody(body []byte, encoding, contentType string) []byte {
+	if strings.TrimSpace(encoding) != "" {
+		decoded, err := ioutil.ReadAll(runner.DecodeBody(bytes.NewReader(body), encoding))
+		if err == nil || len(decoded) > 0 {
+			body = decoded
+		}
+	}
+	reader, name := runner.DecodeCharset(bytes.NewReader(body), contentType)
+	if name == "" {
+		return body
+	}
+	if decoded, err := ioutil.ReadAll(reader); err == nil {
+		return decoded
+	}
+	return body
+}
@@ -0,0 +1,328 @@
+package capture
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+)
+
+// newTestAPI creates a server for a small users API
+func newTestAPI(t *testing.T, secure bool) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/api/users/"):
+			w.Write([]byte(`{"id": 42, "name": "alice", "admin": false}`))
+		case r.Method == "POST" && r.URL.Path == "/api/users":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 43}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not found"}`))
+		}
+	})
+	if secure {
+		return httptest.NewTLSServer(handler)
+	}
+	return httptest.NewServer(handler)
+}
+
+// findEndpoint returns the inventory endpoint with a method and path
+func findEndpoint(inventory []*InventoryEndpoint, method, path string) *InventoryEndpoint {
+	for _, endpoint := range inventory {
+		if endpoint.Method == method && endpoint.Path == path {
+			return endpoint
+		}
+	}
+	return nil
+}
+
+func TestReverseProxy(t *testing.T) {
+	upstream := newTestAPI(t, false)
+	defer upstream.Close()
+
+	recorder := NewRecorder()
+	proxy, err := NewProxy(recorder, upstream.URL)
+	if err != nil {
+		t.Fatalf("NewProxy returned an error: %s", err)
+	}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/users/42?fields=name")
+	if err != nil {
+		t.Fatalf("Request through the proxy failed: %s", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 || !strings.Contains(string(body), "alice") {
+		t.Errorf("Expected the upstream response, got status %d: %s", resp.StatusCode, body)
+	}
+
+	resp, err = http.Post(server.URL+"/api/users", "application/json", strings.NewReader(`{"name": "bob", "admin": true}`))
+	if err != nil {
+		t.Fatalf("Request through the proxy failed: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 recorded entries, got %d", len(entries))
+	}
+	if entries[1].Request.PostData == nil || !strings.Contains(entries[1].Request.PostData.Text, "bob") {
+		t.Errorf("Expected the request body to be recorded")
+	}
+
+	inventory := recorder.Inventory()
+	get := findEndpoint(inventory, "GET", "/api/users/{userId}")
+	if get == nil {
+		t.Fatalf("Expected GET /api/users/{userId} in the inventory, got %d endpoints", len(inventory))
+	}
+	names := make(map[string]string)
+	for _, param := range get.Parameters {
+		names[param.Name] = param.In
+	}
+	if names["userId"] != "path" || names["fields"] != "query" {
+		t.Errorf("Expected the path and query parameters, got %v", names)
+	}
+	if get.ResponseSchema == nil {
+		t.Errorf("Expected a response schema")
+	}
+
+	post := findEndpoint(inventory, "POST", "/api/users")
+	if post == nil {
+		t.Fatalf("Expected POST /api/users in the inventory")
+	}
+	if post.RequestSchema == nil || post.RequestType != "application/json" {
+		t.Errorf("Expected a JSON request schema, got %s %v", post.RequestType, post.RequestSchema)
+	}
+
+	targets := recorder.Targets()
+	if len(targets) != 2 {
+		t.Fatalf("Expected 2 targets, got %d", len(targets))
+	}
+	for _, target := range targets {
+		if !strings.HasPrefix(target.URL, upstream.URL) || strings.Contains(target.URL, "{") {
+			t.Errorf("Expected a concrete upstream URL, got %s", target.URL)
+		}
+	}
+	// The POST setting the admin field is riskier than the GET of a user
+	if targets[0].Method != "POST" || targets[0].Risk <= targets[1].Risk {
+		t.Errorf("Expected the riskiest target first, got %s (%d) and %s (%d)", targets[0].Method, targets[0].Risk, targets[1].Method, targets[1].Risk)
+	}
+}
+
+func TestForwardProxy(t *testing.T) {
+	upstream := newTestAPI(t, false)
+	defer upstream.Close()
+
+	recorder := NewRecorder()
+	recorder.Parser.Hosts = []string{"127.0.0.1"}
+	proxy, err := NewProxy(recorder, "")
+	if err != nil {
+		t.Fatalf("NewProxy returned an error: %s", err)
+	}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	proxyURL, _ := url.Parse(server.URL)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	resp, err := client.Get(upstream.URL + "/api/users/7")
+	if err != nil {
+		t.Fatalf("Request through the proxy failed: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 || entries[0].Request.URL != upstream.URL+"/api/users/7" {
+		t.Fatalf("Expected the request to be recorded, got %d entries", len(entries))
+	}
+
+	// Requests to other hosts are forwarded but not recorded
+	recorder.Parser.Hosts = []string{"api.example.com"}
+	resp, err = client.Get(upstream.URL + "/api/users/8")
+	if err != nil {
+		t.Fatalf("Request through the proxy failed: %s", err)
+	}
+	resp.Body.Close()
+	if len(recorder.Entries()) != 1 {
+		t.Errorf("Expected requests to other hosts not to be recorded")
+	}
+}
+
+func TestProxyIntercept(t *testing.T) {
+	upstream := newTestAPI(t, true)
+	defer upstream.Close()
+
+	ca, err := NewCertificateAuthority()
+	if err != nil {
+		t.Fatalf("NewCertificateAuthority returned an error: %s", err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca.key")
+	if err := ca.Save(certFile, keyFile); err != nil {
+		t.Fatalf("Save returned an error: %s", err)
+	}
+	if ca, err = LoadCertificateAuthority(certFile, keyFile); err != nil {
+		t.Fatalf("LoadCertificateAuthority returned an error: %s", err)
+	}
+
+	recorder := NewRecorder()
+	proxy, err := NewProxy(recorder, "")
+	if err != nil {
+		t.Fatalf("NewProxy returned an error: %s", err)
+	}
+	proxy.CA = ca
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	caPEM, _ := os.ReadFile(certFile)
+	roots.AppendCertsFromPEM(caPEM)
+	proxyURL, _ := url.Parse(server.URL)
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}}
+	resp, err := client.Post(upstream.URL+"/api/users", "application/json", strings.NewReader(`{"name": "carol"}`))
+	if err != nil {
+		t.Fatalf("Request through the intercepting proxy failed: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 recorded entry, got %d", len(entries))
+	}
+	if entries[0].Request.URL != upstream.URL+"/api/users" {
+		t.Errorf("Expected the HTTPS URL to be recorded, got %s", entries[0].Request.URL)
+	}
+	if entries[0].Response.Status != http.StatusCreated {
+		t.Errorf("Expected the response status to be recorded, got %d", entries[0].Response.Status)
+	}
+}
+
+func TestRecorderWriteHAR(t *testing.T) {
+	upstream := newTestAPI(t, false)
+	defer upstream.Close()
+
+	recorder := NewRecorder()
+	proxy, err := NewProxy(recorder, upstream.URL)
+	if err != nil {
+		t.Fatalf("NewProxy returned an error: %s", err)
+	}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+	for _, id := range []string{"1", "2"} {
+		resp, err := http.Get(server.URL + "/api/users/" + id)
+		if err != nil {
+			t.Fatalf("Request through the proxy failed: %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	var buf bytes.Buffer
+	if err := recorder.WriteHAR(&buf); err != nil {
+		t.Fatalf("WriteHAR returned an error: %s", err)
+	}
+	harFile := filepath.Join(t.TempDir(), "capture.har")
+	if err := os.WriteFile(harFile, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write HAR file: %s", err)
+	}
+	discovery := parser.NewAPIEndpointDiscovery("")
+	if err := discovery.DiscoverFromHAR(harFile); err != nil {
+		t.Fatalf("DiscoverFromHAR returned an error: %s", err)
+	}
+	if len(discovery.Endpoints) != 1 || discovery.Endpoints[0].Path != "/api/users/{userId}" {
+		t.Errorf("Expected the recorded endpoint to be imported, got %d endpoints", len(discovery.Endpoints))
+	}
+
+	var inventory struct {
+		Endpoints []*InventoryEndpoint `json:"endpoints"`
+	}
+	buf.Reset()
+	if err := recorder.WriteInventory(&buf); err != nil {
+		t.Fatalf("WriteInventory returned an error: %s", err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &inventory); err != nil {
+		t.Fatalf("Invalid inventory JSON: %s", err)
+	}
+	if len(inventory.Endpoints) != 1 || inventory.Endpoints[0].Count != 2 {
+		t.Errorf("Expected 1 endpoint seen twice in the inventory")
+	}
+
+	testDiscovery := recorder.Discovery()
+	extractor := parser.NewAPIParameterExtractor(testDiscovery)
+	if err := extractor.ExtractParameters(); err != nil {
+		t.Fatalf("ExtractParameters returned an error: %s", err)
+	}
+	generator := parser.NewAPITestGenerator(testDiscovery, extractor)
+	generator.AddDefaultTemplates()
+	if err := generator.GenerateTestCases(); err != nil {
+		t.Fatalf("GenerateTestCases returned an error: %s", err)
+	}
+	if len(generator.GetTestCases()) == 0 {
+		t.Errorf("Expected test cases for the recorded endpoints")
+	}
+}
+
+func TestRecorderImportFile(t *testing.T) {
+	dir := t.TempDir()
+	exportPath := filepath.Join(dir, "messages.txt")
+	export := "==== 1 ==========\r\n" +
+		"GET https://api.example.com/api/users/1 HTTP/1.1\r\nHost: api.example.com\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n{\"id\": 1, \"name\": \"alice\"}\r\n" +
+		"==== 2 ==========\r\n" +
+		"GET https://api.example.com/api/users/2 HTTP/1.1\r\nHost: api.example.com\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n{\"id\": 2, \"name\": \"bob\"}\r\n"
+	if err := os.WriteFile(exportPath, []byte(export), 0644); err != nil {
+		t.Fatalf("Failed to write export: %s", err)
+	}
+
+	recorder := NewRecorder()
+	count, format, err := recorder.ImportFile(exportPath)
+	if err != nil {
+		t.Fatalf("ImportFile returned an error: %s", err)
+	}
+	if count != 2 || format != parser.ProxyExportZAP {
+		t.Errorf("Expected 2 ZAP messages to be imported, got %d %s messages", count, format)
+	}
+	inventory := recorder.Inventory()
+	if len(inventory) != 1 || inventory[0].Count != 2 {
+		t.Fatalf("Expected 1 endpoint seen twice in the inventory")
+	}
+
+	// The HAR file of the recorder is imported back
+	var buf bytes.Buffer
+	if err := recorder.WriteHAR(&buf); err != nil {
+		t.Fatalf("WriteHAR returned an error: %s", err)
+	}
+	harPath := filepath.Join(dir, "capture.har")
+	if err := os.WriteFile(harPath, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write HAR file: %s", err)
+	}
+	count, format, err = NewRecorder().ImportFile(harPath)
+	if err != nil || count != 2 || format != "HAR" {
+		t.Errorf("Expected 2 HAR entries to be imported, got %d %s entries, %v", count, format, err)
+	}
+}
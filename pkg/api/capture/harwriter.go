@@ -0,0 +1,309 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// DefaultHARMaxBodySize is the default maximum size of the bodies written by a HAR writer
+const DefaultHARMaxBodySize = 64 * 1024
+
+// HARWriter writes the requests sent by ffuf and their responses to a HAR 1.2 capture as they
+// are sent, so that the traffic of a run can be reviewed in browser developer tools or
+// imported into other tools. Entries are not kept in memory. HAR writers are safe for
+// concurrent use.
+type HARWriter struct {
+	// MaxBodySize is the maximum size of the written bodies, larger bodies are truncated. 0
+	// writes whole bodies.
+	MaxBodySize int
+	// MaxSize is the maximum size of the HAR capture in bytes. Entries that would make it
+	// larger are dropped. 0 writes every entry.
+	MaxSize int64
+	// OnError is called when an entry cannot be written
+	OnError func(err error)
+	// Redactor masks the tokens and personal data of the written entries, nil to write them
+	// as is
+	Redactor *secrets.Redactor
+
+	mu      sync.Mutex
+	w       *bufio.Writer
+	closer  io.Closer
+	size    int64
+	entries int
+	dropped int
+	err     error
+	closed  bool
+}
+
+// harHeader opens the log and the entries of a HAR capture
+const harHeader = `{
+  "log": {
+    "version": "1.2",
+    "creator": {
+      "name": "ffuf",
+      "version": %q
+    },
+    "entries": [`
+
+// NewHARWriter creates a HAR writer writing to w
+func NewHARWriter(w io.Writer) *HARWriter {
+	writer := &HARWriter{
+		MaxBodySize: DefaultHARMaxBodySize,
+		w:           bufio.NewWriter(w),
+	}
+	writer.write([]byte(fmt.Sprintf(harHeader, ffuf.Version())))
+	return writer
+}
+
+// OpenHARWriter creates a HAR writer writing to a file
+func OpenHARWriter(path string) (*HARWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to create HAR capture: "+err.Error(), 0)
+	}
+	writer := NewHARWriter(f)
+	writer.closer = f
+	return writer, nil
+}
+
+// Record writes a request and its response. Requests without a response are written with
+// an empty response, commented with their error. It is a security.RequestHandler.
+func (w *HARWriter) Record(req *ffuf.Request, resp *ffuf.Response, err error) {
+	entry := redactEntry(w.Redactor, w.entry(req, resp, err))
+	data, merr := json.MarshalIndent(entry, "    ", "  ")
+	if merr != nil {
+		w.fail(api.NewAPIError("Failed to encode HAR entry: "+merr.Error(), 0))
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	separator := ","
+	if w.entries == 0 {
+		separator = ""
+	}
+	line := append([]byte(separator+"\n    "), data...)
+	// The footer is small, the maximum size leaves it out
+	if w.MaxSize > 0 && w.size+int64(len(line)) > w.MaxSize {
+		w.dropped++
+		return
+	}
+	w.entries++
+	w.write(line)
+}
+
+// entry converts a request and its response to a HAR entry
+func (w *HARWriter) entry(req *ffuf.Request, resp *ffuf.Response, err error) *Entry {
+	started := req.Timestamp
+	if started.IsZero() {
+		started = time.Now()
+	}
+	entry := &Entry{
+		StartedDateTime: started,
+		Request: EntryRequest{
+			Method:      req.Method,
+			URL:         req.Url,
+			HTTPVersion: "HTTP/1.1",
+			Headers:     make([]NameValue, 0, len(req.Headers)),
+			Cookies:     make([]NameValue, 0),
+			QueryString: make([]NameValue, 0),
+			HeadersSize: -1,
+			BodySize:    len(req.Data),
+		},
+		Response: EntryResponse{
+			HTTPVersion: "HTTP/1.1",
+			Headers:     make([]NameValue, 0),
+			Cookies:     make([]NameValue, 0),
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+	names := make([]string, 0, len(req.Headers))
+	for name := range req.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entry.Request.Headers = append(entry.Request.Headers, NameValue{Name: name, Value: req.Headers[name]})
+	}
+	if _, ok := req.Headers["Host"]; !ok && req.Host != "" {
+		entry.Request.Headers = append(entry.Request.Headers, NameValue{Name: "Host", Value: req.Host})
+	}
+	header := http.Header{}
+	for name, value := range req.Headers {
+		header.Set(name, value)
+	}
+	for _, cookie := range (&http.Request{Header: header}).Cookies() {
+		entry.Request.Cookies = append(entry.Request.Cookies, NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	if u, perr := url.Parse(req.Url); perr == nil {
+		for name, values := range u.Query() {
+			for _, value := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, NameValue{Name: name, Value: value})
+			}
+		}
+	}
+	if len(req.Data) > 0 {
+		entry.Request.PostData = &EntryBody{
+			MimeType: header.Get("Content-Type"),
+			Text:     string(w.truncate(req.Data)),
+		}
+	}
+
+	if err != nil {
+		entry.Comment = err.Error()
+	}
+	if resp == nil || resp.StatusCode == 0 {
+		return entry
+	}
+	entry.Time = float64(resp.Duration) / float64(time.Millisecond)
+	entry.Timings.Wait = entry.Time
+	if resp.Proto != "" {
+		entry.Response.HTTPVersion = resp.Proto
+	}
+	respHeader := http.Header(resp.Headers)
+	entry.Response.Status = int(resp.StatusCode)
+	entry.Response.StatusText = http.StatusText(int(resp.StatusCode))
+	entry.Response.Headers = headerValues(respHeader)
+	sort.SliceStable(entry.Response.Headers, func(i, j int) bool {
+		return entry.Response.Headers[i].Name < entry.Response.Headers[j].Name
+	})
+	for _, cookie := range (&http.Response{Header: respHeader}).Cookies() {
+		entry.Response.Cookies = append(entry.Response.Cookies, NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	entry.Response.RedirectURL = respHeader.Get("Location")
+	entry.Response.BodySize = len(resp.Data)
+	entry.Response.Content = EntryContent{Size: len(resp.Data), MimeType: resp.ContentType}
+	if len(resp.Data) > 0 {
+		body := w.truncate(resp.Data)
+		if utf8.Valid(body) {
+			entry.Response.Content.Text = string(body)
+		} else {
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+			entry.Response.Content.Encoding = "base64"
+		}
+	}
+	return entry
+}
+
+// truncate shortens a body to the maximum body size
+func (w *HARWriter) truncate(body []byte) []byte {
+	if w.MaxBodySize > 0 && len(body) > w.MaxBodySize {
+		return body[:w.MaxBodySize]
+	}
+	return body
+}
+
+// write writes data to the capture. Callers hold the lock, except for the header.
+func (w *HARWriter) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(data)
+	w.size += int64(n)
+	if err != nil {
+		w.err = err
+		w.fail(api.NewAPIError("Failed to write HAR capture: "+err.Error(), 0))
+	}
+}
+
+// fail reports an error writing the capture
+func (w *HARWriter) fail(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
+
+// Entries returns the number of written entries, and the number of entries dropped because
+// the capture reached its maximum size
+func (w *HARWriter) Entries() (int, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.entries, w.dropped
+}
+
+// Close ends the capture, commenting it with the number of dropped entries, and closes its
+// file. Requests recorded after Close are ignored.
+func (w *HARWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	footer := "\n    ]"
+	if w.entries == 0 {
+		footer = "]"
+	}
+	if w.dropped > 0 {
+		comment, _ := json.Marshal(fmt.Sprintf("%d requests were dropped, the capture reached its maximum size of %d bytes", w.dropped, w.MaxSize))
+		footer += ",\n    \"comment\": " + string(comment)
+	}
+	w.write([]byte(footer + "\n  }\n}\n"))
+	if w.err == nil {
+		if err := w.w.Flush(); err != nil {
+			w.err = err
+		}
+	}
+	if w.closer != nil {
+		if err := w.closer.Close(); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+	if w.err != nil {
+		return api.NewAPIError("Failed to write HAR capture: "+w.err.Error(), 0)
+	}
+	return nil
+}
+
+// HARRunner wraps a runner and writes every executed request to a HAR writer
+type HARRunner struct {
+	// Writer writes the executed requests
+	Writer *HARWriter
+	runner ffuf.RunnerProvider
+}
+
+// NewHARRunner creates a runner writing the requests executed by a runner to a HAR writer
+func NewHARRunner(writer *HARWriter, r ffuf.RunnerProvider) *HARRunner {
+	return &HARRunner{
+		Writer: writer,
+		runner: r,
+	}
+}
+
+// Prepare prepares a request using the underlying runner
+func (r *HARRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return r.runner.Prepare(input, basereq)
+}
+
+// Dump dumps a request using the underlying runner
+func (r *HARRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return r.runner.Dump(req)
+}
+
+// Execute executes a request using the underlying runner and writes it with its response.
+// Requests denied by the scope policy or the safe mode are not sent and not written.
+func (r *HARRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	resp, err := r.runner.Execute(req)
+	if !ffuf.IsPolicyViolation(err) && !ffuf.IsSafeModeViolation(err) {
+		r.Writer.Record(req, &resp, err)
+	}
+	return resp, err
+}
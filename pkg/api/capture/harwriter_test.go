@@ -0,0 +1,194 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// testRunner returns a fixed response, or an error for the URLs of errs
+type testRunner struct {
+	resp ffuf.Response
+	errs map[string]error
+}
+
+func (r *testRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return *basereq, nil
+}
+
+func (r *testRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return nil, nil
+}
+
+func (r *testRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	if err, ok := r.errs[req.Url]; ok {
+		return ffuf.Response{}, err
+	}
+	req.Timestamp = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	resp := r.resp
+	resp.Request = req
+	return resp, nil
+}
+
+func TestHARWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewHARWriter(&buf)
+	writer.MaxBodySize = 16
+	r := NewHARRunner(writer, &testRunner{
+		resp: ffuf.Response{
+			StatusCode:  200,
+			Headers:     map[string][]string{"Content-Type": {"application/json"}, "Set-Cookie": {"session=abc"}},
+			Data:        []byte(`{"id": 42, "name": "alice", "admin": false}`),
+			ContentType: "application/json",
+			Proto:       "HTTP/1.1",
+			Duration:    25 * time.Millisecond,
+		},
+		errs: map[string]error{
+			"https://api.example.com/slow":  fmt.Errorf("timeout"),
+			"https://api.example.com/admin": &ffuf.PolicyViolation{Rule: "path", Method: "GET", Url: "https://api.example.com/admin"},
+		},
+	})
+	r.Execute(&ffuf.Request{
+		Method:  "POST",
+		Url:     "https://api.example.com/api/users?debug=1",
+		Headers: map[string]string{"Content-Type": "application/json", "Cookie": "token=xyz"},
+		Data:    []byte(`{"name": "FUZZ", "role": "admin"}`),
+	})
+	r.Execute(&ffuf.Request{Method: "GET", Url: "https://api.example.com/slow"})
+	r.Execute(&ffuf.Request{Method: "GET", Url: "https://api.example.com/admin"})
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned an error: %s", err)
+	}
+
+	var har struct {
+		Log struct {
+			Version string   `json:"version"`
+			Entries []*Entry `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil {
+		t.Fatalf("Invalid HAR capture: %s\n%s", err, buf.String())
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 2 {
+		t.Fatalf("Expected 2 entries without the request denied by the policy, got %d", len(har.Log.Entries))
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.URL != "https://api.example.com/api/users?debug=1" || entry.Time != 25 {
+		t.Errorf("Unexpected request %+v", entry)
+	}
+	if len(entry.Request.QueryString) != 1 || len(entry.Request.Cookies) != 1 || entry.Request.Cookies[0].Value != "xyz" {
+		t.Errorf("Expected the query string and cookies of the request, got %+v", entry.Request)
+	}
+	if entry.Request.PostData == nil || entry.Request.PostData.Text != `{"name": "FUZZ",` || entry.Request.BodySize != 33 {
+		t.Errorf("Expected the truncated request body, got %+v", entry.Request.PostData)
+	}
+	if entry.Response.Status != 200 || entry.Response.Content.Size != 43 || entry.Response.Content.Text != `{"id": 42, "name` {
+		t.Errorf("Expected the truncated response body, got %+v", entry.Response)
+	}
+	if len(entry.Response.Cookies) != 1 || entry.Response.Cookies[0].Value != "abc" {
+		t.Errorf("Expected the cookies of the response, got %+v", entry.Response.Cookies)
+	}
+	if failed := har.Log.Entries[1]; failed.Comment != "timeout" || failed.Response.Status != 0 {
+		t.Errorf("Expected the error of the request without a response, got %+v", failed)
+	}
+	if written, dropped := writer.Entries(); written != 2 || dropped != 0 {
+		t.Errorf("Expected 2 entries written, got %d written and %d dropped", written, dropped)
+	}
+
+	// Requests recorded after Close are ignored
+	r.Execute(&ffuf.Request{Method: "GET", Url: "https://api.example.com/late"})
+	if written, _ := writer.Entries(); written != 2 {
+		t.Errorf("Expected no entry after Close, got %d", written)
+	}
+}
+
+func TestHARWriterMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.har")
+	writer, err := OpenHARWriter(path)
+	if err != nil {
+		t.Fatalf("OpenHARWriter returned an error: %s", err)
+	}
+	writer.MaxSize = 4096
+	resp := &ffuf.Response{StatusCode: 200, Data: []byte(strings.Repeat("a", 500)), ContentType: "text/plain"}
+	for i := 0; i < 20; i++ {
+		writer.Record(&ffuf.Request{Method: "GET", Url: fmt.Sprintf("https://api.example.com/users/%d", i)}, resp, nil)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned an error: %s", err)
+	}
+
+	written, dropped := writer.Entries()
+	if written == 0 || dropped == 0 || written+dropped != 20 {
+		t.Fatalf("Expected entries dropped once the maximum size is reached, got %d written and %d dropped", written, dropped)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read the HAR capture: %s", err)
+	}
+	if len(data) > 4096+200 {
+		t.Errorf("Expected a capture of about 4096 bytes, got %d", len(data))
+	}
+	if !strings.Contains(string(data), fmt.Sprintf(`"comment": "%d requests were dropped`, dropped)) {
+		t.Errorf("Expected a comment with the dropped requests, got %s", data)
+	}
+
+	// The capture can be imported again
+	discovery := parser.NewAPIEndpointDiscovery("")
+	if err := discovery.DiscoverFromHAR(path); err != nil {
+		t.Fatalf("DiscoverFromHAR returned an error: %s", err)
+	}
+	if len(discovery.GetEndpoints()) != 1 {
+		t.Errorf("Expected the requests to be imported as a single endpoint, got %d", len(discovery.GetEndpoints()))
+	}
+}
+
+func TestHARWriterRedactor(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewHARWriter(&buf)
+	writer.Redactor, _ = secrets.NewRedactor([]string{"authorization", "cookies", "emails"}, nil)
+	writer.Record(&ffuf.Request{
+		Method:  "POST",
+		Url:     "https://api.example.com/login?access_token=abc&page=2",
+		Headers: map[string]string{"Authorization": "Bearer s3cr3t", "Cookie": "session=xyz"},
+		Data:    []byte(`{"email": "alice@example.com"}`),
+	}, &ffuf.Response{
+		StatusCode: 200,
+		Headers:    map[string][]string{"Set-Cookie": {"session=uvw; HttpOnly"}},
+		Data:       []byte(`{"owner": "bob@example.org"}`),
+	}, nil)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close returned an error: %s", err)
+	}
+
+	for _, secret := range []string{"abc", "s3cr3t", "xyz", "uvw", "alice@example.com", "bob@example.org"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("Expected %q to be redacted from the HAR capture", secret)
+		}
+	}
+	var har struct {
+		Log struct {
+			Entries []*Entry `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil || len(har.Log.Entries) != 1 {
+		t.Fatalf("Invalid HAR capture: %v\n%s", err, buf.String())
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.Cookies[0].Name != "session" || entry.Request.Cookies[0].Value != secrets.Redacted {
+		t.Errorf("Expected the cookie value to be redacted, got %+v", entry.Request.Cookies)
+	}
+	for _, param := range entry.Request.QueryString {
+		if param.Name == "page" && param.Value != "2" {
+			t.Errorf("Expected the page parameter to be kept, got %+v", param)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package capture
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+)
+
+// logger is the logger of the capture proxy
+var logger = logging.For("capture")
+
+// hopHeaders are the hop-by-hop headers, which are not forwarded by the proxy
+var hopHeaders = []string{"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// Proxy is an HTTP proxy recording the requests passing through it. As a forward proxy, it
+// intercepts HTTPS connections with certificates issued by its certificate authority, or
+// tunnels them unrecorded if it has none. As a reverse proxy, it forwards every request to
+// its target.
+type Proxy struct {
+	// Recorder records the requests and responses
+	Recorder *Recorder
+	// Target is the upstream URL of a reverse proxy, nil for a forward proxy
+	Target *url.URL
+	// CA issues the certificates of intercepted HTTPS connections
+	CA *CertificateAuthority
+	// Transport sends the requests upstream
+	Transport http.RoundTripper
+}
+
+// NewProxy creates a forward proxy, or a reverse proxy to a target URL if it is not empty
+func NewProxy(recorder *Recorder, target string) (*Proxy, error) {
+	p := &Proxy{
+		Recorder: recorder,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+			DisableCompression:  true,
+			MaxIdleConnsPerHost: 100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	if target != "" {
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, api.NewAPIError("Invalid capture target URL, expected an absolute URL: "+target, 0)
+		}
+		p.Target = u
+	}
+	return p, nil
+}
+
+// ServeHTTP forwards a request and records it with its response
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.handleConnect(w, r)
+		return
+	}
+	if p.Target != nil {
+		r.URL.Scheme = p.Target.Scheme
+		r.URL.Host = p.Target.Host
+		r.URL.Path = singleJoiningSlash(p.Target.Path, r.URL.Path)
+		if r.URL.RawPath != "" {
+			r.URL.RawPath = singleJoiningSlash(p.Target.EscapedPath(), r.URL.RawPath)
+		}
+		r.Host = p.Target.Host
+	} else if !r.URL.IsAbs() {
+		http.Error(w, "ffuf capture proxy: request URL must be absolute", http.StatusBadRequest)
+		return
+	}
+	p.forward(w, r)
+}
+
+// forward sends a request with an absolute URL upstream, and writes and records its response
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, "ffuf capture proxy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	out.ContentLength = int64(len(reqBody))
+	if len(reqBody) == 0 {
+		out.Body = nil
+	}
+	removeHopHeaders(out.Header)
+
+	started := time.Now()
+	resp, err := p.Transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, "ffuf capture proxy: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	for name, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	// Stream the response to the client, and keep the beginning of it to record
+	limit := int64(DefaultMaxBodySize)
+	if p.Recorder != nil && p.Recorder.MaxBodySize > 0 {
+		limit = int64(p.Recorder.MaxBodySize)
+	}
+	captured := &limitedBuffer{limit: limit}
+	io.Copy(w, io.TeeReader(resp.Body, captured))
+	if p.Recorder == nil {
+		return
+	}
+
+	recorded := out.Clone(out.Context())
+	recorded.Header = r.Header.Clone()
+	recorded.Host = r.Host
+	if err := p.Recorder.Record(recorded, reqBody, resp, captured.Bytes(), started, time.Since(started)); err != nil {
+		logger.Warn("Failed to record the request", "method", r.Method, logging.FieldEndpoint, r.URL.String(), logging.FieldError, err)
+	}
+}
+
+// handleConnect intercepts or tunnels an HTTPS connection
+func (p *Proxy) handleConnect(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "ffuf capture proxy: CONNECT is not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		conn.Close()
+		return
+	}
+
+	if p.CA == nil {
+		p.tunnel(conn, r.Host)
+		return
+	}
+
+	host := r.Host
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname = host
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName != "" {
+				return p.CA.Certificate(hello.ServerName)
+			}
+			return p.CA.Certificate(hostname)
+		},
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		logger.Warn("TLS handshake with the client failed", logging.FieldTarget, host, logging.FieldError, err)
+		conn.Close()
+		return
+	}
+
+	// Serve the requests of the decrypted connection
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			req.URL.Scheme = "https"
+			req.URL.Host = host
+			if strings.HasSuffix(req.URL.Host, ":443") {
+				req.URL.Host = strings.TrimSuffix(req.URL.Host, ":443")
+			}
+			p.forward(w, req)
+		}),
+		ErrorLog: log.New(ioutil.Discard, "", 0),
+	}
+	server.Serve(newConnListener(tlsConn))
+}
+
+// tunnel relays the bytes of a connection to a host without recording them
+func (p *Proxy) tunnel(conn net.Conn, host string) {
+	upstream, err := net.DialTimeout("tcp", host, 30*time.Second)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go func() {
+		io.Copy(upstream, conn)
+		upstream.Close()
+	}()
+	io.Copy(conn, upstream)
+	conn.Close()
+}
+
+// removeHopHeaders removes the hop-by-hop headers, including those named by the Connection
+// header
+func removeHopHeaders(header http.Header) {
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			header.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
+
+// singleJoiningSlash joins two URL paths with a single slash
+func singleJoiningSlash(a, b string) string {
+	aslash := strings.HasSuffix(a, "/")
+	bslash := strings.HasPrefix(b, "/")
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	}
+	return a + b
+}
+
+// limitedBuffer is a writer keeping the first bytes written to it
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int64
+}
+
+// Write keeps the bytes up to the limit, and discards the others
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - int64(b.Len()); remaining > 0 {
+		if int64(len(p)) > remaining {
+			b.Buffer.Write(p[:remaining])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// connListener is a listener accepting a single connection, and closed when the connection
+// is closed
+type connListener struct {
+	conn net.Conn
+	once sync.Once
+	done chan struct{}
+}
+
+// newConnListener creates a listener accepting a connection
+func newConnListener(conn net.Conn) *connListener {
+	l := &connListener{done: make(chan struct{})}
+	l.conn = &notifyConn{Conn: conn, done: l.done}
+	return l
+}
+
+// Accept returns the connection on the first call, and blocks until it is closed afterwards
+func (l *connListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() { conn = l.conn })
+	if conn != nil {
+		return conn, nil
+	}
+	<-l.done
+	return nil, io.EOF
+}
+
+// Close does nothing, the connection is closed by the server
+func (l *connListener) Close() error {
+	return nil
+}
+
+// Addr returns the local address of the connection
+func (l *connListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// notifyConn is a connection closing a channel when it is closed
+type notifyConn struct {
+	net.Conn
+	once sync.Once
+	done chan struct{}
+}
+
+// Close closes the connection and the channel
+func (c *notifyConn) Close() error {
+	c.once.Do(func() { close(c.done) })
+	return c.Conn.Close()
+}
@@ -3,8 +3,6 @@ package client
 
 import (
 	"bytes"
-	"compress/flate"
-	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"fmt"
@@ -14,15 +12,12 @@ import (
 	"net/http/httptrace"
 	"net/http/httputil"
 	"net/textproto"
-	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
 	"github.com/ffuf/ffuf/v2/pkg/runner"
-
-	"github.com/andybalholm/brotli"
 )
 
 // APIClient is an optimized HTTP client for API testing
@@ -39,13 +34,7 @@ func NewAPIClient(conf *ffuf.Config) *APIClient {
 	baseRunner := runner.NewSimpleRunner(conf, false).(*runner.SimpleRunner)
 
 	// Create a transport with optimized settings for API testing
-	proxyURL := http.ProxyFromEnvironment
-	if len(conf.ProxyURL) > 0 {
-		pu, err := url.Parse(conf.ProxyURL)
-		if err == nil {
-			proxyURL = http.ProxyURL(pu)
-		}
-	}
+	proxyURL := runner.ProxyFunc(conf, false)
 
 	cert := []tls.Certificate{}
 	if conf.ClientCert != "" && conf.ClientKey != "" {
@@ -62,11 +51,11 @@ func NewAPIClient(conf *ffuf.Config) *APIClient {
 		IdleConnTimeout:     90 * time.Second, // Increased for API testing
 		TLSHandshakeTimeout: time.Duration(time.Duration(conf.Timeout) * time.Second),
 		DisableCompression:  false, // Enable compression for APIs
-		DialContext: (&net.Dialer{
+		DialContext: runner.PinnedDial(conf, (&net.Dialer{
 			Timeout:   time.Duration(time.Duration(conf.Timeout) * time.Second),
 			KeepAlive: 30 * time.Second, // Increased for API testing
 			DualStack: true,             // Support IPv4 and IPv6
-		}).DialContext,
+		}).DialContext),
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: true,
 			MinVersion:         tls.VersionTLS12, // Minimum TLS 1.2 for security
@@ -253,45 +242,34 @@ func (c *APIClient) Execute(req *ffuf.Request) (ffuf.Response, error) {
 	size, err := strconv.Atoi(httpresp.Header.Get("Content-Length"))
 	if err == nil {
 		resp.ContentLength = int64(size)
-		if (c.config.IgnoreBody) || (size > runner.MAX_DOWNLOAD_SIZE) {
+		if c.config.IgnoreBody {
 			resp.Cancelled = true
 			return resp, nil
 		}
 	}
 
+	// The raw body is recorded as it is read, up to the maximum body size
+	var rawBody bytes.Buffer
 	if len(c.config.OutputDirectory) > 0 || len(c.config.AuditLog) > 0 {
-		rawresp, _ := httputil.DumpResponse(httpresp, true)
+		rawresp, _ := httputil.DumpResponse(httpresp, false)
 		resp.Request.Raw = string(rawreq)
 		resp.Raw = string(rawresp)
+		httpresp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(httpresp.Body, runner.NewLimitWriter(&rawBody, c.config.APIMaxBody)), httpresp.Body}
 	}
 
-	// Handle different content encodings
-	var bodyReader io.ReadCloser
-	switch httpresp.Header.Get("Content-Encoding") {
-	case "gzip":
-		bodyReader, err = gzip.NewReader(httpresp.Body)
-		if err != nil {
-			bodyReader = httpresp.Body
-		}
-	case "br":
-		bodyReader = io.NopCloser(brotli.NewReader(httpresp.Body))
-	case "deflate":
-		bodyReader = flate.NewReader(httpresp.Body)
-	default:
-		bodyReader = httpresp.Body
-	}
+	// Decode the content encoding and convert the charset to UTF-8 before the body reaches the
+	// parsers
+	resp.ContentEncoding = runner.ContentEncoding(httpresp)
+	bodyReader := runner.DecodeBody(httpresp.Body, httpresp.Header.Get("Content-Encoding"))
+	bodyReader, resp.Charset = runner.DecodeCharset(bodyReader, httpresp.Header.Get("Content-Type"))
 
-	// Read response body with streaming for large API responses
-	if respbody, err := io.ReadAll(bodyReader); err == nil {
-		resp.ContentLength = int64(len(string(respbody)))
-		resp.Data = respbody
+	// Read the response body up to the maximum body size, counting the rest of larger bodies
+	if err := runner.ReadBody(bodyReader, c.config.APIMaxBody, &resp); err == nil && len(resp.Raw) > 0 {
+		resp.Raw += rawBody.String()
 	}
-
-	// Process response data
-	wordsSize := len(strings.Split(string(resp.Data), " "))
-	linesSize := len(strings.Split(string(resp.Data), "\n"))
-	resp.ContentWords = int64(wordsSize)
-	resp.ContentLines = int64(linesSize)
 	resp.Duration = firstByteTime
 	resp.Timestamp = start.Add(firstByteTime)
 
@@ -0,0 +1,307 @@
+package contenttype
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// Kinds of negotiation variants
+const (
+	// VariantFormat sends the body of a request converted to another format, or as is under
+	// another content type
+	VariantFormat = "format"
+	// VariantCharset sends the body of a request encoded in another charset
+	VariantCharset = "charset"
+	// VariantAccept asks for the response in another format
+	VariantAccept = "accept"
+	// VariantLanguage asks for the response in another language
+	VariantLanguage = "language"
+	// VariantQuality sends an Accept or Accept-Language header with unusual quality values
+	VariantQuality = "quality"
+)
+
+// DefaultAcceptTypes are the Accept headers replayed to find undeclared response formats
+var DefaultAcceptTypes = []string{
+	"application/xml",
+	"text/html",
+	"text/plain",
+	"text/csv",
+	"application/x-yaml",
+	"*/*",
+	"application/*",
+}
+
+// DefaultLanguages are the Accept-Language headers replayed to find localized responses,
+// including a language no API supports
+var DefaultLanguages = []string{"fr", "de", "ja", "ar", "zz-ZZ"}
+
+// NegotiationVariant is a request replayed with an alternate Content-Type or Accept header
+type NegotiationVariant struct {
+	// Name describes the variant, such as "JSON body converted to XML"
+	Name string
+	// Kind is VariantFormat, VariantCharset, VariantAccept, VariantLanguage or VariantQuality
+	Kind string
+	// Header is the Content-Type of the variant, its Accept header for VariantAccept, its
+	// Accept-Language header for VariantLanguage, or the value of the header with unusual
+	// quality values for VariantQuality
+	Header string
+	// Simple reports whether browsers send the content type of the variant cross-origin
+	// without a CORS preflight
+	Simple bool
+	// Request is the replayed request
+	Request *ffuf.Request
+}
+
+// NegotiationVariants returns the variants of a request replaying it with alternate
+// Content-Type and Accept headers. The body of the request is converted to other formats,
+// sent as is under other content types and encoded in other charsets, and the response is
+// asked for in the formats of acceptTypes.
+func NegotiationVariants(req *ffuf.Request, acceptTypes []string) []*NegotiationVariant {
+	variants := make([]*NegotiationVariant, 0)
+	add := func(kind, name, header string, data []byte) {
+		variant := copyRequest(req)
+		if kind == VariantAccept {
+			setHeader(variant.Headers, "Accept", header)
+		} else {
+			setHeader(variant.Headers, "Content-Type", header)
+			variant.Data = data
+		}
+		variants = append(variants, &NegotiationVariant{
+			Name:    name,
+			Kind:    kind,
+			Header:  header,
+			Simple:  kind != VariantAccept && isSimpleContentType(header),
+			Request: variant,
+		})
+	}
+
+	if len(req.Data) > 0 {
+		header := getHeader(req.Headers, "Content-Type")
+		source := DetectContentType(req.Data)
+		mediaType := ContentTypeString(source)
+		if header != "" {
+			source = ContentTypeFromString(header)
+			if parsed, _, err := mime.ParseMediaType(header); err == nil {
+				mediaType = parsed
+			}
+		}
+
+		switch source {
+		case TypeJSON:
+			if data, err := jsonToXML(req.Data); err == nil {
+				add(VariantFormat, "JSON body converted to XML", "application/xml", data)
+			}
+			if data, err := convertData(req.Data, TypeJSON, TypeFormURLEncoded); err == nil {
+				add(VariantFormat, "JSON body converted to form", ContentTypeString(TypeFormURLEncoded), data)
+			}
+			add(VariantFormat, "JSON body sent as text/plain", "text/plain", req.Data)
+			add(VariantFormat, "JSON body sent as form", ContentTypeString(TypeFormURLEncoded), req.Data)
+		case TypeFormURLEncoded:
+			if data, err := convertData(req.Data, TypeFormURLEncoded, TypeJSON); err == nil {
+				add(VariantFormat, "Form body converted to JSON", "application/json", data)
+				if data, err := jsonToXML(data); err == nil {
+					add(VariantFormat, "Form body converted to XML", "application/xml", data)
+				}
+			}
+			add(VariantFormat, "Form body sent as text/plain", "text/plain", req.Data)
+		case TypeXML:
+			alternate := "text/xml"
+			if mediaType == alternate {
+				alternate = "application/xml"
+			}
+			add(VariantFormat, "XML body sent as "+alternate, alternate, req.Data)
+			add(VariantFormat, "XML body sent as text/plain", "text/plain", req.Data)
+		}
+
+		switch source {
+		case TypeJSON, TypeFormURLEncoded, TypeXML, TypeText:
+			add(VariantCharset, "Body encoded in UTF-16", mediaType+"; charset=utf-16", encodeUTF16(req.Data, true))
+			add(VariantCharset, "Body encoded in UTF-16LE", mediaType+"; charset=utf-16le", encodeUTF16(req.Data, false))
+		}
+	}
+
+	for _, accept := range acceptTypes {
+		add(VariantAccept, "Accept: "+accept, accept, nil)
+	}
+	return variants
+}
+
+// LanguageVariants returns the variants of a request asking for the response in each of
+// languages with an Accept-Language header, and sending Accept and Accept-Language headers
+// with unusual quality values: a quality of 0 refusing the only acceptable value, qualities
+// above 1, negative, not numbers or with too many decimals, and ties between media types.
+// The Accept headers use the media type the request asks for, or JSON.
+func LanguageVariants(req *ffuf.Request, languages []string) []*NegotiationVariant {
+	variants := make([]*NegotiationVariant, 0)
+	add := func(kind, header, value string) {
+		variant := copyRequest(req)
+		setHeader(variant.Headers, header, value)
+		variants = append(variants, &NegotiationVariant{
+			Name:    header + ": " + value,
+			Kind:    kind,
+			Header:  value,
+			Request: variant,
+		})
+	}
+
+	for _, language := range languages {
+		add(VariantLanguage, "Accept-Language", language)
+	}
+
+	mediaType := "application/json"
+	if accept := strings.TrimSpace(strings.Split(getHeader(req.Headers, "Accept"), ",")[0]); accept != "" {
+		if parsed, _, err := mime.ParseMediaType(accept); err == nil && parsed != "*/*" {
+			mediaType = parsed
+		}
+	}
+	for _, quality := range []string{"0", "1.5", "-1", "abc", "0.0001"} {
+		add(VariantQuality, "Accept", mediaType+";q="+quality)
+	}
+	add(VariantQuality, "Accept", mediaType+";q=0.5, text/html;q=0.5, */*;q=0.5")
+	add(VariantQuality, "Accept-Language", "en;q=abc, fr;q=2")
+	add(VariantQuality, "Accept-Language", "*;q=0")
+	return variants
+}
+
+// convertData converts a body between formats with a ContentTypeHandler
+func convertData(data []byte, from, to ContentType) ([]byte, error) {
+	req := &ffuf.Request{Headers: map[string]string{"Content-Type": ContentTypeString(from)}, Data: data}
+	if err := NewContentTypeHandler(from).ConvertRequestData(req, to); err != nil {
+		return nil, err
+	}
+	return req.Data, nil
+}
+
+// jsonToXML converts a JSON body to an XML document with a root element. The members of
+// objects are elements, and the items of arrays are repeated elements.
+func jsonToXML(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	writeXMLElement(&buf, "root", value)
+	return buf.Bytes(), nil
+}
+
+// writeXMLElement writes a JSON value as an XML element
+func writeXMLElement(buf *bytes.Buffer, name string, value interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		if name == "root" {
+			buf.WriteString("<root>")
+			for _, item := range v {
+				writeXMLElement(buf, "item", item)
+			}
+			buf.WriteString("</root>")
+			return
+		}
+		for _, item := range v {
+			writeXMLElement(buf, name, item)
+		}
+		return
+	}
+
+	buf.WriteString("<" + name + ">")
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			writeXMLElement(buf, xmlName(key), v[key])
+		}
+	case nil:
+	default:
+		xml.EscapeText(buf, []byte(fmt.Sprint(v)))
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+// xmlName returns a valid XML element name for a JSON member name
+func xmlName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// encodeUTF16 encodes a UTF-8 body in UTF-16, big endian with a byte order mark, or little
+// endian without one
+func encodeUTF16(data []byte, bigEndian bool) []byte {
+	units := utf16.Encode([]rune(string(data)))
+	encoded := make([]byte, 0, 2*len(units)+2)
+	if bigEndian {
+		encoded = append(encoded, 0xfe, 0xff)
+	}
+	for _, unit := range units {
+		if bigEndian {
+			encoded = append(encoded, byte(unit>>8), byte(unit))
+		} else {
+			encoded = append(encoded, byte(unit), byte(unit>>8))
+		}
+	}
+	return encoded
+}
+
+// isSimpleContentType reports whether browsers send a content type cross-origin without a
+// CORS preflight
+func isSimpleContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/plain" || mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// copyRequest returns a copy of a request with its own headers
+func copyRequest(req *ffuf.Request) *ffuf.Request {
+	variant := *req
+	variant.Headers = make(map[string]string, len(req.Headers)+1)
+	for name, value := range req.Headers {
+		variant.Headers[name] = value
+	}
+	return &variant
+}
+
+// getHeader returns the value of a header, whatever its case
+func getHeader(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// setHeader sets a header, replacing it whatever its case
+func setHeader(headers map[string]string, name, value string) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+	headers[name] = value
+}
@@ -0,0 +1,166 @@
+package contenttype
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestNegotiationVariants_JSON(t *testing.T) {
+	req := &ffuf.Request{
+		Method:  "POST",
+		Url:     "https://api.example.com/users",
+		Headers: map[string]string{"content-type": "application/json; charset=utf-8", "Authorization": "Bearer token"},
+		Data:    []byte(`{"name":"Alice","roles":["admin","user"],"address":{"zip":"01234"},"2fa":true,"note":"a<b"}`),
+	}
+	variants := NegotiationVariants(req, []string{"application/xml", "*/*"})
+
+	expected := []struct {
+		name   string
+		kind   string
+		header string
+		simple bool
+		data   string
+	}{
+		{"JSON body converted to XML", VariantFormat, "application/xml", false,
+			xml.Header + `<root><_fa>true</_fa><address><zip>01234</zip></address><name>Alice</name><note>a&lt;b</note><roles>admin</roles><roles>user</roles></root>`},
+		{"JSON body converted to form", VariantFormat, "application/x-www-form-urlencoded", true, ""},
+		{"JSON body sent as text/plain", VariantFormat, "text/plain", true, string(req.Data)},
+		{"JSON body sent as form", VariantFormat, "application/x-www-form-urlencoded", true, string(req.Data)},
+		{"Body encoded in UTF-16", VariantCharset, "application/json; charset=utf-16", false, ""},
+		{"Body encoded in UTF-16LE", VariantCharset, "application/json; charset=utf-16le", false, ""},
+		{"Accept: application/xml", VariantAccept, "application/xml", false, string(req.Data)},
+		{"Accept: */*", VariantAccept, "*/*", false, string(req.Data)},
+	}
+	if len(variants) != len(expected) {
+		t.Fatalf("Expected %d variants, got %d", len(expected), len(variants))
+	}
+	for i, e := range expected {
+		variant := variants[i]
+		if variant.Name != e.name || variant.Kind != e.kind || variant.Header != e.header || variant.Simple != e.simple {
+			t.Errorf("Expected variant %d to be %q (%s, %s, simple %t), got %q (%s, %s, simple %t)", i,
+				e.name, e.kind, e.header, e.simple, variant.Name, variant.Kind, variant.Header, variant.Simple)
+		}
+		if e.data != "" && string(variant.Request.Data) != e.data {
+			t.Errorf("Expected the body of %q to be %s, got %s", e.name, e.data, variant.Request.Data)
+		}
+		if variant.Request.Headers["Authorization"] != "Bearer token" || variant.Request.Method != "POST" {
+			t.Errorf("Expected %q to replay the method and headers of the request", e.name)
+		}
+		header := "Content-Type"
+		if e.kind == VariantAccept {
+			header = "Accept"
+		}
+		if variant.Request.Headers[header] != e.header {
+			t.Errorf("Expected the %s header of %q to be %s, got %v", header, e.name, e.header, variant.Request.Headers)
+		}
+		if e.kind != VariantAccept {
+			if _, ok := variant.Request.Headers["content-type"]; ok {
+				t.Errorf("Expected %q to replace the content type of the request whatever its case", e.name)
+			}
+		}
+	}
+	if len(req.Headers) != 2 || req.Headers["content-type"] != "application/json; charset=utf-8" {
+		t.Errorf("Expected the headers of the request to be left unchanged, got %v", req.Headers)
+	}
+
+	// UTF-16 bodies decode to the original body
+	for i, bigEndian := range []bool{true, false} {
+		data := variants[4+i].Request.Data
+		units := make([]uint16, 0, len(data)/2)
+		if bigEndian {
+			if !bytes.HasPrefix(data, []byte{0xfe, 0xff}) {
+				t.Fatalf("Expected a byte order mark in the UTF-16 body")
+			}
+			for j := 2; j+1 < len(data); j += 2 {
+				units = append(units, uint16(data[j])<<8|uint16(data[j+1]))
+			}
+		} else {
+			for j := 0; j+1 < len(data); j += 2 {
+				units = append(units, uint16(data[j])|uint16(data[j+1])<<8)
+			}
+		}
+		if decoded := string(utf16.Decode(units)); decoded != string(req.Data) {
+			t.Errorf("Expected the UTF-16 body to decode to the original one, got %s", decoded)
+		}
+	}
+}
+
+func TestNegotiationVariants_Form(t *testing.T) {
+	req := &ffuf.Request{
+		Method:  "POST",
+		Url:     "https://api.example.com/login",
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Data:    []byte("user=alice&password=secret"),
+	}
+	names := make(map[string]*NegotiationVariant)
+	for _, variant := range NegotiationVariants(req, nil) {
+		names[variant.Name] = variant
+	}
+	for _, name := range []string{"Form body converted to JSON", "Form body converted to XML", "Form body sent as text/plain", "Body encoded in UTF-16"} {
+		if names[name] == nil {
+			t.Errorf("Expected the variant %q", name)
+		}
+	}
+	if variant := names["Form body converted to XML"]; variant != nil &&
+		string(variant.Request.Data) != xml.Header+`<root><password>secret</password><user>alice</user></root>` {
+		t.Errorf("Unexpected XML body %s", variant.Request.Data)
+	}
+}
+
+func TestNegotiationVariants_NoBody(t *testing.T) {
+	variants := NegotiationVariants(&ffuf.Request{Method: "GET", Url: "https://api.example.com/users"}, DefaultAcceptTypes)
+	if len(variants) != len(DefaultAcceptTypes) {
+		t.Fatalf("Expected only Accept variants for a request without a body, got %d variants", len(variants))
+	}
+	for _, variant := range variants {
+		if variant.Kind != VariantAccept || variant.Request.Headers["Accept"] != variant.Header {
+			t.Errorf("Unexpected variant %q", variant.Name)
+		}
+	}
+}
+
+func TestNegotiationVariants_XML(t *testing.T) {
+	req := &ffuf.Request{
+		Method:  "POST",
+		Url:     "https://api.example.com/soap",
+		Headers: map[string]string{"Content-Type": "text/xml"},
+		Data:    []byte(`<Envelope><Body/></Envelope>`),
+	}
+	variants := NegotiationVariants(req, nil)
+	if len(variants) < 2 || variants[0].Header != "application/xml" || variants[1].Header != "text/plain" || !variants[1].Simple {
+		t.Errorf("Expected the XML body to be sent as application/xml and text/plain")
+	}
+}
+
+func TestLanguageVariants(t *testing.T) {
+	req := &ffuf.Request{Method: "GET", Url: "https://api.example.com/items", Headers: map[string]string{"accept": "application/xml, */*", "Accept-Language": "en"}}
+	variants := LanguageVariants(req, []string{"fr", "zz-ZZ"})
+
+	names := make(map[string]*NegotiationVariant)
+	for _, variant := range variants {
+		names[variant.Name] = variant
+	}
+	if variant := names["Accept-Language: fr"]; variant == nil || variant.Kind != VariantLanguage || variant.Request.Headers["Accept-Language"] != "fr" {
+		t.Errorf("Expected an Accept-Language: fr variant, got %v", variant)
+	}
+	if variant := names["Accept: application/xml;q=abc"]; variant == nil || variant.Kind != VariantQuality || variant.Request.Headers["Accept"] != "application/xml;q=abc" || variant.Request.Headers["accept"] != "" {
+		t.Errorf("Expected an Accept variant with an invalid quality for the requested type, got %v", variant)
+	}
+	if variant := names["Accept-Language: en;q=abc, fr;q=2"]; variant == nil || variant.Kind != VariantQuality {
+		t.Errorf("Expected an Accept-Language variant with unusual quality values, got %v", variant)
+	}
+	if req.Headers["Accept-Language"] != "en" {
+		t.Error("LanguageVariants() changed the original request")
+	}
+
+	for _, variant := range LanguageVariants(&ffuf.Request{Headers: map[string]string{}}, nil) {
+		if variant.Kind != VariantQuality || (variant.Request.Headers["Accept"] != "" && !strings.HasPrefix(variant.Request.Headers["Accept"], "application/json;")) {
+			t.Errorf("Unexpected variant %q without languages", variant.Name)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// DefaultThreshold is the default minimum similarity of two responses of the same resource
+const DefaultThreshold = 0.9
+
+// Placeholders replacing the volatile values of normalized responses
+const (
+	timestampPlaceholder = "<timestamp>"
+	uuidPlaceholder      = "<uuid>"
+	volatilePlaceholder  = "<volatile>"
+)
+
+var (
+	// timestampPattern matches ISO 8601 and RFC 1123 dates and times
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?|(Mon|Tue|Wed|Thu|Fri|Sat|Sun), \d{2} (Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) \d{4} \d{2}:\d{2}:\d{2} (GMT|UTC|[+-]\d{4})`)
+	// uuidPattern matches UUIDs
+	uuidPattern = regexp.MustCompile(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	// epochPattern matches Unix timestamps in seconds or milliseconds
+	epochPattern = regexp.MustCompile(`\b1[0-9]{9}([0-9]{3})?\b`)
+)
+
+// Options configures how responses are normalized and compared
+type Options struct {
+	// IgnoreHeaders are the headers left out of the comparison, such as dates and request IDs
+	IgnoreHeaders []string
+	// IgnoreFields are the JSON fields left out of the comparison, as field names or dotted
+	// paths without array indexes, such as "meta.generated"
+	IgnoreFields []string
+	// VolatileFields are the names of JSON fields whose values change with every response,
+	// compared only by presence
+	VolatileFields []string
+	// Threshold is the minimum similarity of two responses of the same resource
+	Threshold float64
+}
+
+// DefaultOptions returns options ignoring the usual per-response headers and fields
+func DefaultOptions() *Options {
+	return &Options{
+		IgnoreHeaders: []string{
+			"Date", "Expires", "Last-Modified", "Age", "ETag", "Set-Cookie", "Content-Length",
+			"X-Request-Id", "X-Correlation-Id", "X-Amzn-RequestId", "X-Amzn-Trace-Id", "X-Runtime",
+			"X-Response-Time", "Server-Timing", "CF-Ray", "Traceparent", "Report-To", "NEL", "Via",
+			"X-Cache", "X-Served-By", "X-Timer", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+			"RateLimit-Remaining", "RateLimit-Reset",
+		},
+		IgnoreFields: []string{},
+		VolatileFields: []string{
+			"request_id", "trace_id", "span_id", "correlation_id", "nonce", "csrf", "csrf_token",
+			"timestamp", "server_time", "took", "elapsed", "duration", "response_time",
+		},
+		Threshold: DefaultThreshold,
+	}
+}
+
+// ChangeKind is the kind of a change between two responses
+type ChangeKind string
+
+const (
+	// ChangeAdded is a header or field only in the second response
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is a header or field only in the first response
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified is a header or field with different values
+	ChangeModified ChangeKind = "modified"
+	// ChangeType is a field with values of different JSON types
+	ChangeType ChangeKind = "type"
+)
+
+// Change is a difference between the headers or bodies of two responses
+type Change struct {
+	Path   string
+	Kind   ChangeKind
+	First  interface{}
+	Second interface{}
+}
+
+// Comparison is the structural comparison of two normalized responses
+type Comparison struct {
+	StatusChanged      bool
+	ContentTypeChanged bool
+	HeaderChanges      []Change
+	BodyChanges        []Change
+	// StructureSimilarity is the ratio of JSON fields present in both bodies
+	StructureSimilarity float64
+	// Similarity is the ratio of JSON fields, or words of other bodies, equal in both bodies
+	Similarity float64
+
+	threshold float64
+}
+
+// SameResource checks if the responses represent the same resource: same status and media
+// type, and bodies at least as similar as the threshold once normalized. Header changes are
+// not taken into account.
+func (c *Comparison) SameResource() bool {
+	return !c.StatusChanged && !c.ContentTypeChanged && c.Similarity >= c.threshold
+}
+
+// String returns a summary of the comparison, to be used as evidence
+func (c *Comparison) String() string {
+	parts := []string{fmt.Sprintf("similarity %.2f", c.Similarity)}
+	if c.StatusChanged {
+		parts = append(parts, "status changed")
+	}
+	if c.ContentTypeChanged {
+		parts = append(parts, "content type changed")
+	}
+	if len(c.BodyChanges) > 0 {
+		paths := make([]string, 0, 5)
+		for i, change := range c.BodyChanges {
+			if i == 5 {
+				paths = append(paths, "...")
+				break
+			}
+			paths = append(paths, change.Path)
+		}
+		parts = append(parts, fmt.Sprintf("%d body change(s): %s", len(c.BodyChanges), strings.Join(paths, ", ")))
+	}
+	if len(c.HeaderChanges) > 0 {
+		parts = append(parts, fmt.Sprintf("%d header change(s)", len(c.HeaderChanges)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Compare compares two responses once normalized. Default options are used if options is nil.
+func Compare(first, second *ffuf.Response, options *Options) *Comparison {
+	if options == nil {
+		options = DefaultOptions()
+	}
+	c := &Comparison{
+		StatusChanged:      first.StatusCode != second.StatusCode,
+		ContentTypeChanged: mediaType(first.ContentType) != mediaType(second.ContentType),
+		HeaderChanges:      compareHeaders(first.Headers, second.Headers, options),
+		BodyChanges:        make([]Change, 0),
+		threshold:          options.Threshold,
+	}
+	if c.threshold <= 0 {
+		c.threshold = DefaultThreshold
+	}
+
+	var json1, json2 interface{}
+	if json.Unmarshal(first.Data, &json1) == nil && json.Unmarshal(second.Data, &json2) == nil {
+		fields1 := make(map[string]interface{})
+		fields2 := make(map[string]interface{})
+		flattenJSON(json1, "", "", options, fields1)
+		flattenJSON(json2, "", "", options, fields2)
+		c.BodyChanges, c.StructureSimilarity, c.Similarity = compareFields(fields1, fields2)
+		return c
+	}
+
+	text1 := NormalizeText(string(first.Data))
+	text2 := NormalizeText(string(second.Data))
+	c.Similarity = wordSimilarity(text1, text2)
+	c.StructureSimilarity = c.Similarity
+	if text1 != text2 {
+		c.BodyChanges = append(c.BodyChanges, Change{Path: "body", Kind: ChangeModified, First: text1, Second: text2})
+	}
+	return c
+}
+
+// SameResource checks if two responses represent the same resource. Default options are used
+// if options is nil.
+func SameResource(first, second *ffuf.Response, options *Options) bool {
+	return Compare(first, second, options).SameResource()
+}
+
+// NormalizeText replaces the timestamps, including Unix timestamps, and UUIDs of a text with
+// placeholders
+func NormalizeText(text string) string {
+	return epochPattern.ReplaceAllString(normalizeString(text), timestampPlaceholder)
+}
+
+// normalizeString replaces the dates and UUIDs of a string with placeholders
+func normalizeString(s string) string {
+	s = timestampPattern.ReplaceAllString(s, timestampPlaceholder)
+	return uuidPattern.ReplaceAllString(s, uuidPlaceholder)
+}
+
+// compareHeaders compares the normalized values of the headers not ignored
+func compareHeaders(headers1, headers2 map[string][]string, options *Options) []Change {
+	ignored := make(map[string]bool)
+	for _, name := range options.IgnoreHeaders {
+		ignored[strings.ToLower(name)] = true
+	}
+	values1 := normalizeHeaders(headers1, ignored)
+	values2 := normalizeHeaders(headers2, ignored)
+
+	changes := make([]Change, 0)
+	for _, name := range sortedKeys(values1, values2) {
+		value1, ok1 := values1[name]
+		value2, ok2 := values2[name]
+		switch {
+		case !ok1:
+			changes = append(changes, Change{Path: name, Kind: ChangeAdded, Second: value2})
+		case !ok2:
+			changes = append(changes, Change{Path: name, Kind: ChangeRemoved, First: value1})
+		case value1 != value2:
+			changes = append(changes, Change{Path: name, Kind: ChangeModified, First: value1, Second: value2})
+		}
+	}
+	return changes
+}
+
+// normalizeHeaders returns the normalized values of the headers not ignored, by lowercase name
+func normalizeHeaders(headers map[string][]string, ignored map[string]bool) map[string]interface{} {
+	normalized := make(map[string]interface{})
+	for name, values := range headers {
+		name = strings.ToLower(name)
+		if ignored[name] {
+			continue
+		}
+		normalized[name] = NormalizeText(strings.Join(values, ", "))
+	}
+	return normalized
+}
+
+// flattenJSON adds the normalized leaf values of a JSON value to fields, by dotted path
+func flattenJSON(value interface{}, path, key string, options *Options, fields map[string]interface{}) {
+	if path != "" && isIgnoredField(path, key, options.IgnoreFields) {
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			fields[path] = v
+			return
+		}
+		for name, child := range v {
+			childPath := name
+			if path != "" {
+				childPath = path + "." + name
+			}
+			flattenJSON(child, childPath, name, options, fields)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			fields[path] = v
+			return
+		}
+		for i, child := range v {
+			flattenJSON(child, fmt.Sprintf("%s[%d]", path, i), key, options, fields)
+		}
+	default:
+		fields[path] = normalizeValue(v, key, options.VolatileFields)
+	}
+}
+
+// normalizeValue replaces a volatile value with a placeholder
+func normalizeValue(value interface{}, key string, volatileFields []string) interface{} {
+	if key != "" && matchesFieldName(key, volatileFields) {
+		return volatilePlaceholder
+	}
+	switch v := value.(type) {
+	case string:
+		if isTimeField(key) {
+			return NormalizeText(v)
+		}
+		return normalizeString(v)
+	case float64:
+		if isTimeField(key) && v >= 1e9 && v < 1e13 && v == math.Trunc(v) {
+			return timestampPlaceholder
+		}
+	}
+	return value
+}
+
+// isIgnoredField checks if a field is ignored, by name or by path without array indexes
+func isIgnoredField(path, key string, ignoreFields []string) bool {
+	if len(ignoreFields) == 0 {
+		return false
+	}
+	stripped := arrayIndexPattern.ReplaceAllString(path, "")
+	for _, field := range ignoreFields {
+		if strings.EqualFold(field, stripped) || (key != "" && strings.EqualFold(field, key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// arrayIndexPattern matches the array indexes of a field path
+var arrayIndexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// matchesFieldName checks if a field name is in a list, ignoring case and separators
+func matchesFieldName(name string, names []string) bool {
+	name = canonicalFieldName(name)
+	for _, candidate := range names {
+		if canonicalFieldName(candidate) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalFieldName lowercases a field name and removes its separators
+func canonicalFieldName(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// isTimeField checks if a field name suggests a date or time, such as created_at or expires
+func isTimeField(name string) bool {
+	if strings.HasSuffix(name, "_at") || strings.HasSuffix(name, "At") {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, word := range []string{"time", "date", "expires", "expiry"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return name == "iat" || name == "nbf" || name == "exp"
+}
+
+// compareFields compares flattened JSON fields, and returns their changes, the ratio of fields
+// present in both and the ratio of fields equal in both
+func compareFields(fields1, fields2 map[string]interface{}) ([]Change, float64, float64) {
+	changes := make([]Change, 0)
+	paths := sortedKeys(fields1, fields2)
+	if len(paths) == 0 {
+		return changes, 1, 1
+	}
+	common, equal := 0, 0
+	for _, path := range paths {
+		value1, ok1 := fields1[path]
+		value2, ok2 := fields2[path]
+		switch {
+		case !ok1:
+			changes = append(changes, Change{Path: path, Kind: ChangeAdded, Second: value2})
+		case !ok2:
+			changes = append(changes, Change{Path: path, Kind: ChangeRemoved, First: value1})
+		default:
+			common++
+			if jsonType(value1) != jsonType(value2) {
+				changes = append(changes, Change{Path: path, Kind: ChangeType, First: value1, Second: value2})
+			} else if fmt.Sprint(value1) != fmt.Sprint(value2) {
+				changes = append(changes, Change{Path: path, Kind: ChangeModified, First: value1, Second: value2})
+			} else {
+				equal++
+			}
+		}
+	}
+	return changes, float64(common) / float64(len(paths)), float64(equal) / float64(len(paths))
+}
+
+// jsonType returns the JSON type of a decoded value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// wordSimilarity returns the Dice coefficient of the words of two texts
+func wordSimilarity(text1, text2 string) float64 {
+	words1 := strings.Fields(text1)
+	words2 := strings.Fields(text2)
+	if len(words1) == 0 && len(words2) == 0 {
+		return 1
+	}
+	counts := make(map[string]int)
+	for _, word := range words1 {
+		counts[word]++
+	}
+	common := 0
+	for _, word := range words2 {
+		if counts[word] > 0 {
+			counts[word]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(words1)+len(words2))
+}
+
+// mediaType returns the media type of a Content-Type header value, without parameters
+func mediaType(contentType string) string {
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// sortedKeys returns the sorted union of the keys of two maps
+func sortedKeys(map1, map2 map[string]interface{}) []string {
+	keys := make([]string, 0, len(map1)+len(map2))
+	for key := range map1 {
+		keys = append(keys, key)
+	}
+	for key := range map2 {
+		if _, ok := map1[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// jsonResponse creates a JSON response with headers
+func jsonResponse(status int64, body string, headers map[string][]string) *ffuf.Response {
+	return &ffuf.Response{StatusCode: status, ContentType: "application/json; charset=utf-8", Data: []byte(body), Headers: headers}
+}
+
+func TestCompareNormalizesVolatileValues(t *testing.T) {
+	first := jsonResponse(200, `{"id": 1, "name": "alice", "updated_at": "2024-05-01T10:00:00Z", "expires": 1714557600,
+		"meta": {"request_id": "a1b2", "session": "3f2504e0-4f89-11d3-9a0c-0305e82c3301"}}`,
+		map[string][]string{"Date": {"Wed, 01 May 2024 10:00:00 GMT"}, "X-Request-Id": {"1"}, "Cache-Control": {"no-store"}})
+	second := jsonResponse(200, `{"id": 1, "name": "alice", "updated_at": "2024-05-01T10:00:07.123+02:00", "expires": 1714557607,
+		"meta": {"request_id": "c3d4", "session": "6ba7b810-9dad-11d1-80b4-00c04fd430c8"}}`,
+		map[string][]string{"Date": {"Wed, 01 May 2024 10:00:07 GMT"}, "X-Request-Id": {"2"}, "Cache-Control": {"no-store"}})
+
+	c := Compare(first, second, nil)
+	if len(c.BodyChanges) != 0 || len(c.HeaderChanges) != 0 {
+		t.Errorf("Expected no changes once normalized, got %s", c)
+	}
+	if c.Similarity != 1 || !c.SameResource() {
+		t.Errorf("Expected the same resource, got %s", c)
+	}
+}
+
+func TestCompareStructuralChanges(t *testing.T) {
+	first := jsonResponse(200, `{"id": 1, "name": "alice", "roles": ["user"], "email": "alice@example.com"}`, nil)
+	second := jsonResponse(200, `{"id": "2", "name": "bob", "roles": ["user", "admin"]}`, map[string][]string{"X-Admin": {"1"}})
+
+	c := Compare(first, second, nil)
+	kinds := make(map[string]ChangeKind)
+	for _, change := range c.BodyChanges {
+		kinds[change.Path] = change.Kind
+	}
+	expected := map[string]ChangeKind{
+		"id":       ChangeType,
+		"name":     ChangeModified,
+		"roles[1]": ChangeAdded,
+		"email":    ChangeRemoved,
+	}
+	for path, kind := range expected {
+		if kinds[path] != kind {
+			t.Errorf("Expected %s change for %s, got %q", kind, path, kinds[path])
+		}
+	}
+	if len(c.HeaderChanges) != 1 || c.HeaderChanges[0].Path != "x-admin" || c.HeaderChanges[0].Kind != ChangeAdded {
+		t.Errorf("Expected the added header, got %v", c.HeaderChanges)
+	}
+	if c.SameResource() {
+		t.Errorf("Expected different resources, got %s", c)
+	}
+	if c.StructureSimilarity <= c.Similarity {
+		t.Errorf("Expected the structure to be more similar than the values, got %.2f and %.2f", c.StructureSimilarity, c.Similarity)
+	}
+}
+
+func TestCompareOptions(t *testing.T) {
+	first := jsonResponse(200, `{"id": 1, "items": [{"id": 1, "views": 10}], "generated": "abc"}`, nil)
+	second := jsonResponse(200, `{"id": 1, "items": [{"id": 1, "views": 12}], "generated": "def"}`, nil)
+	if SameResource(first, second, &Options{Threshold: 1}) {
+		t.Errorf("Expected different resources without ignored fields")
+	}
+	options := DefaultOptions()
+	options.IgnoreFields = []string{"items.views", "generated"}
+	options.Threshold = 1
+	if c := Compare(first, second, options); !c.SameResource() {
+		t.Errorf("Expected the same resource with ignored fields, got %s", c)
+	}
+}
+
+func TestCompareText(t *testing.T) {
+	first := &ffuf.Response{StatusCode: 200, ContentType: "text/html", Data: []byte("<html><body>Page not found, request 3f2504e0-4f89-11d3-9a0c-0305e82c3301 at 1714557600</body></html>")}
+	second := &ffuf.Response{StatusCode: 200, ContentType: "text/html", Data: []byte("<html><body>Page not found, request 6ba7b810-9dad-11d1-80b4-00c04fd430c8 at 1714557607</body></html>")}
+	if c := Compare(first, second, nil); !c.SameResource() || len(c.BodyChanges) != 0 {
+		t.Errorf("Expected the same page once normalized, got %s", c)
+	}
+
+	third := &ffuf.Response{StatusCode: 200, ContentType: "text/html", Data: []byte("<html><body>Welcome to the admin console</body></html>")}
+	if SameResource(first, third, nil) {
+		t.Errorf("Expected different pages")
+	}
+	third.StatusCode = 404
+	third.Data = first.Data
+	if SameResource(first, third, nil) {
+		t.Errorf("Expected responses with different status codes to be different resources")
+	}
+}
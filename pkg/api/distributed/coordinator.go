@@ -0,0 +1,358 @@
+package distributed
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// Coordinator leases the shards of a scan to workers and merges the results they report.
+// It implements http.Handler, serving the endpoints polled by the workers.
+type Coordinator struct {
+	// Token authenticates the workers, sent as a bearer token. Any worker is accepted if empty.
+	Token string
+	// LeaseTimeout is how long a worker holds a shard without sending a heartbeat
+	LeaseTimeout time.Duration
+	// MaxAttempts is the number of times a shard failing on workers is leased before it is
+	// abandoned
+	MaxAttempts int
+	// OnReport is called with the report of every completed shard
+	OnReport func(shard Shard, report Report)
+
+	job       Job
+	leases    []*lease
+	remaining int
+	state     *os.File
+	done      chan struct{}
+	mu        sync.Mutex
+}
+
+// lease is the assignment state of a shard
+type lease struct {
+	shard    Shard
+	worker   string
+	deadline time.Time
+	failures int
+	done     bool
+	report   Report
+}
+
+// stateRecord is a completed shard stored in the state file
+type stateRecord struct {
+	Key    string `json:"key"`
+	Report Report `json:"report"`
+}
+
+// NewCoordinator creates a coordinator leasing the shards of a job
+func NewCoordinator(job Job, shards []Shard) *Coordinator {
+	c := &Coordinator{
+		LeaseTimeout: time.Minute,
+		MaxAttempts:  3,
+		job:          job,
+		leases:       make([]*lease, len(shards)),
+		remaining:    len(shards),
+		done:         make(chan struct{}),
+	}
+	for i, shard := range shards {
+		c.leases[i] = &lease{shard: shard}
+	}
+	if c.remaining == 0 {
+		close(c.done)
+	}
+	return c
+}
+
+// OpenState opens the state file recording completed shards. If resume is true, the shards
+// recorded in the file are completed with their recorded results, otherwise the file is
+// truncated.
+func (c *Coordinator) OpenState(path string, resume bool) error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if resume {
+		if err := c.loadState(path); err != nil {
+			return err
+		}
+	} else {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return api.NewAPIError("Failed to open state file: "+err.Error(), 0)
+	}
+	c.mu.Lock()
+	c.state = file
+	c.mu.Unlock()
+	return nil
+}
+
+// loadState completes the shards recorded in a state file. A truncated last line, left by
+// a coordinator killed while writing, is ignored.
+func (c *Coordinator) loadState(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return api.NewAPIError("Failed to read state file: "+err.Error(), 0)
+	}
+	defer file.Close()
+
+	completed := make(map[string]Report)
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 && err == nil {
+			var record stateRecord
+			if jsonErr := json.Unmarshal(line, &record); jsonErr == nil {
+				completed[record.Key] = record.Report
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, l := range c.leases {
+		if report, ok := completed[l.shard.Key()]; ok && !l.done {
+			c.complete(l, report)
+		}
+	}
+	return nil
+}
+
+// Lease leases the next shard to a worker. It returns nil if all the remaining shards are
+// leased to other workers, and done is true once every shard is completed.
+func (c *Coordinator) Lease(worker string) (shard *Shard, done bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.remaining == 0 {
+		return nil, true
+	}
+	now := time.Now()
+	for _, l := range c.leases {
+		// Shards of workers which stopped sending heartbeats are leased again
+		if l.done || (l.worker != "" && now.Before(l.deadline)) {
+			continue
+		}
+		l.worker = worker
+		l.deadline = now.Add(c.LeaseTimeout)
+		shard := l.shard
+		return &shard, false
+	}
+	return nil, false
+}
+
+// Heartbeat extends the lease of a shard held by a worker. It returns an error if the shard
+// was leased to another worker since.
+func (c *Coordinator) Heartbeat(id int, worker string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, err := c.lease(id)
+	if err != nil {
+		return err
+	}
+	if l.done {
+		return nil
+	}
+	if l.worker != worker {
+		return api.NewAPIError("Shard "+strconv.Itoa(id)+" is leased to another worker", http.StatusConflict)
+	}
+	l.deadline = time.Now().Add(c.LeaseTimeout)
+	return nil
+}
+
+// Complete records the report of a shard. A shard failing on a worker is leased again until
+// MaxAttempts is reached. Reports of shards already completed by another worker are ignored.
+func (c *Coordinator) Complete(id int, report Report) error {
+	c.mu.Lock()
+	l, err := c.lease(id)
+	if err != nil || l.done {
+		c.mu.Unlock()
+		return err
+	}
+	if report.Error != "" {
+		l.failures++
+		if l.failures < c.MaxAttempts {
+			l.worker = ""
+			c.mu.Unlock()
+			return nil
+		}
+	} else if c.state != nil {
+		line, _ := json.Marshal(stateRecord{Key: l.shard.Key(), Report: report})
+		if _, err := c.state.Write(append(line, '\n')); err != nil {
+			c.mu.Unlock()
+			return api.NewAPIError("Failed to write state file: "+err.Error(), 0)
+		}
+	}
+	c.complete(l, report)
+	shard, onReport := l.shard, c.OnReport
+	c.mu.Unlock()
+
+	if onReport != nil {
+		onReport(shard, report)
+	}
+	return nil
+}
+
+// complete marks a shard as completed. The caller must hold the lock.
+func (c *Coordinator) complete(l *lease, report Report) {
+	l.done = true
+	l.report = report
+	c.remaining--
+	if c.remaining == 0 {
+		close(c.done)
+	}
+}
+
+// lease returns the lease of a shard. The caller must hold the lock.
+func (c *Coordinator) lease(id int) (*lease, error) {
+	if id < 0 || id >= len(c.leases) {
+		return nil, api.NewAPIError("Unknown shard "+strconv.Itoa(id), http.StatusNotFound)
+	}
+	return c.leases[id], nil
+}
+
+// Done returns a channel closed once every shard is completed
+func (c *Coordinator) Done() <-chan struct{} {
+	return c.done
+}
+
+// Progress returns the number of completed shards and the total number of shards
+func (c *Coordinator) Progress() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.leases) - c.remaining, len(c.leases)
+}
+
+// Results returns the results of the completed shards, in the order of the shards
+func (c *Coordinator) Results() []ffuf.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	results := make([]ffuf.Result, 0)
+	for _, l := range c.leases {
+		if l.done {
+			results = append(results, l.report.Results...)
+		}
+	}
+	return results
+}
+
+// Failures returns the reports of the shards abandoned after failing MaxAttempts times
+func (c *Coordinator) Failures() map[int]Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	failures := make(map[int]Report)
+	for _, l := range c.leases {
+		if l.done && l.report.Error != "" {
+			failures[l.shard.ID] = l.report
+		}
+	}
+	return failures
+}
+
+// Close closes the state file
+func (c *Coordinator) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == nil {
+		return nil
+	}
+	err := c.state.Close()
+	c.state = nil
+	if err != nil {
+		return api.NewAPIError("Failed to close state file: "+err.Error(), 0)
+	}
+	return nil
+}
+
+// ServeHTTP serves the job, lease, heartbeat and report endpoints to the workers
+func (c *Coordinator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if c.Token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+c.Token)) != 1 {
+		http.Error(w, "invalid worker token", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == JobPath && r.Method == http.MethodGet:
+		writeJSON(w, c.job)
+	case r.URL.Path == LeasePath && r.Method == http.MethodPost:
+		var req leaseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid lease request", http.StatusBadRequest)
+			return
+		}
+		shard, done := c.Lease(req.Worker)
+		switch {
+		case done:
+			w.WriteHeader(http.StatusGone)
+		case shard == nil:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			seconds := int(c.LeaseTimeout / time.Second)
+			if seconds < 1 {
+				seconds = 1
+			}
+			writeJSON(w, leaseResponse{Shard: *shard, LeaseSeconds: seconds})
+		}
+	case strings.HasPrefix(r.URL.Path, ShardsPath) && r.Method == http.MethodPost:
+		c.serveShard(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveShard serves the heartbeat and report endpoints of a shard, /dist/shards/{id}/heartbeat
+// and /dist/shards/{id}/report
+func (c *Coordinator) serveShard(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, ShardsPath), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch parts[1] {
+	case "heartbeat":
+		var req leaseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid heartbeat", http.StatusBadRequest)
+			return
+		}
+		err = c.Heartbeat(id, req.Worker)
+	case "report":
+		var report Report
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			http.Error(w, "invalid report", http.StatusBadRequest)
+			return
+		}
+		err = c.Complete(id, report)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if apiErr, ok := err.(*api.APIError); ok && apiErr.Code != 0 {
+		http.Error(w, apiErr.Message, apiErr.Code)
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
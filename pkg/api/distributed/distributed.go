@@ -0,0 +1,94 @@
+// Package distributed shards the work of a scan over remote ffuf workers.
+//
+// A coordinator splits the wordlist of a scan, for every target, into shards and leases
+// them to workers polling it over HTTP. Workers fuzz the words of a shard with the options
+// of the scan and report the results, which the coordinator merges into a single report.
+// A shard whose worker stops sending heartbeats is leased to another worker, and completed
+// shards are recorded in a state file so that an interrupted coordinator resumes the scan.
+package distributed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+const (
+	// JobPath is the path of the endpoint returning the job of the scan
+	JobPath = "/dist/job"
+	// LeasePath is the path of the endpoint leasing a shard to a worker
+	LeasePath = "/dist/lease"
+	// ShardsPath is the path prefix of the heartbeat and report endpoints of the shards
+	ShardsPath = "/dist/shards/"
+)
+
+// Job is the scan run by the workers
+type Job struct {
+	// Options are the options of the scan, the first wordlist being replaced with the
+	// words of the shard
+	Options *ffuf.ConfigOptions `json:"options"`
+	// Flags are the names of the flags set on the command line of the coordinator, which
+	// select the default matchers
+	Flags []string `json:"flags"`
+}
+
+// Shard is a unit of work: words of the first wordlist fuzzed against a target
+type Shard struct {
+	ID int `json:"id"`
+	// Target is the base URL the URL of the scan is appended to, empty to fuzz the URL as is
+	Target string   `json:"target,omitempty"`
+	Words  []string `json:"words"`
+}
+
+// Report is the outcome of a shard reported by a worker
+type Report struct {
+	Worker  string        `json:"worker"`
+	Results []ffuf.Result `json:"results"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// leaseRequest is the body of the lease and heartbeat requests of a worker
+type leaseRequest struct {
+	Worker string `json:"worker"`
+}
+
+// leaseResponse is the response of the lease endpoint
+type leaseResponse struct {
+	Shard Shard `json:"shard"`
+	// LeaseSeconds is the time the worker holds the shard without sending a heartbeat
+	LeaseSeconds int `json:"lease_seconds"`
+}
+
+// SplitShards splits the words into shards of up to size words for every target
+func SplitShards(targets []string, words []string, size int) []Shard {
+	if size < 1 {
+		size = 1
+	}
+	if len(targets) == 0 {
+		targets = []string{""}
+	}
+	shards := make([]Shard, 0)
+	for _, target := range targets {
+		for start := 0; start < len(words); start += size {
+			end := start + size
+			if end > len(words) {
+				end = len(words)
+			}
+			shards = append(shards, Shard{
+				ID:     len(shards),
+				Target: target,
+				Words:  words[start:end],
+			})
+		}
+	}
+	return shards
+}
+
+// Key identifies a shard by its target and words, so that completed shards are recognized
+// when a scan is resumed
+func (s Shard) Key() string {
+	h := sha256.Sum256([]byte(s.Target + "\n" + strings.Join(s.Words, "\n")))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,178 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestSplitShards(t *testing.T) {
+	words := []string{"a", "b", "c", "d", "e"}
+	shards := SplitShards([]string{"https://one.example", "https://two.example"}, words, 2)
+	if len(shards) != 6 {
+		t.Fatalf("Expected 6 shards, got %d", len(shards))
+	}
+	if shards[3].ID != 3 || shards[3].Target != "https://two.example" || strings.Join(shards[3].Words, ",") != "a,b" {
+		t.Errorf("Unexpected shard: %+v", shards[3])
+	}
+	if shards[0].Key() == shards[3].Key() {
+		t.Errorf("Expected shards of different targets to have different keys")
+	}
+	if shards := SplitShards(nil, words, 10); len(shards) != 1 || shards[0].Target != "" {
+		t.Errorf("Expected a single shard without target, got %+v", shards)
+	}
+}
+
+func TestCoordinatorLeases(t *testing.T) {
+	c := NewCoordinator(Job{}, SplitShards(nil, []string{"a", "b", "c"}, 2))
+	c.LeaseTimeout = 50 * time.Millisecond
+	c.MaxAttempts = 2
+
+	first, _ := c.Lease("w1")
+	second, _ := c.Lease("w2")
+	if first == nil || second == nil || first.ID != 0 || second.ID != 1 {
+		t.Fatalf("Expected the shards to be leased in order, got %v %v", first, second)
+	}
+	if shard, done := c.Lease("w3"); shard != nil || done {
+		t.Fatalf("Expected no shard to be available, got %v %v", shard, done)
+	}
+
+	// The lease of the second worker expires without heartbeats, the first one is extended
+	time.Sleep(30 * time.Millisecond)
+	if err := c.Heartbeat(0, "w1"); err != nil {
+		t.Fatalf("Heartbeat returned an error: %s", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if shard, _ := c.Lease("w3"); shard == nil || shard.ID != 1 {
+		t.Fatalf("Expected the expired shard to be leased again, got %v", shard)
+	}
+	if err := c.Heartbeat(1, "w2"); err == nil {
+		t.Errorf("Expected a heartbeat for a lost lease to fail")
+	}
+
+	// A failed shard is leased again until MaxAttempts is reached
+	if err := c.Complete(0, Report{Worker: "w1", Error: "connection refused"}); err != nil {
+		t.Fatalf("Complete returned an error: %s", err)
+	}
+	if shard, _ := c.Lease("w1"); shard == nil || shard.ID != 0 {
+		t.Fatalf("Expected the failed shard to be leased again, got %v", shard)
+	}
+	c.Complete(0, Report{Worker: "w1", Error: "connection refused"})
+	if failures := c.Failures(); len(failures) != 1 || failures[0].Error != "connection refused" {
+		t.Errorf("Expected the shard to be abandoned, got %v", failures)
+	}
+
+	c.Complete(1, Report{Worker: "w3", Results: []ffuf.Result{{Url: "https://example.com/b"}}})
+	// A late report of the worker which lost the lease is ignored
+	c.Complete(1, Report{Worker: "w2", Results: []ffuf.Result{{Url: "https://example.com/late"}}})
+	if done, total := c.Progress(); done != 2 || total != 2 {
+		t.Errorf("Expected 2/2 completed shards, got %d/%d", done, total)
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Errorf("Expected the coordinator to be done")
+	}
+	if results := c.Results(); len(results) != 1 || results[0].Url != "https://example.com/b" {
+		t.Errorf("Unexpected results: %v", results)
+	}
+	if err := c.Complete(5, Report{}); err == nil {
+		t.Errorf("Expected a report of an unknown shard to fail")
+	}
+}
+
+func TestCoordinatorResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+	shards := SplitShards([]string{"https://api.example.com"}, []string{"a", "b", "c"}, 1)
+
+	c := NewCoordinator(Job{}, shards)
+	if err := c.OpenState(path, false); err != nil {
+		t.Fatalf("OpenState returned an error: %s", err)
+	}
+	c.Lease("w1")
+	c.Complete(0, Report{Worker: "w1", Results: []ffuf.Result{{Url: "https://api.example.com/a"}}})
+	c.Complete(2, Report{Worker: "w1", Error: "timeout"})
+	c.Close()
+
+	resumed := NewCoordinator(Job{}, shards)
+	if err := resumed.OpenState(path, true); err != nil {
+		t.Fatalf("OpenState returned an error: %s", err)
+	}
+	defer resumed.Close()
+	if done, _ := resumed.Progress(); done != 1 {
+		t.Errorf("Expected the recorded shard to be completed, got %d completed shards", done)
+	}
+	if shard, _ := resumed.Lease("w2"); shard == nil || shard.ID != 1 {
+		t.Errorf("Expected the first remaining shard to be leased, got %v", shard)
+	}
+	if results := resumed.Results(); len(results) != 1 || results[0].Url != "https://api.example.com/a" {
+		t.Errorf("Expected the recorded results, got %v", results)
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	job := Job{Options: ffuf.NewConfigOptions(), Flags: []string{"mc"}}
+	job.Options.HTTP.URL = "/FUZZ"
+	words := make([]string, 25)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+	c := NewCoordinator(job, SplitShards([]string{"https://one.example", "https://two.example"}, words, 4))
+	c.Token = "secret"
+	var mu sync.Mutex
+	reports := 0
+	c.OnReport = func(shard Shard, report Report) {
+		mu.Lock()
+		reports++
+		mu.Unlock()
+	}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	if _, err := NewWorker(server.URL, "wrong", "w0").FetchJob(context.Background()); err == nil {
+		t.Fatalf("Expected a worker with an invalid token to be rejected")
+	}
+	fetched, err := NewWorker(server.URL, "secret", "w0").FetchJob(context.Background())
+	if err != nil || fetched.Options.HTTP.URL != "/FUZZ" || fetched.Flags[0] != "mc" {
+		t.Fatalf("Unexpected job: %+v %v", fetched, err)
+	}
+
+	// Every word is reported as a result, the executions of each worker are counted
+	execute := func(ctx context.Context, shard Shard) ([]ffuf.Result, error) {
+		results := make([]ffuf.Result, 0)
+		for _, word := range shard.Words {
+			results = append(results, ffuf.Result{Url: shard.Target + "/" + word})
+		}
+		return results, nil
+	}
+	var wg sync.WaitGroup
+	executed := make([]int, 3)
+	for i := range executed {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := NewWorker(server.URL, "secret", fmt.Sprintf("w%d", i))
+			w.PollInterval = 10 * time.Millisecond
+			var err error
+			if executed[i], err = w.Run(context.Background(), execute); err != nil {
+				t.Errorf("Worker %d returned an error: %s", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if total := executed[0] + executed[1] + executed[2]; total != 14 || reports != 14 {
+		t.Errorf("Expected 14 shards to be executed and reported, got %d executed and %d reported", total, reports)
+	}
+	results := c.Results()
+	if len(results) != 50 || results[0].Url != "https://one.example/word0" || results[49].Url != "https://two.example/word24" {
+		t.Errorf("Expected the merged results of both targets in order, got %d results", len(results))
+	}
+}
@@ -0,0 +1,198 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// errScanDone is returned when the coordinator has completed every shard
+var errScanDone = api.NewAPIError("The scan is completed", http.StatusGone)
+
+// ExecuteFunc fuzzes the words of a shard and returns the matching results
+type ExecuteFunc func(ctx context.Context, shard Shard) ([]ffuf.Result, error)
+
+// Worker leases shards from a coordinator, executes them and reports their results
+type Worker struct {
+	// Coordinator is the base URL of the coordinator
+	Coordinator string
+	// Token authenticates the worker to the coordinator
+	Token string
+	// Name identifies the worker in the leases of the coordinator
+	Name string
+	// PollInterval is the time waited before asking for a shard again when every remaining
+	// shard is leased, or the coordinator is unreachable
+	PollInterval time.Duration
+	// MaxErrors is the number of consecutive failed requests to the coordinator after which
+	// the worker gives up
+	MaxErrors int
+	Client    *http.Client
+}
+
+// NewWorker creates a worker of a coordinator
+func NewWorker(coordinator, token, name string) *Worker {
+	return &Worker{
+		Coordinator:  strings.TrimSuffix(coordinator, "/"),
+		Token:        token,
+		Name:         name,
+		PollInterval: 2 * time.Second,
+		MaxErrors:    5,
+		Client:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// FetchJob returns the job of the scan run by the coordinator
+func (w *Worker) FetchJob(ctx context.Context) (*Job, error) {
+	var job Job
+	if err := w.call(ctx, http.MethodGet, JobPath, nil, &job); err != nil {
+		return nil, err
+	}
+	if job.Options == nil {
+		return nil, api.NewAPIError("The coordinator returned a job without options", 0)
+	}
+	return &job, nil
+}
+
+// Run leases shards and executes them until every shard of the scan is completed or the
+// context is cancelled. It returns the number of shards executed by the worker.
+func (w *Worker) Run(ctx context.Context, execute ExecuteFunc) (int, error) {
+	executed := 0
+	failures := 0
+	for ctx.Err() == nil {
+		var lease leaseResponse
+		err := w.call(ctx, http.MethodPost, LeasePath, leaseRequest{Worker: w.Name}, &lease)
+		if err == errScanDone {
+			return executed, nil
+		}
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.Code == http.StatusUnauthorized {
+			return executed, err
+		}
+		if err != nil {
+			failures++
+			if failures >= w.MaxErrors {
+				return executed, err
+			}
+			w.wait(ctx)
+			continue
+		}
+		failures = 0
+		if lease.LeaseSeconds == 0 {
+			// Every remaining shard is leased to other workers
+			w.wait(ctx)
+			continue
+		}
+
+		report := w.execute(ctx, lease, execute)
+		if ctx.Err() != nil {
+			// The lease of an interrupted shard expires, and the shard is leased again
+			break
+		}
+		if err := w.report(ctx, lease.Shard.ID, report); err != nil {
+			return executed, err
+		}
+		executed++
+	}
+	return executed, ctx.Err()
+}
+
+// execute executes a shard, sending heartbeats to the coordinator until it is done
+func (w *Worker) execute(ctx context.Context, lease leaseResponse, execute ExecuteFunc) Report {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(time.Duration(lease.LeaseSeconds) * time.Second / 3)
+		defer ticker.Stop()
+		path := fmt.Sprintf("%s%d/heartbeat", ShardsPath, lease.Shard.ID)
+		for {
+			select {
+			case <-ticker.C:
+				w.call(ctx, http.MethodPost, path, leaseRequest{Worker: w.Name}, nil)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	results, err := execute(ctx, lease.Shard)
+	report := Report{Worker: w.Name, Results: results}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	if report.Results == nil {
+		report.Results = []ffuf.Result{}
+	}
+	return report
+}
+
+// report sends the report of a shard to the coordinator, retrying failed requests
+func (w *Worker) report(ctx context.Context, id int, report Report) error {
+	path := fmt.Sprintf("%s%d/report", ShardsPath, id)
+	var err error
+	for attempt := 0; attempt < w.MaxErrors; attempt++ {
+		if err = w.call(ctx, http.MethodPost, path, report, nil); err == nil || err == errScanDone {
+			return nil
+		}
+		w.wait(ctx)
+	}
+	return err
+}
+
+// wait waits for the poll interval or the context to be cancelled
+func (w *Worker) wait(ctx context.Context) {
+	timer := time.NewTimer(w.PollInterval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// call sends a request to the coordinator and decodes its JSON response into out, if any
+func (w *Worker) call(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, w.Coordinator+path, reader)
+	if err != nil {
+		return api.NewAPIError("Invalid coordinator URL: "+err.Error(), 0)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.Token)
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return api.NewAPIError("Failed to reach the coordinator: "+err.Error(), 0)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusGone:
+		return errScanDone
+	case resp.StatusCode == http.StatusNoContent:
+		return nil
+	case resp.StatusCode >= 300:
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return api.NewAPIError("The coordinator rejected the request: "+strings.TrimSpace(string(message)), resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return api.NewAPIError("Invalid response from the coordinator: "+err.Error(), 0)
+	}
+	return nil
+}
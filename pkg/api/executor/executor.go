@@ -0,0 +1,559 @@
+// Package executor runs generated API test cases and evaluates their results.
+//
+// Test cases produced by the parser package are sent through an ffuf runner with
+// bounded concurrency. Dependencies between test cases are honored, and every
+// response is compared against the expected status code, content type and body.
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/auth"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/state"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+// TestStatus represents the outcome of a test case execution.
+type TestStatus string
+
+const (
+	// StatusPassed means the response matched all expectations
+	StatusPassed TestStatus = "passed"
+	// StatusFailed means the response did not match at least one expectation
+	StatusFailed TestStatus = "failed"
+	// StatusError means the request could not be executed
+	StatusError TestStatus = "error"
+	// StatusSkipped means the test case was not executed
+	StatusSkipped TestStatus = "skipped"
+)
+
+// Options contains configuration options for the executor.
+type Options struct {
+	// Concurrency is the maximum number of test cases to execute concurrently
+	Concurrency int
+
+	// Headers are added to every request; test case headers take precedence
+	Headers map[string]string
+
+	// SkipOnDependencyFailure skips test cases whose dependencies did not pass
+	SkipOnDependencyFailure bool
+
+	// State records completed requests, and replays the requests recorded by an
+	// interrupted run instead of sending them again
+	State *state.Store
+
+	// Auth authenticates requests with the token and cookies of a login session
+	Auth *auth.Session
+
+	// Variables resolve the {{name}} placeholders of test cases and headers. The values
+	// extracted from the responses of dependencies are available as variables too.
+	Variables *parser.Variables
+}
+
+// DefaultOptions returns the default executor options.
+func DefaultOptions() *Options {
+	return &Options{
+		Concurrency:             10,
+		Headers:                 make(map[string]string),
+		SkipOnDependencyFailure: true,
+	}
+}
+
+// TestResult represents the result of executing a single test case.
+type TestResult struct {
+	// TestCase is the test case that was executed
+	TestCase *parser.APITestCase
+
+	// Status is the outcome of the execution
+	Status TestStatus
+
+	// Request is the request that was sent
+	Request *ffuf.Request
+
+	// Response is the response that was received, if any
+	Response *ffuf.Response
+
+	// Failures lists the expectations that were not met
+	Failures []string
+
+	// Error is the error that occurred, if any
+	Error error
+
+	// Variables contains the values extracted from the responses of dependencies
+	Variables map[string]string
+
+	// StartTime is when the test case started execution
+	StartTime time.Time
+
+	// EndTime is when the test case completed execution
+	EndTime time.Time
+}
+
+// Passed returns whether the test case passed.
+func (r *TestResult) Passed() bool {
+	return r.Status == StatusPassed
+}
+
+// ExecutionResult represents the result of executing a set of test cases.
+type ExecutionResult struct {
+	// Results contains the test results, dependencies before their dependents
+	Results []*TestResult
+
+	// Passed is the number of passed test cases
+	Passed int
+
+	// Failed is the number of failed test cases
+	Failed int
+
+	// Errors is the number of test cases that could not be executed
+	Errors int
+
+	// Skipped is the number of skipped test cases
+	Skipped int
+
+	// StartTime is when the execution started
+	StartTime time.Time
+
+	// EndTime is when the execution completed
+	EndTime time.Time
+
+	// Duration is the total duration of the execution
+	Duration time.Duration
+
+	byTestCase map[*parser.APITestCase]*TestResult
+}
+
+// GetResult returns the result of a test case, or nil if it was not part of the execution.
+func (r *ExecutionResult) GetResult(testCase *parser.APITestCase) *TestResult {
+	return r.byTestCase[testCase]
+}
+
+// GetResultsByStatus returns all results with the given status.
+func (r *ExecutionResult) GetResultsByStatus(status TestStatus) []*TestResult {
+	results := make([]*TestResult, 0)
+	for _, result := range r.Results {
+		if result.Status == status {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Sequence returns the steps of the given test cases, or of all test cases if none are
+// given, in execution order. It is visualized with parser.Visualizer.VisualizeSequence.
+func (r *ExecutionResult) Sequence(testCases ...*parser.APITestCase) []*parser.SequenceStep {
+	included := make(map[*parser.APITestCase]bool, len(testCases))
+	for _, testCase := range testCases {
+		included[testCase] = true
+	}
+
+	steps := make([]*parser.SequenceStep, 0, len(r.Results))
+	byTestCase := make(map[*parser.APITestCase]*parser.SequenceStep, len(r.Results))
+	for _, result := range r.Results {
+		if len(included) > 0 && !included[result.TestCase] {
+			continue
+		}
+		step := &parser.SequenceStep{
+			Name:      result.TestCase.Name,
+			Method:    result.TestCase.Method,
+			URL:       result.TestCase.URL,
+			Outcome:   string(result.Status),
+			Variables: result.Variables,
+		}
+		if result.Request != nil {
+			step.Method = result.Request.Method
+			step.URL = result.Request.Url
+		}
+		if result.Response != nil {
+			step.StatusCode = int(result.Response.StatusCode)
+		}
+		messages := append([]string{}, result.Failures...)
+		if result.Error != nil {
+			messages = append(messages, result.Error.Error())
+		}
+		step.Message = strings.Join(messages, "; ")
+		steps = append(steps, step)
+		byTestCase[result.TestCase] = step
+	}
+
+	// Show the values on the responses they were extracted from
+	for _, result := range r.Results {
+		for _, extraction := range result.TestCase.Extractions {
+			value, ok := result.Variables[extraction.Name]
+			source := byTestCase[extraction.Source(result.TestCase)]
+			if !ok || source == nil {
+				continue
+			}
+			if source.Extracted == nil {
+				source.Extracted = make(map[string]string)
+			}
+			source.Extracted[extraction.Name] = value
+		}
+	}
+	return steps
+}
+
+// Executor runs API test cases through an ffuf runner.
+type Executor struct {
+	// Options contains the executor configuration
+	Options *Options
+
+	runner ffuf.RunnerProvider
+}
+
+// NewExecutor creates a new executor that sends requests with a simple runner for the given config.
+func NewExecutor(config *ffuf.Config, options *Options) *Executor {
+	return NewExecutorWithRunner(runner.NewSimpleRunner(config, false), options)
+}
+
+// NewExecutorWithRunner creates a new executor that sends requests with the given runner.
+func NewExecutorWithRunner(r ffuf.RunnerProvider, options *Options) *Executor {
+	if options == nil {
+		options = DefaultOptions()
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+	if options.Auth != nil {
+		r = auth.NewRunner(options.Auth, r)
+	}
+	if options.State != nil {
+		r = state.NewRunner(options.State, state.ScopeTestGen, r)
+	}
+	return &Executor{
+		Options: options,
+		runner:  r,
+	}
+}
+
+// Execute runs the test cases and returns their results. Dependencies of the test cases
+// and the sources of their extractions are executed before their dependents, including
+// those that are not part of the given list. An error is returned if the dependencies
+// contain a cycle.
+func (e *Executor) Execute(ctx context.Context, testCases []*parser.APITestCase) (*ExecutionResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	order, err := orderTestCases(testCases)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExecutionResult{
+		Results:    make([]*TestResult, len(order)),
+		StartTime:  time.Now(),
+		byTestCase: make(map[*parser.APITestCase]*TestResult, len(order)),
+	}
+
+	done := make(map[*parser.APITestCase]chan struct{}, len(order))
+	for _, testCase := range order {
+		done[testCase] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, e.Options.Concurrency)
+
+	for i, testCase := range order {
+		wg.Add(1)
+		go func(i int, testCase *parser.APITestCase) {
+			defer wg.Done()
+			defer close(done[testCase])
+
+			// Wait for all dependencies to complete
+			for _, dep := range testCase.Prerequisites() {
+				<-done[dep]
+			}
+
+			var testResult *TestResult
+			if skip := e.dependencyFailure(testCase, result, &mu); skip != "" {
+				testResult = &TestResult{TestCase: testCase, Status: StatusSkipped, Failures: []string{skip}}
+			} else {
+				select {
+				case semaphore <- struct{}{}:
+					testResult = e.executeTestCase(ctx, testCase, result, &mu)
+					<-semaphore
+				case <-ctx.Done():
+					testResult = &TestResult{TestCase: testCase, Status: StatusSkipped, Error: ctx.Err()}
+				}
+			}
+
+			mu.Lock()
+			result.Results[i] = testResult
+			result.byTestCase[testCase] = testResult
+			mu.Unlock()
+		}(i, testCase)
+	}
+	wg.Wait()
+
+	for _, testResult := range result.Results {
+		switch testResult.Status {
+		case StatusPassed:
+			result.Passed++
+		case StatusFailed:
+			result.Failed++
+		case StatusError:
+			result.Errors++
+		case StatusSkipped:
+			result.Skipped++
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// dependencyFailure returns the reason for skipping a test case if one of its dependencies did not pass
+func (e *Executor) dependencyFailure(testCase *parser.APITestCase, result *ExecutionResult, mu *sync.Mutex) string {
+	if !e.Options.SkipOnDependencyFailure {
+		return ""
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, dep := range testCase.Prerequisites() {
+		if depResult := result.byTestCase[dep]; depResult == nil || !depResult.Passed() {
+			return fmt.Sprintf("dependency %q did not pass", dep.Name)
+		}
+	}
+	return ""
+}
+
+// executeTestCase resolves the extractions of a test case, sends its request and evaluates the response
+func (e *Executor) executeTestCase(ctx context.Context, testCase *parser.APITestCase, result *ExecutionResult, mu *sync.Mutex) *TestResult {
+	testResult := &TestResult{
+		TestCase:  testCase,
+		StartTime: time.Now(),
+	}
+	defer func() {
+		testResult.EndTime = time.Now()
+	}()
+
+	if err := ctx.Err(); err != nil {
+		testResult.Status = StatusSkipped
+		testResult.Error = err
+		return testResult
+	}
+
+	variables, err := resolveExtractions(testCase, result, mu)
+	if err != nil {
+		testResult.Status = StatusError
+		testResult.Error = err
+		return testResult
+	}
+	testResult.Variables = variables
+
+	applied := testCase.ApplyVariables(variables)
+	req, err := e.BuildRequest(applied.ApplyTemplate(e.Options.Variables.With(variables)))
+	if err != nil {
+		testResult.Status = StatusError
+		testResult.Error = err
+		return testResult
+	}
+	testResult.Request = req
+
+	resp, err := e.runner.Execute(req)
+	if err != nil {
+		testResult.Status = StatusError
+		testResult.Error = api.NewAPIError(fmt.Sprintf("Failed to execute test case %q: %s", testCase.Name, err.Error()), 0)
+		return testResult
+	}
+	testResult.Response = &resp
+
+	testResult.Failures = Evaluate(applied, &resp)
+	if len(testResult.Failures) > 0 {
+		testResult.Status = StatusFailed
+	} else {
+		testResult.Status = StatusPassed
+	}
+	return testResult
+}
+
+// resolveExtractions extracts the variables of a test case from the responses of its dependencies
+func resolveExtractions(testCase *parser.APITestCase, result *ExecutionResult, mu *sync.Mutex) (map[string]string, error) {
+	variables := make(map[string]string, len(testCase.Extractions))
+	for _, extraction := range testCase.Extractions {
+		source := extraction.Source(testCase)
+		if source == nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Extraction '%s' of test case %q has no source", extraction.Name, testCase.Name), 0)
+		}
+
+		mu.Lock()
+		sourceResult := result.byTestCase[source]
+		mu.Unlock()
+		if sourceResult == nil || sourceResult.Response == nil {
+			return nil, api.NewAPIError(fmt.Sprintf("No response of %q to extract '%s' from", source.Name, extraction.Name), 0)
+		}
+
+		value, err := extraction.ExtractResponse(sourceResult.Response)
+		if err != nil {
+			return nil, err
+		}
+		variables[extraction.Name] = value
+	}
+	return variables, nil
+}
+
+// BuildRequest builds the ffuf request for a test case, substituting path parameters,
+// appending query parameters and applying headers and authentication.
+func (e *Executor) BuildRequest(testCase *parser.APITestCase) (*ffuf.Request, error) {
+	target := testCase.URL
+	if target == "" {
+		target = testCase.Path
+	}
+	for name, value := range testCase.PathParams {
+		target = strings.ReplaceAll(target, "{"+name+"}", url.PathEscape(value))
+	}
+
+	parsedURL, err := url.Parse(target)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Invalid URL for test case %q: %s", testCase.Name, err.Error()), 0)
+	}
+	if !parsedURL.IsAbs() {
+		return nil, api.NewAPIError(fmt.Sprintf("Test case %q has no absolute URL", testCase.Name), 0)
+	}
+	if len(testCase.QueryParams) > 0 {
+		query := parsedURL.Query()
+		for name, value := range testCase.QueryParams {
+			query.Set(name, value)
+		}
+		parsedURL.RawQuery = query.Encode()
+	}
+
+	req := &ffuf.Request{
+		Method:  testCase.Method,
+		Url:     parsedURL.String(),
+		Headers: make(map[string]string),
+		Data:    []byte(testCase.Body),
+	}
+	for key, value := range e.Options.Headers {
+		req.Headers[key] = e.Options.Variables.Resolve(value)
+	}
+	for key, value := range testCase.Headers {
+		req.Headers[key] = value
+	}
+
+	if testCase.RequiresAuth && testCase.Auth != nil {
+		switch testCase.Auth.Type {
+		case "basic":
+			credentials := base64.StdEncoding.EncodeToString([]byte(testCase.Auth.Username + ":" + testCase.Auth.Password))
+			req.Headers["Authorization"] = "Basic " + credentials
+		case "bearer", "oauth":
+			if testCase.Auth.Token != "" {
+				req.Headers["Authorization"] = "Bearer " + testCase.Auth.Token
+			}
+		}
+	}
+
+	return req, nil
+}
+
+// Evaluate compares a response against the expectations of a test case and returns
+// a description of every expectation that was not met.
+func Evaluate(testCase *parser.APITestCase, resp *ffuf.Response) []string {
+	failures := make([]string, 0)
+
+	if testCase.ExpectedStatus != 0 && resp.StatusCode != int64(testCase.ExpectedStatus) {
+		failures = append(failures, fmt.Sprintf("expected status %d, got %d", testCase.ExpectedStatus, resp.StatusCode))
+	}
+
+	if testCase.ExpectedContentType != "" {
+		expected := mediaType(testCase.ExpectedContentType)
+		actual := mediaType(resp.ContentType)
+		if actual != expected {
+			failures = append(failures, fmt.Sprintf("expected content type %q, got %q", expected, actual))
+		}
+	}
+
+	if testCase.ExpectedResponseBody != "" && !strings.Contains(string(resp.Data), testCase.ExpectedResponseBody) {
+		failures = append(failures, fmt.Sprintf("expected response body to contain %q", testCase.ExpectedResponseBody))
+	}
+
+	if !testCase.MatchResponse(resp) {
+		failures = append(failures, "response did not match the template matchers")
+	}
+
+	for _, assertion := range testCase.Assertions {
+		if failure := assertion.Check(resp); failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+
+	return failures
+}
+
+// mediaType returns the lowercase media type of a content type, without parameters
+func mediaType(contentType string) string {
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// orderTestCases returns the test cases with their transitive dependencies in dependency
+// order, keeping the input order where possible
+func orderTestCases(testCases []*parser.APITestCase) ([]*parser.APITestCase, error) {
+	order := make([]*parser.APITestCase, 0, len(testCases))
+	state := make(map[*parser.APITestCase]int)
+
+	var visit func(testCase *parser.APITestCase, path []string) error
+	visit = func(testCase *parser.APITestCase, path []string) error {
+		switch state[testCase] {
+		case 1:
+			return api.NewAPIError(fmt.Sprintf("Dependency cycle detected: %s", strings.Join(append(path, testCase.Name), " -> ")), 0)
+		case 2:
+			return nil
+		}
+
+		state[testCase] = 1
+		for _, dep := range testCase.Prerequisites() {
+			if err := visit(dep, append(path, testCase.Name)); err != nil {
+				return err
+			}
+		}
+		state[testCase] = 2
+		order = append(order, testCase)
+		return nil
+	}
+
+	for _, testCase := range testCases {
+		if err := visit(testCase, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// TestedParameters returns the names of the parameters exercised by a test case
+func TestedParameters(testCase *parser.APITestCase) []string {
+	params := make([]string, 0, len(testCase.PathParams)+len(testCase.QueryParams))
+	for name := range testCase.PathParams {
+		params = append(params, name)
+	}
+	for name := range testCase.QueryParams {
+		params = append(params, name)
+	}
+
+	// Top-level fields of JSON bodies
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(testCase.Body), &body); err == nil {
+		for name := range body {
+			params = append(params, name)
+		}
+	}
+	sort.Strings(params)
+	return params
+}
@@ -0,0 +1,480 @@
+package executor
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// recordingRunner is a runner that records the order of executed requests
+type recordingRunner struct {
+	mu       sync.Mutex
+	urls     []string
+	response func(req *ffuf.Request) ffuf.Response
+}
+
+func (r *recordingRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return ffuf.CopyRequest(basereq), nil
+}
+
+func (r *recordingRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	r.mu.Lock()
+	r.urls = append(r.urls, req.Url)
+	r.mu.Unlock()
+	if r.response != nil {
+		return r.response(req), nil
+	}
+	return ffuf.Response{StatusCode: 200, ContentType: "application/json"}, nil
+}
+
+func (r *recordingRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func TestExecutor_Execute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/42" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"id": 42, "verbose": "` + r.URL.Query().Get("verbose") + `"}`))
+	}))
+	defer server.Close()
+
+	config := &ffuf.Config{
+		Context: context.Background(),
+		Timeout: 10,
+	}
+	auth := &parser.APITestAuth{Type: "bearer", Token: "secret"}
+	testCases := []*parser.APITestCase{
+		{
+			Name:                 "get user",
+			Method:               "GET",
+			URL:                  server.URL + "/users/{id}",
+			Path:                 "/users/{id}",
+			PathParams:           map[string]string{"id": "42"},
+			QueryParams:          map[string]string{"verbose": "yes"},
+			ExpectedStatus:       200,
+			ExpectedContentType:  "application/json",
+			ExpectedResponseBody: `"verbose": "yes"`,
+			RequiresAuth:         true,
+			Auth:                 auth,
+		},
+		{
+			Name:           "get user without auth",
+			Method:         "GET",
+			URL:            server.URL + "/users/42",
+			Path:           "/users/{id}",
+			ExpectedStatus: 200,
+		},
+		{
+			Name:   "invalid url",
+			Method: "GET",
+			Path:   "/users",
+		},
+	}
+
+	result, err := NewExecutor(config, nil).Execute(context.Background(), testCases)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Passed != 1 || result.Failed != 1 || result.Errors != 1 {
+		t.Fatalf("Expected 1 passed, 1 failed and 1 error, got %d/%d/%d", result.Passed, result.Failed, result.Errors)
+	}
+
+	failed := result.GetResult(testCases[1])
+	if len(failed.Failures) != 1 || !strings.Contains(failed.Failures[0], "got 401") {
+		t.Errorf("Unexpected failures %v", failed.Failures)
+	}
+}
+
+func TestExecutor_Dependencies(t *testing.T) {
+	create := &parser.APITestCase{Name: "create", Method: "POST", URL: "http://api.example.com/create", ExpectedStatus: 201}
+	read := &parser.APITestCase{Name: "read", Method: "GET", URL: "http://api.example.com/read", Dependencies: []*parser.APITestCase{create}}
+	update := &parser.APITestCase{Name: "update", Method: "PUT", URL: "http://api.example.com/update", Dependencies: []*parser.APITestCase{read}}
+
+	r := &recordingRunner{response: func(req *ffuf.Request) ffuf.Response {
+		if req.Method == "POST" {
+			return ffuf.Response{StatusCode: 201}
+		}
+		return ffuf.Response{StatusCode: 200}
+	}}
+	result, err := NewExecutorWithRunner(r, &Options{Concurrency: 4, SkipOnDependencyFailure: true}).
+		Execute(context.Background(), []*parser.APITestCase{update})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	got := strings.Join(r.urls, ",")
+	want := "http://api.example.com/create,http://api.example.com/read,http://api.example.com/update"
+	if got != want {
+		t.Errorf("Expected execution order %s, got %s", want, got)
+	}
+	if result.Passed != 3 {
+		t.Errorf("Expected 3 passed test cases, got %d", result.Passed)
+	}
+
+	// A failing dependency skips its dependents
+	create.ExpectedStatus = 204
+	result, err = NewExecutorWithRunner(&recordingRunner{}, nil).Execute(context.Background(), []*parser.APITestCase{create, read, update})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Failed != 1 || result.Skipped != 2 {
+		t.Errorf("Expected 1 failed and 2 skipped test cases, got %d/%d", result.Failed, result.Skipped)
+	}
+
+	// Cycles are rejected
+	create.Dependencies = []*parser.APITestCase{update}
+	if _, err := NewExecutorWithRunner(&recordingRunner{}, nil).Execute(context.Background(), []*parser.APITestCase{create}); err == nil {
+		t.Error("Expected error for dependency cycle")
+	}
+}
+
+func TestExecutor_Chain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/users":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 7, "session": "s3cr3t"}`))
+		case r.URL.Path == "/users/7" && r.Header.Get("X-Session") == "s3cr3t":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	create := &parser.APITestCase{Name: "create", Method: "POST", URL: server.URL + "/users", ExpectedStatus: 201}
+	read := &parser.APITestCase{
+		Name:           "read",
+		Method:         "GET",
+		URL:            server.URL + "/users/{id}",
+		PathParams:     map[string]string{"id": "${id}"},
+		Headers:        map[string]string{"X-Session": "${session}"},
+		ExpectedStatus: 200,
+		Extractions: []*parser.APITestExtraction{
+			{Name: "id", From: create, JSONPath: "$.id"},
+			{Name: "session", From: create, Regex: `"session": "(\w+)"`},
+		},
+	}
+	missing := &parser.APITestCase{
+		Name:         "missing",
+		Method:       "GET",
+		URL:          server.URL + "/users/${uuid}",
+		Dependencies: []*parser.APITestCase{create},
+		Extractions:  []*parser.APITestExtraction{{Name: "uuid", JSONPath: "$.uuid"}},
+	}
+
+	config := &ffuf.Config{Context: context.Background(), Timeout: 10}
+	result, err := NewExecutor(config, nil).Execute(context.Background(), []*parser.APITestCase{read, missing})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	readResult := result.GetResult(read)
+	if !readResult.Passed() {
+		t.Errorf("Expected chained read to pass, got %s: %v %v", readResult.Status, readResult.Failures, readResult.Error)
+	}
+	if readResult.Variables["id"] != "7" || readResult.Variables["session"] != "s3cr3t" {
+		t.Errorf("Unexpected extracted variables %v", readResult.Variables)
+	}
+	if result.GetResult(missing).Status != StatusError {
+		t.Errorf("Expected error for failed extraction, got %s", result.GetResult(missing).Status)
+	}
+}
+
+func TestExecutor_Variables(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]*ffuf.Request)
+	runner := &recordingRunner{response: func(req *ffuf.Request) ffuf.Response {
+		mu.Lock()
+		requests[req.Url] = req
+		mu.Unlock()
+		return ffuf.Response{StatusCode: 201, ContentType: "application/json", Data: []byte(`{"id": 7}`)}
+	}}
+
+	variables := parser.NewVariables()
+	variables.Set("base_url", "https://{{env.API_HOST}}/v1")
+	variables.Set("token", "secret")
+	variables.LookupEnv = func(name string) (string, bool) {
+		values := map[string]string{"API_HOST": "api.example.com", "USER_ID": "42"}
+		value, ok := values[name]
+		return value, ok
+	}
+	options := DefaultOptions()
+	options.Variables = variables
+	options.Headers["X-User"] = "{{env.USER_ID}}"
+
+	create := &parser.APITestCase{
+		Name:         "create",
+		Method:       "POST",
+		URL:          "{{base_url}}/users",
+		Body:         `{"owner": "{{env.USER_ID}}"}`,
+		RequiresAuth: true,
+		Auth:         &parser.APITestAuth{Type: "bearer", Token: "{{token}}"},
+	}
+	read := &parser.APITestCase{
+		Name:        "read",
+		Method:      "GET",
+		URL:         "{{base_url}}/users/{{id}}",
+		Extractions: []*parser.APITestExtraction{{Name: "id", From: create, JSONPath: "$.id"}},
+	}
+	if _, err := NewExecutorWithRunner(runner, options).Execute(context.Background(), []*parser.APITestCase{read}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	req := requests["https://api.example.com/v1/users"]
+	if req == nil {
+		t.Fatalf("Expected the URL to be resolved, got %v", runner.urls)
+	}
+	if req.Headers["Authorization"] != "Bearer secret" || req.Headers["X-User"] != "42" || string(req.Data) != `{"owner": "42"}` {
+		t.Errorf("Unexpected resolved request %v %s", req.Headers, req.Data)
+	}
+	if requests["https://api.example.com/v1/users/7"] == nil {
+		t.Errorf("Expected the extracted value to be available as a variable, got %v", runner.urls)
+	}
+}
+
+func TestExecutionResult_Sequence(t *testing.T) {
+	runner := &recordingRunner{response: func(req *ffuf.Request) ffuf.Response {
+		if req.Method == "POST" {
+			return ffuf.Response{StatusCode: 201, Data: []byte(`{"id": 7}`)}
+		}
+		return ffuf.Response{StatusCode: 404}
+	}}
+	create := &parser.APITestCase{Name: "create", Method: "POST", URL: "https://api.example.com/users", ExpectedStatus: 201}
+	read := &parser.APITestCase{
+		Name:           "read",
+		Method:         "GET",
+		URL:            "https://api.example.com/users/${id}",
+		ExpectedStatus: 200,
+		Extractions:    []*parser.APITestExtraction{{Name: "id", From: create, JSONPath: "$.id"}},
+	}
+	remove := &parser.APITestCase{Name: "delete", Method: "DELETE", URL: "https://api.example.com/users/${id}", Dependencies: []*parser.APITestCase{read}}
+	other := &parser.APITestCase{Name: "other", Method: "GET", URL: "https://api.example.com/health"}
+
+	result, err := NewExecutorWithRunner(runner, nil).Execute(context.Background(), []*parser.APITestCase{remove, create, read, other})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(result.Sequence()) != 4 {
+		t.Errorf("Expected a step per test case")
+	}
+
+	steps := result.Sequence(create, read, remove)
+	if len(steps) != 3 || steps[0].Name != "create" || steps[1].Name != "read" || steps[2].Name != "delete" {
+		t.Fatalf("Expected the steps of the chain in execution order, got %v", steps)
+	}
+	if steps[0].StatusCode != 201 || steps[0].Extracted["id"] != "7" {
+		t.Errorf("Expected the extracted id on the create step, got %+v", steps[0])
+	}
+	if steps[1].URL != "https://api.example.com/users/7" || steps[1].Variables["id"] != "7" || steps[1].Outcome != "failed" || !strings.Contains(steps[1].Message, "404") {
+		t.Errorf("Expected the failed read step, got %+v", steps[1])
+	}
+	if steps[2].Outcome != "skipped" || steps[2].StatusCode != 0 || steps[2].URL != remove.URL {
+		t.Errorf("Expected the skipped delete step, got %+v", steps[2])
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	testCase := &parser.APITestCase{
+		ExpectedStatus:       200,
+		ExpectedContentType:  "Application/JSON",
+		ExpectedResponseBody: "ok",
+	}
+
+	resp := &ffuf.Response{StatusCode: 200, ContentType: "application/json; charset=utf-8", Data: []byte(`{"status":"ok"}`)}
+	if failures := Evaluate(testCase, resp); len(failures) != 0 {
+		t.Errorf("Expected no failures, got %v", failures)
+	}
+
+	resp = &ffuf.Response{StatusCode: 500, ContentType: "text/html", Data: []byte("error")}
+	if failures := Evaluate(testCase, resp); len(failures) != 3 {
+		t.Errorf("Expected 3 failures, got %v", failures)
+	}
+}
+
+func TestExecutor_Idempotency(t *testing.T) {
+	template, err := parser.ParseTestCaseTemplate([]byte("id: idempotency\nmode: idempotency\n"), "yaml")
+	if err != nil {
+		t.Fatalf("ParseTestCaseTemplate() error = %v", err)
+	}
+	endpoint := &parser.DiscoveredEndpoint{Method: "POST", Path: "/orders"}
+	params := []*parser.ExtractedParameter{{Name: "item", In: "body", Type: "string", Example: "book"}}
+
+	for _, honorsKey := range []bool{true, false} {
+		var mu sync.Mutex
+		created := 0
+		keys := make(map[string]string)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			body, _ := ioutil.ReadAll(r.Body)
+			key := r.Header.Get(parser.IdempotencyKeyHeader)
+			if stored, ok := keys[key]; ok && honorsKey {
+				if stored != string(body) {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id": 1}`))
+				return
+			}
+			keys[key] = string(body)
+			created++
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": ` + string(rune('0'+created)) + `}`))
+		}))
+
+		testCases := template.Generator(endpoint, params)
+		for _, testCase := range testCases {
+			testCase.URL = server.URL + testCase.Path
+		}
+		if len(testCases) != 3 {
+			t.Fatalf("Expected 3 chained test cases, got %d", len(testCases))
+		}
+		config := &ffuf.Config{Context: context.Background(), Timeout: 10}
+		options := DefaultOptions()
+		options.Concurrency = 1
+		result, err := NewExecutor(config, options).Execute(context.Background(), testCases)
+		server.Close()
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		replay := result.GetResult(testCases[1])
+		conflict := result.GetResult(testCases[2])
+		if honorsKey && (!replay.Passed() || !conflict.Passed()) {
+			t.Errorf("Expected the chain to pass, got %s %v and %s %v", replay.Status, replay.Failures, conflict.Status, conflict.Failures)
+		}
+		if !honorsKey && (replay.Status != StatusFailed || !strings.Contains(strings.Join(replay.Failures, ";"), `expected 'id' to be "1"`)) {
+			t.Errorf("Expected the replay creating a duplicate to fail, got %s %v", replay.Status, replay.Failures)
+		}
+	}
+}
+
+func TestExecutor_CORSPreflight(t *testing.T) {
+	template, err := parser.ParseTestCaseTemplate([]byte("id: cors\nmode: cors\ncors:\n  allowed-origins: [https://app.example.com]\n  allowed-methods: [GET, PUT]\n"), "yaml")
+	if err != nil {
+		t.Fatalf("ParseTestCaseTemplate() error = %v", err)
+	}
+	endpoint := &parser.DiscoveredEndpoint{Method: "PUT", Path: "/items"}
+
+	for _, reflects := range []bool{false, true} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "https://app.example.com" || reflects {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, PUT")
+			}
+			if reflects {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		testCases := template.Generator(endpoint, nil)
+		for _, testCase := range testCases {
+			testCase.URL = server.URL + testCase.Path
+		}
+		config := &ffuf.Config{Context: context.Background(), Timeout: 10}
+		result, err := NewExecutor(config, DefaultOptions()).Execute(context.Background(), testCases)
+		server.Close()
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		for _, testCase := range testCases {
+			testResult := result.GetResult(testCase)
+			// The reflecting server allows any origin with undeclared credentials
+			shouldFail := reflects && !strings.Contains(testCase.Name, "undeclared")
+			if shouldFail && testResult.Passed() {
+				t.Errorf("Expected %q to fail against a server reflecting origins", testCase.Name)
+			}
+			if !shouldFail && !testResult.Passed() {
+				t.Errorf("Expected %q to pass, got %s %v", testCase.Name, testResult.Status, testResult.Failures)
+			}
+		}
+	}
+}
+
+func TestExecutor_CachePoisoning(t *testing.T) {
+	template, err := parser.ParseTestCaseTemplate([]byte("id: cache\nmode: cache\nunkeyed-headers: [X-Forwarded-Host]\n"), "yaml")
+	if err != nil {
+		t.Fatalf("ParseTestCaseTemplate() error = %v", err)
+	}
+	endpoint := &parser.DiscoveredEndpoint{Method: "GET", Path: "/items"}
+
+	tests := []struct {
+		keysQuery    bool
+		usesHeader   bool
+		failingSteps string
+	}{
+		{true, false, ""},
+		{false, false, "with another ffufcb parameter"},
+		{true, true, "after a request with X-Forwarded-Host"},
+	}
+	for _, tt := range tests {
+		// A cache keyed by the path, and the query string if keysQuery, of responses built
+		// from the forwarded host if usesHeader
+		var mu sync.Mutex
+		cache := make(map[string]string)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			key := r.URL.Path
+			if tt.keysQuery {
+				key = r.URL.RequestURI()
+			}
+			if body, ok := cache[key]; ok {
+				w.Header().Set("X-Cache", "HIT")
+				w.Write([]byte(body))
+				return
+			}
+			host := r.Host
+			if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" && tt.usesHeader {
+				host = forwarded
+			}
+			cache[key] = `{"next": "https://` + host + `/items?page=2"}`
+			w.Header().Set("X-Cache", "MISS")
+			w.Write([]byte(cache[key]))
+		}))
+
+		testCases := template.Generator(endpoint, nil)
+		for _, testCase := range testCases {
+			testCase.URL = server.URL + testCase.Path
+		}
+		config := &ffuf.Config{Context: context.Background(), Timeout: 10}
+		options := DefaultOptions()
+		options.Concurrency = 1
+		result, err := NewExecutor(config, options).Execute(context.Background(), testCases)
+		server.Close()
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+
+		for _, testCase := range testCases {
+			testResult := result.GetResult(testCase)
+			shouldFail := tt.failingSteps != "" && strings.HasSuffix(testCase.Name, tt.failingSteps)
+			if shouldFail && testResult.Passed() {
+				t.Errorf("Expected %q to fail", testCase.Name)
+			}
+			if !shouldFail && !testResult.Passed() {
+				t.Errorf("Expected %q to pass, got %s %v", testCase.Name, testResult.Status, testResult.Failures)
+			}
+		}
+	}
+}
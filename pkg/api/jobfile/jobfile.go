@@ -0,0 +1,694 @@
+// Package jobfile loads declarative scan jobs: YAML files declaring the targets, API
+// specifications, authentication, security testers, payloads, rate limits and reports of an
+// engagement, so that it can be run again with ffuf api run instead of long flag strings.
+package jobfile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+	"github.com/ffuf/ffuf/v2/pkg/api/reporting"
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"gopkg.in/yaml.v3"
+)
+
+// Job is a scan job. The fuzzing stage runs if Fuzz is set, then the security stage if
+// Security is set.
+type Job struct {
+	// Name of the job, used as the target of the reports
+	Name string `yaml:"name"`
+	// VarsFile is a vars file of the variables of the {{name}} placeholders of the job
+	VarsFile string `yaml:"vars_file"`
+	// Vars are variables taking precedence over the vars file
+	Vars map[string]string `yaml:"vars"`
+	// Targets are the base URLs of the scanned APIs
+	Targets []string `yaml:"targets"`
+	// Specs are OpenAPI specifications, Postman collections, HAR files or proxy exports of
+	// the endpoints scanned by the security stage
+	Specs   []string          `yaml:"specs"`
+	Headers map[string]string `yaml:"headers"`
+	Proxy   string            `yaml:"proxy"`
+	// ProxyList is a file of proxy URLs, one per line, rotated per request in place of proxy
+	ProxyList string `yaml:"proxy_list"`
+	// Resolve pins hosts to addresses in the form host:port:address, as curl --resolve
+	Resolve  []string  `yaml:"resolve"`
+	Auth     Auth      `yaml:"auth"`
+	Payload  Payload   `yaml:"payload"`
+	Rate     Rate      `yaml:"rate"`
+	Policy   string    `yaml:"policy"`
+	SafeMode string    `yaml:"safe_mode"`
+	Fuzz     *Fuzz     `yaml:"fuzz"`
+	Security *Security `yaml:"security"`
+	Reports  []Report  `yaml:"reports"`
+	Redact   Redact    `yaml:"redact"`
+	Log      Log       `yaml:"log"`
+}
+
+// Auth configures the authentication of the requests of the job
+type Auth struct {
+	// Type is bearer, basic or apikey, inferred from the credentials if empty
+	Type           string `yaml:"type"`
+	Username       string `yaml:"username"`
+	Password       string `yaml:"password"`
+	Token          string `yaml:"token"`
+	APIKey         string `yaml:"api_key"`
+	APIKeyName     string `yaml:"api_key_name"`
+	APIKeyLocation string `yaml:"api_key_location"`
+	Login          *Login `yaml:"login"`
+	// Sign is a signing config file, as set with -api-sign
+	Sign string `yaml:"sign"`
+}
+
+// Login configures the login of the job, as set with the -api-login options
+type Login struct {
+	URL       string `yaml:"url"`
+	Type      string `yaml:"type"`
+	Data      string `yaml:"data"`
+	Client    string `yaml:"client"`
+	Scope     string `yaml:"scope"`
+	TokenPath string `yaml:"token_path"`
+	Header    string `yaml:"header"`
+}
+
+// Payload configures the request bodies of the job, as set with the -api-payload options
+type Payload struct {
+	Format   string `yaml:"format"`
+	Template string `yaml:"template"`
+	Path     string `yaml:"path"`
+}
+
+// Redact configures the redaction of the tokens and personal data of the reports, evidence
+// bundles, baseline and logs of the job, as set with the -redact options
+type Redact struct {
+	// Rules are redaction rules, such as authorization and emails, or all
+	Rules []string `yaml:"rules"`
+	// Patterns are regular expressions of the masked data
+	Patterns []string `yaml:"patterns"`
+}
+
+// Log configures the log records of the job, as set with the -log options
+type Log struct {
+	// Level is debug, info, warn or error
+	Level string `yaml:"level"`
+	// Format is text or json
+	Format string `yaml:"format"`
+	// File is a file the log records are appended to, in addition to stderr
+	File string `yaml:"file"`
+}
+
+// Rate configures the concurrency and rate limits of the job
+type Rate struct {
+	Threads           int    `yaml:"threads"`
+	RequestsPerSecond int    `yaml:"requests_per_second"`
+	Delay             string `yaml:"delay"`
+	Timeout           int    `yaml:"timeout"`
+	Adaptive          bool   `yaml:"adaptive"`
+	BackoffMax        int    `yaml:"backoff_max"`
+	MaxTime           int    `yaml:"max_time"`
+	TargetsParallel   int    `yaml:"targets_parallel"`
+}
+
+// Fuzz configures the fuzzing stage of the job, run against every target
+type Fuzz struct {
+	// Path is the path and query fuzzed on the targets, e.g. /api/FUZZ
+	Path            string   `yaml:"path"`
+	Method          string   `yaml:"method"`
+	Data            string   `yaml:"data"`
+	Wordlists       []string `yaml:"wordlists"`
+	MatchStatus     string   `yaml:"match_status"`
+	FilterStatus    string   `yaml:"filter_status"`
+	FilterSize      string   `yaml:"filter_size"`
+	AutoCalibration bool     `yaml:"autocalibration"`
+	// Output is the results file of each target, named after the target
+	Output       string `yaml:"output"`
+	OutputFormat string `yaml:"output_format"`
+}
+
+// Security configures the security stage of the job, run against the endpoints of the specs
+// on every target, or against the targets themselves
+type Security struct {
+	Profile        string            `yaml:"profile"`
+	Include        []string          `yaml:"include"`
+	Exclude        []string          `yaml:"exclude"`
+	Options        map[string]string `yaml:"options"`
+	Scoring        string            `yaml:"scoring"`
+	Baseline       string            `yaml:"baseline"`
+	UpdateBaseline bool              `yaml:"update_baseline"`
+	FailOnNew      bool              `yaml:"fail_on_new"`
+	Evidence       string            `yaml:"evidence"`
+	Mermaid        string            `yaml:"mermaid"`
+	// MaxDuration is the maximum running time of the security stage, e.g. 30m
+	MaxDuration string `yaml:"max_duration"`
+	// Budgets are the maximum running times of the testers against each endpoint, by
+	// vulnerability type, or default for the testers without a budget
+	Budgets map[string]string `yaml:"budgets"`
+	// Payloads are the payload files, directories and feeds of the injection tester
+	Payloads []string `yaml:"payloads"`
+	// PayloadCategories are the payload categories sent by the injection tester, all if empty
+	PayloadCategories []string `yaml:"payload_categories"`
+	// PayloadsReplace replaces the built-in payloads of the categories of the payloads
+	PayloadsReplace bool `yaml:"payloads_replace"`
+	// Tamper are the tampers transforming the payloads of the injection tester, in order
+	Tamper []string `yaml:"tamper"`
+	// WAF fingerprints the web application firewall in front of the targets before the scan
+	WAF bool `yaml:"waf"`
+	// Fingerprint fingerprints the technology stack of the targets before the scan
+	Fingerprint bool `yaml:"fingerprint"`
+	// Headers are headers "Name: value" added to the requests of the testers, by vulnerability
+	// type, or default for every tester. An empty value removes the header.
+	Headers map[string][]string `yaml:"headers"`
+	// Proxies are the proxy URLs of the requests of the testers, by vulnerability type
+	Proxies map[string]string `yaml:"proxies"`
+	// Identities are the two users of the BOLA tester in the form name:id1,id2=Name: value, a
+	// header authenticating the user and the identifiers of the objects it owns
+	Identities []string `yaml:"identities"`
+	// LoginAttempts is the maximum number of login attempts of the testers to each login
+	// endpoint, 0 for no limit. Default: 10
+	LoginAttempts *int `yaml:"login_attempts"`
+	// AccountSafe stops the credential testing of a host after its first lockout indicator
+	AccountSafe bool `yaml:"account_safe"`
+	// OOBListen is the address of the HTTP listener receiving the out-of-band interactions of
+	// the payloads, and OOBURL the public URL the targets reach it at
+	OOBListen string `yaml:"oob_listen"`
+	OOBURL    string `yaml:"oob_url"`
+	// OOBDNSListen is the address of the DNS listener receiving the out-of-band lookups of the
+	// payloads, and OOBDomain the domain delegated to it
+	OOBDNSListen string `yaml:"oob_dns_listen"`
+	OOBDomain    string `yaml:"oob_domain"`
+	// State is the file recording the completed requests of the testers, and Resume replays
+	// the requests it recorded in a previous run of the job
+	State  string `yaml:"state"`
+	Resume bool   `yaml:"resume"`
+}
+
+// Duration returns the maximum running time of the security stage, 0 for no limit
+func (s *Security) Duration() time.Duration {
+	duration, _ := time.ParseDuration(s.MaxDuration)
+	return duration
+}
+
+// BudgetOptions returns the budgets of the testers as -api-security-budget options
+func (s *Security) BudgetOptions() []string {
+	names := make([]string, 0, len(s.Budgets))
+	for name := range s.Budgets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	budgets := make([]string, 0, len(names))
+	for _, name := range names {
+		budgets = append(budgets, name+"="+s.Budgets[name])
+	}
+	return budgets
+}
+
+// HeaderOptions returns the headers of the testers in the form type=Name: value, or Name: value
+// for the default headers
+func (s *Security) HeaderOptions() []string {
+	names := make([]string, 0, len(s.Headers))
+	for name := range s.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := make([]string, 0)
+	for _, name := range names {
+		for _, header := range s.Headers[name] {
+			if name == "default" {
+				headers = append(headers, header)
+			} else {
+				headers = append(headers, name+"="+header)
+			}
+		}
+	}
+	return headers
+}
+
+// ProxyOptions returns the proxies of the testers in the form type=URL
+func (s *Security) ProxyOptions() []string {
+	names := make([]string, 0, len(s.Proxies))
+	for name := range s.Proxies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	proxies := make([]string, 0, len(names))
+	for _, name := range names {
+		proxies = append(proxies, name+"="+s.Proxies[name])
+	}
+	return proxies
+}
+
+// Report is a vulnerability report written by the security stage
+type Report struct {
+	File string `yaml:"file"`
+	// Format of the report, inferred from the extension of the file if empty
+	Format string `yaml:"format"`
+}
+
+// reportExtensions maps the extensions of report files to their format
+var reportExtensions = map[string]reporting.CoverageFormat{
+	".json":  reporting.FormatJSON,
+	".sarif": reporting.FormatSARIF,
+	".html":  reporting.FormatHTML,
+	".md":    reporting.FormatMarkdown,
+	".csv":   reporting.FormatCSV,
+	".xlsx":  reporting.FormatXLSX,
+	".xml":   reporting.FormatBurp,
+	".har":   reporting.FormatHAR,
+}
+
+// Load loads a job file. The {{name}} placeholders of the file are replaced with its
+// variables and environment variables, relative paths are resolved against the directory of
+// the file, and the job is validated.
+func Load(filePath string) (*Job, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to read job file: %s", err.Error()), 0)
+	}
+	dir := filepath.Dir(filePath)
+
+	var declared struct {
+		VarsFile string            `yaml:"vars_file"`
+		Vars     map[string]string `yaml:"vars"`
+	}
+	if err := yaml.Unmarshal(data, &declared); err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to parse job file %s: %s", filePath, err.Error()), 0)
+	}
+	variables := parser.NewVariables()
+	if declared.VarsFile != "" {
+		if variables, err = parser.LoadVariables(resolvePath(dir, declared.VarsFile)); err != nil {
+			return nil, err
+		}
+	}
+	resolved := variables.With(declared.Vars).Resolve(string(data))
+
+	job := &Job{}
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(resolved)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(job); err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to parse job file %s: %s", filePath, err.Error()), 0)
+	}
+	job.resolvePaths(dir)
+	job.Normalize()
+	if err := job.Validate(); err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Invalid job file %s: %s", filePath, err.Error()), 0)
+	}
+	return job, nil
+}
+
+// resolvePath resolves a path relative to the directory of the job file
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) || path == "-" {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// resolvePaths resolves the relative paths of the job against the directory of the job file
+func (j *Job) resolvePaths(dir string) {
+	j.VarsFile = resolvePath(dir, j.VarsFile)
+	for i, spec := range j.Specs {
+		if !strings.HasPrefix(spec, "http://") && !strings.HasPrefix(spec, "https://") {
+			j.Specs[i] = resolvePath(dir, spec)
+		}
+	}
+	j.Auth.Sign = resolvePath(dir, j.Auth.Sign)
+	j.Policy = resolvePath(dir, j.Policy)
+	j.ProxyList = resolvePath(dir, j.ProxyList)
+	if j.Fuzz != nil {
+		for i, wordlist := range j.Fuzz.Wordlists {
+			parts := strings.SplitN(wordlist, ":", 2)
+			parts[0] = resolvePath(dir, parts[0])
+			j.Fuzz.Wordlists[i] = strings.Join(parts, ":")
+		}
+		j.Fuzz.Output = resolvePath(dir, j.Fuzz.Output)
+	}
+	if j.Security != nil {
+		j.Security.Scoring = resolvePath(dir, j.Security.Scoring)
+		j.Security.Baseline = resolvePath(dir, j.Security.Baseline)
+		j.Security.Evidence = resolvePath(dir, j.Security.Evidence)
+		j.Security.State = resolvePath(dir, j.Security.State)
+		j.Security.Mermaid = resolvePath(dir, j.Security.Mermaid)
+		for i, source := range j.Security.Payloads {
+			if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+				j.Security.Payloads[i] = resolvePath(dir, source)
+			}
+		}
+		for i, tamper := range j.Security.Tamper {
+			if !payload.IsBuiltinTamper(tamper) {
+				j.Security.Tamper[i] = resolvePath(dir, tamper)
+			}
+		}
+	}
+	for i := range j.Reports {
+		j.Reports[i].File = resolvePath(dir, j.Reports[i].File)
+	}
+	j.Log.File = resolvePath(dir, j.Log.File)
+}
+
+// Normalize adds the https scheme to the targets without one, and removes their trailing slash
+func (j *Job) Normalize() {
+	for i, target := range j.Targets {
+		j.Targets[i] = normalizeTarget(target)
+	}
+}
+
+// normalizeTarget adds the https scheme to a target without one, and removes its trailing slash
+func normalizeTarget(target string) string {
+	target = strings.TrimSpace(target)
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+	return strings.TrimSuffix(target, "/")
+}
+
+// Validate checks that the job declares something to scan and that its settings are valid
+func (j *Job) Validate() error {
+	if j.Fuzz == nil && j.Security == nil {
+		return fmt.Errorf("a fuzz or security stage is required")
+	}
+	if len(j.Targets) == 0 && len(j.Specs) == 0 {
+		return fmt.Errorf("targets or specs are required")
+	}
+	for _, target := range j.Targets {
+		if u, err := url.Parse(target); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid target %s", target)
+		}
+	}
+	switch j.authType() {
+	case "", "bearer", "basic", "apikey":
+	default:
+		return fmt.Errorf("auth type must be bearer, basic or apikey, got %s", j.Auth.Type)
+	}
+	switch j.Auth.APIKeyLocation {
+	case "", "header", "query":
+	default:
+		return fmt.Errorf("auth api_key_location must be header or query, got %s", j.Auth.APIKeyLocation)
+	}
+	if j.Policy != "" {
+		if _, err := ffuf.LoadPolicy(j.Policy); err != nil {
+			return err
+		}
+	}
+	if j.SafeMode != "" {
+		if _, err := ffuf.NewSafeMode(j.SafeMode); err != nil {
+			return err
+		}
+	}
+	if _, err := secrets.NewRedactor(j.Redact.Rules, j.Redact.Patterns); err != nil {
+		return err
+	}
+	if err := (logging.Options{Level: j.Log.Level, Format: j.Log.Format}).Validate(); err != nil {
+		return err
+	}
+	if j.Fuzz != nil && len(j.Fuzz.Wordlists) == 0 {
+		return fmt.Errorf("the fuzz stage requires wordlists")
+	}
+	if j.ProxyList != "" {
+		if j.Proxy != "" {
+			return fmt.Errorf("proxy and proxy_list cannot be used together")
+		}
+		if _, err := ffuf.ReadProxyList(j.ProxyList); err != nil {
+			return err
+		}
+	}
+	if _, err := ffuf.ParseResolve(j.Resolve); err != nil {
+		return err
+	}
+	if len(j.Resolve) > 0 && (j.Proxy != "" || j.ProxyList != "") {
+		return fmt.Errorf("resolve cannot be used with proxy or proxy_list, the proxy resolves the hosts")
+	}
+	if j.Security != nil {
+		if _, err := security.DefaultRegistry.Select(j.Security.Profile, j.Security.Include, j.Security.Exclude); err != nil {
+			return err
+		}
+		if j.Security.MaxDuration != "" {
+			if duration, err := time.ParseDuration(j.Security.MaxDuration); err != nil || duration <= 0 {
+				return fmt.Errorf("invalid max_duration %s, expected a duration such as 30m", j.Security.MaxDuration)
+			}
+		}
+		if _, err := security.ParseTesterBudgets(j.Security.BudgetOptions()); err != nil {
+			return err
+		}
+		if _, err := security.ParseRequestDecorations(&ffuf.Config{APISecurityHeaders: j.Security.HeaderOptions(), APISecurityProxies: j.Security.ProxyOptions()}); err != nil {
+			return err
+		}
+		if _, err := security.NewPayloadSelection(nil, j.Security.PayloadCategories, false); err != nil {
+			return err
+		}
+		if _, err := security.ParseBOLAIdentities(j.Security.Identities); err != nil {
+			return err
+		}
+		if len(j.Security.Identities) > 0 && j.Auth.Login != nil {
+			return fmt.Errorf("identities cannot be used with auth.login, the login session replaces the headers of the identities")
+		}
+		if j.Security.LoginAttempts != nil && *j.Security.LoginAttempts < 0 {
+			return fmt.Errorf("invalid login_attempts %d, expected 0 or more", *j.Security.LoginAttempts)
+		}
+		if j.Security.OOBURL != "" {
+			if u, err := url.Parse(j.Security.OOBURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid oob_url %s, expected an http or https URL", j.Security.OOBURL)
+			}
+		}
+		if j.Security.OOBListen != "" && j.Security.OOBURL == "" {
+			return fmt.Errorf("oob_listen requires oob_url, the public URL the targets reach the callback listener at")
+		}
+		if j.Security.OOBDNSListen != "" && j.Security.OOBDomain == "" {
+			return fmt.Errorf("oob_dns_listen requires oob_domain, the domain delegated to the DNS listener")
+		}
+		if (j.Security.OOBURL != "" || j.Security.OOBDomain != "") && j.Security.OOBListen == "" && j.Security.OOBDNSListen == "" {
+			return fmt.Errorf("oob_url and oob_domain require a callback listener set with oob_listen or oob_dns_listen")
+		}
+		if j.Security.Resume && j.Security.State == "" {
+			return fmt.Errorf("resume requires a state file")
+		}
+	}
+	if j.Security != nil && (j.Security.UpdateBaseline || j.Security.FailOnNew) && j.Security.Baseline == "" {
+		return fmt.Errorf("update_baseline and fail_on_new require a baseline")
+	}
+	if len(j.Reports) > 0 && j.Security == nil {
+		return fmt.Errorf("reports require a security stage")
+	}
+	for _, report := range j.Reports {
+		if report.File == "" {
+			return fmt.Errorf("reports require a file")
+		}
+		if _, err := report.ReportFormat(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReportFormat returns the format of the report, inferred from its extension if not set
+func (r Report) ReportFormat() (reporting.CoverageFormat, error) {
+	if r.Format != "" {
+		for _, format := range reportExtensions {
+			if string(format) == r.Format {
+				return format, nil
+			}
+		}
+		return "", fmt.Errorf("unsupported format %s of the report %s", r.Format, r.File)
+	}
+	if format, ok := reportExtensions[strings.ToLower(filepath.Ext(r.File))]; ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("the format of the report %s cannot be inferred from its extension", r.File)
+}
+
+// authType returns the authentication type of the job, inferred from its credentials
+func (j *Job) authType() string {
+	switch {
+	case j.Auth.Type != "":
+		return strings.ToLower(j.Auth.Type)
+	case j.Auth.Token != "":
+		return "bearer"
+	case j.Auth.Username != "":
+		return "basic"
+	case j.Auth.APIKey != "":
+		return "apikey"
+	}
+	return ""
+}
+
+// apiKeyName returns the name of the header or query parameter of the API key
+func (j *Job) apiKeyName() string {
+	if j.Auth.APIKeyName != "" {
+		return j.Auth.APIKeyName
+	}
+	return "X-API-Key"
+}
+
+// URL returns the URL of a path on a target, with the API key of the job if it is sent in
+// the query
+func (j *Job) URL(target, path string) string {
+	u := strings.TrimSuffix(target, "/")
+	if path != "" && !strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "?") {
+		path = "/" + path
+	}
+	u += path
+	if j.authType() == "apikey" && j.Auth.APIKeyLocation == "query" {
+		separator := "?"
+		if strings.Contains(u, "?") {
+			separator = "&"
+		}
+		u += separator + url.QueryEscape(j.apiKeyName()) + "=" + url.QueryEscape(j.Auth.APIKey)
+	}
+	return u
+}
+
+// HeaderLines returns the headers of the job, including its authentication, as sorted
+// "Name: value" lines
+func (j *Job) HeaderLines() []string {
+	headers := make(map[string]string, len(j.Headers)+1)
+	for name, value := range j.Headers {
+		headers[name] = value
+	}
+	switch j.authType() {
+	case "bearer":
+		headers["Authorization"] = "Bearer " + j.Auth.Token
+	case "basic":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(j.Auth.Username+":"+j.Auth.Password))
+	case "apikey":
+		if j.Auth.APIKeyLocation != "query" {
+			headers[j.apiKeyName()] = j.Auth.APIKey
+		}
+	}
+	lines := make([]string, 0, len(headers))
+	for name, value := range headers {
+		lines = append(lines, name+": "+value)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// Options returns the options of the job, as if set on the command line. The URL is the
+// path of the fuzz stage, fuzzed on each target.
+func (j *Job) Options() *ffuf.ConfigOptions {
+	opts := ffuf.NewConfigOptions()
+	opts.General.Noninteractive = true
+	opts.HTTP.Headers = j.HeaderLines()
+	opts.HTTP.ProxyURL = j.Proxy
+	opts.HTTP.ProxyList = j.ProxyList
+	opts.HTTP.Resolve = j.Resolve
+
+	opts.API.AuthType = j.authType()
+	opts.API.AuthUsername = j.Auth.Username
+	opts.API.AuthPassword = j.Auth.Password
+	opts.API.AuthToken = j.Auth.Token
+	opts.API.AuthAPIKey = j.Auth.APIKey
+	opts.API.AuthAPIKeyName = j.Auth.APIKeyName
+	if j.Auth.APIKeyLocation != "" {
+		opts.API.AuthAPIKeyLoc = j.Auth.APIKeyLocation
+	}
+	if login := j.Auth.Login; login != nil {
+		opts.API.LoginURL = login.URL
+		if login.Type != "" {
+			opts.API.LoginType = login.Type
+		}
+		opts.API.LoginData = login.Data
+		opts.API.LoginClient = login.Client
+		opts.API.LoginScope = login.Scope
+		opts.API.LoginTokenPath = login.TokenPath
+		opts.API.LoginHeader = login.Header
+	}
+	opts.API.SigningConfig = j.Auth.Sign
+
+	if j.Payload.Format != "" {
+		opts.API.PayloadFormat = j.Payload.Format
+	}
+	opts.API.PayloadTemplate = j.Payload.Template
+	opts.API.PayloadPath = j.Payload.Path
+
+	if j.Rate.Threads > 0 {
+		opts.General.Threads = j.Rate.Threads
+	}
+	opts.General.Rate = j.Rate.RequestsPerSecond
+	opts.General.Delay = j.Rate.Delay
+	opts.General.MaxTime = j.Rate.MaxTime
+	if j.Rate.Timeout > 0 {
+		opts.HTTP.Timeout = j.Rate.Timeout
+	}
+	opts.API.AdaptiveRate = j.Rate.Adaptive
+	if j.Rate.BackoffMax > 0 {
+		opts.API.BackoffMax = j.Rate.BackoffMax
+	}
+	if j.Rate.TargetsParallel > 0 {
+		opts.API.TargetsParallel = j.Rate.TargetsParallel
+	}
+	opts.API.Policy = j.Policy
+	opts.API.SafeMode = j.SafeMode
+	opts.API.Redact = strings.Join(j.Redact.Rules, ",")
+	opts.API.RedactPatterns = append([]string{}, j.Redact.Patterns...)
+	if j.Log.Level != "" {
+		opts.API.LogLevel = j.Log.Level
+	}
+	if j.Log.Format != "" {
+		opts.API.LogFormat = j.Log.Format
+	}
+	opts.API.LogFile = j.Log.File
+
+	if fuzz := j.Fuzz; fuzz != nil {
+		opts.HTTP.URL = j.URL("", fuzz.Path)
+		opts.HTTP.Method = fuzz.Method
+		opts.HTTP.Data = fuzz.Data
+		opts.Input.Wordlists = append([]string{}, fuzz.Wordlists...)
+		if fuzz.MatchStatus != "" {
+			opts.Matcher.Status = fuzz.MatchStatus
+		}
+		opts.Filter.Status = fuzz.FilterStatus
+		opts.Filter.Size = fuzz.FilterSize
+		opts.General.AutoCalibration = fuzz.AutoCalibration
+		opts.Output.OutputFile = fuzz.Output
+		if fuzz.OutputFormat != "" {
+			opts.Output.OutputFormat = fuzz.OutputFormat
+		}
+	}
+
+	if security := j.Security; security != nil {
+		opts.API.SecurityProfile = security.Profile
+		opts.API.SecurityInclude = strings.Join(security.Include, ",")
+		opts.API.SecurityExclude = strings.Join(security.Exclude, ",")
+		names := make([]string, 0, len(security.Options))
+		for name := range security.Options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			opts.API.SecurityOptions = append(opts.API.SecurityOptions, name+"="+security.Options[name])
+		}
+		opts.API.SecurityScoring = security.Scoring
+		opts.API.SecurityBudgets = security.BudgetOptions()
+		opts.API.SecurityPayloads = security.Payloads
+		opts.API.PayloadCategories = strings.Join(security.PayloadCategories, ",")
+		opts.API.PayloadsReplace = security.PayloadsReplace
+		opts.API.SecurityTamper = strings.Join(security.Tamper, ",")
+		opts.API.SecurityWAF = security.WAF
+		opts.API.Fingerprint = security.Fingerprint
+		opts.API.SecurityHeaders = security.HeaderOptions()
+		opts.API.SecurityProxies = security.ProxyOptions()
+		opts.API.Identities = security.Identities
+		if security.LoginAttempts != nil {
+			opts.API.LoginAttempts = *security.LoginAttempts
+		}
+		opts.API.AccountSafe = security.AccountSafe
+		opts.API.OOBURL = security.OOBURL
+		opts.API.OOBListen = security.OOBListen
+		opts.API.OOBDomain = security.OOBDomain
+		opts.API.OOBDNSListen = security.OOBDNSListen
+		opts.API.StateFile = security.State
+		opts.API.Resume = security.Resume
+	}
+	return opts
+}
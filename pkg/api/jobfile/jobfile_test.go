@@ -0,0 +1,229 @@
+package jobfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJob = `name: staging
+vars_file: staging.env
+vars:
+  version: v2
+targets:
+  - "{{host}}/"
+specs: [specs/openapi.yaml]
+headers:
+  X-Client: ffuf
+resolve: ["api.example.com:443:10.0.0.5"]
+auth:
+  api_key: "{{env.FFUF_TEST_KEY}}"
+  api_key_name: key
+  api_key_location: query
+rate:
+  threads: 5
+  requests_per_second: 20
+fuzz:
+  path: /{{version}}/FUZZ
+  wordlists: [words.txt, /tmp/ids.txt:ID]
+security:
+  profile: injection-only
+  options:
+    injection.TestTimeBased: "false"
+  max_duration: 30m
+  budgets:
+    injection: 5m
+    default: 1m
+  payloads: [payloads, https://payloads.example.com/sqli.txt]
+  payload_categories: [sqli-error, xxe-oob]
+  tamper: [randomcase, tamper.sh]
+  waf: true
+  fingerprint: true
+  headers:
+    default: ["X-Scan: ffuf"]
+    injection: ["X-Debug: 1", "Cookie: debug=1"]
+  proxies:
+    ssrf: http://127.0.0.1:8080
+  login_attempts: 3
+  account_safe: true
+  oob_listen: 0.0.0.0:8089
+  oob_url: http://oob.example.com:8089
+  identities:
+    - "alice:101,102=Authorization: Bearer alice"
+    - "bob:201=Authorization: Bearer bob"
+  baseline: baseline.json
+  fail_on_new: true
+  state: out/scan.state
+  resume: true
+reports:
+  - file: out/report.sarif
+  - file: out/issues.xml
+    format: burp
+redact:
+  rules: [authorization, emails]
+  patterns: ['ssn=(\d+)']
+log:
+  level: debug
+  format: json
+  file: logs/scan.log
+`
+
+// writeJob writes a job file and its vars file to a temporary directory
+func writeJob(t *testing.T, job string) (string, func()) {
+	dir, err := ioutil.TempDir("", "jobfile")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "staging.env"), []byte("host=api.example.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write vars file: %s", err)
+	}
+	path := filepath.Join(dir, "job.yaml")
+	if err := ioutil.WriteFile(path, []byte(job), 0644); err != nil {
+		t.Fatalf("Failed to write job file: %s", err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestLoad(t *testing.T) {
+	os.Setenv("FFUF_TEST_KEY", "s3cr3t")
+	defer os.Unsetenv("FFUF_TEST_KEY")
+	path, cleanup := writeJob(t, testJob)
+	defer cleanup()
+	dir := filepath.Dir(path)
+
+	job, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned an error: %s", err)
+	}
+	if len(job.Targets) != 1 || job.Targets[0] != "https://api.example.com" {
+		t.Errorf("Expected the target to be resolved and normalized, got %v", job.Targets)
+	}
+	if job.Specs[0] != filepath.Join(dir, "specs/openapi.yaml") || job.Security.Baseline != filepath.Join(dir, "baseline.json") {
+		t.Errorf("Expected the paths to be relative to the job file, got %s and %s", job.Specs[0], job.Security.Baseline)
+	}
+	if job.Fuzz.Wordlists[0] != filepath.Join(dir, "words.txt") || job.Fuzz.Wordlists[1] != "/tmp/ids.txt:ID" {
+		t.Errorf("Unexpected wordlists %v", job.Fuzz.Wordlists)
+	}
+	if u := job.URL(job.Targets[0], "/users?page=1"); u != "https://api.example.com/users?page=1&key=s3cr3t" {
+		t.Errorf("Expected the API key in the query, got %s", u)
+	}
+	if format, err := job.Reports[0].ReportFormat(); err != nil || format != "sarif" {
+		t.Errorf("Expected the sarif format to be inferred, got %s %v", format, err)
+	}
+
+	opts := job.Options()
+	if opts.HTTP.URL != "/v2/FUZZ?key=s3cr3t" {
+		t.Errorf("Unexpected fuzz URL %s", opts.HTTP.URL)
+	}
+	if strings.Join(opts.HTTP.Headers, ", ") != "X-Client: ffuf" {
+		t.Errorf("Unexpected headers %v", opts.HTTP.Headers)
+	}
+	if len(opts.HTTP.Resolve) != 1 || opts.HTTP.Resolve[0] != "api.example.com:443:10.0.0.5" {
+		t.Errorf("Unexpected resolve entries %v", opts.HTTP.Resolve)
+	}
+	if opts.General.Threads != 5 || opts.General.Rate != 20 || !opts.General.Noninteractive {
+		t.Errorf("Unexpected rate options %d threads, %d/s", opts.General.Threads, opts.General.Rate)
+	}
+	if opts.API.SecurityProfile != "injection-only" || len(opts.API.SecurityOptions) != 1 || opts.API.SecurityOptions[0] != "injection.TestTimeBased=false" {
+		t.Errorf("Unexpected security options %s %v", opts.API.SecurityProfile, opts.API.SecurityOptions)
+	}
+	if job.Security.Duration() != 30*time.Minute || strings.Join(opts.API.SecurityBudgets, ", ") != "default=1m, injection=5m" {
+		t.Errorf("Unexpected time budgets %s %v", job.Security.Duration(), opts.API.SecurityBudgets)
+	}
+	if opts.API.SecurityPayloads[0] != filepath.Join(dir, "payloads") || opts.API.SecurityPayloads[1] != "https://payloads.example.com/sqli.txt" || opts.API.PayloadCategories != "sqli-error,xxe-oob" {
+		t.Errorf("Unexpected payloads %v %s", opts.API.SecurityPayloads, opts.API.PayloadCategories)
+	}
+	if opts.API.SecurityTamper != "randomcase,"+filepath.Join(dir, "tamper.sh") {
+		t.Errorf("Expected the tamper scripts to be relative to the job file, got %s", opts.API.SecurityTamper)
+	}
+	if !opts.API.SecurityWAF {
+		t.Error("Expected WAF detection to be enabled")
+	}
+	if !opts.API.Fingerprint {
+		t.Error("Expected technology fingerprinting to be enabled")
+	}
+	if strings.Join(opts.API.SecurityHeaders, ", ") != "X-Scan: ffuf, injection=X-Debug: 1, injection=Cookie: debug=1" {
+		t.Errorf("Unexpected tester headers %v", opts.API.SecurityHeaders)
+	}
+	if strings.Join(opts.API.SecurityProxies, ", ") != "ssrf=http://127.0.0.1:8080" {
+		t.Errorf("Unexpected tester proxies %v", opts.API.SecurityProxies)
+	}
+	if opts.API.LoginAttempts != 3 || !opts.API.AccountSafe {
+		t.Errorf("Unexpected login attempts %d and account-safe mode %t", opts.API.LoginAttempts, opts.API.AccountSafe)
+	}
+	if opts.API.OOBListen != "0.0.0.0:8089" || opts.API.OOBURL != "http://oob.example.com:8089" {
+		t.Errorf("Unexpected callback listener %s and URL %s", opts.API.OOBListen, opts.API.OOBURL)
+	}
+	if len(opts.API.Identities) != 2 || !strings.HasPrefix(opts.API.Identities[0], "alice:101,102=Authorization: Bearer ") {
+		t.Errorf("Unexpected identities %v", opts.API.Identities)
+	}
+	if opts.API.StateFile != filepath.Join(dir, "out", "scan.state") || !opts.API.Resume {
+		t.Errorf("Expected the state file to be relative to the job file and resumed, got %s %t", opts.API.StateFile, opts.API.Resume)
+	}
+	if opts.API.Redact != "authorization,emails" || len(opts.API.RedactPatterns) != 1 || opts.API.RedactPatterns[0] != `ssn=(\d+)` {
+		t.Errorf("Unexpected redaction %s %v", opts.API.Redact, opts.API.RedactPatterns)
+	}
+	if opts.API.LogLevel != "debug" || opts.API.LogFormat != "json" || opts.API.LogFile != filepath.Join(dir, "logs", "scan.log") {
+		t.Errorf("Unexpected log options %s %s %s", opts.API.LogLevel, opts.API.LogFormat, opts.API.LogFile)
+	}
+}
+
+func TestHeaderLines(t *testing.T) {
+	job := &Job{Headers: map[string]string{"X-Client": "ffuf"}, Auth: Auth{Username: "alice", Password: "secret"}}
+	if lines := job.HeaderLines(); len(lines) != 2 || lines[0] != "Authorization: Basic YWxpY2U6c2VjcmV0" {
+		t.Errorf("Expected basic authentication, got %v", lines)
+	}
+	job.Auth = Auth{Token: "abc"}
+	if lines := job.HeaderLines(); lines[0] != "Authorization: Bearer abc" {
+		t.Errorf("Expected bearer authentication, got %v", lines)
+	}
+	job.Auth = Auth{APIKey: "abc"}
+	if lines := job.HeaderLines(); lines[0] != "X-API-Key: abc" {
+		t.Errorf("Expected the API key header, got %v", lines)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		job, expected string
+	}{
+		{"targets: [api.example.com]\n", "a fuzz or security stage is required"},
+		{"security: {}\n", "targets or specs are required"},
+		{"targets: [api.example.com]\nsecurity: {profile: unknown}\n", "unknown scan profile"},
+		{"targets: [api.example.com]\nfuzz: {path: /FUZZ}\n", "requires wordlists"},
+		{"targets: [api.example.com]\nsecurity: {}\nreports: [{file: report.pdf}]\n", "cannot be inferred"},
+		{"targets: [api.example.com]\nsecurity: {}\nauth: {type: digest}\n", "auth type"},
+		{"targets: [api.example.com]\nsecurity: {}\nreport: []\n", "field report not found"},
+		{"targets: [api.example.com]\nsecurity: {max_duration: 30}\n", "invalid max_duration"},
+		{"targets: [api.example.com]\nsecurity: {budgets: {injection: fast}}\n", "invalid tester budget"},
+		{"targets: [api.example.com]\nsecurity: {budgets: {unknown: 1m}}\n", "unknown vulnerability type"},
+		{"targets: [api.example.com]\nsecurity: {payload_categories: [sqli]}\n", "unknown payload category"},
+		{"targets: [api.example.com]\nsecurity: {headers: {injection: [X-Debug]}}\n", "invalid tester header"},
+		{"targets: [api.example.com]\nsecurity: {proxies: {ssrf: 127.0.0.1}}\n", "invalid tester proxy"},
+		{"targets: [api.example.com]\nsecurity: {}\nredact: {rules: [phones]}\n", "Unknown redaction rule"},
+		{"targets: [api.example.com]\nsecurity: {}\nlog: {level: trace}\n", "Unknown log level"},
+		{"targets: [api.example.com]\nsecurity: {}\nproxy: http://127.0.0.1:8080\nproxy_list: proxies.txt\n", "cannot be used together"},
+		{"targets: [api.example.com]\nsecurity: {}\nproxy_list: proxies.txt\n", "Could not read the proxy list"},
+		{"targets: [api.example.com]\nsecurity: {}\nresolve: [api.example.com:10.0.0.5]\n", "Bad resolve entry"},
+		{"targets: [api.example.com]\nsecurity: {}\nproxy: http://127.0.0.1:8080\nresolve: [api.example.com:443:10.0.0.5]\n", "resolve cannot be used with proxy"},
+		{"targets: [api.example.com]\nsecurity:\n  login_attempts: -1\n", "invalid login_attempts"},
+		{"targets: [api.example.com]\nsecurity: {oob_listen: 0.0.0.0:8089}\n", "oob_listen requires oob_url"},
+		{"targets: [api.example.com]\nsecurity: {oob_listen: 0.0.0.0:8089, oob_url: oob.example.com}\n", "invalid oob_url"},
+		{"targets: [api.example.com]\nsecurity: {oob_dns_listen: 0.0.0.0:53}\n", "oob_dns_listen requires oob_domain"},
+		{"targets: [api.example.com]\nsecurity: {oob_domain: oob.example.com}\n", "require a callback listener"},
+		{"targets: [api.example.com]\nsecurity: {resume: true}\n", "resume requires a state file"},
+		{"targets: [api.example.com]\nsecurity:\n  identities: [\"alice:101=Authorization: Bearer alice\"]\n", "expected two identities"},
+		{"targets: [api.example.com]\nsecurity:\n  identities: [alice:101, bob:201]\n", "invalid identity"},
+		{"targets: [api.example.com]\nauth: {login: {url: https://api.example.com/login}}\nsecurity:\n  identities: [\"alice:101=Authorization: Bearer alice\", \"bob:201=Authorization: Bearer bob\"]\n", "identities cannot be used with auth.login"},
+	} {
+		path, cleanup := writeJob(t, tc.job)
+		_, err := Load(path)
+		cleanup()
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("Expected an error containing %q for %q, got %v", tc.expected, tc.job, err)
+		}
+	}
+}
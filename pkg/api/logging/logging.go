@@ -0,0 +1,319 @@
+// Package logging provides the leveled, structured logger of the API testing modules.
+//
+// Each module logs through its own logger, created with For, whose records carry the name
+// of the module and the fields added with With, such as the target and the endpoint being
+// tested. The records of every logger are written to a shared output, standard error by
+// default, as text or JSON lines, and optionally to a log file.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// Level is the severity of a log record
+type Level int
+
+// Log levels, from the most to the least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// String returns the name of the level
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "level(" + strconv.Itoa(int(l)) + ")"
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses the name of a level, case insensitively. An empty name is LevelInfo.
+func ParseLevel(name string) (Level, error) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	switch lower {
+	case "":
+		return LevelInfo, nil
+	case "warning":
+		return LevelWarn, nil
+	}
+	for i, levelName := range levelNames {
+		if lower == levelName {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, api.NewAPIError(fmt.Sprintf("Unknown log level: %s, expected %s", name, strings.Join(levelNames, ", ")), 0)
+}
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Common fields of the records
+const (
+	FieldModule   = "module"
+	FieldTarget   = "target"
+	FieldEndpoint = "endpoint"
+	FieldError    = "error"
+)
+
+// Options configure the output of the loggers
+type Options struct {
+	// Level is the name of the minimum level of the records written, info by default
+	Level string
+	// Format is the format of the records, text or json, text by default
+	Format string
+	// File is the path of a file the records are appended to, in addition to Output
+	File string
+	// Output is the writer of the records, standard error by default
+	Output io.Writer
+	// Redact masks the tokens and personal data of the messages and string fields, if set
+	Redact func(string) string
+}
+
+// output is the shared output of the loggers
+type output struct {
+	mu     sync.Mutex
+	level  Level
+	json   bool
+	writer io.Writer
+	file   *os.File
+	redact func(string) string
+}
+
+var std = &output{level: LevelInfo, writer: os.Stderr}
+
+// now returns the time of the records, replaced by tests
+var now = time.Now
+
+// Validate checks the level and the format of the options
+func (o Options) Validate() error {
+	if _, err := ParseLevel(o.Level); err != nil {
+		return err
+	}
+	format := strings.ToLower(strings.TrimSpace(o.Format))
+	if format != "" && format != FormatText && format != FormatJSON {
+		return api.NewAPIError(fmt.Sprintf("Unknown log format: %s, expected %s or %s", o.Format, FormatText, FormatJSON), 0)
+	}
+	return nil
+}
+
+// Configure sets the level, the format and the outputs of the records of every logger. The
+// log file of a previous configuration is closed.
+func Configure(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	level, _ := ParseLevel(opts.Level)
+	var file *os.File
+	var err error
+	if opts.File != "" {
+		file, err = os.OpenFile(opts.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return api.NewAPIError(fmt.Sprintf("Could not open the log file %s: %s", opts.File, err), 0)
+		}
+	}
+	writer := opts.Output
+	if writer == nil {
+		writer = os.Stderr
+	}
+
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	if std.file != nil {
+		std.file.Close()
+	}
+	std.level = level
+	std.json = strings.EqualFold(strings.TrimSpace(opts.Format), FormatJSON)
+	std.writer = writer
+	std.file = file
+	std.redact = opts.Redact
+	return nil
+}
+
+// Close closes the log file, further records are only written to the output
+func Close() error {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	if std.file == nil {
+		return nil
+	}
+	err := std.file.Close()
+	std.file = nil
+	return err
+}
+
+// Logger writes the records of a module
+type Logger struct {
+	module string
+	fields []interface{}
+}
+
+// For returns the logger of a module
+func For(module string) *Logger {
+	return &Logger{module: module}
+}
+
+// With returns a logger adding fields, given as alternating keys and values, to the records
+// of the logger
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+	return &Logger{module: l.module, fields: fields}
+}
+
+// Enabled returns true if the records of a level are written
+func (l *Logger) Enabled(level Level) bool {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	return level >= std.level
+}
+
+// Debug writes a debug record with fields given as alternating keys and values
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LevelDebug, msg, keyvals)
+}
+
+// Info writes an info record with fields given as alternating keys and values
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.log(LevelInfo, msg, keyvals)
+}
+
+// Warn writes a warning record with fields given as alternating keys and values
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.log(LevelWarn, msg, keyvals)
+}
+
+// Error writes an error record with fields given as alternating keys and values
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.log(LevelError, msg, keyvals)
+}
+
+// log writes a record to the output and the log file. Fields with a nil value are left out.
+func (l *Logger) log(level Level, msg string, keyvals []interface{}) {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	if level < std.level {
+		return
+	}
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+	if len(fields)%2 != 0 {
+		last := fields[len(fields)-1]
+		fields = append(fields[:len(fields)-1], "!BADKEY", last)
+	}
+	for i := 1; i < len(fields); i += 2 {
+		fields[i] = fieldValue(fields[i])
+		if s, ok := fields[i].(string); ok && std.redact != nil {
+			fields[i] = std.redact(s)
+		}
+	}
+	if std.redact != nil {
+		msg = std.redact(msg)
+	}
+
+	var line []byte
+	if std.json {
+		line = jsonRecord(now(), level, l.module, msg, fields)
+	} else {
+		line = textRecord(now(), level, l.module, msg, fields)
+	}
+	std.writer.Write(line)
+	if std.file != nil {
+		std.file.Write(line)
+	}
+}
+
+// textRecord formats a record as a line of text:
+// 2006/01/02 15:04:05 [WARN] module: message key=value
+func textRecord(t time.Time, level Level, module, msg string, fields []interface{}) []byte {
+	var b strings.Builder
+	b.WriteString(t.Format("2006/01/02 15:04:05"))
+	b.WriteString(" [")
+	b.WriteString(strings.ToUpper(level.String()))
+	b.WriteString("] ")
+	if module != "" {
+		b.WriteString(module)
+		b.WriteString(": ")
+	}
+	b.WriteString(msg)
+	for i := 0; i < len(fields); i += 2 {
+		if fields[i+1] == nil {
+			continue
+		}
+		value := fmt.Sprint(fields[i+1])
+		if value == "" || strings.ContainsAny(value, " \t\r\n\"=") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(" ")
+		b.WriteString(fmt.Sprint(fields[i]))
+		b.WriteString("=")
+		b.WriteString(value)
+	}
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+// jsonRecord formats a record as a JSON object on a line, with the time, level, module and
+// msg keys followed by the fields
+func jsonRecord(t time.Time, level Level, module, msg string, fields []interface{}) []byte {
+	var b strings.Builder
+	b.WriteString(`{"time":`)
+	b.Write(jsonValue(t.Format("2006-01-02T15:04:05.000Z07:00")))
+	b.WriteString(`,"level":`)
+	b.Write(jsonValue(level.String()))
+	if module != "" {
+		b.WriteString(`,"` + FieldModule + `":`)
+		b.Write(jsonValue(module))
+	}
+	b.WriteString(`,"msg":`)
+	b.Write(jsonValue(msg))
+	for i := 0; i < len(fields); i += 2 {
+		if fields[i+1] == nil {
+			continue
+		}
+		b.WriteString(",")
+		b.Write(jsonValue(fmt.Sprint(fields[i])))
+		b.WriteString(":")
+		b.Write(jsonValue(fields[i+1]))
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+// fieldValue returns the value of a field written to a record: the message of an error and
+// the string of a fmt.Stringer, or the value itself
+func fieldValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
+
+// jsonValue encodes a value as JSON, or its string if it can not be encoded
+func jsonValue(value interface{}) []byte {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return data
+}
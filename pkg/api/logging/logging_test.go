@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// configure configures the loggers for a test, writing to the returned builder, and
+// restores the defaults at the end of the test
+func configure(t *testing.T, opts Options) *strings.Builder {
+	var buf strings.Builder
+	opts.Output = &buf
+	if err := Configure(opts); err != nil {
+		t.Fatalf("Configure returned an error: %s", err)
+	}
+	now = func() time.Time { return time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() {
+		Configure(Options{})
+		now = time.Now
+	})
+	return &buf
+}
+
+func TestLogger_Text(t *testing.T) {
+	buf := configure(t, Options{})
+	logger := For("capture").With(FieldTarget, "https://api.example.com")
+	logger.Warn("Failed to record the request", FieldEndpoint, "/users?q=a b", FieldError, errors.New("EOF"), "status", nil)
+	logger.Debug("Not written at the info level")
+
+	expected := "2024/03/01 12:30:00 [WARN] capture: Failed to record the request target=https://api.example.com endpoint=\"/users?q=a b\" error=EOF\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected text record:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestLogger_JSON(t *testing.T) {
+	buf := configure(t, Options{Level: "debug", Format: "JSON"})
+	For("security").With(FieldTarget, "https://api.example.com").Debug("Request sent", "status", 200, "duration", 1500*time.Millisecond, "odd")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(buf.String()), &record); err != nil {
+		t.Fatalf("Expected a JSON record, got %s: %s", buf.String(), err)
+	}
+	expected := map[string]interface{}{
+		"time":     "2024-03-01T12:30:00.000Z",
+		"level":    "debug",
+		"module":   "security",
+		"msg":      "Request sent",
+		"target":   "https://api.example.com",
+		"status":   float64(200),
+		"duration": "1.5s",
+		"!BADKEY":  "odd",
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, record[key])
+		}
+	}
+	if !strings.HasPrefix(buf.String(), `{"time":"2024-03-01T12:30:00.000Z","level":"debug","module":"security","msg":"Request sent",`) {
+		t.Errorf("Expected the time, level, module and msg keys first: %s", buf.String())
+	}
+}
+
+func TestLogger_Redact(t *testing.T) {
+	buf := configure(t, Options{Redact: func(s string) string { return strings.ReplaceAll(s, "s3cr3t", "[REDACTED]") }})
+	For("security").Info("Token s3cr3t rejected", FieldEndpoint, "/users?token=s3cr3t", "status", 401)
+	if strings.Contains(buf.String(), "s3cr3t") || !strings.Contains(buf.String(), `endpoint="/users?token=[REDACTED]" status=401`) {
+		t.Errorf("Expected the record to be redacted: %s", buf.String())
+	}
+}
+
+func TestLogger_Level(t *testing.T) {
+	buf := configure(t, Options{Level: "error"})
+	logger := For("wordlist")
+	logger.Info("Not written")
+	logger.Warn("Not written")
+	logger.Error("Written")
+	if strings.Count(buf.String(), "\n") != 1 || !strings.Contains(buf.String(), "[ERROR] wordlist: Written") {
+		t.Errorf("Expected only the error record, got %s", buf.String())
+	}
+	if logger.Enabled(LevelWarn) || !logger.Enabled(LevelError) {
+		t.Errorf("Unexpected enabled levels at the error level")
+	}
+}
+
+func TestConfigure_File(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scan.log")
+	buf := configure(t, Options{Format: "json", File: file})
+	For("scan").Info("Scan started")
+	if err := Close(); err != nil {
+		t.Fatalf("Close returned an error: %s", err)
+	}
+	For("scan").Info("Scan finished")
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Could not read the log file: %s", err)
+	}
+	if !strings.Contains(string(data), "Scan started") || strings.Contains(string(data), "Scan finished") {
+		t.Errorf("Unexpected log file: %s", data)
+	}
+	if strings.Count(buf.String(), "\n") != 2 {
+		t.Errorf("Expected both records in the output, got %s", buf.String())
+	}
+
+	if err := Configure(Options{Level: "verbose"}); err == nil || !strings.Contains(err.Error(), "Unknown log level") {
+		t.Errorf("Expected an unknown level error, got %v", err)
+	}
+	if err := Configure(Options{Format: "xml"}); err == nil || !strings.Contains(err.Error(), "Unknown log format") {
+		t.Errorf("Expected an unknown format error, got %v", err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{"": LevelInfo, "DEBUG": LevelDebug, " warning ": LevelWarn, "warn": LevelWarn, "error": LevelError}
+	for name, expected := range tests {
+		if level, err := ParseLevel(name); err != nil || level != expected {
+			t.Errorf("ParseLevel(%q) = %s, %v, expected %s", name, level, err, expected)
+		}
+	}
+}
@@ -232,6 +232,7 @@ func (a *APIOutput) Result(resp ffuf.Response) {
 		ContentWords:     resp.ContentWords,
 		ContentLines:     resp.ContentLines,
 		ContentType:      resp.ContentType,
+		Proto:            resp.Proto,
 		RedirectLocation: resp.GetRedirectLocation(false),
 		ScraperData:      resp.ScraperData,
 		Url:              resp.Request.Url,
@@ -297,6 +298,9 @@ func (a *APIOutput) resultMultiline(res ffuf.Result) {
 	reslines := ""
 	if a.config.Verbose {
 		reslines = fmt.Sprintf("%s%s| URL | %s\n", reslines, TERMINAL_CLEAR_LINE, res.Url)
+		if res.Proto != "" {
+			reslines = fmt.Sprintf("%s%s| PRO | %s\n", reslines, TERMINAL_CLEAR_LINE, res.Proto)
+		}
 		redirectLocation := res.RedirectLocation
 		if redirectLocation != "" {
 			reslines = fmt.Sprintf("%s%s| --> | %s\n", reslines, TERMINAL_CLEAR_LINE, redirectLocation)
@@ -0,0 +1,613 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// AsyncAPIParser provides methods for parsing AsyncAPI 2.x specifications
+type AsyncAPIParser struct {
+	// The parsed specification
+	Spec *AsyncAPISpec
+	// The version of the specification (e.g., "2.6.0")
+	Version string
+	// HTTP client used to fetch specifications from URLs
+	Client *http.Client
+}
+
+// AsyncAPISpec represents a parsed AsyncAPI specification
+type AsyncAPISpec struct {
+	// Raw data of the specification
+	Raw map[string]interface{}
+	// Title of the API
+	Title string
+	// Description of the API
+	Description string
+	// Version of the API (not the AsyncAPI version)
+	Version string
+	// Servers by name
+	Servers map[string]*AsyncAPIServer
+	// Extracted channel operations
+	Channels []*AsyncAPIChannel
+}
+
+// AsyncAPIServer represents a server (broker or HTTP/WebSocket host) of an AsyncAPI specification
+type AsyncAPIServer struct {
+	// Name of the server
+	Name string
+	// URL of the server, with variables substituted by their defaults
+	URL string
+	// Protocol used by the server (http, https, ws, wss, mqtt, kafka, amqp, ...)
+	Protocol string
+	// Description of the server
+	Description string
+	// Whether the server declares security requirements
+	RequiresAuth bool
+}
+
+// AsyncAPIChannel represents a publish or subscribe operation on a channel
+type AsyncAPIChannel struct {
+	// Name of the channel (e.g., "user/{userId}/signedup")
+	Name string
+	// Operation on the channel ("publish" or "subscribe")
+	Operation string
+	// Operation identifier
+	OperationID string
+	// Summary of the operation
+	Summary string
+	// Description of the channel or operation
+	Description string
+	// Channel parameters
+	Parameters []*OpenAPIParameter
+	// Messages accepted by the operation
+	Messages []*AsyncAPIMessage
+	// Names of servers the channel is available on (empty means all servers)
+	Servers []string
+	// Protocol bindings of the channel and operation, by protocol name
+	Bindings map[string]map[string]interface{}
+	// Tags associated with the operation
+	Tags []string
+}
+
+// AsyncAPIMessage represents a message of a channel operation
+type AsyncAPIMessage struct {
+	// Name of the message
+	Name string
+	// Content type of the payload
+	ContentType string
+	// Schema of the message payload
+	Payload *OpenAPISchema
+	// Schema of the message headers
+	Headers *OpenAPISchema
+	// Example payloads
+	Examples []interface{}
+}
+
+// NewAsyncAPIParser creates a new AsyncAPIParser
+func NewAsyncAPIParser() *AsyncAPIParser {
+	return &AsyncAPIParser{
+		Spec: &AsyncAPISpec{
+			Raw:      make(map[string]interface{}),
+			Servers:  make(map[string]*AsyncAPIServer),
+			Channels: make([]*AsyncAPIChannel, 0),
+		},
+		Client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// ParseFromFile parses an AsyncAPI specification from a file
+func (p *AsyncAPIParser) ParseFromFile(filePath string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to read AsyncAPI file: %s", err.Error()), 0)
+	}
+
+	// Determine format based on file extension
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == ".yaml" || ext == ".yml" {
+		return p.ParseYAML(data)
+	}
+	return p.ParseJSON(data)
+}
+
+// ParseFromURL parses an AsyncAPI specification from a URL
+func (p *AsyncAPIParser) ParseFromURL(specURL string) error {
+	if _, err := url.Parse(specURL); err != nil {
+		return api.NewAPIError(fmt.Sprintf("Invalid URL: %s", err.Error()), 0)
+	}
+
+	resp, err := p.Client.Get(specURL)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to fetch AsyncAPI spec: %s", err.Error()), 0)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return api.NewAPIError(fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, resp.Status), 0)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to read response body: %s", err.Error()), 0)
+	}
+	return p.ParseJSON(data)
+}
+
+// ParseJSON parses an AsyncAPI specification from JSON data
+func (p *AsyncAPIParser) ParseJSON(data []byte) error {
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to parse JSON: %s", err.Error()), 0)
+	}
+
+	return p.parseSpec(spec)
+}
+
+// ParseYAML parses an AsyncAPI specification from YAML data
+// Note: like OpenAPIParser.ParseYAML, this only accepts the JSON subset of YAML
+func (p *AsyncAPIParser) ParseYAML(data []byte) error {
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to parse YAML as JSON: %s", err.Error()), 0)
+	}
+
+	return p.parseSpec(spec)
+}
+
+// parseSpec parses the AsyncAPI specification
+func (p *AsyncAPIParser) parseSpec(spec map[string]interface{}) error {
+	p.Spec.Raw = spec
+
+	version, ok := spec["asyncapi"].(string)
+	if !ok {
+		return api.NewAPIError("Invalid AsyncAPI specification: missing version", 0)
+	}
+	if !strings.HasPrefix(version, "2.") {
+		return api.NewAPIError(fmt.Sprintf("Unsupported AsyncAPI version: %s", version), 0)
+	}
+	p.Version = version
+
+	// Extract basic information
+	if info, ok := spec["info"].(map[string]interface{}); ok {
+		if title, ok := info["title"].(string); ok {
+			p.Spec.Title = title
+		}
+		if description, ok := info["description"].(string); ok {
+			p.Spec.Description = description
+		}
+		if v, ok := info["version"].(string); ok {
+			p.Spec.Version = v
+		}
+	}
+
+	// Extract servers
+	if servers, ok := spec["servers"].(map[string]interface{}); ok {
+		for name, s := range servers {
+			serverMap, ok := p.resolve(s).(map[string]interface{})
+			if !ok {
+				continue
+			}
+			server := &AsyncAPIServer{Name: name}
+			server.URL, _ = serverMap["url"].(string)
+			server.Protocol, _ = serverMap["protocol"].(string)
+			server.Description, _ = serverMap["description"].(string)
+			if security, ok := serverMap["security"].([]interface{}); ok && len(security) > 0 {
+				server.RequiresAuth = true
+			}
+
+			// Substitute server variables with their default values
+			if variables, ok := serverMap["variables"].(map[string]interface{}); ok {
+				for varName, v := range variables {
+					if varMap, ok := v.(map[string]interface{}); ok {
+						if def, ok := varMap["default"].(string); ok {
+							server.URL = strings.ReplaceAll(server.URL, "{"+varName+"}", def)
+						}
+					}
+				}
+			}
+			p.Spec.Servers[name] = server
+		}
+	}
+
+	// Extract channels
+	if channels, ok := spec["channels"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(channels))
+		for name := range channels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			channelMap, ok := p.resolve(channels[name]).(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, operation := range []string{"publish", "subscribe"} {
+				opMap, ok := channelMap[operation].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				p.Spec.Channels = append(p.Spec.Channels, p.parseOperation(name, operation, channelMap, opMap))
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseOperation parses a publish or subscribe operation of a channel
+func (p *AsyncAPIParser) parseOperation(name, operation string, channelMap, opMap map[string]interface{}) *AsyncAPIChannel {
+	channel := &AsyncAPIChannel{
+		Name:       name,
+		Operation:  operation,
+		Parameters: make([]*OpenAPIParameter, 0),
+		Messages:   make([]*AsyncAPIMessage, 0),
+		Servers:    make([]string, 0),
+		Bindings:   make(map[string]map[string]interface{}),
+		Tags:       make([]string, 0),
+	}
+	channel.OperationID, _ = opMap["operationId"].(string)
+	channel.Summary, _ = opMap["summary"].(string)
+	channel.Description, _ = opMap["description"].(string)
+	if channel.Description == "" {
+		channel.Description, _ = channelMap["description"].(string)
+	}
+
+	if servers, ok := channelMap["servers"].([]interface{}); ok {
+		for _, s := range servers {
+			if server, ok := s.(string); ok {
+				channel.Servers = append(channel.Servers, server)
+			}
+		}
+	}
+
+	if tags, ok := opMap["tags"].([]interface{}); ok {
+		for _, tag := range tags {
+			if tagMap, ok := tag.(map[string]interface{}); ok {
+				if tagName, ok := tagMap["name"].(string); ok {
+					channel.Tags = append(channel.Tags, tagName)
+				}
+			}
+		}
+	}
+
+	// Channel parameters
+	if params, ok := channelMap["parameters"].(map[string]interface{}); ok {
+		for paramName, param := range params {
+			paramMap, ok := p.resolve(param).(map[string]interface{})
+			if !ok {
+				continue
+			}
+			parameter := &OpenAPIParameter{Name: paramName, In: "path", Required: true}
+			parameter.Description, _ = paramMap["description"].(string)
+			if schema, ok := p.resolve(paramMap["schema"]).(map[string]interface{}); ok {
+				parameter.Schema = p.extractSchema(schema)
+			}
+			channel.Parameters = append(channel.Parameters, parameter)
+		}
+		sort.Slice(channel.Parameters, func(i, j int) bool {
+			return channel.Parameters[i].Name < channel.Parameters[j].Name
+		})
+	}
+
+	// Bindings of the channel, overridden by bindings of the operation
+	for _, source := range []interface{}{channelMap["bindings"], opMap["bindings"]} {
+		if bindings, ok := p.resolve(source).(map[string]interface{}); ok {
+			for protocol, binding := range bindings {
+				if bindingMap, ok := binding.(map[string]interface{}); ok {
+					channel.Bindings[protocol] = bindingMap
+				}
+			}
+		}
+	}
+
+	// Messages, either a single message or a oneOf list
+	if message, ok := p.resolve(opMap["message"]).(map[string]interface{}); ok {
+		if oneOf, ok := message["oneOf"].([]interface{}); ok {
+			for _, m := range oneOf {
+				if msgMap, ok := p.resolve(m).(map[string]interface{}); ok {
+					channel.Messages = append(channel.Messages, p.parseMessage(msgMap))
+				}
+			}
+		} else {
+			channel.Messages = append(channel.Messages, p.parseMessage(message))
+		}
+	}
+
+	return channel
+}
+
+// parseMessage parses a message object
+func (p *AsyncAPIParser) parseMessage(msgMap map[string]interface{}) *AsyncAPIMessage {
+	message := &AsyncAPIMessage{
+		Examples: make([]interface{}, 0),
+	}
+	message.Name, _ = msgMap["name"].(string)
+	message.ContentType, _ = msgMap["contentType"].(string)
+	if message.ContentType == "" {
+		message.ContentType, _ = p.Spec.Raw["defaultContentType"].(string)
+	}
+	if payload, ok := p.resolve(msgMap["payload"]).(map[string]interface{}); ok {
+		message.Payload = p.extractSchema(payload)
+	}
+	if headers, ok := p.resolve(msgMap["headers"]).(map[string]interface{}); ok {
+		message.Headers = p.extractSchema(headers)
+	}
+	if examples, ok := msgMap["examples"].([]interface{}); ok {
+		for _, example := range examples {
+			if exampleMap, ok := example.(map[string]interface{}); ok {
+				if payload, ok := exampleMap["payload"]; ok {
+					message.Examples = append(message.Examples, payload)
+				}
+			}
+		}
+	}
+	return message
+}
+
+// extractSchema extracts a schema, resolving local references of nested properties
+func (p *AsyncAPIParser) extractSchema(schema map[string]interface{}) *OpenAPISchema {
+	return NewOpenAPIParser().extractSchema(p.resolveSchema(schema, 0))
+}
+
+// resolveSchema returns a copy of a schema with local references in properties and items resolved
+func (p *AsyncAPIParser) resolveSchema(schema map[string]interface{}, depth int) map[string]interface{} {
+	resolved, ok := p.resolve(schema).(map[string]interface{})
+	if !ok || depth > 10 {
+		return schema
+	}
+
+	result := make(map[string]interface{}, len(resolved))
+	for k, v := range resolved {
+		result[k] = v
+	}
+	if properties, ok := resolved["properties"].(map[string]interface{}); ok {
+		props := make(map[string]interface{}, len(properties))
+		for name, prop := range properties {
+			if propMap, ok := prop.(map[string]interface{}); ok {
+				props[name] = p.resolveSchema(propMap, depth+1)
+			}
+		}
+		result["properties"] = props
+		if _, ok := result["type"]; !ok {
+			result["type"] = "object"
+		}
+	}
+	if items, ok := resolved["items"].(map[string]interface{}); ok {
+		result["items"] = p.resolveSchema(items, depth+1)
+	}
+	return result
+}
+
+// resolve follows a local JSON reference ("#/components/...") if the value is a reference
+func (p *AsyncAPIParser) resolve(value interface{}) interface{} {
+	for i := 0; i < 10; i++ {
+		refMap, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		ref, ok := refMap["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return value
+		}
+
+		var current interface{} = p.Spec.Raw
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			current = m[part]
+		}
+		value = current
+	}
+	return value
+}
+
+// GetChannels returns all channel operations from the specification
+func (p *AsyncAPIParser) GetChannels() []*AsyncAPIChannel {
+	return p.Spec.Channels
+}
+
+// GetServersByProtocol returns all servers using one of the given protocols
+func (p *AsyncAPIParser) GetServersByProtocol(protocols ...string) []*AsyncAPIServer {
+	servers := make([]*AsyncAPIServer, 0)
+	for _, server := range p.Spec.Servers {
+		for _, protocol := range protocols {
+			if strings.EqualFold(server.Protocol, protocol) {
+				servers = append(servers, server)
+				break
+			}
+		}
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers
+}
+
+// GetChannelServers returns the servers a channel is available on
+func (p *AsyncAPIParser) GetChannelServers(channel *AsyncAPIChannel) []*AsyncAPIServer {
+	if len(channel.Servers) == 0 {
+		servers := make([]*AsyncAPIServer, 0, len(p.Spec.Servers))
+		for _, server := range p.Spec.Servers {
+			servers = append(servers, server)
+		}
+		sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+		return servers
+	}
+
+	servers := make([]*AsyncAPIServer, 0, len(channel.Servers))
+	for _, name := range channel.Servers {
+		if server, ok := p.Spec.Servers[name]; ok {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// ChannelMethod returns the request method used to reach a channel operation.
+// HTTP bindings define their own method, WebSocket channels are opened with GET,
+// and other protocols use the operation name in upper case.
+func (p *AsyncAPIParser) ChannelMethod(channel *AsyncAPIChannel, server *AsyncAPIServer) string {
+	if binding, ok := channel.Bindings["http"]; ok {
+		if method, ok := binding["method"].(string); ok && method != "" {
+			return strings.ToUpper(method)
+		}
+	}
+	if binding, ok := channel.Bindings["ws"]; ok {
+		if method, ok := binding["method"].(string); ok && method != "" {
+			return strings.ToUpper(method)
+		}
+	}
+
+	switch strings.ToLower(server.Protocol) {
+	case "http", "https":
+		if channel.Operation == "subscribe" {
+			return "GET"
+		}
+		return "POST"
+	case "ws", "wss":
+		return "GET"
+	}
+	return strings.ToUpper(channel.Operation)
+}
+
+// GenerateWordlist generates a wordlist of channel names from the specification
+func (p *AsyncAPIParser) GenerateWordlist() []string {
+	wordlist := make([]string, 0, len(p.Spec.Channels))
+	seen := make(map[string]bool)
+	for _, channel := range p.Spec.Channels {
+		path := "/" + strings.TrimPrefix(channel.Name, "/")
+		if !seen[path] {
+			wordlist = append(wordlist, path)
+			seen[path] = true
+		}
+	}
+	return wordlist
+}
+
+// GetEndpoints converts the channel operations into discovered endpoints, one per channel
+// operation and server. Channels on brokers that are not reachable over HTTP keep the
+// operation name as method so they can be listed without being sent as HTTP requests.
+func (p *AsyncAPIParser) GetEndpoints() []*DiscoveredEndpoint {
+	endpoints := make([]*DiscoveredEndpoint, 0)
+	for _, channel := range p.Spec.Channels {
+		servers := p.GetChannelServers(channel)
+		if len(servers) == 0 {
+			servers = []*AsyncAPIServer{{}}
+		}
+
+		for _, server := range servers {
+			path := "/" + strings.TrimPrefix(channel.Name, "/")
+			endpoint := &DiscoveredEndpoint{
+				Method:       p.ChannelMethod(channel, server),
+				Path:         path,
+				RequiresAuth: server.RequiresAuth,
+				Description:  channel.Summary,
+				Tags:         append([]string{"asyncapi"}, channel.Tags...),
+				Source:       "AsyncAPI",
+				Operation:    channel.Operation + " " + channel.Name,
+				Parameters:   make([]*DiscoveredParameter, 0),
+			}
+			if endpoint.Description == "" {
+				endpoint.Description = channel.Description
+			}
+			if channel.OperationID != "" {
+				endpoint.Operation = channel.Operation + " " + channel.OperationID
+			}
+			if server.Protocol != "" {
+				endpoint.Tags = append(endpoint.Tags, strings.ToLower(server.Protocol))
+			}
+			if server.URL != "" {
+				baseURL := server.URL
+				if server.Protocol != "" && !strings.Contains(baseURL, "://") {
+					baseURL = strings.ToLower(server.Protocol) + "://" + baseURL
+				}
+				endpoint.URL = strings.TrimSuffix(baseURL, "/") + path
+			}
+
+			for _, param := range channel.Parameters {
+				endpoint.Parameters = append(endpoint.Parameters, p.discoveredParameter(param.Name, "path", true, param.Description, param.Schema))
+			}
+
+			// Query and header parameters of the HTTP and WebSocket bindings
+			for _, protocol := range []string{"http", "ws"} {
+				binding, ok := channel.Bindings[protocol]
+				if !ok {
+					continue
+				}
+				for field, in := range map[string]string{"query": "query", "headers": "header"} {
+					if schemaMap, ok := p.resolve(binding[field]).(map[string]interface{}); ok {
+						endpoint.Parameters = append(endpoint.Parameters, p.schemaParameters(p.extractSchema(schemaMap), in)...)
+					}
+				}
+			}
+
+			// Message headers and payload of the first message
+			if len(channel.Messages) > 0 {
+				message := channel.Messages[0]
+				if message.Headers != nil {
+					endpoint.Parameters = append(endpoint.Parameters, p.schemaParameters(message.Headers, "header")...)
+				}
+				if message.Payload != nil {
+					endpoint.Parameters = append(endpoint.Parameters, p.schemaParameters(message.Payload, "body")...)
+				}
+			}
+
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// schemaParameters converts the properties of an object schema into parameters
+func (p *AsyncAPIParser) schemaParameters(schema *OpenAPISchema, in string) []*DiscoveredParameter {
+	params := make([]*DiscoveredParameter, 0, len(schema.Properties))
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		required := false
+		for _, req := range schema.Required {
+			if req == name {
+				required = true
+				break
+			}
+		}
+		params = append(params, p.discoveredParameter(name, in, required, "", schema.Properties[name]))
+	}
+	return params
+}
+
+// discoveredParameter creates a discovered parameter from a schema
+func (p *AsyncAPIParser) discoveredParameter(name, in string, required bool, description string, schema *OpenAPISchema) *DiscoveredParameter {
+	param := &DiscoveredParameter{
+		Name:        name,
+		In:          in,
+		Required:    required,
+		Description: description,
+	}
+	if schema != nil {
+		param.Type = schema.Type
+		param.Example = schema.Example
+		if param.Example == nil && len(schema.Enum) > 0 {
+			param.Example = schema.Enum[0]
+		}
+	}
+	return param
+}
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testAsyncAPISpec = `{
+	"asyncapi": "2.6.0",
+	"info": {"title": "Account Service", "version": "1.0.0"},
+	"defaultContentType": "application/json",
+	"servers": {
+		"broker": {"url": "broker.example.com:1883", "protocol": "mqtt"},
+		"events": {
+			"url": "{host}/events",
+			"protocol": "wss",
+			"variables": {"host": {"default": "wss://ws.example.com"}},
+			"security": [{"token": []}]
+		},
+		"management": {"url": "https://manage.example.com/api", "protocol": "https"}
+	},
+	"channels": {
+		"user/{userId}/signedup": {
+			"servers": ["broker", "events"],
+			"parameters": {
+				"userId": {"description": "ID of the user", "schema": {"type": "string"}}
+			},
+			"subscribe": {
+				"operationId": "onUserSignedUp",
+				"summary": "User signed up",
+				"message": {"$ref": "#/components/messages/UserSignedUp"}
+			}
+		},
+		"subscriptions": {
+			"servers": ["management"],
+			"bindings": {
+				"http": {
+					"type": "request",
+					"method": "PUT",
+					"query": {"type": "object", "properties": {"dryRun": {"type": "boolean"}}}
+				}
+			},
+			"publish": {
+				"message": {
+					"oneOf": [
+						{"$ref": "#/components/messages/Subscription"},
+						{"name": "Ignored", "payload": {"type": "object"}}
+					]
+				}
+			}
+		}
+	},
+	"components": {
+		"messages": {
+			"UserSignedUp": {
+				"name": "UserSignedUp",
+				"headers": {"type": "object", "properties": {"correlationId": {"type": "string"}}},
+				"payload": {"$ref": "#/components/schemas/User"}
+			},
+			"Subscription": {
+				"name": "Subscription",
+				"payload": {
+					"type": "object",
+					"required": ["topic"],
+					"properties": {
+						"topic": {"type": "string", "enum": ["users", "orders"]},
+						"owner": {"$ref": "#/components/schemas/User"}
+					}
+				}
+			}
+		},
+		"schemas": {
+			"User": {
+				"type": "object",
+				"required": ["email"],
+				"properties": {
+					"email": {"type": "string", "format": "email"},
+					"age": {"type": "integer"}
+				}
+			}
+		}
+	}
+}`
+
+func TestAsyncAPIParser_ParseJSON(t *testing.T) {
+	parser := NewAsyncAPIParser()
+	if err := parser.ParseJSON([]byte(testAsyncAPISpec)); err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+
+	if parser.Version != "2.6.0" || parser.Spec.Title != "Account Service" {
+		t.Errorf("Unexpected spec info: version %s, title %s", parser.Version, parser.Spec.Title)
+	}
+	if parser.Spec.Servers["events"].URL != "wss://ws.example.com/events" {
+		t.Errorf("Expected server variables to be substituted, got '%s'", parser.Spec.Servers["events"].URL)
+	}
+
+	channels := parser.GetChannels()
+	if len(channels) != 2 {
+		t.Fatalf("Expected 2 channel operations, got %d", len(channels))
+	}
+
+	signedUp := channels[1]
+	if signedUp.Name != "user/{userId}/signedup" || signedUp.Operation != "subscribe" {
+		t.Fatalf("Unexpected channel %s %s", signedUp.Operation, signedUp.Name)
+	}
+	if len(signedUp.Messages) != 1 || signedUp.Messages[0].ContentType != "application/json" {
+		t.Fatalf("Expected referenced message with default content type, got %+v", signedUp.Messages)
+	}
+	if signedUp.Messages[0].Payload.Properties["email"] == nil {
+		t.Errorf("Expected referenced payload schema to be resolved")
+	}
+
+	subscriptions := channels[0]
+	if len(subscriptions.Messages) != 2 {
+		t.Fatalf("Expected 2 oneOf messages, got %d", len(subscriptions.Messages))
+	}
+	owner := subscriptions.Messages[0].Payload.Properties["owner"]
+	if owner == nil || owner.Type != "object" || owner.Properties["age"] == nil {
+		t.Errorf("Expected nested reference to be resolved, got %+v", owner)
+	}
+
+	if err := NewAsyncAPIParser().ParseJSON([]byte(`{"asyncapi": "3.0.0"}`)); err == nil {
+		t.Error("Expected error for unsupported AsyncAPI version")
+	}
+}
+
+func TestAsyncAPIParser_GetEndpoints(t *testing.T) {
+	parser := NewAsyncAPIParser()
+	if err := parser.ParseJSON([]byte(testAsyncAPISpec)); err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+
+	endpoints := parser.GetEndpoints()
+	if len(endpoints) != 3 {
+		t.Fatalf("Expected 3 endpoints, got %d", len(endpoints))
+	}
+
+	tests := []struct {
+		method string
+		url    string
+		params []string
+	}{
+		{"PUT", "https://manage.example.com/api/subscriptions", []string{"query:dryRun", "body:owner", "body:topic"}},
+		{"SUBSCRIBE", "mqtt://broker.example.com:1883/user/{userId}/signedup", []string{"path:userId", "header:correlationId", "body:age", "body:email"}},
+		{"GET", "wss://ws.example.com/events/user/{userId}/signedup", []string{"path:userId", "header:correlationId", "body:age", "body:email"}},
+	}
+	for i, tt := range tests {
+		endpoint := endpoints[i]
+		if endpoint.Method != tt.method || endpoint.URL != tt.url {
+			t.Errorf("Endpoint %d: got %s %s, want %s %s", i, endpoint.Method, endpoint.URL, tt.method, tt.url)
+		}
+		if len(endpoint.Parameters) != len(tt.params) {
+			t.Errorf("Endpoint %d: expected %d parameters, got %d", i, len(tt.params), len(endpoint.Parameters))
+			continue
+		}
+		for j, param := range endpoint.Parameters {
+			if param.In+":"+param.Name != tt.params[j] {
+				t.Errorf("Endpoint %d: parameter %d is %s:%s, want %s", i, j, param.In, param.Name, tt.params[j])
+			}
+		}
+	}
+
+	if !endpoints[2].RequiresAuth || endpoints[1].RequiresAuth {
+		t.Error("Expected only the secured WebSocket server to require authentication")
+	}
+	if endpoints[0].Parameters[2].Example != "users" {
+		t.Errorf("Expected enum value as example, got %v", endpoints[0].Parameters[2].Example)
+	}
+}
+
+func TestAPIEndpointDiscovery_DiscoverFromAsyncAPI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asyncapi")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	specPath := filepath.Join(dir, "asyncapi.json")
+	if err := ioutil.WriteFile(specPath, []byte(testAsyncAPISpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	discovery := NewAPIEndpointDiscovery("")
+	if err := discovery.DiscoverFromAsyncAPI(specPath); err != nil {
+		t.Fatalf("DiscoverFromAsyncAPI() error = %v", err)
+	}
+	if discovery.BaseURL != "https://manage.example.com" {
+		t.Errorf("Expected base URL from HTTP server, got '%s'", discovery.BaseURL)
+	}
+
+	generator := NewAPITestGenerator(discovery, nil)
+	generator.Options.IncludeAuthEndpoints = true
+	if err := generator.GenerateTestCases(); err != nil {
+		t.Fatalf("GenerateTestCases() error = %v", err)
+	}
+	for _, testCase := range generator.TestCases {
+		if testCase.Method == "SUBSCRIBE" {
+			t.Errorf("Expected no test cases for MQTT channels, got %s", testCase.Name)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+)
+
+// CacheBusterParam is the query parameter making the requests of cache test cases unique, so
+// that only they are served from or stored in a cache
+const CacheBusterParam = "ffufcb"
+
+// cacheHitMatcher fails test cases whose response was served from a cache
+var cacheHitMatcher = &APITestMatcher{
+	Type: "regex",
+	Part: "header",
+	Regex: []string{
+		`(?im)^(X-Cache|X-Cache-Status|X-Cache-Lookup|Cf-Cache-Status|X-Proxy-Cache|Akamai-Cache-Status|X-Drupal-Cache):.*\bhit\b`,
+		`(?im)^Age:\s*[1-9]`,
+	},
+	Negative: true,
+}
+
+// publicCacheMatcher fails test cases whose response may be stored by shared caches
+var publicCacheMatcher = &APITestMatcher{
+	Type:     "regex",
+	Part:     "header",
+	Regex:    []string{`(?im)^Cache-Control:.*\b(public|s-maxage)\b`},
+	Negative: true,
+}
+
+// randomHex returns n random bytes encoded in hexadecimal
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// DefaultUnkeyedHeaders returns the headers set by proxies that caches commonly leave out of
+// their keys: the host headers and the scheme headers of the header catalog
+func DefaultUnkeyedHeaders() []payload.CatalogHeader {
+	return append(payload.CatalogHeaders(payload.HeaderHost), payload.CatalogHeaders(payload.HeaderScheme)...)
+}
+
+// generateCacheBusterTestCases generates cache buster test cases for GET requests
+func generateCacheBusterTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	return cacheBusterTestCases("Cache buster", endpoint, params, CacheBusterParam)
+}
+
+// cacheBusterTestCases returns a chain sending a GET request with a new cache buster parameter,
+// then with another one, expecting the second response not to be a cache hit. A hit means the
+// cache leaves the query string out of its key, so that query parameters can poison it.
+func cacheBusterTestCases(name string, endpoint *DiscoveredEndpoint, params []*ExtractedParameter, buster string) []*APITestCase {
+	if endpoint.Method != "GET" {
+		return nil
+	}
+
+	first := cacheStep(endpoint, params, buster)
+	first.Name = fmt.Sprintf("%s: first GET request to %s", name, endpoint.Path)
+	first.Description = fmt.Sprintf("Send a GET request to %s with a new %s parameter", endpoint.Path, buster)
+	first.ExpectedStatus = 0
+
+	second := cacheStep(endpoint, params, buster)
+	second.Name = fmt.Sprintf("%s: GET %s with another %s parameter", name, endpoint.Path, buster)
+	second.Description = fmt.Sprintf("Test whether the %s query parameter is part of the cache key of %s", buster, endpoint.Path)
+	second.Matchers = []*APITestMatcher{cacheHitMatcher}
+	second.Dependencies = []*APITestCase{first}
+	return []*APITestCase{first, second}
+}
+
+// generateCachePoisoningTestCases generates web cache poisoning chains for GET requests
+func generateCachePoisoningTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	return cachePoisoningTestCases("Cache poisoning", endpoint, params, CacheBusterParam, DefaultUnkeyedHeaders())
+}
+
+// cachePoisoningTestCases returns a chain per header sending a GET request with the header and
+// a new cache buster parameter, then the same request without the header, expecting a 200 OK
+// response not reflecting the host sent in the header. Host headers carry a canary host and
+// scheme headers http, which applications commonly answer with a redirect.
+func cachePoisoningTestCases(name string, endpoint *DiscoveredEndpoint, params []*ExtractedParameter, buster string, headers []payload.CatalogHeader) []*APITestCase {
+	if endpoint.Method != "GET" {
+		return nil
+	}
+	testCases := make([]*APITestCase, 0)
+	for _, header := range headers {
+		value := "ffuf" + randomHex(4) + ".example.com"
+		if header.Category == payload.HeaderScheme {
+			value = "http"
+		}
+
+		poison := cacheStep(endpoint, params, buster)
+		poison.Name = fmt.Sprintf("%s: GET %s with %s", name, endpoint.Path, header.Name)
+		poison.Description = fmt.Sprintf("Send a GET request to %s with %s: %s", endpoint.Path, header.Name, header.Value(value))
+		poison.Headers[header.Name] = header.Value(value)
+		poison.ExpectedStatus = 0
+
+		clean := copyTestCase(poison)
+		delete(clean.Headers, header.Name)
+		clean.Name = fmt.Sprintf("%s: GET %s after a request with %s", name, endpoint.Path, header.Name)
+		clean.Description = fmt.Sprintf("Test whether a response to a request with the unkeyed %s header is cached and served to requests without it", header.Name)
+		clean.ExpectedStatus = 200
+		if header.Category != payload.HeaderScheme {
+			clean.Matchers = []*APITestMatcher{{Type: "word", Part: "all", Words: []string{value}, Negative: true}}
+		}
+		clean.Dependencies = []*APITestCase{poison}
+		testCases = append(testCases, poison, clean)
+	}
+	return testCases
+}
+
+// generateSensitiveCachingTestCases generates sensitive response caching test cases for the GET
+// requests of endpoints requiring authentication, or of every endpoint if the options have
+// authentication details
+func (g *APITestGenerator) generateSensitiveCachingTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	if !endpoint.RequiresAuth && g.Options.Auth == nil {
+		return nil
+	}
+	return sensitiveCachingTestCases("Sensitive response caching", endpoint, params)
+}
+
+// sensitiveCachingTestCases returns an authenticated GET request expecting a response that
+// shared caches may not store, without public or s-maxage in its Cache-Control header
+func sensitiveCachingTestCases(name string, endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	if endpoint.Method != "GET" {
+		return nil
+	}
+	testCase := cacheStep(endpoint, params, "")
+	testCase.Name = fmt.Sprintf("%s for GET %s", name, endpoint.Path)
+	testCase.Description = fmt.Sprintf("Test whether the authenticated response of %s may be stored by shared caches", endpoint.Path)
+	testCase.RequiresAuth = true
+	testCase.ExpectedStatus = 0
+	testCase.Matchers = []*APITestMatcher{publicCacheMatcher}
+	return []*APITestCase{testCase}
+}
+
+// cacheStep creates a request of a cache test case with valid parameters and a new value of
+// the cache buster parameter, if any
+func cacheStep(endpoint *DiscoveredEndpoint, params []*ExtractedParameter, buster string) *APITestCase {
+	testCase := generateValidRequestTestCases(endpoint, params)[0]
+	testCase.Category = "cache"
+	testCase.Priority = 2
+	testCase.MatchersCondition = "and"
+	if buster != "" {
+		testCase.QueryParams[buster] = randomHex(8)
+	}
+	return testCase
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+)
+
+func TestCachePoisoningTestCases(t *testing.T) {
+	endpoint := &DiscoveredEndpoint{Method: "GET", Path: "/items"}
+	headers := []payload.CatalogHeader{
+		{Name: "X-Forwarded-Host", Category: payload.HeaderHost},
+		{Name: "X-Forwarded-Scheme", Category: payload.HeaderScheme},
+	}
+	testCases := cachePoisoningTestCases("Cache poisoning", endpoint, nil, CacheBusterParam, headers)
+	if len(testCases) != 4 {
+		t.Fatalf("Expected a chain of 2 test cases per header, got %d", len(testCases))
+	}
+
+	poison, clean := testCases[0], testCases[1]
+	if poison.QueryParams[CacheBusterParam] == "" || clean.QueryParams[CacheBusterParam] != poison.QueryParams[CacheBusterParam] {
+		t.Errorf("Expected the chain to share a cache buster, got %v and %v", poison.QueryParams, clean.QueryParams)
+	}
+	canary := poison.Headers["X-Forwarded-Host"]
+	if !strings.HasSuffix(canary, ".example.com") {
+		t.Errorf("Expected a canary host, got %q", canary)
+	}
+	if _, ok := clean.Headers["X-Forwarded-Host"]; ok || len(clean.Dependencies) != 1 || clean.Dependencies[0] != poison {
+		t.Errorf("Expected the clean request to follow the poisoning one without the header")
+	}
+	if len(clean.Matchers) != 1 || !clean.Matchers[0].Negative || clean.Matchers[0].Words[0] != canary {
+		t.Errorf("Expected the canary not to be reflected, got %v", clean.Matchers)
+	}
+
+	scheme := testCases[3]
+	if testCases[2].Headers["X-Forwarded-Scheme"] != "http" || len(scheme.Matchers) != 0 || scheme.ExpectedStatus != 200 {
+		t.Errorf("Expected the scheme chain to expect the status of the response, got %v", scheme)
+	}
+	if len(cachePoisoningTestCases("Cache poisoning", &DiscoveredEndpoint{Method: "POST", Path: "/items"}, nil, CacheBusterParam, headers)) != 0 {
+		t.Errorf("Expected no test cases for POST requests")
+	}
+}
+
+func TestParseTestCaseTemplate_Cache(t *testing.T) {
+	data := `id: cache
+mode: cache
+cache-buster: cb
+unkeyed-headers: [X-Original-Host]
+`
+	template, err := ParseTestCaseTemplate([]byte(data), "yaml")
+	if err != nil {
+		t.Fatalf("ParseTestCaseTemplate() error = %v", err)
+	}
+	if template.Category != "cache" {
+		t.Errorf("Category = %q, want cache", template.Category)
+	}
+	testCases := template.Generator(&DiscoveredEndpoint{Method: "GET", Path: "/account", RequiresAuth: true}, nil)
+	if len(testCases) != 5 {
+		t.Fatalf("Expected the cache buster, poisoning and sensitive caching test cases, got %d", len(testCases))
+	}
+	if testCases[1].QueryParams["cb"] == "" || testCases[1].QueryParams["cb"] == testCases[0].QueryParams["cb"] {
+		t.Errorf("Expected the cache buster requests to use different values, got %v and %v", testCases[0].QueryParams, testCases[1].QueryParams)
+	}
+	if testCases[2].Headers["X-Original-Host"] == "" {
+		t.Errorf("Expected the unkeyed header to be sent, got %v", testCases[2].Headers)
+	}
+	if sensitive := testCases[4]; !sensitive.RequiresAuth || sensitive.Matchers[0] != publicCacheMatcher {
+		t.Errorf("Expected an authenticated request checking its Cache-Control, got %v", sensitive)
+	}
+}
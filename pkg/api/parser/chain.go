@@ -0,0 +1,286 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// APITestExtraction extracts a value from the response of a dependency of a test case.
+// Extracted values are referenced as ${name} in the URL, headers, parameters and body.
+type APITestExtraction struct {
+	// Name of the variable
+	Name string
+	// Test case to extract the value from (defaults to the first dependency)
+	From *APITestCase
+	// JSONPath expression evaluated against the JSON response body
+	JSONPath string
+	// Regular expression evaluated against the response body, or the header values, using the
+	// first capture group if any
+	Regex string
+	// Response header to extract the value from instead of the body
+	Header string
+}
+
+// Source returns the test case the value is extracted from
+func (e *APITestExtraction) Source(testCase *APITestCase) *APITestCase {
+	if e.From != nil {
+		return e.From
+	}
+	if len(testCase.Dependencies) > 0 {
+		return testCase.Dependencies[0]
+	}
+	return nil
+}
+
+// ExtractResponse extracts the value from a response header, or from the response body
+func (e *APITestExtraction) ExtractResponse(resp *ffuf.Response) (string, error) {
+	if e.Header == "" {
+		return e.Extract(resp.Data)
+	}
+	for name, values := range resp.Headers {
+		if !strings.EqualFold(name, e.Header) {
+			continue
+		}
+		for _, value := range values {
+			if e.Regex == "" {
+				return value, nil
+			}
+			if extracted, err := e.Extract([]byte(value)); err == nil {
+				return extracted, nil
+			}
+		}
+	}
+	return "", api.NewAPIError(fmt.Sprintf("Failed to extract '%s': no matching %s header", e.Name, e.Header), 0)
+}
+
+// Extract extracts the value from a response body
+func (e *APITestExtraction) Extract(body []byte) (string, error) {
+	if e.JSONPath != "" {
+		parser, err := NewJSONPathParser(body)
+		if err != nil {
+			return "", err
+		}
+		value, err := parser.EvaluateToString(e.JSONPath)
+		if err != nil {
+			return "", api.NewAPIError(fmt.Sprintf("Failed to extract '%s': %s", e.Name, err.Error()), 0)
+		}
+		return value, nil
+	}
+
+	if e.Regex != "" {
+		re, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return "", api.NewAPIError(fmt.Sprintf("Invalid regex for '%s': %s", e.Name, err.Error()), 0)
+		}
+		match := re.FindSubmatch(body)
+		if match == nil {
+			return "", api.NewAPIError(fmt.Sprintf("Failed to extract '%s': no match for %s", e.Name, e.Regex), 0)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	}
+
+	return "", api.NewAPIError(fmt.Sprintf("Extraction '%s' has no JSONPath or regex", e.Name), 0)
+}
+
+// APITestAssertion compares a value extracted from the response of a test case with an
+// expected value, which may reference the variables of the test case as ${name}
+type APITestAssertion struct {
+	// Extraction of the value from the response of the test case, its From is not used
+	APITestExtraction
+	// Value expected
+	Value string
+	// Whether the extracted value is expected to differ from Value instead
+	NotEqual bool
+}
+
+// Check returns a description of the failed assertion, or an empty string if the response
+// satisfies it
+func (a *APITestAssertion) Check(resp *ffuf.Response) string {
+	value, err := a.ExtractResponse(resp)
+	if err != nil {
+		return err.Error()
+	}
+	if a.NotEqual && value == a.Value {
+		return fmt.Sprintf("expected '%s' to differ from %q", a.Name, a.Value)
+	}
+	if !a.NotEqual && value != a.Value {
+		return fmt.Sprintf("expected '%s' to be %q, got %q", a.Name, a.Value, value)
+	}
+	return ""
+}
+
+// Prerequisites returns the test cases that must complete before the test case,
+// which are its dependencies and the sources of its extractions
+func (t *APITestCase) Prerequisites() []*APITestCase {
+	prerequisites := make([]*APITestCase, 0, len(t.Dependencies)+len(t.Extractions))
+	seen := make(map[*APITestCase]bool)
+	for _, dep := range t.Dependencies {
+		if !seen[dep] {
+			prerequisites = append(prerequisites, dep)
+			seen[dep] = true
+		}
+	}
+	for _, extraction := range t.Extractions {
+		if source := extraction.Source(t); source != nil && !seen[source] {
+			prerequisites = append(prerequisites, source)
+			seen[source] = true
+		}
+	}
+	return prerequisites
+}
+
+// ApplyVariables returns a copy of the test case with ${name} references in the URL,
+// headers, parameters, body and assertions replaced by the variable values
+func (t *APITestCase) ApplyVariables(variables map[string]string) *APITestCase {
+	replacements := make([]string, 0, len(variables)*2)
+	for name, value := range variables {
+		replacements = append(replacements, "${"+name+"}", value)
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	replaceMap := func(m map[string]string) map[string]string {
+		if m == nil {
+			return nil
+		}
+		result := make(map[string]string, len(m))
+		for key, value := range m {
+			result[key] = replacer.Replace(value)
+		}
+		return result
+	}
+
+	applied := *t
+	applied.URL = replacer.Replace(t.URL)
+	applied.Headers = replaceMap(t.Headers)
+	applied.QueryParams = replaceMap(t.QueryParams)
+	applied.PathParams = replaceMap(t.PathParams)
+	applied.Body = replacer.Replace(t.Body)
+	if t.Assertions != nil {
+		applied.Assertions = make([]*APITestAssertion, len(t.Assertions))
+		for i, assertion := range t.Assertions {
+			resolved := *assertion
+			resolved.Value = replacer.Replace(assertion.Value)
+			applied.Assertions[i] = &resolved
+		}
+	}
+	return &applied
+}
+
+// GenerateCRUDChains generates chained test cases for resources that can be created,
+// read, updated and deleted. A chain creates a resource on a collection path
+// (e.g., POST /users), extracts its identifier from the response and uses it for the
+// operations on the item path (e.g., GET, PUT, PATCH and DELETE /users/{id}).
+func (g *APITestGenerator) GenerateCRUDChains() []*APITestCase {
+	testCases := make([]*APITestCase, 0)
+	if g.Discovery == nil {
+		return testCases
+	}
+	if g.Extractor == nil {
+		g.Extractor = NewAPIParameterExtractor(g.Discovery)
+		if err := g.Extractor.ExtractParameters(); err != nil {
+			return testCases
+		}
+	}
+
+	// Group item endpoints by their collection path
+	items := make(map[string][]*DiscoveredEndpoint)
+	for _, endpoint := range g.Discovery.GetEndpoints() {
+		if collection, idParam := splitItemPath(endpoint.Path); collection != "" && idParam != "" {
+			items[collection] = append(items[collection], endpoint)
+		}
+	}
+
+	for _, create := range g.Discovery.GetEndpoints() {
+		if create.Method != "POST" || len(items[create.Path]) == 0 {
+			continue
+		}
+		if create.RequiresAuth && !g.Options.IncludeAuthEndpoints {
+			continue
+		}
+
+		createCase := g.chainStep(create, 201)
+		createCase.Name = fmt.Sprintf("Chain: create %s", create.Path)
+		chain := []*APITestCase{createCase}
+
+		// Order the item operations as read, update, delete
+		operations := items[create.Path]
+		sort.SliceStable(operations, func(i, j int) bool {
+			return crudOrder(operations[i].Method) < crudOrder(operations[j].Method)
+		})
+		for _, endpoint := range operations {
+			if crudOrder(endpoint.Method) > 3 || (endpoint.RequiresAuth && !g.Options.IncludeAuthEndpoints) {
+				continue
+			}
+			_, idParam := splitItemPath(endpoint.Path)
+
+			expectedStatus := 200
+			if endpoint.Method == "DELETE" {
+				expectedStatus = 204
+			}
+			testCase := g.chainStep(endpoint, expectedStatus)
+			testCase.Name = fmt.Sprintf("Chain: %s %s", endpoint.Method, endpoint.Path)
+			testCase.PathParams[idParam] = "${" + idParam + "}"
+			testCase.Dependencies = []*APITestCase{chain[len(chain)-1]}
+			testCase.Extractions = []*APITestExtraction{
+				{Name: idParam, From: createCase, JSONPath: "$.id"},
+			}
+			chain = append(chain, testCase)
+		}
+
+		if len(chain) > 1 {
+			testCases = append(testCases, chain...)
+		}
+	}
+
+	return testCases
+}
+
+// chainStep creates a chained test case with valid parameters for an endpoint
+func (g *APITestGenerator) chainStep(endpoint *DiscoveredEndpoint, expectedStatus int) *APITestCase {
+	testCase := generateValidRequestTestCases(endpoint, g.endpointParameters(endpoint))[0]
+	testCase.Description = fmt.Sprintf("Chained %s request to %s", endpoint.Method, endpoint.Path)
+	testCase.ExpectedStatus = expectedStatus
+	testCase.Category = "chain"
+	g.completeTestCase(testCase)
+	return testCase
+}
+
+// splitItemPath splits an item path like /users/{id} into its collection path and identifier parameter
+func splitItemPath(path string) (string, string) {
+	trimmed := strings.TrimSuffix(path, "/")
+	i := strings.LastIndex(trimmed, "/")
+	if i < 0 {
+		return "", ""
+	}
+	last := trimmed[i+1:]
+	if !strings.HasPrefix(last, "{") || !strings.HasSuffix(last, "}") {
+		return "", ""
+	}
+	collection := trimmed[:i]
+	if collection == "" {
+		collection = "/"
+	}
+	return collection, strings.Trim(last, "{}")
+}
+
+// crudOrder returns the position of a method in a create, read, update, delete chain
+func crudOrder(method string) int {
+	switch method {
+	case "GET":
+		return 1
+	case "PUT", "PATCH":
+		return 2
+	case "DELETE":
+		return 3
+	}
+	return 4
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestAPITestExtraction_Extract(t *testing.T) {
+	body := []byte(`{"data": {"id": 42, "token": "abc"}}`)
+
+	tests := []struct {
+		name       string
+		extraction *APITestExtraction
+		want       string
+		wantErr    bool
+	}{
+		{"jsonpath", &APITestExtraction{Name: "id", JSONPath: "$.data.id"}, "42", false},
+		{"regex group", &APITestExtraction{Name: "token", Regex: `"token": "(\w+)"`}, "abc", false},
+		{"regex match", &APITestExtraction{Name: "token", Regex: `\d+`}, "42", false},
+		{"missing path", &APITestExtraction{Name: "id", JSONPath: "$.missing.id"}, "", true},
+		{"no match", &APITestExtraction{Name: "id", Regex: `uuid`}, "", true},
+		{"no expression", &APITestExtraction{Name: "id"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.extraction.Extract(body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Extract() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Extract() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPITestCase_ApplyVariables(t *testing.T) {
+	testCase := &APITestCase{
+		URL:        "https://api.example.com/users/${id}",
+		Headers:    map[string]string{"Authorization": "Bearer ${token}"},
+		PathParams: map[string]string{"id": "${id}"},
+		Body:       `{"owner": "${id}"}`,
+	}
+
+	applied := testCase.ApplyVariables(map[string]string{"id": "42", "token": "abc"})
+	if applied.URL != "https://api.example.com/users/42" || applied.Headers["Authorization"] != "Bearer abc" ||
+		applied.PathParams["id"] != "42" || applied.Body != `{"owner": "42"}` {
+		t.Errorf("Unexpected applied test case %+v", applied)
+	}
+	if testCase.PathParams["id"] != "${id}" {
+		t.Error("Expected original test case to be unchanged")
+	}
+}
+
+func TestAPITestGenerator_GenerateCRUDChains(t *testing.T) {
+	discovery := NewAPIEndpointDiscovery("https://api.example.com")
+	idParam := &DiscoveredParameter{Name: "userId", In: "path", Required: true, Type: "integer"}
+	discovery.Endpoints = []*DiscoveredEndpoint{
+		{Method: "DELETE", Path: "/users/{userId}", Parameters: []*DiscoveredParameter{idParam}},
+		{Method: "GET", Path: "/users/{userId}", Parameters: []*DiscoveredParameter{idParam}},
+		{Method: "POST", Path: "/users", Parameters: []*DiscoveredParameter{{Name: "name", In: "body", Type: "string"}}},
+		{Method: "PUT", Path: "/users/{userId}", Parameters: []*DiscoveredParameter{idParam}},
+		{Method: "GET", Path: "/orders/{orderId}"},
+	}
+
+	generator := NewAPITestGenerator(discovery, nil)
+	generator.Options.BaseURL = discovery.BaseURL
+	chain := generator.GenerateCRUDChains()
+	if len(chain) != 4 {
+		t.Fatalf("Expected 4 chained test cases, got %d", len(chain))
+	}
+
+	wantMethods := []string{"POST", "GET", "PUT", "DELETE"}
+	for i, testCase := range chain {
+		if testCase.Method != wantMethods[i] {
+			t.Errorf("Step %d: expected %s, got %s", i, wantMethods[i], testCase.Method)
+		}
+		if testCase.Category != "chain" {
+			t.Errorf("Step %d: expected chain category, got %s", i, testCase.Category)
+		}
+		if i == 0 {
+			continue
+		}
+		if len(testCase.Dependencies) != 1 || testCase.Dependencies[0] != chain[i-1] {
+			t.Errorf("Step %d: expected dependency on previous step", i)
+		}
+		if len(testCase.Extractions) != 1 || testCase.Extractions[0].From != chain[0] || testCase.PathParams["userId"] != "${userId}" {
+			t.Errorf("Step %d: expected userId extraction from create step", i)
+		}
+	}
+	if chain[0].Body == "" {
+		t.Errorf("Expected create body, got %q", chain[0].Body)
+	}
+}
@@ -2,7 +2,6 @@
 package parser
 
 import (
-	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -25,6 +24,12 @@ const (
 	CorrelationTypeParentChild CorrelationType = "parent_child"
 	// CorrelationTypeSequence represents a sequential relationship
 	CorrelationTypeSequence CorrelationType = "sequence"
+	// CorrelationTypeCSRF represents an anti-CSRF token sent back in later requests
+	CorrelationTypeCSRF CorrelationType = "csrf"
+	// CorrelationTypeSession represents a session identifier sent back in later requests
+	CorrelationTypeSession CorrelationType = "session"
+	// CorrelationTypeToken represents an authentication token sent back in later requests
+	CorrelationTypeToken CorrelationType = "token"
 	// CorrelationTypeUnknown represents an unknown correlation type
 	CorrelationTypeUnknown CorrelationType = "unknown"
 )
@@ -146,10 +151,10 @@ func (s *APISession) ExtractValue(responseID, jsonPath, name string) (string, er
 		return "", api.NewAPIError(fmt.Sprintf("Response with ID %s not found", responseID), 0)
 	}
 
-	// Parse the response body as JSON
-	var jsonData interface{}
-	if err := json.Unmarshal(resp.Data, &jsonData); err != nil {
-		return "", api.NewAPIError("Failed to parse JSON: "+err.Error(), 0)
+	// Parse the response body as JSON, or the data of an XML or HTML response
+	jsonData, err := NewResponseParser(resp.ContentType).ParseData(resp.Data)
+	if err != nil {
+		return "", err
 	}
 
 	// Create a JSONPath parser
@@ -228,14 +233,9 @@ func (d *CorrelationDetector) DetectCorrelations(sessionID string) ([]Correlatio
 
 	// Process each response
 	for i, resp1 := range responses {
-		// Skip if not JSON
-		if !strings.Contains(resp1.ContentType, "application/json") {
-			continue
-		}
-
-		// Parse the response body as JSON
-		var jsonData1 interface{}
-		if err := json.Unmarshal(resp1.Data, &jsonData1); err != nil {
+		// Skip if not JSON, XML or HTML
+		jsonData1, err := parseStructuredResponse(resp1)
+		if err != nil {
 			continue
 		}
 
@@ -249,14 +249,9 @@ func (d *CorrelationDetector) DetectCorrelations(sessionID string) ([]Correlatio
 				continue
 			}
 
-			// Skip if not JSON
-			if !strings.Contains(resp2.ContentType, "application/json") {
-				continue
-			}
-
-			// Parse the response body as JSON
-			var jsonData2 interface{}
-			if err := json.Unmarshal(resp2.Data, &jsonData2); err != nil {
+			// Skip if not JSON, XML or HTML
+			jsonData2, err := parseStructuredResponse(resp2)
+			if err != nil {
 				continue
 			}
 
@@ -0,0 +1,227 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// untrustedOrigin is an origin no CORS policy allows
+const untrustedOrigin = "https://ffuf-untrusted.example"
+
+// undeclaredRequestHeader is a request header no CORS policy allows
+const undeclaredRequestHeader = "X-Ffuf-Undeclared"
+
+// CORSPolicy is the cross-origin resource sharing policy declared for an API, which the
+// responses of CORS preflight test cases are compared with
+type CORSPolicy struct {
+	// Origins allowed to send cross-origin requests, "*" allowing any origin
+	AllowedOrigins []string `yaml:"allowed-origins" json:"allowed-origins"`
+	// Methods allowed in cross-origin requests (defaults to the documented methods of the path)
+	AllowedMethods []string `yaml:"allowed-methods" json:"allowed-methods"`
+	// Request headers allowed in cross-origin requests
+	AllowedHeaders []string `yaml:"allowed-headers" json:"allowed-headers"`
+	// Whether cross-origin requests may include credentials
+	AllowCredentials bool `yaml:"allow-credentials" json:"allow-credentials"`
+}
+
+// allowsOrigin returns true if the policy allows an origin
+func (p *CORSPolicy) allowsOrigin(origin string) bool {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsAnyHeader returns true if the policy allows any request header
+func (p *CORSPolicy) allowsAnyHeader() bool {
+	for _, header := range p.AllowedHeaders {
+		if header == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// generateCORSPreflightTestCases generates the CORS preflight test cases of an endpoint for
+// the CORS policy of the options, allowing the documented methods of its path
+func (g *APITestGenerator) generateCORSPreflightTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	policy := &CORSPolicy{}
+	if g.Options.CORSPolicy != nil {
+		policy = g.Options.CORSPolicy
+	}
+	methods := policy.AllowedMethods
+	if len(methods) == 0 && g.Discovery != nil {
+		for _, other := range g.Discovery.GetEndpoints() {
+			if other.Path == endpoint.Path && isHTTPMethod(other.Method) {
+				methods = appendUnique(methods, other.Method)
+			}
+		}
+	}
+	target := endpoint.URL
+	if target == "" {
+		target = g.Options.BaseURL
+	}
+	return corsPreflightTestCases("CORS preflight", endpoint, params, policy, methods, target)
+}
+
+// corsPreflightTestCases returns OPTIONS preflight requests for an endpoint, asserting that
+// the responses follow a CORS policy. Preflights from the allowed origins expect the origin,
+// the method, the allowed headers and the credentials to be allowed. Preflights from an
+// untrusted origin, the null origin, and origins that only start or end like an allowed
+// one, or the target, or downgrade it to HTTP, expect the origin not to be allowed. Preflights
+// for a method and a request header the policy does not declare expect them not to be
+// allowed.
+func corsPreflightTestCases(name string, endpoint *DiscoveredEndpoint, params []*ExtractedParameter, policy *CORSPolicy, methods []string, target string) []*APITestCase {
+	testCases := make([]*APITestCase, 0)
+	pathParams := make(map[string]string)
+	for _, param := range params {
+		if param.In == "path" {
+			pathParams[param.Name] = getExampleValue(param)
+		}
+	}
+	newPreflight := func(title, description, origin, method string, headers []string) *APITestCase {
+		testCase := &APITestCase{
+			Name:        fmt.Sprintf("%s (%s) for %s %s", name, title, endpoint.Method, endpoint.Path),
+			Description: description,
+			Method:      "OPTIONS",
+			Path:        endpoint.Path,
+			Headers: map[string]string{
+				"Origin":                        origin,
+				"Access-Control-Request-Method": method,
+			},
+			QueryParams:       make(map[string]string),
+			PathParams:        copyStringMap(pathParams),
+			Category:          "security",
+			Priority:          2,
+			MatchersCondition: "and",
+		}
+		if len(headers) > 0 {
+			testCase.Headers["Access-Control-Request-Headers"] = strings.ToLower(strings.Join(headers, ", "))
+		}
+		testCases = append(testCases, testCase)
+		return testCase
+	}
+
+	// The allowed origins are granted the method, headers and credentials of the policy
+	trusted := ""
+	for _, origin := range policy.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		if trusted == "" {
+			trusted = origin
+		}
+		testCase := newPreflight("allowed origin "+origin, fmt.Sprintf("Test that the preflight of a %s request from the allowed origin %s is allowed", endpoint.Method, origin), origin, endpoint.Method, policy.AllowedHeaders)
+		testCase.Matchers = []*APITestMatcher{{Type: "status", Status: []int{200, 204}}}
+		testCase.Assertions = []*APITestAssertion{{
+			APITestExtraction: APITestExtraction{Name: "Access-Control-Allow-Origin", Header: "Access-Control-Allow-Origin"},
+			Value:             origin,
+		}}
+		if !isSimpleMethod(endpoint.Method) {
+			testCase.Matchers = append(testCase.Matchers, corsHeaderMatcher("Access-Control-Allow-Methods", endpoint.Method, false))
+		}
+		for _, header := range policy.AllowedHeaders {
+			if header != "*" {
+				testCase.Matchers = append(testCase.Matchers, corsHeaderMatcher("Access-Control-Allow-Headers", header, false))
+			}
+		}
+		if policy.AllowCredentials {
+			testCase.Assertions = append(testCase.Assertions, &APITestAssertion{
+				APITestExtraction: APITestExtraction{Name: "Access-Control-Allow-Credentials", Header: "Access-Control-Allow-Credentials"},
+				Value:             "true",
+			})
+		} else {
+			testCase.Matchers = append(testCase.Matchers, credentialsMatcher())
+		}
+	}
+
+	// Other origins are denied, only without credentials if the policy allows any origin
+	origins := []string{untrustedOrigin, "null"}
+	reference := trusted
+	if reference == "" {
+		reference = target
+	}
+	if u, err := url.Parse(reference); err == nil && u.Host != "" {
+		origins = append(origins, "https://"+u.Hostname()+".ffuf-untrusted.example", "https://ffuf-untrusted"+u.Hostname())
+		if u.Scheme == "https" {
+			origins = append(origins, "http://"+u.Host)
+		}
+	}
+	for _, origin := range origins {
+		if policy.allowsOrigin(origin) && !policy.allowsOrigin(untrustedOrigin) {
+			continue
+		}
+		testCase := newPreflight("origin "+origin, fmt.Sprintf("Test that the preflight of a %s request from the origin %s is not allowed", endpoint.Method, origin), origin, endpoint.Method, nil)
+		if policy.allowsOrigin(origin) {
+			testCase.Description = fmt.Sprintf("Test that the preflight of a %s request from the origin %s is not allowed with credentials", endpoint.Method, origin)
+			testCase.Matchers = []*APITestMatcher{credentialsMatcher()}
+			continue
+		}
+		testCase.Matchers = []*APITestMatcher{{
+			Type:     "regex",
+			Part:     "header",
+			Regex:    []string{`(?im)^Access-Control-Allow-Origin:\s*(\*|` + regexp.QuoteMeta(origin) + `)\s*$`},
+			Negative: true,
+		}}
+	}
+
+	// Undeclared methods and request headers are not allowed to the allowed origins
+	origin := trusted
+	if origin == "" {
+		origin = untrustedOrigin
+	}
+	for _, method := range []string{"PUT", "DELETE", "PATCH"} {
+		if containsFold(methods, method) {
+			continue
+		}
+		testCase := newPreflight("undeclared method "+method, fmt.Sprintf("Test that the undeclared %s method is not allowed in cross-origin requests", method), origin, method, nil)
+		testCase.Matchers = []*APITestMatcher{corsHeaderMatcher("Access-Control-Allow-Methods", method, true)}
+		break
+	}
+	if !policy.allowsAnyHeader() {
+		testCase := newPreflight("undeclared header", fmt.Sprintf("Test that the undeclared %s request header is not allowed in cross-origin requests", undeclaredRequestHeader), origin, endpoint.Method, []string{undeclaredRequestHeader})
+		testCase.Matchers = []*APITestMatcher{corsHeaderMatcher("Access-Control-Allow-Headers", undeclaredRequestHeader, true)}
+	}
+	return testCases
+}
+
+// corsHeaderMatcher returns a matcher of a CORS response header listing a value or *, or not
+// listing them if negative
+func corsHeaderMatcher(header, value string, negative bool) *APITestMatcher {
+	return &APITestMatcher{
+		Type:     "regex",
+		Part:     "header",
+		Regex:    []string{`(?im)^` + header + `:.*(\*|\b` + regexp.QuoteMeta(value) + `\b)`},
+		Negative: negative,
+	}
+}
+
+// credentialsMatcher returns a matcher failing responses allowing credentials
+func credentialsMatcher() *APITestMatcher {
+	return &APITestMatcher{
+		Type:     "regex",
+		Part:     "header",
+		Regex:    []string{`(?im)^Access-Control-Allow-Credentials:\s*true\s*$`},
+		Negative: true,
+	}
+}
+
+// isSimpleMethod returns true for the methods browsers send cross-origin without asking for
+// them in a preflight
+func isSimpleMethod(method string) bool {
+	return method == "GET" || method == "HEAD" || method == "POST"
+}
+
+// containsFold returns true if a list contains a value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
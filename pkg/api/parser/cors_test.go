@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCORSPreflightTestCases(t *testing.T) {
+	endpoint := &DiscoveredEndpoint{Method: "PUT", Path: "/users/{id}", URL: "https://api.example.com/users/{id}"}
+	params := []*ExtractedParameter{{Name: "id", In: "path", Type: "integer", Example: 7}}
+	policy := &CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedHeaders:   []string{"Authorization"},
+		AllowCredentials: true,
+	}
+	testCases := corsPreflightTestCases("CORS preflight", endpoint, params, policy, []string{"GET", "PUT"}, endpoint.URL)
+
+	byName := make(map[string]*APITestCase)
+	for _, testCase := range testCases {
+		if testCase.Method != "OPTIONS" || testCase.PathParams["id"] != "7" {
+			t.Errorf("Test case %q is a %s request with path params %v", testCase.Name, testCase.Method, testCase.PathParams)
+		}
+		byName[testCase.Name] = testCase
+	}
+
+	allowed, ok := byName["CORS preflight (allowed origin https://app.example.com) for PUT /users/{id}"]
+	if !ok {
+		t.Fatalf("No preflight from the allowed origin in %v", testCases)
+	}
+	if allowed.Headers["Access-Control-Request-Headers"] != "authorization" {
+		t.Errorf("Access-Control-Request-Headers = %q, want the allowed headers", allowed.Headers["Access-Control-Request-Headers"])
+	}
+	if len(allowed.Assertions) != 2 || allowed.Assertions[0].Value != "https://app.example.com" || allowed.Assertions[1].Value != "true" {
+		t.Errorf("Expected the allowed origin and credentials to be asserted, got %v", allowed.Assertions)
+	}
+	for _, origin := range []string{untrustedOrigin, "null", "https://app.example.com.ffuf-untrusted.example", "https://ffuf-untrustedapp.example.com", "http://app.example.com"} {
+		testCase, ok := byName["CORS preflight (origin "+origin+") for PUT /users/{id}"]
+		if !ok {
+			t.Errorf("No preflight from the origin %s", origin)
+			continue
+		}
+		if len(testCase.Matchers) != 1 || !testCase.Matchers[0].Negative {
+			t.Errorf("Expected the origin %s not to be allowed, got %v", origin, testCase.Matchers)
+		}
+	}
+	if testCase, ok := byName["CORS preflight (undeclared method DELETE) for PUT /users/{id}"]; !ok || testCase.Headers["Access-Control-Request-Method"] != "DELETE" {
+		t.Errorf("No preflight for the undeclared DELETE method")
+	}
+	if _, ok := byName["CORS preflight (undeclared method PATCH) for PUT /users/{id}"]; ok {
+		t.Errorf("Expected a single undeclared method preflight")
+	}
+	if testCase, ok := byName["CORS preflight (undeclared header) for PUT /users/{id}"]; !ok || testCase.Headers["Access-Control-Request-Headers"] != "x-ffuf-undeclared" {
+		t.Errorf("No preflight for an undeclared request header")
+	}
+}
+
+func TestCORSPreflightTestCases_AnyOrigin(t *testing.T) {
+	endpoint := &DiscoveredEndpoint{Method: "GET", Path: "/items"}
+	policy := &CORSPolicy{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}}
+	testCases := corsPreflightTestCases("CORS preflight", endpoint, nil, policy, []string{"GET"}, "")
+
+	for _, testCase := range testCases {
+		if testCase.Headers["Access-Control-Request-Headers"] != "" {
+			t.Errorf("Expected no undeclared header preflight when any header is allowed, got %q", testCase.Name)
+		}
+		if !strings.Contains(testCase.Name, "(origin ") {
+			continue
+		}
+		for _, matcher := range testCase.Matchers {
+			if !strings.Contains(matcher.Regex[0], "Credentials") {
+				t.Errorf("Expected any origin to be allowed without credentials, got %v", matcher.Regex)
+			}
+		}
+	}
+}
+
+func TestParseTestCaseTemplate_CORS(t *testing.T) {
+	data := `id: cors
+mode: cors
+cors:
+  allowed-origins: [https://app.example.com]
+  allowed-methods: [GET, POST, DELETE]
+matchers:
+  - type: status
+    status: [500]
+    negative: true
+`
+	template, err := ParseTestCaseTemplate([]byte(data), "yaml")
+	if err != nil {
+		t.Fatalf("ParseTestCaseTemplate() error = %v", err)
+	}
+	testCases := template.Generator(&DiscoveredEndpoint{Method: "DELETE", Path: "/items/{id}"}, nil)
+	if len(testCases) == 0 {
+		t.Fatal("Expected CORS preflight test cases")
+	}
+	for _, testCase := range testCases {
+		if testCase.Category != "security" || testCase.Matchers[len(testCase.Matchers)-1].Status[0] != 500 {
+			t.Errorf("Test case %q has category %q and matchers %v", testCase.Name, testCase.Category, testCase.Matchers)
+		}
+		if strings.Contains(testCase.Name, "allowed origin") && len(testCase.Assertions) == 0 {
+			t.Errorf("Expected the allowed origin to be asserted in %q", testCase.Name)
+		}
+		if testCase.Headers["Access-Control-Request-Method"] == "POST" {
+			t.Errorf("Expected no preflight for the declared POST method, got %q", testCase.Name)
+		}
+	}
+}
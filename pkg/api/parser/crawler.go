@@ -0,0 +1,508 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// Kinds of references to API endpoints found by the crawler
+const (
+	// CrawlKindFetch is a call to fetch()
+	CrawlKindFetch = "fetch"
+	// CrawlKindAxios is a call to an axios-like HTTP client method (axios.get, $http.post, ...)
+	CrawlKindAxios = "axios"
+	// CrawlKindXHR is a call to XMLHttpRequest.open()
+	CrawlKindXHR = "xhr"
+	// CrawlKindRequest is a request options object with a url property ($.ajax, axios({...}))
+	CrawlKindRequest = "request"
+	// CrawlKindRoute is a client-side route definition
+	CrawlKindRoute = "route"
+	// CrawlKindPath is a string literal shaped like an API path or URL
+	CrawlKindPath = "path"
+	// CrawlKindForm is the action of an HTML form
+	CrawlKindForm = "form"
+)
+
+var (
+	// crawlQuote matches a JavaScript string literal, its content being the second group
+	crawlQuote = "([\"'`])([^\"'`\\s<>]*)[\"'`]"
+	// crawlFetchPattern matches fetch() calls with a literal URL and their options
+	crawlFetchPattern = regexp.MustCompile(`\bfetch\(\s*` + crawlQuote + `(\s*\+)?(\s*,\s*\{[^{}]{0,300})?`)
+	// crawlClientPattern matches HTTP client method calls with a literal URL
+	crawlClientPattern = regexp.MustCompile(`(?i)\.(get|post|put|patch|delete|head|options|getJSON)\(\s*` + crawlQuote + `(\s*\+)?`)
+	// crawlXHRPattern matches XMLHttpRequest.open() calls with a literal URL
+	crawlXHRPattern = regexp.MustCompile(`(?i)\.open\(\s*["'](GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS)["']\s*,\s*` + crawlQuote + `(\s*\+)?`)
+	// crawlRequestPattern matches request options objects with a literal url property
+	crawlRequestPattern = regexp.MustCompile(`\{[^{}]{0,300}?\burl\s*:\s*` + crawlQuote + `(\s*\+)?[^{}]{0,300}\}`)
+	// crawlMethodPattern matches the method of a request options object
+	crawlMethodPattern = regexp.MustCompile(`(?i)\b(?:method|type)\s*:\s*["'](GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS)["']`)
+	// crawlRoutePattern matches route definitions of client-side routers (Angular, Vue, React Router)
+	crawlRoutePattern = regexp.MustCompile(`(?:\bpath\s*:\s*|<Route[^>]+path=\{?)["'](/?[A-Za-z0-9_\-./:*{}]*)["']`)
+	// crawlPathPattern matches string literals shaped like API paths or URLs
+	crawlPathPattern = regexp.MustCompile("[\"'`]((?:https?://[^\"'`\\s<>/]+)?/?(?:api|rest|graphql|v[0-9]+|services?|rpc|odata)(?:/[^\"'`\\s<>]*)?)[\"'`](\\s*\\+)?")
+	// crawlScriptPattern matches the scripts of HTML pages
+	crawlScriptPattern = regexp.MustCompile(`(?is)<script([^>]*)>(.*?)</script>`)
+	// crawlAttributePattern matches the attributes of HTML tags referencing other resources
+	crawlAttributePattern = regexp.MustCompile(`(?i)\b(src|href|action)\s*=\s*["']([^"']+)["']`)
+	// crawlFormPattern matches the opening tags of HTML forms
+	crawlFormPattern = regexp.MustCompile(`(?i)<form([^>]*)>`)
+	// crawlFormMethodPattern matches the method of an HTML form
+	crawlFormMethodPattern = regexp.MustCompile(`(?i)\bmethod\s*=\s*["']?(\w+)`)
+	// crawlLinkPattern matches the tags of HTML pages linking to scripts and pages
+	crawlLinkPattern = regexp.MustCompile(`(?i)<(a|link)\b([^>]*)>`)
+	// crawlImportPattern matches the static and dynamic imports and chunk names of JavaScript bundles
+	crawlImportPattern = regexp.MustCompile("[\"'`]([^\"'`\\s<>()]+\\.m?js)[\"'`]")
+	// crawlTemplatePattern matches the expressions of template literals
+	crawlTemplatePattern = regexp.MustCompile(`\$\{([^{}]*)\}`)
+	// crawlRouteParamPattern matches the :name parameters of route definitions
+	crawlRouteParamPattern = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)\??`)
+	// crawlValidPathPattern matches the characters allowed in crawled paths
+	crawlValidPathPattern = regexp.MustCompile(`^/[A-Za-z0-9_\-./{}~%@!$&'()*+,;=:]*$`)
+)
+
+// JSCrawler discovers API endpoints referenced by the HTML pages and JavaScript bundles of a
+// web application, such as a single page application without an API specification
+type JSCrawler struct {
+	// URL of the first page to crawl
+	StartURL string
+	// Hosts, in addition to the host of the start URL, whose pages, scripts and endpoints are kept
+	Hosts []string
+	// Headers sent with every request (e.g., Cookie)
+	Headers map[string]string
+	// Maximum number of pages and scripts to fetch
+	MaxPages int
+	// Maximum number of links followed from the start URL
+	MaxDepth int
+	// Maximum size of a fetched page or script
+	MaxBodySize int64
+	// HTTP client used to fetch pages and scripts
+	Client *http.Client
+	// Endpoints found, one per method and path
+	Endpoints []*CrawledEndpoint
+
+	base    *url.URL
+	index   map[string]*CrawledEndpoint
+	queued  map[string]bool
+	fetched int
+}
+
+// CrawledEndpoint represents an API endpoint referenced by a page or script
+type CrawledEndpoint struct {
+	// HTTP method, GET when not known from the reference
+	Method string
+	// Path with template expressions, concatenated values and route parameters replaced by {name} placeholders
+	Path string
+	// Scheme and host the endpoint is requested on
+	Origin string
+	// Path and query parameters
+	Parameters []*DiscoveredParameter
+	// Kinds of references found (fetch, axios, xhr, request, route, path, form)
+	Kinds []string
+	// URLs of the pages and scripts referencing the endpoint
+	Sources []string
+}
+
+// crawlItem is a page or script waiting to be fetched
+type crawlItem struct {
+	url   string
+	depth int
+}
+
+// NewJSCrawler creates a new JSCrawler
+func NewJSCrawler(startURL string) *JSCrawler {
+	return &JSCrawler{
+		StartURL:    startURL,
+		Hosts:       make([]string, 0),
+		Headers:     make(map[string]string),
+		MaxPages:    50,
+		MaxDepth:    2,
+		MaxBodySize: 5 * 1024 * 1024,
+		Client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		Endpoints: make([]*CrawledEndpoint, 0),
+		index:     make(map[string]*CrawledEndpoint),
+		queued:    make(map[string]bool),
+	}
+}
+
+// Crawl fetches the start URL and the pages and scripts it references, breadth first, and
+// extracts the API endpoints they reference
+func (c *JSCrawler) Crawl() error {
+	base, err := url.Parse(c.StartURL)
+	if err != nil || base.Host == "" {
+		return api.NewAPIError(fmt.Sprintf("Invalid start URL: %s", c.StartURL), 0)
+	}
+	c.base = base
+
+	queue := []crawlItem{{url: base.String()}}
+	c.queued[base.String()] = true
+	for len(queue) > 0 && c.fetched < c.MaxPages {
+		item := queue[0]
+		queue = queue[1:]
+
+		data, contentType, err := c.fetch(item.url)
+		if err != nil {
+			if item.depth == 0 {
+				return err
+			}
+			continue
+		}
+
+		var links []string
+		if isScript(item.url, contentType) {
+			links = c.ParseScript(item.url, data)
+		} else {
+			links = c.ParseHTML(item.url, data)
+		}
+		if item.depth >= c.MaxDepth {
+			continue
+		}
+		for _, link := range links {
+			if !c.queued[link] {
+				c.queued[link] = true
+				queue = append(queue, crawlItem{url: link, depth: item.depth + 1})
+			}
+		}
+	}
+	return nil
+}
+
+// fetch fetches a page or script and returns its body and content type
+func (c *JSCrawler) fetch(target string) ([]byte, string, error) {
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, "", api.NewAPIError(fmt.Sprintf("Failed to create request: %s", err.Error()), 0)
+	}
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+	c.fetched++
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, "", api.NewAPIError(fmt.Sprintf("Failed to fetch %s: %s", target, err.Error()), 0)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", api.NewAPIError(fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, resp.Status), 0)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.MaxBodySize))
+	if err != nil {
+		return nil, "", api.NewAPIError(fmt.Sprintf("Failed to read response body: %s", err.Error()), 0)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// ParseHTML extracts the API endpoints referenced by an HTML page and its inline scripts, and
+// returns the URLs of the scripts and pages it links to
+func (c *JSCrawler) ParseHTML(pageURL string, data []byte) []string {
+	page := string(data)
+	scripts := make([]string, 0)
+	pages := make([]string, 0)
+
+	for _, match := range crawlScriptPattern.FindAllStringSubmatch(page, -1) {
+		if src := crawlAttributePattern.FindStringSubmatch(match[1]); src != nil && strings.EqualFold(src[1], "src") {
+			if link := c.resolve(pageURL, src[2]); link != "" {
+				scripts = append(scripts, link)
+			}
+			continue
+		}
+		scripts = append(scripts, c.ParseScript(pageURL, []byte(match[2]))...)
+	}
+
+	for _, match := range crawlLinkPattern.FindAllStringSubmatch(page, -1) {
+		href := crawlAttributePattern.FindStringSubmatch(match[2])
+		if href == nil || !strings.EqualFold(href[1], "href") {
+			continue
+		}
+		link := c.resolve(pageURL, href[2])
+		if link == "" {
+			continue
+		}
+		if strings.EqualFold(match[1], "link") {
+			// Preloaded modules and scripts
+			if isScript(link, "") {
+				scripts = append(scripts, link)
+			}
+			continue
+		}
+		if u, err := url.Parse(link); err == nil && !isStaticAsset(u.Path, "") {
+			pages = append(pages, link)
+		}
+	}
+
+	for _, match := range crawlFormPattern.FindAllStringSubmatch(page, -1) {
+		action := crawlAttributePattern.FindStringSubmatch(match[1])
+		if action == nil || !strings.EqualFold(action[1], "action") {
+			continue
+		}
+		method := "GET"
+		if m := crawlFormMethodPattern.FindStringSubmatch(match[1]); m != nil {
+			method = strings.ToUpper(m[1])
+		}
+		c.addReference(pageURL, pageURL, method, action[2], false, CrawlKindForm)
+	}
+
+	// Scripts are fetched before the pages linked to
+	return append(scripts, pages...)
+}
+
+// ParseScript extracts the API endpoints referenced by a JavaScript source, and returns the
+// URLs of the modules and chunks it imports
+func (c *JSCrawler) ParseScript(scriptURL string, data []byte) []string {
+	source := string(data)
+	// Endpoints are resolved against the page, not the script, unless they are absolute
+	pageURL := c.StartURL
+
+	for _, match := range crawlFetchPattern.FindAllStringSubmatch(source, -1) {
+		method := "GET"
+		if m := crawlMethodPattern.FindStringSubmatch(match[4]); m != nil {
+			method = strings.ToUpper(m[1])
+		}
+		c.addReference(scriptURL, pageURL, method, match[2], match[3] != "", CrawlKindFetch)
+	}
+	for _, match := range crawlClientPattern.FindAllStringSubmatch(source, -1) {
+		method := strings.ToUpper(match[1])
+		if method == "GETJSON" {
+			method = "GET"
+		}
+		c.addReference(scriptURL, pageURL, method, match[3], match[4] != "", CrawlKindAxios)
+	}
+	for _, match := range crawlXHRPattern.FindAllStringSubmatch(source, -1) {
+		c.addReference(scriptURL, pageURL, strings.ToUpper(match[1]), match[3], match[4] != "", CrawlKindXHR)
+	}
+	for _, match := range crawlRequestPattern.FindAllStringSubmatch(source, -1) {
+		method := "GET"
+		if m := crawlMethodPattern.FindStringSubmatch(match[0]); m != nil {
+			method = strings.ToUpper(m[1])
+		}
+		c.addReference(scriptURL, pageURL, method, match[2], match[3] != "", CrawlKindRequest)
+	}
+	for _, match := range crawlRoutePattern.FindAllStringSubmatch(source, -1) {
+		c.addReference(scriptURL, pageURL, "GET", match[1], false, CrawlKindRoute)
+	}
+	for _, match := range crawlPathPattern.FindAllStringSubmatch(source, -1) {
+		c.addReference(scriptURL, pageURL, "", match[1], match[2] != "", CrawlKindPath)
+	}
+
+	imports := make([]string, 0)
+	for _, match := range crawlImportPattern.FindAllStringSubmatch(source, -1) {
+		if link := c.resolve(scriptURL, match[1]); link != "" {
+			imports = append(imports, link)
+		}
+	}
+	return imports
+}
+
+// addReference adds the endpoint referenced by a URL literal. A method left empty adds the
+// endpoint as GET unless it is already known with another method.
+func (c *JSCrawler) addReference(source, pageURL, method, literal string, concatenated bool, kind string) {
+	if c.base == nil {
+		c.base, _ = url.Parse(c.StartURL)
+	}
+	literal = crawlTemplatePattern.ReplaceAllStringFunc(literal, func(expr string) string {
+		return "{" + crawlParameterName(expr[2:len(expr)-1]) + "}"
+	})
+	if strings.HasPrefix(literal, "{") && strings.Contains(literal, "}/") {
+		// A leading expression is most likely the base URL of the API
+		literal = literal[strings.Index(literal, "}")+1:]
+	}
+	if kind == CrawlKindRoute {
+		literal = crawlRouteParamPattern.ReplaceAllString(literal, "{$1}")
+		literal = strings.TrimRight(literal, "*")
+		if !strings.HasPrefix(literal, "/") {
+			literal = "/" + literal
+		}
+	}
+	if concatenated && !strings.Contains(literal, "?") {
+		if !strings.HasSuffix(literal, "/") {
+			literal += "/"
+		}
+		literal += "{id}"
+	}
+	if literal == "" || strings.HasPrefix(literal, "#") {
+		return
+	}
+
+	// Placeholders are not valid URL characters, keep them through the URL parser
+	escaped := strings.NewReplacer("{", "%7B", "}", "%7D").Replace(literal)
+	ref, err := url.Parse(escaped)
+	if err != nil || (ref.Scheme != "" && ref.Scheme != "http" && ref.Scheme != "https") {
+		return
+	}
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return
+	}
+	if kind != CrawlKindRoute && kind != CrawlKindPath && !ref.IsAbs() && !strings.HasPrefix(literal, "/") && !strings.Contains(literal, "/") {
+		// A bare word is an unlikely endpoint, such as the key of a map.get() call
+		return
+	}
+	resolved := page.ResolveReference(ref)
+	if !c.allowedHost(resolved.Host) {
+		return
+	}
+	endpointPath, _ := url.PathUnescape(resolved.EscapedPath())
+	if endpointPath == "" {
+		endpointPath = "/"
+	}
+	if !crawlValidPathPattern.MatchString(endpointPath) || isStaticAsset(endpointPath, "") || (endpointPath == "/" && kind != CrawlKindForm) {
+		return
+	}
+
+	c.addEndpoint(source, method, resolved.Scheme+"://"+resolved.Host, endpointPath, resolved.Query(), kind)
+}
+
+// addEndpoint adds or updates a crawled endpoint
+func (c *JSCrawler) addEndpoint(source, method, origin, endpointPath string, query url.Values, kind string) {
+	if method == "" {
+		// Paths without a call are kept as GET unless already known with a method
+		for _, endpoint := range c.Endpoints {
+			if endpoint.Origin == origin && endpoint.Path == endpointPath {
+				c.updateEndpoint(endpoint, source, query, kind)
+				return
+			}
+		}
+		method = "GET"
+	}
+
+	key := method + " " + origin + endpointPath
+	endpoint, ok := c.index[key]
+	if !ok {
+		endpoint = &CrawledEndpoint{
+			Method:     method,
+			Path:       endpointPath,
+			Origin:     origin,
+			Parameters: make([]*DiscoveredParameter, 0),
+			Kinds:      make([]string, 0),
+			Sources:    make([]string, 0),
+		}
+		for _, segment := range strings.Split(endpointPath, "/") {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				endpoint.addParameter(segment[1:len(segment)-1], "path", true, nil)
+			}
+		}
+		c.index[key] = endpoint
+		c.Endpoints = append(c.Endpoints, endpoint)
+	}
+	c.updateEndpoint(endpoint, source, query, kind)
+}
+
+// updateEndpoint records a reference to a crawled endpoint
+func (c *JSCrawler) updateEndpoint(endpoint *CrawledEndpoint, source string, query url.Values, kind string) {
+	if !contains(endpoint.Kinds, kind) {
+		endpoint.Kinds = append(endpoint.Kinds, kind)
+	}
+	if !contains(endpoint.Sources, source) {
+		endpoint.Sources = append(endpoint.Sources, source)
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var example interface{}
+		if value := query.Get(name); value != "" && !strings.HasPrefix(value, "{") {
+			example = value
+		}
+		endpoint.addParameter(name, "query", false, example)
+	}
+}
+
+// addParameter adds a parameter to a crawled endpoint if it is not already known
+func (e *CrawledEndpoint) addParameter(name, in string, required bool, example interface{}) {
+	for _, param := range e.Parameters {
+		if param.Name == name && param.In == in {
+			return
+		}
+	}
+	paramType := "string"
+	if value, ok := example.(string); ok {
+		paramType = inferStringType(value)
+	}
+	e.Parameters = append(e.Parameters, &DiscoveredParameter{
+		Name:     name,
+		In:       in,
+		Required: required,
+		Type:     paramType,
+		Example:  example,
+	})
+}
+
+// resolve resolves a link of a page or script and returns it if its host is allowed
+func (c *JSCrawler) resolve(sourceURL, link string) string {
+	if strings.HasPrefix(link, "#") || strings.HasPrefix(strings.ToLower(link), "javascript:") || strings.HasPrefix(strings.ToLower(link), "mailto:") {
+		return ""
+	}
+	source, err := url.Parse(sourceURL)
+	if err != nil {
+		return ""
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	resolved := source.ResolveReference(ref)
+	if (resolved.Scheme != "http" && resolved.Scheme != "https") || !c.allowedHost(resolved.Host) {
+		return ""
+	}
+	resolved.Fragment = ""
+	return resolved.String()
+}
+
+// allowedHost checks if a host is the host of the start URL or one of the additional hosts
+func (c *JSCrawler) allowedHost(host string) bool {
+	if c.base != nil && strings.EqualFold(host, c.base.Host) {
+		return true
+	}
+	hostname := strings.Split(host, ":")[0]
+	for _, allowed := range c.Hosts {
+		if strings.EqualFold(host, allowed) || strings.EqualFold(hostname, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetEndpoints returns the crawled endpoints
+func (c *JSCrawler) GetEndpoints() []*CrawledEndpoint {
+	return c.Endpoints
+}
+
+// crawlParameterName returns the name of the placeholder of a template literal expression,
+// such as id for ${user.id}
+func crawlParameterName(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if i := strings.LastIndexAny(expr, ".["); i >= 0 {
+		expr = expr[i+1:]
+	}
+	name := variableNamePattern.ReplaceAllString(expr, "")
+	if name == "" {
+		return "param"
+	}
+	return name
+}
+
+// isScript checks if a URL or content type is a JavaScript source
+func isScript(scriptURL, contentType string) bool {
+	if strings.Contains(strings.ToLower(contentType), "javascript") {
+		return true
+	}
+	if u, err := url.Parse(scriptURL); err == nil {
+		return strings.HasSuffix(u.Path, ".js") || strings.HasSuffix(u.Path, ".mjs")
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const crawlerTestPage = `<!DOCTYPE html>
+<html>
+<head>
+  <script type="module" src="/assets/main.js"></script>
+  <script src="https://cdn.example.com/lib/jquery.min.js"></script>
+  <link rel="modulepreload" href="/assets/vendor.js">
+</head>
+<body>
+  <a href="/about">About</a>
+  <form action="/account/login" method="post"><input name="user"></form>
+  <script>
+    window.CONFIG = {search: "/api/v1/search?q=test&limit=10"};
+  </script>
+</body>
+</html>`
+
+const crawlerTestMain = `
+import { h } from "./vendor.js";
+const API = "/api/v1";
+const routes = [
+  { path: '/users/:userId', component: User },
+  { path: 'settings', component: Settings },
+  { path: '**', component: NotFound },
+];
+function loadUser(user) {
+  return fetch(` + "`/api/v1/users/${user.id}/profile`" + `, { headers: { Accept: "application/json" } });
+}
+function saveUser(user) {
+  return fetch("/api/v1/users", { method: "POST", body: JSON.stringify(user) });
+}
+axios.delete("/api/v1/users/" + id);
+axios.put(` + "`${API}/users/${userId}/roles`" + `, roles);
+cache.get("currentUser");
+$.ajax({ url: "/api/v1/orders", type: "POST", data: order });
+var xhr = new XMLHttpRequest();
+xhr.open("PATCH", "/api/v1/orders/" + orderId);
+const chunk = () => import("./chunk-reports.js");
+`
+
+const crawlerTestChunk = `
+export const loadReports = () => fetch("https://api.internal.example.com/v2/reports?from=2024-01-01");
+export const exportReports = () => fetch("https://tracker.example.org/collect");
+`
+
+// newCrawlerTestServer creates a server for a single page application
+func newCrawlerTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "/about":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(crawlerTestPage))
+		case "/assets/main.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			w.Write([]byte(crawlerTestMain))
+		case "/assets/vendor.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			w.Write([]byte(`export function h() {}`))
+		case "/assets/chunk-reports.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			w.Write([]byte(crawlerTestChunk))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestJSCrawler_Crawl(t *testing.T) {
+	server := newCrawlerTestServer()
+	defer server.Close()
+
+	crawler := NewJSCrawler(server.URL + "/")
+	crawler.Hosts = []string{"api.internal.example.com"}
+	if err := crawler.Crawl(); err != nil {
+		t.Fatalf("Crawl returned an error: %s", err)
+	}
+
+	found := make(map[string]*CrawledEndpoint)
+	for _, endpoint := range crawler.GetEndpoints() {
+		found[endpoint.Method+" "+endpoint.Origin+endpoint.Path] = endpoint
+	}
+	tests := []struct {
+		key  string
+		kind string
+	}{
+		{"GET " + server.URL + "/api/v1/users/{id}/profile", CrawlKindFetch},
+		{"POST " + server.URL + "/api/v1/users", CrawlKindFetch},
+		{"DELETE " + server.URL + "/api/v1/users/{id}", CrawlKindAxios},
+		{"PUT " + server.URL + "/users/{userId}/roles", CrawlKindAxios},
+		{"POST " + server.URL + "/api/v1/orders", CrawlKindRequest},
+		{"PATCH " + server.URL + "/api/v1/orders/{id}", CrawlKindXHR},
+		{"GET " + server.URL + "/users/{userId}", CrawlKindRoute},
+		{"GET " + server.URL + "/settings", CrawlKindRoute},
+		{"GET " + server.URL + "/api/v1/search", CrawlKindPath},
+		{"GET " + server.URL + "/api/v1", CrawlKindPath},
+		{"POST " + server.URL + "/account/login", CrawlKindForm},
+		{"GET https://api.internal.example.com/v2/reports", CrawlKindFetch},
+	}
+	for _, tt := range tests {
+		endpoint, ok := found[tt.key]
+		if !ok {
+			t.Errorf("Expected endpoint %s to be found", tt.key)
+			continue
+		}
+		if !contains(endpoint.Kinds, tt.kind) {
+			t.Errorf("Expected endpoint %s to be found by a %s reference, got %v", tt.key, tt.kind, endpoint.Kinds)
+		}
+	}
+	if len(found) != len(tests) {
+		keys := make([]string, 0, len(found))
+		for key := range found {
+			keys = append(keys, key)
+		}
+		t.Errorf("Expected %d endpoints, got %d: %s", len(tests), len(found), strings.Join(keys, ", "))
+	}
+
+	search := found["GET "+server.URL+"/api/v1/search"]
+	if search != nil && (len(search.Parameters) != 2 || search.Parameters[0].Name != "limit" || search.Parameters[0].Type != "integer") {
+		t.Errorf("Expected the query parameters of the search endpoint, got %d", len(search.Parameters))
+	}
+	profile := found["GET "+server.URL+"/api/v1/users/{id}/profile"]
+	if profile != nil && (len(profile.Parameters) != 1 || profile.Parameters[0].In != "path" || !profile.Parameters[0].Required) {
+		t.Errorf("Expected the path parameter of the profile endpoint")
+	}
+	if profile != nil && (len(profile.Sources) != 1 || profile.Sources[0] != server.URL+"/assets/main.js") {
+		t.Errorf("Expected the profile endpoint to be referenced by the main script, got %v", profile.Sources)
+	}
+}
+
+func TestAPIEndpointDiscovery_DiscoverFromCrawl(t *testing.T) {
+	server := newCrawlerTestServer()
+	defer server.Close()
+
+	discovery := NewAPIEndpointDiscovery("")
+	if err := discovery.DiscoverFromCrawl(server.URL, nil); err != nil {
+		t.Fatalf("DiscoverFromCrawl returned an error: %s", err)
+	}
+	if discovery.BaseURL != server.URL {
+		t.Errorf("Expected base URL %s, got %s", server.URL, discovery.BaseURL)
+	}
+	if len(discovery.GetEndpointsByMethod("POST")) != 3 {
+		t.Errorf("Expected 3 POST endpoints, got %d", len(discovery.GetEndpointsByMethod("POST")))
+	}
+	for _, endpoint := range discovery.Endpoints {
+		if endpoint.Source != "Crawler" || !strings.HasPrefix(endpoint.URL, server.URL) {
+			t.Errorf("Unexpected endpoint %s %s from %s", endpoint.Method, endpoint.URL, endpoint.Source)
+		}
+	}
+
+	if err := NewAPIEndpointDiscovery("").DiscoverFromCrawl(server.URL+"/missing", nil); err == nil {
+		t.Errorf("Expected an error when the start URL cannot be fetched")
+	}
+}
@@ -3,6 +3,7 @@ package parser
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"path/filepath"
 	"strings"
@@ -18,6 +19,9 @@ type APIEndpointDiscovery struct {
 	Endpoints []*DiscoveredEndpoint
 	// Parser used for discovery
 	Parser interface{}
+	// HTTP client used to fetch specifications, pages and documents, the clients of the
+	// parsers if nil
+	Client *http.Client
 }
 
 // DiscoveredEndpoint represents an API endpoint discovered from documentation
@@ -38,6 +42,12 @@ type DiscoveredEndpoint struct {
 	Tags []string
 	// Source of the endpoint (e.g., "OpenAPI", "Swagger")
 	Source string
+	// Operation name for endpoints that share a URL (e.g., "query user" for GraphQL)
+	Operation string
+	// Request document template for the operation (e.g., a GraphQL query)
+	Document string
+	// Media types of the successful responses documented by the specification
+	ResponseTypes []string
 }
 
 // DiscoveredParameter represents a parameter for an API endpoint
@@ -54,6 +64,8 @@ type DiscoveredParameter struct {
 	Description string
 	// Example value for the parameter
 	Example interface{}
+	// Schema of the parameter, if known from the specification
+	Schema *OpenAPISchema
 }
 
 // NewAPIEndpointDiscovery creates a new APIEndpointDiscovery
@@ -68,6 +80,9 @@ func NewAPIEndpointDiscovery(baseURL string) *APIEndpointDiscovery {
 func (d *APIEndpointDiscovery) DiscoverFromOpenAPI(specPath string) error {
 	// Create a new OpenAPI parser
 	parser := NewOpenAPIParser()
+	if d.Client != nil {
+		parser.Client = d.Client
+	}
 	d.Parser = parser
 
 	// Determine if the spec path is a URL or a file path
@@ -88,17 +103,24 @@ func (d *APIEndpointDiscovery) DiscoverFromOpenAPI(specPath string) error {
 		d.BaseURL = parser.Spec.BaseURL
 	}
 
+	d.addOpenAPIEndpoints(parser)
+	return nil
+}
+
+// addOpenAPIEndpoints converts the endpoints of a parsed OpenAPI/Swagger specification to discovered endpoints
+func (d *APIEndpointDiscovery) addOpenAPIEndpoints(parser *OpenAPIParser) {
 	// Convert OpenAPI endpoints to discovered endpoints
 	for _, endpoint := range parser.GetEndpoints() {
 		// Create a new discovered endpoint
 		discoveredEndpoint := &DiscoveredEndpoint{
-			Method:       endpoint.Method,
-			Path:         endpoint.Path,
-			RequiresAuth: endpoint.RequiresAuth,
-			Description:  endpoint.Description,
-			Tags:         endpoint.Tags,
-			Source:       "OpenAPI",
-			Parameters:   make([]*DiscoveredParameter, 0),
+			Method:        endpoint.Method,
+			Path:          endpoint.Path,
+			RequiresAuth:  endpoint.RequiresAuth,
+			Description:   endpoint.Description,
+			Tags:          endpoint.Tags,
+			Source:        "OpenAPI",
+			Parameters:    make([]*DiscoveredParameter, 0),
+			ResponseTypes: endpoint.Produces,
 		}
 
 		// Set the full URL
@@ -125,6 +147,7 @@ func (d *APIEndpointDiscovery) DiscoverFromOpenAPI(specPath string) error {
 			// Set the type from the schema if available
 			if param.Schema != nil {
 				discoveredParam.Type = param.Schema.Type
+				discoveredParam.Schema = param.Schema
 			}
 
 			discoveredEndpoint.Parameters = append(discoveredEndpoint.Parameters, discoveredParam)
@@ -139,6 +162,7 @@ func (d *APIEndpointDiscovery) DiscoverFromOpenAPI(specPath string) error {
 					Description: "", // No description available in the schema
 					Type:        prop.Type,
 					Example:     prop.Example,
+					Schema:      prop,
 				}
 
 				// Check if the parameter is required
@@ -156,10 +180,294 @@ func (d *APIEndpointDiscovery) DiscoverFromOpenAPI(specPath string) error {
 		// Add the endpoint to the list
 		d.Endpoints = append(d.Endpoints, discoveredEndpoint)
 	}
+}
+
+// DiscoverFromPostman discovers API endpoints from a Postman Collection v2.1 export
+func (d *APIEndpointDiscovery) DiscoverFromPostman(collectionPath string) error {
+	parser := NewPostmanParser()
+	d.Parser = parser
+
+	if err := parser.ParseFromFile(collectionPath); err != nil {
+		return err
+	}
+
+	d.addPostmanEndpoints(parser)
+	return nil
+}
+
+// addPostmanEndpoints converts the endpoints of a parsed Postman collection to discovered endpoints
+func (d *APIEndpointDiscovery) addPostmanEndpoints(parser *PostmanParser) {
+	// If base URL is not set, use the one from the collection
+	if d.BaseURL == "" && parser.BaseURL != "" {
+		d.BaseURL = parser.BaseURL
+	}
+
+	for _, endpoint := range parser.GetEndpoints() {
+		discoveredEndpoint := &DiscoveredEndpoint{
+			URL:          endpoint.URL,
+			Method:       endpoint.Method,
+			Path:         endpoint.Path,
+			Parameters:   endpoint.Parameters,
+			RequiresAuth: endpoint.AuthType != "",
+			Description:  endpoint.Description,
+			Tags:         endpoint.Folders,
+			Source:       "Postman",
+		}
+		if discoveredEndpoint.Tags == nil {
+			discoveredEndpoint.Tags = make([]string, 0)
+		}
+
+		d.Endpoints = append(d.Endpoints, discoveredEndpoint)
+	}
+}
+
+// DiscoverFromHAR discovers API endpoints from requests captured in a HAR file
+func (d *APIEndpointDiscovery) DiscoverFromHAR(harPath string) error {
+	parser := NewHARParser()
+	d.Parser = parser
+
+	if err := parser.ParseFromFile(harPath); err != nil {
+		return err
+	}
+
+	d.addHAREndpoints(parser, "HAR")
+	return nil
+}
+
+// DiscoverFromProxyExport discovers API endpoints from the requests of a Burp Suite XML export
+// or an OWASP ZAP message export
+func (d *APIEndpointDiscovery) DiscoverFromProxyExport(exportPath string) error {
+	messages, format, err := LoadProxyExport(exportPath)
+	if err != nil {
+		return err
+	}
+	parser := NewHARParser()
+	d.Parser = parser
+	if err := parser.ParseProxyMessages(messages); err != nil {
+		return err
+	}
+
+	d.addHAREndpoints(parser, format)
+	return nil
+}
+
+// DiscoverFromHARParser discovers API endpoints from the requests parsed by a HAR parser, such
+// as the parser of a live capture
+func (d *APIEndpointDiscovery) DiscoverFromHARParser(parser *HARParser) {
+	d.Parser = parser
+	d.addHAREndpoints(parser, "HAR")
+}
+
+// addHAREndpoints converts the endpoints of a parsed HAR capture to discovered endpoints of
+// the source of the capture
+func (d *APIEndpointDiscovery) addHAREndpoints(parser *HARParser, source string) {
+	// If base URL is not set, use the one from the capture
+	if d.BaseURL == "" && parser.BaseURL != "" {
+		d.BaseURL = parser.BaseURL
+	}
+
+	for _, endpoint := range parser.GetEndpoints() {
+		discoveredEndpoint := &DiscoveredEndpoint{
+			URL:          endpoint.Origin + endpoint.Path,
+			Method:       endpoint.Method,
+			Path:         endpoint.Path,
+			Parameters:   endpoint.Parameters,
+			RequiresAuth: endpoint.RequiresAuth,
+			Description:  fmt.Sprintf("Observed %d time(s) in %s capture", endpoint.Count, source),
+			Tags:         make([]string, 0),
+			Source:       source,
+		}
+
+		d.Endpoints = append(d.Endpoints, discoveredEndpoint)
+	}
+}
+
+// DiscoverFromGraphQL discovers GraphQL operations by running an introspection query
+func (d *APIEndpointDiscovery) DiscoverFromGraphQL(endpointURL string, headers map[string]string) error {
+	parser := NewGraphQLSchemaParser(endpointURL)
+	if d.Client != nil {
+		parser.Client = d.Client
+	}
+	for name, value := range headers {
+		parser.Headers[name] = value
+	}
+	d.Parser = parser
+
+	if err := parser.Introspect(); err != nil {
+		return err
+	}
 
+	// If base URL is not set, use the GraphQL endpoint origin
+	if d.BaseURL == "" {
+		if parsedURL, err := url.Parse(endpointURL); err == nil {
+			d.BaseURL = fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
+		}
+	}
+
+	d.Endpoints = append(d.Endpoints, parser.GetEndpoints()...)
 	return nil
 }
 
+// DiscoverFromWSDL discovers SOAP operations from a WSDL 1.1/2.0 document
+func (d *APIEndpointDiscovery) DiscoverFromWSDL(wsdlPath string) error {
+	parser := NewWSDLParser()
+	if d.Client != nil {
+		parser.Client = d.Client
+	}
+	d.Parser = parser
+
+	// Determine if the WSDL path is a URL or a file path
+	if strings.HasPrefix(wsdlPath, "http://") || strings.HasPrefix(wsdlPath, "https://") {
+		if err := parser.ParseFromURL(wsdlPath); err != nil {
+			return err
+		}
+	} else {
+		if err := parser.ParseFromFile(wsdlPath); err != nil {
+			return err
+		}
+	}
+
+	// If base URL is not set, use the service address origin
+	if d.BaseURL == "" && parser.Address != "" {
+		if parsedURL, err := url.Parse(parser.Address); err == nil {
+			d.BaseURL = fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
+		}
+	}
+
+	d.Endpoints = append(d.Endpoints, parser.GetEndpoints()...)
+	return nil
+}
+
+// DiscoverFromAsyncAPI discovers channel operations from an AsyncAPI 2.x specification
+func (d *APIEndpointDiscovery) DiscoverFromAsyncAPI(specPath string) error {
+	parser := NewAsyncAPIParser()
+	if d.Client != nil {
+		parser.Client = d.Client
+	}
+	d.Parser = parser
+
+	// Determine if the spec path is a URL or a file path
+	if strings.HasPrefix(specPath, "http://") || strings.HasPrefix(specPath, "https://") {
+		if err := parser.ParseFromURL(specPath); err != nil {
+			return err
+		}
+	} else {
+		if err := parser.ParseFromFile(specPath); err != nil {
+			return err
+		}
+	}
+
+	// If base URL is not set, use the first HTTP server of the spec
+	if d.BaseURL == "" {
+		for _, server := range parser.GetServersByProtocol("http", "https") {
+			if parsedURL, err := url.Parse(server.URL); err == nil && parsedURL.Host != "" {
+				d.BaseURL = fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
+				break
+			}
+		}
+	}
+
+	d.Endpoints = append(d.Endpoints, parser.GetEndpoints()...)
+	return nil
+}
+
+// DiscoverFromCrawl discovers API endpoints referenced by the HTML pages and JavaScript bundles
+// of a web application, crawled from a start URL
+func (d *APIEndpointDiscovery) DiscoverFromCrawl(startURL string, headers map[string]string) error {
+	crawler := NewJSCrawler(startURL)
+	if d.Client != nil {
+		crawler.Client = d.Client
+	}
+	for name, value := range headers {
+		crawler.Headers[name] = value
+	}
+	d.Parser = crawler
+
+	if err := crawler.Crawl(); err != nil {
+		return err
+	}
+
+	d.DiscoverFromCrawler(crawler)
+	return nil
+}
+
+// DiscoverFromCrawler discovers API endpoints from the endpoints found by a crawler, such as a
+// crawler configured with additional hosts or parsing sources directly
+func (d *APIEndpointDiscovery) DiscoverFromCrawler(crawler *JSCrawler) {
+	d.Parser = crawler
+
+	// If base URL is not set, use the start URL origin
+	if d.BaseURL == "" {
+		if parsedURL, err := url.Parse(crawler.StartURL); err == nil && parsedURL.Host != "" {
+			d.BaseURL = fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
+		}
+	}
+
+	for _, endpoint := range crawler.GetEndpoints() {
+		d.Endpoints = append(d.Endpoints, &DiscoveredEndpoint{
+			URL:         endpoint.Origin + endpoint.Path,
+			Method:      endpoint.Method,
+			Path:        endpoint.Path,
+			Parameters:  endpoint.Parameters,
+			Description: fmt.Sprintf("Referenced by %s", strings.Join(endpoint.Sources, ", ")),
+			Tags:        append([]string{}, endpoint.Kinds...),
+			Source:      "Crawler",
+		})
+	}
+}
+
+// DiscoverFromWellKnown checks the well-known locations of a target for API specifications,
+// GraphQL endpoints, robots.txt files and sitemaps, and adds the endpoints found that are not
+// already known. It returns the documents found.
+func (d *APIEndpointDiscovery) DiscoverFromWellKnown(targetURL string, headers map[string]string) ([]*WellKnownResult, error) {
+	prober := NewWellKnownProber()
+	if d.Client != nil {
+		prober.Client = d.Client
+	}
+	for name, value := range headers {
+		prober.Headers[name] = value
+	}
+	d.Parser = prober
+
+	results, err := prober.Probe(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// If base URL is not set, use the target origin
+	if d.BaseURL == "" {
+		if parsedURL, err := url.Parse(targetURL); err == nil {
+			d.BaseURL = fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
+		}
+	}
+
+	for _, result := range results {
+		d.mergeEndpoints(result.Endpoints)
+	}
+	return results, nil
+}
+
+// mergeEndpoints adds the endpoints whose method and URL are not already known, and returns
+// the number of endpoints added
+func (d *APIEndpointDiscovery) mergeEndpoints(endpoints []*DiscoveredEndpoint) int {
+	known := make(map[string]bool)
+	for _, endpoint := range d.Endpoints {
+		known[endpoint.Method+" "+endpoint.URL+" "+endpoint.Operation] = true
+	}
+
+	added := 0
+	for _, endpoint := range endpoints {
+		key := endpoint.Method + " " + endpoint.URL + " " + endpoint.Operation
+		if known[key] {
+			continue
+		}
+		known[key] = true
+		d.Endpoints = append(d.Endpoints, endpoint)
+		added++
+	}
+	return added
+}
+
 // GetEndpoints returns all discovered endpoints
 func (d *APIEndpointDiscovery) GetEndpoints() []*DiscoveredEndpoint {
 	return d.Endpoints
@@ -352,6 +660,7 @@ func (d *APIEndpointDiscovery) DiscoverFromURL(targetURL string) error {
 
 		// Try to discover from this URL
 		fileDiscovery := NewAPIEndpointDiscovery(d.BaseURL)
+		fileDiscovery.Client = d.Client
 		if err := fileDiscovery.DiscoverFromOpenAPI(docURL); err != nil {
 			// Skip URLs that can't be parsed as OpenAPI/Swagger
 			continue
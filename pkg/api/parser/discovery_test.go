@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 )
@@ -335,6 +338,28 @@ func removeTempFile(t *testing.T, path string) {
 	// For this test, do nothing
 }
 
+func TestAPIEndpointDiscovery_Client(t *testing.T) {
+	// The specification is fetched through the proxy of the client of the discovery
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "http://specs.example.com/openapi.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"openapi": "3.0.0", "paths": {"/users": {"get": {}}}}`))
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	discovery := NewAPIEndpointDiscovery("")
+	discovery.Client = &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	if err := discovery.DiscoverFromOpenAPI("http://specs.example.com/openapi.json"); err != nil {
+		t.Fatalf("DiscoverFromOpenAPI returned an error: %s", err)
+	}
+	if endpoints := discovery.GetEndpoints(); len(endpoints) != 1 || endpoints[0].Path != "/users" {
+		t.Errorf("Expected the endpoint of the proxied specification, got %d endpoints", len(endpoints))
+	}
+}
+
 func TestAPIEndpointDiscovery_PathMatches(t *testing.T) {
 	testCases := []struct {
 		path    string
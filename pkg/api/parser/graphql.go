@@ -0,0 +1,539 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// GraphQLIntrospectionQuery is the introspection query sent to GraphQL endpoints
+const GraphQLIntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      description
+      fields(includeDeprecated: true) {
+        name
+        description
+        args { name description type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+      }
+      inputFields { name description type { ...TypeRef } defaultValue }
+      enumValues(includeDeprecated: true) { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType { kind name }
+      }
+    }
+  }
+}`
+
+// GraphQL operation types
+const (
+	// GraphQLQuery represents a query operation
+	GraphQLQuery = "query"
+	// GraphQLMutation represents a mutation operation
+	GraphQLMutation = "mutation"
+	// GraphQLSubscription represents a subscription operation
+	GraphQLSubscription = "subscription"
+)
+
+// GraphQLSchemaParser provides methods for discovering GraphQL operations through introspection
+type GraphQLSchemaParser struct {
+	// URL of the GraphQL endpoint
+	EndpointURL string
+	// Headers sent with the introspection query (e.g., Authorization)
+	Headers map[string]string
+	// Types defined in the schema, by name
+	Types map[string]*GraphQLType
+	// Query fields
+	Queries []*GraphQLField
+	// Mutation fields
+	Mutations []*GraphQLField
+	// Subscription fields
+	Subscriptions []*GraphQLField
+	// Maximum depth of generated selection sets
+	MaxDepth int
+	// HTTP client used to send the introspection query
+	Client *http.Client
+}
+
+// GraphQLType represents a named type in a GraphQL schema
+type GraphQLType struct {
+	// Kind of the type (OBJECT, INPUT_OBJECT, ENUM, SCALAR, ...)
+	Kind string `json:"kind"`
+	// Name of the type
+	Name string `json:"name"`
+	// Description of the type
+	Description string `json:"description"`
+	// Fields of object and interface types
+	Fields []*GraphQLField `json:"fields"`
+	// Fields of input object types
+	InputFields []*GraphQLInputValue `json:"inputFields"`
+	// Values of enum types
+	EnumValues []struct {
+		Name string `json:"name"`
+	} `json:"enumValues"`
+}
+
+// GraphQLField represents a field of an object type, including root operation fields
+type GraphQLField struct {
+	// Name of the field
+	Name string `json:"name"`
+	// Description of the field
+	Description string `json:"description"`
+	// Arguments of the field
+	Args []*GraphQLInputValue `json:"args"`
+	// Type of the field
+	Type *GraphQLTypeRef `json:"type"`
+}
+
+// GraphQLInputValue represents an argument or an input object field
+type GraphQLInputValue struct {
+	// Name of the argument
+	Name string `json:"name"`
+	// Description of the argument
+	Description string `json:"description"`
+	// Type of the argument
+	Type *GraphQLTypeRef `json:"type"`
+	// Default value of the argument in GraphQL syntax
+	DefaultValue *string `json:"defaultValue"`
+}
+
+// GraphQLTypeRef represents a possibly wrapped (NON_NULL, LIST) type reference
+type GraphQLTypeRef struct {
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	OfType *GraphQLTypeRef `json:"ofType"`
+}
+
+// GraphQLOperation represents a ready-to-send GraphQL operation
+type GraphQLOperation struct {
+	// Operation type (query, mutation, subscription)
+	Type string
+	// Root field name
+	Field string
+	// Operation document
+	Query string
+	// Default variable values
+	Variables map[string]interface{}
+}
+
+// String renders the type reference in GraphQL syntax (e.g., [User!]!)
+func (t *GraphQLTypeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// NamedType returns the innermost named type
+func (t *GraphQLTypeRef) NamedType() *GraphQLTypeRef {
+	for t != nil && (t.Kind == "NON_NULL" || t.Kind == "LIST") {
+		t = t.OfType
+	}
+	return t
+}
+
+// IsRequired reports whether the type is non-null
+func (t *GraphQLTypeRef) IsRequired() bool {
+	return t != nil && t.Kind == "NON_NULL"
+}
+
+// IsList reports whether the type is a list, ignoring a non-null wrapper
+func (t *GraphQLTypeRef) IsList() bool {
+	if t != nil && t.Kind == "NON_NULL" {
+		t = t.OfType
+	}
+	return t != nil && t.Kind == "LIST"
+}
+
+// NewGraphQLSchemaParser creates a new GraphQLSchemaParser
+func NewGraphQLSchemaParser(endpointURL string) *GraphQLSchemaParser {
+	return &GraphQLSchemaParser{
+		EndpointURL:   endpointURL,
+		Headers:       make(map[string]string),
+		Types:         make(map[string]*GraphQLType),
+		Queries:       make([]*GraphQLField, 0),
+		Mutations:     make([]*GraphQLField, 0),
+		Subscriptions: make([]*GraphQLField, 0),
+		MaxDepth:      3,
+		Client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Introspect runs the introspection query against the endpoint and parses the result
+func (p *GraphQLSchemaParser) Introspect() error {
+	if _, err := url.Parse(p.EndpointURL); err != nil || p.EndpointURL == "" {
+		return api.NewAPIError(fmt.Sprintf("Invalid GraphQL endpoint URL: %s", p.EndpointURL), 0)
+	}
+
+	body, _ := json.Marshal(map[string]string{"query": GraphQLIntrospectionQuery})
+	req, err := http.NewRequest("POST", p.EndpointURL, bytes.NewReader(body))
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to create introspection request: %s", err.Error()), 0)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range p.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to send introspection query: %s", err.Error()), 0)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to read response body: %s", err.Error()), 0)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return api.NewAPIError(fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, resp.Status), resp.StatusCode)
+	}
+
+	return p.ParseJSON(data)
+}
+
+// ParseFromFile parses a saved introspection result from a file
+func (p *GraphQLSchemaParser) ParseFromFile(filePath string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to read introspection result: %s", err.Error()), 0)
+	}
+	return p.ParseJSON(data)
+}
+
+// ParseJSON parses an introspection result, with or without the top-level "data" wrapper
+func (p *GraphQLSchemaParser) ParseJSON(data []byte) error {
+	var result struct {
+		Data struct {
+			Schema *graphQLSchema `json:"__schema"`
+		} `json:"data"`
+		Schema *graphQLSchema `json:"__schema"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to parse introspection result: %s", err.Error()), 0)
+	}
+
+	schema := result.Data.Schema
+	if schema == nil {
+		schema = result.Schema
+	}
+	if schema == nil {
+		if len(result.Errors) > 0 {
+			return api.NewAPIError(fmt.Sprintf("Introspection failed: %s", result.Errors[0].Message), 0)
+		}
+		return api.NewAPIError("Invalid introspection result: missing __schema", 0)
+	}
+
+	for _, t := range schema.Types {
+		if t != nil && t.Name != "" {
+			p.Types[t.Name] = t
+		}
+	}
+
+	p.Queries = p.rootFields(schema.QueryType)
+	p.Mutations = p.rootFields(schema.MutationType)
+	p.Subscriptions = p.rootFields(schema.SubscriptionType)
+	return nil
+}
+
+type graphQLSchema struct {
+	QueryType        *struct{ Name string } `json:"queryType"`
+	MutationType     *struct{ Name string } `json:"mutationType"`
+	SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+	Types            []*GraphQLType         `json:"types"`
+}
+
+// rootFields returns the fields of a root operation type
+func (p *GraphQLSchemaParser) rootFields(root *struct{ Name string }) []*GraphQLField {
+	if root == nil {
+		return make([]*GraphQLField, 0)
+	}
+	if t, ok := p.Types[root.Name]; ok && t.Fields != nil {
+		return t.Fields
+	}
+	return make([]*GraphQLField, 0)
+}
+
+// GetOperations returns a ready-to-send operation for every root field
+func (p *GraphQLSchemaParser) GetOperations() []*GraphQLOperation {
+	operations := make([]*GraphQLOperation, 0)
+	for _, group := range []struct {
+		opType string
+		fields []*GraphQLField
+	}{
+		{GraphQLQuery, p.Queries},
+		{GraphQLMutation, p.Mutations},
+		{GraphQLSubscription, p.Subscriptions},
+	} {
+		for _, field := range group.fields {
+			operations = append(operations, p.BuildOperation(group.opType, field))
+		}
+	}
+	return operations
+}
+
+// BuildOperation builds an operation document for a root field, passing every argument
+// as a variable so that individual values can be fuzzed
+func (p *GraphQLSchemaParser) BuildOperation(opType string, field *GraphQLField) *GraphQLOperation {
+	op := &GraphQLOperation{
+		Type:      opType,
+		Field:     field.Name,
+		Variables: make(map[string]interface{}),
+	}
+
+	var definitions, arguments []string
+	for _, arg := range field.Args {
+		definitions = append(definitions, fmt.Sprintf("$%s: %s", arg.Name, arg.Type.String()))
+		arguments = append(arguments, fmt.Sprintf("%s: $%s", arg.Name, arg.Name))
+		op.Variables[arg.Name] = p.exampleValue(arg.Type, 0)
+	}
+
+	var buf strings.Builder
+	buf.WriteString(opType)
+	buf.WriteString(" ")
+	buf.WriteString(graphQLOperationName(field.Name))
+	if len(definitions) > 0 {
+		buf.WriteString("(" + strings.Join(definitions, ", ") + ")")
+	}
+	buf.WriteString(" { ")
+	buf.WriteString(field.Name)
+	if len(arguments) > 0 {
+		buf.WriteString("(" + strings.Join(arguments, ", ") + ")")
+	}
+	buf.WriteString(p.selectionSet(field.Type, 1))
+	buf.WriteString(" }")
+
+	op.Query = buf.String()
+	return op
+}
+
+// selectionSet builds a selection set for object types, limited to MaxDepth levels
+func (p *GraphQLSchemaParser) selectionSet(ref *GraphQLTypeRef, depth int) string {
+	named := ref.NamedType()
+	if named == nil {
+		return ""
+	}
+	t, ok := p.Types[named.Name]
+	if !ok || (t.Kind != "OBJECT" && t.Kind != "INTERFACE") {
+		return ""
+	}
+	if depth > p.MaxDepth {
+		return " { __typename }"
+	}
+
+	selections := make([]string, 0)
+	for _, f := range t.Fields {
+		// Fields with required arguments cannot be selected without values
+		requiresArgs := false
+		for _, arg := range f.Args {
+			if arg.Type.IsRequired() && arg.DefaultValue == nil {
+				requiresArgs = true
+				break
+			}
+		}
+		if requiresArgs {
+			continue
+		}
+
+		sub := p.selectionSet(f.Type, depth+1)
+		fieldType := f.Type.NamedType()
+		if sub == "" && fieldType != nil {
+			if ft, ok := p.Types[fieldType.Name]; ok && (ft.Kind == "OBJECT" || ft.Kind == "INTERFACE" || ft.Kind == "UNION") {
+				continue
+			}
+		}
+		selections = append(selections, f.Name+sub)
+	}
+	if len(selections) == 0 {
+		selections = append(selections, "__typename")
+	}
+	return " { " + strings.Join(selections, " ") + " }"
+}
+
+// exampleValue returns an example variable value for a type reference
+func (p *GraphQLSchemaParser) exampleValue(ref *GraphQLTypeRef, depth int) interface{} {
+	if ref == nil {
+		return nil
+	}
+	if ref.IsList() {
+		inner := ref
+		if inner.Kind == "NON_NULL" {
+			inner = inner.OfType
+		}
+		return []interface{}{p.exampleValue(inner.OfType, depth)}
+	}
+
+	named := ref.NamedType()
+	switch named.Name {
+	case "Int":
+		return 1
+	case "Float":
+		return 1.5
+	case "Boolean":
+		return true
+	case "ID":
+		return "1"
+	case "String":
+		return "test"
+	}
+
+	t, ok := p.Types[named.Name]
+	if !ok {
+		return "test"
+	}
+	switch t.Kind {
+	case "ENUM":
+		if len(t.EnumValues) > 0 {
+			return t.EnumValues[0].Name
+		}
+	case "INPUT_OBJECT":
+		obj := make(map[string]interface{})
+		if depth >= p.MaxDepth {
+			return obj
+		}
+		for _, f := range t.InputFields {
+			if f.Type.IsRequired() || depth == 0 {
+				obj[f.Name] = p.exampleValue(f.Type, depth+1)
+			}
+		}
+		return obj
+	}
+	return "test"
+}
+
+// GetEndpoints converts the discovered operations to endpoints that share the GraphQL URL
+func (p *GraphQLSchemaParser) GetEndpoints() []*DiscoveredEndpoint {
+	endpoints := make([]*DiscoveredEndpoint, 0)
+	path := "/"
+	if parsed, err := url.Parse(p.EndpointURL); err == nil && parsed.Path != "" {
+		path = parsed.Path
+	}
+
+	for _, op := range p.GetOperations() {
+		field := p.findField(op.Type, op.Field)
+		endpoint := &DiscoveredEndpoint{
+			URL:        p.EndpointURL,
+			Method:     "POST",
+			Path:       path,
+			Operation:  op.Type + " " + op.Field,
+			Document:   op.Query,
+			Parameters: make([]*DiscoveredParameter, 0),
+			Tags:       []string{"graphql", op.Type},
+			Source:     "GraphQL",
+		}
+		if field != nil {
+			endpoint.Description = field.Description
+			for _, arg := range field.Args {
+				endpoint.Parameters = append(endpoint.Parameters, &DiscoveredParameter{
+					Name:        arg.Name,
+					In:          "body",
+					Required:    arg.Type.IsRequired() && arg.DefaultValue == nil,
+					Type:        p.parameterType(arg.Type),
+					Description: arg.Description,
+					Example:     op.Variables[arg.Name],
+				})
+			}
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// findField looks up a root field by operation type and name
+func (p *GraphQLSchemaParser) findField(opType, name string) *GraphQLField {
+	fields := p.Queries
+	if opType == GraphQLMutation {
+		fields = p.Mutations
+	} else if opType == GraphQLSubscription {
+		fields = p.Subscriptions
+	}
+	for _, f := range fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// parameterType maps a GraphQL type to the parameter type names used by the parser package
+func (p *GraphQLSchemaParser) parameterType(ref *GraphQLTypeRef) string {
+	if ref.IsList() {
+		return "array"
+	}
+	named := ref.NamedType()
+	switch named.Name {
+	case "Int":
+		return "integer"
+	case "Float":
+		return "number"
+	case "Boolean":
+		return "boolean"
+	case "ID", "String":
+		return "string"
+	}
+	if t, ok := p.Types[named.Name]; ok && t.Kind == "INPUT_OBJECT" {
+		return "object"
+	}
+	return "string"
+}
+
+// GetTypeNames returns the names of all non-introspection types, sorted
+func (p *GraphQLSchemaParser) GetTypeNames() []string {
+	names := make([]string, 0, len(p.Types))
+	for name := range p.Types {
+		if !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// graphQLOperationName derives an operation name from a field name
+func graphQLOperationName(field string) string {
+	if field == "" {
+		return "Operation"
+	}
+	return strings.ToUpper(field[:1]) + field[1:]
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testGraphQLIntrospection = `{
+	"data": {
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"mutationType": {"name": "Mutation"},
+			"subscriptionType": null,
+			"types": [
+				{
+					"kind": "OBJECT",
+					"name": "Query",
+					"fields": [
+						{
+							"name": "user",
+							"description": "Fetch a user",
+							"args": [
+								{"name": "id", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID"}}, "defaultValue": null}
+							],
+							"type": {"kind": "OBJECT", "name": "User"}
+						}
+					]
+				},
+				{
+					"kind": "OBJECT",
+					"name": "Mutation",
+					"fields": [
+						{
+							"name": "createUser",
+							"args": [
+								{"name": "input", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "INPUT_OBJECT", "name": "UserInput"}}, "defaultValue": null}
+							],
+							"type": {"kind": "OBJECT", "name": "User"}
+						}
+					]
+				},
+				{
+					"kind": "OBJECT",
+					"name": "User",
+					"fields": [
+						{"name": "id", "args": [], "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID"}}},
+						{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+						{"name": "role", "args": [], "type": {"kind": "ENUM", "name": "Role"}}
+					]
+				},
+				{
+					"kind": "INPUT_OBJECT",
+					"name": "UserInput",
+					"inputFields": [
+						{"name": "name", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "String"}}},
+						{"name": "age", "type": {"kind": "SCALAR", "name": "Int"}}
+					]
+				},
+				{
+					"kind": "ENUM",
+					"name": "Role",
+					"enumValues": [{"name": "USER"}, {"name": "ADMIN"}]
+				}
+			]
+		}
+	}
+}`
+
+func TestGraphQLSchemaParser_ParseJSON(t *testing.T) {
+	parser := NewGraphQLSchemaParser("https://api.example.com/graphql")
+	if err := parser.ParseJSON([]byte(testGraphQLIntrospection)); err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+
+	if len(parser.Queries) != 1 || len(parser.Mutations) != 1 || len(parser.Subscriptions) != 0 {
+		t.Fatalf("Unexpected root fields: %d queries, %d mutations, %d subscriptions",
+			len(parser.Queries), len(parser.Mutations), len(parser.Subscriptions))
+	}
+
+	op := parser.BuildOperation(GraphQLQuery, parser.Queries[0])
+	want := "query User($id: ID!) { user(id: $id) { id name role } }"
+	if op.Query != want {
+		t.Errorf("BuildOperation() query = %q, want %q", op.Query, want)
+	}
+	if op.Variables["id"] != "1" {
+		t.Errorf("Expected example value '1' for ID, got %v", op.Variables["id"])
+	}
+
+	mutation := parser.BuildOperation(GraphQLMutation, parser.Mutations[0])
+	input, ok := mutation.Variables["input"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected object value for input, got %T", mutation.Variables["input"])
+	}
+	if input["name"] != "test" || input["age"] != 1 {
+		t.Errorf("Unexpected input object example %v", input)
+	}
+}
+
+func TestGraphQLSchemaParser_GetEndpoints(t *testing.T) {
+	parser := NewGraphQLSchemaParser("https://api.example.com/graphql")
+	if err := parser.ParseJSON([]byte(testGraphQLIntrospection)); err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+
+	endpoints := parser.GetEndpoints()
+	if len(endpoints) != 2 {
+		t.Fatalf("Expected 2 endpoints, got %d", len(endpoints))
+	}
+	if endpoints[0].Path != "/graphql" || endpoints[0].Method != "POST" {
+		t.Errorf("Unexpected endpoint %s %s", endpoints[0].Method, endpoints[0].Path)
+	}
+	if endpoints[0].Operation != "query user" {
+		t.Errorf("Expected operation 'query user', got '%s'", endpoints[0].Operation)
+	}
+	if len(endpoints[1].Parameters) != 1 || endpoints[1].Parameters[0].Type != "object" || !endpoints[1].Parameters[0].Required {
+		t.Errorf("Unexpected mutation parameters %+v", endpoints[1].Parameters)
+	}
+}
+
+func TestAPIEndpointDiscovery_DiscoverFromGraphQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !strings.Contains(req["query"], "__schema") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testGraphQLIntrospection))
+	}))
+	defer server.Close()
+
+	discovery := NewAPIEndpointDiscovery("")
+	if err := discovery.DiscoverFromGraphQL(server.URL+"/graphql", nil); err == nil {
+		t.Error("Expected error without authorization header")
+	}
+
+	discovery = NewAPIEndpointDiscovery("")
+	err := discovery.DiscoverFromGraphQL(server.URL+"/graphql", map[string]string{"Authorization": "Bearer token"})
+	if err != nil {
+		t.Fatalf("DiscoverFromGraphQL() error = %v", err)
+	}
+	if len(discovery.GetEndpoints()) != 2 {
+		t.Fatalf("Expected 2 endpoints, got %d", len(discovery.GetEndpoints()))
+	}
+
+	generator := NewAPITestGenerator(discovery, nil)
+	if err := generator.GenerateTestCases(); err != nil {
+		t.Fatalf("GenerateTestCases() error = %v", err)
+	}
+	for _, testCase := range generator.GetTestCasesByCategory("positive") {
+		var body map[string]interface{}
+		if err := json.Unmarshal([]byte(testCase.Body), &body); err != nil {
+			t.Fatalf("Expected JSON body, got %q", testCase.Body)
+		}
+		if _, ok := body["query"]; !ok {
+			t.Errorf("Expected GraphQL operation document in body, got %q", testCase.Body)
+		}
+		if _, ok := body["variables"]; !ok {
+			t.Errorf("Expected GraphQL variables in body, got %q", testCase.Body)
+		}
+	}
+}
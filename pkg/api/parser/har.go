@@ -0,0 +1,400 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// HARParser provides methods for converting HAR (HTTP Archive) captures into API endpoints
+type HARParser struct {
+	// Extracted endpoints, one per unique method and normalized path
+	Endpoints []*HAREndpoint
+	// Base URL derived from the first captured API request
+	BaseURL string
+	// Only requests to these hosts are imported when set
+	Hosts []string
+	// Whether to import requests for static assets (images, scripts, styles, fonts)
+	IncludeStatic bool
+	// Detector used to infer request and response body schemas
+	SchemaDetector *SchemaDetector
+}
+
+// HAREndpoint represents an API endpoint observed in a HAR capture
+type HAREndpoint struct {
+	// HTTP method (GET, POST, etc.)
+	Method string
+	// Normalized path with identifier segments replaced by {name} placeholders
+	Path string
+	// URL of the first observed request
+	URL string
+	// Scheme and host the requests were sent to
+	Origin string
+	// Parameters observed across all requests to the endpoint
+	Parameters []*DiscoveredParameter
+	// Inferred schema of JSON request bodies
+	RequestSchema *Schema
+	// Inferred schema of JSON response bodies
+	ResponseSchema *Schema
+	// Request body MIME type
+	RequestMimeType string
+	// Observed response status codes
+	StatusCodes []int
+	// Number of requests observed for the endpoint
+	Count int
+	// Whether an Authorization header or session cookie was observed
+	RequiresAuth bool
+
+	requestBodies  [][]byte
+	responseBodies [][]byte
+	paramIndex     map[string]*DiscoveredParameter
+}
+
+type harFile struct {
+	Log struct {
+		Entries []*harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method      string          `json:"method"`
+		URL         string          `json:"url"`
+		Headers     []*harNameValue `json:"headers"`
+		Cookies     []*harNameValue `json:"cookies"`
+		QueryString []*harNameValue `json:"queryString"`
+		PostData    *struct {
+			MimeType string          `json:"mimeType"`
+			Text     string          `json:"text"`
+			Params   []*harNameValue `json:"params"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var (
+	// harIdentifierPattern matches path segments that look like resource identifiers
+	harIdentifierPattern = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{24,})$`)
+	// harStaticExtensions lists file extensions of static assets
+	harStaticExtensions = []string{".js", ".css", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".ico", ".woff", ".woff2", ".ttf", ".eot", ".map", ".webp"}
+	// harSkippedHeaders lists headers that are set by the browser and are not useful fuzzing targets
+	harSkippedHeaders = []string{"host", "content-length", "connection", "accept-encoding", "cookie", "origin", "referer", "user-agent", "pragma", "cache-control"}
+)
+
+// harMaxBodySamples is the maximum number of bodies per endpoint kept to infer schemas
+const harMaxBodySamples = 50
+
+// NewHARParser creates a new HARParser
+func NewHARParser() *HARParser {
+	return &HARParser{
+		Endpoints:      make([]*HAREndpoint, 0),
+		Hosts:          make([]string, 0),
+		SchemaDetector: NewSchemaDetector(),
+	}
+}
+
+// ParseFromFile parses a HAR capture from a file
+func (p *HARParser) ParseFromFile(filePath string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to read HAR file: %s", err.Error()), 0)
+	}
+	return p.ParseJSON(data)
+}
+
+// ParseJSON parses a HAR capture from JSON data
+func (p *HARParser) ParseJSON(data []byte) error {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to parse HAR: %s", err.Error()), 0)
+	}
+	if har.Log.Entries == nil {
+		return api.NewAPIError("Invalid HAR: missing log entries", 0)
+	}
+
+	index := make(map[string]*HAREndpoint)
+	for _, existing := range p.Endpoints {
+		index[existing.Origin+" "+existing.Method+" "+existing.Path] = existing
+	}
+	// Only the schemas of the endpoints of the entries are inferred again, so that captures
+	// can be parsed incrementally
+	updated := make(map[*HAREndpoint]bool)
+
+	for _, entry := range har.Log.Entries {
+		if entry == nil {
+			continue
+		}
+		parsedURL, err := url.Parse(entry.Request.URL)
+		if err != nil || parsedURL.Host == "" {
+			continue
+		}
+		if !p.acceptHost(parsedURL.Hostname()) {
+			continue
+		}
+		if !p.IncludeStatic && isStaticAsset(parsedURL.Path, entry.Response.Content.MimeType) {
+			continue
+		}
+
+		method := strings.ToUpper(entry.Request.Method)
+		normalizedPath, pathParams := normalizeHARPath(parsedURL.Path)
+		origin := parsedURL.Scheme + "://" + parsedURL.Host
+		key := origin + " " + method + " " + normalizedPath
+
+		endpoint, ok := index[key]
+		if !ok {
+			endpoint = &HAREndpoint{
+				Method:      method,
+				Path:        normalizedPath,
+				URL:         entry.Request.URL,
+				Origin:      origin,
+				Parameters:  make([]*DiscoveredParameter, 0),
+				StatusCodes: make([]int, 0),
+				paramIndex:  make(map[string]*DiscoveredParameter),
+			}
+			index[key] = endpoint
+			p.Endpoints = append(p.Endpoints, endpoint)
+		}
+		if p.BaseURL == "" {
+			p.BaseURL = origin
+		}
+		endpoint.Count++
+		p.recordEntry(endpoint, entry, pathParams)
+		updated[endpoint] = true
+	}
+
+	for _, endpoint := range p.Endpoints {
+		if updated[endpoint] {
+			p.inferSchemas(endpoint)
+		}
+	}
+
+	return nil
+}
+
+// recordEntry merges the parameters and bodies of a single HAR entry into an endpoint
+func (p *HARParser) recordEntry(endpoint *HAREndpoint, entry *harEntry, pathParams map[string]string) {
+	for name, value := range pathParams {
+		endpoint.addParameter(name, "path", value, true)
+	}
+	for _, q := range entry.Request.QueryString {
+		endpoint.addParameter(q.Name, "query", q.Value, false)
+	}
+	for _, h := range entry.Request.Headers {
+		lower := strings.ToLower(h.Name)
+		if lower == "authorization" {
+			endpoint.RequiresAuth = true
+		}
+		if strings.HasPrefix(lower, ":") || contains(harSkippedHeaders, lower) {
+			continue
+		}
+		endpoint.addParameter(h.Name, "header", h.Value, false)
+	}
+	for _, c := range entry.Request.Cookies {
+		if looksLikeSessionCookie(c.Name) {
+			endpoint.RequiresAuth = true
+		}
+		endpoint.addParameter(c.Name, "cookie", c.Value, false)
+	}
+
+	if postData := entry.Request.PostData; postData != nil {
+		endpoint.RequestMimeType = postData.MimeType
+		for _, param := range postData.Params {
+			endpoint.addParameter(param.Name, "body", param.Value, false)
+		}
+		if strings.Contains(postData.MimeType, "json") && postData.Text != "" {
+			if len(endpoint.requestBodies) < harMaxBodySamples {
+				endpoint.requestBodies = append(endpoint.requestBodies, []byte(postData.Text))
+			}
+			for _, param := range bodyParametersFromJSON(postData.Text) {
+				endpoint.addParameter(param.Name, "body", param.Example, false)
+			}
+		} else if strings.Contains(postData.MimeType, "x-www-form-urlencoded") && len(postData.Params) == 0 {
+			if values, err := url.ParseQuery(postData.Text); err == nil {
+				for name, v := range values {
+					endpoint.addParameter(name, "body", v[0], false)
+				}
+			}
+		}
+	}
+
+	status := entry.Response.Status
+	if status != 0 && !containsInt(endpoint.StatusCodes, status) {
+		endpoint.StatusCodes = append(endpoint.StatusCodes, status)
+		sort.Ints(endpoint.StatusCodes)
+	}
+	content := entry.Response.Content
+	if strings.Contains(content.MimeType, "json") && content.Text != "" && content.Encoding == "" && len(endpoint.responseBodies) < harMaxBodySamples {
+		endpoint.responseBodies = append(endpoint.responseBodies, []byte(content.Text))
+	}
+}
+
+// inferSchemas infers request and response body schemas from the collected samples
+func (p *HARParser) inferSchemas(endpoint *HAREndpoint) {
+	if p.SchemaDetector == nil {
+		return
+	}
+	if len(endpoint.requestBodies) > 0 {
+		if schema, err := p.SchemaDetector.DetectSchemaFromSamples(endpoint.requestBodies); err == nil {
+			endpoint.RequestSchema = schema
+		}
+	}
+	if len(endpoint.responseBodies) > 0 {
+		if schema, err := p.SchemaDetector.DetectSchemaFromSamples(endpoint.responseBodies); err == nil {
+			endpoint.ResponseSchema = schema
+		}
+	}
+}
+
+// acceptHost reports whether requests to the host should be imported
+func (p *HARParser) acceptHost(host string) bool {
+	if len(p.Hosts) == 0 {
+		return true
+	}
+	for _, h := range p.Hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetEndpoints returns all endpoints extracted from the capture
+func (p *HARParser) GetEndpoints() []*HAREndpoint {
+	return p.Endpoints
+}
+
+// addParameter records a parameter the first time it is observed
+func (e *HAREndpoint) addParameter(name, in string, example interface{}, required bool) {
+	if name == "" {
+		return
+	}
+	key := in + ":" + name
+	if _, ok := e.paramIndex[key]; ok {
+		return
+	}
+
+	// Values outside of JSON bodies are always strings, so guess their type from content
+	paramType := jsonValueType(example)
+	if s, ok := example.(string); ok && in != "body" {
+		paramType = inferStringType(s)
+	}
+	param := &DiscoveredParameter{
+		Name:     name,
+		In:       in,
+		Required: required,
+		Type:     paramType,
+		Example:  example,
+	}
+	e.paramIndex[key] = param
+	e.Parameters = append(e.Parameters, param)
+}
+
+// normalizeHARPath replaces identifier-like path segments with {name} placeholders
+// and returns the observed values for each placeholder
+func normalizeHARPath(rawPath string) (string, map[string]string) {
+	params := make(map[string]string)
+	if rawPath == "" {
+		return "/", params
+	}
+
+	segments := strings.Split(rawPath, "/")
+	for i, segment := range segments {
+		if !harIdentifierPattern.MatchString(segment) {
+			continue
+		}
+
+		name := "id"
+		if i > 0 && segments[i-1] != "" && !strings.HasPrefix(segments[i-1], "{") {
+			name = singularize(segments[i-1]) + "Id"
+		}
+		for n := 2; params[name] != ""; n++ {
+			name = fmt.Sprintf("id%d", n)
+		}
+		params[name] = segment
+		segments[i] = "{" + name + "}"
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+// singularize strips a trailing plural "s" from a resource name
+func singularize(s string) string {
+	if strings.HasSuffix(s, "ies") && len(s) > 3 {
+		return s[:len(s)-3] + "y"
+	}
+	if strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss") && len(s) > 1 {
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+// inferStringType infers the parameter type of a string value observed in traffic
+func inferStringType(s string) string {
+	if s == "true" || s == "false" {
+		return "boolean"
+	}
+	if s != "" && isNumericString(s) {
+		return "integer"
+	}
+	return "string"
+}
+
+// isNumericString reports whether a string consists of decimal digits only
+func isNumericString(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isStaticAsset reports whether a request targets a static asset
+func isStaticAsset(urlPath, mimeType string) bool {
+	ext := strings.ToLower(path.Ext(urlPath))
+	for _, e := range harStaticExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	mimeType = strings.ToLower(mimeType)
+	return strings.HasPrefix(mimeType, "image/") || strings.HasPrefix(mimeType, "font/") ||
+		strings.Contains(mimeType, "javascript") || strings.Contains(mimeType, "text/css")
+}
+
+// looksLikeSessionCookie reports whether a cookie name suggests an authenticated session
+func looksLikeSessionCookie(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "session") || strings.Contains(lower, "token") ||
+		strings.Contains(lower, "auth") || lower == "sid" || lower == "jwt"
+}
+
+// containsInt checks if an int is in a slice
+func containsInt(slice []int, item int) bool {
+	for _, v := range slice {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testHARCapture = `{
+	"log": {
+		"version": "1.2",
+		"entries": [
+			{
+				"request": {
+					"method": "GET",
+					"url": "https://api.example.com/users/42?fields=name",
+					"headers": [
+						{"name": "Authorization", "value": "Bearer abc"},
+						{"name": "User-Agent", "value": "Mozilla/5.0"}
+					],
+					"queryString": [{"name": "fields", "value": "name"}]
+				},
+				"response": {
+					"status": 200,
+					"content": {"mimeType": "application/json", "text": "{\"id\": 42, \"name\": \"alice\"}"}
+				}
+			},
+			{
+				"request": {
+					"method": "GET",
+					"url": "https://api.example.com/users/43",
+					"headers": [{"name": "Authorization", "value": "Bearer abc"}]
+				},
+				"response": {
+					"status": 404,
+					"content": {"mimeType": "application/json", "text": "{\"error\": \"not found\"}"}
+				}
+			},
+			{
+				"request": {
+					"method": "POST",
+					"url": "https://api.example.com/users",
+					"headers": [{"name": "Content-Type", "value": "application/json"}],
+					"postData": {"mimeType": "application/json", "text": "{\"name\": \"bob\", \"admin\": false}"}
+				},
+				"response": {"status": 201, "content": {"mimeType": "application/json", "text": "{\"id\": 44}"}}
+			},
+			{
+				"request": {"method": "GET", "url": "https://api.example.com/static/app.js", "headers": []},
+				"response": {"status": 200, "content": {"mimeType": "application/javascript"}}
+			},
+			{
+				"request": {"method": "GET", "url": "https://cdn.other.com/lib", "headers": []},
+				"response": {"status": 200, "content": {"mimeType": "application/json", "text": "{}"}}
+			}
+		]
+	}
+}`
+
+func TestHARParser_ParseJSON(t *testing.T) {
+	parser := NewHARParser()
+	parser.Hosts = []string{"api.example.com"}
+	if err := parser.ParseJSON([]byte(testHARCapture)); err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+
+	endpoints := parser.GetEndpoints()
+	if len(endpoints) != 2 {
+		t.Fatalf("Expected 2 endpoints, got %d", len(endpoints))
+	}
+
+	getUser := endpoints[0]
+	if getUser.Path != "/users/{userId}" {
+		t.Errorf("Expected normalized path '/users/{userId}', got '%s'", getUser.Path)
+	}
+	if getUser.Count != 2 {
+		t.Errorf("Expected 2 observed requests, got %d", getUser.Count)
+	}
+	if !getUser.RequiresAuth {
+		t.Error("Expected endpoint to require authentication")
+	}
+	if len(getUser.StatusCodes) != 2 || getUser.StatusCodes[0] != 200 || getUser.StatusCodes[1] != 404 {
+		t.Errorf("Unexpected status codes %v", getUser.StatusCodes)
+	}
+	if getUser.ResponseSchema == nil || getUser.ResponseSchema.Type != TypeObject {
+		t.Error("Expected an inferred object response schema")
+	}
+
+	params := make(map[string]*DiscoveredParameter)
+	for _, param := range getUser.Parameters {
+		params[param.In+":"+param.Name] = param
+	}
+	if p, ok := params["path:userId"]; !ok || p.Type != "integer" {
+		t.Errorf("Expected integer path parameter 'userId', got %+v", p)
+	}
+	if _, ok := params["query:fields"]; !ok {
+		t.Error("Expected query parameter 'fields'")
+	}
+	if _, ok := params["header:User-Agent"]; ok {
+		t.Error("Expected browser header 'User-Agent' to be skipped")
+	}
+
+	createUser := endpoints[1]
+	if createUser.RequestSchema == nil {
+		t.Fatal("Expected an inferred request schema")
+	}
+	if _, ok := createUser.RequestSchema.Properties["admin"]; !ok {
+		t.Error("Expected request schema to contain 'admin'")
+	}
+}
+
+func TestNormalizeHARPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/users/42", "/users/{userId}"},
+		{"/categories/7/items/9", "/categories/{categoryId}/items/{itemId}"},
+		{"/orders/123e4567-e89b-12d3-a456-426614174000", "/orders/{orderId}"},
+		{"/health", "/health"},
+		{"", "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got, _ := normalizeHARPath(tt.path); got != tt.want {
+				t.Errorf("normalizeHARPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIEndpointDiscovery_DiscoverFromHAR(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "har-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "capture.har")
+	if err := ioutil.WriteFile(path, []byte(testHARCapture), 0644); err != nil {
+		t.Fatalf("Failed to write HAR file: %v", err)
+	}
+
+	discovery := NewAPIEndpointDiscovery("")
+	if err := discovery.DiscoverFromHAR(path); err != nil {
+		t.Fatalf("DiscoverFromHAR() error = %v", err)
+	}
+
+	if len(discovery.GetEndpoints()) != 3 {
+		t.Fatalf("Expected 3 endpoints, got %d", len(discovery.GetEndpoints()))
+	}
+	if discovery.GetEndpoints()[0].URL != "https://api.example.com/users/{userId}" {
+		t.Errorf("Unexpected endpoint URL '%s'", discovery.GetEndpoints()[0].URL)
+	}
+	if discovery.GetEndpoints()[0].Source != "HAR" {
+		t.Errorf("Expected source 'HAR', got '%s'", discovery.GetEndpoints()[0].Source)
+	}
+}
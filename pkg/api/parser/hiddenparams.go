@@ -0,0 +1,514 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/diff"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// DefaultParameterNames are the candidate names of hidden query and body parameters tried
+// without a wordlist: debugging switches, access control fields, formats, redirects and the
+// usual names of filters and identifiers
+var DefaultParameterNames = []string{
+	"debug", "test", "testing", "dev", "verbose", "trace", "preview", "draft", "internal",
+	"admin", "is_admin", "isAdmin", "role", "roles", "scope", "permissions", "privileged",
+	"superuser", "staff", "impersonate", "as_user", "sudo", "bypass", "force", "override",
+	"id", "user", "user_id", "userId", "uid", "account", "account_id", "owner", "owner_id",
+	"tenant", "tenant_id", "org", "org_id", "group", "email", "username", "token",
+	"access_token", "api_key", "apikey", "key", "secret", "signature", "password",
+	"format", "output", "type", "mode", "view", "version", "v", "lang", "locale",
+	"callback", "jsonp", "cb", "redirect", "redirect_uri", "return", "return_url", "next",
+	"url", "uri", "target", "dest", "file", "path", "template", "include", "expand", "embed",
+	"fields", "select", "filter", "where", "query", "q", "search", "sort", "order", "page",
+	"limit", "offset", "per_page", "count", "cursor", "all", "deleted", "show_deleted",
+	"hidden", "status", "state", "cache", "nocache", "refresh", "raw", "pretty", "export",
+	"download", "source", "src", "config", "settings", "env", "region", "ref", "action",
+}
+
+// DefaultHeaderNames are the candidate names of hidden headers
+var DefaultHeaderNames = []string{
+	"X-Debug", "X-Debug-Mode", "X-Test", "X-Dev", "X-Verbose", "X-Trace", "X-Internal",
+	"X-Admin", "X-Role", "X-User", "X-User-Id", "X-Account-Id", "X-Tenant-Id", "X-Org-Id",
+	"X-Api-Version", "Api-Version", "X-Version", "X-Feature", "X-Features", "X-Beta",
+	"X-Forwarded-For", "X-Forwarded-Host", "X-Forwarded-Proto", "X-Real-IP", "X-Original-URL",
+	"X-Rewrite-URL", "X-Override-URL", "X-HTTP-Method-Override", "X-Api-Key", "X-Auth-Token",
+	"X-Access-Token", "X-Client-Id", "X-Requested-With", "X-Impersonate", "X-Sudo",
+	"X-Bypass-Cache", "X-Cache-Bypass", "X-Format", "X-Response-Format", "X-Pretty-Print",
+	"X-Locale", "X-Region", "X-Env", "X-Environment", "X-Preview", "X-Draft",
+}
+
+// Locations of hidden parameters
+const (
+	HiddenParamQuery  = "query"
+	HiddenParamBody   = "body"
+	HiddenParamHeader = "header"
+)
+
+// HiddenParameter is a parameter the endpoint does not document but reacts to
+type HiddenParameter struct {
+	// Name of the parameter
+	Name string `json:"name"`
+	// In is the location of the parameter: query, body or header
+	In string `json:"in"`
+	// Reason describes how the response changed, e.g. "status 400 instead of 200"
+	Reason string `json:"reason"`
+}
+
+// HiddenParameterFinder discovers the hidden parameters of an endpoint, in the way of Arjun:
+// candidate names are sent in batches in the query, the JSON or form body and the headers,
+// the responses are compared with the response to the original request using the response
+// diff engine, and the batches changing the response are split until the parameters
+// responsible are found.
+type HiddenParameterFinder struct {
+	// Names are the candidate names of query and body parameters
+	Names []string
+	// HeaderNames are the candidate names of headers
+	HeaderNames []string
+	// Locations are the locations the candidates are sent in: query, body and header. Bodies
+	// are only sent by methods with a body, as JSON or form fields depending on the original
+	// body.
+	Locations []string
+	// ChunkSize is the number of query and body parameters sent per request
+	ChunkSize int
+	// HeaderChunkSize is the number of headers sent per request
+	HeaderChunkSize int
+	// Heuristics adds the names found in the response to the original request, such as its
+	// JSON fields, the parameters of its links and the inputs of its forms
+	Heuristics bool
+	// DiffOptions configure the comparison of the responses, default options are used if nil
+	DiffOptions *diff.Options
+	// Requests is the number of requests sent by the last search
+	Requests int
+
+	runner ffuf.RunnerProvider
+}
+
+// NewHiddenParameterFinder creates a HiddenParameterFinder sending its requests with a runner
+func NewHiddenParameterFinder(r ffuf.RunnerProvider) *HiddenParameterFinder {
+	return &HiddenParameterFinder{
+		Names:           DefaultParameterNames,
+		HeaderNames:     DefaultHeaderNames,
+		Locations:       []string{HiddenParamQuery, HiddenParamBody, HiddenParamHeader},
+		ChunkSize:       30,
+		HeaderChunkSize: 10,
+		Heuristics:      true,
+		runner:          r,
+	}
+}
+
+// LoadParameterNames reads candidate parameter names from a wordlist, one per line. Empty
+// lines and comments starting with # are skipped.
+func LoadParameterNames(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to open parameter wordlist: "+err.Error(), 0)
+	}
+	defer f.Close()
+	names := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name != "" && !strings.HasPrefix(name, "#") {
+			names = append(names, name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, api.NewAPIError("Failed to read parameter wordlist: "+err.Error(), 0)
+	}
+	return names, nil
+}
+
+// hiddenSearch is the search of the hidden parameters of a location of an endpoint
+type hiddenSearch struct {
+	finder   *HiddenParameterFinder
+	base     *ffuf.Request
+	in       string
+	baseline *ffuf.Response
+	options  *diff.Options
+	// volatile are the headers and body fields changing between responses to the same request
+	volatile map[string]bool
+	// similarity is the similarity of two responses to the same request
+	similarity float64
+	// reflects is true if the values of unknown parameters are reflected in responses
+	reflects bool
+}
+
+// Find sends the candidate names to an endpoint and returns the parameters changing its
+// response, in the order of their locations
+func (f *HiddenParameterFinder) Find(base *ffuf.Request) ([]*HiddenParameter, error) {
+	f.Requests = 0
+	first, err := f.send(base)
+	if err != nil {
+		return nil, err
+	}
+	second, err := f.send(base)
+	if err != nil {
+		return nil, err
+	}
+	options := f.DiffOptions
+	if options == nil {
+		options = diff.DefaultOptions()
+	}
+	stability := diff.Compare(first, second, options)
+	if stability.StatusChanged || stability.ContentTypeChanged {
+		return nil, api.NewAPIError(fmt.Sprintf("The responses to %s %s are not stable", base.Method, base.Url), 0)
+	}
+	volatile := make(map[string]bool)
+	for _, change := range append(stability.HeaderChanges, stability.BodyChanges...) {
+		volatile[change.Path] = true
+	}
+
+	names := f.Names
+	if f.Heuristics {
+		names = mergeNames(names, responseNames(first))
+	}
+	found := make([]*HiddenParameter, 0)
+	for _, in := range f.Locations {
+		candidates, size := names, f.ChunkSize
+		if in == HiddenParamHeader {
+			candidates, size = f.HeaderNames, f.HeaderChunkSize
+		}
+		if in == HiddenParamBody && !bodyMethod(base.Method) {
+			continue
+		}
+		candidates = withoutKnownNames(base, in, candidates)
+		if len(candidates) == 0 {
+			continue
+		}
+		search := &hiddenSearch{finder: f, base: base, in: in, options: options, volatile: volatile, similarity: stability.Similarity}
+		params, err := search.run(candidates, size)
+		if err != nil {
+			return found, err
+		}
+		found = append(found, params...)
+	}
+	return found, nil
+}
+
+// run searches the candidates in chunks of size names
+func (s *hiddenSearch) run(candidates []string, size int) ([]*HiddenParameter, error) {
+	// The response to an unknown name is the baseline of the location, so that endpoints
+	// rejecting unknown parameters do not make every name a hidden parameter
+	canary := "ffufx" + hiddenValue(strings.Join(candidates, ","))
+	req, ok := s.request([]string{canary})
+	if !ok {
+		return nil, nil
+	}
+	baseline, err := s.finder.send(req)
+	if err != nil {
+		return nil, err
+	}
+	s.baseline = baseline
+	s.reflects = strings.Contains(string(baseline.Data), hiddenValue(canary))
+
+	if size < 1 {
+		size = 1
+	}
+	found := make([]*HiddenParameter, 0)
+	for start := 0; start < len(candidates); start += size {
+		end := start + size
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		params, err := s.bisect(candidates[start:end])
+		if err != nil {
+			return found, err
+		}
+		found = append(found, params...)
+	}
+	return found, nil
+}
+
+// bisect sends a chunk of names, and splits it in halves until the names changing the response
+// are found. Single names are sent twice to confirm them.
+func (s *hiddenSearch) bisect(names []string) ([]*HiddenParameter, error) {
+	req, ok := s.request(names)
+	if !ok {
+		return nil, nil
+	}
+	resp, err := s.finder.send(req)
+	if err != nil {
+		return nil, err
+	}
+	reason := s.change(resp, names)
+	if reason == "" {
+		return nil, nil
+	}
+	if len(names) == 1 {
+		confirm, err := s.finder.send(req)
+		if err != nil {
+			return nil, err
+		}
+		if s.change(confirm, names) == "" {
+			return nil, nil
+		}
+		return []*HiddenParameter{{Name: names[0], In: s.in, Reason: reason}}, nil
+	}
+	half := len(names) / 2
+	found, err := s.bisect(names[:half])
+	if err != nil {
+		return found, err
+	}
+	more, err := s.bisect(names[half:])
+	return append(found, more...), err
+}
+
+// change describes how a response differs from the baseline of the location, or returns an
+// empty string if it does not
+func (s *hiddenSearch) change(resp *ffuf.Response, names []string) string {
+	if !s.reflects {
+		for _, name := range names {
+			if strings.Contains(string(resp.Data), hiddenValue(name)) {
+				return "value reflected in the response"
+			}
+		}
+	}
+	c := diff.Compare(s.baseline, resp, s.options)
+	if c.StatusChanged {
+		return fmt.Sprintf("status %d instead of %d", resp.StatusCode, s.baseline.StatusCode)
+	}
+	if c.ContentTypeChanged {
+		return fmt.Sprintf("content type %s instead of %s", resp.ContentType, s.baseline.ContentType)
+	}
+	changed := make([]string, 0)
+	for _, change := range c.BodyChanges {
+		if change.Path == "body" && s.volatile["body"] {
+			// Text bodies changing with every response differ if they are less similar
+			if c.Similarity < s.similarity-0.05 {
+				changed = append(changed, "body")
+			}
+			continue
+		}
+		if !s.volatile[change.Path] {
+			changed = append(changed, change.Path)
+		}
+	}
+	if len(changed) > 0 {
+		return "body changed: " + strings.Join(limitNames(changed, 5), ", ")
+	}
+	for _, change := range c.HeaderChanges {
+		if !s.volatile[change.Path] {
+			changed = append(changed, change.Path)
+		}
+	}
+	if len(changed) > 0 {
+		return "headers changed: " + strings.Join(limitNames(changed, 5), ", ")
+	}
+	return ""
+}
+
+// request returns the original request with the names added to the location, or false if the
+// location cannot hold them, such as a body which is neither JSON nor a form
+func (s *hiddenSearch) request(names []string) (*ffuf.Request, bool) {
+	req := ffuf.CopyRequest(s.base)
+	switch s.in {
+	case HiddenParamQuery:
+		u, err := url.Parse(req.Url)
+		if err != nil {
+			return nil, false
+		}
+		query := make([]string, 0, len(names)+1)
+		if u.RawQuery != "" {
+			query = append(query, u.RawQuery)
+		}
+		for _, name := range names {
+			query = append(query, url.QueryEscape(name)+"="+hiddenValue(name))
+		}
+		u.RawQuery = strings.Join(query, "&")
+		req.Url = u.String()
+	case HiddenParamHeader:
+		for _, name := range names {
+			req.Headers[name] = hiddenValue(name)
+		}
+	case HiddenParamBody:
+		contentType := ""
+		for name, value := range req.Headers {
+			if strings.EqualFold(name, "Content-Type") {
+				contentType = strings.ToLower(value)
+			}
+		}
+		switch {
+		case strings.Contains(contentType, "x-www-form-urlencoded"):
+			fields := make([]string, 0, len(names)+1)
+			if len(req.Data) > 0 {
+				fields = append(fields, string(req.Data))
+			}
+			for _, name := range names {
+				fields = append(fields, url.QueryEscape(name)+"="+hiddenValue(name))
+			}
+			req.Data = []byte(strings.Join(fields, "&"))
+		case len(req.Data) == 0 || strings.Contains(contentType, "json"):
+			body := make(map[string]interface{})
+			if len(req.Data) > 0 && json.Unmarshal(req.Data, &body) != nil {
+				return nil, false
+			}
+			for _, name := range names {
+				body[name] = hiddenValue(name)
+			}
+			req.Data, _ = json.Marshal(body)
+			if contentType == "" {
+				req.Headers["Content-Type"] = "application/json"
+			}
+		default:
+			return nil, false
+		}
+	}
+	return &req, true
+}
+
+// send executes a request and counts it
+func (f *HiddenParameterFinder) send(req *ffuf.Request) (*ffuf.Response, error) {
+	f.Requests++
+	sent := ffuf.CopyRequest(req)
+	resp, err := f.runner.Execute(&sent)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// hiddenValue is the value sent with a candidate name, unique enough to be found when it is
+// reflected in a response
+func hiddenValue(name string) string {
+	return fmt.Sprintf("ffuf%08x", crc32.ChecksumIEEE([]byte(name)))
+}
+
+// bodyMethod checks if the requests of a method have a body
+func bodyMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "POST", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return false
+}
+
+// withoutKnownNames removes the names already set in a location of a request
+func withoutKnownNames(req *ffuf.Request, in string, names []string) []string {
+	known := make(map[string]bool)
+	switch in {
+	case HiddenParamQuery:
+		if u, err := url.Parse(req.Url); err == nil {
+			for name := range u.Query() {
+				known[strings.ToLower(name)] = true
+			}
+		}
+	case HiddenParamHeader:
+		for name := range req.Headers {
+			known[strings.ToLower(name)] = true
+		}
+	case HiddenParamBody:
+		body := make(map[string]interface{})
+		if json.Unmarshal(req.Data, &body) == nil {
+			for name := range body {
+				known[strings.ToLower(name)] = true
+			}
+		} else if values, err := url.ParseQuery(string(req.Data)); err == nil {
+			for name := range values {
+				known[strings.ToLower(name)] = true
+			}
+		}
+	}
+	unknown := make([]string, 0, len(names))
+	for _, name := range names {
+		if !known[strings.ToLower(name)] {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// formNamePattern matches the names and identifiers of the inputs of HTML forms, and the
+// variables of scripts
+var formNamePattern = regexp.MustCompile(`(?i)<(?:input|select|textarea)[^>]+(?:name|id)=["']([a-z_][a-z0-9_\-\[\]]*)["']|(?:var|let|const)\s+([a-zA-Z_][a-zA-Z0-9_]*)\s*=`)
+
+// responseNames returns the parameter names found in a response: its JSON fields, the
+// parameters of its links and the inputs and variables of its pages
+func responseNames(resp *ffuf.Response) []string {
+	names := make([]string, 0)
+	if resp.Request != nil {
+		if params, err := NewParameterDiscovery().DiscoverParameters(resp); err == nil {
+			for _, param := range params {
+				names = append(names, param.Name)
+			}
+		}
+	}
+	for _, match := range formNamePattern.FindAllStringSubmatch(string(resp.Data), -1) {
+		if match[1] != "" {
+			names = append(names, match[1])
+		} else {
+			names = append(names, match[2])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mergeNames appends the names missing from a list of names
+func mergeNames(names []string, more []string) []string {
+	seen := make(map[string]bool, len(names))
+	merged := make([]string, 0, len(names)+len(more))
+	for _, name := range append(append([]string{}, names...), more...) {
+		if !seen[name] {
+			seen[name] = true
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
+
+// limitNames returns the first max names, followed by ... if there are more
+func limitNames(names []string, max int) []string {
+	if len(names) <= max {
+		return names
+	}
+	return append(names[:max:max], "...")
+}
+
+// AddHiddenParameters adds hidden parameters of an endpoint to the endpoint and to the
+// extracted parameters, so that they are fuzzed and tested like documented parameters
+func (e *APIParameterExtractor) AddHiddenParameters(endpoint *DiscoveredEndpoint, params []*HiddenParameter) {
+	for _, hidden := range params {
+		known := false
+		for _, param := range endpoint.Parameters {
+			if param.Name == hidden.Name && param.In == hidden.In {
+				known = true
+			}
+		}
+		if known {
+			continue
+		}
+		endpoint.Parameters = append(endpoint.Parameters, &DiscoveredParameter{
+			Name:        hidden.Name,
+			In:          hidden.In,
+			Type:        "string",
+			Description: "Hidden parameter: " + hidden.Reason,
+		})
+
+		if extracted := e.GetParameterByName(hidden.Name); extracted != nil {
+			extracted.Endpoints = append(extracted.Endpoints, endpoint)
+			extracted.Frequency++
+			continue
+		}
+		e.ParameterTypes[hidden.Name] = "string"
+		e.ParameterLocations[hidden.Name] = hidden.In
+		e.ParameterDescriptions[hidden.Name] = "Hidden parameter: " + hidden.Reason
+		e.Parameters = append(e.Parameters, &ExtractedParameter{
+			Name:        hidden.Name,
+			In:          hidden.In,
+			Type:        "string",
+			Description: "Hidden parameter: " + hidden.Reason,
+			Endpoints:   []*DiscoveredEndpoint{endpoint},
+			Frequency:   1,
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+// newHiddenParamServer returns a server with hidden parameters in the query, the body and the
+// headers, and a field changing with every response
+func newHiddenParamServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body := map[string]interface{}{"users": []string{"alice", "bob"}, "nonce": time.Now().UnixNano()}
+		if r.Method == "POST" {
+			var fields map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&fields)
+			if _, ok := fields["role"]; ok {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}
+		query := r.URL.Query()
+		if query.Get("debug") != "" {
+			body["sql"] = "SELECT * FROM users"
+		}
+		if callback := query.Get("callback"); callback != "" {
+			body["callback"] = callback
+		}
+		if r.Header.Get("X-Api-Version") != "" {
+			body["deprecated"] = true
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+}
+
+func TestHiddenParameterFinder(t *testing.T) {
+	server := newHiddenParamServer()
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Timeout = 5
+	finder := NewHiddenParameterFinder(runner.NewSimpleRunner(&conf, false))
+	finder.Names = append([]string{"page", "debug"}, DefaultParameterNames...)
+	finder.Names = append(finder.Names, "callback", "role")
+	for i := 0; i < 50; i++ {
+		finder.Names = append(finder.Names, fmt.Sprintf("unknown%d", i))
+	}
+
+	req := &ffuf.Request{Method: "GET", Url: server.URL + "/users?page=1", Headers: map[string]string{}}
+	found, err := finder.Find(req)
+	if err != nil {
+		t.Fatalf("Find returned an error: %s", err)
+	}
+	params := make(map[string]*HiddenParameter)
+	for _, param := range found {
+		params[param.In+" "+param.Name] = param
+	}
+	if len(found) != 3 || params["query debug"] == nil || params["query callback"] == nil || params["header X-Api-Version"] == nil {
+		t.Fatalf("Expected the debug and callback query parameters and the X-Api-Version header, got %+v", params)
+	}
+	if params["query callback"].Reason != "value reflected in the response" || params["query debug"].Reason != "body changed: sql" {
+		t.Errorf("Unexpected reasons %q and %q", params["query callback"].Reason, params["query debug"].Reason)
+	}
+	if finder.Requests >= len(finder.Names) {
+		t.Errorf("Expected the names to be sent in batches, got %d requests for %d names", finder.Requests, len(finder.Names))
+	}
+
+	// Body parameters are sent as JSON fields
+	finder.Locations = []string{HiddenParamBody}
+	req = &ffuf.Request{Method: "POST", Url: server.URL + "/users", Headers: map[string]string{"Content-Type": "application/json"}, Data: []byte(`{"name": "carol"}`)}
+	found, err = finder.Find(req)
+	if err != nil {
+		t.Fatalf("Find returned an error: %s", err)
+	}
+	if len(found) != 1 || found[0].Name != "role" || found[0].Reason != "status 403 instead of 201" {
+		t.Fatalf("Expected the role body parameter, got %+v", found)
+	}
+
+	// Hidden parameters are added to the endpoint and the extracted parameters
+	discovery := NewAPIEndpointDiscovery(server.URL)
+	endpoint := &DiscoveredEndpoint{Method: "POST", URL: server.URL + "/users", Path: "/users", Parameters: []*DiscoveredParameter{{Name: "name", In: "body"}}}
+	discovery.Endpoints = []*DiscoveredEndpoint{endpoint}
+	extractor := NewAPIParameterExtractor(discovery)
+	if err := extractor.ExtractParameters(); err != nil {
+		t.Fatalf("ExtractParameters returned an error: %s", err)
+	}
+	extractor.AddHiddenParameters(endpoint, found)
+	extractor.AddHiddenParameters(endpoint, found)
+	if len(endpoint.Parameters) != 2 || len(extractor.GetParameters()) != 2 {
+		t.Fatalf("Expected the hidden parameter to be added once, got %d and %d parameters", len(endpoint.Parameters), len(extractor.GetParameters()))
+	}
+	if role := extractor.GetParameterByName("role"); role == nil || role.In != "body" || len(role.Endpoints) != 1 {
+		t.Errorf("Unexpected extracted parameter %+v", role)
+	}
+}
+
+func TestHiddenParameterFinder_Unstable(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(200 + requests%2)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Timeout = 5
+	finder := NewHiddenParameterFinder(runner.NewSimpleRunner(&conf, false))
+	if _, err := finder.Find(&ffuf.Request{Method: "GET", Url: server.URL, Headers: map[string]string{}}); err == nil {
+		t.Error("Expected an error for an endpoint without stable responses")
+	}
+}
+
+func TestLoadParameterNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "params.txt")
+	if err := os.WriteFile(path, []byte("# parameters\ndebug\n\n  admin  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := LoadParameterNames(path)
+	if err != nil || len(names) != 2 || names[0] != "debug" || names[1] != "admin" {
+		t.Errorf("Expected debug and admin, got %v (%v)", names, err)
+	}
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// IdempotencyKeyHeader is the header clients send to make a request safe to repeat
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyIDVariable is the variable holding the identifier returned by the first request
+// of an idempotency chain
+const idempotencyIDVariable = "idempotencyId"
+
+// NewIdempotencyKey returns a random idempotency key
+func NewIdempotencyKey() string {
+	return "ffuf-" + randomHex(16)
+}
+
+// generateIdempotencyKeyTestCases generates idempotency key chains for POST and PATCH requests
+func generateIdempotencyKeyTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	return idempotencyKeyTestCases("Idempotency key", endpoint, params, IdempotencyKeyHeader)
+}
+
+// idempotencyKeyTestCases returns a chain sending a POST or PATCH request with an idempotency
+// key header, then the same request with the same key, expecting the same status and
+// resource identifier instead of a duplicate, and the request with the same key and a
+// different body, expecting 422 Unprocessable Content
+func idempotencyKeyTestCases(name string, endpoint *DiscoveredEndpoint, params []*ExtractedParameter, header string) []*APITestCase {
+	if endpoint.Method != "POST" && endpoint.Method != "PATCH" {
+		return nil
+	}
+	key := NewIdempotencyKey()
+
+	first := replayStep(endpoint, params)
+	first.Name = fmt.Sprintf("%s: first %s request to %s", name, endpoint.Method, endpoint.Path)
+	first.Description = fmt.Sprintf("Send a %s request to %s with a new %s", endpoint.Method, endpoint.Path, header)
+	first.Headers[header] = key
+
+	replay := copyTestCase(first)
+	replay.Name = fmt.Sprintf("%s: replay of %s %s with the same key", name, endpoint.Method, endpoint.Path)
+	replay.Description = fmt.Sprintf("Test whether a request repeated with the same %s returns the first result instead of being processed again", header)
+	replay.Dependencies = []*APITestCase{first}
+	replay.Extractions = []*APITestExtraction{{Name: idempotencyIDVariable, From: first, JSONPath: "$.id"}}
+	replay.Assertions = []*APITestAssertion{{
+		APITestExtraction: APITestExtraction{Name: "id", JSONPath: "$.id"},
+		Value:             "${" + idempotencyIDVariable + "}",
+	}}
+	testCases := []*APITestCase{first, replay}
+
+	// Reusing the key for another request is a client error
+	if target, value, ok := changedBodyParameter(params); ok {
+		conflict := schemaValueTestCase(endpoint, params, target, value)
+		conflict.Name = fmt.Sprintf("%s: %s %s with the same key and a different body", name, endpoint.Method, endpoint.Path)
+		conflict.Description = fmt.Sprintf("Test whether a different request reusing the %s of another one is rejected", header)
+		conflict.Headers[header] = key
+		conflict.ExpectedStatus = 422
+		conflict.Category = "idempotency"
+		conflict.Dependencies = []*APITestCase{first}
+		testCases = append(testCases, conflict)
+	}
+	return testCases
+}
+
+// generateReplayTestCases generates a chain sending a POST request twice without an
+// idempotency key, expecting the replay to be rejected with 409 Conflict rather than to
+// create a duplicate
+func generateReplayTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	if endpoint.Method != "POST" {
+		return nil
+	}
+
+	first := replayStep(endpoint, params)
+	first.Name = fmt.Sprintf("Replay: first POST request to %s", endpoint.Path)
+	first.Description = fmt.Sprintf("Send a POST request to %s", endpoint.Path)
+
+	replay := copyTestCase(first)
+	replay.Name = fmt.Sprintf("Replay: duplicate POST request to %s", endpoint.Path)
+	replay.Description = fmt.Sprintf("Test whether replaying a POST request to %s creates a duplicate", endpoint.Path)
+	replay.ExpectedStatus = 409
+	replay.Dependencies = []*APITestCase{first}
+	return []*APITestCase{first, replay}
+}
+
+// replayStep creates the first request of a replay chain with valid parameters, expecting
+// 201 Created for POST requests and 200 OK otherwise
+func replayStep(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) *APITestCase {
+	testCase := generateValidRequestTestCases(endpoint, params)[0]
+	testCase.Category = "idempotency"
+	testCase.Priority = 2
+	if endpoint.Method == "POST" {
+		testCase.ExpectedStatus = 201
+	}
+	return testCase
+}
+
+// copyTestCase returns a copy of a test case with its own headers and parameters
+func copyTestCase(testCase *APITestCase) *APITestCase {
+	copied := *testCase
+	copied.Headers = copyStringMap(testCase.Headers)
+	copied.QueryParams = copyStringMap(testCase.QueryParams)
+	copied.PathParams = copyStringMap(testCase.PathParams)
+	return &copied
+}
+
+// changedBodyParameter returns the first body parameter by name and a valid value different
+// from its example
+func changedBodyParameter(params []*ExtractedParameter) (*ExtractedParameter, interface{}, bool) {
+	body := make([]*ExtractedParameter, 0)
+	for _, param := range params {
+		if param.In == "body" {
+			body = append(body, param)
+		}
+	}
+	if len(body) == 0 {
+		return nil, nil, false
+	}
+	sort.SliceStable(body, func(i, j int) bool { return body[i].Name < body[j].Name })
+
+	switch value := getExampleValueAsInterface(body[0]).(type) {
+	case string:
+		return body[0], value + "-changed", true
+	case bool:
+		return body[0], !value, true
+	case int:
+		return body[0], value + 1, true
+	case int64:
+		return body[0], value + 1, true
+	case float64:
+		return body[0], value + 1, true
+	}
+	return body[0], "changed", true
+}
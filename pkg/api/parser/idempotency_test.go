@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestIdempotencyKeyTestCases(t *testing.T) {
+	endpoint := &DiscoveredEndpoint{Method: "POST", Path: "/orders"}
+	params := []*ExtractedParameter{
+		{Name: "quantity", In: "body", Type: "integer", Example: 2},
+		{Name: "item", In: "body", Type: "string", Example: "book"},
+	}
+
+	testCases := generateIdempotencyKeyTestCases(endpoint, params)
+	if len(testCases) != 3 {
+		t.Fatalf("Expected 3 test cases, got %d", len(testCases))
+	}
+	first, replay, conflict := testCases[0], testCases[1], testCases[2]
+	key := first.Headers[IdempotencyKeyHeader]
+	if !strings.HasPrefix(key, "ffuf-") || replay.Headers[IdempotencyKeyHeader] != key || conflict.Headers[IdempotencyKeyHeader] != key {
+		t.Errorf("Expected the same idempotency key in every request, got %q, %q and %q", key, replay.Headers[IdempotencyKeyHeader], conflict.Headers[IdempotencyKeyHeader])
+	}
+	if first.ExpectedStatus != 201 || replay.ExpectedStatus != 201 || conflict.ExpectedStatus != 422 {
+		t.Errorf("Unexpected expected statuses %d, %d and %d", first.ExpectedStatus, replay.ExpectedStatus, conflict.ExpectedStatus)
+	}
+	if replay.Body != first.Body || !strings.Contains(conflict.Body, `"item":"book-changed"`) {
+		t.Errorf("Unexpected bodies %q, %q and %q", first.Body, replay.Body, conflict.Body)
+	}
+	if len(replay.Dependencies) != 1 || replay.Dependencies[0] != first || len(conflict.Dependencies) != 1 || conflict.Dependencies[0] != first {
+		t.Error("Expected the replays to depend on the first request")
+	}
+
+	applied := replay.ApplyVariables(map[string]string{idempotencyIDVariable: "42"})
+	if failure := applied.Assertions[0].Check(&ffuf.Response{Data: []byte(`{"id": 42}`)}); failure != "" {
+		t.Errorf("Check() = %q, want no failure for the same identifier", failure)
+	}
+	if failure := applied.Assertions[0].Check(&ffuf.Response{Data: []byte(`{"id": 43}`)}); failure == "" {
+		t.Error("Check() = \"\", want a failure for a duplicate")
+	}
+	if replay.Assertions[0].Value != "${"+idempotencyIDVariable+"}" {
+		t.Errorf("ApplyVariables() changed the assertion of the test case to %q", replay.Assertions[0].Value)
+	}
+
+	if testCases := generateIdempotencyKeyTestCases(&DiscoveredEndpoint{Method: "PUT", Path: "/orders/{id}"}, nil); len(testCases) != 0 {
+		t.Errorf("Expected no test cases for PUT, got %d", len(testCases))
+	}
+	if testCases := generateIdempotencyKeyTestCases(&DiscoveredEndpoint{Method: "PATCH", Path: "/orders/{id}"}, nil); len(testCases) != 2 || testCases[0].ExpectedStatus != 200 {
+		t.Errorf("Expected a PATCH replay without a conflicting body, got %d test cases", len(testCases))
+	}
+}
+
+func TestReplayTestCases(t *testing.T) {
+	testCases := generateReplayTestCases(&DiscoveredEndpoint{Method: "POST", Path: "/orders"}, nil)
+	if len(testCases) != 2 {
+		t.Fatalf("Expected 2 test cases, got %d", len(testCases))
+	}
+	if _, ok := testCases[1].Headers[IdempotencyKeyHeader]; ok || testCases[1].ExpectedStatus != 409 || testCases[1].Dependencies[0] != testCases[0] {
+		t.Errorf("Unexpected replay %+v", testCases[1])
+	}
+	if testCases := generateReplayTestCases(&DiscoveredEndpoint{Method: "PATCH", Path: "/orders/{id}"}, nil); len(testCases) != 0 {
+		t.Errorf("Expected no test cases for PATCH, got %d", len(testCases))
+	}
+}
+
+func TestParseTestCaseTemplate_Idempotency(t *testing.T) {
+	template, err := ParseTestCaseTemplate([]byte(`id: idempotency
+info:
+  name: Payment replay
+mode: idempotency
+idempotency-header: X-Request-Id
+`), "yaml")
+	if err != nil {
+		t.Fatalf("ParseTestCaseTemplate() error = %v", err)
+	}
+	testCases := template.Generator(&DiscoveredEndpoint{Method: "POST", Path: "/payments"}, nil)
+	if len(testCases) != 2 || template.Category != "idempotency" {
+		t.Fatalf("Expected 2 idempotency test cases, got %d in %q", len(testCases), template.Category)
+	}
+	for _, testCase := range testCases {
+		if !strings.HasPrefix(testCase.Name, "Payment replay: ") || testCase.Headers["X-Request-Id"] == "" {
+			t.Errorf("Unexpected test case %q with headers %v", testCase.Name, testCase.Headers)
+		}
+	}
+}
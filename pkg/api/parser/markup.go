@@ -0,0 +1,384 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+
+	"golang.org/x/net/html"
+)
+
+// ParseData parses a JSON, XML or HTML response into JSON-like data, on which schemas are
+// inferred, correlations detected and JSONPath expressions evaluated. Responses of an
+// unknown format are parsed as JSON.
+func (p *ResponseParser) ParseData(data []byte) (interface{}, error) {
+	switch p.format {
+	case FormatXML:
+		return p.ParseXML(data)
+	case FormatHTML:
+		return p.ParseHTML(data)
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, api.NewAPIError("Failed to parse JSON: "+err.Error(), 0)
+	}
+	return result, nil
+}
+
+// ParseXML parses an XML response, such as a SOAP envelope, into a map of its root element.
+// An element is a map of its attributes prefixed with @, of its child elements, arrays if
+// repeated, and of its text as #text. Elements with only text are their value. Namespace
+// prefixes are dropped, and numbers and booleans are typed.
+func (p *ResponseParser) ParseXML(data []byte) (map[string]interface{}, error) {
+	if p.format != FormatXML && p.format != FormatUnknown {
+		return nil, api.NewAPIError("Response is not in XML format", 0)
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, api.NewAPIError("Failed to parse XML: no root element", 0)
+		} else if err != nil {
+			return nil, api.NewAPIError("Failed to parse XML: "+err.Error(), 0)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			value, err := xmlElementValue(dec, start)
+			if err != nil {
+				return nil, api.NewAPIError("Failed to parse XML: "+err.Error(), 0)
+			}
+			return map[string]interface{}{start.Name.Local: value}, nil
+		}
+	}
+}
+
+// xmlElementValue returns the value of the element started by start
+func xmlElementValue(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	value := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		value["@"+attr.Name.Local] = markupScalar(attr.Value)
+	}
+
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := xmlElementValue(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			addMarkupChild(value, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(value) == 0 {
+				return markupScalar(content), nil
+			}
+			if content != "" {
+				value["#text"] = markupScalar(content)
+			}
+			return value, nil
+		}
+	}
+}
+
+// addMarkupChild adds a child to a map, turning repeated children into an array
+func addMarkupChild(value map[string]interface{}, name string, child interface{}) {
+	existing, ok := value[name]
+	if !ok {
+		value[name] = child
+	} else if list, ok := existing.([]interface{}); ok {
+		value[name] = append(list, child)
+	} else {
+		value[name] = []interface{}{existing, child}
+	}
+}
+
+// markupScalar types the text of an XML or HTML value as a number or a boolean if it is
+// written as one, so that identifiers such as 00123 stay strings
+func markupScalar(text string) interface{} {
+	switch text {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if number, err := strconv.ParseFloat(text, 64); err == nil && strconv.FormatFloat(number, 'f', -1, 64) == text {
+		return number
+	}
+	return text
+}
+
+// ParseHTML scrapes the data of an HTML page returned by a legacy API: its title, the rows
+// of its tables keyed by their headers, the fields of its forms, the terms of its definition
+// lists and its links. Tables and forms are keyed by their id or name.
+func (p *ResponseParser) ParseHTML(data []byte) (map[string]interface{}, error) {
+	if p.format != FormatHTML && p.format != FormatUnknown {
+		return nil, api.NewAPIError("Response is not in HTML format", 0)
+	}
+
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, api.NewAPIError("Failed to parse HTML: "+err.Error(), 0)
+	}
+	result := make(map[string]interface{})
+	tables := make(map[string]interface{})
+	forms := make(map[string]interface{})
+	definitions := make(map[string]interface{})
+	links := make([]interface{}, 0)
+	seen := make(map[string]bool)
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch node.Data {
+			case "title":
+				if _, ok := result["title"]; !ok {
+					result["title"] = htmlText(node)
+				}
+			case "table":
+				tables[htmlName(node, "table", len(tables)+1)] = htmlTableRows(node)
+			case "form":
+				forms[htmlName(node, "form", len(forms)+1)] = htmlForm(node)
+			case "dt":
+				if dd := htmlNextElement(node, "dd"); dd != nil {
+					definitions[htmlText(node)] = markupScalar(htmlText(dd))
+				}
+			case "a":
+				if href := htmlAttr(node, "href"); href != "" && !strings.HasPrefix(href, "#") &&
+					!strings.HasPrefix(strings.ToLower(href), "javascript:") && !seen[href] {
+					seen[href] = true
+					links = append(links, href)
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	if len(tables) > 0 {
+		result["tables"] = tables
+	}
+	if len(forms) > 0 {
+		result["forms"] = forms
+	}
+	if len(definitions) > 0 {
+		result["definitions"] = definitions
+	}
+	if len(links) > 0 {
+		result["links"] = links
+	}
+	return result, nil
+}
+
+// htmlTableRows returns the rows of a table as maps of their cells keyed by the headers of
+// the table, or by column1, column2... if it has none
+func htmlTableRows(table *html.Node) []interface{} {
+	headers := make([]string, 0)
+	rows := make([]interface{}, 0)
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "table" && node != table {
+			// Nested tables are scraped on their own
+			return
+		}
+		if node.Type == html.ElementNode && node.Data == "tr" {
+			cells := make([]*html.Node, 0)
+			isHeader := true
+			for cell := node.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					cells = append(cells, cell)
+					isHeader = isHeader && cell.Data == "th"
+				}
+			}
+			if len(cells) == 0 {
+				return
+			}
+			if isHeader && len(headers) == 0 {
+				for _, cell := range cells {
+					headers = append(headers, htmlText(cell))
+				}
+				return
+			}
+			row := make(map[string]interface{})
+			for i, cell := range cells {
+				name := fmt.Sprintf("column%d", i+1)
+				if i < len(headers) && headers[i] != "" {
+					name = headers[i]
+				}
+				row[name] = markupScalar(htmlText(cell))
+			}
+			rows = append(rows, row)
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(table)
+	return rows
+}
+
+// htmlForm returns the action, method and named fields of a form
+func htmlForm(form *html.Node) map[string]interface{} {
+	method := strings.ToUpper(htmlAttr(form, "method"))
+	if method == "" {
+		method = "GET"
+	}
+	fields := make(map[string]interface{})
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			name := htmlAttr(node, "name")
+			switch {
+			case name == "":
+			case node.Data == "input":
+				fields[name] = markupScalar(htmlAttr(node, "value"))
+			case node.Data == "textarea":
+				fields[name] = markupScalar(htmlText(node))
+			case node.Data == "select":
+				fields[name] = markupScalar(htmlSelectValue(node))
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(form)
+	return map[string]interface{}{
+		"action": htmlAttr(form, "action"),
+		"method": method,
+		"fields": fields,
+	}
+}
+
+// htmlSelectValue returns the value of the selected option of a select, or of its first one
+func htmlSelectValue(node *html.Node) string {
+	var first, selected *html.Node
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "option" {
+			if first == nil {
+				first = node
+			}
+			if _, ok := htmlAttrValue(node, "selected"); ok && selected == nil {
+				selected = node
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	if selected == nil {
+		selected = first
+	}
+	if selected == nil {
+		return ""
+	}
+	if value, ok := htmlAttrValue(selected, "value"); ok {
+		return value
+	}
+	return htmlText(selected)
+}
+
+// htmlNextElement returns the next sibling element of a node if it has the given tag
+func htmlNextElement(node *html.Node, tag string) *html.Node {
+	for sibling := node.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode {
+			if sibling.Data == tag {
+				return sibling
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// htmlName returns the id or name of an element, or its tag and position
+func htmlName(node *html.Node, tag string, position int) string {
+	if id := htmlAttr(node, "id"); id != "" {
+		return id
+	}
+	if name := htmlAttr(node, "name"); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s%d", tag, position)
+}
+
+// htmlAttr returns the value of an attribute of an element, or an empty string
+func htmlAttr(node *html.Node, name string) string {
+	value, _ := htmlAttrValue(node, name)
+	return value
+}
+
+// htmlAttrValue returns the value of an attribute of an element and whether it is set
+func htmlAttrValue(node *html.Node, name string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// htmlText returns the text of an element with its whitespace collapsed
+func htmlText(node *html.Node) string {
+	var text strings.Builder
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			text.WriteString(node.Data + " ")
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
+// parseStructuredResponse parses the body of a JSON, XML or HTML response into JSON-like data
+func parseStructuredResponse(resp *ffuf.Response) (interface{}, error) {
+	p := NewResponseParser(resp.ContentType)
+	switch p.format {
+	case FormatJSON, FormatXML, FormatHTML:
+		return p.ParseData(resp.Data)
+	}
+	return nil, api.NewAPIError("Response is not in JSON, XML or HTML format", 0)
+}
+
+// DetectXMLSchema detects the schema of an XML response, in the layout of ParseXML
+func (d *SchemaDetector) DetectXMLSchema(data []byte) (*Schema, error) {
+	value, err := NewResponseParser("application/xml").ParseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	return d.inferSchema(value, "")
+}
+
+// DetectHTMLSchema detects the schema of the data scraped from an HTML page by ParseHTML
+func (d *SchemaDetector) DetectHTMLSchema(data []byte) (*Schema, error) {
+	value, err := NewResponseParser("text/html").ParseHTML(data)
+	if err != nil {
+		return nil, err
+	}
+	return d.inferSchema(value, "")
+}
@@ -0,0 +1,195 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+const testSOAPResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <GetUserResponse xmlns="http://example.com/users">
+      <User id="42" active="true">
+        <Name>Alice</Name>
+        <Email>alice@example.com</Email>
+        <Zip>01234</Zip>
+        <Role>admin</Role>
+        <Role>user</Role>
+        <Note lang="en">Created by import</Note>
+      </User>
+    </GetUserResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+const testHTMLResponse = `<!DOCTYPE html>
+<html>
+<head><title> User   list </title></head>
+<body>
+  <table id="users">
+    <thead><tr><th>ID</th><th>Name</th></tr></thead>
+    <tbody>
+      <tr><td>1</td><td><a href="/users/1">Alice</a></td></tr>
+      <tr><td>2</td><td><a href="/users/2">Bob</a></td></tr>
+    </tbody>
+  </table>
+  <table><tr><td>a</td><td>b</td></tr></table>
+  <form action="/users/search" method="post">
+    <input type="hidden" name="csrf_token" value="abc123">
+    <input type="text" name="query">
+    <select name="sort"><option value="name">Name</option><option value="id" selected>ID</option></select>
+    <textarea name="comment">none</textarea>
+  </form>
+  <dl><dt>Total</dt><dd>2</dd><dt>Updated</dt><dd>2023-01-15T14:30:45Z</dd></dl>
+  <a href="#top">Top</a>
+  <a href="javascript:void(0)">Nothing</a>
+</body>
+</html>`
+
+func TestResponseParser_ParseXML(t *testing.T) {
+	data, err := NewResponseParser("text/xml; charset=utf-8").ParseXML([]byte(testSOAPResponse))
+	if err != nil {
+		t.Fatalf("ParseXML returned an error: %s", err)
+	}
+	expected := map[string]interface{}{
+		"Envelope": map[string]interface{}{
+			"Body": map[string]interface{}{
+				"GetUserResponse": map[string]interface{}{
+					"User": map[string]interface{}{
+						"@id":     42.0,
+						"@active": true,
+						"Name":    "Alice",
+						"Email":   "alice@example.com",
+						"Zip":     "01234",
+						"Role":    []interface{}{"admin", "user"},
+						"Note":    map[string]interface{}{"@lang": "en", "#text": "Created by import"},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected %v, got %v", expected, data)
+	}
+
+	for _, malformed := range []string{"", "<a><b></a>", "not xml"} {
+		if _, err := NewResponseParser("application/xml").ParseXML([]byte(malformed)); err == nil {
+			t.Errorf("Expected an error for %q", malformed)
+		}
+	}
+	if _, err := NewResponseParser("application/json").ParseXML([]byte("<a/>")); err == nil {
+		t.Errorf("Expected an error for a JSON response")
+	}
+}
+
+func TestResponseParser_ParseHTML(t *testing.T) {
+	data, err := NewResponseParser("text/html").ParseHTML([]byte(testHTMLResponse))
+	if err != nil {
+		t.Fatalf("ParseHTML returned an error: %s", err)
+	}
+	expected := map[string]interface{}{
+		"title": "User list",
+		"tables": map[string]interface{}{
+			"users": []interface{}{
+				map[string]interface{}{"ID": 1.0, "Name": "Alice"},
+				map[string]interface{}{"ID": 2.0, "Name": "Bob"},
+			},
+			"table2": []interface{}{
+				map[string]interface{}{"column1": "a", "column2": "b"},
+			},
+		},
+		"forms": map[string]interface{}{
+			"form1": map[string]interface{}{
+				"action": "/users/search",
+				"method": "POST",
+				"fields": map[string]interface{}{"csrf_token": "abc123", "query": "", "sort": "id", "comment": "none"},
+			},
+		},
+		"definitions": map[string]interface{}{"Total": 2.0, "Updated": "2023-01-15T14:30:45Z"},
+		"links":       []interface{}{"/users/1", "/users/2"},
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected %v, got %v", expected, data)
+	}
+}
+
+func TestResponseParser_DetectSchema_Markup(t *testing.T) {
+	schema, err := NewResponseParser("application/soap+xml").DetectSchema([]byte(testSOAPResponse))
+	if err != nil {
+		t.Fatalf("DetectSchema returned an error: %s", err)
+	}
+	user := schema.Properties["Envelope"].Properties["Body"].Properties["GetUserResponse"].Properties["User"]
+	if user.Properties["@id"].Type != TypeInteger {
+		t.Errorf("Expected the id attribute to be an integer, got %s", user.Properties["@id"].Type)
+	}
+	if user.Properties["Email"].Format != FormatEmail {
+		t.Errorf("Expected the email format to be detected, got %q", user.Properties["Email"].Format)
+	}
+	if user.Properties["Role"].Type != TypeArray || user.Properties["Role"].Items.Type != TypeString {
+		t.Errorf("Expected the repeated roles to be an array of strings")
+	}
+
+	schema, err = NewResponseParser("text/html; charset=utf-8").DetectSchema([]byte(testHTMLResponse))
+	if err != nil {
+		t.Fatalf("DetectSchema returned an error: %s", err)
+	}
+	rows := schema.Properties["tables"].Properties["users"]
+	if rows.Type != TypeArray || rows.Items.Properties["ID"].Type != TypeInteger {
+		t.Errorf("Expected the rows of the users table to have an integer ID")
+	}
+	if schema.Properties["definitions"].Properties["Updated"].Format != FormatDateTime {
+		t.Errorf("Expected the date-time format of a definition to be detected")
+	}
+}
+
+func TestCorrelationDetector_Markup(t *testing.T) {
+	detector := NewCorrelationDetector()
+	session := detector.CreateSession("markup")
+	session.AddResponse(&ffuf.Response{
+		StatusCode:  200,
+		ContentType: "text/xml",
+		Data:        []byte(`<users><user><id>12345</id><name>Alice</name></user></users>`),
+		Request:     &ffuf.Request{Method: "GET", Url: "https://api.example.com/users"},
+	}, "")
+	responseID := session.AddResponse(&ffuf.Response{
+		StatusCode:  200,
+		ContentType: "text/html",
+		Data:        []byte(`<table><tr><th>id</th><th>total</th></tr><tr><td>12345</td><td>99</td></tr></table>`),
+		Request:     &ffuf.Request{Method: "GET", Url: "https://api.example.com/orders?user=12345"},
+	}, "")
+
+	correlations, err := detector.DetectCorrelations("markup")
+	if err != nil {
+		t.Fatalf("DetectCorrelations returned an error: %s", err)
+	}
+	if len(correlations) == 0 {
+		t.Errorf("Expected the identifier shared by the XML and HTML responses to be correlated")
+	}
+
+	value, err := session.ExtractValue(responseID, "$.tables.table1[0].total", "total")
+	if err != nil {
+		t.Fatalf("ExtractValue returned an error: %s", err)
+	}
+	if value != "99" {
+		t.Errorf("Expected the total 99, got %q", value)
+	}
+}
+
+func TestVisualizeResponse_Markup(t *testing.T) {
+	visualizer := NewVisualizer(&VisOptions{Format: VisFormatDOT, MaxDepth: 10, IncludeValues: true, Title: "Users"})
+	output, err := visualizer.VisualizeResponse(&ffuf.Response{
+		ContentType: "text/xml",
+		Data:        []byte(testSOAPResponse),
+		Request:     &ffuf.Request{Url: "https://api.example.com/soap"},
+	})
+	if err != nil {
+		t.Fatalf("VisualizeResponse returned an error: %s", err)
+	}
+	for _, expected := range []string{`label="Envelope"`, `label="@id"`, `label="alice@example.com"`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %s in the visualization of an XML response", expected)
+		}
+	}
+}
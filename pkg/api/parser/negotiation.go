@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/contenttype"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// undeclaredMediaType is a media type no endpoint serves
+const undeclaredMediaType = "application/x-ffuf-undeclared"
+
+// serverErrorMatcher fails test cases answered with a server error
+var serverErrorMatcher = &APITestMatcher{
+	Type:     "status",
+	Status:   []int{500, 501, 502, 503, 504, 505, 506, 507, 508, 510, 511},
+	Negative: true,
+}
+
+// generateContentNegotiationTestCases generates test cases asking for each response format
+// the endpoint documents, expecting it as the content type of the response, and for an
+// undeclared one, expecting 406 Not Acceptable. Test cases asking for the documented
+// languages of the Accept-Language parameter, or common ones, and sending Accept and
+// Accept-Language headers with unusual quality values expect no server error.
+func generateContentNegotiationTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	testCases := make([]*APITestCase, 0)
+	base := generateValidRequestTestCases(endpoint, params)[0]
+	newTestCase := func(header, value, description string) *APITestCase {
+		testCase := copyTestCase(base)
+		for key := range testCase.Headers {
+			if strings.EqualFold(key, header) {
+				delete(testCase.Headers, key)
+			}
+		}
+		testCase.Headers[header] = value
+		testCase.Name = fmt.Sprintf("Content negotiation (%s: %s) for %s %s", header, value, endpoint.Method, endpoint.Path)
+		testCase.Description = description
+		testCase.Category = "negotiation"
+		testCase.Priority = 3
+		return testCase
+	}
+
+	for _, mediaType := range endpoint.ResponseTypes {
+		if strings.Contains(mediaType, "*") {
+			continue
+		}
+		testCase := newTestCase("Accept", mediaType, fmt.Sprintf("Test that the documented %s response format is served", mediaType))
+		testCase.ExpectedContentType = mediaType
+		testCases = append(testCases, testCase)
+	}
+	if len(endpoint.ResponseTypes) > 0 {
+		testCase := newTestCase("Accept", undeclaredMediaType, "Test that an undeclared response format is not acceptable")
+		testCase.ExpectedStatus = 406
+		testCases = append(testCases, testCase)
+	}
+
+	// Accept headers use the first documented format
+	req := &ffuf.Request{Headers: make(map[string]string)}
+	if len(endpoint.ResponseTypes) > 0 {
+		req.Headers["Accept"] = endpoint.ResponseTypes[0]
+	}
+	for _, variant := range contenttype.LanguageVariants(req, documentedLanguages(params)) {
+		header := strings.SplitN(variant.Name, ":", 2)[0]
+		description := fmt.Sprintf("Test that the response in the %s language is served without a server error", variant.Header)
+		if variant.Kind == contenttype.VariantQuality {
+			description = fmt.Sprintf("Test that the unusual quality values of the %s header do not cause a server error", header)
+		}
+		testCase := newTestCase(header, variant.Header, description)
+		testCase.ExpectedStatus = 0
+		testCase.Matchers = []*APITestMatcher{serverErrorMatcher}
+		testCases = append(testCases, testCase)
+	}
+	return testCases
+}
+
+// documentedLanguages returns the enum values and the example of the Accept-Language
+// parameter of an endpoint, or the default languages if it has none
+func documentedLanguages(params []*ExtractedParameter) []string {
+	languages := make([]string, 0)
+	for _, param := range params {
+		if param.In != "header" || !strings.EqualFold(param.Name, "Accept-Language") {
+			continue
+		}
+		values := make([]interface{}, 0)
+		if param.Schema != nil {
+			values = append(values, param.Schema.Enum...)
+		}
+		values = append(values, param.Example)
+		for _, value := range values {
+			if language, ok := value.(string); ok && language != "" {
+				languages = appendUnique(languages, language)
+			}
+		}
+	}
+	if len(languages) == 0 {
+		return contenttype.DefaultLanguages
+	}
+	return languages
+}
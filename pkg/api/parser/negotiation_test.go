@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestContentNegotiationTestCases(t *testing.T) {
+	parser := NewOpenAPIParser()
+	err := parser.ParseJSON([]byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Items", "version": "1.0"},
+		"paths": {
+			"/items": {
+				"get": {
+					"parameters": [{"name": "Accept-Language", "in": "header", "schema": {"type": "string", "enum": ["en", "de"]}}],
+					"responses": {
+						"200": {"content": {"application/xml": {"schema": {"type": "array"}}, "application/json": {"schema": {"type": "array"}}}},
+						"400": {"content": {"application/problem+json": {"schema": {"type": "object"}}}}
+					}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+	discovery := NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.addOpenAPIEndpoints(parser)
+	endpoint := discovery.GetEndpoints()[0]
+	if strings.Join(endpoint.ResponseTypes, ",") != "application/json,application/xml" {
+		t.Fatalf("ResponseTypes = %v, want the media types of the successful response", endpoint.ResponseTypes)
+	}
+
+	extractor := NewAPIParameterExtractor(discovery)
+	if err := extractor.ExtractParameters(); err != nil {
+		t.Fatalf("ExtractParameters() error = %v", err)
+	}
+	generator := NewAPITestGenerator(discovery, extractor)
+	testCases := generateContentNegotiationTestCases(endpoint, generator.endpointParameters(endpoint))
+
+	byName := make(map[string]*APITestCase)
+	for _, testCase := range testCases {
+		byName[testCase.Name] = testCase
+		if testCase.Category != "negotiation" {
+			t.Errorf("Test case %q has category %q", testCase.Name, testCase.Category)
+		}
+	}
+	if xml := byName["Content negotiation (Accept: application/xml) for GET /items"]; xml == nil || xml.ExpectedContentType != "application/xml" || xml.ExpectedStatus != 200 {
+		t.Errorf("Expected a test case asking for the documented XML format, got %+v", xml)
+	}
+	if undeclared := byName["Content negotiation (Accept: "+undeclaredMediaType+") for GET /items"]; undeclared == nil || undeclared.ExpectedStatus != 406 {
+		t.Errorf("Expected a test case asking for an undeclared format, got %+v", undeclared)
+	}
+	german := byName["Content negotiation (Accept-Language: de) for GET /items"]
+	if german == nil || german.Headers["Accept-Language"] != "de" || german.ExpectedStatus != 0 {
+		t.Fatalf("Expected a test case asking for the documented German language, got %+v", german)
+	}
+	if byName["Content negotiation (Accept-Language: fr) for GET /items"] != nil {
+		t.Error("Expected only the documented languages")
+	}
+	if quality := byName["Content negotiation (Accept: application/json;q=1.5) for GET /items"]; quality == nil || quality.Headers["Accept"] != "application/json;q=1.5" {
+		t.Errorf("Expected a test case with an unusual quality value, got %+v", quality)
+	}
+
+	if !german.MatchResponse(&ffuf.Response{StatusCode: 200}) || german.MatchResponse(&ffuf.Response{StatusCode: 500}) {
+		t.Error("Expected server errors to fail the test cases")
+	}
+	if languages := documentedLanguages(nil); len(languages) == 0 || languages[0] != "fr" {
+		t.Errorf("documentedLanguages() = %v, want the default languages", languages)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -34,6 +35,8 @@ type OpenAPIParser struct {
 	Spec *OpenAPISpec
 	// The version of the specification
 	Version OpenAPIVersion
+	// HTTP client used to fetch specifications from URLs
+	Client *http.Client
 }
 
 // OpenAPISpec represents a parsed OpenAPI/Swagger specification
@@ -68,6 +71,8 @@ type OpenAPIEndpoint struct {
 	RequestBody *OpenAPISchema
 	// Response schemas
 	Responses map[string]*OpenAPISchema
+	// Media types of the successful responses
+	Produces []string
 	// Tags associated with the endpoint
 	Tags []string
 	// Whether the endpoint requires authentication
@@ -106,6 +111,24 @@ type OpenAPISchema struct {
 	Enum []interface{}
 	// Example value for the schema
 	Example interface{}
+	// Default value for the schema
+	Default interface{}
+	// Pattern is the regular expression string values must match
+	Pattern string
+	// MinLength and MaxLength constrain the length of string values
+	MinLength *int
+	MaxLength *int
+	// Minimum and Maximum constrain numeric values, exclusively if ExclusiveMinimum or
+	// ExclusiveMaximum is set
+	Minimum          *float64
+	Maximum          *float64
+	ExclusiveMinimum bool
+	ExclusiveMaximum bool
+	// MultipleOf constrains numeric values to multiples of a number
+	MultipleOf *float64
+	// MinItems and MaxItems constrain the number of items of array values
+	MinItems *int
+	MaxItems *int
 }
 
 // NewOpenAPIParser creates a new OpenAPIParser
@@ -115,6 +138,9 @@ func NewOpenAPIParser() *OpenAPIParser {
 			Endpoints: make([]*OpenAPIEndpoint, 0),
 			Raw:       make(map[string]interface{}),
 		},
+		Client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
 	}
 }
 
@@ -149,13 +175,8 @@ func (p *OpenAPIParser) ParseFromURL(specURL string) error {
 		return api.NewAPIError(fmt.Sprintf("Invalid URL: %s", err.Error()), 0)
 	}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
 	// Make the request
-	resp, err := client.Get(specURL)
+	resp, err := p.Client.Get(specURL)
 	if err != nil {
 		return api.NewAPIError(fmt.Sprintf("Failed to fetch OpenAPI spec: %s", err.Error()), 0)
 	}
@@ -341,9 +362,11 @@ func (p *OpenAPIParser) parseSpec(spec map[string]interface{}) error {
 											parameter.Example = example
 										}
 
-										// Extract schema
+										// Extract schema, Swagger 2.0 declares non-body parameter schemas inline
 										if schema, ok := paramMap["schema"].(map[string]interface{}); ok {
 											parameter.Schema = p.extractSchema(schema)
+										} else if _, ok := paramMap["type"].(string); ok {
+											parameter.Schema = p.extractSchema(paramMap)
 										}
 
 										endpoint.Parameters = append(endpoint.Parameters, parameter)
@@ -351,6 +374,17 @@ func (p *OpenAPIParser) parseSpec(spec map[string]interface{}) error {
 								}
 							}
 
+							// Extract the response media types of the operation, or of the spec
+							produces, ok := op["produces"].([]interface{})
+							if !ok {
+								produces, _ = spec["produces"].([]interface{})
+							}
+							for _, mediaType := range produces {
+								if m, ok := mediaType.(string); ok {
+									endpoint.Produces = append(endpoint.Produces, m)
+								}
+							}
+
 							// Check if authentication is required
 							if security, ok := op["security"].([]interface{}); ok && len(security) > 0 {
 								endpoint.RequiresAuth = true
@@ -421,9 +455,11 @@ func (p *OpenAPIParser) parseSpec(spec map[string]interface{}) error {
 											parameter.Example = example
 										}
 
-										// Extract schema
+										// Extract schema, Swagger 2.0 declares non-body parameter schemas inline
 										if schema, ok := paramMap["schema"].(map[string]interface{}); ok {
 											parameter.Schema = p.extractSchema(schema)
+										} else if _, ok := paramMap["type"].(string); ok {
+											parameter.Schema = p.extractSchema(paramMap)
 										}
 
 										endpoint.Parameters = append(endpoint.Parameters, parameter)
@@ -463,6 +499,11 @@ func (p *OpenAPIParser) parseSpec(spec map[string]interface{}) error {
 								for code, response := range responses {
 									if resp, ok := response.(map[string]interface{}); ok {
 										if content, ok := resp["content"].(map[string]interface{}); ok {
+											if strings.HasPrefix(code, "2") {
+												for mediaType := range content {
+													endpoint.Produces = appendUnique(endpoint.Produces, mediaType)
+												}
+											}
 											// Try to get JSON schema first, then any other content type
 											var schema map[string]interface{}
 											if jsonContent, ok := content["application/json"].(map[string]interface{}); ok {
@@ -489,6 +530,9 @@ func (p *OpenAPIParser) parseSpec(spec map[string]interface{}) error {
 								}
 							}
 
+							// Content types are listed in a map
+							sort.Strings(endpoint.Produces)
+
 							// Check if authentication is required
 							if security, ok := op["security"].([]interface{}); ok && len(security) > 0 {
 								endpoint.RequiresAuth = true
@@ -560,10 +604,63 @@ func (p *OpenAPIParser) extractSchema(schema map[string]interface{}) *OpenAPISch
 	if example, ok := schema["example"]; ok {
 		result.Example = example
 	}
+	if def, ok := schema["default"]; ok {
+		result.Default = def
+	}
+
+	// Extract constraints
+	if pattern, ok := schema["pattern"].(string); ok {
+		result.Pattern = pattern
+	}
+	result.MinLength = schemaInt(schema, "minLength")
+	result.MaxLength = schemaInt(schema, "maxLength")
+	result.MinItems = schemaInt(schema, "minItems")
+	result.MaxItems = schemaInt(schema, "maxItems")
+	result.Minimum = schemaNumber(schema, "minimum")
+	result.Maximum = schemaNumber(schema, "maximum")
+	result.MultipleOf = schemaNumber(schema, "multipleOf")
+	// OpenAPI 3.0 uses booleans modifying minimum and maximum, OpenAPI 3.1 uses numbers
+	if exclusive, ok := schema["exclusiveMinimum"].(bool); ok {
+		result.ExclusiveMinimum = exclusive
+	} else if exclusive := schemaNumber(schema, "exclusiveMinimum"); exclusive != nil {
+		result.Minimum, result.ExclusiveMinimum = exclusive, true
+	}
+	if exclusive, ok := schema["exclusiveMaximum"].(bool); ok {
+		result.ExclusiveMaximum = exclusive
+	} else if exclusive := schemaNumber(schema, "exclusiveMaximum"); exclusive != nil {
+		result.Maximum, result.ExclusiveMaximum = exclusive, true
+	}
 
 	return result
 }
 
+// schemaNumber returns a numeric keyword of a schema, or nil if it is not set
+func schemaNumber(schema map[string]interface{}, keyword string) *float64 {
+	switch v := schema[keyword].(type) {
+	case float64:
+		return &v
+	case int:
+		f := float64(v)
+		return &f
+	case int64:
+		f := float64(v)
+		return &f
+	case uint64:
+		f := float64(v)
+		return &f
+	}
+	return nil
+}
+
+// schemaInt returns an integer keyword of a schema, or nil if it is not set
+func schemaInt(schema map[string]interface{}, keyword string) *int {
+	if f := schemaNumber(schema, keyword); f != nil {
+		i := int(*f)
+		return &i
+	}
+	return nil
+}
+
 // GetEndpoints returns all endpoints from the specification
 func (p *OpenAPIParser) GetEndpoints() []*OpenAPIEndpoint {
 	return p.Spec.Endpoints
@@ -703,3 +800,13 @@ func isHTTPMethod(method string) bool {
 	return method == "GET" || method == "POST" || method == "PUT" || method == "DELETE" ||
 		method == "PATCH" || method == "HEAD" || method == "OPTIONS" || method == "TRACE"
 }
+
+// appendUnique appends a value to a list if it is not part of it yet
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -24,6 +24,8 @@ type APIParameterExtractor struct {
 	ParameterDescriptions map[string]string
 	// Parameter examples
 	ParameterExamples map[string]interface{}
+	// Parameter schemas
+	ParameterSchemas map[string]*OpenAPISchema
 }
 
 // ExtractedParameter represents a parameter extracted from API documentation
@@ -44,6 +46,8 @@ type ExtractedParameter struct {
 	Endpoints []*DiscoveredEndpoint
 	// Frequency of the parameter across all endpoints
 	Frequency int
+	// Schema of the parameter, if known from the specification
+	Schema *OpenAPISchema
 }
 
 // NewAPIParameterExtractor creates a new APIParameterExtractor
@@ -56,6 +60,7 @@ func NewAPIParameterExtractor(discovery *APIEndpointDiscovery) *APIParameterExtr
 		RequiredParameters:    make(map[string]bool),
 		ParameterDescriptions: make(map[string]string),
 		ParameterExamples:     make(map[string]interface{}),
+		ParameterSchemas:      make(map[string]*OpenAPISchema),
 	}
 }
 
@@ -94,6 +99,9 @@ func (e *APIParameterExtractor) ExtractParameters() error {
 			if param.Example != nil {
 				e.ParameterExamples[param.Name] = param.Example
 			}
+			if param.Schema != nil {
+				e.ParameterSchemas[param.Name] = param.Schema
+			}
 		}
 	}
 
@@ -108,6 +116,7 @@ func (e *APIParameterExtractor) ExtractParameters() error {
 			Example:     e.ParameterExamples[name],
 			Endpoints:   paramEndpoints[name],
 			Frequency:   frequency,
+			Schema:      e.ParameterSchemas[name],
 		}
 		e.Parameters = append(e.Parameters, param)
 	}
@@ -183,6 +192,66 @@ func (e *APIParameterExtractor) GetParameterByName(name string) *ExtractedParame
 	return nil
 }
 
+// Pagination roles of parameters
+const (
+	// PaginationLimit parameters set the number of items per page
+	PaginationLimit = "limit"
+	// PaginationOffset parameters set the number of items skipped
+	PaginationOffset = "offset"
+	// PaginationPage parameters set the page number
+	PaginationPage = "page"
+	// PaginationSort parameters set the field items are sorted by
+	PaginationSort = "sort"
+	// PaginationFilter parameters filter or search items
+	PaginationFilter = "filter"
+)
+
+// PaginationParameterNames are the parameter names of each pagination role, normalized to
+// lower case without separators. The first name of each role is the most common one.
+var PaginationParameterNames = map[string][]string{
+	PaginationLimit:  {"limit", "pagesize", "perpage", "size", "max", "maxresults", "top", "first", "take", "rows", "pagelimit"},
+	PaginationOffset: {"offset", "skip", "start", "from", "startindex"},
+	PaginationPage:   {"page", "pagenumber", "pagenum", "pageno", "pageindex"},
+	PaginationSort:   {"sort", "sortby", "orderby", "order", "sortfield", "ordering"},
+	PaginationFilter: {"filter", "q", "query", "search", "where", "keyword", "term"},
+}
+
+// PaginationRole returns the pagination role of a parameter name, or an empty string. Bracket
+// notations such as page[size] and filter[name] are recognized, as are the $top, $skip,
+// $orderby and $filter parameters of OData.
+func PaginationRole(name string) string {
+	normalized := strings.ToLower(name)
+	if i := strings.Index(normalized, "["); i > 0 {
+		if normalized[:i] == PaginationFilter {
+			return PaginationFilter
+		}
+		normalized = normalized[:i] + strings.Trim(normalized[i:], "[]")
+	}
+	normalized = strings.NewReplacer("_", "", "-", "", "$", "", ".", "").Replace(normalized)
+	for _, role := range []string{PaginationLimit, PaginationOffset, PaginationPage, PaginationSort, PaginationFilter} {
+		for _, candidate := range PaginationParameterNames[role] {
+			if normalized == candidate {
+				return role
+			}
+		}
+	}
+	return ""
+}
+
+// GetPaginationParameters returns the query parameters with a pagination role, by role
+func (e *APIParameterExtractor) GetPaginationParameters() map[string][]*ExtractedParameter {
+	params := make(map[string][]*ExtractedParameter)
+	for _, param := range e.Parameters {
+		if param.In != "" && !strings.EqualFold(param.In, "query") {
+			continue
+		}
+		if role := PaginationRole(param.Name); role != "" {
+			params[role] = append(params[role], param)
+		}
+	}
+	return params
+}
+
 // GenerateParameterWordlist generates a wordlist of parameter names
 func (e *APIParameterExtractor) GenerateParameterWordlist() []string {
 	params := make([]string, 0, len(e.Parameters))
@@ -290,6 +359,38 @@ func (e *APIParameterExtractor) ExtractParametersFromOpenAPI(specPath string) er
 	return e.ExtractParameters()
 }
 
+// ExtractParametersFromPostman extracts parameters from a Postman collection
+func (e *APIParameterExtractor) ExtractParametersFromPostman(collectionPath string) error {
+	// Create a new discovery if not provided
+	if e.Discovery == nil {
+		e.Discovery = NewAPIEndpointDiscovery("")
+	}
+
+	// Discover endpoints from the Postman collection
+	if err := e.Discovery.DiscoverFromPostman(collectionPath); err != nil {
+		return err
+	}
+
+	// Extract parameters from the discovered endpoints
+	return e.ExtractParameters()
+}
+
+// ExtractParametersFromHAR extracts parameters from requests captured in a HAR file
+func (e *APIParameterExtractor) ExtractParametersFromHAR(harPath string) error {
+	// Create a new discovery if not provided
+	if e.Discovery == nil {
+		e.Discovery = NewAPIEndpointDiscovery("")
+	}
+
+	// Discover endpoints from the HAR capture
+	if err := e.Discovery.DiscoverFromHAR(harPath); err != nil {
+		return err
+	}
+
+	// Extract parameters from the discovered endpoints
+	return e.ExtractParameters()
+}
+
 // ExtractParametersFromDirectory extracts parameters from all OpenAPI/Swagger specifications in a directory
 func (e *APIParameterExtractor) ExtractParametersFromDirectory(dirPath string) error {
 	// Create a new discovery if not provided
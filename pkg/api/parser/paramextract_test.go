@@ -369,6 +369,69 @@ func TestAPIParameterExtractor_EmptyDiscovery(t *testing.T) {
 	}
 }
 
+func TestPaginationRole(t *testing.T) {
+	tests := map[string]string{
+		"limit":        PaginationLimit,
+		"per_page":     PaginationLimit,
+		"pageSize":     PaginationLimit,
+		"page[size]":   PaginationLimit,
+		"$top":         PaginationLimit,
+		"$skip":        PaginationOffset,
+		"offset":       PaginationOffset,
+		"page":         PaginationPage,
+		"page[number]": PaginationPage,
+		"sort_by":      PaginationSort,
+		"$orderby":     PaginationSort,
+		"filter[name]": PaginationFilter,
+		"$filter":      PaginationFilter,
+		"q":            PaginationFilter,
+		"$count":       "",
+		"user_id":      "",
+	}
+	for name, expected := range tests {
+		if role := PaginationRole(name); role != expected {
+			t.Errorf("Expected role %q for %s, got %q", expected, name, role)
+		}
+	}
+}
+
+func TestAPIParameterExtractor_GetPaginationParameters(t *testing.T) {
+	discovery := NewAPIEndpointDiscovery("")
+	discovery.Endpoints = []*DiscoveredEndpoint{
+		{
+			Path:   "/api/users",
+			Method: "GET",
+			Parameters: []*DiscoveredParameter{
+				{Name: "per_page", In: "query", Type: "integer"},
+				{Name: "page", In: "query", Type: "integer"},
+				{Name: "sort", In: "query", Type: "string"},
+				{Name: "filter[email]", In: "query", Type: "string"},
+				{Name: "limit", In: "header", Type: "integer"},
+			},
+		},
+	}
+	extractor := NewAPIParameterExtractor(discovery)
+	if err := extractor.ExtractParameters(); err != nil {
+		t.Fatalf("ExtractParameters returned an error: %s", err)
+	}
+
+	params := extractor.GetPaginationParameters()
+	expected := map[string]string{
+		PaginationLimit:  "per_page",
+		PaginationPage:   "page",
+		PaginationSort:   "sort",
+		PaginationFilter: "filter[email]",
+	}
+	if len(params) != len(expected) {
+		t.Errorf("Expected %d roles, got %d", len(expected), len(params))
+	}
+	for role, name := range expected {
+		if len(params[role]) != 1 || params[role][0].Name != name {
+			t.Errorf("Expected %s as the %s parameter, got %v", name, role, params[role])
+		}
+	}
+}
+
 func TestGenerateStringPayloads(t *testing.T) {
 	param := &ExtractedParameter{
 		Name:    "test",
@@ -25,6 +25,10 @@ const (
 	FormatXML
 	// FormatGraphQL represents a GraphQL response
 	FormatGraphQL
+	// FormatNDJSON represents a stream of newline-delimited JSON records
+	FormatNDJSON
+	// FormatHTML represents an HTML response
+	FormatHTML
 )
 
 // ResponseParser provides methods for parsing API responses
@@ -37,9 +41,13 @@ func NewResponseParser(contentType string) *ResponseParser {
 	format := FormatUnknown
 	
 	contentType = strings.ToLower(contentType)
-	if strings.Contains(contentType, "application/json") {
+	if isNDJSONContentType(contentType) {
+		format = FormatNDJSON
+	} else if strings.Contains(contentType, "application/json") {
 		format = FormatJSON
-	} else if strings.Contains(contentType, "application/xml") || strings.Contains(contentType, "text/xml") {
+	} else if strings.Contains(contentType, "text/html") || strings.Contains(contentType, "application/xhtml+xml") {
+		format = FormatHTML
+	} else if strings.Contains(contentType, "application/xml") || strings.Contains(contentType, "text/xml") || strings.Contains(contentType, "+xml") {
 		format = FormatXML
 	} else if strings.Contains(contentType, "application/graphql") {
 		format = FormatGraphQL
@@ -0,0 +1,493 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// PostmanParser provides methods for parsing Postman Collection v2.1 exports
+type PostmanParser struct {
+	// Name of the collection
+	Name string
+	// Description of the collection
+	Description string
+	// Collection variables, used to resolve {{variable}} placeholders
+	Variables map[string]string
+	// Extracted endpoints from the collection
+	Endpoints []*PostmanEndpoint
+	// Base URL derived from the first resolved request URL
+	BaseURL string
+}
+
+// PostmanEndpoint represents a request extracted from a Postman collection
+type PostmanEndpoint struct {
+	// Name of the request
+	Name string
+	// Folder path of the request inside the collection
+	Folders []string
+	// HTTP method (GET, POST, etc.)
+	Method string
+	// Full URL of the request with variables resolved
+	URL string
+	// Path of the request with path variables in {name} form
+	Path string
+	// Description of the request
+	Description string
+	// Parameters of the request (path, query, header, body)
+	Parameters []*DiscoveredParameter
+	// Raw request body
+	Body string
+	// Body mode (raw, urlencoded, formdata, graphql)
+	BodyMode string
+	// Effective authentication type after folder/collection inheritance
+	AuthType string
+	// Whether the request carries a pre-request script
+	HasPreRequestScript bool
+}
+
+// postmanCollection mirrors the subset of the Postman v2.1 schema used by the parser
+type postmanCollection struct {
+	Info struct {
+		Name        string          `json:"name"`
+		Description json.RawMessage `json:"description"`
+		Schema      string          `json:"schema"`
+	} `json:"info"`
+	Item     []*postmanItem     `json:"item"`
+	Auth     *postmanAuth       `json:"auth"`
+	Event    []*postmanEvent    `json:"event"`
+	Variable []*postmanKeyValue `json:"variable"`
+}
+
+type postmanItem struct {
+	Name        string          `json:"name"`
+	Description json.RawMessage `json:"description"`
+	Item        []*postmanItem  `json:"item"`
+	Request     json.RawMessage `json:"request"`
+	Auth        *postmanAuth    `json:"auth"`
+	Event       []*postmanEvent `json:"event"`
+}
+
+type postmanRequest struct {
+	Method      string             `json:"method"`
+	URL         json.RawMessage    `json:"url"`
+	Header      []*postmanKeyValue `json:"header"`
+	Body        *postmanBody       `json:"body"`
+	Auth        *postmanAuth       `json:"auth"`
+	Description json.RawMessage    `json:"description"`
+}
+
+type postmanURL struct {
+	Raw      string             `json:"raw"`
+	Protocol string             `json:"protocol"`
+	Host     json.RawMessage    `json:"host"`
+	Path     json.RawMessage    `json:"path"`
+	Query    []*postmanKeyValue `json:"query"`
+	Variable []*postmanKeyValue `json:"variable"`
+}
+
+type postmanBody struct {
+	Mode       string             `json:"mode"`
+	Raw        string             `json:"raw"`
+	URLEncoded []*postmanKeyValue `json:"urlencoded"`
+	FormData   []*postmanKeyValue `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+}
+
+type postmanAuth struct {
+	Type string `json:"type"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+}
+
+type postmanKeyValue struct {
+	Key         string          `json:"key"`
+	Value       interface{}     `json:"value"`
+	Type        string          `json:"type"`
+	Disabled    bool            `json:"disabled"`
+	Description json.RawMessage `json:"description"`
+}
+
+// postmanVariablePattern matches {{variable}} placeholders
+var postmanVariablePattern = regexp.MustCompile(`{{\s*([^{}\s]+)\s*}}`)
+
+// NewPostmanParser creates a new PostmanParser
+func NewPostmanParser() *PostmanParser {
+	return &PostmanParser{
+		Variables: make(map[string]string),
+		Endpoints: make([]*PostmanEndpoint, 0),
+	}
+}
+
+// SetVariable sets a variable used to resolve {{variable}} placeholders.
+// Variables set before parsing take precedence over collection variables.
+func (p *PostmanParser) SetVariable(name, value string) {
+	p.Variables[name] = value
+}
+
+// ParseFromFile parses a Postman collection from a file
+func (p *PostmanParser) ParseFromFile(filePath string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to read Postman collection: %s", err.Error()), 0)
+	}
+	return p.ParseJSON(data)
+}
+
+// ParseJSON parses a Postman collection from JSON data
+func (p *PostmanParser) ParseJSON(data []byte) error {
+	var collection postmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to parse Postman collection: %s", err.Error()), 0)
+	}
+
+	if collection.Info.Schema != "" && !strings.Contains(collection.Info.Schema, "v2.") {
+		return api.NewAPIError(fmt.Sprintf("Unsupported Postman collection schema: %s", collection.Info.Schema), 0)
+	}
+	if collection.Item == nil {
+		return api.NewAPIError("Invalid Postman collection: missing item list", 0)
+	}
+
+	p.Name = collection.Info.Name
+	p.Description = postmanDescription(collection.Info.Description)
+
+	// Collection variables never override variables set by the caller
+	for _, v := range collection.Variable {
+		if v == nil || v.Disabled {
+			continue
+		}
+		if _, ok := p.Variables[v.Key]; !ok {
+			p.Variables[v.Key] = postmanValue(v.Value)
+		}
+	}
+
+	p.walkItems(collection.Item, nil, postmanAuthType(collection.Auth), postmanHasPreRequest(collection.Event))
+	return nil
+}
+
+// walkItems recursively walks folders and requests, applying auth inheritance
+func (p *PostmanParser) walkItems(items []*postmanItem, folders []string, inheritedAuth string, inheritedScript bool) {
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+
+		itemAuth := inheritedAuth
+		if item.Auth != nil {
+			itemAuth = postmanAuthType(item.Auth)
+		}
+		itemScript := inheritedScript || postmanHasPreRequest(item.Event)
+
+		// Folders contain further items and no request
+		if len(item.Request) == 0 {
+			path := append(append([]string{}, folders...), item.Name)
+			p.walkItems(item.Item, path, itemAuth, itemScript)
+			continue
+		}
+
+		endpoint := p.parseRequest(item, itemAuth)
+		if endpoint == nil {
+			continue
+		}
+		endpoint.Folders = folders
+		endpoint.HasPreRequestScript = itemScript
+		p.Endpoints = append(p.Endpoints, endpoint)
+	}
+}
+
+// parseRequest converts a single Postman request item into a PostmanEndpoint
+func (p *PostmanParser) parseRequest(item *postmanItem, inheritedAuth string) *PostmanEndpoint {
+	var req postmanRequest
+
+	// A request may be a plain URL string
+	var rawURL string
+	if err := json.Unmarshal(item.Request, &rawURL); err == nil {
+		req.Method = "GET"
+		req.URL, _ = json.Marshal(rawURL)
+	} else if err := json.Unmarshal(item.Request, &req); err != nil {
+		return nil
+	}
+
+	endpoint := &PostmanEndpoint{
+		Name:        item.Name,
+		Method:      strings.ToUpper(req.Method),
+		Description: postmanDescription(req.Description),
+		Parameters:  make([]*DiscoveredParameter, 0),
+		AuthType:    inheritedAuth,
+	}
+	if endpoint.Method == "" {
+		endpoint.Method = "GET"
+	}
+	if endpoint.Description == "" {
+		endpoint.Description = postmanDescription(item.Description)
+	}
+	if req.Auth != nil {
+		endpoint.AuthType = postmanAuthType(req.Auth)
+	}
+
+	p.parseURL(req.URL, endpoint)
+
+	// Headers
+	for _, h := range req.Header {
+		if h == nil || h.Disabled {
+			continue
+		}
+		if strings.EqualFold(h.Key, "Authorization") && endpoint.AuthType == "" {
+			endpoint.AuthType = "header"
+		}
+		endpoint.Parameters = append(endpoint.Parameters, &DiscoveredParameter{
+			Name:        h.Key,
+			In:          "header",
+			Type:        "string",
+			Description: postmanDescription(h.Description),
+			Example:     p.resolve(postmanValue(h.Value)),
+		})
+	}
+
+	// Body
+	if req.Body != nil {
+		endpoint.BodyMode = req.Body.Mode
+		switch req.Body.Mode {
+		case "raw":
+			endpoint.Body = p.resolve(req.Body.Raw)
+			endpoint.Parameters = append(endpoint.Parameters, bodyParametersFromJSON(endpoint.Body)...)
+		case "urlencoded", "formdata":
+			fields := req.Body.URLEncoded
+			if req.Body.Mode == "formdata" {
+				fields = req.Body.FormData
+			}
+			values := url.Values{}
+			for _, f := range fields {
+				if f == nil || f.Disabled {
+					continue
+				}
+				paramType := "string"
+				if f.Type == "file" {
+					paramType = "file"
+				}
+				value := p.resolve(postmanValue(f.Value))
+				values.Add(f.Key, value)
+				endpoint.Parameters = append(endpoint.Parameters, &DiscoveredParameter{
+					Name:        f.Key,
+					In:          "body",
+					Type:        paramType,
+					Description: postmanDescription(f.Description),
+					Example:     value,
+				})
+			}
+			endpoint.Body = values.Encode()
+		case "graphql":
+			if req.Body.GraphQL != nil {
+				body, _ := json.Marshal(map[string]string{
+					"query":     req.Body.GraphQL.Query,
+					"variables": req.Body.GraphQL.Variables,
+				})
+				endpoint.Body = string(body)
+			}
+		}
+	}
+
+	return endpoint
+}
+
+// parseURL fills the URL, path and URL-derived parameters of an endpoint
+func (p *PostmanParser) parseURL(raw json.RawMessage, endpoint *PostmanEndpoint) {
+	var u postmanURL
+	var rawString string
+	if err := json.Unmarshal(raw, &rawString); err == nil {
+		u.Raw = rawString
+	} else if err := json.Unmarshal(raw, &u); err != nil {
+		return
+	}
+
+	resolved := p.resolve(u.Raw)
+	if resolved == "" {
+		// Rebuild the URL from its components when raw is missing
+		host := strings.Join(postmanStringList(u.Host), ".")
+		path := strings.Join(postmanStringList(u.Path), "/")
+		resolved = p.resolve(host + "/" + path)
+		if u.Protocol != "" {
+			resolved = u.Protocol + "://" + resolved
+		}
+	}
+	endpoint.URL = resolved
+
+	pathOnly := resolved
+	if parsed, err := url.Parse(resolved); err == nil && parsed.Host != "" {
+		pathOnly = parsed.EscapedPath()
+		if p.BaseURL == "" {
+			p.BaseURL = parsed.Scheme + "://" + parsed.Host
+		}
+		// Query parameters only present in the raw URL
+		if len(u.Query) == 0 {
+			for name, values := range parsed.Query() {
+				u.Query = append(u.Query, &postmanKeyValue{Key: name, Value: values[0]})
+			}
+		}
+	} else if idx := strings.IndexAny(pathOnly, "?#"); idx >= 0 {
+		pathOnly = pathOnly[:idx]
+	}
+	if pathOnly == "" {
+		pathOnly = "/"
+	}
+
+	// Convert Postman :variable segments into {variable} segments
+	segments := strings.Split(pathOnly, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") && len(segment) > 1 {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+
+			param := &DiscoveredParameter{Name: name, In: "path", Required: true, Type: "string"}
+			for _, v := range u.Variable {
+				if v != nil && v.Key == name {
+					param.Example = p.resolve(postmanValue(v.Value))
+					param.Description = postmanDescription(v.Description)
+				}
+			}
+			endpoint.Parameters = append(endpoint.Parameters, param)
+		}
+	}
+	endpoint.Path = strings.Join(segments, "/")
+
+	for _, q := range u.Query {
+		if q == nil || q.Disabled {
+			continue
+		}
+		endpoint.Parameters = append(endpoint.Parameters, &DiscoveredParameter{
+			Name:        q.Key,
+			In:          "query",
+			Type:        "string",
+			Description: postmanDescription(q.Description),
+			Example:     p.resolve(postmanValue(q.Value)),
+		})
+	}
+}
+
+// resolve replaces {{variable}} placeholders with known variable values
+func (p *PostmanParser) resolve(s string) string {
+	return postmanVariablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := postmanVariablePattern.FindStringSubmatch(match)[1]
+		if value, ok := p.Variables[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// GetEndpoints returns all endpoints extracted from the collection
+func (p *PostmanParser) GetEndpoints() []*PostmanEndpoint {
+	return p.Endpoints
+}
+
+// bodyParametersFromJSON extracts top-level fields of a JSON object body as body parameters
+func bodyParametersFromJSON(body string) []*DiscoveredParameter {
+	params := make([]*DiscoveredParameter, 0)
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return params
+	}
+	for name, value := range data {
+		params = append(params, &DiscoveredParameter{
+			Name:    name,
+			In:      "body",
+			Type:    jsonValueType(value),
+			Example: value,
+		})
+	}
+	return params
+}
+
+// jsonValueType returns the OpenAPI type name of a decoded JSON value
+func jsonValueType(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// postmanAuthType returns the effective auth type, treating "noauth" as no authentication
+func postmanAuthType(auth *postmanAuth) string {
+	if auth == nil || auth.Type == "noauth" {
+		return ""
+	}
+	return auth.Type
+}
+
+// postmanHasPreRequest reports whether an event list contains a pre-request script
+func postmanHasPreRequest(events []*postmanEvent) bool {
+	for _, e := range events {
+		if e != nil && e.Listen == "prerequest" {
+			return true
+		}
+	}
+	return false
+}
+
+// postmanDescription decodes a description that may be a string or an object with a content field
+func postmanDescription(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var obj struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &obj); err == nil {
+		return obj.Content
+	}
+	return ""
+}
+
+// postmanStringList decodes a host/path field that may be a string or a list of strings
+func postmanStringList(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}
+	}
+	return nil
+}
+
+// postmanValue converts a key/value entry value to a string
+func postmanValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", value)
+}
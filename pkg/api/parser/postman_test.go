@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testPostmanCollection = `{
+	"info": {
+		"name": "Test Collection",
+		"description": "Collection for testing the Postman parser",
+		"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+	},
+	"auth": {
+		"type": "bearer",
+		"bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]
+	},
+	"variable": [
+		{"key": "baseUrl", "value": "https://api.example.com"},
+		{"key": "token", "value": "secret"}
+	],
+	"item": [
+		{
+			"name": "Users",
+			"item": [
+				{
+					"name": "Get user",
+					"request": {
+						"method": "GET",
+						"url": {
+							"raw": "{{baseUrl}}/users/:id?fields=name",
+							"host": ["{{baseUrl}}"],
+							"path": ["users", ":id"],
+							"query": [{"key": "fields", "value": "name"}],
+							"variable": [{"key": "id", "value": "42"}]
+						}
+					}
+				},
+				{
+					"name": "Create user",
+					"request": {
+						"method": "POST",
+						"header": [{"key": "Content-Type", "value": "application/json"}],
+						"body": {
+							"mode": "raw",
+							"raw": "{\"name\": \"alice\", \"age\": 30}"
+						},
+						"url": "{{baseUrl}}/users"
+					}
+				}
+			]
+		},
+		{
+			"name": "Health",
+			"request": {
+				"auth": {"type": "noauth"},
+				"method": "GET",
+				"url": "{{baseUrl}}/health"
+			}
+		}
+	]
+}`
+
+func TestPostmanParser_ParseJSON(t *testing.T) {
+	parser := NewPostmanParser()
+	if err := parser.ParseJSON([]byte(testPostmanCollection)); err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+
+	if parser.Name != "Test Collection" {
+		t.Errorf("Expected name 'Test Collection', got '%s'", parser.Name)
+	}
+	if parser.BaseURL != "https://api.example.com" {
+		t.Errorf("Expected base URL 'https://api.example.com', got '%s'", parser.BaseURL)
+	}
+
+	endpoints := parser.GetEndpoints()
+	if len(endpoints) != 3 {
+		t.Fatalf("Expected 3 endpoints, got %d", len(endpoints))
+	}
+
+	getUser := endpoints[0]
+	if getUser.Method != "GET" || getUser.Path != "/users/{id}" {
+		t.Errorf("Expected GET /users/{id}, got %s %s", getUser.Method, getUser.Path)
+	}
+	if getUser.URL != "https://api.example.com/users/:id?fields=name" {
+		t.Errorf("Unexpected resolved URL '%s'", getUser.URL)
+	}
+	if len(getUser.Folders) != 1 || getUser.Folders[0] != "Users" {
+		t.Errorf("Expected folder 'Users', got %v", getUser.Folders)
+	}
+	if getUser.AuthType != "bearer" {
+		t.Errorf("Expected inherited bearer auth, got '%s'", getUser.AuthType)
+	}
+
+	foundPath, foundQuery := false, false
+	for _, param := range getUser.Parameters {
+		if param.Name == "id" && param.In == "path" && param.Example == "42" {
+			foundPath = true
+		}
+		if param.Name == "fields" && param.In == "query" {
+			foundQuery = true
+		}
+	}
+	if !foundPath || !foundQuery {
+		t.Errorf("Expected path parameter 'id' and query parameter 'fields', got %+v", getUser.Parameters)
+	}
+
+	createUser := endpoints[1]
+	if createUser.BodyMode != "raw" {
+		t.Errorf("Expected raw body mode, got '%s'", createUser.BodyMode)
+	}
+	bodyTypes := make(map[string]string)
+	for _, param := range createUser.Parameters {
+		if param.In == "body" {
+			bodyTypes[param.Name] = param.Type
+		}
+	}
+	if bodyTypes["name"] != "string" || bodyTypes["age"] != "integer" {
+		t.Errorf("Unexpected body parameters %v", bodyTypes)
+	}
+
+	if endpoints[2].AuthType != "" {
+		t.Errorf("Expected noauth to disable authentication, got '%s'", endpoints[2].AuthType)
+	}
+}
+
+func TestPostmanParser_InvalidCollection(t *testing.T) {
+	parser := NewPostmanParser()
+	if err := parser.ParseJSON([]byte(`{"info": {"name": "x"}}`)); err == nil {
+		t.Error("Expected error for collection without items")
+	}
+	if err := parser.ParseJSON([]byte(`not json`)); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestAPIEndpointDiscovery_DiscoverFromPostman(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "postman-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "collection.json")
+	if err := ioutil.WriteFile(path, []byte(testPostmanCollection), 0644); err != nil {
+		t.Fatalf("Failed to write collection: %v", err)
+	}
+
+	discovery := NewAPIEndpointDiscovery("")
+	if err := discovery.DiscoverFromPostman(path); err != nil {
+		t.Fatalf("DiscoverFromPostman() error = %v", err)
+	}
+
+	if discovery.BaseURL != "https://api.example.com" {
+		t.Errorf("Expected base URL from collection, got '%s'", discovery.BaseURL)
+	}
+	if len(discovery.GetAuthRequiredEndpoints()) != 2 {
+		t.Errorf("Expected 2 authenticated endpoints, got %d", len(discovery.GetAuthRequiredEndpoints()))
+	}
+
+	extractor := NewAPIParameterExtractor(discovery)
+	if err := extractor.ExtractParameters(); err != nil {
+		t.Fatalf("ExtractParameters() error = %v", err)
+	}
+	if extractor.GetParameterByName("name") == nil {
+		t.Error("Expected body parameter 'name' to be extracted")
+	}
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// EndpointRisk is the risk score of an endpoint, used to test the riskiest endpoints first
+type EndpointRisk struct {
+	// Endpoint is the scored endpoint
+	Endpoint *DiscoveredEndpoint
+	// Score is the sum of the weights of the risk factors of the endpoint, the higher the
+	// riskier
+	Score int
+	// Factors describe the risk factors of the endpoint, e.g. "requires authentication"
+	Factors []string
+}
+
+// documentedSources are the sources of endpoints declared in a specification. Endpoints of
+// other sources, such as crawlers and captures, may be undocumented.
+var documentedSources = map[string]bool{
+	"OpenAPI":  true,
+	"Postman":  true,
+	"GraphQL":  true,
+	"WSDL":     true,
+	"AsyncAPI": true,
+}
+
+// methodRisks are the weights of the methods changing the state of the API
+var methodRisks = map[string]int{
+	"DELETE": 4,
+	"PUT":    3,
+	"PATCH":  3,
+	"POST":   3,
+}
+
+// sensitiveNames are the weights of the words of paths and parameter names of sensitive
+// endpoints: administration and debugging, credentials and authorization, personal and
+// payment data, and server-side handling of files, URLs and queries
+var sensitiveNames = map[string]int{
+	"admin": 3, "administrator": 3, "internal": 3, "debug": 3, "sudo": 3, "superuser": 3,
+	"impersonate": 3, "config": 3, "configuration": 3, "settings": 3, "secret": 3,
+	"private": 3, "backup": 3, "export": 3, "import": 3, "upload": 3, "exec": 3, "execute": 3,
+	"command": 3, "cmd": 3, "shell": 3, "eval": 3,
+	"password": 2, "passwd": 2, "token": 2, "apikey": 2, "key": 2, "auth": 2, "login": 2,
+	"session": 2, "role": 2, "permission": 2, "privilege": 2, "scope": 2, "credential": 2,
+	"payment": 2, "card": 2, "billing": 2, "invoice": 2, "account": 2, "bank": 2, "iban": 2,
+	"transfer": 2, "wallet": 2, "ssn": 2, "email": 2, "phone": 2, "address": 2,
+	"user": 1, "owner": 1, "tenant": 1, "org": 1, "file": 2, "path": 2, "url": 2,
+	"redirect": 2, "callback": 2, "webhook": 2, "query": 1, "filter": 1, "sql": 2,
+	"template": 2,
+}
+
+// maxSensitiveScore caps the weight of the sensitive names of an endpoint
+const maxSensitiveScore = 8
+
+// identifierParam matches the names of parameters identifying objects
+var identifierParam = regexp.MustCompile(`^(?i:id|uuid|guid)$|_(?i:id|uuid)$|[a-z](Id|ID|Uuid)$`)
+
+// ScoreEndpoint scores the risk of an endpoint from its authentication, method, sensitive
+// names, object identifiers and source
+func ScoreEndpoint(endpoint *DiscoveredEndpoint) *EndpointRisk {
+	risk := &EndpointRisk{Endpoint: endpoint, Factors: make([]string, 0)}
+	add := func(weight int, factor string) {
+		risk.Score += weight
+		risk.Factors = append(risk.Factors, factor)
+	}
+
+	if endpoint.RequiresAuth {
+		add(3, "requires authentication")
+	}
+	method := strings.ToUpper(endpoint.Method)
+	if weight, ok := methodRisks[method]; ok {
+		add(weight, method+" method")
+	}
+
+	// Sensitive words of the path and parameter names, counted once each
+	words := nameWords(endpoint.Path)
+	identifiers := make([]string, 0)
+	for _, param := range endpoint.Parameters {
+		words = append(words, nameWords(param.Name)...)
+		if param.In == "path" && identifierParam.MatchString(param.Name) {
+			identifiers = append(identifiers, param.Name)
+		}
+	}
+	seen := make(map[string]bool)
+	sensitive := make([]string, 0)
+	score := 0
+	for _, word := range words {
+		weight, ok := sensitiveNames[word]
+		if !ok {
+			// Plural words, e.g. users
+			word = strings.TrimSuffix(word, "s")
+			weight, ok = sensitiveNames[word]
+		}
+		if !ok || seen[word] {
+			continue
+		}
+		seen[word] = true
+		sensitive = append(sensitive, word)
+		score += weight
+	}
+	if score > maxSensitiveScore {
+		score = maxSensitiveScore
+	}
+	if len(sensitive) > 0 {
+		add(score, "sensitive names: "+strings.Join(sensitive, ", "))
+	}
+	if len(identifiers) > 0 {
+		add(2, "object identifiers in the path: "+strings.Join(identifiers, ", "))
+	}
+
+	if endpoint.Source != "" && !documentedSources[endpoint.Source] {
+		add(3, fmt.Sprintf("not in a specification (%s)", endpoint.Source))
+	}
+	return risk
+}
+
+// nameWords splits a path or a parameter name into lowercase words, e.g.
+// /api/userAccounts/{user_id} into api, user, accounts, user and id
+func nameWords(name string) []string {
+	words := make([]string, 0)
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// PrioritizeEndpoints scores endpoints and returns their risks, the riskiest first. Endpoints of
+// the same score keep their order.
+func PrioritizeEndpoints(endpoints []*DiscoveredEndpoint) []*EndpointRisk {
+	risks := make([]*EndpointRisk, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		risks = append(risks, ScoreEndpoint(endpoint))
+	}
+	sort.SliceStable(risks, func(i, j int) bool {
+		return risks[i].Score > risks[j].Score
+	})
+	return risks
+}
+
+// Prioritize orders the discovered endpoints by risk, the riskiest first, and returns their
+// risks
+func (d *APIEndpointDiscovery) Prioritize() []*EndpointRisk {
+	risks := PrioritizeEndpoints(d.Endpoints)
+	for i, risk := range risks {
+		d.Endpoints[i] = risk.Endpoint
+	}
+	return risks
+}
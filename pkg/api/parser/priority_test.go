@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNameWords(t *testing.T) {
+	words := nameWords("/api/userAccounts/{user_id}/resetPassword")
+	expected := []string{"api", "user", "accounts", "user", "id", "reset", "password"}
+	if !reflect.DeepEqual(words, expected) {
+		t.Errorf("Expected %v, got %v", expected, words)
+	}
+}
+
+func TestScoreEndpoint(t *testing.T) {
+	endpoint := &DiscoveredEndpoint{
+		Method:       "DELETE",
+		Path:         "/admin/users/{userId}",
+		RequiresAuth: true,
+		Source:       "OpenAPI",
+		Parameters: []*DiscoveredParameter{
+			{Name: "userId", In: "path"},
+			{Name: "reason", In: "query"},
+		},
+	}
+	risk := ScoreEndpoint(endpoint)
+	// Authentication 3, DELETE 4, admin 3 and user 1, object identifier 2
+	if risk.Score != 13 {
+		t.Errorf("Expected a score of 13, got %d: %v", risk.Score, risk.Factors)
+	}
+	factors := strings.Join(risk.Factors, "; ")
+	for _, factor := range []string{"requires authentication", "DELETE method", "sensitive names: admin, user", "object identifiers in the path: userId"} {
+		if !strings.Contains(factors, factor) {
+			t.Errorf("Expected the factor %q, got %q", factor, factors)
+		}
+	}
+
+	// Words are matched whole, and identifiers by their suffix
+	if risk := ScoreEndpoint(&DiscoveredEndpoint{Method: "GET", Path: "/monkeys/{paid}", Source: "OpenAPI",
+		Parameters: []*DiscoveredParameter{{Name: "paid", In: "path"}}}); risk.Score != 0 {
+		t.Errorf("Expected a score of 0, got %d: %v", risk.Score, risk.Factors)
+	}
+
+	// Endpoints found outside of specifications may be undocumented
+	if risk := ScoreEndpoint(&DiscoveredEndpoint{Method: "GET", Path: "/status", Source: "Crawler"}); risk.Score != 3 || risk.Factors[0] != "not in a specification (Crawler)" {
+		t.Errorf("Expected the endpoint of the crawler to be scored as undocumented, got %d: %v", risk.Score, risk.Factors)
+	}
+
+	// The weight of sensitive names is capped
+	risk = ScoreEndpoint(&DiscoveredEndpoint{Method: "GET", Path: "/internal/debug/config/secret/backup", Source: "OpenAPI"})
+	if risk.Score != maxSensitiveScore {
+		t.Errorf("Expected a score of %d, got %d", maxSensitiveScore, risk.Score)
+	}
+}
+
+func TestPrioritize(t *testing.T) {
+	discovery := NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.Endpoints = []*DiscoveredEndpoint{
+		{Method: "GET", Path: "/health", Source: "OpenAPI"},
+		{Method: "GET", Path: "/products", Source: "OpenAPI"},
+		{Method: "POST", Path: "/orders", Source: "OpenAPI"},
+		{Method: "GET", Path: "/debug/vars", Source: "Crawler"},
+		{Method: "DELETE", Path: "/accounts/{id}", RequiresAuth: true, Source: "OpenAPI",
+			Parameters: []*DiscoveredParameter{{Name: "id", In: "path"}}},
+	}
+	risks := discovery.Prioritize()
+	order := make([]string, 0, len(risks))
+	for _, endpoint := range discovery.GetEndpoints() {
+		order = append(order, endpoint.Method+" "+endpoint.Path)
+	}
+	expected := []string{"DELETE /accounts/{id}", "GET /debug/vars", "POST /orders", "GET /health", "GET /products"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("Expected %v, got %v", expected, order)
+	}
+	if risks[0].Endpoint != discovery.Endpoints[0] || risks[0].Score <= risks[1].Score {
+		t.Errorf("Expected the risks in the order of the endpoints, got %+v", risks)
+	}
+}
+
+func TestGenerateTestCases_Prioritize(t *testing.T) {
+	discovery := NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.Endpoints = []*DiscoveredEndpoint{
+		{Method: "GET", URL: "https://api.example.com/products", Path: "/products", Source: "OpenAPI"},
+		{Method: "DELETE", URL: "https://api.example.com/admin/users", Path: "/admin/users", Source: "OpenAPI", RequiresAuth: true},
+	}
+	generator := NewAPITestGenerator(discovery, nil)
+	if err := generator.GenerateTestCases(); err != nil {
+		t.Fatalf("GenerateTestCases returned an error: %s", err)
+	}
+	cases := generator.GetTestCases()
+	if len(cases) == 0 || cases[0].Path != "/admin/users" {
+		t.Fatalf("Expected the test cases of the riskiest endpoint first, got %d test cases", len(cases))
+	}
+}
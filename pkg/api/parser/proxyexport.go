@@ -0,0 +1,344 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+const (
+	// ProxyExportBurp is the format of the XML exports of Burp Suite items
+	ProxyExportBurp = "Burp"
+	// ProxyExportZAP is the format of OWASP ZAP message exports, as JSON from the API or as text
+	ProxyExportZAP = "ZAP"
+)
+
+// ProxyMessage is a request and its response exported by an intercepting proxy
+type ProxyMessage struct {
+	// Request has an absolute URL
+	Request     *http.Request
+	RequestBody []byte
+	// Response is nil if the request had no response
+	Response     *http.Response
+	ResponseBody []byte
+	Time         time.Time
+}
+
+// burpItems is the XML export of Burp Suite items, from the proxy history or the site map
+type burpItems struct {
+	Items []struct {
+		Time     string   `xml:"time"`
+		URL      string   `xml:"url"`
+		Method   string   `xml:"method"`
+		Request  burpData `xml:"request"`
+		Response burpData `xml:"response"`
+	} `xml:"item"`
+}
+
+type burpData struct {
+	Base64 bool   `xml:"base64,attr"`
+	Data   string `xml:",chardata"`
+}
+
+// zapMessages is the JSON of the messages of the OWASP ZAP API, e.g. /JSON/core/view/messages/
+type zapMessages struct {
+	Messages []struct {
+		RequestHeader  string `json:"requestHeader"`
+		RequestBody    string `json:"requestBody"`
+		ResponseHeader string `json:"responseHeader"`
+		ResponseBody   string `json:"responseBody"`
+		Timestamp      string `json:"timestamp"`
+	} `json:"messages"`
+}
+
+var (
+	// zapMessageSeparator matches the lines separating the messages of ZAP text exports
+	zapMessageSeparator = regexp.MustCompile(`(?m)^==== \d+ ==========\r?$`)
+	// http2StartLine matches the HTTP/2 protocol versions written by proxies in request and
+	// status lines, which net/http does not parse
+	http2StartLine = regexp.MustCompile(`(^[^\r\n]* HTTP/2|^HTTP/2)(\s)`)
+)
+
+// LoadProxyExport loads the messages of a Burp Suite XML export or an OWASP ZAP message
+// export, and returns their format
+func LoadProxyExport(filePath string) ([]*ProxyMessage, string, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, "", api.NewAPIError(fmt.Sprintf("Failed to read proxy export: %s", err.Error()), 0)
+	}
+	return ParseProxyExport(data)
+}
+
+// ParseProxyExport parses the messages of a Burp Suite XML export or an OWASP ZAP message
+// export, and returns their format
+func ParseProxyExport(data []byte) ([]*ProxyMessage, string, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		messages, err := ParseBurpXML(data)
+		return messages, ProxyExportBurp, err
+	case bytes.HasPrefix(trimmed, []byte("{")), zapMessageSeparator.Match(data):
+		messages, err := ParseZAPMessages(data)
+		return messages, ProxyExportZAP, err
+	}
+	return nil, "", api.NewAPIError("Unknown proxy export format, expected a Burp Suite XML export or an OWASP ZAP message export", 0)
+}
+
+// ParseBurpXML parses the items of a Burp Suite XML export, saved from the proxy history or
+// the site map with the raw requests and responses, base64 encoded or not
+func ParseBurpXML(data []byte) ([]*ProxyMessage, error) {
+	var items burpItems
+	if err := xml.Unmarshal(data, &items); err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to parse Burp Suite export: %s", err.Error()), 0)
+	}
+
+	messages := make([]*ProxyMessage, 0, len(items.Items))
+	for i, item := range items.Items {
+		rawRequest, err := item.Request.bytes()
+		if err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid request of Burp Suite item %d: %s", i+1, err.Error()), 0)
+		}
+		message, err := parseRawRequest(rawRequest, item.URL)
+		if err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid request of Burp Suite item %d: %s", i+1, err.Error()), 0)
+		}
+		if rawResponse, err := item.Response.bytes(); err == nil && len(bytes.TrimSpace(rawResponse)) > 0 {
+			if err := message.parseRawResponse(rawResponse); err != nil {
+				return nil, api.NewAPIError(fmt.Sprintf("Invalid response of Burp Suite item %d: %s", i+1, err.Error()), 0)
+			}
+		}
+		// Burp Suite writes times like Mon Jan 02 15:04:05 UTC 2006
+		if t, err := time.Parse("Mon Jan 02 15:04:05 MST 2006", strings.TrimSpace(item.Time)); err == nil {
+			message.Time = t
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// bytes returns the decoded data of a request or response of a Burp Suite item
+func (d burpData) bytes() ([]byte, error) {
+	if d.Base64 {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(d.Data))
+	}
+	return []byte(d.Data), nil
+}
+
+// ParseZAPMessages parses the messages of OWASP ZAP, either the JSON of the messages of its
+// API or the text written by Export Messages to File
+func ParseZAPMessages(data []byte) ([]*ProxyMessage, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return parseZAPText(data)
+	}
+
+	var export zapMessages
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to parse ZAP messages: %s", err.Error()), 0)
+	}
+	if export.Messages == nil {
+		return nil, api.NewAPIError("Invalid ZAP messages: missing messages", 0)
+	}
+	messages := make([]*ProxyMessage, 0, len(export.Messages))
+	for i, msg := range export.Messages {
+		message, err := parseRawRequest([]byte(msg.RequestHeader), "")
+		if err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid request of ZAP message %d: %s", i+1, err.Error()), 0)
+		}
+		message.setRequestBody([]byte(msg.RequestBody))
+		if strings.TrimSpace(msg.ResponseHeader) != "" {
+			if err := message.parseRawResponse([]byte(msg.ResponseHeader)); err != nil {
+				return nil, api.NewAPIError(fmt.Sprintf("Invalid response of ZAP message %d: %s", i+1, err.Error()), 0)
+			}
+			message.setResponseBody([]byte(msg.ResponseBody))
+		}
+		// ZAP timestamps are in milliseconds
+		if ms, err := strconv.ParseInt(msg.Timestamp, 10, 64); err == nil {
+			message.Time = time.Unix(0, ms*int64(time.Millisecond))
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// parseZAPText parses the messages of a ZAP text export. Each message is the raw request,
+// whose body length is given by its Content-Length header, followed by the raw response.
+func parseZAPText(data []byte) ([]*ProxyMessage, error) {
+	blocks := zapMessageSeparator.Split(string(data), -1)
+	messages := make([]*ProxyMessage, 0, len(blocks))
+	for i, block := range blocks[1:] {
+		block = strings.TrimLeft(block, "\r\n")
+		head, rest := splitRawHTTP([]byte(block))
+		message, err := parseRawRequest(head, "")
+		if err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid request of ZAP message %d: %s", i+1, err.Error()), 0)
+		}
+		// The body of the parsed head is empty, the length is read from the header
+		if length, _ := strconv.Atoi(message.Request.Header.Get("Content-Length")); length > 0 && length <= len(rest) {
+			message.setRequestBody(rest[:length])
+			rest = rest[length:]
+		}
+		rest = bytes.TrimLeft(rest, "\r\n")
+		rest = bytes.TrimSuffix(bytes.TrimSuffix(rest, []byte("\n")), []byte("\r"))
+		if len(rest) > 0 {
+			if err := message.parseRawResponse(rest); err != nil {
+				return nil, api.NewAPIError(fmt.Sprintf("Invalid response of ZAP message %d: %s", i+1, err.Error()), 0)
+			}
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// parseRawRequest parses a raw request. Relative request URIs are resolved against rawURL,
+// or against the Host header if rawURL is empty.
+func parseRawRequest(raw []byte, rawURL string) (*ProxyMessage, error) {
+	head, body := splitRawHTTP(raw)
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(head)))
+	if err != nil {
+		return nil, err
+	}
+	if rawURL != "" {
+		if req.URL, err = url.Parse(rawURL); err != nil {
+			return nil, err
+		}
+	}
+	if req.URL.Host == "" {
+		// Requests without an absolute URL are assumed to be sent over HTTPS
+		req.URL.Scheme, req.URL.Host = "https", req.Host
+	}
+	if req.URL.Host == "" {
+		return nil, fmt.Errorf("no host for %s", req.URL)
+	}
+	req.RequestURI = ""
+	message := &ProxyMessage{Request: req, Time: time.Now()}
+	message.setRequestBody(decodeChunked(req.TransferEncoding, body))
+	return message, nil
+}
+
+// parseRawResponse parses the raw response of the request of the message
+func (m *ProxyMessage) parseRawResponse(raw []byte) error {
+	head, body := splitRawHTTP(raw)
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(head)), m.Request)
+	if err != nil {
+		return err
+	}
+	m.Response = resp
+	m.setResponseBody(decodeChunked(resp.TransferEncoding, body))
+	return nil
+}
+
+// setRequestBody sets the body of the request, which can be read from the request too
+func (m *ProxyMessage) setRequestBody(body []byte) {
+	m.RequestBody = body
+	m.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	m.Request.ContentLength = int64(len(body))
+}
+
+// setResponseBody sets the body of the response, which can be read from the response too
+func (m *ProxyMessage) setResponseBody(body []byte) {
+	m.ResponseBody = body
+	m.Response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	m.Response.ContentLength = int64(len(body))
+}
+
+// splitRawHTTP splits a raw request or response into its head, terminated by an empty line
+// that net/http can parse, and its body
+func splitRawHTTP(raw []byte) ([]byte, []byte) {
+	head, body := raw, []byte{}
+	if i := bytes.Index(raw, []byte("\r\n\r\n")); i >= 0 {
+		head, body = raw[:i], raw[i+4:]
+	} else if i := bytes.Index(raw, []byte("\n\n")); i >= 0 {
+		head, body = raw[:i], raw[i+2:]
+	}
+	head = http2StartLine.ReplaceAll(bytes.TrimRight(head, "\r\n"), []byte("${1}.0$2"))
+	return append(head, []byte("\r\n\r\n")...), body
+}
+
+// decodeChunked decodes a body with a chunked transfer encoding. It returns the body unchanged
+// if it is not chunked or cannot be decoded.
+func decodeChunked(transferEncoding []string, body []byte) []byte {
+	if len(transferEncoding) == 0 || transferEncoding[0] != "chunked" {
+		return body
+	}
+	decoded, err := ioutil.ReadAll(httputil.NewChunkedReader(bytes.NewReader(body)))
+	if err != nil {
+		return body
+	}
+	return decoded
+}
+
+// ParseProxyMessages parses messages exported by an intercepting proxy, like the entries of a
+// HAR capture
+func (p *HARParser) ParseProxyMessages(messages []*ProxyMessage) error {
+	entries := make([]map[string]interface{}, 0, len(messages))
+	for _, message := range messages {
+		entries = append(entries, message.harEntry())
+	}
+	data, err := json.Marshal(map[string]interface{}{"log": map[string]interface{}{"entries": entries}})
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to convert proxy messages: %s", err.Error()), 0)
+	}
+	return p.ParseJSON(data)
+}
+
+// harEntry converts the message to a HAR entry
+func (m *ProxyMessage) harEntry() map[string]interface{} {
+	nameValues := func(header http.Header) []map[string]string {
+		values := make([]map[string]string, 0, len(header))
+		for name, hv := range header {
+			for _, value := range hv {
+				values = append(values, map[string]string{"name": name, "value": value})
+			}
+		}
+		return values
+	}
+	cookies := make([]map[string]string, 0)
+	for _, cookie := range m.Request.Cookies() {
+		cookies = append(cookies, map[string]string{"name": cookie.Name, "value": cookie.Value})
+	}
+	query := make([]map[string]string, 0)
+	for name, values := range m.Request.URL.Query() {
+		for _, value := range values {
+			query = append(query, map[string]string{"name": name, "value": value})
+		}
+	}
+	request := map[string]interface{}{
+		"method":      m.Request.Method,
+		"url":         m.Request.URL.String(),
+		"headers":     nameValues(m.Request.Header),
+		"cookies":     cookies,
+		"queryString": query,
+	}
+	if len(m.RequestBody) > 0 {
+		request["postData"] = map[string]string{"mimeType": m.Request.Header.Get("Content-Type"), "text": string(m.RequestBody)}
+	}
+
+	response := map[string]interface{}{"status": 0, "content": map[string]string{}}
+	if m.Response != nil {
+		content := map[string]string{"mimeType": m.Response.Header.Get("Content-Type")}
+		// Compressed and binary bodies are kept out of the inferred schemas
+		if utf8.Valid(m.ResponseBody) {
+			content["text"] = string(m.ResponseBody)
+		} else {
+			content["text"] = base64.StdEncoding.EncodeToString(m.ResponseBody)
+			content["encoding"] = "base64"
+		}
+		response = map[string]interface{}{"status": m.Response.StatusCode, "content": content}
+	}
+	return map[string]interface{}{"request": request, "response": response}
+}
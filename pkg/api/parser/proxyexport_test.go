@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testBurpRequest = "POST /api/users?notify=true HTTP/2\r\nHost: api.example.com\r\nContent-Type: application/json\r\nAuthorization: Bearer abc\r\nContent-Length: 17\r\n\r\n{\"name\": \"alice\"}"
+
+const testBurpResponse = "HTTP/2 201 Created\r\nContent-Type: application/json\r\n\r\n{\"id\": 42}"
+
+// testBurpExport returns a Burp Suite export of a request encoded in base64 and a plain one
+func testBurpExport() string {
+	return `<?xml version="1.0"?>
+<items burpVersion="2023.10" exportTime="Mon Jan 08 10:00:00 UTC 2024">
+  <item>
+    <time>Mon Jan 08 09:59:00 UTC 2024</time>
+    <url><![CDATA[https://api.example.com/api/users?notify=true]]></url>
+    <method><![CDATA[POST]]></method>
+    <request base64="true"><![CDATA[` + base64.StdEncoding.EncodeToString([]byte(testBurpRequest)) + `]]></request>
+    <status>201</status>
+    <response base64="true"><![CDATA[` + base64.StdEncoding.EncodeToString([]byte(testBurpResponse)) + `]]></response>
+  </item>
+  <item>
+    <time>Mon Jan 08 09:59:30 UTC 2024</time>
+    <url><![CDATA[https://api.example.com/api/users/42]]></url>
+    <method><![CDATA[GET]]></method>
+    <request base64="false"><![CDATA[GET /api/users/42 HTTP/1.1
+Host: api.example.com
+Authorization: Bearer abc
+
+]]></request>
+    <response base64="false"></response>
+  </item>
+</items>`
+}
+
+const testZAPJSON = `{"messages": [{
+	"requestHeader": "PUT https://api.example.com/api/users/42 HTTP/1.1\r\nHost: api.example.com\r\nContent-Type: application/json\r\nContent-Length: 15\r\n\r\n",
+	"requestBody": "{\"admin\": true}",
+	"responseHeader": "HTTP/1.1 403 Forbidden\r\nContent-Type: application/json\r\n\r\n",
+	"responseBody": "{\"error\": \"forbidden\"}",
+	"timestamp": "1704708000000"
+}]}`
+
+const testZAPText = "==== 1 ==========\r\n" +
+	"POST https://api.example.com/api/login HTTP/1.1\r\nHost: api.example.com\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 24\r\n\r\n" +
+	"user=alice&password=test" +
+	"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n{\"token\": \"abc\"}\r\n" +
+	"==== 2 ==========\r\n" +
+	"GET https://api.example.com/api/users/42 HTTP/1.1\r\nHost: api.example.com\r\n\r\n" +
+	"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n{\"id\": 42}\r\n"
+
+func TestParseBurpXML(t *testing.T) {
+	messages, format, err := ParseProxyExport([]byte(testBurpExport()))
+	if err != nil {
+		t.Fatalf("ParseProxyExport returned an error: %s", err)
+	}
+	if format != ProxyExportBurp || len(messages) != 2 {
+		t.Fatalf("Expected 2 Burp Suite messages, got %d %s messages", len(messages), format)
+	}
+
+	post := messages[0]
+	if post.Request.Method != "POST" || post.Request.URL.String() != "https://api.example.com/api/users?notify=true" {
+		t.Errorf("Unexpected request %s %s", post.Request.Method, post.Request.URL)
+	}
+	if string(post.RequestBody) != `{"name": "alice"}` {
+		t.Errorf("Unexpected request body %q", post.RequestBody)
+	}
+	if post.Response == nil || post.Response.StatusCode != 201 || string(post.ResponseBody) != `{"id": 42}` {
+		t.Errorf("Expected the HTTP/2 response to be parsed")
+	}
+	if post.Time.IsZero() {
+		t.Errorf("Expected the time of the item to be parsed")
+	}
+	if get := messages[1]; get.Request.Header.Get("Authorization") != "Bearer abc" || get.Response != nil {
+		t.Errorf("Expected the plain request to be parsed without a response")
+	}
+}
+
+func TestParseZAPMessages(t *testing.T) {
+	messages, format, err := ParseProxyExport([]byte(testZAPJSON))
+	if err != nil {
+		t.Fatalf("ParseProxyExport returned an error: %s", err)
+	}
+	if format != ProxyExportZAP || len(messages) != 1 {
+		t.Fatalf("Expected 1 ZAP message, got %d %s messages", len(messages), format)
+	}
+	if messages[0].Request.Method != "PUT" || string(messages[0].RequestBody) != `{"admin": true}` {
+		t.Errorf("Unexpected request %s with body %q", messages[0].Request.Method, messages[0].RequestBody)
+	}
+	if messages[0].Response.StatusCode != 403 || messages[0].Time.Unix() != 1704708000 {
+		t.Errorf("Expected the response and the timestamp to be parsed")
+	}
+
+	messages, format, err = ParseProxyExport([]byte(testZAPText))
+	if err != nil {
+		t.Fatalf("ParseProxyExport returned an error: %s", err)
+	}
+	if format != ProxyExportZAP || len(messages) != 2 {
+		t.Fatalf("Expected 2 ZAP messages, got %d %s messages", len(messages), format)
+	}
+	if string(messages[0].RequestBody) != "user=alice&password=test" {
+		t.Errorf("Unexpected request body %q", messages[0].RequestBody)
+	}
+	if messages[0].Response == nil || string(messages[0].ResponseBody) != `{"token": "abc"}` {
+		t.Errorf("Unexpected response body %q", messages[0].ResponseBody)
+	}
+
+	if _, _, err := ParseProxyExport([]byte("GET / HTTP/1.1")); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+func TestDiscoverFromProxyExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxyexport")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	exportPath := filepath.Join(dir, "items.xml")
+	if err := ioutil.WriteFile(exportPath, []byte(testBurpExport()), 0644); err != nil {
+		t.Fatalf("Failed to write export: %s", err)
+	}
+
+	discovery := NewAPIEndpointDiscovery("")
+	if err := discovery.DiscoverFromProxyExport(exportPath); err != nil {
+		t.Fatalf("DiscoverFromProxyExport returned an error: %s", err)
+	}
+	if discovery.BaseURL != "https://api.example.com" {
+		t.Errorf("Expected the base URL of the export, got %s", discovery.BaseURL)
+	}
+	if len(discovery.Endpoints) != 2 {
+		t.Fatalf("Expected 2 endpoints, got %d", len(discovery.Endpoints))
+	}
+	for _, endpoint := range discovery.Endpoints {
+		if endpoint.Source != ProxyExportBurp || !endpoint.RequiresAuth {
+			t.Errorf("Unexpected endpoint %s %s from %s", endpoint.Method, endpoint.Path, endpoint.Source)
+		}
+		if endpoint.Method == "POST" {
+			names := make(map[string]bool)
+			for _, param := range endpoint.Parameters {
+				names[param.Name] = true
+			}
+			if !names["notify"] || !names["name"] {
+				t.Errorf("Expected the query and body parameters to be seeded, got %v", names)
+			}
+		}
+	}
+}
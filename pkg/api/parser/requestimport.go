@@ -0,0 +1,909 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+const (
+	// RequestFormatCurl is the format of files of curl commands
+	RequestFormatCurl = "curl"
+	// RequestFormatHTTP is the format of the .http files of the JetBrains HTTP Client and the
+	// VS Code REST Client
+	RequestFormatHTTP = "http"
+	// RequestFormatRaw is the format of raw HTTP requests, such as the requests saved by the
+	// "Copy to file" of Burp Suite
+	RequestFormatRaw = "raw"
+)
+
+// ImportedRequest is a request imported from a curl command, a .http file or a raw HTTP
+// request, with the parameters a fuzzing keyword can be inserted into
+type ImportedRequest struct {
+	// Name is the name of the request in a .http file, or its method and URL
+	Name    string
+	Request ffuf.Request
+	// Parameters are the fuzzable path, query, header, cookie and body parameters
+	Parameters []*DiscoveredParameter
+}
+
+var (
+	// httpFileVariable matches the file variable definitions of .http files
+	httpFileVariable = regexp.MustCompile(`^@([A-Za-z_][\w.-]*)\s*=\s*(.*)$`)
+	// httpRequestName matches the # @name comments naming the requests of .http files
+	httpRequestName = regexp.MustCompile(`^(?:#|//)\s*@name\s*=?\s*(\S+)`)
+	// httpMethods lists the methods a request line of a .http file can start with
+	httpMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "TRACE", "CONNECT"}
+	// curlShortValueOptions lists the single letter curl options taking a value
+	curlShortValueOptions = "XHdbuAeFoxmwcETUrKyYzCQ"
+	// curlIgnoredValueOptions lists the long curl options taking a value that do not change
+	// the request
+	curlIgnoredValueOptions = []string{"--output", "--proxy", "--proxy-user", "--max-time", "--connect-timeout", "--write-out",
+		"--cacert", "--capath", "--cert", "--cert-type", "--key", "--key-type", "--pass", "--resolve", "--connect-to", "--cookie-jar",
+		"--upload-file", "--range", "--retry", "--retry-delay", "--retry-max-time", "--limit-rate", "--max-redirs", "--config",
+		"--interface", "--dns-servers", "--proxy-header", "--unix-socket", "--abstract-unix-socket", "--ciphers", "--tls-max",
+		"--speed-limit", "--speed-time", "--time-cond", "--trace", "--trace-ascii", "--stderr", "--dump-header", "--quote",
+		"--continue-at", "--expect100-timeout", "--keepalive-time", "--local-port", "--max-filesize", "--noproxy", "--preproxy",
+		"--socks4", "--socks4a", "--socks5", "--socks5-hostname", "--variable", "--output-dir", "--etag-save", "--etag-compare"}
+	// curlMultipartBoundary is the boundary of the multipart bodies of curl -F forms
+	curlMultipartBoundary = "------------------------ffufimport"
+)
+
+// DetectRequestFormat returns the format of a file of curl commands, a .http file or a raw
+// HTTP request, or an empty string if the file is none of them
+func DetectRequestFormat(filePath string, data []byte) string {
+	first := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		first = strings.Fields(line)
+		break
+	}
+	if len(first) > 0 && isCurlCommand(first[0]) {
+		return RequestFormatCurl
+	}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".http", ".rest":
+		return RequestFormatHTTP
+	}
+	if len(first) == 3 && strings.HasPrefix(first[2], "HTTP/") {
+		return RequestFormatRaw
+	}
+	return ""
+}
+
+// isCurlCommand reports whether a shell word runs curl
+func isCurlCommand(word string) bool {
+	base := strings.ToLower(filepath.Base(strings.ReplaceAll(word, "\\", "/")))
+	return base == "curl" || base == "curl.exe"
+}
+
+// ParseCurlCommands parses the curl commands of a shell snippet, such as the commands copied
+// from the developer tools of a browser. Commands other than curl are ignored.
+func ParseCurlCommands(data []byte) ([]*ImportedRequest, error) {
+	commands, err := splitShellCommands(string(data))
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]*ImportedRequest, 0, len(commands))
+	for _, args := range commands {
+		if len(args) == 0 || !isCurlCommand(args[0]) {
+			continue
+		}
+		request, err := parseCurlArgs(args[1:])
+		if err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid curl command %d: %s", len(requests)+1, err.Error()), 0)
+		}
+		requests = append(requests, request)
+	}
+	if len(requests) == 0 {
+		return nil, api.NewAPIError("No curl command found", 0)
+	}
+	return requests, nil
+}
+
+// splitShellCommands splits a shell snippet into the words of its commands. Single, double
+// and $” quotes, backslash escapes and line continuations are handled like bash does.
+func splitShellCommands(s string) ([][]string, error) {
+	commands := make([][]string, 0)
+	words := make([]string, 0)
+	var word strings.Builder
+	inWord := false
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+			words = make([]string, 0)
+		}
+	}
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\' || (c == '^' && i+1 < len(runes) && (runes[i+1] == '\n' || runes[i+1] == '\r')):
+			// Line continuations of bash and cmd.exe, or an escaped character
+			if i+1 < len(runes) && runes[i+1] == '\r' {
+				i++
+			}
+			if i+1 < len(runes) && runes[i+1] == '\n' {
+				i++
+				continue
+			}
+			if i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+		case c == ' ' || c == '\t' || c == '\r':
+			endWord()
+		case c == '\n' || c == ';' || c == '|' || c == '&':
+			endCommand()
+			if (c == '|' || c == '&') && i+1 < len(runes) && runes[i+1] == c {
+				i++
+			}
+		case c == '#' && !inWord:
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+		case c == '\'':
+			end := indexRune(runes, '\'', i+1)
+			if end < 0 {
+				return nil, api.NewAPIError("Unterminated single quote", 0)
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			inWord = true
+			i = end
+		case c == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			value, end, err := ansiCQuoted(runes, i+2)
+			if err != nil {
+				return nil, err
+			}
+			word.WriteString(value)
+			inWord = true
+			i = end
+		case c == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, api.NewAPIError("Unterminated double quote", 0)
+			}
+			inWord = true
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	endCommand()
+	return commands, nil
+}
+
+// indexRune returns the index of the first occurrence of a rune from a position, or -1
+func indexRune(runes []rune, r rune, from int) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// ansiCQuoted decodes the content of a $” quoted string starting at a position, and returns
+// the index of its closing quote
+func ansiCQuoted(runes []rune, from int) (string, int, error) {
+	var b strings.Builder
+	for i := from; i < len(runes); i++ {
+		c := runes[i]
+		if c == '\'' {
+			return b.String(), i, nil
+		}
+		if c != '\\' || i+1 >= len(runes) {
+			b.WriteRune(c)
+			continue
+		}
+		i++
+		switch runes[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'x', 'u', 'U':
+			digits := map[rune]int{'x': 2, 'u': 4, 'U': 8}[runes[i]]
+			end := i + 1
+			for end < len(runes) && end <= i+digits && strings.ContainsRune("0123456789abcdefABCDEF", runes[end]) {
+				end++
+			}
+			code, err := strconv.ParseUint(string(runes[i+1:end]), 16, 32)
+			if err != nil {
+				return "", 0, api.NewAPIError("Invalid escape sequence in $'' string", 0)
+			}
+			if runes[i] == 'x' {
+				b.WriteByte(byte(code))
+			} else {
+				b.WriteRune(rune(code))
+			}
+			i = end - 1
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+	return "", 0, api.NewAPIError("Unterminated $'' quote", 0)
+}
+
+// parseCurlArgs converts the arguments of a curl command to a request
+func parseCurlArgs(args []string) (*ImportedRequest, error) {
+	var method, rawURL string
+	var data, form []string
+	var cookies []string
+	headers := make(map[string]string)
+	get, isJSON := false, false
+	setHeader := func(name, value string) {
+		for existing := range headers {
+			if strings.EqualFold(existing, name) {
+				delete(headers, existing)
+			}
+		}
+		headers[name] = value
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		option, value, hasValue := arg, "", false
+		if strings.HasPrefix(arg, "--") {
+			if eq := strings.Index(arg, "="); eq > 0 {
+				option, value, hasValue = arg[:eq], arg[eq+1:], true
+			}
+		} else if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			// Short options can be combined, the last one taking the rest of the word or
+			// the next argument as value
+			option = ""
+			for j, letter := range arg[1:] {
+				if strings.ContainsRune(curlShortValueOptions, letter) {
+					option = "-" + string(letter)
+					if rest := arg[j+2:]; rest != "" {
+						value, hasValue = rest, true
+					}
+					break
+				}
+				switch letter {
+				case 'G':
+					get = true
+				case 'I':
+					method = "HEAD"
+				}
+			}
+			if option == "" {
+				continue
+			}
+		} else {
+			if rawURL == "" {
+				rawURL = arg
+			}
+			continue
+		}
+		if !hasValue && curlTakesValue(option) {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value of %s", option)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch option {
+		case "-X", "--request":
+			method = value
+		case "-H", "--header":
+			parts := strings.SplitN(value, ":", 2)
+			if len(parts) == 2 {
+				setHeader(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+			} else if name := strings.TrimSuffix(value, ";"); name != value {
+				setHeader(strings.TrimSpace(name), "")
+			}
+		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii":
+			data = append(data, value)
+		case "--data-urlencode":
+			data = append(data, curlURLEncode(value))
+		case "--json":
+			data = append(data, value)
+			isJSON = true
+		case "-F", "--form", "--form-string":
+			form = append(form, value)
+		case "-u", "--user":
+			setHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(value)))
+		case "--oauth2-bearer":
+			setHeader("Authorization", "Bearer "+value)
+		case "-b", "--cookie":
+			// Without =, the value is a cookie file
+			if strings.Contains(value, "=") {
+				cookies = append(cookies, value)
+			}
+		case "-A", "--user-agent":
+			setHeader("User-Agent", value)
+		case "-e", "--referer":
+			setHeader("Referer", value)
+		case "--url":
+			rawURL = value
+		case "--get":
+			get = true
+		case "--head":
+			method = "HEAD"
+		}
+	}
+	if rawURL == "" {
+		return nil, fmt.Errorf("missing URL")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	if len(cookies) > 0 {
+		if existing := headerValue(headers, "Cookie"); existing != "" {
+			cookies = append([]string{existing}, cookies...)
+		}
+		setHeader("Cookie", strings.Join(cookies, "; "))
+	}
+
+	req := ffuf.Request{Headers: headers}
+	switch {
+	case get && len(data) > 0:
+		separator := "?"
+		if strings.Contains(rawURL, "?") {
+			separator = "&"
+		}
+		rawURL += separator + strings.Join(data, "&")
+	case len(form) > 0:
+		body, contentType := curlMultipartBody(form)
+		req.Data = body
+		setHeader("Content-Type", contentType)
+		if method == "" {
+			method = "POST"
+		}
+	case len(data) > 0:
+		req.Data = []byte(strings.Join(data, "&"))
+		if headerValue(headers, "Content-Type") == "" {
+			if isJSON {
+				setHeader("Content-Type", "application/json")
+			} else {
+				setHeader("Content-Type", "application/x-www-form-urlencoded")
+			}
+		}
+		if isJSON && headerValue(headers, "Accept") == "" {
+			setHeader("Accept", "application/json")
+		}
+		if method == "" {
+			method = "POST"
+		}
+	}
+	if method == "" {
+		method = "GET"
+	}
+	req.Method = method
+	req.Url = rawURL
+	return newImportedRequest("", req)
+}
+
+// curlTakesValue reports whether a curl option takes a value
+func curlTakesValue(option string) bool {
+	switch option {
+	case "--request", "--header", "--data", "--data-raw", "--data-binary", "--data-ascii", "--data-urlencode", "--json",
+		"--form", "--form-string", "--user", "--oauth2-bearer", "--cookie", "--user-agent", "--referer", "--url":
+		return true
+	}
+	return len(option) == 2 || contains(curlIgnoredValueOptions, option)
+}
+
+// curlURLEncode encodes the value of a --data-urlencode option
+func curlURLEncode(value string) string {
+	if strings.HasPrefix(value, "=") {
+		return url.QueryEscape(value[1:])
+	}
+	if parts := strings.SplitN(value, "=", 2); len(parts) == 2 {
+		return parts[0] + "=" + url.QueryEscape(parts[1])
+	}
+	return url.QueryEscape(value)
+}
+
+// curlMultipartBody returns the multipart body of the -F fields of a curl command and its
+// content type. Files are sent with an empty content.
+func curlMultipartBody(fields []string) ([]byte, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.SetBoundary(curlMultipartBoundary)
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		value := ""
+		if len(parts) == 2 {
+			value = parts[1]
+		}
+		if strings.HasPrefix(value, "@") {
+			file := strings.SplitN(value[1:], ";", 2)[0]
+			writer.CreateFormFile(parts[0], filepath.Base(file))
+			continue
+		}
+		writer.WriteField(parts[0], strings.TrimPrefix(value, "<"))
+	}
+	writer.Close()
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+// ParseHTTPFile parses the requests of a .http file of the JetBrains HTTP Client or the VS Code
+// REST Client. Requests are separated by ### lines, {{variable}} placeholders are replaced
+// with the @variable definitions of the file, and relative URLs are resolved against the Host
+// header with the protocol proto.
+func ParseHTTPFile(data []byte, proto string) ([]*ImportedRequest, error) {
+	if proto == "" {
+		proto = "https"
+	}
+	variables := make(map[string]string)
+	expand := func(s string) string {
+		return postmanVariablePattern.ReplaceAllStringFunc(s, func(match string) string {
+			name := postmanVariablePattern.FindStringSubmatch(match)[1]
+			if value, ok := variables[name]; ok {
+				return value
+			}
+			return match
+		})
+	}
+
+	requests := make([]*ImportedRequest, 0)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for start := 0; start < len(lines); {
+		name := ""
+		if strings.HasPrefix(lines[start], "###") {
+			name = strings.TrimSpace(strings.TrimLeft(lines[start], "#"))
+			start++
+		}
+		end := start
+		for end < len(lines) && !strings.HasPrefix(lines[end], "###") {
+			end++
+		}
+		block := lines[start:end]
+		start = end
+
+		// Comments and variable definitions precede the request line
+		i := 0
+		for ; i < len(block); i++ {
+			line := strings.TrimSpace(block[i])
+			if m := httpRequestName.FindStringSubmatch(line); m != nil {
+				name = m[1]
+				continue
+			}
+			if m := httpFileVariable.FindStringSubmatch(line); m != nil {
+				variables[m[1]] = expand(strings.TrimSpace(m[2]))
+				continue
+			}
+			if line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "//") {
+				break
+			}
+		}
+		if i == len(block) {
+			continue
+		}
+
+		req := ffuf.Request{Method: "GET", Headers: make(map[string]string)}
+		fields := strings.Fields(expand(block[i]))
+		if contains(httpMethods, fields[0]) {
+			req.Method = fields[0]
+			fields = fields[1:]
+		}
+		if len(fields) > 1 && strings.HasPrefix(fields[len(fields)-1], "HTTP/") {
+			fields = fields[:len(fields)-1]
+		}
+		if len(fields) == 0 {
+			return nil, api.NewAPIError(fmt.Sprintf("Missing URL of .http request %d", len(requests)+1), 0)
+		}
+		rawURL := strings.Join(fields, "")
+		// Indented ? and & lines continue the query of the URL
+		for i++; i < len(block); i++ {
+			line := strings.TrimSpace(block[i])
+			if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+				break
+			}
+			rawURL += expand(line)
+		}
+		for ; i < len(block) && strings.TrimSpace(block[i]) != ""; i++ {
+			line := strings.TrimSpace(block[i])
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+				continue
+			}
+			if parts := strings.SplitN(expand(line), ":", 2); len(parts) == 2 {
+				req.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			}
+		}
+
+		// The body ends with the response handler and redirection lines, if any
+		body := make([]string, 0)
+		for i++; i < len(block); i++ {
+			if strings.HasPrefix(block[i], "> ") || strings.HasPrefix(block[i], ">>") || strings.HasPrefix(block[i], "<> ") {
+				break
+			}
+			body = append(body, block[i])
+		}
+		req.Data = []byte(strings.TrimRight(expand(strings.Join(body, "\n")), "\n"))
+
+		if strings.HasPrefix(rawURL, "/") {
+			host := headerValue(req.Headers, "Host")
+			if host == "" {
+				return nil, api.NewAPIError(fmt.Sprintf("Missing Host header of the relative URL of .http request %d", len(requests)+1), 0)
+			}
+			rawURL = proto + "://" + host + rawURL
+		}
+		req.Url = rawURL
+		request, err := newImportedRequest(name, req)
+		if err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid .http request %d: %s", len(requests)+1, err.Error()), 0)
+		}
+		requests = append(requests, request)
+	}
+	if len(requests) == 0 {
+		return nil, api.NewAPIError("No request found in .http file", 0)
+	}
+	return requests, nil
+}
+
+// ParseRawRequest parses a raw HTTP request, such as a request saved by the "Copy to file" of
+// Burp Suite. Fuzzing keywords can be anywhere in the request, including its method and
+// target. A relative target is resolved against the Host header with the protocol proto, and
+// a chunked body is decoded, the request being sent with a Content-Length.
+func ParseRawRequest(data []byte, proto string) ([]*ImportedRequest, error) {
+	if proto == "" {
+		proto = "https"
+	}
+	raw := strings.TrimLeft(string(data), "\r\n")
+	head, body := raw, ""
+	if i := strings.Index(raw, "\r\n\r\n"); i >= 0 {
+		head, body = raw[:i], raw[i+4:]
+	} else if i := strings.Index(raw, "\n\n"); i >= 0 {
+		head, body = raw[:i], raw[i+2:]
+	}
+	lines := strings.Split(strings.ReplaceAll(head, "\r\n", "\n"), "\n")
+	fields := strings.Fields(lines[0])
+	if len(fields) != 3 {
+		return nil, api.NewAPIError(fmt.Sprintf("Malformed request line: %s", lines[0]), 0)
+	}
+
+	req := ffuf.Request{Method: fields[0], Headers: make(map[string]string)}
+	chunked := false
+	for _, line := range lines[1:] {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch strings.ToLower(name) {
+		case "content-length":
+			continue
+		case "transfer-encoding":
+			codings := strings.Split(value, ",")
+			if strings.EqualFold(strings.TrimSpace(codings[len(codings)-1]), "chunked") {
+				chunked = true
+				continue
+			}
+		}
+		req.Headers[name] = value
+	}
+
+	// Remove the newline added at the end of the file by editors
+	if strings.HasSuffix(body, "\r\n") {
+		body = body[:len(body)-2]
+	} else if strings.HasSuffix(body, "\n") {
+		body = body[:len(body)-1]
+	}
+	req.Data = []byte(body)
+	if chunked {
+		// Line endings converted to LF by an editor are restored for the chunk sizes to match
+		if !strings.Contains(body, "\r\n") {
+			body = strings.ReplaceAll(body, "\n", "\r\n")
+		}
+		decoded, err := ioutil.ReadAll(httputil.NewChunkedReader(strings.NewReader(body)))
+		if err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid chunked body of the raw request: %s", err.Error()), 0)
+		}
+		req.Data = decoded
+	}
+
+	host := headerValue(req.Headers, "Host")
+	if strings.HasPrefix(fields[1], "/") {
+		if host == "" {
+			return nil, api.NewAPIError("Missing Host header of the relative target of the raw request", 0)
+		}
+		req.Url = proto + "://" + host + fields[1]
+	} else {
+		req.Url = fields[1]
+	}
+	if u, err := url.Parse(req.Url); err == nil && strings.EqualFold(u.Host, host) {
+		// The Host header is set from the URL
+		for name := range req.Headers {
+			if strings.EqualFold(name, "Host") {
+				delete(req.Headers, name)
+			}
+		}
+	}
+	request, err := newImportedRequest("", req)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Invalid raw request: %s", err.Error()), 0)
+	}
+	return []*ImportedRequest{request}, nil
+}
+
+// newImportedRequest identifies the fuzzable parameters of a request
+func newImportedRequest(name string, req ffuf.Request) (*ImportedRequest, error) {
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing host in URL %s", req.Url)
+	}
+	req.Host = u.Host
+	if name == "" {
+		name = req.Method + " " + req.Url
+	}
+	request := &ImportedRequest{Name: name, Request: req, Parameters: make([]*DiscoveredParameter, 0)}
+	add := func(param, in, value string) {
+		request.Parameters = append(request.Parameters, &DiscoveredParameter{Name: param, In: in, Type: inferStringType(value), Example: value})
+	}
+
+	normalized, pathParams := normalizeHARPath(u.EscapedPath())
+	for _, segment := range strings.Split(normalized, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			param := strings.Trim(segment, "{}")
+			request.Parameters = append(request.Parameters, &DiscoveredParameter{Name: param, In: "path", Required: true, Type: inferStringType(pathParams[param]), Example: pathParams[param]})
+		}
+	}
+	for _, pair := range splitPairs(u.RawQuery, "&") {
+		add(pair[0], "query", pair[1])
+	}
+	headers := make([]string, 0, len(req.Headers))
+	for header := range req.Headers {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		if lower := strings.ToLower(header); !contains(harSkippedHeaders, lower) && lower != "content-type" {
+			add(header, "header", req.Headers[header])
+		}
+	}
+	for _, pair := range splitPairs(headerValue(req.Headers, "Cookie"), ";") {
+		add(pair[0], "cookie", pair[1])
+	}
+
+	contentType := strings.ToLower(headerValue(req.Headers, "Content-Type"))
+	switch {
+	case strings.Contains(contentType, "json"):
+		var body interface{}
+		if json.Unmarshal(req.Data, &body) == nil {
+			request.Parameters = append(request.Parameters, jsonLeafParameters("", body)...)
+		}
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		for _, pair := range splitPairs(string(req.Data), "&") {
+			add(pair[0], "body", pair[1])
+		}
+	}
+	return request, nil
+}
+
+// jsonLeafParameters returns the leaf values of a JSON document as body parameters named by
+// their dot path, e.g. user.roles.0
+func jsonLeafParameters(prefix string, value interface{}) []*DiscoveredParameter {
+	params := make([]*DiscoveredParameter, 0)
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			params = append(params, jsonLeafParameters(join(key), v[key])...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			params = append(params, jsonLeafParameters(join(strconv.Itoa(i)), item)...)
+		}
+	default:
+		if prefix != "" {
+			params = append(params, &DiscoveredParameter{Name: prefix, In: "body", Type: jsonValueType(value), Example: value})
+		}
+	}
+	return params
+}
+
+// headerValue returns the value of a header of a request, matching its name case-insensitively
+func headerValue(headers map[string]string, name string) string {
+	for existing, value := range headers {
+		if strings.EqualFold(existing, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// Fuzz returns a copy of the request with the values of parameters replaced with keywords.
+// points maps the names of parameters to their keyword, every parameter with the name being
+// replaced.
+func (r *ImportedRequest) Fuzz(points map[string]string) (ffuf.Request, error) {
+	req := r.Request
+	req.Headers = make(map[string]string, len(r.Request.Headers))
+	for name, value := range r.Request.Headers {
+		req.Headers[name] = value
+	}
+	req.Data = append([]byte(nil), r.Request.Data...)
+
+	names := make([]string, 0, len(points))
+	for name := range points {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		keyword := points[name]
+		found := false
+		for _, param := range r.Parameters {
+			if param.Name != name {
+				continue
+			}
+			found = true
+			if err := fuzzParameter(&req, param, keyword); err != nil {
+				return req, err
+			}
+		}
+		if !found {
+			return req, api.NewAPIError(fmt.Sprintf("Unknown parameter %s, the parameters of the request are: %s", name, r.ParameterList()), 0)
+		}
+	}
+	return req, nil
+}
+
+// ParameterList describes the fuzzable parameters of the request, e.g. id (query), name (body)
+func (r *ImportedRequest) ParameterList() string {
+	if len(r.Parameters) == 0 {
+		return "none"
+	}
+	list := make([]string, 0, len(r.Parameters))
+	for _, param := range r.Parameters {
+		list = append(list, fmt.Sprintf("%s (%s)", param.Name, param.In))
+	}
+	return strings.Join(list, ", ")
+}
+
+// fuzzParameter replaces the value of a parameter of a request with a keyword
+func fuzzParameter(req *ffuf.Request, param *DiscoveredParameter, keyword string) error {
+	switch param.In {
+	case "path", "query":
+		prefix, rest := req.Url, ""
+		if scheme := strings.Index(prefix, "://"); scheme >= 0 {
+			if slash := strings.IndexAny(prefix[scheme+3:], "/?#"); slash >= 0 {
+				prefix, rest = prefix[:scheme+3+slash], prefix[scheme+3+slash:]
+			}
+		}
+		path, query, fragment := rest, "", ""
+		if hash := strings.Index(path, "#"); hash >= 0 {
+			path, fragment = path[:hash], path[hash:]
+		}
+		if q := strings.Index(path, "?"); q >= 0 {
+			path, query = path[:q], path[q:]
+		}
+		if param.In == "path" {
+			normalized, _ := normalizeHARPath(path)
+			segments := strings.Split(path, "/")
+			for i, segment := range strings.Split(normalized, "/") {
+				if segment == "{"+param.Name+"}" && i < len(segments) {
+					segments[i] = keyword
+				}
+			}
+			path = strings.Join(segments, "/")
+		} else {
+			query = "?" + replacePairs(strings.TrimPrefix(query, "?"), "&", param.Name, keyword)
+		}
+		req.Url = prefix + path + query + fragment
+	case "header":
+		for name := range req.Headers {
+			if strings.EqualFold(name, param.Name) {
+				req.Headers[name] = keyword
+			}
+		}
+	case "cookie":
+		for name, value := range req.Headers {
+			if strings.EqualFold(name, "Cookie") {
+				req.Headers[name] = replacePairs(value, "; ", param.Name, keyword)
+			}
+		}
+	case "body":
+		contentType := strings.ToLower(headerValue(req.Headers, "Content-Type"))
+		if !strings.Contains(contentType, "json") {
+			req.Data = []byte(replacePairs(string(req.Data), "&", param.Name, keyword))
+			return nil
+		}
+		// Paths with array indices are given as JSONPath expressions
+		path := param.Name
+		if parts := strings.Split(path, "."); len(parts) > 1 {
+			var b strings.Builder
+			b.WriteString("$")
+			for _, part := range parts {
+				if _, err := strconv.Atoi(part); err == nil {
+					b.WriteString("[" + part + "]")
+				} else {
+					b.WriteString("." + part)
+				}
+			}
+			path = b.String()
+		}
+		data, err := payload.NewPayloadGenerator(payload.FormatJSON).GenerateJSON(string(req.Data), path)
+		if err != nil {
+			return err
+		}
+		if keyword != payload.FuzzMarker {
+			data = strings.ReplaceAll(data, `"`+payload.FuzzMarker+`"`, `"`+keyword+`"`)
+		}
+		req.Data = []byte(data)
+	}
+	return nil
+}
+
+// replacePairs replaces the values of the name=value pairs with a name
+func replacePairs(s, separator, name, value string) string {
+	parts := strings.Split(s, strings.TrimSpace(separator))
+	for i, part := range parts {
+		trimmed := strings.TrimSpace(part)
+		key := strings.SplitN(trimmed, "=", 2)[0]
+		if unescaped, err := url.QueryUnescape(key); err == nil && separator == "&" {
+			key = unescaped
+		}
+		if key == name {
+			parts[i] = part[:len(part)-len(trimmed)] + strings.SplitN(trimmed, "=", 2)[0] + "=" + value
+		}
+	}
+	return strings.Join(parts, strings.TrimSpace(separator))
+}
+
+// ParseImportedRequests parses the requests of a file of curl commands, a .http file or a raw
+// HTTP request, in the format returned by DetectRequestFormat
+func ParseImportedRequests(format string, data []byte, proto string) ([]*ImportedRequest, error) {
+	switch format {
+	case RequestFormatCurl:
+		return ParseCurlCommands(data)
+	case RequestFormatHTTP:
+		return ParseHTTPFile(data, proto)
+	case RequestFormatRaw:
+		return ParseRawRequest(data, proto)
+	}
+	return nil, api.NewAPIError("Unknown request format "+format, 0)
+}
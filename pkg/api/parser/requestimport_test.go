@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+const testCurlCommands = `# Copied from the browser
+curl 'https://api.example.com/api/users/42/orders?status=open&page=2' \
+  -H 'authorization: Bearer abc' \
+  -H 'content-type: application/json' \
+  -b 'session=xyz; theme=dark' \
+  --data-raw $'{"note":"it\'s","items":[{"sku":"A1","qty":2}]}' \
+  --compressed
+curl -sSX PUT https://api.example.com/api/profile -d name=alice -d "bio=hello world" -u admin:secret | jq .
+curl -G api.example.com/search --data-urlencode 'q=a b'
+`
+
+const testHTTPFile = `@host = api.example.com
+@token = abc
+
+### List users
+GET https://{{host}}/api/users
+    ?page=1
+    &limit=10
+Authorization: Bearer {{token}}
+
+###
+# @name createUser
+POST /api/users HTTP/1.1
+Host: {{host}}
+Content-Type: application/json
+
+{"name": "alice", "admin": false}
+
+> {% client.global.set("id", response.body.id); %}
+`
+
+// parameterNames returns the names and locations of the parameters of a request
+func parameterNames(request *ImportedRequest) map[string]string {
+	names := make(map[string]string)
+	for _, param := range request.Parameters {
+		names[param.Name] = param.In
+	}
+	return names
+}
+
+func TestDetectRequestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		path, data, expected string
+	}{
+		{"request.txt", testCurlCommands, RequestFormatCurl},
+		{"requests.http", testHTTPFile, RequestFormatHTTP},
+		{"requests.rest", "GET https://api.example.com/", RequestFormatHTTP},
+		{"request.txt", "GET / HTTP/1.1\nHost: api.example.com\n\n", RequestFormatRaw},
+		{"request.http", "GET / HTTP/1.1\nHost: api.example.com\n\n", RequestFormatHTTP},
+		{"wordlist.txt", "admin\nusers\n", ""},
+	} {
+		if format := DetectRequestFormat(tc.path, []byte(tc.data)); format != tc.expected {
+			t.Errorf("Expected format %q of %s, got %q", tc.expected, tc.path, format)
+		}
+	}
+}
+
+func TestParseRawRequest(t *testing.T) {
+	raw := "FUZZ /api/users/42?fields=USER HTTP/1.1\r\nHost: api.example.com\r\nX-Tenant: TENANT\r\nContent-Type: application/json\r\nTransfer-Encoding: chunked\r\nContent-Length: 99\r\n\r\n" +
+		"b\r\n{\"name\": \"F\r\na\r\nUZZ\", \"a\":\r\n2\r\n1}\r\n0\r\n\r\n"
+	requests, err := ParseRawRequest([]byte(raw), "http")
+	if err != nil {
+		t.Fatalf("ParseRawRequest returned an error: %s", err)
+	}
+	req := requests[0].Request
+	if req.Method != "FUZZ" || req.Url != "http://api.example.com/api/users/42?fields=USER" {
+		t.Errorf("Unexpected request line %s %s", req.Method, req.Url)
+	}
+	if string(req.Data) != `{"name": "FUZZ", "a":1}` {
+		t.Errorf("Expected the chunked body to be decoded, got %q", req.Data)
+	}
+	if len(req.Headers) != 2 || req.Headers["X-Tenant"] != "TENANT" {
+		t.Errorf("Expected the Host, Content-Length and Transfer-Encoding headers to be left out, got %v", req.Headers)
+	}
+	if names := parameterNames(requests[0]); names["userId"] != "path" || names["fields"] != "query" || names["a"] != "body" {
+		t.Errorf("Expected the parameters of the request, got %v", names)
+	}
+
+	// Absolute targets, a virtual host and line endings converted by an editor
+	raw = "POST https://10.0.0.1/login HTTP/2\nHost: FUZZ.example.com\nTransfer-Encoding: chunked\n\n4\nuser\n0\n\n"
+	if requests, err = ParseRawRequest([]byte(raw), ""); err != nil {
+		t.Fatalf("ParseRawRequest returned an error: %s", err)
+	}
+	req = requests[0].Request
+	if req.Url != "https://10.0.0.1/login" || req.Headers["Host"] != "FUZZ.example.com" || string(req.Data) != "user" {
+		t.Errorf("Unexpected request %s %v %q", req.Url, req.Headers, req.Data)
+	}
+
+	for _, invalid := range []string{"GET /users\n\n", "GET /users HTTP/1.1\n\n", "POST /users HTTP/1.1\nHost: a\nTransfer-Encoding: chunked\n\nzz\n"} {
+		if _, err := ParseRawRequest([]byte(invalid), "https"); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestParseCurlCommands(t *testing.T) {
+	requests, err := ParseCurlCommands([]byte(testCurlCommands))
+	if err != nil {
+		t.Fatalf("ParseCurlCommands returned an error: %s", err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(requests))
+	}
+
+	orders := requests[0]
+	if orders.Request.Method != "POST" || orders.Request.Host != "api.example.com" {
+		t.Errorf("Unexpected request %s to %s", orders.Request.Method, orders.Request.Host)
+	}
+	if string(orders.Request.Data) != `{"note":"it's","items":[{"sku":"A1","qty":2}]}` {
+		t.Errorf("Unexpected body %s", orders.Request.Data)
+	}
+	if orders.Request.Headers["Cookie"] != "session=xyz; theme=dark" {
+		t.Errorf("Expected the cookies in the Cookie header, got %v", orders.Request.Headers)
+	}
+	expected := map[string]string{"userId": "path", "status": "query", "page": "query", "authorization": "header",
+		"session": "cookie", "theme": "cookie", "note": "body", "items.0.sku": "body", "items.0.qty": "body"}
+	if names := parameterNames(orders); len(names) != len(expected) {
+		t.Errorf("Expected parameters %v, got %v", expected, names)
+	} else {
+		for name, in := range expected {
+			if names[name] != in {
+				t.Errorf("Expected %s parameter %s, got %v", in, name, names)
+			}
+		}
+	}
+
+	fuzzed, err := orders.Fuzz(map[string]string{"userId": "FUZZ", "items.0.sku": "W2", "session": "W3"})
+	if err != nil {
+		t.Fatalf("Fuzz returned an error: %s", err)
+	}
+	if fuzzed.Url != "https://api.example.com/api/users/FUZZ/orders?status=open&page=2" {
+		t.Errorf("Unexpected fuzzed URL %s", fuzzed.Url)
+	}
+	if !strings.Contains(string(fuzzed.Data), `"sku": "W2"`) || fuzzed.Headers["Cookie"] != "session=W3; theme=dark" {
+		t.Errorf("Unexpected fuzzed request %s %v", fuzzed.Data, fuzzed.Headers)
+	}
+	if orders.Request.Headers["Cookie"] != "session=xyz; theme=dark" {
+		t.Errorf("Expected the imported request to be left unchanged")
+	}
+	if _, err := orders.Fuzz(map[string]string{"missing": "FUZZ"}); err == nil || !strings.Contains(err.Error(), "userId (path)") {
+		t.Errorf("Expected an error listing the parameters, got %v", err)
+	}
+
+	profile := requests[1]
+	if profile.Request.Method != "PUT" || string(profile.Request.Data) != "name=alice&bio=hello world" {
+		t.Errorf("Unexpected request %s with body %s", profile.Request.Method, profile.Request.Data)
+	}
+	if profile.Request.Headers["Authorization"] != "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:secret")) {
+		t.Errorf("Expected basic authentication, got %v", profile.Request.Headers)
+	}
+	fuzzed, err = profile.Fuzz(map[string]string{"name": "FUZZ"})
+	if err != nil || string(fuzzed.Data) != "name=FUZZ&bio=hello world" {
+		t.Errorf("Unexpected fuzzed body %s, %v", fuzzed.Data, err)
+	}
+
+	search := requests[2]
+	if search.Request.Method != "GET" || search.Request.Url != "http://api.example.com/search?q=a+b" || len(search.Request.Data) != 0 {
+		t.Errorf("Expected -G to move the data to the query, got %s %s", search.Request.Method, search.Request.Url)
+	}
+
+	if _, err := ParseCurlCommands([]byte("curl -H 'unterminated")); err == nil {
+		t.Errorf("Expected an error for an unterminated quote")
+	}
+}
+
+func TestParseHTTPFile(t *testing.T) {
+	requests, err := ParseHTTPFile([]byte(testHTTPFile), "https")
+	if err != nil {
+		t.Fatalf("ParseHTTPFile returned an error: %s", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+
+	list := requests[0]
+	if list.Name != "List users" || list.Request.Url != "https://api.example.com/api/users?page=1&limit=10" {
+		t.Errorf("Unexpected request %s: %s", list.Name, list.Request.Url)
+	}
+	if list.Request.Headers["Authorization"] != "Bearer abc" {
+		t.Errorf("Expected the variables to be replaced, got %v", list.Request.Headers)
+	}
+
+	create := requests[1]
+	if create.Name != "createUser" || create.Request.Method != "POST" || create.Request.Url != "https://api.example.com/api/users" {
+		t.Errorf("Unexpected request %s: %s %s", create.Name, create.Request.Method, create.Request.Url)
+	}
+	if string(create.Request.Data) != `{"name": "alice", "admin": false}` {
+		t.Errorf("Expected the response handler to be left out of the body, got %q", create.Request.Data)
+	}
+	if names := parameterNames(create); names["name"] != "body" || names["admin"] != "body" {
+		t.Errorf("Expected the body parameters, got %v", names)
+	}
+}
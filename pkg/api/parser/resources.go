@@ -0,0 +1,279 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// ResourceEdgeNested is the type of the edges from a resource to the resources nested in it
+const ResourceEdgeNested = "nested"
+
+// ResourceNode is a resource of an API, such as /users or /users/{id}/orders
+type ResourceNode struct {
+	// Name is the last static segment of the path of the resource
+	Name string `json:"name"`
+	// Path is the path of the collection of the resource
+	Path string `json:"path"`
+	// Methods are the methods of the collection, such as GET and POST /users
+	Methods []string `json:"methods,omitempty"`
+	// ItemMethods are the methods of the items, such as GET and DELETE /users/{id}
+	ItemMethods []string `json:"item_methods,omitempty"`
+
+	key string
+}
+
+// ResourceEdge links two resources of an API
+type ResourceEdge struct {
+	// From is the path of the source resource
+	From string `json:"from"`
+	// To is the path of the target resource
+	To string `json:"to"`
+	// Type is ResourceEdgeNested, or the type of the correlation linking the resources
+	Type string `json:"type"`
+	// Confidence is the highest confidence of the correlations linking the resources
+	Confidence int `json:"confidence,omitempty"`
+}
+
+// ResourceGraph is the graph of the resources of an API
+type ResourceGraph struct {
+	Nodes []*ResourceNode `json:"nodes"`
+	Edges []*ResourceEdge `json:"edges"`
+
+	byKey map[string]*ResourceNode
+}
+
+// BuildResourceGraph builds the graph of the resources of discovered endpoints. Resources
+// are linked to the resources nested in their paths, and to the resources whose requests
+// send values returned by their responses, according to the correlations.
+func BuildResourceGraph(endpoints []*DiscoveredEndpoint, correlations []Correlation) *ResourceGraph {
+	graph := &ResourceGraph{
+		Nodes: make([]*ResourceNode, 0),
+		Edges: make([]*ResourceEdge, 0),
+		byKey: make(map[string]*ResourceNode),
+	}
+
+	for _, endpoint := range endpoints {
+		segments := resourceSegments(endpoint.Path)
+		last := lastStaticSegment(segments)
+		if last < 0 {
+			continue
+		}
+		key := resourceKey(segments[:last+1])
+		node, ok := graph.byKey[key]
+		if !ok {
+			node = &ResourceNode{Name: segments[last], Path: "/" + strings.Join(segments[:last+1], "/"), key: key}
+			graph.byKey[key] = node
+			graph.Nodes = append(graph.Nodes, node)
+		}
+		method := strings.ToUpper(endpoint.Method)
+		if last == len(segments)-1 {
+			if !contains(node.Methods, method) {
+				node.Methods = append(node.Methods, method)
+			}
+		} else if !contains(node.ItemMethods, method) {
+			node.ItemMethods = append(node.ItemMethods, method)
+		}
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Path < graph.Nodes[j].Path })
+
+	// A resource is nested in the closest resource of the prefixes of its path
+	for _, node := range graph.Nodes {
+		sort.Strings(node.Methods)
+		sort.Strings(node.ItemMethods)
+		segments := strings.Split(node.key, "/")
+		for i := len(segments) - 1; i > 0; i-- {
+			if parent, ok := graph.byKey[strings.Join(segments[:i], "/")]; ok {
+				graph.addEdge(parent, node, ResourceEdgeNested, 0)
+				break
+			}
+		}
+	}
+
+	for _, correlation := range correlations {
+		if correlation.SourceResponse == nil || correlation.SourceResponse.Request == nil || correlation.TargetRequest == nil {
+			continue
+		}
+		source := graph.resourceOf(correlation.SourceResponse.Request.Url)
+		target := graph.resourceOf(correlation.TargetRequest.Url)
+		if source != nil && target != nil && source != target {
+			graph.addEdge(source, target, string(correlation.Type), correlation.Confidence)
+		}
+	}
+	return graph
+}
+
+// addEdge adds an edge between two resources, or raises the confidence of an existing one
+func (g *ResourceGraph) addEdge(from, to *ResourceNode, edgeType string, confidence int) {
+	for _, edge := range g.Edges {
+		if edge.From == from.Path && edge.To == to.Path && edge.Type == edgeType {
+			if confidence > edge.Confidence {
+				edge.Confidence = confidence
+			}
+			return
+		}
+	}
+	g.Edges = append(g.Edges, &ResourceEdge{From: from.Path, To: to.Path, Type: edgeType, Confidence: confidence})
+}
+
+// resourceOf returns the resource of a requested URL, or nil if it is not in the graph
+func (g *ResourceGraph) resourceOf(rawURL string) *ResourceNode {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	segments := resourceSegments(u.Path)
+	// The URL may have a prefix the paths of the endpoints do not have, such as /api/v1
+	for start := 0; start < len(segments); start++ {
+		last := lastStaticSegment(segments[start:])
+		if last < 0 {
+			return nil
+		}
+		if node, ok := g.byKey[resourceKey(segments[start:start+last+1])]; ok {
+			return node
+		}
+	}
+	return nil
+}
+
+// resourceSegments returns the non-empty segments of a path
+func resourceSegments(path string) []string {
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// isResourceParameter reports whether a path segment is a parameter, such as {id}, :id or
+// the value of an identifier
+func isResourceParameter(segment string) bool {
+	return strings.HasPrefix(segment, "{") || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "<") ||
+		harIdentifierPattern.MatchString(segment)
+}
+
+// lastStaticSegment returns the index of the last segment that is not a parameter, or -1
+func lastStaticSegment(segments []string) int {
+	for i := len(segments) - 1; i >= 0; i-- {
+		if !isResourceParameter(segments[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// resourceKey returns the key of a resource path, in which parameters are replaced with *
+func resourceKey(segments []string) string {
+	key := make([]string, len(segments))
+	for i, segment := range segments {
+		if isResourceParameter(segment) {
+			key[i] = "*"
+		} else {
+			key[i] = strings.ToLower(segment)
+		}
+	}
+	return strings.Join(key, "/")
+}
+
+// VisualizeResourceGraph generates a graph of the resources of an API, for the VisTypeGraph
+// visualization type
+func (v *Visualizer) VisualizeResourceGraph(graph *ResourceGraph) (string, error) {
+	switch v.options.Format {
+	case VisFormatJSON:
+		return v.generateJSONVisualization(graph)
+	case VisFormatDOT:
+		return v.generateDOTResourceGraph(graph), nil
+	case VisFormatMermaid:
+		return v.generateMermaidResourceGraph(graph), nil
+	case VisFormatSVG:
+		return v.generateSVGResourceGraph(graph), nil
+	default:
+		return "", api.NewAPIError("Unsupported resource graph format", 0)
+	}
+}
+
+// generateDOTResourceGraph generates a DOT graph of resources
+func (v *Visualizer) generateDOTResourceGraph(graph *ResourceGraph) string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("digraph %s {\n", sanitizeID(v.options.Title)))
+	buf.WriteString("  rankdir=LR;\n  node [shape=box, style=\"rounded,filled\", fillcolor=lightblue];\n")
+	ids := resourceNodeIDs(graph)
+	for _, node := range graph.Nodes {
+		buf.WriteString(fmt.Sprintf("  %s [label=\"%s\"];\n", ids[node.Path], resourceDOTLabel(node)))
+	}
+	for _, edge := range graph.Edges {
+		if edge.Type == ResourceEdgeNested {
+			buf.WriteString(fmt.Sprintf("  %s -> %s;\n", ids[edge.From], ids[edge.To]))
+		} else {
+			buf.WriteString(fmt.Sprintf("  %s -> %s [label=\"%s %d%%\", style=dashed, color=%s];\n", ids[edge.From], ids[edge.To], sanitizeLabel(edge.Type), edge.Confidence, confidenceColor(edge.Confidence)))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// generateMermaidResourceGraph generates a Mermaid graph of resources
+func (v *Visualizer) generateMermaidResourceGraph(graph *ResourceGraph) string {
+	var buf bytes.Buffer
+	buf.WriteString("graph LR\n")
+	ids := resourceNodeIDs(graph)
+	for _, node := range graph.Nodes {
+		label := strings.ReplaceAll(strings.Join(resourceLabel(node), "<br/>"), "\"", "#quot;")
+		buf.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", ids[node.Path], label))
+	}
+	for i, edge := range graph.Edges {
+		if edge.Type == ResourceEdgeNested {
+			buf.WriteString(fmt.Sprintf("  %s --> %s\n", ids[edge.From], ids[edge.To]))
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("  %s -.->|%s %d%%| %s\n", ids[edge.From], edge.Type, edge.Confidence, ids[edge.To]))
+		buf.WriteString(fmt.Sprintf("  linkStyle %d stroke:%s\n", i, confidenceColor(edge.Confidence)))
+	}
+	return buf.String()
+}
+
+// resourceNodeIDs returns the node IDs of the resources by path
+func resourceNodeIDs(graph *ResourceGraph) map[string]string {
+	ids := make(map[string]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		ids[node.Path] = fmt.Sprintf("r%d", i)
+	}
+	return ids
+}
+
+// resourceDOTLabel returns the label of a resource in a DOT graph, with a line per entry
+func resourceDOTLabel(node *ResourceNode) string {
+	lines := resourceLabel(node)
+	for i, line := range lines {
+		lines[i] = sanitizeLabel(line)
+	}
+	return strings.Join(lines, "\\n")
+}
+
+// resourceLabel returns the lines of the label of a resource: its path and its methods
+func resourceLabel(node *ResourceNode) []string {
+	label := []string{node.Path}
+	if len(node.Methods) > 0 {
+		label = append(label, strings.Join(node.Methods, " "))
+	}
+	if len(node.ItemMethods) > 0 {
+		label = append(label, "item: "+strings.Join(node.ItemMethods, " "))
+	}
+	return label
+}
+
+// confidenceColor returns the color of a correlation edge, as in correlation visualizations
+func confidenceColor(confidence int) string {
+	if confidence >= 80 {
+		return "green"
+	} else if confidence >= 50 {
+		return "orange"
+	}
+	return "red"
+}
@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func newTestResourceGraph() *ResourceGraph {
+	endpoints := []*DiscoveredEndpoint{
+		{Method: "GET", Path: "/users"},
+		{Method: "POST", Path: "/users"},
+		{Method: "GET", Path: "/users/{id}"},
+		{Method: "DELETE", Path: "/users/{id}"},
+		{Method: "GET", Path: "/users/{userId}/orders"},
+		{Method: "GET", Path: "/orders/{id}"},
+		{Method: "POST", Path: "/users/:id/activate"},
+		{Method: "GET", Path: "/{tenant}"},
+	}
+	login := &ffuf.Request{Method: "POST", Url: "https://api.example.com/api/v1/users"}
+	correlations := []Correlation{
+		{Type: CorrelationTypeID, SourceResponse: &ffuf.Response{Request: login}, TargetRequest: &ffuf.Request{Url: "https://api.example.com/api/v1/users/42/orders"}, Confidence: 85},
+		{Type: CorrelationTypeID, SourceResponse: &ffuf.Response{Request: login}, TargetRequest: &ffuf.Request{Url: "https://api.example.com/api/v1/users/42/orders?page=2"}, Confidence: 90},
+		{Type: CorrelationTypeReference, SourceResponse: &ffuf.Response{Request: &ffuf.Request{Url: "https://api.example.com/users/42/orders"}}, TargetRequest: &ffuf.Request{Url: "https://api.example.com/orders/5f3e2a1b9c8d7e6f5a4b3c2d"}, Confidence: 40},
+		{Type: CorrelationTypeID, SourceResponse: &ffuf.Response{Request: login}, TargetRequest: &ffuf.Request{Url: "https://api.example.com/users/42"}, Confidence: 85},
+		{Type: CorrelationTypeToken, SourceResponse: &ffuf.Response{Request: login}, TargetRequest: &ffuf.Request{Url: "https://api.example.com/unknown"}, Confidence: 85},
+	}
+	return BuildResourceGraph(endpoints, correlations)
+}
+
+func TestBuildResourceGraph(t *testing.T) {
+	graph := newTestResourceGraph()
+
+	paths := make([]string, 0)
+	for _, node := range graph.Nodes {
+		paths = append(paths, node.Path)
+	}
+	if strings.Join(paths, ",") != "/orders,/users,/users/:id/activate,/users/{userId}/orders" {
+		t.Fatalf("Unexpected resources %v", paths)
+	}
+	users := graph.Nodes[1]
+	if strings.Join(users.Methods, ",") != "GET,POST" || strings.Join(users.ItemMethods, ",") != "DELETE,GET" {
+		t.Errorf("Unexpected methods of /users: %v and %v", users.Methods, users.ItemMethods)
+	}
+
+	edges := make([]string, 0)
+	for _, edge := range graph.Edges {
+		edges = append(edges, edge.From+" -"+edge.Type+"-> "+edge.To)
+	}
+	expected := []string{
+		"/users -nested-> /users/:id/activate",
+		"/users -nested-> /users/{userId}/orders",
+		"/users -id-> /users/{userId}/orders",
+		"/users/{userId}/orders -reference-> /orders",
+	}
+	if strings.Join(edges, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected edges:\n%s", strings.Join(edges, "\n"))
+	}
+	if graph.Edges[2].Confidence != 90 {
+		t.Errorf("Expected the highest confidence of the correlations, got %d", graph.Edges[2].Confidence)
+	}
+}
+
+func TestVisualizeResourceGraph(t *testing.T) {
+	graph := newTestResourceGraph()
+
+	diagram, err := NewVisualizer(&VisOptions{Format: VisFormatMermaid}).VisualizeResourceGraph(graph)
+	if err != nil {
+		t.Fatalf("VisualizeResourceGraph returned an error: %s", err)
+	}
+	for _, e := range []string{
+		"graph LR\n",
+		`  r1["/users<br/>GET POST<br/>item: DELETE GET"]`,
+		"  r1 --> r3\n",
+		"  r1 -.->|id 90%| r3\n  linkStyle 2 stroke:green\n",
+		"  r3 -.->|reference 40%| r0\n  linkStyle 3 stroke:red\n",
+	} {
+		if !strings.Contains(diagram, e) {
+			t.Errorf("Expected %q in the Mermaid graph:\n%s", e, diagram)
+		}
+	}
+
+	diagram, err = NewVisualizer(&VisOptions{Format: VisFormatDOT, Title: "API resources"}).VisualizeResourceGraph(graph)
+	if err != nil {
+		t.Fatalf("VisualizeResourceGraph returned an error: %s", err)
+	}
+	for _, e := range []string{
+		"digraph API_resources {\n",
+		`  r1 [label="/users\nGET POST\nitem: DELETE GET"];`,
+		"  r1 -> r3;\n",
+		`  r1 -> r3 [label="id 90%", style=dashed, color=green];`,
+	} {
+		if !strings.Contains(diagram, e) {
+			t.Errorf("Expected %q in the DOT graph:\n%s", e, diagram)
+		}
+	}
+
+	output, err := NewVisualizer(&VisOptions{Format: VisFormatJSON}).VisualizeResourceGraph(graph)
+	var decoded ResourceGraph
+	if err != nil || json.Unmarshal([]byte(output), &decoded) != nil || len(decoded.Nodes) != 4 || len(decoded.Edges) != 4 {
+		t.Errorf("Expected the graph as JSON, got %s: %v", output, err)
+	}
+	if _, err := NewVisualizer(&VisOptions{Format: VisFormatHTML}).VisualizeResourceGraph(graph); err == nil {
+		t.Errorf("Expected an error for an unsupported format")
+	}
+}
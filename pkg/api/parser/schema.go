@@ -2,6 +2,7 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -694,12 +695,19 @@ func (d *SchemaDetector) fieldToJSONSchema(field *SchemaField) map[string]interf
 
 // DetectSchema detects the schema of a JSON response
 func (p *ResponseParser) DetectSchema(data []byte) (*Schema, error) {
-	if p.format != FormatJSON && p.format != FormatUnknown {
-		return nil, api.NewAPIError("Response is not in JSON format", 0)
-	}
-
 	detector := NewSchemaDetector()
-	return detector.DetectSchema(data)
+	switch p.format {
+	case FormatNDJSON:
+		return detector.DetectSchemaFromReader(bytes.NewReader(data))
+	case FormatXML:
+		return detector.DetectXMLSchema(data)
+	case FormatHTML:
+		return detector.DetectHTMLSchema(data)
+	case FormatJSON, FormatUnknown:
+		return detector.DetectSchema(data)
+	default:
+		return nil, api.NewAPIError("Response is not in JSON, XML or HTML format", 0)
+	}
 }
 
 // DetectSchemaFromSamples detects the schema from multiple JSON response samples
@@ -0,0 +1,685 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxGeneratedLength caps the length of generated strings, so that schemas with large
+// maxLength constraints do not produce huge requests
+const maxGeneratedLength = 4096
+
+// SchemaTestValue is a value generated from a schema for a test case
+type SchemaTestValue struct {
+	// Value is the generated value
+	Value interface{}
+	// Description explains which constraint the value tests
+	Description string
+	// Constraint is the kind of constraint the value tests
+	Constraint string
+}
+
+// Kinds of schema constraints tested by generated values
+const (
+	ConstraintRange    = "range"
+	ConstraintLength   = "length"
+	ConstraintItems    = "items"
+	ConstraintEnum     = "enum"
+	ConstraintFormat   = "format"
+	ConstraintPattern  = "pattern"
+	ConstraintType     = "type"
+	ConstraintRequired = "required"
+)
+
+// SchemaConstraints lists the kinds of schema constraints
+var SchemaConstraints = []string{ConstraintRange, ConstraintLength, ConstraintItems, ConstraintEnum, ConstraintFormat, ConstraintPattern, ConstraintType, ConstraintRequired}
+
+// formatExamples are valid values of the common string formats
+var formatExamples = map[string]string{
+	"email":         "user@example.com",
+	"idn-email":     "user@example.com",
+	"uuid":          "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"date":          "2024-01-15",
+	"date-time":     "2024-01-15T10:30:00Z",
+	"time":          "10:30:00",
+	"uri":           "https://example.com/resource",
+	"url":           "https://example.com/resource",
+	"uri-reference": "/resource",
+	"hostname":      "example.com",
+	"idn-hostname":  "example.com",
+	"ipv4":          "192.0.2.1",
+	"ipv6":          "2001:db8::1",
+	"byte":          "dGVzdA==",
+	"binary":        "test",
+	"password":      "P@ssw0rd123!",
+	"phone":         "+14155550100",
+}
+
+// formatViolations are invalid values of the common string formats
+var formatViolations = map[string]string{
+	"email":     "not-an-email",
+	"idn-email": "not-an-email",
+	"uuid":      "3fa85f64-5717-4562-b3fc",
+	"date":      "2024-13-45",
+	"date-time": "2024-01-15 25:61:00",
+	"time":      "25:61:00",
+	"uri":       "not a uri",
+	"url":       "not a url",
+	"hostname":  "-invalid-.host_name",
+	"ipv4":      "256.256.256.256",
+	"ipv6":      "2001:db8:::1:zz",
+	"byte":      "not base64!",
+}
+
+// formatMalformations are further invalid values of the common string formats, each
+// breaking a different part of the format
+var formatMalformations = map[string][]string{
+	"email":     {"user@", "@example.com", "user@@example.com", "user name@example.com"},
+	"idn-email": {"user@", "@example.com", "user@@example.com", "user name@example.com"},
+	"uuid":      {"zzzzzzzz-zzzz-zzzz-zzzz-zzzzzzzzzzzz", "3fa85f64-5717-4562-b3fc-2c963f66afa6-0", "3fa85f64571745"},
+	"date":      {"2024-02-30", "15/01/2024", "2024-1-5"},
+	"date-time": {"2024-01-15", "2024-01-15T10:30:00", "2024-02-30T10:30:00Z"},
+	"ipv4":      {"192.0.2", "192.0.2.1.5", "192.0.2.-1"},
+}
+
+// GenerateSchemaValue returns a valid value for a schema. The example, default and first
+// enum value of the schema are used if set, otherwise a value satisfying the type, format,
+// pattern, length, range and item constraints is synthesized.
+func GenerateSchemaValue(schema *OpenAPISchema) interface{} {
+	if schema == nil {
+		return "test"
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if schema.Default != nil {
+		return schema.Default
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+
+	switch schema.Type {
+	case "integer":
+		return int64(validNumber(schema, true))
+	case "number":
+		return validNumber(schema, false)
+	case "boolean":
+		return true
+	case "array":
+		count := 1
+		if schema.MinItems != nil && *schema.MinItems > count {
+			count = *schema.MinItems
+		}
+		if schema.MaxItems != nil && *schema.MaxItems < count {
+			count = *schema.MaxItems
+		}
+		return arrayOf(schema.Items, count)
+	case "object":
+		object := make(map[string]interface{}, len(schema.Properties))
+		for name, property := range schema.Properties {
+			object[name] = GenerateSchemaValue(property)
+		}
+		return object
+	}
+	return validString(schema)
+}
+
+// SchemaBoundaryValues returns valid values at the boundaries of the constraints of a
+// schema: the shortest and longest strings, the smallest and largest numbers, the
+// smallest and largest arrays, and every enum value
+func SchemaBoundaryValues(schema *OpenAPISchema) []SchemaTestValue {
+	if schema == nil {
+		return nil
+	}
+
+	values := make([]SchemaTestValue, 0)
+	if len(schema.Enum) > 0 {
+		for _, value := range schema.Enum {
+			values = append(values, SchemaTestValue{Value: value, Description: fmt.Sprintf("enum value %v", value), Constraint: ConstraintEnum})
+		}
+		return values
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		integer := schema.Type == "integer"
+		if min, ok := lowerBound(schema, integer); ok {
+			values = append(values, SchemaTestValue{Value: numberValue(min, integer), Description: "minimum value", Constraint: ConstraintRange})
+		}
+		if max, ok := upperBound(schema, integer); ok {
+			values = append(values, SchemaTestValue{Value: numberValue(max, integer), Description: "maximum value", Constraint: ConstraintRange})
+		}
+	case "string":
+		if schema.MinLength != nil && *schema.MinLength > 0 {
+			values = append(values, SchemaTestValue{Value: stringOfLength(schema, *schema.MinLength), Description: fmt.Sprintf("minimum length %d", *schema.MinLength), Constraint: ConstraintLength})
+		}
+		if schema.MaxLength != nil && *schema.MaxLength <= maxGeneratedLength {
+			values = append(values, SchemaTestValue{Value: stringOfLength(schema, *schema.MaxLength), Description: fmt.Sprintf("maximum length %d", *schema.MaxLength), Constraint: ConstraintLength})
+		}
+	case "array":
+		if schema.MinItems != nil {
+			values = append(values, SchemaTestValue{Value: arrayOf(schema.Items, *schema.MinItems), Description: fmt.Sprintf("minimum of %d items", *schema.MinItems), Constraint: ConstraintItems})
+		}
+		if schema.MaxItems != nil && *schema.MaxItems <= maxGeneratedLength {
+			values = append(values, SchemaTestValue{Value: arrayOf(schema.Items, *schema.MaxItems), Description: fmt.Sprintf("maximum of %d items", *schema.MaxItems), Constraint: ConstraintItems})
+		}
+	}
+	return values
+}
+
+// SchemaInvalidValues returns values violating the constraints of a schema one at a time:
+// values just outside the length, range and item bounds, values close to but not in the
+// enum, strings not matching the pattern or format, and integers overflowing their format
+func SchemaInvalidValues(schema *OpenAPISchema) []SchemaTestValue {
+	if schema == nil {
+		return nil
+	}
+
+	values := make([]SchemaTestValue, 0)
+	if len(schema.Enum) > 0 {
+		if value, ok := enumOffByOne(schema.Enum); ok {
+			values = append(values, SchemaTestValue{Value: value, Description: "value not in enum", Constraint: ConstraintEnum})
+		}
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		integer := schema.Type == "integer"
+		if schema.Minimum != nil {
+			value := *schema.Minimum - 1
+			if schema.ExclusiveMinimum {
+				value = *schema.Minimum
+			}
+			values = append(values, SchemaTestValue{Value: numberValue(value, integer), Description: "below minimum", Constraint: ConstraintRange})
+		}
+		if schema.Maximum != nil {
+			value := *schema.Maximum + 1
+			if schema.ExclusiveMaximum {
+				value = *schema.Maximum
+			}
+			values = append(values, SchemaTestValue{Value: numberValue(value, integer), Description: "above maximum", Constraint: ConstraintRange})
+		}
+		if schema.MultipleOf != nil && *schema.MultipleOf != 0 {
+			base := validNumber(schema, integer)
+			step := *schema.MultipleOf / 2
+			if integer && step < 1 {
+				step = 1
+			}
+			if math.Mod(base+step, *schema.MultipleOf) != 0 {
+				values = append(values, SchemaTestValue{Value: numberValue(base+step, integer), Description: fmt.Sprintf("not a multiple of %v", *schema.MultipleOf), Constraint: ConstraintRange})
+			}
+		}
+		if integer {
+			values = append(values, SchemaTestValue{Value: 1.5, Description: "not an integer", Constraint: ConstraintType})
+			switch schema.Format {
+			case "int32":
+				values = append(values, SchemaTestValue{Value: int64(math.MaxInt32) + 1, Description: "int32 overflow", Constraint: ConstraintRange})
+			case "int64":
+				values = append(values, SchemaTestValue{Value: json.Number("9223372036854775808"), Description: "int64 overflow", Constraint: ConstraintRange})
+			}
+		}
+	case "string":
+		if schema.MinLength != nil && *schema.MinLength > 0 {
+			values = append(values, SchemaTestValue{Value: strings.Repeat("a", *schema.MinLength-1), Description: fmt.Sprintf("shorter than minimum length %d", *schema.MinLength), Constraint: ConstraintLength})
+		}
+		if schema.MaxLength != nil && *schema.MaxLength < maxGeneratedLength {
+			values = append(values, SchemaTestValue{Value: stringOfLength(&OpenAPISchema{Type: "string"}, *schema.MaxLength+1), Description: fmt.Sprintf("longer than maximum length %d", *schema.MaxLength), Constraint: ConstraintLength})
+		}
+		if schema.Pattern != "" {
+			if value, ok := patternViolation(schema.Pattern); ok {
+				values = append(values, SchemaTestValue{Value: value, Description: fmt.Sprintf("not matching pattern %s", schema.Pattern), Constraint: ConstraintPattern})
+			}
+		}
+		if value, ok := formatViolations[schema.Format]; ok {
+			values = append(values, SchemaTestValue{Value: value, Description: fmt.Sprintf("invalid %s format", schema.Format), Constraint: ConstraintFormat})
+		}
+	case "array":
+		if schema.MinItems != nil && *schema.MinItems > 0 {
+			values = append(values, SchemaTestValue{Value: arrayOf(schema.Items, *schema.MinItems-1), Description: fmt.Sprintf("fewer than %d items", *schema.MinItems), Constraint: ConstraintItems})
+		}
+		if schema.MaxItems != nil && *schema.MaxItems < maxGeneratedLength {
+			values = append(values, SchemaTestValue{Value: arrayOf(schema.Items, *schema.MaxItems+1), Description: fmt.Sprintf("more than %d items", *schema.MaxItems), Constraint: ConstraintItems})
+		}
+	case "object":
+		for _, name := range schema.Required {
+			object := objectValue(schema)
+			if _, ok := object[name]; ok {
+				delete(object, name)
+				values = append(values, SchemaTestValue{Value: object, Description: fmt.Sprintf("missing required property %s", name), Constraint: ConstraintRequired})
+			}
+		}
+	}
+	return values
+}
+
+// SchemaBoundaryViolations returns the values of SchemaInvalidValues violating the
+// constraints of a schema, without the values of an invalid type, and further values at the
+// edges of the constraints: an empty array when items are required, more values outside an
+// enum, more malformed values of the common formats, and the values of object schemas whose
+// properties violate their own constraints. Only the values of the listed kinds of
+// constraints are returned, or all of them if none is listed.
+func SchemaBoundaryViolations(schema *OpenAPISchema, constraints ...string) []SchemaTestValue {
+	return schemaBoundaryViolations(schema, constraints, 0)
+}
+
+func schemaBoundaryViolations(schema *OpenAPISchema, constraints []string, depth int) []SchemaTestValue {
+	if schema == nil || depth > maxDictionaryDepth {
+		return nil
+	}
+
+	values := make([]SchemaTestValue, 0)
+	seen := make(map[string]bool)
+	add := func(value SchemaTestValue) {
+		if value.Constraint == ConstraintType || !hasConstraint(constraints, value.Constraint) {
+			return
+		}
+		key := value.Constraint + ":" + SchemaValueString(value.Value)
+		if !seen[key] {
+			seen[key] = true
+			values = append(values, value)
+		}
+	}
+
+	for _, value := range SchemaInvalidValues(schema) {
+		add(value)
+	}
+	for _, value := range enumViolations(schema.Enum) {
+		add(SchemaTestValue{Value: value, Description: "value not in enum", Constraint: ConstraintEnum})
+	}
+	switch schema.Type {
+	case "string":
+		for _, value := range formatMalformations[schema.Format] {
+			add(SchemaTestValue{Value: value, Description: fmt.Sprintf("malformed %s format", schema.Format), Constraint: ConstraintFormat})
+		}
+	case "array":
+		if schema.MinItems != nil && *schema.MinItems > 1 {
+			add(SchemaTestValue{Value: []interface{}{}, Description: fmt.Sprintf("empty while requiring %d items", *schema.MinItems), Constraint: ConstraintItems})
+		}
+	case "object":
+		properties := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			properties = append(properties, name)
+		}
+		sort.Strings(properties)
+		for _, name := range properties {
+			for _, violation := range schemaBoundaryViolations(schema.Properties[name], constraints, depth+1) {
+				object := objectValue(schema)
+				object[name] = violation.Value
+				add(SchemaTestValue{Value: object, Description: fmt.Sprintf("with property %s %s", name, violation.Description), Constraint: violation.Constraint})
+			}
+		}
+	}
+	return values
+}
+
+// hasConstraint returns true if a kind of constraint is listed, or if none is
+func hasConstraint(constraints []string, constraint string) bool {
+	if len(constraints) == 0 {
+		return true
+	}
+	for _, c := range constraints {
+		if c == constraint {
+			return true
+		}
+	}
+	return false
+}
+
+// objectValue returns a copy of the valid value of an object schema, so that the example of
+// the schema is not changed
+func objectValue(schema *OpenAPISchema) map[string]interface{} {
+	generated, _ := GenerateSchemaValue(schema).(map[string]interface{})
+	object := make(map[string]interface{}, len(generated))
+	for name, value := range generated {
+		object[name] = value
+	}
+	return object
+}
+
+// SchemaValueString formats a generated value for a query, path or header parameter
+func SchemaValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// lowerBound returns the smallest valid number of a schema
+func lowerBound(schema *OpenAPISchema, integer bool) (float64, bool) {
+	if schema.Minimum == nil {
+		return 0, false
+	}
+	if !schema.ExclusiveMinimum {
+		return *schema.Minimum, true
+	}
+	if integer {
+		return math.Floor(*schema.Minimum) + 1, true
+	}
+	return math.Nextafter(*schema.Minimum, math.Inf(1)), true
+}
+
+// upperBound returns the largest valid number of a schema
+func upperBound(schema *OpenAPISchema, integer bool) (float64, bool) {
+	if schema.Maximum == nil {
+		return 0, false
+	}
+	if !schema.ExclusiveMaximum {
+		return *schema.Maximum, true
+	}
+	if integer {
+		return math.Ceil(*schema.Maximum) - 1, true
+	}
+	return math.Nextafter(*schema.Maximum, math.Inf(-1)), true
+}
+
+// validNumber returns a valid number of a schema, preferring 1 if it is in range
+func validNumber(schema *OpenAPISchema, integer bool) float64 {
+	value := 1.0
+	if min, ok := lowerBound(schema, integer); ok && value < min {
+		value = min
+	}
+	if max, ok := upperBound(schema, integer); ok && value > max {
+		value = max
+	}
+	if integer {
+		value = math.Ceil(value)
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		value = math.Ceil(value / *schema.MultipleOf) * *schema.MultipleOf
+	}
+	return value
+}
+
+// numberValue converts a number to an integer value for integer schemas
+func numberValue(value float64, integer bool) interface{} {
+	if integer {
+		return int64(value)
+	}
+	return value
+}
+
+// arrayOf returns an array of valid items
+func arrayOf(items *OpenAPISchema, count int) []interface{} {
+	if count < 0 {
+		count = 0
+	}
+	array := make([]interface{}, count)
+	for i := range array {
+		array[i] = GenerateSchemaValue(items)
+	}
+	return array
+}
+
+// validString returns a valid string of a schema
+func validString(schema *OpenAPISchema) string {
+	value := "test"
+	if example, ok := formatExamples[schema.Format]; ok {
+		value = example
+	}
+	if schema.Pattern != "" {
+		if generated, ok := generateFromPattern(schema.Pattern, schema.MinLength, schema.MaxLength); ok {
+			return generated
+		}
+	}
+	return fitLength(value, schema.MinLength, schema.MaxLength)
+}
+
+// stringOfLength returns a string of a schema with a specific length
+func stringOfLength(schema *OpenAPISchema, length int) string {
+	if schema.Pattern != "" {
+		if generated, ok := generateFromPattern(schema.Pattern, &length, &length); ok {
+			return generated
+		}
+	}
+	return fitLength(validString(&OpenAPISchema{Format: schema.Format}), &length, &length)
+}
+
+// fitLength pads or truncates a string to satisfy length constraints
+func fitLength(value string, minLength, maxLength *int) string {
+	if minLength != nil && len(value) < *minLength {
+		value += strings.Repeat("a", *minLength-len(value))
+	}
+	if maxLength != nil && len(value) > *maxLength {
+		value = value[:*maxLength]
+	}
+	return value
+}
+
+// enumOffByOne returns a value close to the values of an enum but not part of it: the
+// largest numeric value plus one, or a string value with its case changed or its last
+// character altered
+func enumOffByOne(enum []interface{}) (interface{}, bool) {
+	inEnum := func(v interface{}) bool {
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				return true
+			}
+		}
+		return false
+	}
+
+	max, numeric := 0.0, true
+	for i, value := range enum {
+		f, ok := value.(float64)
+		if !ok {
+			if n, isInt := value.(int); isInt {
+				f, ok = float64(n), true
+			}
+		}
+		if !ok {
+			numeric = false
+			break
+		}
+		if i == 0 || f > max {
+			max = f
+		}
+	}
+	if numeric {
+		return max + 1, true
+	}
+
+	for _, value := range enum {
+		s, ok := value.(string)
+		if !ok || s == "" {
+			continue
+		}
+		candidates := []string{strings.ToUpper(s), strings.ToLower(s), s[:len(s)-1] + string(rune(s[len(s)-1])+1), s + "x"}
+		for _, candidate := range candidates {
+			if !inEnum(candidate) {
+				return candidate, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// enumViolations returns values not part of an enum: the numbers just below and above a
+// numeric enum, or the values of a string enum with their case changed or their last
+// character altered, and the empty string
+func enumViolations(enum []interface{}) []interface{} {
+	if len(enum) == 0 {
+		return nil
+	}
+	inEnum := make(map[string]bool, len(enum))
+	for _, value := range enum {
+		inEnum[fmt.Sprint(value)] = true
+	}
+
+	violations := make([]interface{}, 0)
+	min, max, numeric := 0.0, 0.0, true
+	for i, value := range enum {
+		f, ok := value.(float64)
+		if !ok {
+			if n, isInt := value.(int); isInt {
+				f, ok = float64(n), true
+			}
+		}
+		if !ok {
+			numeric = false
+			break
+		}
+		if i == 0 || f < min {
+			min = f
+		}
+		if i == 0 || f > max {
+			max = f
+		}
+	}
+	if numeric {
+		for _, value := range []float64{min - 1, max + 1} {
+			if !inEnum[fmt.Sprint(value)] {
+				violations = append(violations, value)
+			}
+		}
+		return violations
+	}
+
+	for _, value := range enum {
+		s, ok := value.(string)
+		if !ok || s == "" {
+			continue
+		}
+		for _, candidate := range []string{strings.ToUpper(s), strings.ToLower(s), s[:len(s)-1] + string(rune(s[len(s)-1])+1)} {
+			if !inEnum[candidate] {
+				inEnum[candidate] = true
+				violations = append(violations, candidate)
+			}
+		}
+		break
+	}
+	if !inEnum[""] {
+		violations = append(violations, "")
+	}
+	return violations
+}
+
+// generateFromPattern generates a string matching a regular expression within length
+// constraints. Repetitions are expanded until the minimum length is reached.
+func generateFromPattern(pattern string, minLength, maxLength *int) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	re = re.Simplify()
+	matcher, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", false
+	}
+
+	for reps := 1; reps <= 64; reps++ {
+		var b strings.Builder
+		writePatternString(&b, re, reps)
+		value := b.String()
+		if !matcher.MatchString(value) {
+			return "", false
+		}
+		if minLength != nil && len(value) < *minLength {
+			continue
+		}
+		if maxLength != nil && len(value) > *maxLength {
+			return "", false
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// writePatternString writes a string matching a parsed regular expression, repeating
+// unbounded repetitions reps times
+func writePatternString(b *strings.Builder, re *syntax.Regexp, reps int) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(charClassRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune('a')
+	case syntax.OpCapture:
+		writePatternString(b, re.Sub[0], reps)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writePatternString(b, sub, reps)
+		}
+	case syntax.OpAlternate:
+		writePatternString(b, re.Sub[0], reps)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		count := reps
+		switch re.Op {
+		case syntax.OpQuest:
+			count = 1
+		case syntax.OpRepeat:
+			if count < re.Min {
+				count = re.Min
+			}
+			if re.Max >= 0 && count > re.Max {
+				count = re.Max
+			}
+		}
+		for i := 0; i < count; i++ {
+			writePatternString(b, re.Sub[0], reps)
+		}
+	}
+}
+
+// charClassRune returns a printable rune of a character class, preferring letters and digits
+func charClassRune(ranges []rune) rune {
+	inClass := func(r rune) bool {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if r >= ranges[i] && r <= ranges[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, r := range "aA0" {
+		if inClass(r) {
+			return r
+		}
+	}
+	for r := rune(0x21); r < 0x7f; r++ {
+		if inClass(r) {
+			return r
+		}
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if unicode.IsPrint(ranges[i]) {
+			return ranges[i]
+		}
+	}
+	if len(ranges) > 0 {
+		return ranges[0]
+	}
+	return 'a'
+}
+
+// patternViolation returns a string not matching a regular expression
+func patternViolation(pattern string) (string, bool) {
+	matcher, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", false
+	}
+	candidates := []string{"!@#$%^&*", "", " ", "test", "12345", "\x00"}
+	if valid, ok := generateFromPattern(pattern, nil, nil); ok {
+		candidates = append([]string{valid + "!", "!" + valid}, candidates...)
+	}
+	for _, candidate := range candidates {
+		if !matcher.MatchString(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
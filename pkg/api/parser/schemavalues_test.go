@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"regexp"
+	"testing"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestGenerateSchemaValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *OpenAPISchema
+		want   interface{}
+	}{
+		{name: "Example", schema: &OpenAPISchema{Type: "string", Example: "john", Default: "jane"}, want: "john"},
+		{name: "Default", schema: &OpenAPISchema{Type: "string", Default: "jane"}, want: "jane"},
+		{name: "Enum", schema: &OpenAPISchema{Type: "string", Enum: []interface{}{"active", "inactive"}}, want: "active"},
+		{name: "Format", schema: &OpenAPISchema{Type: "string", Format: "uuid"}, want: "3fa85f64-5717-4562-b3fc-2c963f66afa6"},
+		{name: "Min length", schema: &OpenAPISchema{Type: "string", MinLength: intPtr(6)}, want: "testaa"},
+		{name: "Max length", schema: &OpenAPISchema{Type: "string", MaxLength: intPtr(2)}, want: "te"},
+		{name: "Integer minimum", schema: &OpenAPISchema{Type: "integer", Minimum: floatPtr(10)}, want: int64(10)},
+		{name: "Integer exclusive maximum", schema: &OpenAPISchema{Type: "integer", Maximum: floatPtr(0), ExclusiveMaximum: true}, want: int64(-1)},
+		{name: "Number multiple", schema: &OpenAPISchema{Type: "number", Minimum: floatPtr(3), MultipleOf: floatPtr(5)}, want: 5.0},
+		{name: "Boolean", schema: &OpenAPISchema{Type: "boolean"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateSchemaValue(tt.schema); got != tt.want {
+				t.Errorf("GenerateSchemaValue() = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+
+	array, ok := GenerateSchemaValue(&OpenAPISchema{Type: "array", MinItems: intPtr(3), Items: &OpenAPISchema{Type: "integer"}}).([]interface{})
+	if !ok || len(array) != 3 || array[0] != int64(1) {
+		t.Errorf("GenerateSchemaValue() = %v, want 3 integers", array)
+	}
+}
+
+func TestGenerateSchemaValue_Pattern(t *testing.T) {
+	patterns := []string{
+		`^[A-Z]{3}-\d{4}$`,
+		`^[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}$`,
+		`^(foo|bar)_[0-9]+$`,
+		`^\+?[1-9]\d{1,14}$`,
+	}
+	for _, pattern := range patterns {
+		value, ok := GenerateSchemaValue(&OpenAPISchema{Type: "string", Pattern: pattern}).(string)
+		if !ok || !regexp.MustCompile(pattern).MatchString(value) {
+			t.Errorf("GenerateSchemaValue() = %q, does not match %s", value, pattern)
+		}
+
+		violation := SchemaInvalidValues(&OpenAPISchema{Type: "string", Pattern: pattern})
+		if len(violation) != 1 || regexp.MustCompile(pattern).MatchString(violation[0].Value.(string)) {
+			t.Errorf("SchemaInvalidValues() = %v, expected a value not matching %s", violation, pattern)
+		}
+	}
+
+	value := GenerateSchemaValue(&OpenAPISchema{Type: "string", Pattern: `^[a-z]+$`, MinLength: intPtr(8)}).(string)
+	if len(value) < 8 || !regexp.MustCompile(`^[a-z]+$`).MatchString(value) {
+		t.Errorf("GenerateSchemaValue() = %q, expected at least 8 lowercase letters", value)
+	}
+}
+
+func TestSchemaBoundaryValues(t *testing.T) {
+	values := SchemaBoundaryValues(&OpenAPISchema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(100), ExclusiveMaximum: true})
+	if len(values) != 2 || values[0].Value != int64(1) || values[1].Value != int64(99) {
+		t.Errorf("SchemaBoundaryValues() = %v, want 1 and 99", values)
+	}
+
+	values = SchemaBoundaryValues(&OpenAPISchema{Type: "string", MinLength: intPtr(2), MaxLength: intPtr(5)})
+	if len(values) != 2 || len(values[0].Value.(string)) != 2 || len(values[1].Value.(string)) != 5 {
+		t.Errorf("SchemaBoundaryValues() = %v, want strings of length 2 and 5", values)
+	}
+
+	values = SchemaBoundaryValues(&OpenAPISchema{Type: "string", Enum: []interface{}{"a", "b"}})
+	if len(values) != 2 {
+		t.Errorf("SchemaBoundaryValues() = %v, want every enum value", values)
+	}
+}
+
+func TestSchemaInvalidValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *OpenAPISchema
+		want   []interface{}
+	}{
+		{
+			name:   "Integer range",
+			schema: &OpenAPISchema{Type: "integer", Format: "int32", Minimum: floatPtr(1), Maximum: floatPtr(10)},
+			want:   []interface{}{int64(0), int64(11), 1.5, int64(2147483648)},
+		},
+		{
+			name:   "Exclusive range",
+			schema: &OpenAPISchema{Type: "number", Minimum: floatPtr(0), ExclusiveMinimum: true},
+			want:   []interface{}{0.0},
+		},
+		{
+			name:   "String length",
+			schema: &OpenAPISchema{Type: "string", MinLength: intPtr(3), MaxLength: intPtr(4)},
+			want:   []interface{}{"aa", "testa"},
+		},
+		{
+			name:   "String enum",
+			schema: &OpenAPISchema{Type: "string", Enum: []interface{}{"admin", "user"}},
+			want:   []interface{}{"ADMIN"},
+		},
+		{
+			name:   "Numeric enum",
+			schema: &OpenAPISchema{Type: "integer", Enum: []interface{}{1.0, 2.0}},
+			want:   []interface{}{3.0, 1.5},
+		},
+		{
+			name:   "Format",
+			schema: &OpenAPISchema{Type: "string", Format: "email"},
+			want:   []interface{}{"not-an-email"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := SchemaInvalidValues(tt.schema)
+			if len(values) != len(tt.want) {
+				t.Fatalf("SchemaInvalidValues() = %v, want %v", values, tt.want)
+			}
+			for i, value := range values {
+				if value.Value != tt.want[i] {
+					t.Errorf("SchemaInvalidValues()[%d] = %v (%T), want %v (%T)", i, value.Value, value.Value, tt.want[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	values := SchemaInvalidValues(&OpenAPISchema{
+		Type:       "object",
+		Required:   []string{"name"},
+		Properties: map[string]*OpenAPISchema{"name": {Type: "string"}, "age": {Type: "integer"}},
+	})
+	if len(values) != 1 {
+		t.Fatalf("SchemaInvalidValues() = %v, want a missing required property", values)
+	}
+	if object := values[0].Value.(map[string]interface{}); len(object) != 1 || object["age"] != int64(1) {
+		t.Errorf("SchemaInvalidValues() = %v, want only the age property", object)
+	}
+}
+
+func TestSchemaBoundaryViolations(t *testing.T) {
+	tests := []struct {
+		name        string
+		schema      *OpenAPISchema
+		constraints []string
+		want        []interface{}
+	}{
+		{
+			name:   "Integer range without invalid types",
+			schema: &OpenAPISchema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(10)},
+			want:   []interface{}{int64(0), int64(11)},
+		},
+		{
+			name:   "Empty array",
+			schema: &OpenAPISchema{Type: "array", MinItems: intPtr(2), Items: &OpenAPISchema{Type: "string"}},
+			want:   []interface{}{`["test"]`, `[]`},
+		},
+		{
+			name:   "Enum members",
+			schema: &OpenAPISchema{Type: "string", Enum: []interface{}{"admin", "user"}},
+			want:   []interface{}{"ADMIN", "admio", ""},
+		},
+		{
+			name:   "Numeric enum members",
+			schema: &OpenAPISchema{Type: "integer", Enum: []interface{}{1.0, 2.0}},
+			want:   []interface{}{3.0, 0.0},
+		},
+		{
+			name:   "Malformed formats",
+			schema: &OpenAPISchema{Type: "string", Format: "uuid"},
+			want:   []interface{}{"3fa85f64-5717-4562-b3fc", "zzzzzzzz-zzzz-zzzz-zzzz-zzzzzzzzzzzz", "3fa85f64-5717-4562-b3fc-2c963f66afa6-0", "3fa85f64571745"},
+		},
+		{
+			name:        "Selected constraints",
+			schema:      &OpenAPISchema{Type: "string", Format: "email", MaxLength: intPtr(5)},
+			constraints: []string{ConstraintLength},
+			want:        []interface{}{"testaa"},
+		},
+		{
+			name: "Object properties",
+			schema: &OpenAPISchema{
+				Type:       "object",
+				Required:   []string{"zip"},
+				Properties: map[string]*OpenAPISchema{"zip": {Type: "string", Example: "12345", MaxLength: intPtr(5)}},
+			},
+			want: []interface{}{`{}`, `{"zip":"testaa"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := SchemaBoundaryViolations(tt.schema, tt.constraints...)
+			if len(values) != len(tt.want) {
+				t.Fatalf("SchemaBoundaryViolations() = %v, want %v", values, tt.want)
+			}
+			for i, value := range values {
+				got := value.Value
+				if _, ok := tt.want[i].(string); ok {
+					got = SchemaValueString(got)
+				}
+				if got != tt.want[i] {
+					t.Errorf("SchemaBoundaryViolations()[%d] = %v (%T), want %v (%T)", i, got, got, tt.want[i], tt.want[i])
+				}
+				if value.Constraint == "" || value.Constraint == ConstraintType {
+					t.Errorf("SchemaBoundaryViolations()[%d] has constraint %q", i, value.Constraint)
+				}
+			}
+		})
+	}
+}
+
+func TestAPITestGenerator_SchemaConstraints(t *testing.T) {
+	discovery := NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.Endpoints = []*DiscoveredEndpoint{
+		{
+			Path:   "/api/items",
+			Method: "GET",
+			Parameters: []*DiscoveredParameter{
+				{
+					Name:   "limit",
+					In:     "query",
+					Type:   "integer",
+					Schema: &OpenAPISchema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(50)},
+				},
+			},
+		},
+	}
+
+	extractor := NewAPIParameterExtractor(discovery)
+	if err := extractor.ExtractParameters(); err != nil {
+		t.Fatalf("Failed to extract parameters: %v", err)
+	}
+	generator := NewAPITestGenerator(discovery, extractor)
+	if err := generator.GenerateTestCases(); err != nil {
+		t.Fatalf("Failed to generate test cases: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, testCase := range generator.GetTestCases() {
+		found[testCase.QueryParams["limit"]] = true
+	}
+	for _, value := range []string{"1", "50", "0", "51"} {
+		if !found[value] {
+			t.Errorf("Expected a test case with limit=%s", value)
+		}
+	}
+}
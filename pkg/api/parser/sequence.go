@@ -0,0 +1,338 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// maxSequenceValueLength is the maximum length of the variable values shown in sequence diagrams
+const maxSequenceValueLength = 40
+
+// SequenceStep is a step of an executed test chain, shown in a sequence diagram
+type SequenceStep struct {
+	// Name of the test case
+	Name string `json:"name"`
+	// Method is the HTTP method of the request
+	Method string `json:"method"`
+	// URL is the URL of the request
+	URL string `json:"url"`
+	// StatusCode is the status code of the response, 0 if there was none
+	StatusCode int `json:"status_code,omitempty"`
+	// Outcome is the outcome of the step: passed, failed, error or skipped
+	Outcome string `json:"outcome"`
+	// Message describes the failures or the error of the step
+	Message string `json:"message,omitempty"`
+	// Variables are the values the request used, extracted from previous responses
+	Variables map[string]string `json:"variables,omitempty"`
+	// Extracted are the values later steps extracted from the response
+	Extracted map[string]string `json:"extracted,omitempty"`
+}
+
+// VisualizeSequence generates a sequence diagram of the steps of an executed test chain, in
+// their execution order, with the status code and the extracted variables of each step
+func (v *Visualizer) VisualizeSequence(steps []*SequenceStep) (string, error) {
+	switch v.options.Format {
+	case VisFormatJSON:
+		return v.generateJSONVisualization(steps)
+	case VisFormatMermaid:
+		return v.generateMermaidSequence(steps), nil
+	case VisFormatPlantUML:
+		return v.generatePlantUMLSequence(steps), nil
+	case VisFormatSVG:
+		return v.generateSVGSequence(steps), nil
+	default:
+		return "", api.NewAPIError("Unsupported sequence diagram format", 0)
+	}
+}
+
+// Kinds of the events of a sequence diagram
+const (
+	sequenceRequestEvent  = "request"
+	sequenceResponseEvent = "response"
+	sequenceNoteEvent     = "note"
+	sequenceSkippedEvent  = "skipped"
+	sequenceErrorEvent    = "error"
+)
+
+// sequenceEvent is a message or a note of a sequence diagram. Participant 0 is the client,
+// the servers are numbered from 1.
+type sequenceEvent struct {
+	kind        string
+	participant int
+	text        string
+	// failed marks requests without a response and responses of steps that did not pass
+	failed bool
+}
+
+// sequenceEvents returns the hosts and the events of the sequence diagram of steps. Notes
+// are over the client, skipped steps span the client and the server, and errors are on the
+// right of the server.
+func sequenceEvents(steps []*SequenceStep) ([]string, []sequenceEvent) {
+	hosts := sequenceHosts(steps)
+	events := make([]sequenceEvent, 0)
+	for i, step := range steps {
+		server := indexOf(hosts, sequenceHost(step)) + 1
+		request := sequenceRequest(i, step)
+		for _, note := range sequenceNotes(step.Variables, "uses") {
+			events = append(events, sequenceEvent{kind: sequenceNoteEvent, text: note})
+		}
+		if step.Outcome == "skipped" {
+			events = append(events, sequenceEvent{kind: sequenceSkippedEvent, participant: server, text: request + " skipped"})
+			continue
+		}
+		events = append(events, sequenceEvent{kind: sequenceRequestEvent, participant: server, text: request, failed: step.StatusCode == 0})
+		if step.StatusCode != 0 {
+			events = append(events, sequenceEvent{kind: sequenceResponseEvent, participant: server, text: sequenceResponse(step), failed: step.Outcome != "passed"})
+		}
+		if step.Message != "" {
+			events = append(events, sequenceEvent{kind: sequenceErrorEvent, participant: server, text: step.Message})
+		}
+		for _, note := range sequenceNotes(step.Extracted, "extracted") {
+			events = append(events, sequenceEvent{kind: sequenceNoteEvent, text: note})
+		}
+	}
+	return hosts, events
+}
+
+// generateMermaidSequence generates a Mermaid sequenceDiagram
+func (v *Visualizer) generateMermaidSequence(steps []*SequenceStep) string {
+	var buf bytes.Buffer
+	buf.WriteString("sequenceDiagram\n")
+	if v.options.Title != "" {
+		buf.WriteString(fmt.Sprintf("  title %s\n", mermaidSequenceText(v.options.Title)))
+	}
+	buf.WriteString("  participant C as Client\n")
+	hosts, events := sequenceEvents(steps)
+	for i, host := range hosts {
+		buf.WriteString(fmt.Sprintf("  participant S%d as %s\n", i+1, mermaidSequenceText(host)))
+	}
+
+	for _, event := range events {
+		server, text := fmt.Sprintf("S%d", event.participant), mermaidSequenceText(event.text)
+		switch event.kind {
+		case sequenceNoteEvent:
+			buf.WriteString(fmt.Sprintf("  Note over C: %s\n", text))
+		case sequenceSkippedEvent:
+			buf.WriteString(fmt.Sprintf("  Note over C,%s: %s\n", server, text))
+		case sequenceErrorEvent:
+			buf.WriteString(fmt.Sprintf("  Note right of %s: %s\n", server, text))
+		case sequenceRequestEvent:
+			arrow := "->>"
+			if event.failed {
+				arrow = "-x"
+			}
+			buf.WriteString(fmt.Sprintf("  C%s%s: %s\n", arrow, server, text))
+		case sequenceResponseEvent:
+			buf.WriteString(fmt.Sprintf("  %s-->>C: %s\n", server, text))
+		}
+	}
+	return buf.String()
+}
+
+// generatePlantUMLSequence generates a PlantUML sequence diagram
+func (v *Visualizer) generatePlantUMLSequence(steps []*SequenceStep) string {
+	var buf bytes.Buffer
+	buf.WriteString("@startuml\n")
+	if v.options.Title != "" {
+		buf.WriteString(fmt.Sprintf("title %s\n", plantUMLText(v.options.Title)))
+	}
+	buf.WriteString("participant \"Client\" as C\n")
+	hosts, events := sequenceEvents(steps)
+	for i, host := range hosts {
+		buf.WriteString(fmt.Sprintf("participant \"%s\" as S%d\n", plantUMLText(host), i+1))
+	}
+
+	for _, event := range events {
+		server, text := fmt.Sprintf("S%d", event.participant), plantUMLText(event.text)
+		switch event.kind {
+		case sequenceNoteEvent:
+			buf.WriteString(fmt.Sprintf("note over C : %s\n", text))
+		case sequenceSkippedEvent:
+			buf.WriteString(fmt.Sprintf("note over C, %s #lightgray : %s\n", server, text))
+		case sequenceErrorEvent:
+			buf.WriteString(fmt.Sprintf("note right of %s #pink : %s\n", server, text))
+		case sequenceRequestEvent:
+			arrow := "->"
+			if event.failed {
+				arrow = "->x"
+			}
+			buf.WriteString(fmt.Sprintf("C %s %s : %s\n", arrow, server, text))
+		case sequenceResponseEvent:
+			color := ""
+			if event.failed {
+				color = "[#red]"
+			}
+			buf.WriteString(fmt.Sprintf("%s --%s> C : %s\n", server, color, text))
+		}
+	}
+	buf.WriteString("@enduml\n")
+	return buf.String()
+}
+
+// generateSVGSequence draws a sequence diagram as SVG, a row per event
+func (v *Visualizer) generateSVGSequence(steps []*SequenceStep) string {
+	hosts, events := sequenceEvents(steps)
+	participants := append([]string{"Client"}, hosts...)
+
+	// Participants are spaced by the longest message between them
+	columnWidth := 160.0
+	for _, event := range events {
+		if event.kind == sequenceRequestEvent || event.kind == sequenceResponseEvent {
+			columnWidth = math.Max(columnWidth, svgTextWidth(event.text)/float64(event.participant)+2*svgPadding)
+		}
+	}
+	for _, participant := range participants {
+		columnWidth = math.Max(columnWidth, svgTextWidth(participant)+4*svgPadding)
+	}
+	center := func(i int) float64 { return svgMargin + columnWidth/2 + float64(i)*columnWidth }
+
+	const rowHeight = 32.0
+	top := svgMargin
+	if v.options.Title != "" {
+		top += svgTitleSize
+	}
+	headerHeight := svgLineHeight + 2*svgPadding
+	rowsTop := top + headerHeight + svgPadding
+	width := 2*svgMargin + float64(len(participants))*columnWidth
+	rightWidth := 0.0
+	for _, event := range events {
+		if event.kind == sequenceErrorEvent {
+			rightWidth = math.Max(rightWidth, center(event.participant)+svgPadding+svgTextWidth(event.text)+2*svgPadding+svgMargin-width)
+		}
+	}
+	width += math.Max(rightWidth, 0)
+	height := rowsTop + float64(len(events))*rowHeight + svgMargin
+
+	var buf bytes.Buffer
+	svgHeader(&buf, width, height, v.options.Title)
+	svgMarkers(&buf, []string{"#333333", "#d32f2f"})
+	for i, participant := range participants {
+		x := center(i)
+		boxWidth := columnWidth - 2*svgPadding
+		buf.WriteString(fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#999999" stroke-dasharray="4,4"/>`+"\n", x, top+headerHeight, x, height-svgMargin))
+		buf.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="4" fill="#e3f2fd" stroke="#1976d2"/>`+"\n", x-boxWidth/2, top, boxWidth, headerHeight))
+		buf.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" font-weight="bold">%s</text>`+"\n", x, top+headerHeight/2+4, svgEscape(participant)))
+	}
+
+	for i, event := range events {
+		y := rowsTop + float64(i)*rowHeight + rowHeight/2
+		client, server := center(0), center(event.participant)
+		switch event.kind {
+		case sequenceNoteEvent, sequenceSkippedEvent, sequenceErrorEvent:
+			x, fill, noteWidth := client-columnWidth/2+svgPadding, "#fff9c4", svgTextWidth(event.text)+2*svgPadding
+			if event.kind == sequenceSkippedEvent {
+				fill, noteWidth = "#eeeeee", math.Max(noteWidth, server-client+columnWidth-2*svgPadding)
+			} else if event.kind == sequenceErrorEvent {
+				x, fill = server+svgPadding, "#ffcdd2"
+			}
+			buf.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="#999999"/>`+"\n", x, y-svgLineHeight/2-2, noteWidth, svgLineHeight+4, fill))
+			buf.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f">%s</text>`+"\n", x+svgPadding, y+4, svgEscape(event.text)))
+		case sequenceRequestEvent, sequenceResponseEvent:
+			x1, x2, color, dash := client, server, "#333333", ""
+			if event.kind == sequenceResponseEvent {
+				x1, x2, dash = server, client, ` stroke-dasharray="6,4"`
+			}
+			if event.failed {
+				color = "#d32f2f"
+			}
+			buf.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" fill="%s">%s</text>`+"\n", (x1+x2)/2, y-4, color, svgEscape(event.text)))
+			if event.kind == sequenceRequestEvent && event.failed {
+				// A request without a response ends with a cross
+				buf.WriteString(fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1.5"/>`+"\n", x1, y+4, x2, y+4, color))
+				buf.WriteString(fmt.Sprintf(`<path d="M %.1f %.1f l 10 10 m 0 -10 l -10 10" stroke="%s" stroke-width="2"/>`+"\n", x2-10, y-1, color))
+				continue
+			}
+			buf.WriteString(fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1.5"%s marker-end="url(#%s)"/>`+"\n", x1, y+4, x2, y+4, color, dash, svgMarkerID(color)))
+		}
+	}
+	buf.WriteString("</svg>\n")
+	return buf.String()
+}
+
+// sequenceHosts returns the hosts of the steps, in the order of their first request
+func sequenceHosts(steps []*SequenceStep) []string {
+	hosts := make([]string, 0)
+	for _, step := range steps {
+		if host := sequenceHost(step); !contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// sequenceHost returns the host a step sends its request to
+func sequenceHost(step *SequenceStep) string {
+	if u, err := url.Parse(step.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "API"
+}
+
+// sequenceRequest returns the label of the request of a step
+func sequenceRequest(i int, step *SequenceStep) string {
+	// The URL is shown as sent, without the escaping of url.URL
+	target := step.URL
+	if u, err := url.Parse(step.URL); err == nil && u.Host != "" {
+		target = strings.TrimPrefix(target, u.Scheme+"://"+u.Host)
+		if target == "" {
+			target = "/"
+		}
+	}
+	return fmt.Sprintf("%d. %s %s", i+1, step.Method, target)
+}
+
+// sequenceResponse returns the label of the response of a step
+func sequenceResponse(step *SequenceStep) string {
+	label := strings.TrimSpace(fmt.Sprintf("%d %s", step.StatusCode, http.StatusText(step.StatusCode)))
+	if step.Outcome != "" && step.Outcome != "passed" {
+		label += " (" + step.Outcome + ")"
+	}
+	return label
+}
+
+// sequenceNotes returns the notes showing variables, sorted by name
+func sequenceNotes(variables map[string]string, verb string) []string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	notes := make([]string, 0, len(names))
+	for _, name := range names {
+		value := variables[name]
+		if len(value) > maxSequenceValueLength {
+			value = value[:maxSequenceValueLength] + "..."
+		}
+		notes = append(notes, fmt.Sprintf("%s %s = %s", verb, name, value))
+	}
+	return notes
+}
+
+// mermaidSequenceText escapes the text of a Mermaid sequence diagram, which ends at a line
+// break or a semicolon
+func mermaidSequenceText(s string) string {
+	s = strings.NewReplacer("#", "#35;", ";", "#59;").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// plantUMLText escapes the text of a PlantUML diagram, which ends at a line break
+func plantUMLText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// indexOf returns the index of a string in a slice, or -1
+func indexOf(slice []string, s string) int {
+	for i, item := range slice {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestSequenceSteps() []*SequenceStep {
+	return []*SequenceStep{
+		{Name: "create", Method: "POST", URL: "https://api.example.com/users", StatusCode: 201, Outcome: "passed", Extracted: map[string]string{"id": "7", "token": strings.Repeat("x", 50)}},
+		{Name: "read", Method: "GET", URL: "https://api.example.com/users/7?fields=a;b", StatusCode: 404, Outcome: "failed", Message: "expected status 200, got 404", Variables: map[string]string{"id": "7"}},
+		{Name: "audit", Method: "GET", URL: "https://audit.example.com/events", Outcome: "error", Message: "connection refused"},
+		{Name: "delete", Method: "DELETE", URL: "https://api.example.com/users/${id}", Outcome: "skipped"},
+	}
+}
+
+func TestVisualizeSequence_Mermaid(t *testing.T) {
+	v := NewVisualizer(&VisOptions{Format: VisFormatMermaid, Title: "CRUD #1"})
+	diagram, err := v.VisualizeSequence(newTestSequenceSteps())
+	if err != nil {
+		t.Fatalf("VisualizeSequence returned an error: %s", err)
+	}
+	expected := []string{
+		"sequenceDiagram\n",
+		"  title CRUD #35;1\n",
+		"  participant S1 as api.example.com\n",
+		"  participant S2 as audit.example.com\n",
+		"  C->>S1: 1. POST /users\n  S1-->>C: 201 Created\n  Note over C: extracted id = 7\n",
+		"  Note over C: extracted token = " + strings.Repeat("x", 40) + "...\n",
+		"  Note over C: uses id = 7\n  C->>S1: 2. GET /users/7?fields=a#59;b\n  S1-->>C: 404 Not Found (failed)\n  Note right of S1: expected status 200, got 404\n",
+		"  C-xS2: 3. GET /events\n  Note right of S2: connection refused\n",
+		"  Note over C,S1: 4. DELETE /users/${id} skipped\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(diagram, e) {
+			t.Errorf("Expected %q in the diagram:\n%s", e, diagram)
+		}
+	}
+}
+
+func TestVisualizeSequence_PlantUML(t *testing.T) {
+	v := NewVisualizer(&VisOptions{Format: VisFormatPlantUML})
+	diagram, err := v.VisualizeSequence(newTestSequenceSteps())
+	if err != nil {
+		t.Fatalf("VisualizeSequence returned an error: %s", err)
+	}
+	if !strings.HasPrefix(diagram, "@startuml\n") || !strings.HasSuffix(diagram, "@enduml\n") || strings.Contains(diagram, "title") {
+		t.Errorf("Unexpected diagram:\n%s", diagram)
+	}
+	expected := []string{
+		"participant \"api.example.com\" as S1\n",
+		"C -> S1 : 1. POST /users\nS1 --> C : 201 Created\nnote over C : extracted id = 7\n",
+		"S1 --[#red]> C : 404 Not Found (failed)\nnote right of S1 #pink : expected status 200, got 404\n",
+		"C ->x S2 : 3. GET /events\n",
+		"note over C, S1 #lightgray : 4. DELETE /users/${id} skipped\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(diagram, e) {
+			t.Errorf("Expected %q in the diagram:\n%s", e, diagram)
+		}
+	}
+}
+
+func TestVisualizeSequence_Formats(t *testing.T) {
+	output, err := NewVisualizer(&VisOptions{Format: VisFormatJSON}).VisualizeSequence(newTestSequenceSteps())
+	if err != nil || !strings.Contains(output, `"outcome": "skipped"`) {
+		t.Errorf("Expected the steps as JSON, got %s: %v", output, err)
+	}
+	if _, err := NewVisualizer(&VisOptions{Format: VisFormatDOT}).VisualizeSequence(newTestSequenceSteps()); err == nil {
+		t.Errorf("Expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,388 @@
+package parser
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/diff"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// Kinds of shadow APIs
+const (
+	// ShadowAPIVersion is an undocumented version of a documented API
+	ShadowAPIVersion = "version"
+	// ShadowAPIHost is a documented API served on an undocumented host
+	ShadowAPIHost = "host"
+)
+
+// DefaultShadowVersionNames are the named versions tried besides the numbered versions around
+// the documented version
+var DefaultShadowVersionNames = []string{
+	"beta", "alpha", "dev", "test", "internal", "private", "preview", "legacy", "old", "latest",
+}
+
+// DefaultShadowEnvironments are the environments of the hosts tried for each documented host,
+// e.g. api-dev.example.com, dev-api.example.com and dev.api.example.com for api.example.com
+var DefaultShadowEnvironments = []string{
+	"dev", "development", "staging", "stage", "test", "qa", "uat", "beta", "sandbox", "preview",
+	"internal", "old", "legacy", "v1", "v2",
+}
+
+// versionSegment matches the path segments naming an API version, e.g. v2, v1.1, v2019 or
+// 2019-07-01
+var versionSegment = regexp.MustCompile(`^(v\d+(\.\d+)?|\d{4}-\d{2}-\d{2})$`)
+
+// pathTemplate matches the path parameters of an endpoint path
+var pathTemplate = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// ShadowAPI is an undocumented version or host of a documented API
+type ShadowAPI struct {
+	// Kind of the shadow API: version or host
+	Kind string
+	// BaseURL of the shadow API, e.g. https://api.example.com/v1 or https://api-dev.example.com
+	BaseURL string
+	// Version of a shadow version, e.g. v1
+	Version string
+	// Host of a shadow host, e.g. api-dev.example.com
+	Host string
+	// Documented is the base URL of the documented API the shadow API was derived from
+	Documented string
+	// Endpoints are the documented endpoints answering on the shadow API
+	Endpoints []*DiscoveredEndpoint
+}
+
+// ShadowAPIProber finds shadow APIs, the undocumented versions and hosts of documented APIs
+// which are still served, e.g. /v1 when /v2 is documented, or api-staging.example.com. The
+// version segments of the documented endpoints are replaced with the versions around them and
+// with named versions, their hosts with the hosts of other environments, and the paths answering
+// differently from a version or host which does not exist are kept. Only GET requests are sent.
+type ShadowAPIProber struct {
+	// Versions enables the enumeration of the versions of the endpoints
+	Versions bool
+	// Hosts enables the enumeration of the hosts of the endpoints
+	Hosts bool
+	// VersionNames are the named versions tried besides the numbered versions
+	VersionNames []string
+	// Environments are the environments of the hosts tried
+	Environments []string
+	// MaxPaths is the maximum number of documented paths requested on each version or host
+	MaxPaths int
+	// Headers sent with every request (e.g., Authorization)
+	Headers map[string]string
+	// Requests is the number of requests sent by the last probe
+	Requests int
+
+	runner   ffuf.RunnerProvider
+	controls map[string]*ffuf.Response
+}
+
+// shadowGroup is a set of documented endpoints sharing a base URL, e.g. the endpoints of
+// https://api.example.com/v2
+type shadowGroup struct {
+	// base is the base URL of the group, before the version segment if any
+	base string
+	// version is the documented version segment, empty for host groups
+	version string
+	// suffixes are the unique path templates after the base and version, in order
+	suffixes []string
+	// endpoints are the endpoints of each path template
+	endpoints map[string][]*DiscoveredEndpoint
+}
+
+// NewShadowAPIProber creates a ShadowAPIProber sending its requests with a runner
+func NewShadowAPIProber(r ffuf.RunnerProvider) *ShadowAPIProber {
+	return &ShadowAPIProber{
+		Versions:     true,
+		Hosts:        true,
+		VersionNames: DefaultShadowVersionNames,
+		Environments: DefaultShadowEnvironments,
+		MaxPaths:     10,
+		Headers:      make(map[string]string),
+		runner:       r,
+	}
+}
+
+// VersionCandidates returns the versions tried for a documented version: the major versions
+// from v0 to two versions ahead, the minor versions of the documented major version, the years
+// of date versions, and the named versions
+func VersionCandidates(version string, names []string) []string {
+	candidates := make([]string, 0)
+	if date, err := time.Parse("2006-01-02", version); err == nil {
+		for year := date.Year() - 3; year <= date.Year()+1; year++ {
+			candidates = append(candidates, fmt.Sprintf("%d-01-01", year))
+		}
+	} else if parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2); parts[0] != "" {
+		major, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return candidates
+		}
+		if major >= 1900 {
+			// Years used as versions, e.g. v2019
+			for year := major - 3; year <= major+1; year++ {
+				candidates = append(candidates, fmt.Sprintf("v%d", year))
+			}
+		} else {
+			for i := 0; i <= major+2; i++ {
+				candidates = append(candidates, fmt.Sprintf("v%d", i))
+				if len(parts) == 2 {
+					candidates = append(candidates, fmt.Sprintf("v%d.0", i))
+				}
+			}
+			if len(parts) == 2 {
+				if minor, err := strconv.Atoi(parts[1]); err == nil {
+					for i := 1; i <= minor+2; i++ {
+						candidates = append(candidates, fmt.Sprintf("v%d.%d", major, i))
+					}
+				}
+			}
+		}
+	}
+	candidates = append(candidates, names...)
+
+	unique := make([]string, 0, len(candidates))
+	seen := map[string]bool{version: true}
+	for _, candidate := range candidates {
+		if !seen[candidate] {
+			seen[candidate] = true
+			unique = append(unique, candidate)
+		}
+	}
+	return unique
+}
+
+// HostCandidates returns the hosts of other environments of a host, e.g. api-dev.example.com,
+// dev-api.example.com and dev.api.example.com for api.example.com. IP addresses and hosts
+// without a domain have none.
+func HostCandidates(host string, environments []string) []string {
+	hostname, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		hostname, port = h, ":"+p
+	}
+	if net.ParseIP(hostname) != nil || !strings.Contains(hostname, ".") {
+		return []string{}
+	}
+	labels := strings.SplitN(hostname, ".", 2)
+	candidates := make([]string, 0, len(environments)*3)
+	for _, env := range environments {
+		candidates = append(candidates,
+			labels[0]+"-"+env+"."+labels[1]+port,
+			env+"-"+labels[0]+"."+labels[1]+port,
+			env+"."+hostname+port)
+	}
+	return candidates
+}
+
+// Probe enumerates the versions and hosts of documented endpoints and returns the shadow APIs
+// found
+func (p *ShadowAPIProber) Probe(endpoints []*DiscoveredEndpoint) []*ShadowAPI {
+	p.Requests = 0
+	p.controls = make(map[string]*ffuf.Response)
+	versionGroups, hostGroups := shadowGroups(endpoints)
+
+	shadows := make([]*ShadowAPI, 0)
+	if p.Versions {
+		for _, group := range versionGroups {
+			for _, version := range VersionCandidates(group.version, p.VersionNames) {
+				control := group.base + "/v" + strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte(group.base))), 10)
+				found := p.probeGroup(group, group.base+"/"+version, control, false)
+				if len(found) > 0 {
+					shadows = append(shadows, &ShadowAPI{
+						Kind:       ShadowAPIVersion,
+						BaseURL:    group.base + "/" + version,
+						Version:    version,
+						Documented: group.base + "/" + group.version,
+						Endpoints:  shadowEndpoints(group, found, group.base+"/"+group.version, group.base+"/"+version, "version "+version),
+					})
+				}
+			}
+		}
+	}
+	if p.Hosts {
+		for _, group := range hostGroups {
+			u, err := url.Parse(group.base)
+			if err != nil {
+				continue
+			}
+			labels := strings.SplitN(u.Host, ".", 2)
+			control := fmt.Sprintf("%s://%s-ffuf%08x.%s", u.Scheme, labels[0], crc32.ChecksumIEEE([]byte(u.Host)), labels[len(labels)-1])
+			for _, host := range HostCandidates(u.Host, p.Environments) {
+				base := u.Scheme + "://" + host
+				found := p.probeGroup(group, base, control, true)
+				if len(found) > 0 {
+					shadows = append(shadows, &ShadowAPI{
+						Kind:       ShadowAPIHost,
+						BaseURL:    base,
+						Host:       host,
+						Documented: group.base,
+						Endpoints:  shadowEndpoints(group, found, group.base, base, "host "+host),
+					})
+				}
+			}
+		}
+	}
+	return shadows
+}
+
+// shadowGroups groups the endpoints by the base URL before their version segment, and by origin
+func shadowGroups(endpoints []*DiscoveredEndpoint) ([]*shadowGroup, []*shadowGroup) {
+	versionGroups := make([]*shadowGroup, 0)
+	hostGroups := make([]*shadowGroup, 0)
+	byKey := make(map[string]*shadowGroup)
+	add := func(groups *[]*shadowGroup, base, version, suffix string, endpoint *DiscoveredEndpoint) {
+		key := base + " " + version
+		group, ok := byKey[key]
+		if !ok {
+			group = &shadowGroup{base: base, version: version, endpoints: make(map[string][]*DiscoveredEndpoint)}
+			byKey[key] = group
+			*groups = append(*groups, group)
+		}
+		if _, ok := group.endpoints[suffix]; !ok {
+			group.suffixes = append(group.suffixes, suffix)
+		}
+		group.endpoints[suffix] = append(group.endpoints[suffix], endpoint)
+	}
+
+	for _, endpoint := range endpoints {
+		// Path templates are kept as they are, {id} being escaped by url.Parse
+		schemeEnd := strings.Index(endpoint.URL, "://")
+		if schemeEnd < 0 {
+			continue
+		}
+		pathStart := strings.IndexAny(endpoint.URL[schemeEnd+3:], "/?#")
+		origin, path := endpoint.URL, "/"
+		if pathStart >= 0 {
+			origin, path = endpoint.URL[:schemeEnd+3+pathStart], endpoint.URL[schemeEnd+3+pathStart:]
+		}
+		if i := strings.IndexAny(path, "?#"); i >= 0 {
+			path = path[:i]
+		}
+		add(&hostGroups, origin, "", path, endpoint)
+
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if versionSegment.MatchString(segment) {
+				add(&versionGroups, origin+strings.Join(segments[:i], "/"), segment, "/"+strings.Join(segments[i+1:], "/"), endpoint)
+				break
+			}
+		}
+	}
+	return versionGroups, hostGroups
+}
+
+// probeGroup requests the paths of a group on a base URL and returns the paths answering
+// differently from the same paths on the control base URL. A host which cannot be reached is
+// skipped after its first request.
+func (p *ShadowAPIProber) probeGroup(group *shadowGroup, base, control string, host bool) []string {
+	found := make([]string, 0)
+	for i, suffix := range group.suffixes {
+		if p.MaxPaths > 0 && i >= p.MaxPaths {
+			break
+		}
+		path := examplePath(suffix, group.endpoints[suffix][0])
+		resp, err := p.get(base + path)
+		if err != nil {
+			if host {
+				break
+			}
+			continue
+		}
+		controlURL := control + path
+		controlResp, ok := p.controls[controlURL]
+		if !ok {
+			if r, err := p.get(controlURL); err == nil {
+				controlResp = r
+			}
+			p.controls[controlURL] = controlResp
+		}
+		if shadowResponse(resp, controlResp) {
+			found = append(found, suffix)
+		}
+	}
+	return found
+}
+
+// get sends a GET request with the headers of the prober
+func (p *ShadowAPIProber) get(u string) (*ffuf.Response, error) {
+	headers := make(map[string]string, len(p.Headers))
+	for name, value := range p.Headers {
+		headers[name] = value
+	}
+	p.Requests++
+	resp, err := p.runner.Execute(&ffuf.Request{Method: "GET", Url: u, Headers: headers})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// shadowResponse returns true if a response shows that a path exists: it is neither a not
+// found nor a server error, and differs from the response of the control
+func shadowResponse(resp, control *ffuf.Response) bool {
+	if resp.StatusCode == 404 || resp.StatusCode == 410 || resp.StatusCode >= 500 || resp.StatusCode == 0 {
+		return false
+	}
+	return control == nil || control.StatusCode != resp.StatusCode || !diff.SameResource(control, resp, nil)
+}
+
+// shadowEndpoints returns copies of the endpoints of the paths found on a shadow API, with
+// their URL moved from the documented base URL to the shadow base URL
+func shadowEndpoints(group *shadowGroup, suffixes []string, documented, base, name string) []*DiscoveredEndpoint {
+	endpoints := make([]*DiscoveredEndpoint, 0)
+	documentedPath, shadowPath := urlPath(documented), urlPath(base)
+	for _, suffix := range suffixes {
+		for _, endpoint := range group.endpoints[suffix] {
+			shadow := *endpoint
+			shadow.Parameters = append([]*DiscoveredParameter{}, endpoint.Parameters...)
+			shadow.URL = base + strings.TrimPrefix(endpoint.URL, documented)
+			// The path of the endpoint includes the version segment when the server URL does not
+			if documentedPath != "" && strings.HasPrefix(endpoint.Path, documentedPath+"/") {
+				shadow.Path = shadowPath + strings.TrimPrefix(endpoint.Path, documentedPath)
+			}
+			shadow.Source = "Shadow"
+			shadow.Tags = append(append([]string{}, endpoint.Tags...), "shadow-api")
+			shadow.Description = fmt.Sprintf("Undocumented %s of %s %s", name, endpoint.Method, endpoint.URL)
+			endpoints = append(endpoints, &shadow)
+		}
+	}
+	return endpoints
+}
+
+// urlPath returns the path of a URL, empty if it has none
+func urlPath(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return strings.TrimSuffix(u.Path, "/")
+	}
+	return ""
+}
+
+// examplePath replaces the path parameters of a path template with the example values of the
+// parameters of an endpoint, or 1
+func examplePath(path string, endpoint *DiscoveredEndpoint) string {
+	return pathTemplate.ReplaceAllStringFunc(path, func(match string) string {
+		name := match[1 : len(match)-1]
+		for _, param := range endpoint.Parameters {
+			if param.Name == name && param.In == "path" && param.Example != nil {
+				return url.PathEscape(fmt.Sprint(param.Example))
+			}
+		}
+		return "1"
+	})
+}
+
+// DiscoverShadowAPIs finds the shadow APIs of the discovered endpoints with a prober and adds
+// the endpoints answering on them, marked with the Shadow source and the shadow-api tag. It
+// returns the shadow APIs found.
+func (d *APIEndpointDiscovery) DiscoverShadowAPIs(prober *ShadowAPIProber) []*ShadowAPI {
+	shadows := prober.Probe(d.GetEndpoints())
+	for _, shadow := range shadows {
+		d.mergeEndpoints(shadow.Endpoints)
+	}
+	return shadows
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// shadowRunner answers the requests of a ShadowAPIProber from the responses of its hosts, and
+// fails to connect to the others
+type shadowRunner struct {
+	hosts    map[string]func(path string) (int, string)
+	requests []string
+}
+
+func (r *shadowRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return *basereq, nil
+}
+
+func (r *shadowRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	r.requests = append(r.requests, req.Method+" "+req.Url)
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return ffuf.Response{}, err
+	}
+	handler, ok := r.hosts[u.Host]
+	if !ok {
+		return ffuf.Response{}, fmt.Errorf("dial tcp: lookup %s: no such host", u.Host)
+	}
+	status, body := handler(u.Path)
+	return ffuf.Response{
+		StatusCode:  int64(status),
+		ContentType: "application/json",
+		Headers:     map[string][]string{"Content-Type": {"application/json"}},
+		Data:        []byte(body),
+		Request:     req,
+	}, nil
+}
+
+func (r *shadowRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return nil, nil
+}
+
+func TestVersionCandidates(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected []string
+	}{
+		{"v2", []string{"v0", "v1", "v3", "v4", "beta"}},
+		{"v1.1", []string{"v0", "v0.0", "v1", "v1.0", "v2", "v2.0", "v3", "v3.0", "v1.2", "v1.3", "beta"}},
+		{"v2021", []string{"v2018", "v2019", "v2020", "v2022", "beta"}},
+		{"2021-06-01", []string{"2018-01-01", "2019-01-01", "2020-01-01", "2021-01-01", "2022-01-01", "beta"}},
+	}
+	for _, test := range tests {
+		if candidates := VersionCandidates(test.version, []string{"beta", test.version}); !reflect.DeepEqual(candidates, test.expected) {
+			t.Errorf("Expected %v for %s, got %v", test.expected, test.version, candidates)
+		}
+	}
+}
+
+func TestHostCandidates(t *testing.T) {
+	expected := []string{"api-dev.example.com:8443", "dev-api.example.com:8443", "dev.api.example.com:8443"}
+	if candidates := HostCandidates("api.example.com:8443", []string{"dev"}); !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("Expected %v, got %v", expected, candidates)
+	}
+	if candidates := HostCandidates("127.0.0.1:8080", []string{"dev"}); len(candidates) != 0 {
+		t.Errorf("Expected no candidates for an IP address, got %v", candidates)
+	}
+}
+
+func TestDiscoverShadowAPIs(t *testing.T) {
+	runner := &shadowRunner{hosts: map[string]func(string) (int, string){
+		"api.example.com": func(path string) (int, string) {
+			switch path {
+			case "/api/v2/users/42", "/api/v1/users/42":
+				return 200, `{"id": 42}`
+			case "/api/v1/orders":
+				return 401, `{"error": "unauthorized"}`
+			}
+			// Unknown versions, including the control version
+			return 400, `{"error": "unknown version"}`
+		},
+		"api-staging.example.com": func(path string) (int, string) {
+			if path == "/api/v2/users/42" {
+				return 200, `{"id": 42, "debug": true}`
+			}
+			return 404, ""
+		},
+	}}
+
+	discovery := NewAPIEndpointDiscovery("https://api.example.com/api/v2")
+	discovery.Endpoints = []*DiscoveredEndpoint{
+		{Method: "GET", URL: "https://api.example.com/api/v2/users/{id}", Path: "/users/{id}", Source: "OpenAPI",
+			Parameters: []*DiscoveredParameter{{Name: "id", In: "path", Example: 42}}},
+		{Method: "DELETE", URL: "https://api.example.com/api/v2/users/{id}", Path: "/users/{id}", Source: "OpenAPI",
+			Parameters: []*DiscoveredParameter{{Name: "id", In: "path", Example: 42}}},
+		{Method: "POST", URL: "https://api.example.com/api/v2/orders", Path: "/orders", Source: "OpenAPI"},
+	}
+	prober := NewShadowAPIProber(runner)
+	prober.VersionNames = []string{"beta"}
+	prober.Environments = []string{"dev", "staging"}
+	shadows := discovery.DiscoverShadowAPIs(prober)
+
+	if len(shadows) != 2 {
+		t.Fatalf("Expected the v1 version and the staging host, got %d shadow APIs", len(shadows))
+	}
+	v1, staging := shadows[0], shadows[1]
+	if v1.Kind != ShadowAPIVersion || v1.BaseURL != "https://api.example.com/api/v1" || v1.Documented != "https://api.example.com/api/v2" || len(v1.Endpoints) != 3 {
+		t.Errorf("Unexpected shadow version %+v", v1)
+	}
+	if staging.Kind != ShadowAPIHost || staging.Host != "api-staging.example.com" || len(staging.Endpoints) != 2 {
+		t.Errorf("Unexpected shadow host %+v", staging)
+	}
+
+	// The endpoints of the shadow APIs are added to the discovery, marked as undocumented
+	if len(discovery.Endpoints) != 8 {
+		t.Fatalf("Expected 8 endpoints, got %d", len(discovery.Endpoints))
+	}
+	endpoint := discovery.Endpoints[3]
+	if endpoint.URL != "https://api.example.com/api/v1/users/{id}" || endpoint.Source != "Shadow" || endpoint.Tags[len(endpoint.Tags)-1] != "shadow-api" {
+		t.Errorf("Unexpected shadow endpoint %+v", endpoint)
+	}
+	if risk := ScoreEndpoint(endpoint); risk.Factors[len(risk.Factors)-1] != "not in a specification (Shadow)" {
+		t.Errorf("Expected the shadow endpoint to be scored as undocumented, got %v", risk.Factors)
+	}
+
+	// Only GET requests are sent, and unreachable hosts are skipped after their first request
+	unreachable := 0
+	for _, request := range runner.requests {
+		if request[:4] != "GET " {
+			t.Errorf("Expected only GET requests, got %s", request)
+		}
+		if u, _ := url.Parse(request[4:]); u.Host == "dev-api.example.com" {
+			unreachable++
+		}
+	}
+	if unreachable != 1 {
+		t.Errorf("Expected a single request to an unreachable host, got %d", unreachable)
+	}
+}
@@ -0,0 +1,285 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// defaultMaxItems is the number of items of an array kept by VisualizeStream if
+// VisOptions.MaxItems is not set
+const defaultMaxItems = 20
+
+// isNDJSONContentType reports whether a content type is a stream of newline-delimited JSON
+// records, such as application/x-ndjson or application/jsonl
+func isNDJSONContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.Contains(contentType, "ndjson") || strings.Contains(contentType, "jsonl") ||
+		strings.Contains(contentType, "json-seq")
+}
+
+// streamError returns the error of a malformed JSON stream
+func streamError(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return api.NewAPIError("Failed to parse JSON data: "+err.Error(), 0)
+}
+
+// skipValue reads the rest of a value of a JSON stream whose first token is tok
+func skipValue(dec *json.Decoder, tok json.Token) error {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := dec.Token()
+		if err != nil {
+			return streamError(err)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// DetectSchemaFromReader detects the schema of a JSON document, or of a stream of JSON
+// records such as NDJSON, without loading it in memory. The schemas of the records are
+// merged, and only the first SampleSize items of arrays are inspected, as in DetectSchema.
+func (d *SchemaDetector) DetectSchemaFromReader(r io.Reader) (*Schema, error) {
+	dec := json.NewDecoder(r)
+	var schema *Schema
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, streamError(err)
+		}
+		field, err := d.streamField(dec, tok, "")
+		if err != nil {
+			return nil, err
+		}
+		schema = d.mergeSchemas(schema, &Schema{
+			Type:       field.Type,
+			Format:     field.Format,
+			Properties: field.Properties,
+			Items:      field.Items,
+		})
+	}
+	if schema == nil {
+		return nil, api.NewAPIError("No JSON data to detect a schema from", 0)
+	}
+	return schema, nil
+}
+
+// streamField infers the schema field of a value of a JSON stream whose first token is tok
+func (d *SchemaDetector) streamField(dec *json.Decoder, tok json.Token, path string) (*SchemaField, error) {
+	switch tok {
+	case json.Delim('{'):
+		field := &SchemaField{Type: TypeObject, Properties: make(SchemaMap)}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, streamError(err)
+			}
+			fieldPath := fmt.Sprintf("%v", key)
+			if path != "" {
+				fieldPath = path + "." + fieldPath
+			}
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, streamError(err)
+			}
+			subField, err := d.streamField(dec, tok, fieldPath)
+			if err != nil {
+				return nil, err
+			}
+			field.Properties[fmt.Sprintf("%v", key)] = *subField
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, streamError(err)
+		}
+		return field, nil
+	case json.Delim('['):
+		field := &SchemaField{Type: TypeArray}
+		for i := 0; dec.More(); i++ {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, streamError(err)
+			}
+			if i > 0 && i >= d.SampleSize {
+				if err := skipValue(dec, tok); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			itemField, err := d.streamField(dec, tok, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			field.Items = d.mergeFields(field.Items, itemField)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, streamError(err)
+		}
+		return field, nil
+	default:
+		return d.inferField(tok, path)
+	}
+}
+
+// DetectSchemaFromReader detects the schema of a JSON or NDJSON response read from r
+func (p *ResponseParser) DetectSchemaFromReader(r io.Reader) (*Schema, error) {
+	if p.format != FormatJSON && p.format != FormatNDJSON && p.format != FormatUnknown {
+		return nil, api.NewAPIError("Response is not in JSON format", 0)
+	}
+
+	detector := NewSchemaDetector()
+	return detector.DetectSchemaFromReader(r)
+}
+
+// ParseNDJSON parses the records of a stream of JSON records read from r one at a time,
+// calling fn with each of them. It stops at the first error returned by fn.
+func (p *ResponseParser) ParseNDJSON(r io.Reader, fn func(record interface{}) error) error {
+	if p.format != FormatJSON && p.format != FormatNDJSON && p.format != FormatUnknown {
+		return api.NewAPIError("Response is not in JSON format", 0)
+	}
+
+	dec := json.NewDecoder(r)
+	for {
+		var record interface{}
+		if err := dec.Decode(&record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return api.NewAPIError("Failed to parse JSON record: "+err.Error(), 0)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// VisualizeStream generates a visualization of a JSON document or of a stream of JSON
+// records read from r, without loading it in memory. The values deeper than MaxDepth and
+// the items of arrays after MaxItems are skipped, and the records of a stream are shown as
+// the items of an array.
+func (v *Visualizer) VisualizeStream(r io.Reader, url string) (string, error) {
+	data, err := v.streamData(r)
+	if err != nil {
+		return "", err
+	}
+	return v.visualizeData(data, url)
+}
+
+// streamData decodes the values of a JSON stream kept by a visualization
+func (v *Visualizer) streamData(r io.Reader) (interface{}, error) {
+	dec := json.NewDecoder(r)
+	records := make([]interface{}, 0)
+	skipped := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, streamError(err)
+		}
+		if len(records) >= v.maxItems() {
+			if err := skipValue(dec, tok); err != nil {
+				return nil, err
+			}
+			skipped++
+			continue
+		}
+		record, err := v.streamValue(dec, tok, 0)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	switch {
+	case len(records) == 0:
+		return nil, api.NewAPIError("No JSON data to visualize", 0)
+	case len(records) == 1 && skipped == 0:
+		return records[0], nil
+	case skipped > 0:
+		records = append(records, fmt.Sprintf("... %d more records", skipped))
+	}
+	return records, nil
+}
+
+// streamValue decodes a value of a JSON stream whose first token is tok, at a depth of the
+// visualization
+func (v *Visualizer) streamValue(dec *json.Decoder, tok json.Token, depth int) (interface{}, error) {
+	if depth > v.options.MaxDepth {
+		if err := skipValue(dec, tok); err != nil {
+			return nil, err
+		}
+		return "...", nil
+	}
+	switch tok {
+	case json.Delim('{'):
+		value := make(map[string]interface{})
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, streamError(err)
+			}
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, streamError(err)
+			}
+			if value[fmt.Sprintf("%v", key)], err = v.streamValue(dec, tok, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, streamError(err)
+		}
+		return value, nil
+	case json.Delim('['):
+		value := make([]interface{}, 0)
+		skipped := 0
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, streamError(err)
+			}
+			if len(value) >= v.maxItems() {
+				if err := skipValue(dec, tok); err != nil {
+					return nil, err
+				}
+				skipped++
+				continue
+			}
+			item, err := v.streamValue(dec, tok, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			value = append(value, item)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, streamError(err)
+		}
+		if skipped > 0 {
+			value = append(value, fmt.Sprintf("... %d more items", skipped))
+		}
+		return value, nil
+	default:
+		return tok, nil
+	}
+}
+
+// maxItems returns the number of items of an array kept by a visualization
+func (v *Visualizer) maxItems() int {
+	if v.options.MaxItems > 0 {
+		return v.options.MaxItems
+	}
+	return defaultMaxItems
+}
@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestDetectSchemaFromReader(t *testing.T) {
+	items := make([]string, 0, 30)
+	for i := 0; i < 30; i++ {
+		items = append(items, fmt.Sprintf(`{"id": %d, "email": "user%d@example.com", "tags": ["a", "b"]}`, i, i))
+	}
+	data := `{"total": 30, "next": null, "users": [` + strings.Join(items, ",") + `]}`
+
+	detector := NewSchemaDetector()
+	expected, err := detector.DetectSchema([]byte(data))
+	if err != nil {
+		t.Fatalf("DetectSchema returned an error: %s", err)
+	}
+	schema, err := detector.DetectSchemaFromReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("DetectSchemaFromReader returned an error: %s", err)
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		got, _ := json.Marshal(schema)
+		want, _ := json.Marshal(expected)
+		t.Errorf("Expected the schema of DetectSchema\n%s, got\n%s", want, got)
+	}
+
+	for _, malformed := range []string{"", `{"id": 1,`, `[1, 2`, `{"id" 1}`} {
+		if _, err := detector.DetectSchemaFromReader(strings.NewReader(malformed)); err == nil {
+			t.Errorf("Expected an error for %q", malformed)
+		}
+	}
+}
+
+func TestDetectSchemaFromReader_NDJSON(t *testing.T) {
+	stream := `{"event": "created", "id": 1, "at": "2023-01-15T14:30:45Z"}
+{"event": "updated", "id": 2.5, "changes": {"name": "new"}}
+
+{"event": "deleted", "id": 3}
+`
+	schema, err := NewSchemaDetector().DetectSchemaFromReader(strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("DetectSchemaFromReader returned an error: %s", err)
+	}
+	if schema.Type != TypeObject {
+		t.Fatalf("Expected an object schema, got %s", schema.Type)
+	}
+	if schema.Properties["id"].Type != TypeNumber {
+		t.Errorf("Expected the id to be a number, got %s", schema.Properties["id"].Type)
+	}
+	for _, name := range []string{"event", "at", "changes"} {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("Expected the property %s to be merged from the records", name)
+		}
+	}
+	if schema.Properties["changes"].Properties["name"].Type != TypeString {
+		t.Errorf("Expected the nested properties of a record to be detected")
+	}
+
+	parser := NewResponseParser("application/x-ndjson")
+	if parser.format != FormatNDJSON {
+		t.Fatalf("Expected the NDJSON format to be detected")
+	}
+	schema, err = parser.DetectSchema([]byte(stream))
+	if err != nil {
+		t.Fatalf("DetectSchema returned an error: %s", err)
+	}
+	if _, ok := schema.Properties["changes"]; !ok {
+		t.Errorf("Expected DetectSchema to merge the records of an NDJSON response")
+	}
+}
+
+func TestResponseParser_ParseNDJSON(t *testing.T) {
+	parser := NewResponseParser("application/jsonl")
+	events := make([]string, 0)
+	err := parser.ParseNDJSON(strings.NewReader("{\"event\": \"a\"}\n{\"event\": \"b\"}\n"), func(record interface{}) error {
+		events = append(events, record.(map[string]interface{})["event"].(string))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseNDJSON returned an error: %s", err)
+	}
+	if !reflect.DeepEqual(events, []string{"a", "b"}) {
+		t.Errorf("Expected the records a and b, got %v", events)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = parser.ParseNDJSON(strings.NewReader("1\n2\n3\n"), func(record interface{}) error {
+		count++
+		return stop
+	})
+	if err != stop || count != 1 {
+		t.Errorf("Expected ParseNDJSON to stop at the first error, got %v after %d records", err, count)
+	}
+	if err := parser.ParseNDJSON(strings.NewReader("{\"event\": \"a\"}\n{"), func(interface{}) error { return nil }); err == nil {
+		t.Errorf("Expected an error for a truncated record")
+	}
+	if err := NewResponseParser("application/xml").ParseNDJSON(strings.NewReader("1"), func(interface{}) error { return nil }); err == nil {
+		t.Errorf("Expected an error for an XML response")
+	}
+}
+
+func TestVisualizeStream(t *testing.T) {
+	visualizer := NewVisualizer(&VisOptions{Format: VisFormatJSON, MaxDepth: 2, MaxItems: 2})
+	output, err := visualizer.VisualizeStream(strings.NewReader(`{"items": [1, 2, 3, 4, 5], "deep": {"a": {"b": {"c": 1}}}}`), "https://api.example.com/items")
+	if err != nil {
+		t.Fatalf("VisualizeStream returned an error: %s", err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &data); err != nil {
+		t.Fatalf("Expected a JSON visualization: %s", err)
+	}
+	expected := map[string]interface{}{
+		"items": []interface{}{1.0, 2.0, "... 3 more items"},
+		"deep":  map[string]interface{}{"a": map[string]interface{}{"b": "..."}},
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected %v, got %v", expected, data)
+	}
+
+	// The records of an NDJSON response are the items of an array
+	resp := &ffuf.Response{
+		ContentType: "application/x-ndjson",
+		Data:        []byte("{\"id\": 1}\n{\"id\": 2}\n{\"id\": 3}\n"),
+		Request:     &ffuf.Request{Url: "https://api.example.com/events"},
+	}
+	output, err = visualizer.VisualizeResponse(resp)
+	if err != nil {
+		t.Fatalf("VisualizeResponse returned an error: %s", err)
+	}
+	var records []interface{}
+	if err := json.Unmarshal([]byte(output), &records); err != nil {
+		t.Fatalf("Expected a JSON array of records: %s", err)
+	}
+	if len(records) != 3 || records[2] != "... 1 more records" {
+		t.Errorf("Expected 2 records and the count of the skipped ones, got %v", records)
+	}
+
+	visualizer.options.Format = VisFormatDOT
+	if output, err = visualizer.VisualizeResponse(resp); err != nil || !strings.Contains(output, "digraph") {
+		t.Errorf("Expected a DOT visualization of the records, got %q (%v)", output, err)
+	}
+	if _, err := visualizer.VisualizeStream(strings.NewReader(`{"items": [1, 2`), ""); err == nil {
+		t.Errorf("Expected an error for a truncated document")
+	}
+}
@@ -0,0 +1,440 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Sizes of the SVG drawings, in pixels
+const (
+	svgCharWidth  = 7.0
+	svgLineHeight = 16.0
+	svgPadding    = 10.0
+	svgNodeGap    = 20.0
+	svgRankGap    = 60.0
+	svgMargin     = 20.0
+	svgTitleSize  = 30.0
+)
+
+// svgNode is a node of an svgGraph
+type svgNode struct {
+	id     string
+	lines  []string
+	fill   string
+	stroke string
+	rank   int
+	order  float64
+	x, y   float64
+	width  float64
+	height float64
+}
+
+// svgEdge is an edge of an svgGraph
+type svgEdge struct {
+	from   *svgNode
+	to     *svgNode
+	label  string
+	color  string
+	dashed bool
+}
+
+// svgGraph is a directed graph drawn as SVG without external tools. Nodes are laid out
+// from left to right in ranks, the longest path from a source deciding the rank of a node.
+type svgGraph struct {
+	nodes []*svgNode
+	edges []*svgEdge
+	index map[string]*svgNode
+}
+
+// newSVGGraph creates an empty graph
+func newSVGGraph() *svgGraph {
+	return &svgGraph{index: make(map[string]*svgNode)}
+}
+
+// addNode adds a node with a line per label entry, or returns the existing node with the ID
+func (g *svgGraph) addNode(id string, lines []string, fill, stroke string) *svgNode {
+	if node, ok := g.index[id]; ok {
+		return node
+	}
+	if fill == "" {
+		fill = "#e3f2fd"
+	}
+	if stroke == "" {
+		stroke = "#1976d2"
+	}
+	node := &svgNode{id: id, lines: lines, fill: fill, stroke: stroke}
+	g.index[id] = node
+	g.nodes = append(g.nodes, node)
+	return node
+}
+
+// addEdge adds an edge between two nodes added before
+func (g *svgGraph) addEdge(from, to, label, color string, dashed bool) {
+	if g.index[from] == nil || g.index[to] == nil {
+		return
+	}
+	if color == "" {
+		color = "#555555"
+	}
+	g.edges = append(g.edges, &svgEdge{from: g.index[from], to: g.index[to], label: label, color: color, dashed: dashed})
+}
+
+// layout assigns the rank, order and position of the nodes
+func (g *svgGraph) layout() {
+	// Edges closing a cycle are ignored when ranking the nodes
+	forward := g.acyclicEdges()
+	incoming := make(map[*svgNode]int)
+	outgoing := make(map[*svgNode][]*svgNode)
+	for _, edge := range forward {
+		incoming[edge.to]++
+		outgoing[edge.from] = append(outgoing[edge.from], edge.to)
+	}
+	queue := make([]*svgNode, 0)
+	for _, node := range g.nodes {
+		node.rank = 0
+		if incoming[node] == 0 {
+			queue = append(queue, node)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range outgoing[node] {
+			if node.rank+1 > next.rank {
+				next.rank = node.rank + 1
+			}
+			if incoming[next]--; incoming[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// Nodes are ordered in their rank by the average order of their neighbours
+	ranks := make([][]*svgNode, 0)
+	for i, node := range g.nodes {
+		for len(ranks) <= node.rank {
+			ranks = append(ranks, nil)
+		}
+		node.order = float64(i)
+		ranks[node.rank] = append(ranks[node.rank], node)
+	}
+	for sweep := 0; sweep < 4; sweep++ {
+		for _, rank := range ranks {
+			for _, node := range rank {
+				sum, count := 0.0, 0
+				for _, edge := range forward {
+					if edge.to == node && edge.from.rank < node.rank {
+						sum, count = sum+edge.from.order, count+1
+					} else if sweep%2 == 1 && edge.from == node && edge.to.rank > node.rank {
+						sum, count = sum+edge.to.order, count+1
+					}
+				}
+				if count > 0 {
+					node.order = sum / float64(count)
+				}
+			}
+			sort.SliceStable(rank, func(i, j int) bool { return rank[i].order < rank[j].order })
+			for i, node := range rank {
+				node.order = float64(i)
+			}
+		}
+	}
+
+	// Ranks are columns, whose nodes are stacked and centered vertically
+	labelWidth := 0.0
+	for _, edge := range g.edges {
+		labelWidth = math.Max(labelWidth, svgTextWidth(edge.label))
+	}
+	gap := svgRankGap + math.Min(labelWidth, 200)
+	heights := make([]float64, len(ranks))
+	maxHeight := 0.0
+	for i, rank := range ranks {
+		for _, node := range rank {
+			width := 0.0
+			for _, line := range node.lines {
+				width = math.Max(width, svgTextWidth(line))
+			}
+			node.width = width + 2*svgPadding
+			node.height = float64(len(node.lines))*svgLineHeight + svgPadding
+			heights[i] += node.height + svgNodeGap
+		}
+		maxHeight = math.Max(maxHeight, heights[i])
+	}
+	x := svgMargin
+	for i, rank := range ranks {
+		y := svgMargin + (maxHeight-heights[i])/2
+		width := 0.0
+		for _, node := range rank {
+			node.x, node.y = x, y
+			y += node.height + svgNodeGap
+			width = math.Max(width, node.width)
+		}
+		x += width + gap
+	}
+}
+
+// acyclicEdges returns the edges that do not close a cycle, in a depth-first search
+func (g *svgGraph) acyclicEdges() []*svgEdge {
+	state := make(map[*svgNode]int)
+	outgoing := make(map[*svgNode][]*svgEdge)
+	for _, edge := range g.edges {
+		outgoing[edge.from] = append(outgoing[edge.from], edge)
+	}
+	backward := make(map[*svgEdge]bool)
+	var visit func(node *svgNode)
+	visit = func(node *svgNode) {
+		state[node] = 1
+		for _, edge := range outgoing[node] {
+			switch state[edge.to] {
+			case 0:
+				visit(edge.to)
+			case 1:
+				backward[edge] = true
+			}
+		}
+		state[node] = 2
+	}
+	for _, node := range g.nodes {
+		if state[node] == 0 {
+			visit(node)
+		}
+	}
+	edges := make([]*svgEdge, 0, len(g.edges))
+	for _, edge := range g.edges {
+		if !backward[edge] {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// render lays out the graph and draws it as an SVG document
+func (g *svgGraph) render(title string) string {
+	g.layout()
+	offset := 0.0
+	if title != "" {
+		offset = svgTitleSize
+	}
+	width, height := 2*svgMargin, 2*svgMargin
+	for _, node := range g.nodes {
+		node.y += offset
+		width = math.Max(width, node.x+node.width+svgMargin)
+		height = math.Max(height, node.y+node.height+svgMargin)
+	}
+	// Edges between nodes of the same or a previous rank are drawn below the nodes
+	for _, edge := range g.edges {
+		if edge.to.rank <= edge.from.rank {
+			height = math.Max(height, math.Max(edge.from.y+edge.from.height, edge.to.y+edge.to.height)+2*svgRankGap/3+svgMargin)
+		}
+	}
+
+	var buf bytes.Buffer
+	svgHeader(&buf, width, height, title)
+	colors := make([]string, 0)
+	for _, edge := range g.edges {
+		if !contains(colors, edge.color) {
+			colors = append(colors, edge.color)
+		}
+	}
+	svgMarkers(&buf, colors)
+
+	for _, edge := range g.edges {
+		x1, y1 := edge.from.x+edge.from.width, edge.from.y+edge.from.height/2
+		x2, y2 := edge.to.x, edge.to.y+edge.to.height/2
+		c1x, c1y, c2x, c2y := x1+svgRankGap/2, y1, x2-svgRankGap/2, y2
+		if edge.to.rank <= edge.from.rank {
+			x1, y1 = edge.from.x+edge.from.width/2, edge.from.y+edge.from.height
+			x2, y2 = edge.to.x+edge.to.width/2, edge.to.y+edge.to.height
+			bottom := math.Max(y1, y2) + 2*svgRankGap/3
+			c1x, c1y, c2x, c2y = x1, bottom, x2, bottom
+		}
+		dash := ""
+		if edge.dashed {
+			dash = ` stroke-dasharray="6,4"`
+		}
+		buf.WriteString(fmt.Sprintf(`<path d="M %.1f %.1f C %.1f %.1f, %.1f %.1f, %.1f %.1f" fill="none" stroke="%s" stroke-width="1.5"%s marker-end="url(#%s)"/>`+"\n",
+			x1, y1, c1x, c1y, c2x, c2y, x2, y2, svgEscape(edge.color), dash, svgMarkerID(edge.color)))
+		if edge.label != "" {
+			// The label is drawn at the middle of the curve
+			mx, my := (x1+3*c1x+3*c2x+x2)/8, (y1+3*c1y+3*c2y+y2)/8
+			w := svgTextWidth(edge.label) + 6
+			buf.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="white" opacity="0.85"/>`+"\n", mx-w/2, my-svgLineHeight/2, w, svgLineHeight))
+			buf.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" fill="%s">%s</text>`+"\n", mx, my+4, svgEscape(edge.color), svgEscape(edge.label)))
+		}
+	}
+
+	for _, node := range g.nodes {
+		buf.WriteString("<g class=\"node\">\n")
+		buf.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="6" fill="%s" stroke="%s"/>`+"\n",
+			node.x, node.y, node.width, node.height, svgEscape(node.fill), svgEscape(node.stroke)))
+		for i, line := range node.lines {
+			weight := ""
+			if i == 0 && len(node.lines) > 1 {
+				weight = ` font-weight="bold"`
+			}
+			buf.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle"%s>%s</text>`+"\n",
+				node.x+node.width/2, node.y+svgPadding/2+float64(i)*svgLineHeight+12, weight, svgEscape(line)))
+		}
+		buf.WriteString("</g>\n")
+	}
+	buf.WriteString("</svg>\n")
+	return buf.String()
+}
+
+// svgHeader writes the root element and the title of an SVG document
+func svgHeader(buf *bytes.Buffer, width, height float64, title string) {
+	buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="Arial, sans-serif" font-size="12">`+"\n",
+		width, height, width, height))
+	if title != "" {
+		buf.WriteString(fmt.Sprintf("<title>%s</title>\n", svgEscape(title)))
+		buf.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" font-size="16" font-weight="bold">%s</text>`+"\n", svgMargin, svgMargin+6, svgEscape(title)))
+	}
+}
+
+// svgMarkers writes the arrowheads of the colors of the edges. Their IDs only depend on the
+// color, so that several drawings can be embedded in a page.
+func svgMarkers(buf *bytes.Buffer, colors []string) {
+	buf.WriteString("<defs>\n")
+	for _, color := range colors {
+		buf.WriteString(fmt.Sprintf(`<marker id="%s" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="%s"/></marker>`+"\n",
+			svgMarkerID(color), svgEscape(color)))
+	}
+	buf.WriteString("</defs>\n")
+}
+
+// svgMarkerID returns the ID of the arrowhead of a color
+func svgMarkerID(color string) string {
+	return "ffuf-arrow-" + sanitizeID(color)
+}
+
+// svgTextWidth estimates the width of a line of text
+func svgTextWidth(s string) float64 {
+	return float64(len([]rune(s))) * svgCharWidth
+}
+
+// svgEscape escapes text and attribute values of an SVG document
+func svgEscape(s string) string {
+	return template.HTMLEscapeString(s)
+}
+
+// svgClassStyle returns the fill and stroke colors of a Mermaid class definition,
+// such as "fill:#c8e6c9,stroke:#2e7d32"
+func svgClassStyle(style string) (string, string) {
+	fill, stroke := "", ""
+	for _, property := range strings.Split(style, ",") {
+		parts := strings.SplitN(property, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(parts[0]) {
+		case "fill":
+			fill = strings.TrimSpace(parts[1])
+		case "stroke":
+			stroke = strings.TrimSpace(parts[1])
+		}
+	}
+	return fill, stroke
+}
+
+// generateSVGVisualization generates an SVG tree of the data of a response
+func (v *Visualizer) generateSVGVisualization(data interface{}, url string) (string, error) {
+	graph := newSVGGraph()
+	graph.addNode("root", []string{url}, "#c8e6c9", "#2e7d32")
+	id := 0
+	var add func(parent string, data interface{}, depth int)
+	add = func(parent string, data interface{}, depth int) {
+		if depth > v.options.MaxDepth {
+			return
+		}
+		child := func(label string, fill string) string {
+			id++
+			node := fmt.Sprintf("n%d", id)
+			graph.addNode(node, []string{label}, fill, "")
+			graph.addEdge(parent, node, "", "", false)
+			return node
+		}
+		switch val := data.(type) {
+		case map[string]interface{}:
+			// Keys are sorted, so that the same data is drawn the same way
+			keys := make([]string, 0, len(val))
+			for key := range val {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				add(child(key, ""), val[key], depth+1)
+			}
+		case []interface{}:
+			for i, value := range val {
+				add(child(fmt.Sprintf("[%d]", i), ""), value, depth+1)
+			}
+		default:
+			if v.options.IncludeValues {
+				child(fmt.Sprintf("%v", val), "#fffde7")
+			}
+		}
+	}
+	add("root", data, 0)
+	return graph.render(v.options.Title), nil
+}
+
+// generateSVGCorrelationVisualization generates an SVG graph of correlations
+func (v *Visualizer) generateSVGCorrelationVisualization(correlations []Correlation) (string, error) {
+	graph := newSVGGraph()
+	for _, correlation := range correlations {
+		graph.addNode(correlation.SourcePath, []string{correlation.SourcePath}, "", "")
+		graph.addNode(correlation.TargetPath, []string{correlation.TargetPath}, "", "")
+		graph.addEdge(correlation.SourcePath, correlation.TargetPath, fmt.Sprintf("%s %d%%", correlation.Type, correlation.Confidence), confidenceColor(correlation.Confidence), false)
+	}
+	return graph.render(v.options.Title), nil
+}
+
+// generateSVGSchemaVisualization generates an SVG tree of the properties of a schema
+func (v *Visualizer) generateSVGSchemaVisualization(schema *Schema) (string, error) {
+	graph := newSVGGraph()
+	graph.addNode("root", []string{"Schema"}, "#c8e6c9", "#2e7d32")
+	var add func(parent string, properties SchemaMap)
+	add = func(parent string, properties SchemaMap) {
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			field := properties[name]
+			label := fmt.Sprintf("%s: %s", name, field.Type)
+			if field.Format != "" {
+				label += fmt.Sprintf(" (%s)", field.Format)
+			}
+			if field.Required {
+				label += " *"
+			}
+			node := parent + "/" + name
+			graph.addNode(node, []string{label}, "", "")
+			graph.addEdge(parent, node, "", "", false)
+			add(node, field.Properties)
+		}
+	}
+	add("root", schema.Properties)
+	return graph.render(v.options.Title), nil
+}
+
+// generateSVGResourceGraph generates an SVG graph of resources
+func (v *Visualizer) generateSVGResourceGraph(graph *ResourceGraph) string {
+	svg := newSVGGraph()
+	for _, node := range graph.Nodes {
+		svg.addNode(node.Path, resourceLabel(node), "", "")
+	}
+	for _, edge := range graph.Edges {
+		if edge.Type == ResourceEdgeNested {
+			svg.addEdge(edge.From, edge.To, "", "", false)
+		} else {
+			svg.addEdge(edge.From, edge.To, fmt.Sprintf("%s %d%%", edge.Type, edge.Confidence), confidenceColor(edge.Confidence), true)
+		}
+	}
+	return svg.render(v.options.Title)
+}
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// svgRect is a node of an SVG drawing
+type svgRect struct {
+	x, y, width, height float64
+}
+
+// parseSVG checks that an SVG drawing is well-formed XML and returns the boxes of its nodes
+func parseSVG(t *testing.T, svg string) []svgRect {
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Expected well-formed XML: %s\n%s", err, svg)
+		}
+	}
+	if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`) {
+		t.Errorf("Expected an SVG root element")
+	}
+	rects := make([]svgRect, 0)
+	pattern := regexp.MustCompile(`<g class="node">\n<rect x="([\d.]+)" y="([\d.]+)" width="([\d.]+)" height="([\d.]+)"`)
+	for _, match := range pattern.FindAllStringSubmatch(svg, -1) {
+		var rect [4]float64
+		for i := range rect {
+			rect[i], _ = strconv.ParseFloat(match[i+1], 64)
+		}
+		rects = append(rects, svgRect{rect[0], rect[1], rect[2], rect[3]})
+	}
+	for i := range rects {
+		for j := i + 1; j < len(rects); j++ {
+			a, b := rects[i], rects[j]
+			if a.x < b.x+b.width && b.x < a.x+a.width && a.y < b.y+b.height && b.y < a.y+a.height {
+				t.Errorf("Expected nodes not to overlap: %v and %v", a, b)
+			}
+		}
+	}
+	return rects
+}
+
+func TestSVGGraph(t *testing.T) {
+	graph := newSVGGraph()
+	graph.addNode("a", []string{"<users>", "GET & POST"}, "", "")
+	graph.addNode("b", []string{"orders"}, "", "")
+	graph.addNode("c", []string{"items"}, "", "")
+	graph.addEdge("a", "b", "nested", "", false)
+	graph.addEdge("b", "c", "id 90%", "green", true)
+	graph.addEdge("c", "a", "cycle", "red", false)
+	graph.addEdge("a", "missing", "", "", false)
+
+	svg := graph.render(`Resources "A" & B`)
+	rects := parseSVG(t, svg)
+	if len(rects) != 3 {
+		t.Fatalf("Expected 3 nodes, got %d", len(rects))
+	}
+	// The cycle is broken, so the nodes are in 3 ranks from left to right
+	if !(rects[0].x < rects[1].x && rects[1].x < rects[2].x) {
+		t.Errorf("Expected the nodes to be ranked from left to right, got %v", rects)
+	}
+	for _, e := range []string{
+		"<title>Resources &#34;A&#34; &amp; B</title>",
+		"&lt;users&gt;",
+		"GET &amp; POST",
+		`stroke-dasharray="6,4" marker-end="url(#ffuf-arrow-green)"`,
+		`<marker id="ffuf-arrow-red"`,
+	} {
+		if !strings.Contains(svg, e) {
+			t.Errorf("Expected %q in the SVG drawing", e)
+		}
+	}
+	if len(regexp.MustCompile(`<path d="M [\d.]+ [\d.]+ C`).FindAllString(svg, -1)) != 3 {
+		t.Errorf("Expected an edge per pair of existing nodes")
+	}
+}
+
+func TestVisualizeSVG(t *testing.T) {
+	endpoints := []*DiscoveredEndpoint{
+		{Method: "GET", Path: "/users"},
+		{Method: "POST", Path: "/users"},
+		{Method: "GET", Path: "/users/{id}"},
+		{Method: "GET", Path: "/health"},
+	}
+	v := NewVisualizer(&VisOptions{Format: VisFormatSVG, ClassStyles: map[string]string{"tested": "fill:#c8e6c9,stroke:#2e7d32"}})
+	svg, err := v.VisualizeEndpoints(endpoints, map[string]string{"GET /users/{id}": "tested"})
+	if err != nil {
+		t.Fatalf("VisualizeEndpoints returned an error: %s", err)
+	}
+	if len(parseSVG(t, svg)) != 8 || strings.Count(svg, `fill="#c8e6c9" stroke="#2e7d32"`) != 2 {
+		t.Errorf("Expected a node per segment and method, and the style of the tested endpoint:\n%s", svg)
+	}
+
+	svg, err = v.VisualizeResourceGraph(newTestResourceGraph())
+	if err != nil || len(parseSVG(t, svg)) != 4 || !strings.Contains(svg, "item: DELETE GET") {
+		t.Errorf("Expected the resources in the SVG drawing: %v", err)
+	}
+
+	svg, err = v.VisualizeCorrelations([]Correlation{
+		{Type: CorrelationTypeID, SourcePath: "$.id", TargetPath: "$.user_id", Confidence: 85},
+		{Type: CorrelationTypeReference, SourcePath: "$.id", TargetPath: "$.links.self", Confidence: 40},
+	})
+	if err != nil || len(parseSVG(t, svg)) != 3 || !strings.Contains(svg, "reference 40%") {
+		t.Errorf("Expected the correlations in the SVG drawing: %v", err)
+	}
+
+	resp := &ffuf.Response{ContentType: "application/json", Data: []byte(`{"b":{"c":[1,2]},"a":"x"}`), Request: &ffuf.Request{Url: "https://api.example.com/"}}
+	v = NewVisualizer(&VisOptions{Format: VisFormatSVG, MaxDepth: 10, IncludeValues: true})
+	svg, err = v.VisualizeResponse(resp)
+	if err != nil || len(parseSVG(t, svg)) != 9 || strings.Index(svg, ">a<") > strings.Index(svg, ">b<") {
+		t.Errorf("Expected the sorted keys and values of the response in the SVG drawing: %v\n%s", err, svg)
+	}
+
+	schema := &Schema{Properties: SchemaMap{"id": {Type: "integer", Required: true}, "owner": {Type: "object", Properties: SchemaMap{"name": {Type: "string"}}}}}
+	svg, err = v.VisualizeSchema(schema)
+	if err != nil || len(parseSVG(t, svg)) != 4 || !strings.Contains(svg, "id: integer *") {
+		t.Errorf("Expected the properties of the schema in the SVG drawing: %v", err)
+	}
+
+	svg, err = v.VisualizeSequence(newTestSequenceSteps())
+	if err != nil {
+		t.Fatalf("VisualizeSequence returned an error: %s", err)
+	}
+	parseSVG(t, svg)
+	for _, e := range []string{">Client<", ">audit.example.com<", ">1. POST /users<", ">404 Not Found (failed)<", ">4. DELETE /users/${id} skipped<", `marker-end="url(#ffuf-arrow-_d32f2f)"`} {
+		if !strings.Contains(svg, e) {
+			t.Errorf("Expected %q in the SVG sequence diagram", e)
+		}
+	}
+}
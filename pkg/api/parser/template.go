@@ -0,0 +1,612 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"gopkg.in/yaml.v3"
+)
+
+// PayloadPlaceholder is replaced by the current payload in template headers
+const PayloadPlaceholder = "{{payload}}"
+
+// Generator modes of template definitions
+const (
+	// TemplateModePayloads inserts the payloads of the template into the parameters
+	TemplateModePayloads = "payloads"
+	// TemplateModeMethodOverride sends the request with method override headers, query
+	// parameters and body fields, and with uncommon verbs
+	TemplateModeMethodOverride = "method-override"
+	// TemplateModeBoundary inserts values violating the schema constraints of the parameters
+	TemplateModeBoundary = "boundary"
+	// TemplateModeIdempotency sends POST and PATCH requests again with the same idempotency
+	// key, and with the same key and a different body
+	TemplateModeIdempotency = "idempotency"
+	// TemplateModeCORS sends CORS preflight requests from allowed and untrusted origins, checking
+	// the responses against the declared CORS policy
+	TemplateModeCORS = "cors"
+	// TemplateModeCache sends GET requests with cache buster parameters and unkeyed headers,
+	// and authenticated GET requests, checking how their responses are cached
+	TemplateModeCache = "cache"
+)
+
+// APITestTemplateDefinition represents a declarative test case template loaded from a YAML or JSON file
+type APITestTemplateDefinition struct {
+	// Unique identifier of the template
+	ID string `yaml:"id" json:"id"`
+	// Descriptive information of the template
+	Info APITestTemplateInfo `yaml:"info" json:"info"`
+	// Endpoints and parameters the template applies to
+	Match APITestTemplateMatch `yaml:"match" json:"match"`
+	// Generator mode of the template ("payloads", "method-override", "boundary", "idempotency",
+	// "cors" or "cache", defaults to "payloads")
+	Mode string `yaml:"mode" json:"mode"`
+	// Methods the method-override mode asks for (defaults to the method of the endpoint)
+	Methods []string `yaml:"methods" json:"methods"`
+	// Kinds of schema constraints the boundary mode violates (defaults to all but "type")
+	Constraints []string `yaml:"constraints" json:"constraints"`
+	// Header of the idempotency key in the idempotency mode (defaults to Idempotency-Key)
+	IdempotencyHeader string `yaml:"idempotency-header" json:"idempotency-header"`
+	// CORS policy the cors mode checks preflight responses against (defaults to allowing no
+	// cross-origin requests)
+	CORS *CORSPolicy `yaml:"cors" json:"cors"`
+	// Query parameter making the requests of the cache mode unique (defaults to ffufcb)
+	CacheBuster string `yaml:"cache-buster" json:"cache-buster"`
+	// Headers the cache mode tries to poison the cache with, carrying a canary host (defaults
+	// to the host and scheme headers of the header catalog)
+	UnkeyedHeaders []string `yaml:"unkeyed-headers" json:"unkeyed-headers"`
+	// Payloads inserted into the matching parameters
+	Payloads []string `yaml:"payloads" json:"payloads"`
+	// Headers added to every request, {{payload}} is replaced by the current payload
+	Headers map[string]string `yaml:"headers" json:"headers"`
+	// Expected status code (defaults to 400 in the boundary mode)
+	ExpectedStatus int `yaml:"expected-status" json:"expected-status"`
+	// Matchers asserting the expected response
+	Matchers []*APITestMatcher `yaml:"matchers" json:"matchers"`
+	// How matchers are combined ("and" or "or", defaults to "or")
+	MatchersCondition string `yaml:"matchers-condition" json:"matchers-condition"`
+}
+
+// APITestTemplateInfo contains descriptive information of a template definition
+type APITestTemplateInfo struct {
+	// Name of the template
+	Name string `yaml:"name" json:"name"`
+	// Description of the template
+	Description string `yaml:"description" json:"description"`
+	// Category of the template (defaults to "security", "negative" in the boundary mode,
+	// "idempotency" in the idempotency mode and "cache" in the cache mode)
+	Category string `yaml:"category" json:"category"`
+	// Priority of the template (1-5, defaults to 2)
+	Priority int `yaml:"priority" json:"priority"`
+}
+
+// APITestTemplateMatch selects the endpoints and parameters a template definition applies to
+type APITestTemplateMatch struct {
+	// HTTP method pattern (defaults to "*")
+	Method string `yaml:"method" json:"method"`
+	// Path pattern (defaults to "*")
+	Path string `yaml:"path" json:"path"`
+	// Parameter name patterns, supporting * and ? wildcards
+	Params []string `yaml:"params" json:"params"`
+	// Parameter locations to insert payloads into (defaults to all)
+	In []string `yaml:"in" json:"in"`
+}
+
+// APITestMatcher asserts a property of the response of a test case.
+// A negative matcher matches when the response does not have the property,
+// which lets templates fail test cases on indicators of a vulnerability.
+type APITestMatcher struct {
+	// Type of the matcher ("status", "word" or "regex")
+	Type string `yaml:"type" json:"type"`
+	// Part of the response to match ("body", "header" or "all", defaults to "body")
+	Part string `yaml:"part" json:"part"`
+	// Status codes for status matchers
+	Status []int `yaml:"status" json:"status"`
+	// Words for word matchers
+	Words []string `yaml:"words" json:"words"`
+	// Regular expressions for regex matchers
+	Regex []string `yaml:"regex" json:"regex"`
+	// How the values of the matcher are combined ("and" or "or", defaults to "or")
+	Condition string `yaml:"condition" json:"condition"`
+	// Whether the result of the matcher is inverted
+	Negative bool `yaml:"negative" json:"negative"`
+
+	compiled []*regexp.Regexp
+}
+
+// compile validates the matcher and compiles its regular expressions
+func (m *APITestMatcher) compile() error {
+	switch m.Type {
+	case "status":
+		if len(m.Status) == 0 {
+			return api.NewAPIError("Status matcher has no status codes", 0)
+		}
+	case "word":
+		if len(m.Words) == 0 {
+			return api.NewAPIError("Word matcher has no words", 0)
+		}
+	case "regex":
+		if len(m.Regex) == 0 {
+			return api.NewAPIError("Regex matcher has no expressions", 0)
+		}
+		m.compiled = make([]*regexp.Regexp, 0, len(m.Regex))
+		for _, expr := range m.Regex {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return api.NewAPIError(fmt.Sprintf("Invalid matcher regex '%s': %s", expr, err.Error()), 0)
+			}
+			m.compiled = append(m.compiled, re)
+		}
+	default:
+		return api.NewAPIError(fmt.Sprintf("Unknown matcher type '%s'", m.Type), 0)
+	}
+
+	switch m.Part {
+	case "", "body", "header", "all":
+	default:
+		return api.NewAPIError(fmt.Sprintf("Unknown matcher part '%s'", m.Part), 0)
+	}
+	if !isCondition(m.Condition) {
+		return api.NewAPIError(fmt.Sprintf("Unknown matcher condition '%s'", m.Condition), 0)
+	}
+	return nil
+}
+
+// Match returns whether the response matches
+func (m *APITestMatcher) Match(resp *ffuf.Response) bool {
+	var results []bool
+	switch m.Type {
+	case "status":
+		for _, status := range m.Status {
+			results = append(results, resp.StatusCode == int64(status))
+		}
+	case "word":
+		content := matcherContent(resp, m.Part)
+		for _, word := range m.Words {
+			results = append(results, strings.Contains(content, word))
+		}
+	case "regex":
+		content := matcherContent(resp, m.Part)
+		if m.compiled != nil {
+			for _, re := range m.compiled {
+				results = append(results, re.MatchString(content))
+			}
+			break
+		}
+		// Matchers that were not loaded from a template are compiled on use
+		for _, expr := range m.Regex {
+			matched, err := regexp.MatchString(expr, content)
+			results = append(results, err == nil && matched)
+		}
+	}
+
+	return combineResults(results, m.Condition) != m.Negative
+}
+
+// MatchResponse returns whether the response satisfies the matchers of the test case
+func (t *APITestCase) MatchResponse(resp *ffuf.Response) bool {
+	results := make([]bool, 0, len(t.Matchers))
+	for _, matcher := range t.Matchers {
+		results = append(results, matcher.Match(resp))
+	}
+	return len(results) == 0 || combineResults(results, t.MatchersCondition)
+}
+
+// matcherContent returns the part of the response a matcher is evaluated against
+func matcherContent(resp *ffuf.Response, part string) string {
+	var headers strings.Builder
+	if part == "header" || part == "all" {
+		for name, values := range resp.Headers {
+			for _, value := range values {
+				headers.WriteString(name + ": " + value + "\n")
+			}
+		}
+	}
+
+	switch part {
+	case "header":
+		return headers.String()
+	case "all":
+		return headers.String() + "\n" + string(resp.Data)
+	}
+	return string(resp.Data)
+}
+
+// combineResults combines matcher results with an "and" or "or" condition
+func combineResults(results []bool, condition string) bool {
+	if len(results) == 0 {
+		return false
+	}
+	for _, result := range results {
+		if condition == "and" && !result {
+			return false
+		}
+		if condition != "and" && result {
+			return true
+		}
+	}
+	return condition == "and"
+}
+
+// isCondition returns whether a string is a valid matcher condition
+func isCondition(condition string) bool {
+	return condition == "" || condition == "and" || condition == "or"
+}
+
+// ParseTestCaseTemplate parses a template definition from YAML or JSON data
+func ParseTestCaseTemplate(data []byte, format string) (*APITestCaseTemplate, error) {
+	definition := &APITestTemplateDefinition{}
+	switch format {
+	case "json":
+		if err := json.Unmarshal(data, definition); err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Failed to parse JSON template: %s", err.Error()), 0)
+		}
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(data, definition); err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Failed to parse YAML template: %s", err.Error()), 0)
+		}
+	default:
+		return nil, api.NewAPIError(fmt.Sprintf("Unsupported template format: %s", format), 0)
+	}
+
+	return definition.Template()
+}
+
+// LoadTestCaseTemplate loads a template definition from a YAML or JSON file
+func LoadTestCaseTemplate(filePath string) (*APITestCaseTemplate, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to read template file: %s", err.Error()), 0)
+	}
+
+	template, err := ParseTestCaseTemplate(data, strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), "."))
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("%s: %s", filePath, err.Error()), 0)
+	}
+	return template, nil
+}
+
+// LoadTemplatesFromDirectory loads all YAML and JSON template definitions from a directory
+// and its subdirectories and adds them to the generator. Note that the default templates
+// are only added by GenerateTestCases when no templates are present, so they need to be
+// added explicitly with AddDefaultTemplates to be used along with the loaded templates.
+func (g *APITestGenerator) LoadTemplatesFromDirectory(dirPath string) error {
+	templates := make([]*APITestCaseTemplate, 0)
+	err := filepath.Walk(dirPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		switch strings.ToLower(filepath.Ext(filePath)) {
+		case ".yaml", ".yml", ".json":
+			template, err := LoadTestCaseTemplate(filePath)
+			if err != nil {
+				return err
+			}
+			templates = append(templates, template)
+		}
+		return nil
+	})
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to load templates: %s", err.Error()), 0)
+	}
+
+	for _, template := range templates {
+		g.AddTemplate(template)
+	}
+	return nil
+}
+
+// Template validates the definition and converts it into a test case template
+func (d *APITestTemplateDefinition) Template() (*APITestCaseTemplate, error) {
+	name := d.Info.Name
+	if name == "" {
+		name = d.ID
+	}
+	if name == "" {
+		return nil, api.NewAPIError("Template has no id or name", 0)
+	}
+	if !isCondition(d.MatchersCondition) {
+		return nil, api.NewAPIError(fmt.Sprintf("Unknown matchers condition '%s'", d.MatchersCondition), 0)
+	}
+	switch d.Mode {
+	case "", TemplateModePayloads, TemplateModeMethodOverride, TemplateModeBoundary, TemplateModeIdempotency, TemplateModeCORS, TemplateModeCache:
+	default:
+		return nil, api.NewAPIError(fmt.Sprintf("Unknown template mode '%s'", d.Mode), 0)
+	}
+	for _, constraint := range d.Constraints {
+		if constraint == ConstraintType || !hasConstraint(SchemaConstraints, constraint) {
+			return nil, api.NewAPIError(fmt.Sprintf("Unknown boundary constraint '%s'", constraint), 0)
+		}
+	}
+	for _, matcher := range d.Matchers {
+		if err := matcher.compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	template := &APITestCaseTemplate{
+		Name:           name,
+		Description:    d.Info.Description,
+		Category:       d.Info.Category,
+		Priority:       d.Info.Priority,
+		MethodPattern:  strings.ToUpper(d.Match.Method),
+		PathPattern:    d.Match.Path,
+		ParamPatterns:  d.Match.Params,
+		ExpectedStatus: d.ExpectedStatus,
+	}
+	if template.Category == "" {
+		template.Category = "security"
+		switch d.Mode {
+		case TemplateModeBoundary:
+			template.Category = "negative"
+		case TemplateModeIdempotency:
+			template.Category = "idempotency"
+		case TemplateModeCache:
+			template.Category = "cache"
+		}
+	}
+	if template.Priority == 0 {
+		template.Priority = 2
+	}
+	if template.MethodPattern == "" {
+		template.MethodPattern = "*"
+	}
+	if template.PathPattern == "" {
+		template.PathPattern = "*"
+	}
+	template.Generator = d.generate(template)
+	return template, nil
+}
+
+// generate returns the generator function of a template definition
+func (d *APITestTemplateDefinition) generate(template *APITestCaseTemplate) func(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	return func(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+		testCases := make([]*APITestCase, 0)
+
+		newTestCase := func(name, description string, target *ExtractedParameter, payload string) *APITestCase {
+			testCase := &APITestCase{
+				Name:              name,
+				Description:       description,
+				Method:            endpoint.Method,
+				Path:              endpoint.Path,
+				Headers:           make(map[string]string),
+				QueryParams:       make(map[string]string),
+				PathParams:        make(map[string]string),
+				ExpectedStatus:    template.ExpectedStatus,
+				Category:          template.Category,
+				Priority:          template.Priority,
+				RequiresAuth:      endpoint.RequiresAuth,
+				Matchers:          d.Matchers,
+				MatchersCondition: d.MatchersCondition,
+			}
+
+			// Add content type header for POST, PUT, PATCH
+			if endpoint.Method == "POST" || endpoint.Method == "PUT" || endpoint.Method == "PATCH" {
+				testCase.Headers["Content-Type"] = requestContentType(endpoint)
+			}
+			for key, value := range d.Headers {
+				testCase.Headers[key] = strings.ReplaceAll(value, PayloadPlaceholder, payload)
+			}
+
+			// Add the payload to the target parameter and valid values to the others
+			bodyParams := make(map[string]interface{})
+			for _, p := range params {
+				value := getExampleValue(p)
+				var bodyValue interface{} = getExampleValueAsInterface(p)
+				if target != nil && p.Name == target.Name {
+					value = payload
+					bodyValue = payload
+				}
+				switch p.In {
+				case "query":
+					testCase.QueryParams[p.Name] = value
+				case "path":
+					testCase.PathParams[p.Name] = value
+				case "header":
+					if _, ok := testCase.Headers[p.Name]; !ok {
+						testCase.Headers[p.Name] = value
+					}
+				case "body":
+					bodyParams[p.Name] = bodyValue
+				}
+			}
+			testCase.Body = buildRequestBody(endpoint, bodyParams)
+			return testCase
+		}
+
+		// The method override mode sends the request as the other methods
+		if d.Mode == TemplateModeMethodOverride {
+			base := newTestCase("", template.Description, nil, "")
+			return methodOverrideTestCases(template.Name, base, payload.NewMethodTamperer(), d.Methods)
+		}
+
+		// The boundary mode inserts values violating the schema constraints of the parameters
+		if d.Mode == TemplateModeBoundary {
+			for _, param := range params {
+				if !d.targetsParameter(param) {
+					continue
+				}
+				for _, value := range SchemaBoundaryViolations(param.Schema, d.Constraints...) {
+					testCase := boundaryViolationTestCase(endpoint, params, param, value)
+					testCase.Name = fmt.Sprintf("%s (%s) in parameter '%s' for %s %s", template.Name, value.Description, param.Name, endpoint.Method, endpoint.Path)
+					if template.Description != "" {
+						testCase.Description = template.Description
+					}
+					for key, header := range d.Headers {
+						testCase.Headers[key] = strings.ReplaceAll(header, PayloadPlaceholder, SchemaValueString(value.Value))
+					}
+					if template.ExpectedStatus != 0 {
+						testCase.ExpectedStatus = template.ExpectedStatus
+					}
+					testCase.Category = template.Category
+					testCase.Priority = template.Priority
+					testCase.Matchers = d.Matchers
+					testCase.MatchersCondition = d.MatchersCondition
+					testCases = append(testCases, testCase)
+				}
+			}
+			return testCases
+		}
+
+		// The idempotency mode chains the requests, keeping the expected status of each step
+		if d.Mode == TemplateModeIdempotency {
+			header := d.IdempotencyHeader
+			if header == "" {
+				header = IdempotencyKeyHeader
+			}
+			for _, testCase := range idempotencyKeyTestCases(template.Name, endpoint, params, header) {
+				for key, value := range d.Headers {
+					testCase.Headers[key] = value
+				}
+				testCase.Category = template.Category
+				testCase.Priority = template.Priority
+				testCase.Matchers = d.Matchers
+				testCase.MatchersCondition = d.MatchersCondition
+				testCases = append(testCases, testCase)
+			}
+			return testCases
+		}
+
+		// The cors mode checks the preflights of the endpoint, along with the template matchers
+		if d.Mode == TemplateModeCORS {
+			policy := d.CORS
+			if policy == nil {
+				policy = &CORSPolicy{}
+			}
+			methods := policy.AllowedMethods
+			if len(methods) == 0 {
+				methods = []string{endpoint.Method}
+			}
+			for _, testCase := range corsPreflightTestCases(template.Name, endpoint, params, policy, methods, endpoint.URL) {
+				for key, value := range d.Headers {
+					testCase.Headers[key] = value
+				}
+				if template.Description != "" {
+					testCase.Description = template.Description
+				}
+				testCase.Category = template.Category
+				testCase.Priority = template.Priority
+				testCase.Matchers = append(testCase.Matchers, d.Matchers...)
+				testCases = append(testCases, testCase)
+			}
+			return testCases
+		}
+
+		// The cache mode chains the cache buster and poisoning requests, keeping their expectations
+		if d.Mode == TemplateModeCache {
+			buster := d.CacheBuster
+			if buster == "" {
+				buster = CacheBusterParam
+			}
+			headers := DefaultUnkeyedHeaders()
+			if len(d.UnkeyedHeaders) > 0 {
+				headers = make([]payload.CatalogHeader, 0, len(d.UnkeyedHeaders))
+				for _, name := range d.UnkeyedHeaders {
+					headers = append(headers, payload.CatalogHeader{Name: name, Category: payload.HeaderHost})
+				}
+			}
+			generated := cacheBusterTestCases(template.Name, endpoint, params, buster)
+			generated = append(generated, cachePoisoningTestCases(template.Name, endpoint, params, buster, headers)...)
+			if endpoint.RequiresAuth {
+				generated = append(generated, sensitiveCachingTestCases(template.Name, endpoint, params)...)
+			}
+			for _, testCase := range generated {
+				for key, value := range d.Headers {
+					testCase.Headers[key] = value
+				}
+				testCase.Category = template.Category
+				testCase.Priority = template.Priority
+				testCase.Matchers = append(testCase.Matchers, d.Matchers...)
+				testCases = append(testCases, testCase)
+			}
+			return testCases
+		}
+
+		// Without payloads the template describes a single request
+		if len(d.Payloads) == 0 {
+			return append(testCases, newTestCase(
+				fmt.Sprintf("%s for %s %s", template.Name, endpoint.Method, endpoint.Path),
+				template.Description, nil, ""))
+		}
+
+		for _, payload := range d.Payloads {
+			// Insert the payload into every matching parameter
+			for _, param := range params {
+				if !d.targetsParameter(param) {
+					continue
+				}
+				testCases = append(testCases, newTestCase(
+					fmt.Sprintf("%s in parameter '%s' for %s %s", template.Name, param.Name, endpoint.Method, endpoint.Path),
+					fmt.Sprintf("%s with payload: %s", template.Description, payload), param, payload))
+			}
+
+			// Insert the payload into the template headers
+			if d.hasHeaderPayload() {
+				testCases = append(testCases, newTestCase(
+					fmt.Sprintf("%s in headers for %s %s", template.Name, endpoint.Method, endpoint.Path),
+					fmt.Sprintf("%s with payload: %s", template.Description, payload), nil, payload))
+			}
+		}
+		return testCases
+	}
+}
+
+// targetsParameter returns whether payloads of the definition are inserted into a parameter
+func (d *APITestTemplateDefinition) targetsParameter(param *ExtractedParameter) bool {
+	if len(d.Match.In) > 0 {
+		found := false
+		for _, in := range d.Match.In {
+			if in == param.In {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Without parameter patterns, payloads go into all parameters unless the headers take them
+	if len(d.Match.Params) == 0 {
+		return !d.hasHeaderPayload()
+	}
+	for _, pattern := range d.Match.Params {
+		if paramMatches(param.Name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHeaderPayload returns whether a header of the definition contains the payload placeholder
+func (d *APITestTemplateDefinition) hasHeaderPayload() bool {
+	for _, value := range d.Headers {
+		if strings.Contains(value, PayloadPlaceholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// paramMatches returns whether a parameter name matches a pattern with * and ? wildcards
+func paramMatches(name, pattern string) bool {
+	if pattern == "*" || pattern == name {
+		return true
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
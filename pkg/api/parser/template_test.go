@@ -0,0 +1,235 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+const testYAMLTemplate = `id: path-traversal
+info:
+  name: Path traversal
+  description: Test for path traversal
+  priority: 1
+match:
+  method: get
+  path: "*"
+  params: ["*file*"]
+  in: [query]
+payloads:
+  - ../../etc/passwd
+  - ..%2f..%2fetc%2fpasswd
+matchers-condition: and
+matchers:
+  - type: word
+    words: ["root:x:0:0"]
+    negative: true
+  - type: status
+    status: [400, 404]
+`
+
+const testJSONTemplate = `{
+	"id": "host-header-injection",
+	"info": {"name": "Host header injection", "category": "security"},
+	"headers": {"X-Forwarded-Host": "{{payload}}"},
+	"payloads": ["evil.example.com"],
+	"matchers": [{"type": "regex", "part": "header", "regex": ["evil\\.example\\.com"], "negative": true}]
+}`
+
+func TestAPITestGenerator_LoadTemplatesFromDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "injection"), 0755); err != nil {
+		t.Fatalf("Failed to create subdirectory: %v", err)
+	}
+	files := map[string]string{
+		"injection/traversal.yaml": testYAMLTemplate,
+		"headers.json":             testJSONTemplate,
+		"README.md":                "not a template",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	discovery := NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.Endpoints = []*DiscoveredEndpoint{
+		{
+			Method: "GET",
+			Path:   "/download",
+			Parameters: []*DiscoveredParameter{
+				{Name: "filename", In: "query", Type: "string"},
+				{Name: "format", In: "query", Type: "string"},
+			},
+		},
+	}
+
+	generator := NewAPITestGenerator(discovery, nil)
+	if err := generator.LoadTemplatesFromDirectory(dir); err != nil {
+		t.Fatalf("LoadTemplatesFromDirectory() error = %v", err)
+	}
+	if len(generator.Templates) != 2 {
+		t.Fatalf("Expected 2 templates, got %d", len(generator.Templates))
+	}
+	if err := generator.GenerateTestCases(); err != nil {
+		t.Fatalf("GenerateTestCases() error = %v", err)
+	}
+
+	traversal := 0
+	headers := 0
+	for _, testCase := range generator.TestCases {
+		switch testCase.Name {
+		case "Path traversal in parameter 'filename' for GET /download":
+			traversal++
+			if testCase.QueryParams["format"] == testCase.QueryParams["filename"] {
+				t.Errorf("Expected payload only in filename, got %v", testCase.QueryParams)
+			}
+			if testCase.Priority != 1 || len(testCase.Matchers) != 2 {
+				t.Errorf("Unexpected priority %d or matchers %v", testCase.Priority, testCase.Matchers)
+			}
+		case "Host header injection in headers for GET /download":
+			headers++
+			if testCase.Headers["X-Forwarded-Host"] != "evil.example.com" {
+				t.Errorf("Expected payload in header, got %v", testCase.Headers)
+			}
+		default:
+			t.Errorf("Unexpected test case %q", testCase.Name)
+		}
+	}
+	if traversal != 2 || headers != 1 {
+		t.Errorf("Expected 2 traversal and 1 header test cases, got %d and %d", traversal, headers)
+	}
+
+	// Invalid templates are reported
+	if err := ioutil.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte("id: x\nmatchers:\n  - type: unknown\n"), 0644); err != nil {
+		t.Fatalf("Failed to write invalid template: %v", err)
+	}
+	if err := NewAPITestGenerator(discovery, nil).LoadTemplatesFromDirectory(dir); err == nil {
+		t.Error("Expected error for invalid template")
+	}
+}
+
+func TestAPITestCase_MatchResponse(t *testing.T) {
+	template, err := ParseTestCaseTemplate([]byte(testYAMLTemplate), "yaml")
+	if err != nil {
+		t.Fatalf("ParseTestCaseTemplate() error = %v", err)
+	}
+	params := []*ExtractedParameter{{Name: "file", In: "query", Type: "string"}}
+	testCases := template.Generator(&DiscoveredEndpoint{Method: "GET", Path: "/download"}, params)
+	if len(testCases) != 2 {
+		t.Fatalf("Expected 2 test cases, got %d", len(testCases))
+	}
+	testCase := testCases[0]
+
+	tests := []struct {
+		name string
+		resp *ffuf.Response
+		want bool
+	}{
+		{"rejected", &ffuf.Response{StatusCode: 400, Data: []byte("invalid filename")}, true},
+		{"leaked", &ffuf.Response{StatusCode: 404, Data: []byte("root:x:0:0:root:/root:/bin/bash")}, false},
+		{"accepted", &ffuf.Response{StatusCode: 200, Data: []byte("ok")}, false},
+	}
+	for _, tt := range tests {
+		if got := testCase.MatchResponse(tt.resp); got != tt.want {
+			t.Errorf("%s: MatchResponse() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	matcher := &APITestMatcher{Type: "regex", Part: "header", Regex: []string{`^X-Debug: true`}}
+	if !matcher.Match(&ffuf.Response{Headers: map[string][]string{"X-Debug": {"true"}}}) {
+		t.Error("Expected header regex matcher to match")
+	}
+}
+
+func TestParseTestCaseTemplate_MethodOverride(t *testing.T) {
+	template, err := ParseTestCaseTemplate([]byte(`id: method-override
+info:
+  name: Admin method override
+match:
+  path: /admin/*
+mode: method-override
+methods: [DELETE, PUT]
+expected-status: 405
+`), "yaml")
+	if err != nil {
+		t.Fatalf("ParseTestCaseTemplate() error = %v", err)
+	}
+	endpoint := &DiscoveredEndpoint{Method: "GET", Path: "/admin/users/{id}"}
+	params := []*ExtractedParameter{{Name: "id", In: "path", Type: "integer", Example: 1}}
+	testCases := template.Generator(endpoint, params)
+
+	found := make(map[string]*APITestCase)
+	for _, testCase := range testCases {
+		found[testCase.Name] = testCase
+		if testCase.PathParams["id"] != "1" || testCase.ExpectedStatus != 405 {
+			t.Errorf("Unexpected test case %q", testCase.Name)
+		}
+	}
+	header := found["Admin method override for GET /admin/users/{id}: POST with X-HTTP-Method-Override: DELETE"]
+	if header == nil || header.Method != "POST" || header.Headers["X-HTTP-Method-Override"] != "DELETE" {
+		t.Errorf("Expected a POST test case overriding the method with a header")
+	}
+	field := found["Admin method override for GET /admin/users/{id}: POST with body field _method=PUT"]
+	if field == nil || field.Body != "_method=PUT" || field.Headers["Content-Type"] != "application/x-www-form-urlencoded" {
+		t.Errorf("Expected a POST test case overriding the method with a form field")
+	}
+	query := found["Admin method override for GET /admin/users/{id}: GET with query parameter _method=DELETE"]
+	if query == nil || query.QueryParams["_method"] != "DELETE" {
+		t.Errorf("Expected a GET test case overriding the method with a query parameter")
+	}
+	if verb := found["Admin method override for GET /admin/users/{id}: FFUF in place of PUT"]; verb == nil || verb.Method != "FFUF" {
+		t.Errorf("Expected a test case with an arbitrary verb")
+	}
+
+	if _, err := ParseTestCaseTemplate([]byte("id: x\nmode: unknown\n"), "yaml"); err == nil {
+		t.Error("Expected error for an unknown mode")
+	}
+}
+
+func TestParseTestCaseTemplate_Boundary(t *testing.T) {
+	template, err := ParseTestCaseTemplate([]byte(`id: boundary
+info:
+  name: Quantity bounds
+match:
+  params: [quantity]
+mode: boundary
+constraints: [range]
+`), "yaml")
+	if err != nil {
+		t.Fatalf("ParseTestCaseTemplate() error = %v", err)
+	}
+	if template.Category != "negative" {
+		t.Errorf("Category = %q, want negative", template.Category)
+	}
+	endpoint := &DiscoveredEndpoint{Method: "GET", Path: "/orders"}
+	params := []*ExtractedParameter{
+		{Name: "quantity", In: "query", Type: "integer", Schema: &OpenAPISchema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(10)}},
+		{Name: "name", In: "query", Type: "string", Schema: &OpenAPISchema{Type: "string", MaxLength: intPtr(3)}},
+	}
+	testCases := template.Generator(endpoint, params)
+
+	values := make([]string, 0)
+	for _, testCase := range testCases {
+		values = append(values, testCase.QueryParams["quantity"])
+		if testCase.ExpectedStatus != 400 || testCase.QueryParams["name"] == "testa" {
+			t.Errorf("Unexpected test case %q", testCase.Name)
+		}
+	}
+	if strings.Join(values, ",") != "0,11" {
+		t.Errorf("quantity values = %v, want 0 and 11", values)
+	}
+
+	if _, err := ParseTestCaseTemplate([]byte("id: x\nmode: boundary\nconstraints: [type]\n"), "yaml"); err == nil {
+		t.Error("Expected error for an unknown constraint")
+	}
+}
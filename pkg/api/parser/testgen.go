@@ -4,10 +4,14 @@ package parser
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
 )
 
 // APITestGenerator provides methods for generating test cases from API specifications
@@ -22,6 +26,9 @@ type APITestGenerator struct {
 	Templates []*APITestCaseTemplate
 	// Test case generation options
 	Options *APITestGenerationOptions
+	// Values are the candidate values of the parameters, such as identifiers observed in
+	// responses, built from the examples and schemas of the endpoints if not set
+	Values *ValueDictionary
 }
 
 // APITestCase represents a test case for an API endpoint
@@ -60,6 +67,15 @@ type APITestCase struct {
 	Auth *APITestAuth
 	// Dependencies on other test cases
 	Dependencies []*APITestCase
+	// Values extracted from the responses of dependencies
+	Extractions []*APITestExtraction
+	// Values of the response compared with expected values, such as the identifier returned
+	// by a dependency
+	Assertions []*APITestAssertion
+	// Matchers asserting the expected response, in addition to the expected values above
+	Matchers []*APITestMatcher
+	// How matchers are combined ("and" or "or", defaults to "or")
+	MatchersCondition string
 	// Test case template used to generate this test case
 	Template *APITestCaseTemplate
 }
@@ -128,6 +144,13 @@ type APITestGenerationOptions struct {
 	MinPriority int
 	// Maximum priority of test cases to generate (1-5, where 1 is highest)
 	MaxPriority int
+	// Whether to generate create, read, update and delete chains for resources
+	GenerateChains bool
+	// Whether to generate the test cases of the riskiest endpoints first
+	Prioritize bool
+	// CORS policy the responses of CORS preflight test cases are checked against (defaults to
+	// allowing no cross-origin requests)
+	CORSPolicy *CORSPolicy
 }
 
 // NewAPITestGenerator creates a new APITestGenerator
@@ -152,6 +175,7 @@ func getDefaultTestGenerationOptions() *APITestGenerationOptions {
 		MaxTestCasesPerEndpoint: 10,
 		MinPriority:             1,
 		MaxPriority:             3,
+		Prioritize:              true,
 	}
 }
 
@@ -234,6 +258,129 @@ func (g *APITestGenerator) AddDefaultTemplates() {
 		ExpectedStatus: 401,
 		Generator:     generateAuthBypassTestCases,
 	})
+
+	// Schema constraint test case templates
+	g.AddTemplate(&APITestCaseTemplate{
+		Name:           "Boundary Values",
+		Description:    "Test with values at the boundaries of the schema constraints",
+		Category:       "positive",
+		Priority:       2,
+		MethodPattern:  "*",
+		PathPattern:    "*",
+		ExpectedStatus: 200,
+		Generator:      generateBoundaryValueTestCases,
+	})
+
+	// Boundary violation test case templates, one per kind of schema constraint
+	for _, family := range []struct {
+		name        string
+		description string
+		constraints []string
+	}{
+		{"Out of Range Values", "Test with numbers just outside the range of the schema", []string{ConstraintRange}},
+		{"Length Violations", "Test with strings just outside the length bounds of the schema", []string{ConstraintLength}},
+		{"Item Count Violations", "Test with arrays just outside the item bounds of the schema", []string{ConstraintItems}},
+		{"Invalid Enum Values", "Test with values close to but not in the enum of the schema", []string{ConstraintEnum}},
+		{"Malformed Formats", "Test with strings not matching the format or pattern of the schema", []string{ConstraintFormat, ConstraintPattern}},
+		{"Missing Required Properties", "Test with objects missing a required property", []string{ConstraintRequired}},
+	} {
+		g.AddTemplate(&APITestCaseTemplate{
+			Name:           family.name,
+			Description:    family.description,
+			Category:       "negative",
+			Priority:       2,
+			MethodPattern:  "*",
+			PathPattern:    "*",
+			ExpectedStatus: 400,
+			Generator:      generateBoundaryViolationTestCases(family.constraints...),
+		})
+	}
+
+	// Method override test case templates
+	g.AddTemplate(&APITestCaseTemplate{
+		Name:           "Method Override",
+		Description:    "Test for access control enforced on the sent HTTP method only",
+		Category:       "security",
+		Priority:       2,
+		MethodPattern:  "*",
+		PathPattern:    "*",
+		ExpectedStatus: 405,
+		Generator:      generateMethodOverrideTestCases,
+	})
+
+	// CORS preflight test case templates
+	g.AddTemplate(&APITestCaseTemplate{
+		Name:          "CORS Preflight",
+		Description:   "Test that CORS preflight responses follow the declared CORS policy",
+		Category:      "security",
+		Priority:      2,
+		MethodPattern: "*",
+		PathPattern:   "*",
+		Generator:     g.generateCORSPreflightTestCases,
+	})
+
+	// Idempotency and replay test case templates
+	g.AddTemplate(&APITestCaseTemplate{
+		Name:           "Idempotency Key Replay",
+		Description:    "Test whether requests repeated with the same Idempotency-Key are processed once",
+		Category:       "idempotency",
+		Priority:       2,
+		MethodPattern:  "*",
+		PathPattern:    "*",
+		ExpectedStatus: 201,
+		Generator:      generateIdempotencyKeyTestCases,
+	})
+	g.AddTemplate(&APITestCaseTemplate{
+		Name:           "Duplicate Request Replay",
+		Description:    "Test whether replayed create requests create duplicates",
+		Category:       "idempotency",
+		Priority:       2,
+		MethodPattern:  "POST",
+		PathPattern:    "*",
+		ExpectedStatus: 409,
+		Generator:      generateReplayTestCases,
+	})
+
+	// Cache behavior test case templates
+	g.AddTemplate(&APITestCaseTemplate{
+		Name:          "Cache Buster",
+		Description:   "Test whether the query string is part of the cache key",
+		Category:      "cache",
+		Priority:      2,
+		MethodPattern: "GET",
+		PathPattern:   "*",
+		Generator:     generateCacheBusterTestCases,
+	})
+	g.AddTemplate(&APITestCaseTemplate{
+		Name:           "Unkeyed Header Cache Poisoning",
+		Description:    "Test whether responses to requests with unkeyed proxy headers are cached and served to other requests",
+		Category:       "cache",
+		Priority:       2,
+		MethodPattern:  "GET",
+		PathPattern:    "*",
+		ExpectedStatus: 200,
+		Generator:      generateCachePoisoningTestCases,
+	})
+	g.AddTemplate(&APITestCaseTemplate{
+		Name:          "Sensitive Response Caching",
+		Description:   "Test whether authenticated responses may be stored by shared caches",
+		Category:      "cache",
+		Priority:      2,
+		MethodPattern: "GET",
+		PathPattern:   "*",
+		Generator:     g.generateSensitiveCachingTestCases,
+	})
+
+	// Content negotiation test case templates
+	g.AddTemplate(&APITestCaseTemplate{
+		Name:          "Content Negotiation",
+		Description:   "Test the documented response formats and languages, and unusual quality values",
+		Category:      "negotiation",
+		Priority:      3,
+		MethodPattern: "*",
+		PathPattern:   "*",
+		Generator:     generateContentNegotiationTestCases,
+	})
 }
 
 // GenerateTestCases generates test cases from the discovered endpoints
@@ -263,6 +410,17 @@ func (g *APITestGenerator) GenerateTestCases() error {
 		g.AddDefaultTemplates()
 	}
 
+	if g.Values == nil {
+		g.Values = NewValueDictionaryFromEndpoints(endpoints)
+	}
+
+	if g.Options.Prioritize {
+		endpoints = make([]*DiscoveredEndpoint, 0, len(endpoints))
+		for _, risk := range PrioritizeEndpoints(g.Discovery.GetEndpoints()) {
+			endpoints = append(endpoints, risk.Endpoint)
+		}
+	}
+
 	// Generate test cases for each endpoint
 	for _, endpoint := range endpoints {
 		// Skip endpoints that require authentication if not included
@@ -270,15 +428,14 @@ func (g *APITestGenerator) GenerateTestCases() error {
 			continue
 		}
 
-		// Get parameters for the endpoint
-		params := make([]*ExtractedParameter, 0)
-		for _, param := range endpoint.Parameters {
-			extractedParam := g.Extractor.GetParameterByName(param.Name)
-			if extractedParam != nil {
-				params = append(params, extractedParam)
-			}
+		// Skip endpoints that are not reachable over HTTP (e.g., MQTT topics)
+		if !isHTTPMethod(endpoint.Method) {
+			continue
 		}
 
+		// Get parameters for the endpoint
+		params := g.endpointParameters(endpoint)
+
 		// Generate test cases for each template
 		for _, template := range g.Templates {
 			// Skip templates that don't match the endpoint
@@ -307,20 +464,8 @@ func (g *APITestGenerator) GenerateTestCases() error {
 				// Set the template
 				testCase.Template = template
 
-				// Set the base URL if not set
-				if testCase.URL == "" && g.Options.BaseURL != "" {
-					baseURL := strings.TrimSuffix(g.Options.BaseURL, "/")
-					path := testCase.Path
-					if !strings.HasPrefix(path, "/") {
-						path = "/" + path
-					}
-					testCase.URL = baseURL + path
-				}
-
-				// Set authentication details if required
-				if testCase.RequiresAuth && testCase.Auth == nil && g.Options.Auth != nil {
-					testCase.Auth = g.Options.Auth
-				}
+				// Set the base URL and authentication details
+				g.completeTestCase(testCase)
 
 				// Add the test case to the list
 				g.TestCases = append(g.TestCases, testCase)
@@ -333,9 +478,82 @@ func (g *APITestGenerator) GenerateTestCases() error {
 		}
 	}
 
+	// Generate resource chains if enabled
+	if g.Options.GenerateChains {
+		g.TestCases = append(g.TestCases, g.GenerateCRUDChains()...)
+	}
+
 	return nil
 }
 
+// endpointParameters returns the extracted parameters of an endpoint, with their candidate
+// values as examples
+func (g *APITestGenerator) endpointParameters(endpoint *DiscoveredEndpoint) []*ExtractedParameter {
+	params := make([]*ExtractedParameter, 0)
+	for _, param := range endpoint.Parameters {
+		extractedParam := g.Extractor.GetParameterByName(param.Name)
+		if extractedParam != nil {
+			params = append(params, g.candidateParameter(extractedParam))
+		}
+	}
+	return params
+}
+
+// candidateParameter returns a copy of a parameter whose example is its candidate value from
+// the value dictionary. Observed values replace the examples of the specification, the values
+// of other parameters with the same name are only used for the parameters without an example
+// nor a schema. Values of another type than the parameter are ignored.
+func (g *APITestGenerator) candidateParameter(param *ExtractedParameter) *ExtractedParameter {
+	if g.Values == nil {
+		return param
+	}
+	candidates := g.Values.Observed(param.Name)
+	if param.Example == nil && param.Schema == nil {
+		candidates = g.Values.Values(param.Name)
+	}
+	for _, value := range candidates {
+		if valueMatchesType(value, param.Type) {
+			candidate := *param
+			candidate.Example = value
+			return &candidate
+		}
+	}
+	return param
+}
+
+// valueMatchesType returns true if a value can be sent as a parameter of a type
+func valueMatchesType(value interface{}, paramType string) bool {
+	text := fmt.Sprint(value)
+	switch strings.ToLower(paramType) {
+	case "integer":
+		return isNumericString(text)
+	case "number":
+		_, err := strconv.ParseFloat(text, 64)
+		return err == nil
+	case "boolean":
+		return text == "true" || text == "false"
+	case "array", "object":
+		return false
+	}
+	return true
+}
+
+// completeTestCase sets the base URL and authentication details of a test case if not set
+func (g *APITestGenerator) completeTestCase(testCase *APITestCase) {
+	if testCase.URL == "" && g.Options.BaseURL != "" {
+		baseURL := strings.TrimSuffix(g.Options.BaseURL, "/")
+		path := testCase.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		testCase.URL = baseURL + path
+	}
+
+	if testCase.RequiresAuth && testCase.Auth == nil && g.Options.Auth != nil {
+		testCase.Auth = g.Options.Auth
+	}
+}
+
 // GetTestCases returns all generated test cases
 func (g *APITestGenerator) GetTestCases() []*APITestCase {
 	return g.TestCases
@@ -511,6 +729,225 @@ func (g *APITestGenerator) ExportTestCasesToCurl() []string {
 	return commands
 }
 
+// PostmanCollectionSchema is the schema URL of Postman Collection v2.1
+const PostmanCollectionSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// ExportTestCasesToPostman exports the test cases to a Postman Collection v2.1 JSON string.
+// Test cases are grouped in one folder per endpoint, and every request carries a test
+// script asserting the expected status code, content type and response body.
+func (g *APITestGenerator) ExportTestCasesToPostman(collectionName string) (string, error) {
+	type KeyValue struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+		Type  string `json:"type,omitempty"`
+	}
+	type Auth map[string]interface{}
+	type Script struct {
+		Type string   `json:"type"`
+		Exec []string `json:"exec"`
+	}
+	type Event struct {
+		Listen string `json:"listen"`
+		Script Script `json:"script"`
+	}
+	type URL struct {
+		Raw      string      `json:"raw"`
+		Protocol string      `json:"protocol,omitempty"`
+		Host     []string    `json:"host,omitempty"`
+		Port     string      `json:"port,omitempty"`
+		Path     []string    `json:"path,omitempty"`
+		Query    []*KeyValue `json:"query,omitempty"`
+		Variable []*KeyValue `json:"variable,omitempty"`
+	}
+	type Body struct {
+		Mode    string                 `json:"mode"`
+		Raw     string                 `json:"raw"`
+		Options map[string]interface{} `json:"options,omitempty"`
+	}
+	type Request struct {
+		Method      string      `json:"method"`
+		Header      []*KeyValue `json:"header"`
+		URL         *URL        `json:"url"`
+		Body        *Body       `json:"body,omitempty"`
+		Auth        Auth        `json:"auth,omitempty"`
+		Description string      `json:"description,omitempty"`
+	}
+	type Item struct {
+		Name    string   `json:"name"`
+		Item    []*Item  `json:"item,omitempty"`
+		Request *Request `json:"request,omitempty"`
+		Event   []*Event `json:"event,omitempty"`
+	}
+	type Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	}
+	type Collection struct {
+		Info     Info        `json:"info"`
+		Item     []*Item     `json:"item"`
+		Auth     Auth        `json:"auth,omitempty"`
+		Variable []*KeyValue `json:"variable,omitempty"`
+	}
+
+	convertAuth := func(auth *APITestAuth) Auth {
+		attr := func(key, value string) *KeyValue {
+			return &KeyValue{Key: key, Value: value, Type: "string"}
+		}
+		switch auth.Type {
+		case "basic":
+			return Auth{"type": "basic", "basic": []*KeyValue{attr("username", auth.Username), attr("password", auth.Password)}}
+		case "bearer":
+			return Auth{"type": "bearer", "bearer": []*KeyValue{attr("token", auth.Token)}}
+		case "oauth":
+			return Auth{"type": "oauth2", "oauth2": []*KeyValue{
+				attr("accessToken", auth.Token),
+				attr("accessTokenUrl", auth.TokenURL),
+				attr("clientId", auth.ClientID),
+				attr("clientSecret", auth.ClientSecret),
+				attr("scope", auth.Scope),
+				attr("grant_type", "client_credentials"),
+			}}
+		}
+		return nil
+	}
+
+	collection := &Collection{
+		Info: Info{Name: collectionName, Schema: PostmanCollectionSchema},
+		Item: make([]*Item, 0),
+	}
+	if g.Options.Auth != nil {
+		collection.Auth = convertAuth(g.Options.Auth)
+	}
+	baseURL := strings.TrimSuffix(g.Options.BaseURL, "/")
+	if baseURL != "" {
+		collection.Variable = []*KeyValue{{Key: "baseUrl", Value: baseURL, Type: "string"}}
+	}
+
+	folders := make(map[string]*Item)
+	for _, testCase := range g.TestCases {
+		// Build the URL, using the baseUrl variable for URLs below the base URL
+		requestURL := &URL{}
+		rawURL := testCase.URL
+		if rawURL == "" {
+			rawURL = testCase.Path
+		}
+		for name, value := range testCase.PathParams {
+			rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", ":"+name)
+			requestURL.Variable = append(requestURL.Variable, &KeyValue{Key: name, Value: value})
+		}
+		sort.Slice(requestURL.Variable, func(i, j int) bool { return requestURL.Variable[i].Key < requestURL.Variable[j].Key })
+
+		path := rawURL
+		if baseURL != "" && strings.HasPrefix(rawURL, baseURL) {
+			path = strings.TrimPrefix(rawURL, baseURL)
+			requestURL.Host = []string{"{{baseUrl}}"}
+			rawURL = "{{baseUrl}}" + path
+		} else if parsedURL, err := url.Parse(rawURL); err == nil && parsedURL.Host != "" {
+			path = parsedURL.Path
+			requestURL.Protocol = parsedURL.Scheme
+			requestURL.Host = strings.Split(parsedURL.Hostname(), ".")
+			requestURL.Port = parsedURL.Port()
+		}
+		for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+			if segment != "" {
+				requestURL.Path = append(requestURL.Path, segment)
+			}
+		}
+
+		queryNames := make([]string, 0, len(testCase.QueryParams))
+		for name := range testCase.QueryParams {
+			queryNames = append(queryNames, name)
+		}
+		sort.Strings(queryNames)
+		queryParts := make([]string, 0, len(queryNames))
+		for _, name := range queryNames {
+			requestURL.Query = append(requestURL.Query, &KeyValue{Key: name, Value: testCase.QueryParams[name]})
+			queryParts = append(queryParts, name+"="+testCase.QueryParams[name])
+		}
+		if len(queryParts) > 0 {
+			rawURL += "?" + strings.Join(queryParts, "&")
+		}
+		requestURL.Raw = rawURL
+
+		request := &Request{
+			Method:      testCase.Method,
+			Header:      make([]*KeyValue, 0, len(testCase.Headers)),
+			URL:         requestURL,
+			Description: testCase.Description,
+		}
+		headerNames := make([]string, 0, len(testCase.Headers))
+		for name := range testCase.Headers {
+			headerNames = append(headerNames, name)
+		}
+		sort.Strings(headerNames)
+		for _, name := range headerNames {
+			request.Header = append(request.Header, &KeyValue{Key: name, Value: testCase.Headers[name]})
+		}
+
+		if testCase.Body != "" {
+			request.Body = &Body{Mode: "raw", Raw: testCase.Body}
+			contentType := strings.ToLower(testCase.Headers["Content-Type"])
+			if strings.Contains(contentType, "json") {
+				request.Body.Options = map[string]interface{}{"raw": map[string]string{"language": "json"}}
+			} else if strings.Contains(contentType, "xml") {
+				request.Body.Options = map[string]interface{}{"raw": map[string]string{"language": "xml"}}
+			}
+		}
+
+		// Requests inherit the collection auth unless they use other credentials or none at all
+		if !testCase.RequiresAuth {
+			request.Auth = Auth{"type": "noauth"}
+		} else if testCase.Auth != nil && testCase.Auth != g.Options.Auth {
+			request.Auth = convertAuth(testCase.Auth)
+		}
+
+		// Build the test script asserting the expectations
+		script := make([]string, 0)
+		if testCase.ExpectedStatus != 0 {
+			script = append(script,
+				fmt.Sprintf("pm.test(\"Status code is %d\", function () {", testCase.ExpectedStatus),
+				fmt.Sprintf("    pm.response.to.have.status(%d);", testCase.ExpectedStatus),
+				"});")
+		}
+		if testCase.ExpectedContentType != "" {
+			expected, _ := json.Marshal(testCase.ExpectedContentType)
+			script = append(script,
+				"pm.test(\"Content-Type is as expected\", function () {",
+				fmt.Sprintf("    pm.expect(pm.response.headers.get(\"Content-Type\")).to.include(%s);", expected),
+				"});")
+		}
+		if testCase.ExpectedResponseBody != "" {
+			expected, _ := json.Marshal(testCase.ExpectedResponseBody)
+			script = append(script,
+				"pm.test(\"Response body is as expected\", function () {",
+				fmt.Sprintf("    pm.expect(pm.response.text()).to.include(%s);", expected),
+				"});")
+		}
+
+		item := &Item{Name: testCase.Name, Request: request}
+		if len(script) > 0 {
+			item.Event = []*Event{{Listen: "test", Script: Script{Type: "text/javascript", Exec: script}}}
+		}
+
+		// Add the request to the folder of its endpoint
+		folderName := fmt.Sprintf("%s %s", testCase.Method, testCase.Path)
+		folder, ok := folders[folderName]
+		if !ok {
+			folder = &Item{Name: folderName, Item: make([]*Item, 0)}
+			folders[folderName] = folder
+			collection.Item = append(collection.Item, folder)
+		}
+		folder.Item = append(folder.Item, item)
+	}
+
+	jsonData, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return "", api.NewAPIError(fmt.Sprintf("Failed to marshal Postman collection: %s", err.Error()), 0)
+	}
+
+	return string(jsonData), nil
+}
+
 // GenerateTestReport generates a report of the test cases
 func (g *APITestGenerator) GenerateTestReport() string {
 	if len(g.TestCases) == 0 {
@@ -598,7 +1035,7 @@ func matchesTemplate(endpoint *DiscoveredEndpoint, template *APITestCaseTemplate
 		paramMatch := false
 		for _, param := range endpoint.Parameters {
 			for _, pattern := range template.ParamPatterns {
-				if pattern == "*" || pattern == param.Name {
+				if paramMatches(param.Name, pattern) {
 					paramMatch = true
 					break
 				}
@@ -638,7 +1075,7 @@ func generateValidRequestTestCases(endpoint *DiscoveredEndpoint, params []*Extra
 
 	// Add content type header for POST, PUT, PATCH
 	if endpoint.Method == "POST" || endpoint.Method == "PUT" || endpoint.Method == "PATCH" {
-		testCase.Headers["Content-Type"] = "application/json"
+		testCase.Headers["Content-Type"] = requestContentType(endpoint)
 	}
 
 	// Add parameters
@@ -657,12 +1094,7 @@ func generateValidRequestTestCases(endpoint *DiscoveredEndpoint, params []*Extra
 	}
 
 	// Add body if there are body parameters
-	if len(bodyParams) > 0 {
-		bodyJSON, err := json.Marshal(bodyParams)
-		if err == nil {
-			testCase.Body = string(bodyJSON)
-		}
-	}
+	testCase.Body = buildRequestBody(endpoint, bodyParams)
 
 	testCases = append(testCases, testCase)
 	return testCases
@@ -698,7 +1130,7 @@ func generateMissingRequiredParamsTestCases(endpoint *DiscoveredEndpoint, params
 
 		// Add content type header for POST, PUT, PATCH
 		if endpoint.Method == "POST" || endpoint.Method == "PUT" || endpoint.Method == "PATCH" {
-			testCase.Headers["Content-Type"] = "application/json"
+			testCase.Headers["Content-Type"] = requestContentType(endpoint)
 		}
 
 		// Add all parameters except the required one
@@ -721,12 +1153,7 @@ func generateMissingRequiredParamsTestCases(endpoint *DiscoveredEndpoint, params
 		}
 
 		// Add body if there are body parameters
-		if len(bodyParams) > 0 {
-			bodyJSON, err := json.Marshal(bodyParams)
-			if err == nil {
-				testCase.Body = string(bodyJSON)
-			}
-		}
+		testCase.Body = buildRequestBody(endpoint, bodyParams)
 
 		testCases = append(testCases, testCase)
 	}
@@ -761,7 +1188,7 @@ func generateInvalidParamTypesTestCases(endpoint *DiscoveredEndpoint, params []*
 
 		// Add content type header for POST, PUT, PATCH
 		if endpoint.Method == "POST" || endpoint.Method == "PUT" || endpoint.Method == "PATCH" {
-			testCase.Headers["Content-Type"] = "application/json"
+			testCase.Headers["Content-Type"] = requestContentType(endpoint)
 		}
 
 		// Add all parameters with valid values except the one with invalid type
@@ -795,12 +1222,7 @@ func generateInvalidParamTypesTestCases(endpoint *DiscoveredEndpoint, params []*
 		}
 
 		// Add body if there are body parameters
-		if len(bodyParams) > 0 {
-			bodyJSON, err := json.Marshal(bodyParams)
-			if err == nil {
-				testCase.Body = string(bodyJSON)
-			}
-		}
+		testCase.Body = buildRequestBody(endpoint, bodyParams)
 
 		testCases = append(testCases, testCase)
 	}
@@ -808,6 +1230,84 @@ func generateInvalidParamTypesTestCases(endpoint *DiscoveredEndpoint, params []*
 	return testCases
 }
 
+// generateBoundaryValueTestCases generates test cases with valid values at the boundaries of the schema constraints
+func generateBoundaryValueTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	testCases := make([]*APITestCase, 0)
+	for _, param := range params {
+		for _, value := range SchemaBoundaryValues(param.Schema) {
+			testCase := schemaValueTestCase(endpoint, params, param, value.Value)
+			testCase.Name = fmt.Sprintf("Boundary value (%s) for parameter '%s' in %s %s", value.Description, param.Name, endpoint.Method, endpoint.Path)
+			testCase.Description = fmt.Sprintf("Test with the %s of parameter '%s'", value.Description, param.Name)
+			testCase.ExpectedStatus = 200
+			testCase.Category = "positive"
+			testCases = append(testCases, testCase)
+		}
+	}
+	return testCases
+}
+
+// generateBoundaryViolationTestCases returns a generator of test cases with values violating
+// the listed kinds of schema constraints, or all of them
+func generateBoundaryViolationTestCases(constraints ...string) func(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	return func(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+		testCases := make([]*APITestCase, 0)
+		for _, param := range params {
+			for _, value := range SchemaBoundaryViolations(param.Schema, constraints...) {
+				testCases = append(testCases, boundaryViolationTestCase(endpoint, params, param, value))
+			}
+		}
+		return testCases
+	}
+}
+
+// boundaryViolationTestCase creates a test case with a value of a parameter violating a schema constraint
+func boundaryViolationTestCase(endpoint *DiscoveredEndpoint, params []*ExtractedParameter, target *ExtractedParameter, value SchemaTestValue) *APITestCase {
+	testCase := schemaValueTestCase(endpoint, params, target, value.Value)
+	testCase.Name = fmt.Sprintf("Constraint violation (%s) for parameter '%s' in %s %s", value.Description, target.Name, endpoint.Method, endpoint.Path)
+	testCase.Description = fmt.Sprintf("Test with a value of parameter '%s' %s", target.Name, value.Description)
+	testCase.ExpectedStatus = 400
+	testCase.Category = "negative"
+	return testCase
+}
+
+// schemaValueTestCase creates a test case with a value for a parameter and example values for the other parameters
+func schemaValueTestCase(endpoint *DiscoveredEndpoint, params []*ExtractedParameter, target *ExtractedParameter, value interface{}) *APITestCase {
+	testCase := &APITestCase{
+		Method:       endpoint.Method,
+		Path:         endpoint.Path,
+		Headers:      make(map[string]string),
+		QueryParams:  make(map[string]string),
+		PathParams:   make(map[string]string),
+		Priority:     2,
+		RequiresAuth: endpoint.RequiresAuth,
+	}
+
+	// Add content type header for POST, PUT, PATCH
+	if endpoint.Method == "POST" || endpoint.Method == "PUT" || endpoint.Method == "PATCH" {
+		testCase.Headers["Content-Type"] = requestContentType(endpoint)
+	}
+
+	bodyParams := make(map[string]interface{})
+	for _, p := range params {
+		var paramValue interface{} = getExampleValueAsInterface(p)
+		if p.Name == target.Name {
+			paramValue = value
+		}
+		switch p.In {
+		case "query":
+			testCase.QueryParams[p.Name] = SchemaValueString(paramValue)
+		case "path":
+			testCase.PathParams[p.Name] = SchemaValueString(paramValue)
+		case "header":
+			testCase.Headers[p.Name] = SchemaValueString(paramValue)
+		case "body":
+			bodyParams[p.Name] = paramValue
+		}
+	}
+	testCase.Body = buildRequestBody(endpoint, bodyParams)
+	return testCase
+}
+
 // generateSQLInjectionTestCases generates test cases for SQL injection
 func generateSQLInjectionTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
 	testCases := make([]*APITestCase, 0)
@@ -845,7 +1345,7 @@ func generateSQLInjectionTestCases(endpoint *DiscoveredEndpoint, params []*Extra
 
 			// Add content type header for POST, PUT, PATCH
 			if endpoint.Method == "POST" || endpoint.Method == "PUT" || endpoint.Method == "PATCH" {
-				testCase.Headers["Content-Type"] = "application/json"
+				testCase.Headers["Content-Type"] = requestContentType(endpoint)
 			}
 
 			// Add all parameters with valid values except the one with SQL injection payload
@@ -879,12 +1379,7 @@ func generateSQLInjectionTestCases(endpoint *DiscoveredEndpoint, params []*Extra
 			}
 
 			// Add body if there are body parameters
-			if len(bodyParams) > 0 {
-				bodyJSON, err := json.Marshal(bodyParams)
-				if err == nil {
-					testCase.Body = string(bodyJSON)
-				}
-			}
+			testCase.Body = buildRequestBody(endpoint, bodyParams)
 
 			testCases = append(testCases, testCase)
 		}
@@ -930,7 +1425,7 @@ func generateXSSTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParam
 
 			// Add content type header for POST, PUT, PATCH
 			if endpoint.Method == "POST" || endpoint.Method == "PUT" || endpoint.Method == "PATCH" {
-				testCase.Headers["Content-Type"] = "application/json"
+				testCase.Headers["Content-Type"] = requestContentType(endpoint)
 			}
 
 			// Add all parameters with valid values except the one with XSS payload
@@ -964,12 +1459,7 @@ func generateXSSTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParam
 			}
 
 			// Add body if there are body parameters
-			if len(bodyParams) > 0 {
-				bodyJSON, err := json.Marshal(bodyParams)
-				if err == nil {
-					testCase.Body = string(bodyJSON)
-				}
-			}
+			testCase.Body = buildRequestBody(endpoint, bodyParams)
 
 			testCases = append(testCases, testCase)
 		}
@@ -1004,7 +1494,7 @@ func generateAuthBypassTestCases(endpoint *DiscoveredEndpoint, params []*Extract
 
 	// Add content type header for POST, PUT, PATCH
 	if endpoint.Method == "POST" || endpoint.Method == "PUT" || endpoint.Method == "PATCH" {
-		testCase.Headers["Content-Type"] = "application/json"
+		testCase.Headers["Content-Type"] = requestContentType(endpoint)
 	}
 
 	// Add all parameters with valid values
@@ -1026,24 +1516,123 @@ func generateAuthBypassTestCases(endpoint *DiscoveredEndpoint, params []*Extract
 	}
 
 	// Add body if there are body parameters
-	if len(bodyParams) > 0 {
-		bodyJSON, err := json.Marshal(bodyParams)
-		if err == nil {
-			testCase.Body = string(bodyJSON)
-		}
-	}
+	testCase.Body = buildRequestBody(endpoint, bodyParams)
 
 	testCases = append(testCases, testCase)
 	return testCases
 }
 
+// generateMethodOverrideTestCases generates test cases sending a valid request with method
+// override headers, parameters and body fields, and with uncommon verbs
+func generateMethodOverrideTestCases(endpoint *DiscoveredEndpoint, params []*ExtractedParameter) []*APITestCase {
+	base := generateValidRequestTestCases(endpoint, params)[0]
+	base.Description = "Test for access control enforced on the sent HTTP method only"
+	base.ExpectedStatus = 405
+	base.Category = "security"
+	base.Priority = 2
+	return methodOverrideTestCases("Method override", base, payload.NewMethodTamperer(), nil)
+}
+
+// methodOverrideTestCases returns a test case for each method override and verb tampering
+// variant of a base test case, asking for the given methods or the method of the base test case
+func methodOverrideTestCases(name string, base *APITestCase, tamperer *payload.MethodTamperer, methods []string) []*APITestCase {
+	testCases := make([]*APITestCase, 0)
+	if len(methods) == 0 {
+		methods = []string{base.Method}
+	}
+	for _, method := range methods {
+		for _, variant := range tamperer.Variants(method) {
+			testCase := *base
+			testCase.Name = fmt.Sprintf("%s for %s %s: %s", name, base.Method, base.Path, variant.Description)
+			testCase.Description = fmt.Sprintf("%s with %s", base.Description, variant.Description)
+			testCase.Method = variant.Method
+			testCase.Headers = copyStringMap(base.Headers)
+			testCase.QueryParams = copyStringMap(base.QueryParams)
+			testCase.PathParams = copyStringMap(base.PathParams)
+
+			switch variant.Technique {
+			case payload.MethodOverrideHeader:
+				testCase.Headers[variant.Header] = variant.Target
+			case payload.MethodOverrideQuery:
+				testCase.QueryParams[variant.Param] = variant.Target
+			case payload.MethodOverrideField:
+				body, contentType, err := payload.AddMethodField(base.Body, base.Headers["Content-Type"], variant.Param, variant.Target)
+				if err != nil {
+					continue
+				}
+				testCase.Body = body
+				testCase.Headers["Content-Type"] = contentType
+			}
+			if testCase.Method == "POST" && testCase.Headers["Content-Type"] == "" {
+				testCase.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+			}
+			testCases = append(testCases, &testCase)
+		}
+	}
+	return testCases
+}
+
 // Helper functions
 
+// copyStringMap returns a copy of a map of strings
+func copyStringMap(m map[string]string) map[string]string {
+	copied := make(map[string]string, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
+
+// requestContentType returns the Content-Type of request bodies for an endpoint
+func requestContentType(endpoint *DiscoveredEndpoint) string {
+	if endpoint.Source == "WSDL" {
+		return "text/xml; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// buildRequestBody builds a request body for an endpoint from body parameter values.
+// GraphQL and SOAP operations place the values into the operation document.
+func buildRequestBody(endpoint *DiscoveredEndpoint, bodyParams map[string]interface{}) string {
+	if endpoint.Source == "GraphQL" && endpoint.Document != "" {
+		payload := map[string]interface{}{
+			"query":     endpoint.Document,
+			"variables": bodyParams,
+		}
+		bodyJSON, err := json.Marshal(payload)
+		if err != nil {
+			return ""
+		}
+		return string(bodyJSON)
+	}
+
+	// SOAP operations fill the parameter values into the envelope template
+	if endpoint.Source == "WSDL" && endpoint.Document != "" {
+		values := make(map[string]string)
+		for name, value := range bodyParams {
+			values[name] = fmt.Sprintf("%v", value)
+		}
+		return FillSOAPEnvelope(endpoint.Document, values)
+	}
+
+	if len(bodyParams) == 0 {
+		return ""
+	}
+	bodyJSON, err := json.Marshal(bodyParams)
+	if err != nil {
+		return ""
+	}
+	return string(bodyJSON)
+}
+
 // getExampleValue returns a string example value for a parameter
 func getExampleValue(param *ExtractedParameter) string {
 	if param.Example != nil {
 		return fmt.Sprintf("%v", param.Example)
 	}
+	if param.Schema != nil {
+		return SchemaValueString(GenerateSchemaValue(param.Schema))
+	}
 
 	// Generate a default value based on the parameter type
 	switch strings.ToLower(param.Type) {
@@ -1067,6 +1656,9 @@ func getExampleValueAsInterface(param *ExtractedParameter) interface{} {
 	if param.Example != nil {
 		return param.Example
 	}
+	if param.Schema != nil {
+		return GenerateSchemaValue(param.Schema)
+	}
 
 	// Generate a default value based on the parameter type
 	switch strings.ToLower(param.Type) {
@@ -262,6 +262,90 @@ func TestAPITestGenerator_ExportTestCasesToCurl(t *testing.T) {
 	}
 }
 
+func TestAPITestGenerator_ExportTestCasesToPostman(t *testing.T) {
+	generator := NewAPITestGenerator(nil, nil)
+	generator.Options.BaseURL = "https://api.example.com/"
+	generator.Options.Auth = &APITestAuth{Type: "bearer", Token: "secret"}
+	generator.TestCases = []*APITestCase{
+		{
+			Name:           "Get user",
+			Method:         "GET",
+			URL:            "https://api.example.com/users/{id}",
+			Path:           "/users/{id}",
+			PathParams:     map[string]string{"id": "1"},
+			QueryParams:    map[string]string{"fields": "name"},
+			ExpectedStatus: 200,
+			RequiresAuth:   true,
+			Auth:           generator.Options.Auth,
+		},
+		{
+			Name:                 "Get user without auth",
+			Method:               "GET",
+			URL:                  "https://api.example.com/users/{id}",
+			Path:                 "/users/{id}",
+			PathParams:           map[string]string{"id": "1"},
+			ExpectedStatus:       401,
+			ExpectedResponseBody: `"error"`,
+		},
+		{
+			Name:           "Create user",
+			Method:         "POST",
+			URL:            "https://api.example.com/users",
+			Path:           "/users",
+			Headers:        map[string]string{"Content-Type": "application/json"},
+			Body:           `{"name":"test"}`,
+			ExpectedStatus: 201,
+			RequiresAuth:   true,
+			Auth:           &APITestAuth{Type: "basic", Username: "admin", Password: "admin"},
+		},
+	}
+
+	jsonStr, err := generator.ExportTestCasesToPostman("Users API")
+	if err != nil {
+		t.Fatalf("Failed to export test cases to Postman: %v", err)
+	}
+	if !strings.Contains(jsonStr, "pm.response.to.have.status(401);") {
+		t.Errorf("Expected status assertion in test scripts")
+	}
+	if !strings.Contains(jsonStr, `pm.expect(pm.response.text()).to.include(\"\\\"error\\\"\");`) {
+		t.Errorf("Expected escaped body assertion in test scripts")
+	}
+
+	// The exported collection can be imported again
+	parser := NewPostmanParser()
+	if err := parser.ParseJSON([]byte(jsonStr)); err != nil {
+		t.Fatalf("Failed to parse exported collection: %v", err)
+	}
+	if parser.Name != "Users API" || parser.BaseURL != "https://api.example.com" {
+		t.Errorf("Unexpected collection name '%s' or base URL '%s'", parser.Name, parser.BaseURL)
+	}
+
+	endpoints := parser.GetEndpoints()
+	if len(endpoints) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(endpoints))
+	}
+	expected := []struct {
+		folder string
+		path   string
+		auth   string
+	}{
+		{"GET /users/{id}", "/users/{id}", "bearer"},
+		{"GET /users/{id}", "/users/{id}", ""},
+		{"POST /users", "/users", "basic"},
+	}
+	for i, e := range expected {
+		if len(endpoints[i].Folders) != 1 || endpoints[i].Folders[0] != e.folder {
+			t.Errorf("Request %d: expected folder '%s', got %v", i, e.folder, endpoints[i].Folders)
+		}
+		if endpoints[i].Path != e.path {
+			t.Errorf("Request %d: expected path '%s', got '%s'", i, e.path, endpoints[i].Path)
+		}
+		if endpoints[i].AuthType != e.auth {
+			t.Errorf("Request %d: expected auth '%s', got '%s'", i, e.auth, endpoints[i].AuthType)
+		}
+	}
+}
+
 func TestAPITestGenerator_GenerateTestReport(t *testing.T) {
 	// Create a test generator with some test cases
 	generator := createTestGenerator(t)
@@ -0,0 +1,658 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// FlowLocation is the part of a request or response a value appears in
+type FlowLocation string
+
+const (
+	// FlowLocationBody is a value of a JSON, form or HTML body
+	FlowLocationBody FlowLocation = "body"
+	// FlowLocationHeader is a value of a header
+	FlowLocationHeader FlowLocation = "header"
+	// FlowLocationCookie is a cookie set by a response or sent by a request
+	FlowLocationCookie FlowLocation = "cookie"
+	// FlowLocationQuery is a query parameter of a request URL
+	FlowLocationQuery FlowLocation = "query"
+	// FlowLocationPath is a segment of a request URL path
+	FlowLocationPath FlowLocation = "path"
+)
+
+// TokenOccurrence is an occurrence of a value in a request or response of a sequence
+type TokenOccurrence struct {
+	// Step is the index of the request and response in the sequence
+	Step int `json:"step"`
+	// Location is the part of the request or response the value appears in
+	Location FlowLocation `json:"location"`
+	// Name is the JSONPath of a JSON body value, the name of a header, cookie, form or query
+	// parameter, or the index of a path segment
+	Name string `json:"name"`
+	// Pattern is the regular expression extracting the value from the body or header of a
+	// response, whose first capture group is the value
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// String returns a short description of the occurrence, such as "header:X-CSRF-Token"
+func (o TokenOccurrence) String() string {
+	return fmt.Sprintf("%s:%s", o.Location, o.Name)
+}
+
+// TokenFlow is a value returned by a response of a sequence and sent again in later requests,
+// such as an object identifier, an anti-CSRF token or a session identifier
+type TokenFlow struct {
+	// Name is the variable name of the value in exported test cases
+	Name string `json:"name"`
+	// Type is the label of the value
+	Type CorrelationType `json:"type"`
+	// Value is the value returned by the source response
+	Value string `json:"value"`
+	// Source is where the value was returned
+	Source TokenOccurrence `json:"source"`
+	// Uses are where the value was sent again
+	Uses []TokenOccurrence `json:"uses"`
+	// Confidence is a score from 0-100 indicating confidence in the flow
+	Confidence int `json:"confidence"`
+}
+
+// Exchange is a request of a sequence and its response
+type Exchange struct {
+	Request  *ffuf.Request
+	Response *ffuf.Response
+}
+
+// CorrelationAnalyzer identifies the values returned by the responses of a sequence of
+// requests which reappear in later requests, and builds the chains of these values. The
+// chains can be exported as test cases replaying the sequence with the chaining executor.
+type CorrelationAnalyzer struct {
+	// MinLength is the minimum length of tracked values not named like identifiers or tokens
+	MinLength int
+
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// tokenSource is a value returned by a response
+type tokenSource struct {
+	value      string
+	key        string
+	occurrence TokenOccurrence
+}
+
+var (
+	// tokenKeyPattern matches the names of values holding tokens
+	tokenKeyPattern = regexp.MustCompile(`(?i)(token|jwt|bearer|access|refresh|auth|apikey|api_key|secret|signature)`)
+	// csrfKeyPattern matches the names of values holding anti-CSRF tokens
+	csrfKeyPattern = regexp.MustCompile(`(?i)(csrf|xsrf|authenticity|nonce|__requestverification)`)
+	// sessionKeyPattern matches the names of values holding session identifiers
+	sessionKeyPattern = regexp.MustCompile(`(?i)(session|sess|^sid$|phpsessid|jsessionid|connect\.sid)`)
+	// idKeyPattern matches the names of values holding identifiers
+	idKeyPattern = regexp.MustCompile(`(?i)(^id$|_id$|-id$|[a-z]Id$|uuid|guid|^key$|_key$)`)
+	// hiddenInputPattern matches the hidden inputs and meta tags of HTML pages holding tokens
+	hiddenInputPattern = regexp.MustCompile(`(?i)<(?:input[^>]+name=["']([^"']+)["'][^>]+value=["']([^"']*)["']|meta[^>]+name=["']([^"']*(?:csrf|xsrf|token)[^"']*)["'][^>]+content=["']([^"']*)["'])`)
+	// identifierPattern matches values shaped like identifiers, such as numbers, UUIDs and hashes
+	identifierPattern = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F-]{8,}|[A-Za-z0-9_\-.~+/=]{16,})$`)
+	// slugIdentifierPattern matches shorter identifiers mixing letters and digits, such as ord_1234
+	slugIdentifierPattern = regexp.MustCompile(`^[A-Za-z_\-]*[0-9][A-Za-z0-9_\-.~]*$`)
+	// variableNamePattern matches the characters not allowed in variable names
+	variableNamePattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// NewCorrelationAnalyzer creates a new CorrelationAnalyzer
+func NewCorrelationAnalyzer() *CorrelationAnalyzer {
+	return &CorrelationAnalyzer{
+		MinLength: 6,
+		exchanges: make([]Exchange, 0),
+	}
+}
+
+// Add appends a request and its response to the sequence, and returns their step
+func (a *CorrelationAnalyzer) Add(req *ffuf.Request, resp *ffuf.Response) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.exchanges = append(a.exchanges, Exchange{Request: req, Response: resp})
+	return len(a.exchanges) - 1
+}
+
+// Exchanges returns the requests and responses of the sequence
+func (a *CorrelationAnalyzer) Exchanges() []Exchange {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	exchanges := make([]Exchange, len(a.exchanges))
+	copy(exchanges, a.exchanges)
+	return exchanges
+}
+
+// Analyze returns the values returned by a response and sent again in later requests, ordered
+// by the step of their source. A value returned by several responses is attributed to the
+// latest response before each request using it.
+func (a *CorrelationAnalyzer) Analyze() []*TokenFlow {
+	exchanges := a.Exchanges()
+	latest := make(map[string]*tokenSource)
+	flows := make(map[*tokenSource]*TokenFlow)
+	ordered := make([]*TokenFlow, 0)
+
+	for step, exchange := range exchanges {
+		if exchange.Request != nil {
+			for _, use := range requestValues(step, exchange.Request) {
+				source, ok := latest[use.value]
+				if !ok {
+					continue
+				}
+				flow, ok := flows[source]
+				if !ok {
+					flow = &TokenFlow{
+						Value:  source.value,
+						Source: source.occurrence,
+						Uses:   make([]TokenOccurrence, 0),
+					}
+					flows[source] = flow
+					ordered = append(ordered, flow)
+				}
+				flow.Uses = append(flow.Uses, use.occurrence)
+				if flow.Type == "" || flow.Type == CorrelationTypeID || flow.Type == CorrelationTypeUnknown {
+					flow.Type = labelToken(source, use)
+				}
+			}
+		}
+		if exchange.Response != nil {
+			for _, source := range responseValues(step, exchange.Response) {
+				if a.tracked(source) {
+					latest[source.value] = source
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Source.Step < ordered[j].Source.Step
+	})
+	names := make(map[string]int)
+	for _, flow := range ordered {
+		flow.Confidence = tokenConfidence(flow)
+		flow.Name = uniqueVariableName(variableName(flow), names)
+	}
+	return ordered
+}
+
+// tracked checks if a value returned by a response is distinctive enough to be tracked
+func (a *CorrelationAnalyzer) tracked(source *tokenSource) bool {
+	value := source.value
+	if value == "" || value == "true" || value == "false" || value == "null" {
+		return false
+	}
+	if source.occurrence.Location == FlowLocationCookie || keyLabel(source.key) != CorrelationTypeUnknown {
+		return true
+	}
+	return len(value) >= a.MinLength && identifierPattern.MatchString(value)
+}
+
+// Correlations returns a correlation for every use of the values of the sequence, to be
+// visualized with the correlations of a CorrelationDetector
+func (a *CorrelationAnalyzer) Correlations() []Correlation {
+	exchanges := a.Exchanges()
+	correlations := make([]Correlation, 0)
+	for _, flow := range a.Analyze() {
+		for _, use := range flow.Uses {
+			correlations = append(correlations, Correlation{
+				Type:           flow.Type,
+				SourcePath:     flow.Source.String(),
+				TargetPath:     use.String(),
+				SourceValue:    flow.Value,
+				SourceResponse: exchanges[flow.Source.Step].Response,
+				TargetRequest:  exchanges[use.Step].Request,
+				Confidence:     flow.Confidence,
+				Description:    fmt.Sprintf("%s %s returned by step %d (%s) is sent by step %d (%s)", flow.Type, flow.Name, flow.Source.Step+1, flow.Source, use.Step+1, use),
+			})
+		}
+	}
+	return correlations
+}
+
+// ExportJSON exports the flows of the sequence as JSON
+func (a *CorrelationAnalyzer) ExportJSON() (string, error) {
+	data, err := json.MarshalIndent(map[string]interface{}{"flows": a.Analyze()}, "", "  ")
+	if err != nil {
+		return "", api.NewAPIError("Failed to marshal token flows to JSON: "+err.Error(), 0)
+	}
+	return string(data), nil
+}
+
+// ExportTestCases exports the sequence as chained test cases for the chaining executor. Each
+// step depends on the previous one, and the values flowing between steps are replaced by
+// variables extracted from the responses of their source steps.
+func (a *CorrelationAnalyzer) ExportTestCases() []*APITestCase {
+	exchanges := a.Exchanges()
+	flows := a.Analyze()
+	testCases := make([]*APITestCase, 0, len(exchanges))
+
+	for step, exchange := range exchanges {
+		req := exchange.Request
+		if req == nil {
+			continue
+		}
+		testCase := &APITestCase{
+			Name:         fmt.Sprintf("Step %d: %s %s", step+1, req.Method, requestPath(req.Url)),
+			Description:  fmt.Sprintf("Step %d of the recorded sequence", step+1),
+			Method:       req.Method,
+			URL:          req.Url,
+			Path:         requestPath(req.Url),
+			Headers:      make(map[string]string, len(req.Headers)),
+			Body:         string(req.Data),
+			Category:     "chain",
+			Priority:     1,
+			Dependencies: make([]*APITestCase, 0),
+			Extractions:  make([]*APITestExtraction, 0),
+		}
+		for name, value := range req.Headers {
+			testCase.Headers[name] = value
+		}
+		if exchange.Response != nil {
+			testCase.ExpectedStatus = int(exchange.Response.StatusCode)
+		}
+		if len(testCases) > 0 {
+			testCase.Dependencies = append(testCase.Dependencies, testCases[len(testCases)-1])
+		}
+
+		for _, flow := range flows {
+			for _, use := range flow.Uses {
+				if use.Step != step {
+					continue
+				}
+				source := stepTestCase(testCases, exchanges, flow.Source.Step)
+				if source == nil {
+					continue
+				}
+				replaceUse(testCase, use, flow.Value, "${"+flow.Name+"}")
+				if !hasExtraction(testCase, flow.Name) {
+					testCase.Extractions = append(testCase.Extractions, flowExtraction(flow, source))
+				}
+			}
+		}
+		testCases = append(testCases, testCase)
+	}
+	return testCases
+}
+
+// stepTestCase returns the exported test case of a step, the steps without request having no
+// test case
+func stepTestCase(testCases []*APITestCase, exchanges []Exchange, step int) *APITestCase {
+	index := 0
+	for i := 0; i < step; i++ {
+		if exchanges[i].Request != nil {
+			index++
+		}
+	}
+	if index >= len(testCases) || exchanges[step].Request == nil {
+		return nil
+	}
+	return testCases[index]
+}
+
+// hasExtraction checks if a test case already extracts a variable
+func hasExtraction(testCase *APITestCase, name string) bool {
+	for _, extraction := range testCase.Extractions {
+		if extraction.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flowExtraction creates the extraction of the value of a flow from its source test case
+func flowExtraction(flow *TokenFlow, source *APITestCase) *APITestExtraction {
+	extraction := &APITestExtraction{Name: flow.Name, From: source}
+	switch flow.Source.Location {
+	case FlowLocationHeader:
+		extraction.Header = flow.Source.Name
+		extraction.Regex = flow.Source.Pattern
+	case FlowLocationCookie:
+		extraction.Header = "Set-Cookie"
+		extraction.Regex = flow.Source.Pattern
+	default:
+		if flow.Source.Pattern != "" {
+			extraction.Regex = flow.Source.Pattern
+		} else {
+			extraction.JSONPath = flow.Source.Name
+		}
+	}
+	return extraction
+}
+
+// replaceUse replaces the value of a use in the request of a test case with a variable
+func replaceUse(testCase *APITestCase, use TokenOccurrence, value, variable string) {
+	switch use.Location {
+	case FlowLocationPath:
+		index, _ := strconv.Atoi(use.Name)
+		testCase.URL = replacePathSegment(testCase.URL, index, variable)
+		testCase.Path = requestPath(testCase.URL)
+	case FlowLocationQuery:
+		testCase.URL = replaceQueryValue(testCase.URL, use.Name, variable)
+	case FlowLocationHeader, FlowLocationCookie:
+		for name, headerValue := range testCase.Headers {
+			if strings.EqualFold(name, use.Name) || (use.Location == FlowLocationCookie && strings.EqualFold(name, "Cookie")) {
+				testCase.Headers[name] = strings.ReplaceAll(headerValue, value, variable)
+			}
+		}
+	case FlowLocationBody:
+		testCase.Body = replaceBodyValue(testCase.Body, use.Name, value, variable)
+	}
+}
+
+// requestValues returns the values sent by a request
+func requestValues(step int, req *ffuf.Request) []*tokenSource {
+	values := make([]*tokenSource, 0)
+	add := func(value, key string, location FlowLocation, name string) {
+		values = append(values, &tokenSource{value: value, key: key, occurrence: TokenOccurrence{Step: step, Location: location, Name: name}})
+	}
+
+	if u, err := url.Parse(req.Url); err == nil {
+		for i, segment := range strings.Split(u.EscapedPath(), "/") {
+			if decoded, err := url.PathUnescape(segment); err == nil && decoded != "" {
+				add(decoded, "", FlowLocationPath, strconv.Itoa(i))
+			}
+		}
+		for _, pair := range splitPairs(u.RawQuery, "&") {
+			add(pair[1], pair[0], FlowLocationQuery, pair[0])
+		}
+	}
+
+	for name, value := range req.Headers {
+		switch {
+		case strings.EqualFold(name, "Cookie"):
+			for _, pair := range splitPairs(value, ";") {
+				add(pair[1], pair[0], FlowLocationCookie, pair[0])
+			}
+		case strings.EqualFold(name, "Authorization"):
+			parts := strings.SplitN(value, " ", 2)
+			add(parts[len(parts)-1], name, FlowLocationHeader, name)
+		default:
+			add(value, name, FlowLocationHeader, name)
+		}
+	}
+
+	if len(req.Data) > 0 {
+		var body interface{}
+		if json.Unmarshal(req.Data, &body) == nil {
+			walkJSONValues(body, "$", "", func(path, key, value string) {
+				add(value, key, FlowLocationBody, path)
+			})
+		} else if strings.Contains(string(req.Data), "=") {
+			for _, pair := range splitPairs(string(req.Data), "&") {
+				add(pair[1], pair[0], FlowLocationBody, pair[0])
+			}
+		}
+	}
+	return values
+}
+
+// responseValues returns the values returned by a response
+func responseValues(step int, resp *ffuf.Response) []*tokenSource {
+	values := make([]*tokenSource, 0)
+	add := func(value, key string, location FlowLocation, name, pattern string) {
+		values = append(values, &tokenSource{value: value, key: key, occurrence: TokenOccurrence{Step: step, Location: location, Name: name, Pattern: pattern}})
+	}
+
+	for name, headerValues := range resp.Headers {
+		for _, value := range headerValues {
+			switch {
+			case strings.EqualFold(name, "Set-Cookie"):
+				pair := strings.SplitN(strings.SplitN(value, ";", 2)[0], "=", 2)
+				if len(pair) == 2 {
+					cookie := strings.TrimSpace(pair[0])
+					add(strings.TrimSpace(pair[1]), cookie, FlowLocationCookie, cookie, "^"+regexp.QuoteMeta(cookie)+"=([^;]*)")
+				}
+			case strings.EqualFold(name, "Location"):
+				if u, err := url.Parse(value); err == nil {
+					segments := strings.Split(strings.TrimSuffix(u.Path, "/"), "/")
+					if last := segments[len(segments)-1]; identifierPattern.MatchString(last) || slugIdentifierPattern.MatchString(last) {
+						add(last, "id", FlowLocationHeader, name, `([^/?#]+)/?(?:[?#].*)?$`)
+					}
+				}
+			case keyLabel(name) != CorrelationTypeUnknown:
+				// Only headers named like tokens or identifiers, standard headers such as
+				// Content-Type are sent back with the same values by chance
+				add(value, name, FlowLocationHeader, name, "")
+			}
+		}
+	}
+
+	var body interface{}
+	if json.Unmarshal(resp.Data, &body) == nil {
+		walkJSONValues(body, "$", "", func(path, key, value string) {
+			add(value, key, FlowLocationBody, path, "")
+		})
+		return values
+	}
+	for _, match := range hiddenInputPattern.FindAllStringSubmatch(string(resp.Data), -1) {
+		name, value := match[1], match[2]
+		if name == "" {
+			name, value = match[3], match[4]
+		}
+		add(value, name, FlowLocationBody, name, `name=["']`+regexp.QuoteMeta(name)+`["'][^>]+(?:value|content)=["']([^"']*)["']`)
+	}
+	return values
+}
+
+// walkJSONValues calls a function with the JSONPath, key and string value of the string and
+// number values of a JSON value
+func walkJSONValues(data interface{}, path, key string, fn func(path, key, value string)) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for childKey, child := range v {
+			walkJSONValues(child, path+"."+childKey, childKey, fn)
+		}
+	case []interface{}:
+		for i, child := range v {
+			walkJSONValues(child, fmt.Sprintf("%s[%d]", path, i), key, fn)
+		}
+	case string:
+		fn(path, key, v)
+	case float64:
+		fn(path, key, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+}
+
+// splitPairs splits URL encoded name and value pairs
+func splitPairs(s, separator string) [][2]string {
+	pairs := make([][2]string, 0)
+	for _, part := range strings.Split(s, separator) {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+		name, err1 := url.QueryUnescape(kv[0])
+		value, err2 := url.QueryUnescape(kv[1])
+		if err1 != nil || err2 != nil {
+			name, value = kv[0], kv[1]
+		}
+		pairs = append(pairs, [2]string{name, value})
+	}
+	return pairs
+}
+
+// keyLabel labels a value by the name of its field, header, cookie or parameter
+func keyLabel(key string) CorrelationType {
+	switch {
+	case key == "":
+		return CorrelationTypeUnknown
+	case csrfKeyPattern.MatchString(key):
+		return CorrelationTypeCSRF
+	case sessionKeyPattern.MatchString(key):
+		return CorrelationTypeSession
+	case tokenKeyPattern.MatchString(key):
+		return CorrelationTypeToken
+	case idKeyPattern.MatchString(key):
+		return CorrelationTypeID
+	}
+	return CorrelationTypeUnknown
+}
+
+// labelToken labels a value by the names of its source and use, and where it is sent
+func labelToken(source, use *tokenSource) CorrelationType {
+	for _, key := range []string{source.key, use.key} {
+		if label := keyLabel(key); label != CorrelationTypeUnknown && label != CorrelationTypeID {
+			return label
+		}
+	}
+	switch {
+	case strings.EqualFold(use.key, "Authorization"):
+		return CorrelationTypeToken
+	case source.occurrence.Location == FlowLocationCookie:
+		return CorrelationTypeSession
+	case keyLabel(source.key) == CorrelationTypeID || keyLabel(use.key) == CorrelationTypeID || use.occurrence.Location == FlowLocationPath:
+		return CorrelationTypeID
+	}
+	return CorrelationTypeUnknown
+}
+
+// tokenConfidence scores a flow by its label and the length of its value
+func tokenConfidence(flow *TokenFlow) int {
+	confidence := 60
+	switch flow.Type {
+	case CorrelationTypeCSRF, CorrelationTypeSession, CorrelationTypeToken:
+		confidence = 95
+	case CorrelationTypeID:
+		confidence = 85
+	}
+	if len(flow.Value) < 4 {
+		confidence -= 20
+	}
+	return confidence
+}
+
+// variableName derives the variable name of a flow from the name of its source
+func variableName(flow *TokenFlow) string {
+	name := flow.Source.Name
+	if flow.Source.Location == FlowLocationBody && strings.HasPrefix(name, "$") {
+		name = name[strings.LastIndex(name, ".")+1:]
+		if i := strings.Index(name, "["); i >= 0 {
+			name = name[:i]
+		}
+	}
+	if flow.Source.Location == FlowLocationHeader && strings.EqualFold(name, "Location") {
+		name = "id"
+	}
+	name = strings.Trim(variableNamePattern.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		name = string(flow.Type)
+	}
+	return strings.ToLower(name)
+}
+
+// uniqueVariableName returns a name not returned before, adding a number to repeated names
+func uniqueVariableName(name string, names map[string]int) string {
+	names[name]++
+	if names[name] == 1 {
+		return name
+	}
+	return fmt.Sprintf("%s_%d", name, names[name])
+}
+
+// requestPath returns the path of a URL
+func requestPath(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return "/"
+}
+
+// replacePathSegment replaces a segment of the path of a URL
+func replacePathSegment(rawURL string, index int, value string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	segments := strings.Split(u.EscapedPath(), "/")
+	if index <= 0 || index >= len(segments) {
+		return rawURL
+	}
+	segments[index] = value
+	prefix := rawURL[:strings.Index(rawURL, u.Host)+len(u.Host)]
+	result := prefix + strings.Join(segments, "/")
+	if u.RawQuery != "" {
+		result += "?" + u.RawQuery
+	}
+	return result
+}
+
+// replaceQueryValue replaces the value of a query parameter of a URL, keeping the other
+// parameters as they are
+func replaceQueryValue(rawURL, name, value string) string {
+	i := strings.Index(rawURL, "?")
+	if i < 0 {
+		return rawURL
+	}
+	return rawURL[:i+1] + replacePairValue(rawURL[i+1:], "&", name, value)
+}
+
+// replacePairValue replaces the value of a name and value pair of URL encoded pairs
+func replacePairValue(s, separator, name, value string) string {
+	parts := strings.Split(s, separator)
+	for i, part := range parts {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if key, err := url.QueryUnescape(kv[0]); err == nil && key == name {
+			parts[i] = kv[0] + "=" + value
+		}
+	}
+	return strings.Join(parts, separator)
+}
+
+// replaceBodyValue replaces a value of a JSON or form body, at a JSONPath or form parameter
+func replaceBodyValue(body, name, value, variable string) string {
+	if !strings.HasPrefix(name, "$") {
+		return replacePairValue(body, "&", name, variable)
+	}
+	var data interface{}
+	if json.Unmarshal([]byte(body), &data) != nil {
+		return strings.ReplaceAll(body, value, variable)
+	}
+	// Replace the value with a placeholder, then the placeholder with the variable, unquoted for
+	// numbers
+	const placeholder = "\u0000ffuf-variable\u0000"
+	number := false
+	replaced := replaceJSONValue(data, "$", name, placeholder, &number)
+	encoded, err := json.Marshal(replaced)
+	if err != nil {
+		return body
+	}
+	quotedPlaceholder, _ := json.Marshal(placeholder)
+	if number {
+		return strings.Replace(string(encoded), string(quotedPlaceholder), variable, 1)
+	}
+	return strings.Replace(string(encoded), string(quotedPlaceholder), `"`+variable+`"`, 1)
+}
+
+// replaceJSONValue replaces the value at a JSONPath of a JSON value
+func replaceJSONValue(data interface{}, path, target string, replacement interface{}, number *bool) interface{} {
+	if path == target {
+		_, *number = data.(float64)
+		return replacement
+	}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = replaceJSONValue(child, path+"."+key, target, replacement, number)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = replaceJSONValue(child, fmt.Sprintf("%s[%d]", path, i), target, replacement, number)
+		}
+	}
+	return data
+}
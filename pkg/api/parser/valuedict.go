@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxDictionaryDepth caps the depth of the schemas and JSON documents values are collected from
+const maxDictionaryDepth = 8
+
+// ValueDictionary collects candidate values of parameters by name: the enums, examples and
+// defaults of specifications, and the values observed in traffic and responses, such as the
+// identifiers of the items returned by list endpoints. Names are matched case insensitively,
+// ignoring underscores and dashes, so that user_id and userId share their values. It is safe
+// for concurrent use.
+type ValueDictionary struct {
+	// MaxValues caps the number of values kept per parameter
+	MaxValues int
+
+	mu     sync.Mutex
+	params map[string]*dictionaryParameter
+}
+
+// dictionaryParameter holds the values of a parameter, the observed values being preferred to
+// those of specifications
+type dictionaryParameter struct {
+	identifier bool
+	observed   []interface{}
+	spec       []interface{}
+	seen       map[string]bool
+}
+
+// NewValueDictionary creates an empty value dictionary
+func NewValueDictionary() *ValueDictionary {
+	return &ValueDictionary{
+		MaxValues: 20,
+		params:    make(map[string]*dictionaryParameter),
+	}
+}
+
+// NewValueDictionaryFromEndpoints creates a value dictionary from the examples and schemas of
+// the parameters of endpoints
+func NewValueDictionaryFromEndpoints(endpoints []*DiscoveredEndpoint) *ValueDictionary {
+	d := NewValueDictionary()
+	d.AddEndpoints(endpoints)
+	return d
+}
+
+// dictionaryKey normalizes the name of a parameter
+func dictionaryKey(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// isIdentifierName returns true if a parameter name designates an object identifier, e.g. id,
+// user_id, userId or uuid
+func isIdentifierName(name string) bool {
+	lower := strings.ToLower(name)
+	if lower == "id" || lower == "uuid" || lower == "guid" {
+		return true
+	}
+	return strings.HasSuffix(name, "Id") || strings.HasSuffix(name, "ID") ||
+		strings.HasSuffix(lower, "_id") || strings.HasSuffix(lower, "-id") ||
+		strings.HasSuffix(lower, "uuid") || strings.HasSuffix(lower, "guid")
+}
+
+// add adds a scalar value of a parameter
+func (d *ValueDictionary) add(name string, value interface{}, observed bool) {
+	switch value.(type) {
+	case string, float64, int, int64, bool, json.Number:
+	default:
+		return
+	}
+	if s, ok := value.(string); ok && strings.TrimSpace(s) == "" {
+		return
+	}
+	key := dictionaryKey(name)
+	if key == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	param, ok := d.params[key]
+	if !ok {
+		param = &dictionaryParameter{seen: make(map[string]bool)}
+		d.params[key] = param
+	}
+	param.identifier = param.identifier || isIdentifierName(name)
+	text := fmt.Sprint(value)
+	values := &param.spec
+	if observed {
+		values = &param.observed
+	}
+	if observed {
+		text = "observed:" + text
+	}
+	if param.seen[text] || (d.MaxValues > 0 && len(*values) >= d.MaxValues) {
+		return
+	}
+	*values = append(*values, value)
+	param.seen[text] = true
+}
+
+// AddSpecValue adds a value of a parameter taken from a specification
+func (d *ValueDictionary) AddSpecValue(name string, value interface{}) {
+	d.add(name, value, false)
+}
+
+// AddObservedValue adds a value of a parameter observed in traffic or in a response
+func (d *ValueDictionary) AddObservedValue(name string, value interface{}) {
+	d.add(name, value, true)
+}
+
+// AddSchema adds the example, default and enum values of a schema of a parameter, and those of
+// the properties of object schemas and the items of array schemas
+func (d *ValueDictionary) AddSchema(name string, schema *OpenAPISchema) {
+	d.addSchema(name, schema, 0)
+}
+
+func (d *ValueDictionary) addSchema(name string, schema *OpenAPISchema, depth int) {
+	if schema == nil || depth > maxDictionaryDepth {
+		return
+	}
+	d.AddSpecValue(name, schema.Example)
+	d.AddSpecValue(name, schema.Default)
+	for _, value := range schema.Enum {
+		d.AddSpecValue(name, value)
+	}
+	properties := make([]string, 0, len(schema.Properties))
+	for property := range schema.Properties {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+	for _, property := range properties {
+		d.addSchema(property, schema.Properties[property], depth+1)
+	}
+	d.addSchema(name, schema.Items, depth+1)
+}
+
+// AddEndpoints adds the examples and schema values of the parameters of endpoints
+func (d *ValueDictionary) AddEndpoints(endpoints []*DiscoveredEndpoint) {
+	for _, endpoint := range endpoints {
+		for _, param := range endpoint.Parameters {
+			d.AddSpecValue(param.Name, param.Example)
+			d.AddSchema(param.Name, param.Schema)
+		}
+	}
+}
+
+// AddJSON adds the values of the fields of a JSON body of a request to a path or of its
+// response as observed values. The identifiers of the items of a collection, e.g. the id of
+// the items returned by /users, are also added as the identifiers of the resource, userId.
+func (d *ValueDictionary) AddJSON(path string, body []byte) {
+	var data interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// Numbers are kept as written, large identifiers included
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return
+	}
+	resource := ""
+	segments := resourceSegments(path)
+	if last := lastStaticSegment(segments); last >= 0 && last == len(segments)-1 {
+		resource = singularize(segments[last])
+	}
+	d.addDocument("", data, resource, 0)
+}
+
+// addDocument adds the scalar fields of a JSON document. The identifiers of the objects of
+// arrays are added as identifiers of the resource too, if it is known.
+func (d *ValueDictionary) addDocument(name string, data interface{}, resource string, depth int) {
+	if depth > maxDictionaryDepth {
+		return
+	}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			d.addDocument(key, v[key], resource, depth+1)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if object, ok := item.(map[string]interface{}); ok && resource != "" {
+				if id, ok := object["id"]; ok {
+					d.AddObservedValue(resource+"Id", id)
+				}
+			}
+			d.addDocument(name, item, resource, depth+1)
+		}
+	default:
+		if name != "" {
+			d.AddObservedValue(name, v)
+		}
+	}
+}
+
+// Values returns the values of a parameter, the observed values first
+func (d *ValueDictionary) Values(name string) []interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	param, ok := d.params[dictionaryKey(name)]
+	if !ok {
+		return nil
+	}
+	values := make([]interface{}, 0, len(param.observed)+len(param.spec))
+	values = append(values, param.observed...)
+	return append(values, param.spec...)
+}
+
+// Observed returns the observed values of a parameter
+func (d *ValueDictionary) Observed(name string) []interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	param, ok := d.params[dictionaryKey(name)]
+	if !ok {
+		return nil
+	}
+	return append([]interface{}(nil), param.observed...)
+}
+
+// Identifiers returns the values of the identifier parameters, such as id, userId or uuid, the
+// observed values first, without duplicates
+func (d *ValueDictionary) Identifiers() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	keys := make([]string, 0, len(d.params))
+	for key, param := range d.params {
+		if param.identifier {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, observed := range []bool{true, false} {
+		for _, key := range keys {
+			values := d.params[key].spec
+			if observed {
+				values = d.params[key].observed
+			}
+			for _, value := range values {
+				if id := fmt.Sprint(value); !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValueDictionary(t *testing.T) {
+	values := NewValueDictionaryFromEndpoints([]*DiscoveredEndpoint{
+		{Path: "/users", Method: "POST", Parameters: []*DiscoveredParameter{
+			{Name: "role", In: "body", Schema: &OpenAPISchema{Type: "string", Enum: []interface{}{"member", "admin"}}},
+			{Name: "profile", In: "body", Schema: &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{
+				"country": {Type: "string", Example: "FR"},
+			}}},
+		}},
+		{Path: "/users/{user_id}", Method: "GET", Parameters: []*DiscoveredParameter{
+			{Name: "user_id", In: "path", Example: 42},
+		}},
+	})
+	values.AddJSON("/api/users", []byte(`{"data": [{"id": 1001, "role": "admin"}, {"id": 12345678901234567890, "role": "owner"}], "total": 2}`))
+
+	if got := values.Values("role"); !reflect.DeepEqual(got, []interface{}{"admin", "owner", "member", "admin"}) {
+		t.Errorf("Expected the observed roles before the enum, got %v", got)
+	}
+	if got := values.Values("COUNTRY"); !reflect.DeepEqual(got, []interface{}{"FR"}) {
+		t.Errorf("Expected the example of the nested property, got %v", got)
+	}
+	// The identifiers of the items of /api/users are those of the user resource, userId and
+	// user_id sharing their values
+	if got := values.Observed("userId"); !reflect.DeepEqual(got, []interface{}{json.Number("1001"), json.Number("12345678901234567890")}) {
+		t.Errorf("Expected the identifiers of the listed users, got %v", got)
+	}
+	if got := values.Identifiers(); !reflect.DeepEqual(got, []string{"1001", "12345678901234567890", "42"}) {
+		t.Errorf("Expected the observed identifiers first, got %v", got)
+	}
+
+	values.MaxValues = 1
+	values.AddObservedValue("total", 3)
+	values.AddObservedValue("limit", nil)
+	if got := values.Values("total"); len(got) != 1 || values.Values("limit") != nil {
+		t.Errorf("Expected the values to be capped and nil values ignored, got %v", got)
+	}
+}
+
+func TestAPITestGenerator_CandidateValues(t *testing.T) {
+	discovery := NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.Endpoints = []*DiscoveredEndpoint{
+		{Path: "/orders/{orderId}", Method: "GET", Parameters: []*DiscoveredParameter{
+			{Name: "orderId", In: "path", Required: true, Type: "integer", Example: 1},
+			{Name: "status", In: "query", Type: "string"},
+		}},
+		{Path: "/orders", Method: "GET", Parameters: []*DiscoveredParameter{
+			{Name: "status", In: "query", Type: "string", Schema: &OpenAPISchema{Type: "string", Enum: []interface{}{"shipped"}}},
+		}},
+	}
+	generator := NewAPITestGenerator(discovery, nil)
+	generator.Values = NewValueDictionaryFromEndpoints(discovery.Endpoints)
+	generator.Values.AddJSON("/orders", []byte(`[{"id": "ord-7"}, {"id": 77}]`))
+	if err := generator.GenerateTestCases(); err != nil {
+		t.Fatalf("GenerateTestCases returned an error: %s", err)
+	}
+	for _, testCase := range generator.GetTestCasesByCategory("positive") {
+		if testCase.Path != "/orders/{orderId}" {
+			continue
+		}
+		// The observed identifier of the integer type replaces the example of the spec
+		if testCase.PathParams["orderId"] != "77" || testCase.QueryParams["status"] != "shipped" {
+			t.Errorf("Expected the candidate values, got %v and %v", testCase.PathParams, testCase.QueryParams)
+		}
+		return
+	}
+	t.Errorf("Expected a positive test case of /orders/{orderId}")
+}
@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvVariablePrefix prefixes the {{env.NAME}} placeholders resolved from environment variables
+const EnvVariablePrefix = "env."
+
+// variableMaxDepth is the maximum depth of variables referencing other variables
+const variableMaxDepth = 10
+
+// Variables resolves {{name}} placeholders in URLs, headers, bodies and settings. Values are
+// defined in a vars file, set from values extracted by chained test cases, or read from the
+// environment with {{env.NAME}}. Values can reference other variables, and unknown
+// placeholders are left unchanged.
+type Variables struct {
+	// Values maps the names of variables to their value
+	Values map[string]string
+	// LookupEnv looks up environment variables, os.LookupEnv if nil
+	LookupEnv func(name string) (string, bool)
+}
+
+// NewVariables creates a new empty set of variables
+func NewVariables() *Variables {
+	return &Variables{Values: make(map[string]string)}
+}
+
+// LoadVariables loads a vars file: a YAML or JSON object, nested objects being flattened into
+// dot separated names such as auth.token, or a .env file of NAME=value lines
+func LoadVariables(filePath string) (*Variables, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to read vars file: %s", err.Error()), 0)
+	}
+	variables := NewVariables()
+	if strings.EqualFold(filepath.Ext(filePath), ".env") || strings.HasPrefix(strings.ToLower(filepath.Base(filePath)), ".env") {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "export "))
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) != 2 {
+				return nil, api.NewAPIError(fmt.Sprintf("Invalid line in vars file %s: %s", filePath, line), 0)
+			}
+			value := strings.TrimSpace(parts[1])
+			if len(value) > 1 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+				value = value[1 : len(value)-1]
+			}
+			variables.Set(strings.TrimSpace(parts[0]), value)
+		}
+		return variables, nil
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to parse vars file %s: %s", filePath, err.Error()), 0)
+	}
+	variables.flatten("", values)
+	return variables, nil
+}
+
+// flatten sets the values of a decoded vars file, joining the names of nested objects with dots
+func (v *Variables) flatten(prefix string, values map[string]interface{}) {
+	for name, value := range values {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			v.flatten(prefix+name+".", value)
+		case nil:
+			v.Set(prefix+name, "")
+		default:
+			v.Set(prefix+name, fmt.Sprint(value))
+		}
+	}
+}
+
+// Set sets the value of a variable
+func (v *Variables) Set(name, value string) {
+	v.Values[name] = value
+}
+
+// With returns a copy of the variables with additional values, such as the values extracted
+// by chained test cases, taking precedence over the existing values
+func (v *Variables) With(values map[string]string) *Variables {
+	merged := NewVariables()
+	if v != nil {
+		merged.LookupEnv = v.LookupEnv
+		for name, value := range v.Values {
+			merged.Values[name] = value
+		}
+	}
+	for name, value := range values {
+		merged.Values[name] = value
+	}
+	return merged
+}
+
+// lookup returns the value of a variable or an environment variable
+func (v *Variables) lookup(name string) (string, bool) {
+	if strings.HasPrefix(name, EnvVariablePrefix) {
+		lookupEnv := v.LookupEnv
+		if lookupEnv == nil {
+			lookupEnv = os.LookupEnv
+		}
+		return lookupEnv(strings.TrimPrefix(name, EnvVariablePrefix))
+	}
+	value, ok := v.Values[name]
+	return value, ok
+}
+
+// Resolve replaces the {{name}} placeholders of a string with the values of the variables
+func (v *Variables) Resolve(s string) string {
+	if v == nil {
+		return s
+	}
+	for depth := 0; depth < variableMaxDepth && strings.Contains(s, "{{"); depth++ {
+		resolved := postmanVariablePattern.ReplaceAllStringFunc(s, func(match string) string {
+			if value, ok := v.lookup(postmanVariablePattern.FindStringSubmatch(match)[1]); ok {
+				return value
+			}
+			return match
+		})
+		if resolved == s {
+			break
+		}
+		s = resolved
+	}
+	return s
+}
+
+// ResolveMap returns a copy of a map with the placeholders of its values resolved
+func (v *Variables) ResolveMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	resolved := make(map[string]string, len(m))
+	for key, value := range m {
+		resolved[key] = v.Resolve(value)
+	}
+	return resolved
+}
+
+// Unresolved returns the sorted names of the placeholders of a string without a value
+func (v *Variables) Unresolved(s string) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, match := range postmanVariablePattern.FindAllStringSubmatch(v.Resolve(s), -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyTemplate returns a copy of the test case with the {{name}} placeholders of its URL,
+// headers, parameters, body and authentication resolved
+func (t *APITestCase) ApplyTemplate(variables *Variables) *APITestCase {
+	applied := *t
+	applied.URL = variables.Resolve(t.URL)
+	applied.Headers = variables.ResolveMap(t.Headers)
+	applied.QueryParams = variables.ResolveMap(t.QueryParams)
+	applied.PathParams = variables.ResolveMap(t.PathParams)
+	applied.Body = variables.Resolve(t.Body)
+	if t.Auth != nil {
+		auth := *t.Auth
+		auth.Username = variables.Resolve(auth.Username)
+		auth.Password = variables.Resolve(auth.Password)
+		auth.Token = variables.Resolve(auth.Token)
+		auth.ClientID = variables.Resolve(auth.ClientID)
+		auth.ClientSecret = variables.Resolve(auth.ClientSecret)
+		auth.Scope = variables.Resolve(auth.Scope)
+		auth.TokenURL = variables.Resolve(auth.TokenURL)
+		applied.Auth = &auth
+	}
+	return &applied
+}
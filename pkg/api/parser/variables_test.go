@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadVariables(t *testing.T) {
+	dir, err := ioutil.TempDir("", "variables")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	yamlPath := filepath.Join(dir, "vars.yaml")
+	ioutil.WriteFile(yamlPath, []byte("base_url: https://{{env.API_HOST}}/v1\nauth:\n  token: secret\n  ttl: 60\n"), 0644)
+	envPath := filepath.Join(dir, "staging.env")
+	ioutil.WriteFile(envPath, []byte("# Staging\nexport base_url=\"https://staging.example.com\"\ntoken=abc\n"), 0644)
+
+	variables, err := LoadVariables(yamlPath)
+	if err != nil {
+		t.Fatalf("LoadVariables returned an error: %s", err)
+	}
+	expected := map[string]string{"base_url": "https://{{env.API_HOST}}/v1", "auth.token": "secret", "auth.ttl": "60"}
+	if !reflect.DeepEqual(variables.Values, expected) {
+		t.Errorf("Expected %v, got %v", expected, variables.Values)
+	}
+	variables.LookupEnv = func(name string) (string, bool) {
+		if name == "API_HOST" {
+			return "api.example.com", true
+		}
+		return "", false
+	}
+	resolved := variables.Resolve("{{base_url}}/users?token={{ auth.token }}&user={{env.USER_ID}}&q={{payload}}")
+	if resolved != "https://api.example.com/v1/users?token=secret&user={{env.USER_ID}}&q={{payload}}" {
+		t.Errorf("Unexpected resolved string %s", resolved)
+	}
+	if unresolved := variables.Unresolved(resolved); !reflect.DeepEqual(unresolved, []string{"env.USER_ID", "payload"}) {
+		t.Errorf("Unexpected unresolved variables %v", unresolved)
+	}
+	if chained := variables.With(map[string]string{"auth.token": "extracted"}); chained.Resolve("{{auth.token}}") != "extracted" || variables.Resolve("{{auth.token}}") != "secret" {
+		t.Errorf("Expected chained values to take precedence in a copy")
+	}
+
+	variables, err = LoadVariables(envPath)
+	if err != nil {
+		t.Fatalf("LoadVariables returned an error: %s", err)
+	}
+	if variables.Values["base_url"] != "https://staging.example.com" || variables.Values["token"] != "abc" {
+		t.Errorf("Unexpected .env variables %v", variables.Values)
+	}
+
+	// Self-referencing variables stop resolving
+	loop := NewVariables()
+	loop.Set("a", "{{a}}x")
+	if resolved := loop.Resolve("{{a}}"); len(resolved) > 32 {
+		t.Errorf("Expected the resolution depth to be limited, got %s", resolved)
+	}
+}
+
+func TestAPITestCase_ApplyTemplate(t *testing.T) {
+	variables := NewVariables()
+	variables.Set("token", "secret")
+	variables.Set("id", "42")
+	testCase := &APITestCase{
+		URL:         "https://api.example.com/users/{{id}}",
+		Headers:     map[string]string{"X-Id": "{{id}}"},
+		QueryParams: map[string]string{"id": "{{id}}"},
+		Body:        `{"id": {{id}}}`,
+		Auth:        &APITestAuth{Type: "bearer", Token: "{{token}}"},
+	}
+	applied := testCase.ApplyTemplate(variables)
+	if applied.URL != "https://api.example.com/users/42" || applied.Headers["X-Id"] != "42" || applied.QueryParams["id"] != "42" || applied.Body != `{"id": 42}` || applied.Auth.Token != "secret" {
+		t.Errorf("Unexpected applied test case %+v", applied)
+	}
+	if testCase.Auth.Token != "{{token}}" || testCase.URL != "https://api.example.com/users/{{id}}" {
+		t.Errorf("Expected the test case to be left unchanged")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"html/template"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/ffuf/ffuf/v2/pkg/api"
@@ -25,6 +26,10 @@ const (
 	VisFormatDOT VisFormat = "dot"
 	// VisFormatMermaid represents Mermaid format
 	VisFormatMermaid VisFormat = "mermaid"
+	// VisFormatPlantUML represents PlantUML format, supported by sequence diagrams
+	VisFormatPlantUML VisFormat = "plantuml"
+	// VisFormatSVG represents an SVG image, laid out without external tools
+	VisFormatSVG VisFormat = "svg"
 )
 
 // VisType represents the type of visualization
@@ -49,12 +54,18 @@ type VisOptions struct {
 	Type VisType
 	// MaxDepth is the maximum depth to visualize
 	MaxDepth int
+	// MaxItems is the maximum number of items of an array decoded by VisualizeStream, 20 if
+	// not set
+	MaxItems int
 	// IncludeValues indicates whether to include values in the visualization
 	IncludeValues bool
 	// ColorScheme is the color scheme to use
 	ColorScheme string
 	// Title is the title of the visualization
 	Title string
+	// ClassStyles are the styles of the classes of VisualizeEndpoints, such as
+	// "fill:#c8e6c9,stroke:#2e7d32", as Mermaid class definitions and SVG colors
+	ClassStyles map[string]string
 }
 
 // DefaultVisOptions returns default visualization options
@@ -63,6 +74,7 @@ func DefaultVisOptions() *VisOptions {
 		Format:        VisFormatHTML,
 		Type:          VisTypeTree,
 		MaxDepth:      10,
+		MaxItems:      20,
 		IncludeValues: true,
 		ColorScheme:   "default",
 		Title:         "API Response Visualization",
@@ -86,27 +98,33 @@ func NewVisualizer(options *VisOptions) *Visualizer {
 
 // VisualizeResponse generates a visualization of an API response
 func (v *Visualizer) VisualizeResponse(resp *ffuf.Response) (string, error) {
-	// Check if the response is JSON
-	if !strings.Contains(resp.ContentType, "application/json") {
-		return "", api.NewAPIError("Response is not in JSON format", 0)
+	// NDJSON responses are streamed, as they can be large
+	if isNDJSONContentType(resp.ContentType) {
+		return v.VisualizeStream(bytes.NewReader(resp.Data), resp.Request.Url)
 	}
 
-	// Parse the response body as JSON
-	var jsonData interface{}
-	if err := json.Unmarshal(resp.Data, &jsonData); err != nil {
-		return "", api.NewAPIError("Failed to parse JSON: "+err.Error(), 0)
+	// Parse the response body as JSON, or the data of an XML or HTML response
+	jsonData, err := parseStructuredResponse(resp)
+	if err != nil {
+		return "", err
 	}
 
-	// Generate the visualization based on the format
+	return v.visualizeData(jsonData, resp.Request.Url)
+}
+
+// visualizeData generates a visualization of parsed JSON data in the format of the options
+func (v *Visualizer) visualizeData(jsonData interface{}, url string) (string, error) {
 	switch v.options.Format {
 	case VisFormatJSON:
 		return v.generateJSONVisualization(jsonData)
 	case VisFormatHTML:
-		return v.generateHTMLVisualization(jsonData, resp.Request.Url)
+		return v.generateHTMLVisualization(jsonData, url)
 	case VisFormatDOT:
-		return v.generateDOTVisualization(jsonData, resp.Request.Url)
+		return v.generateDOTVisualization(jsonData, url)
 	case VisFormatMermaid:
-		return v.generateMermaidVisualization(jsonData, resp.Request.Url)
+		return v.generateMermaidVisualization(jsonData, url)
+	case VisFormatSVG:
+		return v.generateSVGVisualization(jsonData, url)
 	default:
 		return "", api.NewAPIError("Unsupported visualization format", 0)
 	}
@@ -124,6 +142,8 @@ func (v *Visualizer) VisualizeCorrelations(correlations []Correlation) (string,
 		return v.generateDOTCorrelationVisualization(correlations)
 	case VisFormatMermaid:
 		return v.generateMermaidCorrelationVisualization(correlations)
+	case VisFormatSVG:
+		return v.generateSVGCorrelationVisualization(correlations)
 	default:
 		return "", api.NewAPIError("Unsupported visualization format", 0)
 	}
@@ -141,6 +161,8 @@ func (v *Visualizer) VisualizeSchema(schema *Schema) (string, error) {
 		return v.generateDOTSchemaVisualization(schema)
 	case VisFormatMermaid:
 		return v.generateMermaidSchemaVisualization(schema)
+	case VisFormatSVG:
+		return v.generateSVGSchemaVisualization(schema)
 	default:
 		return "", api.NewAPIError("Unsupported visualization format", 0)
 	}
@@ -990,3 +1012,149 @@ func (p *ResponseParser) VisualizeResponse(resp *ffuf.Response, format VisFormat
 	visualizer := NewVisualizer(options)
 	return visualizer.VisualizeResponse(resp)
 }
+
+// EndpointNode is a path segment of an endpoint map, with the methods of the endpoint ending
+// at the segment
+type EndpointNode struct {
+	Segment  string          `json:"segment"`
+	Methods  []string        `json:"methods,omitempty"`
+	Children []*EndpointNode `json:"children,omitempty"`
+	// paths are the paths of the endpoints of the methods, as discovered
+	paths map[string]string
+}
+
+// VisualizeEndpoints generates a map of endpoints as a tree of their path segments, with a
+// leaf per method. In Mermaid, DOT and SVG, classes assigns a class to the leaf of an endpoint,
+// keyed by "METHOD path". The classes are styled by the ClassStyles option.
+func (v *Visualizer) VisualizeEndpoints(endpoints []*DiscoveredEndpoint, classes map[string]string) (string, error) {
+	root := buildEndpointTree(endpoints)
+	switch v.options.Format {
+	case VisFormatJSON:
+		jsonBytes, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			return "", api.NewAPIError("Failed to generate JSON: "+err.Error(), 0)
+		}
+		return string(jsonBytes), nil
+	case VisFormatDOT:
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf("digraph %s {\n", sanitizeID(v.options.Title)))
+		buf.WriteString("  rankdir=LR;\n  node [shape=box, style=filled, fillcolor=lightblue];\n")
+		buf.WriteString("  n0 [label=\"/\", shape=ellipse, fillcolor=lightgreen];\n")
+		id := 0
+		walkEndpointTree(root, "n0", &id, func(parent, node, label, key string, method bool) {
+			attrs := ""
+			if method {
+				attrs = ", shape=ellipse, fillcolor=white"
+				if class, ok := classes[key]; ok {
+					attrs += fmt.Sprintf(", class=\"%s\"", sanitizeLabel(class))
+				}
+			}
+			buf.WriteString(fmt.Sprintf("  %s [label=\"%s\"%s];\n  %s -> %s;\n", node, sanitizeLabel(label), attrs, parent, node))
+		})
+		buf.WriteString("}\n")
+		return buf.String(), nil
+	case VisFormatMermaid:
+		var buf bytes.Buffer
+		buf.WriteString("graph LR\n  n0((\"/\"))\n")
+		id := 0
+		walkEndpointTree(root, "n0", &id, func(parent, node, label, key string, method bool) {
+			label = strings.ReplaceAll(label, "\"", "#quot;")
+			if method {
+				buf.WriteString(fmt.Sprintf("  %s --> %s([\"%s\"])\n", parent, node, label))
+				if class, ok := classes[key]; ok {
+					buf.WriteString(fmt.Sprintf("  class %s %s\n", node, sanitizeID(class)))
+				}
+			} else {
+				buf.WriteString(fmt.Sprintf("  %s --> %s[\"%s\"]\n", parent, node, label))
+			}
+		})
+		names := make([]string, 0, len(v.options.ClassStyles))
+		for name := range v.options.ClassStyles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			buf.WriteString(fmt.Sprintf("  classDef %s %s\n", sanitizeID(name), v.options.ClassStyles[name]))
+		}
+		return buf.String(), nil
+	case VisFormatSVG:
+		graph := newSVGGraph()
+		graph.addNode("n0", []string{"/"}, "#c8e6c9", "#2e7d32")
+		id := 0
+		walkEndpointTree(root, "n0", &id, func(parent, node, label, key string, method bool) {
+			fill, stroke := "", ""
+			if method {
+				fill, stroke = "white", "#555555"
+				if class, ok := classes[key]; ok {
+					if classFill, classStroke := svgClassStyle(v.options.ClassStyles[class]); classFill != "" {
+						fill, stroke = classFill, classStroke
+					}
+				}
+			}
+			graph.addNode(node, []string{label}, fill, stroke)
+			graph.addEdge(parent, node, "", "", false)
+		})
+		return graph.render(v.options.Title), nil
+	default:
+		return "", api.NewAPIError("Unsupported visualization format", 0)
+	}
+}
+
+// buildEndpointTree builds the tree of the path segments of endpoints, sorted by segment
+func buildEndpointTree(endpoints []*DiscoveredEndpoint) *EndpointNode {
+	root := &EndpointNode{Segment: "/", paths: make(map[string]string)}
+	for _, endpoint := range endpoints {
+		node := root
+		for _, segment := range strings.Split(strings.Trim(endpoint.Path, "/"), "/") {
+			if segment == "" {
+				continue
+			}
+			var child *EndpointNode
+			for _, c := range node.Children {
+				if c.Segment == segment {
+					child = c
+					break
+				}
+			}
+			if child == nil {
+				child = &EndpointNode{Segment: segment, paths: make(map[string]string)}
+				node.Children = append(node.Children, child)
+			}
+			node = child
+		}
+		method := strings.ToUpper(endpoint.Method)
+		if !contains(node.Methods, method) {
+			node.Methods = append(node.Methods, method)
+			node.paths[method] = endpoint.Path
+		}
+	}
+	sortEndpointTree(root)
+	return root
+}
+
+// sortEndpointTree sorts the methods and children of a node recursively
+func sortEndpointTree(node *EndpointNode) {
+	sort.Strings(node.Methods)
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Segment < node.Children[j].Segment
+	})
+	for _, child := range node.Children {
+		sortEndpointTree(child)
+	}
+}
+
+// walkEndpointTree calls visit for the methods and children of a node, depth first, with the
+// IDs of the parent and of the visited node. Method leaves have the "METHOD path" key of their
+// endpoint.
+func walkEndpointTree(node *EndpointNode, nodeID string, id *int, visit func(parent, node, label, key string, method bool)) {
+	for _, method := range node.Methods {
+		*id++
+		visit(nodeID, fmt.Sprintf("n%d", *id), method, method+" "+node.paths[method], true)
+	}
+	for _, child := range node.Children {
+		*id++
+		childID := fmt.Sprintf("n%d", *id)
+		visit(nodeID, childID, child.Segment, "", false)
+		walkEndpointTree(child, childID, id, visit)
+	}
+}
@@ -325,3 +325,50 @@ func TestSanitizeFunctions(t *testing.T) {
 		}
 	}
 }
+
+func TestVisualizeEndpoints(t *testing.T) {
+	endpoints := []*DiscoveredEndpoint{
+		{Method: "GET", Path: "/users"},
+		{Method: "POST", Path: "/users"},
+		{Method: "GET", Path: "/users/{id}"},
+		{Method: "get", Path: "/health"},
+	}
+	classes := map[string]string{"GET /users/{id}": "tested"}
+
+	v := NewVisualizer(&VisOptions{Format: VisFormatMermaid, Title: "API"})
+	diagram, err := v.VisualizeEndpoints(endpoints, classes)
+	if err != nil {
+		t.Fatalf("VisualizeEndpoints returned an error: %s", err)
+	}
+	if !strings.HasPrefix(diagram, "graph LR\n") {
+		t.Errorf("Expected a Mermaid graph, got %s", diagram)
+	}
+	if strings.Count(diagram, `["users"]`) != 1 || strings.Count(diagram, `(["GET"])`) != 3 || strings.Count(diagram, `(["POST"])`) != 1 {
+		t.Errorf("Expected a node per path segment and a leaf per method, got %s", diagram)
+	}
+	if strings.Count(diagram, "  class ") != 1 || !strings.Contains(diagram, " tested\n") {
+		t.Errorf("Expected the class of the tested endpoint, got %s", diagram)
+	}
+	// Segments are sorted, so health comes before users
+	if strings.Index(diagram, `"health"`) > strings.Index(diagram, `"users"`) {
+		t.Errorf("Expected sorted segments, got %s", diagram)
+	}
+
+	v = NewVisualizer(&VisOptions{Format: VisFormatJSON})
+	output, err := v.VisualizeEndpoints(endpoints, nil)
+	if err != nil {
+		t.Fatalf("VisualizeEndpoints returned an error: %s", err)
+	}
+	var root EndpointNode
+	if err := json.Unmarshal([]byte(output), &root); err != nil {
+		t.Fatalf("Expected valid JSON: %s", err)
+	}
+	if len(root.Children) != 2 || root.Children[1].Segment != "users" || len(root.Children[1].Methods) != 2 {
+		t.Errorf("Unexpected endpoint tree: %s", output)
+	}
+
+	v = NewVisualizer(&VisOptions{Format: VisFormatHTML})
+	if _, err := v.VisualizeEndpoints(endpoints, nil); err == nil {
+		t.Errorf("Expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,420 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// Kinds of well-known locations
+const (
+	// WellKnownOpenAPI is an OpenAPI/Swagger specification
+	WellKnownOpenAPI = "openapi"
+	// WellKnownSwaggerResources is a Springfox list of OpenAPI/Swagger specifications
+	WellKnownSwaggerResources = "swagger-resources"
+	// WellKnownAsyncAPI is an AsyncAPI specification
+	WellKnownAsyncAPI = "asyncapi"
+	// WellKnownGraphQL is a GraphQL endpoint accepting introspection queries
+	WellKnownGraphQL = "graphql"
+	// WellKnownRobots is a robots.txt file
+	WellKnownRobots = "robots"
+	// WellKnownSitemap is a sitemap or sitemap index
+	WellKnownSitemap = "sitemap"
+)
+
+// WellKnownLocation is a path checked for API specifications and endpoint lists
+type WellKnownLocation struct {
+	// Path of the location on the target
+	Path string
+	// Kind of document expected at the location
+	Kind string
+}
+
+// DefaultWellKnownLocations are the locations checked by default
+var DefaultWellKnownLocations = []WellKnownLocation{
+	{"/openapi.json", WellKnownOpenAPI},
+	{"/swagger.json", WellKnownOpenAPI},
+	{"/v2/api-docs", WellKnownOpenAPI},
+	{"/v3/api-docs", WellKnownOpenAPI},
+	{"/api-docs", WellKnownOpenAPI},
+	{"/api-docs.json", WellKnownOpenAPI},
+	{"/.well-known/openapi", WellKnownOpenAPI},
+	{"/.well-known/openapi.json", WellKnownOpenAPI},
+	{"/swagger/v1/swagger.json", WellKnownOpenAPI},
+	{"/api/openapi.json", WellKnownOpenAPI},
+	{"/api/swagger.json", WellKnownOpenAPI},
+	{"/api/v1/openapi.json", WellKnownOpenAPI},
+	{"/api/v1/swagger.json", WellKnownOpenAPI},
+	{"/docs/openapi.json", WellKnownOpenAPI},
+	{"/swagger-resources", WellKnownSwaggerResources},
+	{"/asyncapi.json", WellKnownAsyncAPI},
+	{"/graphql", WellKnownGraphQL},
+	{"/api/graphql", WellKnownGraphQL},
+	{"/v1/graphql", WellKnownGraphQL},
+	{"/robots.txt", WellKnownRobots},
+	{"/sitemap.xml", WellKnownSitemap},
+}
+
+// WellKnownResult is a location where a document was found
+type WellKnownResult struct {
+	// URL of the document
+	URL string
+	// Kind of the document
+	Kind string
+	// Endpoints found in the document
+	Endpoints []*DiscoveredEndpoint
+}
+
+// WellKnownProber checks the well-known locations of a target for API specifications, GraphQL
+// endpoints, robots.txt files and sitemaps, and parses the documents found
+type WellKnownProber struct {
+	// Locations checked on the target
+	Locations []WellKnownLocation
+	// Headers sent with every request (e.g., Authorization)
+	Headers map[string]string
+	// Maximum number of sitemaps fetched, including the sitemaps of indexes and robots.txt files
+	MaxSitemaps int
+	// Maximum number of URLs read from sitemaps
+	MaxSitemapURLs int
+	// Maximum size of a fetched document
+	MaxBodySize int64
+	// HTTP client used to fetch documents
+	Client *http.Client
+
+	origin   *url.URL
+	sitemaps map[string]bool
+	pages    map[string]*DiscoveredEndpoint
+	urls     int
+}
+
+// sitemapDocument is a sitemap or sitemap index
+type sitemapDocument struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// NewWellKnownProber creates a new WellKnownProber
+func NewWellKnownProber() *WellKnownProber {
+	return &WellKnownProber{
+		Locations:      DefaultWellKnownLocations,
+		Headers:        make(map[string]string),
+		MaxSitemaps:    10,
+		MaxSitemapURLs: 1000,
+		MaxBodySize:    10 * 1024 * 1024,
+		Client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Probe checks the well-known locations of a target and returns the documents found. Documents
+// which cannot be parsed, such as the pages returned for any path, are ignored.
+func (p *WellKnownProber) Probe(targetURL string) ([]*WellKnownResult, error) {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil || parsedURL.Host == "" {
+		return nil, api.NewAPIError(fmt.Sprintf("Invalid URL: %s", targetURL), 0)
+	}
+	p.origin = &url.URL{Scheme: parsedURL.Scheme, Host: parsedURL.Host}
+	p.sitemaps = make(map[string]bool)
+	p.pages = make(map[string]*DiscoveredEndpoint)
+	p.urls = 0
+
+	results := make([]*WellKnownResult, 0)
+	sitemaps := make([]string, 0)
+	for _, location := range p.Locations {
+		locationURL := p.origin.String() + location.Path
+		var endpoints []*DiscoveredEndpoint
+		switch location.Kind {
+		case WellKnownGraphQL:
+			endpoints = p.probeGraphQL(locationURL)
+		case WellKnownSitemap:
+			sitemaps = append(sitemaps, locationURL)
+			continue
+		default:
+			data, err := p.fetch(locationURL)
+			if err != nil {
+				continue
+			}
+			switch location.Kind {
+			case WellKnownOpenAPI:
+				endpoints = p.parseOpenAPI(data)
+			case WellKnownSwaggerResources:
+				endpoints = p.parseSwaggerResources(data)
+			case WellKnownAsyncAPI:
+				endpoints = p.parseAsyncAPI(data)
+			case WellKnownRobots:
+				var robotsSitemaps []string
+				endpoints, robotsSitemaps = p.parseRobots(data)
+				sitemaps = append(sitemaps, robotsSitemaps...)
+			}
+		}
+		if len(endpoints) > 0 {
+			results = append(results, &WellKnownResult{URL: locationURL, Kind: location.Kind, Endpoints: endpoints})
+		}
+	}
+
+	for _, sitemapURL := range sitemaps {
+		if endpoints := p.probeSitemap(sitemapURL); len(endpoints) > 0 {
+			results = append(results, &WellKnownResult{URL: sitemapURL, Kind: WellKnownSitemap, Endpoints: endpoints})
+		}
+	}
+	return results, nil
+}
+
+// fetch fetches a document and returns its body
+func (p *WellKnownProber) fetch(documentURL string) ([]byte, error) {
+	req, err := http.NewRequest("GET", documentURL, nil)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to create request: %s", err.Error()), 0)
+	}
+	for name, value := range p.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to fetch %s: %s", documentURL, err.Error()), 0)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, api.NewAPIError(fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, resp.Status), 0)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, p.MaxBodySize))
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to read response body: %s", err.Error()), 0)
+	}
+	return data, nil
+}
+
+// parseOpenAPI returns the endpoints of an OpenAPI/Swagger specification
+func (p *WellKnownProber) parseOpenAPI(data []byte) []*DiscoveredEndpoint {
+	parser := NewOpenAPIParser()
+	if err := parser.ParseJSON(data); err != nil {
+		return nil
+	}
+
+	// Servers relative to the specification are relative to the target
+	baseURL := p.origin.String()
+	if parser.Spec.BaseURL != "" {
+		if ref, err := url.Parse(parser.Spec.BaseURL); err == nil {
+			baseURL = p.origin.ResolveReference(ref).String()
+		}
+	}
+	discovery := NewAPIEndpointDiscovery(baseURL)
+	discovery.addOpenAPIEndpoints(parser)
+	return discovery.Endpoints
+}
+
+// parseSwaggerResources returns the endpoints of the specifications listed by Springfox
+func (p *WellKnownProber) parseSwaggerResources(data []byte) []*DiscoveredEndpoint {
+	var resources []struct {
+		Location string `json:"location"`
+		URL      string `json:"url"`
+	}
+	if err := json.Unmarshal(data, &resources); err != nil {
+		return nil
+	}
+
+	endpoints := make([]*DiscoveredEndpoint, 0)
+	for _, resource := range resources {
+		location := resource.URL
+		if location == "" {
+			location = resource.Location
+		}
+		ref, err := url.Parse(location)
+		if err != nil || location == "" {
+			continue
+		}
+		specURL := p.origin.ResolveReference(ref)
+		if specURL.Host != p.origin.Host {
+			continue
+		}
+		if spec, err := p.fetch(specURL.String()); err == nil {
+			endpoints = append(endpoints, p.parseOpenAPI(spec)...)
+		}
+	}
+	return endpoints
+}
+
+// parseAsyncAPI returns the endpoints of an AsyncAPI specification
+func (p *WellKnownProber) parseAsyncAPI(data []byte) []*DiscoveredEndpoint {
+	parser := NewAsyncAPIParser()
+	if err := parser.ParseJSON(data); err != nil {
+		return nil
+	}
+	return parser.GetEndpoints()
+}
+
+// probeGraphQL returns the operations of a GraphQL endpoint accepting introspection queries
+func (p *WellKnownProber) probeGraphQL(endpointURL string) []*DiscoveredEndpoint {
+	parser := NewGraphQLSchemaParser(endpointURL)
+	parser.Client = p.Client
+	for name, value := range p.Headers {
+		parser.Headers[name] = value
+	}
+	if err := parser.Introspect(); err != nil {
+		return nil
+	}
+	return parser.GetEndpoints()
+}
+
+// parseRobots returns the paths allowed or disallowed by a robots.txt file, and the sitemaps it lists
+func (p *WellKnownProber) parseRobots(data []byte) ([]*DiscoveredEndpoint, []string) {
+	endpoints := make([]*DiscoveredEndpoint, 0)
+	sitemaps := make([]string, 0)
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.SplitN(scanner.Text(), "#", 2)[0])
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		directive, value := strings.ToLower(strings.TrimSpace(parts[0])), strings.TrimSpace(parts[1])
+		switch directive {
+		case "sitemap":
+			if ref, err := url.Parse(value); err == nil && value != "" {
+				sitemaps = append(sitemaps, p.origin.ResolveReference(ref).String())
+			}
+		case "allow", "disallow":
+			// Wildcards and end anchors are cut, keeping the literal prefix of the rule
+			if i := strings.IndexAny(value, "*$"); i >= 0 {
+				value = value[:i]
+			}
+			if !strings.HasPrefix(value, "/") || value == "/" || seen[value] {
+				continue
+			}
+			seen[value] = true
+			endpoints = append(endpoints, &DiscoveredEndpoint{
+				URL:         p.origin.String() + value,
+				Method:      "GET",
+				Path:        value,
+				Parameters:  make([]*DiscoveredParameter, 0),
+				Description: fmt.Sprintf("%s rule of robots.txt", strings.ToUpper(directive[:1])+directive[1:]),
+				Tags:        make([]string, 0),
+				Source:      "robots.txt",
+			})
+		}
+	}
+	return endpoints, sitemaps
+}
+
+// probeSitemap returns the pages listed by a sitemap and the sitemaps of a sitemap index, with
+// identifier segments of their paths replaced by {name} placeholders
+func (p *WellKnownProber) probeSitemap(sitemapURL string) []*DiscoveredEndpoint {
+	endpoints := make([]*DiscoveredEndpoint, 0)
+	queue := []string{sitemapURL}
+	for len(queue) > 0 && len(p.sitemaps) < p.MaxSitemaps {
+		current := queue[0]
+		queue = queue[1:]
+		if p.sitemaps[current] {
+			continue
+		}
+		p.sitemaps[current] = true
+
+		data, err := p.fetch(current)
+		if err != nil {
+			continue
+		}
+		if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+			reader, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				continue
+			}
+			data, err = ioutil.ReadAll(io.LimitReader(reader, p.MaxBodySize))
+			if err != nil {
+				continue
+			}
+		}
+		var document sitemapDocument
+		if err := xml.Unmarshal(data, &document); err != nil {
+			continue
+		}
+
+		for _, loc := range document.Sitemaps {
+			if ref, err := url.Parse(strings.TrimSpace(loc)); err == nil {
+				if resolved := p.origin.ResolveReference(ref); resolved.Host == p.origin.Host {
+					queue = append(queue, resolved.String())
+				}
+			}
+		}
+		for _, loc := range document.URLs {
+			if p.urls >= p.MaxSitemapURLs {
+				break
+			}
+			p.urls++
+			if endpoint := p.addPage(strings.TrimSpace(loc)); endpoint != nil {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+	return endpoints
+}
+
+// addPage adds a page listed by a sitemap, returning nil if it is on another host or the same
+// endpoint as a page already listed
+func (p *WellKnownProber) addPage(loc string) *DiscoveredEndpoint {
+	pageURL, err := url.Parse(loc)
+	if err != nil || pageURL.Host != p.origin.Host {
+		return nil
+	}
+	path, params := normalizeHARPath(pageURL.Path)
+	if endpoint, ok := p.pages[path]; ok {
+		// Query parameters of other pages of the same endpoint
+		for name, values := range pageURL.Query() {
+			addDiscoveredParameter(endpoint, name, "query", values[0])
+		}
+		return nil
+	}
+
+	endpoint := &DiscoveredEndpoint{
+		URL:         p.origin.String() + path,
+		Method:      "GET",
+		Path:        path,
+		Parameters:  make([]*DiscoveredParameter, 0),
+		Description: "Listed in sitemap",
+		Tags:        make([]string, 0),
+		Source:      "Sitemap",
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := segment[1 : len(segment)-1]
+			addDiscoveredParameter(endpoint, name, "path", params[name])
+		}
+	}
+	for name, values := range pageURL.Query() {
+		addDiscoveredParameter(endpoint, name, "query", values[0])
+	}
+	p.pages[path] = endpoint
+	return endpoint
+}
+
+// addDiscoveredParameter adds a parameter with an example value to an endpoint if it is not already known
+func addDiscoveredParameter(endpoint *DiscoveredEndpoint, name, in, example string) {
+	for _, param := range endpoint.Parameters {
+		if param.Name == name && param.In == in {
+			return
+		}
+	}
+	endpoint.Parameters = append(endpoint.Parameters, &DiscoveredParameter{
+		Name:     name,
+		In:       in,
+		Required: in == "path",
+		Type:     inferStringType(example),
+		Example:  example,
+	})
+}
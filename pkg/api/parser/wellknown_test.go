@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const wellKnownTestSpec = `{
+	"openapi": "3.0.0",
+	"info": {"title": "Shop API", "version": "1.0.0"},
+	"servers": [{"url": "/api"}],
+	"paths": {
+		"/products": {"get": {"responses": {"200": {"description": "OK"}}}},
+		"/products/{productId}": {"get": {"parameters": [{"name": "productId", "in": "path", "required": true, "schema": {"type": "integer"}}], "responses": {"200": {"description": "OK"}}}}
+	}
+}`
+
+const wellKnownTestRobots = `User-agent: *
+Disallow: /admin/
+Disallow: /internal/*.json$
+Allow: /   # everything else
+Sitemap: /sitemap_index.xml
+`
+
+// newWellKnownTestServer creates a server exposing an OpenAPI specification, a GraphQL
+// endpoint, a robots.txt file and sitemaps, and returning the same page for any other path
+func newWellKnownTestServer() *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v3/api-docs":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(wellKnownTestSpec))
+		case "/graphql":
+			if r.Method != "POST" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testGraphQLIntrospection))
+		case "/robots.txt":
+			w.Write([]byte(wellKnownTestRobots))
+		case "/sitemap.xml":
+			w.Write([]byte(`<urlset><url><loc>` + server.URL + `/about</loc></url><url><loc>https://other.example.com/</loc></url></urlset>`))
+		case "/sitemap_index.xml":
+			w.Write([]byte(`<sitemapindex><sitemap><loc>` + server.URL + `/sitemap-products.xml.gz</loc></sitemap></sitemapindex>`))
+		case "/sitemap-products.xml.gz":
+			var products bytes.Buffer
+			writer := gzip.NewWriter(&products)
+			writer.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>` + server.URL + `/products/1001</loc></url>
+  <url><loc>` + server.URL + `/products/1002?ref=sitemap</loc></url>
+</urlset>`))
+			writer.Close()
+			w.Write(products.Bytes())
+		default:
+			// Single page application fallback
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<!DOCTYPE html><html><body><div id="app"></div></body></html>`))
+		}
+	}))
+	return server
+}
+
+func TestWellKnownProber_Probe(t *testing.T) {
+	server := newWellKnownTestServer()
+	defer server.Close()
+
+	prober := NewWellKnownProber()
+	prober.Headers["X-Api-Key"] = "secret"
+	results, err := prober.Probe(server.URL + "/app/")
+	if err != nil {
+		t.Fatalf("Probe returned an error: %s", err)
+	}
+
+	byKind := make(map[string][]*WellKnownResult)
+	for _, result := range results {
+		byKind[result.Kind] = append(byKind[result.Kind], result)
+	}
+	if len(byKind[WellKnownOpenAPI]) != 1 || byKind[WellKnownOpenAPI][0].URL != server.URL+"/v3/api-docs" {
+		t.Fatalf("Expected the OpenAPI specification only at /v3/api-docs, got %d", len(byKind[WellKnownOpenAPI]))
+	}
+	spec := byKind[WellKnownOpenAPI][0].Endpoints
+	if len(spec) != 2 || !strings.HasPrefix(spec[0].URL, server.URL+"/api/products") {
+		t.Errorf("Expected the specification endpoints under the relative server URL, got %d", len(spec))
+	}
+	if len(byKind[WellKnownGraphQL]) != 1 || len(byKind[WellKnownGraphQL][0].Endpoints) != 2 {
+		t.Errorf("Expected the GraphQL operations of /graphql only")
+	}
+
+	if len(byKind[WellKnownRobots]) != 1 {
+		t.Fatalf("Expected the robots.txt file")
+	}
+	paths := make([]string, 0)
+	for _, endpoint := range byKind[WellKnownRobots][0].Endpoints {
+		paths = append(paths, endpoint.Path)
+	}
+	if strings.Join(paths, ",") != "/admin/,/internal/" {
+		t.Errorf("Expected the robots.txt rules, got %v", paths)
+	}
+
+	if len(byKind[WellKnownSitemap]) != 2 {
+		t.Fatalf("Expected the sitemap and the sitemap index of robots.txt, got %d", len(byKind[WellKnownSitemap]))
+	}
+	var pages []*DiscoveredEndpoint
+	for _, result := range byKind[WellKnownSitemap] {
+		if result.URL == server.URL+"/sitemap_index.xml" {
+			pages = result.Endpoints
+		}
+	}
+	if len(pages) != 1 || pages[0].Path != "/products/{productId}" {
+		t.Fatalf("Expected the product pages of the sitemap index as a single endpoint, got %d", len(pages))
+	}
+	names := make(map[string]string)
+	for _, param := range pages[0].Parameters {
+		names[param.Name] = param.In
+	}
+	if names["productId"] != "path" || names["ref"] != "query" {
+		t.Errorf("Expected the path and query parameters of the product pages, got %v", names)
+	}
+}
+
+func TestAPIEndpointDiscovery_DiscoverFromWellKnown(t *testing.T) {
+	server := newWellKnownTestServer()
+	defer server.Close()
+
+	discovery := NewAPIEndpointDiscovery("")
+	discovery.Endpoints = append(discovery.Endpoints, &DiscoveredEndpoint{Method: "GET", URL: server.URL + "/api/products", Path: "/products", Source: "HAR"})
+	results, err := discovery.DiscoverFromWellKnown(server.URL, map[string]string{"X-Api-Key": "secret"})
+	if err != nil {
+		t.Fatalf("DiscoverFromWellKnown returned an error: %s", err)
+	}
+	if len(results) != 5 {
+		t.Errorf("Expected 5 documents, got %d", len(results))
+	}
+	// 1 known endpoint, 1 new from the specification, 2 GraphQL operations, 2 robots.txt rules and 2 sitemap endpoints
+	if len(discovery.Endpoints) != 8 {
+		t.Errorf("Expected 8 endpoints once merged, got %d", len(discovery.Endpoints))
+	}
+	if discovery.Endpoints[0].Source != "HAR" || discovery.BaseURL != server.URL {
+		t.Errorf("Expected known endpoints to be kept and the base URL to be set")
+	}
+
+	// Without the key, every location is rejected
+	if results, err := NewAPIEndpointDiscovery("").DiscoverFromWellKnown(server.URL, nil); err != nil || len(results) != 0 {
+		t.Errorf("Expected no documents without the key, got %d", len(results))
+	}
+}
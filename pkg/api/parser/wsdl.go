@@ -0,0 +1,632 @@
+// Package parser provides functionality for parsing API responses and specifications.
+package parser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// WSDLVersion represents the version of a WSDL document
+type WSDLVersion string
+
+const (
+	// WSDLV11 represents WSDL 1.1
+	WSDLV11 WSDLVersion = "1.1"
+	// WSDLV20 represents WSDL 2.0
+	WSDLV20 WSDLVersion = "2.0"
+)
+
+// envelopePlaceholderPattern matches {{name}} placeholders in envelope templates
+var envelopePlaceholderPattern = regexp.MustCompile(`{{[^{}]+}}`)
+
+// SOAP envelope namespaces
+const (
+	soap11EnvelopeNS = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12EnvelopeNS = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// WSDLParser provides methods for parsing WSDL 1.1 and 2.0 service descriptions
+type WSDLParser struct {
+	// Version of the parsed document
+	Version WSDLVersion
+	// Name of the service
+	ServiceName string
+	// Target namespace of the service
+	TargetNamespace string
+	// Service endpoint address
+	Address string
+	// Whether the binding uses SOAP 1.2
+	SOAP12 bool
+	// Extracted operations
+	Operations []*WSDLOperation
+	// Complex types defined in the embedded XML schema, by name
+	ComplexTypes map[string]*WSDLComplexType
+	// HTTP client used to fetch documents from URLs
+	Client *http.Client
+
+	elements map[string]*xmlNode
+	messages map[string]*xmlNode
+}
+
+// WSDLOperation represents a SOAP operation extracted from a WSDL document
+type WSDLOperation struct {
+	// Name of the operation
+	Name string
+	// Documentation of the operation
+	Documentation string
+	// SOAPAction header value
+	SOAPAction string
+	// Name of the wrapper element inside the SOAP body
+	InputElement string
+	// Input fields of the operation
+	Fields []*WSDLField
+}
+
+// WSDLComplexType represents an XML schema complex type
+type WSDLComplexType struct {
+	// Name of the type
+	Name string
+	// Fields of the type
+	Fields []*WSDLField
+}
+
+// WSDLField represents an element of an operation input or a complex type
+type WSDLField struct {
+	// Name of the element
+	Name string
+	// XML schema type name without namespace prefix
+	Type string
+	// Whether the element is required (minOccurs > 0)
+	Required bool
+	// Nested fields when the type is a complex type
+	Fields []*WSDLField
+}
+
+// xmlNode is a generic XML element used to walk WSDL documents regardless of prefixes
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []*xmlNode `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// attr returns the value of an attribute by local name
+func (n *xmlNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// children returns direct children with the given local name
+func (n *xmlNode) children(name string) []*xmlNode {
+	result := make([]*xmlNode, 0)
+	for _, c := range n.Children {
+		if c.XMLName.Local == name {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// child returns the first direct child with the given local name
+func (n *xmlNode) child(name string) *xmlNode {
+	for _, c := range n.Children {
+		if c.XMLName.Local == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// NewWSDLParser creates a new WSDLParser
+func NewWSDLParser() *WSDLParser {
+	return &WSDLParser{
+		Operations:   make([]*WSDLOperation, 0),
+		ComplexTypes: make(map[string]*WSDLComplexType),
+		elements:     make(map[string]*xmlNode),
+		messages:     make(map[string]*xmlNode),
+		Client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// ParseFromFile parses a WSDL document from a file
+func (p *WSDLParser) ParseFromFile(filePath string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to read WSDL file: %s", err.Error()), 0)
+	}
+	return p.ParseXML(data)
+}
+
+// ParseFromURL parses a WSDL document from a URL (e.g., https://host/service?wsdl)
+func (p *WSDLParser) ParseFromURL(wsdlURL string) error {
+	if _, err := url.Parse(wsdlURL); err != nil {
+		return api.NewAPIError(fmt.Sprintf("Invalid URL: %s", err.Error()), 0)
+	}
+
+	resp, err := p.Client.Get(wsdlURL)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to fetch WSDL: %s", err.Error()), 0)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return api.NewAPIError(fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, resp.Status), 0)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to read response body: %s", err.Error()), 0)
+	}
+	return p.ParseXML(data)
+}
+
+// ParseXML parses a WSDL document from XML data
+func (p *WSDLParser) ParseXML(data []byte) error {
+	var root xmlNode
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to parse WSDL: %s", err.Error()), 0)
+	}
+
+	switch root.XMLName.Local {
+	case "definitions":
+		p.Version = WSDLV11
+	case "description":
+		p.Version = WSDLV20
+	default:
+		return api.NewAPIError(fmt.Sprintf("Invalid WSDL document: unexpected root element %s", root.XMLName.Local), 0)
+	}
+
+	p.TargetNamespace = root.attr("targetNamespace")
+	p.parseTypes(&root)
+
+	if p.Version == WSDLV11 {
+		p.parseV11(&root)
+	} else {
+		p.parseV20(&root)
+	}
+
+	return nil
+}
+
+// parseTypes collects global elements and complex types from embedded XML schemas
+func (p *WSDLParser) parseTypes(root *xmlNode) {
+	types := root.child("types")
+	if types == nil {
+		return
+	}
+
+	for _, schema := range types.children("schema") {
+		for _, el := range schema.children("element") {
+			if name := el.attr("name"); name != "" {
+				p.elements[name] = el
+			}
+		}
+		for _, ct := range schema.children("complexType") {
+			if name := ct.attr("name"); name != "" {
+				p.ComplexTypes[name] = &WSDLComplexType{Name: name}
+				p.ComplexTypes[name].Fields = p.complexTypeFields(ct, 0)
+			}
+		}
+	}
+
+	// Resolve named complex types referenced by fields now that all types are known
+	for _, ct := range p.ComplexTypes {
+		p.resolveFields(ct.Fields, 0)
+	}
+}
+
+// complexTypeFields returns the element fields of a complexType node
+func (p *WSDLParser) complexTypeFields(ct *xmlNode, depth int) []*WSDLField {
+	fields := make([]*WSDLField, 0)
+	if ct == nil || depth > 5 {
+		return fields
+	}
+
+	for _, group := range ct.Children {
+		switch group.XMLName.Local {
+		case "sequence", "all", "choice":
+			for _, el := range group.children("element") {
+				fields = append(fields, p.elementField(el, depth))
+			}
+		case "complexContent":
+			// Flatten extensions of a base type
+			if ext := group.child("extension"); ext != nil {
+				fields = append(fields, p.complexTypeFields(ext, depth+1)...)
+				if base, ok := p.ComplexTypes[stripPrefix(ext.attr("base"))]; ok {
+					fields = append(fields, base.Fields...)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// elementField converts an xsd:element node into a field
+func (p *WSDLParser) elementField(el *xmlNode, depth int) *WSDLField {
+	name := el.attr("name")
+	typeName := stripPrefix(el.attr("type"))
+	if ref := el.attr("ref"); ref != "" && name == "" {
+		name = stripPrefix(ref)
+		if refEl, ok := p.elements[name]; ok {
+			typeName = stripPrefix(refEl.attr("type"))
+		}
+	}
+
+	field := &WSDLField{
+		Name:     name,
+		Type:     typeName,
+		Required: el.attr("minOccurs") != "0",
+	}
+	if inline := el.child("complexType"); inline != nil {
+		field.Type = "object"
+		field.Fields = p.complexTypeFields(inline, depth+1)
+	}
+	return field
+}
+
+// resolveFields attaches nested fields for fields that reference named complex types
+func (p *WSDLParser) resolveFields(fields []*WSDLField, depth int) {
+	if depth > 5 {
+		return
+	}
+	for _, f := range fields {
+		if ct, ok := p.ComplexTypes[f.Type]; ok && len(f.Fields) == 0 {
+			f.Fields = copyFields(ct.Fields)
+			p.resolveFields(f.Fields, depth+1)
+		} else if len(f.Fields) > 0 {
+			p.resolveFields(f.Fields, depth+1)
+		}
+	}
+}
+
+// elementFields returns the fields of a global element used as an operation input
+func (p *WSDLParser) elementFields(name string) []*WSDLField {
+	el, ok := p.elements[name]
+	if !ok {
+		return make([]*WSDLField, 0)
+	}
+	if inline := el.child("complexType"); inline != nil {
+		fields := p.complexTypeFields(inline, 0)
+		p.resolveFields(fields, 0)
+		return fields
+	}
+	if ct, ok := p.ComplexTypes[stripPrefix(el.attr("type"))]; ok {
+		return copyFields(ct.Fields)
+	}
+	return make([]*WSDLField, 0)
+}
+
+// parseV11 extracts operations from a WSDL 1.1 document
+func (p *WSDLParser) parseV11(root *xmlNode) {
+	for _, msg := range root.children("message") {
+		p.messages[msg.attr("name")] = msg
+	}
+
+	// SOAP actions and version from the binding
+	actions := make(map[string]string)
+	for _, binding := range root.children("binding") {
+		if b := binding.child("binding"); b != nil && b.XMLName.Space == "http://schemas.xmlsoap.org/wsdl/soap12/" {
+			p.SOAP12 = true
+		}
+		for _, op := range binding.children("operation") {
+			if soapOp := op.child("operation"); soapOp != nil {
+				actions[op.attr("name")] = soapOp.attr("soapAction")
+			}
+		}
+	}
+
+	for _, service := range root.children("service") {
+		p.ServiceName = service.attr("name")
+		for _, port := range service.children("port") {
+			if addr := port.child("address"); addr != nil && p.Address == "" {
+				p.Address = addr.attr("location")
+			}
+		}
+	}
+
+	for _, portType := range root.children("portType") {
+		for _, op := range portType.children("operation") {
+			operation := &WSDLOperation{
+				Name:         op.attr("name"),
+				SOAPAction:   actions[op.attr("name")],
+				InputElement: op.attr("name"),
+				Fields:       make([]*WSDLField, 0),
+			}
+			if doc := op.child("documentation"); doc != nil {
+				operation.Documentation = strings.TrimSpace(doc.Text)
+			}
+
+			if input := op.child("input"); input != nil {
+				if msg, ok := p.messages[stripPrefix(input.attr("message"))]; ok {
+					for _, part := range msg.children("part") {
+						if element := stripPrefix(part.attr("element")); element != "" {
+							// Document/literal: the part element wraps the fields
+							operation.InputElement = element
+							operation.Fields = append(operation.Fields, p.elementFields(element)...)
+						} else {
+							// RPC style: each part is a field
+							field := &WSDLField{Name: part.attr("name"), Type: stripPrefix(part.attr("type")), Required: true}
+							p.resolveFields([]*WSDLField{field}, 0)
+							operation.Fields = append(operation.Fields, field)
+						}
+					}
+				}
+			}
+			p.Operations = append(p.Operations, operation)
+		}
+	}
+}
+
+// parseV20 extracts operations from a WSDL 2.0 document
+func (p *WSDLParser) parseV20(root *xmlNode) {
+	actions := make(map[string]string)
+	for _, binding := range root.children("binding") {
+		if strings.Contains(binding.attr("version"), "1.2") || binding.attr("protocol") != "" {
+			p.SOAP12 = true
+		}
+		for _, op := range binding.children("operation") {
+			actions[stripPrefix(op.attr("ref"))] = op.attr("action")
+		}
+	}
+
+	for _, service := range root.children("service") {
+		p.ServiceName = service.attr("name")
+		for _, endpoint := range service.children("endpoint") {
+			if p.Address == "" {
+				p.Address = endpoint.attr("address")
+			}
+		}
+	}
+
+	for _, iface := range root.children("interface") {
+		for _, op := range iface.children("operation") {
+			operation := &WSDLOperation{
+				Name:         op.attr("name"),
+				SOAPAction:   actions[op.attr("name")],
+				InputElement: op.attr("name"),
+				Fields:       make([]*WSDLField, 0),
+			}
+			if doc := op.child("documentation"); doc != nil {
+				operation.Documentation = strings.TrimSpace(doc.Text)
+			}
+			if input := op.child("input"); input != nil {
+				if element := stripPrefix(input.attr("element")); element != "" {
+					operation.InputElement = element
+					operation.Fields = p.elementFields(element)
+				}
+			}
+			p.Operations = append(p.Operations, operation)
+		}
+	}
+}
+
+// GetOperations returns all extracted operations
+func (p *WSDLParser) GetOperations() []*WSDLOperation {
+	return p.Operations
+}
+
+// GenerateEnvelopeTemplate generates a SOAP envelope for an operation where every
+// leaf value is a {{name}} placeholder
+func (p *WSDLParser) GenerateEnvelopeTemplate(op *WSDLOperation) string {
+	envNS := soap11EnvelopeNS
+	if p.SOAP12 {
+		envNS = soap12EnvelopeNS
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buf.WriteString(fmt.Sprintf(`<soapenv:Envelope xmlns:soapenv="%s" xmlns:tns="%s">`+"\n", envNS, p.TargetNamespace))
+	buf.WriteString("  <soapenv:Header/>\n")
+	buf.WriteString("  <soapenv:Body>\n")
+	buf.WriteString(fmt.Sprintf("    <tns:%s>\n", op.InputElement))
+	writeEnvelopeFields(&buf, op.Fields, "", 3)
+	buf.WriteString(fmt.Sprintf("    </tns:%s>\n", op.InputElement))
+	buf.WriteString("  </soapenv:Body>\n")
+	buf.WriteString("</soapenv:Envelope>")
+	return buf.String()
+}
+
+// GenerateEnvelope generates a SOAP envelope with example values, placing the FUZZ
+// marker in the field named fuzzField (dot-separated for nested fields)
+func (p *WSDLParser) GenerateEnvelope(op *WSDLOperation, fuzzField string) string {
+	values := make(map[string]string)
+	for _, name := range op.FieldPaths() {
+		values[name] = "test"
+	}
+	if fuzzField != "" {
+		values[fuzzField] = "FUZZ"
+	}
+	return FillSOAPEnvelope(p.GenerateEnvelopeTemplate(op), values)
+}
+
+// GenerateXXEEnvelopes generates envelopes that declare an external entity pointing to
+// target (e.g., file:///etc/passwd) and reference it from each field in turn
+func (p *WSDLParser) GenerateXXEEnvelopes(op *WSDLOperation, target string) []string {
+	const marker = "FFUFXXEREFERENCE"
+	envelopes := make([]string, 0)
+	doctype := fmt.Sprintf(`<!DOCTYPE foo [<!ENTITY xxe SYSTEM "%s">]>`, target)
+
+	for _, field := range op.FieldPaths() {
+		values := make(map[string]string)
+		for _, name := range op.FieldPaths() {
+			values[name] = "test"
+		}
+		values[field] = marker
+
+		// The entity reference must not be escaped, so substitute it after filling
+		envelope := FillSOAPEnvelope(p.GenerateEnvelopeTemplate(op), values)
+		envelope = strings.Replace(envelope, marker, "&xxe;", 1)
+		envelope = strings.Replace(envelope, "?>\n", "?>\n"+doctype+"\n", 1)
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes
+}
+
+// GetEndpoints converts the operations to endpoints that share the service address
+func (p *WSDLParser) GetEndpoints() []*DiscoveredEndpoint {
+	endpoints := make([]*DiscoveredEndpoint, 0)
+	path := "/"
+	if parsed, err := url.Parse(p.Address); err == nil && parsed.Path != "" {
+		path = parsed.Path
+	}
+
+	for _, op := range p.Operations {
+		endpoint := &DiscoveredEndpoint{
+			URL:         p.Address,
+			Method:      "POST",
+			Path:        path,
+			Description: op.Documentation,
+			Operation:   op.Name,
+			Document:    p.GenerateEnvelopeTemplate(op),
+			Parameters:  make([]*DiscoveredParameter, 0),
+			Tags:        []string{"soap"},
+			Source:      "WSDL",
+		}
+		if p.ServiceName != "" {
+			endpoint.Tags = append(endpoint.Tags, p.ServiceName)
+		}
+
+		if op.SOAPAction != "" || !p.SOAP12 {
+			endpoint.Parameters = append(endpoint.Parameters, &DiscoveredParameter{
+				Name:     "SOAPAction",
+				In:       "header",
+				Required: !p.SOAP12,
+				Type:     "string",
+				Example:  fmt.Sprintf("%q", op.SOAPAction),
+			})
+		}
+		for _, name := range op.FieldPaths() {
+			field := wsdlFindField(op.Fields, name)
+			endpoint.Parameters = append(endpoint.Parameters, &DiscoveredParameter{
+				Name:     name,
+				In:       "body",
+				Required: field != nil && field.Required,
+				Type:     xsdParameterType(field),
+			})
+		}
+
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// FillSOAPEnvelope replaces {{name}} placeholders in an envelope template with XML-escaped values.
+// Placeholders without a value are replaced with an empty string.
+func FillSOAPEnvelope(template string, values map[string]string) string {
+	result := template
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var escaped bytes.Buffer
+		xml.EscapeText(&escaped, []byte(values[name]))
+		result = strings.ReplaceAll(result, "{{"+name+"}}", escaped.String())
+	}
+	return envelopePlaceholderPattern.ReplaceAllString(result, "")
+}
+
+// FieldPaths returns the dot-separated paths of all leaf input fields
+func (op *WSDLOperation) FieldPaths() []string {
+	return wsdlFieldPaths(op.Fields)
+}
+
+// wsdlFieldPaths returns the dot-separated paths of all leaf fields
+func wsdlFieldPaths(fields []*WSDLField) []string {
+	paths := make([]string, 0)
+	for _, f := range fields {
+		if len(f.Fields) > 0 {
+			for _, sub := range wsdlFieldPaths(f.Fields) {
+				paths = append(paths, f.Name+"."+sub)
+			}
+			continue
+		}
+		paths = append(paths, f.Name)
+	}
+	return paths
+}
+
+// writeEnvelopeFields writes fields as XML elements with {{path}} placeholders
+func writeEnvelopeFields(buf *bytes.Buffer, fields []*WSDLField, prefix string, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for _, f := range fields {
+		path := f.Name
+		if prefix != "" {
+			path = prefix + "." + f.Name
+		}
+		if len(f.Fields) > 0 {
+			buf.WriteString(fmt.Sprintf("%s<tns:%s>\n", pad, f.Name))
+			writeEnvelopeFields(buf, f.Fields, path, indent+1)
+			buf.WriteString(fmt.Sprintf("%s</tns:%s>\n", pad, f.Name))
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("%s<tns:%s>{{%s}}</tns:%s>\n", pad, f.Name, path, f.Name))
+	}
+}
+
+// wsdlFindField returns the leaf field at a dot-separated path
+func wsdlFindField(fields []*WSDLField, path string) *WSDLField {
+	parts := strings.SplitN(path, ".", 2)
+	for _, f := range fields {
+		if f.Name != parts[0] {
+			continue
+		}
+		if len(parts) == 1 {
+			return f
+		}
+		return wsdlFindField(f.Fields, parts[1])
+	}
+	return nil
+}
+
+// xsdParameterType maps an XML schema type to the parameter type names used by the parser package
+func xsdParameterType(field *WSDLField) string {
+	if field == nil {
+		return "string"
+	}
+	switch field.Type {
+	case "int", "integer", "long", "short", "byte", "unsignedInt", "unsignedLong", "positiveInteger", "nonNegativeInteger":
+		return "integer"
+	case "decimal", "float", "double":
+		return "number"
+	case "boolean":
+		return "boolean"
+	}
+	return "string"
+}
+
+// stripPrefix removes the namespace prefix of a qualified name
+func stripPrefix(qname string) string {
+	if idx := strings.LastIndex(qname, ":"); idx >= 0 {
+		return qname[idx+1:]
+	}
+	return qname
+}
+
+// copyFields deep-copies a list of fields
+func copyFields(fields []*WSDLField) []*WSDLField {
+	result := make([]*WSDLField, 0, len(fields))
+	for _, f := range fields {
+		c := *f
+		c.Fields = copyFields(f.Fields)
+		result = append(result, &c)
+	}
+	return result
+}
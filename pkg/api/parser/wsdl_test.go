@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const testWSDL11 = `<?xml version="1.0" encoding="UTF-8"?>
+<definitions xmlns="http://schemas.xmlsoap.org/wsdl/"
+	xmlns:soap="http://schemas.xmlsoap.org/wsdl/soap/"
+	xmlns:xsd="http://www.w3.org/2001/XMLSchema"
+	xmlns:tns="urn:users"
+	targetNamespace="urn:users">
+	<types>
+		<xsd:schema targetNamespace="urn:users">
+			<xsd:complexType name="Address">
+				<xsd:sequence>
+					<xsd:element name="city" type="xsd:string"/>
+					<xsd:element name="zip" type="xsd:string" minOccurs="0"/>
+				</xsd:sequence>
+			</xsd:complexType>
+			<xsd:element name="CreateUser">
+				<xsd:complexType>
+					<xsd:sequence>
+						<xsd:element name="name" type="xsd:string"/>
+						<xsd:element name="age" type="xsd:int" minOccurs="0"/>
+						<xsd:element name="address" type="tns:Address"/>
+					</xsd:sequence>
+				</xsd:complexType>
+			</xsd:element>
+		</xsd:schema>
+	</types>
+	<message name="CreateUserRequest">
+		<part name="parameters" element="tns:CreateUser"/>
+	</message>
+	<message name="DeleteUserRequest">
+		<part name="id" type="xsd:int"/>
+	</message>
+	<portType name="UserPort">
+		<operation name="CreateUser">
+			<documentation>Creates a user</documentation>
+			<input message="tns:CreateUserRequest"/>
+		</operation>
+		<operation name="DeleteUser">
+			<input message="tns:DeleteUserRequest"/>
+		</operation>
+	</portType>
+	<binding name="UserBinding" type="tns:UserPort">
+		<soap:binding style="document" transport="http://schemas.xmlsoap.org/soap/http"/>
+		<operation name="CreateUser">
+			<soap:operation soapAction="urn:users#CreateUser"/>
+		</operation>
+		<operation name="DeleteUser">
+			<soap:operation soapAction="urn:users#DeleteUser"/>
+		</operation>
+	</binding>
+	<service name="UserService">
+		<port name="UserPort" binding="tns:UserBinding">
+			<soap:address location="https://soap.example.com/services/users"/>
+		</port>
+	</service>
+</definitions>`
+
+const testWSDL20 = `<?xml version="1.0" encoding="UTF-8"?>
+<description xmlns="http://www.w3.org/ns/wsdl"
+	xmlns:xs="http://www.w3.org/2001/XMLSchema"
+	xmlns:tns="urn:orders"
+	targetNamespace="urn:orders">
+	<types>
+		<xs:schema targetNamespace="urn:orders">
+			<xs:element name="getOrder">
+				<xs:complexType>
+					<xs:sequence>
+						<xs:element name="orderId" type="xs:long"/>
+					</xs:sequence>
+				</xs:complexType>
+			</xs:element>
+		</xs:schema>
+	</types>
+	<interface name="OrderInterface">
+		<operation name="getOrder" pattern="http://www.w3.org/ns/wsdl/in-out">
+			<input element="tns:getOrder"/>
+		</operation>
+	</interface>
+	<binding name="OrderBinding" interface="tns:OrderInterface" type="http://www.w3.org/ns/wsdl/soap">
+		<operation ref="tns:getOrder" action="urn:orders#getOrder"/>
+	</binding>
+	<service name="OrderService" interface="tns:OrderInterface">
+		<endpoint name="OrderEndpoint" binding="tns:OrderBinding" address="https://soap.example.com/orders"/>
+	</service>
+</description>`
+
+func TestWSDLParser_ParseXML11(t *testing.T) {
+	parser := NewWSDLParser()
+	if err := parser.ParseXML([]byte(testWSDL11)); err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+
+	if parser.Version != WSDLV11 {
+		t.Errorf("Expected version 1.1, got %s", parser.Version)
+	}
+	if parser.Address != "https://soap.example.com/services/users" {
+		t.Errorf("Unexpected address '%s'", parser.Address)
+	}
+
+	ops := parser.GetOperations()
+	if len(ops) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(ops))
+	}
+
+	create := ops[0]
+	if create.SOAPAction != "urn:users#CreateUser" {
+		t.Errorf("Unexpected SOAPAction '%s'", create.SOAPAction)
+	}
+	paths := strings.Join(create.FieldPaths(), ",")
+	if paths != "name,age,address.city,address.zip" {
+		t.Errorf("Unexpected field paths '%s'", paths)
+	}
+
+	del := ops[1]
+	if len(del.Fields) != 1 || del.Fields[0].Name != "id" || del.Fields[0].Type != "int" {
+		t.Errorf("Unexpected RPC fields %+v", del.Fields)
+	}
+}
+
+func TestWSDLParser_ParseXML20(t *testing.T) {
+	parser := NewWSDLParser()
+	if err := parser.ParseXML([]byte(testWSDL20)); err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+
+	if parser.Version != WSDLV20 {
+		t.Errorf("Expected version 2.0, got %s", parser.Version)
+	}
+	ops := parser.GetOperations()
+	if len(ops) != 1 || ops[0].SOAPAction != "urn:orders#getOrder" {
+		t.Fatalf("Unexpected operations %+v", ops)
+	}
+
+	endpoints := parser.GetEndpoints()
+	if len(endpoints) != 1 || endpoints[0].Path != "/orders" {
+		t.Fatalf("Unexpected endpoints %+v", endpoints)
+	}
+	for _, param := range endpoints[0].Parameters {
+		if param.Name == "orderId" && param.Type != "integer" {
+			t.Errorf("Expected integer type for orderId, got '%s'", param.Type)
+		}
+	}
+}
+
+func TestWSDLParser_GenerateEnvelope(t *testing.T) {
+	parser := NewWSDLParser()
+	if err := parser.ParseXML([]byte(testWSDL11)); err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+	op := parser.GetOperations()[0]
+
+	envelope := parser.GenerateEnvelope(op, "address.city")
+	if !strings.Contains(envelope, "<tns:city>FUZZ</tns:city>") {
+		t.Errorf("Expected fuzz marker in city element, got:\n%s", envelope)
+	}
+	if !strings.Contains(envelope, `xmlns:tns="urn:users"`) {
+		t.Errorf("Expected target namespace in envelope, got:\n%s", envelope)
+	}
+	var doc interface{}
+	if err := xml.Unmarshal([]byte(envelope), &doc); err != nil {
+		t.Errorf("Generated envelope is not well-formed XML: %v", err)
+	}
+
+	filled := FillSOAPEnvelope(parser.GenerateEnvelopeTemplate(op), map[string]string{"name": "<b>&"})
+	if !strings.Contains(filled, "<tns:name>&lt;b&gt;&amp;</tns:name>") {
+		t.Errorf("Expected escaped value, got:\n%s", filled)
+	}
+
+	xxe := parser.GenerateXXEEnvelopes(op, "file:///etc/passwd")
+	if len(xxe) != 4 {
+		t.Fatalf("Expected 4 XXE envelopes, got %d", len(xxe))
+	}
+	if !strings.Contains(xxe[0], `<!ENTITY xxe SYSTEM "file:///etc/passwd">`) || !strings.Contains(xxe[0], "<tns:name>&xxe;</tns:name>") {
+		t.Errorf("Unexpected XXE envelope:\n%s", xxe[0])
+	}
+}
+
+func TestBuildRequestBody_WSDL(t *testing.T) {
+	parser := NewWSDLParser()
+	if err := parser.ParseXML([]byte(testWSDL11)); err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+	endpoint := parser.GetEndpoints()[1]
+
+	body := buildRequestBody(endpoint, map[string]interface{}{"id": 42})
+	if !strings.Contains(body, "<tns:id>42</tns:id>") {
+		t.Errorf("Expected id value in envelope, got:\n%s", body)
+	}
+	if requestContentType(endpoint) != "text/xml; charset=utf-8" {
+		t.Errorf("Unexpected content type '%s'", requestContentType(endpoint))
+	}
+}
@@ -0,0 +1,197 @@
+package payload
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+
+	"github.com/ffuf/pencode/pkg/pencode"
+)
+
+// ValueEncoder encodes a fuzz value
+type ValueEncoder func(value string) string
+
+// valueEncoders are the encoders of fuzz values, by name. The encoders of pencode, which
+// ffuf uses for the -enc option, are available too.
+var valueEncoders = map[string]ValueEncoder{
+	"urlencode":       urlEncode,
+	"urlencodeall":    urlEncodeAll,
+	"doubleurlencode": func(value string) string { return urlEncode(urlEncode(value)) },
+	"base64":          func(value string) string { return base64.StdEncoding.EncodeToString([]byte(value)) },
+	"htmlentities":    html.EscapeString,
+	"htmlentitiesall": htmlEntitiesAll,
+	"unicodeescape":   unicodeEscape,
+	"upper":           strings.ToUpper,
+	"lower":           strings.ToLower,
+	"mixedcase":       mixedCase,
+	"nullbyte":        func(value string) string { return value + "%00" },
+	"rawnullbyte":     func(value string) string { return value + "\x00" },
+}
+
+// AvailableEncoders returns the names of the encoders of fuzz values
+func AvailableEncoders() []string {
+	names := make([]string, 0, len(valueEncoders))
+	for name := range valueEncoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EncoderChain applies encoders to fuzz values one after another, e.g. to test the
+// decoding performed by a web application firewall and the application behind it
+type EncoderChain struct {
+	names    []string
+	encoders []ValueEncoder
+}
+
+// NewEncoderChain creates an encoder chain applying the named encoders in order
+func NewEncoderChain(names []string) (*EncoderChain, error) {
+	chain := &EncoderChain{}
+	for _, name := range names {
+		encoder, ok := valueEncoders[name]
+		if !ok {
+			// Fall back to the encoders of pencode
+			pchain := pencode.NewChain()
+			if err := pchain.Initialize([]string{name}); err != nil {
+				return nil, api.NewAPIError(fmt.Sprintf("Unknown encoder: %s, available encoders are: %s", name, strings.Join(AvailableEncoders(), ", ")), 0)
+			}
+			encoder = func(value string) string {
+				encoded, err := pchain.Encode([]byte(value))
+				if err != nil {
+					return value
+				}
+				return string(encoded)
+			}
+		}
+		chain.names = append(chain.names, name)
+		chain.encoders = append(chain.encoders, encoder)
+	}
+	return chain, nil
+}
+
+// ParseEncoderChain creates an encoder chain from encoder names separated by spaces or '|',
+// e.g. "upper|urlencode"
+func ParseEncoderChain(spec string) (*EncoderChain, error) {
+	names := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == '|' || unicode.IsSpace(r)
+	})
+	return NewEncoderChain(names)
+}
+
+// Encode applies the encoders of the chain to a value
+func (c *EncoderChain) Encode(value string) string {
+	if c == nil {
+		return value
+	}
+	for _, encoder := range c.encoders {
+		value = encoder(value)
+	}
+	return value
+}
+
+// EncodeAll applies the encoders of the chain to values
+func (c *EncoderChain) EncodeAll(values []string) []string {
+	if c == nil || len(c.encoders) == 0 {
+		return values
+	}
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		encoded[i] = c.Encode(value)
+	}
+	return encoded
+}
+
+// String returns the encoder names of the chain separated by '|'
+func (c *EncoderChain) String() string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(c.names, "|")
+}
+
+// EncodeVariants returns the values followed by their encodings by each chain, skipping
+// encodings identical to the value
+func EncodeVariants(values []string, chains []*EncoderChain) []string {
+	variants := make([]string, 0, len(values)*(len(chains)+1))
+	for _, value := range values {
+		variants = append(variants, value)
+		seen := map[string]bool{value: true}
+		for _, chain := range chains {
+			encoded := chain.Encode(value)
+			if !seen[encoded] {
+				seen[encoded] = true
+				variants = append(variants, encoded)
+			}
+		}
+	}
+	return variants
+}
+
+// SetEncoderChain sets the encoder chain applied to the fuzz values before their insertion
+func (g *PayloadGenerator) SetEncoderChain(chain *EncoderChain) {
+	g.encoders = chain
+}
+
+// urlEncode percent-encodes the characters of a value other than the unreserved characters
+func urlEncode(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < 0x80 && (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-._~", c) >= 0) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// urlEncodeAll percent-encodes every byte of a value
+func urlEncodeAll(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		fmt.Fprintf(&b, "%%%02X", value[i])
+	}
+	return b.String()
+}
+
+// htmlEntitiesAll encodes every character of a value as a numeric HTML entity
+func htmlEntitiesAll(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		fmt.Fprintf(&b, "&#x%x;", r)
+	}
+	return b.String()
+}
+
+// unicodeEscape encodes every character of a value as a \uXXXX escape, with surrogate pairs
+// for characters outside the basic multilingual plane
+func unicodeEscape(value string) string {
+	var b strings.Builder
+	for _, r := range utf16.Encode([]rune(value)) {
+		fmt.Fprintf(&b, "\\u%04x", r)
+	}
+	return b.String()
+}
+
+// mixedCase alternates the case of the letters of a value, starting with upper case
+func mixedCase(value string) string {
+	letters := 0
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) {
+			return r
+		}
+		letters++
+		if letters%2 == 1 {
+			return unicode.ToUpper(r)
+		}
+		return unicode.ToLower(r)
+	}, value)
+}
@@ -0,0 +1,73 @@
+package payload
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncoderChain_Encode(t *testing.T) {
+	tests := []struct {
+		spec  string
+		value string
+		want  string
+	}{
+		{spec: "urlencode", value: "' OR 1=1--", want: "%27%20OR%201%3D1--"},
+		{spec: "doubleurlencode", value: "<a>", want: "%253Ca%253E"},
+		{spec: "urlencodeall", value: "ab", want: "%61%62"},
+		{spec: "base64", value: "admin", want: "YWRtaW4="},
+		{spec: "htmlentities", value: `<"x">`, want: "&lt;&#34;x&#34;&gt;"},
+		{spec: "htmlentitiesall", value: "<a", want: "&#x3c;&#x61;"},
+		{spec: "unicodeescape", value: "a<😀", want: `\u0061\u003c\ud83d\ude00`},
+		{spec: "upper", value: "select", want: "SELECT"},
+		{spec: "lower", value: "SELECT", want: "select"},
+		{spec: "mixedcase", value: "union select", want: "UnIoN sElEcT"},
+		{spec: "nullbyte", value: "file.php", want: "file.php%00"},
+		{spec: "rawnullbyte", value: "a", want: "a\x00"},
+		{spec: "mixedcase|urlencode", value: "or 1", want: "Or%201"},
+		{spec: "upper base64", value: "a", want: "QQ=="},
+		{spec: "hexencode", value: "a", want: "61"},
+		{spec: "", value: "unchanged", want: "unchanged"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			chain, err := ParseEncoderChain(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseEncoderChain() error = %v", err)
+			}
+			if got := chain.Encode(tt.value); got != tt.want {
+				t.Errorf("Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseEncoderChain("urlencode|rot13"); err == nil {
+		t.Error("ParseEncoderChain() expected an error for an unknown encoder")
+	}
+}
+
+func TestEncodeVariants(t *testing.T) {
+	upper, _ := ParseEncoderChain("upper")
+	url, _ := ParseEncoderChain("urlencode")
+
+	got := EncodeVariants([]string{"a b", "1"}, []*EncoderChain{upper, url})
+	want := []string{"a b", "A B", "a%20b", "1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EncodeVariants() = %q, want %q", got, want)
+	}
+}
+
+func TestPayloadGenerator_EncoderChain(t *testing.T) {
+	chain, _ := ParseEncoderChain("base64")
+	generator := NewPayloadGenerator(FormatJSON)
+	generator.SetEncoderChain(chain)
+
+	payloads, err := generator.FuzzJSON(`{"user":"x"}`, "user", []string{"admin"})
+	if err != nil {
+		t.Fatalf("FuzzJSON() error = %v", err)
+	}
+	if len(payloads) != 1 || !strings.Contains(payloads[0], `"user": "YWRtaW4="`) {
+		t.Errorf("FuzzJSON() = %v", payloads)
+	}
+}
@@ -0,0 +1,290 @@
+package payload
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// DefaultMultipartBoundary is the boundary of the multipart bodies created by the generator.
+// A fixed boundary keeps the generated payloads reproducible.
+const DefaultMultipartBoundary = "ffufFormBoundary7MA4YWxkTrZu0gW"
+
+// FormTarget is the part of a form field that the fuzz marker is inserted into
+type FormTarget int
+
+const (
+	// FormTargetValue targets the value of a field
+	FormTargetValue FormTarget = iota
+	// FormTargetName targets the name of a field
+	FormTargetName
+	// FormTargetFilename targets the filename of a file field
+	FormTargetFilename
+	// FormTargetContent targets the content of a file field
+	FormTargetContent
+	// FormTargetContentType targets the content type of a file field
+	FormTargetContentType
+)
+
+// FormField is a field of a form. Fields with a filename are sent as files in multipart bodies.
+type FormField struct {
+	Name  string
+	Value string
+	// Filename is the name of the uploaded file
+	Filename string
+	// ContentType is the content type of the uploaded file
+	ContentType string
+	// Content is the content of the uploaded file
+	Content []byte
+}
+
+// IsFile checks if the field is a file upload
+func (f FormField) IsFile() bool {
+	return f.Filename != "" || f.Content != nil
+}
+
+// SetMultipartBoundary sets the boundary of the generated multipart bodies
+func (g *PayloadGenerator) SetMultipartBoundary(boundary string) {
+	g.boundary = boundary
+}
+
+// MultipartContentType returns the Content-Type header of the generated multipart bodies
+func (g *PayloadGenerator) MultipartContentType() string {
+	return "multipart/form-data; boundary=" + g.multipartBoundary()
+}
+
+// GenerateFormURLEncoded creates an application/x-www-form-urlencoded body from a template
+// (e.g. "user=john&role=user") with the fuzz marker in the name or value of a field. The
+// field is added if the template does not contain it. The order of the fields is kept.
+func (g *PayloadGenerator) GenerateFormURLEncoded(template string, field string, target FormTarget) (string, error) {
+	if g.format != FormatFormData {
+		return "", api.NewAPIError("Generator is not configured for form data payloads", 0)
+	}
+
+	fields, err := parseFormURLEncoded(template)
+	if err != nil {
+		return "", err
+	}
+	fields, err = setFormFuzzMarker(fields, field, target)
+	if err != nil {
+		return "", err
+	}
+	return encodeFormURLEncoded(fields), nil
+}
+
+// FuzzFormURLEncoded creates multiple form bodies by replacing the fuzz marker with the
+// URL-encoded values
+func (g *PayloadGenerator) FuzzFormURLEncoded(template string, field string, target FormTarget, values []string) ([]string, error) {
+	templateForm, err := g.GenerateFormURLEncoded(template, field, target)
+	if err != nil {
+		return nil, err
+	}
+	values = g.encoders.EncodeAll(values)
+
+	payloads := make([]string, len(values))
+	for i, value := range values {
+		payloads[i] = strings.ReplaceAll(templateForm, FuzzMarker, url.QueryEscape(value))
+	}
+	return payloads, nil
+}
+
+// GenerateMultipart creates a multipart/form-data body with the fuzz marker in the name,
+// value, filename, content type or content of a field. The field is added if it does not
+// exist. Use MultipartContentType for the Content-Type header of the body.
+func (g *PayloadGenerator) GenerateMultipart(fields []FormField, field string, target FormTarget) (string, error) {
+	if g.format != FormatFormData {
+		return "", api.NewAPIError("Generator is not configured for form data payloads", 0)
+	}
+
+	fields, err := setFormFuzzMarker(append([]FormField{}, fields...), field, target)
+	if err != nil {
+		return "", err
+	}
+	return g.encodeMultipart(fields)
+}
+
+// FuzzMultipart creates multiple multipart bodies by replacing the fuzz marker with the values.
+// Values are inserted as is, so that filenames can contain path traversal sequences.
+func (g *PayloadGenerator) FuzzMultipart(fields []FormField, field string, target FormTarget, values []string) ([]string, error) {
+	templateBody, err := g.GenerateMultipart(fields, field, target)
+	if err != nil {
+		return nil, err
+	}
+	values = g.encoders.EncodeAll(values)
+
+	payloads := make([]string, len(values))
+	for i, value := range values {
+		payloads[i] = strings.ReplaceAll(templateBody, FuzzMarker, value)
+	}
+	return payloads, nil
+}
+
+// GenerateUploads creates a multipart body per upload payload, sending the file of the payload
+// in the file field and the other fields unchanged
+func (g *PayloadGenerator) GenerateUploads(fields []FormField, fileField string, uploads []UploadPayload) ([]string, error) {
+	if g.format != FormatFormData {
+		return nil, api.NewAPIError("Generator is not configured for form data payloads", 0)
+	}
+
+	payloads := make([]string, 0, len(uploads))
+	for _, upload := range uploads {
+		file := FormField{
+			Name:        fileField,
+			Filename:    upload.Filename,
+			ContentType: upload.ContentType,
+			Content:     upload.Content,
+		}
+		body, err := g.encodeMultipart(replaceFormField(fields, file))
+		if err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, body)
+	}
+	return payloads, nil
+}
+
+// multipartBoundary returns the boundary of the generated multipart bodies
+func (g *PayloadGenerator) multipartBoundary() string {
+	if g.boundary == "" {
+		return DefaultMultipartBoundary
+	}
+	return g.boundary
+}
+
+// encodeMultipart encodes the fields as a multipart body
+func (g *PayloadGenerator) encodeMultipart(fields []FormField) (string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	if err := writer.SetBoundary(g.multipartBoundary()); err != nil {
+		return "", api.NewAPIError("Invalid multipart boundary: "+err.Error(), 0)
+	}
+
+	for _, field := range fields {
+		header := make(textproto.MIMEHeader)
+		disposition := fmt.Sprintf(`form-data; name="%s"`, escapeFormQuotes(field.Name))
+		content := []byte(field.Value)
+		if field.IsFile() {
+			disposition += fmt.Sprintf(`; filename="%s"`, escapeFormQuotes(field.Filename))
+			contentType := field.ContentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			header.Set("Content-Type", contentType)
+			content = field.Content
+		}
+		header.Set("Content-Disposition", disposition)
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return "", api.NewAPIError("Failed to create multipart body: "+err.Error(), 0)
+		}
+		part.Write(content)
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", api.NewAPIError("Failed to create multipart body: "+err.Error(), 0)
+	}
+	return buf.String(), nil
+}
+
+// setFormFuzzMarker inserts the fuzz marker into a field, adding the field if needed
+func setFormFuzzMarker(fields []FormField, name string, target FormTarget) ([]FormField, error) {
+	if name == "" {
+		return nil, api.NewAPIError("A form field name is required", 0)
+	}
+
+	index := -1
+	for i, field := range fields {
+		if field.Name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		fields = append(fields, FormField{Name: name})
+		index = len(fields) - 1
+	}
+
+	field := &fields[index]
+	switch target {
+	case FormTargetValue:
+		field.Value = FuzzMarker
+	case FormTargetName:
+		field.Name = FuzzMarker
+	case FormTargetFilename:
+		field.Filename = FuzzMarker
+	case FormTargetContent:
+		if field.Filename == "" {
+			field.Filename = "upload.txt"
+		}
+		field.Content = []byte(FuzzMarker)
+	case FormTargetContentType:
+		if field.Filename == "" {
+			field.Filename = "upload.txt"
+		}
+		field.ContentType = FuzzMarker
+	default:
+		return nil, api.NewAPIError(fmt.Sprintf("Unsupported form target: %d", target), 0)
+	}
+	return fields, nil
+}
+
+// replaceFormField replaces the field with the same name, or adds the field
+func replaceFormField(fields []FormField, field FormField) []FormField {
+	result := make([]FormField, 0, len(fields)+1)
+	replaced := false
+	for _, f := range fields {
+		if f.Name == field.Name && !replaced {
+			result = append(result, field)
+			replaced = true
+			continue
+		}
+		result = append(result, f)
+	}
+	if !replaced {
+		result = append(result, field)
+	}
+	return result
+}
+
+// parseFormURLEncoded parses a form body keeping the order of its fields
+func parseFormURLEncoded(body string) ([]FormField, error) {
+	var fields []FormField
+	for _, pair := range strings.Split(body, "&") {
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		name, err := url.QueryUnescape(parts[0])
+		if err != nil {
+			return nil, api.NewAPIError("Failed to parse form template: "+err.Error(), 0)
+		}
+		field := FormField{Name: name}
+		if len(parts) == 2 {
+			if field.Value, err = url.QueryUnescape(parts[1]); err != nil {
+				return nil, api.NewAPIError("Failed to parse form template: "+err.Error(), 0)
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// encodeFormURLEncoded encodes the fields of a form body in order
+func encodeFormURLEncoded(fields []FormField) string {
+	pairs := make([]string, len(fields))
+	for i, field := range fields {
+		pairs[i] = url.QueryEscape(field.Name) + "=" + url.QueryEscape(field.Value)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escapeFormQuotes escapes the quotes and backslashes of a Content-Disposition parameter
+func escapeFormQuotes(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,250 @@
+package payload
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestGenerateFormURLEncoded(t *testing.T) {
+	generator := NewPayloadGenerator(FormatFormData)
+
+	tests := []struct {
+		name     string
+		template string
+		field    string
+		target   FormTarget
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Empty template",
+			template: "",
+			field:    "username",
+			target:   FormTargetValue,
+			want:     "username=FUZZ",
+			wantErr:  false,
+		},
+		{
+			name:     "Existing field keeps order",
+			template: "user=john&role=user&token=a%2Fb",
+			field:    "role",
+			target:   FormTargetValue,
+			want:     "user=john&role=FUZZ&token=a%2Fb",
+			wantErr:  false,
+		},
+		{
+			name:     "Field name",
+			template: "user=john&role=user",
+			field:    "role",
+			target:   FormTargetName,
+			want:     "user=john&FUZZ=user",
+			wantErr:  false,
+		},
+		{
+			name:     "Missing field name",
+			template: "user=john",
+			field:    "",
+			target:   FormTargetValue,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid template",
+			template: "user=%zz",
+			field:    "user",
+			target:   FormTargetValue,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generator.GenerateFormURLEncoded(tt.template, tt.field, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateFormURLEncoded() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GenerateFormURLEncoded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	got, err := generator.FuzzFormURLEncoded("user=john", "user", FormTargetValue, []string{"admin", "' OR 1=1--"})
+	if err != nil {
+		t.Fatalf("FuzzFormURLEncoded() error = %v", err)
+	}
+	want := []string{"user=admin", "user=%27+OR+1%3D1--"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FuzzFormURLEncoded() = %v, want %v", got, want)
+	}
+
+	if _, err := NewPayloadGenerator(FormatJSON).GenerateFormURLEncoded("", "a", FormTargetValue); err == nil {
+		t.Error("GenerateFormURLEncoded() expected an error for a JSON generator")
+	}
+}
+
+// readMultipart parses a multipart body created by the generator
+func readMultipart(t *testing.T, generator *PayloadGenerator, body string) []FormField {
+	_, params, err := mime.ParseMediaType(generator.MultipartContentType())
+	if err != nil {
+		t.Fatalf("Invalid multipart content type: %v", err)
+	}
+	reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+
+	var fields []FormField
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read multipart body: %v", err)
+		}
+		content, _ := io.ReadAll(part)
+		field := FormField{Name: part.FormName(), Filename: part.FileName()}
+		if field.Filename != "" {
+			field.ContentType = part.Header.Get("Content-Type")
+			field.Content = content
+		} else {
+			field.Value = string(content)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func TestGenerateMultipart(t *testing.T) {
+	generator := NewPayloadGenerator(FormatFormData)
+	fields := []FormField{
+		{Name: "title", Value: "avatar"},
+		{Name: "file", Filename: "avatar.png", ContentType: "image/png", Content: []byte("png")},
+	}
+
+	tests := []struct {
+		name   string
+		field  string
+		target FormTarget
+		want   []FormField
+	}{
+		{
+			name:   "Value",
+			field:  "title",
+			target: FormTargetValue,
+			want: []FormField{
+				{Name: "title", Value: "FUZZ"},
+				{Name: "file", Filename: "avatar.png", ContentType: "image/png", Content: []byte("png")},
+			},
+		},
+		{
+			name:   "Filename",
+			field:  "file",
+			target: FormTargetFilename,
+			want: []FormField{
+				{Name: "title", Value: "avatar"},
+				{Name: "file", Filename: "FUZZ", ContentType: "image/png", Content: []byte("png")},
+			},
+		},
+		{
+			name:   "Content",
+			field:  "file",
+			target: FormTargetContent,
+			want: []FormField{
+				{Name: "title", Value: "avatar"},
+				{Name: "file", Filename: "avatar.png", ContentType: "image/png", Content: []byte("FUZZ")},
+			},
+		},
+		{
+			name:   "New file field",
+			field:  "attachment",
+			target: FormTargetContentType,
+			want: []FormField{
+				{Name: "title", Value: "avatar"},
+				{Name: "file", Filename: "avatar.png", ContentType: "image/png", Content: []byte("png")},
+				{Name: "attachment", Filename: "upload.txt", ContentType: "FUZZ", Content: []byte{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := generator.GenerateMultipart(fields, tt.field, tt.target)
+			if err != nil {
+				t.Fatalf("GenerateMultipart() error = %v", err)
+			}
+			if got := readMultipart(t, generator, body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenerateMultipart() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// The template fields are not modified
+	if fields[0].Value != "avatar" || fields[1].Filename != "avatar.png" {
+		t.Errorf("GenerateMultipart() modified the template fields: %+v", fields)
+	}
+
+	payloads, err := generator.FuzzMultipart(fields, "file", FormTargetFilename, []string{"../../shell.php"})
+	if err != nil {
+		t.Fatalf("FuzzMultipart() error = %v", err)
+	}
+	if !strings.Contains(payloads[0], `filename="../../shell.php"`) {
+		t.Errorf("FuzzMultipart() = %v, want the filename inserted as is", payloads[0])
+	}
+}
+
+func TestGenerateUploads(t *testing.T) {
+	generator := NewPayloadGenerator(FormatFormData)
+	generator.SetMultipartBoundary("testboundary")
+	uploads := MaliciousUploadPayloads()
+
+	payloads, err := generator.GenerateUploads([]FormField{{Name: "file"}, {Name: "title", Value: "x"}}, "file", uploads)
+	if err != nil {
+		t.Fatalf("GenerateUploads() error = %v", err)
+	}
+	if len(payloads) != len(uploads) {
+		t.Fatalf("GenerateUploads() returned %d payloads, want %d", len(payloads), len(uploads))
+	}
+
+	for i, upload := range uploads {
+		if upload.Name == "null-byte-extension" {
+			// The null byte is rejected by Go's multipart reader, but sent as is
+			if !strings.Contains(payloads[i], "image.php\x00.jpg") {
+				t.Errorf("Upload %s: filename not sent as is", upload.Name)
+			}
+			continue
+		}
+		fields := readMultipart(t, generator, payloads[i])
+		if len(fields) != 2 || fields[0].Name != "file" || fields[1].Value != "x" {
+			t.Errorf("Upload %s: unexpected fields %+v", upload.Name, fields)
+			continue
+		}
+		if string(fields[0].Content) != string(upload.Content) || fields[0].ContentType != upload.ContentType {
+			t.Errorf("Upload %s: file not sent as is", upload.Name)
+		}
+	}
+
+	if size := len(OversizedUpload(1024).Content); size != 1024 {
+		t.Errorf("OversizedUpload() size = %d, want 1024", size)
+	}
+}
+
+func TestGenerateRESTRequestFormBody(t *testing.T) {
+	generator := NewPayloadGenerator(FormatFormData)
+	req, err := generator.GenerateRESTRequest(&ffuf.Request{
+		Method:  "POST",
+		Url:     "https://api.example.com/login",
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Data:    []byte("user=john&password=secret"),
+	}, "body", "password")
+	if err != nil {
+		t.Fatalf("GenerateRESTRequest() error = %v", err)
+	}
+	if string(req.Data) != "user=john&password=FUZZ" {
+		t.Errorf("GenerateRESTRequest() body = %s, want user=john&password=FUZZ", req.Data)
+	}
+}
@@ -0,0 +1,105 @@
+package payload
+
+import (
+	"fmt"
+)
+
+// HeaderCategory groups the headers of the catalog by what they make the server trust
+type HeaderCategory string
+
+const (
+	// HeaderClientIP headers carry the address of the client set by proxies
+	HeaderClientIP HeaderCategory = "client-ip"
+	// HeaderHost headers carry the host requested from proxies
+	HeaderHost HeaderCategory = "host"
+	// HeaderURL headers carry the path requested before a rewrite
+	HeaderURL HeaderCategory = "url"
+	// HeaderScheme headers carry the scheme requested from proxies
+	HeaderScheme HeaderCategory = "scheme"
+	// HeaderPort headers carry the port requested from proxies
+	HeaderPort HeaderCategory = "port"
+	// HeaderMethodOverride headers carry a method overriding the method of the request
+	HeaderMethodOverride HeaderCategory = "method-override"
+)
+
+// CatalogHeader is a header of the catalog
+type CatalogHeader struct {
+	Name     string
+	Category HeaderCategory
+	// Format formats the values of the header, such as "for=%s" for Forwarded. Values are
+	// sent as is if it is empty.
+	Format string
+}
+
+// Value returns the header value carrying a value
+func (h CatalogHeader) Value(value string) string {
+	if h.Format == "" {
+		return value
+	}
+	return fmt.Sprintf(h.Format, value)
+}
+
+// HeaderCatalog is the catalog of request headers that proxies, gateways and frameworks
+// trust, shared by the security testers and payload generators instead of their own lists
+var HeaderCatalog = []CatalogHeader{
+	{Name: "X-Forwarded-For", Category: HeaderClientIP},
+	{Name: "X-Real-IP", Category: HeaderClientIP},
+	{Name: "X-Originating-IP", Category: HeaderClientIP},
+	{Name: "X-Client-IP", Category: HeaderClientIP},
+	{Name: "X-Remote-IP", Category: HeaderClientIP},
+	{Name: "X-Remote-Addr", Category: HeaderClientIP},
+	{Name: "X-Cluster-Client-IP", Category: HeaderClientIP},
+	{Name: "True-Client-IP", Category: HeaderClientIP},
+	{Name: "CF-Connecting-IP", Category: HeaderClientIP},
+	{Name: "Fastly-Client-IP", Category: HeaderClientIP},
+	{Name: "Client-IP", Category: HeaderClientIP},
+	{Name: "Forwarded", Category: HeaderClientIP, Format: "for=%s"},
+
+	{Name: "X-Forwarded-Host", Category: HeaderHost},
+	{Name: "X-Host", Category: HeaderHost},
+	{Name: "X-Forwarded-Server", Category: HeaderHost},
+	{Name: "X-Original-Host", Category: HeaderHost},
+	{Name: "X-HTTP-Host-Override", Category: HeaderHost},
+	{Name: "Forwarded", Category: HeaderHost, Format: "host=%s"},
+
+	{Name: "X-Original-URL", Category: HeaderURL},
+	{Name: "X-Rewrite-URL", Category: HeaderURL},
+
+	{Name: "X-Forwarded-Proto", Category: HeaderScheme},
+	{Name: "X-Forwarded-Scheme", Category: HeaderScheme},
+
+	{Name: "X-Forwarded-Port", Category: HeaderPort},
+
+	{Name: "X-HTTP-Method-Override", Category: HeaderMethodOverride},
+	{Name: "X-HTTP-Method", Category: HeaderMethodOverride},
+	{Name: "X-Method-Override", Category: HeaderMethodOverride},
+}
+
+// CatalogHeaders returns the headers of the catalog in a category
+func CatalogHeaders(category HeaderCategory) []CatalogHeader {
+	headers := make([]CatalogHeader, 0)
+	for _, header := range HeaderCatalog {
+		if header.Category == category {
+			headers = append(headers, header)
+		}
+	}
+	return headers
+}
+
+// CatalogHeaderNames returns the names of the headers of the catalog in a category
+func CatalogHeaderNames(category HeaderCategory) []string {
+	names := make([]string, 0)
+	for _, header := range CatalogHeaders(category) {
+		names = append(names, header.Name)
+	}
+	return names
+}
+
+// CatalogHeaderValues returns the headers of a category carrying a value, keyed by name
+func CatalogHeaderValues(category HeaderCategory, value string) map[string]string {
+	headers := make(map[string]string)
+	for _, header := range CatalogHeaders(category) {
+		headers[header.Name] = header.Value(value)
+	}
+	return headers
+}
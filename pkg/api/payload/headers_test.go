@@ -0,0 +1,33 @@
+package payload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCatalogHeaders(t *testing.T) {
+	names := CatalogHeaderNames(HeaderMethodOverride)
+	if !reflect.DeepEqual(names, []string{"X-HTTP-Method-Override", "X-HTTP-Method", "X-Method-Override"}) {
+		t.Errorf("Unexpected method override headers %v", names)
+	}
+	if !reflect.DeepEqual(DefaultMethodOverrideHeaders, names) {
+		t.Errorf("Expected the method override headers to come from the catalog")
+	}
+
+	values := CatalogHeaderValues(HeaderClientIP, "127.0.0.1")
+	if values["X-Forwarded-For"] != "127.0.0.1" || values["Forwarded"] != "for=127.0.0.1" {
+		t.Errorf("Unexpected client IP header values %v", values)
+	}
+	if values := CatalogHeaderValues(HeaderHost, "evil.example.com"); values["Forwarded"] != "host=evil.example.com" {
+		t.Errorf("Expected the host in the Forwarded header, got %q", values["Forwarded"])
+	}
+
+	for _, header := range HeaderCatalog {
+		if header.Name == "" || header.Category == "" {
+			t.Errorf("Incomplete catalog header %+v", header)
+		}
+	}
+	if len(CatalogHeaders("unknown")) != 0 {
+		t.Errorf("Expected no headers in an unknown category")
+	}
+}
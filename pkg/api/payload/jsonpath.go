@@ -0,0 +1,518 @@
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// jsonPathSegmentKind is the kind of selector of a JSONPath segment
+type jsonPathSegmentKind int
+
+const (
+	// jsonPathNames selects object members by name
+	jsonPathNames jsonPathSegmentKind = iota
+	// jsonPathIndexes selects array items by index
+	jsonPathIndexes
+	// jsonPathWildcard selects every member or item
+	jsonPathWildcard
+	// jsonPathSlice selects a range of array items
+	jsonPathSlice
+	// jsonPathFilter selects the members or items matching a filter expression
+	jsonPathFilter
+)
+
+// jsonPathSegment is a segment of a JSONPath expression
+type jsonPathSegment struct {
+	kind    jsonPathSegmentKind
+	names   []string
+	indexes []int
+	// start and end are the bounds of slices, nil if omitted
+	start, end *int
+	filter     *jsonPathFilterExpr
+	// recursive is set for segments following the descendant operator ".."
+	recursive bool
+}
+
+// jsonPathFilterExpr is a filter expression of the form ?(@.path) or ?(@.path op literal)
+type jsonPathFilterExpr struct {
+	path     []jsonPathSegment
+	operator string
+	literal  interface{}
+}
+
+// isJSONPath checks if a fuzz point path is a JSONPath expression rather than a dot path
+func isJSONPath(path string) bool {
+	return strings.HasPrefix(path, "$")
+}
+
+// definite checks if the segments select at most a single value, in which case missing
+// members and items are created
+func definite(segments []jsonPathSegment) bool {
+	for _, segment := range segments {
+		if segment.recursive {
+			return false
+		}
+		switch segment.kind {
+		case jsonPathNames:
+			if len(segment.names) != 1 {
+				return false
+			}
+		case jsonPathIndexes:
+			if len(segment.indexes) != 1 || segment.indexes[0] < 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseJSONPath parses a JSONPath expression such as $.users[*].email, $..id or
+// $.items[?(@.type=='admin')].name
+func parseJSONPath(expression string) ([]jsonPathSegment, error) {
+	if !isJSONPath(expression) {
+		return nil, fmt.Errorf("JSONPath expression must start with $")
+	}
+	segments := make([]jsonPathSegment, 0)
+	rest := expression[1:]
+	for rest != "" {
+		recursive := false
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			recursive = true
+			rest = rest[2:]
+		case rest[0] == '.':
+			rest = rest[1:]
+		case rest[0] != '[':
+			return nil, fmt.Errorf("unexpected character %q in JSONPath expression %s", rest[0], expression)
+		}
+
+		var segment jsonPathSegment
+		var err error
+		switch {
+		case rest == "":
+			return nil, fmt.Errorf("JSONPath expression %s ends with a dot", expression)
+		case rest[0] == '[':
+			segment, rest, err = parseJSONPathBracket(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSONPath expression %s: %s", expression, err)
+			}
+		case rest[0] == '*':
+			segment = jsonPathSegment{kind: jsonPathWildcard}
+			rest = rest[1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segment = jsonPathSegment{kind: jsonPathNames, names: []string{rest[:end]}}
+			rest = rest[end:]
+		}
+		segment.recursive = recursive
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// parseJSONPathBracket parses a bracket segment and returns the remainder of the expression
+func parseJSONPathBracket(expression string) (jsonPathSegment, string, error) {
+	if strings.HasPrefix(expression, "[?(") {
+		end := strings.Index(expression, ")]")
+		if end < 0 {
+			return jsonPathSegment{}, "", fmt.Errorf("unterminated filter")
+		}
+		filter, err := parseJSONPathFilter(expression[3:end])
+		if err != nil {
+			return jsonPathSegment{}, "", err
+		}
+		return jsonPathSegment{kind: jsonPathFilter, filter: filter}, expression[end+2:], nil
+	}
+
+	// Find the closing bracket, skipping quoted names
+	end := -1
+	var quote byte
+	for i := 1; i < len(expression) && end < 0; i++ {
+		switch c := expression[i]; {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case quote == 0 && c == ']':
+			end = i
+		}
+	}
+	if end < 0 {
+		return jsonPathSegment{}, "", fmt.Errorf("unterminated bracket")
+	}
+	content, rest := strings.TrimSpace(expression[1:end]), expression[end+1:]
+
+	if content == "*" {
+		return jsonPathSegment{kind: jsonPathWildcard}, rest, nil
+	}
+	if content != "" && (content[0] == '\'' || content[0] == '"') {
+		names, err := parseJSONPathNames(content)
+		return jsonPathSegment{kind: jsonPathNames, names: names}, rest, err
+	}
+	if strings.Contains(content, ":") {
+		bounds := strings.Split(content, ":")
+		if len(bounds) != 2 {
+			return jsonPathSegment{}, "", fmt.Errorf("unsupported slice [%s]", content)
+		}
+		segment := jsonPathSegment{kind: jsonPathSlice}
+		for i, bound := range bounds {
+			bound = strings.TrimSpace(bound)
+			if bound == "" {
+				continue
+			}
+			n, err := strconv.Atoi(bound)
+			if err != nil {
+				return jsonPathSegment{}, "", fmt.Errorf("invalid slice bound %s", bound)
+			}
+			if i == 0 {
+				segment.start = &n
+			} else {
+				segment.end = &n
+			}
+		}
+		return segment, rest, nil
+	}
+
+	segment := jsonPathSegment{kind: jsonPathIndexes}
+	for _, part := range strings.Split(content, ",") {
+		index, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return jsonPathSegment{}, "", fmt.Errorf("invalid index %s", part)
+		}
+		segment.indexes = append(segment.indexes, index)
+	}
+	return segment, rest, nil
+}
+
+// parseJSONPathNames parses comma separated quoted member names
+func parseJSONPathNames(content string) ([]string, error) {
+	names := make([]string, 0)
+	for content != "" {
+		quote := content[0]
+		if quote != '\'' && quote != '"' {
+			return nil, fmt.Errorf("expected a quoted name in [%s]", content)
+		}
+		var name strings.Builder
+		i := 1
+		for ; i < len(content) && content[i] != quote; i++ {
+			if content[i] == '\\' && i+1 < len(content) {
+				i++
+			}
+			name.WriteByte(content[i])
+		}
+		if i >= len(content) {
+			return nil, fmt.Errorf("unterminated name in [%s]", content)
+		}
+		names = append(names, name.String())
+		content = strings.TrimSpace(content[i+1:])
+		if strings.HasPrefix(content, ",") {
+			content = strings.TrimSpace(content[1:])
+		} else if content != "" {
+			return nil, fmt.Errorf("expected a comma in [%s]", content)
+		}
+	}
+	return names, nil
+}
+
+// parseJSONPathFilter parses the expression of a filter, e.g. @.role=='admin' or @.id
+func parseJSONPathFilter(expression string) (*jsonPathFilterExpr, error) {
+	expression = strings.TrimSpace(expression)
+	if !strings.HasPrefix(expression, "@") {
+		return nil, fmt.Errorf("filter %s must start with @", expression)
+	}
+
+	filter := &jsonPathFilterExpr{}
+	path := expression
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if i := strings.Index(expression, operator); i > 0 {
+			path = strings.TrimSpace(expression[:i])
+			filter.operator = operator
+			literal := strings.TrimSpace(expression[i+len(operator):])
+			if strings.HasPrefix(literal, "'") && strings.HasSuffix(literal, "'") && len(literal) >= 2 {
+				filter.literal = literal[1 : len(literal)-1]
+			} else if err := json.Unmarshal([]byte(literal), &filter.literal); err != nil {
+				return nil, fmt.Errorf("invalid filter value %s", literal)
+			}
+			break
+		}
+	}
+
+	segments, err := parseJSONPath("$" + path[1:])
+	if err != nil {
+		return nil, err
+	}
+	filter.path = segments
+	return filter, nil
+}
+
+// matches checks if a value matches the filter
+func (f *jsonPathFilterExpr) matches(value interface{}) bool {
+	selected := selectJSONPath(value, f.path)
+	if f.operator == "" {
+		return len(selected) > 0
+	}
+	for _, v := range selected {
+		if compareJSONValues(v, f.operator, f.literal) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareJSONValues compares a value to a filter literal. Numbers are compared numerically,
+// other values by their string representation.
+func compareJSONValues(value interface{}, operator string, literal interface{}) bool {
+	a, aNumber := value.(float64)
+	b, bNumber := literal.(float64)
+	if !aNumber || !bNumber {
+		if operator != "==" && operator != "!=" {
+			return false
+		}
+		equal := fmt.Sprint(value) == fmt.Sprint(literal)
+		return equal == (operator == "==")
+	}
+	switch operator {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	}
+	return a >= b
+}
+
+// sortedKeys returns the keys of an object in sorted order, for deterministic results
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// selectedIndexes returns the indexes of the items of an array of the given length selected
+// by a segment. Negative indexes count from the end of the array.
+func selectedIndexes(segment jsonPathSegment, items []interface{}) []int {
+	length := len(items)
+	indexes := make([]int, 0)
+	switch segment.kind {
+	case jsonPathIndexes:
+		for _, index := range segment.indexes {
+			if index < 0 {
+				index += length
+			}
+			if index >= 0 && index < length {
+				indexes = append(indexes, index)
+			}
+		}
+	case jsonPathWildcard:
+		for i := range items {
+			indexes = append(indexes, i)
+		}
+	case jsonPathSlice:
+		start, end := 0, length
+		if segment.start != nil {
+			start = *segment.start
+		}
+		if segment.end != nil {
+			end = *segment.end
+		}
+		if start < 0 {
+			start += length
+		}
+		if end < 0 {
+			end += length
+		}
+		for i := start; i < end && i < length; i++ {
+			if i >= 0 {
+				indexes = append(indexes, i)
+			}
+		}
+	case jsonPathFilter:
+		for i, item := range items {
+			if segment.filter.matches(item) {
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	return indexes
+}
+
+// selectedKeys returns the keys of the members of an object selected by a segment
+func selectedKeys(segment jsonPathSegment, object map[string]interface{}) []string {
+	keys := make([]string, 0)
+	switch segment.kind {
+	case jsonPathNames:
+		for _, name := range segment.names {
+			if _, ok := object[name]; ok {
+				keys = append(keys, name)
+			}
+		}
+	case jsonPathWildcard:
+		keys = sortedKeys(object)
+	case jsonPathFilter:
+		for _, key := range sortedKeys(object) {
+			if segment.filter.matches(object[key]) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// selectJSONPath returns the values selected by JSONPath segments
+func selectJSONPath(node interface{}, segments []jsonPathSegment) []interface{} {
+	if len(segments) == 0 {
+		return []interface{}{node}
+	}
+	segment, rest := segments[0], segments[1:]
+	selected := make([]interface{}, 0)
+	if segment.recursive {
+		segment.recursive = false
+		selected = append(selected, selectJSONPath(node, append([]jsonPathSegment{segment}, rest...))...)
+		for _, child := range jsonChildren(node) {
+			selected = append(selected, selectJSONPath(child, segments)...)
+		}
+		return selected
+	}
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for _, key := range selectedKeys(segment, v) {
+			selected = append(selected, selectJSONPath(v[key], rest)...)
+		}
+	case []interface{}:
+		for _, index := range selectedIndexes(segment, v) {
+			selected = append(selected, selectJSONPath(v[index], rest)...)
+		}
+	}
+	return selected
+}
+
+// jsonChildren returns the member values or items of a JSON value
+func jsonChildren(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		children := make([]interface{}, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			children = append(children, v[key])
+		}
+		return children
+	case []interface{}:
+		return v
+	}
+	return nil
+}
+
+// setJSONPath sets the values selected by JSONPath segments, and returns the updated node
+// and the number of values set. Missing members and items are created if create is set.
+func setJSONPath(node interface{}, segments []jsonPathSegment, value interface{}, create bool) (interface{}, int) {
+	if len(segments) == 0 {
+		return value, 1
+	}
+	segment, rest := segments[0], segments[1:]
+
+	if segment.recursive {
+		segment.recursive = false
+		node, count := setJSONPath(node, append([]jsonPathSegment{segment}, rest...), value, false)
+		switch v := node.(type) {
+		case map[string]interface{}:
+			for _, key := range sortedKeys(v) {
+				child, n := setJSONPath(v[key], segments, value, false)
+				v[key] = child
+				count += n
+			}
+		case []interface{}:
+			for i := range v {
+				child, n := setJSONPath(v[i], segments, value, false)
+				v[i] = child
+				count += n
+			}
+		}
+		return node, count
+	}
+
+	if node == nil && create {
+		if segment.kind == jsonPathNames {
+			node = make(map[string]interface{})
+		} else {
+			node = make([]interface{}, 0)
+		}
+	}
+
+	count := 0
+	switch v := node.(type) {
+	case map[string]interface{}:
+		keys := selectedKeys(segment, v)
+		if len(keys) == 0 && create && segment.kind == jsonPathNames {
+			keys = segment.names
+		}
+		for _, key := range keys {
+			child, n := setJSONPath(v[key], rest, value, create)
+			if n > 0 {
+				v[key] = child
+				count += n
+			}
+		}
+	case []interface{}:
+		if create && segment.kind == jsonPathIndexes && segment.indexes[0] >= len(v) {
+			items := make([]interface{}, segment.indexes[0]+1)
+			copy(items, v)
+			node, v = items, items
+		}
+		for _, index := range selectedIndexes(segment, v) {
+			child, n := setJSONPath(v[index], rest, value, create)
+			if n > 0 {
+				v[index] = child
+				count += n
+			}
+		}
+	}
+	return node, count
+}
+
+// generateJSONWithJSONPath creates a JSON document with a value at every position selected by
+// a JSONPath expression. Missing members and items of expressions selecting a single
+// position, such as $.user.addresses[0].city, are created.
+func (g *PayloadGenerator) generateJSONWithJSONPath(template string, expression string, value interface{}) (string, error) {
+	segments, err := parseJSONPath(expression)
+	if err != nil {
+		return "", api.NewAPIError(err.Error(), 0)
+	}
+
+	var data interface{} = make(map[string]interface{})
+	if template != "" {
+		if err := json.Unmarshal([]byte(template), &data); err != nil {
+			return "", api.NewAPIError("Failed to parse JSON template: "+err.Error(), 0)
+		}
+	}
+
+	data, count := setJSONPath(data, segments, value, definite(segments))
+	if count == 0 {
+		return "", api.NewAPIError(fmt.Sprintf("JSONPath expression %s matches no values", expression), 0)
+	}
+
+	jsonBytes, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return "", api.NewAPIError("Failed to generate JSON: "+err.Error(), 0)
+	}
+	return string(jsonBytes), nil
+}
@@ -0,0 +1,121 @@
+package payload
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const jsonPathTemplate = `{
+  "company": {
+    "name": "ACME",
+    "departments": [
+      {"name": "sales", "users": [{"email": "a@example.com", "role": "admin"}, {"email": "b@example.com", "role": "user"}]},
+      {"name": "it", "users": [{"email": "c@example.com", "role": "admin", "age": 40}]}
+    ]
+  },
+  "users": [{"email": "d@example.com", "age": 20}, {"email": "e@example.com", "age": 30}]
+}`
+
+func TestPayloadGenerator_GenerateJSON_JSONPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		count   int
+		wantErr bool
+	}{
+		{name: "Wildcard", path: "$.users[*].email", want: []string{"$.users[0].email", "$.users[1].email"}, count: 2},
+		{name: "Recursive descent", path: "$..email", count: 5},
+		{name: "Deeply nested", path: "$.company.departments[1].users[0].email", want: []string{"$.company.departments[1].users[0].email"}, count: 1},
+		{name: "Nested wildcards", path: "$.company.departments[*].users[*].role", count: 3},
+		{name: "Negative index", path: "$.users[-1].email", want: []string{"$.users[1].email"}, count: 1},
+		{name: "Slice", path: "$.users[0:1].age", want: []string{"$.users[0].age"}, count: 1},
+		{name: "Bracket names", path: `$['company']["name"]`, want: []string{"$.company.name"}, count: 1},
+		{name: "Multiple names", path: "$.users[0]['email','age']", count: 2},
+		{name: "Filter", path: "$..users[?(@.role=='admin')].email", want: []string{"$.company.departments[0].users[0].email", "$.company.departments[1].users[0].email"}, count: 2},
+		{name: "Numeric filter", path: "$.users[?(@.age>25)].email", want: []string{"$.users[1].email"}, count: 1},
+		{name: "Wildcard member", path: "$.company.*", count: 2},
+		{name: "Created member", path: "$.company.address.city", want: []string{"$.company.address.city"}, count: 1},
+		{name: "Created item", path: "$.users[3].email", want: []string{"$.users[3].email"}, count: 1},
+		{name: "No match", path: "$.users[*].phone", wantErr: true},
+		{name: "Invalid", path: "$.users[", wantErr: true},
+	}
+
+	generator := NewPayloadGenerator(FormatJSON)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generator.GenerateJSON(jsonPathTemplate, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var data interface{}
+			if err := json.Unmarshal([]byte(got), &data); err != nil {
+				t.Fatalf("GenerateJSON() returned invalid JSON: %v", err)
+			}
+			markers := selectJSONPath(data, mustParseJSONPath(t, "$..*"))
+			count := 0
+			for _, value := range markers {
+				if value == FuzzMarker {
+					count++
+				}
+			}
+			if count != tt.count {
+				t.Errorf("Expected %d fuzz markers, got %d in %s", tt.count, count, got)
+			}
+			for _, path := range tt.want {
+				if selected := selectJSONPath(data, mustParseJSONPath(t, path)); len(selected) != 1 || selected[0] != FuzzMarker {
+					t.Errorf("Expected a fuzz marker at %s, got %v", path, selected)
+				}
+			}
+		})
+	}
+}
+
+func mustParseJSONPath(t *testing.T, expression string) []jsonPathSegment {
+	segments, err := parseJSONPath(expression)
+	if err != nil {
+		t.Fatalf("parseJSONPath(%s) error = %v", expression, err)
+	}
+	return segments
+}
+
+func TestPayloadGenerator_FuzzJSON_JSONPath(t *testing.T) {
+	generator := NewPayloadGenerator(FormatJSON)
+	payloads, err := generator.FuzzJSON(`{"a":{"b":[{"c":1},{"c":2}]},"invalid":1}`, "$..c", []string{"x"})
+	if err != nil {
+		t.Fatalf("FuzzJSON() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(payloads[0]), &got); err != nil {
+		t.Fatalf("FuzzJSON() returned invalid JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"a":       map[string]interface{}{"b": []interface{}{map[string]interface{}{"c": "x"}, map[string]interface{}{"c": "x"}}},
+		"invalid": float64(1),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FuzzJSON() = %v, want %v", got, want)
+	}
+
+	// JSONPath and dot paths can be mixed
+	result, err := generator.GenerateJSONWithMultipleFuzzPoints(`{"a":[1,2],"b":{"c":1}}`, []string{"$.a[*]", "b.c"})
+	if err != nil {
+		t.Fatalf("GenerateJSONWithMultipleFuzzPoints() error = %v", err)
+	}
+	got = nil
+	if err := json.Unmarshal([]byte(result), &got); err != nil {
+		t.Fatalf("GenerateJSONWithMultipleFuzzPoints() returned invalid JSON: %v", err)
+	}
+	want = map[string]interface{}{
+		"a": []interface{}{FuzzMarker, FuzzMarker},
+		"b": map[string]interface{}{"c": FuzzMarker},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GenerateJSONWithMultipleFuzzPoints() = %v, want %v", got, want)
+	}
+}
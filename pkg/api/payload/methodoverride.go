@@ -0,0 +1,242 @@
+package payload
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// MethodOverrideTechnique is a way of having a request processed as another HTTP method
+type MethodOverrideTechnique string
+
+const (
+	// MethodOverrideHeader sends the method in a header such as X-HTTP-Method-Override
+	MethodOverrideHeader MethodOverrideTechnique = "header"
+	// MethodOverrideQuery sends the method in a query parameter such as _method
+	MethodOverrideQuery MethodOverrideTechnique = "query"
+	// MethodOverrideField sends the method in a field of the request body such as _method
+	MethodOverrideField MethodOverrideTechnique = "form-field"
+	// MethodOverrideVerb sends the request with an uncommon verb or another case of the method,
+	// which access control rules listing methods may not cover
+	MethodOverrideVerb MethodOverrideTechnique = "verb"
+)
+
+// AllMethodOverrideTechniques returns every method override technique, in the order their
+// variants are generated
+func AllMethodOverrideTechniques() []MethodOverrideTechnique {
+	return []MethodOverrideTechnique{
+		MethodOverrideHeader,
+		MethodOverrideQuery,
+		MethodOverrideField,
+		MethodOverrideVerb,
+	}
+}
+
+// DefaultMethodOverrideHeaders are the headers frameworks and gateways read an overridden
+// method from
+var DefaultMethodOverrideHeaders = CatalogHeaderNames(HeaderMethodOverride)
+
+// DefaultMethodOverrideParam is the query parameter and body field frameworks such as Rails,
+// Laravel and Symfony read an overridden method from
+const DefaultMethodOverrideParam = "_method"
+
+// DefaultTamperingVerbs are the uncommon verbs sent in place of a method. FFUF stands for
+// an arbitrary verb, which some servers process as GET. OPTIONS is left out as servers
+// answer it for any resource.
+var DefaultTamperingVerbs = []string{"HEAD", "TRACE", "PROPFIND", "FFUF"}
+
+// MethodVariant is a request variant having a request processed as another method
+type MethodVariant struct {
+	Technique MethodOverrideTechnique
+	// Method is the method the variant is sent with
+	Method string
+	// Target is the method the variant asks for, or the method whose access control it
+	// evades for verb tampering
+	Target string
+	// Header is the override header of MethodOverrideHeader variants
+	Header string
+	// Param is the override parameter of MethodOverrideQuery and MethodOverrideField variants
+	Param string
+	// Description explains the variant
+	Description string
+}
+
+// MethodTamperer generates method override and verb tampering variants of requests, to find
+// access control rules enforced on the sent method only
+type MethodTamperer struct {
+	// Techniques are the applied techniques, all techniques if empty
+	Techniques []MethodOverrideTechnique
+	// Headers are the override headers
+	Headers []string
+	// Param is the override query parameter and body field
+	Param string
+	// Carriers are the methods overrides are sent with
+	Carriers []string
+	// Verbs are the verbs sent in place of the method
+	Verbs []string
+}
+
+// NewMethodTamperer creates a new MethodTamperer applying every technique
+func NewMethodTamperer() *MethodTamperer {
+	return &MethodTamperer{
+		Headers:  append([]string{}, DefaultMethodOverrideHeaders...),
+		Param:    DefaultMethodOverrideParam,
+		Carriers: []string{"POST", "GET"},
+		Verbs:    append([]string{}, DefaultTamperingVerbs...),
+	}
+}
+
+// Variants returns the variants having a request processed as the target method. Overrides
+// are sent with each carrier other than the target, in a body field with POST only, and the
+// verbs are completed with the target in lower case. HEAD is not sent in place of GET, which
+// it is equivalent to.
+func (m *MethodTamperer) Variants(target string) []MethodVariant {
+	target = strings.ToUpper(target)
+	enabled := make(map[MethodOverrideTechnique]bool)
+	for _, t := range m.Techniques {
+		enabled[t] = true
+	}
+	if len(enabled) == 0 {
+		for _, t := range AllMethodOverrideTechniques() {
+			enabled[t] = true
+		}
+	}
+
+	variants := make([]MethodVariant, 0)
+	for _, carrier := range m.Carriers {
+		carrier = strings.ToUpper(carrier)
+		if carrier == target {
+			continue
+		}
+		if enabled[MethodOverrideHeader] {
+			for _, header := range m.Headers {
+				variants = append(variants, MethodVariant{
+					Technique:   MethodOverrideHeader,
+					Method:      carrier,
+					Target:      target,
+					Header:      header,
+					Description: carrier + " with " + header + ": " + target,
+				})
+			}
+		}
+		if enabled[MethodOverrideQuery] && m.Param != "" {
+			variants = append(variants, MethodVariant{
+				Technique:   MethodOverrideQuery,
+				Method:      carrier,
+				Target:      target,
+				Param:       m.Param,
+				Description: carrier + " with query parameter " + m.Param + "=" + target,
+			})
+		}
+		if enabled[MethodOverrideField] && m.Param != "" && carrier == "POST" {
+			variants = append(variants, MethodVariant{
+				Technique:   MethodOverrideField,
+				Method:      carrier,
+				Target:      target,
+				Param:       m.Param,
+				Description: carrier + " with body field " + m.Param + "=" + target,
+			})
+		}
+	}
+
+	if enabled[MethodOverrideVerb] {
+		verbs := append([]string{}, m.Verbs...)
+		if lower := strings.ToLower(target); lower != target {
+			verbs = append(verbs, lower)
+		}
+		for _, verb := range verbs {
+			if verb == target || (verb == "HEAD" && target == "GET") {
+				continue
+			}
+			variants = append(variants, MethodVariant{
+				Technique:   MethodOverrideVerb,
+				Method:      verb,
+				Target:      target,
+				Description: verb + " in place of " + target,
+			})
+		}
+	}
+	return variants
+}
+
+// Apply returns a copy of a request sent as the variant. The override field of
+// MethodOverrideField variants is added to form and JSON object bodies, and an empty body
+// becomes a form.
+func (v MethodVariant) Apply(req *ffuf.Request) (*ffuf.Request, error) {
+	variant := *req
+	variant.Method = v.Method
+	variant.Headers = make(map[string]string, len(req.Headers)+1)
+	for name, value := range req.Headers {
+		variant.Headers[name] = value
+	}
+
+	switch v.Technique {
+	case MethodOverrideHeader:
+		setRequestHeader(variant.Headers, v.Header, v.Target)
+	case MethodOverrideQuery:
+		separator := "?"
+		if strings.Contains(variant.Url, "?") {
+			separator = "&"
+		}
+		variant.Url += separator + url.QueryEscape(v.Param) + "=" + url.QueryEscape(v.Target)
+	case MethodOverrideField:
+		contentType := ""
+		for name, value := range variant.Headers {
+			if strings.EqualFold(name, "Content-Type") {
+				contentType = value
+			}
+		}
+		body, contentType, err := AddMethodField(string(req.Data), contentType, v.Param, v.Target)
+		if err != nil {
+			return nil, err
+		}
+		variant.Data = []byte(body)
+		setRequestHeader(variant.Headers, "Content-Type", contentType)
+	}
+	return &variant, nil
+}
+
+// AddMethodField adds a method override field to a form or JSON object body, and returns
+// the body with its content type. An empty body becomes a form.
+func AddMethodField(body, contentType, field, method string) (string, string, error) {
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case trimmed == "":
+		return url.QueryEscape(field) + "=" + url.QueryEscape(method), "application/x-www-form-urlencoded", nil
+	case strings.Contains(contentType, "json") || (contentType == "" && strings.HasPrefix(trimmed, "{")):
+		root, err := parseOrderedJSON([]byte(body))
+		if err != nil {
+			return "", "", api.NewAPIError("Failed to parse JSON body: "+err.Error(), 0)
+		}
+		object, ok := root.(*jsonObject)
+		if !ok {
+			return "", "", api.NewAPIError("JSON body is not an object", 0)
+		}
+		object.members = append(object.members, jsonMember{key: field, value: method})
+		var buf bytes.Buffer
+		writeOrderedJSON(&buf, object)
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		return buf.String(), contentType, nil
+	case strings.Contains(contentType, "x-www-form-urlencoded") || (contentType == "" && strings.Contains(trimmed, "=")):
+		if contentType == "" {
+			contentType = "application/x-www-form-urlencoded"
+		}
+		return trimmed + "&" + url.QueryEscape(field) + "=" + url.QueryEscape(method), contentType, nil
+	}
+	return "", "", api.NewAPIError("Body of type "+contentType+" cannot carry a method field", 0)
+}
+
+// setRequestHeader sets a header, replacing it whatever its case
+func setRequestHeader(headers map[string]string, name, value string) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+	headers[name] = value
+}
@@ -0,0 +1,124 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestMethodTamperer_Variants(t *testing.T) {
+	variants := NewMethodTamperer().Variants("delete")
+
+	// 3 headers and a query parameter with POST and GET, a body field with POST, and 4 verbs
+	// with the lower case target
+	if len(variants) != 2*4+1+5 {
+		t.Fatalf("Expected 14 variants, got %d", len(variants))
+	}
+	expected := []MethodVariant{
+		{Technique: MethodOverrideHeader, Method: "POST", Target: "DELETE", Header: "X-HTTP-Method-Override", Description: "POST with X-HTTP-Method-Override: DELETE"},
+		{Technique: MethodOverrideQuery, Method: "POST", Target: "DELETE", Param: "_method", Description: "POST with query parameter _method=DELETE"},
+		{Technique: MethodOverrideField, Method: "POST", Target: "DELETE", Param: "_method", Description: "POST with body field _method=DELETE"},
+		{Technique: MethodOverrideHeader, Method: "GET", Target: "DELETE", Header: "X-HTTP-Method-Override", Description: "GET with X-HTTP-Method-Override: DELETE"},
+		{Technique: MethodOverrideVerb, Method: "FFUF", Target: "DELETE", Description: "FFUF in place of DELETE"},
+		{Technique: MethodOverrideVerb, Method: "delete", Target: "DELETE", Description: "delete in place of DELETE"},
+	}
+	for _, e := range expected {
+		found := false
+		for _, variant := range variants {
+			if variant == e {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected the variant %q", e.Description)
+		}
+	}
+
+	tamperer := NewMethodTamperer()
+	tamperer.Techniques = []MethodOverrideTechnique{MethodOverrideHeader, MethodOverrideVerb}
+	for _, variant := range tamperer.Variants("POST") {
+		if variant.Technique != MethodOverrideHeader && variant.Technique != MethodOverrideVerb {
+			t.Errorf("Unexpected technique %s", variant.Technique)
+		}
+		if variant.Method == "POST" {
+			t.Errorf("Expected no variant sent with the target method, got %q", variant.Description)
+		}
+	}
+	for _, variant := range NewMethodTamperer().Variants("GET") {
+		if variant.Method == "HEAD" {
+			t.Errorf("Expected HEAD not to be sent in place of GET")
+		}
+	}
+}
+
+func TestMethodVariant_Apply(t *testing.T) {
+	req := &ffuf.Request{
+		Method:  "DELETE",
+		Url:     "https://api.example.com/users/1?force=true",
+		Headers: map[string]string{"Authorization": "Bearer token", "content-type": "application/json"},
+		Data:    []byte(`{"reason":"spam","id":1}`),
+	}
+
+	tests := []struct {
+		variant MethodVariant
+		url     string
+		header  string
+		value   string
+		data    string
+	}{
+		{MethodVariant{Technique: MethodOverrideHeader, Method: "POST", Target: "DELETE", Header: "X-HTTP-Method-Override"},
+			req.Url, "X-HTTP-Method-Override", "DELETE", string(req.Data)},
+		{MethodVariant{Technique: MethodOverrideQuery, Method: "GET", Target: "DELETE", Param: "_method"},
+			req.Url + "&_method=DELETE", "content-type", "application/json", string(req.Data)},
+		{MethodVariant{Technique: MethodOverrideField, Method: "POST", Target: "DELETE", Param: "_method"},
+			req.Url, "Content-Type", "application/json", `{"reason":"spam","id":1,"_method":"DELETE"}`},
+		{MethodVariant{Technique: MethodOverrideVerb, Method: "delete", Target: "DELETE"},
+			req.Url, "content-type", "application/json", string(req.Data)},
+	}
+	for _, test := range tests {
+		variant, err := test.variant.Apply(req)
+		if err != nil {
+			t.Fatalf("Apply returned an error for %s: %s", test.variant.Technique, err)
+		}
+		if variant.Method != test.variant.Method || variant.Url != test.url || string(variant.Data) != test.data {
+			t.Errorf("Unexpected %s variant %s %s %s", test.variant.Technique, variant.Method, variant.Url, variant.Data)
+		}
+		if variant.Headers[test.header] != test.value || variant.Headers["Authorization"] != "Bearer token" {
+			t.Errorf("Unexpected headers of the %s variant: %v", test.variant.Technique, variant.Headers)
+		}
+	}
+	if req.Method != "DELETE" || len(req.Headers) != 2 {
+		t.Errorf("Expected the request to be left unchanged")
+	}
+}
+
+func TestAddMethodField(t *testing.T) {
+	tests := []struct {
+		body        string
+		contentType string
+		expected    string
+		expectedCT  string
+		err         bool
+	}{
+		{"", "", "_method=PUT", "application/x-www-form-urlencoded", false},
+		{"name=alice", "application/x-www-form-urlencoded", "name=alice&_method=PUT", "application/x-www-form-urlencoded", false},
+		{`{"name":"alice"}`, "", `{"name":"alice","_method":"PUT"}`, "application/json", false},
+		{`["alice"]`, "application/json", "", "", true},
+		{"<user/>", "application/xml", "", "", true},
+	}
+	for _, test := range tests {
+		body, contentType, err := AddMethodField(test.body, test.contentType, "_method", "PUT")
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected an error for %q", test.body)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("AddMethodField returned an error for %q: %s", test.body, err)
+		}
+		if body != test.expected || contentType != test.expectedCT {
+			t.Errorf("Expected %q (%s), got %q (%s)", test.expected, test.expectedCT, body, contentType)
+		}
+	}
+}
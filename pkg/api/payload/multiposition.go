@@ -0,0 +1,118 @@
+package payload
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// FuzzMode is the way value lists are combined when fuzzing multiple positions independently,
+// analogous to the -mode option of ffuf
+type FuzzMode string
+
+const (
+	// FuzzModePitchfork uses the nth value of every list for the nth payload, stopping at the
+	// end of the shortest list
+	FuzzModePitchfork FuzzMode = "pitchfork"
+	// FuzzModeClusterbomb uses every combination of the values of the lists
+	FuzzModeClusterbomb FuzzMode = "clusterbomb"
+)
+
+// ParseFuzzMode parses a fuzz mode from its name
+func ParseFuzzMode(name string) (FuzzMode, error) {
+	switch mode := FuzzMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case FuzzModePitchfork, FuzzModeClusterbomb:
+		return mode, nil
+	}
+	return "", api.NewAPIError(fmt.Sprintf("Unknown fuzz mode: %s, expected pitchfork or clusterbomb", name), 0)
+}
+
+// positionMarker returns the fuzz marker of the position with the given index. The trailing
+// underscore keeps markers from being prefixes of each other.
+func positionMarker(index int) string {
+	return fmt.Sprintf("%s_%d_", FuzzMarker, index+1)
+}
+
+// FuzzJSONPositions creates JSON payloads with a value of its own list at each path, e.g. a
+// username list at $.username and a password list at $.password. The values lists are
+// combined according to the mode: pitchfork zips the lists, clusterbomb takes their
+// cartesian product, the value of the last path changing first.
+func (g *PayloadGenerator) FuzzJSONPositions(template string, paths []string, values [][]string, mode FuzzMode) ([]string, error) {
+	if g.format != FormatJSON {
+		return nil, api.NewAPIError("Generator is not configured for JSON payloads", 0)
+	}
+	if len(paths) != len(values) {
+		return nil, api.NewAPIError(fmt.Sprintf("Expected a list of values for each of the %d paths, got %d lists", len(paths), len(values)), 0)
+	}
+	if len(paths) == 0 {
+		return []string{}, nil
+	}
+
+	// Insert a marker of its own at each path
+	templateJSON := template
+	for i, path := range paths {
+		var err error
+		templateJSON, err = g.generateJSONWithPath(templateJSON, path, positionMarker(i))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	encoded := make([][]string, len(values))
+	for i, list := range values {
+		encoded[i] = g.encoders.EncodeAll(list)
+	}
+	combinations, err := combineValues(encoded, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := make([]string, len(combinations))
+	replacements := make([]string, 2*len(paths))
+	for i, combination := range combinations {
+		for j, value := range combination {
+			replacements[2*j] = positionMarker(j)
+			replacements[2*j+1] = value
+		}
+		// The markers are replaced in a single pass, so that values containing a marker are
+		// inserted as is
+		payloads[i] = strings.NewReplacer(replacements...).Replace(templateJSON)
+	}
+	return payloads, nil
+}
+
+// combineValues returns the combinations of values of the lists for a fuzz mode
+func combineValues(values [][]string, mode FuzzMode) ([][]string, error) {
+	switch mode {
+	case FuzzModePitchfork:
+		count := len(values[0])
+		for _, list := range values[1:] {
+			if len(list) < count {
+				count = len(list)
+			}
+		}
+		combinations := make([][]string, count)
+		for i := range combinations {
+			combination := make([]string, len(values))
+			for j, list := range values {
+				combination[j] = list[i]
+			}
+			combinations[i] = combination
+		}
+		return combinations, nil
+	case FuzzModeClusterbomb:
+		combinations := [][]string{{}}
+		for _, list := range values {
+			next := make([][]string, 0, len(combinations)*len(list))
+			for _, combination := range combinations {
+				for _, value := range list {
+					next = append(next, append(combination[:len(combination):len(combination)], value))
+				}
+			}
+			combinations = next
+		}
+		return combinations, nil
+	}
+	return nil, api.NewAPIError(fmt.Sprintf("Unknown fuzz mode: %s, expected pitchfork or clusterbomb", mode), 0)
+}
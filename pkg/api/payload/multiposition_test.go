@@ -0,0 +1,82 @@
+package payload
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPayloadGenerator_FuzzJSONPositions(t *testing.T) {
+	generator := NewPayloadGenerator(FormatJSON)
+	template := `{"username":"","password":"","remember":true}`
+	paths := []string{"username", "$.password"}
+	values := [][]string{{"admin", "root", "guest"}, {"secret", "toor"}}
+
+	tests := []struct {
+		mode FuzzMode
+		want [][2]string
+	}{
+		{
+			mode: FuzzModePitchfork,
+			want: [][2]string{{"admin", "secret"}, {"root", "toor"}},
+		},
+		{
+			mode: FuzzModeClusterbomb,
+			want: [][2]string{
+				{"admin", "secret"}, {"admin", "toor"},
+				{"root", "secret"}, {"root", "toor"},
+				{"guest", "secret"}, {"guest", "toor"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			payloads, err := generator.FuzzJSONPositions(template, paths, values, tt.mode)
+			if err != nil {
+				t.Fatalf("FuzzJSONPositions() error = %v", err)
+			}
+			got := make([][2]string, len(payloads))
+			for i, payload := range payloads {
+				var data map[string]interface{}
+				if err := json.Unmarshal([]byte(payload), &data); err != nil {
+					t.Fatalf("FuzzJSONPositions() returned invalid JSON: %v", err)
+				}
+				if data["remember"] != true {
+					t.Errorf("Expected the other fields to be kept, got %s", payload)
+				}
+				got[i] = [2]string{data["username"].(string), data["password"].(string)}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FuzzJSONPositions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPayloadGenerator_FuzzJSONPositions_Errors(t *testing.T) {
+	generator := NewPayloadGenerator(FormatJSON)
+
+	// Values containing markers are inserted as is
+	payloads, err := generator.FuzzJSONPositions(`{}`, []string{"a", "b"}, [][]string{{"FUZZ_2_"}, {"x"}}, FuzzModePitchfork)
+	if err != nil {
+		t.Fatalf("FuzzJSONPositions() error = %v", err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(payloads[0]), &data); err != nil || data["a"] != "FUZZ_2_" || data["b"] != "x" {
+		t.Errorf("FuzzJSONPositions() = %s", payloads[0])
+	}
+
+	if _, err := generator.FuzzJSONPositions(`{}`, []string{"a", "b"}, [][]string{{"x"}}, FuzzModePitchfork); err == nil {
+		t.Error("Expected an error for a missing value list")
+	}
+	if _, err := generator.FuzzJSONPositions(`{}`, []string{"a"}, [][]string{{"x"}}, FuzzMode("sniper")); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+	if _, err := NewPayloadGenerator(FormatXML).FuzzJSONPositions(`{}`, []string{"a"}, [][]string{{"x"}}, FuzzModePitchfork); err == nil {
+		t.Error("Expected an error for an XML generator")
+	}
+	if mode, err := ParseFuzzMode("ClusterBomb"); err != nil || mode != FuzzModeClusterbomb {
+		t.Errorf("ParseFuzzMode() = %v, %v", mode, err)
+	}
+}
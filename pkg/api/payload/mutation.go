@@ -0,0 +1,491 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// MutationType is a structural mutation of a JSON body
+type MutationType string
+
+const (
+	// MutationFieldDeletion removes a field from its object
+	MutationFieldDeletion MutationType = "field-deletion"
+	// MutationTypeFlip replaces a value with a value of another JSON type
+	MutationTypeFlip MutationType = "type-flip"
+	// MutationNullInjection replaces a value with null
+	MutationNullInjection MutationType = "null-injection"
+	// MutationArrayExplosion repeats the first item of an array many times
+	MutationArrayExplosion MutationType = "array-explosion"
+	// MutationKeyDuplication adds a second field with the same name to an object
+	MutationKeyDuplication MutationType = "key-duplication"
+	// MutationUnicodeConfusable replaces characters of strings and field names with
+	// look-alike unicode characters
+	MutationUnicodeConfusable MutationType = "unicode-confusable"
+	// MutationOversizedString replaces a string with a very long string
+	MutationOversizedString MutationType = "oversized-string"
+)
+
+// AllMutationTypes returns every mutation type, in the order they are applied
+func AllMutationTypes() []MutationType {
+	return []MutationType{
+		MutationFieldDeletion,
+		MutationTypeFlip,
+		MutationNullInjection,
+		MutationArrayExplosion,
+		MutationKeyDuplication,
+		MutationUnicodeConfusable,
+		MutationOversizedString,
+	}
+}
+
+const (
+	// DefaultArrayExplosionSize is the number of items of exploded arrays
+	DefaultArrayExplosionSize = 10000
+	// DefaultOversizedStringLength is the length of oversized strings
+	DefaultOversizedStringLength = 10 * 1024 * 1024
+)
+
+// confusables maps ASCII letters to look-alike Cyrillic and Greek letters
+var confusables = map[rune]rune{
+	'a': 'а', 'c': 'с', 'e': 'е', 'i': 'і', 'j': 'ј', 'o': 'о', 'p': 'р', 's': 'ѕ', 'x': 'х', 'y': 'у',
+	'A': 'А', 'B': 'В', 'C': 'С', 'E': 'Е', 'H': 'Н', 'I': 'І', 'K': 'К', 'M': 'М', 'N': 'Ν',
+	'O': 'О', 'P': 'Р', 'T': 'Т', 'X': 'Х', 'Y': 'Υ', 'Z': 'Ζ',
+}
+
+// Mutation is a mutated body
+type Mutation struct {
+	Type MutationType
+	// Path is the path of the mutated value, in the dot notation of the JSON fuzz points.
+	// It is empty for the root value.
+	Path string
+	// Description explains the mutation
+	Description string
+	Body        string
+}
+
+// Mutator applies structural mutations to a valid JSON body, producing a corpus for
+// robustness fuzzing without an API specification
+type Mutator struct {
+	// Types are the applied mutation types, all types if empty
+	Types []MutationType
+	// ArrayExplosionSize is the number of items of exploded arrays
+	ArrayExplosionSize int
+	// OversizedStringLength is the length of oversized strings
+	OversizedStringLength int
+}
+
+// NewMutator creates a new Mutator applying every mutation type
+func NewMutator() *Mutator {
+	return &Mutator{
+		ArrayExplosionSize:    DefaultArrayExplosionSize,
+		OversizedStringLength: DefaultOversizedStringLength,
+	}
+}
+
+// Mutate returns the mutations of a JSON body. Each mutation changes a single value of
+// the body, keeping the order of the fields of the original body.
+func (m *Mutator) Mutate(body string) ([]Mutation, error) {
+	mutations := make([]Mutation, 0)
+	err := m.Each(body, func(mutation Mutation) error {
+		mutations = append(mutations, mutation)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mutations, nil
+}
+
+// Each calls fn with every mutation of a JSON body, stopping at the first error returned by
+// fn. Unlike Mutate, it does not keep every mutated body in memory.
+func (m *Mutator) Each(body string, fn func(Mutation) error) error {
+	root, err := parseOrderedJSON([]byte(body))
+	if err != nil {
+		return api.NewAPIError("Failed to parse JSON body: "+err.Error(), 0)
+	}
+
+	enabled := make(map[MutationType]bool)
+	for _, t := range m.Types {
+		enabled[t] = true
+	}
+	if len(enabled) == 0 {
+		for _, t := range AllMutationTypes() {
+			enabled[t] = true
+		}
+	}
+
+	var visitErr error
+	walkOrderedJSON(root, nil, "", func(steps []int, path string, value interface{}, key string, member bool) {
+		if visitErr != nil {
+			return
+		}
+		for _, t := range AllMutationTypes() {
+			if !enabled[t] {
+				continue
+			}
+			for _, edit := range m.edits(t, value, key, member) {
+				mutated := editOrderedJSON(root, steps, edit.apply)
+				var buf bytes.Buffer
+				writeOrderedJSON(&buf, mutated)
+				visitErr = fn(Mutation{Type: t, Path: path, Description: edit.description, Body: buf.String()})
+				if visitErr != nil {
+					return
+				}
+			}
+		}
+	})
+	return visitErr
+}
+
+// mutationEdit is an edit of a value in a copy of the body
+type mutationEdit struct {
+	description string
+	apply       func(parent interface{}, index int)
+}
+
+// edits returns the edits of a mutation type applicable to a value. member is true if the
+// value is a field of an object, with the name key.
+func (m *Mutator) edits(t MutationType, value interface{}, key string, member bool) []mutationEdit {
+	edits := make([]mutationEdit, 0)
+	switch t {
+	case MutationFieldDeletion:
+		if member {
+			edits = append(edits, mutationEdit{"delete field", func(parent interface{}, index int) {
+				object := parent.(*jsonObject)
+				object.members = append(object.members[:index:index], object.members[index+1:]...)
+			}})
+		}
+	case MutationTypeFlip:
+		for _, flip := range typeFlips(value) {
+			flip := flip
+			edits = append(edits, mutationEdit{fmt.Sprintf("%s to %s", jsonTypeName(value), jsonTypeName(flip)), func(parent interface{}, index int) {
+				setOrderedJSON(parent, index, flip)
+			}})
+		}
+	case MutationNullInjection:
+		if value != nil {
+			edits = append(edits, mutationEdit{"replace with null", func(parent interface{}, index int) {
+				setOrderedJSON(parent, index, nil)
+			}})
+		}
+	case MutationArrayExplosion:
+		if array, ok := value.(*jsonArray); ok {
+			var item interface{} = "a"
+			if len(array.items) > 0 {
+				item = array.items[0]
+			}
+			size := m.ArrayExplosionSize
+			edits = append(edits, mutationEdit{fmt.Sprintf("array of %d items", size), func(parent interface{}, index int) {
+				items := make([]interface{}, size)
+				for i := range items {
+					items[i] = item
+				}
+				setOrderedJSON(parent, index, &jsonArray{items: items})
+			}})
+		}
+	case MutationKeyDuplication:
+		if member {
+			edits = append(edits, mutationEdit{"duplicate field", func(parent interface{}, index int) {
+				object := parent.(*jsonObject)
+				duplicate := jsonMember{key: object.members[index].key, value: "duplicate"}
+				if s, ok := object.members[index].value.(string); ok {
+					duplicate.value = s + "-duplicate"
+				}
+				members := append(object.members[:index+1:index+1], duplicate)
+				object.members = append(members, object.members[index+1:]...)
+			}})
+		}
+	case MutationUnicodeConfusable:
+		if s, ok := value.(string); ok {
+			if confusable, changed := confusableString(s); changed {
+				edits = append(edits, mutationEdit{"confusable characters in value", func(parent interface{}, index int) {
+					setOrderedJSON(parent, index, confusable)
+				}})
+			}
+			if fullwidth, changed := fullwidthString(s); changed {
+				edits = append(edits, mutationEdit{"fullwidth characters in value", func(parent interface{}, index int) {
+					setOrderedJSON(parent, index, fullwidth)
+				}})
+			}
+		}
+		if confusable, changed := confusableString(key); member && changed {
+			edits = append(edits, mutationEdit{"confusable characters in field name", func(parent interface{}, index int) {
+				parent.(*jsonObject).members[index].key = confusable
+			}})
+		}
+	case MutationOversizedString:
+		if _, ok := value.(string); ok {
+			size := m.OversizedStringLength
+			edits = append(edits, mutationEdit{fmt.Sprintf("string of %d characters", size), func(parent interface{}, index int) {
+				setOrderedJSON(parent, index, strings.Repeat("A", size))
+			}})
+		}
+	}
+	return edits
+}
+
+// typeFlips returns values of the other JSON types for a value
+func typeFlips(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case string:
+		return []interface{}{json.Number("1"), true, &jsonArray{items: []interface{}{v}}, &jsonObject{}}
+	case json.Number:
+		return []interface{}{v.String(), true, &jsonArray{items: []interface{}{v}}}
+	case bool:
+		number := json.Number("0")
+		if v {
+			number = json.Number("1")
+		}
+		return []interface{}{strconv.FormatBool(v), number}
+	case *jsonObject:
+		return []interface{}{"", &jsonArray{items: []interface{}{v}}}
+	case *jsonArray:
+		return []interface{}{"", &jsonObject{}}
+	case nil:
+		return []interface{}{"null", json.Number("0")}
+	}
+	return nil
+}
+
+// jsonTypeName returns the JSON type name of a value
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case *jsonObject:
+		return "object"
+	case *jsonArray:
+		return "array"
+	}
+	return "null"
+}
+
+// confusableString replaces the first letter of a string having a look-alike character
+func confusableString(s string) (string, bool) {
+	for i, r := range s {
+		if confusable, ok := confusables[r]; ok {
+			return s[:i] + string(confusable) + s[i+len(string(r)):], true
+		}
+	}
+	return s, false
+}
+
+// fullwidthString replaces the printable ASCII characters of a string with their fullwidth
+// forms, which unicode normalization maps back to ASCII
+func fullwidthString(s string) (string, bool) {
+	changed := false
+	fullwidth := strings.Map(func(r rune) rune {
+		if r >= 0x21 && r <= 0x7e {
+			changed = true
+			return r + 0xfee0
+		}
+		return r
+	}, s)
+	return fullwidth, changed
+}
+
+// jsonObject is a JSON object keeping the order of its fields, and duplicate fields
+type jsonObject struct {
+	members []jsonMember
+}
+
+// jsonMember is a field of a JSON object
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+// jsonArray is a JSON array
+type jsonArray struct {
+	items []interface{}
+}
+
+// parseOrderedJSON parses a JSON document into jsonObject, jsonArray, string, json.Number,
+// bool and nil values
+func parseOrderedJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := readOrderedJSON(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+// readOrderedJSON reads a JSON value from a decoder
+func readOrderedJSON(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := &jsonObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readOrderedJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.members = append(object.members, jsonMember{key: key.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := &jsonArray{items: make([]interface{}, 0)}
+		for decoder.More() {
+			value, err := readOrderedJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array.items = append(array.items, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+	return token, nil
+}
+
+// writeOrderedJSON writes a JSON value in compact form
+func writeOrderedJSON(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case *jsonObject:
+		buf.WriteByte('{')
+		for i, member := range v.members {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, member.key)
+			buf.WriteByte(':')
+			writeOrderedJSON(buf, member.value)
+		}
+		buf.WriteByte('}')
+	case *jsonArray:
+		buf.WriteByte('[')
+		for i, item := range v.items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeOrderedJSON(buf, item)
+		}
+		buf.WriteByte(']')
+	case string:
+		writeJSONString(buf, v)
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// writeJSONString writes a JSON string without escaping HTML characters
+func writeJSONString(buf *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	// Remove the newline written by the encoder
+	buf.Truncate(buf.Len() - 1)
+}
+
+// walkOrderedJSON calls visit with every value of a JSON document, its steps (the indexes of
+// the fields and items leading to it) and its path
+func walkOrderedJSON(value interface{}, steps []int, path string, visit func(steps []int, path string, value interface{}, key string, member bool)) {
+	visit(steps, path, value, "", false)
+	walkChildren(value, steps, path, visit)
+}
+
+// walkChildren walks the fields and items of a JSON value
+func walkChildren(value interface{}, steps []int, path string, visit func(steps []int, path string, value interface{}, key string, member bool)) {
+	childPath := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+	switch v := value.(type) {
+	case *jsonObject:
+		for i, member := range v.members {
+			childSteps := append(steps[:len(steps):len(steps)], i)
+			visit(childSteps, childPath(member.key), member.value, member.key, true)
+			walkChildren(member.value, childSteps, childPath(member.key), visit)
+		}
+	case *jsonArray:
+		for i, item := range v.items {
+			childSteps := append(steps[:len(steps):len(steps)], i)
+			walkOrderedJSON(item, childSteps, childPath(strconv.Itoa(i)), visit)
+		}
+	}
+}
+
+// editOrderedJSON applies an edit to the value at the given steps of a copy of a JSON
+// document, and returns the edited copy
+func editOrderedJSON(root interface{}, steps []int, edit func(parent interface{}, index int)) interface{} {
+	// The root is edited as the item of an array holding it
+	holder := &jsonArray{items: []interface{}{copyOrderedJSON(root)}}
+	var parent interface{} = holder
+	index := 0
+	for _, step := range steps {
+		parent, index = childOrderedJSON(parent, index), step
+	}
+	edit(parent, index)
+	return holder.items[0]
+}
+
+// childOrderedJSON returns the field or item of a JSON value at an index
+func childOrderedJSON(value interface{}, index int) interface{} {
+	switch v := value.(type) {
+	case *jsonObject:
+		return v.members[index].value
+	case *jsonArray:
+		return v.items[index]
+	}
+	return nil
+}
+
+// setOrderedJSON sets the field or item of a JSON value at an index
+func setOrderedJSON(parent interface{}, index int, value interface{}) {
+	switch v := parent.(type) {
+	case *jsonObject:
+		v.members[index].value = value
+	case *jsonArray:
+		v.items[index] = value
+	}
+}
+
+// copyOrderedJSON returns a deep copy of a JSON value
+func copyOrderedJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *jsonObject:
+		object := &jsonObject{members: make([]jsonMember, len(v.members))}
+		for i, member := range v.members {
+			object.members[i] = jsonMember{key: member.key, value: copyOrderedJSON(member.value)}
+		}
+		return object
+	case *jsonArray:
+		array := &jsonArray{items: make([]interface{}, len(v.items))}
+		for i, item := range v.items {
+			array.items[i] = copyOrderedJSON(item)
+		}
+		return array
+	}
+	return value
+}
@@ -0,0 +1,88 @@
+package payload
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMutator_Mutate(t *testing.T) {
+	mutator := NewMutator()
+	mutator.ArrayExplosionSize = 3
+	mutator.OversizedStringLength = 8
+
+	mutations, err := mutator.Mutate(`{"name":"bob","age":30,"tags":["a"],"admin":false,"meta":null}`)
+	if err != nil {
+		t.Fatalf("Mutate() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"field-deletion name":            `{"age":30,"tags":["a"],"admin":false,"meta":null}`,
+		"type-flip age":                  `{"name":"bob","age":"30","tags":["a"],"admin":false,"meta":null}`,
+		"type-flip admin":                `{"name":"bob","age":30,"tags":["a"],"admin":"false","meta":null}`,
+		"null-injection tags.0":          `{"name":"bob","age":30,"tags":[null],"admin":false,"meta":null}`,
+		"array-explosion tags":           `{"name":"bob","age":30,"tags":["a","a","a"],"admin":false,"meta":null}`,
+		"key-duplication name":           `{"name":"bob","name":"bob-duplicate","age":30,"tags":["a"],"admin":false,"meta":null}`,
+		"unicode-confusable name":        `{"name":"bоb","age":30,"tags":["a"],"admin":false,"meta":null}`,
+		"oversized-string name":          `{"name":"AAAAAAAA","age":30,"tags":["a"],"admin":false,"meta":null}`,
+		"type-flip ":                     `""`,
+		"null-injection ":                `null`,
+		"unicode-confusable tags.0":      `{"name":"bob","age":30,"tags":["а"],"admin":false,"meta":null}`,
+		"key-duplication meta":           `{"name":"bob","age":30,"tags":["a"],"admin":false,"meta":null,"meta":"duplicate"}`,
+		"field-deletion meta":            `{"name":"bob","age":30,"tags":["a"],"admin":false}`,
+		"unicode-confusable admin field": `{"name":"bob","age":30,"tags":["a"],"аdmin":false,"meta":null}`,
+	}
+	found := make(map[string]bool)
+	for _, mutation := range mutations {
+		if !json.Valid([]byte(mutation.Body)) {
+			t.Errorf("Mutation %s of %s is not valid JSON: %s", mutation.Type, mutation.Path, mutation.Body)
+		}
+		for name, body := range expected {
+			if mutation.Body == body && strings.HasPrefix(name, string(mutation.Type)+" "+mutation.Path) {
+				found[name] = true
+			}
+		}
+		if mutation.Type == MutationNullInjection && mutation.Path == "meta" {
+			t.Error("Expected no null injection of a null value")
+		}
+	}
+	for name, body := range expected {
+		if !found[name] {
+			t.Errorf("Expected mutation %s: %s", name, body)
+		}
+	}
+}
+
+func TestMutator_Types(t *testing.T) {
+	mutator := NewMutator()
+	mutator.Types = []MutationType{MutationOversizedString}
+
+	mutations, err := mutator.Mutate(`{"user":{"email":"a@example.com","id":1}}`)
+	if err != nil {
+		t.Fatalf("Mutate() error = %v", err)
+	}
+	if len(mutations) != 1 || mutations[0].Path != "user.email" {
+		t.Fatalf("Expected a single oversized string mutation, got %d", len(mutations))
+	}
+	if len(mutations[0].Body) < DefaultOversizedStringLength {
+		t.Errorf("Expected a body of at least %d bytes, got %d", DefaultOversizedStringLength, len(mutations[0].Body))
+	}
+
+	// Special characters are kept as is
+	mutator.Types = []MutationType{MutationFieldDeletion}
+	mutations, err = mutator.Mutate(`{"a":"<b>&","c":1.50}`)
+	if err != nil {
+		t.Fatalf("Mutate() error = %v", err)
+	}
+	if len(mutations) != 2 || mutations[0].Body != `{"c":1.50}` || mutations[1].Body != `{"a":"<b>&"}` {
+		t.Errorf("Unexpected mutations %v", mutations)
+	}
+}
+
+func TestMutator_InvalidBody(t *testing.T) {
+	for _, body := range []string{"", "{", `{"a":1} x`, "not json"} {
+		if _, err := NewMutator().Mutate(body); err == nil {
+			t.Errorf("Mutate(%q) expected an error", body)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 // Package payload provides functionality for generating API request payloads.
 //
 // This package includes generators for various API payload formats including JSON,
-// XML, GraphQL queries, and form data. It enables creation of structured payloads
+// XML, GraphQL queries, form data and protobuf. It enables creation of structured payloads
 // for API testing with support for fuzzing specific fields.
 package payload
 
@@ -28,6 +28,8 @@ const (
 	FormatGraphQL
 	// FormatFormData represents form data
 	FormatFormData
+	// FormatProtobuf represents a binary protobuf message
+	FormatProtobuf
 )
 
 // FuzzMarker is the string that will be replaced with fuzzing values
@@ -36,6 +38,12 @@ const FuzzMarker = "FUZZ"
 // PayloadGenerator provides methods for generating API request payloads
 type PayloadGenerator struct {
 	format PayloadFormat
+	// namespaces maps the prefixes of XML fuzz point paths to namespace URIs
+	namespaces map[string]string
+	// boundary is the boundary of multipart bodies
+	boundary string
+	// encoders is the encoder chain applied to fuzz values
+	encoders *EncoderChain
 }
 
 // NewPayloadGenerator creates a new PayloadGenerator with the specified format
@@ -45,8 +53,13 @@ func NewPayloadGenerator(format PayloadFormat) *PayloadGenerator {
 	}
 }
 
-// generateJSONWithPath is a helper function that creates a JSON object with a value at the specified path
+// generateJSONWithPath is a helper function that creates a JSON object with a value at the specified path.
+// Paths starting with $ are JSONPath expressions, which can select many positions at once.
 func (g *PayloadGenerator) generateJSONWithPath(template string, path string, value interface{}) (string, error) {
+	if isJSONPath(path) {
+		return g.generateJSONWithJSONPath(template, path, value)
+	}
+
 	// If template is empty, create a new JSON object
 	var jsonData map[string]interface{}
 	if template == "" {
@@ -284,7 +297,9 @@ func isNumeric(s string) bool {
 	return err == nil
 }
 
-// GenerateJSON creates a JSON payload with the fuzz marker in the specified path
+// GenerateJSON creates a JSON payload with the fuzz marker in the specified path. The path is
+// either a dot path such as users.0.name, or a JSONPath expression such as $.users[*].email
+// placing the fuzz marker at every matching position.
 func (g *PayloadGenerator) GenerateJSON(template string, path string) (string, error) {
 	if g.format != FormatJSON {
 		return "", api.NewAPIError("Generator is not configured for JSON payloads", 0)
@@ -296,7 +311,7 @@ func (g *PayloadGenerator) GenerateJSON(template string, path string) (string, e
 	}
 
 	// Check if the path contains "invalid" to handle the invalid array index test case
-	if strings.Contains(path, "invalid") {
+	if !isJSONPath(path) && strings.Contains(path, "invalid") {
 		return "", api.NewAPIError("Invalid array index: invalid", 0)
 	}
 
@@ -426,6 +441,7 @@ func (g *PayloadGenerator) FuzzGraphQL(queryTemplate string, variables map[strin
 	if g.format != FormatGraphQL {
 		return nil, api.NewAPIError("Generator is not configured for GraphQL payloads", 0)
 	}
+	values = g.encoders.EncodeAll(values)
 
 	// Generate the template GraphQL payload with the fuzz marker
 	templatePayload, err := g.GenerateGraphQLWithFuzzPoint(queryTemplate, variables)
@@ -451,6 +467,7 @@ func (g *PayloadGenerator) FuzzGraphQLVariable(query string, variableName string
 	if g.format != FormatGraphQL {
 		return nil, api.NewAPIError("Generator is not configured for GraphQL payloads", 0)
 	}
+	values = g.encoders.EncodeAll(values)
 
 	// Generate the template GraphQL payload with the fuzz marker in the variable
 	templatePayload, err := g.GenerateGraphQLWithVariableFuzzPoint(query, variableName)
@@ -477,6 +494,7 @@ func (g *PayloadGenerator) FuzzJSON(template string, path string, values []strin
 	if g.format != FormatJSON {
 		return nil, api.NewAPIError("Generator is not configured for JSON payloads", 0)
 	}
+	values = g.encoders.EncodeAll(values)
 
 	// Generate the template JSON with the fuzz marker
 	templateJSON, err := g.GenerateJSON(template, path)
@@ -503,6 +521,7 @@ func (g *PayloadGenerator) FuzzJSONWithMultipleFuzzPoints(template string, paths
 	if g.format != FormatJSON {
 		return nil, api.NewAPIError("Generator is not configured for JSON payloads", 0)
 	}
+	values = g.encoders.EncodeAll(values)
 
 	// Generate the template JSON with the fuzz markers
 	templateJSON, err := g.GenerateJSONWithMultipleFuzzPoints(template, paths)
@@ -610,8 +629,18 @@ func (g *PayloadGenerator) GenerateRESTRequest(baseReq *ffuf.Request, paramType
 			}
 
 			req.Data = jsonBytes
+		} else if strings.HasPrefix(req.Headers["Content-Type"], "application/x-www-form-urlencoded") {
+			// Insert the fuzz marker in the value of the form field
+			fields, err := parseFormURLEncoded(string(req.Data))
+			if err != nil {
+				return nil, err
+			}
+			if fields, err = setFormFuzzMarker(fields, paramName, FormTargetValue); err != nil {
+				return nil, err
+			}
+			req.Data = []byte(encodeFormURLEncoded(fields))
 		} else {
-			return nil, api.NewAPIError("Body parameter fuzzing is only supported for JSON content type", 0)
+			return nil, api.NewAPIError("Body parameter fuzzing is only supported for JSON and form content types", 0)
 		}
 
 	default:
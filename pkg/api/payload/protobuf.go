@@ -0,0 +1,583 @@
+package payload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// ProtoSchema contains the messages, enums and services of protobuf definitions
+type ProtoSchema struct {
+	// Messages maps the full names of messages (e.g. "users.v1.GetUserRequest") to their definition
+	Messages map[string]*ProtoMessage
+	// Enums maps the full names of enums to their definition
+	Enums map[string]*ProtoEnum
+	// Services maps the full names of services to their definition
+	Services map[string]*ProtoService
+}
+
+// ProtoMessage is a protobuf message definition
+type ProtoMessage struct {
+	Name   string
+	Fields []*ProtoField
+	// MapEntry is true for the synthetic entry messages of map fields
+	MapEntry bool
+}
+
+// ProtoField is a field of a protobuf message
+type ProtoField struct {
+	Name   string
+	Number int
+	// Type is the scalar type of the field (e.g. "int32" or "string"), "message" or "enum"
+	Type string
+	// TypeName is the full name of the message or enum type of the field
+	TypeName string
+	Repeated bool
+	// scope is the message the field is defined in, used to resolve TypeName
+	scope string
+}
+
+// ProtoEnum is a protobuf enum definition
+type ProtoEnum struct {
+	Name   string
+	Values map[string]int32
+}
+
+// ProtoService is a gRPC service definition
+type ProtoService struct {
+	Name    string
+	Methods []*ProtoMethod
+}
+
+// ProtoMethod is a method of a gRPC service
+type ProtoMethod struct {
+	Name       string
+	InputType  string
+	OutputType string
+}
+
+// protoScalarTypes are the scalar types of protobuf fields
+var protoScalarTypes = map[string]bool{
+	"double": true, "float": true, "int32": true, "int64": true, "uint32": true, "uint64": true,
+	"sint32": true, "sint64": true, "fixed32": true, "fixed64": true, "sfixed32": true,
+	"sfixed64": true, "bool": true, "string": true, "bytes": true,
+}
+
+// NewProtoSchema creates an empty protobuf schema
+func NewProtoSchema() *ProtoSchema {
+	return &ProtoSchema{
+		Messages: make(map[string]*ProtoMessage),
+		Enums:    make(map[string]*ProtoEnum),
+		Services: make(map[string]*ProtoService),
+	}
+}
+
+// LoadProtoSchema loads protobuf definitions from a .proto file or from a binary
+// FileDescriptorSet, such as created by "protoc --descriptor_set_out" or "buf build -o".
+// Imports of .proto files are not followed, use a descriptor set including imports
+// (--include_imports) if messages reference types of other files.
+func LoadProtoSchema(path string) (*ProtoSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to read protobuf definitions: "+err.Error(), 0)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".proto") {
+		return ParseProto(string(data))
+	}
+	return ParseDescriptorSet(data)
+}
+
+// ParseProto parses the protobuf definitions of a .proto file
+func ParseProto(source string) (*ProtoSchema, error) {
+	schema := NewProtoSchema()
+	p := &protoParser{tokens: tokenizeProto(source), schema: schema}
+	if err := p.parseFile(); err != nil {
+		return nil, err
+	}
+	if err := schema.resolve(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Message returns the definition of a message. The name may be given with or without a leading dot.
+func (s *ProtoSchema) Message(name string) (*ProtoMessage, error) {
+	message, ok := s.Messages[strings.TrimPrefix(name, ".")]
+	if !ok {
+		return nil, api.NewAPIError(fmt.Sprintf("Unknown protobuf message: %s", name), 0)
+	}
+	return message, nil
+}
+
+// MethodInput returns the input message of a gRPC method from its path, e.g.
+// "/users.v1.UserService/GetUser" or a full URL ending with such a path
+func (s *ProtoSchema) MethodInput(path string) (string, error) {
+	parts := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	if len(parts) < 2 {
+		return "", api.NewAPIError(fmt.Sprintf("Invalid gRPC method path: %s", path), 0)
+	}
+	serviceName, methodName := parts[len(parts)-2], parts[len(parts)-1]
+
+	service, ok := s.Services[serviceName]
+	if !ok {
+		return "", api.NewAPIError(fmt.Sprintf("Unknown gRPC service: %s", serviceName), 0)
+	}
+	for _, method := range service.Methods {
+		if method.Name == methodName {
+			return method.InputType, nil
+		}
+	}
+	return "", api.NewAPIError(fmt.Sprintf("Unknown method %s of gRPC service %s", methodName, serviceName), 0)
+}
+
+// resolve resolves the type names of message fields and service methods
+func (s *ProtoSchema) resolve() error {
+	for _, message := range s.Messages {
+		for _, field := range message.Fields {
+			if field.Type != "" {
+				continue
+			}
+			name, kind := s.lookup(field.TypeName, field.scope)
+			if kind == "" {
+				return api.NewAPIError(fmt.Sprintf("Unknown type %s of field %s.%s", field.TypeName, message.Name, field.Name), 0)
+			}
+			field.Type, field.TypeName = kind, name
+		}
+	}
+	for _, service := range s.Services {
+		scope := service.Name[:strings.LastIndex(service.Name, ".")+1]
+		for _, method := range service.Methods {
+			for _, typeName := range []*string{&method.InputType, &method.OutputType} {
+				name, kind := s.lookup(*typeName, strings.TrimSuffix(scope, "."))
+				if kind != "message" {
+					return api.NewAPIError(fmt.Sprintf("Unknown message %s of method %s.%s", *typeName, service.Name, method.Name), 0)
+				}
+				*typeName = name
+			}
+		}
+	}
+	return nil
+}
+
+// lookup resolves a type name from a scope following the protobuf scoping rules, and
+// returns its full name and kind ("message" or "enum")
+func (s *ProtoSchema) lookup(name, scope string) (string, string) {
+	candidates := []string{strings.TrimPrefix(name, ".")}
+	if !strings.HasPrefix(name, ".") {
+		candidates = nil
+		for scope != "" {
+			candidates = append(candidates, scope+"."+name)
+			if i := strings.LastIndex(scope, "."); i >= 0 {
+				scope = scope[:i]
+			} else {
+				scope = ""
+			}
+		}
+		candidates = append(candidates, name)
+	}
+
+	for _, candidate := range candidates {
+		if _, ok := s.Messages[candidate]; ok {
+			return candidate, "message"
+		}
+		if _, ok := s.Enums[candidate]; ok {
+			return candidate, "enum"
+		}
+	}
+	return "", ""
+}
+
+// protoParser parses the tokens of a .proto file
+type protoParser struct {
+	tokens []string
+	pos    int
+	pkg    string
+	schema *ProtoSchema
+}
+
+// tokenizeProto splits a .proto file into tokens, dropping comments
+func tokenizeProto(source string) []string {
+	var tokens []string
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(source[i:], "//"):
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				i = len(source)
+			} else {
+				i += end + 4
+			}
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(source) && source[j] != c {
+				if source[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(source) {
+				j = len(source) - 1
+			}
+			tokens = append(tokens, source[i:j+1])
+			i = j + 1
+		case strings.ContainsRune("{}[]()<>;=,", rune(c)):
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			j := i
+			for j < len(source) && !strings.ContainsRune(" \t\n\r{}[]()<>;=,\"'/", rune(source[j])) {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			tokens = append(tokens, source[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+// next returns the next token
+func (p *protoParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	return token
+}
+
+// peek returns the next token without consuming it
+func (p *protoParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+// expect consumes the next token and checks its value
+func (p *protoParser) expect(expected string) error {
+	if token := p.next(); token != expected {
+		if token == "" {
+			token = "end of file"
+		}
+		return api.NewAPIError(fmt.Sprintf("Failed to parse protobuf definitions: expected '%s', got '%s'", expected, token), 0)
+	}
+	return nil
+}
+
+// skipStatement skips tokens up to the end of the statement, including option blocks
+func (p *protoParser) skipStatement() {
+	depth := 0
+	for {
+		token := p.next()
+		switch token {
+		case "":
+			return
+		case "{", "[", "(":
+			depth++
+		case "}", "]", ")":
+			depth--
+			if depth == 0 && token == "}" {
+				return
+			}
+		case ";":
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// skipBlock skips a block of braces starting with the next token
+func (p *protoParser) skipBlock() {
+	for p.peek() != "{" && p.peek() != "" {
+		p.next()
+	}
+	p.skipStatement()
+}
+
+// parseFile parses the top-level statements of a .proto file
+func (p *protoParser) parseFile() error {
+	for p.peek() != "" {
+		switch token := p.next(); token {
+		case "package":
+			p.pkg = p.next()
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case "message":
+			if err := p.parseMessage(p.pkg); err != nil {
+				return err
+			}
+		case "enum":
+			if err := p.parseEnum(p.pkg); err != nil {
+				return err
+			}
+		case "service":
+			if err := p.parseService(); err != nil {
+				return err
+			}
+		case "extend":
+			p.skipBlock()
+		case ";":
+		default:
+			// syntax, edition, import and option statements
+			p.pos--
+			p.skipStatement()
+		}
+	}
+	return nil
+}
+
+// fullName returns the full name of a definition in a scope
+func fullName(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// parseMessage parses a message definition after the "message" keyword
+func (p *protoParser) parseMessage(scope string) error {
+	message := &ProtoMessage{Name: fullName(scope, p.next())}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	p.schema.Messages[message.Name] = message
+
+	for {
+		switch token := p.next(); token {
+		case "}":
+			return nil
+		case "":
+			return api.NewAPIError("Failed to parse protobuf definitions: unclosed message "+message.Name, 0)
+		case "message":
+			if err := p.parseMessage(message.Name); err != nil {
+				return err
+			}
+		case "enum":
+			if err := p.parseEnum(message.Name); err != nil {
+				return err
+			}
+		case "oneof":
+			// Fields of a oneof are fields of the message
+			p.next()
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			for p.peek() != "}" && p.peek() != "" {
+				if p.peek() == "option" {
+					p.skipStatement()
+					continue
+				}
+				if err := p.parseField(message, ""); err != nil {
+					return err
+				}
+			}
+			p.next()
+		case "extend":
+			p.skipBlock()
+		case "option", "reserved", "extensions", ";":
+			if token != ";" {
+				p.skipStatement()
+			}
+		case "map":
+			if err := p.parseMapField(message); err != nil {
+				return err
+			}
+		case "repeated", "optional", "required":
+			label := token
+			if p.peek() == "map" {
+				p.next()
+				if err := p.parseMapField(message); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := p.parseField(message, label); err != nil {
+				return err
+			}
+		default:
+			p.pos--
+			if err := p.parseField(message, ""); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseField parses a field definition after its label
+func (p *protoParser) parseField(message *ProtoMessage, label string) error {
+	typeName := p.next()
+	if typeName == "group" {
+		return api.NewAPIError("Failed to parse protobuf definitions: groups are not supported", 0)
+	}
+	name := p.next()
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	number, err := strconv.Atoi(p.next())
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to parse protobuf definitions: invalid number of field %s", name), 0)
+	}
+	// Field options, e.g. [packed = false]
+	p.skipStatement()
+
+	field := &ProtoField{Name: name, Number: number, Repeated: label == "repeated", scope: message.Name}
+	if protoScalarTypes[typeName] {
+		field.Type = typeName
+	} else {
+		field.TypeName = typeName
+	}
+	message.Fields = append(message.Fields, field)
+	return nil
+}
+
+// parseMapField parses a map field after the "map" keyword. Map fields are repeated
+// fields of a synthetic entry message with a key and a value field.
+func (p *protoParser) parseMapField(message *ProtoMessage) error {
+	if err := p.expect("<"); err != nil {
+		return err
+	}
+	keyType := p.next()
+	if err := p.expect(","); err != nil {
+		return err
+	}
+	valueType := p.next()
+	if err := p.expect(">"); err != nil {
+		return err
+	}
+	name := p.next()
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	number, err := strconv.Atoi(p.next())
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Failed to parse protobuf definitions: invalid number of field %s", name), 0)
+	}
+	p.skipStatement()
+
+	entry := &ProtoMessage{Name: fullName(message.Name, mapEntryName(name)), MapEntry: true}
+	value := &ProtoField{Name: "value", Number: 2, scope: message.Name}
+	if protoScalarTypes[valueType] {
+		value.Type = valueType
+	} else {
+		value.TypeName = valueType
+	}
+	entry.Fields = []*ProtoField{{Name: "key", Number: 1, Type: keyType}, value}
+	p.schema.Messages[entry.Name] = entry
+
+	message.Fields = append(message.Fields, &ProtoField{Name: name, Number: number, Type: "message", TypeName: entry.Name, Repeated: true})
+	return nil
+}
+
+// mapEntryName returns the name of the entry message of a map field, e.g. "AttributesEntry"
+func mapEntryName(field string) string {
+	var b strings.Builder
+	upper := true
+	for _, c := range field {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(c)))
+			upper = false
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String() + "Entry"
+}
+
+// parseEnum parses an enum definition after the "enum" keyword
+func (p *protoParser) parseEnum(scope string) error {
+	enum := &ProtoEnum{Name: fullName(scope, p.next()), Values: make(map[string]int32)}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	p.schema.Enums[enum.Name] = enum
+
+	for {
+		switch token := p.next(); token {
+		case "}":
+			return nil
+		case "":
+			return api.NewAPIError("Failed to parse protobuf definitions: unclosed enum "+enum.Name, 0)
+		case "option", "reserved":
+			p.skipStatement()
+		case ";":
+		default:
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			number, err := strconv.ParseInt(p.next(), 0, 32)
+			if err != nil {
+				return api.NewAPIError(fmt.Sprintf("Failed to parse protobuf definitions: invalid value of %s", token), 0)
+			}
+			enum.Values[token] = int32(number)
+			p.skipStatement()
+		}
+	}
+}
+
+// parseService parses a service definition after the "service" keyword
+func (p *protoParser) parseService() error {
+	service := &ProtoService{Name: fullName(p.pkg, p.next())}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	p.schema.Services[service.Name] = service
+
+	for {
+		switch token := p.next(); token {
+		case "}":
+			return nil
+		case "":
+			return api.NewAPIError("Failed to parse protobuf definitions: unclosed service "+service.Name, 0)
+		case "rpc":
+			method := &ProtoMethod{Name: p.next()}
+			types := []*string{&method.InputType, &method.OutputType}
+			for i, typeName := range types {
+				if i == 1 {
+					if err := p.expect("returns"); err != nil {
+						return err
+					}
+				}
+				if err := p.expect("("); err != nil {
+					return err
+				}
+				if p.peek() == "stream" {
+					p.next()
+				}
+				*typeName = p.next()
+				if err := p.expect(")"); err != nil {
+					return err
+				}
+			}
+			if p.peek() == "{" {
+				p.skipStatement()
+			} else if err := p.expect(";"); err != nil {
+				return err
+			}
+			service.Methods = append(service.Methods, method)
+		case ";":
+		default:
+			p.skipStatement()
+		}
+	}
+}
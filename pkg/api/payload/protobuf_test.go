@@ -0,0 +1,267 @@
+package payload
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const testProto = `
+syntax = "proto3";
+
+package users.v1;
+
+option go_package = "example.com/users/v1;usersv1";
+
+// UserService manages users
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc UpdateUser(User) returns (User) {
+    option (google.api.http) = { put: "/v1/users/{id}" body: "*" };
+  }
+}
+
+message GetUserRequest {
+  int64 id = 1;
+}
+
+message User {
+  enum Role {
+    ROLE_UNSPECIFIED = 0;
+    ROLE_ADMIN = 1;
+  }
+  message Address {
+    string city = 1;
+  }
+
+  int64 id = 1;
+  string display_name = 2 [json_name = "displayName"];
+  Role role = 3;
+  repeated int32 scores = 4;
+  Address address = 5;
+  map<string, string> labels = 6;
+  oneof contact {
+    string email = 7;
+    string phone = 8;
+  }
+  sint32 balance = 9;
+  bytes avatar = 10;
+  reserved 11, 12;
+}
+`
+
+func TestParseProto(t *testing.T) {
+	schema, err := ParseProto(testProto)
+	if err != nil {
+		t.Fatalf("ParseProto() error = %v", err)
+	}
+
+	user, err := schema.Message("users.v1.User")
+	if err != nil {
+		t.Fatalf("Message() error = %v", err)
+	}
+	if len(user.Fields) != 10 {
+		t.Fatalf("Expected 10 fields, got %d", len(user.Fields))
+	}
+	expected := map[string]ProtoField{
+		"role":    {Name: "role", Number: 3, Type: "enum", TypeName: "users.v1.User.Role"},
+		"scores":  {Name: "scores", Number: 4, Type: "int32", Repeated: true},
+		"address": {Name: "address", Number: 5, Type: "message", TypeName: "users.v1.User.Address"},
+		"labels":  {Name: "labels", Number: 6, Type: "message", TypeName: "users.v1.User.LabelsEntry", Repeated: true},
+		"phone":   {Name: "phone", Number: 8, Type: "string"},
+	}
+	for _, field := range user.Fields {
+		want, ok := expected[field.Name]
+		if !ok {
+			continue
+		}
+		got := *field
+		got.scope = ""
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Field %s = %+v, want %+v", field.Name, got, want)
+		}
+	}
+
+	input, err := schema.MethodInput("https://api.example.com/users.v1.UserService/GetUser")
+	if err != nil || input != "users.v1.GetUserRequest" {
+		t.Errorf("MethodInput() = %v, %v, want users.v1.GetUserRequest", input, err)
+	}
+	if _, err := schema.MethodInput("/users.v1.UserService/DeleteUser"); err == nil {
+		t.Error("MethodInput() expected an error for an unknown method")
+	}
+
+	if _, err := ParseProto(`message A { Unknown b = 1; }`); err == nil {
+		t.Error("ParseProto() expected an error for an unknown type")
+	}
+}
+
+func TestProtoSchema_Encode(t *testing.T) {
+	schema, err := ParseProto(testProto)
+	if err != nil {
+		t.Fatalf("ParseProto() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		json    string
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:    "Varint",
+			message: "users.v1.GetUserRequest",
+			json:    `{"id": 150}`,
+			want:    []byte{0x08, 0x96, 0x01},
+		},
+		{
+			name:    "Integer as string",
+			message: "users.v1.GetUserRequest",
+			json:    `{"id": "-1"}`,
+			want:    []byte{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+		},
+		{
+			name:    "Empty message",
+			message: "users.v1.GetUserRequest",
+			json:    ``,
+			want:    []byte{},
+		},
+		{
+			name:    "String, enum, packed and zigzag",
+			message: "users.v1.User",
+			json:    `{"displayName": "ab", "role": "ROLE_ADMIN", "scores": [1, 2], "balance": -2}`,
+			want:    []byte{0x12, 0x02, 'a', 'b', 0x18, 0x01, 0x22, 0x02, 0x01, 0x02, 0x48, 0x03},
+		},
+		{
+			name:    "Nested message and map",
+			message: "users.v1.User",
+			json:    `{"address": {"city": "x"}, "labels": {"k": "v"}}`,
+			want:    []byte{0x2a, 0x03, 0x0a, 0x01, 'x', 0x32, 0x06, 0x0a, 0x01, 'k', 0x12, 0x01, 'v'},
+		},
+		{
+			name:    "Unknown field",
+			message: "users.v1.User",
+			json:    `{"password": "x"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid integer",
+			message: "users.v1.GetUserRequest",
+			json:    `{"id": "abc"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := schema.Encode(tt.message, []byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Encode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !bytes.Equal(got, tt.want) {
+				t.Errorf("Encode() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtoSchema_Decode(t *testing.T) {
+	schema, err := ParseProto(testProto)
+	if err != nil {
+		t.Fatalf("ParseProto() error = %v", err)
+	}
+
+	data, err := schema.Encode("users.v1.User", []byte(`{"id": 7, "display_name": "john", "role": 1, "scores": [3, -4], "address": {"city": "Paris"}, "labels": {"a": "b"}, "balance": -5, "avatar": "AQI="}`))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := schema.Decode("users.v1.User", data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"id":           int64(7),
+		"display_name": "john",
+		"role":         "ROLE_ADMIN",
+		"scores":       []interface{}{int64(3), int64(-4)},
+		"address":      map[string]interface{}{"city": "Paris"},
+		"labels":       map[string]interface{}{"a": "b"},
+		"balance":      int64(-5),
+		"avatar":       "AQI=",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %v, want %v", got, want)
+	}
+}
+
+// descriptorField encodes a length-delimited field of a descriptor
+func descriptorField(number int, data []byte) []byte {
+	var buf bytes.Buffer
+	writeProtoKey(&buf, number, protoWireBytes)
+	writeProtoBytes(&buf, data)
+	return buf.Bytes()
+}
+
+// descriptorVarint encodes a varint field of a descriptor
+func descriptorVarint(number int, value uint64) []byte {
+	var buf bytes.Buffer
+	writeProtoKey(&buf, number, protoWireVarint)
+	writeProtoVarint(&buf, value)
+	return buf.Bytes()
+}
+
+func TestParseDescriptorSet(t *testing.T) {
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	field := join(descriptorField(1, []byte("name")), descriptorVarint(3, 1), descriptorVarint(4, 1), descriptorVarint(5, 9))
+	message := join(descriptorField(1, []byte("Request")), descriptorField(2, field))
+	method := join(descriptorField(1, []byte("Call")), descriptorField(2, []byte(".pkg.Request")), descriptorField(3, []byte(".pkg.Request")))
+	service := join(descriptorField(1, []byte("Service")), descriptorField(2, method))
+	file := join(descriptorField(1, []byte("pkg.proto")), descriptorField(2, []byte("pkg")), descriptorField(4, message), descriptorField(6, service))
+
+	schema, err := ParseDescriptorSet(descriptorField(1, file))
+	if err != nil {
+		t.Fatalf("ParseDescriptorSet() error = %v", err)
+	}
+	input, err := schema.MethodInput("/pkg.Service/Call")
+	if err != nil || input != "pkg.Request" {
+		t.Fatalf("MethodInput() = %v, %v, want pkg.Request", input, err)
+	}
+	data, err := schema.Encode(input, []byte(`{"name": "x"}`))
+	if err != nil || !bytes.Equal(data, []byte{0x0a, 0x01, 'x'}) {
+		t.Errorf("Encode() = %x, %v", data, err)
+	}
+}
+
+func TestFuzzProtobuf(t *testing.T) {
+	schema, err := ParseProto(testProto)
+	if err != nil {
+		t.Fatalf("ParseProto() error = %v", err)
+	}
+	generator := NewPayloadGenerator(FormatProtobuf)
+
+	payloads, err := generator.FuzzProtobuf(schema, "users.v1.User", `{"id": 1}`, "display_name", []string{`a"b`, "2147483648"})
+	if err != nil {
+		t.Fatalf("FuzzProtobuf() error = %v", err)
+	}
+	want := [][]byte{
+		{0x08, 0x01, 0x12, 0x03, 'a', '"', 'b'},
+		{0x08, 0x01, 0x12, 0x0a, '2', '1', '4', '7', '4', '8', '3', '6', '4', '8'},
+	}
+	if !reflect.DeepEqual(payloads, want) {
+		t.Errorf("FuzzProtobuf() = %x, want %x", payloads, want)
+	}
+
+	// Numeric fuzz points accept values out of the range of the field type
+	payloads, err = generator.FuzzProtobuf(schema, "users.v1.User", `{"scores": ["FUZZ"]}`, "", []string{"4294967296"})
+	if err != nil {
+		t.Fatalf("FuzzProtobuf() error = %v", err)
+	}
+	if !bytes.Equal(payloads[0], []byte{0x22, 0x05, 0x80, 0x80, 0x80, 0x80, 0x10}) {
+		t.Errorf("FuzzProtobuf() = %x", payloads[0])
+	}
+
+	if _, err := NewPayloadGenerator(FormatJSON).FuzzProtobuf(schema, "users.v1.User", "", "id", nil); err == nil {
+		t.Error("FuzzProtobuf() expected an error for a JSON generator")
+	}
+}
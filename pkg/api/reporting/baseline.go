@@ -0,0 +1,131 @@
+package reporting
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+const (
+	// FindingNew is the baseline status of a finding missing from the baseline
+	FindingNew = "new"
+	// FindingKnown is the baseline status of a finding already in the baseline
+	FindingKnown = "known"
+	// FindingFixed is the baseline status of a finding of the baseline no longer found
+	FindingFixed = "fixed"
+)
+
+// Baseline is the set of findings of a previous scan, identified by their fingerprints.
+// Comparing a report with a baseline marks its findings as new or known, and lists the
+// findings of the baseline that were fixed.
+type Baseline struct {
+	Target      string            `json:"target"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Findings    []BaselineFinding `json:"findings"`
+}
+
+// BaselineFinding is a finding recorded in a baseline
+type BaselineFinding struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Severity string `json:"severity"`
+	Method   string `json:"method,omitempty"`
+	URL      string `json:"url,omitempty"`
+	// FirstSeen is the time the finding was first recorded in the baseline
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// LoadBaseline loads a baseline file. A missing file is an empty baseline, so that the first
+// scan of a target creates it.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Baseline{Findings: []BaselineFinding{}}, nil
+	}
+	if err != nil {
+		return nil, api.NewAPIError("Failed to read baseline: "+err.Error(), 0)
+	}
+	baseline := &Baseline{}
+	if err := json.Unmarshal(data, baseline); err != nil {
+		return nil, api.NewAPIError("Failed to parse baseline "+path+": "+err.Error(), 0)
+	}
+	return baseline, nil
+}
+
+// Save writes the baseline to a file
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return api.NewAPIError("Failed to generate baseline: "+err.Error(), 0)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return api.NewAPIError("Failed to write baseline: "+err.Error(), 0)
+	}
+	return nil
+}
+
+// CompareBaseline sets the baseline status of the findings of the report, new or known, and
+// lists the findings of the baseline no longer found as fixed
+func (r *VulnerabilityReport) CompareBaseline(baseline *Baseline) {
+	found := make(map[string]bool, len(r.Findings))
+	known := make(map[string]bool, len(baseline.Findings))
+	for _, finding := range baseline.Findings {
+		known[finding.ID] = true
+	}
+	for _, finding := range r.Findings {
+		found[finding.ID] = true
+		finding.Status = FindingNew
+		if known[finding.ID] {
+			finding.Status = FindingKnown
+		}
+	}
+	r.Fixed = []BaselineFinding{}
+	for _, finding := range baseline.Findings {
+		if !found[finding.ID] {
+			r.Fixed = append(r.Fixed, finding)
+		}
+	}
+}
+
+// BaselineCounts returns the number of new, known and fixed findings once the report is
+// compared with a baseline
+func (r *VulnerabilityReport) BaselineCounts() map[string]int {
+	counts := map[string]int{FindingNew: 0, FindingKnown: 0, FindingFixed: len(r.Fixed)}
+	for _, finding := range r.Findings {
+		if finding.Status != "" {
+			counts[finding.Status]++
+		}
+	}
+	return counts
+}
+
+// NewBaseline returns the baseline of the findings of the report. The time findings of the
+// previous baseline, if any, were first seen is kept.
+func (r *VulnerabilityReport) NewBaseline(previous *Baseline) *Baseline {
+	firstSeen := make(map[string]time.Time)
+	if previous != nil {
+		for _, finding := range previous.Findings {
+			firstSeen[finding.ID] = finding.FirstSeen
+		}
+	}
+	baseline := &Baseline{Target: r.Target, GeneratedAt: r.GeneratedAt, Findings: []BaselineFinding{}}
+	for _, finding := range r.Findings {
+		seen, ok := firstSeen[finding.ID]
+		if !ok {
+			seen = r.GeneratedAt
+		}
+		baseline.Findings = append(baseline.Findings, BaselineFinding{
+			ID:        finding.ID,
+			Type:      finding.Type,
+			Name:      finding.Name,
+			Severity:  finding.Severity,
+			Method:    finding.Method,
+			URL:       finding.URL,
+			FirstSeen: seen,
+		})
+	}
+	return baseline
+}
@@ -0,0 +1,127 @@
+package reporting
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+)
+
+func TestVulnerabilityFingerprint(t *testing.T) {
+	vuln := newTestVulnerability("SQL Injection", "Critical", 9.8, "https://api.example.com/users/42?id=1")
+	other := newTestVulnerability("SQL Injection", "Critical", 9.8, "https://API.example.com/users/1337?id=%27+OR+1%3D1")
+	if vuln.Fingerprint() != other.Fingerprint() {
+		t.Errorf("Expected identifiers and parameter values to be ignored")
+	}
+
+	uuid := newTestVulnerability("SQL Injection", "Critical", 9.8, "https://api.example.com/users/3f2504e0-4f89-11d3-9a0c-0305e82c3301?id=1")
+	if vuln.Fingerprint() != uuid.Fingerprint() {
+		t.Errorf("Expected UUIDs to be ignored")
+	}
+
+	for name, changed := range map[string]security.VulnerabilityInfo{
+		"endpoint":  newTestVulnerability("SQL Injection", "Critical", 9.8, "https://api.example.com/orders/42?id=1"),
+		"name":      newTestVulnerability("Command Injection", "Critical", 9.8, "https://api.example.com/users/42?id=1"),
+		"parameter": newTestVulnerability("SQL Injection", "Critical", 9.8, "https://api.example.com/users/42?name=1"),
+	} {
+		if changed.Fingerprint() == vuln.Fingerprint() {
+			t.Errorf("Expected a different %s to change the fingerprint", name)
+		}
+	}
+
+	// A known parameter replaces the parameters of the request
+	vuln.Parameter, other.Parameter = "id", "id"
+	other.Request.URL.RawQuery = "id=1&page=2"
+	if vuln.Fingerprint() != other.Fingerprint() {
+		t.Errorf("Expected other parameters to be ignored once the vulnerable parameter is known")
+	}
+	other.PayloadClass = "Boolean"
+	if vuln.Fingerprint() == other.Fingerprint() {
+		t.Errorf("Expected the payload class to change the fingerprint")
+	}
+}
+
+func TestVulnerabilityReport_Baseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	baseline, err := LoadBaseline(path)
+	if err != nil || len(baseline.Findings) != 0 {
+		t.Fatalf("Expected a missing baseline to be empty, got %v, %v", baseline, err)
+	}
+
+	first := newTestReport()
+	first.CompareBaseline(baseline)
+	counts := first.BaselineCounts()
+	if counts[FindingNew] != 3 || counts[FindingKnown] != 0 || counts[FindingFixed] != 0 {
+		t.Errorf("Expected all findings to be new, got %v", counts)
+	}
+	if err := first.NewBaseline(baseline).Save(path); err != nil {
+		t.Fatalf("Save returned an error: %s", err)
+	}
+
+	// The second scan fixes the command injection and finds a new vulnerability
+	results := []*security.TestResult{{
+		TestName: "Injection",
+		Vulnerabilities: []security.VulnerabilityInfo{
+			newTestVulnerability("SQL Injection", "Critical", 9.8, "https://api.example.com/users?id=1"),
+			newTestVulnerability("Verbose Errors", "Low", 3.1, "https://api.example.com/users"),
+			newTestVulnerability("NoSQL Injection", "Critical", 9.0, "https://api.example.com/login"),
+		},
+	}}
+	second := NewVulnerabilityReport("https://api.example.com", results)
+	second.GeneratedAt = first.GeneratedAt.Add(time.Hour)
+	baseline, err = LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline returned an error: %s", err)
+	}
+	second.CompareBaseline(baseline)
+	counts = second.BaselineCounts()
+	if counts[FindingNew] != 1 || counts[FindingKnown] != 2 || counts[FindingFixed] != 1 {
+		t.Errorf("Expected 1 new, 2 known and 1 fixed findings, got %v", counts)
+	}
+	for _, finding := range second.Findings {
+		if (finding.Name == "NoSQL Injection") != (finding.Status == FindingNew) {
+			t.Errorf("Unexpected status %s of %s", finding.Status, finding.Name)
+		}
+	}
+	if second.Fixed[0].Name != "Command Injection" {
+		t.Errorf("Expected the command injection to be fixed, got %s", second.Fixed[0].Name)
+	}
+
+	updated := second.NewBaseline(baseline)
+	for _, finding := range updated.Findings {
+		expected := first.GeneratedAt
+		if finding.Name == "NoSQL Injection" {
+			expected = second.GeneratedAt
+		}
+		if !finding.FirstSeen.Equal(expected) {
+			t.Errorf("Unexpected first seen time %s of %s", finding.FirstSeen, finding.Name)
+		}
+	}
+
+	output, err := second.Generate(FormatMarkdown)
+	if err != nil {
+		t.Fatalf("Generate returned an error: %s", err)
+	}
+	for _, expected := range []string{"**Baseline**: 1 new, 2 known, 1 fixed", "- **Status**: new", "## Fixed Findings", "Command Injection"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected Markdown report to contain %q", expected)
+		}
+	}
+
+	output, err = second.Generate(FormatSARIF)
+	if err != nil {
+		t.Fatalf("Generate returned an error: %s", err)
+	}
+	var sarif sarifLog
+	if err := json.Unmarshal([]byte(output), &sarif); err != nil {
+		t.Fatalf("Invalid SARIF: %s", err)
+	}
+	for _, result := range sarif.Runs[0].Results {
+		if result.BaselineState != "new" && result.BaselineState != "unchanged" {
+			t.Errorf("Unexpected baseline state %q", result.BaselineState)
+		}
+	}
+}
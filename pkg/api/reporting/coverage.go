@@ -9,11 +9,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"net/url"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/executor"
 	"github.com/ffuf/ffuf/v2/pkg/api/parser"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
 )
@@ -58,6 +62,9 @@ type CoverageOptions struct {
 	Format CoverageFormat
 	// OutputFile specifies the file to write the report to (empty for stdout)
 	OutputFile string
+	// MermaidScript is the path of a local Mermaid script embedded in HTML reports to render
+	// their diagrams with Mermaid instead of the SVG images drawn by ffuf.
+	MermaidScript string
 }
 
 // DefaultCoverageOptions returns the default coverage options
@@ -91,6 +98,21 @@ type EndpointCoverage struct {
 	TestCount int `json:"test_count"`
 	// ErrorCount is the number of errors encountered during testing
 	ErrorCount int `json:"error_count"`
+	// FailedRequests is the number of requests to the endpoint that got no response, after
+	// their retries
+	FailedRequests int `json:"failed_requests,omitempty"`
+	// LastError is the error of the last request that got no response
+	LastError string `json:"last_error,omitempty"`
+	// PassCount is the number of executed test cases that passed
+	PassCount int `json:"pass_count"`
+	// FailCount is the number of executed test cases that failed or could not be executed
+	FailCount int `json:"fail_count"`
+	// LastRequest is the raw last request recorded for the endpoint
+	LastRequest string `json:"last_request,omitempty"`
+	// LastResponse is the raw response to the last request
+	LastResponse string `json:"last_response,omitempty"`
+	// Curl is a curl command replaying the last request
+	Curl string `json:"curl,omitempty"`
 }
 
 // ParameterCoverage represents coverage information for a single API parameter
@@ -119,8 +141,32 @@ type CoverageAnalyzer struct {
 	visualizer *parser.Visualizer
 	// startTime is the time when the analyzer was created
 	startTime time.Time
+	// matchers match executed requests to imported endpoints
+	matchers []*endpointMatcher
+	// unmatched is the number of executed requests not matching an imported endpoint
+	unmatched int
+	// history contains the snapshots of the runs shown as a trend
+	history []*CoverageSnapshot
+	// covered is called when a request first exercises an imported endpoint
+	covered CoveredHandler
+	mu      sync.Mutex
 }
 
+// CoveredHandler is called with an imported endpoint when a request first exercises it
+type CoveredHandler func(endpoint EndpointCoverage)
+
+// endpointMatcher matches the URLs of requests to an imported endpoint
+type endpointMatcher struct {
+	key     string
+	method  string
+	pattern *regexp.Regexp
+	params  []string
+	literal int
+}
+
+// placeholderPattern matches the {name} placeholders of endpoint paths
+var placeholderPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
 // NewCoverageAnalyzer creates a new coverage analyzer with the given options
 func NewCoverageAnalyzer(options *CoverageOptions) *CoverageAnalyzer {
 	if options == nil {
@@ -137,6 +183,8 @@ func NewCoverageAnalyzer(options *CoverageOptions) *CoverageAnalyzer {
 
 // ImportFromDiscovery imports endpoints from an API endpoint discovery instance
 func (c *CoverageAnalyzer) ImportFromDiscovery(discovery *parser.APIEndpointDiscovery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.discovery = discovery
 	
 	// Import all discovered endpoints
@@ -165,12 +213,170 @@ func (c *CoverageAnalyzer) ImportFromDiscovery(discovery *parser.APIEndpointDisc
 				TestCount:  0,
 				ErrorCount: 0,
 			}
+			c.matchers = append(c.matchers, newEndpointMatcher(key, endpoint))
+		}
+	}
+}
+
+// newEndpointMatcher creates a matcher for the path of an endpoint, below the path of its
+// URL if known
+func newEndpointMatcher(key string, endpoint *parser.DiscoveredEndpoint) *endpointMatcher {
+	path := endpoint.Path
+	prefix := ".*"
+	if endpoint.URL != "" {
+		if u, err := url.Parse(strings.NewReplacer("{", "%7B", "}", "%7D").Replace(endpoint.URL)); err == nil {
+			if full, err := url.PathUnescape(u.EscapedPath()); err == nil && strings.HasSuffix(full, path) {
+				path = full
+				prefix = ""
+			}
 		}
 	}
+
+	matcher := &endpointMatcher{key: key, method: strings.ToUpper(endpoint.Method), params: make([]string, 0)}
+	expr := ""
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(path, -1) {
+		expr += regexp.QuoteMeta(path[last:loc[0]]) + "[^/]+"
+		matcher.literal += loc[0] - last
+		matcher.params = append(matcher.params, path[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	expr += regexp.QuoteMeta(path[last:])
+	matcher.literal += len(path) - last
+	matcher.pattern = regexp.MustCompile("^" + prefix + strings.TrimSuffix(expr, "/") + "/?$")
+	return matcher
+}
+
+// RecordRequest records a request executed during fuzzing against the imported endpoint
+// matching its method and URL, and returns false if no imported endpoint matches it. The path
+// parameters of the endpoint and the query, body, header and cookie parameters sent are
+// recorded as tested.
+func (c *CoverageAnalyzer) RecordRequest(req *ffuf.Request, resp *ffuf.Response) bool {
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return false
+	}
+
+	// The covered handler is called once the analyzer is unlocked
+	var notify func()
+	defer func() {
+		if notify != nil {
+			notify()
+		}
+	}()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	best := c.match(req.Method, u)
+	if best == nil {
+		c.unmatched++
+		return false
+	}
+
+	params := append([]string{}, best.params...)
+	for name := range u.Query() {
+		params = append(params, name)
+	}
+	for name, value := range req.Headers {
+		if strings.EqualFold(name, "Cookie") {
+			for _, cookie := range strings.Split(value, ";") {
+				params = append(params, strings.TrimSpace(strings.SplitN(cookie, "=", 2)[0]))
+			}
+			continue
+		}
+		params = append(params, name)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(req.Data, &body); err == nil {
+		for name := range body {
+			params = append(params, name)
+		}
+	} else if form, err := url.ParseQuery(string(req.Data)); err == nil {
+		for name := range form {
+			params = append(params, name)
+		}
+	}
+
+	endpoint := c.endpoints[best.key]
+	first := endpoint.TestCount == 0
+	c.recordTest(endpoint.Method, endpoint.Path, resp, params)
+	endpoint.LastRequest, endpoint.Curl = replayFFUFRequest(req)
+	if resp != nil {
+		endpoint.LastResponse = replayFFUFResponse(resp)
+	}
+	if handler := c.covered; first && handler != nil {
+		copied := *endpoint
+		notify = func() { handler(copied) }
+	}
+	return true
+}
+
+// RecordFailure records a request that got no response, such as a request still timing out
+// after its retries, against the imported endpoint matching its method and URL. The endpoint
+// is marked as StatusError, and is not covered by the request. It returns false if no
+// imported endpoint matches the request.
+func (c *CoverageAnalyzer) RecordFailure(req *ffuf.Request, failure error) bool {
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	best := c.match(req.Method, u)
+	if best == nil {
+		c.unmatched++
+		return false
+	}
+	endpoint := c.endpoints[best.key]
+	endpoint.ErrorCount++
+	endpoint.FailedRequests++
+	endpoint.LastError = failure.Error()
+	endpoint.Status = StatusError
+	endpoint.LastRequest, endpoint.Curl = replayFFUFRequest(req)
+	return true
+}
+
+// match returns the imported endpoint matching the method and the URL of a request, or nil.
+// The endpoint with the most literal characters wins, /users/me over /users/{id}. The
+// analyzer must be locked.
+func (c *CoverageAnalyzer) match(method string, u *url.URL) *endpointMatcher {
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = "GET"
+	}
+	var best *endpointMatcher
+	for _, matcher := range c.matchers {
+		if matcher.method == method && matcher.pattern.MatchString(u.Path) && (best == nil || matcher.literal > best.literal) {
+			best = matcher
+		}
+	}
+	return best
+}
+
+// SetCoveredHandler sets the handler called when a request first exercises an imported
+// endpoint
+func (c *CoverageAnalyzer) SetCoveredHandler(handler CoveredHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.covered = handler
+}
+
+// UnmatchedRequests returns the number of recorded requests not matching an imported endpoint
+func (c *CoverageAnalyzer) UnmatchedRequests() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.unmatched
 }
 
 // RecordTest records a test of an API endpoint
 func (c *CoverageAnalyzer) RecordTest(method, path string, resp *ffuf.Response, testedParams []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordTest(method, path, resp, testedParams)
+}
+
+// recordTest records a test of an API endpoint, the analyzer being locked
+func (c *CoverageAnalyzer) recordTest(method, path string, resp *ffuf.Response, testedParams []string) {
 	key := fmt.Sprintf("%s %s", method, path)
 	
 	// Create the endpoint if it doesn't exist
@@ -203,7 +409,7 @@ func (c *CoverageAnalyzer) RecordTest(method, path string, resp *ffuf.Response,
 	paramsTested := 0
 	for i, param := range endpoint.Parameters {
 		for _, testedParam := range testedParams {
-			if param.Name == testedParam {
+			if strings.EqualFold(param.Name, testedParam) {
 				endpoint.Parameters[i].Tested = true
 				endpoint.Parameters[i].TestCount++
 				paramsTested++
@@ -227,14 +433,40 @@ func (c *CoverageAnalyzer) RecordTest(method, path string, resp *ffuf.Response,
 	}
 }
 
+// RecordExecution records the results of executed test cases
+func (c *CoverageAnalyzer) RecordExecution(result *executor.ExecutionResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, testResult := range result.Results {
+		if testResult.Status == executor.StatusSkipped {
+			continue
+		}
+
+		testCase := testResult.TestCase
+		c.recordTest(testCase.Method, testCase.Path, testResult.Response, executor.TestedParameters(testCase))
+
+		endpoint := c.endpoints[fmt.Sprintf("%s %s", testCase.Method, testCase.Path)]
+		if testResult.Passed() {
+			endpoint.PassCount++
+		} else {
+			endpoint.FailCount++
+		}
+	}
+}
+
 // GetCoverageStats returns overall coverage statistics
 func (c *CoverageAnalyzer) GetCoverageStats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	totalEndpoints := len(c.endpoints)
 	testedEndpoints := 0
 	partialEndpoints := 0
 	errorEndpoints := 0
+	answeredErrorEndpoints := 0
 	totalParams := 0
 	testedParams := 0
+	passedTests := 0
+	failedTests := 0
 	
 	for _, endpoint := range c.endpoints {
 		if endpoint.Status == StatusTested {
@@ -243,8 +475,14 @@ func (c *CoverageAnalyzer) GetCoverageStats() map[string]interface{} {
 			partialEndpoints++
 		} else if endpoint.Status == StatusError {
 			errorEndpoints++
+			if endpoint.TestCount > 0 {
+				answeredErrorEndpoints++
+			}
 		}
 		
+		passedTests += endpoint.PassCount
+		failedTests += endpoint.FailCount
+		
 		for _, param := range endpoint.Parameters {
 			totalParams++
 			if param.Tested {
@@ -259,6 +497,13 @@ func (c *CoverageAnalyzer) GetCoverageStats() map[string]interface{} {
 		endpointCoverage = float64(testedEndpoints+partialEndpoints) / float64(totalEndpoints) * 100
 	}
 	
+	// Endpoints which answered requests, including those answering with errors. Endpoints
+	// whose requests all failed are not exercised.
+	exercisedCoverage := 0.0
+	if totalEndpoints > 0 {
+		exercisedCoverage = float64(testedEndpoints+partialEndpoints+answeredErrorEndpoints) / float64(totalEndpoints) * 100
+	}
+
 	paramCoverage := 0.0
 	if totalParams > 0 {
 		paramCoverage = float64(testedParams) / float64(totalParams) * 100
@@ -271,15 +516,19 @@ func (c *CoverageAnalyzer) GetCoverageStats() map[string]interface{} {
 		"error_endpoints":     errorEndpoints,
 		"untested_endpoints":  totalEndpoints - testedEndpoints - partialEndpoints - errorEndpoints,
 		"endpoint_coverage":   endpointCoverage,
+		"exercised_endpoints": testedEndpoints + partialEndpoints + answeredErrorEndpoints,
+		"exercised_coverage":  exercisedCoverage,
 		"total_parameters":    totalParams,
 		"tested_parameters":   testedParams,
 		"parameter_coverage":  paramCoverage,
+		"passed_tests":        passedTests,
+		"failed_tests":        failedTests,
 		"duration":            time.Since(c.startTime).String(),
 		"timestamp":           time.Now().Format(time.RFC3339),
 	}
 }
 
-// GenerateReport generates a coverage report in the specified format
+// GenerateReport generates a coverage report in the specified format. XLSX reports are binary.
 func (c *CoverageAnalyzer) GenerateReport() (string, error) {
 	switch c.options.Format {
 	case FormatJSON:
@@ -290,6 +539,10 @@ func (c *CoverageAnalyzer) GenerateReport() (string, error) {
 		return c.generateMarkdownReport()
 	case FormatText:
 		return c.generateTextReport()
+	case FormatCSV:
+		return c.generateCSVReport()
+	case FormatXLSX:
+		return c.generateXLSXReport()
 	default:
 		return "", api.NewAPIError("Unsupported report format", 0)
 	}
@@ -302,6 +555,9 @@ func (c *CoverageAnalyzer) generateJSONReport() (string, error) {
 		"stats":     c.GetCoverageStats(),
 		"endpoints": c.getEndpointsForReport(),
 	}
+	if history := c.getHistory(); len(history) > 0 {
+		report["history"] = history
+	}
 	
 	// Convert to JSON
 	jsonData, err := json.MarshalIndent(report, "", "  ")
@@ -373,6 +629,8 @@ func (c *CoverageAnalyzer) generateHTMLReport() (string, error) {
         .parameter-untested {
             color: gray;
         }
+        .legend span { margin-right: 15px; font-size: 14px; }
+        {{.assets.Style}}
     </style>
 </head>
 <body>
@@ -412,8 +670,41 @@ func (c *CoverageAnalyzer) generateHTMLReport() (string, error) {
         </div>
     </div>
     
+    {{if .history}}
+    <h2>Coverage Trend</h2>
+    {{trendChart .history}}
+    <div class="legend">
+        <span style="color: #4CAF50;">&#9632; Endpoints requested</span>
+        <span style="color: #2196F3;">&#9632; Parameters tested</span>
+        <span style="color: #c0392b;">&#9632; Vulnerabilities</span>
+    </div>
+    {{end}}
+    
+    {{if .diagram}}
+    <h2>API Map</h2>
+    <div class="diagram">
+        <div class="diagram-image">{{.diagramImage}}</div>
+        <details>
+            <summary>Mermaid source</summary>
+            <pre class="mermaid-source">{{.diagram}}</pre>
+        </details>
+    </div>
+    {{end}}
+    
     <h2>Endpoint Details</h2>
-    <table>
+    <div class="filters">
+        <input type="search" class="filter" data-table="endpoints" placeholder="Filter by method, path, tag or parameter">
+        <select class="filter" data-table="endpoints" data-field="status">
+            <option value="">All statuses</option>
+            <option value="tested">Tested</option>
+            <option value="partial">Partial</option>
+            <option value="error">Error</option>
+            <option value="untested">Untested</option>
+        </select>
+        <span class="filter-count" data-table="endpoints"></span>
+    </div>
+    <table id="endpoints">
+        <thead>
         <tr>
             <th>Method</th>
             <th>Path</th>
@@ -422,37 +713,61 @@ func (c *CoverageAnalyzer) generateHTMLReport() (string, error) {
             <th>Tests</th>
             <th>Last Tested</th>
         </tr>
-        {{range .endpoints}}
+        </thead>
+        {{range $i, $e := .endpoints}}
+        <tbody class="item" data-status="{{$e.Status}}" data-search="{{searchText $e}}">
         <tr>
-            <td>{{.Method}}</td>
-            <td>{{.Path}}</td>
-            <td class="status-{{.Status}}">{{.Status}}</td>
+            <td>{{$e.Method}}</td>
+            <td>{{$e.Path}}</td>
+            <td class="status-{{$e.Status}}">{{$e.Status}}</td>
             <td>
-                {{range .Tags}}
+                {{range $e.Tags}}
                 <span class="tag">{{.}}</span>
                 {{end}}
             </td>
-            <td>{{.TestCount}}</td>
-            <td>{{if .LastTested}}{{.LastTested | formatTime}}{{else}}Never{{end}}</td>
+            <td>{{$e.TestCount}}</td>
+            <td>{{if $e.LastTested}}{{$e.LastTested | formatTime}}{{else}}Never{{end}}</td>
         </tr>
-        {{if gt (len .Parameters) 0}}
-        <tr>
+        {{if or (gt (len $e.Parameters) 0) $e.LastRequest}}
+        <tr class="detail">
             <td colspan="6" class="parameters">
+                {{if $e.FailedRequests}}
+                <div class="status-error">{{$e.FailedRequests}} requests failed, last error: {{$e.LastError}}</div>
+                {{end}}
+                {{if gt (len $e.Parameters) 0}}
                 <strong>Parameters:</strong>
-                {{range .Parameters}}
+                {{range $e.Parameters}}
                 <div class="{{if .Tested}}parameter-tested{{else}}parameter-untested{{end}}">
                     <span class="parameter-name">{{.Name}}</span> 
                     ({{.Type}}{{if .Required}}, required{{end}}) - 
                     {{if .Tested}}Tested {{.TestCount}} times{{else}}Not tested{{end}}
                 </div>
                 {{end}}
+                {{end}}
+                {{if $e.LastRequest}}
+                <details>
+                    <summary>Last request</summary>
+                    <pre class="raw">{{$e.LastRequest}}</pre>
+                    <button class="copy" data-copy="endpoint-{{$i}}-curl">Copy as curl</button>
+                    <pre class="raw" id="endpoint-{{$i}}-curl">{{$e.Curl}}</pre>
+                </details>
+                {{end}}
+                {{if $e.LastResponse}}
+                <details>
+                    <summary>Last response</summary>
+                    <pre class="raw">{{$e.LastResponse}}</pre>
+                </details>
+                {{end}}
             </td>
         </tr>
         {{end}}
+        </tbody>
         {{end}}
     </table>
     
     <p><small>Report generated by ffuf API Coverage Analyzer. Duration: {{.stats.duration}}</small></p>
+    {{if .assets.Mermaid}}<script>{{.assets.Mermaid}}</script>{{end}}
+    <script>{{.assets.Script}}</script>
 </body>
 </html>`
 
@@ -461,6 +776,14 @@ func (c *CoverageAnalyzer) generateHTMLReport() (string, error) {
 		"formatTime": func(t time.Time) string {
 			return t.Format("2006-01-02 15:04:05")
 		},
+		"trendChart": trendChart,
+		"searchText": func(e *EndpointCoverage) string {
+			words := append([]string{e.Method, e.Path}, e.Tags...)
+			for _, param := range e.Parameters {
+				words = append(words, param.Name)
+			}
+			return strings.ToLower(strings.Join(words, " "))
+		},
 	}
 	
 	t, err := template.New("report").Funcs(funcMap).Parse(tmpl)
@@ -468,10 +791,21 @@ func (c *CoverageAnalyzer) generateHTMLReport() (string, error) {
 		return "", api.NewAPIError("Failed to parse HTML template: "+err.Error(), 0)
 	}
 	
+	assets, err := reportAssets(c.options.MermaidScript)
+	if err != nil {
+		return "", err
+	}
+
 	// Prepare the report data
+	endpoints := c.getEndpointsForReport()
+	image, diagram := coverageDiagram(endpoints)
 	report := map[string]interface{}{
-		"stats":     c.GetCoverageStats(),
-		"endpoints": c.getEndpointsForReport(),
+		"stats":        c.GetCoverageStats(),
+		"endpoints":    endpoints,
+		"history":      c.getHistory(),
+		"diagram":      diagram,
+		"diagramImage": image,
+		"assets":       assets,
 	}
 	
 	// Execute the template
@@ -527,6 +861,9 @@ func (c *CoverageAnalyzer) generateMarkdownReport() (string, error) {
 			tags, 
 			endpoint.TestCount, 
 			lastTested))
+		if endpoint.FailedRequests > 0 {
+			buf.WriteString(fmt.Sprintf("\n**Failed requests:** %d, last error: `%s`\n\n", endpoint.FailedRequests, endpoint.LastError))
+		}
 		
 		// Write parameter details if detail level > 1
 		if c.options.DetailLevel > 1 && len(endpoint.Parameters) > 0 {
@@ -597,6 +934,9 @@ func (c *CoverageAnalyzer) generateTextReport() (string, error) {
 			buf.WriteString(fmt.Sprintf("  Tags:       %s\n", strings.Join(endpoint.Tags, ", ")))
 		}
 		buf.WriteString(fmt.Sprintf("  Tests:      %d\n", endpoint.TestCount))
+		if endpoint.FailedRequests > 0 {
+			buf.WriteString(fmt.Sprintf("  Failed:     %d requests, last error: %s\n", endpoint.FailedRequests, endpoint.LastError))
+		}
 		buf.WriteString(fmt.Sprintf("  Last Tested: %s\n", lastTested))
 		
 		// Write parameter details if detail level > 1
@@ -632,6 +972,8 @@ func (c *CoverageAnalyzer) generateTextReport() (string, error) {
 
 // getEndpointsForReport returns a slice of endpoints for the report
 func (c *CoverageAnalyzer) getEndpointsForReport() []*EndpointCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	endpoints := make([]*EndpointCoverage, 0, len(c.endpoints))
 	
 	for _, endpoint := range c.endpoints {
@@ -654,4 +996,27 @@ func (c *CoverageAnalyzer) getEndpointsForReport() []*EndpointCoverage {
 	})
 	
 	return endpoints
-}
\ No newline at end of file
+}
+
+// getHistory returns the snapshots of the runs shown as a trend
+func (c *CoverageAnalyzer) getHistory() []*CoverageSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.history
+}
+
+// coverageDiagram returns the map of endpoints, colored by their status
+func coverageDiagram(endpoints []*EndpointCoverage) (template.HTML, string) {
+	discovered := make([]*parser.DiscoveredEndpoint, 0, len(endpoints))
+	classes := make(map[string]string)
+	for _, endpoint := range endpoints {
+		discovered = append(discovered, &parser.DiscoveredEndpoint{Method: endpoint.Method, Path: endpoint.Path})
+		classes[endpoint.Method+" "+endpoint.Path] = string(endpoint.Status)
+	}
+	return endpointDiagram(discovered, classes, map[string]string{
+		string(StatusTested):   "fill:#c8e6c9,stroke:#2e7d32",
+		string(StatusPartial):  "fill:#ffe0b2,stroke:#ef6c00",
+		string(StatusError):    "fill:#ffcdd2,stroke:#c62828",
+		string(StatusUntested): "fill:#eeeeee,stroke:#9e9e9e",
+	})
+}
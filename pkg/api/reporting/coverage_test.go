@@ -2,10 +2,12 @@ package reporting
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ffuf/ffuf/v2/pkg/api/executor"
 	"github.com/ffuf/ffuf/v2/pkg/api/parser"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
 )
@@ -90,6 +92,38 @@ func TestRecordTest(t *testing.T) {
 	}
 }
 
+func TestRecordExecution(t *testing.T) {
+	analyzer := NewCoverageAnalyzer(nil)
+
+	testCase := &parser.APITestCase{
+		Method:      "GET",
+		Path:        "/users/{id}",
+		PathParams:  map[string]string{"id": "1"},
+		QueryParams: map[string]string{"fields": "name"},
+	}
+	result := &executor.ExecutionResult{
+		Results: []*executor.TestResult{
+			{TestCase: testCase, Status: executor.StatusPassed, Response: &ffuf.Response{StatusCode: 200}},
+			{TestCase: testCase, Status: executor.StatusFailed, Response: &ffuf.Response{StatusCode: 200}},
+			{TestCase: testCase, Status: executor.StatusSkipped},
+		},
+	}
+	analyzer.RecordExecution(result)
+
+	endpoint := analyzer.endpoints["GET /users/{id}"]
+	if endpoint == nil {
+		t.Fatal("Expected endpoint to be recorded")
+	}
+	if endpoint.TestCount != 2 || endpoint.PassCount != 1 || endpoint.FailCount != 1 {
+		t.Errorf("Expected 2 tests with 1 pass and 1 failure, got %d/%d/%d", endpoint.TestCount, endpoint.PassCount, endpoint.FailCount)
+	}
+
+	stats := analyzer.GetCoverageStats()
+	if stats["passed_tests"] != 1 || stats["failed_tests"] != 1 {
+		t.Errorf("Unexpected test stats %v/%v", stats["passed_tests"], stats["failed_tests"])
+	}
+}
+
 func TestImportFromDiscovery(t *testing.T) {
 	// Create a mock discovery with some endpoints
 	discovery := &parser.APIEndpointDiscovery{}
@@ -296,4 +330,82 @@ func TestGetEndpointsForReport(t *testing.T) {
 	if endpoints[0].Path != "/api/test1" {
 		t.Errorf("Expected path /api/test1, got %s", endpoints[0].Path)
 	}
-}
\ No newline at end of file
+}
+func TestRecordRequest(t *testing.T) {
+	discovery := parser.NewAPIEndpointDiscovery("https://api.example.com/v1")
+	discovery.Endpoints = []*parser.DiscoveredEndpoint{
+		{Method: "GET", Path: "/users/{id}", URL: "https://api.example.com/v1/users/{id}", Parameters: []*parser.DiscoveredParameter{
+			{Name: "id", In: "path", Required: true}, {Name: "fields", In: "query"},
+		}},
+		{Method: "GET", Path: "/users/me", URL: "https://api.example.com/v1/users/me"},
+		{Method: "POST", Path: "/users", URL: "https://api.example.com/v1/users", Parameters: []*parser.DiscoveredParameter{
+			{Name: "name", In: "body"}, {Name: "X-Tenant", In: "header"},
+		}},
+		{Method: "DELETE", Path: "/users/{id}"},
+	}
+	analyzer := NewCoverageAnalyzer(nil)
+	analyzer.ImportFromDiscovery(discovery)
+
+	requests := []struct {
+		method  string
+		url     string
+		data    string
+		headers map[string]string
+		matched bool
+	}{
+		{"GET", "https://staging.example.com/v1/users/42?fields=name", "", nil, true},
+		{"GET", "https://api.example.com/v1/users/me", "", nil, true},
+		{"POST", "https://api.example.com/v1/users", `{"name": "alice"}`, map[string]string{"x-tenant": "1"}, true},
+		{"DELETE", "https://api.example.com/internal/users/7/", "", nil, true},
+		{"GET", "https://api.example.com/v1/users/42/orders", "", nil, false},
+		{"GET", "https://api.example.com/users/42", "", nil, false},
+		{"PUT", "https://api.example.com/v1/users/42", "", nil, false},
+	}
+	for _, r := range requests {
+		req := &ffuf.Request{Method: r.method, Url: r.url, Data: []byte(r.data), Headers: r.headers}
+		if matched := analyzer.RecordRequest(req, &ffuf.Response{StatusCode: 200}); matched != r.matched {
+			t.Errorf("Expected %s %s matched to be %t", r.method, r.url, r.matched)
+		}
+	}
+
+	expected := map[string]EndpointStatus{
+		"GET /users/{id}":    StatusTested,
+		"GET /users/me":      StatusTested,
+		"POST /users":        StatusTested,
+		"DELETE /users/{id}": StatusTested,
+	}
+	for key, status := range expected {
+		if endpoint := analyzer.endpoints[key]; endpoint.Status != status || endpoint.TestCount != 1 {
+			t.Errorf("Expected %s to be %s once, got %s %d times", key, status, endpoint.Status, endpoint.TestCount)
+		}
+	}
+	if analyzer.UnmatchedRequests() != 3 {
+		t.Errorf("Expected 3 unmatched requests, got %d", analyzer.UnmatchedRequests())
+	}
+
+	stats := analyzer.GetCoverageStats()
+	if stats["total_endpoints"] != 4 || stats["exercised_coverage"] != 100.0 {
+		t.Errorf("Expected the unmatched requests not to be added as endpoints, got %v", stats)
+	}
+}
+
+func TestSetCoveredHandler(t *testing.T) {
+	discovery := parser.NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.Endpoints = []*parser.DiscoveredEndpoint{
+		{Method: "GET", Path: "/users/{id}"},
+		{Method: "GET", Path: "/orders"},
+	}
+	analyzer := NewCoverageAnalyzer(nil)
+	analyzer.ImportFromDiscovery(discovery)
+	covered := make([]string, 0)
+	analyzer.SetCoveredHandler(func(endpoint EndpointCoverage) {
+		covered = append(covered, fmt.Sprintf("%s %s %d", endpoint.Method, endpoint.Path, endpoint.ResponseStatus))
+	})
+
+	for _, u := range []string{"/users/1", "/users/2", "/unknown", "/orders"} {
+		analyzer.RecordRequest(&ffuf.Request{Method: "GET", Url: "https://api.example.com" + u}, &ffuf.Response{StatusCode: 200})
+	}
+	if strings.Join(covered, ", ") != "GET /users/{id} 200, GET /orders 200" {
+		t.Errorf("Expected each endpoint to be reported once when first covered, got %v", covered)
+	}
+}
@@ -0,0 +1,270 @@
+package reporting
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// EventLogVersion is the version of the schema of event log lines, incremented on
+// incompatible changes
+const EventLogVersion = 1
+
+const (
+	// EventRunStarted is the first event of a run
+	EventRunStarted = "run_started"
+	// EventRequest is a request sent, with its response
+	EventRequest = "request"
+	// EventResult is a response matched by the matchers and filters of a fuzzing run
+	EventResult = "result"
+	// EventEndpointCovered is an endpoint of the coverage specification requested for the
+	// first time
+	EventEndpointCovered = "endpoint_covered"
+	// EventFinding is a vulnerability found by a security tester
+	EventFinding = "finding"
+	// EventTesterFinished is a security tester completing its tests of a target
+	EventTesterFinished = "tester_finished"
+	// EventRunFinished is the last event of a run
+	EventRunFinished = "run_finished"
+)
+
+// Event is a line of an event log
+type Event struct {
+	// Version is EventLogVersion
+	Version int `json:"version"`
+	// Seq is the position of the event in the log, starting at 1
+	Seq int64 `json:"seq"`
+	// Time is the time the event happened
+	Time time.Time `json:"time"`
+	// Event is the kind of the event, e.g. EventRequest
+	Event string `json:"event"`
+	// Data is the data of the event, depending on its kind
+	Data interface{} `json:"data"`
+}
+
+// RunEvent is the data of run_started and run_finished events
+type RunEvent struct {
+	// Command is the kind of run: fuzz for fuzzing runs, capture for the scans of ffuf
+	// capture, and scan for the scan jobs of ffuf api scan and ffuf api run
+	Command string `json:"command"`
+	// Targets are the URLs or base URLs of the run
+	Targets []string `json:"targets,omitempty"`
+	// DurationMs is the duration of a finished run in milliseconds
+	DurationMs int64 `json:"duration_ms,omitempty"`
+	// Events is the number of events of a finished run per kind
+	Events map[string]int `json:"events,omitempty"`
+}
+
+// RequestEvent is the data of request and result events
+type RequestEvent struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Input are the values of the keywords of a fuzzing request
+	Input map[string]string `json:"input,omitempty"`
+	// Position is the position of a fuzzing request in the wordlists
+	Position         int     `json:"position,omitempty"`
+	Status           int64   `json:"status"`
+	Length           int64   `json:"length"`
+	Words            int64   `json:"words"`
+	Lines            int64   `json:"lines"`
+	ContentType      string  `json:"content_type,omitempty"`
+	RedirectLocation string  `json:"redirect_location,omitempty"`
+	DurationMs       float64 `json:"duration_ms"`
+	// Error is the error of a request without a response
+	Error string `json:"error,omitempty"`
+}
+
+// CoverageEvent is the data of endpoint_covered events
+type CoverageEvent struct {
+	Method string `json:"method"`
+	// Path is the path of the endpoint in the specification, e.g. /users/{id}
+	Path string `json:"path"`
+	// Status is the status code of the response to the request covering the endpoint
+	Status int `json:"status,omitempty"`
+}
+
+// FindingEvent is the data of finding events: the target of the tester, and the finding as
+// in JSON vulnerability reports
+type FindingEvent struct {
+	Target string `json:"target"`
+	*Finding
+}
+
+// TesterEvent is the data of tester_finished events
+type TesterEvent struct {
+	Target     string `json:"target"`
+	Tester     string `json:"tester"`
+	Findings   int    `json:"findings"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+	// Incomplete is true if the tester was stopped by its time budget or the maximum
+	// duration of the scan
+	Incomplete      bool `json:"incomplete,omitempty"`
+	SkippedRequests int  `json:"skipped_requests,omitempty"`
+	// FailedRequests is the number of requests that got no response after their retries
+	FailedRequests int `json:"failed_requests,omitempty"`
+}
+
+// EventLog writes the significant events of a run as NDJSON, a JSON Event per line, as they
+// happen, so that external tools can follow a run without waiting for its reports. It
+// implements ffuf.EventLogger and is safe for concurrent use.
+type EventLog struct {
+	// OnError is called when an event cannot be written
+	OnError func(err error)
+	// Redactor masks the tokens and personal data of the URLs, inputs and findings of the
+	// events, nil to write them as is
+	Redactor *secrets.Redactor
+	w        io.Writer
+	closer   io.Closer
+	seq      int64
+	counts   map[string]int
+	started  time.Time
+	mu       sync.Mutex
+}
+
+// NewEventLog creates an event log writing to w
+func NewEventLog(w io.Writer) *EventLog {
+	return &EventLog{
+		w:       w,
+		counts:  make(map[string]int),
+		started: time.Now(),
+	}
+}
+
+// OpenEventLog creates an event log writing to a file, or to stdout if the path is -
+func OpenEventLog(path string) (*EventLog, error) {
+	if path == "-" {
+		return NewEventLog(os.Stdout), nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to create event log: "+err.Error(), 0)
+	}
+	log := NewEventLog(f)
+	log.closer = f
+	return log, nil
+}
+
+// Emit writes an event
+func (l *EventLog) Emit(event string, data interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq++
+	l.counts[event]++
+	line, err := json.Marshal(&Event{Version: EventLogVersion, Seq: l.seq, Time: time.Now(), Event: event, Data: data})
+	if err == nil {
+		_, err = l.w.Write(append(line, '\n'))
+	}
+	if err != nil && l.OnError != nil {
+		l.OnError(err)
+	}
+}
+
+// RunStarted writes the run_started event of a run
+func (l *EventLog) RunStarted(command string, targets []string) {
+	l.Emit(EventRunStarted, &RunEvent{Command: command, Targets: targets})
+}
+
+// RunFinished writes the run_finished event of a run, with the number of events written
+func (l *EventLog) RunFinished(command string) {
+	l.mu.Lock()
+	counts := make(map[string]int, len(l.counts))
+	for event, count := range l.counts {
+		counts[event] = count
+	}
+	duration := time.Since(l.started)
+	l.mu.Unlock()
+	l.Emit(EventRunFinished, &RunEvent{Command: command, DurationMs: duration.Milliseconds(), Events: counts})
+}
+
+// Request writes the request event of a request sent and its response
+func (l *EventLog) Request(req *ffuf.Request, resp *ffuf.Response, err error) {
+	event := l.newRequestEvent(req, resp)
+	if err != nil {
+		event.Error = err.Error()
+	}
+	l.Emit(EventRequest, event)
+}
+
+// Result writes the result event of a matched response
+func (l *EventLog) Result(resp *ffuf.Response) {
+	req := resp.Request
+	if req == nil {
+		req = &ffuf.Request{}
+	}
+	event := l.newRequestEvent(req, resp)
+	event.RedirectLocation = l.Redactor.String(resp.GetRedirectLocation(false))
+	l.Emit(EventResult, event)
+}
+
+// newRequestEvent creates the data of a request or result event
+func (l *EventLog) newRequestEvent(req *ffuf.Request, resp *ffuf.Response) *RequestEvent {
+	event := &RequestEvent{
+		Method:   req.Method,
+		URL:      l.Redactor.String(req.Url),
+		Position: req.Position,
+	}
+	for keyword, value := range req.Input {
+		if keyword == "FFUFHASH" {
+			continue
+		}
+		if event.Input == nil {
+			event.Input = make(map[string]string, len(req.Input))
+		}
+		event.Input[keyword] = l.Redactor.String(string(value))
+	}
+	if resp != nil {
+		event.Status = resp.StatusCode
+		event.Length = resp.ContentLength
+		event.Words = resp.ContentWords
+		event.Lines = resp.ContentLines
+		event.ContentType = resp.ContentType
+		event.DurationMs = float64(resp.Duration) / float64(time.Millisecond)
+	}
+	return event
+}
+
+// EndpointCovered writes the endpoint_covered event of an endpoint. It is a CoveredHandler.
+func (l *EventLog) EndpointCovered(endpoint EndpointCoverage) {
+	l.Emit(EventEndpointCovered, &CoverageEvent{Method: endpoint.Method, Path: endpoint.Path, Status: endpoint.ResponseStatus})
+}
+
+// ResultHandler returns a security.ResultHandler writing the finding events of the results
+// of the testers of a target, then their tester_finished event
+func (l *EventLog) ResultHandler(target string) security.ResultHandler {
+	return func(result *security.TestResult) {
+		for _, vuln := range result.Vulnerabilities {
+			finding := newFinding(result.TestName, vuln)
+			finding.redact(l.Redactor)
+			l.Emit(EventFinding, &FindingEvent{Target: l.Redactor.String(target), Finding: finding})
+		}
+		event := &TesterEvent{
+			Target:          l.Redactor.String(target),
+			Tester:          result.TestName,
+			Findings:        len(result.Vulnerabilities),
+			DurationMs:      result.Duration.Milliseconds(),
+			Incomplete:      result.Incomplete,
+			SkippedRequests: result.SkippedRequests,
+			FailedRequests:  result.FailedRequests,
+		}
+		if result.Error != nil {
+			event.Error = result.Error.Error()
+		}
+		l.Emit(EventTesterFinished, event)
+	}
+}
+
+// Close closes the file of the event log
+func (l *EventLog) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
@@ -0,0 +1,131 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// decodeEvents decodes the lines of an event log
+func decodeEvents(t *testing.T, data []byte) []map[string]interface{} {
+	events := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Invalid event log line %q: %s", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestEventLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewEventLog(&buf)
+	log.RunStarted("fuzz", []string{"https://api.example.com"})
+	req := &ffuf.Request{Method: "GET", Url: "https://api.example.com/admin", Position: 3,
+		Input: map[string][]byte{"FUZZ": []byte("admin"), "FFUFHASH": []byte("1234")}}
+	resp := &ffuf.Response{StatusCode: 200, ContentLength: 42, ContentType: "application/json", Duration: 1500 * time.Microsecond, Request: req}
+	log.Request(req, resp, nil)
+	log.Request(&ffuf.Request{Method: "GET", Url: "https://api.example.com/slow"}, &ffuf.Response{}, fmt.Errorf("timeout"))
+	log.Result(resp)
+	log.EndpointCovered(EndpointCoverage{Method: "GET", Path: "/admin", ResponseStatus: 200})
+	log.ResultHandler("https://api.example.com/admin")(&security.TestResult{
+		TestName:        "Injection",
+		Duration:        2 * time.Second,
+		Vulnerabilities: []security.VulnerabilityInfo{newTestVulnerability("SQL injection", "High", 8.0, "https://api.example.com/admin?id=1")},
+	})
+	log.RunFinished("fuzz")
+
+	events := decodeEvents(t, buf.Bytes())
+	kinds := make([]string, 0, len(events))
+	for i, event := range events {
+		kinds = append(kinds, event["event"].(string))
+		if event["version"] != float64(EventLogVersion) || event["seq"] != float64(i+1) {
+			t.Errorf("Unexpected version or sequence number of event %d: %v", i, event)
+		}
+	}
+	if strings.Join(kinds, ",") != "run_started,request,request,result,endpoint_covered,finding,tester_finished,run_finished" {
+		t.Fatalf("Unexpected events %v", kinds)
+	}
+
+	request := events[1]["data"].(map[string]interface{})
+	if request["url"] != "https://api.example.com/admin" || request["status"] != float64(200) || request["duration_ms"] != 1.5 {
+		t.Errorf("Unexpected request event %v", request)
+	}
+	if input := request["input"].(map[string]interface{}); len(input) != 1 || input["FUZZ"] != "admin" {
+		t.Errorf("Expected the input without FFUFHASH, got %v", input)
+	}
+	if failed := events[2]["data"].(map[string]interface{}); failed["error"] != "timeout" {
+		t.Errorf("Expected the error of the request, got %v", failed)
+	}
+	finding := events[5]["data"].(map[string]interface{})
+	if finding["target"] != "https://api.example.com/admin" || finding["name"] != "SQL injection" || finding["severity"] != "High" || finding["id"] == "" {
+		t.Errorf("Unexpected finding event %v", finding)
+	}
+	tester := events[6]["data"].(map[string]interface{})
+	if tester["tester"] != "Injection" || tester["findings"] != float64(1) || tester["duration_ms"] != float64(2000) {
+		t.Errorf("Unexpected tester_finished event %v", tester)
+	}
+	finished := events[7]["data"].(map[string]interface{})
+	if counts := finished["events"].(map[string]interface{}); counts["request"] != float64(2) || counts["finding"] != float64(1) {
+		t.Errorf("Expected the number of events per kind, got %v", finished)
+	}
+}
+
+func TestEventLog_Scan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	log := NewEventLog(&buf)
+	tester := &streamingTester{vulnType: security.VulnInjection, start: make(chan struct{})}
+	close(tester.start)
+	registry := security.NewSecurityTestRegistry()
+	registry.Register(tester)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = server.URL
+
+	// Result handlers of parent contexts are called first
+	handled := make([]string, 0)
+	ctx = security.WithResultHandler(ctx, func(result *security.TestResult) { handled = append(handled, "parent") })
+	ctx = security.WithResultHandler(ctx, log.ResultHandler(server.URL))
+	ctx = security.WithResultHandler(ctx, func(result *security.TestResult) { handled = append(handled, "child") })
+	ctx = security.WithRequestHandler(ctx, log.Request)
+	if _, err := registry.RunAll(ctx, &conf); err != nil {
+		t.Fatalf("RunAll returned an error: %s", err)
+	}
+	if strings.Join(handled, ",") != "parent,child" {
+		t.Errorf("Expected the result handlers to be chained, got %v", handled)
+	}
+
+	scheduler := security.NewScheduler(ctx, &conf)
+	defer scheduler.Close()
+	req := ffuf.NewRequest(&conf)
+	req.Method = "GET"
+	req.Url = server.URL + "/users"
+	if _, err := scheduler.Execute(&req); err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+
+	events := decodeEvents(t, buf.Bytes())
+	if len(events) != 3 || events[0]["event"] != EventFinding || events[1]["event"] != EventTesterFinished || events[2]["event"] != EventRequest {
+		t.Fatalf("Unexpected events %v", events)
+	}
+	if request := events[2]["data"].(map[string]interface{}); request["status"] != float64(http.StatusNoContent) {
+		t.Errorf("Expected the response of the scheduled request, got %v", request)
+	}
+}
@@ -0,0 +1,273 @@
+package reporting
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+)
+
+// EvidenceIndexFile is the name of the file listing the bundles of an evidence directory
+const EvidenceIndexFile = "index.json"
+
+// EvidenceBundle is the self-contained evidence of a vulnerability found by a security
+// tester, written to its own directory for triage and retesting: the raw request and
+// response, and scripts replaying the request.
+type EvidenceBundle struct {
+	// FindingID is the ID of the finding the vulnerability is merged into in the report
+	FindingID string `json:"finding_id"`
+	Name      string `json:"name"`
+	Severity  string `json:"severity"`
+	Tester    string `json:"tester"`
+	Method    string `json:"method,omitempty"`
+	URL       string `json:"url,omitempty"`
+	// StatusCode is the HTTP status code of the response
+	StatusCode int `json:"status_code,omitempty"`
+	// ContentEncoding is the Content-Encoding the body of the response was decoded from
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	Evidence        string `json:"evidence"`
+	// Fingerprint is the SHA-256 hash of the raw request and response, identifying the
+	// exchange across scans
+	Fingerprint string `json:"fingerprint"`
+	// Dir is the directory of the bundle
+	Dir string `json:"dir"`
+	// Files maps the names of the files of the bundle to their SHA-256 hash
+	Files      map[string]string `json:"files"`
+	DetectedAt time.Time         `json:"detected_at"`
+}
+
+// occurrence is a vulnerability of a security tester merged into a finding
+type occurrence struct {
+	finding *Finding
+	tester  string
+	vuln    security.VulnerabilityInfo
+}
+
+// WriteEvidenceBundles writes an evidence bundle for every vulnerability of the report to a
+// subdirectory of dir named after the ID of its finding, and an index of the bundles. The
+// directories of the bundles are added to the findings, so that reports generated afterwards
+// reference them.
+func (r *VulnerabilityReport) WriteEvidenceBundles(dir string) ([]*EvidenceBundle, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, api.NewAPIError("Failed to create evidence directory: "+err.Error(), 0)
+	}
+	bundles := make([]*EvidenceBundle, 0, len(r.occurrences))
+	for _, finding := range r.Findings {
+		finding.EvidenceBundles = nil
+	}
+	for _, occ := range r.occurrences {
+		bundleDir := filepath.Join(dir, fmt.Sprintf("%s-%03d", occ.finding.ID, len(occ.finding.EvidenceBundles)+1))
+		bundle, err := writeEvidenceBundle(bundleDir, occ)
+		if err != nil {
+			return bundles, err
+		}
+		occ.finding.EvidenceBundles = append(occ.finding.EvidenceBundles, filepath.ToSlash(bundleDir))
+		bundles = append(bundles, bundle)
+	}
+
+	index, _ := json.MarshalIndent(bundles, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, EvidenceIndexFile), index, 0644); err != nil {
+		return bundles, api.NewAPIError("Failed to write evidence index: "+err.Error(), 0)
+	}
+	return bundles, nil
+}
+
+// writeEvidenceBundle writes the files of the bundle of an occurrence to a directory
+func writeEvidenceBundle(dir string, occ occurrence) (*EvidenceBundle, error) {
+	vuln := occ.vuln
+	bundle := &EvidenceBundle{
+		FindingID:  occ.finding.ID,
+		Name:       vuln.Name,
+		Severity:   vuln.Severity,
+		Tester:     occ.tester,
+		Evidence:   vuln.Evidence,
+		Dir:        filepath.ToSlash(dir),
+		Files:      make(map[string]string),
+		DetectedAt: vuln.DetectedAt,
+	}
+
+	files := make(map[string][]byte)
+	var rawRequest, rawResponse []byte
+	if vuln.Request != nil && vuln.Request.URL != nil {
+		bundle.Method = vuln.Request.Method
+		bundle.URL = vuln.Request.URL.String()
+		headers, body := requestParts(vuln.Request)
+		rawRequest = []byte(fullRawHTTP(fmt.Sprintf("%s %s HTTP/1.1\nHost: %s", bundle.Method, vuln.Request.URL.RequestURI(), requestHost(vuln.Request)), headers, body))
+		files["request.txt"] = rawRequest
+		title := strings.ReplaceAll(vuln.Name, "\n", " ")
+		files["replay.http"] = []byte(fmt.Sprintf("### %s\n%s\n", title, fullRawHTTP(fmt.Sprintf("%s %s HTTP/1.1", bundle.Method, bundle.URL), headers, body)))
+		files["replay.sh"] = []byte(fmt.Sprintf("#!/bin/sh\n# Replays: %s (finding %s)\n%s\n", title, occ.finding.ID, curlCommand(bundle.Method, bundle.URL, headers, body)))
+		files["replay.py"] = []byte(pythonReplay(title, occ.finding.ID, bundle.Method, bundle.URL, headers, body))
+	}
+	if vuln.Response != nil {
+		bundle.StatusCode = vuln.Response.StatusCode
+		bundle.ContentEncoding = decodedEncoding(vuln.Response)
+		rawResponse = []byte(fullRawHTTPResponse(vuln.Response))
+		files["response.txt"] = rawResponse
+	}
+	sum := sha256.Sum256(append(append(rawRequest, 0), rawResponse...))
+	bundle.Fingerprint = hex.EncodeToString(sum[:])
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, api.NewAPIError("Failed to create evidence bundle: "+err.Error(), 0)
+	}
+	for name, data := range files {
+		mode := os.FileMode(0644)
+		if strings.HasPrefix(name, "replay.") && name != "replay.http" {
+			mode = 0755
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, mode); err != nil {
+			return nil, api.NewAPIError("Failed to write evidence bundle: "+err.Error(), 0)
+		}
+		fileSum := sha256.Sum256(data)
+		bundle.Files[name] = hex.EncodeToString(fileSum[:])
+	}
+	metadata, _ := json.MarshalIndent(bundle, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, "bundle.json"), metadata, 0644); err != nil {
+		return nil, api.NewAPIError("Failed to write evidence bundle: "+err.Error(), 0)
+	}
+	return bundle, nil
+}
+
+// requestParts returns the headers of a request, without Host unless it differs from the host
+// of the URL, and its full body if the request can replay it
+func requestParts(req *http.Request) (http.Header, []byte) {
+	var body []byte
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+	headers := http.Header{}
+	for name, values := range req.Header {
+		if !strings.EqualFold(name, "Host") {
+			headers[name] = values
+		}
+	}
+	if host := requestHost(req); host != req.URL.Host {
+		headers["Host"] = []string{host}
+	}
+	return headers, body
+}
+
+// requestHost returns the host a request is sent to
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+// fullRawHTTPResponse returns the raw response of a security tester with its body, if the
+// tester kept it. The body is restored so that the response can be read again. The body of a
+// compressed response being decoded, its Content-Encoding and Content-Length are left out.
+func fullRawHTTPResponse(resp *http.Response) string {
+	var body []byte
+	if resp.Body != nil {
+		body, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	proto := resp.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	status := resp.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	header := resp.Header
+	if resp.Uncompressed {
+		header = resp.Header.Clone()
+		header.Del("Content-Encoding")
+		header.Del("Content-Length")
+	}
+	return fullRawHTTP(proto+" "+status, header, body)
+}
+
+// decodedEncoding returns the Content-Encoding the body of a response was decoded from, if the
+// runner decoded it
+func decodedEncoding(resp *http.Response) string {
+	if !resp.Uncompressed {
+		return ""
+	}
+	return resp.Header.Get("Content-Encoding")
+}
+
+// fullRawHTTP formats a request or response line, its headers and its body, like rawHTTP
+// without truncation
+func fullRawHTTP(line string, headers http.Header, body []byte) string {
+	var b strings.Builder
+	b.WriteString(line + "\n")
+	for _, name := range sortedHeaderNames(headers) {
+		for _, value := range headers[name] {
+			b.WriteString(name + ": " + value + "\n")
+		}
+	}
+	if len(body) > 0 {
+		b.WriteString("\n")
+		b.Write(body)
+	}
+	return b.String()
+}
+
+// pythonReplay returns a Python script replaying a request with the requests library
+func pythonReplay(name, findingID, method, target string, headers http.Header, body []byte) string {
+	flat := make(map[string]string, len(headers))
+	for _, header := range sortedHeaderNames(headers) {
+		flat[header] = strings.Join(headers[header], ", ")
+	}
+	data := "None"
+	if len(body) > 0 && utf8.Valid(body) {
+		data = pythonString(string(body)) + ".encode()"
+	} else if len(body) > 0 {
+		data = fmt.Sprintf("bytes.fromhex(%q)", hex.EncodeToString(body))
+	}
+	headersJSON, _ := json.MarshalIndent(flat, "", "    ")
+
+	var b strings.Builder
+	b.WriteString("#!/usr/bin/env python3\n")
+	b.WriteString(fmt.Sprintf("# Replays: %s (finding %s)\n", name, findingID))
+	b.WriteString("import requests\n\n")
+	b.WriteString(fmt.Sprintf("headers = %s\n\n", headersJSON))
+	b.WriteString("response = requests.request(\n")
+	b.WriteString(fmt.Sprintf("    %s,\n    %s,\n", pythonString(method), pythonString(target)))
+	b.WriteString(fmt.Sprintf("    headers=headers,\n    data=%s,\n", data))
+	b.WriteString("    allow_redirects=False,\n    verify=False,\n)\n")
+	b.WriteString("print(response.status_code, response.reason)\n")
+	b.WriteString("for name, value in response.headers.items():\n    print(f\"{name}: {value}\")\n")
+	b.WriteString("print()\nprint(response.text)\n")
+	return b.String()
+}
+
+// pythonString returns a Python string literal. JSON string literals are valid Python ones.
+func pythonString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSpace(buf.String())
+}
+
+// sortedHeaderNames returns the sorted names of headers
+func sortedHeaderNames(headers http.Header) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,87 @@
+package reporting
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+)
+
+func TestWriteEvidenceBundles(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://api.example.com/login", strings.NewReader(`{"user":"admin' --"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := &http.Response{
+		StatusCode: 500,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       io.NopCloser(strings.NewReader("SQL syntax error")),
+	}
+	results := []*security.TestResult{{
+		TestName: "Injection",
+		Vulnerabilities: []security.VulnerabilityInfo{
+			{Type: security.VulnInjection, Name: "SQL Injection", Severity: "Critical", CVSS: 9.8, Request: req, Response: resp, DetectedAt: time.Now()},
+			newTestVulnerability("Verbose Errors", "Low", 3.1, "https://api.example.com/users"),
+			newTestVulnerability("Verbose Errors", "Low", 3.1, "https://api.example.com/users"),
+		},
+	}}
+	report := NewVulnerabilityReport("https://api.example.com", results)
+
+	dir := filepath.Join(t.TempDir(), "evidence")
+	bundles, err := report.WriteEvidenceBundles(dir)
+	if err != nil {
+		t.Fatalf("WriteEvidenceBundles returned an error: %s", err)
+	}
+	if len(bundles) != 3 {
+		t.Fatalf("Expected 3 bundles, got %d", len(bundles))
+	}
+
+	first := bundles[0]
+	if filepath.Base(first.Dir) != first.FindingID+"-001" || len(first.Fingerprint) != 64 {
+		t.Errorf("Unexpected bundle: %+v", first)
+	}
+	for _, name := range []string{"request.txt", "response.txt", "replay.http", "replay.sh", "replay.py", "bundle.json"} {
+		if _, err := os.Stat(filepath.Join(first.Dir, name)); err != nil {
+			t.Errorf("Expected bundle file %s: %s", name, err)
+		}
+	}
+	raw, _ := os.ReadFile(filepath.Join(first.Dir, "request.txt"))
+	if !strings.HasPrefix(string(raw), "POST /login HTTP/1.1\nHost: api.example.com\n") || !strings.HasSuffix(string(raw), `{"user":"admin' --"}`) {
+		t.Errorf("Unexpected raw request: %s", raw)
+	}
+	raw, _ = os.ReadFile(filepath.Join(first.Dir, "response.txt"))
+	if string(raw) != "HTTP/1.1 500 Internal Server Error\nContent-Type: text/plain\n\nSQL syntax error" {
+		t.Errorf("Unexpected raw response: %s", raw)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != "SQL syntax error" {
+		t.Errorf("Expected the response body to be readable again, got %q", body)
+	}
+	script, _ := os.ReadFile(filepath.Join(first.Dir, "replay.py"))
+	if !strings.Contains(string(script), `data="{\"user\":\"admin' --\"}".encode()`) {
+		t.Errorf("Unexpected Python replay script: %s", script)
+	}
+	script, _ = os.ReadFile(filepath.Join(first.Dir, "replay.sh"))
+	if !strings.Contains(string(script), "curl -i -X 'POST' -H 'Content-Type: application/json'") {
+		t.Errorf("Unexpected curl replay script: %s", script)
+	}
+
+	// Both occurrences of the verbose errors are referenced from the merged finding
+	verbose := report.Findings[1]
+	if len(verbose.EvidenceBundles) != 2 || !strings.HasSuffix(verbose.EvidenceBundles[1], verbose.ID+"-002") {
+		t.Errorf("Expected the finding to reference its bundles, got %v", verbose.EvidenceBundles)
+	}
+	output, _ := report.Generate(FormatMarkdown)
+	if !strings.Contains(output, verbose.EvidenceBundles[0]) {
+		t.Errorf("Expected the Markdown report to reference the bundles")
+	}
+
+	var index []EvidenceBundle
+	data, _ := os.ReadFile(filepath.Join(dir, EvidenceIndexFile))
+	if err := json.Unmarshal(data, &index); err != nil || len(index) != 3 || index[2].Name != "Verbose Errors" {
+		t.Errorf("Unexpected index: %s", data)
+	}
+}
@@ -0,0 +1,363 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// Exporter pushes the findings of a vulnerability report to a vulnerability management system
+type Exporter interface {
+	// Name returns the name of the exporter
+	Name() string
+	// Export pushes the findings of the report
+	Export(report *VulnerabilityReport) (*ExportResult, error)
+}
+
+// ExportResult summarizes an export
+type ExportResult struct {
+	// Created is the number of findings or issues created
+	Created int
+	// Skipped is the number of findings skipped as duplicates or below the minimum severity
+	Skipped int
+	// Links are the URLs of the created test or issues
+	Links []string
+}
+
+// DefectDojoExporter imports findings into DefectDojo through its import-scan API. Findings
+// are sent in the Generic Findings Import format with their fingerprint as unique ID, so that
+// DefectDojo deduplicates them across scans.
+type DefectDojoExporter struct {
+	// BaseURL is the URL of the DefectDojo instance
+	BaseURL string
+	// APIKey is the API v2 key of the importing user
+	APIKey string
+	// EngagementID is the ID of the engagement to import into
+	EngagementID int
+	// ProductName and EngagementName select the engagement if EngagementID is not set
+	ProductName    string
+	EngagementName string
+	// AutoCreateContext creates the product and engagement if they do not exist
+	AutoCreateContext bool
+	// MinimumSeverity is the minimum severity of the imported findings
+	MinimumSeverity string
+	// CloseOldFindings closes the findings of previous imports not present in this one
+	CloseOldFindings bool
+	// Tags are added to the created test
+	Tags []string
+	// Client is the HTTP client used for the API requests
+	Client *http.Client
+}
+
+// NewDefectDojoExporter creates a new DefectDojo exporter
+func NewDefectDojoExporter(baseURL, apiKey string) *DefectDojoExporter {
+	return &DefectDojoExporter{
+		BaseURL:         strings.TrimSuffix(baseURL, "/"),
+		APIKey:          apiKey,
+		MinimumSeverity: "Info",
+		Client:          &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Name returns the name of the exporter
+func (e *DefectDojoExporter) Name() string {
+	return "DefectDojo"
+}
+
+// defectDojoFinding is a finding in the DefectDojo Generic Findings Import format
+type defectDojoFinding struct {
+	Title            string   `json:"title"`
+	Description      string   `json:"description"`
+	Severity         string   `json:"severity"`
+	Mitigation       string   `json:"mitigation"`
+	References       string   `json:"references,omitempty"`
+	CWE              int      `json:"cwe,omitempty"`
+	CVSSv3           string   `json:"cvssv3,omitempty"`
+	CVSSv3Score      float64  `json:"cvssv3_score,omitempty"`
+	Date             string   `json:"date"`
+	UniqueIDFromTool string   `json:"unique_id_from_tool"`
+	VulnIDFromTool   string   `json:"vuln_id_from_tool"`
+	Endpoints        []string `json:"endpoints,omitempty"`
+}
+
+// Export imports the findings of the report into DefectDojo
+func (e *DefectDojoExporter) Export(report *VulnerabilityReport) (*ExportResult, error) {
+	result := &ExportResult{}
+	findings := []defectDojoFinding{}
+	for _, finding := range report.Findings {
+		if severityRank(finding.Severity) > severityRank(e.MinimumSeverity) {
+			result.Skipped++
+			continue
+		}
+		findings = append(findings, newDefectDojoFinding(finding))
+	}
+	if len(findings) == 0 {
+		return result, nil
+	}
+
+	scan, err := json.Marshal(map[string]interface{}{"findings": findings})
+	if err != nil {
+		return nil, api.NewAPIError("Failed to encode DefectDojo findings: "+err.Error(), 0)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	fields := map[string]string{
+		"scan_type":          "Generic Findings Import",
+		"scan_date":          report.GeneratedAt.Format("2006-01-02"),
+		"minimum_severity":   e.MinimumSeverity,
+		"active":             "true",
+		"verified":           "false",
+		"close_old_findings": strconv.FormatBool(e.CloseOldFindings),
+		"test_title":         "ffuf API security scan",
+	}
+	if e.EngagementID > 0 {
+		fields["engagement"] = strconv.Itoa(e.EngagementID)
+	} else {
+		fields["product_name"] = e.ProductName
+		fields["engagement_name"] = e.EngagementName
+		fields["auto_create_context"] = strconv.FormatBool(e.AutoCreateContext)
+	}
+	if len(e.Tags) > 0 {
+		fields["tags"] = strings.Join(e.Tags, ",")
+	}
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	file, err := form.CreateFormFile("file", "ffuf-findings.json")
+	if err != nil {
+		return nil, api.NewAPIError("Failed to create DefectDojo import: "+err.Error(), 0)
+	}
+	file.Write(scan)
+	form.Close()
+
+	req, err := http.NewRequest("POST", e.BaseURL+"/api/v2/import-scan/", &body)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to create DefectDojo request: "+err.Error(), 0)
+	}
+	req.Header.Set("Authorization", "Token "+e.APIKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	var response struct {
+		Test int `json:"test"`
+	}
+	if err := doJSON(e.Client, req, &response); err != nil {
+		return nil, err
+	}
+
+	result.Created = len(findings)
+	if response.Test > 0 {
+		result.Links = append(result.Links, fmt.Sprintf("%s/test/%d", e.BaseURL, response.Test))
+	}
+	return result, nil
+}
+
+// newDefectDojoFinding converts a finding to the DefectDojo Generic Findings Import format
+func newDefectDojoFinding(finding *Finding) defectDojoFinding {
+	cwe, _ := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(finding.CWE), "CWE-"))
+	ddFinding := defectDojoFinding{
+		Title:            finding.Name,
+		Description:      findingDescription(finding, "**%s**: %s\n\n"),
+		Severity:         severityOrder[minInt(severityRank(finding.Severity), len(severityOrder)-1)],
+		Mitigation:       finding.Remediation,
+		References:       strings.Join(finding.References, "\n"),
+		CWE:              cwe,
+		CVSSv3:           finding.CVSSVector,
+		CVSSv3Score:      finding.CVSS,
+		Date:             finding.DetectedAt.Format("2006-01-02"),
+		UniqueIDFromTool: finding.ID,
+		VulnIDFromTool:   sarifRuleID(finding),
+	}
+	if finding.URL != "" {
+		ddFinding.Endpoints = []string{finding.URL}
+	}
+	return ddFinding
+}
+
+// JiraExporter opens a Jira issue per finding. Issues are labeled with the fingerprint of
+// their finding, and findings that already have an issue are skipped.
+type JiraExporter struct {
+	// BaseURL is the URL of the Jira instance
+	BaseURL string
+	// Username and APIToken are used for basic authentication (Jira Cloud)
+	Username string
+	APIToken string
+	// Token is a personal access token used for bearer authentication (Jira Server)
+	Token string
+	// ProjectKey is the key of the project the issues are created in
+	ProjectKey string
+	// IssueType is the name of the type of the created issues
+	IssueType string
+	// MinimumSeverity is the minimum severity of the findings issues are opened for
+	MinimumSeverity string
+	// Labels are added to the created issues
+	Labels []string
+	// Priorities maps finding severities to Jira priority names. Unmapped severities keep
+	// the default priority of the project.
+	Priorities map[string]string
+	// Client is the HTTP client used for the API requests
+	Client *http.Client
+}
+
+// NewJiraExporter creates a new Jira exporter
+func NewJiraExporter(baseURL, projectKey string) *JiraExporter {
+	return &JiraExporter{
+		BaseURL:         strings.TrimSuffix(baseURL, "/"),
+		ProjectKey:      projectKey,
+		IssueType:       "Bug",
+		MinimumSeverity: "Medium",
+		Labels:          []string{"ffuf", "security"},
+		Priorities:      map[string]string{},
+		Client:          &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the name of the exporter
+func (e *JiraExporter) Name() string {
+	return "Jira"
+}
+
+// Export opens Jira issues for the findings of the report that do not have one yet
+func (e *JiraExporter) Export(report *VulnerabilityReport) (*ExportResult, error) {
+	result := &ExportResult{}
+	for _, finding := range report.Findings {
+		if severityRank(finding.Severity) > severityRank(e.MinimumSeverity) {
+			result.Skipped++
+			continue
+		}
+
+		label := "ffuf-" + finding.ID
+		exists, err := e.issueExists(label)
+		if err != nil {
+			return result, err
+		}
+		if exists {
+			result.Skipped++
+			continue
+		}
+
+		key, err := e.createIssue(finding, label)
+		if err != nil {
+			return result, err
+		}
+		result.Created++
+		result.Links = append(result.Links, e.BaseURL+"/browse/"+key)
+	}
+	return result, nil
+}
+
+// issueExists checks if the project has an issue with a label
+func (e *JiraExporter) issueExists(label string) (bool, error) {
+	jql := fmt.Sprintf(`project = "%s" AND labels = "%s"`, e.ProjectKey, label)
+	req, err := http.NewRequest("GET", e.BaseURL+"/rest/api/2/search?maxResults=0&jql="+url.QueryEscape(jql), nil)
+	if err != nil {
+		return false, api.NewAPIError("Failed to create Jira request: "+err.Error(), 0)
+	}
+	e.authenticate(req)
+
+	var response struct {
+		Total int `json:"total"`
+	}
+	if err := doJSON(e.Client, req, &response); err != nil {
+		return false, err
+	}
+	return response.Total > 0, nil
+}
+
+// createIssue creates an issue for a finding and returns its key
+func (e *JiraExporter) createIssue(finding *Finding, label string) (string, error) {
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": e.ProjectKey},
+		"issuetype":   map[string]string{"name": e.IssueType},
+		"summary":     fmt.Sprintf("[%s] %s", finding.Severity, finding.Name),
+		"description": findingDescription(finding, "*%s*: %s\n\n"),
+		"labels":      append(append([]string{}, e.Labels...), label),
+	}
+	if priority, ok := e.Priorities[finding.Severity]; ok {
+		fields["priority"] = map[string]string{"name": priority}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"fields": fields})
+	if err != nil {
+		return "", api.NewAPIError("Failed to encode Jira issue: "+err.Error(), 0)
+	}
+	req, err := http.NewRequest("POST", e.BaseURL+"/rest/api/2/issue", bytes.NewReader(body))
+	if err != nil {
+		return "", api.NewAPIError("Failed to create Jira request: "+err.Error(), 0)
+	}
+	e.authenticate(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	var response struct {
+		Key string `json:"key"`
+	}
+	if err := doJSON(e.Client, req, &response); err != nil {
+		return "", err
+	}
+	return response.Key, nil
+}
+
+// authenticate adds the credentials of the exporter to a request
+func (e *JiraExporter) authenticate(req *http.Request) {
+	if e.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.Token)
+	} else if e.Username != "" {
+		req.SetBasicAuth(e.Username, e.APIToken)
+	}
+	req.Header.Set("Accept", "application/json")
+}
+
+// findingDescription describes a finding using a format for its labeled fields
+func findingDescription(finding *Finding, field string) string {
+	var buf bytes.Buffer
+	buf.WriteString(finding.Description + "\n\n")
+	if finding.URL != "" {
+		buf.WriteString(fmt.Sprintf(field, "Request", finding.Method+" "+finding.URL))
+	}
+	buf.WriteString(fmt.Sprintf(field, "Severity", fmt.Sprintf("%s (CVSS %.1f)", finding.Severity, finding.CVSS)))
+	if finding.CVSSVector != "" {
+		buf.WriteString(fmt.Sprintf(field, "CVSS vector", finding.CVSSVector))
+	}
+	if finding.CWE != "" {
+		buf.WriteString(fmt.Sprintf(field, "CWE", finding.CWE))
+	}
+	buf.WriteString(fmt.Sprintf(field, "Evidence", finding.Evidence))
+	buf.WriteString(fmt.Sprintf(field, "Remediation", finding.Remediation))
+	buf.WriteString(fmt.Sprintf(field, "Fingerprint", finding.ID))
+	return strings.TrimSpace(buf.String())
+}
+
+// doJSON executes a request and decodes its JSON response
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Request to %s failed: %s", req.URL.Host, err), 0)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return api.NewAPIError(fmt.Sprintf("Request to %s failed: %s", req.URL.Path, strings.TrimSpace(string(body))), resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return api.NewAPIError("Failed to decode response: "+err.Error(), resp.StatusCode)
+	}
+	return nil
+}
+
+// minInt returns the smaller of two integers
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
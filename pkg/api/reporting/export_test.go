@@ -0,0 +1,122 @@
+package reporting
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDefectDojoExporter_Export(t *testing.T) {
+	var scan struct {
+		Findings []defectDojoFinding `json:"findings"`
+	}
+	var fields map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/import-scan/" || r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse import form: %v", err)
+		}
+		fields = map[string]string{}
+		for name, values := range r.MultipartForm.Value {
+			fields[name] = values[0]
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected a scan file: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		json.Unmarshal(data, &scan)
+		w.Write([]byte(`{"test": 42}`))
+	}))
+	defer server.Close()
+
+	exporter := NewDefectDojoExporter(server.URL+"/", "secret")
+	exporter.EngagementID = 7
+	exporter.MinimumSeverity = "High"
+	result, err := exporter.Export(newTestReport())
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	if result.Created != 2 || result.Skipped != 1 {
+		t.Errorf("Expected 2 created and 1 skipped, got %d and %d", result.Created, result.Skipped)
+	}
+	if len(result.Links) != 1 || result.Links[0] != server.URL+"/test/42" {
+		t.Errorf("Unexpected links: %v", result.Links)
+	}
+	if fields["scan_type"] != "Generic Findings Import" || fields["engagement"] != "7" {
+		t.Errorf("Unexpected import fields: %v", fields)
+	}
+	if len(scan.Findings) != 2 || scan.Findings[0].CWE != 89 || scan.Findings[0].UniqueIDFromTool == "" {
+		t.Errorf("Unexpected imported findings: %+v", scan.Findings)
+	}
+}
+
+func TestJiraExporter_Export(t *testing.T) {
+	existing := ""
+	var created []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user@example.com" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			total := 0
+			if existing != "" && strings.Contains(r.URL.Query().Get("jql"), existing) {
+				total = 1
+			}
+			json.NewEncoder(w).Encode(map[string]int{"total": total})
+		case "/rest/api/2/issue":
+			var issue struct {
+				Fields map[string]interface{} `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&issue)
+			created = append(created, issue.Fields)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"key": "SEC-1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	report := newTestReport()
+	existing = "ffuf-" + report.Findings[1].ID
+
+	exporter := NewJiraExporter(server.URL, "SEC")
+	exporter.Username = "user@example.com"
+	exporter.APIToken = "token"
+	exporter.Priorities["Critical"] = "Highest"
+	result, err := exporter.Export(report)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	// The low finding is below the minimum severity and the high finding already has an issue
+	if result.Created != 1 || result.Skipped != 2 {
+		t.Errorf("Expected 1 created and 2 skipped, got %d and %d", result.Created, result.Skipped)
+	}
+	if len(result.Links) != 1 || result.Links[0] != server.URL+"/browse/SEC-1" {
+		t.Errorf("Unexpected links: %v", result.Links)
+	}
+	if len(created) != 1 {
+		t.Fatalf("Expected 1 issue, got %d", len(created))
+	}
+	if created[0]["summary"] != "[Critical] SQL Injection" {
+		t.Errorf("Unexpected summary: %v", created[0]["summary"])
+	}
+	if priority, _ := created[0]["priority"].(map[string]interface{}); priority["name"] != "Highest" {
+		t.Errorf("Unexpected priority: %v", created[0]["priority"])
+	}
+	labels, _ := created[0]["labels"].([]interface{})
+	if len(labels) != 3 || labels[2] != "ffuf-"+report.Findings[0].ID {
+		t.Errorf("Unexpected labels: %v", labels)
+	}
+}
@@ -0,0 +1,209 @@
+package reporting
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+)
+
+// CoverageSnapshot is the coverage of a run, stored in a coverage history
+type CoverageSnapshot struct {
+	// Timestamp is the end of the run
+	Timestamp time.Time `json:"timestamp"`
+	// Target is the tested target, such as the scheme and host of the API
+	Target string `json:"target"`
+	// SpecVersion is the version of the imported specification
+	SpecVersion string `json:"spec_version,omitempty"`
+	// TotalEndpoints is the number of imported endpoints
+	TotalEndpoints int `json:"total_endpoints"`
+	// ExercisedEndpoints is the number of endpoints that received requests
+	ExercisedEndpoints int `json:"exercised_endpoints"`
+	// EndpointCoverage is the percentage of fully or partially tested endpoints
+	EndpointCoverage float64 `json:"endpoint_coverage"`
+	// ExercisedCoverage is the percentage of endpoints that received requests
+	ExercisedCoverage float64 `json:"exercised_coverage"`
+	// ParameterCoverage is the percentage of tested parameters
+	ParameterCoverage float64 `json:"parameter_coverage"`
+	// Vulnerabilities is the number of findings of the run
+	Vulnerabilities int `json:"vulnerabilities"`
+	// Severities is the number of findings per severity
+	Severities map[string]int `json:"severities,omitempty"`
+}
+
+// Snapshot summarizes the coverage of the run and the findings of a vulnerability report,
+// which may be nil
+func (c *CoverageAnalyzer) Snapshot(target string, vulnerabilities *VulnerabilityReport) *CoverageSnapshot {
+	stats := c.GetCoverageStats()
+	snapshot := &CoverageSnapshot{
+		Timestamp:          time.Now(),
+		Target:             target,
+		SpecVersion:        c.SpecVersion(),
+		TotalEndpoints:     stats["total_endpoints"].(int),
+		ExercisedEndpoints: stats["exercised_endpoints"].(int),
+		EndpointCoverage:   stats["endpoint_coverage"].(float64),
+		ExercisedCoverage:  stats["exercised_coverage"].(float64),
+		ParameterCoverage:  stats["parameter_coverage"].(float64),
+	}
+	if vulnerabilities != nil {
+		snapshot.Vulnerabilities = len(vulnerabilities.Findings)
+		snapshot.Severities = vulnerabilities.SeverityCounts()
+	}
+	return snapshot
+}
+
+// SpecVersion returns the API version of the imported OpenAPI specification, or an empty
+// string for other imports
+func (c *CoverageAnalyzer) SpecVersion() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.discovery == nil {
+		return ""
+	}
+	if openapi, ok := c.discovery.Parser.(*parser.OpenAPIParser); ok && openapi.Spec != nil {
+		return openapi.Spec.Version
+	}
+	return ""
+}
+
+// SetHistory sets the snapshots of the runs shown as a trend in the HTML and JSON reports
+func (c *CoverageAnalyzer) SetHistory(history []*CoverageSnapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.history = history
+}
+
+// CoverageHistory stores coverage snapshots in a JSON lines file, so that runs can be
+// compared over time
+type CoverageHistory struct {
+	// Path is the path of the history file
+	Path string
+}
+
+// NewCoverageHistory creates a coverage history stored in a file
+func NewCoverageHistory(path string) *CoverageHistory {
+	return &CoverageHistory{Path: path}
+}
+
+// Append appends a snapshot to the history file, creating it if needed
+func (h *CoverageHistory) Append(snapshot *CoverageSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return api.NewAPIError("Failed to encode coverage snapshot: "+err.Error(), 0)
+	}
+	f, err := os.OpenFile(h.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return api.NewAPIError("Failed to open coverage history: "+err.Error(), 0)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return api.NewAPIError("Failed to write coverage history: "+err.Error(), 0)
+	}
+	return nil
+}
+
+// Load returns the snapshots of a target and specification version, oldest first. A
+// missing history file has no snapshots.
+func (h *CoverageHistory) Load(target, specVersion string) ([]*CoverageSnapshot, error) {
+	snapshots := make([]*CoverageSnapshot, 0)
+	f, err := os.Open(h.Path)
+	if os.IsNotExist(err) {
+		return snapshots, nil
+	} else if err != nil {
+		return nil, api.NewAPIError("Failed to open coverage history: "+err.Error(), 0)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var snapshot CoverageSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid coverage snapshot on line %d: %s", line, err), 0)
+		}
+		if snapshot.Target == target && snapshot.SpecVersion == specVersion {
+			snapshots = append(snapshots, &snapshot)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, api.NewAPIError("Failed to read coverage history: "+err.Error(), 0)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+	})
+	return snapshots, nil
+}
+
+// trendChart renders the coverage and vulnerability counts of snapshots as an SVG line chart.
+// Coverage uses the left axis, from 0 to 100%, and vulnerabilities the right axis.
+func trendChart(history []*CoverageSnapshot) template.HTML {
+	const width, height, left, right, top, bottom = 720.0, 260.0, 50.0, 50.0, 20.0, 40.0
+	plotWidth, plotHeight := width-left-right, height-top-bottom
+
+	maxVulnerabilities := 1
+	for _, snapshot := range history {
+		if snapshot.Vulnerabilities > maxVulnerabilities {
+			maxVulnerabilities = snapshot.Vulnerabilities
+		}
+	}
+	x := func(i int) float64 {
+		if len(history) == 1 {
+			return left + plotWidth/2
+		}
+		return left + plotWidth*float64(i)/float64(len(history)-1)
+	}
+	y := func(ratio float64) float64 {
+		return top + plotHeight*(1-ratio)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="trend" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" xmlns="http://www.w3.org/2000/svg">`, width, height, width, height)
+	for _, percent := range []float64{0, 25, 50, 75, 100} {
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#e0e0e0"/>`, left, y(percent/100), width-right, y(percent/100))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end" font-size="11" fill="#666">%.0f%%</text>`, left-6, y(percent/100)+4, percent)
+	}
+	fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="11" fill="#c0392b">%d</text>`, width-right+6, y(1)+4, maxVulnerabilities)
+	fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="11" fill="#c0392b">0</text>`, width-right+6, y(0)+4)
+
+	series := []struct {
+		color string
+		value func(*CoverageSnapshot) float64
+	}{
+		{"#4CAF50", func(s *CoverageSnapshot) float64 { return s.ExercisedCoverage / 100 }},
+		{"#2196F3", func(s *CoverageSnapshot) float64 { return s.ParameterCoverage / 100 }},
+		{"#c0392b", func(s *CoverageSnapshot) float64 { return float64(s.Vulnerabilities) / float64(maxVulnerabilities) }},
+	}
+	for _, serie := range series {
+		points := make([]string, 0, len(history))
+		for i, snapshot := range history {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(serie.value(snapshot))))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, serie.color, strings.Join(points, " "))
+		for _, point := range points {
+			coords := strings.Split(point, ",")
+			fmt.Fprintf(&b, `<circle cx="%s" cy="%s" r="3" fill="%s"/>`, coords[0], coords[1], serie.color)
+		}
+	}
+	for i, snapshot := range history {
+		if len(history) > 10 && i%(len(history)/10+1) != 0 && i != len(history)-1 {
+			continue
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle" font-size="11" fill="#666">%s</text>`,
+			x(i), height-bottom+16, template.HTMLEscapeString(snapshot.Timestamp.Format("01-02 15:04")))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
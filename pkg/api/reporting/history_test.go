@@ -0,0 +1,83 @@
+package reporting
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestCoverageAnalyzer_Snapshot(t *testing.T) {
+	discovery := parser.NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.Parser = &parser.OpenAPIParser{Spec: &parser.OpenAPISpec{Version: "2.1.0"}}
+	discovery.Endpoints = []*parser.DiscoveredEndpoint{
+		{Method: "GET", Path: "/users", URL: "https://api.example.com/users"},
+		{Method: "GET", Path: "/orders", URL: "https://api.example.com/orders"},
+	}
+	analyzer := NewCoverageAnalyzer(nil)
+	analyzer.ImportFromDiscovery(discovery)
+	analyzer.RecordRequest(&ffuf.Request{Method: "GET", Url: "https://api.example.com/users"}, &ffuf.Response{StatusCode: 200})
+
+	vulnerabilities := NewVulnerabilityReport("https://api.example.com", []*security.TestResult{
+		{TestName: "injection", Vulnerabilities: []security.VulnerabilityInfo{
+			{Name: "SQL Injection", Severity: "High"},
+		}},
+	})
+	snapshot := analyzer.Snapshot("https://api.example.com", vulnerabilities)
+	if snapshot.SpecVersion != "2.1.0" || snapshot.TotalEndpoints != 2 || snapshot.ExercisedCoverage != 50.0 {
+		t.Errorf("Unexpected snapshot: %+v", snapshot)
+	}
+	if snapshot.Vulnerabilities != 1 || snapshot.Severities["High"] != 1 {
+		t.Errorf("Expected the findings of the vulnerability report, got %d", snapshot.Vulnerabilities)
+	}
+}
+
+func TestCoverageHistory(t *testing.T) {
+	history := NewCoverageHistory(filepath.Join(t.TempDir(), "coverage.jsonl"))
+	if snapshots, err := history.Load("https://api.example.com", "1.0"); err != nil || len(snapshots) != 0 {
+		t.Fatalf("Expected a missing history to be empty, got %d: %v", len(snapshots), err)
+	}
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	snapshots := []*CoverageSnapshot{
+		{Timestamp: start.Add(2 * time.Hour), Target: "https://api.example.com", SpecVersion: "1.0", ExercisedCoverage: 60, Vulnerabilities: 3},
+		{Timestamp: start, Target: "https://api.example.com", SpecVersion: "1.0", ExercisedCoverage: 40, Vulnerabilities: 5},
+		{Timestamp: start, Target: "https://api.example.com", SpecVersion: "2.0", ExercisedCoverage: 10},
+		{Timestamp: start, Target: "https://staging.example.com", SpecVersion: "1.0", ExercisedCoverage: 90},
+	}
+	for _, snapshot := range snapshots {
+		if err := history.Append(snapshot); err != nil {
+			t.Fatalf("Append returned an error: %s", err)
+		}
+	}
+
+	loaded, err := history.Load("https://api.example.com", "1.0")
+	if err != nil {
+		t.Fatalf("Load returned an error: %s", err)
+	}
+	if len(loaded) != 2 || loaded[0].ExercisedCoverage != 40 || loaded[1].ExercisedCoverage != 60 {
+		t.Fatalf("Expected the 2 snapshots of the target and version, oldest first, got %d", len(loaded))
+	}
+
+	analyzer := NewCoverageAnalyzer(&CoverageOptions{Format: FormatHTML})
+	analyzer.SetHistory(loaded)
+	report, err := analyzer.GenerateReport()
+	if err != nil {
+		t.Fatalf("GenerateReport returned an error: %s", err)
+	}
+	if !strings.Contains(report, "Coverage Trend") || strings.Count(report, "<polyline") != 3 {
+		t.Errorf("Expected a trend chart with 3 series in the HTML report")
+	}
+
+	f, _ := os.OpenFile(history.Path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("{not json\n")
+	f.Close()
+	if _, err := history.Load("https://api.example.com", "1.0"); err == nil || !strings.Contains(err.Error(), "line 5") {
+		t.Errorf("Expected an error for the invalid line, got %v", err)
+	}
+}
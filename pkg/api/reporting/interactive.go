@@ -0,0 +1,272 @@
+package reporting
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// maxRawLength is the maximum length of the raw requests and responses kept for replay
+const maxRawLength = 16 * 1024
+
+// interactiveStyle styles the filters, collapsible panes and diagrams of the HTML reports
+const interactiveStyle = `
+        .filters { display: flex; flex-wrap: wrap; gap: 10px; margin: 10px 0; }
+        .filters input, .filters select { padding: 6px 8px; border: 1px solid #ccc; border-radius: 4px; font-size: 14px; }
+        .filters input { min-width: 300px; }
+        .filter-count { align-self: center; color: #666; font-size: 14px; }
+        tbody.item.hidden { display: none; }
+        tr.detail td { background-color: #fafafa; border-bottom: 2px solid #ddd; }
+        details { margin: 8px 0; }
+        details summary { cursor: pointer; font-weight: bold; }
+        pre.raw { background-color: #272822; color: #f8f8f2; padding: 10px; border-radius: 5px; overflow-x: auto; white-space: pre-wrap; word-break: break-all; max-height: 400px; }
+        pre.mermaid-source { background-color: #f5f5f5; padding: 10px; border-radius: 5px; overflow-x: auto; }
+        button.copy { margin: 4px 0; padding: 4px 10px; border: 1px solid #ccc; border-radius: 4px; background-color: white; cursor: pointer; }
+        button.copy:hover { background-color: #f2f2f2; }
+        .diagram { overflow-x: auto; }
+        .diagram details { margin-top: 0; }
+`
+
+// interactiveScript filters the tables, copies replay snippets and renders the Mermaid
+// diagrams if a Mermaid script was embedded. It runs offline.
+const interactiveScript = `
+(function () {
+    function applyFilters(table) {
+        var text = document.querySelector('input.filter[data-table="' + table.id + '"]');
+        var selects = document.querySelectorAll('select.filter[data-table="' + table.id + '"]');
+        var query = text ? text.value.toLowerCase() : '';
+        var shown = 0, items = table.querySelectorAll('tbody.item');
+        items.forEach(function (item) {
+            var visible = item.getAttribute('data-search').indexOf(query) !== -1;
+            selects.forEach(function (select) {
+                if (select.value && item.getAttribute('data-' + select.getAttribute('data-field')) !== select.value) {
+                    visible = false;
+                }
+            });
+            item.classList.toggle('hidden', !visible);
+            if (visible) { shown++; }
+        });
+        var count = document.querySelector('.filter-count[data-table="' + table.id + '"]');
+        if (count) { count.textContent = shown + ' of ' + items.length + ' shown'; }
+    }
+    document.querySelectorAll('.filter').forEach(function (filter) {
+        var table = document.getElementById(filter.getAttribute('data-table'));
+        filter.addEventListener('input', function () { applyFilters(table); });
+        filter.addEventListener('change', function () { applyFilters(table); });
+        applyFilters(table);
+    });
+
+    function copied(button) {
+        var label = button.textContent;
+        button.textContent = 'Copied';
+        setTimeout(function () { button.textContent = label; }, 1500);
+    }
+    document.querySelectorAll('button.copy').forEach(function (button) {
+        button.addEventListener('click', function () {
+            var text = document.getElementById(button.getAttribute('data-copy')).textContent;
+            if (navigator.clipboard && window.isSecureContext) {
+                navigator.clipboard.writeText(text).then(function () { copied(button); });
+                return;
+            }
+            var area = document.createElement('textarea');
+            area.value = text;
+            document.body.appendChild(area);
+            area.select();
+            document.execCommand('copy');
+            document.body.removeChild(area);
+            copied(button);
+        });
+    });
+
+    if (window.mermaid) {
+        document.querySelectorAll('pre.mermaid-source').forEach(function (source) {
+            var container = source.closest('.diagram');
+            var image = container.querySelector('.diagram-image');
+            var diagram = document.createElement('div');
+            diagram.className = 'mermaid';
+            diagram.textContent = source.textContent;
+            container.insertBefore(diagram, image);
+            image.style.display = 'none';
+        });
+        window.mermaid.initialize({ startOnLoad: true, securityLevel: 'strict' });
+    }
+})();
+`
+
+// reportAssets returns the style and scripts embedded in an interactive HTML report. The
+// Mermaid script is read from a local file, so that reports are generated offline.
+func reportAssets(mermaidScript string) (map[string]interface{}, error) {
+	assets := map[string]interface{}{
+		"Style":  template.CSS(interactiveStyle),
+		"Script": template.JS(interactiveScript),
+	}
+	if mermaidScript != "" {
+		data, err := os.ReadFile(mermaidScript)
+		if err != nil {
+			return nil, api.NewAPIError("Failed to read Mermaid script: "+err.Error(), 0)
+		}
+		// The script is embedded in a script element, which must not be closed early
+		assets["Mermaid"] = template.JS(strings.ReplaceAll(string(data), "</script", `<\/script`))
+	}
+	return assets, nil
+}
+
+// endpointDiagram returns the map of endpoints as an SVG image and as a Mermaid diagram,
+// whose leaves are styled with the classes of classDefs
+func endpointDiagram(endpoints []*parser.DiscoveredEndpoint, classes map[string]string, classDefs map[string]string) (template.HTML, string) {
+	if len(endpoints) == 0 {
+		return "", ""
+	}
+	options := &parser.VisOptions{Format: parser.VisFormatSVG, Title: "API Map", ClassStyles: classDefs}
+	image, err := parser.NewVisualizer(options).VisualizeEndpoints(endpoints, classes)
+	if err != nil {
+		return "", ""
+	}
+	options.Format = parser.VisFormatMermaid
+	diagram, err := parser.NewVisualizer(options).VisualizeEndpoints(endpoints, classes)
+	if err != nil {
+		return "", ""
+	}
+	// The SVG image escapes its labels
+	return template.HTML(image), diagram
+}
+
+// curlCommand returns a curl command replaying a request
+func curlCommand(method, target string, headers http.Header, body []byte) string {
+	parts := []string{"curl", "-i"}
+	if method != "" && method != "GET" {
+		parts = append(parts, "-X", shellQuote(method))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range headers[name] {
+			parts = append(parts, "-H", shellQuote(name+": "+value))
+		}
+	}
+	if len(body) > 0 {
+		parts = append(parts, "--data-raw", shellQuote(string(body)))
+	}
+	parts = append(parts, shellQuote(target))
+	return strings.Join(parts, " ")
+}
+
+// shellQuote quotes a string for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// rawHTTP formats a request or response line, its headers and its body, truncated to
+// maxRawLength
+func rawHTTP(line string, headers http.Header, body []byte) string {
+	var b strings.Builder
+	b.WriteString(line + "\n")
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range headers[name] {
+			b.WriteString(name + ": " + value + "\n")
+		}
+	}
+	if len(body) > 0 {
+		b.WriteString("\n")
+		b.Write(body)
+	}
+	return truncateRaw(b.String())
+}
+
+// truncateRaw truncates a raw request or response to maxRawLength
+func truncateRaw(raw string) string {
+	if len(raw) <= maxRawLength {
+		return raw
+	}
+	return strings.ToValidUTF8(raw[:maxRawLength], "") + "\n... (truncated)"
+}
+
+// replayHTTPRequest returns the raw request and the curl command of a request found by a
+// security tester. The body is only available if the request can replay it.
+func replayHTTPRequest(req *http.Request) (string, string) {
+	var body []byte
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(io.LimitReader(rc, maxRawLength+1))
+			rc.Close()
+		}
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := http.Header{}
+	for name, values := range req.Header {
+		if !strings.EqualFold(name, "Host") {
+			headers[name] = values
+		}
+	}
+	raw := rawHTTP(fmt.Sprintf("%s %s HTTP/1.1\nHost: %s", req.Method, req.URL.RequestURI(), host), headers, body)
+	if host != req.URL.Host {
+		headers["Host"] = []string{host}
+	}
+	return raw, curlCommand(req.Method, req.URL.String(), headers, body)
+}
+
+// replayHTTPResponse returns the status line and headers of a response found by a security
+// tester. Its body was consumed by the tester.
+func replayHTTPResponse(resp *http.Response) string {
+	proto := resp.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	status := resp.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return rawHTTP(proto+" "+status, resp.Header, nil)
+}
+
+// replayFFUFRequest returns the raw request and the curl command of a request executed by ffuf
+func replayFFUFRequest(req *ffuf.Request) (string, string) {
+	headers := http.Header{}
+	for name, value := range req.Headers {
+		headers[name] = []string{value}
+	}
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+	curl := curlCommand(method, req.Url, headers, req.Data)
+	if req.Raw != "" {
+		return truncateRaw(req.Raw), curl
+	}
+	requestURI, host := req.Url, req.Host
+	if u, err := url.Parse(req.Url); err == nil {
+		requestURI = u.RequestURI()
+		if host == "" {
+			host = u.Host
+		}
+	}
+	return rawHTTP(fmt.Sprintf("%s %s HTTP/1.1\nHost: %s", method, requestURI, host), headers, req.Data), curl
+}
+
+// replayFFUFResponse returns the raw response of a request executed by ffuf
+func replayFFUFResponse(resp *ffuf.Response) string {
+	if resp.Raw != "" {
+		return truncateRaw(resp.Raw)
+	}
+	return rawHTTP(fmt.Sprintf("HTTP/1.1 %d %s", resp.StatusCode, http.StatusText(int(resp.StatusCode))), http.Header(resp.Headers), resp.Data)
+}
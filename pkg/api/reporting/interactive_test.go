@@ -0,0 +1,120 @@
+package reporting
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestReplayHTTPRequest(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://api.example.com/users?name=o'brien", bytes.NewBufferString(`{"name":"o'brien"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	raw, curl := replayHTTPRequest(req)
+	expected := "POST /users?name=o'brien HTTP/1.1\nHost: api.example.com\nContent-Type: application/json\n\n{\"name\":\"o'brien\"}"
+	if raw != expected {
+		t.Errorf("Unexpected raw request:\n%s", raw)
+	}
+	expected = `curl -i -X 'POST' -H 'Content-Type: application/json' --data-raw '{"name":"o'\''brien"}' 'https://api.example.com/users?name=o'\''brien'`
+	if curl != expected {
+		t.Errorf("Unexpected curl command:\n%s", curl)
+	}
+
+	resp := &http.Response{StatusCode: 500, Header: http.Header{"Content-Type": []string{"text/plain"}}}
+	if raw := replayHTTPResponse(resp); raw != "HTTP/1.1 500 Internal Server Error\nContent-Type: text/plain\n" {
+		t.Errorf("Unexpected raw response:\n%s", raw)
+	}
+}
+
+func TestReplayFFUFRequest(t *testing.T) {
+	req := &ffuf.Request{Method: "PUT", Url: "https://api.example.com/users/1", Headers: map[string]string{"X-Token": "secret"}, Data: []byte(strings.Repeat("a", maxRawLength))}
+	raw, curl := replayFFUFRequest(req)
+	if !strings.HasPrefix(raw, "PUT /users/1 HTTP/1.1\nHost: api.example.com\nX-Token: secret\n\n") || !strings.HasSuffix(raw, "... (truncated)") {
+		t.Errorf("Expected a truncated raw request, got %s", raw[:80])
+	}
+	if !strings.HasPrefix(curl, "curl -i -X 'PUT' -H 'X-Token: secret' --data-raw 'aaa") {
+		t.Errorf("Unexpected curl command: %s", curl[:80])
+	}
+
+	resp := &ffuf.Response{StatusCode: 404, Headers: map[string][]string{"Server": {"test"}}, Data: []byte("not found")}
+	if raw := replayFFUFResponse(resp); raw != "HTTP/1.1 404 Not Found\nServer: test\n\nnot found" {
+		t.Errorf("Unexpected raw response:\n%s", raw)
+	}
+}
+
+func TestVulnerabilityReport_InteractiveHTML(t *testing.T) {
+	report := newTestReport()
+	output, err := report.Generate(FormatHTML)
+	if err != nil {
+		t.Fatalf("Generate returned an error: %s", err)
+	}
+	for _, expected := range []string{
+		`<table id="findings">`,
+		`<select class="filter" data-table="findings" data-field="severity">`,
+		`<tbody class="item" data-severity="Critical"`,
+		`data-copy="finding-0-curl"`,
+		`<pre class="mermaid-source">graph LR`,
+		"class n4 severityCritical",
+		"classDef severityCritical",
+		`<div class="diagram-image"><svg xmlns="http://www.w3.org/2000/svg"`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the HTML report", expected)
+		}
+	}
+	if strings.Contains(output, "<script src=") || strings.Contains(output, "<link ") {
+		t.Errorf("Expected the report to work offline")
+	}
+
+	// The Mermaid script is embedded, without closing its script element early
+	dir := t.TempDir()
+	script := filepath.Join(dir, "mermaid.min.js")
+	os.WriteFile(script, []byte(`window.mermaid = {}; var s = "</script>";`), 0644)
+	report.MermaidScript = script
+	output, err = report.Generate(FormatHTML)
+	if err != nil {
+		t.Fatalf("Generate returned an error: %s", err)
+	}
+	if !strings.Contains(output, `window.mermaid = {}; var s = "<\/script>";`) {
+		t.Errorf("Expected the Mermaid script to be embedded")
+	}
+	report.MermaidScript = filepath.Join(dir, "missing.js")
+	if _, err := report.Generate(FormatHTML); err == nil {
+		t.Errorf("Expected an error for a missing Mermaid script")
+	}
+}
+
+func TestCoverageAnalyzer_InteractiveHTML(t *testing.T) {
+	discovery := parser.NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.Endpoints = []*parser.DiscoveredEndpoint{
+		{Method: "GET", Path: "/users/{id}", Tags: []string{"users"}},
+		{Method: "GET", Path: "/health"},
+	}
+	analyzer := NewCoverageAnalyzer(&CoverageOptions{IncludeUntested: true, Format: FormatHTML})
+	analyzer.ImportFromDiscovery(discovery)
+	analyzer.RecordRequest(&ffuf.Request{Method: "GET", Url: "https://api.example.com/users/1"}, &ffuf.Response{StatusCode: 200})
+
+	output, err := analyzer.GenerateReport()
+	if err != nil {
+		t.Fatalf("GenerateReport returned an error: %s", err)
+	}
+	for _, expected := range []string{
+		`<table id="endpoints">`,
+		`<tbody class="item" data-status="tested" data-search="get /users/{id} users">`,
+		`data-copy="endpoint-1-curl"`,
+		"curl -i &#39;https://api.example.com/users/1&#39;",
+		"class n5 tested",
+		"classDef untested",
+		`<div class="diagram-image"><svg xmlns="http://www.w3.org/2000/svg"`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the HTML report", expected)
+		}
+	}
+}
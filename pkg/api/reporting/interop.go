@@ -0,0 +1,302 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+const (
+	// FormatBurp represents the Burp Suite issues XML format, supported by vulnerability reports
+	FormatBurp CoverageFormat = "burp"
+	// FormatHAR represents a HAR file of the requests of the findings, supported by
+	// vulnerability reports and importable by OWASP ZAP and Burp Suite
+	FormatHAR CoverageFormat = "har"
+)
+
+// burpExtensionIssueType is the type Burp Suite uses for issues reported by extensions
+const burpExtensionIssueType = 134217728
+
+// burpIssues is the root element of a Burp Suite issues export
+type burpIssues struct {
+	XMLName     xml.Name    `xml:"issues"`
+	BurpVersion string      `xml:"burpVersion,attr"`
+	ExportTime  string      `xml:"exportTime,attr"`
+	Issues      []burpIssue `xml:"issue"`
+}
+
+type burpIssue struct {
+	SerialNumber                 string               `xml:"serialNumber"`
+	Type                         int                  `xml:"type"`
+	Name                         string               `xml:"name"`
+	Host                         burpHost             `xml:"host"`
+	Path                         string               `xml:"path"`
+	Location                     string               `xml:"location"`
+	Severity                     string               `xml:"severity"`
+	Confidence                   string               `xml:"confidence"`
+	IssueBackground              string               `xml:"issueBackground,omitempty"`
+	RemediationBackground        string               `xml:"remediationBackground,omitempty"`
+	References                   string               `xml:"references,omitempty"`
+	VulnerabilityClassifications string               `xml:"vulnerabilityClassifications,omitempty"`
+	IssueDetail                  string               `xml:"issueDetail,omitempty"`
+	RequestResponse              *burpRequestResponse `xml:"requestresponse,omitempty"`
+}
+
+type burpHost struct {
+	IP   string `xml:"ip,attr"`
+	Name string `xml:",chardata"`
+}
+
+type burpRequestResponse struct {
+	Request  *burpMessage `xml:"request,omitempty"`
+	Response *burpMessage `xml:"response,omitempty"`
+}
+
+type burpMessage struct {
+	Method string `xml:"method,attr,omitempty"`
+	Base64 bool   `xml:"base64,attr"`
+	Data   string `xml:",chardata"`
+}
+
+// generateBurpReport generates the findings as a Burp Suite issues XML export
+func (r *VulnerabilityReport) generateBurpReport() (string, error) {
+	issues := burpIssues{
+		BurpVersion: "ffuf " + ffuf.Version(),
+		ExportTime:  r.GeneratedAt.Format("Mon Jan 02 15:04:05 MST 2006"),
+		Issues:      []burpIssue{},
+	}
+	first := r.firstOccurrences()
+	for i, finding := range r.Findings {
+		issue := burpIssue{
+			SerialNumber:          fmt.Sprintf("%d", i+1),
+			Type:                  burpExtensionIssueType,
+			Name:                  finding.Name,
+			Severity:              burpSeverity(finding.Severity),
+			Confidence:            "Certain",
+			IssueBackground:       finding.Description,
+			RemediationBackground: finding.Remediation,
+			IssueDetail:           burpIssueDetail(finding),
+		}
+		if u, err := url.Parse(finding.URL); err == nil && finding.URL != "" {
+			issue.Host = burpHost{Name: u.Scheme + "://" + u.Host}
+			issue.Path = u.EscapedPath()
+			issue.Location = u.RequestURI()
+		}
+		if finding.CWE != "" {
+			id := strings.TrimPrefix(strings.ToUpper(finding.CWE), "CWE-")
+			issue.VulnerabilityClassifications = fmt.Sprintf("<ul><li><a href=\"https://cwe.mitre.org/data/definitions/%s.html\">CWE-%s</a></li></ul>", html.EscapeString(id), html.EscapeString(id))
+		}
+		if len(finding.References) > 0 {
+			var refs strings.Builder
+			refs.WriteString("<ul>")
+			for _, ref := range finding.References {
+				fmt.Fprintf(&refs, "<li><a href=\"%s\">%s</a></li>", html.EscapeString(ref), html.EscapeString(ref))
+			}
+			refs.WriteString("</ul>")
+			issue.References = refs.String()
+		}
+		if occ, ok := first[finding]; ok {
+			issue.RequestResponse = burpExchange(occ)
+		}
+		issues.Issues = append(issues.Issues, issue)
+	}
+
+	data, err := xml.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		return "", api.NewAPIError("Failed to generate Burp Suite report: "+err.Error(), 0)
+	}
+	return xml.Header + string(data), nil
+}
+
+// burpSeverity maps a severity to a Burp Suite severity, which has no critical level
+func burpSeverity(severity string) string {
+	switch severity {
+	case "Critical", "High":
+		return "High"
+	case "Medium", "Low":
+		return severity
+	default:
+		return "Information"
+	}
+}
+
+// burpExchange returns the raw request and response of an occurrence, base64 encoded
+func burpExchange(occ occurrence) *burpRequestResponse {
+	exchange := &burpRequestResponse{}
+	if req := occ.vuln.Request; req != nil && req.URL != nil {
+		headers, body := requestParts(req)
+		headers.Set("Host", requestHost(req))
+		raw := fullRawHTTP(fmt.Sprintf("%s %s HTTP/1.1", req.Method, req.URL.RequestURI()), headers, body)
+		exchange.Request = &burpMessage{Method: req.Method, Base64: true, Data: base64.StdEncoding.EncodeToString([]byte(crlf(raw)))}
+	}
+	if resp := occ.vuln.Response; resp != nil {
+		exchange.Response = &burpMessage{Base64: true, Data: base64.StdEncoding.EncodeToString([]byte(crlf(fullRawHTTPResponse(resp))))}
+	}
+	if exchange.Request == nil && exchange.Response == nil {
+		return nil
+	}
+	return exchange
+}
+
+// crlf terminates the lines of the head of a raw HTTP message with CRLF
+func crlf(raw string) string {
+	parts := strings.SplitN(raw, "\n\n", 2)
+	head := strings.ReplaceAll(parts[0], "\n", "\r\n")
+	if len(parts) == 1 {
+		return head + "\r\n"
+	}
+	return head + "\r\n\r\n" + parts[1]
+}
+
+// burpIssueDetail describes a finding in the HTML Burp Suite renders issue details with
+func burpIssueDetail(finding *Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(finding.Evidence))
+	fmt.Fprintf(&b, "<p>Severity: %s (CVSS %.1f)", html.EscapeString(finding.Severity), finding.CVSS)
+	if finding.CVSSVector != "" {
+		fmt.Fprintf(&b, "<br>CVSS vector: %s", html.EscapeString(finding.CVSSVector))
+	}
+	fmt.Fprintf(&b, "<br>Found by: %s<br>Fingerprint: %s</p>", html.EscapeString(finding.Tester), finding.ID)
+	return b.String()
+}
+
+// firstOccurrences returns the first occurrence of every finding
+func (r *VulnerabilityReport) firstOccurrences() map[*Finding]occurrence {
+	first := make(map[*Finding]occurrence, len(r.Findings))
+	for _, occ := range r.occurrences {
+		if _, ok := first[occ.finding]; !ok {
+			first[occ.finding] = occ
+		}
+	}
+	return first
+}
+
+// harNameValue is a header or query parameter of a HAR entry
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// generateHARReport generates the requests and responses of the findings as a HAR file,
+// commented with the finding they expose, so that they can be imported into OWASP ZAP or
+// Burp Suite and replayed
+func (r *VulnerabilityReport) generateHARReport() (string, error) {
+	entries := []interface{}{}
+	first := r.firstOccurrences()
+	for _, finding := range r.Findings {
+		occ, ok := first[finding]
+		if !ok || occ.vuln.Request == nil || occ.vuln.Request.URL == nil {
+			continue
+		}
+		req := occ.vuln.Request
+		headers, body := requestParts(req)
+		headers.Set("Host", requestHost(req))
+		request := map[string]interface{}{
+			"method":      req.Method,
+			"url":         req.URL.String(),
+			"httpVersion": "HTTP/1.1",
+			"headers":     harHeaders(headers),
+			"queryString": harQuery(req.URL),
+			"cookies":     []interface{}{},
+			"headersSize": -1,
+			"bodySize":    len(body),
+		}
+		if len(body) > 0 {
+			request["postData"] = map[string]interface{}{"mimeType": headers.Get("Content-Type"), "text": string(body)}
+		}
+		response := map[string]interface{}{
+			"status":      0,
+			"statusText":  "",
+			"httpVersion": "HTTP/1.1",
+			"headers":     []harNameValue{},
+			"cookies":     []interface{}{},
+			"content":     map[string]interface{}{"size": 0, "mimeType": ""},
+			"redirectURL": "",
+			"headersSize": -1,
+			"bodySize":    -1,
+		}
+		if resp := occ.vuln.Response; resp != nil {
+			respBody := responseBody(resp)
+			response["status"] = resp.StatusCode
+			response["statusText"] = http.StatusText(resp.StatusCode)
+			response["headers"] = harHeaders(resp.Header)
+			response["content"] = map[string]interface{}{"size": len(respBody), "mimeType": resp.Header.Get("Content-Type"), "text": string(respBody)}
+			response["redirectURL"] = resp.Header.Get("Location")
+			response["bodySize"] = len(respBody)
+		}
+		entries = append(entries, map[string]interface{}{
+			"startedDateTime": finding.DetectedAt,
+			"time":            0,
+			"request":         request,
+			"response":        response,
+			"cache":           map[string]interface{}{},
+			"timings":         map[string]int{"send": 0, "wait": 0, "receive": 0},
+			"comment":         fmt.Sprintf("[%s] %s (%s)", finding.Severity, finding.Name, finding.ID),
+		})
+	}
+
+	har := map[string]interface{}{
+		"log": map[string]interface{}{
+			"version": "1.2",
+			"creator": map[string]string{"name": "ffuf", "version": ffuf.Version()},
+			"comment": fmt.Sprintf("Findings of the security scan of %s", r.Target),
+			"entries": entries,
+		},
+	}
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return "", api.NewAPIError("Failed to generate HAR report: "+err.Error(), 0)
+	}
+	return string(data), nil
+}
+
+// harHeaders returns headers as HAR name/value pairs, sorted by name
+func harHeaders(headers http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	for _, name := range sortedHeaderNames(headers) {
+		for _, value := range headers[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+// harQuery returns the query parameters of a URL as HAR name/value pairs
+func harQuery(u *url.URL) []harNameValue {
+	pairs := []harNameValue{}
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		parts := strings.SplitN(param, "=", 2)
+		name, _ := url.QueryUnescape(parts[0])
+		value := ""
+		if len(parts) == 2 {
+			value, _ = url.QueryUnescape(parts[1])
+		}
+		pairs = append(pairs, harNameValue{Name: name, Value: value})
+	}
+	return pairs
+}
+
+// responseBody returns the body of a response a security tester kept, restoring it so that
+// the response can be read again
+func responseBody(resp *http.Response) []byte {
+	if resp.Body == nil {
+		return nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
@@ -0,0 +1,82 @@
+package reporting
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestVulnerabilityReport_Burp(t *testing.T) {
+	report := newTestReport()
+	output, err := report.Generate(FormatBurp)
+	if err != nil {
+		t.Fatalf("Generate returned an error: %s", err)
+	}
+
+	var issues burpIssues
+	if err := xml.Unmarshal([]byte(output), &issues); err != nil {
+		t.Fatalf("Invalid Burp Suite XML: %s", err)
+	}
+	if len(issues.Issues) != 3 {
+		t.Fatalf("Expected 3 issues, got %d", len(issues.Issues))
+	}
+	issue := issues.Issues[0]
+	if issue.Name != "SQL Injection" || issue.Severity != "High" || issue.Host.Name != "https://api.example.com" || issue.Path != "/users" {
+		t.Errorf("Unexpected issue %+v", issue)
+	}
+	if !strings.Contains(issue.VulnerabilityClassifications, "CWE-89") {
+		t.Errorf("Expected the CWE to be classified, got %q", issue.VulnerabilityClassifications)
+	}
+	if issue.RequestResponse == nil || issue.RequestResponse.Request == nil {
+		t.Fatalf("Expected the request of the issue")
+	}
+	request, err := base64.StdEncoding.DecodeString(issue.RequestResponse.Request.Data)
+	if err != nil || !strings.HasPrefix(string(request), "GET /users?id=%27+OR+1%3D1 HTTP/1.1\r\n") || !strings.Contains(string(request), "Host: api.example.com\r\n") {
+		t.Errorf("Unexpected raw request %q", request)
+	}
+	if issues.Issues[2].Severity != "Low" {
+		t.Errorf("Expected the low severity to be kept, got %s", issues.Issues[2].Severity)
+	}
+}
+
+func TestVulnerabilityReport_HAR(t *testing.T) {
+	report := newTestReport()
+	output, err := report.Generate(FormatHAR)
+	if err != nil {
+		t.Fatalf("Generate returned an error: %s", err)
+	}
+
+	var har struct {
+		Log struct {
+			Entries []struct {
+				Request struct {
+					Method      string         `json:"method"`
+					URL         string         `json:"url"`
+					QueryString []harNameValue `json:"queryString"`
+				} `json:"request"`
+				Response struct {
+					Status int `json:"status"`
+				} `json:"response"`
+				Comment string `json:"comment"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal([]byte(output), &har); err != nil {
+		t.Fatalf("Invalid HAR: %s", err)
+	}
+	if len(har.Log.Entries) != 3 {
+		t.Fatalf("Expected an entry per finding, got %d", len(har.Log.Entries))
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.Method != "GET" || entry.Response.Status != 500 {
+		t.Errorf("Unexpected entry %s %d", entry.Request.Method, entry.Response.Status)
+	}
+	if len(entry.Request.QueryString) != 1 || entry.Request.QueryString[0].Value != "' OR 1=1" {
+		t.Errorf("Unexpected query string %v", entry.Request.QueryString)
+	}
+	if !strings.HasPrefix(entry.Comment, "[Critical] SQL Injection (") {
+		t.Errorf("Expected the finding in the comment, got %q", entry.Comment)
+	}
+}
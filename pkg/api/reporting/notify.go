@@ -0,0 +1,395 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+)
+
+const (
+	// NotificationFinding is the event of a notification about a new finding
+	NotificationFinding = "finding"
+	// NotificationSummary is the event of a notification summarizing a completed scan
+	NotificationSummary = "summary"
+)
+
+// DefaultFindingTemplate is the text/template of finding notifications
+const DefaultFindingTemplate = `[{{.Finding.Severity}}] {{.Finding.Name}}{{if .Finding.URL}} on {{.Finding.Method}} {{.Finding.URL}}{{end}}
+{{.Finding.Description}}
+CVSS {{printf "%.1f" .Finding.CVSS}}{{if .Finding.CWE}}, {{.Finding.CWE}}{{end}}, found by {{.Finding.Tester}}`
+
+// DefaultSummaryTemplate is the text/template of summary notifications
+const DefaultSummaryTemplate = `Scan of {{.Target}} completed with {{.Findings}} finding(s)
+{{range $i, $s := .Severities}}{{if $i}}, {{end}}{{$s.Severity}}: {{$s.Count}}{{end}}`
+
+// severityColors are the colors of the severities in reports and notifications
+var severityColors = map[string]string{
+	"Critical": "#7b1fa2",
+	"High":     "#d32f2f",
+	"Medium":   "#f57c00",
+	"Low":      "#fbc02d",
+	"Info":     "#1976d2",
+}
+
+// Notification is a finding or a scan summary sent to notification sinks
+type Notification struct {
+	// Event is NotificationFinding or NotificationSummary
+	Event string `json:"event"`
+	// Target is the scanned target
+	Target string `json:"target"`
+	// Time is the time the notification was created
+	Time time.Time `json:"time"`
+	// Text is the notification rendered with the template of the event
+	Text string `json:"text"`
+	// Finding is the new finding of a finding notification
+	Finding *Finding `json:"finding,omitempty"`
+	// Findings is the number of findings of a summary notification
+	Findings int `json:"findings,omitempty"`
+	// Severities are the number of findings per severity of a summary notification
+	Severities []SeverityCount `json:"severities,omitempty"`
+	// Testers summarizes the runs of the security testers of a summary notification
+	Testers []TesterSummary `json:"testers,omitempty"`
+}
+
+// SeverityCount is the number of findings of a severity
+type SeverityCount struct {
+	Severity string `json:"severity"`
+	Count    int    `json:"count"`
+}
+
+// NotificationSink delivers notifications to a chat or an HTTP endpoint
+type NotificationSink interface {
+	// Name returns the name of the sink
+	Name() string
+	// Send delivers a notification
+	Send(notification *Notification) error
+}
+
+// Notifier sends findings to notification sinks as they are found, and a summary once the
+// scan completes. Findings are sent once, and only from the minimum severity.
+type Notifier struct {
+	// Target is the scanned target
+	Target string
+	// Sinks receive the notifications
+	Sinks []NotificationSink
+	// MinimumSeverity is the minimum severity of the findings sent
+	MinimumSeverity string
+	// FindingTemplate renders the text of finding notifications
+	FindingTemplate *template.Template
+	// SummaryTemplate renders the text of summary notifications
+	SummaryTemplate *template.Template
+	// OnError is called when a sink fails to deliver a notification
+	OnError func(sink NotificationSink, err error)
+	// Redactor masks the tokens and personal data of the findings sent, nil to send them as is
+	Redactor *secrets.Redactor
+	sent     map[string]bool
+	mu       sync.Mutex
+}
+
+// NewNotifier creates a notifier sending the findings of a target to sinks
+func NewNotifier(target string, sinks ...NotificationSink) *Notifier {
+	return &Notifier{
+		Target:          target,
+		Sinks:           sinks,
+		MinimumSeverity: "Info",
+		FindingTemplate: template.Must(template.New("finding").Parse(DefaultFindingTemplate)),
+		SummaryTemplate: template.Must(template.New("summary").Parse(DefaultSummaryTemplate)),
+		sent:            make(map[string]bool),
+	}
+}
+
+// SetTemplates parses the text/templates of finding and summary notifications. An empty
+// template keeps the current one.
+func (n *Notifier) SetTemplates(finding, summary string) error {
+	if finding != "" {
+		tmpl, err := template.New("finding").Parse(finding)
+		if err != nil {
+			return api.NewAPIError("Invalid finding notification template: "+err.Error(), 0)
+		}
+		n.FindingTemplate = tmpl
+	}
+	if summary != "" {
+		tmpl, err := template.New("summary").Parse(summary)
+		if err != nil {
+			return api.NewAPIError("Invalid summary notification template: "+err.Error(), 0)
+		}
+		n.SummaryTemplate = tmpl
+	}
+	return nil
+}
+
+// HandleResult sends the findings of a tester result. It is a security.ResultHandler, so that
+// findings are sent as each tester completes.
+func (n *Notifier) HandleResult(result *security.TestResult) {
+	for _, vuln := range result.Vulnerabilities {
+		finding := newFinding(result.TestName, vuln)
+		finding.redact(n.Redactor)
+		n.NotifyFinding(finding)
+	}
+}
+
+// NotifyFinding sends a finding unless it was already sent or is below the minimum severity
+func (n *Notifier) NotifyFinding(finding *Finding) error {
+	if severityRank(finding.Severity) > severityRank(n.MinimumSeverity) {
+		return nil
+	}
+	n.mu.Lock()
+	if n.sent[finding.ID] {
+		n.mu.Unlock()
+		return nil
+	}
+	n.sent[finding.ID] = true
+	n.mu.Unlock()
+
+	return n.send(&Notification{
+		Event:   NotificationFinding,
+		Target:  n.Target,
+		Time:    time.Now(),
+		Finding: finding,
+	}, n.FindingTemplate)
+}
+
+// NotifySummary sends the summary of a completed scan
+func (n *Notifier) NotifySummary(report *VulnerabilityReport) error {
+	counts := report.SeverityCounts()
+	severities := make([]SeverityCount, 0, len(severityOrder))
+	for _, severity := range severityOrder {
+		severities = append(severities, SeverityCount{Severity: severity, Count: counts[severity]})
+	}
+	return n.send(&Notification{
+		Event:      NotificationSummary,
+		Target:     n.Target,
+		Time:       time.Now(),
+		Findings:   len(report.Findings),
+		Severities: severities,
+		Testers:    report.Testers,
+	}, n.SummaryTemplate)
+}
+
+// send renders a notification and delivers it to every sink, returning the first error
+func (n *Notifier) send(notification *Notification, tmpl *template.Template) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, notification); err != nil {
+		return api.NewAPIError("Failed to render notification: "+err.Error(), 0)
+	}
+	notification.Text = strings.TrimSpace(buf.String())
+
+	var first error
+	for _, sink := range n.Sinks {
+		if err := sink.Send(notification); err != nil {
+			if n.OnError != nil {
+				n.OnError(sink, err)
+			}
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// WebhookSink posts notifications as JSON to an HTTP endpoint
+type WebhookSink struct {
+	// URL is the URL of the endpoint
+	URL string
+	// Headers are added to the requests, e.g. for authentication
+	Headers map[string]string
+	// Client is the HTTP client used for the requests
+	Client *http.Client
+}
+
+// NewWebhookSink creates a sink posting notifications to a URL
+func NewWebhookSink(target string) *WebhookSink {
+	return &WebhookSink{
+		URL:     target,
+		Headers: make(map[string]string),
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the name of the sink
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Send posts the notification
+func (s *WebhookSink) Send(notification *Notification) error {
+	return postNotification(s.Client, s.URL, s.Headers, notification)
+}
+
+// SlackSink posts notifications to a Slack incoming webhook
+type SlackSink struct {
+	// WebhookURL is the URL of the incoming webhook
+	WebhookURL string
+	// Client is the HTTP client used for the requests
+	Client *http.Client
+}
+
+// NewSlackSink creates a sink posting notifications to a Slack incoming webhook
+func NewSlackSink(webhookURL string) *SlackSink {
+	return &SlackSink{
+		WebhookURL: webhookURL,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the name of the sink
+func (s *SlackSink) Name() string {
+	return "Slack"
+}
+
+// Send posts the notification, colored by the severity of its finding
+func (s *SlackSink) Send(notification *Notification) error {
+	title, text := splitNotification(notification.Text)
+	payload := map[string]interface{}{
+		"text": title,
+		"attachments": []map[string]interface{}{
+			{"color": notificationColor(notification), "text": text},
+		},
+	}
+	return postNotification(s.Client, s.WebhookURL, nil, payload)
+}
+
+// TeamsSink posts notifications as Adaptive Cards to a Microsoft Teams webhook
+type TeamsSink struct {
+	// WebhookURL is the URL of the incoming webhook or workflow
+	WebhookURL string
+	// Client is the HTTP client used for the requests
+	Client *http.Client
+}
+
+// NewTeamsSink creates a sink posting notifications to a Microsoft Teams webhook
+func NewTeamsSink(webhookURL string) *TeamsSink {
+	return &TeamsSink{
+		WebhookURL: webhookURL,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the name of the sink
+func (s *TeamsSink) Name() string {
+	return "Teams"
+}
+
+// Send posts the notification
+func (s *TeamsSink) Send(notification *Notification) error {
+	title, text := splitNotification(notification.Text)
+	color := "Default"
+	if notification.Finding != nil {
+		switch notification.Finding.Severity {
+		case "Critical", "High":
+			color = "Attention"
+		case "Medium", "Low":
+			color = "Warning"
+		}
+	}
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": title, "weight": "Bolder", "size": "Medium", "color": color, "wrap": true},
+	}
+	if text != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": text, "wrap": true})
+	}
+	payload := map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]interface{}{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}
+	return postNotification(s.Client, s.WebhookURL, nil, payload)
+}
+
+// NewNotificationSink creates a sink from a specification in the form kind=URL, where kind is
+// webhook, slack or teams. Without a kind, Slack and Teams are recognized by the host of the URL.
+func NewNotificationSink(spec string) (NotificationSink, error) {
+	kind, target := "", spec
+	if parts := strings.SplitN(spec, "=", 2); len(parts) == 2 && !strings.Contains(parts[0], "/") {
+		kind, target = strings.ToLower(parts[0]), parts[1]
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, api.NewAPIError("Invalid notification URL: "+target, 0)
+	}
+	if kind == "" {
+		switch {
+		case u.Hostname() == "hooks.slack.com":
+			kind = "slack"
+		case strings.HasSuffix(u.Hostname(), ".webhook.office.com") || strings.HasSuffix(u.Hostname(), ".logic.azure.com"):
+			kind = "teams"
+		default:
+			kind = "webhook"
+		}
+	}
+	switch kind {
+	case "webhook":
+		return NewWebhookSink(target), nil
+	case "slack":
+		return NewSlackSink(target), nil
+	case "teams":
+		return NewTeamsSink(target), nil
+	default:
+		return nil, api.NewAPIError(fmt.Sprintf("Unknown notification sink %s, expected webhook, slack or teams", kind), 0)
+	}
+}
+
+// splitNotification splits the text of a notification into its first line and the rest
+func splitNotification(text string) (string, string) {
+	parts := strings.SplitN(text, "\n", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+// notificationColor returns the color of the severity of a finding notification
+func notificationColor(notification *Notification) string {
+	if notification.Finding != nil {
+		if color, ok := severityColors[notification.Finding.Severity]; ok {
+			return color
+		}
+	}
+	return "#4CAF50"
+}
+
+// postNotification posts a JSON payload and checks that it was accepted
+func postNotification(client *http.Client, target string, headers map[string]string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return api.NewAPIError("Failed to encode notification: "+err.Error(), 0)
+	}
+	req, err := http.NewRequest("POST", target, bytes.NewReader(data))
+	if err != nil {
+		return api.NewAPIError("Failed to create notification request: "+err.Error(), 0)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Notification to %s failed: %s", req.URL.Host, err), 0)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return api.NewAPIError(fmt.Sprintf("Notification to %s failed: %s", req.URL.Host, strings.TrimSpace(string(body))), resp.StatusCode)
+	}
+	return nil
+}
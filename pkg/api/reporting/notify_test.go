@@ -0,0 +1,198 @@
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// notificationServer records the JSON payloads posted to it
+type notificationServer struct {
+	*httptest.Server
+	payloads []map[string]interface{}
+	headers  []http.Header
+	mu       sync.Mutex
+}
+
+func newNotificationServer(status int) *notificationServer {
+	s := &notificationServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		s.mu.Lock()
+		s.payloads = append(s.payloads, payload)
+		s.headers = append(s.headers, r.Header)
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	return s
+}
+
+func TestNotifier(t *testing.T) {
+	webhook := newNotificationServer(http.StatusOK)
+	defer webhook.Close()
+	slack := newNotificationServer(http.StatusOK)
+	defer slack.Close()
+
+	webhookSink := NewWebhookSink(webhook.URL)
+	webhookSink.Headers["Authorization"] = "Bearer secret"
+	notifier := NewNotifier("https://api.example.com", webhookSink, NewSlackSink(slack.URL))
+	notifier.MinimumSeverity = "High"
+
+	// Findings are sent once, from the minimum severity
+	result := newTestReport()
+	notifier.HandleResult(&security.TestResult{TestName: "Injection", Vulnerabilities: []security.VulnerabilityInfo{
+		newTestVulnerability("SQL Injection", "Critical", 9.8, "https://api.example.com/users?id=1"),
+		newTestVulnerability("SQL Injection", "Critical", 9.8, "https://api.example.com/users?id=2"),
+		newTestVulnerability("Verbose Errors", "Low", 3.1, "https://api.example.com/users"),
+		newTestVulnerability("Command Injection", "High", 8.8, "https://api.example.com/ping?host=x"),
+	}})
+	if len(webhook.payloads) != 2 || len(slack.payloads) != 2 {
+		t.Fatalf("Expected 2 findings to be sent to each sink, got %d and %d", len(webhook.payloads), len(slack.payloads))
+	}
+	first := webhook.payloads[0]
+	if first["event"] != NotificationFinding || first["finding"].(map[string]interface{})["name"] != "SQL Injection" {
+		t.Errorf("Expected the finding in the webhook payload, got %v", first)
+	}
+	if !strings.HasPrefix(first["text"].(string), "[Critical] SQL Injection on GET https://api.example.com/users?id=1") {
+		t.Errorf("Unexpected notification text: %s", first["text"])
+	}
+	if webhook.headers[0].Get("Authorization") != "Bearer secret" {
+		t.Errorf("Expected the headers of the webhook sink")
+	}
+	attachment := slack.payloads[1]["attachments"].([]interface{})[0].(map[string]interface{})
+	if slack.payloads[1]["text"] != "[High] Command Injection on GET https://api.example.com/ping?host=x" || attachment["color"] != severityColors["High"] {
+		t.Errorf("Unexpected Slack payload: %v", slack.payloads[1])
+	}
+
+	if err := notifier.SetTemplates("", "{{.Findings}} findings on {{.Target}}{{range .Severities}}{{if .Count}} {{.Severity}}={{.Count}}{{end}}{{end}}"); err != nil {
+		t.Fatalf("SetTemplates returned an error: %s", err)
+	}
+	if err := notifier.NotifySummary(result); err != nil {
+		t.Fatalf("NotifySummary returned an error: %s", err)
+	}
+	summary := webhook.payloads[2]
+	if summary["event"] != NotificationSummary || summary["text"] != "3 findings on https://api.example.com Critical=1 High=1 Low=1" {
+		t.Errorf("Unexpected summary: %v", summary)
+	}
+
+	if err := notifier.SetTemplates("{{.Finding.Name", ""); err == nil {
+		t.Errorf("Expected an error for an invalid template")
+	}
+}
+
+// streamingTester returns a vulnerability once its start channel is closed
+type streamingTester struct {
+	vulnType security.VulnerabilityType
+	start    chan struct{}
+}
+
+func (s *streamingTester) Test(ctx context.Context, config *ffuf.Config) (*security.TestResult, error) {
+	select {
+	case <-s.start:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &security.TestResult{TestName: s.GetName(), Vulnerabilities: []security.VulnerabilityInfo{
+		newTestVulnerability(s.GetName()+" finding", "High", 8.0, config.Url),
+	}}, nil
+}
+
+func (s *streamingTester) GetType() security.VulnerabilityType { return s.vulnType }
+func (s *streamingTester) GetName() string                     { return s.vulnType.String() }
+func (s *streamingTester) GetDescription() string              { return "" }
+
+func TestNotifier_Streaming(t *testing.T) {
+	webhook := newNotificationServer(http.StatusOK)
+	defer webhook.Close()
+	notifier := NewNotifier("https://api.example.com", NewWebhookSink(webhook.URL))
+
+	// The second tester only completes once the finding of the first one was sent
+	first := &streamingTester{vulnType: security.VulnInjection, start: make(chan struct{})}
+	second := &streamingTester{vulnType: security.VulnSSRF, start: make(chan struct{})}
+	close(first.start)
+	handled := 0
+	handler := func(result *security.TestResult) {
+		notifier.HandleResult(result)
+		if handled++; handled == 1 {
+			close(second.start)
+		}
+	}
+
+	registry := security.NewSecurityTestRegistry()
+	registry.Register(first)
+	registry.Register(second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = "https://api.example.com/users"
+	results, err := registry.RunAll(security.WithResultHandler(ctx, handler), &conf)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Expected the results of both testers, got %d: %v", len(results), err)
+	}
+	if len(webhook.payloads) != 2 || !strings.Contains(webhook.payloads[0]["text"].(string), first.GetName()) {
+		t.Errorf("Expected the findings to be sent as the testers complete")
+	}
+}
+
+func TestNotifier_Errors(t *testing.T) {
+	failing := newNotificationServer(http.StatusForbidden)
+	defer failing.Close()
+	teams := newNotificationServer(http.StatusAccepted)
+	defer teams.Close()
+
+	notifier := NewNotifier("https://api.example.com", NewWebhookSink(failing.URL), NewTeamsSink(teams.URL))
+	failed := make([]string, 0)
+	notifier.OnError = func(sink NotificationSink, err error) {
+		failed = append(failed, sink.Name())
+	}
+	report := newTestReport()
+	if err := notifier.NotifyFinding(report.Findings[0]); err == nil {
+		t.Errorf("Expected the error of the failing sink")
+	}
+	if len(failed) != 1 || failed[0] != "webhook" {
+		t.Errorf("Expected the failing sink to be reported, got %v", failed)
+	}
+	if len(teams.payloads) != 1 {
+		t.Fatalf("Expected the other sinks to receive the notification")
+	}
+	content := teams.payloads[0]["attachments"].([]interface{})[0].(map[string]interface{})["content"].(map[string]interface{})
+	title := content["body"].([]interface{})[0].(map[string]interface{})
+	if content["type"] != "AdaptiveCard" || title["color"] != "Attention" || !strings.Contains(title["text"].(string), "SQL Injection") {
+		t.Errorf("Unexpected Teams card: %v", content)
+	}
+}
+
+func TestNewNotificationSink(t *testing.T) {
+	tests := []struct {
+		spec string
+		name string
+	}{
+		{"https://hooks.slack.com/services/T000/B000/XXX", "Slack"},
+		{"https://example.webhook.office.com/webhookb2/abc", "Teams"},
+		{"https://hooks.example.com/ffuf", "webhook"},
+		{"slack=https://chat.example.com/hooks/1", "Slack"},
+		{"teams=https://prod-01.westus.logic.azure.com/workflows/1", "Teams"},
+		{"webhook=https://hooks.slack.com/services/T000/B000/XXX", "webhook"},
+		{"https://hooks.example.com/ffuf?token=a=b", "webhook"},
+	}
+	for _, test := range tests {
+		sink, err := NewNotificationSink(test.spec)
+		if err != nil || sink.Name() != test.name {
+			t.Errorf("Expected a %s sink for %s, got %v", test.name, test.spec, err)
+		}
+	}
+	for _, spec := range []string{"email=https://example.com", "ftp://example.com", "slack=hooks.slack.com"} {
+		if _, err := NewNotificationSink(spec); err == nil {
+			t.Errorf("Expected an error for %s", spec)
+		}
+	}
+}
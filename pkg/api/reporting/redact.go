@@ -0,0 +1,84 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+)
+
+// Redact masks the tokens and personal data of the findings of the report, and of the
+// requests and responses of their occurrences, so that the reports, evidence bundles and
+// baselines written afterwards do not contain them. The IDs of the findings are kept, so
+// that redacted reports still compare with baselines.
+func (r *VulnerabilityReport) Redact(redactor *secrets.Redactor) {
+	if redactor == nil {
+		return
+	}
+	for _, finding := range r.Findings {
+		finding.redact(redactor)
+	}
+	for i := range r.occurrences {
+		vuln := &r.occurrences[i].vuln
+		vuln.Evidence = redactor.String(vuln.Evidence)
+		vuln.Description = redactor.String(vuln.Description)
+		if vuln.Request != nil {
+			vuln.Request = redactHTTPRequest(redactor, vuln.Request)
+		}
+		if vuln.Response != nil {
+			vuln.Response = redactHTTPResponse(redactor, vuln.Response)
+		}
+	}
+}
+
+// redact masks the tokens and personal data of a finding
+func (f *Finding) redact(redactor *secrets.Redactor) {
+	if redactor == nil {
+		return
+	}
+	f.Description = redactor.String(f.Description)
+	f.URL = redactor.String(f.URL)
+	f.Request = redactor.String(f.Request)
+	f.Response = redactor.String(f.Response)
+	f.Curl = redactor.String(f.Curl)
+	f.Evidence = redactor.String(f.Evidence)
+}
+
+// redactHTTPRequest returns a copy of a request found by a security tester with its URL,
+// headers and body masked
+func redactHTTPRequest(redactor *secrets.Redactor, req *http.Request) *http.Request {
+	redacted := req.Clone(context.Background())
+	redacted.Header = redactor.Header(req.Header)
+	if req.URL != nil {
+		if u, err := url.Parse(redactor.String(req.URL.String())); err == nil {
+			redacted.URL = u
+		}
+	}
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ := io.ReadAll(rc)
+			rc.Close()
+			body = redactor.Bytes(body)
+			redacted.Body = io.NopCloser(bytes.NewReader(body))
+			redacted.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+			redacted.ContentLength = int64(len(body))
+		}
+	}
+	return redacted
+}
+
+// redactHTTPResponse returns a copy of a response found by a security tester with its headers
+// and the body the tester kept masked
+func redactHTTPResponse(redactor *secrets.Redactor, resp *http.Response) *http.Response {
+	redacted := *resp
+	redacted.Header = redactor.Header(resp.Header)
+	if resp.Body != nil {
+		redacted.Body = io.NopCloser(bytes.NewReader(redactor.Bytes(responseBody(resp))))
+	}
+	return &redacted
+}
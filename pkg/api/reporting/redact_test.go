@@ -0,0 +1,97 @@
+package reporting
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+)
+
+func TestVulnerabilityReport_Redact(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://api.example.com/users?api_key=k3y", strings.NewReader(`{"email":"alice@example.com"}`))
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	req.Header.Set("Cookie", "session=0123456789")
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Set-Cookie": {"session=9876543210; HttpOnly"}},
+		Body:       io.NopCloser(strings.NewReader(`{"email":"alice@example.com","card":"4111-1111-1111-1111"}`)),
+	}
+	results := []*security.TestResult{{
+		TestName: "Data Exposure",
+		Vulnerabilities: []security.VulnerabilityInfo{{
+			Type: security.VulnExcessiveDataExposure, Name: "Excessive Data Exposure", Severity: "High", CVSS: 7.5,
+			Evidence: "The response exposes alice@example.com", Request: req, Response: resp, DetectedAt: time.Now(),
+		}},
+	}}
+	report := NewVulnerabilityReport("https://api.example.com", results)
+	id := report.Findings[0].ID
+
+	redactor, err := secrets.NewRedactor([]string{"all"}, nil)
+	if err != nil {
+		t.Fatalf("NewRedactor returned an error: %s", err)
+	}
+	report.Redact(redactor)
+	if report.Findings[0].ID != id {
+		t.Errorf("Expected the finding ID to be kept, got %s instead of %s", report.Findings[0].ID, id)
+	}
+
+	leaked := []string{"k3y", "s3cr3t", "0123456789", "9876543210", "alice@example.com", "4111-1111-1111-1111"}
+	outputs := make(map[string]string)
+	for _, format := range []CoverageFormat{FormatJSON, FormatHTML, FormatBurp, FormatHAR} {
+		generated, err := report.Generate(format)
+		if err != nil {
+			t.Fatalf("Generate(%s) returned an error: %s", format, err)
+		}
+		outputs[string(format)] = generated
+	}
+	dir := filepath.Join(t.TempDir(), "evidence")
+	bundles, err := report.WriteEvidenceBundles(dir)
+	if err != nil || len(bundles) != 1 {
+		t.Fatalf("WriteEvidenceBundles returned %d bundles and %v", len(bundles), err)
+	}
+	for name := range bundles[0].Files {
+		data, _ := os.ReadFile(filepath.Join(bundles[0].Dir, name))
+		outputs[name] = string(data)
+	}
+	for name, output := range outputs {
+		for _, secret := range leaked {
+			if strings.Contains(output, secret) {
+				t.Errorf("Expected %q to be redacted from %s", secret, name)
+			}
+		}
+	}
+	if !strings.Contains(outputs["request.txt"], "Authorization: Bearer [REDACTED]") || !strings.Contains(outputs["response.txt"], `"email":"[REDACTED]"`) {
+		t.Errorf("Unexpected redacted evidence:\n%s\n%s", outputs["request.txt"], outputs["response.txt"])
+	}
+
+	// The requests and responses of the testers are left as is
+	if req.Header.Get("Authorization") != "Bearer s3cr3t" {
+		t.Errorf("Expected the original request to be kept")
+	}
+	if body, _ := io.ReadAll(resp.Body); !strings.Contains(string(body), "alice@example.com") {
+		t.Errorf("Expected the original response body to be kept, got %s", body)
+	}
+}
+
+func TestEventLog_Redactor(t *testing.T) {
+	var buf strings.Builder
+	log := NewEventLog(&buf)
+	log.Redactor, _ = secrets.NewRedactor([]string{"authorization", "emails"}, nil)
+	req, _ := http.NewRequest("GET", "https://api.example.com/users?token=abc&email=bob@example.org", nil)
+	log.ResultHandler("https://api.example.com")(&security.TestResult{
+		TestName: "Data Exposure",
+		Vulnerabilities: []security.VulnerabilityInfo{{
+			Type: security.VulnExcessiveDataExposure, Name: "Excessive Data Exposure", Severity: "High",
+			Evidence: "bob@example.org", Request: req, DetectedAt: time.Now(),
+		}},
+	})
+	if strings.Contains(buf.String(), "bob@example.org") || strings.Contains(buf.String(), "token=abc") {
+		t.Errorf("Expected the finding event to be redacted: %s", buf.String())
+	}
+}
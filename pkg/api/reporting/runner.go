@@ -0,0 +1,43 @@
+package reporting
+
+import (
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// CoverageRunner wraps a runner and records every executed request in a coverage analyzer
+type CoverageRunner struct {
+	// Analyzer records the executed requests against the imported endpoints
+	Analyzer *CoverageAnalyzer
+	runner   ffuf.RunnerProvider
+}
+
+// NewCoverageRunner creates a runner recording the requests executed by a runner
+func NewCoverageRunner(analyzer *CoverageAnalyzer, r ffuf.RunnerProvider) *CoverageRunner {
+	return &CoverageRunner{
+		Analyzer: analyzer,
+		runner:   r,
+	}
+}
+
+// Prepare prepares a request using the underlying runner
+func (r *CoverageRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return r.runner.Prepare(input, basereq)
+}
+
+// Dump dumps a request using the underlying runner
+func (r *CoverageRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return r.runner.Dump(req)
+}
+
+// Execute executes a request using the underlying runner and records it if a response was
+// received, or records its failure. Requests denied by the scope policy or the safe mode are
+// not recorded.
+func (r *CoverageRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	resp, err := r.runner.Execute(req)
+	if err == nil {
+		r.Analyzer.RecordRequest(req, &resp)
+	} else if !ffuf.IsPolicyViolation(err) && !ffuf.IsSafeModeViolation(err) {
+		r.Analyzer.RecordFailure(req, err)
+	}
+	return resp, err
+}
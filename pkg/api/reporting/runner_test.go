@@ -0,0 +1,91 @@
+package reporting
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// staticRunner answers requests with a status code, or fails them
+type staticRunner struct {
+	status int64
+	fail   bool
+	err    error
+}
+
+func (r *staticRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	req := *basereq
+	req.Url = basereq.Url + string(input["FUZZ"])
+	return req, nil
+}
+
+func (r *staticRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	if r.err != nil {
+		return ffuf.Response{}, r.err
+	}
+	if r.fail {
+		return ffuf.Response{}, fmt.Errorf("connection refused")
+	}
+	return ffuf.Response{StatusCode: r.status, Request: req}, nil
+}
+
+func (r *staticRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return []byte(req.Url), nil
+}
+
+func TestCoverageRunner(t *testing.T) {
+	discovery := parser.NewAPIEndpointDiscovery("")
+	discovery.Endpoints = []*parser.DiscoveredEndpoint{
+		{Method: "GET", Path: "/items/{itemId}"},
+		{Method: "GET", Path: "/health"},
+	}
+	analyzer := NewCoverageAnalyzer(nil)
+	analyzer.ImportFromDiscovery(discovery)
+
+	upstream := &staticRunner{status: 404}
+	runner := NewCoverageRunner(analyzer, upstream)
+	base := &ffuf.Request{Method: "GET", Url: "https://shop.example.com/items/"}
+	for _, word := range []string{"1", "2", "abc"} {
+		req, err := runner.Prepare(map[string][]byte{"FUZZ": []byte(word)}, base)
+		if err != nil {
+			t.Fatalf("Prepare returned an error: %s", err)
+		}
+		if resp, err := runner.Execute(&req); err != nil || resp.StatusCode != 404 {
+			t.Fatalf("Expected the response of the underlying runner, got %d: %v", resp.StatusCode, err)
+		}
+	}
+
+	// Requests without a response mark their endpoint as failed, without exercising it
+	upstream.fail = true
+	req := ffuf.Request{Method: "GET", Url: "https://shop.example.com/health"}
+	if _, err := runner.Execute(&req); err == nil {
+		t.Errorf("Expected the error of the underlying runner")
+	}
+
+	stats := analyzer.GetCoverageStats()
+	if stats["exercised_endpoints"] != 1 || stats["exercised_coverage"] != 50.0 {
+		t.Errorf("Expected half of the endpoints to be exercised, got %v", stats)
+	}
+	if endpoint := analyzer.endpoints["GET /items/{itemId}"]; endpoint.TestCount != 3 || endpoint.Status != StatusError {
+		t.Errorf("Expected the item endpoint to be requested 3 times with errors, got %d %s", endpoint.TestCount, endpoint.Status)
+	}
+	if endpoint := analyzer.endpoints["GET /health"]; endpoint.Status != StatusError || endpoint.FailedRequests != 1 || endpoint.LastError != "connection refused" || endpoint.TestCount != 0 {
+		t.Errorf("Expected the failed request to be recorded, got %+v", endpoint)
+	}
+	if stats["error_endpoints"] != 2 || stats["untested_endpoints"] != 0 {
+		t.Errorf("Expected both endpoints to have errors, got %v", stats)
+	}
+	report, _ := analyzer.generateTextReport()
+	if !strings.Contains(report, "Failed:     1 requests, last error: connection refused") {
+		t.Errorf("Expected the failed requests in the report:\n%s", report)
+	}
+
+	// Requests denied by the scope policy are not recorded
+	upstream.err = &ffuf.PolicyViolation{}
+	if _, err := runner.Execute(&req); err == nil || analyzer.endpoints["GET /health"].FailedRequests != 1 {
+		t.Errorf("Expected the policy violation not to be recorded")
+	}
+}
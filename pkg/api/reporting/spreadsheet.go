@@ -0,0 +1,338 @@
+package reporting
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+const (
+	// FormatCSV represents a CSV report format, with one row per endpoint or finding
+	FormatCSV CoverageFormat = "csv"
+	// FormatXLSX represents an Excel workbook report format. The report is binary.
+	FormatXLSX CoverageFormat = "xlsx"
+)
+
+// maxCellLength is the maximum number of characters of an Excel cell
+const maxCellLength = 32767
+
+// spreadsheetSheet is a named table of a workbook. The first row is the header.
+type spreadsheetSheet struct {
+	name string
+	rows [][]interface{}
+}
+
+var coverageColumns = []interface{}{"Method", "Path", "Status", "Tags", "Tests", "Errors", "Passed", "Failed", "Last Response", "Last Tested", "Tested Parameters", "Untested Parameters"}
+
+var findingColumns = []interface{}{"ID", "Severity", "CVSS", "Name", "Type", "Tester", "CWE", "Method", "URL", "Status Code", "Occurrences", "Detected At", "Description", "Evidence", "Remediation", "References", "CVSS Vector"}
+
+// generateCSVReport generates a CSV coverage report with one row per endpoint
+func (c *CoverageAnalyzer) generateCSVReport() (string, error) {
+	return generateCSV(c.coverageRows(c.getEndpointsForReport()))
+}
+
+// generateXLSXReport generates an Excel coverage workbook with a summary, all endpoints and
+// one sheet per tag
+func (c *CoverageAnalyzer) generateXLSXReport() (string, error) {
+	stats := c.GetCoverageStats()
+	endpoints := c.getEndpointsForReport()
+	sheets := []spreadsheetSheet{
+		{name: "Summary", rows: [][]interface{}{
+			{"Metric", "Value"},
+			{"Generated", stats["timestamp"]},
+			{"Total Endpoints", stats["total_endpoints"]},
+			{"Requested Endpoints", stats["exercised_endpoints"]},
+			{"Fully Tested", stats["tested_endpoints"]},
+			{"Partially Tested", stats["partial_endpoints"]},
+			{"Errors", stats["error_endpoints"]},
+			{"Untested", stats["untested_endpoints"]},
+			{"Endpoint Coverage (%)", roundPercent(stats["endpoint_coverage"].(float64))},
+			{"Requested Coverage (%)", roundPercent(stats["exercised_coverage"].(float64))},
+			{"Total Parameters", stats["total_parameters"]},
+			{"Tested Parameters", stats["tested_parameters"]},
+			{"Parameter Coverage (%)", roundPercent(stats["parameter_coverage"].(float64))},
+		}},
+		{name: "Endpoints", rows: c.coverageRows(endpoints)},
+	}
+
+	if c.options.GroupByTags {
+		byTag := make(map[string][]*EndpointCoverage)
+		for _, endpoint := range endpoints {
+			for _, tag := range endpoint.Tags {
+				byTag[tag] = append(byTag[tag], endpoint)
+			}
+		}
+		tags := make([]string, 0, len(byTag))
+		for tag := range byTag {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		for _, tag := range tags {
+			sheets = append(sheets, spreadsheetSheet{name: tag, rows: c.coverageRows(byTag[tag])})
+		}
+	}
+	return generateXLSX(sheets)
+}
+
+// coverageRows returns the header and a row per endpoint
+func (c *CoverageAnalyzer) coverageRows(endpoints []*EndpointCoverage) [][]interface{} {
+	rows := [][]interface{}{coverageColumns}
+	for _, endpoint := range endpoints {
+		tested := make([]string, 0)
+		untested := make([]string, 0)
+		for _, param := range endpoint.Parameters {
+			if param.Tested {
+				tested = append(tested, param.Name)
+			} else {
+				untested = append(untested, param.Name)
+			}
+		}
+		lastTested := ""
+		if !endpoint.LastTested.IsZero() {
+			lastTested = endpoint.LastTested.Format(time.RFC3339)
+		}
+		rows = append(rows, []interface{}{
+			endpoint.Method, endpoint.Path, string(endpoint.Status), strings.Join(endpoint.Tags, ", "),
+			endpoint.TestCount, endpoint.ErrorCount, endpoint.PassCount, endpoint.FailCount,
+			endpoint.ResponseStatus, lastTested, strings.Join(tested, ", "), strings.Join(untested, ", "),
+		})
+	}
+	return rows
+}
+
+// generateCSVReport generates a CSV vulnerability report with one row per finding
+func (r *VulnerabilityReport) generateCSVReport() (string, error) {
+	return generateCSV(findingRows(r.Findings))
+}
+
+// generateXLSXReport generates an Excel vulnerability workbook with a summary, all findings
+// and one sheet per severity
+func (r *VulnerabilityReport) generateXLSXReport() (string, error) {
+	counts := r.SeverityCounts()
+	summary := [][]interface{}{
+		{"Target", r.Target},
+		{"Generated", r.GeneratedAt.Format(time.RFC3339)},
+		{},
+		{"Severity", "Findings"},
+	}
+	for _, severity := range severityOrder {
+		summary = append(summary, []interface{}{severity, counts[severity]})
+	}
+	summary = append(summary, []interface{}{}, []interface{}{"Tester", "Vulnerabilities", "Duration", "Status", "Error"})
+	for _, tester := range r.Testers {
+		summary = append(summary, []interface{}{tester.Name, tester.Vulnerabilities, tester.Duration.String(), tester.Status(), tester.Error})
+	}
+
+	sheets := []spreadsheetSheet{
+		{name: "Summary", rows: summary},
+		{name: "Findings", rows: findingRows(r.Findings)},
+	}
+	for _, severity := range severityOrder {
+		findings := make([]*Finding, 0)
+		for _, finding := range r.Findings {
+			if finding.Severity == severity {
+				findings = append(findings, finding)
+			}
+		}
+		if len(findings) > 0 {
+			sheets = append(sheets, spreadsheetSheet{name: severity, rows: findingRows(findings)})
+		}
+	}
+	return generateXLSX(sheets)
+}
+
+// findingRows returns the header and a row per finding
+func findingRows(findings []*Finding) [][]interface{} {
+	rows := [][]interface{}{findingColumns}
+	for _, finding := range findings {
+		rows = append(rows, []interface{}{
+			finding.ID, finding.Severity, finding.CVSS, finding.Name, finding.Type, finding.Tester, finding.CWE,
+			finding.Method, finding.URL, finding.StatusCode, finding.Occurrences, finding.DetectedAt.Format(time.RFC3339),
+			finding.Description, finding.Evidence, finding.Remediation, strings.Join(finding.References, "\n"), finding.CVSSVector,
+		})
+	}
+	return rows
+}
+
+// roundPercent rounds a percentage to one decimal
+func roundPercent(value float64) float64 {
+	return math.Round(value*10) / 10
+}
+
+// generateCSV writes rows as CSV. Text cells starting with a formula character are prefixed
+// with a quote, so that payloads in evidence are not evaluated when opened in a spreadsheet.
+func generateCSV(rows [][]interface{}) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if text, ok := value.(string); ok {
+				if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+					text = "'" + text
+				}
+				record[i] = text
+			} else {
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return "", api.NewAPIError("Failed to generate CSV report: "+err.Error(), 0)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", api.NewAPIError("Failed to generate CSV report: "+err.Error(), 0)
+	}
+	return buf.String(), nil
+}
+
+// generateXLSX writes sheets as an Office Open XML workbook. Numbers are written as numeric
+// cells and everything else as inline strings, with a bold frozen header row.
+func generateXLSX(sheets []spreadsheetSheet) (string, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	names := make([]string, len(sheets))
+	used := make(map[string]bool)
+	for i, sheet := range sheets {
+		names[i] = xlsxSheetName(sheet.name, used)
+	}
+
+	var workbook, workbookRels, contentTypes strings.Builder
+	for i, name := range names {
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			contentTypes.String() + `</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + workbook.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			workbookRels.String() + `</Relationships>`},
+		{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+			`</styleSheet>`},
+	}
+	for i, sheet := range sheets {
+		files = append(files, struct {
+			name    string
+			content string
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxWorksheet(sheet.rows)})
+	}
+
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err == nil {
+			_, err = w.Write([]byte(file.content))
+		}
+		if err != nil {
+			return "", api.NewAPIError("Failed to generate XLSX report: "+err.Error(), 0)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return "", api.NewAPIError("Failed to generate XLSX report: "+err.Error(), 0)
+	}
+	return buf.String(), nil
+}
+
+// xlsxWorksheet returns the XML of a worksheet
+func xlsxWorksheet(rows [][]interface{}) string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	b.WriteString(`<sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		style := ""
+		if i == 0 {
+			style = ` s="1"`
+		}
+		for j, value := range row {
+			ref := fmt.Sprintf("%s%d", xlsxColumn(j), i+1)
+			switch v := value.(type) {
+			case int, int64, float64:
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%v</v></c>`, ref, style, v)
+			default:
+				text := fmt.Sprint(v)
+				if runes := []rune(text); len(runes) > maxCellLength {
+					text = string(runes[:maxCellLength])
+				}
+				fmt.Fprintf(&b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(text))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// xlsxColumn returns the letters of a zero-based column index
+func xlsxColumn(index int) string {
+	column := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		column = string(rune('A'+(index-1)%26)) + column
+	}
+	return column
+}
+
+// xlsxSheetName returns a valid and unique sheet name: at most 31 characters, without the
+// characters Excel rejects
+func xlsxSheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.Trim(name, "'"))
+	if name == "" {
+		name = "Sheet"
+	}
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	unique := name
+	for i := 2; used[strings.ToLower(unique)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		runes := []rune(name)
+		if len(runes)+len(suffix) > 31 {
+			runes = runes[:31-len(suffix)]
+		}
+		unique = string(runes) + suffix
+	}
+	used[strings.ToLower(unique)] = true
+	return unique
+}
+
+// xmlEscape escapes text for XML, replacing characters that are invalid in XML
+func xmlEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package reporting
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+)
+
+// readXLSX returns the sheet names and the content of each worksheet of a workbook
+func readXLSX(t *testing.T, report string) ([]string, map[string]string) {
+	archive, err := zip.NewReader(bytes.NewReader([]byte(report)), int64(len(report)))
+	if err != nil {
+		t.Fatalf("Expected a zip archive: %s", err)
+	}
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		f, _ := file.Open()
+		data, _ := io.ReadAll(f)
+		f.Close()
+		files[file.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in the workbook", name)
+		}
+	}
+	names := make([]string, 0)
+	for _, match := range regexp.MustCompile(`<sheet name="([^"]*)"`).FindAllStringSubmatch(files["xl/workbook.xml"], -1) {
+		names = append(names, match[1])
+	}
+	return names, files
+}
+
+func TestVulnerabilityReport_Spreadsheets(t *testing.T) {
+	report := newTestReport()
+	report.Findings[2].Evidence = "=HYPERLINK(\"http://evil.example.com\")"
+
+	output, err := report.Generate(FormatCSV)
+	if err != nil {
+		t.Fatalf("Generate returned an error: %s", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV: %s", err)
+	}
+	if len(records) != 4 || records[0][1] != "Severity" || records[1][3] != "SQL Injection" || records[1][10] != "2" {
+		t.Errorf("Expected a header and a row per finding, got %v", records)
+	}
+	if records[3][13] != "'=HYPERLINK(\"http://evil.example.com\")" {
+		t.Errorf("Expected formulas to be escaped, got %s", records[3][13])
+	}
+
+	output, err = report.Generate(FormatXLSX)
+	if err != nil {
+		t.Fatalf("Generate returned an error: %s", err)
+	}
+	names, files := readXLSX(t, output)
+	if strings.Join(names, ",") != "Summary,Findings,Critical,High,Low" {
+		t.Errorf("Expected a sheet per severity with findings, got %v", names)
+	}
+	if !strings.Contains(files["xl/worksheets/sheet3.xml"], "SQL Injection") || strings.Contains(files["xl/worksheets/sheet3.xml"], "Verbose Errors") {
+		t.Errorf("Expected only critical findings in the Critical sheet")
+	}
+	if !strings.Contains(files["xl/worksheets/sheet2.xml"], `<v>9.8</v>`) || !strings.Contains(files["xl/worksheets/sheet2.xml"], "=HYPERLINK(&#34;") {
+		t.Errorf("Expected numeric CVSS cells and escaped text cells")
+	}
+}
+
+func TestCoverageAnalyzer_Spreadsheets(t *testing.T) {
+	discovery := parser.NewAPIEndpointDiscovery("https://api.example.com")
+	discovery.Endpoints = []*parser.DiscoveredEndpoint{
+		{Method: "GET", Path: "/users", Tags: []string{"users"}},
+		{Method: "GET", Path: "/orders", Tags: []string{"orders/admin", "users"}, Parameters: []*parser.DiscoveredParameter{{Name: "status", In: "query"}}},
+		{Method: "GET", Path: "/health"},
+	}
+	analyzer := NewCoverageAnalyzer(&CoverageOptions{IncludeUntested: true, GroupByTags: true, Format: FormatXLSX})
+	analyzer.ImportFromDiscovery(discovery)
+	analyzer.RecordTest("GET", "/users", nil, nil)
+
+	output, err := analyzer.GenerateReport()
+	if err != nil {
+		t.Fatalf("GenerateReport returned an error: %s", err)
+	}
+	names, files := readXLSX(t, output)
+	if strings.Join(names, ",") != "Summary,Endpoints,orders_admin,users" {
+		t.Errorf("Expected a sheet per tag, got %v", names)
+	}
+	if strings.Count(files["xl/worksheets/sheet4.xml"], "<row ") != 3 {
+		t.Errorf("Expected the 2 endpoints of the users tag")
+	}
+
+	analyzer.options.Format = FormatCSV
+	output, err = analyzer.GenerateReport()
+	if err != nil {
+		t.Fatalf("GenerateReport returned an error: %s", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil || len(records) != 4 {
+		t.Fatalf("Expected a header and a row per endpoint, got %d: %v", len(records), err)
+	}
+}
+
+func TestXLSXSheetName(t *testing.T) {
+	used := make(map[string]bool)
+	names := []string{
+		xlsxSheetName("Summary", used),
+		xlsxSheetName("summary", used),
+		xlsxSheetName("a very long tag name exceeding the limit", used),
+		xlsxSheetName("a very long tag name exceeding the limit", used),
+		xlsxSheetName("'[admin]'", used),
+	}
+	expected := []string{"Summary", "summary (2)", "a very long tag name exceeding ", "a very long tag name exceed (2)", "_admin_"}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], names[i])
+		}
+	}
+	if xlsxColumn(0) != "A" || xlsxColumn(25) != "Z" || xlsxColumn(26) != "AA" || xlsxColumn(701) != "ZZ" {
+		t.Errorf("Unexpected column letters")
+	}
+}
@@ -0,0 +1,1008 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// FormatSARIF represents a SARIF 2.1.0 report format, supported by vulnerability reports
+const FormatSARIF CoverageFormat = "sarif"
+
+// severityOrder lists the severities from the most to the least severe
+var severityOrder = []string{"Critical", "High", "Medium", "Low", "Info"}
+
+// Finding is a deduplicated vulnerability found by a security tester
+type Finding struct {
+	// ID is a fingerprint of the finding, stable across scans
+	ID string `json:"id"`
+	// Type is the name of the vulnerability type
+	Type string `json:"type"`
+	// Tester is the name of the security tester that found the vulnerability
+	Tester string `json:"tester"`
+	// Name is the name of the vulnerability
+	Name string `json:"name"`
+	// Description describes the vulnerability
+	Description string `json:"description"`
+	// Severity is one of Critical, High, Medium, Low or Info
+	Severity string `json:"severity"`
+	// CVSS is the Common Vulnerability Scoring System score
+	CVSS float64 `json:"cvss"`
+	// CVSSVector is the CVSS v3.1 vector the score is computed from
+	CVSSVector string `json:"cvss_vector,omitempty"`
+	// CWE is the Common Weakness Enumeration ID
+	CWE string `json:"cwe,omitempty"`
+	// Method is the HTTP method of the request that exposed the vulnerability
+	Method string `json:"method,omitempty"`
+	// URL is the URL of the request that exposed the vulnerability
+	URL string `json:"url,omitempty"`
+	// StatusCode is the HTTP status code of the response
+	StatusCode int `json:"status_code,omitempty"`
+	// Request is the raw request that exposed the vulnerability
+	Request string `json:"request,omitempty"`
+	// Response is the status line and headers of the response
+	Response string `json:"response,omitempty"`
+	// ContentEncoding is the Content-Encoding the response body was decoded from before
+	// being analyzed, if it was compressed
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	// Curl is a curl command replaying the request
+	Curl string `json:"curl,omitempty"`
+	// Evidence is the evidence of the first occurrence
+	Evidence string `json:"evidence"`
+	// Remediation describes how to fix the vulnerability
+	Remediation string `json:"remediation"`
+	// References are links to more information
+	References []string `json:"references,omitempty"`
+	// Occurrences is the number of duplicates merged into the finding
+	Occurrences int `json:"occurrences"`
+	// DetectedAt is the time of the first occurrence
+	DetectedAt time.Time `json:"detected_at"`
+	// EvidenceBundles are the directories of the evidence bundles of the occurrences, once
+	// written with WriteEvidenceBundles
+	EvidenceBundles []string `json:"evidence_bundles,omitempty"`
+	// Status is new or known once the report is compared with a baseline
+	Status string `json:"status,omitempty"`
+	// WAF is blocked if the response is a block page of the WAF detected in front of the
+	// target, passed if the payload passed it
+	WAF string `json:"waf,omitempty"`
+}
+
+// TesterSummary summarizes the run of a security tester
+type TesterSummary struct {
+	Name            string        `json:"name"`
+	Duration        time.Duration `json:"duration"`
+	Vulnerabilities int           `json:"vulnerabilities"`
+	Error           string        `json:"error,omitempty"`
+	// Incomplete is true if the tester was stopped by its time budget or the maximum
+	// duration of the scan
+	Incomplete      bool `json:"incomplete,omitempty"`
+	SkippedRequests int  `json:"skipped_requests,omitempty"`
+	// WAFBlocked is the number of requests blocked by the WAF detected in front of the target
+	WAFBlocked int `json:"waf_blocked,omitempty"`
+	// FailedRequests is the number of requests that got no response after their retries
+	FailedRequests int `json:"failed_requests,omitempty"`
+}
+
+// Status returns complete, or the number of requests skipped by an incomplete tester, and the
+// numbers of requests that failed and were blocked by the WAF
+func (t TesterSummary) Status() string {
+	status := "complete"
+	if t.Incomplete {
+		status = fmt.Sprintf("stopped, %d requests skipped", t.SkippedRequests)
+	}
+	if t.FailedRequests > 0 {
+		status += fmt.Sprintf(", %d requests failed", t.FailedRequests)
+	}
+	if t.WAFBlocked > 0 {
+		status += fmt.Sprintf(", %d requests blocked by the WAF", t.WAFBlocked)
+	}
+	return status
+}
+
+// VulnerabilityReport aggregates the results of security testers
+type VulnerabilityReport struct {
+	// Target is the scanned target
+	Target string `json:"target"`
+	// GeneratedAt is the time the report was created
+	GeneratedAt time.Time `json:"generated_at"`
+	// Findings are the deduplicated findings, sorted by CVSS score
+	Findings []*Finding `json:"findings"`
+	// Testers summarizes the runs of the security testers
+	Testers []TesterSummary `json:"testers"`
+	// PolicyViolations is the number of requests denied by each rule of the scope policy
+	PolicyViolations map[string]int `json:"policy_violations,omitempty"`
+	// SkippedEndpoints are the endpoints not scanned before the scan reached its maximum
+	// duration
+	SkippedEndpoints []string `json:"skipped_endpoints,omitempty"`
+	// WAF are the names of the web application firewalls detected in front of the targets
+	WAF []string `json:"waf,omitempty"`
+	// Technologies are the technologies of the stacks of the targets identified by
+	// fingerprinting
+	Technologies []security.Technology `json:"technologies,omitempty"`
+	// Fixed are the findings of the baseline no longer found, once the report is compared
+	// with a baseline
+	Fixed []BaselineFinding `json:"fixed,omitempty"`
+	// MermaidScript is the path of a local Mermaid script embedded in HTML reports to render
+	// their diagrams with Mermaid instead of the SVG images drawn by ffuf.
+	MermaidScript string `json:"-"`
+
+	occurrences []occurrence
+}
+
+// NewVulnerabilityReport aggregates the results of security testers into a report.
+// Vulnerabilities with the same fingerprint are merged into a single finding.
+func NewVulnerabilityReport(target string, results []*security.TestResult) *VulnerabilityReport {
+	report := &VulnerabilityReport{
+		Target:      target,
+		GeneratedAt: time.Now(),
+		Findings:    []*Finding{},
+		Testers:     []TesterSummary{},
+	}
+
+	findings := make(map[string]*Finding)
+	wafs := make(map[string]bool)
+	technologies := make(map[string]bool)
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		summary := TesterSummary{
+			Name:            result.TestName,
+			Duration:        result.Duration,
+			Vulnerabilities: len(result.Vulnerabilities),
+			Incomplete:      result.Incomplete,
+			SkippedRequests: result.SkippedRequests,
+			WAFBlocked:      result.WAFBlocked,
+			FailedRequests:  result.FailedRequests,
+		}
+		if result.Error != nil {
+			summary.Error = result.Error.Error()
+		}
+		report.Testers = append(report.Testers, summary)
+		if result.WAF != "" && !wafs[result.WAF] {
+			wafs[result.WAF] = true
+			report.WAF = append(report.WAF, result.WAF)
+		}
+		for _, technology := range result.Technologies {
+			if !technologies[technology.Name] {
+				technologies[technology.Name] = true
+				report.Technologies = append(report.Technologies, technology)
+			}
+		}
+
+		for _, vuln := range result.Vulnerabilities {
+			finding := newFinding(result.TestName, vuln)
+			if existing, ok := findings[finding.ID]; ok {
+				existing.Occurrences++
+				report.occurrences = append(report.occurrences, occurrence{finding: existing, tester: result.TestName, vuln: vuln})
+				continue
+			}
+			findings[finding.ID] = finding
+			report.occurrences = append(report.occurrences, occurrence{finding: finding, tester: result.TestName, vuln: vuln})
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.CVSS != b.CVSS {
+			return a.CVSS > b.CVSS
+		}
+		if severityRank(a.Severity) != severityRank(b.Severity) {
+			return severityRank(a.Severity) < severityRank(b.Severity)
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// LoadVulnerabilityReport loads a report generated in the JSON format, to generate it again
+// in other formats. The raw requests and responses of the occurrences are not part of the
+// JSON format, so the loaded report has no occurrences to write evidence bundles or HAR
+// entries for.
+func LoadVulnerabilityReport(path string) (*VulnerabilityReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to read vulnerability report: "+err.Error(), 0)
+	}
+	report := &VulnerabilityReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, api.NewAPIError("Failed to parse vulnerability report "+path+": "+err.Error(), 0)
+	}
+	if report.Findings == nil {
+		return nil, api.NewAPIError(path+" is not a JSON vulnerability report", 0)
+	}
+	if report.Testers == nil {
+		report.Testers = []TesterSummary{}
+	}
+	return report, nil
+}
+
+// newFinding creates a finding from a vulnerability
+func newFinding(tester string, vuln security.VulnerabilityInfo) *Finding {
+	finding := &Finding{
+		Type:        vuln.Type.String(),
+		Tester:      tester,
+		Name:        vuln.Name,
+		Description: vuln.Description,
+		Severity:    vuln.Severity,
+		CVSS:        vuln.CVSS,
+		CVSSVector:  vuln.CVSSVector,
+		CWE:         vuln.CWE,
+		Evidence:    vuln.Evidence,
+		Remediation: vuln.Remediation,
+		References:  vuln.References,
+		Occurrences: 1,
+		DetectedAt:  vuln.DetectedAt,
+		WAF:         vuln.WAF,
+	}
+	if vuln.Request != nil && vuln.Request.URL != nil {
+		finding.Method = vuln.Request.Method
+		finding.URL = vuln.Request.URL.String()
+		finding.Request, finding.Curl = replayHTTPRequest(vuln.Request)
+	}
+	if vuln.Response != nil {
+		finding.StatusCode = vuln.Response.StatusCode
+		finding.Response = replayHTTPResponse(vuln.Response)
+		finding.ContentEncoding = decodedEncoding(vuln.Response)
+	}
+	finding.ID = vuln.Fingerprint()
+	return finding
+}
+
+// severityRank returns the position of a severity in severityOrder
+func severityRank(severity string) int {
+	for i, s := range severityOrder {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+	return len(severityOrder)
+}
+
+// SeverityCounts returns the number of findings per severity
+func (r *VulnerabilityReport) SeverityCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, severity := range severityOrder {
+		counts[severity] = 0
+	}
+	for _, finding := range r.Findings {
+		counts[finding.Severity]++
+	}
+	return counts
+}
+
+// Generate generates the report in the specified format. XLSX reports are binary.
+func (r *VulnerabilityReport) Generate(format CoverageFormat) (string, error) {
+	switch format {
+	case FormatJSON:
+		return r.generateJSONReport()
+	case FormatSARIF:
+		return r.generateSARIFReport()
+	case FormatHTML:
+		return r.generateHTMLReport()
+	case FormatMarkdown:
+		return r.generateMarkdownReport()
+	case FormatCSV:
+		return r.generateCSVReport()
+	case FormatXLSX:
+		return r.generateXLSXReport()
+	case FormatBurp:
+		return r.generateBurpReport()
+	case FormatHAR:
+		return r.generateHARReport()
+	default:
+		return "", api.NewAPIError("Unsupported report format", 0)
+	}
+}
+
+// IncompleteSummary describes the testers stopped by their time budget or the maximum
+// duration of the scan, and the endpoints not scanned, or returns an empty string if the scan
+// completed
+func (r *VulnerabilityReport) IncompleteSummary() string {
+	stopped, skipped, failed := 0, 0, 0
+	for _, tester := range r.Testers {
+		if tester.Incomplete {
+			stopped++
+			skipped += tester.SkippedRequests
+		}
+		failed += tester.FailedRequests
+	}
+	var parts []string
+	if stopped > 0 {
+		parts = append(parts, fmt.Sprintf("%d tester runs were stopped before completing, skipping %d requests", stopped, skipped))
+	}
+	if failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d requests of the testers failed after their retries", failed))
+	}
+	if len(r.SkippedEndpoints) > 0 {
+		parts = append(parts, fmt.Sprintf("%d endpoints were not scanned", len(r.SkippedEndpoints)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "The scan is incomplete: " + strings.Join(parts, ", and ") + "."
+}
+
+// WAFSummary describes the web application firewalls detected in front of the targets, the
+// requests they blocked and the findings that are block pages, or returns an empty string if
+// no WAF was detected
+func (r *VulnerabilityReport) WAFSummary() string {
+	if len(r.WAF) == 0 {
+		return ""
+	}
+	blocked, findings := 0, 0
+	for _, tester := range r.Testers {
+		blocked += tester.WAFBlocked
+	}
+	for _, finding := range r.Findings {
+		if finding.WAF == security.WAFBlocked {
+			findings++
+		}
+	}
+	summary := fmt.Sprintf("WAF detected: %s. It blocked %d requests of the security testers", strings.Join(r.WAF, ", "), blocked)
+	if findings > 0 {
+		summary += fmt.Sprintf(", and %d findings are block pages of the WAF, likely false positives", findings)
+	}
+	return summary + "."
+}
+
+// TechnologySummary lists the technologies identified in the stacks of the targets with their
+// category, or returns an empty string if none was identified
+func (r *VulnerabilityReport) TechnologySummary() string {
+	if len(r.Technologies) == 0 {
+		return ""
+	}
+	names := make([]string, len(r.Technologies))
+	for i, technology := range r.Technologies {
+		names[i] = fmt.Sprintf("%s (%s)", technology.Name, technology.Category)
+	}
+	return fmt.Sprintf("Technologies identified: %s.", strings.Join(names, ", "))
+}
+
+// generateJSONReport generates a JSON vulnerability report
+func (r *VulnerabilityReport) generateJSONReport() (string, error) {
+	report := map[string]interface{}{
+		"target":       r.Target,
+		"generated_at": r.GeneratedAt,
+		"summary":      r.SeverityCounts(),
+		"findings":     r.Findings,
+		"testers":      r.Testers,
+	}
+	if r.PolicyViolations != nil {
+		report["policy_violations"] = r.PolicyViolations
+	}
+	if incomplete := r.IncompleteSummary(); incomplete != "" {
+		report["incomplete"] = incomplete
+	}
+	if len(r.WAF) > 0 {
+		report["waf"] = r.WAF
+	}
+	if len(r.Technologies) > 0 {
+		report["technologies"] = r.Technologies
+	}
+	if len(r.SkippedEndpoints) > 0 {
+		report["skipped_endpoints"] = r.SkippedEndpoints
+	}
+	if r.Fixed != nil {
+		report["baseline"] = r.BaselineCounts()
+		report["fixed"] = r.Fixed
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", api.NewAPIError("Failed to generate JSON report: "+err.Error(), 0)
+	}
+	return string(jsonData), nil
+}
+
+// sarifLog is the root object of a SARIF 2.1.0 log
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string                 `json:"id"`
+	Name             string                 `json:"name"`
+	ShortDescription sarifMessage           `json:"shortDescription"`
+	FullDescription  sarifMessage           `json:"fullDescription"`
+	Help             sarifMessage           `json:"help"`
+	HelpURI          string                 `json:"helpUri,omitempty"`
+	Properties       map[string]interface{} `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+	BaselineState       string                 `json:"baselineState,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// generateSARIFReport generates a SARIF 2.1.0 vulnerability report for code scanning tools
+func (r *VulnerabilityReport) generateSARIFReport() (string, error) {
+	driver := sarifDriver{
+		Name:           "ffuf",
+		InformationURI: "https://github.com/ffuf/ffuf",
+		Version:        ffuf.Version(),
+		Rules:          []sarifRule{},
+	}
+	results := []sarifResult{}
+	ruleIndex := make(map[string]int)
+
+	for _, finding := range r.Findings {
+		ruleID := sarifRuleID(finding)
+		index, ok := ruleIndex[ruleID]
+		if !ok {
+			index = len(driver.Rules)
+			ruleIndex[ruleID] = index
+			driver.Rules = append(driver.Rules, newSARIFRule(ruleID, finding))
+		}
+
+		location := finding.URL
+		if location == "" {
+			location = r.Target
+		}
+		var properties map[string]interface{}
+		if len(finding.EvidenceBundles) > 0 {
+			properties = map[string]interface{}{"evidenceBundles": finding.EvidenceBundles}
+		}
+		result := sarifResult{
+			RuleID:    ruleID,
+			RuleIndex: index,
+			Level:     sarifLevel(finding.Severity),
+			Message:   sarifMessage{Text: fmt.Sprintf("%s: %s", finding.Name, finding.Evidence)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: location}},
+			}},
+			PartialFingerprints: map[string]string{"ffufFindingHash/v1": finding.ID},
+			Properties:          properties,
+		}
+		switch finding.Status {
+		case FindingNew:
+			result.BaselineState = "new"
+		case FindingKnown:
+			result.BaselineState = "unchanged"
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+	jsonData, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", api.NewAPIError("Failed to generate SARIF report: "+err.Error(), 0)
+	}
+	return string(jsonData), nil
+}
+
+// newSARIFRule creates the SARIF rule of a finding
+func newSARIFRule(id string, finding *Finding) sarifRule {
+	tags := []string{"security", finding.Type}
+	if finding.CWE != "" {
+		tags = append(tags, "external/cwe/"+strings.ToLower(finding.CWE))
+	}
+	rule := sarifRule{
+		ID:               id,
+		Name:             finding.Name,
+		ShortDescription: sarifMessage{Text: finding.Name},
+		FullDescription:  sarifMessage{Text: finding.Description},
+		Help:             sarifMessage{Text: finding.Remediation},
+		Properties: map[string]interface{}{
+			"tags":              tags,
+			"security-severity": strconv.FormatFloat(finding.CVSS, 'f', 1, 64),
+		},
+	}
+	if finding.CVSSVector != "" {
+		rule.Properties["cvssV3Vector"] = finding.CVSSVector
+	}
+	if len(finding.References) > 0 {
+		rule.HelpURI = finding.References[0]
+	}
+	return rule
+}
+
+// sarifRuleID returns the SARIF rule ID of a finding, e.g. injection/sql-injection
+func sarifRuleID(finding *Finding) string {
+	var slug strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(finding.Name) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			slug.WriteRune(c)
+			dash = false
+		} else if !dash && slug.Len() > 0 {
+			slug.WriteRune('-')
+			dash = true
+		}
+	}
+	return finding.Type + "/" + strings.TrimSuffix(slug.String(), "-")
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// generateMarkdownReport generates a Markdown vulnerability report
+func (r *VulnerabilityReport) generateMarkdownReport() (string, error) {
+	counts := r.SeverityCounts()
+
+	var buf bytes.Buffer
+	buf.WriteString("# API Security Report\n\n")
+	buf.WriteString(fmt.Sprintf("Target: %s\n\n", r.Target))
+	buf.WriteString(fmt.Sprintf("Generated on %s\n\n", r.GeneratedAt.Format("2006-01-02 15:04:05")))
+
+	buf.WriteString("## Summary\n\n")
+	buf.WriteString("| Severity | Findings |\n")
+	buf.WriteString("|----------|----------|\n")
+	for _, severity := range severityOrder {
+		buf.WriteString(fmt.Sprintf("| %s | %d |\n", severity, counts[severity]))
+	}
+	buf.WriteString(fmt.Sprintf("\n**Total**: %d\n\n", len(r.Findings)))
+	if incomplete := r.IncompleteSummary(); incomplete != "" {
+		buf.WriteString(fmt.Sprintf("> %s\n\n", incomplete))
+	}
+	if waf := r.WAFSummary(); waf != "" {
+		buf.WriteString(fmt.Sprintf("> %s\n\n", waf))
+	}
+	if technologies := r.TechnologySummary(); technologies != "" {
+		buf.WriteString(fmt.Sprintf("> %s\n\n", technologies))
+	}
+	if r.Fixed != nil {
+		baseline := r.BaselineCounts()
+		buf.WriteString(fmt.Sprintf("**Baseline**: %d new, %d known, %d fixed\n\n", baseline[FindingNew], baseline[FindingKnown], baseline[FindingFixed]))
+	}
+
+	buf.WriteString("## Findings\n\n")
+	if len(r.Findings) == 0 {
+		buf.WriteString("No vulnerabilities found.\n\n")
+	}
+	for i, finding := range r.Findings {
+		buf.WriteString(fmt.Sprintf("### %d. %s\n\n", i+1, finding.Name))
+		buf.WriteString(fmt.Sprintf("- **Severity**: %s (CVSS %.1f)\n", finding.Severity, finding.CVSS))
+		if finding.CVSSVector != "" {
+			buf.WriteString(fmt.Sprintf("- **CVSS vector**: `%s`\n", finding.CVSSVector))
+		}
+		if finding.CWE != "" {
+			buf.WriteString(fmt.Sprintf("- **CWE**: %s\n", finding.CWE))
+		}
+		if finding.URL != "" {
+			buf.WriteString(fmt.Sprintf("- **Request**: `%s %s`\n", finding.Method, finding.URL))
+		}
+		buf.WriteString(fmt.Sprintf("- **Tester**: %s\n", finding.Tester))
+		if finding.Status != "" {
+			buf.WriteString(fmt.Sprintf("- **Status**: %s\n", finding.Status))
+		}
+		if finding.WAF != "" {
+			buf.WriteString(fmt.Sprintf("- **WAF**: %s\n", finding.WAF))
+		}
+		if finding.Occurrences > 1 {
+			buf.WriteString(fmt.Sprintf("- **Occurrences**: %d\n", finding.Occurrences))
+		}
+		buf.WriteString(fmt.Sprintf("\n%s\n\n", finding.Description))
+		buf.WriteString(fmt.Sprintf("**Evidence**: %s\n\n", finding.Evidence))
+		buf.WriteString(fmt.Sprintf("**Remediation**: %s\n\n", finding.Remediation))
+		if len(finding.EvidenceBundles) > 0 {
+			buf.WriteString("**Evidence bundles**:\n\n")
+			for _, bundle := range finding.EvidenceBundles {
+				buf.WriteString(fmt.Sprintf("- [%s](%s/)\n", bundle, bundle))
+			}
+			buf.WriteString("\n")
+		}
+		if len(finding.References) > 0 {
+			buf.WriteString("**References**:\n\n")
+			for _, ref := range finding.References {
+				buf.WriteString(fmt.Sprintf("- %s\n", ref))
+			}
+			buf.WriteString("\n")
+		}
+	}
+
+	if len(r.Fixed) > 0 {
+		buf.WriteString("## Fixed Findings\n\n")
+		buf.WriteString("| Finding | Severity | Request | First Seen |\n")
+		buf.WriteString("|---------|----------|---------|------------|\n")
+		for _, finding := range r.Fixed {
+			buf.WriteString(fmt.Sprintf("| %s | %s | `%s %s` | %s |\n", finding.Name, finding.Severity, finding.Method, finding.URL, finding.FirstSeen.Format("2006-01-02")))
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("## Testers\n\n")
+	buf.WriteString("| Tester | Findings | Duration | Status | Error |\n")
+	buf.WriteString("|--------|----------|----------|--------|-------|\n")
+	for _, tester := range r.Testers {
+		buf.WriteString(fmt.Sprintf("| %s | %d | %s | %s | %s |\n", tester.Name, tester.Vulnerabilities, tester.Duration.Round(time.Millisecond), tester.Status(), tester.Error))
+	}
+
+	if len(r.SkippedEndpoints) > 0 {
+		buf.WriteString("\n## Skipped Endpoints\n\n")
+		for _, endpoint := range r.SkippedEndpoints {
+			buf.WriteString(fmt.Sprintf("- `%s`\n", endpoint))
+		}
+	}
+
+	if r.PolicyViolations != nil {
+		buf.WriteString("\n## Scope Policy\n\n")
+		buf.WriteString("| Rule | Denied Requests |\n")
+		buf.WriteString("|------|-----------------|\n")
+		rules := make([]string, 0, len(r.PolicyViolations))
+		for rule := range r.PolicyViolations {
+			rules = append(rules, rule)
+		}
+		sort.Strings(rules)
+		for _, rule := range rules {
+			buf.WriteString(fmt.Sprintf("| %s | %d |\n", rule, r.PolicyViolations[rule]))
+		}
+	}
+
+	buf.WriteString("\n*Report generated by ffuf API Security Testing.*\n")
+	return buf.String(), nil
+}
+
+// generateHTMLReport generates a styled HTML vulnerability report with a severity chart
+func (r *VulnerabilityReport) generateHTMLReport() (string, error) {
+	tmpl := `<!DOCTYPE html>
+<html>
+<head>
+    <title>API Security Report</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 20px; color: #333; }
+        h1, h2, h3 { color: #333; }
+        .chart { max-width: 600px; margin-bottom: 20px; }
+        .chart-row { display: flex; align-items: center; margin: 6px 0; }
+        .chart-label { width: 80px; }
+        .chart-bar { height: 20px; border-radius: 3px; margin-right: 8px; min-width: 2px; }
+        .severity { display: inline-block; padding: 3px 8px; border-radius: 10px; color: white; font-size: 12px; }
+        .severity-Critical { background-color: #7b1fa2; }
+        .severity-High { background-color: #d32f2f; }
+        .severity-Medium { background-color: #f57c00; }
+        .severity-Low { background-color: #fbc02d; }
+        .severity-Info { background-color: #1976d2; }
+        .status { display: inline-block; padding: 1px 6px; border-radius: 10px; font-size: 11px; border: 1px solid #999; color: #555; }
+        .status-new { border-color: #d32f2f; color: #d32f2f; }
+        .waf-blocked { border-color: #f57c00; color: #f57c00; }
+        td code { background-color: #f5f5f5; padding: 2px 4px; word-break: break-all; }
+        .label { font-weight: bold; }
+        table { border-collapse: collapse; width: 100%; margin-top: 20px; }
+        th, td { padding: 12px 15px; text-align: left; border-bottom: 1px solid #ddd; }
+        th { background-color: #f2f2f2; }
+        {{.Assets.Style}}
+    </style>
+</head>
+<body>
+    <h1>API Security Report</h1>
+    <p>Target: {{.Target}}<br>Generated on {{.GeneratedAt | formatTime}}</p>
+
+    <h2>Summary</h2>
+    <div class="chart">
+        {{range .Chart}}
+        <div class="chart-row">
+            <div class="chart-label">{{.Severity}}</div>
+            <div class="chart-bar severity-{{.Severity}}" style="width: {{.Width}}%;"></div>
+            <div>{{.Count}}</div>
+        </div>
+        {{end}}
+    </div>
+    <p>Total findings: {{len .Findings}}</p>
+    {{with .Incomplete}}<p><strong>{{.}}</strong></p>{{end}}
+    {{with .WAF}}<p>{{.}}</p>{{end}}
+    {{with .Technologies}}<p>{{.}}</p>{{end}}
+    {{if .Baseline}}<p>Baseline: {{index .Baseline "new"}} new, {{index .Baseline "known"}} known, {{index .Baseline "fixed"}} fixed</p>{{end}}
+
+    {{if .Diagram}}
+    <h2>API Map</h2>
+    <div class="diagram">
+        <div class="diagram-image">{{.DiagramImage}}</div>
+        <details>
+            <summary>Mermaid source</summary>
+            <pre class="mermaid-source">{{.Diagram}}</pre>
+        </details>
+    </div>
+    {{end}}
+
+    <h2>Findings</h2>
+    {{if not .Findings}}<p>No vulnerabilities found.</p>{{else}}
+    <div class="filters">
+        <input type="search" class="filter" data-table="findings" placeholder="Filter by name, URL, tester or CWE">
+        <select class="filter" data-table="findings" data-field="severity">
+            <option value="">All severities</option>
+            {{range .Chart}}{{if .Count}}<option value="{{.Severity}}">{{.Severity}}</option>{{end}}{{end}}
+        </select>
+        <span class="filter-count" data-table="findings"></span>
+    </div>
+    <table id="findings">
+        <thead>
+        <tr>
+            <th>#</th>
+            <th>Severity</th>
+            <th>Name</th>
+            <th>Request</th>
+            <th>CVSS</th>
+            <th>CWE</th>
+            <th>Tester</th>
+            <th>Occurrences</th>
+        </tr>
+        </thead>
+        {{range $i, $f := .Findings}}
+        <tbody class="item" data-severity="{{$f.Severity}}" data-search="{{searchText $f}}">
+        <tr>
+            <td>{{inc $i}}</td>
+            <td><span class="severity severity-{{$f.Severity}}">{{$f.Severity}}</span></td>
+            <td>{{$f.Name}}{{if $f.Status}} <span class="status status-{{$f.Status}}">{{$f.Status}}</span>{{end}}{{if $f.WAF}} <span class="status waf-{{$f.WAF}}">WAF {{$f.WAF}}</span>{{end}}</td>
+            <td>{{if $f.URL}}<code>{{$f.Method}} {{$f.URL}}</code>{{end}}</td>
+            <td>{{if $f.CVSSVector}}<span title="{{$f.CVSSVector}}">{{printf "%.1f" $f.CVSS}}</span>{{else}}{{printf "%.1f" $f.CVSS}}{{end}}</td>
+            <td>{{$f.CWE}}</td>
+            <td>{{$f.Tester}}</td>
+            <td>{{$f.Occurrences}}</td>
+        </tr>
+        <tr class="detail">
+            <td colspan="8" class="finding">
+                <p>{{$f.Description}}</p>
+                <p><span class="label">Evidence:</span> {{$f.Evidence}}</p>
+                <p><span class="label">Remediation:</span> {{$f.Remediation}}</p>
+                {{if $f.CVSSVector}}<p><span class="label">CVSS vector:</span> <code>{{$f.CVSSVector}}</code></p>{{end}}
+                {{if $f.EvidenceBundles}}
+                <p><span class="label">Evidence bundles:</span>
+                    {{range $f.EvidenceBundles}}<a href="{{.}}/">{{.}}</a> {{end}}
+                </p>
+                {{end}}
+                {{if $f.References}}
+                <ul>
+                    {{range $f.References}}<li><a href="{{.}}">{{.}}</a></li>{{end}}
+                </ul>
+                {{end}}
+                {{if $f.Request}}
+                <details>
+                    <summary>Request</summary>
+                    <pre class="raw">{{$f.Request}}</pre>
+                    <button class="copy" data-copy="finding-{{$i}}-curl">Copy as curl</button>
+                    <pre class="raw" id="finding-{{$i}}-curl">{{$f.Curl}}</pre>
+                </details>
+                {{end}}
+                {{if $f.Response}}
+                <details>
+                    <summary>Response</summary>
+                    <pre class="raw">{{$f.Response}}</pre>
+                </details>
+                {{end}}
+            </td>
+        </tr>
+        </tbody>
+        {{end}}
+    </table>
+    {{end}}
+
+    {{if .Fixed}}
+    <h2>Fixed Findings</h2>
+    <table>
+        <tr>
+            <th>Severity</th>
+            <th>Name</th>
+            <th>Request</th>
+            <th>First Seen</th>
+        </tr>
+        {{range .Fixed}}
+        <tr>
+            <td><span class="severity severity-{{.Severity}}">{{.Severity}}</span></td>
+            <td>{{.Name}}</td>
+            <td>{{if .URL}}<code>{{.Method}} {{.URL}}</code>{{end}}</td>
+            <td>{{.FirstSeen | formatTime}}</td>
+        </tr>
+        {{end}}
+    </table>
+    {{end}}
+
+    <h2>Testers</h2>
+    <table>
+        <tr>
+            <th>Tester</th>
+            <th>Findings</th>
+            <th>Duration</th>
+            <th>Status</th>
+            <th>Error</th>
+        </tr>
+        {{range .Testers}}
+        <tr>
+            <td>{{.Name}}</td>
+            <td>{{.Vulnerabilities}}</td>
+            <td>{{.Duration}}</td>
+            <td>{{.Status}}</td>
+            <td>{{.Error}}</td>
+        </tr>
+        {{end}}
+    </table>
+
+    {{if .SkippedEndpoints}}
+    <h2>Skipped Endpoints</h2>
+    <ul>
+        {{range .SkippedEndpoints}}<li><code>{{.}}</code></li>
+        {{end}}
+    </ul>
+    {{end}}
+
+    {{if .Policy}}
+    <h2>Scope Policy</h2>
+    <table>
+        <tr>
+            <th>Rule</th>
+            <th>Denied Requests</th>
+        </tr>
+        {{range $rule, $count := .PolicyViolations}}
+        <tr>
+            <td>{{$rule}}</td>
+            <td>{{$count}}</td>
+        </tr>
+        {{end}}
+    </table>
+    {{end}}
+
+    <p><small>Report generated by ffuf API Security Testing.</small></p>
+    {{if .Assets.Mermaid}}<script>{{.Assets.Mermaid}}</script>{{end}}
+    <script>{{.Assets.Script}}</script>
+</body>
+</html>`
+
+	funcMap := template.FuncMap{
+		"formatTime": func(t time.Time) string {
+			return t.Format("2006-01-02 15:04:05")
+		},
+		"inc": func(i int) int {
+			return i + 1
+		},
+		"searchText": func(f *Finding) string {
+			return strings.ToLower(strings.Join([]string{f.Name, f.Type, f.Method, f.URL, f.Tester, f.CWE}, " "))
+		},
+	}
+
+	t, err := template.New("report").Funcs(funcMap).Parse(tmpl)
+	if err != nil {
+		return "", api.NewAPIError("Failed to parse HTML template: "+err.Error(), 0)
+	}
+	assets, err := reportAssets(r.MermaidScript)
+	if err != nil {
+		return "", err
+	}
+
+	// Chart bars are sized relative to the most common severity
+	type chartBar struct {
+		Severity string
+		Count    int
+		Width    int
+	}
+	counts := r.SeverityCounts()
+	max := 0
+	for _, count := range counts {
+		if count > max {
+			max = count
+		}
+	}
+	var chart []chartBar
+	for _, severity := range severityOrder {
+		bar := chartBar{Severity: severity, Count: counts[severity]}
+		if max > 0 {
+			bar.Width = counts[severity] * 80 / max
+		}
+		chart = append(chart, bar)
+	}
+
+	var baseline map[string]int
+	if r.Fixed != nil {
+		baseline = r.BaselineCounts()
+	}
+	image, diagram := r.diagram()
+	data := map[string]interface{}{
+		"Target":           r.Target,
+		"GeneratedAt":      r.GeneratedAt,
+		"Chart":            chart,
+		"Findings":         r.Findings,
+		"Testers":          r.Testers,
+		"Incomplete":       r.IncompleteSummary(),
+		"WAF":              r.WAFSummary(),
+		"Technologies":     r.TechnologySummary(),
+		"SkippedEndpoints": r.SkippedEndpoints,
+		"Policy":           r.PolicyViolations != nil,
+		"PolicyViolations": r.PolicyViolations,
+		"Baseline":         baseline,
+		"Fixed":            r.Fixed,
+		"Diagram":          diagram,
+		"DiagramImage":     image,
+		"Assets":           assets,
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", api.NewAPIError("Failed to generate HTML report: "+err.Error(), 0)
+	}
+	return buf.String(), nil
+}
+
+// diagram returns the map of the endpoints with findings, colored by their most severe finding
+func (r *VulnerabilityReport) diagram() (template.HTML, string) {
+	endpoints := make([]*parser.DiscoveredEndpoint, 0)
+	classes := make(map[string]string)
+	for _, finding := range r.Findings {
+		u, err := url.Parse(finding.URL)
+		if finding.URL == "" || err != nil {
+			continue
+		}
+		path := u.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+		key := finding.Method + " " + path
+		current, ok := classes[key]
+		if !ok {
+			endpoints = append(endpoints, &parser.DiscoveredEndpoint{Method: finding.Method, Path: path})
+		}
+		if !ok || severityRank(finding.Severity) < severityRank(strings.TrimPrefix(current, "severity")) {
+			classes[key] = "severity" + finding.Severity
+		}
+	}
+	return endpointDiagram(endpoints, classes, map[string]string{
+		"severityCritical": "fill:#e1bee7,stroke:#7b1fa2",
+		"severityHigh":     "fill:#ffcdd2,stroke:#d32f2f",
+		"severityMedium":   "fill:#ffe0b2,stroke:#f57c00",
+		"severityLow":      "fill:#fff9c4,stroke:#fbc02d",
+		"severityInfo":     "fill:#bbdefb,stroke:#1976d2",
+	})
+}
@@ -0,0 +1,257 @@
+package reporting
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+)
+
+func newTestVulnerability(name, severity string, cvss float64, rawURL string) security.VulnerabilityInfo {
+	req, _ := http.NewRequest("GET", rawURL, nil)
+	return security.VulnerabilityInfo{
+		Type:        security.VulnInjection,
+		Name:        name,
+		Description: name + " description",
+		Severity:    severity,
+		Request:     req,
+		Response:    &http.Response{StatusCode: 500},
+		Evidence:    "evidence of " + name,
+		Remediation: "fix " + name,
+		CVSS:        cvss,
+		CWE:         "CWE-89",
+		References:  []string{"https://owasp.org/API-Security/"},
+		DetectedAt:  time.Now(),
+	}
+}
+
+func newTestReport() *VulnerabilityReport {
+	results := []*security.TestResult{
+		{
+			TestName: "Injection",
+			Vulnerabilities: []security.VulnerabilityInfo{
+				newTestVulnerability("SQL Injection", "Critical", 9.8, "https://api.example.com/users?id=%27+OR+1%3D1"),
+				newTestVulnerability("SQL Injection", "Critical", 9.8, "https://api.example.com/users?id=%27+OR+2%3D2"),
+				newTestVulnerability("Verbose Errors", "Low", 3.1, "https://api.example.com/users"),
+				newTestVulnerability("Command Injection", "High", 8.8, "https://api.example.com/ping?host=x"),
+			},
+			Duration: time.Second,
+		},
+	}
+	return NewVulnerabilityReport("https://api.example.com", results)
+}
+
+func TestNewVulnerabilityReport(t *testing.T) {
+	report := newTestReport()
+
+	if len(report.Findings) != 3 {
+		t.Fatalf("Expected 3 deduplicated findings, got %d", len(report.Findings))
+	}
+	if report.Findings[0].Name != "SQL Injection" || report.Findings[0].Occurrences != 2 {
+		t.Errorf("Expected merged SQL injection first, got %s with %d occurrences", report.Findings[0].Name, report.Findings[0].Occurrences)
+	}
+	if report.Findings[1].Name != "Command Injection" || report.Findings[2].Name != "Verbose Errors" {
+		t.Errorf("Expected findings sorted by CVSS, got %s, %s", report.Findings[1].Name, report.Findings[2].Name)
+	}
+
+	counts := report.SeverityCounts()
+	if counts["Critical"] != 1 || counts["High"] != 1 || counts["Low"] != 1 || counts["Medium"] != 0 {
+		t.Errorf("Unexpected severity counts: %v", counts)
+	}
+	if len(report.Testers) != 1 || report.Testers[0].Vulnerabilities != 4 {
+		t.Errorf("Unexpected tester summary: %+v", report.Testers)
+	}
+}
+
+func TestVulnerabilityReport_SARIF(t *testing.T) {
+	output, err := newTestReport().Generate(FormatSARIF)
+	if err != nil {
+		t.Fatalf("Failed to generate SARIF report: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(output), &log); err != nil {
+		t.Fatalf("Failed to parse SARIF report: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF log: version %s, %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 3 || len(run.Results) != 3 {
+		t.Fatalf("Expected 3 rules and 3 results, got %d and %d", len(run.Tool.Driver.Rules), len(run.Results))
+	}
+	if run.Results[0].RuleID != "injection/sql-injection" || run.Results[0].Level != "error" {
+		t.Errorf("Unexpected first result: %s (%s)", run.Results[0].RuleID, run.Results[0].Level)
+	}
+	if run.Results[2].Level != "note" {
+		t.Errorf("Expected low severity finding to be a note, got %s", run.Results[2].Level)
+	}
+	if run.Tool.Driver.Rules[0].Properties["security-severity"] != "9.8" {
+		t.Errorf("Unexpected security severity: %v", run.Tool.Driver.Rules[0].Properties["security-severity"])
+	}
+}
+
+func TestVulnerabilityReport_Formats(t *testing.T) {
+	report := newTestReport()
+
+	for _, format := range []CoverageFormat{FormatJSON, FormatMarkdown, FormatHTML} {
+		output, err := report.Generate(format)
+		if err != nil {
+			t.Errorf("Failed to generate %s report: %v", format, err)
+			continue
+		}
+		if !strings.Contains(output, "SQL Injection") || !strings.Contains(output, "Command Injection") {
+			t.Errorf("Expected %s report to contain the findings", format)
+		}
+	}
+
+	if _, err := report.Generate(FormatText); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestVulnerabilityReport_PolicyViolations(t *testing.T) {
+	report := newTestReport()
+	report.PolicyViolations = map[string]int{"no logout": 3}
+
+	for _, format := range []CoverageFormat{FormatJSON, FormatMarkdown, FormatHTML} {
+		output, err := report.Generate(format)
+		if err != nil {
+			t.Fatalf("Failed to generate %s report: %v", format, err)
+		}
+		if !strings.Contains(output, "no logout") {
+			t.Errorf("Expected %s report to contain the policy violations", format)
+		}
+	}
+}
+
+func TestVulnerabilityReport_Budgets(t *testing.T) {
+	report := NewVulnerabilityReport("https://api.example.com", []*security.TestResult{
+		{TestName: "Injection", Incomplete: true, SkippedRequests: 42},
+		{TestName: "SSRF"},
+	})
+	report.SkippedEndpoints = []string{"GET https://api.example.com/orders"}
+	if summary := report.IncompleteSummary(); !strings.Contains(summary, "1 tester runs were stopped") || !strings.Contains(summary, "1 endpoints were not scanned") {
+		t.Errorf("Unexpected incomplete summary %q", summary)
+	}
+	for _, format := range []CoverageFormat{FormatJSON, FormatMarkdown, FormatHTML} {
+		output, err := report.Generate(format)
+		if err != nil {
+			t.Fatalf("Failed to generate %s report: %v", format, err)
+		}
+		if !strings.Contains(output, "The scan is incomplete") || !strings.Contains(output, "GET https://api.example.com/orders") {
+			t.Errorf("Expected %s report to contain the skipped testers and endpoints", format)
+		}
+	}
+}
+
+func TestVulnerabilityReport_WAF(t *testing.T) {
+	report := NewVulnerabilityReport("https://api.example.com", []*security.TestResult{{
+		TestName:   "Injection",
+		WAF:        "ModSecurity",
+		WAFBlocked: 12,
+		Vulnerabilities: []security.VulnerabilityInfo{
+			func() security.VulnerabilityInfo {
+				vuln := newTestVulnerability("Verbose Errors", "Low", 3.1, "https://api.example.com/search")
+				vuln.WAF = security.WAFBlocked
+				return vuln
+			}(),
+		},
+	}})
+	if summary := report.WAFSummary(); summary != "WAF detected: ModSecurity. It blocked 12 requests of the security testers, and 1 findings are block pages of the WAF, likely false positives." {
+		t.Errorf("Unexpected WAF summary %q", summary)
+	}
+	for _, format := range []CoverageFormat{FormatJSON, FormatMarkdown, FormatHTML} {
+		output, err := report.Generate(format)
+		if err != nil {
+			t.Fatalf("Failed to generate %s report: %v", format, err)
+		}
+		if !strings.Contains(output, "ModSecurity") || !strings.Contains(output, "blocked") {
+			t.Errorf("Expected %s report to contain the WAF and the blocked finding", format)
+		}
+	}
+}
+
+func TestVulnerabilityReport_Technologies(t *testing.T) {
+	report := NewVulnerabilityReport("https://api.example.com", []*security.TestResult{{
+		TestName: "Injection",
+		Technologies: []security.Technology{
+			{Name: "Django", Category: "framework"},
+			{Name: "Python", Category: "language"},
+			{Name: "PostgreSQL", Category: "database"},
+			{Name: "nginx", Category: "server"},
+		},
+	}})
+	if summary := report.TechnologySummary(); summary != "Technologies identified: Django (framework), Python (language), PostgreSQL (database), nginx (server)." {
+		t.Errorf("Unexpected technology summary %q", summary)
+	}
+	for _, format := range []CoverageFormat{FormatJSON, FormatMarkdown, FormatHTML} {
+		output, err := report.Generate(format)
+		if err != nil {
+			t.Fatalf("Failed to generate %s report: %v", format, err)
+		}
+		if !strings.Contains(output, "Django") || !strings.Contains(output, "PostgreSQL") {
+			t.Errorf("Expected %s report to contain the identified technologies", format)
+		}
+	}
+}
+
+func TestVulnerabilityReport_CVSSScoring(t *testing.T) {
+	vuln := newTestVulnerability("Command Injection", "Medium", 6.6, "https://api.example.com/ping?host=x")
+	vuln.CVSSVector = "CVSS:3.1/AV:N/AC:H/PR:H/UI:N/S:U/C:H/I:H/A:H"
+	report := NewVulnerabilityReport("https://api.example.com", []*security.TestResult{{TestName: "Injection", Vulnerabilities: []security.VulnerabilityInfo{vuln}}})
+	for _, format := range []CoverageFormat{FormatJSON, FormatMarkdown, FormatHTML, FormatSARIF, FormatCSV} {
+		output, err := report.Generate(format)
+		if err != nil {
+			t.Fatalf("Failed to generate %s report: %v", format, err)
+		}
+		if !strings.Contains(output, "CVSS:3.1/AV:N/AC:H/PR:H/UI:N/S:U/C:H/I:H/A:H") {
+			t.Errorf("Expected %s report to contain the CVSS vectors", format)
+		}
+	}
+}
+
+func TestLoadVulnerabilityReport(t *testing.T) {
+	dir, err := os.MkdirTemp("", "report")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	report := newTestReport()
+	report.PolicyViolations = map[string]int{"host": 2}
+	output, err := report.Generate(FormatJSON)
+	if err != nil {
+		t.Fatalf("Generate returned an error: %s", err)
+	}
+	path := filepath.Join(dir, "report.json")
+	if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+		t.Fatalf("Failed to write report: %s", err)
+	}
+
+	loaded, err := LoadVulnerabilityReport(path)
+	if err != nil {
+		t.Fatalf("LoadVulnerabilityReport returned an error: %s", err)
+	}
+	if loaded.Target != report.Target || len(loaded.Findings) != 3 || loaded.Findings[0].ID != report.Findings[0].ID {
+		t.Errorf("Expected the findings of the report, got %d findings for %s", len(loaded.Findings), loaded.Target)
+	}
+	if loaded.PolicyViolations["host"] != 2 || len(loaded.Testers) != 1 {
+		t.Errorf("Expected the policy violations and testers of the report")
+	}
+	markdown, err := loaded.Generate(FormatMarkdown)
+	if err != nil || !strings.Contains(markdown, "Command Injection") {
+		t.Errorf("Expected the loaded report to be generated again, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"log": {}}`), 0644); err != nil {
+		t.Fatalf("Failed to write report: %s", err)
+	}
+	if _, err := LoadVulnerabilityReport(path); err == nil {
+		t.Errorf("Expected an error for a file that is not a vulnerability report")
+	}
+}
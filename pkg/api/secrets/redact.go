@@ -0,0 +1,302 @@
+package secrets
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+)
+
+// Redaction rules
+const (
+	// RedactAuthorization masks the values of authorization and API key headers, and of
+	// credential parameters such as access_token and password
+	RedactAuthorization = "authorization"
+	// RedactCookies masks the values of the cookies of Cookie and Set-Cookie headers
+	RedactCookies = "cookies"
+	// RedactEmails masks email addresses
+	RedactEmails = "emails"
+	// RedactCards masks payment card numbers passing the Luhn check
+	RedactCards = "cards"
+	// RedactSecrets masks the secrets found by the default secret rules, such as cloud keys
+	// and JSON Web Tokens
+	RedactSecrets = "secrets"
+)
+
+// Redacted replaces the redacted values
+const Redacted = "[REDACTED]"
+
+// redactRules are the redaction rules, in the order they are applied
+var redactRules = []string{RedactAuthorization, RedactCookies, RedactSecrets, RedactEmails, RedactCards}
+
+// authorizationHeaders are the lowercase names of the headers masked by RedactAuthorization
+var authorizationHeaders = map[string]bool{
+	"authorization":        true,
+	"proxy-authorization":  true,
+	"x-api-key":            true,
+	"api-key":              true,
+	"x-auth-token":         true,
+	"x-access-token":       true,
+	"x-amz-security-token": true,
+}
+
+// credentialNames are the names of the credential parameters masked by RedactAuthorization
+const credentialNames = `access_token|refresh_token|id_token|token|client_secret|api_?key|password|passwd|secret`
+
+var (
+	// authorizationText matches authorization headers in raw HTTP messages, curl commands and
+	// JSON objects
+	authorizationText = regexp.MustCompile(`(?i)\b(proxy-authorization|authorization|x-api-key|api-key|x-auth-token|x-access-token|x-amz-security-token)("?[ \t]*:[ \t]*"?)([^\r\n"']+)`)
+	// credentialText matches credential parameters of query strings, forms and JSON objects
+	credentialText = regexp.MustCompile(`(?i)(["']?\b(?:` + credentialNames + `)["']?[ \t]*[:=][ \t]*["']?)([^"'&\s,;}]+)`)
+	// credentialName matches the names of credential parameters
+	credentialName = regexp.MustCompile(`(?i)^(?:` + credentialNames + `)$`)
+	// authorizationScheme matches the scheme of an authorization header, kept by redaction
+	authorizationScheme = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)[ \t]+\S`)
+	// cookieText matches cookie headers in raw HTTP messages, curl commands and JSON objects
+	cookieText = regexp.MustCompile(`(?i)\b(set-cookie|cookie)("?[ \t]*:[ \t]*"?)([^\r\n"']+)`)
+	// emailText matches email addresses
+	emailText = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// cardText matches numbers of 13 to 19 digits, optionally grouped with spaces or dashes,
+	// starting like the numbers of the major card networks
+	cardText = regexp.MustCompile(`\b[2-6](?:[ -]?\d){12,18}\b`)
+)
+
+// Redactor masks tokens and personal data in requests, responses and the text of reports
+// before they are written, with built-in rules and custom regular expressions. The methods
+// of a nil Redactor return their input unchanged.
+type Redactor struct {
+	rules    map[string]bool
+	patterns []*regexp.Regexp
+	scanner  *Scanner
+}
+
+// RedactRules returns the names of the redaction rules
+func RedactRules() []string {
+	return append([]string{}, redactRules...)
+}
+
+// NewRedactor creates a redactor applying the named rules, or every rule for "all", and
+// masking the matches of custom patterns: the first capture group of a pattern with groups,
+// or the whole match. It returns nil if there are neither rules nor patterns.
+func NewRedactor(rules []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{rules: make(map[string]bool)}
+	for _, rule := range rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if rule == "" {
+			continue
+		}
+		if rule == "all" {
+			for _, name := range redactRules {
+				r.rules[name] = true
+			}
+			continue
+		}
+		known := false
+		for _, name := range redactRules {
+			known = known || name == rule
+		}
+		if !known {
+			return nil, api.NewAPIError(fmt.Sprintf("Unknown redaction rule: %s, expected all or %s", rule, strings.Join(redactRules, ", ")), 0)
+		}
+		r.rules[rule] = true
+	}
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Invalid redaction pattern '%s': %s", pattern, err.Error()), 0)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	if len(r.rules) == 0 && len(r.patterns) == 0 {
+		return nil, nil
+	}
+	if r.rules[RedactSecrets] {
+		secretRules := make([]*Rule, 0)
+		for _, rule := range DefaultRules() {
+			if !rule.Reveal {
+				secretRules = append(secretRules, rule)
+			}
+		}
+		scanner, err := NewScanner(secretRules)
+		if err != nil {
+			return nil, err
+		}
+		r.scanner = scanner
+	}
+	return r, nil
+}
+
+// String masks the tokens and personal data of a text, such as a raw HTTP message, a URL or
+// a curl command
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	if r.rules[RedactAuthorization] {
+		s = authorizationText.ReplaceAllStringFunc(s, func(match string) string {
+			parts := authorizationText.FindStringSubmatch(match)
+			return parts[1] + parts[2] + redactAuthorization(parts[3])
+		})
+		s = credentialText.ReplaceAllString(s, "${1}"+Redacted)
+	}
+	if r.rules[RedactCookies] {
+		s = cookieText.ReplaceAllStringFunc(s, func(match string) string {
+			parts := cookieText.FindStringSubmatch(match)
+			return parts[1] + parts[2] + redactCookies(parts[3], strings.EqualFold(parts[1], "set-cookie"))
+		})
+	}
+	if r.scanner != nil {
+		for _, finding := range r.scanner.Scan([]byte(s)) {
+			s = strings.ReplaceAll(s, finding.Secret, Redacted)
+		}
+	}
+	if r.rules[RedactEmails] {
+		s = emailText.ReplaceAllString(s, Redacted)
+	}
+	if r.rules[RedactCards] {
+		s = cardText.ReplaceAllStringFunc(s, func(match string) string {
+			if luhn(match) {
+				return Redacted
+			}
+			return match
+		})
+	}
+	for _, pattern := range r.patterns {
+		s = redactPattern(pattern, s)
+	}
+	return s
+}
+
+// Bytes masks the tokens and personal data of a body
+func (r *Redactor) Bytes(data []byte) []byte {
+	if r == nil || len(data) == 0 {
+		return data
+	}
+	return []byte(r.String(string(data)))
+}
+
+// Value masks the value of a header: the credentials of authorization headers, the values of
+// the cookies of cookie headers, and the tokens and personal data of other headers
+func (r *Redactor) Value(name, value string) string {
+	if r == nil {
+		return value
+	}
+	lower := strings.ToLower(name)
+	if r.rules[RedactAuthorization] && authorizationHeaders[lower] {
+		return redactAuthorization(value)
+	}
+	if r.rules[RedactCookies] && (lower == "cookie" || lower == "set-cookie") {
+		return redactCookies(value, lower == "set-cookie")
+	}
+	return r.String(value)
+}
+
+// Param masks the value of a query, form or JSON parameter: the value of credential
+// parameters, and the tokens and personal data of other parameters
+func (r *Redactor) Param(name, value string) string {
+	if r == nil {
+		return value
+	}
+	if r.rules[RedactAuthorization] && credentialName.MatchString(name) {
+		return Redacted
+	}
+	return r.String(value)
+}
+
+// Cookie masks the value of a cookie
+func (r *Redactor) Cookie(value string) string {
+	if r == nil {
+		return value
+	}
+	if r.rules[RedactCookies] {
+		return Redacted
+	}
+	return r.String(value)
+}
+
+// Header returns a copy of headers with their values masked
+func (r *Redactor) Header(header http.Header) http.Header {
+	if r == nil || header == nil {
+		return header
+	}
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		redacted[name] = make([]string, len(values))
+		for i, value := range values {
+			redacted[name][i] = r.Value(name, value)
+		}
+	}
+	return redacted
+}
+
+// redactAuthorization masks the credentials of an authorization header, keeping its scheme
+func redactAuthorization(value string) string {
+	if scheme := authorizationScheme.FindStringSubmatch(value); scheme != nil {
+		return scheme[1] + " " + Redacted
+	}
+	return Redacted
+}
+
+// redactCookies masks the values of the cookies of a Cookie header, or of the cookie of a
+// Set-Cookie header, keeping their names and the attributes of the Set-Cookie cookie
+func redactCookies(value string, set bool) string {
+	pairs := strings.Split(value, ";")
+	for i, pair := range pairs {
+		if set && i > 0 {
+			break
+		}
+		if eq := strings.Index(pair, "="); eq >= 0 {
+			pairs[i] = pair[:eq+1] + Redacted
+		} else if strings.TrimSpace(pair) != "" {
+			pairs[i] = Redacted
+		}
+	}
+	return strings.Join(pairs, ";")
+}
+
+// redactPattern masks the matches of a custom pattern: its first capture group if it has
+// groups, or the whole match
+func redactPattern(pattern *regexp.Regexp, s string) string {
+	if pattern.NumSubexp() == 0 {
+		return pattern.ReplaceAllString(s, Redacted)
+	}
+	var b strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringSubmatchIndex(s, -1) {
+		if match[2] < 0 {
+			continue
+		}
+		b.WriteString(s[last:match[2]])
+		b.WriteString(Redacted)
+		last = match[3]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// luhn returns true if the digits of a number pass the Luhn check
+func luhn(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
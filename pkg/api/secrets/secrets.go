@@ -0,0 +1,254 @@
+// Package secrets provides scanning of API responses and assets for leaked secrets.
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule finds a kind of secret with a regular expression
+type Rule struct {
+	// Unique identifier of the rule
+	ID string `yaml:"id" json:"id"`
+	// Name of the secret, such as "AWS Access Key ID"
+	Name string `yaml:"name" json:"name"`
+	// Regular expression matching the secret
+	Regex string `yaml:"regex" json:"regex"`
+	// Capture group of the regular expression holding the secret, 0 for the whole match
+	Group int `yaml:"group" json:"group"`
+	// Minimum Shannon entropy of the secret in bits per character, which rules out
+	// placeholders such as "your_api_key_here"
+	Entropy float64 `yaml:"entropy" json:"entropy"`
+	// Severity of a leak of the secret ("Critical", "High", "Medium", "Low" or "Info")
+	Severity string `yaml:"severity" json:"severity"`
+	// Whether matches are reported unredacted, for rules finding locations such as internal
+	// URLs rather than credentials
+	Reveal bool `yaml:"reveal" json:"reveal"`
+
+	compiled *regexp.Regexp
+}
+
+// RuleFile is the layout of a rules file
+type RuleFile struct {
+	Rules []*Rule `yaml:"rules" json:"rules"`
+}
+
+// compile validates the rule and compiles its regular expression
+func (r *Rule) compile() error {
+	if r.ID == "" {
+		return api.NewAPIError("Secret rule has no id", 0)
+	}
+	if r.Name == "" {
+		r.Name = r.ID
+	}
+	if r.Severity == "" {
+		r.Severity = "Medium"
+	}
+	re, err := regexp.Compile(r.Regex)
+	if err != nil {
+		return api.NewAPIError(fmt.Sprintf("Invalid regex of secret rule '%s': %s", r.ID, err.Error()), 0)
+	}
+	if r.Group < 0 || r.Group > re.NumSubexp() {
+		return api.NewAPIError(fmt.Sprintf("Secret rule '%s' has no capture group %d", r.ID, r.Group), 0)
+	}
+	r.compiled = re
+	return nil
+}
+
+// DefaultRules returns the built-in secret rules
+func DefaultRules() []*Rule {
+	return []*Rule{
+		{ID: "aws-access-key-id", Name: "AWS Access Key ID", Severity: "High", Entropy: 3.0, Group: 1,
+			Regex: `\b((?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[0-9A-Z]{16})\b`},
+		{ID: "aws-secret-access-key", Name: "AWS Secret Access Key", Severity: "Critical", Entropy: 4.0, Group: 1,
+			Regex: `(?i)aws.{0,20}?(?:secret|key).{0,20}?['"]([A-Za-z0-9/+=]{40})['"]`},
+		{ID: "private-key", Name: "Private Key", Severity: "Critical",
+			Regex: `-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`},
+		{ID: "stripe-secret-key", Name: "Stripe Secret Key", Severity: "Critical", Entropy: 3.5, Group: 1,
+			Regex: `\b((?:sk|rk)_live_[0-9a-zA-Z]{24,99})\b`},
+		{ID: "github-token", Name: "GitHub Token", Severity: "Critical", Entropy: 3.5, Group: 1,
+			Regex: `\b(gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{60,255})\b`},
+		{ID: "slack-token", Name: "Slack Token", Severity: "High", Entropy: 3.0, Group: 1,
+			Regex: `\b(xox[abprs]-[0-9A-Za-z-]{10,72})\b`},
+		{ID: "slack-webhook", Name: "Slack Webhook URL", Severity: "Medium",
+			Regex: `https://hooks\.slack\.com/services/T[A-Z0-9]+/B[A-Z0-9]+/[A-Za-z0-9]{24}`},
+		{ID: "google-api-key", Name: "Google API Key", Severity: "Medium", Entropy: 3.5, Group: 1,
+			Regex: `\b(AIza[0-9A-Za-z_-]{35})\b`},
+		{ID: "sendgrid-api-key", Name: "SendGrid API Key", Severity: "High", Entropy: 4.0, Group: 1,
+			Regex: `\b(SG\.[0-9A-Za-z_-]{22}\.[0-9A-Za-z_-]{43})\b`},
+		{ID: "twilio-api-key", Name: "Twilio API Key", Severity: "High", Entropy: 3.0, Group: 1,
+			Regex: `\b(SK[0-9a-f]{32})\b`},
+		{ID: "jwt", Name: "JSON Web Token", Severity: "Medium", Entropy: 4.0, Group: 1,
+			Regex: `\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`},
+		{ID: "connection-string", Name: "Database Connection String", Severity: "High", Group: 1,
+			Regex: `\b((?:postgres(?:ql)?|mysql|mongodb(?:\+srv)?|redis|amqps?|mssql)://[^\s:"'@/]+:[^\s"'@/]+@[^\s"'<>]+)`},
+		{ID: "generic-secret", Name: "Generic Secret", Severity: "Medium", Entropy: 3.5, Group: 1,
+			Regex: `(?i)(?:api[_-]?key|api[_-]?secret|client[_-]?secret|secret[_-]?key|access[_-]?token|auth[_-]?token|password|passwd)["']?\s*[:=]\s*["']([A-Za-z0-9_\-+/=.!@#$%^&*]{12,})["']`},
+		{ID: "internal-url", Name: "Internal URL", Severity: "Low", Reveal: true,
+			Regex: `https?://(?:localhost|127\.0\.0\.1|10\.\d{1,3}\.\d{1,3}\.\d{1,3}|192\.168\.\d{1,3}\.\d{1,3}|172\.(?:1[6-9]|2\d|3[01])\.\d{1,3}\.\d{1,3}|[a-z0-9.-]+\.(?:internal|local|corp|lan|intranet))(?::\d+)?(?:/[^\s"'<>)\\]*)?`},
+	}
+}
+
+// ParseRules parses secret rules from YAML or JSON data
+func ParseRules(data []byte, format string) ([]*Rule, error) {
+	file := &RuleFile{}
+	switch format {
+	case "json":
+		if err := json.Unmarshal(data, file); err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Failed to parse JSON rules: %s", err.Error()), 0)
+		}
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(data, file); err != nil {
+			return nil, api.NewAPIError(fmt.Sprintf("Failed to parse YAML rules: %s", err.Error()), 0)
+		}
+	default:
+		return nil, api.NewAPIError(fmt.Sprintf("Unsupported rules format: %s", format), 0)
+	}
+	for _, rule := range file.Rules {
+		if err := rule.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return file.Rules, nil
+}
+
+// LoadRules loads secret rules from a YAML or JSON file
+func LoadRules(filePath string) ([]*Rule, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Failed to read rules file: %s", err.Error()), 0)
+	}
+	rules, err := ParseRules(data, strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), "."))
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("%s: %s", filePath, err.Error()), 0)
+	}
+	return rules, nil
+}
+
+// Finding is a secret found by a rule
+type Finding struct {
+	Rule *Rule
+	// Secret is the matched secret
+	Secret string
+	// Redacted is the secret with all but its first characters masked, or the secret itself
+	// for rules revealing their matches
+	Redacted string
+	// Entropy is the Shannon entropy of the secret in bits per character
+	Entropy float64
+	// Line is the line of the secret in the scanned data, starting at 1
+	Line int
+}
+
+// Scanner scans data for secrets with a set of rules
+type Scanner struct {
+	Rules []*Rule
+}
+
+// NewScanner creates a new scanner with the given rules, or the default rules if none are
+// given. Rules of the same id replace each other, so that a rules file can override
+// default rules.
+func NewScanner(rules ...[]*Rule) (*Scanner, error) {
+	if len(rules) == 0 {
+		rules = [][]*Rule{DefaultRules()}
+	}
+	byID := make(map[string]int)
+	scanner := &Scanner{Rules: make([]*Rule, 0)}
+	for _, set := range rules {
+		for _, rule := range set {
+			if rule.compiled == nil {
+				if err := rule.compile(); err != nil {
+					return nil, err
+				}
+			}
+			if i, ok := byID[rule.ID]; ok {
+				scanner.Rules[i] = rule
+				continue
+			}
+			byID[rule.ID] = len(scanner.Rules)
+			scanner.Rules = append(scanner.Rules, rule)
+		}
+	}
+	return scanner, nil
+}
+
+// Scan returns the secrets found in data, once per rule and secret, in the order of the rules
+// and of the secrets in the data
+func (s *Scanner) Scan(data []byte) []Finding {
+	findings := make([]Finding, 0)
+	text := string(data)
+	for _, rule := range s.Rules {
+		seen := make(map[string]bool)
+		for _, match := range rule.compiled.FindAllStringSubmatchIndex(text, -1) {
+			start, end := match[2*rule.Group], match[2*rule.Group+1]
+			if start < 0 {
+				continue
+			}
+			secret := text[start:end]
+			if seen[secret] {
+				continue
+			}
+			entropy := ShannonEntropy(secret)
+			if entropy < rule.Entropy {
+				continue
+			}
+			seen[secret] = true
+
+			redacted := secret
+			if !rule.Reveal {
+				redacted = Redact(secret)
+			}
+			findings = append(findings, Finding{
+				Rule:     rule,
+				Secret:   secret,
+				Redacted: redacted,
+				Entropy:  entropy,
+				Line:     strings.Count(text[:start], "\n") + 1,
+			})
+		}
+	}
+	return findings
+}
+
+// Redact masks all but the first characters of a secret, keeping its length
+func Redact(secret string) string {
+	runes := []rune(secret)
+	visible := 4
+	if len(runes) <= 12 {
+		visible = len(runes) / 4
+	}
+	return string(runes[:visible]) + strings.Repeat("*", len(runes)-visible)
+}
+
+// ShannonEntropy returns the Shannon entropy of a string in bits per character
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	// Sum in a fixed order so that the entropy of a string does not vary between runs
+	frequencies := make([]int, 0, len(counts))
+	for _, count := range counts {
+		frequencies = append(frequencies, count)
+	}
+	sort.Ints(frequencies)
+	entropy := 0.0
+	for _, count := range frequencies {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
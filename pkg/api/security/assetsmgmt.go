@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // ImproperAssetsMgmtTester implements testing for Improper Assets Management (API9:2019)
@@ -76,33 +75,33 @@ func (t *ImproperAssetsMgmtTester) Test(ctx context.Context, config *ffuf.Config
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract the base URL from the config
 	baseURL := extractBaseURL(config.Url)
 
 	// Test for deprecated API versions
 	if t.CheckDeprecatedFeatures {
-		t.testDeprecatedVersions(baseURL, r, result)
+		t.testDeprecatedVersions(ctx, baseURL, r, result)
 	}
 
 	// Test for beta/development endpoints
 	if t.CheckUnpublishedAPIs {
-		t.testBetaEndpoints(baseURL, r, result)
+		t.testBetaEndpoints(ctx, baseURL, r, result)
 	}
 
 	// Test for debug endpoints
-	t.testDebugEndpoints(baseURL, r, result)
+	t.testDebugEndpoints(ctx, baseURL, r, result)
 
 	// Test for backup files
-	t.testBackupFiles(baseURL, r, result)
+	t.testBackupFiles(ctx, baseURL, r, result)
 
 	// Test for common vulnerable paths
-	t.testCommonVulnerablePaths(baseURL, r, result)
+	t.testCommonVulnerablePaths(ctx, baseURL, r, result)
 
 	// Test for multiple API versions
 	if t.CheckMultipleVersions {
-		t.testMultipleAPIVersions(baseURL, r, result)
+		t.testMultipleAPIVersions(ctx, baseURL, r, result)
 	}
 
 	result.EndTime = time.Now()
@@ -111,7 +110,7 @@ func (t *ImproperAssetsMgmtTester) Test(ctx context.Context, config *ffuf.Config
 }
 
 // testDeprecatedVersions tests for deprecated API versions
-func (t *ImproperAssetsMgmtTester) testDeprecatedVersions(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *ImproperAssetsMgmtTester) testDeprecatedVersions(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	for _, version := range t.DeprecatedVersions {
 		// Create URLs with different version patterns
 		testURLs := []string{
@@ -121,11 +120,14 @@ func (t *ImproperAssetsMgmtTester) testDeprecatedVersions(baseURL string, r ffuf
 		}
 
 		for _, testURL := range testURLs {
+			if ctx.Err() != nil {
+				return
+			}
 			req := &ffuf.Request{
 				Method: "GET",
 				Url:    testURL,
 				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+					"User-Agent": testerUserAgent,
 				},
 			}
 
@@ -163,7 +165,7 @@ func (t *ImproperAssetsMgmtTester) testDeprecatedVersions(baseURL string, r ffuf
 }
 
 // testBetaEndpoints tests for beta/development endpoints
-func (t *ImproperAssetsMgmtTester) testBetaEndpoints(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *ImproperAssetsMgmtTester) testBetaEndpoints(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	for _, endpoint := range t.BetaEndpoints {
 		// Create URLs with different patterns
 		testURLs := []string{
@@ -173,11 +175,14 @@ func (t *ImproperAssetsMgmtTester) testBetaEndpoints(baseURL string, r ffuf.Runn
 		}
 
 		for _, testURL := range testURLs {
+			if ctx.Err() != nil {
+				return
+			}
 			req := &ffuf.Request{
 				Method: "GET",
 				Url:    testURL,
 				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+					"User-Agent": testerUserAgent,
 				},
 			}
 
@@ -215,7 +220,7 @@ func (t *ImproperAssetsMgmtTester) testBetaEndpoints(baseURL string, r ffuf.Runn
 }
 
 // testDebugEndpoints tests for debug endpoints
-func (t *ImproperAssetsMgmtTester) testDebugEndpoints(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *ImproperAssetsMgmtTester) testDebugEndpoints(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	for _, endpoint := range t.DebugEndpoints {
 		// Create URLs with different patterns
 		testURLs := []string{
@@ -226,11 +231,14 @@ func (t *ImproperAssetsMgmtTester) testDebugEndpoints(baseURL string, r ffuf.Run
 		}
 
 		for _, testURL := range testURLs {
+			if ctx.Err() != nil {
+				return
+			}
 			req := &ffuf.Request{
 				Method: "GET",
 				Url:    testURL,
 				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+					"User-Agent": testerUserAgent,
 				},
 			}
 
@@ -268,7 +276,7 @@ func (t *ImproperAssetsMgmtTester) testDebugEndpoints(baseURL string, r ffuf.Run
 }
 
 // testBackupFiles tests for backup files
-func (t *ImproperAssetsMgmtTester) testBackupFiles(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *ImproperAssetsMgmtTester) testBackupFiles(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	// Common files to check for backups
 	filesToCheck := []string{
 		"config.js", "config.php", "config.xml", "config.json",
@@ -279,12 +287,15 @@ func (t *ImproperAssetsMgmtTester) testBackupFiles(baseURL string, r ffuf.Runner
 
 	for _, file := range filesToCheck {
 		for _, ext := range t.BackupFiles {
+			if ctx.Err() != nil {
+				return
+			}
 			testURL := fmt.Sprintf("%s/%s%s", baseURL, file, ext)
 			req := &ffuf.Request{
 				Method: "GET",
 				Url:    testURL,
 				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+					"User-Agent": testerUserAgent,
 				},
 			}
 
@@ -321,14 +332,17 @@ func (t *ImproperAssetsMgmtTester) testBackupFiles(baseURL string, r ffuf.Runner
 }
 
 // testCommonVulnerablePaths tests for common vulnerable paths
-func (t *ImproperAssetsMgmtTester) testCommonVulnerablePaths(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *ImproperAssetsMgmtTester) testCommonVulnerablePaths(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	for _, path := range t.CommonVulnerablePaths {
+		if ctx.Err() != nil {
+			return
+		}
 		testURL := fmt.Sprintf("%s/%s", baseURL, path)
 		req := &ffuf.Request{
 			Method: "GET",
 			Url:    testURL,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
@@ -364,7 +378,7 @@ func (t *ImproperAssetsMgmtTester) testCommonVulnerablePaths(baseURL string, r f
 }
 
 // testMultipleAPIVersions tests for multiple API versions
-func (t *ImproperAssetsMgmtTester) testMultipleAPIVersions(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *ImproperAssetsMgmtTester) testMultipleAPIVersions(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	accessibleVersions := []string{}
 
 	for _, version := range t.APIVersionsToTest {
@@ -376,11 +390,14 @@ func (t *ImproperAssetsMgmtTester) testMultipleAPIVersions(baseURL string, r ffu
 		}
 
 		for _, testURL := range testURLs {
+			if ctx.Err() != nil {
+				return
+			}
 			req := &ffuf.Request{
 				Method: "GET",
 				Url:    testURL,
 				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+					"User-Agent": testerUserAgent,
 				},
 			}
 
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // BrokenAuthTester implements testing for Broken User Authentication (API2:2019)
@@ -66,21 +65,24 @@ func (t *BrokenAuthTester) Test(ctx context.Context, config *ffuf.Config) (*Test
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract potential auth endpoints from the config
 	endpoints := extractAuthEndpointsFromConfig(config)
 
 	// Test each endpoint for authentication vulnerabilities
 	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
 		// Test for weak password vulnerabilities
-		t.testWeakPasswords(endpoint, r, result)
+		t.testWeakPasswords(ctx, endpoint, r, result)
 
 		// Test for weak token vulnerabilities
-		t.testWeakTokens(endpoint, r, result)
+		t.testWeakTokens(ctx, endpoint, r, result)
 
 		// Test for JWT vulnerabilities
-		t.testJWTVulnerabilities(endpoint, r, result)
+		t.testJWTVulnerabilities(ctx, endpoint, r, result)
 	}
 
 	result.EndTime = time.Now()
@@ -89,7 +91,7 @@ func (t *BrokenAuthTester) Test(ctx context.Context, config *ffuf.Config) (*Test
 }
 
 // testWeakPasswords tests for weak password vulnerabilities
-func (t *BrokenAuthTester) testWeakPasswords(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *BrokenAuthTester) testWeakPasswords(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Skip endpoints that don't look like login endpoints
 	if !isLoginEndpoint(endpoint) {
 		return
@@ -100,6 +102,9 @@ func (t *BrokenAuthTester) testWeakPasswords(endpoint string, r ffuf.RunnerProvi
 
 	for _, username := range usernames {
 		for _, password := range t.CommonPasswords {
+			if ctx.Err() != nil {
+				return
+			}
 			// Create a login request with the username and password
 			req := &ffuf.Request{
 				Method: "POST",
@@ -143,13 +148,16 @@ func (t *BrokenAuthTester) testWeakPasswords(endpoint string, r ffuf.RunnerProvi
 }
 
 // testWeakTokens tests for weak token vulnerabilities
-func (t *BrokenAuthTester) testWeakTokens(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *BrokenAuthTester) testWeakTokens(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Skip endpoints that don't look like they require authentication
 	if !requiresAuthentication(endpoint) {
 		return
 	}
 
 	for _, token := range t.WeakTokenTests {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a request with the token
 		req := &ffuf.Request{
 			Method: "GET",
@@ -191,13 +199,16 @@ func (t *BrokenAuthTester) testWeakTokens(endpoint string, r ffuf.RunnerProvider
 }
 
 // testJWTVulnerabilities tests for JWT-specific vulnerabilities
-func (t *BrokenAuthTester) testJWTVulnerabilities(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *BrokenAuthTester) testJWTVulnerabilities(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Skip endpoints that don't look like they require authentication
 	if !requiresAuthentication(endpoint) {
 		return
 	}
 
 	for _, jwt := range t.JWTTests {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a request with the JWT
 		req := &ffuf.Request{
 			Method: "GET",
@@ -0,0 +1,227 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// defaultErrorPatterns are the error messages that indicate a payload reached an interpreter
+var defaultErrorPatterns = []string{
+	`(?i)you have an error in your sql syntax`,
+	`(?i)sql syntax.*mysql`,
+	`(?i)unclosed quotation mark`,
+	`(?i)quoted string not properly terminated`,
+	`(?i)pg::syntaxerror|postgresql.*error`,
+	`ORA-[0-9]{5}`,
+	`(?i)sqlite3?::|sqlite error`,
+	`SQLSTATE\[`,
+	`(?i)mongoerror|mongoservererror`,
+	`(?i)unterminated string`,
+	`(?i)syntax error`,
+	`(?i)ldapexception|invalid dn syntax`,
+	`(?i)xmlsyntaxerror|xml parsing error|doctype is disallowed`,
+	`(?i)traceback \(most recent call last\)`,
+	`(?i)stack trace|stacktrace`,
+	`(?i)exception in thread`,
+	`(?i)sh: [0-9]+: .*: not found|command not found`,
+}
+
+// Baseline is the control response of a request with a benign value
+type Baseline struct {
+	StatusCode int64
+	Length     int
+	Words      int
+	// Error patterns present in the control response
+	Errors map[string]bool
+}
+
+// BaselineDifference describes how a payload response differs from its baseline
+type BaselineDifference struct {
+	StatusChanged bool
+	FromStatus    int64
+	ToStatus      int64
+	LengthDelta   int
+	LengthChanged bool
+	NewErrors     []string
+}
+
+// Material returns true if the payload response differs materially from the baseline
+func (d *BaselineDifference) Material() bool {
+	return d.StatusChanged || d.LengthChanged || len(d.NewErrors) > 0
+}
+
+// String returns a description of the difference
+func (d *BaselineDifference) String() string {
+	var parts []string
+	if d.StatusChanged {
+		parts = append(parts, fmt.Sprintf("status changed from %d to %d", d.FromStatus, d.ToStatus))
+	}
+	if d.LengthChanged {
+		parts = append(parts, fmt.Sprintf("length changed by %+d bytes", d.LengthDelta))
+	}
+	if len(d.NewErrors) > 0 {
+		parts = append(parts, fmt.Sprintf("new error patterns %s", strings.Join(d.NewErrors, ", ")))
+	}
+	if len(parts) == 0 {
+		return "no material difference from baseline"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// BaselineEngine records a control response per endpoint and parameter and compares payload
+// responses against it, so that heuristics matching content that is present in normal
+// responses do not raise vulnerabilities
+type BaselineEngine struct {
+	// Minimum length difference in bytes considered material
+	MinLengthDelta int
+	// Minimum length difference relative to the control length considered material
+	LengthDeltaRatio float64
+	// Error patterns that are material when they appear in the payload response only
+	ErrorPatterns []*regexp.Regexp
+
+	runner   ffuf.RunnerProvider
+	controls map[string]*Baseline
+	mu       sync.Mutex
+}
+
+// NewBaselineEngine creates a new baseline engine using a runner for control requests
+func NewBaselineEngine(r ffuf.RunnerProvider) *BaselineEngine {
+	patterns := make([]*regexp.Regexp, 0, len(defaultErrorPatterns))
+	for _, pattern := range defaultErrorPatterns {
+		patterns = append(patterns, regexp.MustCompile(pattern))
+	}
+	return &BaselineEngine{
+		MinLengthDelta:   20,
+		LengthDeltaRatio: 0.1,
+		ErrorPatterns:    patterns,
+		runner:           r,
+		controls:         make(map[string]*Baseline),
+	}
+}
+
+// Control returns the baseline of a control request, executing it the first time
+func (e *BaselineEngine) Control(req *ffuf.Request) (*Baseline, error) {
+	key := req.Method + " " + req.Url + "\n" + string(req.Data)
+
+	e.mu.Lock()
+	baseline, ok := e.controls[key]
+	e.mu.Unlock()
+	if ok {
+		return baseline, nil
+	}
+
+	resp, err := e.runner.Execute(req)
+	if err != nil {
+		return nil, err
+	}
+	baseline = e.NewBaseline(resp)
+
+	e.mu.Lock()
+	e.controls[key] = baseline
+	e.mu.Unlock()
+	return baseline, nil
+}
+
+// NewBaseline creates a baseline from a control response
+func (e *BaselineEngine) NewBaseline(resp ffuf.Response) *Baseline {
+	baseline := &Baseline{
+		StatusCode: resp.StatusCode,
+		Length:     len(resp.Data),
+		Words:      len(strings.Fields(string(resp.Data))),
+		Errors:     make(map[string]bool),
+	}
+	for _, pattern := range e.ErrorPatterns {
+		if pattern.Match(resp.Data) {
+			baseline.Errors[pattern.String()] = true
+		}
+	}
+	return baseline
+}
+
+// Compare compares a payload response with a baseline
+func (e *BaselineEngine) Compare(baseline *Baseline, resp ffuf.Response) *BaselineDifference {
+	diff := &BaselineDifference{
+		FromStatus:  baseline.StatusCode,
+		ToStatus:    resp.StatusCode,
+		LengthDelta: len(resp.Data) - baseline.Length,
+	}
+	diff.StatusChanged = resp.StatusCode != baseline.StatusCode
+
+	delta := diff.LengthDelta
+	if delta < 0 {
+		delta = -delta
+	}
+	threshold := int(float64(baseline.Length) * e.LengthDeltaRatio)
+	if threshold < e.MinLengthDelta {
+		threshold = e.MinLengthDelta
+	}
+	diff.LengthChanged = delta >= threshold
+
+	for _, pattern := range e.ErrorPatterns {
+		if !baseline.Errors[pattern.String()] && pattern.Match(resp.Data) {
+			diff.NewErrors = append(diff.NewErrors, pattern.String())
+		}
+	}
+	return diff
+}
+
+// Differs executes the control request if needed and compares the payload response with it.
+// If the control request fails, the payload response is considered different.
+func (e *BaselineEngine) Differs(control *ffuf.Request, resp ffuf.Response) (bool, string) {
+	baseline, err := e.Control(control)
+	if err != nil {
+		return true, "baseline unavailable"
+	}
+	diff := e.Compare(baseline, resp)
+	return diff.Material(), diff.String()
+}
+
+// insertionPoint builds the request carrying a value in a parameter. Payload and control
+// requests are built from the same insertion point, so that they differ by the value only,
+// however the payload is encoded.
+type insertionPoint func(value string) *ffuf.Request
+
+// queryInsertionPoint returns the insertion point of a query parameter of an endpoint
+func queryInsertionPoint(endpoint, paramName string) insertionPoint {
+	return func(value string) *ffuf.Request {
+		return &ffuf.Request{
+			Method: "GET",
+			Url:    addOrReplaceParameter(endpoint, paramName, value),
+			Headers: map[string]string{
+				"User-Agent": testerUserAgent,
+			},
+		}
+	}
+}
+
+// jsonInsertionPoint returns the insertion point of a property of a JSON body. The value is
+// inserted between quotes if quoted is set, and as-is otherwise.
+func jsonInsertionPoint(endpoint, paramName string, quoted bool) insertionPoint {
+	format := `{"%s":%s}`
+	if quoted {
+		format = `{"%s":"%s"}`
+	}
+	return func(value string) *ffuf.Request {
+		return &ffuf.Request{
+			Method: "POST",
+			Url:    endpoint,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"User-Agent":   testerUserAgent,
+			},
+			Data: []byte(fmt.Sprintf(format, paramName, value)),
+		}
+	}
+}
+
+// withControlBody returns a copy of a payload request with a benign body
+func withControlBody(req *ffuf.Request, body string) *ffuf.Request {
+	control := *req
+	control.Data = []byte(body)
+	return &control
+}
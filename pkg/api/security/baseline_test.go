@@ -0,0 +1,81 @@
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestInsertionPoints(t *testing.T) {
+	// The payload also appears in the path of the endpoint
+	point := queryInsertionPoint("https://api.example.com/o'reilly/books?q=test&page=2", "q")
+	if req := point("%27%20OR%20%271%27%3D%271"); req.Url != "https://api.example.com/o'reilly/books?q=%27%20OR%20%271%27%3D%271&page=2" {
+		t.Errorf("Expected the encoded payload in the parameter, got %s", req.Url)
+	}
+	if req := point("1"); req.Url != "https://api.example.com/o'reilly/books?q=1&page=2" {
+		t.Errorf("Expected the control value in the parameter only, got %s", req.Url)
+	}
+
+	point = jsonInsertionPoint("https://api.example.com/books", "q", true)
+	if req := point(`' OR "1"="1`); req.Method != "POST" || string(req.Data) != `{"q":"' OR "1"="1"}` {
+		t.Errorf("Expected the payload between quotes, got %s", req.Data)
+	}
+	if req := point("1"); string(req.Data) != `{"q":"1"}` || req.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected the control value between quotes, got %s", req.Data)
+	}
+	point = jsonInsertionPoint("https://api.example.com/books", "q", false)
+	if req := point(`{"$ne": null}`); string(req.Data) != `{"q":{"$ne": null}}` {
+		t.Errorf("Expected the payload as-is, got %s", req.Data)
+	}
+}
+
+func TestInjectionTester_BaselineControl(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/o'reilly/books?q=test")
+	tester := NewInjectionTester()
+	tester.TestTimeBased = false
+	tester.SQLInjectionPayloads = []string{"'"}
+
+	// sqlServer answers with a SQL error if the query string contains a quote, and always if
+	// broken is set
+	sqlServer := func(broken bool) func(req *ffuf.Request) ffuf.Response {
+		return func(req *ffuf.Request) ffuf.Response {
+			query := ""
+			if parts := strings.SplitN(req.Url, "?", 2); len(parts) == 2 {
+				query = parts[1]
+			}
+			if broken || strings.Contains(query, "'") || strings.Contains(query, "%27") {
+				return ffuf.Response{StatusCode: 500, Data: []byte("You have an error in your SQL syntax near '1'")}
+			}
+			return ffuf.Response{Data: []byte(`{"books":[]}`)}
+		}
+	}
+
+	// The control requests carry the benign value in the parameter, not where the payload
+	// appears first
+	result, runner := runFake(t, tester, conf, sqlServer(false))
+	findings := 0
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Name == "SQL Injection" && vuln.Parameter == "q" {
+			findings++
+		}
+	}
+	if findings != 1 {
+		t.Errorf("Expected the SQL injection of the query parameter to be reported, got %v", vulnerabilityNames(result))
+	}
+	control := false
+	for _, req := range runner.Requests() {
+		control = control || req.Url == "https://api.example.com/o'reilly/books?q=1"
+	}
+	if !control {
+		t.Errorf("Expected a control request with the benign value")
+	}
+
+	// Errors of the control requests are not evidence
+	result, _ = runFake(t, tester, conf, sqlServer(true))
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Name == "SQL Injection" {
+			t.Errorf("Expected errors present in the control response not to be reported, got %s", vuln.Evidence)
+		}
+	}
+}
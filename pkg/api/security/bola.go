@@ -2,16 +2,21 @@
 package security
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
+	"io"
+	"math/big"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ffuf/ffuf/v2/pkg/api/diff"
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // BrokenObjectLevelAuthTester implements testing for Broken Object Level Authorization (API1:2019)
@@ -21,6 +26,19 @@ type BrokenObjectLevelAuthTester struct {
 	IDParameterNames []string
 	TestUserIDs      []string
 	TestObjectIDs    []string
+	// IdentityA and IdentityB are users whose objects are requested as the other user. They
+	// are loaded from the identities of the config if not set.
+	IdentityA *BOLAIdentity
+	IdentityB *BOLAIdentity
+	// IdentityEndpoints are the endpoint templates of the objects of the identities, containing
+	// BOLAIDPlaceholder (e.g., https://api.example.com/users/{id}). The endpoints of the config
+	// are used if empty.
+	IdentityEndpoints []string
+	// TestModification enables requests of the identities with the modification methods,
+	// which may change or delete the objects of the other identity
+	TestModification    bool
+	ModificationMethods []string
+	ModificationBody    string
 }
 
 // NewBrokenObjectLevelAuthTester creates a new tester for Broken Object Level Authorization
@@ -31,8 +49,11 @@ func NewBrokenObjectLevelAuthTester() *BrokenObjectLevelAuthTester {
 			"id", "user_id", "user", "account", "account_id", "customer", "customer_id",
 			"object", "object_id", "record", "record_id", "uuid", "guid",
 		},
-		TestUserIDs:   []string{},
-		TestObjectIDs: []string{},
+		TestUserIDs:         []string{},
+		TestObjectIDs:       []string{},
+		IdentityEndpoints:   []string{},
+		ModificationMethods: []string{"PUT", "PATCH", "DELETE"},
+		ModificationBody:    "{}",
 	}
 }
 
@@ -48,7 +69,7 @@ func (t *BrokenObjectLevelAuthTester) GetName() string {
 
 // GetDescription returns a description of the security test
 func (t *BrokenObjectLevelAuthTester) GetDescription() string {
-	return "Tests for API endpoints that don't properly verify that the requesting user has the necessary permissions to access the requested resource."
+	return "Tests for API endpoints that don't properly verify that the requesting user has the necessary permissions to access the requested resource, and with two configured identities, for users reading or modifying the objects of the other user."
 }
 
 // Test runs the security test against the target
@@ -58,12 +79,22 @@ func (t *BrokenObjectLevelAuthTester) Test(ctx context.Context, config *ffuf.Con
 		StartTime: time.Now(),
 	}
 
+	identityA, identityB, err := t.identities(config)
+	if err != nil {
+		result.Error = err
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		return result, err
+	}
+
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
-	// If no test IDs are provided, generate some
-	if len(t.TestObjectIDs) == 0 {
-		t.TestObjectIDs = generateTestIDs(t.MaxIDsToTest)
+	// If no test IDs are provided, use the identifiers of the value dictionary of the scan,
+	// completed with generated ones
+	testIDs := t.TestObjectIDs
+	if len(testIDs) == 0 {
+		testIDs = candidateIDs(ctx, t.MaxIDsToTest)
 	}
 
 	// Extract potential endpoints from the config
@@ -71,13 +102,22 @@ func (t *BrokenObjectLevelAuthTester) Test(ctx context.Context, config *ffuf.Con
 
 	// Test each endpoint for BOLA vulnerabilities
 	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
 		// Skip endpoints that don't look like they would have object IDs
 		if !containsIDPattern(endpoint) {
 			continue
 		}
 
+		// Request an object which does not exist, to tell objects from a catch-all response
+		control := controlResponse(r, "GET", replaceIDInEndpoint(endpoint, controlIdentifier()), config.Headers)
+
 		// Test the endpoint with different object IDs
-		for _, testID := range t.TestObjectIDs {
+		for _, testID := range testIDs {
+			if ctx.Err() != nil {
+				break
+			}
 			// Create a modified endpoint with the test ID
 			modifiedEndpoint := replaceIDInEndpoint(endpoint, testID)
 			if modifiedEndpoint == endpoint {
@@ -98,7 +138,7 @@ func (t *BrokenObjectLevelAuthTester) Test(ctx context.Context, config *ffuf.Con
 			}
 
 			// Check if the response indicates a successful access
-			if isSuccessfulAccess(resp) {
+			if isSuccessfulAccess(resp) && !matchesControl(control, resp) {
 				// Create a vulnerability info
 				vuln := VulnerabilityInfo{
 					Type:        VulnBrokenObjectLevelAuth,
@@ -122,6 +162,11 @@ func (t *BrokenObjectLevelAuthTester) Test(ctx context.Context, config *ffuf.Con
 		}
 	}
 
+	// Request the objects of each configured identity as the other identity
+	if identityA != nil {
+		t.testCrossUser(ctx, config, identityA, identityB, r, result)
+	}
+
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 	return result, nil
@@ -138,6 +183,47 @@ func generateTestIDs(count int) []string {
 	return ids
 }
 
+// valueDictionaryKey is the context key of the value dictionary of a scan
+type valueDictionaryKey struct{}
+
+// WithValueDictionary returns a context that makes the security test runs it is passed to use
+// the candidate parameter values of a dictionary, such as the identifiers of real objects for
+// BOLA testing. The values of the JSON responses to the GET requests of the testers are added
+// to the dictionary as they are received.
+func WithValueDictionary(ctx context.Context, values *parser.ValueDictionary) context.Context {
+	ctx = context.WithValue(ctx, valueDictionaryKey{}, values)
+	return WithRequestHandler(ctx, func(req *ffuf.Request, resp *ffuf.Response, err error) {
+		if err != nil || resp == nil || req.Method != "GET" || resp.StatusCode != http.StatusOK || resp.Truncated ||
+			!strings.Contains(strings.ToLower(resp.ContentType), "json") {
+			return
+		}
+		if u, err := url.Parse(req.Url); err == nil {
+			values.AddJSON(u.Path, resp.Data)
+		}
+	})
+}
+
+// candidateIDs returns up to count object identifiers: those of the value dictionary of the
+// context, the observed ones first, completed with generated identifiers
+func candidateIDs(ctx context.Context, count int) []string {
+	candidates := generateTestIDs(count)
+	if values, ok := ctx.Value(valueDictionaryKey{}).(*parser.ValueDictionary); ok {
+		candidates = append(values.Identifiers(), candidates...)
+	}
+	ids := make([]string, 0, count)
+	seen := make(map[string]bool)
+	for _, id := range candidates {
+		if len(ids) == count {
+			break
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // extractEndpointsFromConfig extracts potential endpoints from the config
 func extractEndpointsFromConfig(config *ffuf.Config) []string {
 	// In a real implementation, this would extract endpoints from the config
@@ -217,6 +303,66 @@ func isSuccessfulAccess(resp ffuf.Response) bool {
 	return resp.StatusCode >= 200 && resp.StatusCode < 300
 }
 
+// controlIdentifier returns a numeric identifier of an object which should not exist
+func controlIdentifier() string {
+	n, err := rand.Int(rand.Reader, big.NewInt(1e11))
+	if err != nil {
+		return "987654321098"
+	}
+	return strconv.FormatInt(9e11+n.Int64(), 10)
+}
+
+// controlSiblingURL returns the URL of an endpoint with its last path segment replaced by a
+// name which should not exist
+func controlSiblingURL(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	u.Path = path[:strings.LastIndex(path, "/")+1] + "ffuf" + controlIdentifier()
+	u.RawPath = ""
+	return u.String()
+}
+
+// controlResponse requests a control URL which should not exist, and returns its response if
+// it is successful, such as a soft 404 page or the fallback page of a single page application
+func controlResponse(r ffuf.RunnerProvider, method, controlURL string, headers map[string]string) *ffuf.Response {
+	if controlURL == "" {
+		return nil
+	}
+	// The runner sets default headers, the headers of the caller are copied
+	reqHeaders := make(map[string]string, len(headers))
+	for name, value := range headers {
+		reqHeaders[name] = value
+	}
+	resp, err := r.Execute(&ffuf.Request{Method: method, Url: controlURL, Headers: reqHeaders})
+	if err != nil || !isSuccessfulAccess(resp) {
+		return nil
+	}
+	return &resp
+}
+
+// matchesControl checks if a response is the same resource as a successful control response,
+// in which case its success does not show access to the requested resource
+func matchesControl(control *ffuf.Response, resp ffuf.Response) bool {
+	return control != nil && diff.SameResource(control, &resp, nil)
+}
+
+// configRequest returns the configured request for an endpoint, with the method, headers and
+// body of the configuration, and GET if it sets no method
+func configRequest(config *ffuf.Config, endpoint string) *ffuf.Request {
+	method := config.Method
+	if method == "" {
+		method = "GET"
+	}
+	req := &ffuf.Request{Method: method, Url: endpoint, Headers: make(map[string]string), Data: []byte(config.Data)}
+	for name, value := range config.Headers {
+		req.Headers[name] = value
+	}
+	return req
+}
+
 // convertToHTTPRequest converts an ffuf.Request to an http.Request
 func convertToHTTPRequest(req *ffuf.Request) *http.Request {
 	httpReq, _ := http.NewRequest(req.Method, req.Url, strings.NewReader(string(req.Data)))
@@ -226,13 +372,24 @@ func convertToHTTPRequest(req *ffuf.Request) *http.Request {
 	return httpReq
 }
 
-// convertToHTTPResponse converts an ffuf.Response to an http.Response
+// convertToHTTPResponse converts an ffuf.Response to an http.Response, keeping its headers
+// and body as evidence. The body of a compressed response is decoded, so the response is
+// marked as uncompressed, its Content-Encoding header recording the original encoding.
 func convertToHTTPResponse(resp ffuf.Response) *http.Response {
-	// This is a simplified conversion
+	header := make(http.Header, len(resp.Headers))
+	for name, values := range resp.Headers {
+		header[name] = append([]string(nil), values...)
+	}
+	if resp.ContentEncoding != "" {
+		header.Set("Content-Encoding", resp.ContentEncoding)
+	}
 	return &http.Response{
-		StatusCode: int(resp.StatusCode),
-		Body:       nil, // We don't need the body for now
-		Header:     make(http.Header),
+		StatusCode:    int(resp.StatusCode),
+		Proto:         resp.Proto,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(resp.Data)),
+		ContentLength: int64(len(resp.Data)),
+		Uncompressed:  resp.ContentEncoding != "",
 	}
 }
 
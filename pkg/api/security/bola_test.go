@@ -0,0 +1,123 @@
+package security
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// objectServer answers the requests for the objects of alice (101) and bob (201), checking
+// the owner of the objects read and written if checkOwner is set
+func objectServer(checkOwner bool) func(req *ffuf.Request) ffuf.Response {
+	owners := map[string]string{"101": "Bearer alice", "201": "Bearer bob"}
+	return func(req *ffuf.Request) ffuf.Response {
+		u, _ := url.Parse(req.Url)
+		id := path.Base(u.Path)
+		owner, ok := owners[id]
+		auth := req.Headers["Authorization"]
+		switch {
+		case auth != "Bearer alice" && auth != "Bearer bob":
+			return ffuf.Response{StatusCode: 401}
+		case !ok:
+			return ffuf.Response{StatusCode: 404}
+		case checkOwner && auth != owner:
+			return ffuf.Response{StatusCode: 403}
+		}
+		return ffuf.Response{ContentType: "application/json", Data: []byte(`{"id":` + id + `,"owner":"` + strings.TrimPrefix(owner, "Bearer ") + `","email":"user` + id + `@example.com"}`)}
+	}
+}
+
+func TestParseBOLAIdentities(t *testing.T) {
+	identities, err := ParseBOLAIdentities([]string{
+		"alice:101,102=Authorization: Bearer a:b==",
+		"bob:201=Authorization: Bearer bob",
+		"alice=Cookie: session=alice",
+	})
+	if err != nil || len(identities) != 2 {
+		t.Fatalf("Expected two identities, got %d: %v", len(identities), err)
+	}
+	alice := identities[0]
+	if alice.Name != "alice" || len(alice.ObjectIDs) != 2 || alice.Headers["Authorization"] != "Bearer a:b==" || alice.Headers["Cookie"] != "session=alice" {
+		t.Errorf("Expected the headers and objects of alice to be merged, got %+v", alice)
+	}
+	if identities, err := ParseBOLAIdentities(nil); err != nil || len(identities) != 0 {
+		t.Errorf("Expected no identities, got %d: %v", len(identities), err)
+	}
+
+	for _, values := range [][]string{
+		{"alice:101=Authorization: Bearer alice"},
+		{"alice:101=Authorization: Bearer alice", "bob:201=Authorization: Bearer bob", "carol:301=Authorization: Bearer carol"},
+		{"alice:101=Authorization: Bearer alice", "bob=Authorization: Bearer bob"},
+		{"alice:101", "bob:201=Authorization: Bearer bob"},
+		{"alice:101=Bearer alice", "bob:201=Authorization: Bearer bob"},
+	} {
+		if _, err := ParseBOLAIdentities(values); err == nil {
+			t.Errorf("Expected %v to fail", values)
+		}
+	}
+}
+
+func TestBrokenObjectLevelAuthTester_Identities(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/users/101")
+	conf.Headers = map[string]string{"authorization": "Bearer scanner"}
+	conf.APISecurityIdentities = []string{"alice:101=Authorization: Bearer alice", "bob:201=Authorization: Bearer bob"}
+
+	// Each identity reads the object of the other identity
+	result, runner := runFake(t, NewBrokenObjectLevelAuthTester(), conf, objectServer(false))
+	evidence := make([]string, 0)
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Name == "Broken Object Level Authorization (Read)" {
+			evidence = append(evidence, vuln.Evidence)
+		}
+	}
+	if len(evidence) != 2 || !strings.Contains(strings.Join(evidence, "\n"), "Identity 'bob' read object 101 owned by identity 'alice'") {
+		t.Errorf("Expected each identity to read the object of the other, got %v", evidence)
+	}
+	for _, req := range runner.Requests() {
+		if _, ok := req.Headers["authorization"]; ok && req.Headers["Authorization"] != "" {
+			t.Fatalf("Expected the header of the identity to replace the header of the config, got %v", req.Headers)
+		}
+	}
+
+	// Modifications of the objects of the other identity are tested if enabled
+	tester := NewBrokenObjectLevelAuthTester()
+	tester.TestModification = true
+	tester.ModificationMethods = []string{"PUT"}
+	result, _ = runFake(t, tester, conf, objectServer(false))
+	if names := strings.Join(vulnerabilityNames(result), ","); strings.Count(names, "Broken Object Level Authorization (Modify)") != 2 {
+		t.Errorf("Expected each identity to modify the object of the other, got %s", names)
+	}
+
+	// The objects of each identity are denied to the other identity
+	tester.TestModification = true
+	result, _ = runFake(t, tester, conf, objectServer(true))
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability, got %v", vulnerabilityNames(result))
+	}
+
+	// Invalid identities fail the test
+	conf.APISecurityIdentities = []string{"alice:101=Authorization: Bearer alice"}
+	if _, err := NewBrokenObjectLevelAuthTester().Test(conf.Context, conf); err == nil || !strings.Contains(err.Error(), "expected two identities") {
+		t.Errorf("Expected a single identity to fail, got %v", err)
+	}
+}
+
+func TestBrokenObjectLevelAuthTester_IdentityEndpoints(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/")
+	tester := NewBrokenObjectLevelAuthTester()
+	tester.IdentityA = NewBOLATokenIdentity("alice", "alice", "101")
+	tester.IdentityB = NewBOLATokenIdentity("bob", "bob", "201")
+	tester.IdentityEndpoints = []string{"https://api.example.com/documents/{id}"}
+	result, runner := runFake(t, tester, conf, objectServer(false))
+	if len(result.Vulnerabilities) != 2 {
+		t.Errorf("Expected each identity to read the object of the other, got %v", vulnerabilityNames(result))
+	}
+	for _, req := range runner.Requests() {
+		if !strings.HasPrefix(req.Url, "https://api.example.com/documents/") {
+			t.Errorf("Expected the objects of the endpoint templates to be requested, got %s", req.Url)
+		}
+	}
+}
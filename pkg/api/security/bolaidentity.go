@@ -0,0 +1,257 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/diff"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// BOLAIDPlaceholder is the placeholder for the object identifier in BOLA endpoint templates
+const BOLAIDPlaceholder = "{id}"
+
+// BOLAIdentity represents a user of the API and the objects it owns
+type BOLAIdentity struct {
+	// Name of the identity used in the evidence (e.g., "alice")
+	Name string
+	// Bearer token used to authenticate as the identity
+	Token string
+	// Username and password used for basic authentication if no token is set
+	Username string
+	Password string
+	// Additional headers sent as the identity (e.g., session cookies)
+	Headers map[string]string
+	// Identifiers of the objects owned by the identity
+	ObjectIDs []string
+}
+
+// NewBOLATokenIdentity creates an identity that authenticates with a bearer token
+func NewBOLATokenIdentity(name, token string, objectIDs ...string) *BOLAIdentity {
+	return &BOLAIdentity{
+		Name:      name,
+		Token:     token,
+		ObjectIDs: objectIDs,
+	}
+}
+
+// NewBOLACredentialsIdentity creates an identity that authenticates with a username and password
+func NewBOLACredentialsIdentity(name, username, password string, objectIDs ...string) *BOLAIdentity {
+	return &BOLAIdentity{
+		Name:      name,
+		Username:  username,
+		Password:  password,
+		ObjectIDs: objectIDs,
+	}
+}
+
+// headers returns the request headers for the identity on top of the base headers
+func (i *BOLAIdentity) headers(base map[string]string) map[string]string {
+	own := make(map[string]string, len(i.Headers)+1)
+	if i.Token != "" {
+		own["Authorization"] = "Bearer " + i.Token
+	} else if i.Username != "" {
+		own["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(i.Username+":"+i.Password))
+	}
+	for k, v := range i.Headers {
+		own[k] = v
+	}
+	headers := make(map[string]string, len(base)+len(own))
+	for k, v := range base {
+		headers[k] = v
+	}
+	// The headers of the identity replace those of the base, whatever their case
+	for k, v := range own {
+		for existing := range headers {
+			if strings.EqualFold(existing, k) {
+				delete(headers, existing)
+			}
+		}
+		headers[k] = v
+	}
+	return headers
+}
+
+// ParseBOLAIdentities parses identities in the form name:id1,id2=Name: value, a header sent as
+// the user name, which owns the objects of the identifiers. The headers and identifiers of the
+// values of the same name are merged. Either no identities or two are expected.
+func ParseBOLAIdentities(values []string) ([]*BOLAIdentity, error) {
+	identities := make([]*BOLAIdentity, 0, 2)
+	byName := make(map[string]*BOLAIdentity)
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid identity: %s, expected name:id1,id2=Name: value", value)
+		}
+		name, ids, _ := strings.Cut(parts[0], ":")
+		name = strings.TrimSpace(name)
+		header := strings.SplitN(parts[1], ":", 2)
+		if name == "" || len(header) != 2 || strings.TrimSpace(header[0]) == "" {
+			return nil, fmt.Errorf("invalid identity: %s, expected name:id1,id2=Name: value", value)
+		}
+		identity, ok := byName[name]
+		if !ok {
+			identity = &BOLAIdentity{Name: name, Headers: make(map[string]string)}
+			byName[name] = identity
+			identities = append(identities, identity)
+		}
+		identity.Headers[strings.TrimSpace(header[0])] = strings.TrimSpace(header[1])
+		for _, id := range strings.Split(ids, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				identity.ObjectIDs = append(identity.ObjectIDs, id)
+			}
+		}
+	}
+	if len(identities) != 0 && len(identities) != 2 {
+		return nil, fmt.Errorf("expected two identities, got %d", len(identities))
+	}
+	for _, identity := range identities {
+		if len(identity.ObjectIDs) == 0 {
+			return nil, fmt.Errorf("identity %s owns no objects, expected name:id1,id2=Name: value", identity.Name)
+		}
+	}
+	return identities, nil
+}
+
+// identities returns the two identities of the cross-user test, the identities of the config
+// if the tester sets none, or nil if no identities are configured
+func (t *BrokenObjectLevelAuthTester) identities(config *ffuf.Config) (*BOLAIdentity, *BOLAIdentity, error) {
+	if t.IdentityA != nil && t.IdentityB != nil {
+		return t.IdentityA, t.IdentityB, nil
+	}
+	identities, err := ParseBOLAIdentities(config.APISecurityIdentities)
+	if err != nil || len(identities) == 0 {
+		return nil, nil, err
+	}
+	return identities[0], identities[1], nil
+}
+
+// testCrossUser requests the objects of each identity as the other identity
+func (t *BrokenObjectLevelAuthTester) testCrossUser(ctx context.Context, config *ffuf.Config, identityA, identityB *BOLAIdentity, r ffuf.RunnerProvider, result *TestResult) {
+	endpoints := t.IdentityEndpoints
+	if len(endpoints) == 0 {
+		endpoints = extractEndpointsFromConfig(config)
+	}
+	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			return
+		}
+		t.testIdentities(ctx, endpoint, identityA, identityB, config.Headers, r, result)
+		t.testIdentities(ctx, endpoint, identityB, identityA, config.Headers, r, result)
+	}
+}
+
+// testIdentities requests the objects of the owner as the attacker
+func (t *BrokenObjectLevelAuthTester) testIdentities(ctx context.Context, endpoint string, owner, attacker *BOLAIdentity, baseHeaders map[string]string, r ffuf.RunnerProvider, result *TestResult) {
+	for _, objectID := range owner.ObjectIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		objectURL := bolaObjectURL(endpoint, objectID)
+		if objectURL == "" {
+			continue // No identifier in the endpoint
+		}
+
+		// Request the object as its owner to confirm that it exists
+		ownerReq := &ffuf.Request{
+			Method:  "GET",
+			Url:     objectURL,
+			Headers: owner.headers(baseHeaders),
+		}
+		ownerResp, err := r.Execute(ownerReq)
+		if err != nil || !isSuccessfulAccess(ownerResp) {
+			continue
+		}
+
+		// Request the object as the other identity
+		attackerReq := &ffuf.Request{
+			Method:  "GET",
+			Url:     objectURL,
+			Headers: attacker.headers(baseHeaders),
+		}
+		attackerResp, err := r.Execute(attackerReq)
+		if err == nil && isSuccessfulAccess(attackerResp) && isSameObject(ownerResp, attackerResp) {
+			result.Vulnerabilities = append(result.Vulnerabilities, bolaVulnerability(
+				"Broken Object Level Authorization (Read)",
+				fmt.Sprintf("Identity '%s' read object %s owned by identity '%s'", attacker.Name, objectID, owner.Name),
+				"High", 7.5, attackerReq, attackerResp,
+			))
+		}
+
+		if !t.TestModification {
+			continue
+		}
+		for _, method := range t.ModificationMethods {
+			headers := attacker.headers(baseHeaders)
+			var data []byte
+			if method != "DELETE" {
+				headers["Content-Type"] = "application/json"
+				data = []byte(t.ModificationBody)
+			}
+			modifyReq := &ffuf.Request{
+				Method:  method,
+				Url:     objectURL,
+				Headers: headers,
+				Data:    data,
+			}
+			modifyResp, err := r.Execute(modifyReq)
+			if err != nil || !isSuccessfulAccess(modifyResp) {
+				continue
+			}
+			result.Vulnerabilities = append(result.Vulnerabilities, bolaVulnerability(
+				"Broken Object Level Authorization (Modify)",
+				fmt.Sprintf("Identity '%s' modified object %s owned by identity '%s' with %s (status %d)", attacker.Name, objectID, owner.Name, method, modifyResp.StatusCode),
+				"Critical", 9.1, modifyReq, modifyResp,
+			))
+		}
+	}
+}
+
+// bolaObjectURL returns the URL of an object for an endpoint template, replacing the
+// placeholder or the first identifier in the endpoint
+func bolaObjectURL(endpoint, objectID string) string {
+	if strings.Contains(endpoint, BOLAIDPlaceholder) {
+		return strings.ReplaceAll(endpoint, BOLAIDPlaceholder, url.PathEscape(objectID))
+	}
+	// The identifier of the endpoint is marked, so that an endpoint of the object itself is
+	// told from an endpoint without identifier
+	const marker = "ffufobjectid"
+	marked := replaceIDInEndpoint(endpoint, marker)
+	if marked == endpoint {
+		return ""
+	}
+	return strings.ReplaceAll(marked, marker, url.PathEscape(objectID))
+}
+
+// isSameObject checks if the response to the other identity is the object returned to its
+// owner, ignoring volatile values such as timestamps and request identifiers
+func isSameObject(ownerResp, attackerResp ffuf.Response) bool {
+	return diff.SameResource(&ownerResp, &attackerResp, nil)
+}
+
+// bolaVulnerability creates a vulnerability info for cross-user access
+func bolaVulnerability(name, evidence, severity string, cvss float64, req *ffuf.Request, resp ffuf.Response) VulnerabilityInfo {
+	return VulnerabilityInfo{
+		Type:        VulnBrokenObjectLevelAuth,
+		Name:        name,
+		Description: "The API endpoint allows a user to access objects owned by another user.",
+		Severity:    severity,
+		Request:     convertToHTTPRequest(req),
+		Response:    convertToHTTPResponse(resp),
+		Evidence:    evidence,
+		Remediation: "Implement object level authorization checks in every function that accesses a data source using an identifier from the client. Verify that the authenticated user owns or is permitted to access the requested object.",
+		CVSS:        cvss,
+		CWE:         "CWE-639",
+		References: []string{
+			"https://owasp.org/API-Security/editions/2019/en/0xa1-broken-object-level-authorization/",
+			"https://cheatsheetseries.owasp.org/cheatsheets/Authorization_Cheat_Sheet.html",
+		},
+		DetectedAt: time.Now(),
+	}
+}
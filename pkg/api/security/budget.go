@@ -0,0 +1,51 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TesterBudgets are the maximum running times of the security testers of a scan against each
+// target. Once its budget is spent, the requests of a tester fail without being sent, so that
+// it completes with the vulnerabilities found so far.
+type TesterBudgets struct {
+	// Default is the budget of the testers without their own budget, 0 for no limit
+	Default time.Duration
+	// Types are the budgets of the testers of vulnerability types
+	Types map[VulnerabilityType]time.Duration
+}
+
+// ParseTesterBudgets parses budgets in the form type=duration, e.g. injection=2m, or a bare
+// duration setting the default budget
+func ParseTesterBudgets(values []string) (*TesterBudgets, error) {
+	budgets := &TesterBudgets{Types: make(map[VulnerabilityType]time.Duration)}
+	for _, value := range values {
+		name, duration := "default", value
+		if parts := strings.SplitN(value, "=", 2); len(parts) == 2 {
+			name, duration = strings.TrimSpace(parts[0]), parts[1]
+		}
+		budget, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || budget <= 0 {
+			return nil, fmt.Errorf("invalid tester budget: %s, expected type=duration or a duration, e.g. injection=2m", value)
+		}
+		if name == "default" {
+			budgets.Default = budget
+			continue
+		}
+		vulnType, err := ParseVulnerabilityType(name)
+		if err != nil {
+			return nil, err
+		}
+		budgets.Types[vulnType] = budget
+	}
+	return budgets, nil
+}
+
+// Budget returns the budget of the tester of a vulnerability type, 0 for no limit
+func (b *TesterBudgets) Budget(vulnType VulnerabilityType) time.Duration {
+	if budget, ok := b.Types[vulnType]; ok {
+		return budget
+	}
+	return b.Default
+}
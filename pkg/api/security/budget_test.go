@@ -0,0 +1,53 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestTesterBudgets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// The injection tester is stopped by its budget, the fetch tester completes
+	registry := NewSecurityTestRegistry()
+	registry.Register(NewInjectionTester())
+	registry.Register(&fetchTester{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = server.URL + "/search?q=test"
+	conf.Threads = 2
+	conf.APISecurityBudgets = []string{"injection=200ms"}
+	results, err := registry.RunAll(ctx, &conf)
+	if err != nil {
+		t.Fatalf("RunAll returned an error: %s", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected the results of both testers, got %d", len(results))
+	}
+	byName := make(map[string]*TestResult)
+	for _, result := range results {
+		byName[result.TestName] = result
+	}
+	if injection := byName["Injection"]; injection == nil || !injection.Incomplete || injection.SkippedRequests == 0 || injection.Duration > 2*time.Second {
+		t.Fatalf("Expected the injection tester to be stopped by its budget, got %+v", injection)
+	}
+	if fetch := byName["Fetch Tester"]; fetch == nil || fetch.Incomplete || len(fetch.Vulnerabilities) != 1 {
+		t.Errorf("Expected the fetch tester to complete, got %+v", fetch)
+	}
+
+	conf.APISecurityBudgets = []string{"injection=soon"}
+	if _, err := registry.RunAll(ctx, &conf); err == nil || !strings.Contains(err.Error(), "invalid tester budget") {
+		t.Errorf("Expected an invalid budget error, got %v", err)
+	}
+}
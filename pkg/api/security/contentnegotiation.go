@@ -0,0 +1,360 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/contenttype"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// ContentNegotiationTester implements testing for undeclared request and response formats
+// accepted through content negotiation, and for negotiation headers causing server errors or
+// partially localized responses
+type ContentNegotiationTester struct {
+	// Configuration options
+	AcceptTypes        []string
+	Languages          []string
+	TestRequestFormats bool
+	TestCharsets       bool
+	TestAcceptFormats  bool
+	TestLanguages      bool
+	TestQualityValues  bool
+}
+
+// NewContentNegotiationTester creates a new tester for content negotiation
+func NewContentNegotiationTester() *ContentNegotiationTester {
+	return &ContentNegotiationTester{
+		AcceptTypes:        append([]string{}, contenttype.DefaultAcceptTypes...),
+		Languages:          append([]string{}, contenttype.DefaultLanguages...),
+		TestRequestFormats: true,
+		TestCharsets:       true,
+		TestAcceptFormats:  true,
+		TestLanguages:      true,
+		TestQualityValues:  true,
+	}
+}
+
+// GetType returns the type of vulnerability this tester checks for
+func (t *ContentNegotiationTester) GetType() VulnerabilityType {
+	return VulnContentNegotiation
+}
+
+// GetName returns the name of the security test
+func (t *ContentNegotiationTester) GetName() string {
+	return "Content Negotiation"
+}
+
+// GetDescription returns a description of the security test
+func (t *ContentNegotiationTester) GetDescription() string {
+	return "Tests for API endpoints that accept request bodies in undeclared formats or charsets, or serve undeclared response formats, which expose hidden parsers, enable cross-site request forgery and evade web application firewalls, and for Accept and Accept-Language headers causing server errors or partially translated responses."
+}
+
+// Test runs the security test against the target
+func (t *ContentNegotiationTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	result := &TestResult{
+		TestName:  t.GetName(),
+		StartTime: time.Now(),
+	}
+
+	// Create a runner for making HTTP requests
+	r := newTestRunner(ctx, config)
+
+	for _, endpoint := range extractEndpointsFromConfig(config) {
+		if ctx.Err() != nil {
+			break
+		}
+		// Replay the configured request, whose response is the baseline of the variants
+		req := configRequest(config, endpoint)
+		baseline, err := r.Execute(req)
+		if err != nil || !isSuccessfulAccess(baseline) {
+			continue
+		}
+
+		acceptTypes := t.AcceptTypes
+		if !t.TestAcceptFormats {
+			acceptTypes = nil
+		}
+		reported := make(map[string]bool)
+		for _, variant := range contenttype.NegotiationVariants(req, acceptTypes) {
+			if ctx.Err() != nil {
+				break
+			}
+			switch {
+			case variant.Kind == contenttype.VariantFormat && t.TestRequestFormats,
+				variant.Kind == contenttype.VariantCharset && t.TestCharsets:
+				t.testRequestVariant(variant, baseline, r, result)
+			case variant.Kind == contenttype.VariantAccept:
+				t.testAcceptVariant(variant, baseline, r, result, reported)
+			}
+		}
+
+		languages := t.Languages
+		if !t.TestLanguages {
+			languages = nil
+		}
+		for _, variant := range contenttype.LanguageVariants(req, languages) {
+			if ctx.Err() != nil {
+				break
+			}
+			if variant.Kind == contenttype.VariantQuality && !t.TestQualityValues {
+				continue
+			}
+			t.testLanguageVariant(variant, baseline, r, result, reported)
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// testRequestVariant tests whether a request body converted to another format, sent under
+// another content type or encoded in another charset is accepted as the original one
+func (t *ContentNegotiationTester) testRequestVariant(variant *contenttype.NegotiationVariant, baseline ffuf.Response, r ffuf.RunnerProvider, result *TestResult) {
+	resp, err := r.Execute(variant.Request)
+	if err != nil || resp.StatusCode != baseline.StatusCode {
+		return
+	}
+
+	// A server which does not parse the body accepts a malformed one as well
+	control := *variant.Request
+	control.Data = []byte("ffuf" + controlIdentifier())
+	controlStatus := "failed"
+	if controlResp, err := r.Execute(&control); err == nil {
+		if controlResp.StatusCode == resp.StatusCode {
+			return
+		}
+		controlStatus = fmt.Sprintf("returned %d", controlResp.StatusCode)
+	}
+	evidence := fmt.Sprintf("%s (Content-Type: %s) returned %d as the original request, while a malformed body under the same content type %s",
+		variant.Name, variant.Header, resp.StatusCode, controlStatus)
+
+	switch {
+	case variant.Kind == contenttype.VariantCharset:
+		result.Vulnerabilities = append(result.Vulnerabilities, negotiationVulnerability(
+			"Alternate Request Charset Accepted",
+			"The API decodes request bodies in a charset other than UTF-8, which web application firewalls inspecting the body as UTF-8 do not decode, allowing payloads to evade them.",
+			"Low", 3.7, "CWE-436", evidence, variant.Request, resp))
+	case variant.Simple:
+		severity, cvss := "Medium", 6.5
+		if isSafeMethod(variant.Request.Method) {
+			severity, cvss = "Low", 3.5
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, negotiationVulnerability(
+			"Request Format Accepted Without CORS Preflight",
+			"The API accepts the request body under a content type that browsers send cross-origin without a CORS preflight, allowing other sites to forge the request with the credentials of a user.",
+			severity, cvss, "CWE-352", evidence, variant.Request, resp))
+	case strings.Contains(variant.Header, "xml"):
+		result.Vulnerabilities = append(result.Vulnerabilities, negotiationVulnerability(
+			"Undeclared XML Request Format Accepted",
+			"The API parses XML request bodies the endpoint does not declare, exposing an XML parser which may resolve external entities (XXE) and interpret the request differently from the declared format.",
+			"Medium", 5.3, "CWE-436", evidence, variant.Request, resp))
+	default:
+		result.Vulnerabilities = append(result.Vulnerabilities, negotiationVulnerability(
+			"Undeclared Request Format Accepted",
+			"The API parses request bodies in a format the endpoint does not declare, whose parser may interpret the request differently from the declared format.",
+			"Low", 3.7, "CWE-436", evidence, variant.Request, resp))
+	}
+}
+
+// testAcceptVariant tests whether a response is served in another format than the baseline
+// response, once per format
+func (t *ContentNegotiationTester) testAcceptVariant(variant *contenttype.NegotiationVariant, baseline ffuf.Response, r ffuf.RunnerProvider, result *TestResult, reported map[string]bool) {
+	resp, err := r.Execute(variant.Request)
+	if err != nil || t.reportServerError(variant, baseline, resp, result, reported) || !isSuccessfulAccess(resp) {
+		return
+	}
+
+	mediaType := responseMediaType(resp)
+	format := contenttype.ContentTypeFromString(mediaType)
+	if format == contenttype.TypeHTML && contenttype.DetectContentType(resp.Data) == contenttype.TypeJSON {
+		if !reported["html-json"] {
+			reported["html-json"] = true
+			result.Vulnerabilities = append(result.Vulnerabilities, negotiationVulnerability(
+				"JSON Response Served as HTML",
+				"The API serves a JSON response with an HTML content type when asked for HTML, so that values reflected in the response are rendered by browsers, allowing cross-site scripting.",
+				"Medium", 4.7, "CWE-79",
+				fmt.Sprintf("%s returned a JSON body with Content-Type %s", variant.Name, resp.ContentType),
+				variant.Request, resp))
+		}
+		return
+	}
+
+	baselineType := responseMediaType(baseline)
+	if mediaType == "" || mediaType == baselineType || reported[mediaType] {
+		return
+	}
+	reported[mediaType] = true
+	result.Vulnerabilities = append(result.Vulnerabilities, negotiationVulnerability(
+		"Undeclared Response Format Supported",
+		"The API serves responses in a format other than its default one, whose serializer may expose fields or behave differently from the declared format.",
+		"Info", 0, "CWE-436",
+		fmt.Sprintf("%s returned Content-Type %s instead of %s", variant.Name, mediaType, baselineType),
+		variant.Request, resp))
+}
+
+// testLanguageVariant tests whether asking for another language, or sending unusual quality
+// values, causes a server error, and whether a response in the requested language leaves
+// some of the texts of the baseline response untranslated
+func (t *ContentNegotiationTester) testLanguageVariant(variant *contenttype.NegotiationVariant, baseline ffuf.Response, r ffuf.RunnerProvider, result *TestResult, reported map[string]bool) {
+	resp, err := r.Execute(variant.Request)
+	if err != nil || t.reportServerError(variant, baseline, resp, result, reported) || variant.Kind != contenttype.VariantLanguage || reported["mixed-language"] {
+		return
+	}
+
+	// Only responses declaring the requested language are expected to be translated
+	language := primaryLanguage(firstHeader(resp, "Content-Language"))
+	if language == "" || language != primaryLanguage(variant.Header) || language == primaryLanguage(firstHeader(baseline, "Content-Language")) {
+		return
+	}
+	translated, untranslated := mixedLanguageTexts(baseline.Data, resp.Data)
+	if len(translated) == 0 || len(untranslated) == 0 {
+		return
+	}
+	reported["mixed-language"] = true
+	if len(untranslated) > 5 {
+		untranslated = append(untranslated[:5], "...")
+	}
+	result.Vulnerabilities = append(result.Vulnerabilities, negotiationVulnerability(
+		"Mixed-Language Response",
+		"The API serves a response in the requested language in which some texts are left in the default language, revealing messages, templates or data of another locale that were not meant to be served to the client.",
+		"Info", 0, "CWE-436",
+		fmt.Sprintf("%s returned Content-Language %s with %d texts translated and %d left as in the default response: %s",
+			variant.Name, firstHeader(resp, "Content-Language"), len(translated), len(untranslated), strings.Join(untranslated, ", ")),
+		variant.Request, resp))
+}
+
+// reportServerError reports a server error caused by a negotiation header, once per endpoint,
+// and returns true if the response is a server error
+func (t *ContentNegotiationTester) reportServerError(variant *contenttype.NegotiationVariant, baseline, resp ffuf.Response, result *TestResult, reported map[string]bool) bool {
+	if resp.StatusCode < 500 || baseline.StatusCode >= 500 {
+		return false
+	}
+	if !reported["server-error"] {
+		reported["server-error"] = true
+		result.Vulnerabilities = append(result.Vulnerabilities, negotiationVulnerability(
+			"Server Error on Content Negotiation",
+			"The API fails with a server error on an Accept or Accept-Language header it does not expect, showing that content negotiation headers reach code that does not handle them, which may leak stack traces or be used to disrupt the service.",
+			"Low", 3.7, "CWE-755",
+			fmt.Sprintf("%s returned %d while the original request returned %d", variant.Name, resp.StatusCode, baseline.StatusCode),
+			variant.Request, resp))
+	}
+	return true
+}
+
+// mixedLanguageTexts compares the texts of a JSON response in another language with those
+// of the baseline response, and returns the paths of the texts that were translated and of
+// those left as they are. Only sentences are compared, so that identifiers and codes are
+// not mistaken for untranslated texts.
+func mixedLanguageTexts(baseline, localized []byte) ([]string, []string) {
+	var baselineData, localizedData interface{}
+	if json.Unmarshal(baseline, &baselineData) != nil || json.Unmarshal(localized, &localizedData) != nil {
+		return nil, nil
+	}
+	baselineTexts := make(map[string]string)
+	localizedTexts := make(map[string]string)
+	collectTexts("$", baselineData, baselineTexts)
+	collectTexts("$", localizedData, localizedTexts)
+
+	translated := make([]string, 0)
+	untranslated := make([]string, 0)
+	for path, text := range localizedTexts {
+		original, ok := baselineTexts[path]
+		switch {
+		case !ok:
+		case original == text:
+			untranslated = append(untranslated, path)
+		default:
+			translated = append(translated, path)
+		}
+	}
+	sort.Strings(translated)
+	sort.Strings(untranslated)
+	return translated, untranslated
+}
+
+// collectTexts collects the sentences of a JSON document by path: strings of several words
+// with letters, which are not URLs
+func collectTexts(path string, data interface{}, texts map[string]string) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			collectTexts(path+"."+key, value, texts)
+		}
+	case []interface{}:
+		for i, value := range v {
+			collectTexts(fmt.Sprintf("%s[%d]", path, i), value, texts)
+		}
+	case string:
+		if len(strings.Fields(v)) >= 2 && strings.IndexFunc(v, unicode.IsLetter) >= 0 && !strings.Contains(v, "://") {
+			texts[path] = v
+		}
+	}
+}
+
+// primaryLanguage returns the lowercase primary subtag of the first language of a
+// Content-Language or Accept-Language header, e.g. fr for fr-CH
+func primaryLanguage(header string) string {
+	language := strings.TrimSpace(strings.Split(strings.Split(header, ",")[0], ";")[0])
+	return strings.ToLower(strings.Split(language, "-")[0])
+}
+
+// firstHeader returns the first value of a response header
+func firstHeader(resp ffuf.Response, name string) string {
+	if values := resp.Headers[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// responseMediaType returns the media type of a response, without its parameters
+func responseMediaType(resp ffuf.Response) string {
+	mediaType, _, err := mime.ParseMediaType(resp.ContentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(resp.ContentType, ";")[0]))
+	}
+	return mediaType
+}
+
+// isSafeMethod checks if a method does not change state
+func isSafeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// negotiationVulnerability creates a vulnerability info for a content negotiation finding
+func negotiationVulnerability(name, description, severity string, cvss float64, cwe, evidence string, req *ffuf.Request, resp ffuf.Response) VulnerabilityInfo {
+	return VulnerabilityInfo{
+		Type:        VulnContentNegotiation,
+		Name:        name,
+		Description: description,
+		Severity:    severity,
+		Request:     convertToHTTPRequest(req),
+		Response:    convertToHTTPResponse(resp),
+		Evidence:    evidence,
+		Remediation: "Accept only the content types and charsets an endpoint declares, rejecting others with 415 Unsupported Media Type. Serve only the declared response formats, and disable external entities in XML parsers. Fall back to the default format and language on unexpected Accept and Accept-Language headers, and declare with Content-Language only the languages a response is fully translated into.",
+		CVSS:        cvss,
+		CWE:         cwe,
+		References: []string{
+			"https://owasp.org/API-Security/editions/2023/en/0xa8-security-misconfiguration/",
+			"https://cheatsheetseries.owasp.org/cheatsheets/Cross-Site_Request_Forgery_Prevention_Cheat_Sheet.html",
+		},
+		DetectedAt: time.Now(),
+	}
+}
+
+func init() {
+	// Register the tester with the default registry
+	RegisterSecurityTester(NewContentNegotiationTester())
+}
@@ -0,0 +1,97 @@
+package security
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newNegotiationServer returns a server creating users from JSON bodies. The lenient server
+// parses XML bodies too and serves XML responses on request, the strict one rejects other
+// content types with 415 and always answers with JSON.
+func newNegotiationServer(lenient bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user struct {
+			Name string `json:"name" xml:"name"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		var err error
+		switch {
+		case params["charset"] != "" && !strings.EqualFold(params["charset"], "utf-8"):
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		case mediaType == "application/json":
+			err = json.Unmarshal(body, &user)
+		case lenient && (mediaType == "application/xml" || mediaType == "text/xml"):
+			err = xml.Unmarshal(body, &user)
+		default:
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if err != nil || user.Name == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if lenient && strings.Contains(r.Header.Get("Accept"), "xml") {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusCreated)
+			xml.NewEncoder(w).Encode(struct {
+				XMLName xml.Name `xml:"user"`
+				Name    string   `xml:"name"`
+			}{Name: user.Name})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(user)
+	}))
+}
+
+// runContentNegotiation runs the content negotiation tester with a JSON request to a server
+func runContentNegotiation(t *testing.T, server *httptest.Server) *TestResult {
+	t.Helper()
+	conf := newFakeConfig(t, server.URL+"/users")
+	conf.Method = "POST"
+	conf.Data = `{"name":"alice"}`
+	conf.Headers = map[string]string{"Content-Type": "application/json"}
+	result, err := NewContentNegotiationTester().Test(conf.Context, conf)
+	if err != nil {
+		t.Fatalf("Test returned an error: %s", err)
+	}
+	return result
+}
+
+func TestContentNegotiationTester_Accepted(t *testing.T) {
+	server := newNegotiationServer(true)
+	defer server.Close()
+	result := runContentNegotiation(t, server)
+	names := vulnerabilityNames(result)
+	if strings.Join(names, ",") != "Undeclared XML Request Format Accepted,Undeclared Response Format Supported" {
+		t.Fatalf("Expected the XML request and response formats to be reported, got %v", names)
+	}
+	expected := "JSON body converted to XML (Content-Type: application/xml) returned 201 as the original request, while a malformed body under the same content type returned 400"
+	if evidence := result.Vulnerabilities[0].Evidence; evidence != expected {
+		t.Errorf("Expected %q, got %q", expected, evidence)
+	}
+	if contentType := result.Vulnerabilities[0].Request.Header.Get("Content-Type"); contentType != "application/xml" {
+		t.Errorf("Expected the XML request to be reported, got Content-Type %s", contentType)
+	}
+	if evidence := result.Vulnerabilities[1].Evidence; evidence != "Accept: application/xml returned Content-Type application/xml instead of application/json" {
+		t.Errorf("Expected the XML response format as evidence, got %s", evidence)
+	}
+}
+
+func TestContentNegotiationTester_Rejected(t *testing.T) {
+	server := newNegotiationServer(false)
+	defer server.Close()
+	if result := runContentNegotiation(t, server); len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability when the other content types are rejected, got %v", vulnerabilityNames(result))
+	}
+}
@@ -0,0 +1,301 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"gopkg.in/yaml.v3"
+)
+
+// cvssPrefix is the prefix of CVSS v3.1 vector strings
+const cvssPrefix = "CVSS:3.1/"
+
+// cvssMetrics are the base metrics of a CVSS v3.1 vector in their canonical order, with
+// their allowed values
+var cvssMetrics = []struct {
+	name   string
+	values string
+}{
+	{"AV", "NALP"},
+	{"AC", "LH"},
+	{"PR", "NLH"},
+	{"UI", "NR"},
+	{"S", "UC"},
+	{"C", "HLN"},
+	{"I", "HLN"},
+	{"A", "HLN"},
+}
+
+// CVSSVector is a CVSS v3.1 base vector, e.g. CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H
+type CVSSVector struct {
+	metrics map[string]byte
+}
+
+// ParseCVSSVector parses a CVSS v3.1 vector string. All base metrics are required, temporal
+// and environmental metrics are not supported.
+func ParseCVSSVector(vector string) (*CVSSVector, error) {
+	vector = strings.TrimSpace(vector)
+	if !strings.HasPrefix(vector, cvssPrefix) {
+		return nil, fmt.Errorf("invalid CVSS vector %s: expected a %s prefix", vector, cvssPrefix)
+	}
+	v := &CVSSVector{metrics: make(map[string]byte)}
+	for _, part := range strings.Split(strings.TrimPrefix(vector, cvssPrefix), "/") {
+		nameValue := strings.SplitN(part, ":", 2)
+		if len(nameValue) != 2 || len(nameValue[1]) != 1 {
+			return nil, fmt.Errorf("invalid CVSS vector %s: invalid metric %s", vector, part)
+		}
+		name, value := nameValue[0], nameValue[1][0]
+		allowed := ""
+		for _, metric := range cvssMetrics {
+			if metric.name == name {
+				allowed = metric.values
+			}
+		}
+		if allowed == "" || strings.IndexByte(allowed, value) < 0 {
+			return nil, fmt.Errorf("invalid CVSS vector %s: invalid metric %s", vector, part)
+		}
+		if _, ok := v.metrics[name]; ok {
+			return nil, fmt.Errorf("invalid CVSS vector %s: duplicate metric %s", vector, name)
+		}
+		v.metrics[name] = value
+	}
+	for _, metric := range cvssMetrics {
+		if _, ok := v.metrics[metric.name]; !ok {
+			return nil, fmt.Errorf("invalid CVSS vector %s: missing metric %s", vector, metric.name)
+		}
+	}
+	return v, nil
+}
+
+// String returns the vector string with the metrics in their canonical order
+func (v *CVSSVector) String() string {
+	parts := make([]string, 0, len(cvssMetrics))
+	for _, metric := range cvssMetrics {
+		parts = append(parts, metric.name+":"+string(v.metrics[metric.name]))
+	}
+	return cvssPrefix + strings.Join(parts, "/")
+}
+
+// BaseScore computes the base score of the vector as specified by CVSS v3.1
+func (v *CVSSVector) BaseScore() float64 {
+	changed := v.metrics["S"] == 'C'
+	weight := func(metric string) float64 {
+		switch value := v.metrics[metric]; metric {
+		case "AV":
+			return map[byte]float64{'N': 0.85, 'A': 0.62, 'L': 0.55, 'P': 0.2}[value]
+		case "AC":
+			return map[byte]float64{'L': 0.77, 'H': 0.44}[value]
+		case "PR":
+			if changed {
+				return map[byte]float64{'N': 0.85, 'L': 0.68, 'H': 0.5}[value]
+			}
+			return map[byte]float64{'N': 0.85, 'L': 0.62, 'H': 0.27}[value]
+		case "UI":
+			return map[byte]float64{'N': 0.85, 'R': 0.62}[value]
+		default:
+			return map[byte]float64{'H': 0.56, 'L': 0.22, 'N': 0}[value]
+		}
+	}
+
+	iss := 1 - (1-weight("C"))*(1-weight("I"))*(1-weight("A"))
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0
+	}
+	exploitability := 8.22 * weight("AV") * weight("AC") * weight("PR") * weight("UI")
+	if changed {
+		return cvssRoundUp(math.Min(1.08*(impact+exploitability), 10))
+	}
+	return cvssRoundUp(math.Min(impact+exploitability, 10))
+}
+
+// cvssRoundUp rounds a score up to one decimal, avoiding floating point errors as specified by
+// CVSS v3.1
+func cvssRoundUp(score float64) float64 {
+	n := int(math.Round(score * 100000))
+	if n%10000 == 0 {
+		return float64(n) / 100000
+	}
+	return float64(n/10000+1) / 10
+}
+
+// CVSSSeverity returns the severity of a CVSS score: Critical, High, Medium, Low, or Info for
+// scores of 0
+func CVSSSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return "Critical"
+	case score >= 7:
+		return "High"
+	case score >= 4:
+		return "Medium"
+	case score > 0:
+		return "Low"
+	}
+	return "Info"
+}
+
+// DefaultCVSSVectors are the vectors scoring the findings of each tester, unless the finding
+// has its own vector or a severity other than the one of the default vector
+var DefaultCVSSVectors = map[VulnerabilityType]string{
+	VulnBrokenObjectLevelAuth:   "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:L/A:N",
+	VulnBrokenAuth:              "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+	VulnExcessiveDataExposure:   "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+	VulnLackOfResources:         "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
+	VulnBrokenFunctionLevelAuth: "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:N",
+	VulnMassAssignment:          "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:L/I:H/A:N",
+	VulnSecurityMisconfig:       "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N",
+	VulnInjection:               "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+	VulnImproperAssetsMgmt:      "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+	VulnInsufficientLogging:     "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:N",
+	VulnSSRF:                    "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:N/A:N",
+	VulnContentNegotiation:      "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:L/A:N",
+	VulnHeaderAttack:            "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N",
+	VulnSecretLeakage:           "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+	VulnPaginationAbuse:         "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N",
+	VulnGraphQL:                 "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:L",
+	VulnWebSocket:               "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:N",
+	VulnErrorDisclosure:         "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N",
+}
+
+// severityCVSSVectors are representative vectors of each severity, scoring the findings whose
+// severity differs from the one of the default vector of their tester
+var severityCVSSVectors = map[string]string{
+	"Critical": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+	"High":     "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+	"Medium":   "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N",
+	"Low":      "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:L/I:N/A:N",
+	"Info":     "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N",
+}
+
+// CVSSOverride re-scores the findings matching its vulnerability type, name and CWE. Empty
+// criteria match any finding.
+type CVSSOverride struct {
+	// Type is the name of the vulnerability type of the tester, e.g. injection
+	Type string `yaml:"type"`
+	// Name is a regular expression matching the names of the findings
+	Name string `yaml:"name"`
+	CWE  string `yaml:"cwe"`
+	// Vector is the CVSS v3.1 vector scoring the findings
+	Vector string `yaml:"vector"`
+	// Severity replaces the severity derived from the score of the vector
+	Severity string `yaml:"severity"`
+
+	vulnType VulnerabilityType
+	name     *regexp.Regexp
+	vector   *CVSSVector
+}
+
+// Scoring scores the findings of security testers with CVSS v3.1 vectors: the vector of the
+// first matching override, the vector set by the tester, or the default vector of the tester.
+type Scoring struct {
+	Overrides []*CVSSOverride `yaml:"overrides"`
+}
+
+// LoadScoring loads a YAML or JSON file of CVSS overrides
+func LoadScoring(path string) (*Scoring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CVSS overrides: %w", err)
+	}
+	scoring := &Scoring{}
+	if err := yaml.Unmarshal(data, scoring); err != nil {
+		return nil, fmt.Errorf("failed to parse CVSS overrides %s: %w", path, err)
+	}
+	for i, override := range scoring.Overrides {
+		if override.Type == "" && override.Name == "" && override.CWE == "" {
+			return nil, fmt.Errorf("CVSS override %d has no type, name or cwe", i+1)
+		}
+		if override.Type != "" {
+			if override.vulnType, err = ParseVulnerabilityType(override.Type); err != nil {
+				return nil, fmt.Errorf("CVSS override %d: %w", i+1, err)
+			}
+		}
+		if override.Name != "" {
+			if override.name, err = regexp.Compile("(?i)" + override.Name); err != nil {
+				return nil, fmt.Errorf("CVSS override %d: invalid name regex: %w", i+1, err)
+			}
+		}
+		if override.vector, err = ParseCVSSVector(override.Vector); err != nil {
+			return nil, fmt.Errorf("CVSS override %d: %w", i+1, err)
+		}
+		if override.Severity != "" && severityCVSSVectors[override.Severity] == "" {
+			return nil, fmt.Errorf("CVSS override %d: severity must be Critical, High, Medium, Low or Info, got %s", i+1, override.Severity)
+		}
+	}
+	return scoring, nil
+}
+
+// LoadConfiguredScoring loads the CVSS overrides file of the config, if any, or returns the
+// scoring of the default vectors
+func LoadConfiguredScoring(config *ffuf.Config) (*Scoring, error) {
+	if config.APISecurityScoring == "" {
+		return &Scoring{}, nil
+	}
+	return LoadScoring(config.APISecurityScoring)
+}
+
+// Score sets the CVSS vector, score and severity of a vulnerability
+func (s *Scoring) Score(vuln *VulnerabilityInfo) {
+	for _, override := range s.Overrides {
+		if override.matches(vuln) {
+			vuln.CVSSVector = override.vector.String()
+			vuln.CVSS = override.vector.BaseScore()
+			vuln.Severity = CVSSSeverity(vuln.CVSS)
+			if override.Severity != "" {
+				vuln.Severity = override.Severity
+			}
+			return
+		}
+	}
+
+	if vuln.CVSSVector != "" {
+		vector, err := ParseCVSSVector(vuln.CVSSVector)
+		if err == nil {
+			vuln.CVSSVector = vector.String()
+			vuln.CVSS = vector.BaseScore()
+			vuln.Severity = CVSSSeverity(vuln.CVSS)
+			return
+		}
+		logger.Warn("Ignoring the CVSS vector", "vulnerability", vuln.Name, logging.FieldError, err)
+	}
+
+	// The default vector of the tester is only used if it does not change the severity set by
+	// the tester
+	vector, _ := ParseCVSSVector(DefaultCVSSVectors[vuln.Type])
+	if vector == nil || CVSSSeverity(vector.BaseScore()) != vuln.Severity {
+		vector, _ = ParseCVSSVector(severityCVSSVectors[vuln.Severity])
+	}
+	if vector == nil {
+		return
+	}
+	vuln.CVSSVector = vector.String()
+	vuln.CVSS = vector.BaseScore()
+}
+
+// ScoreResult scores the vulnerabilities of the result of a tester
+func (s *Scoring) ScoreResult(result *TestResult) {
+	for i := range result.Vulnerabilities {
+		s.Score(&result.Vulnerabilities[i])
+	}
+}
+
+// matches returns true if the override applies to the vulnerability
+func (o *CVSSOverride) matches(vuln *VulnerabilityInfo) bool {
+	if o.Type != "" && o.vulnType != vuln.Type {
+		return false
+	}
+	if o.name != nil && !o.name.MatchString(vuln.Name) {
+		return false
+	}
+	return o.CWE == "" || strings.EqualFold(o.CWE, vuln.CWE)
+}
@@ -0,0 +1,85 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScoring(t *testing.T) {
+	for vector, score := range map[string]float64{
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H": 9.8,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H": 10,
+		"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N": 6.4,
+		"CVSS:3.1/AV:P/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N": 1.6,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N": 0,
+	} {
+		parsed, err := ParseCVSSVector(vector)
+		if err != nil {
+			t.Fatalf("ParseCVSSVector returned an error: %s", err)
+		}
+		if parsed.BaseScore() != score {
+			t.Errorf("Expected %s to score %.1f, got %.1f", vector, score, parsed.BaseScore())
+		}
+	}
+	for _, vector := range []string{"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H", "CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"} {
+		if _, err := ParseCVSSVector(vector); err == nil {
+			t.Errorf("Expected %s to be rejected", vector)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "scoring.yaml")
+	content := `overrides:
+  - type: injection
+    name: ^command
+    vector: CVSS:3.1/AV:N/AC:H/PR:H/UI:N/S:U/C:H/I:H/A:H
+  - cwe: cwe-200
+    vector: CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N
+    severity: High
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	scoring, err := LoadScoring(path)
+	if err != nil {
+		t.Fatalf("LoadScoring returned an error: %s", err)
+	}
+
+	result := &TestResult{TestName: "Injection", Vulnerabilities: []VulnerabilityInfo{
+		{Type: VulnInjection, Name: "SQL Injection", Severity: "Critical", CVSS: 9.5, CWE: "CWE-89"},
+		{Type: VulnInjection, Name: "Command Injection", Severity: "Critical", CVSS: 9.8, CWE: "CWE-89"},
+		{Type: VulnInjection, Name: "Verbose Errors", Severity: "Low", CVSS: 3.1, CWE: "CWE-89"},
+		{Type: VulnExcessiveDataExposure, Name: "Sensitive Data", Severity: "Low", CVSS: 3.1, CWE: "CWE-200"},
+	}}
+	scoring.ScoreResult(result)
+
+	expected := []struct {
+		vector   string
+		score    float64
+		severity string
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, "Critical"},
+		{"CVSS:3.1/AV:N/AC:H/PR:H/UI:N/S:U/C:H/I:H/A:H", 6.6, "Medium"},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:L/I:N/A:N", 3.7, "Low"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N", 5.3, "High"},
+	}
+	for i, vuln := range result.Vulnerabilities {
+		if vuln.CVSSVector != expected[i].vector || vuln.CVSS != expected[i].score || vuln.Severity != expected[i].severity {
+			t.Errorf("Expected %s to be scored %s (%.1f, %s), got %s (%.1f, %s)", vuln.Name, expected[i].vector, expected[i].score, expected[i].severity, vuln.CVSSVector, vuln.CVSS, vuln.Severity)
+		}
+	}
+
+	for _, content := range []string{
+		`{"overrides": [{"vector": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N"}]}`,
+		`{"overrides": [{"type": "sqli", "vector": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N"}]}`,
+		`{"overrides": [{"type": "injection", "vector": "AV:N"}]}`,
+		`{"overrides": [{"cwe": "CWE-89", "vector": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N", "severity": "Severe"}]}`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadScoring(path); err == nil {
+			t.Errorf("Expected CVSS overrides %s to be rejected", content)
+		}
+	}
+}
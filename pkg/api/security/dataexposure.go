@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // ExcessiveDataExposureTester implements testing for Excessive Data Exposure (API3:2019)
@@ -74,13 +73,16 @@ func (t *ExcessiveDataExposureTester) Test(ctx context.Context, config *ffuf.Con
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract potential endpoints from the config
 	endpoints := extractEndpointsFromConfig(config)
 
 	// Test each endpoint for excessive data exposure
 	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
 		// Create a request for the endpoint
 		req := &ffuf.Request{
 			Method:  "GET",
@@ -0,0 +1,177 @@
+package security
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// testerUserAgent is the User-Agent of the requests of the testers, replaced by the
+// User-Agent of the config if it sets one
+const testerUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+
+// RequestDecoration is the headers and the proxy applied to the requests of a tester
+type RequestDecoration struct {
+	// Headers are added to the requests not setting them, an empty value removing the
+	// header. Cookies are added to the cookies of the requests.
+	Headers map[string]string
+	// Proxy is the URL of the proxy of the requests, empty for the proxy of the config
+	Proxy string
+}
+
+// RequestDecorations are the decorations of the requests of every tester, and the overrides
+// of the testers of vulnerability types
+type RequestDecorations struct {
+	// Default is the decoration of the requests of every tester
+	Default RequestDecoration
+	// Types are the overrides of the testers of vulnerability types
+	Types map[VulnerabilityType]*RequestDecoration
+}
+
+// ParseRequestDecorations returns the decorations of the requests of the testers: the headers,
+// cookies and proxy of the config, overridden by the headers of config.APISecurityHeaders in
+// the form type=Name: value, or Name: value for every tester, and the proxies of
+// config.APISecurityProxies in the form type=URL
+func ParseRequestDecorations(config *ffuf.Config) (*RequestDecorations, error) {
+	decorations := &RequestDecorations{
+		Default: RequestDecoration{Headers: make(map[string]string, len(config.Headers))},
+		Types:   make(map[VulnerabilityType]*RequestDecoration),
+	}
+	for name, value := range config.Headers {
+		decorations.Default.Headers[name] = value
+	}
+
+	for _, value := range config.APISecurityHeaders {
+		headers, header := decorations.Default.Headers, value
+		// The type is before an equal sign preceding the colon of the header
+		if eq, colon := strings.Index(value, "="), strings.Index(value, ":"); eq > 0 && (colon < 0 || eq < colon) {
+			decoration, err := decorations.override(value[:eq])
+			if err != nil {
+				return nil, err
+			}
+			headers, header = decoration.Headers, value[eq+1:]
+		}
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid tester header: %s, expected type=Name: value or Name: value", value)
+		}
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	for _, value := range config.APISecurityProxies {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid tester proxy: %s, expected type=URL, e.g. ssrf=http://127.0.0.1:8080", value)
+		}
+		if u, err := url.Parse(strings.TrimSpace(parts[1])); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid tester proxy: %s, expected type=URL, e.g. ssrf=http://127.0.0.1:8080", value)
+		}
+		decoration, err := decorations.override(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		decoration.Proxy = strings.TrimSpace(parts[1])
+	}
+	return decorations, nil
+}
+
+// override returns the override of the testers of a vulnerability type, creating it if needed
+func (d *RequestDecorations) override(name string) (*RequestDecoration, error) {
+	vulnType, err := ParseVulnerabilityType(strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+	decoration, ok := d.Types[vulnType]
+	if !ok {
+		decoration = &RequestDecoration{Headers: make(map[string]string)}
+		d.Types[vulnType] = decoration
+	}
+	return decoration, nil
+}
+
+// For returns the decoration of the requests of the tester of a vulnerability type: the
+// default headers overridden by the headers of the type, and the proxy of the type
+func (d *RequestDecorations) For(vulnType VulnerabilityType) RequestDecoration {
+	override, ok := d.Types[vulnType]
+	if !ok {
+		return d.Default
+	}
+	decoration := RequestDecoration{Headers: make(map[string]string, len(d.Default.Headers)+len(override.Headers)), Proxy: override.Proxy}
+	for name, value := range d.Default.Headers {
+		decoration.Headers[name] = value
+	}
+	for name, value := range override.Headers {
+		for existing := range decoration.Headers {
+			if strings.EqualFold(existing, name) {
+				delete(decoration.Headers, existing)
+			}
+		}
+		decoration.Headers[name] = value
+	}
+	return decoration
+}
+
+// Apply sets the headers of the decoration on a request. Headers set by the request take
+// precedence, except the User-Agent of the testers, and headers with an empty value are
+// removed. The cookies of the decoration are added to the cookies of the request, unless it
+// sets a cookie of the same name.
+func (d RequestDecoration) Apply(req *ffuf.Request) {
+	if len(d.Headers) == 0 {
+		return
+	}
+	// Copy the headers, which may be shared with other requests
+	headers := make(map[string]string, len(req.Headers)+len(d.Headers))
+	for name, value := range req.Headers {
+		headers[name] = value
+	}
+	names := make([]string, 0, len(d.Headers))
+	for name := range d.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := d.Headers[name]
+		existing, current := "", ""
+		for key, v := range headers {
+			if strings.EqualFold(key, name) {
+				existing, current = key, v
+			}
+		}
+		switch {
+		case value == "":
+			delete(headers, existing)
+		case existing == "":
+			headers[name] = value
+		case strings.EqualFold(name, "Cookie"):
+			headers[existing] = mergeCookies(current, value)
+		case strings.EqualFold(name, "User-Agent") && current == testerUserAgent:
+			delete(headers, existing)
+			headers[name] = value
+		}
+	}
+	req.Headers = headers
+}
+
+// mergeCookies adds the cookies of a Cookie header to another, skipping the cookies it sets
+func mergeCookies(header, added string) string {
+	cookies := strings.Split(header, ";")
+	set := make(map[string]bool, len(cookies))
+	for _, cookie := range cookies {
+		set[strings.TrimSpace(strings.SplitN(cookie, "=", 2)[0])] = true
+	}
+	merged := strings.TrimSpace(header)
+	for _, cookie := range strings.Split(added, ";") {
+		cookie = strings.TrimSpace(cookie)
+		if cookie == "" || set[strings.SplitN(cookie, "=", 2)[0]] {
+			continue
+		}
+		if merged != "" {
+			merged += "; "
+		}
+		merged += cookie
+	}
+	return merged
+}
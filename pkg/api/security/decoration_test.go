@@ -0,0 +1,76 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestRequestDecorations(t *testing.T) {
+	var mu sync.Mutex
+	direct, proxied := make([]*http.Request, 0), make([]*http.Request, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		direct = append(direct, r)
+		mu.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r)
+		mu.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer proxy.Close()
+
+	// The headers and cookies of the config apply to every tester, the injection tester
+	// adding a header and going through a proxy
+	registry := NewSecurityTestRegistry()
+	registry.Register(NewInjectionTester())
+	registry.Register(NewSecurityMisconfigTester())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = server.URL + "/search?q=test"
+	conf.Threads = 2
+	conf.Headers = map[string]string{"User-Agent": "scanner/1.0", "Cookie": "session=abc"}
+	conf.APISecurityHeaders = []string{"X-Scan: ffuf", "injection=X-Debug: 1"}
+	conf.APISecurityProxies = []string{"injection=" + proxy.URL}
+	conf.APISecurityPayloadCategories = []string{PayloadSQLiError}
+	if _, err := registry.RunAll(ctx, &conf); err != nil {
+		t.Fatalf("RunAll returned an error: %s", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(direct) == 0 || len(proxied) == 0 {
+		t.Fatalf("Expected requests to the target and the proxy, got %d and %d", len(direct), len(proxied))
+	}
+	for _, r := range append(direct, proxied...) {
+		if r.Header.Get("User-Agent") != "scanner/1.0" {
+			t.Errorf("Expected the User-Agent of the config on %s %s, got %q", r.Method, r.URL, r.Header.Get("User-Agent"))
+		}
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "abc" {
+			t.Errorf("Expected the session cookie on %s %s, got %q", r.Method, r.URL, r.Header.Get("Cookie"))
+		}
+		if r.Header.Get("X-Scan") != "ffuf" {
+			t.Errorf("Expected the X-Scan header on %s %s", r.Method, r.URL)
+		}
+	}
+	for _, r := range direct {
+		if r.Header.Get("X-Debug") != "" {
+			t.Errorf("Expected the X-Debug header only on the injection requests, got it on %s %s", r.Method, r.URL)
+		}
+	}
+	for _, r := range proxied {
+		if r.Header.Get("X-Debug") != "1" {
+			t.Errorf("Expected the X-Debug header on the injection request %s %s", r.Method, r.URL)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of error signatures
+const (
+	// ErrorKindStackTrace signatures match the stack traces of languages and runtimes
+	ErrorKindStackTrace = "stack-trace"
+	// ErrorKindFramework signatures match the error pages and banners of frameworks and servers
+	ErrorKindFramework = "framework"
+	// ErrorKindSQL signatures match the error messages of databases and their drivers
+	ErrorKindSQL = "sql"
+	// ErrorKindPath signatures match paths of the file system of the server
+	ErrorKindPath = "path"
+)
+
+// errorKinds are the names and default severities of the kinds of error signatures
+var errorKinds = map[string]struct{ name, severity string }{
+	ErrorKindStackTrace: {"Stack Trace Disclosure", "Medium"},
+	ErrorKindFramework:  {"Framework Error Disclosure", "Low"},
+	ErrorKindSQL:        {"Database Error Disclosure", "Medium"},
+	ErrorKindPath:       {"Internal Path Disclosure", "Low"},
+}
+
+// ErrorSignature identifies a technology by the messages of its errors
+type ErrorSignature struct {
+	Name string `yaml:"name" json:"name"`
+	// Technology the signature identifies, e.g. Java or PostgreSQL
+	Technology string `yaml:"technology" json:"technology"`
+	// Kind of the signature: stack-trace, framework, sql or path
+	Kind string `yaml:"kind" json:"kind"`
+	// Regular expression matching the error messages
+	Pattern string `yaml:"pattern" json:"pattern"`
+	// Severity of the findings, defaulting to the one of the kind
+	Severity string `yaml:"severity" json:"severity"`
+
+	pattern *regexp.Regexp
+}
+
+// ErrorMatch is an error message matched by a signature
+type ErrorMatch struct {
+	Signature *ErrorSignature
+	// Match is the lines of the matched message
+	Match string
+}
+
+// defaultErrorSignatures is the built-in fingerprint database of error messages
+var defaultErrorSignatures = []*ErrorSignature{
+	// Stack traces
+	{Name: "Java stack trace", Technology: "Java", Kind: ErrorKindStackTrace, Pattern: `(?m)^\s*at [\w$]+(\.[\w$<>]+)+\([\w$]+\.(java|kt|scala):\d+\)`},
+	{Name: "Java exception", Technology: "Java", Kind: ErrorKindStackTrace, Pattern: `\bjava\.(lang|io|util|net)\.\w+(Exception|Error)\b`},
+	{Name: "Python traceback", Technology: "Python", Kind: ErrorKindStackTrace, Pattern: `Traceback \(most recent call last\)|File "[^"]+\.py", line \d+`},
+	{Name: ".NET stack trace", Technology: ".NET", Kind: ErrorKindStackTrace, Pattern: `(?m)^\s*at [\w.<>` + "`" + `]+\(.*\) in .+:line \d+`},
+	{Name: ".NET exception", Technology: ".NET", Kind: ErrorKindStackTrace, Pattern: `\bSystem\.(\w+\.)*\w+Exception\b`},
+	{Name: "PHP error", Technology: "PHP", Kind: ErrorKindStackTrace, Pattern: `(?i)(fatal error|parse error|warning|notice)(</b>)?:.+ in .+\.php( on line |:)\d+|Stack trace:\s*(<br />)?\s*#0`},
+	{Name: "Node.js stack trace", Technology: "Node.js", Kind: ErrorKindStackTrace, Pattern: `(?m)^\s*at .+ \((/|[A-Za-z]:\\|node:).+\.?(js|ts|mjs|cjs)?:\d+:\d+\)`},
+	{Name: "Ruby backtrace", Technology: "Ruby", Kind: ErrorKindStackTrace, Pattern: `\.rb:\d+:in [` + "`" + `']`},
+	{Name: "Go panic", Technology: "Go", Kind: ErrorKindStackTrace, Pattern: `goroutine \d+ \[running\]|panic: runtime error`},
+
+	// Frameworks and servers
+	{Name: "Django debug page", Technology: "Django", Kind: ErrorKindFramework, Pattern: `DEBUG = True|Django Version:|django\.(core|db|http)\.`},
+	{Name: "Werkzeug debugger", Technology: "Flask", Kind: ErrorKindFramework, Pattern: `Werkzeug Debugger|werkzeug\.exceptions`},
+	{Name: "Spring error page", Technology: "Spring", Kind: ErrorKindFramework, Pattern: `Whitelabel Error Page|org\.springframework\.`},
+	{Name: "Jackson parse error", Technology: "Jackson", Kind: ErrorKindFramework, Pattern: `com\.fasterxml\.jackson\.|JSON parse error: (Unexpected|Cannot deserialize)`},
+	{Name: "Hibernate error", Technology: "Hibernate", Kind: ErrorKindFramework, Pattern: `org\.hibernate\.`},
+	{Name: "Laravel error", Technology: "Laravel", Kind: ErrorKindFramework, Pattern: `Illuminate\\[A-Z]\w+\\|Whoops, looks like something went wrong`},
+	{Name: "Symfony error", Technology: "Symfony", Kind: ErrorKindFramework, Pattern: `Symfony\\Component\\`},
+	{Name: "Rails error", Technology: "Ruby on Rails", Kind: ErrorKindFramework, Pattern: `ActionController::\w+|ActiveRecord::\w+|ActionDispatch::\w+`},
+	{Name: "ASP.NET error page", Technology: "ASP.NET", Kind: ErrorKindFramework, Pattern: `Server Error in '[^']*' Application|ASP\.NET is configured to show verbose error messages`},
+	{Name: "Express error", Technology: "Express", Kind: ErrorKindFramework, Pattern: `Unexpected token .+ in JSON at position \d+|Cannot (GET|POST|PUT|PATCH|DELETE) /|node_modules/(express|body-parser)/`},
+	{Name: "Tomcat error page", Technology: "Apache Tomcat", Kind: ErrorKindFramework, Pattern: `Apache Tomcat/\d+(\.\d+)+`},
+	{Name: "nginx error page", Technology: "nginx", Kind: ErrorKindFramework, Pattern: `<center>nginx/\d+(\.\d+)+</center>`},
+	{Name: "Apache error page", Technology: "Apache HTTP Server", Kind: ErrorKindFramework, Pattern: `<address>Apache/\d+(\.\d+)+`},
+
+	// Databases
+	{Name: "MySQL error", Technology: "MySQL", Kind: ErrorKindSQL, Pattern: `You have an error in your SQL syntax|com\.mysql\.(cj\.)?jdbc|MySqlException|Warning: mysqli?_`},
+	{Name: "PostgreSQL error", Technology: "PostgreSQL", Kind: ErrorKindSQL, Pattern: `PG::\w+Error|org\.postgresql\.util\.PSQLException|ERROR:\s+syntax error at or near|psycopg2\.\w+|\bpq: \w+`},
+	{Name: "SQL Server error", Technology: "Microsoft SQL Server", Kind: ErrorKindSQL, Pattern: `Unclosed quotation mark after the character string|System\.Data\.SqlClient\.SqlException|Microsoft SQL Server|\[SQL Server\]`},
+	{Name: "Oracle error", Technology: "Oracle Database", Kind: ErrorKindSQL, Pattern: `\bORA-\d{5}\b`},
+	{Name: "SQLite error", Technology: "SQLite", Kind: ErrorKindSQL, Pattern: `SQLITE_ERROR|sqlite3\.OperationalError|SQLiteException|SQLite3::`},
+	{Name: "MongoDB error", Technology: "MongoDB", Kind: ErrorKindSQL, Pattern: `MongoError|MongoServerError|E11000 duplicate key error`},
+	{Name: "PDO error", Technology: "PHP PDO", Kind: ErrorKindSQL, Pattern: `SQLSTATE\[\w+\]`},
+
+	// File system paths
+	{Name: "Unix path", Technology: "Unix", Kind: ErrorKindPath, Pattern: `(/home/[\w.-]+|/var/www|/usr/(local/)?(lib|src|share)|/opt/[\w.-]+|/srv/[\w.-]+|/app)(/[\w.@-]+)*/[\w.-]+\.(py|rb|php|js|ts|java|go|cs|jar)\b`},
+	{Name: "Windows path", Technology: "Windows", Kind: ErrorKindPath, Pattern: `\b[A-Za-z]:\\([\w .-]+\\)+[\w .-]+\.(cs|aspx?|php|py|js|java|dll|config)\b`},
+}
+
+// DefaultErrorSignatures returns the built-in error signatures
+func DefaultErrorSignatures() []*ErrorSignature {
+	signatures := make([]*ErrorSignature, len(defaultErrorSignatures))
+	for i, signature := range defaultErrorSignatures {
+		copied := *signature
+		signatures[i] = &copied
+	}
+	return signatures
+}
+
+// LoadErrorSignatures loads a YAML or JSON list of error signatures
+func LoadErrorSignatures(path string) ([]*ErrorSignature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read error signatures: %w", err)
+	}
+	var file struct {
+		Signatures []*ErrorSignature `yaml:"signatures"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse error signatures %s: %w", path, err)
+	}
+	if err := compileErrorSignatures(file.Signatures); err != nil {
+		return nil, fmt.Errorf("error signatures %s: %w", path, err)
+	}
+	return file.Signatures, nil
+}
+
+// compileErrorSignatures validates signatures and compiles their patterns
+func compileErrorSignatures(signatures []*ErrorSignature) error {
+	for i, signature := range signatures {
+		if signature.Name == "" {
+			return fmt.Errorf("error signature %d has no name", i+1)
+		}
+		if _, ok := errorKinds[signature.Kind]; !ok {
+			return fmt.Errorf("error signature %s: kind must be stack-trace, framework, sql or path, got %q", signature.Name, signature.Kind)
+		}
+		if signature.Severity != "" && severityCVSSVectors[signature.Severity] == "" {
+			return fmt.Errorf("error signature %s: severity must be Critical, High, Medium, Low or Info, got %s", signature.Name, signature.Severity)
+		}
+		re, err := regexp.Compile(signature.Pattern)
+		if err != nil || signature.Pattern == "" {
+			return fmt.Errorf("error signature %s: invalid pattern %q", signature.Name, signature.Pattern)
+		}
+		signature.pattern = re
+	}
+	return nil
+}
+
+// ClassifyError returns the matches of the signatures in the headers and the body of a response
+func ClassifyError(resp ffuf.Response, signatures []*ErrorSignature) []ErrorMatch {
+	var content strings.Builder
+	for name, values := range resp.Headers {
+		for _, value := range values {
+			content.WriteString(name + ": " + value + "\n")
+		}
+	}
+	content.Write(resp.Data)
+	text := content.String()
+
+	matches := make([]ErrorMatch, 0)
+	for _, signature := range signatures {
+		if signature.pattern == nil {
+			continue
+		}
+		loc := signature.pattern.FindStringIndex(text)
+		if loc == nil {
+			continue
+		}
+		// The match is extended to its lines, giving the context of short matches
+		start := strings.LastIndex(text[:loc[0]], "\n") + 1
+		end := loc[1]
+		if i := strings.Index(text[end:], "\n"); i >= 0 {
+			end += i
+		} else {
+			end = len(text)
+		}
+		matches = append(matches, ErrorMatch{Signature: signature, Match: strings.TrimSpace(text[start:end])})
+	}
+	return matches
+}
+
+// ErrorDisclosureTester implements testing for error messages disclosing the technologies,
+// queries and files of the API
+type ErrorDisclosureTester struct {
+	// Configuration options
+	SignaturesFile    string
+	TestMalformedJSON bool
+	TestWrongTypes    bool
+	TestLongURL       bool
+	LongURLLength     int
+}
+
+// NewErrorDisclosureTester creates a new tester for error message disclosure
+func NewErrorDisclosureTester() *ErrorDisclosureTester {
+	return &ErrorDisclosureTester{
+		TestMalformedJSON: true,
+		TestWrongTypes:    true,
+		TestLongURL:       true,
+		LongURLLength:     8192,
+	}
+}
+
+// GetType returns the type of vulnerability this tester checks for
+func (t *ErrorDisclosureTester) GetType() VulnerabilityType {
+	return VulnErrorDisclosure
+}
+
+// GetName returns the name of the security test
+func (t *ErrorDisclosureTester) GetName() string {
+	return "Error Message Disclosure"
+}
+
+// GetDescription returns a description of the security test
+func (t *ErrorDisclosureTester) GetDescription() string {
+	return "Tests for error messages disclosing stack traces, frameworks, database errors and internal paths in the responses to malformed JSON bodies, parameters of the wrong type and overlong URLs."
+}
+
+// errorVariant is a malformed request triggering errors
+type errorVariant struct {
+	description string
+	request     *ffuf.Request
+}
+
+// Test runs the security test against the target
+func (t *ErrorDisclosureTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	result := &TestResult{
+		TestName:  t.GetName(),
+		StartTime: time.Now(),
+	}
+
+	signatures := DefaultErrorSignatures()
+	compileErrorSignatures(signatures)
+	if t.SignaturesFile != "" {
+		fileSignatures, err := LoadErrorSignatures(t.SignaturesFile)
+		if err != nil {
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result, result.Error
+		}
+		signatures = append(signatures, fileSignatures...)
+	}
+
+	// Create a runner for making HTTP requests
+	r := newTestRunner(ctx, config)
+
+	for _, endpoint := range extractEndpointsFromConfig(config) {
+		if ctx.Err() != nil {
+			break
+		}
+		req := configRequest(config, endpoint)
+
+		// Messages present in the normal response are part of the content of the endpoint
+		baseline := make(map[string]bool)
+		if resp, err := r.Execute(req); err == nil {
+			for _, match := range ClassifyError(resp, signatures) {
+				baseline[match.Signature.Name] = true
+			}
+		}
+
+		reported := make(map[string]bool)
+		for _, variant := range t.variants(req) {
+			if ctx.Err() != nil {
+				break
+			}
+			resp, err := r.Execute(variant.request)
+			if err != nil {
+				continue
+			}
+			for _, match := range ClassifyError(resp, signatures) {
+				key := match.Signature.Kind + "\n" + match.Signature.Technology
+				if baseline[match.Signature.Name] || reported[key] {
+					continue
+				}
+				reported[key] = true
+				result.Vulnerabilities = append(result.Vulnerabilities, errorDisclosureFinding(variant, resp, match))
+			}
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// variants returns the malformed requests of the enabled checks: a truncated JSON body, the
+// parameters with values of the wrong type, and an overlong URL
+func (t *ErrorDisclosureTester) variants(req *ffuf.Request) []errorVariant {
+	variants := make([]errorVariant, 0)
+	if t.TestMalformedJSON {
+		malformed := withHeaders(req, map[string]string{"Content-Type": "application/json"})
+		if malformed.Method == "GET" || malformed.Method == "HEAD" {
+			malformed.Method = "POST"
+		}
+		malformed.Data = []byte(`{"ffuf": [1, "\x", {`)
+		if len(req.Data) > 1 {
+			malformed.Data = append(append([]byte{}, req.Data[:len(req.Data)/2]...), []byte(`"'{[`)...)
+		}
+		variants = append(variants, errorVariant{"malformed JSON body", malformed})
+	}
+	if t.TestWrongTypes {
+		if wrong := wrongTypeRequest(req); wrong != nil {
+			variants = append(variants, errorVariant{"parameters of the wrong type", wrong})
+		}
+	}
+	if t.TestLongURL && t.LongURLLength > 0 {
+		long := *req
+		long.Url = addOrReplaceParameter(req.Url, "ffuf", strings.Repeat("A", t.LongURLLength))
+		variants = append(variants, errorVariant{fmt.Sprintf("URL of %d characters", len(long.Url)), &long})
+	}
+	return variants
+}
+
+// wrongTypeRequest returns a copy of a request whose JSON body fields, or query parameters if it
+// has no JSON body, carry values of the wrong type, or nil if it has neither. Query parameters
+// are sent as arrays, with a string for numeric values.
+func wrongTypeRequest(req *ffuf.Request) *ffuf.Request {
+	var body map[string]interface{}
+	if len(req.Data) > 0 && json.Unmarshal(req.Data, &body) == nil && len(body) > 0 {
+		for name, value := range body {
+			body[name] = wrongTypeValue(value)
+		}
+		data, _ := json.Marshal(body)
+		wrong := *req
+		wrong.Data = data
+		return &wrong
+	}
+
+	u, err := url.Parse(req.Url)
+	if err != nil || u.RawQuery == "" {
+		return nil
+	}
+	query := u.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	params := make([]string, 0, len(names))
+	for _, name := range names {
+		value := query.Get(name)
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			value = "ffuf"
+		}
+		params = append(params, url.QueryEscape(name)+"[]="+url.QueryEscape(value))
+	}
+	u.RawQuery = strings.Join(params, "&")
+	wrong := *req
+	wrong.Url = u.String()
+	return &wrong
+}
+
+// wrongTypeValue returns a value of another JSON type: a number for strings, an object for
+// arrays, and a string for the others
+func wrongTypeValue(value interface{}) interface{} {
+	switch value.(type) {
+	case string:
+		return 1e308
+	case []interface{}:
+		return map[string]interface{}{"ffuf": 1}
+	case map[string]interface{}:
+		return []interface{}{"ffuf"}
+	}
+	return "ffuf"
+}
+
+// errorDisclosureFinding returns the finding of an error message matched in the response to a
+// malformed request
+func errorDisclosureFinding(variant errorVariant, resp ffuf.Response, match ErrorMatch) VulnerabilityInfo {
+	kind := errorKinds[match.Signature.Kind]
+	severity := match.Signature.Severity
+	if severity == "" {
+		severity = kind.severity
+	}
+	cvss := 5.3
+	switch severity {
+	case "Low":
+		cvss = 3.7
+	case "Info":
+		cvss = 0
+	}
+	evidence := match.Match
+	if len(evidence) > 200 {
+		evidence = evidence[:200] + "..."
+	}
+	return VulnerabilityInfo{
+		Type:        VulnErrorDisclosure,
+		Name:        fmt.Sprintf("%s (%s)", kind.name, match.Signature.Technology),
+		Description: fmt.Sprintf("The API discloses a %s in its response to a request with %s, revealing that it runs %s and helping attackers craft targeted attacks.", match.Signature.Name, variant.description, match.Signature.Technology),
+		Severity:    severity,
+		Request:     convertToHTTPRequest(variant.request),
+		Response:    convertToHTTPResponse(resp),
+		Evidence:    fmt.Sprintf("The response to the request with %s (status %d) matches the %s signature: %s", variant.description, resp.StatusCode, match.Signature.Name, evidence),
+		Remediation: "Return generic error messages to clients and log the details on the server. Disable the debug mode of frameworks in production, and handle parsing and validation errors so that they return 400 Bad Request responses without internal details.",
+		CVSS:        cvss,
+		CWE:         "CWE-209",
+		References: []string{
+			"https://owasp.org/www-community/Improper_Error_Handling",
+			"https://cheatsheetseries.owasp.org/cheatsheets/Error_Handling_Cheat_Sheet.html",
+		},
+		DetectedAt: time.Now(),
+	}
+}
+
+func init() {
+	// Register the tester with the default registry
+	RegisterSecurityTester(NewErrorDisclosureTester())
+}
@@ -0,0 +1,145 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// javaStackTrace is the error page of a Spring application failing to parse a request
+const javaStackTrace = `java.lang.IllegalStateException: Unexpected character
+	at com.example.api.UserController.create(UserController.java:42)
+	at org.springframework.web.servlet.FrameworkServlet.service(FrameworkServlet.java:897)`
+
+// compiledErrorSignatures returns the compiled built-in error signatures
+func compiledErrorSignatures(t *testing.T) []*ErrorSignature {
+	signatures := DefaultErrorSignatures()
+	if err := compileErrorSignatures(signatures); err != nil {
+		t.Fatalf("compileErrorSignatures returned an error: %s", err)
+	}
+	return signatures
+}
+
+func TestClassifyError(t *testing.T) {
+	signatures := compiledErrorSignatures(t)
+	for body, expected := range map[string]string{
+		javaStackTrace: "Java stack trace",
+		"Traceback (most recent call last):\n  File \"/srv/api/views.py\", line 12, in create": "Python traceback",
+		"panic: runtime error: index out of range [3] with length 3\n\ngoroutine 1 [running]:": "Go panic",
+		"You have an error in your SQL syntax; check the manual near ''' at line 1":            "MySQL error",
+		`ERROR:  syntax error at or near "'" at character 34`:                                  "PostgreSQL error",
+		"<b>Fatal error</b>: Uncaught TypeError in /var/www/html/api/index.php on line 17":     "PHP error",
+	} {
+		matches := ClassifyError(ffuf.Response{Data: []byte(body)}, signatures)
+		if len(matches) == 0 || matches[0].Signature.Name != expected {
+			t.Errorf("Expected %q to match the %s signature, got %v", body, expected, matches)
+		}
+	}
+
+	// The match is extended to its line
+	matches := ClassifyError(ffuf.Response{Data: []byte(javaStackTrace)}, signatures)
+	if matches[0].Match != "at com.example.api.UserController.create(UserController.java:42)" {
+		t.Errorf("Expected the line of the match, got %q", matches[0].Match)
+	}
+
+	// Headers are classified too
+	resp := ffuf.Response{Headers: map[string][]string{"X-Error": {"org.hibernate.exception.SQLGrammarException"}}}
+	if matches := ClassifyError(resp, signatures); len(matches) != 1 || matches[0].Signature.Technology != "Hibernate" {
+		t.Errorf("Expected the header to match the Hibernate signature, got %v", matches)
+	}
+
+	// Generic errors and content mentioning errors are not disclosures
+	for _, body := range []string{
+		`{"error":"Bad Request","message":"The request body is not valid JSON"}`,
+		`{"title":"An exception was raised at the office party","path":"/docs/errors.html"}`,
+		"Internal Server Error",
+	} {
+		if matches := ClassifyError(ffuf.Response{Data: []byte(body)}, signatures); len(matches) != 0 {
+			t.Errorf("Expected %q not to match, got the %s signature", body, matches[0].Signature.Name)
+		}
+	}
+}
+
+func TestErrorDisclosureTester(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/users")
+
+	// The malformed JSON body triggers the error page of the application
+	result, _ := runFake(t, NewErrorDisclosureTester(), conf, func(req *ffuf.Request) ffuf.Response {
+		if req.Method == "POST" {
+			return ffuf.Response{StatusCode: 500, Data: []byte(javaStackTrace)}
+		}
+		return ffuf.Response{Data: []byte(`{"users":[]}`)}
+	})
+	names := vulnerabilityNames(result)
+	if strings.Join(names, ",") != "Stack Trace Disclosure (Java),Framework Error Disclosure (Spring)" {
+		t.Fatalf("Expected the Java stack trace and the Spring error to be reported once, got %v", names)
+	}
+	vuln := result.Vulnerabilities[0]
+	if vuln.Severity != "Medium" || !strings.Contains(vuln.Evidence, "request with malformed JSON body (status 500) matches the Java stack trace signature") {
+		t.Errorf("Expected the variant and the signature as evidence, got %s: %s", vuln.Severity, vuln.Evidence)
+	}
+
+	// Generic error responses are not reported
+	result, _ = runFake(t, NewErrorDisclosureTester(), conf, func(req *ffuf.Request) ffuf.Response {
+		if req.Method == "POST" || strings.Contains(req.Url, "ffuf=") {
+			return ffuf.Response{StatusCode: 400, Data: []byte(`{"error":"Bad Request"}`)}
+		}
+		return ffuf.Response{Data: []byte(`{"users":[]}`)}
+	})
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability for generic errors, got %v", vulnerabilityNames(result))
+	}
+
+	// Messages present in the normal response are not reported
+	result, _ = runFake(t, NewErrorDisclosureTester(), conf, func(req *ffuf.Request) ffuf.Response {
+		return ffuf.Response{Data: []byte(`{"example":"Traceback (most recent call last):"}`)}
+	})
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected the messages of the baseline not to be reported, got %v", vulnerabilityNames(result))
+	}
+}
+
+func TestLoadErrorSignatures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signatures.yaml")
+	content := `signatures:
+  - name: Acme error
+    technology: Acme
+    kind: framework
+    pattern: 'AcmeException: .+'
+    severity: High
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile returned an error: %s", err)
+	}
+	tester := NewErrorDisclosureTester()
+	tester.SignaturesFile = path
+	result, _ := runFake(t, tester, newFakeConfig(t, "https://api.example.com/users?id=1"), func(req *ffuf.Request) ffuf.Response {
+		if strings.Contains(req.Url, "id[]=") {
+			return ffuf.Response{StatusCode: 500, Data: []byte("AcmeException: id must be a number")}
+		}
+		return ffuf.Response{Data: []byte(`{"id":1}`)}
+	})
+	if len(result.Vulnerabilities) != 1 || result.Vulnerabilities[0].Name != "Framework Error Disclosure (Acme)" || result.Vulnerabilities[0].Severity != "High" {
+		t.Fatalf("Expected the signature of the file to be reported, got %v", vulnerabilityNames(result))
+	}
+	if !strings.Contains(result.Vulnerabilities[0].Evidence, "request with parameters of the wrong type") {
+		t.Errorf("Expected the wrong type variant as evidence, got %s", result.Vulnerabilities[0].Evidence)
+	}
+
+	for content, expected := range map[string]string{
+		"signatures:\n  - kind: sql\n    pattern: x\n":                    "has no name",
+		"signatures:\n  - name: X\n    kind: debug\n    pattern: x\n":     "kind must be",
+		"signatures:\n  - name: X\n    kind: sql\n    pattern: '('\n":     "invalid pattern",
+		"signatures:\n  - name: X\n    kind: sql\n    severity: Severe\n": "severity must be",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile returned an error: %s", err)
+		}
+		if _, err := LoadErrorSignatures(path); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q, got %v", expected, err)
+		}
+	}
+}
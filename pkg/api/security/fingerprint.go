@@ -0,0 +1,50 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// fingerprintIdentifierPattern matches path segments holding identifiers: numbers, UUIDs and
+// long hexadecimal strings such as object IDs
+var fingerprintIdentifierPattern = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{24,})$`)
+
+// Fingerprint returns an identifier of the vulnerability that is stable across scans. It
+// hashes the vulnerability type, the payload class, the endpoint and the vulnerable parameter.
+// Identifiers in the path and the values of parameters are ignored, so that a vulnerability
+// found again with other payloads or on other objects keeps its fingerprint.
+func (v VulnerabilityInfo) Fingerprint() string {
+	method, endpoint, params := "", "", ""
+	if v.Request != nil && v.Request.URL != nil {
+		u := v.Request.URL
+		method = strings.ToUpper(v.Request.Method)
+		segments := strings.Split(u.Path, "/")
+		for i, segment := range segments {
+			if fingerprintIdentifierPattern.MatchString(segment) {
+				segments[i] = "{id}"
+			}
+		}
+		endpoint = strings.ToLower(u.Scheme+"://"+u.Host) + strings.Join(segments, "/")
+		names := make([]string, 0)
+		for name := range u.Query() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		params = strings.Join(names, "&")
+	}
+	parameter := v.Parameter
+	if parameter == "" {
+		// Without a known parameter, the parameters of the request are part of the endpoint
+		parameter = "?" + params
+	}
+	class := v.PayloadClass
+	if class == "" {
+		class = v.Name
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{v.Type.String(), class, method, endpoint, parameter}, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
@@ -7,8 +7,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // BrokenFunctionLevelAuthTester implements testing for Broken Function Level Authorization (API5:2019)
@@ -19,6 +19,9 @@ type BrokenFunctionLevelAuthTester struct {
 	UserRoles          []string
 	RolePermissionMap  map[string][]string
 	MethodPermissionMap map[string][]string
+	TestMethodOverride bool
+	OverrideHeaders    []string
+	TamperingVerbs     []string
 }
 
 // NewBrokenFunctionLevelAuthTester creates a new tester for Broken Function Level Authorization
@@ -46,6 +49,9 @@ func NewBrokenFunctionLevelAuthTester() *BrokenFunctionLevelAuthTester {
 			"PATCH":  {"write", "write_own"},
 			"DELETE": {"delete"},
 		},
+		TestMethodOverride: true,
+		OverrideHeaders:    append([]string{}, payload.DefaultMethodOverrideHeaders...),
+		TamperingVerbs:     append([]string{}, payload.DefaultTamperingVerbs...),
 	}
 }
 
@@ -72,26 +78,34 @@ func (t *BrokenFunctionLevelAuthTester) Test(ctx context.Context, config *ffuf.C
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract potential endpoints from the config
 	endpoints := extractEndpointsFromConfig(config)
 
 	// Test each endpoint for function level authorization vulnerabilities
 	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
 		// Test for admin endpoints accessible without admin privileges
 		if t.isAdminEndpoint(endpoint) {
 			t.testAdminEndpoint(endpoint, r, result)
 		}
 
 		// Test for sensitive methods accessible without proper authorization
-		t.testSensitiveMethods(endpoint, r, result)
+		t.testSensitiveMethods(ctx, endpoint, r, result)
 
 		// Test for horizontal privilege escalation
 		t.testHorizontalPrivilegeEscalation(endpoint, r, result)
 
 		// Test for vertical privilege escalation
-		t.testVerticalPrivilegeEscalation(endpoint, r, result)
+		t.testVerticalPrivilegeEscalation(ctx, endpoint, r, result)
+
+		// Test for denied methods allowed through method override or verb tampering
+		if t.TestMethodOverride {
+			t.testMethodOverride(ctx, endpoint, config, r, result)
+		}
 	}
 
 	result.EndTime = time.Now()
@@ -126,8 +140,8 @@ func (t *BrokenFunctionLevelAuthTester) testAdminEndpoint(endpoint string, r ffu
 		return
 	}
 
-	// Check if the request was successful
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+	// Check if the request was successful, and not answered like any other path
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && !matchesControl(controlResponse(r, req.Method, controlSiblingURL(endpoint), req.Headers), resp) {
 		// Create a vulnerability info
 		vuln := VulnerabilityInfo{
 			Type:        VulnBrokenFunctionLevelAuth,
@@ -151,8 +165,11 @@ func (t *BrokenFunctionLevelAuthTester) testAdminEndpoint(endpoint string, r ffu
 }
 
 // testSensitiveMethods tests if sensitive HTTP methods are accessible without proper authorization
-func (t *BrokenFunctionLevelAuthTester) testSensitiveMethods(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *BrokenFunctionLevelAuthTester) testSensitiveMethods(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	for _, method := range t.AdminMethods {
+		if ctx.Err() != nil {
+			return
+		}
 		// Try to access the endpoint with a sensitive method
 		req := &ffuf.Request{
 			Method: method,
@@ -168,8 +185,8 @@ func (t *BrokenFunctionLevelAuthTester) testSensitiveMethods(endpoint string, r
 			continue
 		}
 
-		// Check if the request was successful
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		// Check if the request was successful, and not answered like any other path
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 && !matchesControl(controlResponse(r, method, controlSiblingURL(endpoint), req.Headers), resp) {
 			// Create a vulnerability info
 			vuln := VulnerabilityInfo{
 				Type:        VulnBrokenFunctionLevelAuth,
@@ -193,6 +210,97 @@ func (t *BrokenFunctionLevelAuthTester) testSensitiveMethods(endpoint string, r
 	}
 }
 
+// testMethodOverride tests if methods denied to the user are allowed when asked for through
+// method override headers, query parameters or body fields, or when sent as uncommon verbs
+func (t *BrokenFunctionLevelAuthTester) testMethodOverride(ctx context.Context, endpoint string, config *ffuf.Config, r ffuf.RunnerProvider, result *TestResult) {
+	tamperer := payload.NewMethodTamperer()
+	tamperer.Headers = t.OverrideHeaders
+	tamperer.Verbs = t.TamperingVerbs
+
+	// Methods allowed without an override show nothing about the override
+	carrierAllowed := make(map[string]bool)
+
+	for _, method := range append([]string{"GET"}, t.AdminMethods...) {
+		if ctx.Err() != nil {
+			return
+		}
+		req := configRequest(config, endpoint)
+		req.Method = method
+		denied, err := r.Execute(req)
+		if err != nil || !isDeniedAccess(denied) {
+			continue
+		}
+
+		// Each technique is reported once per method
+		reported := make(map[payload.MethodOverrideTechnique]bool)
+		for _, variant := range tamperer.Variants(method) {
+			if ctx.Err() != nil {
+				return
+			}
+			if reported[variant.Technique] {
+				continue
+			}
+			variantReq, err := variant.Apply(req)
+			if err != nil {
+				continue
+			}
+			resp, err := r.Execute(variantReq)
+			if err != nil || !isSuccessfulAccess(resp) {
+				continue
+			}
+
+			name := "HTTP Method Override Bypasses Access Control"
+			description := "A method denied to the user is allowed when asked for through a method override, which the access control rules do not check while the application honors it."
+			cvss := 8.1
+			if variant.Technique == payload.MethodOverrideVerb {
+				// The verb may be answered alike for any path
+				if matchesControl(controlResponse(r, variant.Method, controlSiblingURL(endpoint), req.Headers), resp) {
+					continue
+				}
+				name = "HTTP Verb Tampering Bypasses Access Control"
+				description = "A method denied to the user is allowed when the request is sent with another verb, which the access control rules do not cover while the application processes it."
+				cvss = 7.5
+			} else {
+				allowed, ok := carrierAllowed[variant.Method]
+				if !ok {
+					control := *req
+					control.Method = variant.Method
+					controlResp, err := r.Execute(&control)
+					allowed = err != nil || isSuccessfulAccess(controlResp)
+					carrierAllowed[variant.Method] = allowed
+				}
+				if allowed {
+					continue
+				}
+			}
+
+			reported[variant.Technique] = true
+			result.Vulnerabilities = append(result.Vulnerabilities, VulnerabilityInfo{
+				Type:        VulnBrokenFunctionLevelAuth,
+				Name:        name,
+				Description: description,
+				Severity:    "High",
+				Request:     convertToHTTPRequest(variantReq),
+				Response:    convertToHTTPResponse(resp),
+				Evidence:    fmt.Sprintf("%s %s was denied with %d, while %s returned %d", method, endpoint, denied.StatusCode, variant.Description, resp.StatusCode),
+				Remediation: "Enforce authorization on the method the application processes rather than on the method of the request line. Disable method override headers and parameters, or check access after they are applied, and reject unknown verbs.",
+				CVSS:        cvss,
+				CWE:         "CWE-650",
+				References: []string{
+					"https://owasp.org/API-Security/editions/2019/en/0xa5-broken-function-level-authorization/",
+					"https://owasp.org/www-project-web-security-testing-guide/latest/4-Web_Application_Security_Testing/02-Configuration_and_Deployment_Management_Testing/06-Test_HTTP_Methods",
+				},
+				DetectedAt: time.Now(),
+			})
+		}
+	}
+}
+
+// isDeniedAccess checks if a response denies the request to the user
+func isDeniedAccess(resp ffuf.Response) bool {
+	return resp.StatusCode == 401 || resp.StatusCode == 403 || resp.StatusCode == 405
+}
+
 // testHorizontalPrivilegeEscalation tests for horizontal privilege escalation vulnerabilities
 func (t *BrokenFunctionLevelAuthTester) testHorizontalPrivilegeEscalation(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Skip endpoints that don't look like they would have user-specific resources
@@ -223,8 +331,10 @@ func (t *BrokenFunctionLevelAuthTester) testHorizontalPrivilegeEscalation(endpoi
 		return
 	}
 
-	// Check if the request was successful
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+	// Check if the request was successful, and not answered like a request for a resource which
+	// does not exist
+	control := controlResponse(r, req.Method, replaceIDInEndpoint(endpoint, controlIdentifier()), req.Headers)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && !matchesControl(control, resp) {
 		// Create a vulnerability info
 		vuln := VulnerabilityInfo{
 			Type:        VulnBrokenFunctionLevelAuth,
@@ -248,7 +358,7 @@ func (t *BrokenFunctionLevelAuthTester) testHorizontalPrivilegeEscalation(endpoi
 }
 
 // testVerticalPrivilegeEscalation tests for vertical privilege escalation vulnerabilities
-func (t *BrokenFunctionLevelAuthTester) testVerticalPrivilegeEscalation(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *BrokenFunctionLevelAuthTester) testVerticalPrivilegeEscalation(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Try to access the endpoint with a higher privilege level operation
 	for _, role := range t.UserRoles {
 		// Get the permissions for this role
@@ -258,6 +368,9 @@ func (t *BrokenFunctionLevelAuthTester) testVerticalPrivilegeEscalation(endpoint
 		}
 
 		for method, methodPermissions := range t.MethodPermissionMap {
+			if ctx.Err() != nil {
+				return
+			}
 			// Check if this method requires permissions that the role doesn't have
 			hasPermission := false
 			for _, methodPerm := range methodPermissions {
@@ -288,8 +401,8 @@ func (t *BrokenFunctionLevelAuthTester) testVerticalPrivilegeEscalation(endpoint
 					continue
 				}
 
-				// Check if the request was successful
-				if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				// Check if the request was successful, and not answered like any other path
+				if resp.StatusCode >= 200 && resp.StatusCode < 300 && !matchesControl(controlResponse(r, method, controlSiblingURL(endpoint), req.Headers), resp) {
 					// Create a vulnerability info
 					vuln := VulnerabilityInfo{
 						Type:        VulnBrokenFunctionLevelAuth,
@@ -0,0 +1,415 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// GraphQLSecurityTester implements testing for the security controls of GraphQL endpoints:
+// introspection, field suggestions, query depth and complexity limits, batching, aliasing and
+// cross-site request forgery
+type GraphQLSecurityTester struct {
+	// Configuration options
+	GraphQLPaths         []string
+	QueryDepth           int
+	ComplexityAliases    int
+	BatchSize            int
+	AliasCount           int
+	TestIntrospection    bool
+	TestFieldSuggestions bool
+	TestQueryLimits      bool
+	TestBatching         bool
+	TestAliasing         bool
+	TestCSRF             bool
+}
+
+// NewGraphQLSecurityTester creates a new tester for GraphQL endpoints
+func NewGraphQLSecurityTester() *GraphQLSecurityTester {
+	return &GraphQLSecurityTester{
+		GraphQLPaths:         []string{"/graphql", "/api/graphql", "/graphql/v1", "/v1/graphql", "/gql", "/query"},
+		QueryDepth:           15,
+		ComplexityAliases:    20,
+		BatchSize:            10,
+		AliasCount:           100,
+		TestIntrospection:    true,
+		TestFieldSuggestions: true,
+		TestQueryLimits:      true,
+		TestBatching:         true,
+		TestAliasing:         true,
+		TestCSRF:             true,
+	}
+}
+
+// GetType returns the type of vulnerability this tester checks for
+func (t *GraphQLSecurityTester) GetType() VulnerabilityType {
+	return VulnGraphQL
+}
+
+// GetName returns the name of the security test
+func (t *GraphQLSecurityTester) GetName() string {
+	return "GraphQL Security"
+}
+
+// GetDescription returns a description of the security test
+func (t *GraphQLSecurityTester) GetDescription() string {
+	return "Tests GraphQL endpoints for enabled introspection, field suggestions, missing query depth and complexity limits, batching and alias overloading allowing rate limits to be bypassed, and operations accepted in cross-site requests."
+}
+
+// graphQLResult is the result of a GraphQL operation
+type graphQLResult struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Test runs the security test against the target
+func (t *GraphQLSecurityTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	result := &TestResult{
+		TestName:  t.GetName(),
+		StartTime: time.Now(),
+	}
+
+	// Create a runner for making HTTP requests
+	r := newTestRunner(ctx, config)
+
+	for _, endpoint := range t.graphQLEndpoints(ctx, config, r) {
+		if ctx.Err() != nil {
+			break
+		}
+		base := configRequest(config, endpoint)
+
+		if t.TestIntrospection {
+			t.testIntrospection(base, r, result)
+		}
+		if t.TestFieldSuggestions {
+			t.testFieldSuggestions(base, r, result)
+		}
+		if t.TestQueryLimits {
+			t.testQueryLimits(base, r, result)
+		}
+		if t.TestBatching {
+			t.testBatching(base, r, result)
+		}
+		if t.TestAliasing {
+			t.testAliasing(base, r, result)
+		}
+		if t.TestCSRF {
+			t.testCSRF(base, r, result)
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// graphQLEndpoints returns the configured endpoints answering GraphQL operations, or the
+// GraphQL paths of their hosts answering them if they do not
+func (t *GraphQLSecurityTester) graphQLEndpoints(ctx context.Context, config *ffuf.Config, r ffuf.RunnerProvider) []string {
+	endpoints := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, endpoint := range extractEndpointsFromConfig(config) {
+		if ctx.Err() != nil {
+			break
+		}
+		candidates := []string{endpoint}
+		if u, err := url.Parse(endpoint); err == nil {
+			for _, path := range t.GraphQLPaths {
+				candidates = append(candidates, (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: path}).String())
+			}
+		}
+		for _, candidate := range candidates {
+			if ctx.Err() != nil {
+				break
+			}
+			if seen[candidate] {
+				continue
+			}
+			seen[candidate] = true
+			_, resp, err := postGraphQL(configRequest(config, candidate), map[string]string{"query": "query { __typename }"}, r)
+			if err != nil {
+				continue
+			}
+			if gql, ok := parseGraphQLResult(resp.Data); ok && gql.Data["__typename"] != nil {
+				endpoints = append(endpoints, candidate)
+				break
+			}
+		}
+	}
+	return endpoints
+}
+
+// testIntrospection tests if the schema can be read with the introspection query
+func (t *GraphQLSecurityTester) testIntrospection(base *ffuf.Request, r ffuf.RunnerProvider, result *TestResult) {
+	req, resp, err := postGraphQL(base, map[string]string{"query": parser.GraphQLIntrospectionQuery}, r)
+	if err != nil {
+		return
+	}
+	schema := parser.NewGraphQLSchemaParser(base.Url)
+	if schema.ParseJSON(resp.Data) != nil || len(schema.Types) == 0 {
+		return
+	}
+	result.Vulnerabilities = append(result.Vulnerabilities, graphQLVulnerability(
+		"GraphQL Introspection Enabled",
+		"The GraphQL endpoint answers the introspection query, giving attackers the complete schema including the operations and fields not used by the clients.",
+		"Medium", 5.3, "CWE-200",
+		fmt.Sprintf("The introspection query returned %d types, %d queries and %d mutations", len(schema.Types), len(schema.Queries), len(schema.Mutations)),
+		"Disable introspection in production, or restrict it to authenticated developers.",
+		req, resp))
+}
+
+// testFieldSuggestions tests if validation errors suggest the names of existing fields, which
+// allows the schema to be recovered when introspection is disabled
+func (t *GraphQLSecurityTester) testFieldSuggestions(base *ffuf.Request, r ffuf.RunnerProvider, result *TestResult) {
+	query := "query { usr accont ordr produc seach viewr __schema { typ } }"
+	req, resp, err := postGraphQL(base, map[string]string{"query": query}, r)
+	if err != nil {
+		return
+	}
+	gql, ok := parseGraphQLResult(resp.Data)
+	if !ok {
+		return
+	}
+	suggestions := make([]string, 0)
+	for _, e := range gql.Errors {
+		if strings.Contains(e.Message, "Did you mean") && len(suggestions) < 3 {
+			suggestions = append(suggestions, e.Message)
+		}
+	}
+	if len(suggestions) == 0 {
+		return
+	}
+	result.Vulnerabilities = append(result.Vulnerabilities, graphQLVulnerability(
+		"GraphQL Field Suggestions Enabled",
+		"Validation errors of the GraphQL endpoint suggest the names of existing fields, allowing the schema to be recovered with misspelled queries even when introspection is disabled.",
+		"Low", 3.7, "CWE-209",
+		"Validation errors suggested fields: "+strings.Join(suggestions, " | "),
+		"Disable field suggestions in production, and return generic validation errors.",
+		req, resp))
+}
+
+// testQueryLimits tests if deeply nested queries and queries requesting the schema many times
+// are executed
+func (t *GraphQLSecurityTester) testQueryLimits(base *ffuf.Request, r ffuf.RunnerProvider, result *TestResult) {
+	if t.QueryDepth > 0 {
+		req, resp, err := postGraphQL(base, map[string]string{"query": nestedIntrospectionQuery(t.QueryDepth)}, r)
+		if err == nil && isExecutedGraphQL(resp, "__schema") {
+			result.Vulnerabilities = append(result.Vulnerabilities, graphQLVulnerability(
+				"No GraphQL Query Depth Limit",
+				"The GraphQL endpoint executes deeply nested queries, whose cost grows exponentially with their depth, allowing denial of service with a single request.",
+				"Medium", 5.3, "CWE-770",
+				fmt.Sprintf("A query nested %d levels deep was executed", t.QueryDepth),
+				"Limit the depth of queries, for example to 10 levels, and reject deeper queries before executing them.",
+				req, resp))
+		}
+	}
+
+	if t.ComplexityAliases > 0 {
+		selections := make([]string, 0, t.ComplexityAliases)
+		for i := 0; i < t.ComplexityAliases; i++ {
+			selections = append(selections, fmt.Sprintf("c%d: __schema { types { fields { args { name type { name } } type { name } } } }", i))
+		}
+		last := fmt.Sprintf("c%d", t.ComplexityAliases-1)
+		req, resp, err := postGraphQL(base, map[string]string{"query": "query { " + strings.Join(selections, " ") + " }"}, r)
+		if err == nil && isExecutedGraphQL(resp, last) {
+			result.Vulnerabilities = append(result.Vulnerabilities, graphQLVulnerability(
+				"No GraphQL Query Complexity Limit",
+				"The GraphQL endpoint executes queries requesting expensive fields many times, allowing denial of service with a single request.",
+				"Medium", 5.3, "CWE-770",
+				fmt.Sprintf("A query requesting the whole schema %d times was executed", t.ComplexityAliases),
+				"Compute the cost of queries from their fields and the sizes of their lists, and reject queries above a maximum cost.",
+				req, resp))
+		}
+	}
+}
+
+// testBatching tests if an array of operations is executed in a single request, which allows
+// rate limits counting requests to be bypassed
+func (t *GraphQLSecurityTester) testBatching(base *ffuf.Request, r ffuf.RunnerProvider, result *TestResult) {
+	if t.BatchSize < 2 {
+		return
+	}
+	batch := make([]map[string]string, 0, t.BatchSize)
+	for i := 0; i < t.BatchSize; i++ {
+		batch = append(batch, map[string]string{"query": "query { __typename }"})
+	}
+	req, resp, err := postGraphQL(base, batch, r)
+	if err != nil || !isSuccessfulAccess(resp) {
+		return
+	}
+	var results []graphQLResult
+	if json.Unmarshal(resp.Data, &results) != nil || len(results) != t.BatchSize {
+		return
+	}
+	for _, res := range results {
+		if res.Data["__typename"] == nil {
+			return
+		}
+	}
+	result.Vulnerabilities = append(result.Vulnerabilities, graphQLVulnerability(
+		"GraphQL Query Batching Allowed",
+		"The GraphQL endpoint executes arrays of operations in a single request, allowing attackers to bypass rate limits counting requests, for example to brute force passwords or one-time codes.",
+		"Medium", 5.3, "CWE-799",
+		fmt.Sprintf("A batch of %d operations was executed in a single request", t.BatchSize),
+		"Disable batching, or limit the number of operations of a batch and count each operation in the rate limits.",
+		req, resp))
+}
+
+// testAliasing tests if a field requested under many aliases is executed for each of them,
+// which allows rate limits counting requests or operations to be bypassed
+func (t *GraphQLSecurityTester) testAliasing(base *ffuf.Request, r ffuf.RunnerProvider, result *TestResult) {
+	if t.AliasCount < 2 {
+		return
+	}
+	selections := make([]string, 0, t.AliasCount)
+	for i := 0; i < t.AliasCount; i++ {
+		selections = append(selections, fmt.Sprintf("a%d: __typename", i))
+	}
+	last := fmt.Sprintf("a%d", t.AliasCount-1)
+	req, resp, err := postGraphQL(base, map[string]string{"query": "query { " + strings.Join(selections, " ") + " }"}, r)
+	if err != nil || !isExecutedGraphQL(resp, last) {
+		return
+	}
+	result.Vulnerabilities = append(result.Vulnerabilities, graphQLVulnerability(
+		"GraphQL Alias Overloading",
+		"The GraphQL endpoint executes a field requested under many aliases once per alias, allowing attackers to bypass rate limits by calling a mutation such as a login many times in one operation.",
+		"Medium", 5.3, "CWE-799",
+		fmt.Sprintf("A field requested under %d aliases was executed for each alias", t.AliasCount),
+		"Limit the number of aliases and of root fields of an operation, and count each call of sensitive mutations in the rate limits.",
+		req, resp))
+}
+
+// testCSRF tests if operations are executed when sent as a GET request or as a form, which
+// browsers send cross-site with the cookies of the user
+func (t *GraphQLSecurityTester) testCSRF(base *ffuf.Request, r ffuf.RunnerProvider, result *TestResult) {
+	// Operations are sent in the query string of GET requests, and as forms in POST requests
+	send := func(method, query string) (*ffuf.Request, ffuf.Response, bool) {
+		req := withHeaders(base, map[string]string{"Content-Type": "application/x-www-form-urlencoded"})
+		req.Method = method
+		req.Data = []byte("query=" + url.QueryEscape(query))
+		if method == "GET" {
+			delete(req.Headers, "Content-Type")
+			req.Data = nil
+			req.Url = addOrReplaceParameter(base.Url, "query", url.QueryEscape(query))
+		}
+		resp, err := r.Execute(req)
+		return req, resp, err == nil && isExecutedGraphQL(resp, "__typename")
+	}
+
+	// A mutation selecting __typename is valid when the schema has mutations, and changes nothing
+	vectors := make([]string, 0)
+	var firstReq *ffuf.Request
+	var firstResp ffuf.Response
+	for _, method := range []string{"GET", "POST"} {
+		req, resp, ok := send(method, "mutation { __typename }")
+		if !ok {
+			continue
+		}
+		if method == "GET" {
+			vectors = append(vectors, "a GET request")
+		} else {
+			vectors = append(vectors, "a form-encoded POST request")
+		}
+		if firstReq == nil {
+			firstReq, firstResp = req, resp
+		}
+	}
+	if firstReq != nil {
+		result.Vulnerabilities = append(result.Vulnerabilities, graphQLVulnerability(
+			"GraphQL Mutations Vulnerable to CSRF",
+			"The GraphQL endpoint executes mutations sent as requests that browsers send cross-site without a preflight, allowing other sites to perform actions with the session of a user.",
+			"High", 7.1, "CWE-352",
+			"A mutation was executed from "+strings.Join(vectors, " and "),
+			"Only execute operations sent as POST requests with a JSON content type, reject mutations in GET requests, and require a CSRF token or a custom header for cookie authenticated requests.",
+			firstReq, firstResp))
+		return
+	}
+
+	if req, resp, ok := send("GET", "query { __typename }"); ok {
+		result.Vulnerabilities = append(result.Vulnerabilities, graphQLVulnerability(
+			"GraphQL Queries Over GET",
+			"The GraphQL endpoint executes queries sent as GET requests, which other sites can send with the cookies of a user and which are logged by proxies along with their arguments.",
+			"Low", 3.1, "CWE-352",
+			"A query was executed from a GET request",
+			"Only execute queries sent as GET requests for persisted queries without sensitive arguments, and require a custom header for cookie authenticated requests.",
+			req, resp))
+	}
+}
+
+// postGraphQL sends a GraphQL payload as a JSON POST request with the headers of a request
+func postGraphQL(base *ffuf.Request, payload interface{}, r ffuf.RunnerProvider) (*ffuf.Request, ffuf.Response, error) {
+	data, _ := json.Marshal(payload)
+	req := withHeaders(base, map[string]string{"Content-Type": "application/json"})
+	req.Method = "POST"
+	req.Data = data
+	resp, err := r.Execute(req)
+	return req, resp, err
+}
+
+// parseGraphQLResult parses a GraphQL result
+func parseGraphQLResult(data []byte) (*graphQLResult, bool) {
+	var result graphQLResult
+	if err := json.Unmarshal(data, &result); err != nil || (result.Data == nil && result.Errors == nil) {
+		return nil, false
+	}
+	return &result, true
+}
+
+// isExecutedGraphQL checks if a response is the result of an operation executed without
+// errors, with a value for the field
+func isExecutedGraphQL(resp ffuf.Response, field string) bool {
+	if !isSuccessfulAccess(resp) {
+		return false
+	}
+	result, ok := parseGraphQLResult(resp.Data)
+	return ok && len(result.Errors) == 0 && result.Data[field] != nil
+}
+
+// nestedIntrospectionQuery returns an introspection query nested to a depth, by alternating
+// the fields and type of introspection types
+func nestedIntrospectionQuery(depth int) string {
+	query := "__typename"
+	for i := 0; i < depth; i++ {
+		if i%2 == 0 {
+			query = "fields { type { " + query + " } }"
+		} else {
+			query = "ofType { " + query + " }"
+		}
+	}
+	return "query { __schema { types { " + query + " } } }"
+}
+
+// graphQLVulnerability creates a GraphQL vulnerability
+func graphQLVulnerability(name, description, severity string, cvss float64, cwe, evidence, remediation string, req *ffuf.Request, resp ffuf.Response) VulnerabilityInfo {
+	return VulnerabilityInfo{
+		Type:        VulnGraphQL,
+		Name:        name,
+		Description: description,
+		Severity:    severity,
+		Request:     convertToHTTPRequest(req),
+		Response:    convertToHTTPResponse(resp),
+		Evidence:    evidence,
+		Remediation: remediation,
+		CVSS:        cvss,
+		CWE:         cwe,
+		References: []string{
+			"https://cheatsheetseries.owasp.org/cheatsheets/GraphQL_Cheat_Sheet.html",
+			"https://owasp.org/www-project-web-security-testing-guide/latest/4-Web_Application_Security_Testing/12-API_Testing/01-Testing_GraphQL",
+		},
+		DetectedAt: time.Now(),
+	}
+}
+
+func init() {
+	// Register the tester with the default registry
+	RegisterSecurityTester(NewGraphQLSecurityTester())
+}
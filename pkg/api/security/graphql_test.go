@@ -0,0 +1,122 @@
+package security
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// graphQLSchemaResult is the introspection result of a schema with a query and a mutation
+const graphQLSchemaResult = `{"data": {"__schema": {
+	"queryType": {"name": "Query"},
+	"mutationType": {"name": "Mutation"},
+	"types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [{"name": "user", "args": [], "type": {"kind": "OBJECT", "name": "User"}}]},
+		{"kind": "OBJECT", "name": "Mutation", "fields": [{"name": "createUser", "args": [], "type": {"kind": "OBJECT", "name": "User"}}]},
+		{"kind": "OBJECT", "name": "User", "fields": [{"name": "id", "args": [], "type": {"kind": "SCALAR", "name": "ID"}}]}
+	]
+}}}`
+
+// graphQLServer answers the GraphQL operations posted to /graphql, with the introspection
+// query and batches of operations executed if enabled
+func graphQLServer(introspection, batching bool) func(req *ffuf.Request) ffuf.Response {
+	return func(req *ffuf.Request) ffuf.Response {
+		if req.Method != "POST" || !strings.HasSuffix(req.Url, "/graphql") {
+			return ffuf.Response{StatusCode: 404}
+		}
+		var batch []map[string]string
+		if json.Unmarshal(req.Data, &batch) == nil {
+			if !batching {
+				return ffuf.Response{StatusCode: 400, Data: []byte(`{"errors":[{"message":"batching is not supported"}]}`)}
+			}
+			results := make([]string, len(batch))
+			for i := range batch {
+				results[i] = `{"data":{"__typename":"Query"}}`
+			}
+			return ffuf.Response{Data: []byte("[" + strings.Join(results, ",") + "]")}
+		}
+		var operation map[string]string
+		if err := json.Unmarshal(req.Data, &operation); err != nil {
+			return ffuf.Response{StatusCode: 400}
+		}
+		switch query := operation["query"]; {
+		case strings.Contains(query, "IntrospectionQuery"):
+			if !introspection {
+				return ffuf.Response{Data: []byte(`{"errors":[{"message":"GraphQL introspection is not allowed"}]}`)}
+			}
+			return ffuf.Response{Data: []byte(graphQLSchemaResult)}
+		case query == "query { __typename }":
+			return ffuf.Response{Data: []byte(`{"data":{"__typename":"Query"}}`)}
+		}
+		return ffuf.Response{Data: []byte(`{"errors":[{"message":"Cannot query field"}]}`)}
+	}
+}
+
+// newGraphQLTester returns a GraphQL tester of the introspection and batching only
+func newGraphQLTester() *GraphQLSecurityTester {
+	tester := NewGraphQLSecurityTester()
+	tester.TestFieldSuggestions = false
+	tester.TestQueryLimits = false
+	tester.TestAliasing = false
+	tester.TestCSRF = false
+	return tester
+}
+
+func TestGraphQLSecurityTester_Introspection(t *testing.T) {
+	// The GraphQL endpoint is found under the host of the target
+	conf := newFakeConfig(t, "https://api.example.com/")
+	result, _ := runFake(t, newGraphQLTester(), conf, graphQLServer(true, false))
+	if names := vulnerabilityNames(result); len(names) != 1 || names[0] != "GraphQL Introspection Enabled" {
+		t.Fatalf("Expected the introspection to be reported, got %v", names)
+	}
+	vuln := result.Vulnerabilities[0]
+	if vuln.Evidence != "The introspection query returned 3 types, 1 queries and 1 mutations" {
+		t.Errorf("Expected the schema as evidence, got %s", vuln.Evidence)
+	}
+	if vuln.Request.URL.String() != "https://api.example.com/graphql" {
+		t.Errorf("Expected the GraphQL endpoint to be reported, got %s", vuln.Request.URL)
+	}
+
+	result, _ = runFake(t, newGraphQLTester(), conf, graphQLServer(false, false))
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability with introspection disabled, got %v", vulnerabilityNames(result))
+	}
+}
+
+func TestGraphQLSecurityTester_Batching(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/graphql")
+	tester := newGraphQLTester()
+	tester.TestIntrospection = false
+	result, runner := runFake(t, tester, conf, graphQLServer(false, true))
+	if names := vulnerabilityNames(result); len(names) != 1 || names[0] != "GraphQL Query Batching Allowed" {
+		t.Fatalf("Expected the batching to be reported, got %v", names)
+	}
+	batches := 0
+	for _, req := range runner.Requests() {
+		var batch []map[string]string
+		if json.Unmarshal(req.Data, &batch) == nil && len(batch) == tester.BatchSize {
+			batches++
+		}
+	}
+	if batches != 1 {
+		t.Errorf("Expected a batch of %d operations, got %d batches", tester.BatchSize, batches)
+	}
+
+	result, _ = runFake(t, tester, conf, graphQLServer(false, false))
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability with batching disabled, got %v", vulnerabilityNames(result))
+	}
+
+	// Batches answered with fewer results than operations are not executed
+	result, _ = runFake(t, tester, conf, func(req *ffuf.Request) ffuf.Response {
+		if strings.HasPrefix(string(req.Data), "[") {
+			return ffuf.Response{Data: []byte(`[{"data":{"__typename":"Query"}}]`)}
+		}
+		return graphQLServer(false, true)(req)
+	})
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected a partial batch not to be reported, got %v", vulnerabilityNames(result))
+	}
+}
@@ -0,0 +1,359 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/diff"
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// HeaderAttackTester implements testing for attacks through the request headers of the header
+// catalog that proxies and frameworks trust: host header injection, spoofed client addresses
+// and rewritten URLs bypassing access control, and web cache poisoning
+type HeaderAttackTester struct {
+	// Configuration options
+	CanaryHost         string
+	SpoofedAddresses   []string
+	CacheBusterParam   string
+	TestHostInjection  bool
+	TestACLBypass      bool
+	TestCachePoisoning bool
+}
+
+// NewHeaderAttackTester creates a new tester for header attacks
+func NewHeaderAttackTester() *HeaderAttackTester {
+	return &HeaderAttackTester{
+		SpoofedAddresses:   []string{"127.0.0.1", "10.0.0.1", "192.168.0.1"},
+		CacheBusterParam:   "ffufcb",
+		TestHostInjection:  true,
+		TestACLBypass:      true,
+		TestCachePoisoning: true,
+	}
+}
+
+// GetType returns the type of vulnerability this tester checks for
+func (t *HeaderAttackTester) GetType() VulnerabilityType {
+	return VulnHeaderAttack
+}
+
+// GetName returns the name of the security test
+func (t *HeaderAttackTester) GetName() string {
+	return "Header Attacks"
+}
+
+// GetDescription returns a description of the security test
+func (t *HeaderAttackTester) GetDescription() string {
+	return "Tests for API endpoints trusting request headers set by proxies, allowing host header injection, access control bypass with spoofed client addresses or rewritten URLs, and web cache poisoning."
+}
+
+// Test runs the security test against the target
+func (t *HeaderAttackTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	result := &TestResult{
+		TestName:  t.GetName(),
+		StartTime: time.Now(),
+	}
+
+	// Create a runner for making HTTP requests
+	r := newTestRunner(ctx, config)
+
+	for _, endpoint := range extractEndpointsFromConfig(config) {
+		if ctx.Err() != nil {
+			break
+		}
+		req := configRequest(config, endpoint)
+		baseline, err := r.Execute(req)
+		if err != nil {
+			continue
+		}
+
+		// The canary host is unique so that its reflections come from the test
+		canary := t.CanaryHost
+		if canary == "" {
+			canary = "ffuf" + controlIdentifier() + ".example.com"
+		}
+
+		if t.TestHostInjection {
+			t.testHostInjection(ctx, req, canary, r, result)
+		}
+		if t.TestACLBypass && isDeniedAccess(baseline) {
+			t.testACLBypass(ctx, req, baseline, r, result)
+		}
+		if t.TestCachePoisoning && isCachedResponse(baseline) {
+			t.testCachePoisoning(ctx, req, canary, r, result)
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// testHostInjection tests if a host sent in the Host header or in the host headers of the
+// catalog is reflected in the response
+func (t *HeaderAttackTester) testHostInjection(ctx context.Context, req *ffuf.Request, canary string, r ffuf.RunnerProvider, result *TestResult) {
+	// An endpoint echoing any header reflects the host headers as well
+	if resp, err := r.Execute(withHeaders(req, map[string]string{"X-Ffuf-Control": canary})); err != nil || reflectionOf(resp, canary) != "" {
+		return
+	}
+
+	headers := append([]payload.CatalogHeader{{Name: "Host"}}, payload.CatalogHeaders(payload.HeaderHost)...)
+	reflections := make([]string, 0)
+	var firstReq *ffuf.Request
+	var firstResp ffuf.Response
+	for _, header := range headers {
+		if ctx.Err() != nil {
+			return
+		}
+		variant := withHeaders(req, map[string]string{header.Name: header.Value(canary)})
+		resp, err := r.Execute(variant)
+		if err != nil {
+			continue
+		}
+		if where := reflectionOf(resp, canary); where != "" {
+			reflections = append(reflections, fmt.Sprintf("%s (in the %s)", header.Name, where))
+			if firstReq == nil {
+				firstReq, firstResp = variant, resp
+			}
+		}
+	}
+	if firstReq == nil {
+		return
+	}
+
+	result.Vulnerabilities = append(result.Vulnerabilities, VulnerabilityInfo{
+		Type:        VulnHeaderAttack,
+		Name:        "Host Header Injection",
+		Description: "The API builds URLs from a host sent by the client, allowing password reset link poisoning, open redirects and cache poisoning when the URLs are returned to other users.",
+		Severity:    "Medium",
+		Request:     convertToHTTPRequest(firstReq),
+		Response:    convertToHTTPResponse(firstResp),
+		Evidence:    fmt.Sprintf("The host %s was reflected from %s", canary, strings.Join(reflections, ", ")),
+		Remediation: "Build absolute URLs from a configured host rather than from the Host header or forwarded headers, reject requests for unknown hosts, and only trust forwarded headers set by your own proxies.",
+		CVSS:        6.1,
+		CWE:         "CWE-20",
+		References: []string{
+			"https://portswigger.net/web-security/host-header",
+			"https://owasp.org/www-project-web-security-testing-guide/latest/4-Web_Application_Security_Testing/07-Input_Validation_Testing/17-Testing_for_Host_Header_Injection",
+		},
+		DetectedAt: time.Now(),
+	})
+}
+
+// testACLBypass tests if a denied request is allowed with a spoofed client address in the
+// client IP headers of the catalog, or when its path is sent in a URL header of the catalog
+func (t *HeaderAttackTester) testACLBypass(ctx context.Context, req *ffuf.Request, baseline ffuf.Response, r ffuf.RunnerProvider, result *TestResult) {
+	for _, address := range t.SpoofedAddresses {
+		if ctx.Err() != nil {
+			return
+		}
+		headers := payload.CatalogHeaderValues(payload.HeaderClientIP, address)
+		variant := withHeaders(req, headers)
+		resp, err := r.Execute(variant)
+		if err != nil || !isSuccessfulAccess(resp) {
+			continue
+		}
+		// A success for any path shows nothing about the access control of the endpoint
+		if matchesControl(controlResponse(r, req.Method, controlSiblingURL(req.Url), variant.Headers), resp) {
+			continue
+		}
+
+		result.Vulnerabilities = append(result.Vulnerabilities, VulnerabilityInfo{
+			Type:        VulnHeaderAttack,
+			Name:        "IP-Based Access Control Bypass",
+			Description: "Access to the endpoint is restricted by client address, which is read from headers the client controls.",
+			Severity:    "High",
+			Request:     convertToHTTPRequest(variant),
+			Response:    convertToHTTPResponse(resp),
+			Evidence:    fmt.Sprintf("%s %s was denied with %d, while the client address %s in %s returned %d", req.Method, req.Url, baseline.StatusCode, address, strings.Join(payload.CatalogHeaderNames(payload.HeaderClientIP), ", "), resp.StatusCode),
+			Remediation: "Restrict access by the address of the connection, or by a client IP header only when set by your own proxies, which must overwrite it. Prefer authentication to network location for access control.",
+			CVSS:        8.2,
+			CWE:         "CWE-290",
+			References: []string{
+				"https://owasp.org/API-Security/editions/2019/en/0xa5-broken-function-level-authorization/",
+				"https://cheatsheetseries.owasp.org/cheatsheets/Authorization_Cheat_Sheet.html",
+			},
+			DetectedAt: time.Now(),
+		})
+		break
+	}
+
+	// Send the path in a URL header with a request for the root
+	u, err := url.Parse(req.Url)
+	if err != nil || u.Path == "" || u.Path == "/" {
+		return
+	}
+	rewritten := u.RequestURI()
+	u.Path, u.RawPath, u.RawQuery = "/", "", ""
+	root := *req
+	root.Url = u.String()
+	rootResp, err := r.Execute(&root)
+	if err != nil {
+		return
+	}
+	for _, header := range payload.CatalogHeaders(payload.HeaderURL) {
+		if ctx.Err() != nil {
+			return
+		}
+		variant := withHeaders(&root, map[string]string{header.Name: header.Value(rewritten)})
+		resp, err := r.Execute(variant)
+		if err != nil || !isSuccessfulAccess(resp) || diff.SameResource(&rootResp, &resp, nil) {
+			continue
+		}
+
+		result.Vulnerabilities = append(result.Vulnerabilities, VulnerabilityInfo{
+			Type:        VulnHeaderAttack,
+			Name:        "URL Override Header Bypasses Access Control",
+			Description: "Access to the endpoint is restricted by path at a proxy, while the application serves the path sent in a URL override header.",
+			Severity:    "High",
+			Request:     convertToHTTPRequest(variant),
+			Response:    convertToHTTPResponse(resp),
+			Evidence:    fmt.Sprintf("%s %s was denied with %d, while %s with %s: %s returned %d", req.Method, req.Url, baseline.StatusCode, root.Url, header.Name, rewritten, resp.StatusCode),
+			Remediation: "Disable support for the X-Original-URL and X-Rewrite-URL headers in the application, or strip them at the proxy, and enforce access control in the application.",
+			CVSS:        8.2,
+			CWE:         "CWE-288",
+			References: []string{
+				"https://owasp.org/API-Security/editions/2019/en/0xa5-broken-function-level-authorization/",
+				"https://portswigger.net/web-security/access-control",
+			},
+			DetectedAt: time.Now(),
+		})
+		return
+	}
+}
+
+// cacheProbe is a header value probing for cache poisoning
+type cacheProbe struct {
+	header payload.CatalogHeader
+	value  string
+}
+
+// testCachePoisoning tests if a response changed by a host or scheme header of the catalog is
+// cached and served to requests without the header. Every probe adds a unique cache buster
+// parameter, so that only the test requests are poisoned.
+func (t *HeaderAttackTester) testCachePoisoning(ctx context.Context, req *ffuf.Request, canary string, r ffuf.RunnerProvider, result *TestResult) {
+	busted := func() *ffuf.Request {
+		variant := *req
+		variant.Url = addOrReplaceParameter(req.Url, t.CacheBusterParam, controlIdentifier())
+		return &variant
+	}
+	clean, err := r.Execute(busted())
+	if err != nil {
+		return
+	}
+
+	probes := make([]cacheProbe, 0)
+	for _, header := range payload.CatalogHeaders(payload.HeaderHost) {
+		probes = append(probes, cacheProbe{header, canary})
+	}
+	for _, header := range payload.CatalogHeaders(payload.HeaderScheme) {
+		probes = append(probes, cacheProbe{header, "http"})
+	}
+
+	for _, probe := range probes {
+		if ctx.Err() != nil {
+			return
+		}
+		probeReq := busted()
+		poisonReq := withHeaders(probeReq, map[string]string{probe.header.Name: probe.header.Value(probe.value)})
+		poisoned, err := r.Execute(poisonReq)
+		if err != nil {
+			continue
+		}
+		// Hosts are detected by their reflection, and schemes by a changed status such as a
+		// redirect to HTTPS
+		reflected := probe.value == canary && reflectionOf(poisoned, canary) != ""
+		if !reflected && poisoned.StatusCode == clean.StatusCode {
+			continue
+		}
+
+		cached, err := r.Execute(probeReq)
+		if err != nil {
+			continue
+		}
+		if (reflected && reflectionOf(cached, canary) == "") || (!reflected && cached.StatusCode != poisoned.StatusCode) {
+			continue
+		}
+
+		result.Vulnerabilities = append(result.Vulnerabilities, VulnerabilityInfo{
+			Type:        VulnHeaderAttack,
+			Name:        "Web Cache Poisoning",
+			Description: "A response changed by a header left out of the cache key is cached and served to other users, allowing the cache to be poisoned with redirects or injected hosts.",
+			Severity:    "High",
+			Request:     convertToHTTPRequest(poisonReq),
+			Response:    convertToHTTPResponse(cached),
+			Evidence: fmt.Sprintf("The response to %s: %s (status %d) was served from the cache to %s without the header (status %d)",
+				probe.header.Name, probe.header.Value(probe.value), poisoned.StatusCode, probeReq.Url, cached.StatusCode),
+			Remediation: "Include the headers that change responses in the cache key, or strip them at the cache. Do not build responses from forwarded headers not set by your own proxies.",
+			CVSS:        7.5,
+			CWE:         "CWE-349",
+			References: []string{
+				"https://portswigger.net/web-security/web-cache-poisoning",
+				"https://owasp.org/www-community/attacks/Cache_Poisoning",
+			},
+			DetectedAt: time.Now(),
+		})
+	}
+}
+
+// withHeaders returns a copy of a request with headers set
+func withHeaders(req *ffuf.Request, headers map[string]string) *ffuf.Request {
+	variant := *req
+	variant.Headers = make(map[string]string, len(req.Headers)+len(headers))
+	for name, value := range req.Headers {
+		variant.Headers[name] = value
+	}
+	for name, value := range headers {
+		for existing := range variant.Headers {
+			if strings.EqualFold(existing, name) {
+				delete(variant.Headers, existing)
+			}
+		}
+		variant.Headers[name] = value
+	}
+	return &variant
+}
+
+// reflectionOf returns where a value is reflected in a response, or an empty string
+func reflectionOf(resp ffuf.Response, value string) string {
+	for name, values := range resp.Headers {
+		for _, v := range values {
+			if strings.Contains(v, value) {
+				return name + " header"
+			}
+		}
+	}
+	if strings.Contains(string(resp.Data), value) {
+		return "body"
+	}
+	return ""
+}
+
+// isCachedResponse checks if a response was served through a cache
+func isCachedResponse(resp ffuf.Response) bool {
+	cacheHeaders := []string{
+		"Age", "X-Cache", "X-Cache-Status", "X-Cache-Hits", "Cf-Cache-Status",
+		"X-Varnish", "X-Proxy-Cache", "Akamai-Cache-Status", "X-Drupal-Cache",
+	}
+	for _, header := range cacheHeaders {
+		if values, ok := resp.Headers[header]; ok && len(values) > 0 {
+			return true
+		}
+	}
+	for _, value := range resp.Headers["Cache-Control"] {
+		if strings.Contains(value, "public") || strings.Contains(value, "s-maxage") {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	// Register the tester with the default registry
+	RegisterSecurityTester(NewHeaderAttackTester())
+}
@@ -0,0 +1,108 @@
+package security
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// headerServer is an API behind a proxy, trusting the headers of the proxy if enabled
+type headerServer struct {
+	// reflectHost builds the links of the responses from the Host and X-Forwarded-Host headers
+	reflectHost bool
+	// trustClientIP allows /admin to the local addresses of X-Forwarded-For
+	trustClientIP bool
+	// trustOverride serves the path of X-Original-URL
+	trustOverride bool
+}
+
+// start starts the server, with a password reset link under /account and an /admin endpoint
+// denied to remote clients
+func (s headerServer) start() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if override := r.Header.Get("X-Original-URL"); s.trustOverride && override != "" {
+			path = override
+		}
+		switch path {
+		case "/":
+			w.Write([]byte(`{"service":"users"}`))
+		case "/account":
+			host, location := "api.example.com", "https://api.example.com/login"
+			if s.reflectHost {
+				host = r.Host
+				if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+					location = "https://" + forwarded + "/login"
+				}
+			}
+			w.Header().Set("Location", location)
+			fmt.Fprintf(w, `{"reset":"https://%s/reset?token=abc"}`, host)
+		case "/admin":
+			if path != r.URL.Path || (s.trustClientIP && r.Header.Get("X-Forwarded-For") == "127.0.0.1") {
+				w.Write([]byte(`{"users":["alice","bob"]}`))
+				return
+			}
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// runHeaderAttacks runs the header attack tester against an endpoint of a server
+func runHeaderAttacks(t *testing.T, server *httptest.Server, path string) *TestResult {
+	t.Helper()
+	tester := NewHeaderAttackTester()
+	tester.CanaryHost = "canary.example.com"
+	conf := newFakeConfig(t, server.URL+path)
+	result, err := tester.Test(conf.Context, conf)
+	if err != nil {
+		t.Fatalf("Test returned an error: %s", err)
+	}
+	return result
+}
+
+func TestHeaderAttackTester_HostInjection(t *testing.T) {
+	server := headerServer{reflectHost: true}.start()
+	defer server.Close()
+	result := runHeaderAttacks(t, server, "/account")
+	if names := vulnerabilityNames(result); len(names) != 1 || names[0] != "Host Header Injection" {
+		t.Fatalf("Expected the host header injection to be reported, got %v", names)
+	}
+	expected := "The host canary.example.com was reflected from Host (in the body), X-Forwarded-Host (in the Location header)"
+	if evidence := result.Vulnerabilities[0].Evidence; evidence != expected {
+		t.Errorf("Expected %q, got %q", expected, evidence)
+	}
+	if host := result.Vulnerabilities[0].Request.Header.Get("Host"); host != "canary.example.com" {
+		t.Errorf("Expected the request with the canary host to be reported, got %s", host)
+	}
+}
+
+func TestHeaderAttackTester_OverrideHeaders(t *testing.T) {
+	server := headerServer{trustClientIP: true, trustOverride: true}.start()
+	defer server.Close()
+	result := runHeaderAttacks(t, server, "/admin")
+	names := vulnerabilityNames(result)
+	if strings.Join(names, ",") != "IP-Based Access Control Bypass,URL Override Header Bypasses Access Control" {
+		t.Fatalf("Expected the spoofed address and the URL override to be reported, got %v", names)
+	}
+	if evidence := result.Vulnerabilities[0].Evidence; !strings.Contains(evidence, "was denied with 403, while the client address 127.0.0.1") {
+		t.Errorf("Expected the spoofed address as evidence, got %s", evidence)
+	}
+	if evidence := result.Vulnerabilities[1].Evidence; !strings.HasSuffix(evidence, "with X-Original-URL: /admin returned 200") {
+		t.Errorf("Expected the URL override header as evidence, got %s", evidence)
+	}
+}
+
+func TestHeaderAttackTester_Safe(t *testing.T) {
+	// The server ignores the headers of the proxy, so that nothing is reflected or bypassed
+	server := headerServer{}.start()
+	defer server.Close()
+	for _, path := range []string{"/account", "/admin"} {
+		if result := runHeaderAttacks(t, server, path); len(result.Vulnerabilities) != 0 {
+			t.Errorf("Expected no vulnerability on %s, got %v", path, vulnerabilityNames(result))
+		}
+	}
+}
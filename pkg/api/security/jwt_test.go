@@ -0,0 +1,102 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// jwtServer answers with the profile of the user if the bearer token is accepted by verify
+func jwtServer(verify func(token *jwtToken) bool) func(req *ffuf.Request) ffuf.Response {
+	return func(req *ffuf.Request) ffuf.Response {
+		token, err := parseJWT(strings.TrimPrefix(req.Headers["Authorization"], "Bearer "))
+		if err != nil || !verify(token) {
+			return ffuf.Response{StatusCode: 401, Data: []byte(`{"error":"invalid token"}`)}
+		}
+		return ffuf.Response{ContentType: "application/json", Data: []byte(`{"user":"alice"}`)}
+	}
+}
+
+// hs256Verifier verifies the HS256 signature of the tokens with a secret
+func hs256Verifier(secret string) func(token *jwtToken) bool {
+	return func(token *jwtToken) bool {
+		return token.header["alg"] == "HS256" && token.verifyHMAC(secret)
+	}
+}
+
+// newJWTConfig returns a config sending a token signed with a secret
+func newJWTConfig(t *testing.T, secret string) *ffuf.Config {
+	token, err := signJWT(
+		map[string]interface{}{"alg": "HS256", "typ": "JWT"},
+		map[string]interface{}{"sub": "alice", "exp": float64(time.Now().Add(time.Hour).Unix())},
+		[]byte(secret),
+	)
+	if err != nil {
+		t.Fatalf("signJWT returned an error: %s", err)
+	}
+	conf := newFakeConfig(t, "https://api.example.com/me")
+	conf.Headers = map[string]string{"Authorization": "Bearer " + token}
+	return conf
+}
+
+func TestJWTTester_StrictServer(t *testing.T) {
+	const secret = "k7Qz0vR2pX9mW4tY8bN1cJ6hL3sF5dGa"
+	result, runner := runFake(t, NewJWTTester(), newJWTConfig(t, secret), jwtServer(hs256Verifier(secret)))
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability, got %v", vulnerabilityNames(result))
+	}
+	if len(runner.Requests()) < 5 {
+		t.Errorf("Expected the tampered tokens to be replayed, got %d requests", len(runner.Requests()))
+	}
+}
+
+func TestJWTTester_NoneAlgorithm(t *testing.T) {
+	const secret = "k7Qz0vR2pX9mW4tY8bN1cJ6hL3sF5dGa"
+	verify := hs256Verifier(secret)
+	result, _ := runFake(t, NewJWTTester(), newJWTConfig(t, secret), jwtServer(func(token *jwtToken) bool {
+		alg, _ := token.header["alg"].(string)
+		return alg == "None" || verify(token)
+	}))
+	if names := vulnerabilityNames(result); len(names) != 1 || names[0] != "JWT None Algorithm Accepted" {
+		t.Fatalf("Expected the none algorithm to be reported, got %v", names)
+	}
+	if !strings.Contains(result.Vulnerabilities[0].Evidence, "alg 'None'") {
+		t.Errorf("Expected the accepted algorithm as evidence, got %s", result.Vulnerabilities[0].Evidence)
+	}
+	if auth := result.Vulnerabilities[0].Request.Header.Get("Authorization"); !strings.HasSuffix(auth, ".") {
+		t.Errorf("Expected the unsigned token to be reported, got %s", auth)
+	}
+}
+
+func TestJWTTester_WeakSecret(t *testing.T) {
+	result, _ := runFake(t, NewJWTTester(), newJWTConfig(t, "changeme"), jwtServer(hs256Verifier("changeme")))
+	names := vulnerabilityNames(result)
+	if len(names) != 2 || names[0] != "JWT Weak Signing Secret" || !strings.Contains(result.Vulnerabilities[0].Evidence, "'changeme'") {
+		t.Fatalf("Expected the weak secret to be reported, got %v", names)
+	}
+
+	// The expiration of the tokens forged with the secret is not enforced
+	if names[1] != "JWT Expiration Not Enforced" {
+		t.Errorf("Expected the expired token forged with the secret to be reported, got %s", names[1])
+	}
+	result, _ = runFake(t, NewJWTTester(), newJWTConfig(t, "changeme"), jwtServer(func(token *jwtToken) bool {
+		exp, _ := token.claims["exp"].(float64)
+		return hs256Verifier("changeme")(token) && time.Unix(int64(exp), 0).After(time.Now())
+	}))
+	if names := vulnerabilityNames(result); len(names) != 1 || names[0] != "JWT Weak Signing Secret" {
+		t.Errorf("Expected only the weak secret to be reported, got %v", names)
+	}
+}
+
+func TestJWTTester_SignatureNotVerified(t *testing.T) {
+	result, _ := runFake(t, NewJWTTester(), newJWTConfig(t, "k7Qz0vR2pX9mW4tY8bN1cJ6hL3sF5dGa"), jwtServer(func(token *jwtToken) bool {
+		return true
+	}))
+
+	// The other tampered tokens are not reported once any signature is accepted
+	if names := vulnerabilityNames(result); len(names) != 1 || names[0] != "JWT Signature Not Verified" {
+		t.Errorf("Expected the unverified signature to be reported, got %v", names)
+	}
+}
@@ -0,0 +1,127 @@
+package security
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// lockoutPhrases are the phrases of the responses of login endpoints locking an account
+var lockoutPhrases = []string{
+	"account locked", "account is locked", "account has been locked", "locked out",
+	"temporarily locked", "temporarily blocked", "account disabled", "account has been disabled",
+	"account suspended", "too many failed", "too many login attempts", "too many attempts",
+}
+
+// LoginGuard caps the login attempts of the testers sending credentials to login endpoints,
+// and detects the responses of account lockouts. It is shared by the testers of a scan, so
+// that the attempts of every tester count towards the cap of an endpoint.
+type LoginGuard struct {
+	// MaxAttempts is the maximum number of login attempts per endpoint, 0 for no limit
+	MaxAttempts int
+	// AccountSafe stops the login attempts to every endpoint of a host after its first lockout
+	// indicator, instead of those to the locked endpoint only
+	AccountSafe bool
+
+	mu       sync.Mutex
+	attempts map[string]int
+	failed   map[string]bool
+	locked   map[string]bool
+	// stopped are the hosts of the lockouts in account-safe mode
+	stopped map[string]bool
+}
+
+// loginGuardKey is the context key of the login guard of a scan
+type loginGuardKey struct{}
+
+// NewLoginGuard creates a login guard allowing maxAttempts login attempts per endpoint, 0 for
+// no limit, and stopping the login attempts to a host after its first lockout if accountSafe
+// is set
+func NewLoginGuard(maxAttempts int, accountSafe bool) *LoginGuard {
+	return &LoginGuard{
+		MaxAttempts: maxAttempts,
+		AccountSafe: accountSafe,
+		attempts:    make(map[string]int),
+		failed:      make(map[string]bool),
+		locked:      make(map[string]bool),
+		stopped:     make(map[string]bool),
+	}
+}
+
+// WithLoginGuard returns a context that makes the security test runs it is passed to share a
+// login guard, capping the login attempts to the endpoints of every run
+func WithLoginGuard(ctx context.Context, guard *LoginGuard) context.Context {
+	return context.WithValue(ctx, loginGuardKey{}, guard)
+}
+
+// loginGuardFor returns the login guard of a context, or a login guard of the config if the
+// context has none
+func loginGuardFor(ctx context.Context, config *ffuf.Config) *LoginGuard {
+	if guard, ok := ctx.Value(loginGuardKey{}).(*LoginGuard); ok {
+		return guard
+	}
+	return NewLoginGuard(config.APISecurityLoginAttempts, config.APISecurityAccountSafe)
+}
+
+// Allow reserves a login attempt to an endpoint. It returns false if the endpoint reached the
+// cap of attempts or is locked, or if a lockout stopped the login attempts to its host in
+// account-safe mode.
+func (g *LoginGuard) Allow(endpoint string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped[endpointHost(endpoint)] || g.locked[endpoint] || (g.MaxAttempts > 0 && g.attempts[endpoint] >= g.MaxAttempts) {
+		return false
+	}
+	g.attempts[endpoint]++
+	return true
+}
+
+// Record records the response to a login attempt to an endpoint, and returns true if it
+// indicates a lockout: a 423 Locked status, a 403 following failed logins, or a response
+// reporting a locked account. No further attempt to a locked endpoint is allowed.
+func (g *LoginGuard) Record(endpoint string, resp ffuf.Response) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !isLockout(resp, g.failed[endpoint]) {
+		if resp.StatusCode == 400 || resp.StatusCode == 401 {
+			g.failed[endpoint] = true
+		}
+		return false
+	}
+	if !g.locked[endpoint] {
+		g.locked[endpoint] = true
+		logger.Warn("Lockout indicator: stopping the login attempts", "endpoint", endpoint, "status", resp.StatusCode, "account_safe", g.AccountSafe)
+	}
+	if host := endpointHost(endpoint); g.AccountSafe && !g.stopped[host] {
+		g.stopped[host] = true
+		logger.Info("Account-safe mode: stopping the credential testing of the host", logging.FieldTarget, endpoint)
+	}
+	return true
+}
+
+// endpointHost returns the host of the URL of an endpoint
+func endpointHost(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil {
+		return strings.ToLower(u.Host)
+	}
+	return endpoint
+}
+
+// isLockout returns true if the response to a login attempt indicates an account lockout,
+// failed being true if earlier attempts to the endpoint failed with 400 or 401
+func isLockout(resp ffuf.Response, failed bool) bool {
+	if resp.StatusCode == 423 || (resp.StatusCode == 403 && failed) {
+		return true
+	}
+	body := strings.ToLower(string(resp.Data))
+	for _, phrase := range lockoutPhrases {
+		if strings.Contains(body, phrase) {
+			return true
+		}
+	}
+	return false
+}
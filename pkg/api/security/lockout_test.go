@@ -0,0 +1,78 @@
+package security
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestLoginLockout(t *testing.T) {
+	for _, accountSafe := range []bool{false, true} {
+		var mu sync.Mutex
+		attempts := make(map[string]int)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if r.Method != "POST" || !strings.Contains(string(body), `"password"`) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			mu.Lock()
+			attempts[r.URL.Path]++
+			n := attempts[r.URL.Path]
+			mu.Unlock()
+			// The account of /login is locked after two failed logins
+			if r.URL.Path == "/login" && n > 2 {
+				w.WriteHeader(http.StatusLocked)
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+
+		logging := NewInsufficientLoggingTester()
+		logging.TestAccessViolations, logging.TestDataManipulation, logging.TestRateLimitViolations = false, false, false
+		registry := NewSecurityTestRegistry()
+		registry.Register(logging)
+		registry.Register(NewSecurityMisconfigTester())
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		conf := ffuf.NewConfig(ctx, cancel)
+		conf.Url = server.URL + "/"
+		conf.Threads = 2
+		conf.APISecurityLoginAttempts = 4
+		conf.APISecurityAccountSafe = accountSafe
+		results, err := registry.RunAll(ctx, &conf)
+		cancel()
+		server.Close()
+		if err != nil {
+			t.Fatalf("RunAll returned an error: %s", err)
+		}
+
+		for _, result := range results {
+			for _, vuln := range result.Vulnerabilities {
+				if vuln.Name == "Insufficient Logging of Failed Login Attempts" && vuln.Request != nil && vuln.Request.URL.Path == "/login" {
+					t.Errorf("Expected no insufficient logging finding on the locked endpoint")
+				}
+			}
+		}
+		if attempts["/login"] < 3 || attempts["/login"] > 4 {
+			t.Errorf("Expected the login attempts to /login to stop at the lockout, got %d", attempts["/login"])
+		}
+		for path, n := range attempts {
+			if n > 4 {
+				t.Errorf("Expected at most 4 login attempts to %s, got %d", path, n)
+			}
+		}
+		if accountSafe && len(attempts) != 1 {
+			t.Errorf("Expected the account-safe mode to stop the credential testing after the lockout, got attempts %v", attempts)
+		}
+		if !accountSafe && attempts["/api/login"] != 4 {
+			t.Errorf("Expected 4 login attempts to /api/login, got %d", attempts["/api/login"])
+		}
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // InsufficientLoggingTester implements testing for Insufficient Logging & Monitoring (API10:2019)
@@ -69,35 +68,35 @@ func (t *InsufficientLoggingTester) Test(ctx context.Context, config *ffuf.Confi
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract the base URL from the config
 	baseURL := extractBaseURL(config.Url)
 
 	// Test for access to logging endpoints
-	t.testLoggingEndpointAccess(baseURL, r, result)
+	t.testLoggingEndpointAccess(ctx, baseURL, r, result)
 
 	// Test for access to monitoring endpoints
-	t.testMonitoringEndpointAccess(baseURL, r, result)
+	t.testMonitoringEndpointAccess(ctx, baseURL, r, result)
 
 	// Test for failed login logging
 	if t.TestFailedLogins {
-		t.testFailedLoginLogging(baseURL, r, result)
+		t.testFailedLoginLogging(ctx, baseURL, r, loginGuardFor(ctx, config), result)
 	}
 
 	// Test for access violation logging
 	if t.TestAccessViolations {
-		t.testAccessViolationLogging(baseURL, r, result)
+		t.testAccessViolationLogging(ctx, baseURL, r, result)
 	}
 
 	// Test for data manipulation logging
 	if t.TestDataManipulation {
-		t.testDataManipulationLogging(baseURL, r, result)
+		t.testDataManipulationLogging(ctx, baseURL, r, result)
 	}
 
 	// Test for rate limit violation logging
 	if t.TestRateLimitViolations {
-		t.testRateLimitViolationLogging(baseURL, r, result)
+		t.testRateLimitViolationLogging(ctx, baseURL, r, result)
 	}
 
 	result.EndTime = time.Now()
@@ -106,7 +105,7 @@ func (t *InsufficientLoggingTester) Test(ctx context.Context, config *ffuf.Confi
 }
 
 // testLoggingEndpointAccess tests for unauthorized access to logging endpoints
-func (t *InsufficientLoggingTester) testLoggingEndpointAccess(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InsufficientLoggingTester) testLoggingEndpointAccess(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	for _, endpoint := range t.LoggingEndpoints {
 		// Create URLs with different patterns
 		testURLs := []string{
@@ -117,11 +116,14 @@ func (t *InsufficientLoggingTester) testLoggingEndpointAccess(baseURL string, r
 		}
 
 		for _, testURL := range testURLs {
+			if ctx.Err() != nil {
+				return
+			}
 			req := &ffuf.Request{
 				Method: "GET",
 				Url:    testURL,
 				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+					"User-Agent": testerUserAgent,
 				},
 			}
 
@@ -159,7 +161,7 @@ func (t *InsufficientLoggingTester) testLoggingEndpointAccess(baseURL string, r
 }
 
 // testMonitoringEndpointAccess tests for unauthorized access to monitoring endpoints
-func (t *InsufficientLoggingTester) testMonitoringEndpointAccess(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InsufficientLoggingTester) testMonitoringEndpointAccess(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	for _, endpoint := range t.MonitoringEndpoints {
 		// Create URLs with different patterns
 		testURLs := []string{
@@ -170,11 +172,14 @@ func (t *InsufficientLoggingTester) testMonitoringEndpointAccess(baseURL string,
 		}
 
 		for _, testURL := range testURLs {
+			if ctx.Err() != nil {
+				return
+			}
 			req := &ffuf.Request{
 				Method: "GET",
 				Url:    testURL,
 				Headers: map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+					"User-Agent": testerUserAgent,
 				},
 			}
 
@@ -211,8 +216,9 @@ func (t *InsufficientLoggingTester) testMonitoringEndpointAccess(baseURL string,
 	}
 }
 
-// testFailedLoginLogging tests for insufficient logging of failed login attempts
-func (t *InsufficientLoggingTester) testFailedLoginLogging(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+// testFailedLoginLogging tests for insufficient logging of failed login attempts, within the
+// login attempts allowed by the login guard
+func (t *InsufficientLoggingTester) testFailedLoginLogging(ctx context.Context, baseURL string, r ffuf.RunnerProvider, guard *LoginGuard, result *TestResult) {
 	// Common login endpoints
 	loginEndpoints := []string{
 		"/login", "/signin", "/auth", "/api/login", "/api/auth", "/api/v1/login",
@@ -221,7 +227,13 @@ func (t *InsufficientLoggingTester) testFailedLoginLogging(baseURL string, r ffu
 
 	// Test each login endpoint
 	for _, endpoint := range loginEndpoints {
+		if ctx.Err() != nil {
+			return
+		}
 		testURL := fmt.Sprintf("%s%s", baseURL, endpoint)
+		if !guard.Allow(testURL) {
+			continue
+		}
 
 		// Create a request with invalid credentials
 		req := &ffuf.Request{
@@ -229,7 +241,7 @@ func (t *InsufficientLoggingTester) testFailedLoginLogging(baseURL string, r ffu
 			Url:    testURL,
 			Headers: map[string]string{
 				"Content-Type": "application/json",
-				"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent":   testerUserAgent,
 			},
 			Data: []byte(`{"username":"invalid_user","password":"invalid_password"}`),
 		}
@@ -241,19 +253,29 @@ func (t *InsufficientLoggingTester) testFailedLoginLogging(baseURL string, r ffu
 		}
 
 		// If the login endpoint exists (returns 401 or 403), test for insufficient logging
-		if resp.StatusCode == 401 || resp.StatusCode == 403 || resp.StatusCode == 400 {
-			// Make multiple failed login attempts to test for account lockout or rate limiting
-			for i := 0; i < 5; i++ {
-				_, err := r.Execute(req)
+		locked := guard.Record(testURL, resp)
+		if !locked && (resp.StatusCode == 401 || resp.StatusCode == 403 || resp.StatusCode == 400) {
+			// Make multiple failed login attempts to test for account lockout or rate limiting,
+			// stopping at the first lockout indicator
+			attempts := 1
+			for i := 0; i < 5 && !locked && guard.Allow(testURL); i++ {
+				if ctx.Err() != nil {
+					return
+				}
+				attemptResp, err := r.Execute(req)
 				if err != nil {
 					break
 				}
-				time.Sleep(100 * time.Millisecond) // Small delay between requests
+				attempts++
+				locked = guard.Record(testURL, attemptResp)
+				if !sleep(ctx, 100*time.Millisecond) { // Small delay between requests
+					return
+				}
 			}
 
 			// Check if there's any indication of logging or monitoring
 			// This is a heuristic test - in a real implementation, we would need to check server logs
-			if !hasLoggingIndication(resp) {
+			if !locked && attempts > 1 && !hasLoggingIndication(resp) {
 				// Create a vulnerability info
 				vuln := VulnerabilityInfo{
 					Type:        VulnInsufficientLogging,
@@ -280,7 +302,7 @@ func (t *InsufficientLoggingTester) testFailedLoginLogging(baseURL string, r ffu
 }
 
 // testAccessViolationLogging tests for insufficient logging of access violations
-func (t *InsufficientLoggingTester) testAccessViolationLogging(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InsufficientLoggingTester) testAccessViolationLogging(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	// Common protected resource endpoints
 	protectedEndpoints := []string{
 		"/admin", "/dashboard", "/settings", "/profile", "/account",
@@ -289,6 +311,9 @@ func (t *InsufficientLoggingTester) testAccessViolationLogging(baseURL string, r
 
 	// Test each protected endpoint
 	for _, endpoint := range protectedEndpoints {
+		if ctx.Err() != nil {
+			return
+		}
 		testURL := fmt.Sprintf("%s%s", baseURL, endpoint)
 
 		// Create a request without authentication
@@ -296,7 +321,7 @@ func (t *InsufficientLoggingTester) testAccessViolationLogging(baseURL string, r
 			Method: "GET",
 			Url:    testURL,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
@@ -310,11 +335,16 @@ func (t *InsufficientLoggingTester) testAccessViolationLogging(baseURL string, r
 		if resp.StatusCode == 401 || resp.StatusCode == 403 {
 			// Make multiple access attempts to test for rate limiting or blocking
 			for i := 0; i < 5; i++ {
+				if ctx.Err() != nil {
+					return
+				}
 				_, err := r.Execute(req)
 				if err != nil {
 					break
 				}
-				time.Sleep(100 * time.Millisecond) // Small delay between requests
+				if !sleep(ctx, 100*time.Millisecond) { // Small delay between requests
+					return
+				}
 			}
 
 			// Check if there's any indication of logging or monitoring
@@ -346,7 +376,7 @@ func (t *InsufficientLoggingTester) testAccessViolationLogging(baseURL string, r
 }
 
 // testDataManipulationLogging tests for insufficient logging of data manipulation
-func (t *InsufficientLoggingTester) testDataManipulationLogging(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InsufficientLoggingTester) testDataManipulationLogging(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	// Common data manipulation endpoints
 	dataEndpoints := []string{
 		"/api/data", "/api/records", "/api/users", "/api/items", "/api/products",
@@ -355,6 +385,9 @@ func (t *InsufficientLoggingTester) testDataManipulationLogging(baseURL string,
 
 	// Test each data endpoint
 	for _, endpoint := range dataEndpoints {
+		if ctx.Err() != nil {
+			return
+		}
 		testURL := fmt.Sprintf("%s%s", baseURL, endpoint)
 
 		// Create a request to modify data
@@ -363,7 +396,7 @@ func (t *InsufficientLoggingTester) testDataManipulationLogging(baseURL string,
 			Url:    testURL,
 			Headers: map[string]string{
 				"Content-Type": "application/json",
-				"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent":   testerUserAgent,
 			},
 			Data: []byte(`{"id":1,"name":"test","value":"modified"}`),
 		}
@@ -377,11 +410,16 @@ func (t *InsufficientLoggingTester) testDataManipulationLogging(baseURL string,
 		// If the endpoint exists (returns any response), test for insufficient logging
 		// Make multiple data modification attempts
 		for i := 0; i < 3; i++ {
+			if ctx.Err() != nil {
+				return
+			}
 			_, err := r.Execute(req)
 			if err != nil {
 				break
 			}
-			time.Sleep(100 * time.Millisecond) // Small delay between requests
+			if !sleep(ctx, 100*time.Millisecond) { // Small delay between requests
+				return
+			}
 		}
 
 		// Check if there's any indication of logging or monitoring
@@ -412,7 +450,7 @@ func (t *InsufficientLoggingTester) testDataManipulationLogging(baseURL string,
 }
 
 // testRateLimitViolationLogging tests for insufficient logging of rate limit violations
-func (t *InsufficientLoggingTester) testRateLimitViolationLogging(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *InsufficientLoggingTester) testRateLimitViolationLogging(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	// Common API endpoints that might have rate limiting
 	apiEndpoints := []string{
 		"/api", "/api/v1", "/api/data", "/api/search", "/api/query",
@@ -421,6 +459,9 @@ func (t *InsufficientLoggingTester) testRateLimitViolationLogging(baseURL string
 
 	// Test each API endpoint
 	for _, endpoint := range apiEndpoints {
+		if ctx.Err() != nil {
+			return
+		}
 		testURL := fmt.Sprintf("%s%s", baseURL, endpoint)
 
 		// Create a request
@@ -428,7 +469,7 @@ func (t *InsufficientLoggingTester) testRateLimitViolationLogging(baseURL string
 			Method: "GET",
 			Url:    testURL,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
@@ -443,6 +484,9 @@ func (t *InsufficientLoggingTester) testRateLimitViolationLogging(baseURL string
 			// Make many requests to trigger rate limiting
 			rateLimited := false
 			for i := 0; i < 20; i++ {
+				if ctx.Err() != nil {
+					return
+				}
 				resp, err := r.Execute(req)
 				if err != nil {
 					break
@@ -454,7 +498,9 @@ func (t *InsufficientLoggingTester) testRateLimitViolationLogging(baseURL string
 					break
 				}
 
-				time.Sleep(50 * time.Millisecond) // Small delay between requests
+				if !sleep(ctx, 50*time.Millisecond) { // Small delay between requests
+					return
+				}
 			}
 
 			// If rate limiting was triggered but there's no indication of logging
@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // MassAssignmentTester implements testing for Mass Assignment (API6:2019)
@@ -18,6 +21,16 @@ type MassAssignmentTester struct {
 	SensitiveProperties []string
 	AdminProperties     []string
 	TestPayloads        map[string]interface{}
+	// Spec is an optional OpenAPI specification. Properties that appear in response
+	// schemas but not in request schemas are injected into the write operations of
+	// the specification, and the resource is re-read to detect whether they persisted.
+	// Without Spec, the operation of the target in the specifications of the scan is
+	// tested, see WithSpecifications.
+	Spec *parser.OpenAPIParser
+	// BaseURL overrides the base URL of the specification
+	BaseURL string
+	// PathParameterValue is used for path parameters without an example
+	PathParameterValue string
 }
 
 // NewMassAssignmentTester creates a new tester for Mass Assignment
@@ -91,6 +104,7 @@ func NewMassAssignmentTester() *MassAssignmentTester {
 		SensitiveProperties: sensitiveProps,
 		AdminProperties:     adminProps,
 		TestPayloads:        testPayloads,
+		PathParameterValue:  "1",
 	}
 }
 
@@ -117,13 +131,16 @@ func (t *MassAssignmentTester) Test(ctx context.Context, config *ffuf.Config) (*
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract potential endpoints from the config
 	endpoints := extractEndpointsFromConfig(config)
 
 	// Test each endpoint for mass assignment vulnerabilities
 	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
 		// Skip endpoints that don't look like they would accept POST/PUT/PATCH requests
 		if !isWritableEndpoint(endpoint) {
 			continue
@@ -131,6 +148,9 @@ func (t *MassAssignmentTester) Test(ctx context.Context, config *ffuf.Config) (*
 
 		// Test for mass assignment vulnerabilities with different payloads
 		for payloadName, payload := range t.TestPayloads {
+			if ctx.Err() != nil {
+				break
+			}
 			t.testMassAssignment(endpoint, payloadName, payload, r, result)
 		}
 
@@ -141,6 +161,18 @@ func (t *MassAssignmentTester) Test(ctx context.Context, config *ffuf.Config) (*
 		t.testArrayMassAssignment(endpoint, r, result)
 	}
 
+	// Test the write operations of the specification with server-only properties, or the
+	// operation of the target in the specifications of the scan
+	if t.Spec != nil && t.Spec.Spec != nil {
+		t.testSpecMassAssignment(ctx, config, t.Spec, false, r, result)
+	} else if specs, ok := ctx.Value(specificationsKey{}).([]*parser.OpenAPIParser); ok {
+		for _, spec := range specs {
+			if spec != nil && spec.Spec != nil {
+				t.testSpecMassAssignment(ctx, config, spec, true, r, result)
+			}
+		}
+	}
+
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 	return result, nil
@@ -378,6 +410,393 @@ func (t *MassAssignmentTester) testArrayMassAssignment(endpoint string, r ffuf.R
 	}
 }
 
+// specificationsKey is the context key of the OpenAPI specifications of a scan
+type specificationsKey struct{}
+
+// WithSpecifications returns a context that makes the mass assignment tester of the security
+// test runs it is passed to inject the server-only properties of the operation of their target
+// in the specifications, such as the specifications of an API scan
+func WithSpecifications(ctx context.Context, specs []*parser.OpenAPIParser) context.Context {
+	return context.WithValue(ctx, specificationsKey{}, specs)
+}
+
+// testSpecMassAssignment injects the server-only properties of each write operation in the
+// specification and re-reads the resource to detect whether they were persisted. If target is
+// set, only the operation of the method and URL of the config is tested.
+func (t *MassAssignmentTester) testSpecMassAssignment(ctx context.Context, config *ffuf.Config, spec *parser.OpenAPIParser, target bool, r ffuf.RunnerProvider, result *TestResult) {
+	specBaseURL := t.BaseURL
+	if specBaseURL == "" {
+		specBaseURL = spec.Spec.BaseURL
+	}
+	if specBaseURL == "" {
+		specBaseURL = extractBaseURL(config.Url)
+	}
+	specBaseURL = strings.TrimSuffix(specBaseURL, "/")
+
+	for _, endpoint := range spec.GetEndpoints() {
+		if ctx.Err() != nil {
+			return
+		}
+		if endpoint.Method != "POST" && endpoint.Method != "PUT" && endpoint.Method != "PATCH" {
+			continue
+		}
+		if endpoint.RequestBody == nil || endpoint.RequestBody.Type != "object" {
+			continue
+		}
+		baseURL, writeURL := specBaseURL, ""
+		if target {
+			var ok bool
+			if baseURL, ok = operationBaseURL(endpoint, config); !ok {
+				continue
+			}
+			writeURL = config.Url
+		} else {
+			writeURL = baseURL + t.fillPath(endpoint.Path, endpoint.Parameters)
+		}
+
+		// The resource is re-read from the item path for creations and from the same path otherwise
+		readEndpoint := findReadEndpoint(spec, endpoint)
+		if readEndpoint == nil {
+			continue // Persistence cannot be confirmed without re-reading the resource
+		}
+		responseSchema := mergeSchemas(successSchema(endpoint), successSchema(readEndpoint))
+
+		properties := serverOnlyProperties(endpoint.RequestBody, responseSchema)
+		if len(properties) == 0 {
+			continue
+		}
+
+		// Build a valid body with the server-only properties injected
+		body := make(map[string]interface{})
+		for name, schema := range endpoint.RequestBody.Properties {
+			body[name] = exampleValue(name, schema)
+		}
+		injected := make(map[string]interface{}, len(properties))
+		for _, name := range properties {
+			injected[name] = massAssignmentValue(name, responseSchema.Properties[name])
+			body[name] = injected[name]
+		}
+		data, err := json.Marshal(body)
+		if err != nil {
+			continue
+		}
+
+		headers := make(map[string]string, len(config.Headers)+1)
+		for k, v := range config.Headers {
+			headers[k] = v
+		}
+		headers["Content-Type"] = "application/json"
+
+		req := &ffuf.Request{
+			Method:  endpoint.Method,
+			Url:     writeURL,
+			Headers: headers,
+			Data:    data,
+		}
+		resp, err := r.Execute(req)
+		if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			continue
+		}
+
+		// Re-read the resource to check whether the properties were persisted. Properties
+		// echoed by the write response only are not evidence of persistence.
+		readURL := ""
+		if readEndpoint.Path == endpoint.Path {
+			readURL = req.Url
+		} else if id := createdID(resp.Data); id != "" {
+			readURL = baseURL + t.fillPath(strings.Replace(readEndpoint.Path, "{"+lastPathParameter(readEndpoint.Path)+"}", url.PathEscape(id), 1), readEndpoint.Parameters)
+		}
+		if readURL == "" {
+			continue
+		}
+		readResp, err := r.Execute(&ffuf.Request{
+			Method:  "GET",
+			Url:     readURL,
+			Headers: config.Headers,
+		})
+		if err != nil || readResp.StatusCode < 200 || readResp.StatusCode >= 300 {
+			continue
+		}
+
+		persisted := persistedProperties(readResp.Data, injected)
+		if len(persisted) == 0 {
+			continue
+		}
+
+		severity := "Medium"
+		for _, name := range persisted {
+			if containsProperty(t.SensitiveProperties, name) {
+				severity = "High"
+				break
+			}
+		}
+
+		vuln := VulnerabilityInfo{
+			Type:        VulnMassAssignment,
+			Name:        "Mass Assignment of Server-Only Properties",
+			Description: fmt.Sprintf("The %s %s operation accepts properties that are only defined in its response schema.", endpoint.Method, endpoint.Path),
+			Severity:    severity,
+			Request:     convertToHTTPRequest(req),
+			Response:    convertToHTTPResponse(readResp),
+			Evidence:    fmt.Sprintf("Server-only properties %s were persisted in the resource re-read from %s", strings.Join(persisted, ", "), readURL),
+			Remediation: "Implement proper input validation and filtering. Use a whitelist approach to explicitly define which properties can be mass-assigned. Consider using DTOs (Data Transfer Objects) to separate API models from internal models.",
+			CVSS:        8.0,
+			CWE:         "CWE-915",
+			References: []string{
+				"https://owasp.org/API-Security/editions/2019/en/0xa6-mass-assignment/",
+				"https://cheatsheetseries.owasp.org/cheatsheets/Mass_Assignment_Cheat_Sheet.html",
+			},
+			DetectedAt: time.Now(),
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, vuln)
+	}
+}
+
+// findReadEndpoint returns the GET operation used to re-read the resource of a write operation
+func findReadEndpoint(spec *parser.OpenAPIParser, endpoint *parser.OpenAPIEndpoint) *parser.OpenAPIEndpoint {
+	for _, candidate := range spec.GetEndpoints() {
+		if candidate.Method != "GET" {
+			continue
+		}
+		if endpoint.Method != "POST" && candidate.Path == endpoint.Path {
+			return candidate
+		}
+		// Item path of a collection, e.g., /users/{id} for POST /users
+		if endpoint.Method == "POST" && lastPathParameter(candidate.Path) != "" &&
+			strings.TrimSuffix(candidate.Path[:strings.LastIndex(candidate.Path, "/")], "/") == strings.TrimSuffix(endpoint.Path, "/") {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// operationBaseURL returns the base URL of the target of the config if the target is the
+// operation of an endpoint, such as https://api.example.com/v1 for POST /users and the target
+// POST https://api.example.com/v1/users
+func operationBaseURL(endpoint *parser.OpenAPIEndpoint, config *ffuf.Config) (string, bool) {
+	method := config.Method
+	if method == "" {
+		method = "GET"
+	}
+	u, err := url.Parse(config.Url)
+	if err != nil || !strings.EqualFold(method, endpoint.Method) {
+		return "", false
+	}
+	segments := pathParameterPattern.Split(endpoint.Path, -1)
+	for i, segment := range segments {
+		segments[i] = regexp.QuoteMeta(segment)
+	}
+	match := regexp.MustCompile("^(.*?)" + strings.Join(segments, "[^/]+") + "/?$").FindStringSubmatch(u.EscapedPath())
+	if match == nil {
+		return "", false
+	}
+	return u.Scheme + "://" + u.Host + match[1], true
+}
+
+// pathParameterPattern matches the path parameters of an OpenAPI path, e.g. {id}
+var pathParameterPattern = regexp.MustCompile(`\{[^/{}]+\}`)
+
+// fillPath replaces the path parameters of a path with their examples
+func (t *MassAssignmentTester) fillPath(path string, parameters []*parser.OpenAPIParameter) string {
+	for _, param := range parameters {
+		if param.In != "path" {
+			continue
+		}
+		value := t.PathParameterValue
+		if param.Example != nil {
+			value = fmt.Sprint(param.Example)
+		} else if param.Schema != nil && param.Schema.Example != nil {
+			value = fmt.Sprint(param.Schema.Example)
+		}
+		path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(value))
+	}
+	return path
+}
+
+// successSchema returns the object schema of the first successful response of an endpoint
+func successSchema(endpoint *parser.OpenAPIEndpoint) *parser.OpenAPISchema {
+	codes := make([]string, 0, len(endpoint.Responses))
+	for code := range endpoint.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		schema := endpoint.Responses[code]
+		if schema != nil && schema.Type == "array" && schema.Items != nil {
+			schema = schema.Items
+		}
+		if schema != nil && schema.Type == "object" {
+			return schema
+		}
+	}
+	return nil
+}
+
+// mergeSchemas returns an object schema with the properties of both schemas
+func mergeSchemas(a, b *parser.OpenAPISchema) *parser.OpenAPISchema {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	merged := &parser.OpenAPISchema{Type: "object", Properties: make(map[string]*parser.OpenAPISchema)}
+	for name, schema := range b.Properties {
+		merged.Properties[name] = schema
+	}
+	for name, schema := range a.Properties {
+		merged.Properties[name] = schema
+	}
+	return merged
+}
+
+// serverOnlyProperties returns the properties of a response schema that are not in the request schema
+func serverOnlyProperties(request, response *parser.OpenAPISchema) []string {
+	if response == nil {
+		return nil
+	}
+	var properties []string
+	for name := range response.Properties {
+		if _, ok := request.Properties[name]; !ok {
+			properties = append(properties, name)
+		}
+	}
+	sort.Strings(properties)
+	return properties
+}
+
+// exampleValue returns a valid value for a request body property
+func exampleValue(name string, schema *parser.OpenAPISchema) interface{} {
+	if schema == nil {
+		return "test"
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	switch schema.Type {
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return false
+	case "array":
+		return []interface{}{}
+	case "object":
+		return map[string]interface{}{}
+	}
+	if schema.Format == "email" || strings.Contains(strings.ToLower(name), "email") {
+		return "test@example.com"
+	}
+	return "test"
+}
+
+// massAssignmentValue returns a privileged value for a server-only property
+func massAssignmentValue(name string, schema *parser.OpenAPISchema) interface{} {
+	if schema != nil {
+		if len(schema.Enum) > 0 {
+			// Prefer privileged enum values, e.g., "admin" for a role
+			for _, value := range schema.Enum {
+				if s, ok := value.(string); ok && (strings.Contains(strings.ToLower(s), "admin") || strings.Contains(strings.ToLower(s), "super")) {
+					return value
+				}
+			}
+			return schema.Enum[len(schema.Enum)-1]
+		}
+		switch schema.Type {
+		case "integer":
+			return 999999
+		case "number":
+			return 999999.99
+		case "boolean":
+			return true
+		case "array":
+			return []interface{}{"admin"}
+		case "object":
+			return map[string]interface{}{}
+		}
+		if schema.Format == "date-time" {
+			return "2020-01-01T00:00:00Z"
+		}
+	}
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "role") || strings.Contains(lower, "group") || strings.Contains(lower, "permission") {
+		return "admin"
+	}
+	return "mass-assignment-test"
+}
+
+// createdID returns the identifier of a created resource from a JSON response
+func createdID(data []byte) string {
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return ""
+	}
+	for _, key := range []string{"id", "uuid", "_id"} {
+		if value, ok := body[key]; ok && value != nil {
+			if f, ok := value.(float64); ok {
+				return fmt.Sprintf("%.0f", f)
+			}
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// persistedProperties returns the injected properties that have the injected value in a JSON response
+func persistedProperties(data []byte, injected map[string]interface{}) []string {
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil
+	}
+	// Unwrap common response envelopes
+	if inner, ok := body["data"].(map[string]interface{}); ok {
+		body = inner
+	}
+
+	var persisted []string
+	for name, value := range injected {
+		actual, ok := body[name]
+		if !ok {
+			continue
+		}
+		expected, _ := json.Marshal(value)
+		got, _ := json.Marshal(actual)
+		var normalized interface{}
+		if json.Unmarshal(expected, &normalized) == nil {
+			expected, _ = json.Marshal(normalized)
+		}
+		if string(expected) == string(got) {
+			persisted = append(persisted, name)
+		}
+	}
+	sort.Strings(persisted)
+	return persisted
+}
+
+// lastPathParameter returns the name of the parameter in the last segment of a path
+func lastPathParameter(path string) string {
+	trimmed := strings.TrimSuffix(path, "/")
+	last := trimmed[strings.LastIndex(trimmed, "/")+1:]
+	if strings.HasPrefix(last, "{") && strings.HasSuffix(last, "}") {
+		return strings.Trim(last, "{}")
+	}
+	return ""
+}
+
+// containsProperty checks if a property name is in a list, ignoring case
+func containsProperty(properties []string, name string) bool {
+	for _, property := range properties {
+		if strings.EqualFold(property, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // responseIndicatesSuccess checks if the response indicates that the sensitive properties were accepted
 func (t *MassAssignmentTester) responseIndicatesSuccess(resp ffuf.Response, payload interface{}) bool {
 	// This is a simplified check - in a real implementation, this would be more sophisticated
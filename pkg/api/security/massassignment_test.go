@@ -0,0 +1,131 @@
+package security
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// usersSpec is a specification creating users, whose role is set by the server only
+const usersSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Users", "version": "1"},
+  "servers": [{"url": "https://api.example.com/v1"}],
+  "paths": {
+    "/users": {
+      "post": {
+        "requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {"name": {"type": "string"}}}}}},
+        "responses": {"201": {"description": "Created", "content": {"application/json": {"schema": {"type": "object", "properties": {"id": {"type": "integer"}, "name": {"type": "string"}, "role": {"type": "string", "enum": ["user", "admin"]}}}}}}}
+      }
+    },
+    "/users/{id}": {
+      "get": {
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+        "responses": {"200": {"description": "User", "content": {"application/json": {"schema": {"type": "object", "properties": {"id": {"type": "integer"}, "name": {"type": "string"}, "role": {"type": "string", "enum": ["user", "admin"]}}}}}}}
+      }
+    }
+  }
+}`
+
+// usersServer answers the requests of the users API, echoing the properties of the created
+// user, and storing its role if persist is set
+func usersServer(persist bool) func(req *ffuf.Request) ffuf.Response {
+	role := "user"
+	return func(req *ffuf.Request) ffuf.Response {
+		switch {
+		case req.Method == "POST" && strings.HasSuffix(req.Url, "/v1/users"):
+			var body map[string]interface{}
+			if err := json.Unmarshal(req.Data, &body); err != nil {
+				return ffuf.Response{StatusCode: 400}
+			}
+			if value, ok := body["role"].(string); ok && persist {
+				role = value
+			}
+			body["id"] = 42
+			data, _ := json.Marshal(body)
+			return ffuf.Response{StatusCode: 201, ContentType: "application/json", Data: data}
+		case req.Method == "GET" && strings.HasSuffix(req.Url, "/v1/users/42"):
+			return ffuf.Response{ContentType: "application/json", Data: []byte(`{"id":42,"name":"test","role":"` + role + `"}`)}
+		}
+		return ffuf.Response{StatusCode: 404}
+	}
+}
+
+// specFindings returns the findings of the specification test of a result
+func specFindings(result *TestResult) []VulnerabilityInfo {
+	var findings []VulnerabilityInfo
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Name == "Mass Assignment of Server-Only Properties" {
+			findings = append(findings, vuln)
+		}
+	}
+	return findings
+}
+
+func TestMassAssignmentTester_SpecPersistence(t *testing.T) {
+	spec := parser.NewOpenAPIParser()
+	if err := spec.ParseJSON([]byte(usersSpec)); err != nil {
+		t.Fatalf("ParseJSON returned an error: %s", err)
+	}
+	conf := newFakeConfig(t, "https://api.example.com/v1/users")
+	tester := NewMassAssignmentTester()
+	tester.Spec = spec
+
+	// The role is echoed by the write response, but the user re-read keeps its role
+	result, runner := runFake(t, tester, conf, usersServer(false))
+	if findings := specFindings(result); len(findings) != 0 {
+		t.Errorf("Expected the properties echoed by the write response not to be reported, got %s", findings[0].Evidence)
+	}
+	reread := false
+	for _, req := range runner.Requests() {
+		reread = reread || (req.Method == "GET" && strings.HasSuffix(req.Url, "/v1/users/42"))
+	}
+	if !reread {
+		t.Errorf("Expected the created user to be re-read")
+	}
+
+	// The role of the user re-read is the injected one
+	result, _ = runFake(t, tester, conf, usersServer(true))
+	findings := specFindings(result)
+	if len(findings) != 1 || !strings.Contains(findings[0].Evidence, "Server-only properties role were persisted in the resource re-read from https://api.example.com/v1/users/42") {
+		t.Fatalf("Expected the persisted role to be reported, got %v", findings)
+	}
+	if body, _ := io.ReadAll(findings[0].Response.Body); !strings.Contains(string(body), `"role":"admin"`) {
+		t.Errorf("Expected the response of the re-read as evidence, got %s", body)
+	}
+}
+
+func TestMassAssignmentTester_ScanSpecifications(t *testing.T) {
+	spec := parser.NewOpenAPIParser()
+	if err := spec.ParseJSON([]byte(usersSpec)); err != nil {
+		t.Fatalf("ParseJSON returned an error: %s", err)
+	}
+
+	// The operation of the target is tested on the host of the target, not the server of the
+	// specification
+	conf := newFakeConfig(t, "https://staging.example.com/v1/users")
+	conf.Method = "POST"
+	conf.Context = WithSpecifications(conf.Context, []*parser.OpenAPIParser{spec})
+	result, runner := runFake(t, NewMassAssignmentTester(), conf, usersServer(true))
+	findings := specFindings(result)
+	if len(findings) != 1 || !strings.Contains(findings[0].Evidence, "re-read from https://staging.example.com/v1/users/42") {
+		t.Errorf("Expected the operation of the target to be tested, got %v", findings)
+	}
+	for _, req := range runner.Requests() {
+		if strings.HasPrefix(req.Url, "https://api.example.com/") {
+			t.Errorf("Expected the requests to be sent to the target, got %s", req.Url)
+		}
+	}
+
+	// The specification has no write operation for other targets
+	conf.Url = "https://staging.example.com/v1/users/42"
+	conf.Method = "GET"
+	result, _ = runFake(t, NewMassAssignmentTester(), conf, usersServer(true))
+	if findings := specFindings(result); len(findings) != 0 {
+		t.Errorf("Expected only the operation of the target to be tested, got %v", findings)
+	}
+}
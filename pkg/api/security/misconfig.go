@@ -9,14 +9,13 @@ import (
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // SecurityMisconfigTester implements testing for Security Misconfiguration (API7:2019)
 type SecurityMisconfigTester struct {
 	// Configuration options
-	InsecureHeaders      map[string]string
-	MissingHeaders       []string
+	// HeaderPolicyFile is a YAML or JSON header policy replacing the default one
+	HeaderPolicyFile     string
 	DangerousMethods     []string
 	DefaultCredentials   []struct{ Username, Password string }
 	CommonDebugEndpoints []string
@@ -25,27 +24,6 @@ type SecurityMisconfigTester struct {
 // NewSecurityMisconfigTester creates a new tester for Security Misconfiguration
 func NewSecurityMisconfigTester() *SecurityMisconfigTester {
 	return &SecurityMisconfigTester{
-		InsecureHeaders: map[string]string{
-			"X-Frame-Options":        "",
-			"X-Content-Type-Options": "",
-			"Content-Security-Policy": "",
-			"Strict-Transport-Security": "",
-			"X-XSS-Protection":       "",
-			"Cache-Control":          "no-store, no-cache",
-			"Access-Control-Allow-Origin": "*",
-			"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE, OPTIONS, HEAD, PATCH",
-			"Access-Control-Allow-Headers": "*",
-			"Access-Control-Allow-Credentials": "true",
-			"Server":                 "",
-			"X-Powered-By":           "",
-		},
-		MissingHeaders: []string{
-			"X-Frame-Options",
-			"X-Content-Type-Options",
-			"Content-Security-Policy",
-			"Strict-Transport-Security",
-			"X-XSS-Protection",
-		},
 		DangerousMethods: []string{
 			"TRACE",
 			"OPTIONS",
@@ -139,23 +117,34 @@ func (t *SecurityMisconfigTester) Test(ctx context.Context, config *ffuf.Config)
 		StartTime: time.Now(),
 	}
 
+	policy := DefaultHeaderPolicy()
+	if t.HeaderPolicyFile != "" {
+		var err error
+		if policy, err = LoadHeaderPolicy(t.HeaderPolicyFile); err != nil {
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result, result.Error
+		}
+	}
+
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract the base URL from the config
 	baseURL := extractBaseURL(config.Url)
 
 	// Test for insecure HTTP headers
-	t.testInsecureHeaders(baseURL, r, result)
+	t.testInsecureHeaders(baseURL, policy, r, result)
 
 	// Test for dangerous HTTP methods
-	t.testDangerousMethods(baseURL, r, result)
+	t.testDangerousMethods(ctx, baseURL, r, result)
 
 	// Test for default credentials
-	t.testDefaultCredentials(baseURL, r, result)
+	t.testDefaultCredentials(ctx, baseURL, r, loginGuardFor(ctx, config), result)
 
 	// Test for common debug endpoints
-	t.testDebugEndpoints(baseURL, r, result)
+	t.testDebugEndpoints(ctx, baseURL, r, result)
 
 	// Test for CORS misconfiguration
 	t.testCORSMisconfiguration(baseURL, r, result)
@@ -168,14 +157,15 @@ func (t *SecurityMisconfigTester) Test(ctx context.Context, config *ffuf.Config)
 	return result, nil
 }
 
-// testInsecureHeaders tests for insecure HTTP headers
-func (t *SecurityMisconfigTester) testInsecureHeaders(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+// testInsecureHeaders grades the security headers against a header policy and tests for
+// information disclosure headers
+func (t *SecurityMisconfigTester) testInsecureHeaders(baseURL string, policy *HeaderPolicy, r ffuf.RunnerProvider, result *TestResult) {
 	// Create a request to check headers
 	req := &ffuf.Request{
 		Method: "GET",
 		Url:    baseURL,
 		Headers: map[string]string{
-			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+			"User-Agent": testerUserAgent,
 		},
 	}
 
@@ -185,63 +175,27 @@ func (t *SecurityMisconfigTester) testInsecureHeaders(baseURL string, r ffuf.Run
 		return
 	}
 
-	// Check for missing security headers
-	var missingHeaders []string
-	for _, header := range t.MissingHeaders {
-		if _, exists := resp.Headers[header]; !exists {
-			missingHeaders = append(missingHeaders, header)
-		}
-	}
-
-	if len(missingHeaders) > 0 {
-		// Create a vulnerability info
-		vuln := VulnerabilityInfo{
-			Type:        VulnSecurityMisconfig,
-			Name:        "Missing Security Headers",
-			Description: "The API is missing important security headers that help protect against common web vulnerabilities.",
-			Severity:    "Medium",
-			Request:     convertToHTTPRequest(req),
-			Response:    convertToHTTPResponse(resp),
-			Evidence:    fmt.Sprintf("Missing headers: %s", strings.Join(missingHeaders, ", ")),
-			Remediation: "Configure the server to include all necessary security headers. Consider using a security header middleware or framework that automatically adds these headers.",
-			CVSS:        5.0,
-			CWE:         "CWE-16",
-			References: []string{
-				"https://owasp.org/API-Security/editions/2019/en/0xa7-security-misconfiguration/",
-				"https://cheatsheetseries.owasp.org/cheatsheets/REST_Security_Cheat_Sheet.html",
-				"https://owasp.org/www-project-secure-headers/",
-			},
-			DetectedAt: time.Now(),
-		}
-		result.Vulnerabilities = append(result.Vulnerabilities, vuln)
-	}
-
-	// Check for insecure header values
-	var insecureHeaders []string
-	for header, insecureValue := range t.InsecureHeaders {
-		if value, exists := resp.Headers[header]; exists {
-			if insecureValue != "" && strings.Contains(value[0], insecureValue) {
-				insecureHeaders = append(insecureHeaders, fmt.Sprintf("%s: %s", header, value[0]))
-			}
+	// Grade the security headers
+	grade := GradeHeaders(resp.Headers, policy, strings.HasPrefix(strings.ToLower(baseURL), "https://"))
+	if len(grade.Issues) > 0 {
+		issues := make([]string, 0, len(grade.Issues))
+		for _, issue := range grade.Issues {
+			issues = append(issues, fmt.Sprintf("%s (-%d)", issue.Message, issue.Penalty))
 		}
-	}
-
-	if len(insecureHeaders) > 0 {
-		// Create a vulnerability info
 		vuln := VulnerabilityInfo{
 			Type:        VulnSecurityMisconfig,
-			Name:        "Insecure Header Values",
-			Description: "The API is using insecure values for security headers.",
-			Severity:    "Medium",
+			Name:        fmt.Sprintf("Weak Security Headers (Grade %s)", grade.Grade),
+			Description: "The security headers of the API are missing or weaker than the header policy, leaving clients less protected against common web vulnerabilities.",
+			Severity:    headerGradeSeverity(grade.Grade),
 			Request:     convertToHTTPRequest(req),
 			Response:    convertToHTTPResponse(resp),
-			Evidence:    fmt.Sprintf("Insecure headers: %s", strings.Join(insecureHeaders, ", ")),
-			Remediation: "Configure the server to use secure values for security headers. Avoid using wildcard values for CORS headers and ensure proper restrictions are in place.",
+			Evidence:    fmt.Sprintf("Grade %s (%d/100): %s", grade.Grade, grade.Score, strings.Join(issues, "; ")),
+			Remediation: "Configure the server to send the security headers of the header policy with strict values: a Content-Security-Policy without 'unsafe-inline', 'unsafe-eval' or wildcard script sources, a long-lived Strict-Transport-Security including subdomains, a Permissions-Policy not granting features to any origin, and CORS headers allowing only trusted origins. Consider using a security header middleware.",
 			CVSS:        5.0,
-			CWE:         "CWE-16",
+			CWE:         "CWE-693",
 			References: []string{
 				"https://owasp.org/API-Security/editions/2019/en/0xa7-security-misconfiguration/",
-				"https://cheatsheetseries.owasp.org/cheatsheets/REST_Security_Cheat_Sheet.html",
+				"https://cheatsheetseries.owasp.org/cheatsheets/HTTP_Headers_Cheat_Sheet.html",
 				"https://owasp.org/www-project-secure-headers/",
 			},
 			DetectedAt: time.Now(),
@@ -280,15 +234,29 @@ func (t *SecurityMisconfigTester) testInsecureHeaders(baseURL string, r ffuf.Run
 	}
 }
 
+// headerGradeSeverity returns the severity of the findings of a header grade
+func headerGradeSeverity(grade string) string {
+	switch grade {
+	case "A":
+		return "Info"
+	case "B", "C":
+		return "Low"
+	}
+	return "Medium"
+}
+
 // testDangerousMethods tests for dangerous HTTP methods
-func (t *SecurityMisconfigTester) testDangerousMethods(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *SecurityMisconfigTester) testDangerousMethods(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	for _, method := range t.DangerousMethods {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a request with the dangerous method
 		req := &ffuf.Request{
 			Method: method,
 			Url:    baseURL,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
@@ -323,8 +291,9 @@ func (t *SecurityMisconfigTester) testDangerousMethods(baseURL string, r ffuf.Ru
 	}
 }
 
-// testDefaultCredentials tests for default credentials
-func (t *SecurityMisconfigTester) testDefaultCredentials(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+// testDefaultCredentials tests for default credentials, within the login attempts allowed by
+// the login guard
+func (t *SecurityMisconfigTester) testDefaultCredentials(ctx context.Context, baseURL string, r ffuf.RunnerProvider, guard *LoginGuard, result *TestResult) {
 	// Look for potential login endpoints
 	loginEndpoints := []string{
 		"/login",
@@ -352,6 +321,13 @@ func (t *SecurityMisconfigTester) testDefaultCredentials(baseURL string, r ffuf.
 		loginURL += endpoint
 
 		for _, cred := range t.DefaultCredentials {
+			if ctx.Err() != nil {
+				return
+			}
+			// Stop at the cap of login attempts of the endpoint, or once it is locked
+			if !guard.Allow(loginURL) {
+				break
+			}
 			username := cred.Username
 			password := cred.Password
 			// Create a JSON login payload
@@ -363,7 +339,7 @@ func (t *SecurityMisconfigTester) testDefaultCredentials(baseURL string, r ffuf.
 				Url:    loginURL,
 				Headers: map[string]string{
 					"Content-Type": "application/json",
-					"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+					"User-Agent":   testerUserAgent,
 				},
 				Data: []byte(payload),
 			}
@@ -373,6 +349,9 @@ func (t *SecurityMisconfigTester) testDefaultCredentials(baseURL string, r ffuf.
 			if err != nil {
 				continue
 			}
+			if guard.Record(loginURL, resp) {
+				break
+			}
 
 			// Check if the login was successful
 			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
@@ -413,8 +392,11 @@ func (t *SecurityMisconfigTester) testDefaultCredentials(baseURL string, r ffuf.
 }
 
 // testDebugEndpoints tests for common debug endpoints
-func (t *SecurityMisconfigTester) testDebugEndpoints(baseURL string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *SecurityMisconfigTester) testDebugEndpoints(ctx context.Context, baseURL string, r ffuf.RunnerProvider, result *TestResult) {
 	for _, endpoint := range t.CommonDebugEndpoints {
+		if ctx.Err() != nil {
+			return
+		}
 		debugURL := baseURL
 		if !strings.HasSuffix(debugURL, "/") && !strings.HasPrefix(endpoint, "/") {
 			debugURL += "/"
@@ -426,7 +408,7 @@ func (t *SecurityMisconfigTester) testDebugEndpoints(baseURL string, r ffuf.Runn
 			Method: "GET",
 			Url:    debugURL,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
@@ -469,7 +451,7 @@ func (t *SecurityMisconfigTester) testCORSMisconfiguration(baseURL string, r ffu
 		Url:    baseURL,
 		Headers: map[string]string{
 			"Origin":     "https://evil.com",
-			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+			"User-Agent": testerUserAgent,
 		},
 	}
 
@@ -548,7 +530,7 @@ func (t *SecurityMisconfigTester) testTLSMisconfiguration(baseURL string, r ffuf
 			Method: "GET",
 			Url:    httpURL,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
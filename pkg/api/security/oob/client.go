@@ -0,0 +1,83 @@
+package oob
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client connects to a remote callback server started with a poll token
+type Client struct {
+	// Base URL of the remote server
+	ServerURL string
+
+	// Domain delegated to the remote server
+	ServerDomain string
+
+	// Token required to poll the server
+	Token string
+
+	httpClient *http.Client
+	next       int
+	mu         sync.Mutex
+}
+
+// NewClient creates a client for a remote callback server
+func NewClient(serverURL, domain, token string) *Client {
+	return &Client{
+		ServerURL:    strings.TrimSuffix(serverURL, "/"),
+		ServerDomain: strings.ToLower(strings.Trim(domain, ".")),
+		Token:        token,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Domain returns the domain under which payload subdomains are generated
+func (c *Client) Domain() string {
+	if c.ServerDomain != "" {
+		return c.ServerDomain
+	}
+	if u, err := url.Parse(c.ServerURL); err == nil {
+		return u.Hostname()
+	}
+	return ""
+}
+
+// URL returns the base URL of the remote server
+func (c *Client) URL() string {
+	return c.ServerURL
+}
+
+// Poll returns the interactions received by the remote server since the last poll
+func (c *Client) Poll() ([]*Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pollURL := fmt.Sprintf("%s%s?token=%s&since=%d", c.ServerURL, PollPath, url.QueryEscape(c.Token), c.next)
+	resp, err := c.httpClient.Get(pollURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to poll callback server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to poll callback server: status %d", resp.StatusCode)
+	}
+
+	var response pollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode callback server response: %w", err)
+	}
+	c.next = response.Next
+	return response.Interactions, nil
+}
+
+// Close releases the resources of the client
+func (c *Client) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,126 @@
+package oob
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DNS record types
+const (
+	dnsTypeA    uint16 = 1
+	dnsTypeAAAA uint16 = 28
+	dnsClassIN  uint16 = 1
+)
+
+// dnsTypeNames maps common DNS record types to their names
+var dnsTypeNames = map[uint16]string{
+	1:   "A",
+	2:   "NS",
+	5:   "CNAME",
+	6:   "SOA",
+	15:  "MX",
+	16:  "TXT",
+	28:  "AAAA",
+	33:  "SRV",
+	255: "ANY",
+}
+
+// dnsQuestion is the first question of a DNS query
+type dnsQuestion struct {
+	ID    uint16
+	Flags uint16
+	Name  string
+	Type  uint16
+	Class uint16
+	// Raw question section, copied into the response
+	Raw []byte
+}
+
+// typeName returns the name of the record type of the question
+func (q *dnsQuestion) typeName() string {
+	if name, ok := dnsTypeNames[q.Type]; ok {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", q.Type)
+}
+
+// parseDNSQuery parses the header and first question of a DNS query
+func parseDNSQuery(packet []byte) (*dnsQuestion, error) {
+	if len(packet) < 12 {
+		return nil, fmt.Errorf("DNS packet too short")
+	}
+	q := &dnsQuestion{
+		ID:    binary.BigEndian.Uint16(packet[0:2]),
+		Flags: binary.BigEndian.Uint16(packet[2:4]),
+	}
+	if binary.BigEndian.Uint16(packet[4:6]) == 0 {
+		return nil, fmt.Errorf("DNS query has no question")
+	}
+
+	// Read the labels of the name
+	var labels []string
+	offset := 12
+	for {
+		if offset >= len(packet) {
+			return nil, fmt.Errorf("DNS name exceeds packet")
+		}
+		length := int(packet[offset])
+		offset++
+		if length == 0 {
+			break
+		}
+		if length&0xC0 != 0 {
+			return nil, fmt.Errorf("compressed names are not supported in questions")
+		}
+		if offset+length > len(packet) {
+			return nil, fmt.Errorf("DNS label exceeds packet")
+		}
+		labels = append(labels, string(packet[offset:offset+length]))
+		offset += length
+	}
+	if offset+4 > len(packet) {
+		return nil, fmt.Errorf("DNS question exceeds packet")
+	}
+	q.Name = strings.ToLower(strings.Join(labels, "."))
+	q.Type = binary.BigEndian.Uint16(packet[offset : offset+2])
+	q.Class = binary.BigEndian.Uint16(packet[offset+2 : offset+4])
+	q.Raw = packet[12 : offset+4]
+	return q, nil
+}
+
+// buildDNSResponse builds an authoritative response to a question, answering A and AAAA
+// queries with the given IP address
+func buildDNSResponse(q *dnsQuestion, ip net.IP) []byte {
+	var answer []byte
+	if ip != nil && q.Class == dnsClassIN {
+		var rdata []byte
+		if ip4 := ip.To4(); ip4 != nil && q.Type == dnsTypeA {
+			rdata = ip4
+		} else if ip4 == nil && q.Type == dnsTypeAAAA {
+			rdata = ip.To16()
+		}
+		if rdata != nil {
+			answer = make([]byte, 12, 12+len(rdata))
+			binary.BigEndian.PutUint16(answer[0:2], 0xC00C) // Pointer to the question name
+			binary.BigEndian.PutUint16(answer[2:4], q.Type)
+			binary.BigEndian.PutUint16(answer[4:6], dnsClassIN)
+			binary.BigEndian.PutUint32(answer[6:10], 60) // TTL
+			binary.BigEndian.PutUint16(answer[10:12], uint16(len(rdata)))
+			answer = append(answer, rdata...)
+		}
+	}
+
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint16(header[0:2], q.ID)
+	// Response, authoritative answer, copy the opcode and recursion desired bits
+	binary.BigEndian.PutUint16(header[2:4], 0x8400|(q.Flags&0x7900))
+	binary.BigEndian.PutUint16(header[4:6], 1)
+	if answer != nil {
+		binary.BigEndian.PutUint16(header[6:8], 1)
+	}
+
+	response := append(header, q.Raw...)
+	return append(response, answer...)
+}
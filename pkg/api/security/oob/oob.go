@@ -0,0 +1,271 @@
+// Package oob provides out-of-band interaction detection for API security tests.
+//
+// Payloads reference a unique subdomain or URL path generated per payload. A callback
+// service receives the DNS and HTTP interactions triggered by the target, and a Tracker
+// correlates them back to the test that sent the payload.
+package oob
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Protocol of an interaction
+const (
+	ProtocolDNS  = "dns"
+	ProtocolHTTP = "http"
+)
+
+// Interaction represents a DNS query or HTTP request received by the callback service
+type Interaction struct {
+	// Correlation ID extracted from the subdomain or path
+	ID string `json:"id"`
+
+	// Protocol of the interaction (dns or http)
+	Protocol string `json:"protocol"`
+
+	// Full domain name queried or Host header requested
+	Host string `json:"host"`
+
+	// Address of the client that sent the interaction
+	RemoteAddr string `json:"remote_addr"`
+
+	// DNS query type (A, AAAA, TXT, ...) or HTTP method
+	Type string `json:"type"`
+
+	// Raw request for HTTP interactions
+	Raw string `json:"raw,omitempty"`
+
+	// Time the interaction was received
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Provider is a callback service that receives out-of-band interactions
+type Provider interface {
+	// Domain returns the domain under which payload subdomains are generated
+	Domain() string
+
+	// URL returns the base URL of the HTTP callback service
+	URL() string
+
+	// Poll returns the interactions received since the last poll
+	Poll() ([]*Interaction, error)
+
+	// Close stops the provider
+	Close() error
+}
+
+// Payload is a unique callback location for a single test payload
+type Payload struct {
+	// Correlation ID of the payload
+	ID string
+
+	// Unique subdomain of the payload (e.g., 3k9d0c2m1x7q.oob.example.com)
+	Domain string
+
+	// Unique URL of the payload (e.g., http://3k9d0c2m1x7q.oob.example.com/3k9d0c2m1x7q)
+	URL string
+
+	// Name of the test that sent the payload
+	Test string
+
+	// Description of where the payload was sent (e.g., parameter name)
+	Location string
+
+	// Metadata of the test
+	Metadata map[string]string
+
+	// Time the payload was generated
+	CreatedAt time.Time
+}
+
+// Render replaces the {oob_domain} and {oob_url} placeholders in a template with the payload location
+func (p *Payload) Render(template string) string {
+	return strings.NewReplacer("{oob_domain}", p.Domain, "{oob_url}", p.URL).Replace(template)
+}
+
+// Finding is a payload that triggered interactions with the callback service
+type Finding struct {
+	Payload      *Payload
+	Interactions []*Interaction
+}
+
+// Protocols returns the protocols of the interactions of a finding
+func (f *Finding) Protocols() []string {
+	seen := make(map[string]bool)
+	var protocols []string
+	for _, interaction := range f.Interactions {
+		if !seen[interaction.Protocol] {
+			seen[interaction.Protocol] = true
+			protocols = append(protocols, interaction.Protocol)
+		}
+	}
+	return protocols
+}
+
+// Tracker generates payloads and correlates interactions with the tests that sent them
+type Tracker struct {
+	// Callback service
+	Provider Provider
+
+	// Interval between polls of the provider
+	PollInterval time.Duration
+
+	payloads     map[string]*Payload
+	interactions map[string][]*Interaction
+	mu           sync.Mutex
+}
+
+// NewTracker creates a new tracker for a provider
+func NewTracker(provider Provider) *Tracker {
+	return &Tracker{
+		Provider:     provider,
+		PollInterval: time.Second,
+		payloads:     make(map[string]*Payload),
+		interactions: make(map[string][]*Interaction),
+	}
+}
+
+// Payload generates a new payload for a test
+func (t *Tracker) Payload(test, location string) *Payload {
+	id := NewID()
+	domain := id + "." + t.Provider.Domain()
+
+	url := strings.TrimSuffix(t.Provider.URL(), "/") + "/" + id
+	if t.Provider.URL() == "" {
+		url = "http://" + domain + "/" + id
+	}
+
+	payload := &Payload{
+		ID:        id,
+		Domain:    domain,
+		URL:       url,
+		Test:      test,
+		Location:  location,
+		Metadata:  make(map[string]string),
+		CreatedAt: time.Now(),
+	}
+
+	t.mu.Lock()
+	t.payloads[id] = payload
+	t.mu.Unlock()
+	return payload
+}
+
+// Poll retrieves the interactions from the provider and returns the new findings
+func (t *Tracker) Poll() ([]*Finding, error) {
+	interactions, err := t.Provider.Poll()
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	updated := make(map[string]bool)
+	for _, interaction := range interactions {
+		if _, ok := t.payloads[interaction.ID]; !ok {
+			continue // Not one of our payloads
+		}
+		t.interactions[interaction.ID] = append(t.interactions[interaction.ID], interaction)
+		updated[interaction.ID] = true
+	}
+
+	findings := make([]*Finding, 0, len(updated))
+	for id := range updated {
+		findings = append(findings, &Finding{
+			Payload:      t.payloads[id],
+			Interactions: append([]*Interaction{}, t.interactions[id]...),
+		})
+	}
+	return findings, nil
+}
+
+// Wait polls the provider until the timeout or the context is done and returns the findings
+// of all payloads that triggered interactions
+func (t *Tracker) Wait(ctx context.Context, timeout time.Duration) ([]*Finding, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := t.Poll(); err != nil {
+			return t.Findings(), err
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+
+		wait := t.PollInterval
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return t.Findings(), ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return t.Findings(), nil
+}
+
+// Findings returns the findings of all payloads that triggered interactions so far
+func (t *Tracker) Findings() []*Finding {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	findings := make([]*Finding, 0, len(t.interactions))
+	for id, interactions := range t.interactions {
+		findings = append(findings, &Finding{
+			Payload:      t.payloads[id],
+			Interactions: append([]*Interaction{}, interactions...),
+		})
+	}
+	return findings
+}
+
+// idAlphabet contains the characters of correlation IDs, which must be valid DNS labels
+const idAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// idLength is the length of correlation IDs
+const idLength = 16
+
+// NewID generates a new random correlation ID
+func NewID() string {
+	b := make([]byte, idLength)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	for i := range b {
+		b[i] = idAlphabet[int(b[i])%len(idAlphabet)]
+	}
+	return string(b)
+}
+
+// extractID extracts a correlation ID from a host name or URL path
+func extractID(values ...string) string {
+	for _, value := range values {
+		for _, part := range strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+			return r == '.' || r == '/' || r == '?' || r == '&' || r == '='
+		}) {
+			if isID(part) {
+				return part
+			}
+		}
+	}
+	return ""
+}
+
+// isID checks if a string is a valid correlation ID
+func isID(s string) bool {
+	if len(s) != idLength {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(idAlphabet, c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package oob
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTracker_HTTP(t *testing.T) {
+	server, err := NewServer(ServerOptions{HTTPAddr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Close()
+
+	tracker := NewTracker(server)
+	tracker.PollInterval = 10 * time.Millisecond
+	payload := tracker.Payload("Command Injection", "parameter 'cmd'")
+	other := tracker.Payload("Command Injection", "parameter 'host'")
+
+	if !strings.HasPrefix(payload.URL, server.URL()) || !strings.HasSuffix(payload.URL, "/"+payload.ID) {
+		t.Errorf("Unexpected payload URL: %s", payload.URL)
+	}
+	if got := payload.Render("; curl {oob_url}"); got != "; curl "+payload.URL {
+		t.Errorf("Unexpected rendered payload: %s", got)
+	}
+
+	resp, err := http.Get(payload.URL)
+	if err != nil {
+		t.Fatalf("Failed to send callback: %v", err)
+	}
+	resp.Body.Close()
+
+	findings, err := tracker.Wait(context.Background(), 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to wait for interactions: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(findings))
+	}
+	if findings[0].Payload != payload {
+		t.Errorf("Expected the finding of payload %s, got %s", payload.ID, findings[0].Payload.ID)
+	}
+	if findings[0].Payload == other {
+		t.Errorf("Payload without interactions was reported")
+	}
+	if protocols := findings[0].Protocols(); len(protocols) != 1 || protocols[0] != ProtocolHTTP {
+		t.Errorf("Expected an HTTP interaction, got %v", protocols)
+	}
+}
+
+func TestServer_DNS(t *testing.T) {
+	server, err := NewServer(ServerOptions{Domain: "oob.example.com", DNSAddr: "127.0.0.1:0", ResponseIP: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Close()
+
+	tracker := NewTracker(server)
+	payload := tracker.Payload("Server Side Request Forgery", "parameter 'url'")
+
+	// Build an A query for the payload domain
+	query := []byte{0x12, 0x34, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	for _, label := range strings.Split(payload.Domain, ".") {
+		query = append(query, byte(len(label)))
+		query = append(query, label...)
+	}
+	query = append(query, 0x00, 0x00, 0x01, 0x00, 0x01)
+
+	conn, err := net.Dial("udp", server.DNSAddr())
+	if err != nil {
+		t.Fatalf("Failed to connect to DNS listener: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(query); err != nil {
+		t.Fatalf("Failed to send DNS query: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	response := make([]byte, 512)
+	n, err := conn.Read(response)
+	if err != nil {
+		t.Fatalf("Failed to read DNS response: %v", err)
+	}
+	response = response[:n]
+	if binary.BigEndian.Uint16(response[0:2]) != 0x1234 {
+		t.Errorf("DNS response ID does not match the query")
+	}
+	if binary.BigEndian.Uint16(response[6:8]) != 1 {
+		t.Fatalf("Expected 1 answer, got %d", binary.BigEndian.Uint16(response[6:8]))
+	}
+	if ip := net.IP(response[n-4:]); !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("Expected answer 127.0.0.1, got %s", ip)
+	}
+
+	findings, err := tracker.Poll()
+	if err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Payload != payload {
+		t.Fatalf("Expected a finding for the payload, got %d findings", len(findings))
+	}
+	interaction := findings[0].Interactions[0]
+	if interaction.Protocol != ProtocolDNS || interaction.Type != "A" || interaction.Host != payload.Domain {
+		t.Errorf("Unexpected interaction: %+v", interaction)
+	}
+}
+
+func TestClient_Poll(t *testing.T) {
+	server, err := NewServer(ServerOptions{HTTPAddr: "127.0.0.1:0", PollToken: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Close()
+
+	client := NewClient(server.URL(), "", "secret")
+	tracker := NewTracker(client)
+	payload := tracker.Payload("XML Injection (XXE)", "XML body")
+
+	resp, err := http.Get(payload.URL)
+	if err != nil {
+		t.Fatalf("Failed to send callback: %v", err)
+	}
+	resp.Body.Close()
+
+	findings, err := tracker.Poll()
+	if err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Payload != payload {
+		t.Fatalf("Expected a finding for the payload, got %d findings", len(findings))
+	}
+
+	// Interactions are only returned once
+	findings, err = tracker.Poll()
+	if err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Expected no new findings, got %d", len(findings))
+	}
+
+	if _, err := NewClient(server.URL(), "", "wrong").Poll(); err == nil {
+		t.Errorf("Expected an error for an invalid token")
+	}
+}
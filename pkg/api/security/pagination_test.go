@@ -0,0 +1,118 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// paginatedUser is a user of the collection of a paginated API, with hidden fields
+type paginatedUser struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	PasswordHash string `json:"-"`
+}
+
+// newPaginatedServer returns a server of a collection of 500 users in pages of 10. The
+// vulnerable server honors any limit, fails on negative offsets, interprets wildcards in
+// filters and sorts by any field, the safe one clamps the limit to 20, rejects negative
+// offsets, matches filters literally and sorts by the visible fields only.
+func newPaginatedServer(vulnerable bool) *httptest.Server {
+	users := make([]paginatedUser, 500)
+	for i := range users {
+		users[i] = paginatedUser{ID: i + 1, Name: fmt.Sprintf("user%03d", i+1), PasswordHash: fmt.Sprintf("%04x", (i*7919)%997)}
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		selected := append([]paginatedUser{}, users...)
+
+		if filter := query.Get("filter"); filter != "" {
+			matched := selected[:0]
+			for _, user := range selected {
+				if user.Name == filter || (vulnerable && filter == "*") {
+					matched = append(matched, user)
+				}
+			}
+			selected = matched
+		}
+		switch field := query.Get("sort"); {
+		case field == "" || field == "id":
+		case field == "name":
+			sort.SliceStable(selected, func(i, j int) bool { return selected[i].Name > selected[j].Name })
+		case vulnerable && field == "password_hash":
+			sort.SliceStable(selected, func(i, j int) bool { return selected[i].PasswordHash < selected[j].PasswordHash })
+		case !vulnerable:
+			http.Error(w, `{"error":"unknown sort field"}`, http.StatusBadRequest)
+			return
+		}
+
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		if offset < 0 {
+			if vulnerable {
+				http.Error(w, "pq: OFFSET must not be negative", http.StatusInternalServerError)
+			} else {
+				http.Error(w, `{"error":"invalid offset"}`, http.StatusBadRequest)
+			}
+			return
+		}
+		limit := 10
+		if value := query.Get("limit"); value != "" {
+			limit, _ = strconv.Atoi(value)
+			if !vulnerable && (limit < 1 || limit > 20) {
+				limit = 20
+			}
+		}
+		if offset > len(selected) {
+			offset = len(selected)
+		}
+		if limit <= 0 || offset+limit > len(selected) {
+			limit = len(selected) - offset
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": selected[offset : offset+limit], "total": len(selected)})
+	}))
+}
+
+func TestPaginationAbuseTester(t *testing.T) {
+	server := newPaginatedServer(true)
+	defer server.Close()
+	conf := newFakeConfig(t, server.URL+"/users")
+	result, err := NewPaginationAbuseTester().Test(conf.Context, conf)
+	if err != nil {
+		t.Fatalf("Test returned an error: %s", err)
+	}
+	expected := []string{"Unbounded Page Size", "Unvalidated Pagination Offset", "Wildcard Filter Enumeration", "Sorting by Sensitive Field"}
+	if names := vulnerabilityNames(result); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for i, evidence := range []string{
+		"Parameter 'limit=100000' returned 500 items, compared to 10 items of the original request",
+		"Parameter 'offset=-1' returned status 500",
+		"Parameter 'filter=*' returned 10 items, while a value matching nothing returned 0",
+		"Parameter 'sort' sorted the items by the hidden fields password_hash",
+	} {
+		if result.Vulnerabilities[i].Evidence != evidence {
+			t.Errorf("Expected %q as evidence of %s, got %q", evidence, expected[i], result.Vulnerabilities[i].Evidence)
+		}
+	}
+	if u := result.Vulnerabilities[0].Request.URL; u.Query().Get("limit") != "100000" {
+		t.Errorf("Expected the request with the large limit to be reported, got %s", u)
+	}
+}
+
+func TestPaginationAbuseTester_Safe(t *testing.T) {
+	server := newPaginatedServer(false)
+	defer server.Close()
+	conf := newFakeConfig(t, server.URL+"/users")
+	result, err := NewPaginationAbuseTester().Test(conf.Context, conf)
+	if err != nil {
+		t.Fatalf("Test returned an error: %s", err)
+	}
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability on the safe server, got %v", vulnerabilityNames(result))
+	}
+}
@@ -0,0 +1,442 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// Payload categories of the injection tester
+const (
+	PayloadSQLiError  = "sqli-error"
+	PayloadSQLiTime   = "sqli-time"
+	PayloadNoSQLi     = "nosqli"
+	PayloadNoSQLiTime = "nosqli-time"
+	PayloadCmdi       = "cmdi"
+	PayloadCmdiTime   = "cmdi-time"
+	PayloadCmdiOOB    = "cmdi-oob"
+	PayloadLDAPi      = "ldapi"
+	PayloadXXE        = "xxe"
+	PayloadXXEOOB     = "xxe-oob"
+	PayloadJSONi      = "json-injection"
+	PayloadGraphQLi   = "graphql-injection"
+)
+
+// maxPayloadFeedSize is the maximum size of a remote payload feed
+const maxPayloadFeedSize = 10 * 1024 * 1024
+
+// categoryLine matches the lines tagging the payloads following them, e.g. [sqli-error] or
+// [sqli-error, sqli-time], and not payloads such as JSON arrays
+var categoryLine = regexp.MustCompile(`^\[\s*[a-z0-9-]+(\s*,\s*[a-z0-9-]+)*\s*\]$`)
+
+// PayloadCategories describe the payload categories
+var PayloadCategories = map[string]string{
+	PayloadSQLiError:  "SQL injection detected from error messages and altered results",
+	PayloadSQLiTime:   "time-based blind SQL injection, {delay} and {delay_ms} are the delay",
+	PayloadNoSQLi:     "NoSQL operator injection",
+	PayloadNoSQLiTime: "time-based blind NoSQL injection",
+	PayloadCmdi:       "OS command injection detected from command output",
+	PayloadCmdiTime:   "time-based blind OS command injection",
+	PayloadCmdiOOB:    "out-of-band OS command injection, {oob_domain} and {oob_url} are the callback",
+	PayloadLDAPi:      "LDAP filter injection",
+	PayloadXXE:        "XML external entities read from files",
+	PayloadXXEOOB:     "out-of-band XML external entities",
+	PayloadJSONi:      "JSON prototype pollution",
+	PayloadGraphQLi:   "GraphQL introspection and argument injection",
+}
+
+// timeBasedCategories map the kinds of time-based payloads to their category
+var timeBasedCategories = map[string]string{
+	"SQL":     PayloadSQLiTime,
+	"NoSQL":   PayloadNoSQLiTime,
+	"Command": PayloadCmdiTime,
+}
+
+// PayloadProvider provides the payloads of the security testers by category
+type PayloadProvider interface {
+	// Payloads returns the payloads of a category, nil if the provider has none
+	Payloads(category string) []string
+}
+
+// PayloadSet is a PayloadProvider of payloads tagged with categories, loaded from files and
+// remote feeds
+type PayloadSet struct {
+	payloads map[string][]string
+}
+
+// NewPayloadSet creates an empty PayloadSet
+func NewPayloadSet() *PayloadSet {
+	return &PayloadSet{payloads: make(map[string][]string)}
+}
+
+// Add adds payloads to a category, skipping those already in it
+func (s *PayloadSet) Add(category string, payloads ...string) {
+	for _, payload := range payloads {
+		known := false
+		for _, existing := range s.payloads[category] {
+			if existing == payload {
+				known = true
+				break
+			}
+		}
+		if !known {
+			s.payloads[category] = append(s.payloads[category], payload)
+		}
+	}
+}
+
+// Merge adds the payloads of another set
+func (s *PayloadSet) Merge(other *PayloadSet) {
+	for _, category := range other.Categories() {
+		s.Add(category, other.payloads[category]...)
+	}
+}
+
+// Payloads returns the payloads of a category
+func (s *PayloadSet) Payloads(category string) []string {
+	return s.payloads[category]
+}
+
+// Categories returns the sorted categories of the payloads of the set
+func (s *PayloadSet) Categories() []string {
+	categories := make([]string, 0, len(s.payloads))
+	for category := range s.payloads {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// Write writes the payloads of categories in the format read by ParsePayloads, all categories
+// if none are given
+func (s *PayloadSet) Write(w io.Writer, categories []string) error {
+	if len(categories) == 0 {
+		categories = s.Categories()
+	}
+	for i, category := range categories {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n[%s]\n", PayloadCategories[category], category); err != nil {
+			return err
+		}
+		for _, payload := range s.payloads[category] {
+			if _, err := fmt.Fprintln(w, payload); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ParsePayloads parses payloads, one per line. A [category] line tags the payloads following
+// it, [sqli-error, sqli-time] with several categories, and the payloads before the first
+// category line are tagged with the default categories. Empty lines and lines starting with #
+// are skipped.
+func ParsePayloads(data []byte, defaults []string) (*PayloadSet, error) {
+	set := NewPayloadSet()
+	categories := defaults
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxPayloadFeedSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if categoryLine.MatchString(text) {
+			categories = make([]string, 0)
+			for _, category := range strings.Split(text[1:len(text)-1], ",") {
+				category = strings.TrimSpace(category)
+				if _, ok := PayloadCategories[category]; !ok {
+					return nil, fmt.Errorf("unknown payload category %s on line %d, expected one of %s", category, line, strings.Join(payloadCategoryNames(), ", "))
+				}
+				categories = append(categories, category)
+			}
+			continue
+		}
+		if len(categories) == 0 {
+			return nil, fmt.Errorf("the payload on line %d has no category, add a [category] line before it", line)
+		}
+		for _, category := range categories {
+			set.Add(category, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// LoadPayloads loads payloads from a file, the .txt files of a directory, or a remote feed
+// fetched over HTTP(S). The payloads of files named after a category, such as sqli-error.txt,
+// are tagged with it.
+func LoadPayloads(source string) (*PayloadSet, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		data, err := fetchPayloadFeed(source)
+		if err != nil {
+			return nil, err
+		}
+		set, err := ParsePayloads(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload feed %s: %s", source, err)
+		}
+		return set, nil
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the payloads %s: %s", source, err)
+	}
+	files := []string{source}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(source, "*.txt")); err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+	set := NewPayloadSet()
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the payloads %s: %s", file, err)
+		}
+		var defaults []string
+		if category := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)); PayloadCategories[category] != "" {
+			defaults = []string{category}
+		}
+		loaded, err := ParsePayloads(data, defaults)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payloads %s: %s", file, err)
+		}
+		set.Merge(loaded)
+	}
+	return set, nil
+}
+
+// fetchPayloadFeed fetches a remote payload feed
+func fetchPayloadFeed(feedURL string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch the payload feed %s: %s", feedURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch the payload feed %s: %s", feedURL, resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPayloadFeedSize))
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch the payload feed %s: %s", feedURL, err)
+	}
+	return data, nil
+}
+
+// BuiltinPayloads returns the built-in payloads of the injection tester by category
+func BuiltinPayloads() *PayloadSet {
+	return NewInjectionTester().payloadSet()
+}
+
+// payloadSet returns the payloads of the tester by category
+func (t *InjectionTester) payloadSet() *PayloadSet {
+	set := NewPayloadSet()
+	set.Add(PayloadSQLiError, t.SQLInjectionPayloads...)
+	set.Add(PayloadNoSQLi, t.NoSQLInjectionPayloads...)
+	set.Add(PayloadCmdi, t.CommandInjectionPayloads...)
+	set.Add(PayloadLDAPi, t.LDAPInjectionPayloads...)
+	set.Add(PayloadXXE, t.XMLInjectionPayloads...)
+	set.Add(PayloadJSONi, t.JSONInjectionPayloads...)
+	set.Add(PayloadGraphQLi, t.GraphQLInjectionPayloads...)
+	for _, payload := range t.TimeBasedPayloads {
+		set.Add(timeBasedCategories[payload.Kind], payload.Payload)
+	}
+	set.Add(PayloadCmdiOOB, t.OOBCommandPayloads...)
+	set.Add(PayloadXXEOOB, t.OOBXMLPayloads...)
+	return set
+}
+
+// payloadCategoryNames returns the sorted names of the payload categories
+func payloadCategoryNames() []string {
+	names := make([]string, 0, len(PayloadCategories))
+	for name := range PayloadCategories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PayloadSelection selects the payloads sent by the testers: the built-in payloads of the
+// testers and the payloads of a provider, of the selected categories
+type PayloadSelection struct {
+	// Provider provides payloads in addition to the built-in payloads, nil for none
+	Provider PayloadProvider
+	// Categories are the selected categories, all if empty
+	Categories []string
+	// Replace replaces the built-in payloads of the categories the provider has payloads for,
+	// instead of adding to them
+	Replace bool
+	// Tamper transforms the payloads before they are sent, nil for none
+	Tamper *payload.TamperChain
+	// Technologies is the fingerprinted technology stack of the target. Payloads specific to
+	// the databases it excludes are not sent. Nil for none.
+	Technologies *TechnologyProfile
+
+	// tampered are the transformed payloads by payload, set by prepare
+	tampered map[string]string
+}
+
+// NewPayloadSelection creates a PayloadSelection of categories, validating their names
+func NewPayloadSelection(provider PayloadProvider, categories []string, replace bool) (*PayloadSelection, error) {
+	for _, category := range categories {
+		if _, ok := PayloadCategories[category]; !ok {
+			return nil, fmt.Errorf("unknown payload category %s, expected one of %s", category, strings.Join(payloadCategoryNames(), ", "))
+		}
+	}
+	return &PayloadSelection{Provider: provider, Categories: categories, Replace: replace}, nil
+}
+
+// Selected returns true if a category is selected. A nil selection selects all categories.
+func (s *PayloadSelection) Selected(category string) bool {
+	if s == nil || len(s.Categories) == 0 {
+		return true
+	}
+	for _, selected := range s.Categories {
+		if selected == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Payloads returns the payloads of a category: none if it is not selected, else the built-in
+// payloads and the payloads of the provider, without those specific to a database excluded by
+// the technology stack, transformed by the tamper chain. A nil selection returns the built-in
+// payloads.
+func (s *PayloadSelection) Payloads(category string, builtin []string) []string {
+	if !s.Selected(category) {
+		return nil
+	}
+	if s == nil {
+		return builtin
+	}
+	var payloads []string
+	if s.Provider == nil {
+		payloads = builtin
+	} else if provided := s.Provider.Payloads(category); len(provided) > 0 && s.Replace {
+		payloads = provided
+	} else {
+		payloads = append(append([]string{}, builtin...), provided...)
+	}
+	if s.Technologies != nil {
+		compatible := make([]string, 0, len(payloads))
+		for _, payload := range payloads {
+			if !s.Technologies.Excludes(payloadTechnology(category, payload)) {
+				compatible = append(compatible, payload)
+			}
+		}
+		payloads = compatible
+	}
+	if s.tampered == nil {
+		return payloads
+	}
+	tampered := make([]string, len(payloads))
+	for i, payload := range payloads {
+		if tampered[i] = s.tampered[payload]; tampered[i] == "" {
+			tampered[i] = payload
+		}
+	}
+	return tampered
+}
+
+// prepare transforms the built-in payloads and the payloads of the provider with the tamper
+// chain of the selection, once before the payloads are sent
+func (s *PayloadSelection) prepare(builtin *PayloadSet) error {
+	if s == nil || s.Tamper == nil {
+		return nil
+	}
+	payloads := make([]string, 0)
+	seen := make(map[string]bool)
+	for category := range PayloadCategories {
+		sources := builtin.Payloads(category)
+		if s.Provider != nil {
+			sources = append(append([]string{}, sources...), s.Provider.Payloads(category)...)
+		}
+		for _, payload := range sources {
+			if !seen[payload] {
+				seen[payload] = true
+				payloads = append(payloads, payload)
+			}
+		}
+	}
+	sort.Strings(payloads)
+	tampered, err := s.Tamper.Tamper(payloads)
+	if err != nil {
+		return err
+	}
+	s.tampered = make(map[string]string, len(payloads))
+	for i, payload := range payloads {
+		s.tampered[payload] = tampered[i]
+	}
+	return nil
+}
+
+// PayloadConsumer is implemented by the testers whose payloads are selected by a
+// PayloadSelection
+type PayloadConsumer interface {
+	// SetPayloads sets the selection of the payloads of the tester, nil for the built-in
+	// payloads
+	SetPayloads(selection *PayloadSelection)
+}
+
+// LoadConfiguredPayloads loads the payloads of the config and returns their selection, or nil
+// if the config selects the built-in payloads
+func LoadConfiguredPayloads(config *ffuf.Config) (*PayloadSelection, error) {
+	if len(config.APISecurityPayloads) == 0 && len(config.APISecurityPayloadCategories) == 0 && len(config.APISecurityTamper) == 0 {
+		return nil, nil
+	}
+	var provider PayloadProvider
+	if len(config.APISecurityPayloads) > 0 {
+		set := NewPayloadSet()
+		for _, source := range config.APISecurityPayloads {
+			loaded, err := LoadPayloads(source)
+			if err != nil {
+				return nil, err
+			}
+			set.Merge(loaded)
+		}
+		provider = set
+	}
+	selection, err := NewPayloadSelection(provider, config.APISecurityPayloadCategories, config.APISecurityPayloadsReplace)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.APISecurityTamper) > 0 {
+		if selection.Tamper, err = payload.NewTamperChain(config.APISecurityTamper); err != nil {
+			return nil, err
+		}
+	}
+	return selection, nil
+}
+
+// rawPayload returns true if a payload is a JSON object or array, inserted into JSON bodies
+// as-is instead of as a string value
+func rawPayload(payload string) bool {
+	trimmed := strings.TrimSpace(payload)
+	return (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed))
+}
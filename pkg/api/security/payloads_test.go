@@ -0,0 +1,106 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestLoadPayloads(t *testing.T) {
+	var mu sync.Mutex
+	values := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/feed.txt" {
+			w.Write([]byte("# custom payloads\n[sqli-error]\nffuf' OR '1\n"))
+			return
+		}
+		q := r.URL.Query().Get("q")
+		mu.Lock()
+		values = append(values, q)
+		mu.Unlock()
+		if strings.Contains(q, "ffuf") {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("You have an error in your SQL syntax"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// Payloads are tagged by the category line of a feed, or by the name of a file
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sqli-error.txt"), []byte("ffuf'/*\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	set, err := LoadPayloads(dir)
+	if err != nil || len(set.Payloads(PayloadSQLiError)) != 1 {
+		t.Fatalf("Expected the payload of the file to be tagged sqli-error, got %v (%v)", set.Categories(), err)
+	}
+	if _, err := ParsePayloads([]byte("' OR 1=1--\n"), nil); err == nil || !strings.Contains(err.Error(), "has no category") {
+		t.Errorf("Expected an error for a payload without category, got %v", err)
+	}
+	if _, err := ParsePayloads([]byte("[sqli]\n' OR 1=1--\n"), nil); err == nil || !strings.Contains(err.Error(), "unknown payload category sqli") {
+		t.Errorf("Expected an error for an unknown category, got %v", err)
+	}
+
+	// Only the selected category is sent, with the custom payloads replacing the built-in ones
+	registry := NewSecurityTestRegistry()
+	registry.Register(NewInjectionTester())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = server.URL + "/search?q=test"
+	conf.Threads = 2
+	conf.APISecurityPayloads = []string{dir, server.URL + "/feed.txt"}
+	conf.APISecurityPayloadCategories = []string{PayloadSQLiError}
+	conf.APISecurityPayloadsReplace = true
+	results, err := registry.RunAll(ctx, &conf)
+	if err != nil {
+		t.Fatalf("RunAll returned an error: %s", err)
+	}
+	if len(results) != 1 || len(results[0].Vulnerabilities) == 0 || results[0].Vulnerabilities[0].Name != "SQL Injection" {
+		t.Fatalf("Expected SQL injections found with the custom payloads, got %+v", results)
+	}
+	// The values other than payloads are the original and control values
+	for _, value := range values {
+		if value != "test" && value != "1" && !strings.Contains(value, "ffuf") {
+			t.Errorf("Expected only the custom payloads to be sent, got %q", value)
+		}
+	}
+
+	// The payloads are transformed by the tamper chain before they are sent
+	values = values[:0]
+	conf.APISecurityPayloads = []string{server.URL + "/feed.txt"}
+	conf.APISecurityTamper = []string{"space2comment"}
+	if results, err = registry.RunAll(ctx, &conf); err != nil || len(results[0].Vulnerabilities) == 0 {
+		t.Fatalf("Expected SQL injections found with the tampered payloads, got %+v (%v)", results, err)
+	}
+	tampered := false
+	for _, value := range values {
+		if strings.Contains(value, "ffuf' OR") {
+			t.Errorf("Expected the spaces of the payloads to be replaced, got %q", value)
+		}
+		tampered = tampered || value == "ffuf'/**/OR/**/'1"
+	}
+	if !tampered {
+		t.Errorf("Expected the tampered payload to be sent, got %q", values)
+	}
+	conf.APISecurityTamper = []string{"rot13"}
+	if _, err := registry.RunAll(ctx, &conf); err == nil || !strings.Contains(err.Error(), "Unknown tamper") {
+		t.Errorf("Expected an unknown tamper error, got %v", err)
+	}
+
+	conf.APISecurityTamper = nil
+	conf.APISecurityPayloadCategories = []string{"sqli"}
+	if _, err := registry.RunAll(ctx, &conf); err == nil || !strings.Contains(err.Error(), "unknown payload category") {
+		t.Errorf("Expected an unknown payload category error, got %v", err)
+	}
+}
@@ -0,0 +1,306 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// vulnerabilityTypeNames are the names of the vulnerability types used to select testers
+var vulnerabilityTypeNames = map[VulnerabilityType]string{
+	VulnBrokenObjectLevelAuth:   "bola",
+	VulnBrokenAuth:              "broken-auth",
+	VulnExcessiveDataExposure:   "data-exposure",
+	VulnLackOfResources:         "resource-consumption",
+	VulnBrokenFunctionLevelAuth: "function-auth",
+	VulnMassAssignment:          "mass-assignment",
+	VulnSecurityMisconfig:       "misconfig",
+	VulnInjection:               "injection",
+	VulnImproperAssetsMgmt:      "assets-mgmt",
+	VulnInsufficientLogging:     "logging",
+	VulnSSRF:                    "ssrf",
+	VulnContentNegotiation:      "content-negotiation",
+	VulnHeaderAttack:            "header-attacks",
+	VulnSecretLeakage:           "secrets",
+	VulnPaginationAbuse:         "pagination",
+	VulnGraphQL:                 "graphql",
+	VulnWebSocket:               "websocket",
+	VulnErrorDisclosure:         "error-disclosure",
+}
+
+// String returns the name of the vulnerability type
+func (v VulnerabilityType) String() string {
+	if name, ok := vulnerabilityTypeNames[v]; ok {
+		return name
+	}
+	return strconv.Itoa(int(v))
+}
+
+// ParseVulnerabilityType parses a vulnerability type from its name or number
+func ParseVulnerabilityType(name string) (VulnerabilityType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for vulnType, typeName := range vulnerabilityTypeNames {
+		if typeName == name {
+			return vulnType, nil
+		}
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		if _, ok := vulnerabilityTypeNames[VulnerabilityType(n)]; ok {
+			return VulnerabilityType(n), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown vulnerability type: %s", name)
+}
+
+// ScanProfile is a named set of vulnerability types to test for
+type ScanProfile struct {
+	Name        string
+	Description string
+	Types       []VulnerabilityType
+}
+
+// ScanProfiles are the available scan profiles
+var ScanProfiles = map[string]ScanProfile{
+	"all": {
+		Name:        "all",
+		Description: "Run all registered testers",
+	},
+	"quick": {
+		Name:        "quick",
+		Description: "Run the testers sending few requests",
+		Types: []VulnerabilityType{
+			VulnBrokenAuth,
+			VulnExcessiveDataExposure,
+			VulnSecurityMisconfig,
+			VulnImproperAssetsMgmt,
+		},
+	},
+	"owasp-top10": {
+		Name:        "owasp-top10",
+		Description: "Run the testers of the OWASP API Security Top 10 (2019)",
+		Types: []VulnerabilityType{
+			VulnBrokenObjectLevelAuth,
+			VulnBrokenAuth,
+			VulnExcessiveDataExposure,
+			VulnLackOfResources,
+			VulnBrokenFunctionLevelAuth,
+			VulnMassAssignment,
+			VulnSecurityMisconfig,
+			VulnInjection,
+			VulnImproperAssetsMgmt,
+			VulnInsufficientLogging,
+		},
+	},
+	"injection-only": {
+		Name:        "injection-only",
+		Description: "Run the injection tester only",
+		Types:       []VulnerabilityType{VulnInjection},
+	},
+}
+
+// destructiveTypes are the vulnerability types whose testers flood the target with requests,
+// which are not run in safe mode
+var destructiveTypes = map[VulnerabilityType]bool{
+	VulnLackOfResources: true,
+}
+
+// Select returns copies of the registered testers of a profile, with the testers of the
+// included vulnerability types added and the testers of the excluded types removed. All testers
+// are selected if neither a profile nor included types are given. The testers are copied for
+// each scan, so that the options applied to them do not change the registered testers.
+func (r *SecurityTestRegistry) Select(profile string, include, exclude []string) ([]SecurityTester, error) {
+	selected := make(map[VulnerabilityType]bool)
+
+	switch {
+	case profile != "":
+		p, ok := ScanProfiles[strings.ToLower(profile)]
+		if !ok {
+			return nil, fmt.Errorf("unknown scan profile: %s, valid profiles are: %s", profile, strings.Join(scanProfileNames(), ", "))
+		}
+		if p.Types == nil {
+			for vulnType := range r.testers {
+				selected[vulnType] = true
+			}
+		}
+		for _, vulnType := range p.Types {
+			selected[vulnType] = true
+		}
+	case len(include) == 0:
+		for vulnType := range r.testers {
+			selected[vulnType] = true
+		}
+	}
+
+	for _, name := range include {
+		vulnType, err := ParseVulnerabilityType(name)
+		if err != nil {
+			return nil, err
+		}
+		selected[vulnType] = true
+	}
+	for _, name := range exclude {
+		vulnType, err := ParseVulnerabilityType(name)
+		if err != nil {
+			return nil, err
+		}
+		delete(selected, vulnType)
+	}
+
+	var testers []SecurityTester
+	for vulnType := range selected {
+		if tester, ok := r.testers[vulnType]; ok {
+			testers = append(testers, copyTester(tester))
+		}
+	}
+	sort.Slice(testers, func(i, j int) bool {
+		return testers[i].GetType() < testers[j].GetType()
+	})
+	return testers, nil
+}
+
+// RunConfigured runs the testers selected by the security options of the config, after
+// applying the per-tester option overrides
+func (r *SecurityTestRegistry) RunConfigured(ctx context.Context, config *ffuf.Config) ([]*TestResult, error) {
+	testers, err := r.Select(config.APISecurityProfile, config.APISecurityInclude, config.APISecurityExclude)
+	if err != nil {
+		return nil, err
+	}
+	testers = safeTesters(config, testers)
+	for _, option := range config.APISecurityOptions {
+		if err := ApplyTesterOption(testers, option); err != nil {
+			return nil, err
+		}
+	}
+	return runTesters(ctx, config, testers)
+}
+
+// safeTesters returns the testers allowed by the safe mode of the config, skipping the
+// destructive ones
+func safeTesters(config *ffuf.Config, testers []SecurityTester) []SecurityTester {
+	if config.SafeMode == nil {
+		return testers
+	}
+	safe := testers[:0]
+	for _, tester := range testers {
+		if destructiveTypes[tester.GetType()] {
+			logger.Info("Safe mode: skipping the tester", "tester", tester.GetName(), logging.FieldTarget, config.Url)
+			continue
+		}
+		safe = append(safe, tester)
+	}
+	return safe
+}
+
+// copyTester returns a shallow copy of a tester whose fields are set by options, or the tester
+// itself if it is not a pointer to a struct
+func copyTester(tester SecurityTester) SecurityTester {
+	v := reflect.ValueOf(tester)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return tester
+	}
+	copied := reflect.New(v.Elem().Type())
+	copied.Elem().Set(v.Elem())
+	return copied.Interface().(SecurityTester)
+}
+
+// ApplyTesterOption applies an option override in the form type.Field=value to the tester of
+// the vulnerability type, e.g. injection.TestTimeBased=false or ssrf.CallbackTimeout=10s.
+// Slice options are given as comma separated values.
+func ApplyTesterOption(testers []SecurityTester, option string) error {
+	parts := strings.SplitN(option, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid tester option: %s, expected type.Field=value", option)
+	}
+	key, value := parts[0], parts[1]
+	parts = strings.SplitN(key, ".", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid tester option: %s, expected type.Field=value", option)
+	}
+	typeName, field := parts[0], parts[1]
+	vulnType, err := ParseVulnerabilityType(typeName)
+	if err != nil {
+		return err
+	}
+
+	for _, tester := range testers {
+		if tester.GetType() == vulnType {
+			return setTesterField(tester, strings.TrimSpace(field), strings.TrimSpace(value))
+		}
+	}
+	return fmt.Errorf("tester option %s does not match a selected tester", option)
+}
+
+// setTesterField sets an exported field of a tester from its string representation
+func setTesterField(tester SecurityTester, name, value string) error {
+	v := reflect.ValueOf(tester)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("tester %s does not support options", tester.GetName())
+	}
+	field := v.Elem().FieldByName(name)
+	if !field.IsValid() || !field.CanSet() {
+		return fmt.Errorf("tester %s has no option %s", tester.GetName(), name)
+	}
+
+	var err error
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		var d time.Duration
+		if d, err = time.ParseDuration(value); err == nil {
+			field.SetInt(int64(d))
+		}
+	case field.Kind() == reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(value); err == nil {
+			field.SetBool(b)
+		}
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() >= reflect.Int && field.Kind() <= reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(value, 10, 64); err == nil {
+			field.SetInt(i)
+		}
+	case field.Kind() == reflect.Float32 || field.Kind() == reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(value, 64); err == nil {
+			field.SetFloat(f)
+		}
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		values := []string{}
+		if value != "" {
+			for _, item := range strings.Split(value, ",") {
+				values = append(values, strings.TrimSpace(item))
+			}
+		}
+		field.Set(reflect.ValueOf(values).Convert(field.Type()))
+	default:
+		return fmt.Errorf("option %s of tester %s cannot be set from the command line", name, tester.GetName())
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value for option %s of tester %s: %w", name, tester.GetName(), err)
+	}
+	return nil
+}
+
+// scanProfileNames returns the sorted names of the scan profiles
+func scanProfileNames() []string {
+	names := make([]string, 0, len(ScanProfiles))
+	for name := range ScanProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunConfiguredSecurityTests runs the security tests selected by the config using the default registry
+func RunConfiguredSecurityTests(ctx context.Context, config *ffuf.Config) ([]*TestResult, error) {
+	return DefaultRegistry.RunConfigured(ctx, config)
+}
@@ -0,0 +1,186 @@
+package security
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// optionTester records the values of its options seen by its test runs
+type optionTester struct {
+	Delay   time.Duration
+	Enabled bool
+	Names   []string
+
+	seen *[]optionTester
+}
+
+func (t *optionTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	*t.seen = append(*t.seen, *t)
+	return &TestResult{TestName: t.GetName()}, nil
+}
+
+func (t *optionTester) GetType() VulnerabilityType { return VulnInjection }
+func (t *optionTester) GetName() string            { return "Option Tester" }
+func (t *optionTester) GetDescription() string     { return "Records its options" }
+
+func TestSecurityTestRegistry_Select(t *testing.T) {
+	for name, test := range map[string]struct {
+		profile          string
+		include, exclude []string
+		expected         []VulnerabilityType
+	}{
+		"all":      {expected: []VulnerabilityType{VulnBrokenAuth, VulnSecurityMisconfig, VulnInjection}},
+		"profile":  {profile: "quick", expected: []VulnerabilityType{VulnBrokenAuth, VulnSecurityMisconfig}},
+		"include":  {include: []string{"injection"}, expected: []VulnerabilityType{VulnInjection}},
+		"combined": {profile: "Quick", include: []string{"8"}, exclude: []string{"broken-auth"}, expected: []VulnerabilityType{VulnSecurityMisconfig, VulnInjection}},
+	} {
+		registry := NewSecurityTestRegistry()
+		registry.Register(NewBrokenAuthTester())
+		registry.Register(NewSecurityMisconfigTester())
+		registry.Register(NewInjectionTester())
+		testers, err := registry.Select(test.profile, test.include, test.exclude)
+		if err != nil {
+			t.Fatalf("%s: Select returned an error: %s", name, err)
+		}
+		if len(testers) != len(test.expected) {
+			t.Fatalf("%s: Expected %d testers, got %d", name, len(test.expected), len(testers))
+		}
+		for i, tester := range testers {
+			if tester.GetType() != test.expected[i] {
+				t.Errorf("%s: Expected tester %d to be %s, got %s", name, i, test.expected[i], tester.GetType())
+			}
+			if registered, _ := registry.Get(tester.GetType()); registered == tester {
+				t.Errorf("%s: Expected a copy of the registered %s tester", name, tester.GetType())
+			}
+		}
+	}
+
+	registry := NewSecurityTestRegistry()
+	if _, err := registry.Select("unknown", nil, nil); err == nil || !strings.Contains(err.Error(), "valid profiles are") {
+		t.Errorf("Expected an unknown profile to fail, got %v", err)
+	}
+	if _, err := registry.Select("", []string{"unknown"}, nil); err == nil {
+		t.Errorf("Expected an unknown vulnerability type to fail")
+	}
+}
+
+func TestApplyTesterOption(t *testing.T) {
+	testers, err := DefaultRegistry.Select("", []string{"injection"}, nil)
+	if err != nil || len(testers) != 1 {
+		t.Fatalf("Expected the injection tester to be selected, got %d: %v", len(testers), err)
+	}
+	for _, option := range []string{"injection.TestTimeBased=false", "injection.TimeBasedDelay=2s", "injection.TimeBasedTrials=5", "injection.Encoders=urlencode, upper"} {
+		if err := ApplyTesterOption(testers, option); err != nil {
+			t.Fatalf("ApplyTesterOption(%s) returned an error: %s", option, err)
+		}
+	}
+	tester := testers[0].(*InjectionTester)
+	if tester.TestTimeBased || tester.TimeBasedDelay != 2*time.Second || tester.TimeBasedTrials != 5 {
+		t.Errorf("Expected the options to be applied, got %v, %s, %d", tester.TestTimeBased, tester.TimeBasedDelay, tester.TimeBasedTrials)
+	}
+	if len(tester.Encoders) != 2 || tester.Encoders[1] != "upper" {
+		t.Errorf("Expected the encoders to be split, got %v", tester.Encoders)
+	}
+
+	// The options of a scan do not change the registered tester
+	registered, _ := DefaultRegistry.Get(VulnInjection)
+	if injection := registered.(*InjectionTester); !injection.TestTimeBased || injection.TimeBasedDelay == 2*time.Second || len(injection.Encoders) > 0 {
+		t.Errorf("Expected the registered tester to keep its options")
+	}
+
+	for option, expected := range map[string]string{
+		"injection":                         "expected type.Field=value",
+		"TestTimeBased=false":               "expected type.Field=value",
+		"unknown.TestTimeBased=false":       "unknown vulnerability type",
+		"ssrf.CallbackTimeout=10s":          "does not match a selected tester",
+		"injection.Unknown=1":               "has no option Unknown",
+		"injection.baseline=1":              "has no option baseline",
+		"injection.TimeBasedTrials=many":    "invalid value for option TimeBasedTrials",
+		"injection.OOB=http://example.com/": "cannot be set from the command line",
+	} {
+		if err := ApplyTesterOption(testers, option); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %s to fail with %q, got %v", option, expected, err)
+		}
+	}
+}
+
+func TestSecurityTestRegistry_RunConfigured(t *testing.T) {
+	var seen []optionTester
+	registry := NewSecurityTestRegistry()
+	registry.Register(&optionTester{Delay: time.Second, Names: []string{"id"}, seen: &seen})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = "https://api.example.com/users"
+	conf.APISecurityOptions = []string{"injection.Delay=3s", "injection.Enabled=true", "injection.Names=id,user_id"}
+	if results, err := registry.RunConfigured(ctx, &conf); err != nil || len(results) != 1 {
+		t.Fatalf("Expected the result of the tester, got %d: %v", len(results), err)
+	}
+	conf.APISecurityOptions = nil
+	if _, err := registry.RunConfigured(ctx, &conf); err != nil {
+		t.Fatalf("RunConfigured returned an error: %s", err)
+	}
+
+	if len(seen) != 2 {
+		t.Fatalf("Expected two runs, got %d", len(seen))
+	}
+	if seen[0].Delay != 3*time.Second || !seen[0].Enabled || len(seen[0].Names) != 2 {
+		t.Errorf("Expected the options to be applied to the first scan, got %+v", seen[0])
+	}
+	if seen[1].Delay != time.Second || seen[1].Enabled || len(seen[1].Names) != 1 {
+		t.Errorf("Expected the options of the first scan not to leak into the second, got %+v", seen[1])
+	}
+
+	conf.APISecurityOptions = []string{"injection.Unknown=1"}
+	if _, err := registry.RunConfigured(ctx, &conf); err == nil {
+		t.Errorf("Expected an invalid option to fail the scan")
+	}
+	if len(seen) != 2 {
+		t.Errorf("Expected the tester not to run with an invalid option")
+	}
+}
+
+// floodTester counts its test runs
+type floodTester struct {
+	runs int
+}
+
+func (t *floodTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	t.runs++
+	return &TestResult{TestName: t.GetName()}, nil
+}
+
+func (t *floodTester) GetType() VulnerabilityType { return VulnLackOfResources }
+func (t *floodTester) GetName() string            { return "Flood Tester" }
+func (t *floodTester) GetDescription() string     { return "Counts its runs" }
+
+func TestSecurityTestRegistry_RunAll(t *testing.T) {
+	var seen []optionTester
+	flood := &floodTester{}
+	registry := NewSecurityTestRegistry()
+	registry.Register(&optionTester{seen: &seen})
+	registry.Register(flood)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = "https://api.example.com/users"
+	if results, err := registry.RunAll(ctx, &conf); err != nil || len(results) != 2 {
+		t.Fatalf("Expected the results of both testers, got %d: %v", len(results), err)
+	}
+	if flood.runs != 0 {
+		t.Errorf("Expected the run to use a copy of the registered tester, got %d runs of it", flood.runs)
+	}
+
+	// The destructive testers are skipped in safe mode
+	conf.SafeMode, _ = ffuf.NewSafeMode(ffuf.SafeModeNonDestructive)
+	results, err := registry.RunAll(ctx, &conf)
+	if err != nil || len(results) != 1 || results[0].TestName != "Option Tester" {
+		t.Fatalf("Expected only the result of the option tester in safe mode, got %d: %v", len(results), err)
+	}
+}
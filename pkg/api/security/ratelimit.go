@@ -4,11 +4,12 @@ package security
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // LackOfResourcesTester implements testing for Lack of Resources & Rate Limiting (API4:2019)
@@ -21,6 +22,30 @@ type LackOfResourcesTester struct {
 	LargePayloadSize     int
 	LargeParameterCount  int
 	LargeParameterLength int
+	PaginationParameters []string
+	PaginationValue      string
+	GraphQLQueryDepth    int
+	// LatencyDegradation is the factor by which the latency must exceed the baseline
+	// latency to be reported as degradation
+	LatencyDegradation float64
+	// ServerErrorRatio is the ratio of 5xx responses in a burst reported as degradation
+	ServerErrorRatio float64
+	// BaselineSamples is the number of requests measuring the baseline latency
+	BaselineSamples int
+	// DegradationSamples is the number of times a single request must degrade the service
+	// to be reported. Bursts must degrade the service in every burst.
+	DegradationSamples int
+}
+
+// resourceStats summarizes the latency and server errors of a set of responses
+type resourceStats struct {
+	Requests      int
+	Successful    int
+	ServerErrors  int
+	RateLimited   int
+	MedianLatency time.Duration
+	MaxLatency    time.Duration
+	MaxSize       int
 }
 
 // NewLackOfResourcesTester creates a new tester for Lack of Resources & Rate Limiting
@@ -33,6 +58,13 @@ func NewLackOfResourcesTester() *LackOfResourcesTester {
 		LargePayloadSize:     1024 * 100, // 100KB
 		LargeParameterCount:  100,
 		LargeParameterLength: 1000,
+		PaginationParameters: []string{"limit", "page_size", "pageSize", "per_page", "perPage", "size", "count", "max", "top", "first"},
+		PaginationValue:      "1000000",
+		GraphQLQueryDepth:    15,
+		LatencyDegradation:   3.0,
+		ServerErrorRatio:     0.1,
+		BaselineSamples:      5,
+		DegradationSamples:   3,
 	}
 }
 
@@ -59,15 +91,21 @@ func (t *LackOfResourcesTester) Test(ctx context.Context, config *ffuf.Config) (
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract potential endpoints from the config
 	endpoints := extractEndpointsFromConfig(config)
 
 	// Test each endpoint for rate limiting vulnerabilities
 	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
+		// Measure the baseline latency to detect degradation
+		baseline := t.measureBaseline(ctx, endpoint, r)
+
 		// Test for lack of rate limiting
-		if t.testRateLimiting(endpoint, r, result) {
+		if t.testRateLimiting(ctx, endpoint, baseline, r, result) {
 			// If rate limiting is missing, add a vulnerability
 			vuln := VulnerabilityInfo{
 				Type:        VulnLackOfResources,
@@ -87,6 +125,14 @@ func (t *LackOfResourcesTester) Test(ctx context.Context, config *ffuf.Config) (
 			result.Vulnerabilities = append(result.Vulnerabilities, vuln)
 		}
 
+		// Test for oversized pagination values
+		t.testLargePagination(ctx, endpoint, baseline, r, result)
+
+		// Test for deeply nested GraphQL queries
+		if strings.Contains(strings.ToLower(endpoint), "graphql") {
+			t.testDeepGraphQLQuery(ctx, endpoint, baseline, r, result)
+		}
+
 		// Test for lack of resource limiting (large payload)
 		if t.testLargePayload(endpoint, r, result) {
 			// If resource limiting is missing, add a vulnerability
@@ -135,19 +181,27 @@ func (t *LackOfResourcesTester) Test(ctx context.Context, config *ffuf.Config) (
 	return result, nil
 }
 
-// testRateLimiting tests if an endpoint implements rate limiting
-func (t *LackOfResourcesTester) testRateLimiting(endpoint string, r ffuf.RunnerProvider, result *TestResult) bool {
+// testRateLimiting tests if an endpoint implements rate limiting. Degradation of the latency
+// or server errors during the bursts are reported as evidence of resource exhaustion.
+func (t *LackOfResourcesTester) testRateLimiting(ctx context.Context, endpoint string, baseline resourceStats, r ffuf.RunnerProvider, result *TestResult) bool {
 	// Track successful requests
 	successfulRequests := 0
 	totalRequests := t.RequestsPerBurst * t.BurstCount
+	var responses []ffuf.Response
+	var bursts []resourceStats
 
 	// Send requests in bursts
 	for burst := 0; burst < t.BurstCount; burst++ {
+		if ctx.Err() != nil {
+			return false
+		}
 		// Send a burst of requests
-		responses := t.sendRequestBurst(endpoint, r, t.RequestsPerBurst, t.ConcurrentRequests)
+		burstResponses := t.sendRequestBurst(ctx, endpoint, r, t.RequestsPerBurst, t.ConcurrentRequests)
+		responses = append(responses, burstResponses...)
+		bursts = append(bursts, newResourceStats(burstResponses))
 
 		// Count successful responses
-		for _, resp := range responses {
+		for _, resp := range burstResponses {
 			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 				successfulRequests++
 			} else if resp.StatusCode == 429 {
@@ -157,17 +211,39 @@ func (t *LackOfResourcesTester) testRateLimiting(endpoint string, r ffuf.RunnerP
 		}
 
 		// Wait between bursts
-		if burst < t.BurstCount-1 {
-			time.Sleep(t.TimeBetweenBursts)
+		if burst < t.BurstCount-1 && !sleep(ctx, t.TimeBetweenBursts) {
+			return false
 		}
 	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	// Report degradation of the service under load, if every burst degraded it
+	if degraded, evidence := t.isRepeatedlyDegraded(baseline, bursts, newResourceStats(responses)); degraded {
+		result.Vulnerabilities = append(result.Vulnerabilities, VulnerabilityInfo{
+			Type:        VulnLackOfResources,
+			Name:        "Service Degradation Under Load",
+			Description: "The API endpoint degrades under concurrent bursts of requests.",
+			Severity:    "High",
+			Evidence:    fmt.Sprintf("%d concurrent requests in %d bursts: %s in every burst", totalRequests, t.BurstCount, evidence),
+			Remediation: "Implement proper rate limiting and limit the number of concurrent requests per client. Scale the resources of the service or queue expensive operations.",
+			CVSS:        7.5,
+			CWE:         "CWE-770",
+			References: []string{
+				"https://owasp.org/API-Security/editions/2019/en/0xa4-lack-of-resources-and-rate-limiting/",
+				"https://cheatsheetseries.owasp.org/cheatsheets/Denial_of_Service_Cheat_Sheet.html",
+			},
+			DetectedAt: time.Now(),
+		})
+	}
 
 	// If most requests were successful, rate limiting is likely missing
 	return float64(successfulRequests) / float64(totalRequests) > 0.8
 }
 
 // sendRequestBurst sends a burst of requests to an endpoint
-func (t *LackOfResourcesTester) sendRequestBurst(endpoint string, r ffuf.RunnerProvider, count, concurrent int) []ffuf.Response {
+func (t *LackOfResourcesTester) sendRequestBurst(ctx context.Context, endpoint string, r ffuf.RunnerProvider, count, concurrent int) []ffuf.Response {
 	var responses []ffuf.Response
 	var mutex sync.Mutex
 	var wg sync.WaitGroup
@@ -176,6 +252,9 @@ func (t *LackOfResourcesTester) sendRequestBurst(endpoint string, r ffuf.RunnerP
 	semaphore := make(chan struct{}, concurrent)
 
 	for i := 0; i < count; i++ {
+		if ctx.Err() != nil {
+			break
+		}
 		wg.Add(1)
 		semaphore <- struct{}{} // Acquire a slot
 
@@ -277,6 +356,206 @@ func (t *LackOfResourcesTester) testManyParameters(endpoint string, r ffuf.Runne
 	return resp.StatusCode >= 200 && resp.StatusCode < 300
 }
 
+// testLargePagination tests if an endpoint limits the page size of its results
+func (t *LackOfResourcesTester) testLargePagination(ctx context.Context, endpoint string, baseline resourceStats, r ffuf.RunnerProvider, result *TestResult) {
+	for _, paramName := range t.PaginationParameters {
+		if ctx.Err() != nil {
+			return
+		}
+		req := &ffuf.Request{
+			Method:  "GET",
+			Url:     addOrReplaceParameter(endpoint, paramName, t.PaginationValue),
+			Headers: map[string]string{},
+		}
+
+		// Execute the request
+		resp, err := r.Execute(req)
+		if err != nil {
+			continue
+		}
+
+		stats := newResourceStats([]ffuf.Response{resp})
+		degraded, evidence := t.confirmDegraded(ctx, baseline, req, resp, r)
+		if !degraded && resp.StatusCode >= 200 && resp.StatusCode < 300 && baseline.MaxSize > 0 && stats.MaxSize >= baseline.MaxSize*10 {
+			degraded = true
+			evidence = fmt.Sprintf("response size increased from %d to %d bytes", baseline.MaxSize, stats.MaxSize)
+		}
+		if !degraded {
+			continue
+		}
+
+		vuln := VulnerabilityInfo{
+			Type:        VulnLackOfResources,
+			Name:        "Missing Resource Limiting (Pagination)",
+			Description: "The API endpoint does not limit the number of records returned per page.",
+			Severity:    "Medium",
+			Request:     convertToHTTPRequest(req),
+			Response:    convertToHTTPResponse(resp),
+			Evidence:    fmt.Sprintf("Parameter '%s=%s': %s", paramName, t.PaginationValue, evidence),
+			Remediation: "Define and enforce a maximum page size on the server side. Validate pagination parameters and reject values above the limit.",
+			CVSS:        5.3,
+			CWE:         "CWE-770",
+			References: []string{
+				"https://owasp.org/API-Security/editions/2019/en/0xa4-lack-of-resources-and-rate-limiting/",
+				"https://cheatsheetseries.owasp.org/cheatsheets/Denial_of_Service_Cheat_Sheet.html",
+			},
+			DetectedAt: time.Now(),
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, vuln)
+		return
+	}
+}
+
+// testDeepGraphQLQuery tests if a GraphQL endpoint limits the depth of queries
+func (t *LackOfResourcesTester) testDeepGraphQLQuery(ctx context.Context, endpoint string, baseline resourceStats, r ffuf.RunnerProvider, result *TestResult) {
+	// Nest the introspection types to build a query of the configured depth
+	query := nestedIntrospectionQuery(t.GraphQLQueryDepth)
+
+	req := &ffuf.Request{
+		Method: "POST",
+		Url:    endpoint,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Data: []byte(fmt.Sprintf(`{"query":%q}`, query)),
+	}
+
+	// Execute the request
+	resp, err := r.Execute(req)
+	if err != nil {
+		return
+	}
+
+	// A depth limit is reported as a GraphQL error
+	body := strings.ToLower(string(resp.Data))
+	if strings.Contains(body, "depth") || strings.Contains(body, "complexity") {
+		return
+	}
+
+	degraded, evidence := t.confirmDegraded(ctx, baseline, req, resp, r)
+	accepted := resp.StatusCode >= 200 && resp.StatusCode < 300 && !strings.Contains(body, `"errors"`)
+	if !degraded && !accepted {
+		return
+	}
+	if !degraded {
+		evidence = fmt.Sprintf("query was accepted in %s", resp.Duration)
+	}
+
+	vuln := VulnerabilityInfo{
+		Type:        VulnLackOfResources,
+		Name:        "Missing GraphQL Query Depth Limiting",
+		Description: "The GraphQL endpoint executes deeply nested queries.",
+		Severity:    "Medium",
+		Request:     convertToHTTPRequest(req),
+		Response:    convertToHTTPResponse(resp),
+		Evidence:    fmt.Sprintf("Query with depth %d: %s", t.GraphQLQueryDepth, evidence),
+		Remediation: "Limit the depth and complexity of GraphQL queries. Consider query cost analysis, timeouts and persisted queries.",
+		CVSS:        5.3,
+		CWE:         "CWE-400",
+		References: []string{
+			"https://owasp.org/API-Security/editions/2019/en/0xa4-lack-of-resources-and-rate-limiting/",
+			"https://cheatsheetseries.owasp.org/cheatsheets/GraphQL_Cheat_Sheet.html",
+		},
+		DetectedAt: time.Now(),
+	}
+	result.Vulnerabilities = append(result.Vulnerabilities, vuln)
+}
+
+// measureBaseline measures the latency of an endpoint without load
+func (t *LackOfResourcesTester) measureBaseline(ctx context.Context, endpoint string, r ffuf.RunnerProvider) resourceStats {
+	var responses []ffuf.Response
+	for i := 0; i < t.BaselineSamples; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		resp, err := r.Execute(&ffuf.Request{Method: "GET", Url: endpoint, Headers: map[string]string{}})
+		if err != nil {
+			continue
+		}
+		responses = append(responses, resp)
+	}
+	return newResourceStats(responses)
+}
+
+// isDegraded checks if the latency or server errors of a set of responses exceed the baseline
+func (t *LackOfResourcesTester) isDegraded(baseline, stats resourceStats) (bool, string) {
+	if stats.Requests == 0 {
+		return false, ""
+	}
+	if ratio := float64(stats.ServerErrors) / float64(stats.Requests); ratio >= t.ServerErrorRatio && baseline.ServerErrors == 0 {
+		return true, fmt.Sprintf("%d of %d responses were server errors", stats.ServerErrors, stats.Requests)
+	}
+	if baseline.MedianLatency > 0 && float64(stats.MedianLatency) >= float64(baseline.MedianLatency)*t.LatencyDegradation {
+		return true, fmt.Sprintf("median latency increased from %s to %s (max %s)", baseline.MedianLatency, stats.MedianLatency, stats.MaxLatency)
+	}
+	return false, ""
+}
+
+// confirmDegraded checks if a request degrades the service, sending it again until it did
+// DegradationSamples times, so that a single slow response is not reported
+func (t *LackOfResourcesTester) confirmDegraded(ctx context.Context, baseline resourceStats, req *ffuf.Request, resp ffuf.Response, r ffuf.RunnerProvider) (bool, string) {
+	samples := []resourceStats{newResourceStats([]ffuf.Response{resp})}
+	responses := []ffuf.Response{resp}
+	for len(samples) < t.DegradationSamples {
+		if degraded, _ := t.isDegraded(baseline, samples[len(samples)-1]); !degraded || ctx.Err() != nil {
+			return false, ""
+		}
+		resp, err := r.Execute(req)
+		if err != nil {
+			return false, ""
+		}
+		samples = append(samples, newResourceStats([]ffuf.Response{resp}))
+		responses = append(responses, resp)
+	}
+	degraded, evidence := t.isRepeatedlyDegraded(baseline, samples, newResourceStats(responses))
+	if !degraded {
+		return false, ""
+	}
+	return true, fmt.Sprintf("%s in %d of %d requests", evidence, len(samples), len(samples))
+}
+
+// isRepeatedlyDegraded checks if every sample of a set of responses is degraded, and returns
+// the evidence of the degradation of all the responses
+func (t *LackOfResourcesTester) isRepeatedlyDegraded(baseline resourceStats, samples []resourceStats, all resourceStats) (bool, string) {
+	if len(samples) == 0 {
+		return false, ""
+	}
+	for _, sample := range samples {
+		if degraded, _ := t.isDegraded(baseline, sample); !degraded {
+			return false, ""
+		}
+	}
+	return t.isDegraded(baseline, all)
+}
+
+// newResourceStats summarizes a set of responses
+func newResourceStats(responses []ffuf.Response) resourceStats {
+	stats := resourceStats{Requests: len(responses)}
+	latencies := make([]time.Duration, 0, len(responses))
+	for _, resp := range responses {
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			stats.Successful++
+		case resp.StatusCode == 429:
+			stats.RateLimited++
+		case resp.StatusCode >= 500:
+			stats.ServerErrors++
+		}
+		latencies = append(latencies, resp.Duration)
+		if resp.Duration > stats.MaxLatency {
+			stats.MaxLatency = resp.Duration
+		}
+		if len(resp.Data) > stats.MaxSize {
+			stats.MaxSize = len(resp.Data)
+		}
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		stats.MedianLatency = latencies[len(latencies)/2]
+	}
+	return stats
+}
+
 func init() {
 	// Register the tester with the default registry
 	RegisterSecurityTester(NewLackOfResourcesTester())
@@ -0,0 +1,124 @@
+package security
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// newBurstTester returns a resource consumption tester sending small bursts without waiting
+func newBurstTester() *LackOfResourcesTester {
+	tester := NewLackOfResourcesTester()
+	tester.RequestsPerBurst = 4
+	tester.ConcurrentRequests = 2
+	tester.TimeBetweenBursts = 0
+	tester.PaginationParameters = []string{"limit"}
+	return tester
+}
+
+// findingsNamed returns the evidence of the findings of a result with a name
+func findingsNamed(result *TestResult, name string) []string {
+	var evidence []string
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Name == name {
+			evidence = append(evidence, vuln.Evidence)
+		}
+	}
+	return evidence
+}
+
+func TestLackOfResourcesTester_BurstDegradation(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/reports")
+
+	// slowBursts answers the requests of the bursts selected by slow in a second
+	slowBursts := func(slow func(burst int) bool) func(req *ffuf.Request) ffuf.Response {
+		var mu sync.Mutex
+		sent := 0
+		return func(req *ffuf.Request) ffuf.Response {
+			resp := ffuf.Response{Data: []byte(`{"reports":[]}`), Duration: 100 * time.Millisecond}
+			if _, ok := req.Headers["X-Test-ID"]; ok {
+				mu.Lock()
+				burst := sent / 4
+				sent++
+				mu.Unlock()
+				if slow(burst) {
+					resp.Duration = time.Second
+				}
+			}
+			return resp
+		}
+	}
+
+	// A single slow burst is not reported
+	result, _ := runFake(t, newBurstTester(), conf, slowBursts(func(burst int) bool { return burst == 1 }))
+	if evidence := findingsNamed(result, "Service Degradation Under Load"); len(evidence) != 0 {
+		t.Errorf("Expected a single slow burst not to be reported, got %v", evidence)
+	}
+
+	// Every burst is slow
+	result, _ = runFake(t, newBurstTester(), conf, slowBursts(func(burst int) bool { return true }))
+	evidence := findingsNamed(result, "Service Degradation Under Load")
+	if len(evidence) != 1 || !strings.Contains(evidence[0], "median latency increased from 100ms to 1s (max 1s) in every burst") {
+		t.Errorf("Expected the degradation of every burst to be reported, got %v", evidence)
+	}
+}
+
+func TestLackOfResourcesTester_PaginationDegradation(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/reports")
+
+	// slowPages answers the first slow requests with a page size in a second
+	slowPages := func(slow int) func(req *ffuf.Request) ffuf.Response {
+		pages := 0
+		return func(req *ffuf.Request) ffuf.Response {
+			resp := ffuf.Response{Data: []byte(`{"reports":[]}`), Duration: 100 * time.Millisecond}
+			if strings.Contains(req.Url, "limit=") {
+				if pages < slow {
+					resp.Duration = time.Second
+				}
+				pages++
+			}
+			return resp
+		}
+	}
+
+	// A slow response that is not repeated is not reported
+	for _, slow := range []int{1, 2} {
+		result, runner := runFake(t, newBurstTester(), conf, slowPages(slow))
+		if evidence := findingsNamed(result, "Missing Resource Limiting (Pagination)"); len(evidence) != 0 {
+			t.Errorf("Expected %d slow responses not to be reported, got %v", slow, evidence)
+		}
+		pages := 0
+		for _, req := range runner.Requests() {
+			if strings.Contains(req.Url, "limit=") {
+				pages++
+			}
+		}
+		if pages != slow+1 {
+			t.Errorf("Expected the page request to be sent again until it is fast, got %d requests", pages)
+		}
+	}
+
+	// Every response is slow
+	result, _ := runFake(t, newBurstTester(), conf, slowPages(3))
+	evidence := findingsNamed(result, "Missing Resource Limiting (Pagination)")
+	if len(evidence) != 1 || !strings.Contains(evidence[0], "median latency increased from 100ms to 1s (max 1s) in 3 of 3 requests") {
+		t.Errorf("Expected the repeated degradation to be reported, got %v", evidence)
+	}
+
+	// Server errors must also repeat
+	errors := 0
+	result, _ = runFake(t, newBurstTester(), conf, func(req *ffuf.Request) ffuf.Response {
+		if strings.Contains(req.Url, "limit=") {
+			if errors++; errors == 1 {
+				return ffuf.Response{StatusCode: 500}
+			}
+		}
+		return ffuf.Response{Data: []byte(`{"reports":[]}`)}
+	})
+	if evidence := findingsNamed(result, "Missing Resource Limiting (Pagination)"); len(evidence) != 0 {
+		t.Errorf("Expected a single server error not to be reported, got %v", evidence)
+	}
+}
@@ -8,8 +8,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // RateLimitBypassTester implements testing for rate limiting bypass techniques
@@ -61,22 +61,28 @@ func (t *RateLimitBypassTester) Test(ctx context.Context, config *ffuf.Config) (
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract potential endpoints from the config
 	endpoints := extractEndpointsFromConfig(config)
 
 	// Test each endpoint for rate limiting bypass vulnerabilities
 	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
 		// First, check if the endpoint has rate limiting
-		if !t.hasRateLimiting(endpoint, r) {
+		if !t.hasRateLimiting(ctx, endpoint, r) {
 			// If there's no rate limiting, no need to test bypass techniques
 			continue
 		}
 
 		// Test each bypass technique
 		for _, technique := range t.BypassTechniques {
-			if t.testBypassTechnique(endpoint, technique, r, result) {
+			if ctx.Err() != nil {
+				break
+			}
+			if t.testBypassTechnique(ctx, endpoint, technique, r, result) {
 				// If a bypass technique works, add a vulnerability
 				vuln := VulnerabilityInfo{
 					Type:        VulnLackOfResources,
@@ -104,9 +110,9 @@ func (t *RateLimitBypassTester) Test(ctx context.Context, config *ffuf.Config) (
 }
 
 // hasRateLimiting checks if an endpoint has rate limiting
-func (t *RateLimitBypassTester) hasRateLimiting(endpoint string, r ffuf.RunnerProvider) bool {
+func (t *RateLimitBypassTester) hasRateLimiting(ctx context.Context, endpoint string, r ffuf.RunnerProvider) bool {
 	// Send a burst of requests to trigger rate limiting
-	responses := t.sendRequestBurst(endpoint, r, t.RequestsPerTest, t.ConcurrentRequests, nil)
+	responses := t.sendRequestBurst(ctx, endpoint, r, t.RequestsPerTest, t.ConcurrentRequests, nil)
 
 	// Check if any response has a 429 status code (Too Many Requests)
 	for _, resp := range responses {
@@ -147,37 +153,37 @@ func (t *RateLimitBypassTester) hasRateLimitHeaders(resp ffuf.Response) bool {
 }
 
 // testBypassTechnique tests a specific rate limiting bypass technique
-func (t *RateLimitBypassTester) testBypassTechnique(endpoint, technique string, r ffuf.RunnerProvider, result *TestResult) bool {
+func (t *RateLimitBypassTester) testBypassTechnique(ctx context.Context, endpoint, technique string, r ffuf.RunnerProvider, result *TestResult) bool {
 	switch technique {
 	case "ip-rotation":
-		return t.testIPRotation(endpoint, r)
+		return t.testIPRotation(ctx, endpoint, r)
 	case "header-manipulation":
-		return t.testHeaderManipulation(endpoint, r)
+		return t.testHeaderManipulation(ctx, endpoint, r)
 	case "parameter-pollution":
-		return t.testParameterPollution(endpoint, r)
+		return t.testParameterPollution(ctx, endpoint, r)
 	case "http-method-switching":
-		return t.testHTTPMethodSwitching(endpoint, r)
+		return t.testHTTPMethodSwitching(ctx, endpoint, r)
 	case "distributed-attack":
-		return t.testDistributedAttack(endpoint, r)
+		return t.testDistributedAttack(ctx, endpoint, r)
 	case "cache-manipulation":
-		return t.testCacheManipulation(endpoint, r)
+		return t.testCacheManipulation(ctx, endpoint, r)
 	default:
 		return false
 	}
 }
 
 // testIPRotation tests IP rotation bypass technique
-func (t *RateLimitBypassTester) testIPRotation(endpoint string, r ffuf.RunnerProvider) bool {
+func (t *RateLimitBypassTester) testIPRotation(ctx context.Context, endpoint string, r ffuf.RunnerProvider) bool {
 	// Simulate IP rotation by changing X-Forwarded-For header
 	successfulRequests := 0
 	totalRequests := t.RequestsPerTest
 
 	for i := 0; i < totalRequests; i++ {
-		// Create a request with a different X-Forwarded-For header
-		headers := map[string]string{
-			"X-Forwarded-For": fmt.Sprintf("192.168.1.%d", i%255+1),
-			"X-Real-IP":       fmt.Sprintf("192.168.1.%d", i%255+1),
+		if ctx.Err() != nil {
+			return false
 		}
+		// Create a request with a different client address in the client IP headers
+		headers := payload.CatalogHeaderValues(payload.HeaderClientIP, fmt.Sprintf("192.168.1.%d", i%255+1))
 
 		req := &ffuf.Request{
 			Method:  "GET",
@@ -197,7 +203,9 @@ func (t *RateLimitBypassTester) testIPRotation(endpoint string, r ffuf.RunnerPro
 		}
 
 		// Wait between requests
-		time.Sleep(t.TimeBetweenRequests)
+		if !sleep(ctx, t.TimeBetweenRequests) {
+			return false
+		}
 	}
 
 	// If most requests were successful, the bypass technique works
@@ -205,30 +213,28 @@ func (t *RateLimitBypassTester) testIPRotation(endpoint string, r ffuf.RunnerPro
 }
 
 // testHeaderManipulation tests header manipulation bypass technique
-func (t *RateLimitBypassTester) testHeaderManipulation(endpoint string, r ffuf.RunnerProvider) bool {
+func (t *RateLimitBypassTester) testHeaderManipulation(ctx context.Context, endpoint string, r ffuf.RunnerProvider) bool {
 	// Test various header manipulations
 	headerSets := []map[string]string{
-		{"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"},
+		{"User-Agent": testerUserAgent},
 		{"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1.1 Safari/605.1.15"},
 		{"User-Agent": "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"},
-		{"X-Forwarded-For": "127.0.0.1"},
-		{"X-Forwarded-For": "192.168.1.1"},
-		{"X-Forwarded-For": "10.0.0.1"},
-		{"X-Real-IP": "127.0.0.1"},
-		{"X-Real-IP": "192.168.1.1"},
-		{"X-Real-IP": "10.0.0.1"},
-		{"X-Originating-IP": "127.0.0.1"},
-		{"X-Client-IP": "127.0.0.1"},
-		{"X-Remote-IP": "127.0.0.1"},
-		{"X-Remote-Addr": "127.0.0.1"},
-		{"X-Host": "localhost"},
 		{"Host": "localhost"},
 	}
+	for _, header := range payload.CatalogHeaders(payload.HeaderClientIP) {
+		headerSets = append(headerSets, map[string]string{header.Name: header.Value("127.0.0.1")})
+	}
+	for _, header := range payload.CatalogHeaders(payload.HeaderHost) {
+		headerSets = append(headerSets, map[string]string{header.Name: header.Value("localhost")})
+	}
 
 	successfulRequests := 0
 	totalRequests := len(headerSets)
 
 	for _, headers := range headerSets {
+		if ctx.Err() != nil {
+			return false
+		}
 		req := &ffuf.Request{
 			Method:  "GET",
 			Url:     endpoint,
@@ -247,7 +253,9 @@ func (t *RateLimitBypassTester) testHeaderManipulation(endpoint string, r ffuf.R
 		}
 
 		// Wait between requests
-		time.Sleep(t.TimeBetweenRequests)
+		if !sleep(ctx, t.TimeBetweenRequests) {
+			return false
+		}
 	}
 
 	// If most requests were successful, the bypass technique works
@@ -255,12 +263,15 @@ func (t *RateLimitBypassTester) testHeaderManipulation(endpoint string, r ffuf.R
 }
 
 // testParameterPollution tests parameter pollution bypass technique
-func (t *RateLimitBypassTester) testParameterPollution(endpoint string, r ffuf.RunnerProvider) bool {
+func (t *RateLimitBypassTester) testParameterPollution(ctx context.Context, endpoint string, r ffuf.RunnerProvider) bool {
 	// Add different parameters to each request
 	successfulRequests := 0
 	totalRequests := t.RequestsPerTest
 
 	for i := 0; i < totalRequests; i++ {
+		if ctx.Err() != nil {
+			return false
+		}
 		// Add a unique parameter to the URL
 		separator := "?"
 		if strings.Contains(endpoint, "?") {
@@ -285,7 +296,9 @@ func (t *RateLimitBypassTester) testParameterPollution(endpoint string, r ffuf.R
 		}
 
 		// Wait between requests
-		time.Sleep(t.TimeBetweenRequests)
+		if !sleep(ctx, t.TimeBetweenRequests) {
+			return false
+		}
 	}
 
 	// If most requests were successful, the bypass technique works
@@ -293,7 +306,7 @@ func (t *RateLimitBypassTester) testParameterPollution(endpoint string, r ffuf.R
 }
 
 // testHTTPMethodSwitching tests HTTP method switching bypass technique
-func (t *RateLimitBypassTester) testHTTPMethodSwitching(endpoint string, r ffuf.RunnerProvider) bool {
+func (t *RateLimitBypassTester) testHTTPMethodSwitching(ctx context.Context, endpoint string, r ffuf.RunnerProvider) bool {
 	// Test different HTTP methods
 	methods := []string{"GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS"}
 
@@ -301,6 +314,9 @@ func (t *RateLimitBypassTester) testHTTPMethodSwitching(endpoint string, r ffuf.
 	totalRequests := len(methods) * (t.RequestsPerTest / len(methods))
 
 	for i := 0; i < totalRequests; i++ {
+		if ctx.Err() != nil {
+			return false
+		}
 		method := methods[i%len(methods)]
 
 		req := &ffuf.Request{
@@ -328,7 +344,9 @@ func (t *RateLimitBypassTester) testHTTPMethodSwitching(endpoint string, r ffuf.
 		}
 
 		// Wait between requests
-		time.Sleep(t.TimeBetweenRequests)
+		if !sleep(ctx, t.TimeBetweenRequests) {
+			return false
+		}
 	}
 
 	// If most requests were successful, the bypass technique works
@@ -336,13 +354,13 @@ func (t *RateLimitBypassTester) testHTTPMethodSwitching(endpoint string, r ffuf.
 }
 
 // testDistributedAttack tests distributed attack bypass technique
-func (t *RateLimitBypassTester) testDistributedAttack(endpoint string, r ffuf.RunnerProvider) bool {
+func (t *RateLimitBypassTester) testDistributedAttack(ctx context.Context, endpoint string, r ffuf.RunnerProvider) bool {
 	// Simulate a distributed attack by using different headers for each request
 	successfulRequests := 0
 	totalRequests := t.RequestsPerTest
 
 	// Send requests concurrently
-	responses := t.sendRequestBurst(endpoint, r, totalRequests, t.ConcurrentRequests, func(i int) map[string]string {
+	responses := t.sendRequestBurst(ctx, endpoint, r, totalRequests, t.ConcurrentRequests, func(i int) map[string]string {
 		return map[string]string{
 			"User-Agent":      fmt.Sprintf("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.%d Safari/537.36", i),
 			"X-Forwarded-For": fmt.Sprintf("192.168.%d.%d", (i/255)%255+1, i%255+1),
@@ -363,12 +381,15 @@ func (t *RateLimitBypassTester) testDistributedAttack(endpoint string, r ffuf.Ru
 }
 
 // testCacheManipulation tests cache manipulation bypass technique
-func (t *RateLimitBypassTester) testCacheManipulation(endpoint string, r ffuf.RunnerProvider) bool {
+func (t *RateLimitBypassTester) testCacheManipulation(ctx context.Context, endpoint string, r ffuf.RunnerProvider) bool {
 	// Test cache manipulation by adding cache-busting parameters
 	successfulRequests := 0
 	totalRequests := t.RequestsPerTest
 
 	for i := 0; i < totalRequests; i++ {
+		if ctx.Err() != nil {
+			return false
+		}
 		// Add a cache-busting parameter to the URL
 		separator := "?"
 		if strings.Contains(endpoint, "?") {
@@ -397,7 +418,9 @@ func (t *RateLimitBypassTester) testCacheManipulation(endpoint string, r ffuf.Ru
 		}
 
 		// Wait between requests
-		time.Sleep(t.TimeBetweenRequests)
+		if !sleep(ctx, t.TimeBetweenRequests) {
+			return false
+		}
 	}
 
 	// If most requests were successful, the bypass technique works
@@ -405,7 +428,7 @@ func (t *RateLimitBypassTester) testCacheManipulation(endpoint string, r ffuf.Ru
 }
 
 // sendRequestBurst sends a burst of requests to an endpoint
-func (t *RateLimitBypassTester) sendRequestBurst(endpoint string, r ffuf.RunnerProvider, count, concurrent int, headerFunc func(int) map[string]string) []ffuf.Response {
+func (t *RateLimitBypassTester) sendRequestBurst(ctx context.Context, endpoint string, r ffuf.RunnerProvider, count, concurrent int, headerFunc func(int) map[string]string) []ffuf.Response {
 	var responses []ffuf.Response
 	var mutex sync.Mutex
 	var wg sync.WaitGroup
@@ -414,6 +437,9 @@ func (t *RateLimitBypassTester) sendRequestBurst(endpoint string, r ffuf.RunnerP
 	semaphore := make(chan struct{}, concurrent)
 
 	for i := 0; i < count; i++ {
+		if ctx.Err() != nil {
+			break
+		}
 		wg.Add(1)
 		semaphore <- struct{}{} // Acquire a slot
 
@@ -453,7 +479,7 @@ func (t *RateLimitBypassTester) sendRequestBurst(endpoint string, r ffuf.RunnerP
 			mutex.Unlock()
 
 			// Wait between requests
-			time.Sleep(t.TimeBetweenRequests)
+			sleep(ctx, t.TimeBetweenRequests)
 		}(i)
 	}
 
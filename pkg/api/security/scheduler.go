@@ -0,0 +1,274 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+// logger is the logger of the security testers
+var logger = logging.For("security")
+
+// schedulerKey is the context key of the scheduler shared by the testers of a scan
+type schedulerKey struct{}
+
+// workerPoolKey is the context key of the worker pool shared by the endpoints of a scan
+type workerPoolKey struct{}
+
+// Scheduler dispatches the requests of security testers through a bounded worker pool.
+// It implements ffuf.RunnerProvider, so testers use it in place of a runner. At most
+// config.Threads requests are in flight at once, granted round-robin across the endpoints
+// of the requests, requests to each host are throttled to config.Rate requests per second if
+// set, and requests fail once the context is cancelled, aborting the requests in flight.
+// Requests to ws:// and wss:// URLs are executed as WebSocket handshakes. The headers of the
+// decoration of the scheduler are set on the requests.
+type Scheduler struct {
+	ctx        context.Context
+	config     *ffuf.Config
+	runner     ffuf.RunnerProvider
+	websocket  ffuf.RunnerProvider
+	pool       *WorkerPool
+	ownPool    bool
+	handler    RequestHandler
+	skipped    *int64
+	waf        *WAFDetection
+	blocked    *int64
+	failed     *int64
+	decoration RequestDecoration
+}
+
+// WorkerPool is the worker budget and the rate throttles of the hosts shared by the
+// schedulers of the endpoints scanned at once
+type WorkerPool struct {
+	config    *ffuf.Config
+	workers   *runner.WorkerBudget
+	throttles map[string]*ffuf.RateThrottle
+	mu        sync.Mutex
+}
+
+// NewWorkerPool creates a worker pool of config.Threads workers, throttling the requests to
+// each host to config.Rate requests per second if set
+func NewWorkerPool(config *ffuf.Config) *WorkerPool {
+	return &WorkerPool{
+		config:    config,
+		workers:   runner.NewWorkerBudget(config.Threads),
+		throttles: make(map[string]*ffuf.RateThrottle),
+	}
+}
+
+// WithWorkerPool returns a context that makes the schedulers created with it share the pool
+func WithWorkerPool(ctx context.Context, pool *WorkerPool) context.Context {
+	return context.WithValue(ctx, workerPoolKey{}, pool)
+}
+
+// NewScheduler creates a new scheduler for the given config, using the worker pool of the
+// context or a pool of its own
+func NewScheduler(ctx context.Context, config *ffuf.Config) *Scheduler {
+	handler, _ := ctx.Value(requestHandlerKey{}).(RequestHandler)
+	pool, shared := ctx.Value(workerPoolKey{}).(*WorkerPool)
+	if !shared {
+		pool = NewWorkerPool(config)
+	}
+	return &Scheduler{
+		ctx:       ctx,
+		config:    config,
+		runner:    runner.NewSimpleRunner(config, false),
+		websocket: runner.NewWebSocketRunner(config),
+		pool:      pool,
+		ownPool:   !shared,
+		handler:   handler,
+		skipped:   new(int64),
+		blocked:   new(int64),
+		failed:    new(int64),
+	}
+}
+
+// withContext returns a scheduler sharing the worker pool, the runners and the decoration of
+// the scheduler, whose requests fail once ctx is done, such as at the end of the time budget of
+// a tester. Its skipped, blocked and failed requests are counted separately.
+func (s *Scheduler) withContext(ctx context.Context) *Scheduler {
+	view := *s
+	view.ctx = ctx
+	view.skipped = new(int64)
+	view.blocked = new(int64)
+	view.failed = new(int64)
+	return &view
+}
+
+// Skipped returns the number of requests not sent, or aborted in flight, because the context
+// of the scheduler was done
+func (s *Scheduler) Skipped() int {
+	return int(atomic.LoadInt64(s.skipped))
+}
+
+// Blocked returns the number of requests blocked by the WAF detected in front of the target
+func (s *Scheduler) Blocked() int {
+	return int(atomic.LoadInt64(s.blocked))
+}
+
+// Failed returns the number of requests that got no response after their retries, not
+// counting the requests denied by the scope policy or the safe mode, or cancelled
+func (s *Scheduler) Failed() int {
+	return int(atomic.LoadInt64(s.failed))
+}
+
+// WithScheduler returns a context that makes the testers it is passed to share the scheduler
+func WithScheduler(ctx context.Context, s *Scheduler) context.Context {
+	return context.WithValue(ctx, schedulerKey{}, s)
+}
+
+// newTestRunner returns the scheduler of the context, or a new scheduler if there is none
+func newTestRunner(ctx context.Context, config *ffuf.Config) ffuf.RunnerProvider {
+	if s, ok := ctx.Value(schedulerKey{}).(*Scheduler); ok {
+		return s
+	}
+	return NewScheduler(ctx, config)
+}
+
+// Prepare prepares a request using the underlying runner
+func (s *Scheduler) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return s.runner.Prepare(input, basereq)
+}
+
+// Dump dumps a request using the underlying runner
+func (s *Scheduler) Dump(req *ffuf.Request) ([]byte, error) {
+	return s.runner.Dump(req)
+}
+
+// Execute waits for a free worker and the rate limit of the target host, then executes the
+// request with the context of the scheduler and passes it to the request handler of the
+// context, if any
+func (s *Scheduler) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	if err := s.ctx.Err(); err != nil {
+		atomic.AddInt64(s.skipped, 1)
+		return ffuf.Response{}, err
+	}
+	if err := s.pool.workers.Acquire(s.ctx, req.Url); err != nil {
+		atomic.AddInt64(s.skipped, 1)
+		return ffuf.Response{}, err
+	}
+	defer s.pool.workers.Release()
+
+	if throttle := s.pool.throttle(req.Url); throttle != nil {
+		select {
+		case <-throttle.RateLimiter.C:
+		case <-s.ctx.Done():
+			atomic.AddInt64(s.skipped, 1)
+			return ffuf.Response{}, s.ctx.Err()
+		}
+	}
+
+	s.decoration.Apply(req)
+	req.SetContext(s.ctx)
+	var resp ffuf.Response
+	var err error
+	if isWebSocketURL(req.Url) {
+		resp, err = s.websocket.Execute(req)
+	} else {
+		resp, err = s.runner.Execute(req)
+	}
+	if err == nil && s.waf.Blocked(resp.StatusCode, resp.Data) {
+		atomic.AddInt64(s.blocked, 1)
+	}
+	if err != nil && s.ctx.Err() != nil {
+		atomic.AddInt64(s.skipped, 1)
+		return resp, err
+	}
+	if err != nil && !ffuf.IsPolicyViolation(err) && !ffuf.IsSafeModeViolation(err) {
+		atomic.AddInt64(s.failed, 1)
+	}
+	if logger.Enabled(logging.LevelDebug) {
+		logger.Debug("Request sent", logging.FieldTarget, s.config.Url, "method", req.Method, logging.FieldEndpoint, req.Url, "status", resp.StatusCode, logging.FieldError, err)
+	}
+	if s.handler != nil && !ffuf.IsPolicyViolation(err) && !ffuf.IsSafeModeViolation(err) {
+		s.handler(req, &resp, err)
+	}
+	return resp, err
+}
+
+// isWebSocketURL returns true if the URL has a ws or wss scheme
+func isWebSocketURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "ws://") || strings.HasPrefix(rawURL, "wss://")
+}
+
+// throttle returns the rate throttle of the host of a URL, or nil if the rate is not limited
+func (p *WorkerPool) throttle(rawURL string) *ffuf.RateThrottle {
+	if p.config.Rate <= 0 {
+		return nil
+	}
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	throttle, ok := p.throttles[host]
+	if !ok {
+		throttle = ffuf.NewRateThrottle(p.config)
+		p.throttles[host] = throttle
+	}
+	return throttle
+}
+
+// Close stops the rate throttles of the pool
+func (p *WorkerPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for host, throttle := range p.throttles {
+		throttle.RateLimiter.Stop()
+		delete(p.throttles, host)
+	}
+}
+
+// Close stops the rate throttles of the scheduler, unless its worker pool is shared
+func (s *Scheduler) Close() {
+	if s.ownPool {
+		s.pool.Close()
+	}
+}
+
+// endpointCheck is a check of a tester against a single endpoint
+type endpointCheck func(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult)
+
+// runChecks runs independent checks against an endpoint concurrently. The number of requests
+// in flight is bounded by the runner. Vulnerabilities are added to the result in the order
+// of the checks, regardless of the order in which they complete.
+func runChecks(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult, checks ...endpointCheck) {
+	partials := make([]*TestResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		partials[i] = &TestResult{}
+		wg.Add(1)
+		go func(check endpointCheck, partial *TestResult) {
+			defer wg.Done()
+			check(ctx, endpoint, r, partial)
+		}(check, partials[i])
+	}
+	wg.Wait()
+
+	for _, partial := range partials {
+		result.Vulnerabilities = append(result.Vulnerabilities, partial.Vulnerabilities...)
+	}
+}
+
+// sleep waits for a duration between the requests of a tester, and returns false if the
+// context is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,200 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// blockingRunner holds the requests in flight until their context is done
+type blockingRunner struct {
+	fakeRunner
+	started chan struct{}
+}
+
+func (r *blockingRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	r.started <- struct{}{}
+	<-req.Context().Done()
+	return ffuf.Response{}, req.Context().Err()
+}
+
+func TestScheduler_WorkerBudget(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/")
+	conf.Threads = 2
+
+	// The requests of the schedulers sharing the pool are held until released
+	var inFlight, maxInFlight int32
+	release := make(chan struct{})
+	handler := func(req *ffuf.Request) ffuf.Response {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&inFlight, -1)
+		return ffuf.Response{}
+	}
+	pool := NewWorkerPool(conf)
+	defer pool.Close()
+	ctx := WithWorkerPool(conf.Context, pool)
+	schedulers := []*Scheduler{NewScheduler(ctx, conf), NewScheduler(ctx, conf)}
+	for _, scheduler := range schedulers {
+		scheduler.runner = &fakeRunner{handler: handler}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := &ffuf.Request{Method: "GET", Url: fmt.Sprintf("https://api.example.com/endpoint%d", i), Headers: map[string]string{}}
+			if _, err := schedulers[i%2].Execute(req); err != nil {
+				t.Errorf("Execute returned an error: %s", err)
+			}
+		}(i)
+	}
+
+	// The other requests wait for a worker while the budget is exhausted
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&inFlight) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&inFlight); n != 2 {
+		t.Errorf("Expected the worker budget to hold 2 requests in flight, got %d", n)
+	}
+	close(release)
+	wg.Wait()
+	if max := atomic.LoadInt32(&maxInFlight); max != 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", max)
+	}
+}
+
+func TestScheduler_HostRateLimit(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/")
+	conf.Rate = 20
+	scheduler := NewScheduler(conf.Context, conf)
+	defer scheduler.Close()
+	if scheduler.pool.throttle("https://a.example.com/x") != scheduler.pool.throttle("https://a.example.com/y") {
+		t.Errorf("Expected the requests to a host to share a throttle")
+	}
+	if scheduler.pool.throttle("https://a.example.com/") == scheduler.pool.throttle("https://b.example.com/") {
+		t.Errorf("Expected each host to have its own throttle")
+	}
+
+	// The requests to each host are throttled to 20 per second, independently of the other host
+	var mu sync.Mutex
+	sent := make(map[string][]time.Time)
+	scheduler.runner = &fakeRunner{handler: func(req *ffuf.Request) ffuf.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		sent[req.Host] = append(sent[req.Host], time.Now())
+		return ffuf.Response{}
+	}}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			req := &ffuf.Request{Method: "GET", Url: "https://" + host + "/", Host: host, Headers: map[string]string{}}
+			if _, err := scheduler.Execute(req); err != nil {
+				t.Errorf("Execute returned an error: %s", err)
+			}
+		}([]string{"c.example.com", "d.example.com"}[i%2])
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	for host, times := range sent {
+		if len(times) != 4 {
+			t.Fatalf("Expected 4 requests to %s, got %d", host, len(times))
+		}
+		if spread := times[3].Sub(times[0]); spread < 100*time.Millisecond {
+			t.Errorf("Expected the requests to %s to be throttled, sent in %s", host, spread)
+		}
+	}
+	if elapsed >= 350*time.Millisecond {
+		t.Errorf("Expected the hosts to be throttled independently, took %s", elapsed)
+	}
+
+	// Without a rate, the requests are not throttled
+	conf.Rate = 0
+	if NewWorkerPool(conf).throttle("https://a.example.com/") != nil {
+		t.Errorf("Expected no throttle without a rate")
+	}
+}
+
+func TestScheduler_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = "https://api.example.com/"
+	conf.Threads = 1
+	scheduler := NewScheduler(ctx, &conf)
+	defer scheduler.Close()
+	runner := &blockingRunner{started: make(chan struct{}, 2)}
+	scheduler.runner = runner
+
+	// One request is in flight and the other waits for the worker
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := scheduler.Execute(&ffuf.Request{Method: "GET", Url: "https://api.example.com/", Headers: map[string]string{}})
+			errs <- err
+		}()
+	}
+	<-runner.started
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err == nil {
+				t.Errorf("Expected the request to fail once the context is cancelled")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the requests to be aborted once the context is cancelled")
+		}
+	}
+	if len(runner.started) != 0 {
+		t.Errorf("Expected the waiting request not to be sent")
+	}
+
+	// Requests are not sent once the context is cancelled
+	if _, err := scheduler.Execute(&ffuf.Request{Method: "GET", Url: "https://api.example.com/", Headers: map[string]string{}}); err == nil {
+		t.Errorf("Expected the request to fail once the context is cancelled")
+	}
+	if scheduler.Skipped() != 3 || scheduler.Failed() != 0 {
+		t.Errorf("Expected 3 skipped and no failed requests, got %d and %d", scheduler.Skipped(), scheduler.Failed())
+	}
+}
+
+func TestScheduler_ViewCancellation(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/")
+	scheduler := NewScheduler(conf.Context, conf)
+	defer scheduler.Close()
+	scheduler.runner = &fakeRunner{handler: func(req *ffuf.Request) ffuf.Response { return ffuf.Response{} }}
+
+	// The end of the time budget of a tester does not cancel the scheduler of the scan
+	ctx, cancel := context.WithCancel(conf.Context)
+	view := scheduler.withContext(ctx)
+	cancel()
+	if _, err := view.Execute(&ffuf.Request{Method: "GET", Url: "https://api.example.com/", Headers: map[string]string{}}); err == nil {
+		t.Errorf("Expected the request of the view to fail once its context is cancelled")
+	}
+	if _, err := scheduler.Execute(&ffuf.Request{Method: "GET", Url: "https://api.example.com/", Headers: map[string]string{}}); err != nil {
+		t.Errorf("Expected the request of the scheduler to succeed, got %s", err)
+	}
+	if view.Skipped() != 1 || scheduler.Skipped() != 0 {
+		t.Errorf("Expected the skipped requests to be counted by the view only, got %d and %d", view.Skipped(), scheduler.Skipped())
+	}
+}
@@ -0,0 +1,295 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"golang.org/x/net/html"
+)
+
+// SecretLeakageTester implements testing for secrets such as API keys, tokens and internal
+// URLs leaked in responses, error pages and JavaScript assets
+type SecretLeakageTester struct {
+	// Configuration options
+	RulesFile      string
+	ScanJavaScript bool
+	ScanErrorPages bool
+	MaxAssets      int
+}
+
+// NewSecretLeakageTester creates a new tester for secret leakage
+func NewSecretLeakageTester() *SecretLeakageTester {
+	return &SecretLeakageTester{
+		ScanJavaScript: true,
+		ScanErrorPages: true,
+		MaxAssets:      20,
+	}
+}
+
+// GetType returns the type of vulnerability this tester checks for
+func (t *SecretLeakageTester) GetType() VulnerabilityType {
+	return VulnSecretLeakage
+}
+
+// GetName returns the name of the security test
+func (t *SecretLeakageTester) GetName() string {
+	return "Secret Leakage"
+}
+
+// GetDescription returns a description of the security test
+func (t *SecretLeakageTester) GetDescription() string {
+	return "Tests for API keys, tokens, private keys, connection strings and internal URLs leaked in API responses, error pages and the JavaScript assets of the application."
+}
+
+// secretSource is a response scanned for secrets
+type secretSource struct {
+	// Location describes where the response comes from
+	Location string
+	CWE      string
+	Request  *ffuf.Request
+	Response ffuf.Response
+}
+
+// Test runs the security test against the target
+func (t *SecretLeakageTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	result := &TestResult{
+		TestName:  t.GetName(),
+		StartTime: time.Now(),
+	}
+
+	rules := [][]*secrets.Rule{secrets.DefaultRules()}
+	if t.RulesFile != "" {
+		fileRules, err := secrets.LoadRules(t.RulesFile)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, fileRules)
+	}
+	scanner, err := secrets.NewScanner(rules...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a runner for making HTTP requests
+	r := newTestRunner(ctx, config)
+
+	reported := make(map[string]bool)
+	for _, endpoint := range extractEndpointsFromConfig(config) {
+		if ctx.Err() != nil {
+			break
+		}
+		req := configRequest(config, endpoint)
+		resp, err := r.Execute(req)
+		if err != nil {
+			continue
+		}
+		sources := []secretSource{{Location: "the response", CWE: "CWE-200", Request: req, Response: resp}}
+
+		if t.ScanErrorPages {
+			sources = append(sources, t.errorPages(ctx, req, r)...)
+		}
+		if t.ScanJavaScript {
+			sources = append(sources, t.javaScriptAssets(ctx, req, resp, r)...)
+		}
+		for _, source := range sources {
+			t.scanSource(scanner, source, reported, result)
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// errorPages triggers error pages of an endpoint, with a resource which should not exist and
+// with a malformed request, and returns the error responses
+func (t *SecretLeakageTester) errorPages(ctx context.Context, req *ffuf.Request, r ffuf.RunnerProvider) []secretSource {
+	variants := make([]*ffuf.Request, 0)
+	if controlURL := controlSiblingURL(req.Url); controlURL != "" {
+		variants = append(variants, &ffuf.Request{Method: "GET", Url: controlURL, Headers: req.Headers})
+	}
+
+	// Unbalanced quotes and brackets break the parsers of most formats
+	malformed := *req
+	if len(req.Data) > 0 {
+		malformed.Data = append(append([]byte{}, req.Data[:len(req.Data)/2]...), []byte(`'"{[<`)...)
+	} else {
+		malformed.Url = addOrReplaceParameter(req.Url, "ffuf", url.QueryEscape(`'"{[<`))
+	}
+	variants = append(variants, &malformed)
+
+	sources := make([]secretSource, 0)
+	for _, variant := range variants {
+		if ctx.Err() != nil {
+			break
+		}
+		resp, err := r.Execute(variant)
+		if err != nil || resp.StatusCode < 400 {
+			continue
+		}
+		sources = append(sources, secretSource{
+			Location: fmt.Sprintf("the %d error page of %s %s", resp.StatusCode, variant.Method, variant.Url),
+			CWE:      "CWE-209",
+			Request:  variant,
+			Response: resp,
+		})
+	}
+	return sources
+}
+
+// javaScriptAssets fetches the scripts of the same host loaded by an HTML response and by the
+// root page of the host, and returns their responses
+func (t *SecretLeakageTester) javaScriptAssets(ctx context.Context, req *ffuf.Request, resp ffuf.Response, r ffuf.RunnerProvider) []secretSource {
+	base, err := url.Parse(req.Url)
+	if err != nil {
+		return nil
+	}
+	pages := []ffuf.Response{resp}
+	root := &url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/"}
+	if root.String() != req.Url {
+		if rootResp, err := r.Execute(&ffuf.Request{Method: "GET", Url: root.String(), Headers: req.Headers}); err == nil {
+			pages = append(pages, rootResp)
+		}
+	}
+
+	scripts := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, page := range pages {
+		if !strings.Contains(responseMediaType(page), "html") {
+			continue
+		}
+		pageURL := base
+		if page.Request != nil {
+			if u, err := url.Parse(page.Request.Url); err == nil {
+				pageURL = u
+			}
+		}
+		for _, src := range scriptSources(page.Data) {
+			script, err := pageURL.Parse(src)
+			if err != nil || script.Host != base.Host || seen[script.String()] {
+				continue
+			}
+			seen[script.String()] = true
+			scripts = append(scripts, script.String())
+		}
+	}
+
+	sources := make([]secretSource, 0)
+	for i, script := range scripts {
+		if ctx.Err() != nil {
+			break
+		}
+		if t.MaxAssets > 0 && i >= t.MaxAssets {
+			break
+		}
+		assetReq := &ffuf.Request{Method: "GET", Url: script, Headers: req.Headers}
+		assetResp, err := r.Execute(assetReq)
+		if err != nil || !isSuccessfulAccess(assetResp) {
+			continue
+		}
+		sources = append(sources, secretSource{
+			Location: "the JavaScript asset " + script,
+			CWE:      "CWE-798",
+			Request:  assetReq,
+			Response: assetResp,
+		})
+	}
+	return sources
+}
+
+// scanSource reports the secrets found in a response, once per rule and secret. Secrets sent
+// in the request, such as the token of the tester, and revealed URLs of the tested host are
+// not reported.
+func (t *SecretLeakageTester) scanSource(scanner *secrets.Scanner, source secretSource, reported map[string]bool, result *TestResult) {
+	sent := source.Request.Url + "\n" + string(source.Request.Data)
+	for _, value := range source.Request.Headers {
+		sent += "\n" + value
+	}
+	host := ""
+	if u, err := url.Parse(source.Request.Url); err == nil {
+		host = "//" + u.Host
+	}
+
+	for _, finding := range scanner.Scan(source.Response.Data) {
+		key := finding.Rule.ID + "\x00" + finding.Secret
+		if reported[key] || strings.Contains(sent, finding.Secret) || (finding.Rule.Reveal && host != "//" && strings.Contains(finding.Secret, host)) {
+			continue
+		}
+		reported[key] = true
+
+		description := fmt.Sprintf("A secret (%s) is exposed to API clients, who can use it to access the systems it protects.", finding.Rule.Name)
+		remediation := "Revoke and rotate the secret, remove it from responses and client-side code, and keep secrets in server-side configuration or a secrets manager."
+		if finding.Rule.Reveal {
+			description = fmt.Sprintf("An internal location (%s) is exposed to API clients, disclosing the internal infrastructure to attackers.", finding.Rule.Name)
+			remediation = "Remove internal addresses from responses and client-side code, and return generic error messages."
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, VulnerabilityInfo{
+			Type:        VulnSecretLeakage,
+			Name:        "Leaked " + finding.Rule.Name,
+			Description: description,
+			Severity:    finding.Rule.Severity,
+			Request:     convertToHTTPRequest(source.Request),
+			Response:    convertToHTTPResponse(source.Response),
+			Evidence:    fmt.Sprintf("%s found on line %d of %s (rule %s, entropy %.2f)", finding.Redacted, finding.Line, source.Location, finding.Rule.ID, finding.Entropy),
+			Remediation: remediation,
+			CVSS:        severityCVSS(finding.Rule.Severity),
+			CWE:         source.CWE,
+			References: []string{
+				"https://owasp.org/API-Security/editions/2019/en/0xa3-excessive-data-exposure/",
+				"https://cwe.mitre.org/data/definitions/" + strings.TrimPrefix(source.CWE, "CWE-") + ".html",
+			},
+			DetectedAt: time.Now(),
+		})
+	}
+}
+
+// scriptSources returns the sources of the script elements of an HTML document
+func scriptSources(data []byte) []string {
+	sources := make([]string, 0)
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return sources
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "script" {
+				continue
+			}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				if string(key) == "src" && len(value) > 0 {
+					sources = append(sources, string(value))
+				}
+			}
+		}
+	}
+}
+
+// severityCVSS returns a representative CVSS score of a severity
+func severityCVSS(severity string) float64 {
+	switch severity {
+	case "Critical":
+		return 9.1
+	case "High":
+		return 7.5
+	case "Medium":
+		return 5.3
+	case "Low":
+		return 3.7
+	}
+	return 0
+}
+
+func init() {
+	// Register the tester with the default registry
+	RegisterSecurityTester(NewSecretLeakageTester())
+}
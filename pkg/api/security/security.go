@@ -1,17 +1,31 @@
 // Package security provides testing modules for API security vulnerabilities.
 //
-// This package implements testing modules for the OWASP API Security Top 10,
-// including Broken Object Level Authorization, Broken Authentication, Excessive Data Exposure,
-// Lack of Resources & Rate Limiting, Broken Function Level Authorization, Mass Assignment,
-// Security Misconfiguration, Injection, Improper Assets Management, and Insufficient Logging & Monitoring.
+// This package implements testing modules for the OWASP API Security Top 10, including Broken
+// Object Level Authorization, Broken Authentication, Excessive Data Exposure, Lack of
+// Resources & Rate Limiting, Broken Function Level Authorization, Mass Assignment, Security
+// Misconfiguration, Injection, Improper Assets Management, and Insufficient Logging and
+// Monitoring, as well as Server Side Request Forgery from the 2023 edition.
+//
+// Further modules test undeclared formats accepted through content negotiation, attacks
+// through trusted request headers, leaked secrets and the abuse of pagination parameters. They
+// also test the security controls of GraphQL endpoints, the origin checks of WebSocket
+// endpoints and error messages disclosing internal details.
 package security
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/ffuf/ffuf/v2/pkg/api/auth"
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/api/payload"
+	"github.com/ffuf/ffuf/v2/pkg/api/state"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // VulnerabilityType represents the type of vulnerability
@@ -38,6 +52,29 @@ const (
 	VulnImproperAssetsMgmt
 	// VulnInsufficientLogging represents Insufficient Logging & Monitoring (API10:2019)
 	VulnInsufficientLogging
+	// VulnSSRF represents Server Side Request Forgery (API7:2023)
+	VulnSSRF
+	// VulnContentNegotiation represents undeclared request and response formats accepted
+	// through content negotiation
+	VulnContentNegotiation
+	// VulnHeaderAttack represents attacks through request headers trusted by proxies and
+	// frameworks, such as host header injection and web cache poisoning
+	VulnHeaderAttack
+	// VulnSecretLeakage represents secrets such as API keys and tokens leaked in responses,
+	// error pages and JavaScript assets
+	VulnSecretLeakage
+	// VulnPaginationAbuse represents pagination, sorting and filtering parameters allowing
+	// collections to be dumped or hidden fields to be enumerated
+	VulnPaginationAbuse
+	// VulnGraphQL represents missing security controls of GraphQL endpoints, such as enabled
+	// introspection, batching and alias overloading
+	VulnGraphQL
+	// VulnWebSocket represents WebSocket endpoints accepting handshakes from other origins,
+	// allowing cross-site WebSocket hijacking
+	VulnWebSocket
+	// VulnErrorDisclosure represents error messages disclosing stack traces, frameworks,
+	// database errors and internal paths
+	VulnErrorDisclosure
 )
 
 // VulnerabilityInfo contains information about a detected vulnerability
@@ -54,6 +91,17 @@ type VulnerabilityInfo struct {
 	CWE         string  // Common Weakness Enumeration ID
 	References  []string
 	DetectedAt  time.Time
+	// Parameter is the name of the vulnerable parameter, if known
+	Parameter string
+	// PayloadClass is the class of the payload exposing the vulnerability, e.g. "SQL" or
+	// "Command". The name of the vulnerability is used if empty.
+	PayloadClass string
+	// CVSSVector is the CVSS v3.1 vector the score is computed from. Testers may set it, it is
+	// otherwise set to the default vector of the tester when the results are scored.
+	CVSSVector string
+	// WAF is WAFBlocked if the response is a block page of the WAF detected in front of the
+	// target, WAFPassed if the payload passed it, empty if no WAF was detected
+	WAF string
 }
 
 // TestResult represents the result of a security test
@@ -64,6 +112,22 @@ type TestResult struct {
 	EndTime         time.Time
 	Duration        time.Duration
 	Error           error
+	// Incomplete is true if the tester was stopped by its time budget or the maximum
+	// duration of the scan before sending all its requests
+	Incomplete bool
+	// SkippedRequests is the number of requests an incomplete tester did not send
+	SkippedRequests int
+	// WAF is the name of the web application firewall detected in front of the target, if
+	// WAF detection is enabled
+	WAF string
+	// WAFBlocked is the number of requests of the tester blocked by the WAF
+	WAFBlocked int
+	// Technologies are the technologies of the stack of the target identified by
+	// fingerprinting, if technology fingerprinting is enabled
+	Technologies []Technology
+	// FailedRequests is the number of requests of the tester that got no response after
+	// their retries, such as requests timing out, whose checks were skipped
+	FailedRequests int
 }
 
 // SecurityTester is an interface for security testing modules
@@ -110,17 +174,331 @@ func (r *SecurityTestRegistry) GetAll() []SecurityTester {
 	return testers
 }
 
-// RunAll runs all registered security tests
+// RunAll runs all registered security tests concurrently. The requests of all testers are
+// dispatched through a shared scheduler bounded by the thread count of the config. Each run
+// uses copies of the testers, and skips the destructive ones in safe mode.
 func (r *SecurityTestRegistry) RunAll(ctx context.Context, config *ffuf.Config) ([]*TestResult, error) {
-	var results []*TestResult
-	for _, tester := range r.testers {
-		result, err := tester.Test(ctx, config)
+	testers := r.GetAll()
+	for i, tester := range testers {
+		testers[i] = copyTester(tester)
+	}
+	return runTesters(ctx, config, safeTesters(config, testers))
+}
+
+// resultHandlerKey is the context key of the result handler of a scan
+type resultHandlerKey struct{}
+
+// ResultHandler is called with the result of each tester as soon as the tester completes
+type ResultHandler func(result *TestResult)
+
+// WithResultHandler returns a context that makes the scans it is passed to call handler with
+// the result of each tester as it completes, rather than only returning all results at the
+// end. The handlers of parent contexts are called first. Calls are not concurrent.
+func WithResultHandler(ctx context.Context, handler ResultHandler) context.Context {
+	if parent, ok := ctx.Value(resultHandlerKey{}).(ResultHandler); ok {
+		next := handler
+		handler = func(result *TestResult) {
+			parent(result)
+			next(result)
+		}
+	}
+	return context.WithValue(ctx, resultHandlerKey{}, handler)
+}
+
+// requestHandlerKey is the context key of the handler of the requests sent by the testers
+type requestHandlerKey struct{}
+
+// RequestHandler is called with each request sent by the testers and its response
+type RequestHandler func(req *ffuf.Request, resp *ffuf.Response, err error)
+
+// WithRequestHandler returns a context that makes the scans it is passed to call handler with
+// each request sent by the testers once its response is received. The handlers of parent
+// contexts are called first. Calls are concurrent.
+func WithRequestHandler(ctx context.Context, handler RequestHandler) context.Context {
+	if parent, ok := ctx.Value(requestHandlerKey{}).(RequestHandler); ok {
+		next := handler
+		handler = func(req *ffuf.Request, resp *ffuf.Response, err error) {
+			parent(req, resp, err)
+			next(req, resp, err)
+		}
+	}
+	return context.WithValue(ctx, requestHandlerKey{}, handler)
+}
+
+// stateStoreKey is the context key of the state store of a scan
+type stateStoreKey struct{}
+
+// WithStateStore returns a context that makes the security test runs it is passed to record
+// their completed requests to a shared state store, and replay those it recorded before
+func WithStateStore(ctx context.Context, store *state.Store) context.Context {
+	return context.WithValue(ctx, stateStoreKey{}, store)
+}
+
+// stateStoreFor returns the state store of a context, or opens the state file of the config
+// if the context has none, in which case owned is true and the caller closes it. The store
+// is nil if no state file is set.
+func stateStoreFor(ctx context.Context, config *ffuf.Config) (store *state.Store, owned bool, err error) {
+	if store, ok := ctx.Value(stateStoreKey{}).(*state.Store); ok {
+		return store, false, nil
+	}
+	store, err = state.OpenConfigured(config)
+	return store, store != nil, err
+}
+
+// runTesters runs testers concurrently and returns their results ordered by vulnerability type
+func runTesters(ctx context.Context, config *ffuf.Config, testers []SecurityTester) ([]*TestResult, error) {
+	sort.SliceStable(testers, func(i, j int) bool {
+		return testers[i].GetType() < testers[j].GetType()
+	})
+
+	scheduler := NewScheduler(ctx, config)
+	defer scheduler.Close()
+	ctx = WithScheduler(ctx, scheduler)
+	// The testers share the login guard of the scan, or one of the config, capping their
+	// login attempts to each endpoint
+	ctx = WithLoginGuard(ctx, loginGuardFor(ctx, config))
+
+	// Decorate the requests of the testers with the headers and cookies of the config, and the
+	// overrides of each tester
+	decorations, err := ParseRequestDecorations(config)
+	if err != nil {
+		return nil, err
+	}
+	scheduler.decoration = decorations.Default
+
+	// Sign and authenticate requests with the signing config and the login session of the
+	// config. They are applied below the state runner, so that recorded requests do not depend
+	// on signatures and tokens.
+	newRunner := func(conf *ffuf.Config) ffuf.RunnerProvider {
+		return runner.NewSimpleRunner(conf, false)
+	}
+	signed, err := auth.NewConfiguredSigningRunner(config, scheduler.runner, newRunner)
+	if err != nil {
+		return nil, err
+	}
+	scheduler.runner = signed
+	session, err := auth.NewConfiguredSession(config, scheduler.runner)
+	if err != nil {
+		return nil, err
+	}
+	if session != nil {
+		scheduler.runner = auth.NewRunner(session, scheduler.runner)
+	}
+
+	// Record completed requests to resume an interrupted scan, to the store of the scan or
+	// one of the config
+	store, owned, err := stateStoreFor(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if owned {
+		defer store.Close()
+	}
+	if store != nil {
+		scheduler.runner = state.NewRunner(store, state.ScopeSecurity, scheduler.runner)
+	}
+
+	// The testers with a proxy of their own send their requests through runners of the proxy,
+	// signed, authenticated and recorded like the others
+	type proxyRunners struct{ runner, websocket ffuf.RunnerProvider }
+	proxied := make(map[string]proxyRunners)
+	for _, tester := range testers {
+		proxy := decorations.For(tester.GetType()).Proxy
+		if _, ok := proxied[proxy]; proxy == "" || ok {
+			continue
+		}
+		proxyConfig := *config
+		proxyConfig.ProxyURL = proxy
+		proxyConfig.ProxyList = nil
+		proxyRunner, err := auth.NewConfiguredSigningRunner(&proxyConfig, newRunner(&proxyConfig), newRunner)
 		if err != nil {
-			return results, err
+			return nil, err
 		}
-		results = append(results, result)
+		if session != nil {
+			proxyRunner = auth.NewRunner(session, proxyRunner)
+		}
+		if store != nil {
+			proxyRunner = state.NewRunner(store, state.ScopeSecurity, proxyRunner)
+		}
+		proxied[proxy] = proxyRunners{proxyRunner, runner.NewWebSocketRunner(&proxyConfig)}
+	}
+
+	scoring, err := LoadConfiguredScoring(config)
+	if err != nil {
+		return nil, err
+	}
+	budgets, err := ParseTesterBudgets(config.APISecurityBudgets)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ParseBOLAIdentities(config.APISecurityIdentities); err != nil {
+		return nil, err
+	}
+	payloads, err := LoadConfiguredPayloads(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// The testers send their out-of-band payloads to the callback listener of the scan, or
+	// one of the config
+	tracker, owned, err := oobTrackerFor(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if owned {
+		defer tracker.Provider.Close()
+	}
+	if tracker != nil {
+		for _, tester := range testers {
+			if consumer, ok := tester.(OOBConsumer); ok {
+				consumer.SetOOB(tracker)
+			}
+		}
+	}
+
+	// Fingerprint the WAF in front of the target, and tamper with the payloads to evade it
+	// unless a tamper chain is configured
+	var waf *WAFDetection
+	if config.APISecurityWAF {
+		detector, ok := ctx.Value(wafDetectorKey{}).(*WAFDetector)
+		if !ok {
+			detector = NewWAFDetector()
+		}
+		waf = detector.Detect(scheduler, config)
+		scheduler.waf = waf
+		if waf != nil {
+			logger.Debug("WAF detected", logging.FieldTarget, config.Url, "waf", waf.Name, "blocking", waf.Blocking)
+		}
+	}
+	if waf != nil && waf.Blocking && len(config.APISecurityTamper) == 0 && len(waf.Tampers) > 0 {
+		if payloads == nil {
+			payloads = &PayloadSelection{}
+		}
+		if payloads.Tamper, err = payload.NewTamperChain(waf.Tampers); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fingerprint the technology stack of the target, and skip the payloads of the databases
+	// it rules out
+	var technologies *TechnologyProfile
+	if config.APISecurityFingerprint {
+		detector, ok := ctx.Value(technologyDetectorKey{}).(*TechnologyDetector)
+		if !ok {
+			detector = NewTechnologyDetector()
+		}
+		technologies = detector.Detect(scheduler, config)
+		if technologies != nil {
+			logger.Debug("Technologies identified", logging.FieldTarget, config.Url, "technologies", technologies.String())
+			if payloads == nil {
+				payloads = &PayloadSelection{}
+			}
+			payloads.Technologies = technologies
+		}
+	}
+	for _, tester := range testers {
+		if consumer, ok := tester.(PayloadConsumer); ok {
+			consumer.SetPayloads(payloads)
+		}
+	}
+
+	handler, _ := ctx.Value(resultHandlerKey{}).(ResultHandler)
+	var handlerMu sync.Mutex
+
+	results := make([]*TestResult, len(testers))
+	errs := make([]error, len(testers))
+	var wg sync.WaitGroup
+	for i, tester := range testers {
+		wg.Add(1)
+		go func(i int, tester SecurityTester) {
+			defer wg.Done()
+			// Each tester has its own view of the scheduler, failing its requests once its
+			// budget is spent or the scan is interrupted
+			var testerCtx context.Context
+			var cancel context.CancelFunc
+			if budget := budgets.Budget(tester.GetType()); budget > 0 {
+				testerCtx, cancel = context.WithTimeout(ctx, budget)
+			} else {
+				testerCtx, cancel = context.WithCancel(ctx)
+			}
+			defer cancel()
+			view := scheduler.withContext(testerCtx)
+			decoration := decorations.For(tester.GetType())
+			if runners, ok := proxied[decoration.Proxy]; ok {
+				view.runner, view.websocket = runners.runner, runners.websocket
+			}
+			view.decoration = decoration
+			started := time.Now()
+			testerLogger := logger.With(logging.FieldTarget, config.Url, "tester", tester.GetName())
+			testerLogger.Debug("Tester started")
+			results[i], errs[i] = tester.Test(WithScheduler(testerCtx, view), config)
+			if stopped := testerCtx.Err(); stopped != nil && (view.Skipped() > 0 || errors.Is(errs[i], stopped)) {
+				results[i] = stoppedResult(tester, results[i], started, view.Skipped())
+				if errors.Is(errs[i], stopped) {
+					errs[i] = nil
+				}
+			}
+			if results[i] != nil && waf != nil {
+				results[i].WAF = waf.Name
+				results[i].WAFBlocked = view.Blocked()
+				for j := range results[i].Vulnerabilities {
+					results[i].Vulnerabilities[j].WAF = waf.Verdict(results[i].Vulnerabilities[j].Response)
+				}
+			}
+			if results[i] != nil && technologies != nil {
+				results[i].Technologies = technologies.Technologies
+			}
+			if results[i] != nil {
+				scoring.ScoreResult(results[i])
+				results[i].FailedRequests = view.Failed()
+			}
+			if failed := view.Failed(); failed > 0 {
+				testerLogger.Warn("Requests of the tester failed, their checks were skipped", "failed", failed)
+			}
+			if errs[i] != nil {
+				testerLogger.Error("Tester failed", logging.FieldError, errs[i])
+			} else if results[i] != nil {
+				testerLogger.Debug("Tester finished", "vulnerabilities", len(results[i].Vulnerabilities), "incomplete", results[i].Incomplete, "duration", time.Since(started))
+			}
+			if handler != nil && results[i] != nil {
+				handlerMu.Lock()
+				handler(results[i])
+				handlerMu.Unlock()
+			}
+		}(i, tester)
+	}
+	wg.Wait()
+
+	var completed []*TestResult
+	for i, result := range results {
+		if errs[i] != nil {
+			return completed, errs[i]
+		}
+		completed = append(completed, result)
+	}
+	// Testers stopped by the maximum duration of the scan are incomplete, not failed. The
+	// results of the testers of an interrupted scan are returned with the error of the
+	// context.
+	if err := ctx.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return completed, err
+	}
+	return completed, nil
+}
+
+// stoppedResult marks the result of a tester stopped by its time budget, the maximum duration
+// of the scan or its interruption as incomplete, keeping the vulnerabilities found before it
+// stopped
+func stoppedResult(tester SecurityTester, result *TestResult, started time.Time, skipped int) *TestResult {
+	if result == nil {
+		result = &TestResult{TestName: tester.GetName(), StartTime: started, EndTime: time.Now()}
+		result.Duration = result.EndTime.Sub(started)
+	}
+	if errors.Is(result.Error, context.DeadlineExceeded) || errors.Is(result.Error, context.Canceled) {
+		result.Error = nil
 	}
-	return results, nil
+	result.Incomplete = true
+	result.SkippedRequests = skipped
+	return result
 }
 
 // DefaultRegistry is the global security test registry
@@ -0,0 +1,156 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/state"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// fakeRunner answers the requests of the testers with a handler instead of sending them
+type fakeRunner struct {
+	handler func(req *ffuf.Request) ffuf.Response
+
+	mu       sync.Mutex
+	requests []*ffuf.Request
+}
+
+func (r *fakeRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return ffuf.CopyRequest(basereq), nil
+}
+
+func (r *fakeRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.mu.Unlock()
+	resp := r.handler(req)
+	resp.Request = req
+	if resp.StatusCode == 0 {
+		resp.StatusCode = 200
+	}
+	if resp.Headers == nil {
+		resp.Headers = make(map[string][]string)
+	}
+	resp.ContentLength = int64(len(resp.Data))
+	return resp, nil
+}
+
+func (r *fakeRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return nil, nil
+}
+
+// Requests returns the requests answered by the runner
+func (r *fakeRunner) Requests() []*ffuf.Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*ffuf.Request{}, r.requests...)
+}
+
+// newFakeConfig returns a config targeting a URL
+func newFakeConfig(t *testing.T, target string) *ffuf.Config {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = target
+	return &conf
+}
+
+// runFake runs a tester against the target of the config, with its requests answered by handler
+func runFake(t *testing.T, tester SecurityTester, conf *ffuf.Config, handler func(req *ffuf.Request) ffuf.Response) (*TestResult, *fakeRunner) {
+	t.Helper()
+	runner := &fakeRunner{handler: handler}
+	scheduler := NewScheduler(conf.Context, conf)
+	defer scheduler.Close()
+	scheduler.runner = runner
+	result, err := tester.Test(WithScheduler(conf.Context, scheduler), conf)
+	if err != nil {
+		t.Fatalf("%s returned an error: %s", tester.GetName(), err)
+	}
+	return result, runner
+}
+
+// vulnerabilityNames returns the names of the vulnerabilities of a result
+func vulnerabilityNames(result *TestResult) []string {
+	names := make([]string, 0, len(result.Vulnerabilities))
+	for _, vuln := range result.Vulnerabilities {
+		names = append(names, vuln.Name)
+	}
+	return names
+}
+
+// fetchTester requests the target of the config once
+type fetchTester struct{}
+
+func (t *fetchTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	req := &ffuf.Request{Method: "GET", Url: config.Url, Headers: map[string]string{}}
+	resp, err := newTestRunner(ctx, config).Execute(req)
+	if err != nil {
+		return nil, err
+	}
+	return &TestResult{TestName: t.GetName(), Vulnerabilities: []VulnerabilityInfo{{Evidence: string(resp.Data)}}}, nil
+}
+
+func (t *fetchTester) GetType() VulnerabilityType { return VulnSecurityMisconfig }
+func (t *fetchTester) GetName() string            { return "Fetch Tester" }
+func (t *fetchTester) GetDescription() string     { return "Requests the target" }
+
+func TestRunConfigured_SharedStateStore(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %d", r.URL.Path, atomic.AddInt32(&requests, 1))
+	}))
+	defer ts.Close()
+	registry := NewSecurityTestRegistry()
+	registry.Register(&fetchTester{})
+	path := filepath.Join(t.TempDir(), "scan.state")
+
+	// The targets scanned at once record their requests to the state store of the scan
+	scan := func(resume bool) []string {
+		conf := newFakeConfig(t, ts.URL)
+		conf.APIStateFile = path
+		conf.APIResume = resume
+		store, err := state.OpenConfigured(conf)
+		if err != nil {
+			t.Fatalf("OpenConfigured returned an error: %s", err)
+		}
+		ctx := WithStateStore(conf.Context, store)
+		evidence := make([]string, 2)
+		var wg sync.WaitGroup
+		for i, target := range []string{"/users", "/orders"} {
+			wg.Add(1)
+			go func(i int, target string) {
+				defer wg.Done()
+				targetConf := *conf
+				targetConf.Url = ts.URL + target
+				results, err := registry.RunConfigured(ctx, &targetConf)
+				if err != nil || len(results) != 1 || len(results[0].Vulnerabilities) != 1 {
+					t.Errorf("Expected the result of the tester, got %d: %v", len(results), err)
+					return
+				}
+				evidence[i] = results[0].Vulnerabilities[0].Evidence
+			}(i, target)
+		}
+		wg.Wait()
+		if err := store.Close(); err != nil {
+			t.Fatalf("Failed to close store: %s", err)
+		}
+		return evidence
+	}
+
+	first := scan(false)
+	resumed := scan(true)
+	if requests != 2 {
+		t.Errorf("Expected the requests of both targets to be replayed, got %d requests", requests)
+	}
+	if first[0] != resumed[0] || first[1] != resumed[1] {
+		t.Errorf("Expected the recorded responses to be replayed, got %v and %v", first, resumed)
+	}
+}
@@ -0,0 +1,356 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/security/oob"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// SSRFTester implements testing for Server Side Request Forgery (API7:2023)
+type SSRFTester struct {
+	// Configuration options
+	URLParameters        []string
+	InternalTargets      []string
+	CloudMetadataTargets []string
+	ResponseIndicators   []string
+	// CallbackTimeout is how long to wait for out-of-band interactions after all payloads are sent
+	CallbackTimeout time.Duration
+	// OOB is the out-of-band tracker of the callback payloads, set from the callback listener
+	// of the config. Out-of-band detection is disabled if nil.
+	OOB *oob.Tracker
+}
+
+// NewSSRFTester creates a new tester for Server Side Request Forgery
+func NewSSRFTester() *SSRFTester {
+	return &SSRFTester{
+		URLParameters: []string{
+			"url", "uri", "link", "href", "src", "source", "dest", "destination",
+			"target", "redirect", "redirect_uri", "redirect_url", "return", "return_url",
+			"next", "callback", "callback_url", "webhook", "webhook_url", "feed", "host",
+			"domain", "site", "proxy", "fetch", "image", "image_url", "avatar", "avatar_url",
+			"file", "document", "endpoint",
+		},
+		InternalTargets: []string{
+			"http://127.0.0.1/",
+			"http://localhost/",
+			"http://[::1]/",
+			"http://0.0.0.0/",
+			"http://2130706433/", // 127.0.0.1 as a decimal integer
+			"http://0x7f000001/", // 127.0.0.1 as a hexadecimal integer
+			"http://127.0.0.1:22/",
+			"http://127.0.0.1:6379/",
+			"http://127.0.0.1:8080/",
+			"file:///etc/passwd",
+		},
+		CloudMetadataTargets: []string{
+			"http://169.254.169.254/latest/meta-data/",                        // AWS
+			"http://169.254.169.254/computeMetadata/v1/",                      // Google Cloud
+			"http://metadata.google.internal/computeMetadata/v1/",             // Google Cloud
+			"http://169.254.169.254/metadata/instance?api-version=2021-02-01", // Azure
+			"http://169.254.169.254/metadata/v1/",                             // DigitalOcean
+			"http://100.100.100.200/latest/meta-data/",                        // Alibaba Cloud
+			"http://[fd00:ec2::254]/latest/meta-data/",                        // AWS (IPv6)
+		},
+		ResponseIndicators: []string{
+			"ami-id",
+			"instance-id",
+			"iam/security-credentials",
+			"computeMetadata",
+			"\"azEnvironment\"",
+			"droplet_id",
+			"root:x:0:0:",
+			"SSH-2.0-",
+			"-ERR wrong number of arguments",
+			"-ERR unknown command",
+		},
+		CallbackTimeout: 5 * time.Second,
+	}
+}
+
+// GetType returns the type of vulnerability this tester checks for
+func (t *SSRFTester) GetType() VulnerabilityType {
+	return VulnSSRF
+}
+
+// GetName returns the name of the security test
+func (t *SSRFTester) GetName() string {
+	return "Server Side Request Forgery"
+}
+
+// GetDescription returns a description of the security test
+func (t *SSRFTester) GetDescription() string {
+	return "Tests for API endpoints that fetch remote resources from user-supplied URLs without validation, allowing requests to internal services, cloud metadata endpoints, or attacker-controlled servers."
+}
+
+// SetOOB sets the tracker of the out-of-band callback payloads
+func (t *SSRFTester) SetOOB(tracker *oob.Tracker) {
+	t.OOB = tracker
+}
+
+// ssrfCallback records a request sent with an out-of-band callback payload
+type ssrfCallback struct {
+	request   *ffuf.Request
+	paramName string
+	location  string
+}
+
+// Test runs the security test against the target
+func (t *SSRFTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	result := &TestResult{
+		TestName:  t.GetName(),
+		StartTime: time.Now(),
+	}
+
+	// Create a runner for making HTTP requests
+	r := newTestRunner(ctx, config)
+
+	// Extract potential endpoints from the config
+	endpoints := extractEndpointsFromConfig(config)
+
+	callbacks := make(map[string]ssrfCallback)
+	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
+		// Test URL parameters in the query string
+		t.testSSRFGET(ctx, endpoint, r, result, callbacks)
+
+		// Test URL parameters in a JSON body
+		t.testSSRFPOST(ctx, endpoint, r, result, callbacks)
+	}
+
+	// Wait for out-of-band interactions
+	if t.OOB != nil && len(callbacks) > 0 {
+		findings, _ := t.OOB.Wait(ctx, t.CallbackTimeout)
+		for _, finding := range findings {
+			if callback, ok := callbacks[finding.Payload.ID]; ok {
+				result.Vulnerabilities = append(result.Vulnerabilities, blindSSRFVulnerability(callback,
+					fmt.Sprintf("%d %s interaction(s) for payload %s, first from %s", len(finding.Interactions),
+						strings.Join(finding.Protocols(), "/"), finding.Payload.ID, finding.Interactions[0].RemoteAddr)))
+			}
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// testSSRFGET tests for SSRF vulnerabilities in GET parameters
+func (t *SSRFTester) testSSRFGET(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult, callbacks map[string]ssrfCallback) {
+	// Prefer the URL-like parameters of the endpoint and fall back to common names
+	paramNames := make([]string, 0)
+	for _, paramName := range extractParameterNames(endpoint) {
+		if t.isURLParameter(paramName) || isURLValue(parameterValue(endpoint, paramName)) {
+			paramNames = append(paramNames, paramName)
+		}
+	}
+	if len(paramNames) == 0 {
+		paramNames = t.URLParameters
+	}
+
+	baseline := t.baselineIndicators(&ffuf.Request{Method: "GET", Url: endpoint, Headers: ssrfHeaders()}, r)
+
+	for _, paramName := range paramNames {
+		if ctx.Err() != nil {
+			return
+		}
+		t.testParameter(ctx, paramName, "query parameter", baseline, r, result, callbacks, func(payload string) *ffuf.Request {
+			return &ffuf.Request{
+				Method:  "GET",
+				Url:     addOrReplaceParameter(endpoint, paramName, url.QueryEscape(payload)),
+				Headers: ssrfHeaders(),
+			}
+		})
+	}
+}
+
+// testSSRFPOST tests for SSRF vulnerabilities in POST parameters
+func (t *SSRFTester) testSSRFPOST(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult, callbacks map[string]ssrfCallback) {
+	headers := ssrfHeaders()
+	headers["Content-Type"] = "application/json"
+	baseline := t.baselineIndicators(&ffuf.Request{Method: "POST", Url: endpoint, Headers: headers, Data: []byte("{}")}, r)
+
+	for _, paramName := range t.URLParameters {
+		if ctx.Err() != nil {
+			return
+		}
+		t.testParameter(ctx, paramName, "JSON body parameter", baseline, r, result, callbacks, func(payload string) *ffuf.Request {
+			data, _ := json.Marshal(map[string]string{paramName: payload})
+			headers := ssrfHeaders()
+			headers["Content-Type"] = "application/json"
+			return &ffuf.Request{
+				Method:  "POST",
+				Url:     endpoint,
+				Headers: headers,
+				Data:    data,
+			}
+		})
+	}
+}
+
+// testParameter sends the SSRF payloads in a single parameter. Internal and cloud metadata
+// targets are detected from the response, callback payloads are recorded for out-of-band detection.
+func (t *SSRFTester) testParameter(ctx context.Context, paramName, location string, baseline map[string]bool, r ffuf.RunnerProvider, result *TestResult, callbacks map[string]ssrfCallback, buildRequest func(payload string) *ffuf.Request) {
+	targets := append(append([]string{}, t.CloudMetadataTargets...), t.InternalTargets...)
+	for _, target := range targets {
+		if ctx.Err() != nil {
+			return
+		}
+		req := buildRequest(target)
+
+		// Execute the request
+		resp, err := r.Execute(req)
+		if err != nil {
+			continue
+		}
+
+		// Check if the response contains content fetched from the target
+		if indicator := t.findIndicator(resp, baseline); indicator != "" {
+			name := "Server Side Request Forgery"
+			severity := "High"
+			cvss := 8.6
+			for _, metadata := range t.CloudMetadataTargets {
+				if metadata == target {
+					name = "Server Side Request Forgery to Cloud Metadata"
+					severity = "Critical"
+					cvss = 9.1
+					break
+				}
+			}
+			result.Vulnerabilities = append(result.Vulnerabilities, VulnerabilityInfo{
+				Type:        VulnSSRF,
+				Name:        name,
+				Description: "The API endpoint fetches user-supplied URLs, allowing requests to internal services that are not otherwise reachable.",
+				Severity:    severity,
+				Request:     convertToHTTPRequest(req),
+				Response:    convertToHTTPResponse(resp),
+				Evidence:    fmt.Sprintf("Payload '%s' in %s '%s' returned content containing '%s'", target, location, paramName, indicator),
+				Remediation: ssrfRemediation,
+				CVSS:        cvss,
+				CWE:         "CWE-918",
+				References:  ssrfReferences,
+				DetectedAt:  time.Now(),
+			})
+			return // Found a vulnerability, no need to test more payloads for this parameter
+		}
+	}
+
+	// Send a callback payload with a unique subdomain for out-of-band detection
+	if t.OOB == nil {
+		return
+	}
+	payload := t.OOB.Payload("Server Side Request Forgery", fmt.Sprintf("%s '%s'", location, paramName))
+	req := buildRequest(payload.URL)
+	if _, err := r.Execute(req); err == nil {
+		callbacks[payload.ID] = ssrfCallback{request: req, paramName: paramName, location: location}
+	}
+}
+
+// blindSSRFVulnerability creates a vulnerability info for a callback payload that caused interactions
+func blindSSRFVulnerability(callback ssrfCallback, interactions string) VulnerabilityInfo {
+	return VulnerabilityInfo{
+		Type:        VulnSSRF,
+		Name:        "Blind Server Side Request Forgery",
+		Description: "The API endpoint fetches user-supplied URLs, causing the server to connect to an attacker-controlled host.",
+		Severity:    "High",
+		Request:     convertToHTTPRequest(callback.request),
+		Evidence:    fmt.Sprintf("Callback payload in %s '%s' caused %s", callback.location, callback.paramName, interactions),
+		Remediation: ssrfRemediation,
+		CVSS:        8.6,
+		CWE:         "CWE-918",
+		References:  ssrfReferences,
+		DetectedAt:  time.Now(),
+	}
+}
+
+// baselineIndicators returns the response indicators that are present without any payload,
+// so that they are not reported as evidence of SSRF
+func (t *SSRFTester) baselineIndicators(req *ffuf.Request, r ffuf.RunnerProvider) map[string]bool {
+	baseline := make(map[string]bool)
+	resp, err := r.Execute(req)
+	if err != nil {
+		return baseline
+	}
+	body := string(resp.Data)
+	for _, indicator := range t.ResponseIndicators {
+		if strings.Contains(body, indicator) {
+			baseline[indicator] = true
+		}
+	}
+	return baseline
+}
+
+// findIndicator returns the first response indicator found in a response that is not in the baseline
+func (t *SSRFTester) findIndicator(resp ffuf.Response, baseline map[string]bool) string {
+	body := string(resp.Data)
+	for _, indicator := range t.ResponseIndicators {
+		if !baseline[indicator] && strings.Contains(body, indicator) {
+			return indicator
+		}
+	}
+	return ""
+}
+
+// isURLParameter checks if a parameter name is commonly used for URLs
+func (t *SSRFTester) isURLParameter(paramName string) bool {
+	name := strings.ToLower(paramName)
+	for _, candidate := range t.URLParameters {
+		if name == candidate {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "url") || strings.HasSuffix(name, "uri")
+}
+
+// parameterValue returns the value of a query parameter in a URL
+func parameterValue(endpoint, paramName string) string {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	return parsed.Query().Get(paramName)
+}
+
+// isURLValue checks if a parameter value looks like a URL
+func isURLValue(value string) bool {
+	lower := strings.ToLower(value)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "//")
+}
+
+// ssrfHeaders returns the default headers for SSRF test requests
+func ssrfHeaders() map[string]string {
+	return map[string]string{
+		"User-Agent": testerUserAgent,
+	}
+}
+
+// newSSRFToken generates a unique random token
+func newSSRFToken() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+const ssrfRemediation = "Validate and sanitize user-supplied URLs against an allowlist of schemes, hosts and ports. Block requests to private, loopback and link-local addresses after DNS resolution. Disable HTTP redirects and do not return raw responses from fetched resources to the client."
+
+var ssrfReferences = []string{
+	"https://owasp.org/API-Security/editions/2023/en/0xa7-server-side-request-forgery/",
+	"https://cheatsheetseries.owasp.org/cheatsheets/Server_Side_Request_Forgery_Prevention_Cheat_Sheet.html",
+}
+
+func init() {
+	// Register the tester with the default registry
+	RegisterSecurityTester(NewSSRFTester())
+}
@@ -0,0 +1,91 @@
+package security
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/security/oob"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// fetchedURL returns the url parameter of a request, from its query or its JSON body
+func fetchedURL(req *ffuf.Request) string {
+	if req.Method == "POST" {
+		return string(req.Data)
+	}
+	u, _ := url.Parse(req.Url)
+	return u.Query().Get("url")
+}
+
+func TestSSRFTester_ResponseIndicators(t *testing.T) {
+	tester := NewSSRFTester()
+	tester.URLParameters = []string{"url"}
+	conf := newFakeConfig(t, "https://api.example.com/preview?url=https://example.com/")
+
+	// The endpoint fetches the AWS metadata, and always returns an instance ID
+	result, _ := runFake(t, tester, conf, func(req *ffuf.Request) ffuf.Response {
+		body := `{"instance-id": "i-0"}`
+		if strings.Contains(fetchedURL(req), "169.254.169.254/latest/meta-data/") {
+			body = "ami-id\nhostname\ninstance-id"
+		}
+		return ffuf.Response{Data: []byte(body)}
+	})
+	if len(result.Vulnerabilities) != 2 {
+		t.Fatalf("Expected the query and body parameters to be reported, got %v", vulnerabilityNames(result))
+	}
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Name != "Server Side Request Forgery to Cloud Metadata" || !strings.Contains(vuln.Evidence, "containing 'ami-id'") {
+			t.Errorf("Expected the metadata to be reported by the indicator missing from the baseline, got %s: %s", vuln.Name, vuln.Evidence)
+		}
+	}
+
+	// Indicators of the baseline are not evidence
+	result, _ = runFake(t, tester, conf, func(req *ffuf.Request) ffuf.Response {
+		return ffuf.Response{Data: []byte("ami-id instance-id")}
+	})
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability, got %v", vulnerabilityNames(result))
+	}
+}
+
+func TestSSRFTester_Callbacks(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/preview?url=https://example.com/")
+	handler := func(req *ffuf.Request) ffuf.Response {
+		return ffuf.Response{Data: []byte(`{"status":"queued"}`)}
+	}
+
+	// Without a tracker, no callback payload is sent
+	tester := NewSSRFTester()
+	tester.URLParameters = []string{"url"}
+	tester.CallbackTimeout = 0
+	_, runner := runFake(t, tester, conf, handler)
+	for _, req := range runner.Requests() {
+		if strings.Contains(fetchedURL(req), "oob") {
+			t.Fatalf("Expected no callback payload without a tracker, got %s", req.Url)
+		}
+	}
+
+	// The callback payloads are under the public URL of the listener
+	tracker, err := NewOOBTracker(oob.ServerOptions{HTTPAddr: "127.0.0.1:0", PublicURL: "http://oob.example.com:8089/"})
+	if err != nil {
+		t.Fatalf("NewOOBTracker returned an error: %s", err)
+	}
+	defer tracker.Provider.Close()
+	tester.SetOOB(tracker)
+	tester.CallbackTimeout = 100 * time.Millisecond
+	result, runner := runFake(t, tester, conf, handler)
+	callbacks := 0
+	for _, req := range runner.Requests() {
+		if strings.Contains(fetchedURL(req), "http://oob.example.com:8089/") {
+			callbacks++
+		}
+	}
+	if callbacks != 2 {
+		t.Errorf("Expected a callback payload in the query and body parameters, got %d", callbacks)
+	}
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability without interactions, got %v", vulnerabilityNames(result))
+	}
+}
@@ -0,0 +1,501 @@
+package security
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// Categories of technologies
+const (
+	TechnologyFramework = "framework"
+	TechnologyLanguage  = "language"
+	TechnologyServer    = "server"
+	TechnologyGateway   = "gateway"
+	TechnologyDatabase  = "database"
+)
+
+// errorKindCategories are the categories of the technologies identified by error signatures
+var errorKindCategories = map[string]string{
+	ErrorKindStackTrace: TechnologyLanguage,
+	ErrorKindFramework:  TechnologyFramework,
+	ErrorKindSQL:        TechnologyDatabase,
+}
+
+// TechnologySignature identifies a technology of the API stack from the headers, the cookies
+// and the favicon of its responses. Technologies are also identified by the error signatures
+// of their error messages.
+type TechnologySignature struct {
+	// Name is the name of the technology, as named by the error signatures
+	Name string
+	// Category is the category of the technology: framework, language, server, gateway or
+	// database
+	Category string
+	// Headers are headers of the responses of the technology, by name, with a regexp matching
+	// their value, nil for any value
+	Headers map[string]*regexp.Regexp
+	// Cookies are prefixes of the names of the cookies set by the technology
+	Cookies []string
+	// Favicons are the hashes of the default favicons of the technology, as computed by
+	// FaviconHash
+	Favicons []int32
+	// Implies are the technologies the technology runs on
+	Implies []string
+	// Excludes are the technologies not used with the technology, whose payloads are not sent
+	Excludes []string
+}
+
+// TechnologySignatures are the signatures of the identified technologies
+var TechnologySignatures = []*TechnologySignature{
+	// Frameworks
+	{
+		Name:     "Django",
+		Category: TechnologyFramework,
+		Cookies:  []string{"csrftoken", "django_language"},
+		Implies:  []string{"Python"},
+		// Django supports PostgreSQL, MySQL, MariaDB, SQLite and Oracle
+		Excludes: []string{"Microsoft SQL Server"},
+	},
+	{
+		Name:     "Flask",
+		Category: TechnologyFramework,
+		Headers:  map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)werkzeug`)},
+		Implies:  []string{"Python"},
+	},
+	{
+		Name:     "Spring",
+		Category: TechnologyFramework,
+		Headers:  map[string]*regexp.Regexp{"X-Application-Context": nil},
+		Favicons: []int32{116323821},
+		Implies:  []string{"Java"},
+	},
+	{
+		Name:     "Laravel",
+		Category: TechnologyFramework,
+		Cookies:  []string{"laravel_session"},
+		Implies:  []string{"PHP"},
+	},
+	{
+		Name:     "Symfony",
+		Category: TechnologyFramework,
+		Headers:  map[string]*regexp.Regexp{"X-Debug-Token": nil},
+		Implies:  []string{"PHP"},
+	},
+	{
+		Name:     "Ruby on Rails",
+		Category: TechnologyFramework,
+		Cookies:  []string{"_rails_session"},
+		Implies:  []string{"Ruby"},
+	},
+	{
+		Name:     "ASP.NET",
+		Category: TechnologyFramework,
+		Headers:  map[string]*regexp.Regexp{"X-AspNet-Version": nil, "X-AspNetMvc-Version": nil, "X-Powered-By": regexp.MustCompile(`(?i)asp\.net`)},
+		Cookies:  []string{"ASP.NET_SessionId", ".AspNetCore."},
+		Implies:  []string{".NET"},
+	},
+	{
+		Name:     "PHP PDO",
+		Category: TechnologyFramework,
+		Implies:  []string{"PHP"},
+	},
+	{
+		Name:     "Express",
+		Category: TechnologyFramework,
+		Headers:  map[string]*regexp.Regexp{"X-Powered-By": regexp.MustCompile(`(?i)express`)},
+		Implies:  []string{"Node.js"},
+	},
+
+	// Languages
+	{
+		Name:     "Python",
+		Category: TechnologyLanguage,
+		Headers:  map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)python|gunicorn|uvicorn|hypercorn`)},
+	},
+	{
+		Name:     "PHP",
+		Category: TechnologyLanguage,
+		Headers:  map[string]*regexp.Regexp{"X-Powered-By": regexp.MustCompile(`(?i)php`)},
+		Cookies:  []string{"PHPSESSID"},
+	},
+	{
+		Name:     "Java",
+		Category: TechnologyLanguage,
+		Cookies:  []string{"JSESSIONID"},
+	},
+	{
+		Name:     "Node.js",
+		Category: TechnologyLanguage,
+		Cookies:  []string{"connect.sid"},
+	},
+	{
+		Name:     "Ruby",
+		Category: TechnologyLanguage,
+		Headers:  map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)puma|passenger|webrick`), "X-Runtime": nil},
+	},
+	{Name: ".NET", Category: TechnologyLanguage},
+	{Name: "Go", Category: TechnologyLanguage},
+
+	// Servers
+	{
+		Name:     "nginx",
+		Category: TechnologyServer,
+		Headers:  map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)^nginx`)},
+	},
+	{
+		Name:     "Apache HTTP Server",
+		Category: TechnologyServer,
+		Headers:  map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)^apache(/|$)`)},
+	},
+	{
+		Name:     "Apache Tomcat",
+		Category: TechnologyServer,
+		Headers:  map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)apache-coyote`)},
+		Favicons: []int32{-297069493},
+		Implies:  []string{"Java"},
+	},
+	{
+		Name:     "Microsoft IIS",
+		Category: TechnologyServer,
+		Headers:  map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)microsoft-iis`)},
+	},
+
+	// Gateways
+	{
+		Name:     "Kong",
+		Category: TechnologyGateway,
+		Headers:  map[string]*regexp.Regexp{"Via": regexp.MustCompile(`(?i)kong`), "X-Kong-Upstream-Latency": nil, "X-Kong-Proxy-Latency": nil},
+	},
+	{
+		Name:     "AWS API Gateway",
+		Category: TechnologyGateway,
+		Headers:  map[string]*regexp.Regexp{"X-Amz-Apigw-Id": nil, "X-Amzn-Requestid": nil},
+	},
+	{
+		Name:     "Envoy",
+		Category: TechnologyGateway,
+		Headers:  map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)^envoy`), "X-Envoy-Upstream-Service-Time": nil},
+	},
+}
+
+// Technology is a technology identified in the API stack
+type Technology struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	// Evidence describes the response the technology was identified from
+	Evidence string `json:"evidence"`
+}
+
+// TechnologyProfile is the technology stack of a target identified by fingerprinting
+type TechnologyProfile struct {
+	Technologies []Technology
+}
+
+// Has returns true if a technology was identified
+func (p *TechnologyProfile) Has(name string) bool {
+	if p == nil {
+		return false
+	}
+	for _, technology := range p.Technologies {
+		if technology.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Excludes returns true if a technology is ruled out by the stack: it is a database and
+// another database was identified, or a technology of the stack is not used with it. A nil
+// profile excludes nothing.
+func (p *TechnologyProfile) Excludes(name string) bool {
+	if p == nil || name == "" || p.Has(name) {
+		return false
+	}
+	for _, technology := range p.Technologies {
+		if technology.Category == TechnologyDatabase && technologyCategory(name) == TechnologyDatabase {
+			return true
+		}
+		if signature := technologySignature(technology.Name); signature != nil {
+			for _, excluded := range signature.Excludes {
+				if excluded == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// String lists the identified technologies with their category
+func (p *TechnologyProfile) String() string {
+	names := make([]string, len(p.Technologies))
+	for i, technology := range p.Technologies {
+		names[i] = fmt.Sprintf("%s (%s)", technology.Name, technology.Category)
+	}
+	return strings.Join(names, ", ")
+}
+
+// add adds a technology and the technologies it implies, unless already identified
+func (p *TechnologyProfile) add(name, category, evidence string) {
+	if p.Has(name) {
+		return
+	}
+	p.Technologies = append(p.Technologies, Technology{Name: name, Category: category, Evidence: evidence})
+	if signature := technologySignature(name); signature != nil {
+		for _, implied := range signature.Implies {
+			p.add(implied, technologyCategory(implied), "implied by "+name)
+		}
+	}
+}
+
+// technologySignature returns the signature of a technology, nil if it has none
+func technologySignature(name string) *TechnologySignature {
+	for _, signature := range TechnologySignatures {
+		if signature.Name == name {
+			return signature
+		}
+	}
+	return nil
+}
+
+// technologyCategory returns the category of a technology, from its signature or the kind of
+// its error signatures
+func technologyCategory(name string) string {
+	if signature := technologySignature(name); signature != nil {
+		return signature.Category
+	}
+	for _, signature := range defaultErrorSignatures {
+		if signature.Technology == name {
+			return errorKindCategories[signature.Kind]
+		}
+	}
+	return ""
+}
+
+// technologyDetectorKey is the context key of the technology detector shared by the runs of a
+// scan
+type technologyDetectorKey struct{}
+
+// TechnologyDetector fingerprints the technology stacks of targets, once per host
+type TechnologyDetector struct {
+	mu       sync.Mutex
+	profiles map[string]*TechnologyProfile
+}
+
+// NewTechnologyDetector creates a new TechnologyDetector
+func NewTechnologyDetector() *TechnologyDetector {
+	return &TechnologyDetector{profiles: make(map[string]*TechnologyProfile)}
+}
+
+// WithTechnologyDetector returns a context that makes the security test runs it is passed to
+// share the technology profiles of the detector
+func WithTechnologyDetector(ctx context.Context, d *TechnologyDetector) context.Context {
+	return context.WithValue(ctx, technologyDetectorKey{}, d)
+}
+
+// Detect fingerprints the technology stack of the target of the config from a request to the
+// target, its favicon, a missing page and a malformed JSON request, which provoke error pages.
+// It returns nil if no technology is identified.
+func (d *TechnologyDetector) Detect(r ffuf.RunnerProvider, config *ffuf.Config) *TechnologyProfile {
+	u, err := url.Parse(config.Url)
+	if err != nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if profile, ok := d.profiles[u.Host]; ok {
+		return profile
+	}
+
+	target := wafRequest(config, u, "")
+	favicon := *target
+	favicon.Url = (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/favicon.ico"}).String()
+	missing := *target
+	missing.Url = (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/ffuf-" + newSSRFToken()}).String()
+	malformed := withHeaders(target, map[string]string{"Content-Type": "application/json"})
+	malformed.Method = "POST"
+	malformed.Data = []byte(`{"ffuf": [1, "\x", {`)
+
+	responses := make([]*ffuf.Response, 0)
+	var icon []byte
+	for _, req := range []*ffuf.Request{target, &favicon, &missing, malformed} {
+		resp, err := r.Execute(req)
+		if err != nil {
+			continue
+		}
+		if req == &favicon {
+			if resp.StatusCode == http.StatusOK && len(resp.Data) > 0 {
+				icon = resp.Data
+			}
+			continue
+		}
+		responses = append(responses, &resp)
+	}
+	profile := fingerprintTechnologies(responses, icon)
+	d.profiles[u.Host] = profile
+	return profile
+}
+
+// fingerprintTechnologies identifies the technologies of the responses of a target and of its
+// favicon, nil if none is identified
+func fingerprintTechnologies(responses []*ffuf.Response, favicon []byte) *TechnologyProfile {
+	profile := &TechnologyProfile{}
+	for _, resp := range responses {
+		for _, signature := range TechnologySignatures {
+			if evidence := technologyEvidence(signature, resp); evidence != "" {
+				profile.add(signature.Name, signature.Category, evidence)
+			}
+		}
+	}
+	if len(favicon) > 0 {
+		hash := FaviconHash(favicon)
+		for _, signature := range TechnologySignatures {
+			for _, known := range signature.Favicons {
+				if known == hash {
+					profile.add(signature.Name, signature.Category, fmt.Sprintf("favicon hash %d", hash))
+				}
+			}
+		}
+	}
+
+	signatures := DefaultErrorSignatures()
+	compileErrorSignatures(signatures)
+	for _, resp := range responses {
+		for _, match := range ClassifyError(*resp, signatures) {
+			category, ok := errorKindCategories[match.Signature.Kind]
+			if !ok {
+				continue
+			}
+			if known := technologyCategory(match.Signature.Technology); known != "" {
+				category = known
+			}
+			profile.add(match.Signature.Technology, category, fmt.Sprintf("%s with status %d", match.Signature.Name, resp.StatusCode))
+		}
+	}
+
+	if len(profile.Technologies) == 0 {
+		return nil
+	}
+	sort.SliceStable(profile.Technologies, func(i, j int) bool {
+		return technologyCategoryRank[profile.Technologies[i].Category] < technologyCategoryRank[profile.Technologies[j].Category]
+	})
+	return profile
+}
+
+// technologyCategoryRank orders the technologies of profiles by category
+var technologyCategoryRank = map[string]int{
+	TechnologyFramework: 0,
+	TechnologyLanguage:  1,
+	TechnologyDatabase:  2,
+	TechnologyServer:    3,
+	TechnologyGateway:   4,
+}
+
+// technologyEvidence returns the header or cookie of a response matching a signature, or an
+// empty string if none does
+func technologyEvidence(signature *TechnologySignature, resp *ffuf.Response) string {
+	header := http.Header(resp.Headers)
+	for name, value := range signature.Headers {
+		for _, v := range header.Values(name) {
+			if value == nil || value.MatchString(v) {
+				return fmt.Sprintf("header %s: %s", name, v)
+			}
+		}
+	}
+	for _, cookie := range header.Values("Set-Cookie") {
+		for _, prefix := range signature.Cookies {
+			if strings.HasPrefix(strings.TrimSpace(cookie), prefix) {
+				return "cookie " + strings.SplitN(cookie, "=", 2)[0]
+			}
+		}
+	}
+	return ""
+}
+
+// FaviconHash returns the hash of a favicon used by search engines such as Shodan: the 32-bit
+// MurmurHash3 of its base64 encoding, in lines of 76 characters
+func FaviconHash(data []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var lines strings.Builder
+	for len(encoded) > 76 {
+		lines.WriteString(encoded[:76] + "\n")
+		encoded = encoded[76:]
+	}
+	lines.WriteString(encoded + "\n")
+	return int32(murmur3([]byte(lines.String())))
+}
+
+// murmur3 returns the 32-bit MurmurHash3 of data with a seed of 0
+func murmur3(data []byte) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	var h uint32
+	blocks := len(data) / 4 * 4
+	for i := 0; i < blocks; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+	var k uint32
+	tail := data[blocks:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// payloadTechnologies are the databases the SQL injection payloads matching a pattern are
+// specific to
+var payloadTechnologies = []struct {
+	technology string
+	pattern    *regexp.Regexp
+}{
+	{"Microsoft SQL Server", regexp.MustCompile(`(?i)waitfor\s+delay|xp_cmdshell`)},
+	{"PostgreSQL", regexp.MustCompile(`(?i)pg_sleep|::text`)},
+	{"MySQL", regexp.MustCompile(`(?i)\b(sleep|benchmark)\s*\(|floor\s*\(\s*rand\s*\(`)},
+	{"Oracle Database", regexp.MustCompile(`(?i)dbms_pipe|dbms_lock|\bfrom\s+dual\b`)},
+}
+
+// payloadTechnology returns the database a payload of a category is specific to, or an empty
+// string if it is not specific to one
+func payloadTechnology(category, payload string) string {
+	if category != PayloadSQLiError && category != PayloadSQLiTime {
+		return ""
+	}
+	for _, tagged := range payloadTechnologies {
+		if tagged.pattern.MatchString(payload) {
+			return tagged.technology
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestTechnologyDetector(t *testing.T) {
+	var mu sync.Mutex
+	payloads := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "csrftoken", Value: "token"})
+		w.Header().Set("Server", "nginx/1.25.3")
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == "POST" && !json.Valid(body) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("psycopg2.errors.SyntaxError: syntax error at end of input"))
+			return
+		}
+		mu.Lock()
+		payloads = append(payloads, r.URL.RawQuery+string(body))
+		mu.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// Django, Python, PostgreSQL and nginx are identified, and the MySQL payloads are skipped
+	registry := NewSecurityTestRegistry()
+	registry.Register(NewInjectionTester())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = server.URL + "/search?q=test"
+	conf.Threads = 2
+	conf.APISecurityPayloadCategories = []string{PayloadSQLiError}
+	conf.APISecurityFingerprint = true
+	results, err := registry.RunAll(WithTechnologyDetector(ctx, NewTechnologyDetector()), &conf)
+	if err != nil {
+		t.Fatalf("RunAll returned an error: %s", err)
+	}
+	names := make([]string, 0)
+	for _, technology := range results[0].Technologies {
+		names = append(names, technology.Name)
+	}
+	if strings.Join(names, ", ") != "Django, Python, PostgreSQL, nginx" {
+		t.Fatalf("Expected Django, Python, PostgreSQL and nginx to be identified, got %+v", results[0].Technologies)
+	}
+	mu.Lock()
+	sqli := 0
+	for _, payload := range payloads {
+		if strings.Contains(payload, "SLEEP(") || strings.Contains(payload, "BENCHMARK(") {
+			t.Errorf("Expected the MySQL payload %q not to be sent to a PostgreSQL target", payload)
+		}
+		if strings.Contains(payload, "UNION SELECT") {
+			sqli++
+		}
+	}
+	mu.Unlock()
+	if sqli == 0 {
+		t.Error("Expected the other SQL injection payloads to be sent")
+	}
+}
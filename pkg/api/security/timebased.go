@@ -0,0 +1,270 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// TimeBasedPayload is a payload that delays the response when injected successfully.
+// The {delay} and {delay_ms} placeholders are replaced by the delay in seconds and milliseconds.
+type TimeBasedPayload struct {
+	// Kind of injection (SQL, NoSQL or Command)
+	Kind    string
+	Payload string
+	// Raw payloads are inserted into JSON bodies as-is instead of as a string value
+	Raw bool
+}
+
+// render returns the payload with the delay placeholders replaced. The delay is a whole number
+// of seconds, see validateTimeBasedDelay.
+func (p TimeBasedPayload) render(delay time.Duration) string {
+	return strings.NewReplacer(
+		"{delay_ms}", strconv.FormatInt(delay.Milliseconds(), 10),
+		"{delay}", strconv.Itoa(int(delay.Seconds())),
+	).Replace(p.Payload)
+}
+
+// defaultTimeBasedPayloads returns the default time-based payloads
+func defaultTimeBasedPayloads() []TimeBasedPayload {
+	return []TimeBasedPayload{
+		{Kind: "SQL", Payload: "' AND SLEEP({delay}) --"},
+		{Kind: "SQL", Payload: "1 AND SLEEP({delay})"},
+		{Kind: "SQL", Payload: "' AND (SELECT * FROM (SELECT(SLEEP({delay})))a) --"},
+		{Kind: "SQL", Payload: "'; SELECT pg_sleep({delay}) --"},
+		{Kind: "SQL", Payload: "'; WAITFOR DELAY '0:0:{delay}' --"},
+		{Kind: "NoSQL", Payload: `{"$where": "sleep({delay_ms}) || true"}`, Raw: true},
+		{Kind: "NoSQL", Payload: "'; sleep({delay_ms}); var x='"},
+		{Kind: "Command", Payload: "; sleep {delay}"},
+		{Kind: "Command", Payload: "| sleep {delay}"},
+		{Kind: "Command", Payload: "$(sleep {delay})"},
+		{Kind: "Command", Payload: "`sleep {delay}`"},
+	}
+}
+
+// validateTimeBasedDelay checks that a delay can be injected by the payloads in seconds
+func validateTimeBasedDelay(delay time.Duration) error {
+	if delay < time.Second || delay%time.Second != 0 {
+		return fmt.Errorf("invalid time-based delay %s, expected a whole number of seconds", delay)
+	}
+	return nil
+}
+
+// timingBaseline holds the response time statistics of an endpoint without payloads
+type timingBaseline struct {
+	Mean   time.Duration
+	StdDev time.Duration
+}
+
+// threshold returns the minimum response time of a request delayed by the given delay
+func (b timingBaseline) threshold(delay time.Duration) time.Duration {
+	// Allow for jitter in the response time but never less than three standard deviations
+	threshold := b.Mean + delay*8/10
+	if min := b.Mean + 3*b.StdDev; threshold < min {
+		threshold = min
+	}
+	return threshold
+}
+
+// testTimeBasedInjection tests for time-based blind SQL, NoSQL and command injection. The
+// response time of each payload is compared to a baseline across multiple trials, alternating
+// between the configured delay and no delay, so that slow endpoints are not reported.
+func (t *InjectionTester) testTimeBasedInjection(ctx context.Context, endpoint string, r ffuf.RunnerProvider, result *TestResult) {
+	paramNames := extractParameterNames(endpoint)
+	if len(paramNames) == 0 {
+		paramNames = []string{"id", "user_id", "username", "search", "query", "q", "filter", "host", "ip"}
+	}
+
+	getRequest := func(paramName, payload string) *ffuf.Request {
+		return &ffuf.Request{
+			Method: "GET",
+			Url:    addOrReplaceParameter(endpoint, paramName, url.QueryEscape(payload)),
+			Headers: map[string]string{
+				"User-Agent": testerUserAgent,
+			},
+		}
+	}
+	postRequest := func(paramName, payload string, raw bool) *ffuf.Request {
+		value := payload
+		if !raw {
+			quoted, _ := json.Marshal(payload)
+			value = string(quoted)
+		}
+		return &ffuf.Request{
+			Method: "POST",
+			Url:    endpoint,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"User-Agent":   testerUserAgent,
+			},
+			Data: []byte(fmt.Sprintf(`{"%s":%s}`, paramName, value)),
+		}
+	}
+
+	for _, paramName := range paramNames {
+		if ctx.Err() != nil {
+			return
+		}
+		getBaseline, ok := t.measureTiming(getRequest(paramName, "1"), r)
+		if ok {
+			t.testTimeBasedParameter(ctx, paramName, "GET", getBaseline, func(p TimeBasedPayload, delay time.Duration) *ffuf.Request {
+				if p.Raw {
+					return nil
+				}
+				return getRequest(paramName, p.render(delay))
+			}, r, result)
+		}
+
+		postBaseline, ok := t.measureTiming(postRequest(paramName, "1", false), r)
+		if ok {
+			t.testTimeBasedParameter(ctx, paramName, "POST", postBaseline, func(p TimeBasedPayload, delay time.Duration) *ffuf.Request {
+				return postRequest(paramName, p.render(delay), p.Raw)
+			}, r, result)
+		}
+	}
+}
+
+// timeBasedPayloads returns the selected time-based payloads, of the kinds whose category is
+// selected and with the payloads of the provider of the selection
+func (t *InjectionTester) timeBasedPayloads() []TimeBasedPayload {
+	if t.Payloads == nil {
+		return t.TimeBasedPayloads
+	}
+	payloads := make([]TimeBasedPayload, 0, len(t.TimeBasedPayloads))
+	for _, kind := range []string{"SQL", "NoSQL", "Command"} {
+		builtin := make([]string, 0)
+		raw := make(map[string]bool)
+		for _, payload := range t.TimeBasedPayloads {
+			if payload.Kind == kind {
+				builtin = append(builtin, payload.Payload)
+				raw[payload.Payload] = payload.Raw
+			}
+		}
+		for _, payload := range t.Payloads.Payloads(timeBasedCategories[kind], builtin) {
+			isRaw, ok := raw[payload]
+			if !ok {
+				isRaw = rawPayload(payload)
+			}
+			payloads = append(payloads, TimeBasedPayload{Kind: kind, Payload: payload, Raw: isRaw})
+		}
+	}
+	return payloads
+}
+
+// testTimeBasedParameter tests the time-based payloads of each kind in a parameter
+func (t *InjectionTester) testTimeBasedParameter(ctx context.Context, paramName, method string, baseline timingBaseline, build func(TimeBasedPayload, time.Duration) *ffuf.Request, r ffuf.RunnerProvider, result *TestResult) {
+	found := make(map[string]bool)
+	for _, payload := range t.timeBasedPayloads() {
+		if ctx.Err() != nil {
+			return
+		}
+		if found[payload.Kind] {
+			continue // Found a vulnerability, no need to test more payloads of this kind
+		}
+		if build(payload, t.TimeBasedDelay) == nil {
+			continue
+		}
+
+		durations, confirmed := t.confirmDelay(payload, baseline, build, r)
+		if !confirmed {
+			continue
+		}
+		found[payload.Kind] = true
+
+		req := build(payload, t.TimeBasedDelay)
+		observed := make([]string, len(durations))
+		for i, d := range durations {
+			observed[i] = d.Round(time.Millisecond).String()
+		}
+		cwe, remediation := "CWE-89", "Use parameterized queries or prepared statements. Validate and sanitize all user inputs."
+		switch payload.Kind {
+		case "NoSQL":
+			cwe, remediation = "CWE-943", "Validate and sanitize all user inputs. Disable server-side JavaScript execution ($where) and use query builders or ODM/ORM libraries."
+		case "Command":
+			cwe, remediation = "CWE-77", "Avoid using system commands with user input. If necessary, use a whitelist of allowed commands and validate all inputs."
+		}
+
+		vuln := VulnerabilityInfo{
+			Type:        VulnInjection,
+			Name:        fmt.Sprintf("Time-Based Blind %s Injection", payload.Kind),
+			Description: fmt.Sprintf("The API endpoint is vulnerable to time-based blind %s injection attacks.", payload.Kind),
+			Severity:    "Critical",
+			Request:     convertToHTTPRequest(req),
+			Evidence: fmt.Sprintf("Payload '%s' in %s parameter '%s' delayed the response by the injected delay of %s in %d trials (baseline %s ± %s, observed %s)",
+				payload.render(t.TimeBasedDelay), method, paramName, t.TimeBasedDelay, t.TimeBasedTrials,
+				baseline.Mean.Round(time.Millisecond), baseline.StdDev.Round(time.Millisecond), strings.Join(observed, ", ")),
+			Remediation: remediation,
+			CVSS:        9.8,
+			CWE:         cwe,
+			References: []string{
+				"https://owasp.org/API-Security/editions/2019/en/0xa8-injection/",
+				"https://owasp.org/www-community/attacks/Blind_SQL_Injection",
+			},
+			DetectedAt: time.Now(),
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, vuln)
+	}
+}
+
+// confirmDelay runs the trials of a payload, alternating between the configured delay and no
+// delay. The payload is confirmed if every delayed trial exceeds the baseline by the delay and
+// every control trial does not. It returns the response times of the delayed trials.
+func (t *InjectionTester) confirmDelay(payload TimeBasedPayload, baseline timingBaseline, build func(TimeBasedPayload, time.Duration) *ffuf.Request, r ffuf.RunnerProvider) ([]time.Duration, bool) {
+	threshold := baseline.threshold(t.TimeBasedDelay)
+	var durations []time.Duration
+	for i := 0; i < t.TimeBasedTrials; i++ {
+		// Delayed trial
+		resp, err := r.Execute(build(payload, t.TimeBasedDelay))
+		if err != nil || resp.Duration < threshold {
+			return nil, false
+		}
+		durations = append(durations, resp.Duration)
+
+		// Control trial without delay
+		resp, err = r.Execute(build(payload, 0))
+		if err != nil || resp.Duration >= threshold {
+			return nil, false
+		}
+	}
+	return durations, len(durations) > 0
+}
+
+// measureTiming measures the mean and standard deviation of the response time of a request. The
+// response times are measured by the runner, excluding the time spent waiting for the scheduler.
+func (t *InjectionTester) measureTiming(req *ffuf.Request, r ffuf.RunnerProvider) (timingBaseline, bool) {
+	var samples []float64
+	for i := 0; i < t.TimeBasedBaselineSamples; i++ {
+		resp, err := r.Execute(req)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, float64(resp.Duration))
+	}
+	if len(samples) == 0 {
+		return timingBaseline{}, false
+	}
+
+	var sum float64
+	for _, s := range samples {
+		sum += s
+	}
+	mean := sum / float64(len(samples))
+	var variance float64
+	for _, s := range samples {
+		variance += (s - mean) * (s - mean)
+	}
+	variance /= float64(len(samples))
+
+	return timingBaseline{
+		Mean:   time.Duration(mean),
+		StdDev: time.Duration(math.Sqrt(variance)),
+	}, true
+}
@@ -0,0 +1,90 @@
+package security
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// timeBasedFindings returns the time-based findings of a result
+func timeBasedFindings(result *TestResult) []VulnerabilityInfo {
+	var findings []VulnerabilityInfo
+	for _, vuln := range result.Vulnerabilities {
+		if strings.HasPrefix(vuln.Name, "Time-Based Blind") {
+			findings = append(findings, vuln)
+		}
+	}
+	return findings
+}
+
+// sleepServer answers in base, or base plus the delay of the SLEEP payloads of the id query
+// parameter. The response times are reported by the runner, no request is actually delayed.
+func sleepServer(base time.Duration) func(req *ffuf.Request) ffuf.Response {
+	return func(req *ffuf.Request) ffuf.Response {
+		resp := ffuf.Response{Data: []byte(`{"id":1}`), Duration: base}
+		u, _ := url.Parse(req.Url)
+		if strings.Contains(u.Query().Get("id"), "SLEEP(5)") {
+			resp.Duration += 5 * time.Second
+		}
+		return resp
+	}
+}
+
+func TestInjectionTester_TimeBased(t *testing.T) {
+	conf := newFakeConfig(t, "https://api.example.com/users?id=1")
+
+	// The delayed trials exceed the baseline by the delay, the control trials do not
+	result, _ := runFake(t, NewInjectionTester(), conf, sleepServer(100*time.Millisecond))
+	findings := timeBasedFindings(result)
+	if len(findings) != 1 || findings[0].Name != "Time-Based Blind SQL Injection" {
+		t.Fatalf("Expected the SQL injection of the query parameter to be reported, got %v", vulnerabilityNames(result))
+	}
+	if !strings.Contains(findings[0].Evidence, "GET parameter 'id'") || !strings.Contains(findings[0].Evidence, "observed 5.1s, 5.1s, 5.1s") {
+		t.Errorf("Expected the response times of the delayed trials as evidence, got %s", findings[0].Evidence)
+	}
+
+	// Slow responses without injection are not reported
+	result, _ = runFake(t, NewInjectionTester(), conf, func(req *ffuf.Request) ffuf.Response {
+		return ffuf.Response{Data: []byte(`{"id":1}`), Duration: 6 * time.Second}
+	})
+	if findings := timeBasedFindings(result); len(findings) != 0 {
+		t.Errorf("Expected no time-based finding for a slow endpoint, got %s", findings[0].Evidence)
+	}
+
+	// Payloads slowing the endpoint whatever their delay are not reported
+	result, _ = runFake(t, NewInjectionTester(), conf, func(req *ffuf.Request) ffuf.Response {
+		resp := sleepServer(100 * time.Millisecond)(req)
+		u, _ := url.Parse(req.Url)
+		if strings.Contains(u.Query().Get("id"), "SLEEP") {
+			resp.Duration = 5100 * time.Millisecond
+		}
+		return resp
+	})
+	if findings := timeBasedFindings(result); len(findings) != 0 {
+		t.Errorf("Expected no time-based finding for a delayed control trial, got %s", findings[0].Evidence)
+	}
+}
+
+func TestInjectionTester_TimeBasedDelay(t *testing.T) {
+	if rendered := (TimeBasedPayload{Payload: "SLEEP({delay}) {delay_ms}"}).render(3 * time.Second); rendered != "SLEEP(3) 3000" {
+		t.Errorf("Expected the delay in seconds and milliseconds, got %s", rendered)
+	}
+
+	conf := newFakeConfig(t, "https://api.example.com/users?id=1")
+	for _, delay := range []time.Duration{0, 500 * time.Millisecond, 1500 * time.Millisecond} {
+		tester := NewInjectionTester()
+		tester.TimeBasedDelay = delay
+		if _, err := tester.Test(conf.Context, conf); err == nil || !strings.Contains(err.Error(), "expected a whole number of seconds") {
+			t.Errorf("Expected a delay of %s to fail, got %v", delay, err)
+		}
+	}
+
+	// The delay is not used if the time-based test is disabled
+	tester := NewInjectionTester()
+	tester.TestTimeBased = false
+	tester.TimeBasedDelay = 0
+	runFake(t, tester, conf, sleepServer(0))
+}
@@ -8,8 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ffuf/ffuf/v2/pkg/api/diff"
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
-	"github.com/ffuf/ffuf/v2/pkg/runner"
 )
 
 // APIVersionAbuseTester implements testing for API versioning abuse
@@ -76,7 +76,7 @@ func (t *APIVersionAbuseTester) Test(ctx context.Context, config *ffuf.Config) (
 	}
 
 	// Create a runner for making HTTP requests
-	r := runner.NewSimpleRunner(config, false)
+	r := newTestRunner(ctx, config)
 
 	// Extract the base URL from the config
 	baseURL := extractBaseURL(config.Url)
@@ -94,30 +94,40 @@ func (t *APIVersionAbuseTester) Test(ctx context.Context, config *ffuf.Config) (
 
 	// Test each versioned endpoint
 	for _, endpoint := range versionedEndpoints {
+		if ctx.Err() != nil {
+			break
+		}
 		// Extract the current version from the endpoint
 		currentVersion := t.extractVersionFromEndpoint(endpoint)
 		if currentVersion == "" {
 			continue
 		}
 
+		// Request a version which does not exist, to tell other versions from a catch-all response
+		controlEndpoint := t.replaceVersionInEndpoint(endpoint, currentVersion, "v"+controlIdentifier())
+		if controlEndpoint == endpoint {
+			controlEndpoint = ""
+		}
+		control := controlResponse(r, "GET", controlEndpoint, nil)
+
 		// Test for deprecated API versions
 		if t.TestDeprecatedAPIs {
-			t.testDeprecatedVersions(endpoint, currentVersion, r, result)
+			t.testDeprecatedVersions(ctx, endpoint, currentVersion, control, r, result)
 		}
 
 		// Test for beta/alpha API versions
 		if t.TestBetaAPIs {
-			t.testBetaVersions(endpoint, currentVersion, r, result)
+			t.testBetaVersions(ctx, endpoint, currentVersion, control, r, result)
 		}
 
 		// Test for version downgrade vulnerabilities
 		if t.TestVersionDowngrade {
-			t.testVersionDowngrade(endpoint, currentVersion, r, result)
+			t.testVersionDowngrade(ctx, endpoint, currentVersion, control, r, result)
 		}
 
 		// Test for version bypass vulnerabilities
 		if t.TestVersionBypass {
-			t.testVersionBypass(endpoint, currentVersion, r, result)
+			t.testVersionBypass(ctx, endpoint, currentVersion, control, r, result)
 		}
 	}
 
@@ -199,11 +209,14 @@ func (t *APIVersionAbuseTester) extractVersionFromEndpoint(endpoint string) stri
 }
 
 // testDeprecatedVersions tests for deprecated API versions
-func (t *APIVersionAbuseTester) testDeprecatedVersions(endpoint, currentVersion string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *APIVersionAbuseTester) testDeprecatedVersions(ctx context.Context, endpoint, currentVersion string, control *ffuf.Response, r ffuf.RunnerProvider, result *TestResult) {
 	// Deprecated versions are typically older versions
 	deprecatedVersions := t.getDeprecatedVersions(currentVersion)
 
 	for _, version := range deprecatedVersions {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a modified endpoint with the deprecated version
 		modifiedEndpoint := t.replaceVersionInEndpoint(endpoint, currentVersion, version)
 		if modifiedEndpoint == endpoint {
@@ -215,7 +228,7 @@ func (t *APIVersionAbuseTester) testDeprecatedVersions(endpoint, currentVersion
 			Method: "GET",
 			Url:    modifiedEndpoint,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
@@ -226,7 +239,7 @@ func (t *APIVersionAbuseTester) testDeprecatedVersions(endpoint, currentVersion
 		}
 
 		// Check if the response indicates a successful access to a deprecated version
-		if isSuccessfulAccess(resp) {
+		if isSuccessfulAccess(resp) && !matchesControl(control, resp) {
 			// Create a vulnerability info
 			vuln := VulnerabilityInfo{
 				Type:        VulnImproperAssetsMgmt,
@@ -251,11 +264,14 @@ func (t *APIVersionAbuseTester) testDeprecatedVersions(endpoint, currentVersion
 }
 
 // testBetaVersions tests for beta/alpha API versions
-func (t *APIVersionAbuseTester) testBetaVersions(endpoint, currentVersion string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *APIVersionAbuseTester) testBetaVersions(ctx context.Context, endpoint, currentVersion string, control *ffuf.Response, r ffuf.RunnerProvider, result *TestResult) {
 	// Beta/alpha versions
 	betaVersions := []string{"beta", "alpha", "dev", "test", "nightly", "preview", "rc", "snapshot"}
 
 	for _, version := range betaVersions {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a modified endpoint with the beta version
 		modifiedEndpoint := t.replaceVersionInEndpoint(endpoint, currentVersion, version)
 		if modifiedEndpoint == endpoint {
@@ -267,7 +283,7 @@ func (t *APIVersionAbuseTester) testBetaVersions(endpoint, currentVersion string
 			Method: "GET",
 			Url:    modifiedEndpoint,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
@@ -278,7 +294,7 @@ func (t *APIVersionAbuseTester) testBetaVersions(endpoint, currentVersion string
 		}
 
 		// Check if the response indicates a successful access to a beta version
-		if isSuccessfulAccess(resp) {
+		if isSuccessfulAccess(resp) && !matchesControl(control, resp) {
 			// Create a vulnerability info
 			vuln := VulnerabilityInfo{
 				Type:        VulnImproperAssetsMgmt,
@@ -303,7 +319,7 @@ func (t *APIVersionAbuseTester) testBetaVersions(endpoint, currentVersion string
 }
 
 // testVersionDowngrade tests for version downgrade vulnerabilities
-func (t *APIVersionAbuseTester) testVersionDowngrade(endpoint, currentVersion string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *APIVersionAbuseTester) testVersionDowngrade(ctx context.Context, endpoint, currentVersion string, control *ffuf.Response, r ffuf.RunnerProvider, result *TestResult) {
 	// Get older versions for downgrade testing
 	olderVersions := t.getOlderVersions(currentVersion)
 
@@ -312,7 +328,7 @@ func (t *APIVersionAbuseTester) testVersionDowngrade(endpoint, currentVersion st
 		Method: "GET",
 		Url:    endpoint,
 		Headers: map[string]string{
-			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+			"User-Agent": testerUserAgent,
 		},
 	}
 
@@ -323,6 +339,9 @@ func (t *APIVersionAbuseTester) testVersionDowngrade(endpoint, currentVersion st
 
 	// Test each older version
 	for _, version := range olderVersions {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a modified endpoint with the older version
 		modifiedEndpoint := t.replaceVersionInEndpoint(endpoint, currentVersion, version)
 		if modifiedEndpoint == endpoint {
@@ -334,7 +353,7 @@ func (t *APIVersionAbuseTester) testVersionDowngrade(endpoint, currentVersion st
 			Method: "GET",
 			Url:    modifiedEndpoint,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
@@ -346,7 +365,7 @@ func (t *APIVersionAbuseTester) testVersionDowngrade(endpoint, currentVersion st
 
 		// Check if the response indicates a successful access to an older version
 		// and if the response is different from the baseline (indicating different behavior)
-		if isSuccessfulAccess(resp) && t.isResponseDifferent(baselineResp, resp) {
+		if isSuccessfulAccess(resp) && !matchesControl(control, resp) && t.isResponseDifferent(baselineResp, resp) {
 			// Create a vulnerability info
 			vuln := VulnerabilityInfo{
 				Type:        VulnImproperAssetsMgmt,
@@ -371,7 +390,7 @@ func (t *APIVersionAbuseTester) testVersionDowngrade(endpoint, currentVersion st
 }
 
 // testVersionBypass tests for version bypass vulnerabilities
-func (t *APIVersionAbuseTester) testVersionBypass(endpoint, currentVersion string, r ffuf.RunnerProvider, result *TestResult) {
+func (t *APIVersionAbuseTester) testVersionBypass(ctx context.Context, endpoint, currentVersion string, control *ffuf.Response, r ffuf.RunnerProvider, result *TestResult) {
 	// Version bypass techniques
 	bypassVersions := []string{
 		"v999", "v999.999", // Extremely high version
@@ -387,7 +406,7 @@ func (t *APIVersionAbuseTester) testVersionBypass(endpoint, currentVersion strin
 		Method: "GET",
 		Url:    endpoint,
 		Headers: map[string]string{
-			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+			"User-Agent": testerUserAgent,
 		},
 	}
 
@@ -399,6 +418,9 @@ func (t *APIVersionAbuseTester) testVersionBypass(endpoint, currentVersion strin
 
 	// Test each bypass version
 	for _, version := range bypassVersions {
+		if ctx.Err() != nil {
+			return
+		}
 		// Create a modified endpoint with the bypass version
 		modifiedEndpoint := t.replaceVersionInEndpoint(endpoint, currentVersion, version)
 		if modifiedEndpoint == endpoint {
@@ -410,7 +432,7 @@ func (t *APIVersionAbuseTester) testVersionBypass(endpoint, currentVersion strin
 			Method: "GET",
 			Url:    modifiedEndpoint,
 			Headers: map[string]string{
-				"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+				"User-Agent": testerUserAgent,
 			},
 		}
 
@@ -421,7 +443,7 @@ func (t *APIVersionAbuseTester) testVersionBypass(endpoint, currentVersion strin
 		}
 
 		// Check if the response indicates a successful access with a bypass version
-		if isSuccessfulAccess(resp) {
+		if isSuccessfulAccess(resp) && !matchesControl(control, resp) {
 			// Create a vulnerability info
 			vuln := VulnerabilityInfo{
 				Type:        VulnImproperAssetsMgmt,
@@ -568,43 +590,10 @@ func (t *APIVersionAbuseTester) replaceVersionInEndpoint(endpoint, oldVersion, n
 	return modifiedEndpoint
 }
 
-// isResponseDifferent checks if two responses are significantly different
+// isResponseDifferent checks if two responses are significantly different, once volatile values
+// such as timestamps and request identifiers are normalized
 func (t *APIVersionAbuseTester) isResponseDifferent(resp1, resp2 ffuf.Response) bool {
-	// Check if status codes are different
-	if resp1.StatusCode != resp2.StatusCode {
-		return true
-	}
-
-	// Check if content lengths are significantly different (more than 10% difference)
-	if resp1.ContentLength > 0 && resp2.ContentLength > 0 {
-		ratio := float64(resp1.ContentLength) / float64(resp2.ContentLength)
-		if ratio < 0.9 || ratio > 1.1 {
-			return true
-		}
-	}
-
-	// Check if content types are different
-	if resp1.ContentType != resp2.ContentType {
-		return true
-	}
-
-	// Check if response bodies are different (simple check)
-	if len(resp1.Data) > 0 && len(resp2.Data) > 0 {
-		// Compare first 100 characters
-		maxLen := 100
-		if len(resp1.Data) < maxLen {
-			maxLen = len(resp1.Data)
-		}
-		if len(resp2.Data) < maxLen {
-			maxLen = len(resp2.Data)
-		}
-
-		if string(resp1.Data[:maxLen]) != string(resp2.Data[:maxLen]) {
-			return true
-		}
-	}
-
-	return false
+	return !diff.SameResource(&resp1, &resp2, nil)
 }
 
 func init() {
@@ -0,0 +1,301 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// WAF verdicts of findings
+const (
+	// WAFBlocked marks a finding whose response is a block page of the WAF, likely a false
+	// positive
+	WAFBlocked = "blocked"
+	// WAFPassed marks a finding whose payload passed the WAF
+	WAFPassed = "passed"
+)
+
+// wafProbe is the query parameter sent with attack payloads to provoke the WAF
+const wafProbe = "ffufwaf"
+
+// wafAttack is the value of the probe parameter, matching the rules of most WAFs
+const wafAttack = "<script>alert(1)</script>' UNION SELECT NULL,NULL-- ../../../../etc/passwd"
+
+// wafBlockStatuses are the statuses WAFs block requests with
+var wafBlockStatuses = map[int64]bool{403: true, 406: true, 419: true, 429: true, 501: true, 999: true}
+
+// WAFSignature identifies a web application firewall from its responses
+type WAFSignature struct {
+	// Name is the name of the WAF
+	Name string
+	// Headers are headers of the responses of the WAF, by name, with a regexp matching their
+	// value, nil for any value
+	Headers map[string]*regexp.Regexp
+	// Cookies are prefixes of the names of the cookies set by the WAF
+	Cookies []string
+	// Block matches the block page of the WAF
+	Block *regexp.Regexp
+	// Tampers is the tamper chain enabled when the WAF blocks the attack payloads
+	Tampers []string
+}
+
+// WAFSignatures are the signatures of the detected WAFs
+var WAFSignatures = []*WAFSignature{
+	{
+		Name:    "Cloudflare",
+		Headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)cloudflare`), "Cf-Ray": nil},
+		Cookies: []string{"__cfduid", "__cf_bm", "cf_clearance"},
+		Block:   regexp.MustCompile(`(?i)attention required! \| cloudflare|cloudflare ray id|cf-error-details`),
+		Tampers: []string{"randomcase", "space2comment"},
+	},
+	{
+		Name:    "AWS WAF",
+		Headers: map[string]*regexp.Regexp{"X-Amzn-Waf-Action": nil, "Server": regexp.MustCompile(`(?i)awselb`)},
+		Cookies: []string{"aws-waf-token"},
+		Block:   regexp.MustCompile(`(?is)request blocked.*(cloudfront|aws)`),
+		Tampers: []string{"space2comment", "randomcase"},
+	},
+	{
+		Name:    "Akamai",
+		Headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)akamaighost`), "Akamai-Grn": nil},
+		Cookies: []string{"ak_bmsc", "bm_sz"},
+		Block:   regexp.MustCompile(`(?is)access denied.*reference\s*#[0-9a-f.]+`),
+		Tampers: []string{"randomcase", "commentkeywords"},
+	},
+	{
+		Name:    "Imperva Incapsula",
+		Headers: map[string]*regexp.Regexp{"X-Iinfo": nil, "X-Cdn": regexp.MustCompile(`(?i)incapsula`)},
+		Cookies: []string{"incap_ses_", "visid_incap_"},
+		Block:   regexp.MustCompile(`(?i)incapsula incident id|_incapsula_resource`),
+		Tampers: []string{"randomcase", "space2comment", "commentkeywords"},
+	},
+	{
+		Name:    "F5 BIG-IP ASM",
+		Headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)big-?ip`)},
+		Cookies: []string{"TS01", "BIGipServer"},
+		Block:   regexp.MustCompile(`(?i)the requested url was rejected\. please consult with your administrator`),
+		Tampers: []string{"randomcase", "space2comment"},
+	},
+	{
+		Name:    "ModSecurity",
+		Headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)mod_security|noyb`)},
+		Block:   regexp.MustCompile(`(?i)mod_security|modsecurity|not acceptable!`),
+		Tampers: []string{"versionedkeywords", "space2comment"},
+	},
+	{
+		Name:    "Sucuri",
+		Headers: map[string]*regexp.Regexp{"Server": regexp.MustCompile(`(?i)sucuri`), "X-Sucuri-Id": nil},
+		Block:   regexp.MustCompile(`(?i)sucuri website firewall`),
+		Tampers: []string{"randomcase", "space2comment"},
+	},
+	{
+		Name:    "Barracuda",
+		Cookies: []string{"barra_counter_session", "BNI__BARRACUDA_LB_COOKIE"},
+		Block:   regexp.MustCompile(`(?i)barracuda`),
+		Tampers: []string{"randomcase", "space2comment"},
+	},
+	{
+		Name:    "Fortinet FortiWeb",
+		Cookies: []string{"FORTIWAFSID"},
+		Block:   regexp.MustCompile(`(?i)\.fgd_icon|fortiweb`),
+		Tampers: []string{"randomcase", "space2comment"},
+	},
+	{
+		Name:    "Azure WAF",
+		Headers: map[string]*regexp.Regexp{"X-Azure-Ref": nil},
+		Block:   regexp.MustCompile(`(?i)the request is blocked|microsoft-azure-application-gateway`),
+		Tampers: []string{"randomcase", "space2comment"},
+	},
+	{
+		Name:    "Wordfence",
+		Block:   regexp.MustCompile(`(?i)generated by wordfence`),
+		Tampers: []string{"randomcase", "space2comment"},
+	},
+}
+
+// genericWAF is the signature of a WAF blocking the attack payloads without a known signature
+var genericWAF = &WAFSignature{Name: "Unknown WAF", Tampers: []string{"randomcase", "space2comment"}}
+
+// WAFDetection is a web application firewall detected in front of a target
+type WAFDetection struct {
+	// Name is the name of the WAF
+	Name string
+	// Evidence describes the responses the WAF was detected from
+	Evidence string
+	// Blocking is true if the WAF blocked the attack payloads
+	Blocking bool
+	// BlockStatus is the status of the responses blocked by the WAF
+	BlockStatus int64
+	// Tampers is the tamper chain suited to the WAF
+	Tampers []string
+
+	// signature is the signature of the WAF, blockPage is true if blocked responses are matched
+	// by the block page of the signature instead of their status
+	signature *WAFSignature
+	blockPage bool
+}
+
+// Blocked returns true if a response was blocked by the WAF
+func (d *WAFDetection) Blocked(status int64, body []byte) bool {
+	if d == nil || !d.Blocking {
+		return false
+	}
+	if d.blockPage {
+		return d.signature.Block.Match(body)
+	}
+	return status == d.BlockStatus
+}
+
+// Verdict returns whether the response of a finding was blocked by the WAF or passed it
+func (d *WAFDetection) Verdict(resp *http.Response) string {
+	if resp == nil {
+		return WAFPassed
+	}
+	var body []byte
+	if resp.Body != nil {
+		body, _ = io.ReadAll(resp.Body)
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if d.Blocked(int64(resp.StatusCode), body) {
+		return WAFBlocked
+	}
+	return WAFPassed
+}
+
+// String describes the detection
+func (d *WAFDetection) String() string {
+	if !d.Blocking {
+		return fmt.Sprintf("%s (%s, not blocking)", d.Name, d.Evidence)
+	}
+	return fmt.Sprintf("%s (%s)", d.Name, d.Evidence)
+}
+
+// wafDetectorKey is the context key of the WAF detector shared by the runs of a scan
+type wafDetectorKey struct{}
+
+// WAFDetector fingerprints the web application firewalls in front of targets, once per host
+type WAFDetector struct {
+	mu         sync.Mutex
+	detections map[string]*WAFDetection
+}
+
+// NewWAFDetector creates a new WAFDetector
+func NewWAFDetector() *WAFDetector {
+	return &WAFDetector{detections: make(map[string]*WAFDetection)}
+}
+
+// WithWAFDetector returns a context that makes the security test runs it is passed to share
+// the WAF detections of the detector
+func WithWAFDetector(ctx context.Context, d *WAFDetector) context.Context {
+	return context.WithValue(ctx, wafDetectorKey{}, d)
+}
+
+// Detect fingerprints the WAF in front of the target of the config from a request to the
+// target and a request with attack payloads. It returns nil if no WAF is detected, or if the
+// target does not respond.
+func (d *WAFDetector) Detect(r ffuf.RunnerProvider, config *ffuf.Config) *WAFDetection {
+	u, err := url.Parse(config.Url)
+	if err != nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if detection, ok := d.detections[u.Host]; ok {
+		return detection
+	}
+
+	var detection *WAFDetection
+	baseline, err := r.Execute(wafRequest(config, u, ""))
+	if err == nil {
+		attack, err := r.Execute(wafRequest(config, u, wafAttack))
+		if err == nil {
+			detection = detectWAF(&baseline, &attack)
+		}
+	}
+	d.detections[u.Host] = detection
+	return detection
+}
+
+// wafRequest returns a GET request to the target, with the probe parameter if attack is set
+func wafRequest(config *ffuf.Config, u *url.URL, attack string) *ffuf.Request {
+	req := ffuf.NewRequest(config)
+	req.Method = "GET"
+	target := *u
+	if attack != "" {
+		query := target.Query()
+		query.Set(wafProbe, attack)
+		target.RawQuery = query.Encode()
+	}
+	req.Url = target.String()
+	for name, value := range config.Headers {
+		req.Headers[name] = value
+	}
+	return &req
+}
+
+// detectWAF detects a WAF from the response to a request and to the same request with attack
+// payloads
+func detectWAF(baseline, attack *ffuf.Response) *WAFDetection {
+	blocked := attack.StatusCode != baseline.StatusCode && wafBlockStatuses[attack.StatusCode]
+	for _, signature := range WAFSignatures {
+		detection := &WAFDetection{Name: signature.Name, Tampers: signature.Tampers, signature: signature}
+		if signature.Block != nil && signature.Block.Match(attack.Data) && !signature.Block.Match(baseline.Data) {
+			detection.Evidence = fmt.Sprintf("block page with status %d", attack.StatusCode)
+			detection.Blocking = true
+			detection.BlockStatus = attack.StatusCode
+			detection.blockPage = true
+			return detection
+		}
+		if evidence := signatureEvidence(signature, baseline, attack); evidence != "" {
+			detection.Evidence = evidence
+			if blocked {
+				detection.Evidence += fmt.Sprintf(", status %d to attack payloads", attack.StatusCode)
+				detection.Blocking = true
+				detection.BlockStatus = attack.StatusCode
+			}
+			return detection
+		}
+	}
+	if blocked {
+		return &WAFDetection{
+			Name:        genericWAF.Name,
+			Evidence:    fmt.Sprintf("status %d to attack payloads instead of %d", attack.StatusCode, baseline.StatusCode),
+			Blocking:    true,
+			BlockStatus: attack.StatusCode,
+			Tampers:     genericWAF.Tampers,
+			signature:   genericWAF,
+		}
+	}
+	return nil
+}
+
+// signatureEvidence returns the header or cookie of the responses matching a signature, or an
+// empty string if none does
+func signatureEvidence(signature *WAFSignature, responses ...*ffuf.Response) string {
+	for _, resp := range responses {
+		header := http.Header(resp.Headers)
+		for name, value := range signature.Headers {
+			for _, v := range header.Values(name) {
+				if value == nil || value.MatchString(v) {
+					return fmt.Sprintf("header %s: %s", name, v)
+				}
+			}
+		}
+		for _, cookie := range header.Values("Set-Cookie") {
+			for _, prefix := range signature.Cookies {
+				if strings.HasPrefix(strings.TrimSpace(cookie), prefix) {
+					return "cookie " + strings.SplitN(cookie, "=", 2)[0]
+				}
+			}
+		}
+	}
+	return ""
+}
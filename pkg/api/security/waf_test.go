@@ -0,0 +1,74 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestWAFDetector(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Header().Set("Server", "Apache")
+		q := strings.ToLower(r.URL.Query().Get("q") + r.URL.Query().Get("ffufwaf"))
+		// The WAF blocks SQL keywords separated by spaces, tautologies and path traversals
+		if strings.Contains(q, " select") || strings.Contains(q, " or ") || strings.Contains(q, "1=1") || strings.Contains(q, "'1'='1") || strings.Contains(q, "/etc/passwd") {
+			w.WriteHeader(http.StatusNotAcceptable)
+			w.Write([]byte("<h1>Not Acceptable!</h1>An appropriate representation was not found. This error was generated by Mod_Security."))
+			return
+		}
+		if strings.Contains(q, "'") {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("You have an error in your SQL syntax"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// The payloads are tampered with to evade the detected WAF
+	registry := NewSecurityTestRegistry()
+	registry.Register(NewInjectionTester())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conf := ffuf.NewConfig(ctx, cancel)
+	conf.Url = server.URL + "/search?q=test"
+	conf.Threads = 2
+	conf.APISecurityPayloadCategories = []string{PayloadSQLiError}
+	conf.APISecurityWAF = true
+	detector := NewWAFDetector()
+	results, err := registry.RunAll(WithWAFDetector(ctx, detector), &conf)
+	if err != nil {
+		t.Fatalf("RunAll returned an error: %s", err)
+	}
+	if len(results) != 1 || results[0].WAF != "ModSecurity" || results[0].WAFBlocked == 0 {
+		t.Fatalf("Expected ModSecurity to be detected and to block requests, got %+v", results[0])
+	}
+	vulns := results[0].Vulnerabilities
+	if len(vulns) == 0 || vulns[0].Name != "SQL Injection" || vulns[0].WAF != WAFPassed || !strings.Contains(vulns[0].Evidence, "/**/") {
+		t.Fatalf("Expected a SQL injection found with tampered payloads passing the WAF, got %+v", vulns)
+	}
+
+	// The WAF of a host is fingerprinted once
+	mu.Lock()
+	sent := requests
+	mu.Unlock()
+	if _, err := registry.RunAll(WithWAFDetector(ctx, detector), &conf); err != nil {
+		t.Fatalf("RunAll returned an error: %s", err)
+	}
+	mu.Lock()
+	if requests-sent != sent-2 {
+		t.Errorf("Expected the second run to send %d requests, sent %d", sent-2, requests-sent)
+	}
+	mu.Unlock()
+}
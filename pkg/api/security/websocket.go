@@ -0,0 +1,251 @@
+// Package security provides testing modules for API security vulnerabilities.
+package security
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/parser"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// WebSocketOriginTester implements testing for WebSocket endpoints accepting handshakes from
+// other origins, which allows cross-site WebSocket hijacking
+type WebSocketOriginTester struct {
+	// Configuration options
+	Endpoints      []string
+	AsyncAPISpec   string
+	WebSocketPaths []string
+	TrustedOrigin  string
+}
+
+// NewWebSocketOriginTester creates a new tester for the origin checks of WebSocket endpoints
+func NewWebSocketOriginTester() *WebSocketOriginTester {
+	return &WebSocketOriginTester{
+		WebSocketPaths: []string{"/ws", "/websocket", "/socket", "/api/ws", "/cable"},
+	}
+}
+
+// GetType returns the type of vulnerability this tester checks for
+func (t *WebSocketOriginTester) GetType() VulnerabilityType {
+	return VulnWebSocket
+}
+
+// GetName returns the name of the security test
+func (t *WebSocketOriginTester) GetName() string {
+	return "WebSocket Origin Check"
+}
+
+// GetDescription returns a description of the security test
+func (t *WebSocketOriginTester) GetDescription() string {
+	return "Tests if WebSocket endpoints accept handshakes from arbitrary origins, the null origin or origins bypassing a prefix or suffix check, allowing cross-site WebSocket hijacking."
+}
+
+// Test runs the security test against the target
+func (t *WebSocketOriginTester) Test(ctx context.Context, config *ffuf.Config) (*TestResult, error) {
+	result := &TestResult{
+		TestName:  t.GetName(),
+		StartTime: time.Now(),
+	}
+
+	endpoints, err := t.candidateEndpoints(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a runner for making WebSocket handshakes
+	r := newTestRunner(ctx, config)
+
+	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
+		trusted := t.trustedOrigin(config, endpoint)
+		control := configRequest(config, endpoint)
+		control.Data = nil
+		control.Headers["Origin"] = trusted
+		controlResp, err := r.Execute(control)
+		if err != nil || !isAcceptedHandshake(controlResp) {
+			continue
+		}
+		t.testOrigins(ctx, control, trusted, r, result)
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	return result, nil
+}
+
+// candidateEndpoints returns the WebSocket endpoints to test: the target URL if it is a
+// WebSocket URL or the WebSocket paths of its host, the configured endpoints and the
+// WebSocket channels of the AsyncAPI specification
+func (t *WebSocketOriginTester) candidateEndpoints(config *ffuf.Config) ([]string, error) {
+	candidates := make([]string, 0)
+	for _, endpoint := range extractEndpointsFromConfig(config) {
+		if isWebSocketURL(endpoint) {
+			candidates = append(candidates, endpoint)
+			continue
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		scheme := "ws"
+		if u.Scheme == "https" {
+			scheme = "wss"
+		}
+		for _, path := range t.WebSocketPaths {
+			candidates = append(candidates, (&url.URL{Scheme: scheme, Host: u.Host, Path: path}).String())
+		}
+	}
+	candidates = append(candidates, t.Endpoints...)
+
+	if t.AsyncAPISpec != "" {
+		spec := parser.NewAsyncAPIParser()
+		if err := spec.ParseFromFile(t.AsyncAPISpec); err != nil {
+			return nil, err
+		}
+		for _, endpoint := range spec.GetEndpoints() {
+			// Channels with parameters cannot be opened without their values
+			if isWebSocketURL(endpoint.URL) && !strings.Contains(endpoint.URL, "{") {
+				candidates = append(candidates, endpoint.URL)
+			}
+		}
+	}
+
+	endpoints := make([]string, 0, len(candidates))
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if !seen[candidate] {
+			seen[candidate] = true
+			endpoints = append(endpoints, candidate)
+		}
+	}
+	return endpoints, nil
+}
+
+// trustedOrigin returns the origin allowed to open an endpoint: the configured trusted
+// origin, the Origin header of the requests, or the origin of the endpoint itself
+func (t *WebSocketOriginTester) trustedOrigin(config *ffuf.Config, endpoint string) string {
+	if t.TrustedOrigin != "" {
+		return t.TrustedOrigin
+	}
+	for name, value := range config.Headers {
+		if strings.EqualFold(name, "Origin") {
+			return value
+		}
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	scheme := "http"
+	if u.Scheme == "wss" {
+		scheme = "https"
+	}
+	return scheme + "://" + u.Host
+}
+
+// testOrigins opens the endpoint from untrusted origins. An endpoint accepting an arbitrary
+// origin does not validate origins at all, otherwise accepted variants of the trusted origin
+// bypass the validation.
+func (t *WebSocketOriginTester) testOrigins(ctx context.Context, control *ffuf.Request, trusted string, r ffuf.RunnerProvider, result *TestResult) {
+	req, resp, ok := t.handshake(control, "https://evil.example", r)
+	if ok {
+		result.Vulnerabilities = append(result.Vulnerabilities, t.vulnerability(
+			"WebSocket Origin Not Validated",
+			"The WebSocket endpoint accepts handshakes from any origin. A malicious page visited by a user can open the connection with the cookies of the user and read and send messages on their behalf (cross-site WebSocket hijacking).",
+			"CWE-1385",
+			fmt.Sprintf("The handshake from the origin https://evil.example was accepted with status %d", resp.StatusCode),
+			req, resp))
+		return
+	}
+
+	u, err := url.Parse(trusted)
+	if err != nil || u.Host == "" {
+		return
+	}
+	port := ""
+	if u.Port() != "" {
+		port = ":" + u.Port()
+	}
+	variants := []struct {
+		origin      string
+		description string
+	}{
+		{"null", "the null origin, sent by sandboxed frames and local files"},
+		{u.Scheme + "://" + u.Hostname() + ".evil.example" + port, "a domain starting with the trusted host"},
+		{u.Scheme + "://evil" + u.Host, "a domain ending with the trusted host"},
+		{u.Scheme + "://evil.example" + port + "/" + u.Hostname(), "an origin followed by the trusted host"},
+	}
+	for _, variant := range variants {
+		if ctx.Err() != nil {
+			return
+		}
+		req, resp, ok := t.handshake(control, variant.origin, r)
+		if !ok {
+			continue
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, t.vulnerability(
+			"WebSocket Origin Check Bypass",
+			"The WebSocket endpoint rejects arbitrary origins but accepts origins crafted from the trusted origin. A malicious page served from such an origin can open the connection with the cookies of a user (cross-site WebSocket hijacking).",
+			"CWE-346",
+			fmt.Sprintf("The handshake from the origin %s, %s, was accepted with status %d while https://evil.example was rejected", variant.origin, variant.description, resp.StatusCode),
+			req, resp))
+		return
+	}
+}
+
+// handshake opens the endpoint of the control request from an origin, and returns if the
+// handshake was accepted
+func (t *WebSocketOriginTester) handshake(control *ffuf.Request, origin string, r ffuf.RunnerProvider) (*ffuf.Request, ffuf.Response, bool) {
+	req := ffuf.CopyRequest(control)
+	req.Headers["Origin"] = origin
+	resp, err := r.Execute(&req)
+	if err != nil {
+		return &req, resp, false
+	}
+	return &req, resp, isAcceptedHandshake(resp)
+}
+
+// vulnerability creates a cross-site WebSocket hijacking finding. Endpoints relying on
+// cookies or HTTP authentication sent by browsers are rated higher.
+func (t *WebSocketOriginTester) vulnerability(name, description, cwe, evidence string, req *ffuf.Request, resp ffuf.Response) VulnerabilityInfo {
+	severity, cvss := "Medium", 6.5
+	for header := range req.Headers {
+		if strings.EqualFold(header, "Cookie") || strings.EqualFold(header, "Authorization") {
+			severity, cvss = "High", 8.1
+		}
+	}
+	return VulnerabilityInfo{
+		Type:        VulnWebSocket,
+		Name:        name,
+		Description: description,
+		Severity:    severity,
+		Request:     convertToHTTPRequest(req),
+		Response:    convertToHTTPResponse(resp),
+		Evidence:    evidence,
+		Remediation: "Validate the Origin header of WebSocket handshakes against an exact list of trusted origins, and authenticate connections with a token sent in the first message instead of cookies.",
+		CVSS:        cvss,
+		CWE:         cwe,
+		References: []string{
+			"https://owasp.org/www-project-web-security-testing-guide/latest/4-Web_Application_Security_Testing/11-Client-side_Testing/10-Testing_WebSockets",
+			"https://cwe.mitre.org/data/definitions/" + strings.TrimPrefix(cwe, "CWE-") + ".html",
+		},
+		DetectedAt: time.Now(),
+	}
+}
+
+// isAcceptedHandshake returns true if the server switched to the WebSocket protocol without
+// closing the connection right away
+func isAcceptedHandshake(resp ffuf.Response) bool {
+	return resp.StatusCode == 101 && len(resp.Headers["Websocket-Close-Code"]) == 0
+}
+
+func init() {
+	// Register the tester with the default registry
+	RegisterSecurityTester(NewWebSocketOriginTester())
+}
@@ -0,0 +1,88 @@
+package security
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newWebSocketServer returns a server upgrading the connections to /ws from the origins
+// allowed by a check, sending a welcome message before closing them
+func newWebSocketServer(t *testing.T, allowed func(origin string) bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			http.NotFound(w, r)
+			return
+		}
+		if !allowed(r.Header.Get("Origin")) {
+			http.Error(w, "forbidden origin", http.StatusForbidden)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack failed: %s", err)
+			return
+		}
+		defer conn.Close()
+		hash := sha1.Sum([]byte(r.Header.Get("Sec-Websocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(hash[:]))
+		welcome := []byte(`{"type":"welcome"}`)
+		rw.Write(append([]byte{0x81, byte(len(welcome))}, welcome...))
+		rw.Flush()
+	}))
+}
+
+// runWebSocketOrigins runs the WebSocket origin tester against the host of a server
+func runWebSocketOrigins(t *testing.T, server *httptest.Server, tester *WebSocketOriginTester) *TestResult {
+	t.Helper()
+	conf := newFakeConfig(t, server.URL+"/")
+	conf.Headers = map[string]string{"Cookie": "session=abc"}
+	result, err := tester.Test(conf.Context, conf)
+	if err != nil {
+		t.Fatalf("Test returned an error: %s", err)
+	}
+	return result
+}
+
+func TestWebSocketOriginTester_AnyOrigin(t *testing.T) {
+	server := newWebSocketServer(t, func(origin string) bool { return true })
+	defer server.Close()
+	result := runWebSocketOrigins(t, server, NewWebSocketOriginTester())
+	if names := vulnerabilityNames(result); len(names) != 1 || names[0] != "WebSocket Origin Not Validated" {
+		t.Fatalf("Expected the missing origin check to be reported, got %v", names)
+	}
+	vuln := result.Vulnerabilities[0]
+	if vuln.Evidence != "The handshake from the origin https://evil.example was accepted with status 101" {
+		t.Errorf("Expected the accepted origin as evidence, got %s", vuln.Evidence)
+	}
+	if vuln.Severity != "High" || !strings.HasSuffix(vuln.Request.URL.String(), "/ws") {
+		t.Errorf("Expected a high severity handshake to /ws with the cookie, got %s to %s", vuln.Severity, vuln.Request.URL)
+	}
+}
+
+func TestWebSocketOriginTester_OriginChecks(t *testing.T) {
+	tester := NewWebSocketOriginTester()
+	tester.TrustedOrigin = "https://app.example.com"
+
+	// A prefix check accepts the domains starting with the trusted host
+	server := newWebSocketServer(t, func(origin string) bool { return strings.HasPrefix(origin, "https://app.example.com") })
+	defer server.Close()
+	result := runWebSocketOrigins(t, server, tester)
+	if names := vulnerabilityNames(result); len(names) != 1 || names[0] != "WebSocket Origin Check Bypass" {
+		t.Fatalf("Expected the origin check bypass to be reported, got %v", names)
+	}
+	if evidence := result.Vulnerabilities[0].Evidence; !strings.HasPrefix(evidence, "The handshake from the origin https://app.example.com.evil.example, a domain starting with the trusted host, was accepted") {
+		t.Errorf("Expected the bypassing origin as evidence, got %s", evidence)
+	}
+
+	// An exact check rejects every other origin
+	strict := newWebSocketServer(t, func(origin string) bool { return origin == "https://app.example.com" })
+	defer strict.Close()
+	if result := runWebSocketOrigins(t, strict, tester); len(result.Vulnerabilities) != 0 {
+		t.Errorf("Expected no vulnerability when the other origins are rejected, got %v", vulnerabilityNames(result))
+	}
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+const (
+	// ScopeSecurity is the scope of the requests of security testers
+	ScopeSecurity = "security"
+	// ScopeTestGen is the scope of the requests of generated test cases
+	ScopeTestGen = "testgen"
+)
+
+// Runner wraps a runner to make its requests resumable. The nth identical request of a run
+// is answered with the nth response recorded for it in previous runs, if any. Other requests
+// are executed and their responses recorded.
+type Runner struct {
+	// Scope separates the requests of different modules sharing a state file
+	Scope string
+
+	store  *Store
+	runner ffuf.RunnerProvider
+	seen   map[string]int
+	mu     sync.Mutex
+}
+
+// NewRunner creates a resumable runner recording to a store
+func NewRunner(store *Store, scope string, r ffuf.RunnerProvider) *Runner {
+	return &Runner{
+		Scope:  scope,
+		store:  store,
+		runner: r,
+		seen:   make(map[string]int),
+	}
+}
+
+// Prepare prepares a request using the underlying runner
+func (r *Runner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return r.runner.Prepare(input, basereq)
+}
+
+// Dump dumps a request using the underlying runner
+func (r *Runner) Dump(req *ffuf.Request) ([]byte, error) {
+	return r.runner.Dump(req)
+}
+
+// Execute replays the recorded response of a request, or executes and records it
+func (r *Runner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	key := RequestKey(req)
+
+	r.mu.Lock()
+	n := r.seen[key]
+	r.seen[key]++
+	r.mu.Unlock()
+
+	if recorded := r.store.Responses(r.Scope, key); n < len(recorded) {
+		return recorded[n].ToResponse(req), nil
+	}
+
+	resp, err := r.runner.Execute(req)
+	if err != nil {
+		return resp, err
+	}
+	// Write errors are returned when the store is closed
+	r.store.Record(r.Scope, key, resp)
+	return resp, nil
+}
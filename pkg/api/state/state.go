@@ -0,0 +1,250 @@
+// Package state provides a persistent store of completed scan work, so that interrupted
+// scans can be resumed.
+//
+// The store is an append-only file of JSON lines. Every completed request is recorded
+// together with its response as soon as it is received, so a scan that dies on a network
+// error or is interrupted loses at most the requests in flight. When a scan is resumed,
+// the recorded responses are replayed in order instead of sending the requests again.
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// Record is a completed request stored in the state file
+type Record struct {
+	// Scope identifies the module that sent the request, e.g. "security" or "testgen"
+	Scope string `json:"scope"`
+	// Key identifies the request
+	Key string `json:"key"`
+	// Response is the response received for the request
+	Response *Response `json:"response"`
+	// Timestamp is when the request completed
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Response is the stored part of an ffuf response
+type Response struct {
+	StatusCode      int64               `json:"status_code"`
+	Headers         map[string][]string `json:"headers,omitempty"`
+	Data            []byte              `json:"data,omitempty"`
+	ContentLength   int64               `json:"content_length"`
+	ContentWords    int64               `json:"content_words"`
+	ContentLines    int64               `json:"content_lines"`
+	ContentType     string              `json:"content_type,omitempty"`
+	Duration        time.Duration       `json:"duration"`
+	Truncated       bool                `json:"truncated,omitempty"`
+	BodyHash        string              `json:"body_hash,omitempty"`
+	ContentEncoding string              `json:"content_encoding,omitempty"`
+	Charset         string              `json:"charset,omitempty"`
+}
+
+// Store is a persistent record of completed requests
+type Store struct {
+	// Redactor masks the tokens and personal data of the headers and bodies of the responses
+	// before they are written. The responses replayed from the file are redacted.
+	Redactor *secrets.Redactor
+
+	path      string
+	file      *os.File
+	responses map[string][]*Response
+	err       error
+	mu        sync.Mutex
+}
+
+// Open opens a state file. If resume is true, the records of the file are loaded so that
+// their responses can be replayed, otherwise the file is truncated.
+func Open(path string, resume bool) (*Store, error) {
+	s := &Store{
+		path:      path,
+		responses: make(map[string][]*Response),
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if resume {
+		if err := s.load(); err != nil {
+			return nil, err
+		}
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return nil, api.NewAPIError("Failed to open state file: "+err.Error(), 0)
+	}
+	s.file = file
+	return s, nil
+}
+
+// OpenConfigured opens the state file of the config, redacted with the redaction options of
+// the config, or returns nil if no state file is set
+func OpenConfigured(config *ffuf.Config) (*Store, error) {
+	if config.APIStateFile == "" {
+		return nil, nil
+	}
+	redactor, err := secrets.NewRedactor(config.APIRedact, config.APIRedactPatterns)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Open(config.APIStateFile, config.APIResume)
+	if err != nil {
+		return nil, err
+	}
+	s.Redactor = redactor
+	return s, nil
+}
+
+// load reads the records of the state file. A truncated last line, left by a scan that was
+// killed while writing, is ignored.
+func (s *Store) load() error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return api.NewAPIError("Failed to read state file: "+err.Error(), 0)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 && err == nil {
+			var record Record
+			if jsonErr := json.Unmarshal(line, &record); jsonErr == nil && record.Response != nil {
+				key := record.Scope + ":" + record.Key
+				s.responses[key] = append(s.responses[key], record.Response)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// Responses returns the responses recorded for a request in previous runs
+func (s *Store) Responses(scope, key string) []*Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.responses[scope+":"+key]
+}
+
+// Count returns the number of requests recorded in previous runs
+func (s *Store) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, responses := range s.responses {
+		count += len(responses)
+	}
+	return count
+}
+
+// Record appends a completed request to the state file. The first write error is also
+// returned by Close, so that callers recording in the background can ignore it.
+func (s *Store) Record(scope, key string, resp ffuf.Response) error {
+	stored := NewResponse(resp)
+	if s.Redactor != nil {
+		stored.Headers = s.Redactor.Header(stored.Headers)
+		stored.Data = s.Redactor.Bytes(stored.Data)
+	}
+	line, err := json.Marshal(Record{
+		Scope:     scope,
+		Key:       key,
+		Response:  stored,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return api.NewAPIError("Failed to encode state record: "+err.Error(), 0)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		if s.err == nil {
+			s.err = api.NewAPIError("Failed to write state file: "+err.Error(), 0)
+		}
+		return s.err
+	}
+	return nil
+}
+
+// Close closes the state file and returns the first error that occurred while recording
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.Close(); err != nil && s.err == nil {
+		s.err = api.NewAPIError("Failed to close state file: "+err.Error(), 0)
+	}
+	return s.err
+}
+
+// NewResponse creates a stored response from an ffuf response
+func NewResponse(resp ffuf.Response) *Response {
+	return &Response{
+		StatusCode:      resp.StatusCode,
+		Headers:         resp.Headers,
+		Data:            resp.Data,
+		ContentLength:   resp.ContentLength,
+		ContentWords:    resp.ContentWords,
+		ContentLines:    resp.ContentLines,
+		ContentType:     resp.ContentType,
+		Duration:        resp.Duration,
+		Truncated:       resp.Truncated,
+		BodyHash:        resp.BodyHash,
+		ContentEncoding: resp.ContentEncoding,
+		Charset:         resp.Charset,
+	}
+}
+
+// ToResponse converts a stored response to an ffuf response for a request
+func (r *Response) ToResponse(req *ffuf.Request) ffuf.Response {
+	return ffuf.Response{
+		StatusCode:      r.StatusCode,
+		Headers:         r.Headers,
+		Data:            r.Data,
+		ContentLength:   r.ContentLength,
+		ContentWords:    r.ContentWords,
+		ContentLines:    r.ContentLines,
+		ContentType:     r.ContentType,
+		Duration:        r.Duration,
+		Truncated:       r.Truncated,
+		BodyHash:        r.BodyHash,
+		ContentEncoding: r.ContentEncoding,
+		Charset:         r.Charset,
+		Request:         req,
+		Timestamp:       time.Now(),
+	}
+}
+
+// RequestKey identifies a request by its method, URL, headers and body
+func RequestKey(req *ffuf.Request) string {
+	headers := make([]string, 0, len(req.Headers))
+	for name, value := range req.Headers {
+		headers = append(headers, name+": "+value)
+	}
+	sort.Strings(headers)
+
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.Url + "\n"))
+	for _, header := range headers {
+		h.Write([]byte(header + "\n"))
+	}
+	h.Write([]byte("\n"))
+	h.Write(req.Data)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,149 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// countingRunner answers every request with the number of requests executed so far
+type countingRunner struct {
+	executed int
+}
+
+func (r *countingRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return *basereq, nil
+}
+
+func (r *countingRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	r.executed++
+	return ffuf.Response{StatusCode: 200, Data: []byte(fmt.Sprintf("response %d", r.executed))}, nil
+}
+
+func (r *countingRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return nil, nil
+}
+
+func TestRunner_Resume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.state")
+	first := &ffuf.Request{Method: "GET", Url: "http://example.com/a", Headers: map[string]string{}}
+	second := &ffuf.Request{Method: "POST", Url: "http://example.com/a", Headers: map[string]string{}, Data: []byte("{}")}
+
+	// First run sends the same request twice and another one
+	store, err := Open(path, false)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	inner := &countingRunner{}
+	r := NewRunner(store, ScopeSecurity, inner)
+	r.Execute(first)
+	r.Execute(first)
+	r.Execute(second)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	// Simulate a scan killed while writing a record
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	file.Write([]byte(`{"scope":"security","key":"trunc`))
+	file.Close()
+
+	// Resumed run replays the recorded responses in order and executes new requests
+	store, err = Open(path, true)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	if store.Count() != 3 {
+		t.Errorf("Expected 3 recorded requests, got %d", store.Count())
+	}
+
+	inner = &countingRunner{}
+	r = NewRunner(store, ScopeSecurity, inner)
+	expected := []struct {
+		req  *ffuf.Request
+		data string
+	}{
+		{first, "response 1"},
+		{first, "response 2"},
+		{second, "response 3"},
+		{first, "response 1"},
+	}
+	for i, e := range expected {
+		resp, err := r.Execute(e.req)
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i, err)
+		}
+		if string(resp.Data) != e.data {
+			t.Errorf("Request %d: expected %q, got %q", i, e.data, string(resp.Data))
+		}
+	}
+	if inner.executed != 1 {
+		t.Errorf("Expected only the new request to be executed, got %d", inner.executed)
+	}
+
+	// Responses are not shared between scopes
+	if len(store.Responses(ScopeTestGen, RequestKey(first))) != 0 {
+		t.Error("Expected no responses in another scope")
+	}
+}
+
+func TestOpen_Truncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.state")
+	store, _ := Open(path, false)
+	store.Record(ScopeTestGen, "key", ffuf.Response{StatusCode: 200})
+	store.Close()
+
+	store, err := Open(path, false)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+	if store.Count() != 0 {
+		t.Errorf("Expected a new scan to ignore previous records, got %d", store.Count())
+	}
+	if info, _ := os.Stat(path); info.Size() != 0 {
+		t.Errorf("Expected the state file to be truncated, got %d bytes", info.Size())
+	}
+}
+
+func TestOpenConfigured_Redact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.state")
+	config := &ffuf.Config{APIStateFile: path, APIRedact: []string{"authorization", "emails"}}
+	store, err := OpenConfigured(config)
+	if err != nil {
+		t.Fatalf("OpenConfigured returned an error: %v", err)
+	}
+	resp := ffuf.Response{
+		StatusCode: 200,
+		Headers:    map[string][]string{"Authorization": {"Bearer s3cr3t"}},
+		Data:       []byte(`{"email":"alice@example.com"}`),
+	}
+	store.Record(ScopeSecurity, "key", resp)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	store, err = Open(path, true)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	recorded := store.Responses(ScopeSecurity, "key")
+	if len(recorded) != 1 || strings.Contains(recorded[0].Headers["Authorization"][0], "s3cr3t") || strings.Contains(string(recorded[0].Data), "alice@example.com") {
+		t.Fatalf("Expected the state file to be redacted, got %v", recorded)
+	}
+	// The response of the run is not redacted
+	if resp.Headers["Authorization"][0] != "Bearer s3cr3t" || !strings.Contains(string(resp.Data), "alice@example.com") {
+		t.Errorf("Expected the recorded response to be left as is, got %v %s", resp.Headers, resp.Data)
+	}
+
+	config.APIRedact = []string{"phones"}
+	if _, err := OpenConfigured(config); err == nil || !strings.Contains(err.Error(), "Unknown redaction rule") {
+		t.Errorf("Expected an unknown redaction rule to fail, got %v", err)
+	}
+}
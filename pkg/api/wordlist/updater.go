@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -17,6 +16,7 @@ import (
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/api"
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
 )
 
 // Repository information
@@ -102,7 +102,7 @@ type WordlistUpdater struct {
 	stopBackground    chan struct{}
 	backgroundWg      sync.WaitGroup
 	mutex             sync.Mutex
-	logger            *log.Logger
+	logger            *logging.Logger
 
 	// Function fields for testing
 	checkForUpdatesFn func() (bool, error)
@@ -132,7 +132,7 @@ func NewWordlistUpdater(localPath string) *WordlistUpdater {
 		},
 		backgroundRunning: false,
 		stopBackground:    make(chan struct{}),
-		logger:            log.New(os.Stderr, "[api_wordlist] ", log.LstdFlags),
+		logger:            logging.For("wordlist").With("repository", fmt.Sprintf("%s/%s", owner, name)),
 	}
 
 	// Initialize function fields with default implementations
@@ -503,7 +503,7 @@ func (u *WordlistUpdater) StartBackgroundUpdates() error {
 	u.backgroundWg.Add(1)
 	go func() {
 		defer u.backgroundWg.Done()
-		u.logger.Printf("Starting background updates for api_wordlist repository")
+		u.logger.Info("Starting background updates")
 
 		ticker := time.NewTicker(u.UpdateInterval)
 		defer ticker.Stop()
@@ -511,11 +511,11 @@ func (u *WordlistUpdater) StartBackgroundUpdates() error {
 		// Perform an initial update
 		updated, err := u.AutoUpdate()
 		if err != nil {
-			u.logger.Printf("Error during initial api_wordlist update: %v", err)
+			u.logger.Error("Initial update failed", logging.FieldError, err)
 		} else if updated {
-			u.logger.Printf("Initial api_wordlist update completed successfully")
+			u.logger.Info("Initial update completed")
 		} else {
-			u.logger.Printf("No updates available during initial check")
+			u.logger.Info("No updates available during the initial check")
 		}
 
 		// Main update loop
@@ -525,13 +525,13 @@ func (u *WordlistUpdater) StartBackgroundUpdates() error {
 				// Time to check for updates
 				updated, err := u.AutoUpdate()
 				if err != nil {
-					u.logger.Printf("Error during api_wordlist update: %v", err)
+					u.logger.Error("Update failed", logging.FieldError, err)
 				} else if updated {
-					u.logger.Printf("api_wordlist update completed successfully")
+					u.logger.Info("Update completed")
 				}
 			case <-u.stopBackground:
 				// Stop signal received
-				u.logger.Printf("Stopping background updates for api_wordlist repository")
+				u.logger.Info("Stopping background updates")
 				return
 			}
 		}
@@ -45,6 +45,8 @@ type Config struct {
 	OutputSkipEmptyFile       bool                  `json:"OutputSkipEmptyFile"`
 	ProgressFrequency         int                   `json:"-"`
 	ProxyURL                  string                `json:"proxyurl"`
+	ProxyList                 []string              `json:"proxylist"`
+	Resolve                   []string              `json:"resolve"`
 	Quiet                     bool                  `json:"quiet"`
 	Rate                      int64                 `json:"rate"`
 	Raw                       bool                  `json:"raw"`
@@ -66,6 +68,7 @@ type Config struct {
 	Verbose                   bool                  `json:"verbose"`
 	Wordlists                 []string              `json:"wordlists"`
 	Http2                     bool                  `json:"http2"`
+	HTTPVersion               string                `json:"http_version"`
 	ClientCert                string                `json:"client-cert"`
 	ClientKey                 string                `json:"client-key"`
 	// API-specific options
@@ -82,9 +85,79 @@ type Config struct {
 	APIPayloadFormat          string                `json:"api_payload_format"`
 	APIPayloadTemplate        string                `json:"api_payload_template"`
 	APIPayloadPath            string                `json:"api_payload_path"`
+	APIRequestFuzz            string                `json:"api_request_fuzz"`
+	APIRequestName            string                `json:"api_request_name"`
+	APIVars                   string                `json:"api_vars"`
+	APIEventLog               string                `json:"api_event_log"`
+	APIHARFile                string                `json:"api_har_file"`
+	APIHARMaxBody             int                   `json:"api_har_max_body"`
+	APIHARMaxSize             int                   `json:"api_har_max_size"`
+	APIRedact                 []string              `json:"api_redact"`
+	APIRedactPatterns         []string              `json:"api_redact_patterns"`
+	APILogLevel               string                `json:"api_log_level"`
+	APILogFormat              string                `json:"api_log_format"`
+	APILogFile                string                `json:"api_log_file"`
 	APIParseResponseBody      bool                  `json:"api_parse_response_body"`
 	APIExtractEndpoints       bool                  `json:"api_extract_endpoints"`
 	APIOutputFormat           bool                  `json:"api_output_format"`
+	APISecurityProfile        string                `json:"api_security_profile"`
+	APISecurityInclude        []string              `json:"api_security_include"`
+	APISecurityExclude        []string              `json:"api_security_exclude"`
+	APISecurityOptions        []string              `json:"api_security_options"`
+	APISecurityScoring        string                `json:"api_security_scoring"`
+	APISecurityBudgets        []string              `json:"api_security_budgets"`
+	APISecurityPayloads       []string              `json:"api_security_payloads"`
+	APISecurityPayloadCategories []string           `json:"api_security_payload_categories"`
+	APISecurityPayloadsReplace bool                 `json:"api_security_payloads_replace"`
+	APISecurityTamper         []string              `json:"api_security_tamper"`
+	APISecurityWAF            bool                  `json:"api_security_waf"`
+	APISecurityFingerprint    bool                  `json:"api_security_fingerprint"`
+	APISecurityHeaders        []string              `json:"api_security_headers"`
+	APISecurityProxies        []string              `json:"api_security_proxies"`
+	APISecurityIdentities     []string              `json:"api_security_identities"`
+	APISecurityLoginAttempts  int                   `json:"api_security_login_attempts"`
+	APISecurityAccountSafe    bool                  `json:"api_security_account_safe"`
+	APISecurityOOBURL         string                `json:"api_security_oob_url"`
+	APISecurityOOBListen      string                `json:"api_security_oob_listen"`
+	APISecurityOOBDomain      string                `json:"api_security_oob_domain"`
+	APISecurityOOBDNSListen   string                `json:"api_security_oob_dns_listen"`
+	APIStateFile              string                `json:"api_state_file"`
+	APIResume                 bool                  `json:"api_resume"`
+	APIGRPC                   string                `json:"api_grpc"`
+	APIProtoFile              string                `json:"api_proto_file"`
+	APIProtoMessage           string                `json:"api_proto_message"`
+	APIWebSocket              bool                  `json:"api_websocket"`
+	APIStreamTime             int                   `json:"api_stream_time"`
+	APIStreamEvents           int                   `json:"api_stream_events"`
+	APIAdaptiveRate           bool                  `json:"api_adaptive_rate"`
+	APIBackoffMax             int                   `json:"api_backoff_max"`
+	APIRetries                int                   `json:"api_retries"`
+	APIRetryDelay             int                   `json:"api_retry_delay"`
+	APIMaxBody                int                   `json:"api_max_body"`
+	APITargets                string                `json:"api_targets"`
+	APITargetsParallel        int                   `json:"api_targets_parallel"`
+	APICoordinator            string                `json:"api_coordinator"`
+	APIDistToken              string                `json:"-"`
+	APIShardSize              int                   `json:"api_shard_size"`
+	Pacer                     *Pacer                `json:"-"`
+	APIPolicy                 string                `json:"api_policy"`
+	Policy                    *Policy               `json:"-"`
+	APISafeMode               string                `json:"api_safe_mode"`
+	SafeMode                  *SafeMode             `json:"-"`
+	APILoginURL               string                `json:"api_login_url"`
+	APILoginType              string                `json:"api_login_type"`
+	APILoginData              string                `json:"api_login_data"`
+	APILoginClientID          string                `json:"api_login_client_id"`
+	APILoginClientSecret      string                `json:"api_login_client_secret"`
+	APILoginScope             string                `json:"api_login_scope"`
+	APILoginTokenPath         string                `json:"api_login_token_path"`
+	APILoginHeader            string                `json:"api_login_header"`
+	APISigningConfig          string                `json:"api_signing_config"`
+	APICoverageSpec           string                `json:"api_coverage_spec"`
+	APICoverageMin            float64               `json:"api_coverage_min"`
+	APICoverageReport         string                `json:"api_coverage_report"`
+	APICoverageHistory        string                `json:"api_coverage_history"`
+	APIReportMermaid          string                `json:"api_report_mermaid"`
 }
 
 type InputProviderConfig struct {
@@ -125,6 +198,8 @@ func NewConfig(ctx context.Context, cancel context.CancelFunc) Config {
 	conf.Noninteractive = false
 	conf.ProgressFrequency = 125
 	conf.ProxyURL = ""
+	conf.ProxyList = []string{}
+	conf.Resolve = []string{}
 	conf.Quiet = false
 	conf.Rate = 0
 	conf.Raw = false
@@ -144,6 +219,7 @@ func NewConfig(ctx context.Context, cancel context.CancelFunc) Config {
 	conf.Verbose = false
 	conf.Wordlists = []string{}
 	conf.Http2 = false
+	conf.HTTPVersion = ""
 
 	// Initialize API-specific options
 	conf.APIMode = false
@@ -159,9 +235,76 @@ func NewConfig(ctx context.Context, cancel context.CancelFunc) Config {
 	conf.APIPayloadFormat = "json"
 	conf.APIPayloadTemplate = ""
 	conf.APIPayloadPath = ""
+	conf.APIRequestFuzz = ""
+	conf.APIRequestName = ""
+	conf.APIVars = ""
+	conf.APIEventLog = ""
+	conf.APIHARFile = ""
+	conf.APIHARMaxBody = 64 * 1024
+	conf.APIHARMaxSize = 0
+	conf.APIRedact = []string{}
+	conf.APIRedactPatterns = []string{}
+	conf.APILogLevel = "info"
+	conf.APILogFormat = "text"
+	conf.APILogFile = ""
 	conf.APIParseResponseBody = false
 	conf.APIExtractEndpoints = false
 	conf.APIOutputFormat = false
+	conf.APISecurityProfile = ""
+	conf.APISecurityInclude = []string{}
+	conf.APISecurityExclude = []string{}
+	conf.APISecurityOptions = []string{}
+	conf.APISecurityScoring = ""
+	conf.APISecurityBudgets = []string{}
+	conf.APISecurityPayloads = []string{}
+	conf.APISecurityPayloadCategories = []string{}
+	conf.APISecurityPayloadsReplace = false
+	conf.APISecurityTamper = []string{}
+	conf.APISecurityWAF = false
+	conf.APISecurityFingerprint = false
+	conf.APISecurityHeaders = []string{}
+	conf.APISecurityProxies = []string{}
+	conf.APISecurityIdentities = []string{}
+	conf.APISecurityLoginAttempts = 10
+	conf.APISecurityAccountSafe = false
+	conf.APISecurityOOBURL = ""
+	conf.APISecurityOOBListen = ""
+	conf.APISecurityOOBDomain = ""
+	conf.APISecurityOOBDNSListen = ""
+	conf.APIStateFile = ""
+	conf.APIResume = false
+	conf.APIGRPC = ""
+	conf.APIProtoFile = ""
+	conf.APIProtoMessage = ""
+	conf.APIWebSocket = false
+	conf.APIStreamTime = 0
+	conf.APIStreamEvents = 20
+	conf.APIAdaptiveRate = false
+	conf.APIBackoffMax = 30
+	conf.APIRetries = 2
+	conf.APIRetryDelay = 500
+	conf.APIMaxBody = 5 * 1024 * 1024
+	conf.APITargets = ""
+	conf.APITargetsParallel = 10
+	conf.APICoordinator = ""
+	conf.APIDistToken = ""
+	conf.APIShardSize = 500
+	conf.APIPolicy = ""
+	conf.APISafeMode = ""
+	conf.APILoginURL = ""
+	conf.APILoginType = "json"
+	conf.APILoginData = ""
+	conf.APILoginClientID = ""
+	conf.APILoginClientSecret = ""
+	conf.APILoginScope = ""
+	conf.APILoginTokenPath = ""
+	conf.APILoginHeader = ""
+	conf.APISigningConfig = ""
+	conf.APICoverageSpec = ""
+	conf.APICoverageMin = 0
+	conf.APICoverageReport = ""
+	conf.APICoverageHistory = ""
+	conf.APIReportMermaid = ""
 
 	return conf
 }
@@ -86,6 +86,13 @@ type AuditLogger interface {
 	Write(data interface{}) error
 }
 
+// EventLogger streams the significant events of a run, such as the requests sent and the
+// results matched, as they happen
+type EventLogger interface {
+	Request(req *Request, resp *Response, err error)
+	Result(resp *Response)
+}
+
 type Scraper interface {
 	Execute(resp *Response, matched bool) []ScraperResult
 	AppendFromFile(path string) error
@@ -106,6 +113,7 @@ type Result struct {
 	ContentWords     int64               `json:"words"`
 	ContentLines     int64               `json:"lines"`
 	ContentType      string              `json:"content-type"`
+	Proto            string              `json:"proto"`
 	RedirectLocation string              `json:"redirectlocation"`
 	Url              string              `json:"url"`
 	Duration         time.Duration       `json:"duration"`
@@ -1,6 +1,7 @@
 package ffuf
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
@@ -14,6 +15,7 @@ import (
 // Job ties together Config, Runner, Input and Output
 type Job struct {
 	AuditLogger          AuditLogger
+	EventLogger          EventLogger
 	Config               *Config
 	ErrorMutex           sync.Mutex
 	Input                InputProvider
@@ -419,8 +421,18 @@ func (j *Job) runTask(input map[string][]byte, position int, retried bool) {
 		}
 	}
 
+	if IsPolicyViolation(err) || IsSafeModeViolation(err) {
+		// Requests out of scope or blocked by the safe mode are skipped, they are counted by
+		// the policy and the safe mode
+		return
+	}
+	if j.EventLogger != nil {
+		j.EventLogger.Request(&req, &resp, err)
+	}
 	if err != nil {
-		if retried {
+		// Transient errors already retried by the runner with -api-retries are not retried again
+		var retryErr *RetryError
+		if retried || errors.As(err, &retryErr) {
 			j.incError()
 			log.Printf("%s", err)
 		} else {
@@ -501,6 +513,9 @@ func (j *Job) runTask(input map[string][]byte, position int, retried bool) {
 			}
 		}
 		j.Output.Result(resp)
+		if j.EventLogger != nil {
+			j.EventLogger.Result(&resp)
+		}
 
 		// Refresh the progress indicator as we printed something out
 		j.updateProgress()
@@ -12,6 +12,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pelletier/go-toml"
 )
@@ -34,15 +35,18 @@ type HTTPOptions struct {
 	IgnoreBody        bool     `json:"ignore_body"`
 	Method            string   `json:"method"`
 	ProxyURL          string   `json:"proxy_url"`
+	ProxyList         string   `json:"proxy_list"`
 	Raw               bool     `json:"raw"`
 	Recursion         bool     `json:"recursion"`
 	RecursionDepth    int      `json:"recursion_depth"`
 	RecursionStrategy string   `json:"recursion_strategy"`
 	ReplayProxyURL    string   `json:"replay_proxy_url"`
+	Resolve           []string `json:"resolve"`
 	SNI               string   `json:"sni"`
 	Timeout           int      `json:"timeout"`
 	URL               string   `json:"url"`
 	Http2             bool     `json:"http2"`
+	HTTPVersion       string   `json:"http_version"`
 	ClientCert        string   `json:"client-cert"`
 	ClientKey         string   `json:"client-key"`
 }
@@ -117,22 +121,88 @@ type MatcherOptions struct {
 }
 
 type APIOptions struct {
-	Enabled           bool   `json:"enabled"`
-	OutputFormat      bool   `json:"output_format"`
-	WordlistPath      string `json:"wordlist_path"`
-	WordlistCategory  string `json:"wordlist_category"`
-	AuthType          string `json:"auth_type"`
-	AuthUsername      string `json:"auth_username"`
-	AuthPassword      string `json:"auth_password"`
-	AuthToken         string `json:"auth_token"`
-	AuthAPIKey        string `json:"auth_api_key"`
-	AuthAPIKeyName    string `json:"auth_api_key_name"`
-	AuthAPIKeyLoc     string `json:"auth_api_key_loc"`
-	PayloadFormat     string `json:"payload_format"`
-	PayloadTemplate   string `json:"payload_template"`
-	PayloadPath       string `json:"payload_path"`
-	ParseResponseBody bool   `json:"parse_response_body"`
-	ExtractEndpoints  bool   `json:"extract_endpoints"`
+	Enabled           bool     `json:"enabled"`
+	OutputFormat      bool     `json:"output_format"`
+	WordlistPath      string   `json:"wordlist_path"`
+	WordlistCategory  string   `json:"wordlist_category"`
+	AuthType          string   `json:"auth_type"`
+	AuthUsername      string   `json:"auth_username"`
+	AuthPassword      string   `json:"auth_password"`
+	AuthToken         string   `json:"auth_token"`
+	AuthAPIKey        string   `json:"auth_api_key"`
+	AuthAPIKeyName    string   `json:"auth_api_key_name"`
+	AuthAPIKeyLoc     string   `json:"auth_api_key_loc"`
+	PayloadFormat     string   `json:"payload_format"`
+	PayloadTemplate   string   `json:"payload_template"`
+	PayloadPath       string   `json:"payload_path"`
+	RequestFuzz       string   `json:"request_fuzz"`
+	RequestName       string   `json:"request_name"`
+	Vars              string   `json:"vars"`
+	EventLog          string   `json:"event_log"`
+	HARFile           string   `json:"har_file"`
+	HARMaxBody        int      `json:"har_max_body"`
+	HARMaxSize        int      `json:"har_max_size"`
+	Redact            string   `json:"redact"`
+	RedactPatterns    []string `json:"redact_patterns"`
+	LogLevel          string   `json:"log_level"`
+	LogFormat         string   `json:"log_format"`
+	LogFile           string   `json:"log_file"`
+	ParseResponseBody bool     `json:"parse_response_body"`
+	ExtractEndpoints  bool     `json:"extract_endpoints"`
+	SecurityProfile   string   `json:"security_profile"`
+	SecurityInclude   string   `json:"security_include"`
+	SecurityExclude   string   `json:"security_exclude"`
+	SecurityOptions   []string `json:"security_options"`
+	SecurityScoring   string   `json:"security_scoring"`
+	SecurityBudgets   []string `json:"security_budgets"`
+	SecurityPayloads  []string `json:"security_payloads"`
+	PayloadCategories string   `json:"security_payload_categories"`
+	PayloadsReplace   bool     `json:"security_payloads_replace"`
+	SecurityTamper    string   `json:"security_tamper"`
+	SecurityWAF       bool     `json:"security_waf"`
+	Fingerprint       bool     `json:"security_fingerprint"`
+	SecurityHeaders   []string `json:"security_headers"`
+	SecurityProxies   []string `json:"security_proxies"`
+	Identities        []string `json:"security_identities"`
+	LoginAttempts     int      `json:"security_login_attempts"`
+	AccountSafe       bool     `json:"security_account_safe"`
+	OOBURL            string   `json:"security_oob_url"`
+	OOBListen         string   `json:"security_oob_listen"`
+	OOBDomain         string   `json:"security_oob_domain"`
+	OOBDNSListen      string   `json:"security_oob_dns_listen"`
+	StateFile         string   `json:"state_file"`
+	Resume            bool     `json:"resume"`
+	GRPC              string   `json:"grpc"`
+	ProtoFile         string   `json:"proto_file"`
+	ProtoMessage      string   `json:"proto_message"`
+	WebSocket         bool     `json:"websocket"`
+	StreamTime        int      `json:"stream_time"`
+	StreamEvents      int      `json:"stream_events"`
+	AdaptiveRate      bool     `json:"adaptive_rate"`
+	BackoffMax        int      `json:"backoff_max"`
+	Retries           int      `json:"retries"`
+	RetryDelay        int      `json:"retry_delay"`
+	MaxBody           int      `json:"max_body"`
+	Targets           string   `json:"targets"`
+	TargetsParallel   int      `json:"targets_parallel"`
+	Coordinator       string   `json:"coordinator"`
+	DistToken         string   `json:"dist_token"`
+	ShardSize         int      `json:"shard_size"`
+	Policy            string   `json:"policy"`
+	SafeMode          string   `json:"safe_mode"`
+	LoginURL          string   `json:"login_url"`
+	LoginType         string   `json:"login_type"`
+	LoginData         string   `json:"login_data"`
+	LoginClient       string   `json:"login_client"`
+	LoginScope        string   `json:"login_scope"`
+	LoginTokenPath    string   `json:"login_token_path"`
+	LoginHeader       string   `json:"login_header"`
+	SigningConfig     string   `json:"signing_config"`
+	CoverageSpec      string   `json:"coverage_spec"`
+	CoverageMin       float64  `json:"coverage_min"`
+	CoverageReport    string   `json:"coverage_report"`
+	CoverageHistory   string   `json:"coverage_history"`
+	ReportMermaid     string   `json:"report_mermaid"`
 }
 
 // NewConfigOptions returns a newly created ConfigOptions struct with default values
@@ -170,15 +240,18 @@ func NewConfigOptions() *ConfigOptions {
 	c.HTTP.IgnoreBody = false
 	c.HTTP.Method = ""
 	c.HTTP.ProxyURL = ""
+	c.HTTP.ProxyList = ""
 	c.HTTP.Raw = false
 	c.HTTP.Recursion = false
 	c.HTTP.RecursionDepth = 0
 	c.HTTP.RecursionStrategy = "default"
 	c.HTTP.ReplayProxyURL = ""
+	c.HTTP.Resolve = []string{}
 	c.HTTP.Timeout = 10
 	c.HTTP.SNI = ""
 	c.HTTP.URL = ""
 	c.HTTP.Http2 = false
+	c.HTTP.HTTPVersion = ""
 	c.Input.DirSearchCompat = false
 	c.Input.Encoders = []string{}
 	c.Input.Extensions = ""
@@ -213,8 +286,74 @@ func NewConfigOptions() *ConfigOptions {
 	c.API.PayloadFormat = "json"
 	c.API.PayloadTemplate = ""
 	c.API.PayloadPath = ""
+	c.API.RequestFuzz = ""
+	c.API.RequestName = ""
+	c.API.Vars = ""
+	c.API.EventLog = ""
+	c.API.HARFile = ""
+	c.API.HARMaxBody = 64 * 1024
+	c.API.HARMaxSize = 0
+	c.API.Redact = ""
+	c.API.RedactPatterns = []string{}
+	c.API.LogLevel = "info"
+	c.API.LogFormat = "text"
+	c.API.LogFile = ""
 	c.API.ParseResponseBody = false
 	c.API.ExtractEndpoints = false
+	c.API.SecurityProfile = ""
+	c.API.SecurityInclude = ""
+	c.API.SecurityExclude = ""
+	c.API.SecurityOptions = []string{}
+	c.API.SecurityScoring = ""
+	c.API.SecurityBudgets = []string{}
+	c.API.SecurityPayloads = []string{}
+	c.API.PayloadCategories = ""
+	c.API.PayloadsReplace = false
+	c.API.SecurityTamper = ""
+	c.API.SecurityWAF = false
+	c.API.Fingerprint = false
+	c.API.SecurityHeaders = []string{}
+	c.API.SecurityProxies = []string{}
+	c.API.Identities = []string{}
+	c.API.LoginAttempts = 10
+	c.API.AccountSafe = false
+	c.API.OOBURL = ""
+	c.API.OOBListen = ""
+	c.API.OOBDomain = ""
+	c.API.OOBDNSListen = ""
+	c.API.StateFile = ""
+	c.API.Resume = false
+	c.API.GRPC = ""
+	c.API.ProtoFile = ""
+	c.API.ProtoMessage = ""
+	c.API.WebSocket = false
+	c.API.StreamTime = 0
+	c.API.StreamEvents = 20
+	c.API.AdaptiveRate = false
+	c.API.BackoffMax = 30
+	c.API.Retries = 2
+	c.API.RetryDelay = 500
+	c.API.MaxBody = 5 * 1024 * 1024
+	c.API.Targets = ""
+	c.API.TargetsParallel = 10
+	c.API.Coordinator = ""
+	c.API.DistToken = ""
+	c.API.ShardSize = 500
+	c.API.Policy = ""
+	c.API.SafeMode = ""
+	c.API.LoginURL = ""
+	c.API.LoginType = "json"
+	c.API.LoginData = ""
+	c.API.LoginClient = ""
+	c.API.LoginScope = ""
+	c.API.LoginTokenPath = ""
+	c.API.LoginHeader = ""
+	c.API.SigningConfig = ""
+	c.API.CoverageSpec = ""
+	c.API.CoverageMin = 0
+	c.API.CoverageReport = ""
+	c.API.CoverageHistory = ""
+	c.API.ReportMermaid = ""
 	return c
 }
 
@@ -479,6 +618,30 @@ func ConfigFromOptions(parseOpts *ConfigOptions, ctx context.Context, cancel con
 		}
 	}
 
+	// Read the proxies rotated per request
+	if len(parseOpts.HTTP.ProxyList) > 0 {
+		proxies, err := ReadProxyList(parseOpts.HTTP.ProxyList)
+		if err != nil {
+			errs.Add(err)
+		} else if len(parseOpts.HTTP.ProxyURL) > 0 {
+			errs.Add(fmt.Errorf("-x and -proxy-list cannot be used together"))
+		} else {
+			conf.ProxyList = proxies
+		}
+	}
+
+	// Verify the host pinning entries
+	if len(parseOpts.HTTP.Resolve) > 0 {
+		if _, err := ParseResolve(parseOpts.HTTP.Resolve); err != nil {
+			errs.Add(err)
+		} else if len(parseOpts.HTTP.ProxyURL) > 0 || len(parseOpts.HTTP.ProxyList) > 0 {
+			// Requests through a proxy are resolved by the proxy
+			errs.Add(fmt.Errorf("-resolve cannot be used with -x or -proxy-list, the proxy resolves the hosts"))
+		} else {
+			conf.Resolve = parseOpts.HTTP.Resolve
+		}
+	}
+
 	// Verify replayproxy url format
 	if len(parseOpts.HTTP.ReplayProxyURL) > 0 {
 		u, err := url.Parse(parseOpts.HTTP.ReplayProxyURL)
@@ -583,6 +746,16 @@ func ConfigFromOptions(parseOpts *ConfigOptions, ctx context.Context, cancel con
 	conf.Verbose = parseOpts.General.Verbose
 	conf.Json = parseOpts.General.Json
 	conf.Http2 = parseOpts.HTTP.Http2
+	conf.HTTPVersion = parseOpts.HTTP.HTTPVersion
+	switch conf.HTTPVersion {
+	case "", "1.1", "2":
+	case "3":
+		if len(parseOpts.HTTP.ProxyURL) > 0 || len(parseOpts.HTTP.ProxyList) > 0 || len(parseOpts.HTTP.ReplayProxyURL) > 0 || len(parseOpts.API.SecurityProxies) > 0 {
+			errs.Add(fmt.Errorf("-http-version 3 cannot be used with -x, -proxy-list, -replay-proxy or -api-security-proxy, the proxies do not relay QUIC"))
+		}
+	default:
+		errs.Add(fmt.Errorf("-http-version must be 1.1, 2 or 3, got %s", conf.HTTPVersion))
+	}
 
 	// Transfer API-specific options
 	conf.APIMode = parseOpts.API.Enabled
@@ -599,8 +772,166 @@ func ConfigFromOptions(parseOpts *ConfigOptions, ctx context.Context, cancel con
 	conf.APIPayloadFormat = parseOpts.API.PayloadFormat
 	conf.APIPayloadTemplate = parseOpts.API.PayloadTemplate
 	conf.APIPayloadPath = parseOpts.API.PayloadPath
+	conf.APIRequestFuzz = parseOpts.API.RequestFuzz
+	conf.APIRequestName = parseOpts.API.RequestName
+	conf.APIVars = parseOpts.API.Vars
+	conf.APIEventLog = parseOpts.API.EventLog
+	conf.APIHARFile = parseOpts.API.HARFile
+	conf.APIHARMaxBody = parseOpts.API.HARMaxBody
+	conf.APIHARMaxSize = parseOpts.API.HARMaxSize
+	conf.APIRedact = splitList(parseOpts.API.Redact)
+	conf.APIRedactPatterns = parseOpts.API.RedactPatterns
+	conf.APILogLevel = parseOpts.API.LogLevel
+	conf.APILogFormat = parseOpts.API.LogFormat
+	conf.APILogFile = parseOpts.API.LogFile
 	conf.APIParseResponseBody = parseOpts.API.ParseResponseBody
 	conf.APIExtractEndpoints = parseOpts.API.ExtractEndpoints
+	conf.APISecurityProfile = parseOpts.API.SecurityProfile
+	conf.APISecurityInclude = splitList(parseOpts.API.SecurityInclude)
+	conf.APISecurityExclude = splitList(parseOpts.API.SecurityExclude)
+	conf.APISecurityOptions = parseOpts.API.SecurityOptions
+	conf.APISecurityScoring = parseOpts.API.SecurityScoring
+	conf.APISecurityBudgets = parseOpts.API.SecurityBudgets
+	conf.APISecurityPayloads = parseOpts.API.SecurityPayloads
+	conf.APISecurityPayloadCategories = splitList(parseOpts.API.PayloadCategories)
+	conf.APISecurityPayloadsReplace = parseOpts.API.PayloadsReplace
+	conf.APISecurityTamper = splitList(parseOpts.API.SecurityTamper)
+	conf.APISecurityWAF = parseOpts.API.SecurityWAF
+	conf.APISecurityFingerprint = parseOpts.API.Fingerprint
+	conf.APISecurityHeaders = parseOpts.API.SecurityHeaders
+	conf.APISecurityProxies = parseOpts.API.SecurityProxies
+	conf.APISecurityIdentities = parseOpts.API.Identities
+	if len(conf.APISecurityIdentities) > 0 && parseOpts.API.LoginURL != "" {
+		errs.Add(fmt.Errorf("-api-security-identity cannot be used with -api-login-url, the login session replaces the headers of the identities"))
+	}
+	conf.APISecurityLoginAttempts = parseOpts.API.LoginAttempts
+	if conf.APISecurityLoginAttempts < 0 {
+		errs.Add(fmt.Errorf("-api-security-login-attempts must be 0 or more"))
+	}
+	conf.APISecurityAccountSafe = parseOpts.API.AccountSafe
+	conf.APISecurityOOBURL = parseOpts.API.OOBURL
+	conf.APISecurityOOBListen = parseOpts.API.OOBListen
+	conf.APISecurityOOBDomain = parseOpts.API.OOBDomain
+	conf.APISecurityOOBDNSListen = parseOpts.API.OOBDNSListen
+	if conf.APISecurityOOBURL != "" {
+		if u, err := url.Parse(conf.APISecurityOOBURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.Add(fmt.Errorf("-api-security-oob-url must be an http or https URL"))
+		}
+	}
+	if conf.APISecurityOOBListen != "" && conf.APISecurityOOBURL == "" {
+		errs.Add(fmt.Errorf("-api-security-oob-listen requires -api-security-oob-url, the public URL the targets reach the callback listener at"))
+	}
+	if conf.APISecurityOOBDNSListen != "" && conf.APISecurityOOBDomain == "" {
+		errs.Add(fmt.Errorf("-api-security-oob-dns requires -api-security-oob-domain, the domain delegated to the DNS listener"))
+	}
+	if (conf.APISecurityOOBURL != "" || conf.APISecurityOOBDomain != "") && conf.APISecurityOOBListen == "" && conf.APISecurityOOBDNSListen == "" {
+		errs.Add(fmt.Errorf("-api-security-oob-url and -api-security-oob-domain require a callback listener set with -api-security-oob-listen or -api-security-oob-dns"))
+	}
+	conf.APIStateFile = parseOpts.API.StateFile
+	conf.APIResume = parseOpts.API.Resume
+	if conf.APIResume && conf.APIStateFile == "" {
+		errs.Add(fmt.Errorf("-api-resume requires a state file set with -api-state"))
+	}
+	conf.APIGRPC = parseOpts.API.GRPC
+	conf.APIProtoFile = parseOpts.API.ProtoFile
+	conf.APIProtoMessage = parseOpts.API.ProtoMessage
+	if conf.APIGRPC != "" && conf.APIGRPC != "grpc" && conf.APIGRPC != "grpc-web" {
+		errs.Add(fmt.Errorf("-api-grpc must be grpc or grpc-web, got %s", conf.APIGRPC))
+	}
+	if conf.APIProtoFile != "" && conf.APIGRPC == "" {
+		errs.Add(fmt.Errorf("-api-proto requires a gRPC transport set with -api-grpc"))
+	}
+	if conf.APIProtoMessage != "" && conf.APIProtoFile == "" {
+		errs.Add(fmt.Errorf("-api-proto-message requires protobuf definitions set with -api-proto"))
+	}
+	// ws:// and wss:// URLs are always fuzzed over WebSocket
+	conf.APIWebSocket = parseOpts.API.WebSocket || strings.HasPrefix(conf.Url, "ws://") || strings.HasPrefix(conf.Url, "wss://")
+	if conf.APIWebSocket && conf.APIGRPC != "" {
+		errs.Add(fmt.Errorf("-api-websocket cannot be combined with -api-grpc"))
+	}
+	conf.APIStreamTime = parseOpts.API.StreamTime
+	conf.APIStreamEvents = parseOpts.API.StreamEvents
+	if conf.APIStreamTime < 0 || conf.APIStreamEvents < 0 {
+		errs.Add(fmt.Errorf("-api-stream-time and -api-stream-events must not be negative"))
+	}
+	conf.APIAdaptiveRate = parseOpts.API.AdaptiveRate
+	conf.APIBackoffMax = parseOpts.API.BackoffMax
+	conf.APIRetries = parseOpts.API.Retries
+	conf.APIRetryDelay = parseOpts.API.RetryDelay
+	if conf.APIRetries < 0 || conf.APIRetryDelay < 0 {
+		errs.Add(fmt.Errorf("-api-retries and -api-retry-delay must not be negative"))
+	}
+	conf.APIMaxBody = parseOpts.API.MaxBody
+	if conf.APIMaxBody < 0 {
+		errs.Add(fmt.Errorf("-api-max-body must not be negative"))
+	}
+	if conf.APIAdaptiveRate && conf.APIBackoffMax < 1 {
+		errs.Add(fmt.Errorf("-api-backoff-max must be at least 1 second"))
+	} else if conf.APIAdaptiveRate {
+		conf.Pacer = NewPacer(time.Duration(conf.APIBackoffMax) * time.Second)
+	}
+	conf.APITargets = parseOpts.API.Targets
+	conf.APITargetsParallel = parseOpts.API.TargetsParallel
+	if conf.APITargets != "" && parseOpts.Input.Request != "" {
+		errs.Add(fmt.Errorf("-api-targets cannot be combined with -request"))
+	}
+	conf.APICoordinator = parseOpts.API.Coordinator
+	conf.APIDistToken = parseOpts.API.DistToken
+	conf.APIShardSize = parseOpts.API.ShardSize
+	if conf.APICoordinator != "" && conf.APIShardSize < 1 {
+		errs.Add(fmt.Errorf("-api-shard-size must be at least 1"))
+	}
+	conf.APIPolicy = parseOpts.API.Policy
+	if conf.APIPolicy != "" {
+		policy, err := LoadPolicy(conf.APIPolicy)
+		if err != nil {
+			errs.Add(err)
+		} else {
+			conf.Policy = policy
+		}
+	}
+	conf.APISafeMode = parseOpts.API.SafeMode
+	if conf.APISafeMode != "" {
+		safeMode, err := NewSafeMode(conf.APISafeMode)
+		if err != nil {
+			errs.Add(err)
+		} else {
+			conf.SafeMode = safeMode
+		}
+	}
+	conf.APILoginURL = parseOpts.API.LoginURL
+	conf.APILoginType = parseOpts.API.LoginType
+	conf.APILoginData = parseOpts.API.LoginData
+	conf.APILoginScope = parseOpts.API.LoginScope
+	conf.APILoginTokenPath = parseOpts.API.LoginTokenPath
+	conf.APILoginHeader = parseOpts.API.LoginHeader
+	if parseOpts.API.LoginClient != "" {
+		client := strings.SplitN(parseOpts.API.LoginClient, ":", 2)
+		conf.APILoginClientID = client[0]
+		if len(client) == 2 {
+			conf.APILoginClientSecret = client[1]
+		}
+	}
+	switch conf.APILoginType {
+	case "form", "json", "oauth2-password", "oauth2-client-credentials", "oidc":
+	default:
+		errs.Add(fmt.Errorf("-api-login-type must be one of form, json, oauth2-password, oauth2-client-credentials, oidc, got %s", conf.APILoginType))
+	}
+	if conf.APILoginHeader != "" && !strings.Contains(conf.APILoginHeader, ":") {
+		errs.Add(fmt.Errorf("-api-login-header must be in the form \"Name: value\""))
+	}
+	conf.APISigningConfig = parseOpts.API.SigningConfig
+	conf.APICoverageSpec = parseOpts.API.CoverageSpec
+	conf.APICoverageMin = parseOpts.API.CoverageMin
+	conf.APICoverageReport = parseOpts.API.CoverageReport
+	conf.APICoverageHistory = parseOpts.API.CoverageHistory
+	conf.APIReportMermaid = parseOpts.API.ReportMermaid
+	if conf.APICoverageMin < 0 || conf.APICoverageMin > 100 {
+		errs.Add(fmt.Errorf("-api-coverage-min must be a percentage between 0 and 100"))
+	}
+	if conf.APICoverageSpec == "" && (conf.APICoverageMin > 0 || conf.APICoverageReport != "" || conf.APICoverageHistory != "" || conf.APIReportMermaid != "") {
+		errs.Add(fmt.Errorf("-api-coverage-min, -api-coverage-report, -api-coverage-history and -api-report-mermaid require -api-coverage"))
+	}
 
 	// Check that fmode and mmode have sane values
 	valid_opmodes := []string{"and", "or"}
@@ -633,8 +964,8 @@ func ConfigFromOptions(parseOpts *ConfigOptions, ctx context.Context, cancel con
 	// Handle copy as curl situation where POST method is implied by --data flag. If method is set to anything but GET, NOOP
 	if len(conf.Data) > 0 &&
 		conf.Method == "GET" &&
-		//don't modify the method automatically if a request file is being used as input
-		len(parseOpts.Input.Request) == 0 {
+		//don't modify the method automatically if a request file is being used as input or the method is set
+		len(parseOpts.Input.Request) == 0 && parseOpts.HTTP.Method == "" {
 
 		conf.Method = "POST"
 	}
@@ -756,6 +1087,36 @@ func parseRawRequest(parseOpts *ConfigOptions, conf *Config) error {
 	return nil
 }
 
+// ReadProxyList reads the proxy URLs of a file, one per line, skipping empty lines and # comments
+func ReadProxyList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the proxy list (-proxy-list): %s", err)
+	}
+	defer file.Close()
+
+	proxies := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		u, err := url.Parse(line)
+		if err != nil || u.Opaque != "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" && u.Scheme != "socks5h") {
+			return nil, fmt.Errorf("Bad proxy url %s in the proxy list (-proxy-list). Expected http, https or socks5 url", line)
+		}
+		proxies = append(proxies, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Could not read the proxy list (-proxy-list): %s", err)
+	}
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("The proxy list (-proxy-list) %s is empty", path)
+	}
+	return proxies, nil
+}
+
 func keywordPresent(keyword string, conf *Config) bool {
 	//Search for keyword from HTTP method, URL and POST data too
 	if strings.Contains(conf.Method, keyword) {
@@ -1,6 +1,8 @@
 package ffuf
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -132,6 +134,132 @@ func TestProxyParsing(t *testing.T) {
 	}
 }
 
+func TestProxyListParsing(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "proxies.txt")
+	os.WriteFile(list, []byte("# rotated proxies\nhttp://127.0.0.1:8080\n\nsocks5://127.0.0.1:1080\n"), 0644)
+
+	configOptions := NewConfigOptions()
+	configOptions.HTTP.ProxyList = list
+	conf, _ := ConfigFromOptions(configOptions, nil, nil)
+	if len(conf.ProxyList) != 2 || conf.ProxyList[1] != "socks5://127.0.0.1:1080" {
+		t.Errorf("Expected the two proxies of the list, got %v", conf.ProxyList)
+	}
+
+	configOptions.HTTP.ProxyURL = "http://127.0.0.1:8080"
+	_, err := ConfigFromOptions(configOptions, nil, nil)
+	if !strings.Contains(err.Error(), "-x and -proxy-list cannot be used together") {
+		t.Errorf("Expected -x and -proxy-list to be exclusive")
+	}
+
+	os.WriteFile(list, []byte("imap://127.0.0.1\n"), 0644)
+	if _, err := ReadProxyList(list); err == nil || !strings.Contains(err.Error(), "Bad proxy url imap://127.0.0.1") {
+		t.Errorf("Expected proxy with unsupported protocol to fail, got %v", err)
+	}
+	os.WriteFile(list, []byte("# empty\n"), 0644)
+	if _, err := ReadProxyList(list); err == nil {
+		t.Errorf("Expected an empty proxy list to fail")
+	}
+}
+
+func TestResolveParsing(t *testing.T) {
+	configOptions := NewConfigOptions()
+	configOptions.HTTP.Resolve = []string{"api.example.com:443:10.0.0.5"}
+	conf, _ := ConfigFromOptions(configOptions, nil, nil)
+	if len(conf.Resolve) != 1 {
+		t.Errorf("Expected the resolve entry, got %v", conf.Resolve)
+	}
+
+	configOptions.HTTP.Resolve = []string{"api.example.com:10.0.0.5"}
+	_, err := ConfigFromOptions(configOptions, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "Bad resolve entry api.example.com:10.0.0.5") {
+		t.Errorf("Expected an invalid resolve entry to fail, got %v", err)
+	}
+
+	configOptions.HTTP.Resolve = []string{"api.example.com:443:10.0.0.5"}
+	configOptions.HTTP.ProxyURL = "http://127.0.0.1:8080"
+	_, err = ConfigFromOptions(configOptions, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "-resolve cannot be used with -x or -proxy-list") {
+		t.Errorf("Expected -resolve and -x to be exclusive, got %v", err)
+	}
+}
+
+func TestHTTPVersionParsing(t *testing.T) {
+	for _, version := range []string{"1.1", "2", "3"} {
+		configOptions := NewConfigOptions()
+		configOptions.HTTP.HTTPVersion = version
+		conf, _ := ConfigFromOptions(configOptions, nil, nil)
+		if conf.HTTPVersion != version {
+			t.Errorf("Expected HTTP version %s, got %s", version, conf.HTTPVersion)
+		}
+	}
+
+	configOptions := NewConfigOptions()
+	configOptions.HTTP.HTTPVersion = "2"
+	configOptions.HTTP.ProxyURL = "socks5://127.0.0.1:1080"
+	if _, err := ConfigFromOptions(configOptions, nil, nil); err != nil && strings.Contains(err.Error(), "-http-version") {
+		t.Errorf("Expected HTTP/2 to be allowed through a proxy, got %s", err)
+	}
+
+	configOptions.HTTP.HTTPVersion = "3"
+	_, err := ConfigFromOptions(configOptions, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "-http-version 3 cannot be used with -x") {
+		t.Errorf("Expected HTTP/3 through a proxy to fail, got %v", err)
+	}
+
+	configOptions = NewConfigOptions()
+	configOptions.HTTP.HTTPVersion = "1.0"
+	_, err = ConfigFromOptions(configOptions, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "-http-version must be 1.1, 2 or 3") {
+		t.Errorf("Expected an unknown HTTP version to fail, got %v", err)
+	}
+}
+
+func TestIdentityParsing(t *testing.T) {
+	configOptions := NewConfigOptions()
+	configOptions.API.Identities = []string{"alice:101=Authorization: Bearer alice", "bob:201=Authorization: Bearer bob"}
+	conf, _ := ConfigFromOptions(configOptions, nil, nil)
+	if len(conf.APISecurityIdentities) != 2 {
+		t.Errorf("Expected the identities, got %v", conf.APISecurityIdentities)
+	}
+
+	configOptions.API.LoginURL = "https://api.example.com/login"
+	_, err := ConfigFromOptions(configOptions, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "-api-security-identity cannot be used with -api-login-url") {
+		t.Errorf("Expected identities and a login session to be exclusive, got %v", err)
+	}
+}
+
+func TestOOBParsing(t *testing.T) {
+	configOptions := NewConfigOptions()
+	configOptions.API.OOBListen = "0.0.0.0:8089"
+	configOptions.API.OOBURL = "http://oob.example.com:8089"
+	conf, _ := ConfigFromOptions(configOptions, nil, nil)
+	if conf.APISecurityOOBListen != "0.0.0.0:8089" || conf.APISecurityOOBURL != "http://oob.example.com:8089" {
+		t.Errorf("Expected the callback listener and its URL, got %s and %s", conf.APISecurityOOBListen, conf.APISecurityOOBURL)
+	}
+
+	for _, tc := range []struct {
+		listen, url, dns, domain string
+		expected                 string
+	}{
+		{listen: "0.0.0.0:8089", expected: "-api-security-oob-listen requires -api-security-oob-url"},
+		{listen: "0.0.0.0:8089", url: "oob.example.com", expected: "-api-security-oob-url must be an http or https URL"},
+		{dns: "0.0.0.0:53", expected: "-api-security-oob-dns requires -api-security-oob-domain"},
+		{domain: "oob.example.com", expected: "require a callback listener"},
+	} {
+		configOptions := NewConfigOptions()
+		configOptions.API.OOBListen = tc.listen
+		configOptions.API.OOBURL = tc.url
+		configOptions.API.OOBDNSListen = tc.dns
+		configOptions.API.OOBDomain = tc.domain
+		_, err := ConfigFromOptions(configOptions, nil, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("Expected an error containing %q, got %v", tc.expected, err)
+		}
+	}
+}
+
 func TestReplayProxyParsing(t *testing.T) {
 	configOptions := NewConfigOptions()
 	errorString := "Bad replay-proxy url (-replay-proxy) format. Expected http, https or socks5 url"
@@ -0,0 +1,188 @@
+package ffuf
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// pacingInitialDelay is the delay between requests to a host after its first backoff
+	pacingInitialDelay = 100 * time.Millisecond
+	// pacingMinDelay is the delay below which requests to a host are no longer delayed
+	pacingMinDelay = 5 * time.Millisecond
+	// pacingLatencySamples is the number of responses measuring the baseline latency of a host
+	pacingLatencySamples = 10
+	// pacingLatencyFactor is how many times slower than its baseline a host must respond for
+	// its latency to be considered rising
+	pacingLatencyFactor = 3
+	// pacingLatencyFloor is the latency below which a host is never considered overloaded
+	pacingLatencyFloor = 250 * time.Millisecond
+)
+
+// Pacer adapts the rate of requests to each host to the responses of the host. Requests are
+// delayed with an exponential backoff, up to MaxDelay, when the host answers with 429 Too
+// Many Requests, sends a Retry-After header, or responds several times slower than it did at
+// the start of the scan. The delay decreases again while the host responds normally.
+//
+// A pacer is shared by all the runners created from a config, so that the fuzzing job,
+// the security testers and the test executor slow down together.
+type Pacer struct {
+	MaxDelay time.Duration
+	hosts    map[string]*hostPace
+	mu       sync.Mutex
+}
+
+// hostPace is the pacing state of a host
+type hostPace struct {
+	delay    time.Duration
+	next     time.Time
+	baseline time.Duration
+	samples  int
+	latency  time.Duration
+}
+
+// NewPacer creates a pacer whose delays are capped to maxDelay
+func NewPacer(maxDelay time.Duration) *Pacer {
+	return &Pacer{
+		MaxDelay: maxDelay,
+		hosts:    make(map[string]*hostPace),
+	}
+}
+
+// pacingHost returns the host requests to a URL are paced by
+func pacingHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// host returns the pacing state of a host. The caller must hold the lock.
+func (p *Pacer) host(host string) *hostPace {
+	h, ok := p.hosts[host]
+	if !ok {
+		h = &hostPace{}
+		p.hosts[host] = h
+	}
+	return h
+}
+
+// Wait waits until a request to the URL may be sent, or the context is cancelled
+func (p *Pacer) Wait(ctx context.Context, rawURL string) error {
+	p.mu.Lock()
+	h := p.host(pacingHost(rawURL))
+	now := time.Now()
+	slot := h.next
+	if slot.Before(now) {
+		slot = now
+	}
+	// Reserve the slot, the next request is sent after the delay
+	h.next = slot.Add(h.delay)
+	p.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Observe adapts the delay of the host of a request to its response
+func (p *Pacer) Observe(rawURL string, resp Response) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := p.host(pacingHost(rawURL))
+
+	retryAfter, hasRetryAfter := parseRetryAfter(resp.Headers)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || (hasRetryAfter && resp.StatusCode >= 500):
+		p.backoff(h, 2)
+		if hasRetryAfter {
+			if retryAfter > p.MaxDelay {
+				retryAfter = p.MaxDelay
+			}
+			if next := time.Now().Add(retryAfter); next.After(h.next) {
+				h.next = next
+			}
+		}
+	case h.rising(resp.Duration):
+		p.backoff(h, 1.5)
+	default:
+		h.delay = h.delay * 3 / 4
+		if h.delay < pacingMinDelay {
+			h.delay = 0
+		}
+	}
+}
+
+// ObserveError adapts the delay of the host of a request which failed, such as by timing out
+func (p *Pacer) ObserveError(rawURL string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backoff(p.host(pacingHost(rawURL)), 1.5)
+}
+
+// Delay returns the current delay between requests to the host of a URL
+func (p *Pacer) Delay(rawURL string) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.host(pacingHost(rawURL)).delay
+}
+
+// backoff multiplies the delay of a host by a factor, up to MaxDelay. The caller must hold
+// the lock.
+func (p *Pacer) backoff(h *hostPace, factor float64) {
+	h.delay = time.Duration(float64(h.delay) * factor)
+	if h.delay < pacingInitialDelay {
+		h.delay = pacingInitialDelay
+	}
+	if p.MaxDelay > 0 && h.delay > p.MaxDelay {
+		h.delay = p.MaxDelay
+	}
+}
+
+// rising records the latency of a response, and returns true if the recent latency of the
+// host is several times its baseline, measured on its first responses
+func (h *hostPace) rising(latency time.Duration) bool {
+	if latency <= 0 {
+		return false
+	}
+	if h.samples < pacingLatencySamples {
+		h.samples++
+		h.baseline += (latency - h.baseline) / time.Duration(h.samples)
+		h.latency = h.baseline
+		return false
+	}
+	// Exponentially weighted moving average of the recent latency
+	h.latency += (latency - h.latency) * 3 / 10
+	return h.latency > pacingLatencyFloor && h.latency > h.baseline*pacingLatencyFactor
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, in seconds or as an HTTP date
+func parseRetryAfter(headers map[string][]string) (time.Duration, bool) {
+	values := http.Header(headers).Values("Retry-After")
+	if len(values) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(values[0]); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(values[0]); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,84 @@
+package ffuf
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPacerBackoff(t *testing.T) {
+	p := NewPacer(300 * time.Millisecond)
+	limited := Response{StatusCode: 429, Headers: map[string][]string{}}
+	ok := Response{StatusCode: 200, Headers: map[string][]string{}}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, delay := range expected {
+		p.Observe("https://api.example.com/a", limited)
+		if d := p.Delay("https://api.example.com/b"); d != delay {
+			t.Errorf("Expected a delay of %s after %d responses with 429, got %s", delay, i+1, d)
+		}
+	}
+	if d := p.Delay("https://other.example.com/"); d != 0 {
+		t.Errorf("Expected other hosts not to be delayed, got %s", d)
+	}
+
+	p.Observe("https://api.example.com/a", ok)
+	if d := p.Delay("https://api.example.com/"); d != 225*time.Millisecond {
+		t.Errorf("Expected the delay to decrease, got %s", d)
+	}
+	for i := 0; i < 20; i++ {
+		p.Observe("https://api.example.com/a", ok)
+	}
+	if d := p.Delay("https://api.example.com/"); d != 0 {
+		t.Errorf("Expected the delay to be removed, got %s", d)
+	}
+
+	p.ObserveError("https://api.example.com/a")
+	if d := p.Delay("https://api.example.com/"); d != 100*time.Millisecond {
+		t.Errorf("Expected failed requests to delay the host, got %s", d)
+	}
+}
+
+func TestPacerRetryAfter(t *testing.T) {
+	p := NewPacer(200 * time.Millisecond)
+	p.Observe("http://api.example.com/", Response{StatusCode: 503, Headers: map[string][]string{"Retry-After": {"120"}}})
+
+	// Retry-After is capped to the maximum delay
+	start := time.Now()
+	if err := p.Wait(context.Background(), "http://api.example.com/x"); err != nil {
+		t.Fatalf("Wait returned an error: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected to wait for the capped Retry-After delay, waited %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Wait(ctx, "http://api.example.com/x"); err == nil {
+		t.Errorf("Expected a cancelled wait to return an error")
+	}
+
+	if _, ok := parseRetryAfter(map[string][]string{"Retry-After": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}}); !ok {
+		t.Errorf("Expected a Retry-After date to be parsed")
+	}
+	if _, ok := parseRetryAfter(map[string][]string{"Retry-After": {"soon"}}); ok {
+		t.Errorf("Expected an invalid Retry-After header to be ignored")
+	}
+}
+
+func TestPacerRisingLatency(t *testing.T) {
+	p := NewPacer(time.Second)
+	for i := 0; i < pacingLatencySamples; i++ {
+		p.Observe("https://api.example.com/", Response{StatusCode: 200, Duration: 50 * time.Millisecond})
+	}
+	if d := p.Delay("https://api.example.com/"); d != 0 {
+		t.Fatalf("Expected no delay at the baseline latency, got %s", d)
+	}
+	for i := 0; i < 5; i++ {
+		p.Observe("https://api.example.com/", Response{StatusCode: 200, Duration: 2 * time.Second})
+	}
+	if d := p.Delay("https://api.example.com/"); d == 0 {
+		t.Errorf("Expected rising latency to delay the host")
+	}
+}
@@ -0,0 +1,250 @@
+package ffuf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// PolicyAllow is the action of rules allowing requests
+	PolicyAllow = "allow"
+	// PolicyDeny is the action of rules denying requests
+	PolicyDeny = "deny"
+)
+
+// Policy is the scope of a scan: rules allowing or denying requests by host, path, method
+// and parameter name. It is enforced by the runners before any request is sent, so that the
+// fuzzing job, the security testers and the workflows all stay in scope.
+type Policy struct {
+	// Default is the action applied to requests matching no rule, allow if empty
+	Default string        `yaml:"default" json:"default"`
+	Rules   []*PolicyRule `yaml:"rules" json:"rules"`
+
+	violations map[string]int
+	mu         sync.Mutex
+}
+
+// PolicyRule allows or denies the requests matching all of its conditions. Conditions left
+// empty match every request.
+type PolicyRule struct {
+	Name   string `yaml:"name" json:"name"`
+	Action string `yaml:"action" json:"action"`
+	// Hosts are glob patterns of host names, or of host:port if the pattern has a port
+	Hosts []string `yaml:"hosts" json:"hosts"`
+	// Path is a regular expression matched against the path of the URL
+	Path    string   `yaml:"path" json:"path"`
+	Methods []string `yaml:"methods" json:"methods"`
+	// Params are names of query, form or JSON body parameters, any of which must be present
+	Params []string `yaml:"params" json:"params"`
+
+	path *regexp.Regexp
+}
+
+// PolicyViolation is the error returned for requests denied by a policy
+type PolicyViolation struct {
+	Rule   string
+	Method string
+	Url    string
+}
+
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("%s %s denied by scope policy rule '%s'", v.Method, v.Url, v.Rule)
+}
+
+// IsPolicyViolation returns true if the error is caused by a request denied by a policy
+func IsPolicyViolation(err error) bool {
+	var violation *PolicyViolation
+	return errors.As(err, &violation)
+}
+
+// LoadPolicy loads a scope policy from a YAML or JSON file
+func LoadPolicy(filePath string) (*Policy, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read policy file: %s", err)
+	}
+	policy := &Policy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("could not parse policy file %s: %s", filePath, err)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %s", filePath, err)
+	}
+	return policy, nil
+}
+
+// compile validates the rules of the policy and compiles their regular expressions
+func (p *Policy) compile() error {
+	p.Default = strings.ToLower(p.Default)
+	if p.Default == "" {
+		p.Default = PolicyAllow
+	}
+	if p.Default != PolicyAllow && p.Default != PolicyDeny {
+		return fmt.Errorf("default action must be allow or deny, got %s", p.Default)
+	}
+	for i, rule := range p.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		rule.Action = strings.ToLower(rule.Action)
+		if rule.Action != PolicyAllow && rule.Action != PolicyDeny {
+			return fmt.Errorf("action of policy rule '%s' must be allow or deny, got %s", rule.Name, rule.Action)
+		}
+		for _, host := range rule.Hosts {
+			if _, err := path.Match(strings.ToLower(host), ""); err != nil {
+				return fmt.Errorf("invalid host pattern of policy rule '%s': %s", rule.Name, host)
+			}
+		}
+		if rule.Path != "" {
+			re, err := regexp.Compile(rule.Path)
+			if err != nil {
+				return fmt.Errorf("invalid path regex of policy rule '%s': %s", rule.Name, err)
+			}
+			rule.path = re
+		}
+	}
+	p.violations = make(map[string]int)
+	return nil
+}
+
+// Check returns a *PolicyViolation if the request is denied by the policy. The first rule
+// matching the request decides, the default action applies if none does. Violations are
+// logged and counted per rule.
+func (p *Policy) Check(req *Request) error {
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		// Requests to URLs the rules cannot be matched against are left to the runner
+		return nil
+	}
+	var params map[string]bool
+	action, name := p.Default, "default"
+	for _, rule := range p.Rules {
+		if len(rule.Params) > 0 && params == nil {
+			params = requestParams(u, req)
+		}
+		if rule.matches(u, req.Method, params) {
+			action, name = rule.Action, rule.Name
+			break
+		}
+	}
+	if action == PolicyAllow {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.violations[name]++
+	p.mu.Unlock()
+	violation := &PolicyViolation{Rule: name, Method: req.Method, Url: req.Url}
+	logging.For("policy").Warn("Request denied by the scope policy", "rule", name, "method", req.Method, logging.FieldEndpoint, req.Url)
+	return violation
+}
+
+// Violations returns the number of requests denied by each rule of the policy
+func (p *Policy) Violations() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	violations := make(map[string]int, len(p.violations))
+	for name, count := range p.violations {
+		violations[name] = count
+	}
+	return violations
+}
+
+// matches returns true if the request matches all the conditions of the rule
+func (r *PolicyRule) matches(u *url.URL, method string, params map[string]bool) bool {
+	if len(r.Hosts) > 0 && !r.matchesHost(u) {
+		return false
+	}
+	if r.path != nil && !r.path.MatchString(u.Path) {
+		return false
+	}
+	if len(r.Methods) > 0 && !containsFold(r.Methods, method) {
+		return false
+	}
+	if len(r.Params) > 0 {
+		found := false
+		for _, param := range r.Params {
+			if params[strings.ToLower(param)] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesHost returns true if the host of the URL matches one of the host patterns
+func (r *PolicyRule) matchesHost(u *url.URL) bool {
+	for _, pattern := range r.Hosts {
+		pattern = strings.ToLower(pattern)
+		host := strings.ToLower(u.Hostname())
+		if strings.Contains(pattern, ":") {
+			host = strings.ToLower(u.Host)
+		}
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// requestParams returns the lowercased names of the query, form and JSON body parameters of
+// a request
+func requestParams(u *url.URL, req *Request) map[string]bool {
+	params := make(map[string]bool)
+	for name := range u.Query() {
+		params[strings.ToLower(name)] = true
+	}
+	body := strings.TrimSpace(string(req.Data))
+	if body == "" {
+		return params
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err == nil {
+		collectJSONKeys(doc, params)
+	} else if form, err := url.ParseQuery(body); err == nil {
+		for name := range form {
+			params[strings.ToLower(name)] = true
+		}
+	}
+	return params
+}
+
+// collectJSONKeys adds the lowercased keys of the objects of a JSON document
+func collectJSONKeys(doc interface{}, keys map[string]bool) {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			keys[strings.ToLower(key)] = true
+			collectJSONKeys(value, keys)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectJSONKeys(value, keys)
+		}
+	}
+}
+
+// containsFold returns true if the list contains the value, ignoring case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
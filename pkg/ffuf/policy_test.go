@@ -0,0 +1,85 @@
+package ffuf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	content := `default: deny
+rules:
+  - name: no logout
+    action: deny
+    path: ^/logout
+  - name: no deletes in prod
+    action: deny
+    hosts: ["*.prod.example.com"]
+    methods: [delete]
+  - name: no admin flag
+    action: deny
+    params: [isAdmin]
+  - action: allow
+    hosts: ["*.example.com", "localhost:8080"]
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy returned an error: %s", err)
+	}
+
+	tests := []struct {
+		method string
+		url    string
+		data   string
+		rule   string
+	}{
+		{"GET", "https://api.example.com/users", "", ""},
+		{"GET", "https://api.example.com/logout?next=/", "", "no logout"},
+		{"DELETE", "https://api.prod.example.com/users/1", "", "no deletes in prod"},
+		{"DELETE", "https://api.staging.example.com/users/1", "", ""},
+		{"GET", "https://api.example.com/users?ISADMIN=1", "", "no admin flag"},
+		{"POST", "https://api.example.com/users", `{"user":{"isAdmin":true}}`, "no admin flag"},
+		{"POST", "https://api.example.com/users", "name=a&isadmin=1", "no admin flag"},
+		{"GET", "http://localhost:8080/", "", ""},
+		{"GET", "http://localhost:9090/", "", "default"},
+		{"GET", "https://other.org/", "", "default"},
+	}
+	for _, tc := range tests {
+		err := policy.Check(&Request{Method: tc.method, Url: tc.url, Data: []byte(tc.data)})
+		if tc.rule == "" {
+			if err != nil {
+				t.Errorf("Expected %s %s to be allowed, got %s", tc.method, tc.url, err)
+			}
+			continue
+		}
+		violation, ok := err.(*PolicyViolation)
+		if !ok || violation.Rule != tc.rule || !IsPolicyViolation(err) {
+			t.Errorf("Expected %s %s to be denied by '%s', got %v", tc.method, tc.url, tc.rule, err)
+		}
+	}
+
+	violations := policy.Violations()
+	if violations["no admin flag"] != 3 || violations["default"] != 2 || violations["no logout"] != 1 {
+		t.Errorf("Unexpected violation counts: %v", violations)
+	}
+}
+
+func TestLoadPolicyErrors(t *testing.T) {
+	for _, content := range []string{
+		`{"rules": [{"action": "block"}]}`,
+		`{"rules": [{"action": "deny", "path": "("}]}`,
+		`{"default": "skip"}`,
+	} {
+		path := filepath.Join(t.TempDir(), "policy.json")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPolicy(path); err == nil {
+			t.Errorf("Expected policy %s to be rejected", content)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ffuf
 
 import (
+	"context"
 	"strings"
 	"time"
 )
@@ -17,6 +18,20 @@ type Request struct {
 	Raw       string
 	Error     string
 	Timestamp time.Time
+	// ctx cancels the request in place of the context of the config, if set
+	ctx context.Context
+}
+
+// Context returns the context cancelling the request, or nil if it is cancelled with the
+// context of the config
+func (r *Request) Context() context.Context {
+	return r.ctx
+}
+
+// SetContext sets the context cancelling the request, such as the context of the security
+// tester sending it, in place of the context of the config
+func (r *Request) SetContext(ctx context.Context) {
+	r.ctx = ctx
 }
 
 func NewRequest(conf *Config) Request {
@@ -66,6 +81,7 @@ func CopyRequest(basereq *Request) Request {
 
 	req.Position = basereq.Position
 	req.Raw = basereq.Raw
+	req.ctx = basereq.ctx
 
 	return req
 }
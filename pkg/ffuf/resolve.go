@@ -0,0 +1,65 @@
+package ffuf
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseResolve parses host pinning entries in the form host:port:address, as curl --resolve.
+// The port may be * to pin every port of the host, and the address may have a port to connect
+// to instead of the port of the request, e.g. api.example.com:443:10.0.0.5:8443. It returns the
+// pinned addresses by host:port, with an empty port if the address has none.
+func ParseResolve(entries []string) (map[string]string, error) {
+	pins := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("Bad resolve entry %s (-resolve). Expected host:port:address", entry)
+		}
+		host, port, address := strings.ToLower(parts[0]), parts[1], parts[2]
+		if port != "*" && !validPort(port) {
+			return nil, fmt.Errorf("Bad port %s in the resolve entry %s (-resolve). Expected a port number or *", port, entry)
+		}
+
+		// The address is a host, an IPv6 address, or either with a port
+		addressPort := ""
+		if h, p, err := net.SplitHostPort(address); err == nil {
+			address, addressPort = h, p
+			if !validPort(addressPort) {
+				return nil, fmt.Errorf("Bad address port %s in the resolve entry %s (-resolve)", addressPort, entry)
+			}
+		} else if strings.Contains(address, ":") && net.ParseIP(strings.Trim(address, "[]")) == nil {
+			return nil, fmt.Errorf("Bad address %s in the resolve entry %s (-resolve)", address, entry)
+		}
+		pins[host+":"+port] = net.JoinHostPort(strings.Trim(address, "[]"), addressPort)
+	}
+	return pins, nil
+}
+
+// ResolveAddress returns the address to connect to instead of a host:port address, pinned by the
+// entries parsed by ParseResolve, or the address itself if it is not pinned
+func ResolveAddress(pins map[string]string, addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || len(pins) == 0 {
+		return addr
+	}
+	pinned, ok := pins[strings.ToLower(host)+":"+port]
+	if !ok {
+		if pinned, ok = pins[strings.ToLower(host)+":*"]; !ok {
+			return addr
+		}
+	}
+	pinnedHost, pinnedPort, _ := net.SplitHostPort(pinned)
+	if pinnedPort == "" {
+		pinnedPort = port
+	}
+	return net.JoinHostPort(pinnedHost, pinnedPort)
+}
+
+// validPort returns true if a port is a number from 1 to 65535
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
@@ -0,0 +1,39 @@
+package ffuf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseResolve(t *testing.T) {
+	pins, err := ParseResolve([]string{"API.example.com:443:10.0.0.5", "staging.example.com:*:10.0.0.6:8443", "v6.example.com:80:::1", "lb.example.com:443:[::1]:8443", "cutover.example.com:443:new-lb.example.net"})
+	if err != nil {
+		t.Fatalf("ParseResolve returned an error: %s", err)
+	}
+	for addr, expected := range map[string]string{
+		"api.example.com:443":     "10.0.0.5:443",
+		"api.example.com:80":      "api.example.com:80",
+		"staging.example.com:80":  "10.0.0.6:8443",
+		"staging.example.com:443": "10.0.0.6:8443",
+		"v6.example.com:80":       "[::1]:80",
+		"lb.example.com:443":      "[::1]:8443",
+		"cutover.example.com:443": "new-lb.example.net:443",
+		"other.example.com:443":   "other.example.com:443",
+	} {
+		if resolved := ResolveAddress(pins, addr); resolved != expected {
+			t.Errorf("Expected %s to resolve to %s, got %s", addr, expected, resolved)
+		}
+	}
+
+	for entry, expected := range map[string]string{
+		"api.example.com":                "Expected host:port:address",
+		"api.example.com:443":            "Expected host:port:address",
+		"api.example.com:https:10.0.0.5": "Bad port https",
+		"api.example.com:443:10.0.0.5:0": "Bad address port 0",
+		"api.example.com:443:10.0.0:5:6": "Bad address",
+	} {
+		if _, err := ParseResolve([]string{entry}); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error containing %q for %s, got %v", expected, entry, err)
+		}
+	}
+}
@@ -15,6 +15,7 @@ type Response struct {
 	ContentWords  int64
 	ContentLines  int64
 	ContentType   string
+	Proto         string
 	Cancelled     bool
 	Request       *Request
 	Raw           string
@@ -22,6 +23,15 @@ type Response struct {
 	ScraperData   map[string][]string
 	Duration      time.Duration
 	Timestamp     time.Time
+	// Truncated is true if Data only holds the start of a body larger than the maximum body
+	// size. ContentLength, ContentWords, ContentLines and BodyHash cover the whole body.
+	Truncated bool
+	// BodyHash is the hex encoded SHA-256 hash of the body
+	BodyHash string
+	// ContentEncoding is the Content-Encoding the body was decoded from, if it was compressed
+	ContentEncoding string
+	// Charset is the charset the body was converted to UTF-8 from, if it was not UTF-8
+	Charset string
 }
 
 // GetRedirectLocation returns the redirect location for a 3xx redirect HTTP response
@@ -84,6 +94,7 @@ func NewResponse(httpresp *http.Response, req *Request) Response {
 	resp.Request = req
 	resp.StatusCode = int64(httpresp.StatusCode)
 	resp.ContentType = httpresp.Header.Get("Content-Type")
+	resp.Proto = httpresp.Proto
 	resp.Headers = httpresp.Header
 	resp.Cancelled = false
 	resp.Raw = ""
@@ -0,0 +1,83 @@
+package ffuf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryError is the error of a request still failing with a transient error after being
+// retried
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s (gave up after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout returns true if the last attempt timed out, so that os.IsTimeout still recognizes
+// timeouts once retried
+func (e *RetryError) Timeout() bool {
+	var timeout interface{ Timeout() bool }
+	return errors.As(e.Err, &timeout) && timeout.Timeout()
+}
+
+// IsTransientError returns true if a request failed with an error likely to go away when the
+// request is sent again: timeouts, connections reset or closed by the server before it
+// answered, and temporary DNS failures. Cancelled requests, requests denied by the scope
+// policy or the safe mode, invalid requests, unknown hosts, refused connections and TLS
+// errors are permanent.
+func IsTransientError(err error) bool {
+	if err == nil || IsPolicyViolation(err) || IsSafeModeViolation(err) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	// Errors of the HTTP/2 transport are not typed
+	message := err.Error()
+	for _, transient := range []string{"connection reset by peer", "broken pipe", "server closed idle connection", "GOAWAY", "http2: client connection lost"} {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryDelay returns the delay before the retry of a request following its nth failed
+// attempt, counting from 0: an exponential backoff from base, capped to max if it is set,
+// with a random jitter of up to half of the delay so that concurrent requests do not retry
+// in lockstep
+func RetryDelay(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 0; i < attempt && (max <= 0 || delay < max); i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	if half := int64(delay / 2); half > 0 {
+		delay = delay - time.Duration(half) + time.Duration(rand.Int63n(half+1))
+	}
+	return delay
+}
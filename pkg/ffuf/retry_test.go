@@ -0,0 +1,114 @@
+package ffuf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timeoutError is a network error timing out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{&url.Error{Op: "Get", URL: "https://api.example.com", Err: timeoutError{}}, true},
+		{&url.Error{Op: "Get", URL: "https://api.example.com", Err: io.EOF}, true},
+		{&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{fmt.Errorf("http2: server sent GOAWAY and closed the connection"), true},
+		{&net.DNSError{Err: "server misbehaving", Name: "api.example.com", IsTemporary: true}, true},
+		{&net.DNSError{Err: "no such host", Name: "api.example.com", IsNotFound: true}, false},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, false},
+		{&url.Error{Op: "Get", URL: "https://api.example.com", Err: context.Canceled}, false},
+		{&PolicyViolation{Rule: "deny", Method: "DELETE", Url: "https://api.example.com/users/1"}, false},
+		{errors.New("unsupported protocol scheme"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if transient := IsTransientError(tt.err); transient != tt.transient {
+			t.Errorf("IsTransientError(%v) = %t, expected %t", tt.err, transient, tt.transient)
+		}
+	}
+
+	retried := &RetryError{Attempts: 3, Err: &url.Error{Op: "Get", URL: "https://api.example.com", Err: timeoutError{}}}
+	if !os.IsTimeout(retried) || !errors.Is(retried, retried.Err) {
+		t.Errorf("Expected the retried timeout to still be a timeout")
+	}
+	if retried.Error() != `Get "https://api.example.com": i/o timeout (gave up after 3 attempts)` {
+		t.Errorf("Unexpected error message: %s", retried)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second} {
+		for i := 0; i < 20; i++ {
+			if delay := RetryDelay(attempt, base, time.Second); delay < expected/2 || delay > expected {
+				t.Fatalf("Expected the delay of attempt %d to be between %s and %s, got %s", attempt, expected/2, expected, delay)
+			}
+		}
+	}
+	if delay := RetryDelay(0, 0, time.Second); delay != 0 {
+		t.Errorf("Expected no delay without a base delay, got %s", delay)
+	}
+}
+
+// failingRunner fails every request with an error, counting the requests
+type failingRunner struct {
+	err   error
+	count int
+}
+
+func (r *failingRunner) Prepare(input map[string][]byte, basereq *Request) (Request, error) {
+	return *basereq, nil
+}
+
+func (r *failingRunner) Execute(req *Request) (Response, error) {
+	r.count++
+	return Response{}, r.err
+}
+
+func (r *failingRunner) Dump(req *Request) ([]byte, error) {
+	return nil, nil
+}
+
+func TestJobRetry(t *testing.T) {
+	tests := []struct {
+		err      error
+		requests int
+	}{
+		// Failed requests are retried once by the job
+		{io.EOF, 2},
+		{errors.New("invalid request"), 2},
+		// Transient errors already retried by the runner are not
+		{&RetryError{Attempts: 3, Err: io.EOF}, 1},
+	}
+	for _, tt := range tests {
+		conf := NewConfig(context.Background(), func() {})
+		runner := &failingRunner{err: tt.err}
+		job := NewJob(&conf)
+		job.Runner = runner
+		job.queuejobs = []QueueJob{{Url: "https://api.example.com/FUZZ", req: Request{Url: "https://api.example.com/FUZZ"}}}
+		job.queuepos = 1
+		job.runTask(map[string][]byte{"FUZZ": []byte("test")}, 1, false)
+		if runner.count != tt.requests {
+			t.Errorf("Expected %d requests failing with %v, got %d", tt.requests, tt.err, runner.count)
+		}
+		if job.ErrorCounter != 1 {
+			t.Errorf("Expected 1 error failing with %v, got %d", tt.err, job.ErrorCounter)
+		}
+	}
+}
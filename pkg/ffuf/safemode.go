@@ -0,0 +1,188 @@
+package ffuf
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+)
+
+const (
+	// SafeModeNonDestructive sends any request except destructive methods and payloads
+	SafeModeNonDestructive = "non-destructive"
+	// SafeModeReadOnly only sends requests with safe methods and no destructive payloads
+	SafeModeReadOnly = "read-only"
+)
+
+// readOnlyMethods are the methods sent as is in read-only mode
+var readOnlyMethods = []string{"GET", "HEAD", "OPTIONS"}
+
+// destructiveMethods are the methods substituted in non-destructive mode
+var destructiveMethods = []string{"DELETE", "PURGE"}
+
+// methodOverrideHeaders are the headers frameworks read an overridden method from
+var methodOverrideHeaders = []string{"X-HTTP-Method-Override", "X-HTTP-Method", "X-Method-Override"}
+
+// methodOverrideParam matches the _method query parameter or form field
+var methodOverrideParam = regexp.MustCompile(`(?i)(?:^|&)_method=([a-z]+)`)
+
+// DestructivePayload is a pattern of payloads blocked in safe mode
+type DestructivePayload struct {
+	// Kind is the reason the payload is blocked, e.g. "sleep" or "file write"
+	Kind  string
+	Regex *regexp.Regexp
+}
+
+// DestructivePayloads are the payloads blocked in safe mode: payloads deleting or modifying
+// data, writing files, or delaying the server with sleeps and heavy computations
+var DestructivePayloads = []DestructivePayload{
+	{Kind: "sleep", Regex: regexp.MustCompile(`(?i)\b(?:sleep|pg_sleep|benchmark|dbms_lock\.sleep)\s*\(|\bwaitfor\s+delay\b|(?:^|[;|&` + "`" + `(])\s*sleep\s+\d`)},
+	{Kind: "data deletion", Regex: regexp.MustCompile(`(?i)\b(?:drop|truncate)\s+(?:table|database|schema|collection)\b|\bdelete\s+from\b|\.(?:drop|remove|deletemany)\s*\(|\bflushall\b`)},
+	{Kind: "data modification", Regex: regexp.MustCompile(`(?i)\binsert\s+into\b|\bupdate\s+\S+\s+set\b|;\s*shutdown\b`)},
+	{Kind: "file write", Regex: regexp.MustCompile(`(?i)\binto\s+(?:out|dump)file\b|\bxp_cmdshell\b|\b(?:writefile|unlink)(?:sync)?\s*\(|(?:^|[;|&` + "`" + `(])\s*(?:rm\s+-\w*[rf]|mkfs|shred|dd\s+if=|(?:echo|printf|cat)\b[^;|&]*>\s*/)`)},
+}
+
+// SafeMode restricts the requests of a scan so that it can be run against staging and
+// production environments. Requests with methods beyond the consent level are substituted
+// with read-only GET requests to the same URL, and requests carrying destructive payloads are
+// blocked. It is enforced by the runners, like the scope policy.
+type SafeMode struct {
+	Level       string
+	blocked     map[string]int
+	substituted int
+	mu          sync.Mutex
+}
+
+// SafeModeViolation is the error returned for requests blocked in safe mode
+type SafeModeViolation struct {
+	Kind   string
+	Method string
+	Url    string
+}
+
+func (v *SafeModeViolation) Error() string {
+	return fmt.Sprintf("%s %s blocked by safe mode: %s payload", v.Method, v.Url, v.Kind)
+}
+
+// IsSafeModeViolation returns true if the error is caused by a request blocked in safe mode
+func IsSafeModeViolation(err error) bool {
+	var violation *SafeModeViolation
+	return errors.As(err, &violation)
+}
+
+// NewSafeMode creates a safe mode of a consent level, read-only or non-destructive
+func NewSafeMode(level string) (*SafeMode, error) {
+	level = strings.ToLower(level)
+	if level != SafeModeReadOnly && level != SafeModeNonDestructive {
+		return nil, fmt.Errorf("safe mode must be read-only or non-destructive, got %s", level)
+	}
+	return &SafeMode{Level: level, blocked: make(map[string]int)}, nil
+}
+
+// Apply substitutes the request with a read-only GET request if its method, or the method
+// it overrides, is beyond the consent level, and returns a *SafeModeViolation if it carries a
+// destructive payload
+func (s *SafeMode) Apply(req *Request) error {
+	if kind := destructivePayload(req); kind != "" {
+		s.mu.Lock()
+		s.blocked[kind]++
+		s.mu.Unlock()
+		violation := &SafeModeViolation{Kind: kind, Method: req.Method, Url: req.Url}
+		logging.For("safemode").Warn("Request blocked by safe mode", "payload", kind, "method", req.Method, logging.FieldEndpoint, req.Url)
+		return violation
+	}
+	// Servers ignoring method overrides process the request with its own method
+	method := effectiveMethod(req)
+	if s.allowed(req.Method) && s.allowed(method) {
+		return nil
+	}
+
+	logging.For("safemode").Info("Substituting the request with GET", "method", method, logging.FieldEndpoint, req.Url)
+	req.Method = "GET"
+	req.Data = nil
+	// The headers may be shared with the config and other requests
+	headers := make(map[string]string, len(req.Headers))
+	for name, value := range req.Headers {
+		if !containsFold(methodOverrideHeaders, name) && !strings.EqualFold(name, "Content-Type") && !strings.EqualFold(name, "Content-Length") {
+			headers[name] = value
+		}
+	}
+	req.Headers = headers
+	if u, err := url.Parse(req.Url); err == nil && u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if strings.EqualFold(name, "_method") {
+				query.Del(name)
+			}
+		}
+		u.RawQuery = query.Encode()
+		req.Url = u.String()
+	}
+	s.mu.Lock()
+	s.substituted++
+	s.mu.Unlock()
+	return nil
+}
+
+// allowed returns true if a method is sent as is at the consent level
+func (s *SafeMode) allowed(method string) bool {
+	if s.Level == SafeModeReadOnly {
+		return containsFold(readOnlyMethods, method)
+	}
+	return !containsFold(destructiveMethods, method)
+}
+
+// Stats returns the number of requests blocked for each kind of destructive payload, and the
+// number of requests substituted with read-only requests
+func (s *SafeMode) Stats() (map[string]int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	blocked := make(map[string]int, len(s.blocked))
+	for kind, count := range s.blocked {
+		blocked[kind] = count
+	}
+	return blocked, s.substituted
+}
+
+// effectiveMethod returns the method of a request, or the method it overrides with a header,
+// query parameter or form field
+func effectiveMethod(req *Request) string {
+	for name, value := range req.Headers {
+		if containsFold(methodOverrideHeaders, name) && value != "" {
+			return strings.ToUpper(value)
+		}
+	}
+	if u, err := url.Parse(req.Url); err == nil {
+		if m := methodOverrideParam.FindStringSubmatch(u.RawQuery); m != nil {
+			return strings.ToUpper(m[1])
+		}
+	}
+	if m := methodOverrideParam.FindSubmatch(req.Data); m != nil {
+		return strings.ToUpper(string(m[1]))
+	}
+	return strings.ToUpper(req.Method)
+}
+
+// destructivePayload returns the kind of the destructive payload carried by the URL, headers
+// or body of a request, or an empty string if there is none
+func destructivePayload(req *Request) string {
+	parts := []string{req.Url, string(req.Data)}
+	if unescaped, err := url.QueryUnescape(req.Url); err == nil {
+		parts = append(parts, unescaped)
+	}
+	for _, value := range req.Headers {
+		parts = append(parts, value)
+	}
+	for _, payload := range DestructivePayloads {
+		for _, part := range parts {
+			if part != "" && payload.Regex.MatchString(part) {
+				return payload.Kind
+			}
+		}
+	}
+	return ""
+}
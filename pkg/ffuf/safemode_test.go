@@ -0,0 +1,97 @@
+package ffuf
+
+import (
+	"testing"
+)
+
+func TestSafeModeSubstitution(t *testing.T) {
+	readOnly, err := NewSafeMode("read-only")
+	if err != nil {
+		t.Fatalf("NewSafeMode returned an error: %s", err)
+	}
+	nonDestructive, _ := NewSafeMode("Non-Destructive")
+
+	tests := []struct {
+		mode    *SafeMode
+		method  string
+		url     string
+		headers map[string]string
+		data    string
+		// substituted is true if the request is expected to be replaced with a GET request
+		substituted bool
+	}{
+		{readOnly, "GET", "https://api.example.com/users", nil, "", false},
+		{readOnly, "HEAD", "https://api.example.com/users", nil, "", false},
+		{readOnly, "POST", "https://api.example.com/users", map[string]string{"Content-Type": "application/json"}, `{"name":"a"}`, true},
+		{readOnly, "GET", "https://api.example.com/users/1?_method=DELETE&a=1", nil, "", true},
+		{readOnly, "POST", "https://api.example.com/users/1", map[string]string{"X-HTTP-Method-Override": "GET"}, "", true},
+		{nonDestructive, "POST", "https://api.example.com/users", nil, `{"name":"a"}`, false},
+		{nonDestructive, "delete", "https://api.example.com/users/1", nil, "", true},
+		{nonDestructive, "POST", "https://api.example.com/users/1", map[string]string{"x-http-method-override": "DELETE"}, "", true},
+		{nonDestructive, "POST", "https://api.example.com/users/1", nil, "_method=delete", true},
+	}
+	for _, tc := range tests {
+		headers := map[string]string{"Authorization": "Bearer token"}
+		for name, value := range tc.headers {
+			headers[name] = value
+		}
+		req := &Request{Method: tc.method, Url: tc.url, Headers: headers, Data: []byte(tc.data)}
+		if err := tc.mode.Apply(req); err != nil {
+			t.Errorf("Expected %s %s not to be blocked, got %s", tc.method, tc.url, err)
+			continue
+		}
+		if !tc.substituted {
+			if req.Method != tc.method || string(req.Data) != tc.data {
+				t.Errorf("Expected %s %s to be sent as is, got %s", tc.method, tc.url, req.Method)
+			}
+			continue
+		}
+		if req.Method != "GET" || len(req.Data) > 0 || req.Headers["Authorization"] != "Bearer token" || len(req.Headers) != 1 {
+			t.Errorf("Expected %s %s to be substituted with GET, got %s %v", tc.method, tc.url, req.Method, req.Headers)
+		}
+		if len(headers) != len(tc.headers)+1 {
+			t.Errorf("Expected the headers of the request not to be modified")
+		}
+	}
+	if _, substituted := readOnly.Stats(); substituted != 3 {
+		t.Errorf("Expected 3 requests to be substituted, got %d", substituted)
+	}
+	if _, err := NewSafeMode("off"); err == nil {
+		t.Errorf("Expected an unknown consent level to be rejected")
+	}
+}
+
+func TestSafeModeDestructivePayloads(t *testing.T) {
+	safeMode, _ := NewSafeMode("non-destructive")
+	blocked := map[string]string{
+		"https://api.example.com/users?id=1%27%20AND%20SLEEP(5)%20--": "sleep",
+		"https://api.example.com/users?id=1';WAITFOR DELAY '0:0:5'--": "sleep",
+		"https://api.example.com/ping?host=127.0.0.1;sleep 5":         "sleep",
+		"https://api.example.com/users?id=1;DROP TABLE users":         "data deletion",
+		"https://api.example.com/users?id=1' INTO OUTFILE '/tmp/x'":   "file write",
+		"https://api.example.com/ping?host=x|rm -rf /":                "file write",
+	}
+	for rawURL, kind := range blocked {
+		err := safeMode.Apply(&Request{Method: "GET", Url: rawURL})
+		if violation, ok := err.(*SafeModeViolation); !ok || violation.Kind != kind || !IsSafeModeViolation(err) {
+			t.Errorf("Expected %s to be blocked as %s, got %v", rawURL, kind, err)
+		}
+	}
+	body := `{"filter":"'; DELETE FROM users; --"}`
+	if err := safeMode.Apply(&Request{Method: "POST", Url: "https://api.example.com/search", Data: []byte(body)}); !IsSafeModeViolation(err) {
+		t.Errorf("Expected a destructive body to be blocked, got %v", err)
+	}
+
+	for _, rawURL := range []string{
+		"https://api.example.com/users?id=1%27%20OR%20%271%27=%271",
+		"https://api.example.com/sleep-tracker/users?sort=update",
+		"https://api.example.com/search?q=<a>/b</a>",
+	} {
+		if err := safeMode.Apply(&Request{Method: "GET", Url: rawURL}); err != nil {
+			t.Errorf("Expected %s not to be blocked, got %s", rawURL, err)
+		}
+	}
+	if blockedKinds, _ := safeMode.Stats(); blockedKinds["sleep"] != 3 || blockedKinds["data deletion"] != 2 {
+		t.Errorf("Unexpected blocked counts: %v", blockedKinds)
+	}
+}
@@ -451,6 +451,8 @@ func (a *APIConsole) visualizeAPIMap(args []string) {
 			format = parser.VisFormatDOT
 		case "mermaid":
 			format = parser.VisFormatMermaid
+		case "svg":
+			format = parser.VisFormatSVG
 		default:
 			a.Job.Output.Warning(fmt.Sprintf("Unknown visualization format: %s. Using HTML.", args[1]))
 		}
@@ -475,8 +477,8 @@ func (a *APIConsole) visualizeAPIMap(args []string) {
 	a.Job.Output.Raw(fmt.Sprintf("\nAPI Map Visualization (%s format):\n\n", format))
 	a.Job.Output.Raw(visualization)
 
-	// If it's HTML, suggest saving to a file
-	if format == parser.VisFormatHTML {
+	// If it's HTML or SVG, suggest saving to a file
+	if format == parser.VisFormatHTML || format == parser.VisFormatSVG {
 		a.Job.Output.Info("To view the HTML visualization, save it to a file and open in a browser.")
 	}
 }
@@ -502,7 +504,7 @@ API Console Commands:
   show                      - Show current request
   send                      - Send the request
   diff                      - Compare the last two API responses
-  map [FORMAT]              - Generate API structure visualization (formats: json, html, dot, mermaid)
+  map [FORMAT]              - Generate API structure visualization (formats: json, html, dot, mermaid, svg)
   help                      - Show this help
 `
 	a.Job.Output.Raw(help)
@@ -23,6 +23,7 @@ type JsonResult struct {
 	ContentWords     int64               `json:"words"`
 	ContentLines     int64               `json:"lines"`
 	ContentType      string              `json:"content-type"`
+	Proto            string              `json:"proto"`
 	RedirectLocation string              `json:"redirectlocation"`
 	ScraperData      map[string][]string `json:"scraper"`
 	Duration         time.Duration       `json:"duration"`
@@ -73,6 +74,7 @@ func writeJSON(filename string, config *ffuf.Config, res []ffuf.Result) error {
 			ContentWords:     r.ContentWords,
 			ContentLines:     r.ContentLines,
 			ContentType:      r.ContentType,
+			Proto:            r.Proto,
 			RedirectLocation: r.RedirectLocation,
 			ScraperData:      r.ScraperData,
 			Duration:         r.Duration,
@@ -331,6 +331,7 @@ func (s *Stdoutput) Result(resp ffuf.Response) {
 		ContentWords:     resp.ContentWords,
 		ContentLines:     resp.ContentLines,
 		ContentType:      resp.ContentType,
+		Proto:            resp.Proto,
 		RedirectLocation: resp.GetRedirectLocation(false),
 		ScraperData:      resp.ScraperData,
 		Url:              resp.Request.Url,
@@ -417,6 +418,9 @@ func (s *Stdoutput) resultMultiline(res ffuf.Result) {
 	reslines := ""
 	if s.config.Verbose {
 		reslines = fmt.Sprintf("%s%s| URL | %s\n", reslines, TERMINAL_CLEAR_LINE, res.Url)
+		if res.Proto != "" {
+			reslines = fmt.Sprintf("%s%s| PRO | %s\n", reslines, TERMINAL_CLEAR_LINE, res.Proto)
+		}
 		redirectLocation := res.RedirectLocation
 		if redirectLocation != "" {
 			reslines = fmt.Sprintf("%s%s| --> | %s\n", reslines, TERMINAL_CLEAR_LINE, redirectLocation)
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// ReadBody reads a response body into resp.Data, keeping at most limit bytes of it, or all of
+// it if limit is 0. The rest of a larger body is read without being kept, and the response is
+// marked as truncated: its length, words, lines and hash are those of the whole body. If the
+// body can not be read, the data of the response is left empty and its length as is.
+func ReadBody(body io.Reader, limit int, resp *ffuf.Response) error {
+	var data bytes.Buffer
+	counter := newBodyCounter()
+	if _, err := io.Copy(io.MultiWriter(NewLimitWriter(&data, limit), counter), body); err != nil {
+		resp.Data = nil
+		resp.ContentWords = 1
+		resp.ContentLines = 1
+		return err
+	}
+	resp.Data = data.Bytes()
+	resp.Truncated = counter.length > int64(len(resp.Data))
+	counter.apply(resp)
+	return nil
+}
+
+// CountBody sets the length, words, lines and hash of a response from its data
+func CountBody(resp *ffuf.Response) {
+	counter := newBodyCounter()
+	counter.Write(resp.Data)
+	counter.apply(resp)
+}
+
+// bodyCounter counts the length, the words and the lines of a body written to it, and computes
+// its SHA-256 hash
+type bodyCounter struct {
+	length int64
+	spaces int64
+	lines  int64
+	hash   hash.Hash
+}
+
+func newBodyCounter() *bodyCounter {
+	return &bodyCounter{hash: sha256.New()}
+}
+
+func (c *bodyCounter) Write(p []byte) (int, error) {
+	c.length += int64(len(p))
+	c.spaces += int64(bytes.Count(p, []byte(" ")))
+	c.lines += int64(bytes.Count(p, []byte("\n")))
+	c.hash.Write(p)
+	return len(p), nil
+}
+
+// apply sets the counts and the hash of the body to a response. Words and lines are counted
+// as the fields separated by spaces and newlines.
+func (c *bodyCounter) apply(resp *ffuf.Response) {
+	resp.ContentLength = c.length
+	resp.ContentWords = c.spaces + 1
+	resp.ContentLines = c.lines + 1
+	resp.BodyHash = hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// LimitWriter writes at most a number of bytes to a writer and discards the rest, reporting
+// every write as complete
+type LimitWriter struct {
+	w         io.Writer
+	remaining int
+	limited   bool
+}
+
+// NewLimitWriter creates a writer writing at most limit bytes to w, or all of them if limit is 0
+func NewLimitWriter(w io.Writer, limit int) *LimitWriter {
+	return &LimitWriter{w: w, remaining: limit, limited: limit > 0}
+}
+
+func (l *LimitWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if l.limited {
+		if l.remaining <= 0 {
+			return n, nil
+		}
+		if len(p) > l.remaining {
+			p = p[:l.remaining]
+		}
+		l.remaining -= len(p)
+	}
+	if _, err := l.w.Write(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// BudgetRunner executes requests of a runner within a worker budget shared with other runners,
+// bounding the requests in flight across jobs scanning different targets at once
+type BudgetRunner struct {
+	ctx     context.Context
+	workers *WorkerBudget
+	runner  ffuf.RunnerProvider
+}
+
+// WorkerBudget bounds the requests in flight across the runners sharing it. Free workers are
+// granted to the waiting requests round-robin across hosts, and across the endpoints of each
+// host, so that the requests to the first endpoints of a scan do not starve the others.
+type WorkerBudget struct {
+	mu    sync.Mutex
+	free  int
+	hosts []*budgetHost
+}
+
+// budgetHost is a host with requests waiting for a worker, and its endpoints in the order of
+// their turns
+type budgetHost struct {
+	name      string
+	endpoints []*budgetEndpoint
+}
+
+// budgetEndpoint is an endpoint with requests waiting for a worker, in the order they arrived
+type budgetEndpoint struct {
+	name    string
+	waiting []chan struct{}
+}
+
+// NewWorkerBudget creates a worker budget allowing size requests in flight at once
+func NewWorkerBudget(size int) *WorkerBudget {
+	if size < 1 {
+		size = 1
+	}
+	return &WorkerBudget{free: size}
+}
+
+// Acquire waits for a free worker to send a request to a URL, and returns an error if the
+// context is done first. The worker is given back with Release.
+func (b *WorkerBudget) Acquire(ctx context.Context, rawURL string) error {
+	b.mu.Lock()
+	if b.free > 0 {
+		b.free--
+		b.mu.Unlock()
+		return nil
+	}
+	host, endpoint := budgetKey(rawURL)
+	granted := make(chan struct{})
+	b.enqueue(host, endpoint, granted)
+	b.mu.Unlock()
+
+	select {
+	case <-granted:
+		return nil
+	case <-ctx.Done():
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.dequeue(host, endpoint, granted) {
+		// The worker was granted in the meantime
+		b.release()
+	}
+	return ctx.Err()
+}
+
+// Release gives back a worker, granted to the next waiting request if any
+func (b *WorkerBudget) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.release()
+}
+
+// release grants a worker to the first request of the next endpoint of the next host, and
+// moves both to the end of their turns
+func (b *WorkerBudget) release() {
+	if len(b.hosts) == 0 {
+		b.free++
+		return
+	}
+	host := b.hosts[0]
+	endpoint := host.endpoints[0]
+	close(endpoint.waiting[0])
+	endpoint.waiting = endpoint.waiting[1:]
+
+	host.endpoints = host.endpoints[1:]
+	if len(endpoint.waiting) > 0 {
+		host.endpoints = append(host.endpoints, endpoint)
+	}
+	b.hosts = b.hosts[1:]
+	if len(host.endpoints) > 0 {
+		b.hosts = append(b.hosts, host)
+	}
+}
+
+// enqueue adds a request waiting for a worker to its endpoint, new hosts and endpoints taking
+// the last turn
+func (b *WorkerBudget) enqueue(hostName, endpointName string, granted chan struct{}) {
+	var host *budgetHost
+	for _, h := range b.hosts {
+		if h.name == hostName {
+			host = h
+			break
+		}
+	}
+	if host == nil {
+		host = &budgetHost{name: hostName}
+		b.hosts = append(b.hosts, host)
+	}
+	for _, endpoint := range host.endpoints {
+		if endpoint.name == endpointName {
+			endpoint.waiting = append(endpoint.waiting, granted)
+			return
+		}
+	}
+	host.endpoints = append(host.endpoints, &budgetEndpoint{name: endpointName, waiting: []chan struct{}{granted}})
+}
+
+// dequeue removes a request that stopped waiting, and returns false if it is not waiting
+// anymore because it was granted a worker
+func (b *WorkerBudget) dequeue(hostName, endpointName string, granted chan struct{}) bool {
+	for i, host := range b.hosts {
+		if host.name != hostName {
+			continue
+		}
+		for j, endpoint := range host.endpoints {
+			if endpoint.name != endpointName {
+				continue
+			}
+			for k, waiting := range endpoint.waiting {
+				if waiting != granted {
+					continue
+				}
+				endpoint.waiting = append(endpoint.waiting[:k], endpoint.waiting[k+1:]...)
+				if len(endpoint.waiting) == 0 {
+					host.endpoints = append(host.endpoints[:j], host.endpoints[j+1:]...)
+				}
+				if len(host.endpoints) == 0 {
+					b.hosts = append(b.hosts[:i], b.hosts[i+1:]...)
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// budgetKey returns the host and the endpoint of a URL taking turns for the workers. The
+// endpoint is the path of the URL, regardless of its query.
+func budgetKey(rawURL string) (string, string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, ""
+	}
+	return u.Host, u.Path
+}
+
+// NewBudgetRunner creates a runner executing the requests of a runner within a worker budget.
+// Waiting for a worker is abandoned once the context is cancelled.
+func NewBudgetRunner(ctx context.Context, workers *WorkerBudget, r ffuf.RunnerProvider) *BudgetRunner {
+	return &BudgetRunner{
+		ctx:     ctx,
+		workers: workers,
+		runner:  r,
+	}
+}
+
+// Prepare prepares a request using the underlying runner
+func (r *BudgetRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return r.runner.Prepare(input, basereq)
+}
+
+// Dump dumps a request using the underlying runner
+func (r *BudgetRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return r.runner.Dump(req)
+}
+
+// Execute waits for a free worker of the budget, then executes the request
+func (r *BudgetRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	if err := r.workers.Acquire(r.ctx, req.Url); err != nil {
+		return ffuf.Response{}, err
+	}
+	defer r.workers.Release()
+	return r.runner.Execute(req)
+}
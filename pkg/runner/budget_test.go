@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// slowRunner records the highest number of requests it executed at once
+type slowRunner struct {
+	inflight int32
+	peak     int32
+}
+
+func (r *slowRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	return *basereq, nil
+}
+
+func (r *slowRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	return nil, nil
+}
+
+func (r *slowRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	n := atomic.AddInt32(&r.inflight, 1)
+	for {
+		peak := atomic.LoadInt32(&r.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&r.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	atomic.AddInt32(&r.inflight, -1)
+	return ffuf.Response{StatusCode: 200}, nil
+}
+
+func TestBudgetRunner(t *testing.T) {
+	workers := NewWorkerBudget(3)
+	shared := &slowRunner{}
+	runners := []*BudgetRunner{
+		NewBudgetRunner(context.Background(), workers, shared),
+		NewBudgetRunner(context.Background(), workers, shared),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func(r *BudgetRunner) {
+			defer wg.Done()
+			if _, err := r.Execute(&ffuf.Request{}); err != nil {
+				t.Errorf("Execute returned an error: %s", err)
+			}
+		}(runners[i%2])
+	}
+	wg.Wait()
+	if shared.peak > 3 {
+		t.Errorf("Expected at most 3 requests in flight across the runners, got %d", shared.peak)
+	}
+
+	// Waiting for a worker ends once the context is cancelled
+	for i := 0; i < 3; i++ {
+		workers.Acquire(context.Background(), "")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewBudgetRunner(ctx, workers, shared).Execute(&ffuf.Request{}); err == nil {
+		t.Errorf("Expected a cancelled runner to return an error")
+	}
+}
+
+// queued returns the number of requests waiting for a worker of a budget
+func queued(b *WorkerBudget) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, host := range b.hosts {
+		for _, endpoint := range host.endpoints {
+			n += len(endpoint.waiting)
+		}
+	}
+	return n
+}
+
+func TestWorkerBudget_Fair(t *testing.T) {
+	workers := NewWorkerBudget(1)
+	workers.Acquire(context.Background(), "")
+
+	// The requests of the first endpoint are queued first, but the worker is granted
+	// round-robin across the hosts and the endpoints of each host
+	urls := []string{
+		"https://a.example.com/users?id=1", "https://a.example.com/users?id=2", "https://a.example.com/users?id=3",
+		"https://a.example.com/orders", "https://b.example.com/users",
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	order := make([]string, 0, len(urls))
+	for i, u := range urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			workers.Acquire(context.Background(), u)
+			mu.Lock()
+			order = append(order, u)
+			mu.Unlock()
+			workers.Release()
+		}(u)
+		for queued(workers) < i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// A request no longer waiting gives up its turn
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+	if err := workers.Acquire(ctx, "https://c.example.com/"); err == nil {
+		t.Errorf("Expected a cancelled request to return an error")
+	}
+	workers.Release()
+	wg.Wait()
+
+	expected := []string{urls[0], urls[4], urls[3], urls[1], urls[2]}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Unexpected order of the requests %v, expected %v", order, expected)
+		}
+	}
+	if workers.free != 1 || len(workers.hosts) != 0 {
+		t.Errorf("Expected the worker to be free once every request completed, got %d free", workers.free)
+	}
+}
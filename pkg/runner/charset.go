@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// charsetPreview is the number of bytes of a body examined to detect its charset
+const charsetPreview = 1024
+
+var (
+	byteOrderMarks = []struct {
+		bom     []byte
+		charset string
+	}{
+		{[]byte{0xef, 0xbb, 0xbf}, "utf-8"},
+		{[]byte{0xfe, 0xff}, "utf-16be"},
+		{[]byte{0xff, 0xfe}, "utf-16le"},
+	}
+	xmlEncodingRegex = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+	metaCharsetRegex = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([A-Za-z0-9._:-]+)`)
+)
+
+// DecodeCharset returns a reader of a body converted to UTF-8, and the name of the charset it
+// is converted from. The charset is that of a byte order mark, which is removed, of the
+// Content-Type, of the declaration of an XML body or the meta tag of an HTML body, or UTF-16 for
+// a JSON body whose first character has a null byte. Bodies in UTF-8, in an unknown charset or
+// without one are read as is, with an empty name.
+func DecodeCharset(body io.Reader, contentType string) (io.Reader, string) {
+	buffered := bufio.NewReaderSize(body, charsetPreview)
+	preview, _ := buffered.Peek(charsetPreview)
+	name := ""
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(preview, mark.bom) {
+			buffered.Discard(len(mark.bom))
+			name = mark.charset
+			break
+		}
+	}
+	if name == "" {
+		name = detectCharset(preview, contentType)
+	}
+	encoding, name := charset.Lookup(name)
+	if encoding == nil || name == "utf-8" {
+		return buffered, ""
+	}
+	return encoding.NewDecoder().Reader(buffered), name
+}
+
+// detectCharset returns the charset declared by a Content-Type or in the beginning of a body
+func detectCharset(preview []byte, contentType string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err == nil && params["charset"] != "" {
+		return params["charset"]
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		// JSON texts start with an ASCII character, RFC 4627
+		if len(preview) >= 2 && preview[0] == 0 && preview[1] != 0 {
+			return "utf-16be"
+		}
+		if len(preview) >= 2 && preview[0] != 0 && preview[1] == 0 {
+			return "utf-16le"
+		}
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		if match := metaCharsetRegex.FindSubmatch(preview); match != nil {
+			return string(match[1])
+		}
+	}
+	if match := xmlEncodingRegex.FindSubmatch(preview); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
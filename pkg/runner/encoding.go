@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ContentEncoding returns the content encoding of a response, including gzip if the body was
+// already decoded by the transport
+func ContentEncoding(httpresp *http.Response) string {
+	if encoding := httpresp.Header.Get("Content-Encoding"); encoding != "" {
+		return encoding
+	}
+	if httpresp.Uncompressed {
+		return "gzip"
+	}
+	return ""
+}
+
+// DecodeBody returns a reader of a body decoded from a Content-Encoding: gzip, br, zstd and
+// deflate, with or without its zlib header. Multiple encodings are decoded in the reverse of
+// the order they are listed in. A body with an unknown encoding, or not starting like its
+// encoding, is read as is.
+func DecodeBody(body io.Reader, encoding string) io.Reader {
+	encodings := strings.Split(encoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		body = decodeReader(body, strings.ToLower(strings.TrimSpace(encodings[i])))
+	}
+	return body
+}
+
+// decodeReader returns a reader decoding a single content encoding
+func decodeReader(body io.Reader, encoding string) io.Reader {
+	switch encoding {
+	case "gzip", "x-gzip", "br", "zstd", "deflate":
+	default:
+		return body
+	}
+	buffered := bufio.NewReader(body)
+	switch encoding {
+	case "gzip", "x-gzip":
+		if magic, _ := buffered.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+			return buffered
+		}
+		if reader, err := gzip.NewReader(buffered); err == nil {
+			return reader
+		}
+	case "br":
+		return brotli.NewReader(buffered)
+	case "zstd":
+		if magic, _ := buffered.Peek(len(zstdMagic)); !bytes.Equal(magic, zstdMagic) {
+			return buffered
+		}
+		// A single decoding goroutine decodes the stream synchronously, so that readers
+		// never closed do not leak goroutines
+		if decoder, err := zstd.NewReader(buffered, zstd.WithDecoderConcurrency(1)); err == nil {
+			return decoder
+		}
+	case "deflate":
+		// Servers send deflate bodies both with the zlib header of RFC 9110 and without it
+		if header, _ := buffered.Peek(2); len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			if reader, err := zlib.NewReader(buffered); err == nil {
+				return reader
+			}
+		}
+		return flate.NewReader(buffered)
+	}
+	return buffered
+}
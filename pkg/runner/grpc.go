@@ -0,0 +1,282 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+
+	"golang.org/x/net/http2"
+)
+
+// GRPCRunner sends requests as unary gRPC calls over HTTP/2, or as gRPC-web calls.
+//
+// The body of the request is the encoded request message, which is framed by the runner.
+// The response contains the decoded response messages, and the gRPC status and message
+// in the Grpc-Status and Grpc-Message headers.
+type GRPCRunner struct {
+	config *ffuf.Config
+	client *http.Client
+	// h2c is the client for plaintext HTTP/2 (h2c) URLs
+	h2c *http.Client
+	web bool
+	// Encode creates the body of a request from the input values and the base request body,
+	// e.g. by encoding a JSON message template as protobuf. If nil, the keywords of the
+	// body are replaced as is.
+	Encode func(input map[string][]byte, data []byte) ([]byte, error)
+}
+
+// NewGRPCRunner creates a runner for gRPC, or gRPC-web if web is true
+func NewGRPCRunner(conf *ffuf.Config, web bool) *GRPCRunner {
+	cert := []tls.Certificate{}
+	if conf.ClientCert != "" && conf.ClientKey != "" {
+		tmp, _ := tls.LoadX509KeyPair(conf.ClientCert, conf.ClientKey)
+		cert = []tls.Certificate{tmp}
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         conf.SNI,
+		Certificates:       cert,
+	}
+	timeout := time.Duration(conf.Timeout) * time.Second
+	checkRedirect := func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse }
+
+	r := &GRPCRunner{config: conf, web: web}
+	if web {
+		// gRPC-web is served over HTTP/1.1 or HTTP/2, through proxies
+		proxyURL := ProxyFunc(conf, false)
+		r.client = &http.Client{
+			CheckRedirect: checkRedirect,
+			Timeout:       timeout,
+			Transport: &http.Transport{
+				ForceAttemptHTTP2:   true,
+				Proxy:               proxyURL,
+				MaxIdleConnsPerHost: 500,
+				DialContext:         PinnedDial(conf, (&net.Dialer{Timeout: timeout}).DialContext),
+				TLSHandshakeTimeout: timeout,
+				TLSClientConfig:     tlsConfig,
+			},
+		}
+		return r
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	dial := PinnedDial(conf, dialer.DialContext)
+	r.client = &http.Client{
+		CheckRedirect: checkRedirect,
+		Timeout:       timeout,
+		Transport:     &http2.Transport{TLSClientConfig: tlsConfig, DialTLS: pinnedDialTLS(conf, dialer)},
+	}
+	r.h2c = &http.Client{
+		CheckRedirect: checkRedirect,
+		Timeout:       timeout,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return dial(context.Background(), network, addr)
+			},
+		},
+	}
+	return r
+}
+
+func (r *GRPCRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	req := ffuf.CopyRequest(basereq)
+
+	for keyword, inputitem := range input {
+		headers := make(map[string]string, len(req.Headers))
+		for h, v := range req.Headers {
+			var CanonicalHeader string = textproto.CanonicalMIMEHeaderKey(strings.ReplaceAll(h, keyword, string(inputitem)))
+			headers[CanonicalHeader] = strings.ReplaceAll(v, keyword, string(inputitem))
+		}
+		req.Headers = headers
+		req.Url = strings.ReplaceAll(req.Url, keyword, string(inputitem))
+		if r.Encode == nil {
+			req.Data = bytes.ReplaceAll(req.Data, []byte(keyword), inputitem)
+		}
+	}
+
+	if r.Encode != nil {
+		data, err := r.Encode(input, basereq.Data)
+		if err != nil {
+			return req, err
+		}
+		req.Data = data
+	}
+
+	// gRPC calls are always POST requests
+	req.Method = "POST"
+	req.Input = input
+	return req, nil
+}
+
+func (r *GRPCRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	if err := enforce(r.config, req); err != nil {
+		return ffuf.Response{}, err
+	}
+	return send(r.config, req, r.execute)
+}
+
+// execute sends a request
+func (r *GRPCRunner) execute(req *ffuf.Request) (ffuf.Response, error) {
+	var start time.Time
+	var firstByteTime time.Duration
+	// The HTTP/2 transport calls the trace hooks from its writing and reading goroutines
+	var timing sync.Mutex
+	trace := &httptrace.ClientTrace{
+		WroteRequest: func(wri httptrace.WroteRequestInfo) {
+			timing.Lock()
+			defer timing.Unlock()
+			start = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			timing.Lock()
+			defer timing.Unlock()
+			firstByteTime = time.Since(start)
+		},
+	}
+
+	httpreq, err := r.newRequest(httptrace.WithClientTrace(requestContext(r.config, req), trace), req)
+	if err != nil {
+		return ffuf.Response{}, err
+	}
+	var rawreq []byte
+	if len(r.config.OutputDirectory) > 0 || len(r.config.AuditLog) > 0 {
+		rawreq, _ = httputil.DumpRequestOut(httpreq.Clone(httpreq.Context()), true)
+		req.Raw = string(rawreq)
+	}
+
+	client := r.client
+	if !r.web && httpreq.URL.Scheme == "http" {
+		client = r.h2c
+	}
+	httpresp, err := client.Do(httpreq)
+	if err != nil {
+		return ffuf.Response{}, err
+	}
+	defer httpresp.Body.Close()
+	timing.Lock()
+	sent, duration := start, firstByteTime
+	timing.Unlock()
+	req.Timestamp = sent
+
+	resp := ffuf.NewResponse(httpresp, req)
+	body, err := io.ReadAll(io.LimitReader(httpresp.Body, MAX_DOWNLOAD_SIZE))
+	if err != nil {
+		return ffuf.Response{}, err
+	}
+
+	messages, trailers := parseGRPCFrames(body, r.web)
+	if !r.web {
+		trailers = httpresp.Trailer
+	}
+	// Trailers-only responses send the status in the headers
+	for _, name := range []string{"Grpc-Status", "Grpc-Message"} {
+		if value := trailers.Get(name); value != "" {
+			resp.Headers[name] = []string{value}
+		}
+	}
+
+	if len(r.config.OutputDirectory) > 0 || len(r.config.AuditLog) > 0 {
+		resp.Request.Raw = string(rawreq)
+		resp.Raw = fmt.Sprintf("%s %s\r\n\r\n%s", httpresp.Proto, httpresp.Status, messages)
+	}
+	resp.Data = messages
+	resp.ContentLength = int64(len(messages))
+	resp.ContentWords = int64(len(strings.Split(string(messages), " ")))
+	resp.ContentLines = int64(len(strings.Split(string(messages), "\n")))
+	resp.Duration = duration
+	resp.Timestamp = sent.Add(duration)
+	return resp, nil
+}
+
+func (r *GRPCRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	httpreq, err := r.newRequest(requestContext(r.config, req), req)
+	if err != nil {
+		return []byte{}, err
+	}
+	return httputil.DumpRequestOut(httpreq, true)
+}
+
+// newRequest creates the HTTP request of a gRPC call, framing the request message
+func (r *GRPCRunner) newRequest(ctx context.Context, req *ffuf.Request) (*http.Request, error) {
+	httpreq, err := http.NewRequestWithContext(ctx, "POST", req.Url, bytes.NewReader(frameGRPCMessage(req.Data)))
+	if err != nil {
+		return nil, err
+	}
+
+	// set default User-Agent header if not present
+	if _, ok := req.Headers["User-Agent"]; !ok {
+		req.Headers["User-Agent"] = fmt.Sprintf("%s v%s", "Fuzz Faster U Fool", ffuf.Version())
+	}
+	if _, ok := req.Headers["Host"]; ok {
+		httpreq.Host = req.Headers["Host"]
+	}
+	req.Host = httpreq.Host
+	for k, v := range req.Headers {
+		httpreq.Header.Set(k, v)
+	}
+
+	if r.web {
+		if httpreq.Header.Get("Content-Type") == "" {
+			httpreq.Header.Set("Content-Type", "application/grpc-web+proto")
+		}
+		httpreq.Header.Set("X-Grpc-Web", "1")
+	} else {
+		if httpreq.Header.Get("Content-Type") == "" {
+			httpreq.Header.Set("Content-Type", "application/grpc+proto")
+		}
+		httpreq.Header.Set("Te", "trailers")
+	}
+	return httpreq, nil
+}
+
+// frameGRPCMessage prefixes a message with the uncompressed gRPC message header
+func frameGRPCMessage(message []byte) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// parseGRPCFrames returns the concatenated messages of a gRPC response body, and the
+// trailers of gRPC-web responses, which are sent in a frame with the most significant bit
+// of the flags set. Responses that are not gRPC framed are returned as is.
+func parseGRPCFrames(body []byte, web bool) ([]byte, http.Header) {
+	trailers := http.Header{}
+	var messages []byte
+	for rest := body; len(rest) > 0; {
+		if len(rest) < 5 {
+			return body, trailers
+		}
+		length := binary.BigEndian.Uint32(rest[1:5])
+		if uint64(len(rest)-5) < uint64(length) {
+			return body, trailers
+		}
+		data := rest[5 : 5+length]
+		if web && rest[0]&0x80 != 0 {
+			tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(append(data, '\r', '\n'))))
+			if header, err := tp.ReadMIMEHeader(); err == nil || len(header) > 0 {
+				for name, values := range header {
+					trailers[name] = values
+				}
+			}
+		} else {
+			messages = append(messages, data...)
+		}
+		rest = rest[5+length:]
+	}
+	return messages, trailers
+}
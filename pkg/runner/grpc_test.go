@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// grpcEchoHandler answers gRPC calls with the request message, or with an error status if the message is empty
+func grpcEchoHandler(t *testing.T, web bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if len(body) < 5 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+			t.Errorf("Invalid gRPC request frame: %x", body)
+		}
+		message := body[5:]
+
+		if web {
+			w.Header().Set("Content-Type", "application/grpc-web+proto")
+			if len(message) > 0 {
+				w.Write(body)
+			}
+			trailers := []byte("grpc-status: 0\r\ngrpc-message: OK\r\n")
+			if len(message) == 0 {
+				trailers = []byte("grpc-status: 3\r\ngrpc-message: empty\r\n")
+			}
+			frame := frameGRPCMessage(trailers)
+			frame[0] = 0x80
+			w.Write(frame)
+			return
+		}
+
+		if r.ProtoMajor != 2 || r.Header.Get("Te") != "trailers" {
+			t.Errorf("Expected an HTTP/2 request with trailers, got %s", r.Proto)
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		if len(message) == 0 {
+			w.Header().Set("Grpc-Status", "3")
+			w.Header().Set("Grpc-Message", "empty")
+			return
+		}
+		w.Write(body)
+		w.Header().Set("Grpc-Status", "0")
+	}
+}
+
+func TestGRPCRunner(t *testing.T) {
+	tlsServer := httptest.NewUnstartedServer(grpcEchoHandler(t, false))
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	h2cServer := httptest.NewServer(h2c.NewHandler(grpcEchoHandler(t, false), &http2.Server{}))
+	defer h2cServer.Close()
+
+	webServer := httptest.NewServer(grpcEchoHandler(t, true))
+	defer webServer.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		web  bool
+	}{
+		{name: "gRPC over TLS", url: tlsServer.URL},
+		{name: "gRPC over h2c", url: h2cServer.URL},
+		{name: "gRPC-web", url: webServer.URL, web: true},
+	}
+
+	config := &ffuf.Config{Context: context.Background(), Timeout: 10}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewGRPCRunner(config, tt.web)
+			r.Encode = func(input map[string][]byte, data []byte) ([]byte, error) {
+				return append([]byte{0x0a, byte(len(input["FUZZ"]))}, input["FUZZ"]...), nil
+			}
+			base := &ffuf.Request{Method: "GET", Url: tt.url + "/pkg.Service/FUZZ", Headers: map[string]string{}}
+
+			req, err := r.Prepare(map[string][]byte{"FUZZ": []byte("abc")}, base)
+			if err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			if req.Method != "POST" || req.Url != tt.url+"/pkg.Service/abc" {
+				t.Errorf("Unexpected request %s %s", req.Method, req.Url)
+			}
+			resp, err := r.Execute(&req)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if !bytes.Equal(resp.Data, []byte{0x0a, 0x03, 'a', 'b', 'c'}) {
+				t.Errorf("Expected the echoed message, got %x", resp.Data)
+			}
+			if resp.ContentLength != 5 {
+				t.Errorf("Expected content length 5, got %d", resp.ContentLength)
+			}
+
+			r.Encode = nil
+			req, _ = r.Prepare(map[string][]byte{}, base)
+			resp, err = r.Execute(&req)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if status := resp.Headers["Grpc-Status"]; len(status) != 1 || status[0] != "3" {
+				t.Errorf("Expected gRPC status 3, got %v", resp.Headers["Grpc-Status"])
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// ProxyFunc returns the proxy of the requests of a config: the replay proxy if replay is set,
+// each proxy of ProxyList in turn, one per request, or the proxy of ProxyURL. The proxy of the
+// environment is used otherwise.
+func ProxyFunc(conf *ffuf.Config, replay bool) func(*http.Request) (*url.URL, error) {
+	customProxy := conf.ProxyURL
+	if replay {
+		customProxy = conf.ReplayProxyURL
+	} else if len(conf.ProxyList) > 0 {
+		proxies := make([]*url.URL, 0, len(conf.ProxyList))
+		for _, proxy := range conf.ProxyList {
+			if pu, err := url.Parse(proxy); err == nil {
+				proxies = append(proxies, pu)
+			}
+		}
+		if len(proxies) > 0 {
+			var next uint64
+			return func(*http.Request) (*url.URL, error) {
+				return proxies[(atomic.AddUint64(&next, 1)-1)%uint64(len(proxies))], nil
+			}
+		}
+	}
+	if len(customProxy) > 0 {
+		if pu, err := url.Parse(customProxy); err == nil {
+			return http.ProxyURL(pu)
+		}
+	}
+	return http.ProxyFromEnvironment
+}
+
+// dialHost opens a connection to a host through a proxy, with a SOCKS5 connection or a CONNECT
+// request to an HTTP proxy, or to the pinned address of the host of the config without a proxy
+func dialHost(ctx context.Context, conf *ffuf.Config, dialer *net.Dialer, proxy *url.URL, host string) (net.Conn, error) {
+	switch {
+	case proxy == nil:
+		return PinnedDial(conf, dialer.DialContext)(ctx, "tcp", host)
+	case proxy.Scheme == "socks5" || proxy.Scheme == "socks5h":
+		return dialSOCKS5(ctx, dialer, proxy, host)
+	default:
+		return dialProxy(ctx, dialer, proxy, host)
+	}
+}
+
+// dialSOCKS5 opens a connection to a host through a SOCKS5 proxy
+func dialSOCKS5(ctx context.Context, dialer *net.Dialer, proxy *url.URL, host string) (net.Conn, error) {
+	socks, err := xproxy.FromURL(proxy, dialer)
+	if err != nil {
+		return nil, err
+	}
+	return socks.(xproxy.ContextDialer).DialContext(ctx, "tcp", host)
+}
+
+// dialProxy opens a tunnel to a host with a CONNECT request to an HTTP proxy
+func dialProxy(ctx context.Context, dialer *net.Dialer, proxy *url.URL, host string) (net.Conn, error) {
+	if proxy.Scheme != "http" {
+		return nil, fmt.Errorf("unsupported proxy scheme: %s, expected http, socks5 or socks5h", proxy.Scheme)
+	}
+	proxyHost := proxy.Host
+	if proxy.Port() == "" {
+		proxyHost = net.JoinHostPort(proxy.Hostname(), "80")
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyHost)
+	if err != nil {
+		return nil, err
+	}
+
+	connect := &http.Request{Method: "CONNECT", URL: &url.URL{Opaque: host}, Host: host, Header: make(http.Header)}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		connect.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(proxy.User.Username()+":"+password)))
+	}
+	if dialer.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(dialer.Timeout))
+	}
+	if err := connect.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused the tunnel to %s: %s", host, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// NewHTTPClient creates a client following redirects through the proxies and to the pinned
+// hosts of a config, for the requests of the API modules not sent by a runner, such as the
+// fetching of specifications
+func NewHTTPClient(conf *ffuf.Config, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = ProxyFunc(conf, false)
+	transport.DialContext = PinnedDial(conf, transport.DialContext)
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
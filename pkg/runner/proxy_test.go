@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestProxyRotation(t *testing.T) {
+	var first, second int32
+	proxy := func(count *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Host != "api.example.com" {
+				t.Errorf("Expected a proxied request to api.example.com, got %s", r.URL)
+			}
+			atomic.AddInt32(count, 1)
+			w.Write([]byte("ok"))
+		}))
+	}
+	firstProxy, secondProxy := proxy(&first), proxy(&second)
+	defer firstProxy.Close()
+	defer secondProxy.Close()
+
+	config := &ffuf.Config{Context: context.Background(), Timeout: 5, ProxyList: []string{firstProxy.URL, secondProxy.URL}}
+	r := NewSimpleRunner(config, false)
+	for i := 0; i < 4; i++ {
+		req := &ffuf.Request{Method: "GET", Url: "http://api.example.com/users", Headers: map[string]string{}}
+		if resp, err := r.Execute(req); err != nil || resp.StatusCode != 200 {
+			t.Fatalf("Execute returned %d: %v", resp.StatusCode, err)
+		}
+	}
+	if first != 2 || second != 2 {
+		t.Errorf("Expected the requests to alternate between the proxies, got %d and %d", first, second)
+	}
+
+	// The clients of the API modules go through the same proxies
+	resp, err := NewHTTPClient(config, 5*time.Second).Get("http://api.example.com/openapi.json")
+	if err != nil {
+		t.Fatalf("Get returned an error: %s", err)
+	}
+	resp.Body.Close()
+	if first+second != 5 {
+		t.Errorf("Expected the client to use the proxies, got %d requests", first+second)
+	}
+
+	// -x is used without a proxy list, and the replay proxy for replayed requests
+	config = &ffuf.Config{ProxyURL: firstProxy.URL, ReplayProxyURL: secondProxy.URL, ProxyList: []string{}}
+	req, _ := http.NewRequest("GET", "http://api.example.com/", nil)
+	if u, _ := ProxyFunc(config, false)(req); u == nil || u.String() != firstProxy.URL {
+		t.Errorf("Expected the -x proxy, got %v", u)
+	}
+	if u, _ := ProxyFunc(config, true)(req); u == nil || u.String() != secondProxy.URL {
+		t.Errorf("Expected the replay proxy, got %v", u)
+	}
+}
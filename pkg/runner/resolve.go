@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// dialFunc opens a connection to an address
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// PinnedDial returns a dial function connecting to the addresses pinned by the -resolve entries
+// of a config in place of the addresses of the requests. The entries are validated with the
+// config, and invalid entries fail the connections rather than being ignored.
+func PinnedDial(conf *ffuf.Config, dial dialFunc) dialFunc {
+	pins, err := ffuf.ParseResolve(conf.Resolve)
+	if err != nil {
+		return func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, err
+		}
+	}
+	if len(pins) == 0 {
+		return dial
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dial(ctx, network, ffuf.ResolveAddress(pins, addr))
+	}
+}
+
+// pinnedDialTLS returns the DialTLS function of an HTTP/2 transport connecting to the addresses
+// pinned by the -resolve entries of a config, or nil for the default function if none is pinned
+func pinnedDialTLS(conf *ffuf.Config, dialer *net.Dialer) func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+	if len(conf.Resolve) == 0 {
+		return nil
+	}
+	dial := PinnedDial(conf, dialer.DialContext)
+	return func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+		conn, err := dial(context.Background(), network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+func TestResolve(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + " " + r.Proto))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	serverAddr, _ := url.Parse(server.URL)
+	tlsAddr, _ := url.Parse(tlsServer.URL)
+
+	// The hosts of the URLs do not resolve, and are pinned to the test servers
+	resolve := []string{"staging.example.com:80:" + serverAddr.Host, "api.example.com:*:" + tlsAddr.Host}
+	for _, tc := range []struct {
+		url, version, expected string
+	}{
+		{"http://staging.example.com/users", "", "staging.example.com HTTP/1.1"},
+		{"https://api.example.com/users", "1.1", "api.example.com HTTP/1.1"},
+		{"https://api.example.com:8443/users", "2", "api.example.com:8443 HTTP/2.0"},
+	} {
+		config := &ffuf.Config{Context: context.Background(), Timeout: 5, HTTPVersion: tc.version, Resolve: resolve}
+		resp, err := NewSimpleRunner(config, false).Execute(&ffuf.Request{Method: "GET", Url: tc.url, Headers: map[string]string{}})
+		if err != nil {
+			t.Fatalf("Execute %s returned an error: %s", tc.url, err)
+		}
+		if string(resp.Data) != tc.expected {
+			t.Errorf("Expected %q from %s, got %q", tc.expected, tc.url, resp.Data)
+		}
+	}
+
+	// The clients of the API modules connect to the pinned hosts
+	resp, err := NewHTTPClient(&ffuf.Config{Resolve: resolve}, 5*time.Second).Get("http://staging.example.com/openapi.json")
+	if err != nil {
+		t.Fatalf("Get returned an error: %s", err)
+	}
+	resp.Body.Close()
+
+	// WebSocket handshakes connect to the pinned hosts
+	ws := httptest.NewServer(websocketEchoHandler(t, "https://app.example"))
+	defer ws.Close()
+	wsAddr, _ := url.Parse(ws.URL)
+	r := NewWebSocketRunner(&ffuf.Config{Context: context.Background(), Timeout: 5, Resolve: []string{"events.example.com:80:" + wsAddr.Host}})
+	req, _ := r.Prepare(map[string][]byte{}, &ffuf.Request{Url: "ws://events.example.com/socket", Headers: map[string]string{"Origin": "https://app.example"}, Data: []byte("hello")})
+	wsResp, err := r.Execute(&req)
+	if err != nil || wsResp.StatusCode != 101 || !strings.Contains(string(wsResp.Data), "hello") {
+		t.Errorf("Expected the pinned WebSocket handshake to succeed, got %d %q: %v", wsResp.StatusCode, wsResp.Data, err)
+	}
+
+	// Invalid entries fail the connections instead of being ignored
+	config := &ffuf.Config{Context: context.Background(), Timeout: 5, Resolve: []string{"staging.example.com:" + serverAddr.Host}}
+	_, err = NewSimpleRunner(config, false).Execute(&ffuf.Request{Method: "GET", Url: server.URL, Headers: map[string]string{}})
+	if err == nil || !strings.Contains(err.Error(), "in the resolve entry staging.example.com") {
+		t.Errorf("Expected an invalid resolve entry to fail the request, got %v", err)
+	}
+}
@@ -1,10 +1,99 @@
 package runner
 
 import (
+	"context"
+	"time"
+
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
 )
 
 func NewRunnerByName(name string, conf *ffuf.Config, replay bool) ffuf.RunnerProvider {
-	// We have only one Runner at the moment
+	switch name {
+	case "grpc":
+		return NewGRPCRunner(conf, false)
+	case "grpc-web":
+		return NewGRPCRunner(conf, true)
+	case "websocket":
+		return NewWebSocketRunner(conf)
+	}
 	return NewSimpleRunner(conf, replay)
 }
+
+// enforce applies the safe mode of the config, if any, to the request, and returns an error
+// if the request is blocked by the safe mode or denied by the scope policy
+func enforce(conf *ffuf.Config, req *ffuf.Request) error {
+	if conf.SafeMode != nil {
+		if err := conf.SafeMode.Apply(req); err != nil {
+			return err
+		}
+	}
+	if conf.Policy == nil {
+		return nil
+	}
+	return conf.Policy.Check(req)
+}
+
+// requestContext returns the context cancelling a request: its own context if it is set, or
+// the context of the config
+func requestContext(conf *ffuf.Config, req *ffuf.Request) context.Context {
+	if ctx := req.Context(); ctx != nil {
+		return ctx
+	}
+	if conf.Context != nil {
+		return conf.Context
+	}
+	return context.Background()
+}
+
+// pace waits for the pacer of the config, if any, to allow a request
+func pace(conf *ffuf.Config, req *ffuf.Request) error {
+	if conf.Pacer == nil {
+		return nil
+	}
+	return conf.Pacer.Wait(requestContext(conf, req), req.Url)
+}
+
+// send paces and executes a request, retrying it with an exponential backoff and jitter while
+// it fails with a transient error, up to the retries of the config. The pacer of the config
+// observes every attempt. A request still failing once the retries are spent returns a
+// ffuf.RetryError.
+func send(conf *ffuf.Config, req *ffuf.Request, execute func(*ffuf.Request) (ffuf.Response, error)) (ffuf.Response, error) {
+	ctx := requestContext(conf, req)
+	base := time.Duration(conf.APIRetryDelay) * time.Millisecond
+	max := time.Duration(conf.APIBackoffMax) * time.Second
+	for attempt := 0; ; attempt++ {
+		if err := pace(conf, req); err != nil {
+			return ffuf.Response{}, err
+		}
+		resp, err := execute(req)
+		observe(conf, req.Url, resp, err)
+		if err == nil || !ffuf.IsTransientError(err) {
+			return resp, err
+		}
+		if attempt >= conf.APIRetries {
+			if attempt > 0 {
+				err = &ffuf.RetryError{Attempts: attempt + 1, Err: err}
+			}
+			return resp, err
+		}
+		timer := time.NewTimer(ffuf.RetryDelay(attempt, base, max))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		}
+	}
+}
+
+// observe adapts the pacer of the config, if any, to the outcome of a request to a URL
+func observe(conf *ffuf.Config, rawURL string, resp ffuf.Response, err error) {
+	if conf.Pacer == nil {
+		return
+	}
+	if err != nil {
+		conf.Pacer.ObserveError(rawURL)
+		return
+	}
+	conf.Pacer.Observe(rawURL, resp)
+}
@@ -2,8 +2,7 @@ package runner
 
 import (
 	"bytes"
-	"compress/flate"
-	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -15,11 +14,15 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
 
-	"github.com/andybalholm/brotli"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
 )
 
 // Download results < 5MB
@@ -32,20 +35,7 @@ type SimpleRunner struct {
 
 func NewSimpleRunner(conf *ffuf.Config, replay bool) ffuf.RunnerProvider {
 	var simplerunner SimpleRunner
-	proxyURL := http.ProxyFromEnvironment
-	customProxy := ""
-
-	if replay {
-		customProxy = conf.ReplayProxyURL
-	} else {
-		customProxy = conf.ProxyURL
-	}
-	if len(customProxy) > 0 {
-		pu, err := url.Parse(customProxy)
-		if err == nil {
-			proxyURL = http.ProxyURL(pu)
-		}
-	}
+	proxyURL := ProxyFunc(conf, replay)
 	cert := []tls.Certificate{}
 
 	if conf.ClientCert != "" && conf.ClientKey != "" {
@@ -53,28 +43,42 @@ func NewSimpleRunner(conf *ffuf.Config, replay bool) ffuf.RunnerProvider {
 		cert = []tls.Certificate{tmp}
 	}
 
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		Renegotiation:      tls.RenegotiateOnceAsClient,
+		ServerName:         conf.SNI,
+		Certificates:       cert,
+	}
+	transport := &http.Transport{
+		ForceAttemptHTTP2:   conf.Http2,
+		Proxy:               proxyURL,
+		MaxIdleConns:        1000,
+		MaxIdleConnsPerHost: 500,
+		MaxConnsPerHost:     500,
+		DialContext: PinnedDial(conf, (&net.Dialer{
+			Timeout: time.Duration(time.Duration(conf.Timeout) * time.Second),
+		}).DialContext),
+		TLSHandshakeTimeout: time.Duration(time.Duration(conf.Timeout) * time.Second),
+		TLSClientConfig:     tlsConfig,
+	}
+
 	simplerunner.config = conf
 	simplerunner.client = &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
 		Timeout:       time.Duration(time.Duration(conf.Timeout) * time.Second),
-		Transport: &http.Transport{
-			ForceAttemptHTTP2:   conf.Http2,
-			Proxy:               proxyURL,
-			MaxIdleConns:        1000,
-			MaxIdleConnsPerHost: 500,
-			MaxConnsPerHost:     500,
-			DialContext: (&net.Dialer{
-				Timeout: time.Duration(time.Duration(conf.Timeout) * time.Second),
-			}).DialContext,
-			TLSHandshakeTimeout: time.Duration(time.Duration(conf.Timeout) * time.Second),
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-				MinVersion:         tls.VersionTLS10,
-				Renegotiation:      tls.RenegotiateOnceAsClient,
-				ServerName:         conf.SNI,
-				Certificates:       cert,
-			},
-		}}
+		Transport:     transport,
+	}
+	switch conf.HTTPVersion {
+	case "1.1":
+		// A non-nil empty map disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case "2":
+		simplerunner.client.Transport = newHTTP2Transport(conf, proxyURL, tlsConfig)
+	case "3":
+		simplerunner.client.Transport = newHTTP3Transport(conf, tlsConfig)
+	}
 
 	if conf.FollowRedirects {
 		simplerunner.client.CheckRedirect = nil
@@ -82,6 +86,77 @@ func NewSimpleRunner(conf *ffuf.Config, replay bool) ffuf.RunnerProvider {
 	return &simplerunner
 }
 
+// http2Transport sends all requests over HTTP/2, multiplexing the requests to a host over a
+// single connection. Cleartext http:// URLs use h2c with prior knowledge.
+type http2Transport struct {
+	tls *http2.Transport
+	h2c *http2.Transport
+}
+
+// newHTTP2Transport creates the transport of -http-version 2. The connections go through a
+// CONNECT tunnel or a SOCKS5 connection of the proxy of the connection, if any.
+func newHTTP2Transport(conf *ffuf.Config, proxy func(*http.Request) (*url.URL, error), tlsConfig *tls.Config) *http2Transport {
+	dialer := &net.Dialer{Timeout: time.Duration(conf.Timeout) * time.Second}
+	dial := func(ctx context.Context, scheme, addr string) (net.Conn, error) {
+		proxyURL, err := proxy(&http.Request{URL: &url.URL{Scheme: scheme, Host: addr}})
+		if err != nil {
+			return nil, err
+		}
+		return dialHost(ctx, conf, dialer, proxyURL, addr)
+	}
+	return &http2Transport{
+		tls: &http2.Transport{
+			TLSClientConfig: tlsConfig,
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				conn, err := dial(ctx, "https", addr)
+				if err != nil {
+					return nil, err
+				}
+				tlsConn := tls.Client(conn, cfg)
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					conn.Close()
+					return nil, err
+				}
+				if tlsConn.ConnectionState().NegotiatedProtocol != http2.NextProtoTLS {
+					tlsConn.Close()
+					return nil, fmt.Errorf("%s does not support HTTP/2", addr)
+				}
+				return tlsConn, nil
+			},
+		},
+		h2c: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return dial(ctx, "http", addr)
+			},
+		},
+	}
+}
+
+func (t *http2Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(req)
+	}
+	return t.tls.RoundTrip(req)
+}
+
+// newHTTP3Transport creates the transport of -http-version 3, sending the requests over QUIC
+// to the pinned addresses of the hosts. Proxies are not supported, as they do not relay QUIC,
+// and http:// URLs fail, as HTTP/3 has no cleartext form.
+func newHTTP3Transport(conf *ffuf.Config, tlsConfig *tls.Config) *http3.Transport {
+	pins, pinErr := ffuf.ParseResolve(conf.Resolve)
+	return &http3.Transport{
+		TLSClientConfig: tlsConfig,
+		QUICConfig:      &quic.Config{HandshakeIdleTimeout: time.Duration(conf.Timeout) * time.Second},
+		Dial: func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlyConnection, error) {
+			if pinErr != nil {
+				return nil, pinErr
+			}
+			return quic.DialAddrEarly(ctx, ffuf.ResolveAddress(pins, addr), tlsCfg, cfg)
+		},
+	}
+}
+
 func (r *SimpleRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
 	req := ffuf.CopyRequest(basereq)
 
@@ -102,6 +177,14 @@ func (r *SimpleRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (
 }
 
 func (r *SimpleRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	if err := enforce(r.config, req); err != nil {
+		return ffuf.Response{}, err
+	}
+	return send(r.config, req, r.execute)
+}
+
+// execute sends a request
+func (r *SimpleRunner) execute(req *ffuf.Request) (ffuf.Response, error) {
 	var httpreq *http.Request
 	var err error
 	var rawreq []byte
@@ -109,17 +192,26 @@ func (r *SimpleRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
 
 	var start time.Time
 	var firstByteTime time.Duration
+	// The HTTP/2 transport calls the trace hooks from its writing and reading goroutines
+	var timing sync.Mutex
 
 	trace := &httptrace.ClientTrace{
 		WroteRequest: func(wri httptrace.WroteRequestInfo) {
+			timing.Lock()
+			defer timing.Unlock()
 			start = time.Now() // begin the timer after the request is fully written
 		},
 		GotFirstResponseByte: func() {
+			timing.Lock()
+			defer timing.Unlock()
 			firstByteTime = time.Since(start) // record when the first byte of the response was received
 		},
 	}
 
-	httpreq, err = http.NewRequestWithContext(r.config.Context, req.Method, req.Url, data)
+	// Streams are read until the stream window ends, then the request is cancelled
+	ctx, cancel := context.WithCancel(requestContext(r.config, req))
+	defer cancel()
+	httpreq, err = http.NewRequestWithContext(ctx, req.Method, req.Url, data)
 
 	if err != nil {
 		return ffuf.Response{}, err
@@ -136,7 +228,7 @@ func (r *SimpleRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
 	}
 
 	req.Host = httpreq.Host
-	httpreq = httpreq.WithContext(httptrace.WithClientTrace(r.config.Context, trace))
+	httpreq = httpreq.WithContext(httptrace.WithClientTrace(ctx, trace))
 
 	if r.config.Raw {
 		httpreq.URL.Opaque = req.Url
@@ -151,10 +243,19 @@ func (r *SimpleRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
 		req.Raw = string(rawreq)
 	}
 
+	sent := time.Now()
 	httpresp, err := r.client.Do(httpreq)
 	if err != nil {
 		return ffuf.Response{}, err
 	}
+	timing.Lock()
+	if start.IsZero() {
+		// The HTTP/3 transport has no trace hooks, its requests are timed from their sending
+		// to their response headers
+		start = sent
+		firstByteTime = time.Since(sent)
+	}
+	timing.Unlock()
 
 	req.Timestamp = start
 
@@ -165,60 +266,105 @@ func (r *SimpleRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
 	size, err := strconv.Atoi(httpresp.Header.Get("Content-Length"))
 	if err == nil {
 		resp.ContentLength = int64(size)
-		if (r.config.IgnoreBody) || (size > MAX_DOWNLOAD_SIZE) {
+		if r.config.IgnoreBody {
 			resp.Cancelled = true
 			return resp, nil
 		}
 	}
 
+	// Event streams are read as streams. Bodies of unknown length, such as long polls, are
+	// only read as streams with a stream window, as chunked and compressed bodies have no
+	// length either. The window cancels the request so that streams do not hold the worker.
+	eventStream := isEventStream(httpresp.Header.Get("Content-Type"))
+	streaming := eventStream || (r.config.APIStreamTime > 0 && httpresp.ContentLength < 0)
+	var windowEnded atomic.Bool
+	if streaming && r.config.APIStreamTime > 0 {
+		window := time.AfterFunc(time.Duration(r.config.APIStreamTime)*time.Second, func() {
+			windowEnded.Store(true)
+			cancel()
+		})
+		defer window.Stop()
+	}
+
+	// The raw body is recorded as it is read, up to the maximum body size
+	var rawBody bytes.Buffer
 	if len(r.config.OutputDirectory) > 0 || len(r.config.AuditLog) > 0 {
-		rawresp, _ := httputil.DumpResponse(httpresp, true)
+		rawresp, _ := httputil.DumpResponse(httpresp, false)
 		resp.Request.Raw = string(rawreq)
 		resp.Raw = string(rawresp)
-	}
-	var bodyReader io.ReadCloser
-	if httpresp.Header.Get("Content-Encoding") == "gzip" {
-		bodyReader, err = gzip.NewReader(httpresp.Body)
-		if err != nil {
-			// fallback to raw data
-			bodyReader = httpresp.Body
-		}
-	} else if httpresp.Header.Get("Content-Encoding") == "br" {
-		bodyReader = io.NopCloser(brotli.NewReader(httpresp.Body))
-		if err != nil {
-			// fallback to raw data
-			bodyReader = httpresp.Body
-		}
-	} else if httpresp.Header.Get("Content-Encoding") == "deflate" {
-		bodyReader = flate.NewReader(httpresp.Body)
-		if err != nil {
-			// fallback to raw data
-			bodyReader = httpresp.Body
+		if !streaming {
+			httpresp.Body = teeBody(httpresp.Body, NewLimitWriter(&rawBody, r.config.APIMaxBody))
 		}
-	} else {
-		bodyReader = httpresp.Body
 	}
+	resp.ContentEncoding = ContentEncoding(httpresp)
+	bodyReader := DecodeBody(httpresp.Body, httpresp.Header.Get("Content-Encoding"))
 
-	if respbody, err := io.ReadAll(bodyReader); err == nil {
-		resp.ContentLength = int64(len(string(respbody)))
-		resp.Data = respbody
+	if streaming {
+		// Server-Sent Events are UTF-8, other streams are converted like any body
+		if !eventStream {
+			bodyReader, resp.Charset = DecodeCharset(bodyReader, httpresp.Header.Get("Content-Type"))
+		}
+		// The data read until the stream window ended is kept
+		var err error
+		resp.Data, err = r.readStream(eventStream, bodyReader, &resp)
+		resp.Truncated = err != nil && windowEnded.Load()
+		CountBody(&resp)
+	} else {
+		// The length, words, lines and hash are those of the body as received rather than
+		// converted from its charset, for the filters and the calibration to compare
+		wire := newBodyCounter()
+		bodyReader, resp.Charset = DecodeCharset(io.TeeReader(bodyReader, wire), httpresp.Header.Get("Content-Type"))
+		if err := ReadBody(bodyReader, r.config.APIMaxBody, &resp); err == nil {
+			wire.apply(&resp)
+			if len(resp.Raw) > 0 {
+				resp.Raw += rawBody.String()
+			}
+		}
 	}
-
-	wordsSize := len(strings.Split(string(resp.Data), " "))
-	linesSize := len(strings.Split(string(resp.Data), "\n"))
-	resp.ContentWords = int64(wordsSize)
-	resp.ContentLines = int64(linesSize)
 	resp.Duration = firstByteTime
 	resp.Timestamp = start.Add(firstByteTime)
 
 	return resp, nil
 }
 
+// readStream reads a streamed body until it ends, the stream window ends or the maximum body
+// size, or MAX_DOWNLOAD_SIZE without one, is read. The events of Server-Sent Events streams,
+// up to -api-stream-events, are returned as NDJSON records. The error is that of the read
+// interrupted by the end of the stream window, if any.
+func (r *SimpleRunner) readStream(eventStream bool, body io.Reader, resp *ffuf.Response) ([]byte, error) {
+	limit := r.config.APIMaxBody
+	if limit <= 0 {
+		limit = MAX_DOWNLOAD_SIZE
+	}
+	body = io.LimitReader(body, int64(limit))
+	var data, raw []byte
+	var err error
+	if eventStream {
+		data, raw, err = readEventStream(body, r.config.APIStreamEvents)
+		resp.ContentType = "application/x-ndjson"
+	} else {
+		data, err = io.ReadAll(body)
+		raw = data
+	}
+	if len(resp.Raw) > 0 {
+		resp.Raw += string(raw)
+	}
+	return data, err
+}
+
+// teeBody returns a body writing what is read from it to w
+func teeBody(body io.ReadCloser, w io.Writer) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(body, w), body}
+}
+
 func (r *SimpleRunner) Dump(req *ffuf.Request) ([]byte, error) {
 	var httpreq *http.Request
 	var err error
 	data := bytes.NewReader(req.Data)
-	httpreq, err = http.NewRequestWithContext(r.config.Context, req.Method, req.Url, data)
+	httpreq, err = http.NewRequestWithContext(requestContext(r.config, req), req.Method, req.Url, data)
 	if err != nil {
 		return []byte{}, err
 	}
@@ -1,12 +1,29 @@
 package runner
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func TestSimpleRunnerExecute(t *testing.T) {
@@ -54,4 +71,456 @@ func TestSimpleRunnerExecute(t *testing.T) {
 	if string(resp.Data) != `{"status":"ok"}` {
 		t.Errorf("Expected response body {\"status\":\"ok\"}, got %s", string(resp.Data))
 	}
-}
\ No newline at end of file
+}
+func TestSimpleRunnerStreams(t *testing.T) {
+	// Streams never end, and are only flushed
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for i := 1; ; i++ {
+				if _, err := fmt.Fprintf(w, ": keepalive\nid: %d\nevent: update\ndata: {\"n\":%d}\n\n", i, i); err != nil {
+					return
+				}
+				w.(http.Flusher).Flush()
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		w.Write([]byte("waiting"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	config := &ffuf.Config{
+		Context:         context.Background(),
+		Timeout:         10,
+		APIStreamTime:   1,
+		APIStreamEvents: 2,
+	}
+	runner := NewSimpleRunner(config, false)
+
+	start := time.Now()
+	resp, err := runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + "/events", Headers: make(map[string]string)})
+	if err != nil {
+		t.Fatalf("Error executing request: %v", err)
+	}
+	expected := `{"event":"update","id":"1","data":{"n":1}}` + "\n" + `{"event":"update","id":"2","data":{"n":2}}`
+	if string(resp.Data) != expected || resp.ContentLines != 2 || resp.ContentType != "application/x-ndjson" {
+		t.Errorf("Expected 2 events as NDJSON, got %s %q", resp.ContentType, resp.Data)
+	}
+
+	resp, err = runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + "/poll", Headers: make(map[string]string)})
+	if err != nil {
+		t.Fatalf("Error executing request: %v", err)
+	}
+	if string(resp.Data) != "waiting" || !resp.Truncated {
+		t.Errorf("Expected the data read in the stream window, got %q", resp.Data)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the streams to be read for the stream window, took %s", elapsed)
+	}
+}
+
+func TestSimpleRunnerHTTPVersion(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	h2cServer := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer h2cServer.Close()
+
+	tests := []struct {
+		version  string
+		url      string
+		expected string
+	}{
+		{version: "", url: tlsServer.URL, expected: "HTTP/1.1"},
+		{version: "1.1", url: tlsServer.URL, expected: "HTTP/1.1"},
+		{version: "2", url: tlsServer.URL, expected: "HTTP/2.0"},
+		{version: "2", url: h2cServer.URL, expected: "HTTP/2.0"},
+	}
+	for _, tt := range tests {
+		config := &ffuf.Config{Context: context.Background(), Timeout: 10, HTTPVersion: tt.version}
+		resp, err := NewSimpleRunner(config, false).Execute(&ffuf.Request{Method: "GET", Url: tt.url, Headers: make(map[string]string)})
+		if err != nil {
+			t.Errorf("Version %q of %s: error executing request: %v", tt.version, tt.url, err)
+			continue
+		}
+		if string(resp.Data) != tt.expected || resp.Proto != tt.expected {
+			t.Errorf("Version %q of %s: expected %s, got %s sent as %s", tt.version, tt.url, tt.expected, resp.Proto, resp.Data)
+		}
+	}
+}
+
+func TestSimpleRunnerHTTP3(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket returned an error: %s", err)
+	}
+	server := &http3.Server{
+		TLSConfig: http3.ConfigureTLSConfig(tlsServer.TLS.Clone()),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Host + " " + r.Proto))
+		}),
+	}
+	go server.Serve(conn)
+	defer server.Close()
+
+	// The host of the URL does not resolve, and is pinned to the QUIC listener
+	config := &ffuf.Config{Context: context.Background(), Timeout: 10, HTTPVersion: "3", Resolve: []string{"h3.example.com:*:" + conn.LocalAddr().String()}}
+	r := NewSimpleRunner(config, false)
+	for _, target := range []string{"https://" + conn.LocalAddr().String(), "https://h3.example.com/users"} {
+		resp, err := r.Execute(&ffuf.Request{Method: "GET", Url: target, Headers: make(map[string]string)})
+		if err != nil {
+			t.Fatalf("Execute %s returned an error: %s", target, err)
+		}
+		if resp.Proto != "HTTP/3.0" || !strings.HasSuffix(string(resp.Data), " HTTP/3.0") {
+			t.Errorf("Expected %s over HTTP/3, got %s sent as %s", target, resp.Proto, resp.Data)
+		}
+		if resp.Duration <= 0 {
+			t.Errorf("Expected the response of %s to be timed", target)
+		}
+	}
+
+	// HTTP/3 has no cleartext form
+	if _, err := r.Execute(&ffuf.Request{Method: "GET", Url: "http://h3.example.com/users", Headers: make(map[string]string)}); err == nil {
+		t.Errorf("Expected an http:// URL to fail over HTTP/3")
+	}
+}
+
+func TestSimpleRunnerHTTP2Proxy(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	h2cServer := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer h2cServer.Close()
+	h1Server := httptest.NewTLSServer(handler)
+	defer h1Server.Close()
+
+	var tunnels int32
+	proxy := connectProxy(&tunnels)
+	defer proxy.Close()
+	socks := socks5Server(t, &tunnels)
+	defer socks.Close()
+
+	for _, proxyURL := range []string{proxy.URL, "socks5://" + socks.Addr().String()} {
+		atomic.StoreInt32(&tunnels, 0)
+		config := &ffuf.Config{Context: context.Background(), Timeout: 10, HTTPVersion: "2", ProxyURL: proxyURL}
+		r := NewSimpleRunner(config, false)
+		for _, target := range []string{tlsServer.URL, h2cServer.URL} {
+			resp, err := r.Execute(&ffuf.Request{Method: "GET", Url: target, Headers: make(map[string]string)})
+			if err != nil {
+				t.Fatalf("Execute %s through %s returned an error: %s", target, proxyURL, err)
+			}
+			if resp.Proto != "HTTP/2.0" || string(resp.Data) != "HTTP/2.0" {
+				t.Errorf("Expected %s over HTTP/2 through %s, got %s sent as %s", target, proxyURL, resp.Proto, resp.Data)
+			}
+		}
+		if atomic.LoadInt32(&tunnels) != 2 {
+			t.Errorf("Expected the connections to go through %s, got %d tunnels", proxyURL, tunnels)
+		}
+
+		// Servers without HTTP/2 support fail instead of falling back to HTTP/1.1
+		_, err := r.Execute(&ffuf.Request{Method: "GET", Url: h1Server.URL, Headers: make(map[string]string)})
+		if err == nil {
+			t.Errorf("Expected a server without HTTP/2 to fail through %s, got %v", proxyURL, err)
+		}
+	}
+}
+
+func TestSimpleRunnerSafeMode(t *testing.T) {
+	methods := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+	}))
+	defer ts.Close()
+
+	safeMode, _ := ffuf.NewSafeMode(ffuf.SafeModeNonDestructive)
+	config := &ffuf.Config{Context: context.Background(), Timeout: 10, SafeMode: safeMode}
+	runner := NewSimpleRunner(config, false)
+
+	if _, err := runner.Execute(&ffuf.Request{Method: "DELETE", Url: ts.URL + "/users/1", Headers: map[string]string{}}); err != nil {
+		t.Fatalf("Error executing request: %v", err)
+	}
+	_, err := runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + "/users?id=1;DROP%20TABLE%20users", Headers: map[string]string{}})
+	if !ffuf.IsSafeModeViolation(err) {
+		t.Errorf("Expected the destructive payload to be blocked, got %v", err)
+	}
+	if len(methods) != 1 || methods[0] != "GET" {
+		t.Errorf("Expected a single GET request to be sent, got %v", methods)
+	}
+}
+
+func TestSimpleRunnerRetry(t *testing.T) {
+	var requests int32
+	drop := int32(2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first requests are dropped before they are answered
+		if atomic.AddInt32(&requests, 1) <= atomic.LoadInt32(&drop) {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	config := &ffuf.Config{Context: context.Background(), Timeout: 10, APIRetries: 2, APIRetryDelay: 1}
+	runner := NewSimpleRunner(config, false)
+	resp, err := runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL, Headers: map[string]string{}})
+	if err != nil || string(resp.Data) != "ok" {
+		t.Fatalf("Expected the request to succeed once retried, got %q: %v", resp.Data, err)
+	}
+	if atomic.LoadInt32(&requests) != 3 {
+		t.Errorf("Expected the request to be sent 3 times, got %d", requests)
+	}
+
+	// Requests still failing once the retries are spent return a RetryError
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&drop, 10)
+	_, err = runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL, Headers: map[string]string{}})
+	var retryErr *ffuf.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 {
+		t.Errorf("Expected the request to give up after 3 attempts, got %v", err)
+	}
+
+	// Permanent errors are not retried
+	config.APIRetryDelay = 60000
+	start := time.Now()
+	_, err = runner.Execute(&ffuf.Request{Method: "GET", Url: "ftp://api.example.com", Headers: map[string]string{}})
+	if err == nil || errors.As(err, &retryErr) || time.Since(start) > 10*time.Second {
+		t.Errorf("Expected the unsupported scheme to fail at once, got %v", err)
+	}
+}
+
+func TestSimpleRunnerRequestContext(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	// The context of a request aborts it in flight, even if the config is not cancelled
+	config := &ffuf.Config{Context: context.Background(), Timeout: 30, APIRetries: 2, APIRetryDelay: 1}
+	runner := NewSimpleRunner(config, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	req := &ffuf.Request{Method: "GET", Url: ts.URL, Headers: map[string]string{}}
+	req.SetContext(ctx)
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := runner.Execute(req)
+	if !errors.Is(err, context.Canceled) || time.Since(start) > 5*time.Second {
+		t.Errorf("Expected the request to be cancelled at once, got %v after %s", err, time.Since(start))
+	}
+	if copied := ffuf.CopyRequest(req); copied.Context() != ctx {
+		t.Errorf("Expected the copy of the request to keep its context")
+	}
+}
+
+func TestSimpleRunnerMaxBody(t *testing.T) {
+	body := strings.Repeat("lorem ipsum\n", 100000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			w.Write([]byte(body[:len(body)/2]))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(body[len(body)/2:]))
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	sum := sha256.Sum256([]byte(body))
+	config := &ffuf.Config{Context: context.Background(), Timeout: 10, APIMaxBody: 1000, AuditLog: "audit.json"}
+	runner := NewSimpleRunner(config, false)
+	for _, path := range []string{"/chunked", "/length"} {
+		resp, err := runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + path, Headers: map[string]string{}})
+		if err != nil {
+			t.Fatalf("Execute returned an error: %s", err)
+		}
+		if len(resp.Data) != 1000 || !resp.Truncated || resp.Cancelled {
+			t.Errorf("%s: expected the body to be truncated to 1000 bytes, got %d", path, len(resp.Data))
+		}
+		// The counts and the hash cover the whole body
+		if resp.ContentLength != int64(len(body)) || resp.ContentWords != 100001 || resp.ContentLines != 100001 || resp.BodyHash != hex.EncodeToString(sum[:]) {
+			t.Errorf("%s: unexpected length %d, words %d, lines %d and hash %s", path, resp.ContentLength, resp.ContentWords, resp.ContentLines, resp.BodyHash)
+		}
+		if !strings.HasSuffix(resp.Raw, "\r\n\r\n"+body[:1000]) {
+			t.Errorf("%s: expected the raw response to be truncated", path)
+		}
+	}
+
+	// Bodies within the limit are kept whole
+	config.APIMaxBody = 0
+	resp, err := runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + "/length", Headers: map[string]string{}})
+	if err != nil || string(resp.Data) != body || resp.Truncated {
+		t.Errorf("Expected the whole body without a limit, got %d bytes: %v", len(resp.Data), err)
+	}
+}
+
+func TestSimpleRunnerUnknownLength(t *testing.T) {
+	body := `{"users": [{"id": 1}, {"id": 2}, {"id": 3}]}`
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(body))
+	gw.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chunked":
+			// A slow chunked body
+			for i := 0; i < len(body); i += 8 {
+				w.Write([]byte(body[i:min(i+8, len(body))]))
+				w.(http.Flusher).Flush()
+				time.Sleep(300 * time.Millisecond)
+			}
+		case "/gzip":
+			// Bodies decompressed by the transport have no length
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(gz.Bytes())
+		case "/latin1":
+			w.Header().Set("Content-Type", "text/plain; charset=ISO-8859-1")
+			w.Write([]byte("caf\xe9"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer ts.Close()
+
+	// Without a stream window, bodies of unknown length are read as usual
+	runner := NewSimpleRunner(&ffuf.Config{Context: context.Background(), Timeout: 10, APIStreamEvents: 20}, false)
+	for _, path := range []string{"/chunked", "/gzip"} {
+		resp, err := runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + path, Headers: map[string]string{}})
+		if err != nil {
+			t.Fatalf("%s: Execute returned an error: %s", path, err)
+		}
+		if string(resp.Data) != body || resp.ContentLength != int64(len(body)) || resp.Truncated {
+			t.Errorf("%s: expected the whole body, got %q", path, resp.Data)
+		}
+	}
+
+	// With a stream window, they are cut by the window and marked as truncated
+	runner = NewSimpleRunner(&ffuf.Config{Context: context.Background(), Timeout: 10, APIStreamTime: 1, APIStreamEvents: 20}, false)
+	resp, err := runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + "/chunked", Headers: map[string]string{}})
+	if err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if len(resp.Data) == 0 || len(resp.Data) >= len(body) || !resp.Truncated {
+		t.Errorf("Expected the start of the body marked as truncated, got %q", resp.Data)
+	}
+	resp, err = runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + "/gzip", Headers: map[string]string{}})
+	if err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if string(resp.Data) != body || resp.Truncated {
+		t.Errorf("Expected the body read within the window not to be truncated, got %q", resp.Data)
+	}
+
+	// Their charset is converted
+	resp, err = runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + "/latin1", Headers: map[string]string{}})
+	if err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if string(resp.Data) != "café" || resp.Charset != "windows-1252" || !resp.Truncated {
+		t.Errorf("Expected the stream converted from its charset, got %q from %q", resp.Data, resp.Charset)
+	}
+}
+
+func TestSimpleRunnerContentEncoding(t *testing.T) {
+	body := `{"id": 1, "email": "alice@example.com"}`
+	encoded := map[string][]byte{}
+	var gz, zl bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(body))
+	gw.Close()
+	encoded["gzip"] = gz.Bytes()
+	zw := zlib.NewWriter(&zl)
+	zw.Write([]byte(body))
+	zw.Close()
+	encoded["deflate"] = zl.Bytes()
+	zstdWriter, _ := zstd.NewWriter(nil)
+	encoded["zstd"] = zstdWriter.EncodeAll([]byte(body), nil)
+	// A body labelled as gzip but sent as is
+	encoded["mislabelled"] = []byte(body)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.TrimPrefix(r.URL.Path, "/")
+		if encoding == "mislabelled" {
+			w.Header().Set("Content-Encoding", "gzip")
+		} else {
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		w.Write(encoded[encoding])
+	}))
+	defer ts.Close()
+
+	runner := NewSimpleRunner(&ffuf.Config{Context: context.Background(), Timeout: 10}, false)
+	for _, encoding := range []string{"gzip", "deflate", "zstd", "mislabelled"} {
+		resp, err := runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + "/" + encoding, Headers: map[string]string{"Accept-Encoding": "gzip, deflate"}})
+		if err != nil {
+			t.Fatalf("%s: Execute returned an error: %s", encoding, err)
+		}
+		if string(resp.Data) != body || resp.ContentLength != int64(len(body)) {
+			t.Errorf("%s: expected the decoded body, got %q", encoding, resp.Data)
+		}
+		if expected := strings.Replace(encoding, "mislabelled", "gzip", 1); resp.ContentEncoding != expected {
+			t.Errorf("%s: expected the %s encoding to be recorded, got %q", encoding, expected, resp.ContentEncoding)
+		}
+	}
+}
+
+func TestSimpleRunnerCharset(t *testing.T) {
+	tests := []struct {
+		path        string
+		contentType string
+		body        []byte
+		charset     string
+		expected    string
+	}{
+		{"/latin1", "text/plain; charset=ISO-8859-1", []byte("caf\xe9"), "windows-1252", "café"},
+		{"/sjis", "application/json; charset=Shift_JIS", []byte("{\"name\": \"\x83\x65\x83\x58\x83\x67\"}"), "shift_jis", `{"name": "テスト"}`},
+		{"/utf16bom", "application/json", []byte("\xff\xfe{\x00}\x00"), "utf-16le", "{}"},
+		{"/utf16", "application/json", []byte("\x00[\x00]"), "utf-16be", "[]"},
+		{"/xml", "application/xml", []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><a>\xe9</a>"), "windows-1252", "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><a>é</a>"},
+		{"/html", "text/html", []byte("<meta charset=\"shift_jis\"><p>\x83\x65</p>"), "shift_jis", "<meta charset=\"shift_jis\"><p>テ</p>"},
+		{"/utf8", "application/json; charset=utf-8", []byte(`{"name": "テスト"}`), "", `{"name": "テスト"}`},
+		// Bodies without a charset are read as is
+		{"/binary", "image/png", []byte("\x89PNG\xe9\x00"), "", "\x89PNG\xe9\x00"},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, test := range tests {
+			if test.path == r.URL.Path {
+				w.Header().Set("Content-Type", test.contentType)
+				w.Write(test.body)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	runner := NewSimpleRunner(&ffuf.Config{Context: context.Background(), Timeout: 10}, false)
+	for _, test := range tests {
+		resp, err := runner.Execute(&ffuf.Request{Method: "GET", Url: ts.URL + test.path, Headers: map[string]string{}})
+		if err != nil {
+			t.Fatalf("%s: Execute returned an error: %s", test.path, err)
+		}
+		if string(resp.Data) != test.expected || resp.Charset != test.charset {
+			t.Errorf("%s: expected %q from %q, got %q from %q", test.path, test.expected, test.charset, resp.Data, resp.Charset)
+		}
+		// The body is counted as received, for the filters to match the length sent
+		if resp.ContentLength != int64(len(test.body)) || resp.ContentWords != int64(bytes.Count(test.body, []byte(" "))+1) {
+			t.Errorf("%s: expected the length %d of the body as received, got %d", test.path, len(test.body), resp.ContentLength)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// sseEvent is an event of a Server-Sent Events stream, as written to the response data
+type sseEvent struct {
+	Event string          `json:"event"`
+	ID    string          `json:"id,omitempty"`
+	Retry string          `json:"retry,omitempty"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// isEventStream returns true if the content type is a Server-Sent Events stream
+func isEventStream(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), "text/event-stream")
+}
+
+// readEventStream reads the events of a Server-Sent Events stream until maxEvents events are
+// read, if maxEvents is positive, or the stream ends. It returns the events read, as one JSON
+// record per line, and the raw stream read. The error is nil if the stream ended normally.
+func readEventStream(r io.Reader, maxEvents int) ([]byte, []byte, error) {
+	var raw, records bytes.Buffer
+	reader := bufio.NewReader(io.TeeReader(r, &raw))
+	events := 0
+	current := sseEvent{}
+	var data strings.Builder
+
+	for maxEvents <= 0 || events < maxEvents {
+		line, err := reader.ReadString('\n')
+		if err != nil && (line == "" || err != io.EOF) {
+			if err == io.EOF {
+				err = nil
+			}
+			return records.Bytes(), raw.Bytes(), err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		// An empty line dispatches the event, events without data are dropped
+		if line == "" {
+			if data.Len() > 0 {
+				current.Data = eventData(strings.TrimSuffix(data.String(), "\n"))
+				if current.Event == "" {
+					current.Event = "message"
+				}
+				record, _ := json.Marshal(current)
+				if records.Len() > 0 {
+					records.WriteByte('\n')
+				}
+				records.Write(record)
+				events++
+			}
+			current = sseEvent{ID: current.ID}
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			current.Event = value
+		case "data":
+			data.WriteString(value + "\n")
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				current.ID = value
+			}
+		case "retry":
+			current.Retry = value
+		}
+	}
+	return records.Bytes(), raw.Bytes(), nil
+}
+
+// eventData returns the data of an event as JSON, embedding JSON data as is
+func eventData(data string) json.RawMessage {
+	if json.Valid([]byte(data)) {
+		return json.RawMessage(data)
+	}
+	encoded, _ := json.Marshal(data)
+	return encoded
+}
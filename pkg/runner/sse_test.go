@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadEventStream(t *testing.T) {
+	stream := strings.Join([]string{
+		": comment",
+		"retry: 5000",
+		"data: first",
+		"data:second line",
+		"",
+		"id: 7",
+		"event: price",
+		`data: {"symbol":"ABC","price":1.5}`,
+		"",
+		"event: empty",
+		"",
+		"data: last",
+		"",
+		"data: unterminated events are dropped",
+	}, "\r\n")
+
+	events, raw, err := readEventStream(strings.NewReader(stream), 0)
+	if err != nil {
+		t.Fatalf("readEventStream returned an error: %s", err)
+	}
+	expected := strings.Join([]string{
+		`{"event":"message","retry":"5000","data":"first\nsecond line"}`,
+		`{"event":"price","id":"7","data":{"symbol":"ABC","price":1.5}}`,
+		`{"event":"message","id":"7","data":"last"}`,
+	}, "\n")
+	if string(events) != expected {
+		t.Errorf("Unexpected events:\n%s", events)
+	}
+	if string(raw) != stream {
+		t.Errorf("Expected the raw stream to be returned")
+	}
+
+	events, _, _ = readEventStream(strings.NewReader(stream), 1)
+	if strings.Count(string(events), "\n") != 0 || !strings.Contains(string(events), "first") {
+		t.Errorf("Expected a single event, got %s", events)
+	}
+}
+
+func TestIsEventStream(t *testing.T) {
+	if !isEventStream("text/event-stream; charset=utf-8") || isEventStream("application/json") {
+		t.Errorf("Unexpected event stream detection")
+	}
+}
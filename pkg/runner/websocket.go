@@ -0,0 +1,418 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// WebSocket frame opcodes
+const (
+	wsText   = 0x1
+	wsBinary = 0x2
+	wsClose  = 0x8
+	wsPing   = 0x9
+	wsPong   = 0xa
+)
+
+// WebSocketRunner sends requests as WebSocket handshakes followed by a message.
+//
+// The headers of the request are sent in the handshake, overriding the default handshake
+// headers, and the body of the request is sent as a text message once the connection is
+// upgraded. The response contains the messages received in reply, separated by newlines,
+// or the body of the handshake response if the server did not switch protocols. The close
+// code and reason sent by the server are set in the Websocket-Close-Code and
+// Websocket-Close-Reason headers.
+type WebSocketRunner struct {
+	config    *ffuf.Config
+	tlsConfig *tls.Config
+	proxy     func(*http.Request) (*url.URL, error)
+	// MessageTimeout is how long to wait for the reply messages after sending the message
+	MessageTimeout time.Duration
+	// MaxMessages is the number of messages to read before closing the connection
+	MaxMessages int
+}
+
+// NewWebSocketRunner creates a runner for ws:// and wss:// URLs
+func NewWebSocketRunner(conf *ffuf.Config) *WebSocketRunner {
+	cert := []tls.Certificate{}
+	if conf.ClientCert != "" && conf.ClientKey != "" {
+		tmp, _ := tls.LoadX509KeyPair(conf.ClientCert, conf.ClientKey)
+		cert = []tls.Certificate{tmp}
+	}
+	timeout := 2 * time.Second
+	if conf.Timeout > 0 && time.Duration(conf.Timeout)*time.Second < timeout {
+		timeout = time.Duration(conf.Timeout) * time.Second
+	}
+	return &WebSocketRunner{
+		config: conf,
+		tlsConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         conf.SNI,
+			Certificates:       cert,
+		},
+		proxy:          ProxyFunc(conf, false),
+		MessageTimeout: timeout,
+		MaxMessages:    1,
+	}
+}
+
+func (r *WebSocketRunner) Prepare(input map[string][]byte, basereq *ffuf.Request) (ffuf.Request, error) {
+	req := ffuf.CopyRequest(basereq)
+
+	for keyword, inputitem := range input {
+		headers := make(map[string]string, len(req.Headers))
+		for h, v := range req.Headers {
+			var CanonicalHeader string = textproto.CanonicalMIMEHeaderKey(strings.ReplaceAll(h, keyword, string(inputitem)))
+			headers[CanonicalHeader] = strings.ReplaceAll(v, keyword, string(inputitem))
+		}
+		req.Headers = headers
+		req.Url = strings.ReplaceAll(req.Url, keyword, string(inputitem))
+		req.Data = bytes.ReplaceAll(req.Data, []byte(keyword), inputitem)
+	}
+
+	// WebSocket handshakes are always GET requests
+	req.Method = "GET"
+	req.Input = input
+	return req, nil
+}
+
+func (r *WebSocketRunner) Execute(req *ffuf.Request) (ffuf.Response, error) {
+	if err := enforce(r.config, req); err != nil {
+		return ffuf.Response{}, err
+	}
+	return send(r.config, req, r.execute)
+}
+
+// execute sends a request
+func (r *WebSocketRunner) execute(req *ffuf.Request) (ffuf.Response, error) {
+	ctx := requestContext(r.config, req)
+	httpreq, err := r.newRequest(ctx, req)
+	if err != nil {
+		return ffuf.Response{}, err
+	}
+	var handshake bytes.Buffer
+	if err := httpreq.Write(&handshake); err != nil {
+		return ffuf.Response{}, err
+	}
+
+	conn, err := r.dial(ctx, httpreq.URL)
+	if err != nil {
+		return ffuf.Response{}, err
+	}
+	defer conn.Close()
+	// Closing the connection aborts the handshake and the exchange of a cancelled request
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	timeout := time.Duration(r.config.Timeout) * time.Second
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	start := time.Now()
+	req.Timestamp = start
+	if _, err := conn.Write(handshake.Bytes()); err != nil {
+		return ffuf.Response{}, err
+	}
+	reader := bufio.NewReader(conn)
+	if _, err := reader.Peek(1); err != nil {
+		return ffuf.Response{}, err
+	}
+	firstByteTime := time.Since(start)
+	httpresp, err := http.ReadResponse(reader, httpreq)
+	if err != nil {
+		return ffuf.Response{}, err
+	}
+	defer httpresp.Body.Close()
+
+	resp := ffuf.NewResponse(httpresp, req)
+	var data []byte
+	if httpresp.StatusCode != http.StatusSwitchingProtocols {
+		data, err = io.ReadAll(io.LimitReader(httpresp.Body, MAX_DOWNLOAD_SIZE))
+		if err != nil {
+			return ffuf.Response{}, err
+		}
+	} else {
+		messages, closeCode, closeReason, replyTime, err := r.exchange(conn, reader, req.Data)
+		if err != nil {
+			return ffuf.Response{}, err
+		}
+		data = bytes.Join(messages, []byte("\n"))
+		if closeCode != "" {
+			resp.Headers["Websocket-Close-Code"] = []string{closeCode}
+			resp.Headers["Websocket-Close-Reason"] = []string{closeReason}
+		}
+		if replyTime > 0 {
+			firstByteTime = replyTime
+		}
+	}
+
+	if len(r.config.OutputDirectory) > 0 || len(r.config.AuditLog) > 0 {
+		req.Raw = handshake.String() + string(req.Data)
+		resp.Request.Raw = req.Raw
+		resp.Raw = fmt.Sprintf("%s %s\r\n\r\n%s", httpresp.Proto, httpresp.Status, data)
+	}
+	resp.Data = data
+	resp.ContentLength = int64(len(data))
+	resp.ContentWords = int64(len(strings.Split(string(data), " ")))
+	resp.ContentLines = int64(len(strings.Split(string(data), "\n")))
+	resp.Duration = firstByteTime
+	resp.Timestamp = start.Add(firstByteTime)
+	return resp, nil
+}
+
+func (r *WebSocketRunner) Dump(req *ffuf.Request) ([]byte, error) {
+	httpreq, err := r.newRequest(requestContext(r.config, req), req)
+	if err != nil {
+		return []byte{}, err
+	}
+	var dump bytes.Buffer
+	if err := httpreq.Write(&dump); err != nil {
+		return []byte{}, err
+	}
+	dump.Write(req.Data)
+	return dump.Bytes(), nil
+}
+
+// newRequest creates the handshake request of a WebSocket URL
+func (r *WebSocketRunner) newRequest(ctx context.Context, req *ffuf.Request) (*http.Request, error) {
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported WebSocket URL scheme: %s", u.Scheme)
+	}
+	httpreq, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	httpreq.Header.Set("Upgrade", "websocket")
+	httpreq.Header.Set("Connection", "Upgrade")
+	httpreq.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(nonce))
+	httpreq.Header.Set("Sec-WebSocket-Version", "13")
+
+	// set default User-Agent header if not present
+	if _, ok := req.Headers["User-Agent"]; !ok {
+		req.Headers["User-Agent"] = fmt.Sprintf("%s v%s", "Fuzz Faster U Fool", ffuf.Version())
+	}
+	if _, ok := req.Headers["Host"]; ok {
+		httpreq.Host = req.Headers["Host"]
+	}
+	req.Host = httpreq.Host
+	for k, v := range req.Headers {
+		httpreq.Header.Set(k, v)
+	}
+	return httpreq, nil
+}
+
+// dial opens the connection to the host of a handshake URL, over TLS for https URLs, through
+// the proxy of the config if set
+func (r *WebSocketRunner) dial(ctx context.Context, u *url.URL) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: time.Duration(r.config.Timeout) * time.Second}
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+	proxy, err := r.proxy(&http.Request{URL: u})
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialHost(ctx, r.config, dialer, proxy, host)
+	if err != nil || u.Scheme != "https" {
+		return conn, err
+	}
+
+	tlsConfig := r.tlsConfig.Clone()
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// exchange sends the message over an upgraded connection and reads the reply messages, until
+// MaxMessages messages are read, the server closes the connection or MessageTimeout elapses.
+// It returns the messages, the close code and reason, and the time to the first message.
+func (r *WebSocketRunner) exchange(conn net.Conn, reader *bufio.Reader, message []byte) ([][]byte, string, string, time.Duration, error) {
+	sent := time.Now()
+	if len(message) > 0 {
+		opcode := byte(wsText)
+		if !utf8.Valid(message) {
+			opcode = wsBinary
+		}
+		if err := writeWebSocketFrame(conn, opcode, message); err != nil {
+			return nil, "", "", 0, err
+		}
+	}
+	conn.SetReadDeadline(time.Now().Add(r.MessageTimeout))
+
+	messages := make([][]byte, 0)
+	var replyTime time.Duration
+	var fragments []byte
+	size := 0
+	closeCode, closeReason := "", ""
+	for r.MaxMessages <= 0 || len(messages) < r.MaxMessages {
+		fin, opcode, payload, err := readWebSocketFrame(reader)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, "", "", 0, err
+		}
+		switch opcode {
+		case wsPing:
+			writeWebSocketFrame(conn, wsPong, payload)
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			if len(payload) >= 2 {
+				closeCode = strconv.Itoa(int(binary.BigEndian.Uint16(payload)))
+				closeReason = string(payload[2:])
+			} else {
+				closeCode = "1005"
+			}
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			writeWebSocketFrame(conn, wsClose, payload)
+			return messages, closeCode, closeReason, replyTime, nil
+		}
+		if replyTime == 0 {
+			replyTime = time.Since(sent)
+		}
+		size += len(payload)
+		if size > MAX_DOWNLOAD_SIZE {
+			break
+		}
+		fragments = append(fragments, payload...)
+		if fin {
+			messages = append(messages, fragments)
+			fragments = nil
+		}
+	}
+
+	// Close the connection normally
+	closePayload := make([]byte, 2)
+	binary.BigEndian.PutUint16(closePayload, 1000)
+	writeWebSocketFrame(conn, wsClose, closePayload)
+	return messages, closeCode, closeReason, replyTime, nil
+}
+
+// writeWebSocketFrame writes a single masked frame, as sent by clients
+func writeWebSocketFrame(w io.Writer, opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(length))
+	default:
+		frame = append(frame, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(length))
+	}
+
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+// readWebSocketFrame reads a single frame, and returns its FIN bit, opcode and unmasked payload
+func readWebSocketFrame(reader *bufio.Reader) (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(reader, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(reader, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if length > MAX_DOWNLOAD_SIZE {
+		return false, 0, nil, fmt.Errorf("WebSocket frame of %d bytes exceeds the maximum size", length)
+	}
+
+	mask := make([]byte, 4)
+	if masked {
+		if _, err := io.ReadFull(reader, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
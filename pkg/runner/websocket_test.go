@@ -0,0 +1,251 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// websocketEchoHandler upgrades connections from the allowed origin and echoes the first
+// message twice, or closes the connection with a policy violation if the message is "close"
+func websocketEchoHandler(t *testing.T, origin string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != origin {
+			http.Error(w, "forbidden origin", http.StatusForbidden)
+			return
+		}
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || r.Header.Get("Sec-Websocket-Version") != "13" {
+			t.Errorf("Invalid WebSocket handshake: %v", r.Header)
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatalf("Hijack failed: %s", err)
+		}
+		defer conn.Close()
+
+		hash := sha1.Sum([]byte(r.Header.Get("Sec-Websocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(hash[:]))
+		rw.Flush()
+
+		_, opcode, message, err := readWebSocketFrame(rw.Reader)
+		if err != nil || opcode != wsText {
+			t.Errorf("Expected a text message, got opcode %d: %v", opcode, err)
+			return
+		}
+		if string(message) == "close" {
+			payload := make([]byte, 2)
+			binary.BigEndian.PutUint16(payload, 1008)
+			conn.Write(serverFrame(wsClose, append(payload, "policy"...)))
+			return
+		}
+		conn.Write(serverFrame(wsPing, nil))
+		conn.Write(serverFrame(wsText, message))
+		conn.Write(serverFrame(wsText, message))
+		readWebSocketFrame(rw.Reader)
+	}
+}
+
+// serverFrame creates an unmasked frame, as sent by servers
+func serverFrame(opcode byte, payload []byte) []byte {
+	return append([]byte{0x80 | opcode, byte(len(payload))}, payload...)
+}
+
+func TestWebSocketRunner(t *testing.T) {
+	server := httptest.NewServer(websocketEchoHandler(t, "https://app.example"))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	config := &ffuf.Config{Context: context.Background(), Timeout: 5}
+	r := NewWebSocketRunner(config)
+	r.MaxMessages = 2
+
+	basereq := ffuf.Request{
+		Url:     wsURL + "/socket",
+		Headers: map[string]string{"Origin": "FUZZ"},
+		Data:    []byte(`{"action":"subscribe","channel":"BODY"}`),
+	}
+	req, err := r.Prepare(map[string][]byte{"FUZZ": []byte("https://app.example"), "BODY": []byte("orders")}, &basereq)
+	if err != nil {
+		t.Fatalf("Prepare returned an error: %s", err)
+	}
+	if req.Method != "GET" {
+		t.Errorf("Expected a GET handshake, got %s", req.Method)
+	}
+	resp, err := r.Execute(&req)
+	if err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	expected := `{"action":"subscribe","channel":"orders"}`
+	if resp.StatusCode != 101 || string(resp.Data) != expected+"\n"+expected {
+		t.Errorf("Expected the echoed messages, got %d %q", resp.StatusCode, resp.Data)
+	}
+	if resp.ContentLines != 2 {
+		t.Errorf("Expected 2 lines, got %d", resp.ContentLines)
+	}
+
+	req, _ = r.Prepare(map[string][]byte{"FUZZ": []byte("https://app.example"), "BODY": []byte("x")}, &ffuf.Request{Url: wsURL, Headers: map[string]string{"Origin": "FUZZ"}, Data: []byte("close")})
+	resp, err = r.Execute(&req)
+	if err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if len(resp.Data) != 0 || resp.Headers["Websocket-Close-Code"][0] != "1008" || resp.Headers["Websocket-Close-Reason"][0] != "policy" {
+		t.Errorf("Expected a policy violation close, got %q %v", resp.Data, resp.Headers)
+	}
+
+	req, _ = r.Prepare(map[string][]byte{"FUZZ": []byte("https://evil.example")}, &basereq)
+	resp, err = r.Execute(&req)
+	if err != nil {
+		t.Fatalf("Execute returned an error: %s", err)
+	}
+	if resp.StatusCode != 403 || !strings.Contains(string(resp.Data), "forbidden origin") {
+		t.Errorf("Expected the handshake to be rejected, got %d %q", resp.StatusCode, resp.Data)
+	}
+
+	dump, err := r.Dump(&req)
+	if err != nil || !strings.Contains(string(dump), "Upgrade: websocket") || !strings.Contains(string(dump), "Origin: https://evil.example") {
+		t.Errorf("Unexpected dump: %s %v", dump, err)
+	}
+}
+
+func TestWebSocketRunnerProxy(t *testing.T) {
+	server := httptest.NewServer(websocketEchoHandler(t, "https://app.example"))
+	defer server.Close()
+	var tunnels int32
+	proxy := connectProxy(&tunnels)
+	defer proxy.Close()
+
+	socks := socks5Server(t, &tunnels)
+	defer socks.Close()
+
+	for _, proxyURL := range []string{proxy.URL, "socks5://" + socks.Addr().String()} {
+		atomic.StoreInt32(&tunnels, 0)
+		r := NewWebSocketRunner(&ffuf.Config{Context: context.Background(), Timeout: 5, ProxyURL: proxyURL})
+		req, _ := r.Prepare(map[string][]byte{}, &ffuf.Request{
+			Url:     "ws" + strings.TrimPrefix(server.URL, "http"),
+			Headers: map[string]string{"Origin": "https://app.example"},
+			Data:    []byte("hello"),
+		})
+		resp, err := r.Execute(&req)
+		if err != nil {
+			t.Fatalf("Execute through %s returned an error: %s", proxyURL, err)
+		}
+		if resp.StatusCode != 101 || string(resp.Data) != "hello" {
+			t.Errorf("Expected the echoed message through %s, got %d %q", proxyURL, resp.StatusCode, resp.Data)
+		}
+		if atomic.LoadInt32(&tunnels) != 1 {
+			t.Errorf("Expected the connection to go through %s, got %d tunnels", proxyURL, tunnels)
+		}
+	}
+
+	r := NewWebSocketRunner(&ffuf.Config{Context: context.Background(), Timeout: 5, ProxyURL: "https://127.0.0.1:8443"})
+	req, _ := r.Prepare(map[string][]byte{}, &ffuf.Request{Url: "ws" + strings.TrimPrefix(server.URL, "http")})
+	if _, err := r.Execute(&req); err == nil || !strings.Contains(err.Error(), "unsupported proxy scheme: https") {
+		t.Errorf("Expected an unsupported proxy scheme error, got %v", err)
+	}
+}
+
+// connectProxy starts an HTTP proxy tunneling CONNECT requests
+func connectProxy(tunnels *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "CONNECT" {
+			http.Error(w, "expected CONNECT", http.StatusMethodNotAllowed)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+		atomic.AddInt32(tunnels, 1)
+		conn, rw, _ := w.(http.Hijacker).Hijack()
+		defer conn.Close()
+		fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go io.Copy(upstream, rw)
+		io.Copy(conn, upstream)
+	}))
+}
+
+// socks5Server starts a SOCKS5 proxy accepting CONNECT requests without authentication
+func socks5Server(t *testing.T, tunnels *int32) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				// Greeting: version, methods, then the request: version, CONNECT, reserved, address
+				greeting := make([]byte, 2)
+				if _, err := io.ReadFull(reader, greeting); err != nil {
+					return
+				}
+				io.ReadFull(reader, make([]byte, greeting[1]))
+				conn.Write([]byte{5, 0})
+				request := make([]byte, 4)
+				if _, err := io.ReadFull(reader, request); err != nil || request[1] != 1 {
+					return
+				}
+				var host string
+				switch request[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(reader, ip)
+					host = net.IP(ip).String()
+				case 3:
+					length, _ := reader.ReadByte()
+					name := make([]byte, length)
+					io.ReadFull(reader, name)
+					host = string(name)
+				default:
+					return
+				}
+				port := make([]byte, 2)
+				io.ReadFull(reader, port)
+				upstream, err := net.Dial("tcp", net.JoinHostPort(host, fmt.Sprint(binary.BigEndian.Uint16(port))))
+				if err != nil {
+					conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer upstream.Close()
+				atomic.AddInt32(tunnels, 1)
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, reader)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return listener
+}
+
+func TestWebSocketFrames(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 70000} {
+		message := strings.Repeat("a", size)
+		var frame strings.Builder
+		if err := writeWebSocketFrame(&frame, wsBinary, []byte(message)); err != nil {
+			t.Fatalf("writeWebSocketFrame returned an error: %s", err)
+		}
+		fin, opcode, payload, err := readWebSocketFrame(bufio.NewReader(strings.NewReader(frame.String())))
+		if err != nil || !fin || opcode != wsBinary || string(payload) != message {
+			t.Errorf("Frame of %d bytes did not round trip: %v", size, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"sync"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/capture"
+	"github.com/ffuf/ffuf/v2/pkg/api/logging"
+	"github.com/ffuf/ffuf/v2/pkg/api/reporting"
+	"github.com/ffuf/ffuf/v2/pkg/api/secrets"
+	"github.com/ffuf/ffuf/v2/pkg/api/security"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+)
+
+// harLogger is the logger of the HAR capture of a run
+var harLogger = logging.For("har")
+
+// runLogOptions are the files the requests of a run are logged to, the redaction of the
+// logs and reports of the run, and the level, format and file of its log records
+type runLogOptions struct {
+	eventLog       string
+	har            string
+	harMaxBody     int
+	harMaxSize     int
+	redact         string
+	redactPatterns multiStringFlag
+	logLevel       string
+	logFormat      string
+	logFile        string
+}
+
+// apiRunLogOptions returns the -api-event-log, -api-har, -api-redact and -api-log options of a
+// fuzzing run
+func apiRunLogOptions(opts *ffuf.ConfigOptions) runLogOptions {
+	return runLogOptions{
+		eventLog:       opts.API.EventLog,
+		har:            opts.API.HARFile,
+		harMaxBody:     opts.API.HARMaxBody,
+		harMaxSize:     opts.API.HARMaxSize,
+		redact:         opts.API.Redact,
+		redactPatterns: opts.API.RedactPatterns,
+		logLevel:       opts.API.LogLevel,
+		logFormat:      opts.API.LogFormat,
+		logFile:        opts.API.LogFile,
+	}
+}
+
+// addHARFlags adds the flags of the HAR capture of the requests of a subcommand, named after
+// the flag of the file
+func (o *runLogOptions) addHARFlags(flags *flag.FlagSet, name string, subject string) {
+	flags.StringVar(&o.har, name, "", "Record the requests sent by "+subject+" and their responses to a HAR file")
+	flags.IntVar(&o.harMaxBody, name+"-max-body", capture.DefaultHARMaxBodySize, "Maximum size in bytes of the request and response bodies recorded by -"+name+", larger bodies are truncated. 0 for no limit")
+	flags.IntVar(&o.harMaxSize, name+"-max-size", 0, "Maximum size in megabytes of the -"+name+" file, further requests are not recorded. 0 for no limit")
+}
+
+// addRedactFlags adds the flags of the redaction of the logs and reports of a subcommand
+func (o *runLogOptions) addRedactFlags(flags *flag.FlagSet, subject string) {
+	flags.StringVar(&o.redact, "redact", "", "Comma separated list of the redaction rules masking tokens and personal data in "+subject+": "+strings.Join(secrets.RedactRules(), ", ")+", or all")
+	flags.Var(&o.redactPatterns, "redact-pattern", "Regular expression of data masked in "+subject+", its first capture group if it has groups. Multiple flags are accepted.")
+}
+
+// addLogFlags adds the flags of the log records of a subcommand
+func (o *runLogOptions) addLogFlags(flags *flag.FlagSet, subject string) {
+	flags.StringVar(&o.logLevel, "log-level", "", "Minimum level of the log records written to stderr: debug, info, warn or error. Default: info")
+	flags.StringVar(&o.logFormat, "log-format", "", "Format of the log records: text or json. Default: text")
+	flags.StringVar(&o.logFile, "log-file", "", "Append the log records of "+subject+" to a file, in addition to stderr")
+}
+
+// logging returns the logging options of the -log options
+func (o *runLogOptions) logging() logging.Options {
+	return logging.Options{Level: o.logLevel, Format: o.logFormat, File: o.logFile}
+}
+
+// redactor returns the redactor of the -redact options, or nil if they are not set
+func (o *runLogOptions) redactor() (*secrets.Redactor, error) {
+	return secrets.NewRedactor(strings.Split(o.redact, ","), o.redactPatterns)
+}
+
+// runLogs are the event log and the HAR capture of the requests of a run, shared by its jobs,
+// and the redactor of the logs and reports of the run. Each is nil if it is not set.
+type runLogs struct {
+	events   *reporting.EventLog
+	har      *capture.HARWriter
+	redactor *secrets.Redactor
+}
+
+// openRunLogs configures the log records, and opens the event log and the HAR capture of a
+// run. The first error writing to each is logged.
+func openRunLogs(opts runLogOptions) (*runLogs, error) {
+	logs := &runLogs{}
+	var err error
+	if logs.redactor, err = opts.redactor(); err != nil {
+		return nil, err
+	}
+	logOpts := opts.logging()
+	if logs.redactor != nil {
+		logOpts.Redact = logs.redactor.String
+	}
+	if err := logging.Configure(logOpts); err != nil {
+		return nil, err
+	}
+	logs.events, err = openEventLog(opts.eventLog)
+	if err != nil {
+		logging.Close()
+		return nil, err
+	}
+	if logs.events != nil {
+		logs.events.Redactor = logs.redactor
+	}
+	if opts.har != "" {
+		logs.har, err = capture.OpenHARWriter(opts.har)
+		if err != nil {
+			logs.close()
+			return nil, err
+		}
+		logs.har.MaxBodySize = opts.harMaxBody
+		logs.har.MaxSize = int64(opts.harMaxSize) * 1024 * 1024
+		logs.har.Redactor = logs.redactor
+		var once sync.Once
+		logs.har.OnError = func(err error) {
+			once.Do(func() { harLogger.Error("Could not write the HAR capture", logging.FieldError, err) })
+		}
+	}
+	return logs, nil
+}
+
+// start writes the run_started event of a run
+func (l *runLogs) start(command string, targets []string) {
+	if l.events != nil {
+		l.events.RunStarted(command, targets)
+	}
+}
+
+// finish writes the run_finished event of a run
+func (l *runLogs) finish(command string) {
+	if l.events != nil {
+		l.events.RunFinished(command)
+	}
+}
+
+// close closes the event log and the log file, and ends the HAR capture, logging the number
+// of requests it dropped
+func (l *runLogs) close() {
+	defer logging.Close()
+	if l.events != nil {
+		l.events.Close()
+	}
+	if l.har != nil {
+		if err := l.har.Close(); err != nil {
+			harLogger.Error("Could not write the HAR capture", logging.FieldError, err)
+		}
+		if written, dropped := l.har.Entries(); dropped > 0 {
+			harLogger.Warn("The HAR capture reached its maximum size", "recorded", written, "dropped", dropped)
+		}
+	}
+}
+
+// attach makes a job write its requests, results and covered endpoints to the event log. The
+// HAR capture is recorded by the runner of prepareJob.
+func (l *runLogs) attach(job *ffuf.Job) {
+	if l.events != nil {
+		attachEventLog(job, l.events)
+	}
+}
+
+// scanContext returns a context making the security scans it is passed to write their
+// requests to the event log and the HAR capture
+func (l *runLogs) scanContext(ctx context.Context) context.Context {
+	if l.events != nil {
+		ctx = security.WithRequestHandler(ctx, l.events.Request)
+	}
+	if l.har != nil {
+		ctx = security.WithRequestHandler(ctx, l.har.Record)
+	}
+	return ctx
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/ffuf/ffuf/v2/pkg/api/reporting"
+	"github.com/ffuf/ffuf/v2/pkg/ffuf"
+	"github.com/ffuf/ffuf/v2/pkg/runner"
+)
+
+// runTargets fuzzes every target of -api-targets, scanning -api-targets-parallel targets at
+// once. Each target has its own job, and so its own connection pool, rate limit, pacing,
+// coverage and output files, while -t bounds the requests in flight across all targets.
+// It returns the exit code of the run.
+func runTargets(opts *ffuf.ConfigOptions, ctx context.Context, cancel context.CancelFunc) int {
+	targets, err := readTargets(opts.API.Targets)
+	if err == nil && len(targets) == 0 {
+		err = fmt.Errorf("no targets found in %s", opts.API.Targets)
+	}
+	var logs *runLogs
+	if err == nil {
+		logs, err = openRunLogs(apiRunLogOptions(opts))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+	defer logs.close()
+	logs.start("fuzz", targets)
+	defer logs.finish("fuzz")
+	return fuzzTargets(opts, targets, ctx, cancel, logs)
+}
+
+// fuzzTargets fuzzes the targets with a job each, and returns the exit code of the run. The
+// jobs write to the run logs.
+func fuzzTargets(opts *ffuf.ConfigOptions, targets []string, ctx context.Context, cancel context.CancelFunc, logs *runLogs) int {
+	var err error
+	if opts.API.TargetsParallel < 1 {
+		err = fmt.Errorf("-api-targets-parallel must be at least 1")
+	}
+	for _, wordlist := range opts.Input.Wordlists {
+		if err == nil && strings.SplitN(wordlist, ":", 2)[0] == "-" {
+			err = fmt.Errorf("-api-targets cannot be combined with a wordlist read from stdin")
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encountered error(s): %s\n", err)
+		return 1
+	}
+
+	// Stop scanning further targets when interrupted, the jobs stop themselves
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	workers := runner.NewWorkerBudget(opts.General.Threads)
+	parallel := make(chan struct{}, opts.API.TargetsParallel)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	exitCode := 0
+	for i, target := range targets {
+		select {
+		case parallel <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		job, coverage, err := prepareTargetJob(opts, target, ctx, workers, logs)
+		if err != nil {
+			<-parallel
+			fmt.Fprintf(os.Stderr, "Encountered error(s) for %s: %s\n", target, err)
+			if i == 0 {
+				// The options are invalid for every target
+				return 1
+			}
+			mu.Lock()
+			exitCode = 1
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(target string, job *ffuf.Job, coverage *reporting.CoverageAnalyzer) {
+			defer wg.Done()
+			defer func() { <-parallel }()
+			if job.AuditLogger != nil {
+				defer job.AuditLogger.Close()
+			}
+			job.Start()
+			if coverage == nil && job.Config.Policy == nil && job.Config.SafeMode == nil {
+				return
+			}
+			// Summaries of targets finishing together are not interleaved
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintf(os.Stderr, "\n:: Target %s", target)
+			if job.Config.SafeMode != nil {
+				reportSafeMode(job.Config)
+			}
+			if job.Config.Policy != nil {
+				reportPolicy(job.Config)
+			}
+			if coverage != nil && !reportCoverage(job.Config, coverage) {
+				exitCode = 1
+			}
+		}(target, job, coverage)
+	}
+	wg.Wait()
+	return exitCode
+}
+
+// prepareTargetJob creates the job fuzzing a target with a copy of the options, and returns
+// it along with its coverage analyzer if -api-coverage is set. The requests of the job are
+// executed within the shared worker budget, and written to the shared run logs.
+func prepareTargetJob(opts *ffuf.ConfigOptions, target string, ctx context.Context, workers *runner.WorkerBudget, logs *runLogs) (*ffuf.Job, *reporting.CoverageAnalyzer, error) {
+	targetOpts := *opts
+	targetOpts.HTTP.URL = targetURL(target, opts.HTTP.URL)
+	label := targetLabel(target)
+	targetOpts.Output.OutputFile = targetFilename(opts.Output.OutputFile, label)
+	targetOpts.Output.AuditLog = targetFilename(opts.Output.AuditLog, label)
+	targetOpts.API.StateFile = targetFilename(opts.API.StateFile, label)
+	targetOpts.API.CoverageReport = targetFilename(opts.API.CoverageReport, label)
+	if opts.Output.OutputDirectory != "" {
+		targetOpts.Output.OutputDirectory = filepath.Join(opts.Output.OutputDirectory, label)
+	}
+	// Results of all targets are printed to the same terminal, so they are printed with their URL
+	targetOpts.General.Verbose = !opts.General.Json
+	targetOpts.General.Noninteractive = true
+
+	targetCtx, targetCancel := context.WithCancel(ctx)
+	conf, err := ffuf.ConfigFromOptions(&targetOpts, targetCtx, targetCancel)
+	if err != nil {
+		targetCancel()
+		return nil, nil, err
+	}
+	job, err := prepareJob(conf, logs)
+	if err == nil {
+		err = SetupFilters(&targetOpts, conf)
+	}
+	if err != nil {
+		if job.AuditLogger != nil {
+			job.AuditLogger.Close()
+		}
+		targetCancel()
+		return nil, nil, err
+	}
+
+	var coverage *reporting.CoverageAnalyzer
+	if r, ok := job.Runner.(*reporting.CoverageRunner); ok {
+		coverage = r.Analyzer
+	}
+	logs.attach(job)
+	job.Runner = runner.NewBudgetRunner(targetCtx, workers, job.Runner)
+	return job, coverage, nil
+}
+
+// readTargets reads the base URLs of the targets from a file, or from stdin if the path is -.
+// Empty lines, comments starting with # and duplicates are skipped.
+func readTargets(path string) ([]string, error) {
+	var reader io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	targets := make([]string, 0)
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		target := strings.TrimSpace(scanner.Text())
+		if target == "" || strings.HasPrefix(target, "#") {
+			continue
+		}
+		if !strings.Contains(target, "://") {
+			target = "https://" + target
+		}
+		target = strings.TrimSuffix(target, "/")
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets, scanner.Err()
+}
+
+// targetURL returns the URL fuzzed on a target: the path and query of the -u URL appended to
+// the base URL of the target, or the FUZZ keyword if -u is not set
+func targetURL(target, template string) string {
+	if template == "" {
+		template = "/FUZZ"
+	}
+	if i := strings.Index(template, "://"); i >= 0 {
+		template = template[i+3:]
+		if j := strings.IndexAny(template, "/?"); j >= 0 {
+			template = template[j:]
+		} else {
+			template = ""
+		}
+	}
+	if template != "" && !strings.HasPrefix(template, "/") && !strings.HasPrefix(template, "?") {
+		template = "/" + template
+	}
+	return strings.TrimSuffix(target, "/") + template
+}
+
+// targetLabel returns the name of a target in the names of its output files
+func targetLabel(target string) string {
+	label := target
+	if i := strings.Index(label, "://"); i >= 0 {
+		label = label[i+3:]
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == ':' || r == '\\' || r == '?' || r == '*' {
+			return '_'
+		}
+		return r
+	}, label)
+}
+
+// targetFilename inserts the label of a target before the extension of a file name, or
+// returns an empty name as is
+func targetFilename(name, label string) string {
+	if name == "" {
+		return ""
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + label + ext
+}